@@ -0,0 +1,72 @@
+package bbs
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Domain separation tag for audience bound headers
+const audienceDST = "BBS_BLS12381_AUDIENCE_"
+
+// An issuer restricts a credential to one audience class, such as the
+// verifiers of a single DID, by signing under AudienceHeader(header,
+// audience). The header is bound into the domain of the signature and of
+// every proof derived from it, so a verifier that rebuilds the header from
+// its own audience identifier rejects credentials meant for anyone else.
+// Nothing about the audience is disclosed beyond what the verifier already
+// supplies.
+
+// ErrInvalidAudience is returned for an audience identifier that cannot be
+// used, such as a malformed DID
+var ErrInvalidAudience = errors.New("invalid audience")
+
+// AudienceHeader returns header bound to audience:
+// DST || len(audience) || audience || header, with the length as 8 bytes
+// big-endian. An empty audience returns header unchanged.
+func AudienceHeader(header []byte, audience string) []byte {
+	if audience == "" {
+		return header
+	}
+
+	out := make([]byte, 0, len(audienceDST)+8+len(audience)+len(header))
+	out = append(out, audienceDST...)
+	out = binary.BigEndian.AppendUint64(out, uint64(len(audience)))
+	out = append(out, audience...)
+	return append(out, header...)
+}
+
+// DIDAudience returns the audience identifier of a verifier DID: the DID
+// without any path, query or fragment, so every DID URL of one verifier
+// gives the same audience. The method name must be lowercase, as DID syntax
+// requires; the method-specific identifier is kept as is.
+func DIDAudience(did string) (string, error) {
+	if i := strings.IndexAny(did, "/?#"); i >= 0 {
+		did = did[:i]
+	}
+
+	method, id, ok := strings.Cut(strings.TrimPrefix(did, "did:"), ":")
+	if !strings.HasPrefix(did, "did:") || !ok || method == "" || id == "" {
+		return "", fmt.Errorf("%w: '%s' is not a DID", ErrInvalidAudience, did)
+	}
+	for _, r := range method {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') {
+			return "", fmt.Errorf("%w: invalid DID method '%s'", ErrInvalidAudience, method)
+		}
+	}
+	if strings.HasSuffix(id, ":") {
+		return "", fmt.Errorf("%w: DID '%s' ends with a colon", ErrInvalidAudience, did)
+	}
+
+	return did, nil
+}
+
+// DIDAudienceHeader returns header bound to the audience of a verifier DID
+func DIDAudienceHeader(header []byte, did string) ([]byte, error) {
+	audience, err := DIDAudience(did)
+	if err != nil {
+		return nil, err
+	}
+	return AudienceHeader(header, audience), nil
+}
@@ -0,0 +1,80 @@
+package bbs
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"testing"
+)
+
+func TestAudienceHeader(t *testing.T) {
+	keyPair, err := GenerateKeyPair(3, rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key pair: %v", err)
+	}
+	messages := []*big.Int{big.NewInt(1), big.NewInt(2), big.NewInt(3)}
+	base := []byte("issuer/v1")
+
+	header, err := DIDAudienceHeader(base, "did:web:bank.example#key-1")
+	if err != nil {
+		t.Fatalf("DIDAudienceHeader failed: %v", err)
+	}
+	signature, err := Sign(keyPair.PrivateKey, keyPair.PublicKey, messages, header)
+	if err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+	proof, disclosed, err := CreateProof(keyPair.PublicKey, signature, messages, []int{0}, header)
+	if err != nil {
+		t.Fatalf("CreateProof failed: %v", err)
+	}
+
+	// The intended verifier rebuilds the same header from its own DID
+	own, err := DIDAudienceHeader(base, "did:web:bank.example")
+	if err != nil {
+		t.Fatalf("DIDAudienceHeader failed: %v", err)
+	}
+	if err := VerifyProof(keyPair.PublicKey, proof, disclosed, own); err != nil {
+		t.Fatalf("VerifyProof failed for the audience: %v", err)
+	}
+
+	// Any other verifier, or one that ignores the audience, rejects it
+	other, _ := DIDAudienceHeader(base, "did:web:shop.example")
+	if err := VerifyProof(keyPair.PublicKey, proof, disclosed, other); err == nil {
+		t.Fatalf("Expected verification to fail for another audience")
+	}
+	if err := VerifyProof(keyPair.PublicKey, proof, disclosed, base); err == nil {
+		t.Fatalf("Expected verification to fail without the audience")
+	}
+
+	// Audience and header cannot be shifted into each other
+	if bytes.Equal(AudienceHeader([]byte("bc"), "a"), AudienceHeader([]byte("c"), "ab")) {
+		t.Fatalf("Audience headers collide")
+	}
+	if !bytes.Equal(AudienceHeader(base, ""), base) {
+		t.Fatalf("Expected an empty audience to leave the header unchanged")
+	}
+}
+
+func TestDIDAudience(t *testing.T) {
+	valid := map[string]string{
+		"did:web:bank.example":                 "did:web:bank.example",
+		"did:web:bank.example:verifier/path?x": "did:web:bank.example:verifier",
+		"did:key:z6MkhaXgBZD#z6MkhaXgBZD":      "did:key:z6MkhaXgBZD",
+	}
+	for did, want := range valid {
+		got, err := DIDAudience(did)
+		if err != nil {
+			t.Fatalf("DIDAudience(%q) failed: %v", did, err)
+		}
+		if got != want {
+			t.Fatalf("DIDAudience(%q) = %q, expected %q", did, got, want)
+		}
+	}
+
+	for _, did := range []string{"", "web:bank.example", "did:web", "did::x", "did:Web:x", "did:web:", "did:web:x:"} {
+		if _, err := DIDAudience(did); !errors.Is(err, ErrInvalidAudience) {
+			t.Fatalf("Expected ErrInvalidAudience for %q, got %v", did, err)
+		}
+	}
+}
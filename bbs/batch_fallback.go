@@ -0,0 +1,194 @@
+package bbs
+
+import (
+	"fmt"
+	"math/big"
+	"time"
+)
+
+// A failed batch check says only that some proof in the batch is invalid.
+// VerifyAll finds which: it splits a failing range in half and batch-checks
+// the halves, down to single proofs, which are verified on their own so each
+// carries its own error. When the left half of a failing range passes, the
+// right half must hold the invalid proof and is split without checking it
+// first. With k invalid proofs among n this takes about 2k*log2(n) batch
+// checks, each smaller than the last, instead of n individual verifications.
+//
+// Freshness and replay are checked per proof once its signature is known to
+// be valid, so an invalid proof never registers with the replay guard and a
+// replayed proof does not fail the others.
+
+// BatchStats counts the work VerifyAll did
+type BatchStats struct {
+	// BatchChecks is the number of randomized batch checks, including the
+	// first one over the whole batch
+	BatchChecks int
+
+	// IndividualChecks is the number of proofs verified on their own
+	IndividualChecks int
+
+	// Pairings is the number of pairing terms computed, two per proof and
+	// check. Each check adds one final exponentiation.
+	Pairings int
+
+	// Duration is how long VerifyAll took
+	Duration time.Duration
+}
+
+// BatchResult is the per-proof outcome of VerifyAll
+type BatchResult struct {
+	// Errors holds the error of each proof, nil for those that verified
+	Errors []error
+
+	Stats BatchStats
+}
+
+// Valid reports whether every proof verified
+func (r *BatchResult) Valid() bool {
+	for _, err := range r.Errors {
+		if err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// Failed returns the indices of the proofs that did not verify, in order
+func (r *BatchResult) Failed() []int {
+	var failed []int
+	for i, err := range r.Errors {
+		if err != nil {
+			failed = append(failed, i)
+		}
+	}
+	return failed
+}
+
+// VerifyAll verifies a batch of proofs like BatchVerifyProofsWithOptions, and
+// if the batch fails, isolates the invalid proofs by bisection instead of
+// rejecting them all. The error is non-nil only for inconsistent inputs;
+// the verdict of each proof is in the result.
+func VerifyAll(
+	publicKeys []*PublicKey,
+	proofs []*ProofOfKnowledge,
+	disclosedMessagesList []map[int]*big.Int,
+	headers [][]byte,
+	opts VerifyOptions,
+) (*BatchResult, error) {
+	if len(publicKeys) != len(proofs) || len(proofs) != len(disclosedMessagesList) {
+		return nil, fmt.Errorf("mismatched array lengths in batch verification")
+	}
+
+	if len(headers) != 0 && len(headers) != len(proofs) {
+		return nil, fmt.Errorf("headers array length does not match proofs array length")
+	}
+
+	if len(opts.PresentationHeaders) != 0 && len(opts.PresentationHeaders) != len(proofs) {
+		return nil, fmt.Errorf("presentation headers array length does not match proofs array length")
+	}
+
+	started := time.Now()
+	b := &batchBisector{
+		publicKeys: publicKeys,
+		proofs:     proofs,
+		disclosed:  disclosedMessagesList,
+		headers:    headers,
+		opts:       opts,
+		result:     &BatchResult{Errors: make([]error, len(proofs))},
+	}
+
+	// The signatures first; freshness and replay only for valid proofs
+	b.opts.Freshness = nil
+	b.opts.ReplayGuard = nil
+	if len(proofs) > 0 && !b.check(0, len(proofs)) {
+		b.isolate(0, len(proofs))
+	}
+
+	var expiry time.Time
+	if opts.ReplayGuard != nil {
+		expiry = opts.replayExpiry()
+	}
+	for i, err := range b.result.Errors {
+		if err != nil {
+			continue
+		}
+		if opts.Freshness != nil {
+			if err := opts.Freshness.Check(disclosedMessagesList[i]); err != nil {
+				b.result.Errors[i] = err
+				continue
+			}
+		}
+		if opts.ReplayGuard != nil && opts.ReplayGuard.Seen(ProofHash(proofs[i]), expiry) {
+			b.result.Errors[i] = ErrProofReplayed
+		}
+	}
+
+	b.result.Stats.Duration = time.Since(started)
+	return b.result, nil
+}
+
+// batchBisector narrows a failing batch down to its invalid proofs
+type batchBisector struct {
+	publicKeys []*PublicKey
+	proofs     []*ProofOfKnowledge
+	disclosed  []map[int]*big.Int
+	headers    [][]byte
+	opts       VerifyOptions
+	result     *BatchResult
+}
+
+// check verifies proofs[start:end], as a batch if there is more than one, and
+// reports whether all of them are valid. A single proof's error is recorded.
+func (b *batchBisector) check(start, end int) bool {
+	stats := &b.result.Stats
+	stats.Pairings += 2 * (end - start)
+
+	if end-start == 1 {
+		stats.IndividualChecks++
+		err := VerifyProofWithPresentationHeader(
+			b.publicKeys[start], b.proofs[start], b.disclosed[start],
+			headerAt(b.headers, start), headerAt(b.opts.PresentationHeaders, start),
+		)
+		b.result.Errors[start] = err
+		return err == nil
+	}
+
+	stats.BatchChecks++
+	opts := b.opts
+	opts.PresentationHeaders = subrange(b.opts.PresentationHeaders, start, end)
+	err := BatchVerifyProofsWithOptions(
+		b.publicKeys[start:end], b.proofs[start:end], b.disclosed[start:end],
+		subrange(b.headers, start, end), opts,
+	)
+	return err == nil
+}
+
+// isolate records the error of every invalid proof in proofs[start:end],
+// which is known to hold at least one
+func (b *batchBisector) isolate(start, end int) {
+	if end-start == 1 {
+		// Only the error is missing if the range was checked as a batch
+		if b.result.Errors[start] == nil {
+			b.check(start, end)
+		}
+		return
+	}
+
+	mid := start + (end-start)/2
+	if b.check(start, mid) {
+		b.isolate(mid, end)
+		return
+	}
+	b.isolate(start, mid)
+	if !b.check(mid, end) {
+		b.isolate(mid, end)
+	}
+}
+
+// subrange returns s[start:end], or nil for an empty s
+func subrange(s [][]byte, start, end int) [][]byte {
+	if len(s) == 0 {
+		return nil
+	}
+	return s[start:end]
+}
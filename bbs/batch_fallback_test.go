@@ -0,0 +1,70 @@
+package bbs
+
+import (
+	"errors"
+	"math/big"
+	"slices"
+	"testing"
+)
+
+func TestVerifyAll(t *testing.T) {
+	publicKeys, proofs, disclosed, headers := batchFixture(t, 16)
+
+	// A valid batch takes one batch check
+	result, err := VerifyAll(publicKeys, proofs, disclosed, headers, VerifyOptions{})
+	if err != nil {
+		t.Fatalf("VerifyAll failed: %v", err)
+	}
+	if !result.Valid() || result.Stats.BatchChecks != 1 || result.Stats.IndividualChecks != 0 {
+		t.Fatalf("Expected one passing batch check, got %+v", result.Stats)
+	}
+
+	// Invalid proofs are isolated and the rest still verify
+	forged := slices.Clone(disclosed)
+	forged[3] = map[int]*big.Int{0: big.NewInt(999), 3: disclosed[3][3]}
+	tampered := *proofs[12]
+	tampered.ABar.Add(&tampered.ABar, &publicKeys[12].g1)
+	swapped := slices.Clone(proofs)
+	swapped[12] = &tampered
+
+	result, err = VerifyAll(publicKeys, swapped, forged, headers, VerifyOptions{Concurrency: 2})
+	if err != nil {
+		t.Fatalf("VerifyAll failed: %v", err)
+	}
+	if got := result.Failed(); !slices.Equal(got, []int{3, 12}) {
+		t.Fatalf("Expected proofs 3 and 12 to fail, got %v", got)
+	}
+	for _, i := range result.Failed() {
+		if !errors.Is(result.Errors[i], ErrInvalidSignature) {
+			t.Fatalf("Proof %d: expected ErrInvalidSignature, got %v", i, result.Errors[i])
+		}
+	}
+	if stats := result.Stats; stats.IndividualChecks >= len(proofs) || stats.Pairings == 0 {
+		t.Fatalf("Bisection did no better than verifying each proof: %+v", stats)
+	}
+
+	// Replayed proofs fail on their own, and invalid ones never register
+	guard := NewLRUReplayGuard(64)
+	result, err = VerifyAll(publicKeys, swapped, forged, headers, VerifyOptions{ReplayGuard: guard})
+	if err != nil {
+		t.Fatalf("VerifyAll failed: %v", err)
+	}
+	result, err = VerifyAll(publicKeys, proofs, disclosed, headers, VerifyOptions{ReplayGuard: guard})
+	if err != nil {
+		t.Fatalf("VerifyAll failed: %v", err)
+	}
+	for i, err := range result.Errors {
+		wantReplayed := i != 3 && i != 12
+		if errors.Is(err, ErrProofReplayed) != wantReplayed {
+			t.Fatalf("Proof %d: replayed %v, got %v", i, wantReplayed, err)
+		}
+	}
+
+	// Inconsistent inputs are an error rather than a verdict
+	if _, err := VerifyAll(publicKeys, proofs[1:], disclosed, headers, VerifyOptions{}); err == nil {
+		t.Fatalf("Expected mismatched lengths to fail")
+	}
+	if result, err := VerifyAll(nil, nil, nil, nil, VerifyOptions{}); err != nil || !result.Valid() {
+		t.Fatalf("Expected an empty batch to verify, got %v", err)
+	}
+}
@@ -0,0 +1,96 @@
+package bbs
+
+import (
+	"iter"
+	"math/big"
+)
+
+// DefaultStreamBatchSize is the number of proofs VerifyEach checks together
+// when no batch size is given
+const DefaultStreamBatchSize = 256
+
+// BatchItem is one proof of a stream verified by VerifyEach
+type BatchItem struct {
+	PublicKey          *PublicKey
+	Proof              *ProofOfKnowledge
+	DisclosedMessages  map[int]*big.Int
+	Header             []byte
+	PresentationHeader []byte
+}
+
+// Results yields the index and error of every proof in order, nil for those
+// that verified
+func (r *BatchResult) Results() iter.Seq2[int, error] {
+	return func(yield func(int, error) bool) {
+		for i, err := range r.Errors {
+			if !yield(i, err) {
+				return
+			}
+		}
+	}
+}
+
+// VerifyEach verifies a stream of proofs too large to hold at once. It reads
+// batchSize items at a time, or DefaultStreamBatchSize if batchSize is not
+// positive, checks them with VerifyAll and yields the position of each item
+// in the stream with its verdict, nil for a valid proof. Only one batch is in
+// memory at a time, and items are not read ahead of the batch being
+// verified. The presentation headers come from the items, so
+// opts.PresentationHeaders is ignored.
+func VerifyEach(items iter.Seq[BatchItem], batchSize int, opts VerifyOptions) iter.Seq2[int, error] {
+	if batchSize <= 0 {
+		batchSize = DefaultStreamBatchSize
+	}
+
+	return func(yield func(int, error) bool) {
+		var (
+			publicKeys          = make([]*PublicKey, 0, batchSize)
+			proofs              = make([]*ProofOfKnowledge, 0, batchSize)
+			disclosed           = make([]map[int]*big.Int, 0, batchSize)
+			headers             = make([][]byte, 0, batchSize)
+			presentationHeaders = make([][]byte, 0, batchSize)
+			offset              int
+		)
+
+		// flush verifies the pending batch and reports whether to go on
+		flush := func() bool {
+			if len(proofs) == 0 {
+				return true
+			}
+			batchOpts := opts
+			batchOpts.PresentationHeaders = presentationHeaders
+			result, err := VerifyAll(publicKeys, proofs, disclosed, headers, batchOpts)
+			for i := range proofs {
+				verdict := err
+				if result != nil {
+					verdict = result.Errors[i]
+				}
+				if !yield(offset+i, verdict) {
+					return false
+				}
+			}
+
+			offset += len(proofs)
+			clear(publicKeys)
+			clear(proofs)
+			clear(disclosed)
+			clear(headers)
+			clear(presentationHeaders)
+			publicKeys, proofs, disclosed = publicKeys[:0], proofs[:0], disclosed[:0]
+			headers, presentationHeaders = headers[:0], presentationHeaders[:0]
+			return true
+		}
+
+		for item := range items {
+			publicKeys = append(publicKeys, item.PublicKey)
+			proofs = append(proofs, item.Proof)
+			disclosed = append(disclosed, item.DisclosedMessages)
+			headers = append(headers, item.Header)
+			presentationHeaders = append(presentationHeaders, item.PresentationHeader)
+			if len(proofs) == batchSize && !flush() {
+				return
+			}
+		}
+		flush()
+	}
+}
@@ -0,0 +1,69 @@
+package bbs
+
+import (
+	"errors"
+	"math/big"
+	"slices"
+	"testing"
+)
+
+func TestVerifyEach(t *testing.T) {
+	publicKeys, proofs, disclosed, headers := batchFixture(t, 10)
+	disclosed[7] = map[int]*big.Int{0: big.NewInt(999), 3: disclosed[7][3]}
+
+	read := 0
+	items := func(yield func(BatchItem) bool) {
+		for i := range proofs {
+			read++
+			if !yield(BatchItem{
+				PublicKey:         publicKeys[i],
+				Proof:             proofs[i],
+				DisclosedMessages: disclosed[i],
+				Header:            headerAt(headers, i),
+			}) {
+				return
+			}
+		}
+	}
+
+	// Batches of four cover the stream with one short batch at the end
+	var seen []int
+	for i, err := range VerifyEach(items, 4, VerifyOptions{}) {
+		seen = append(seen, i)
+		if (i == 7) != (err != nil) {
+			t.Fatalf("Proof %d: unexpected verdict %v", i, err)
+		}
+		if i == 7 && !errors.Is(err, ErrInvalidSignature) {
+			t.Fatalf("Proof 7: expected ErrInvalidSignature, got %v", err)
+		}
+	}
+	if !slices.Equal(seen, []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}) {
+		t.Fatalf("Unexpected indices %v", seen)
+	}
+
+	// Stopping early reads no further than the batch being verified
+	read = 0
+	for i := range VerifyEach(items, 4, VerifyOptions{}) {
+		if i == 1 {
+			break
+		}
+	}
+	if read != 4 {
+		t.Fatalf("Read %d items before stopping, want 4", read)
+	}
+
+	// The per-proof results of VerifyAll iterate in order
+	result, err := VerifyAll(publicKeys, proofs, disclosed, headers, VerifyOptions{})
+	if err != nil {
+		t.Fatalf("VerifyAll failed: %v", err)
+	}
+	var failed []int
+	for i, err := range result.Results() {
+		if err != nil {
+			failed = append(failed, i)
+		}
+	}
+	if !slices.Equal(failed, result.Failed()) {
+		t.Fatalf("Results disagrees with Failed: %v, %v", failed, result.Failed())
+	}
+}
@@ -0,0 +1,253 @@
+package bbs
+
+import (
+	"fmt"
+	"math/big"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381"
+)
+
+// Batch chunking parameters. Each worker aims for batchChunksPerWorker chunks
+// so uneven proofs still balance across workers, while the chunk size cap
+// keeps very large batches from turning into a few long-running chunks.
+const (
+	batchChunksPerWorker = 4
+	maxBatchChunkSize    = 64
+)
+
+// VerifyOptions tunes proof verification
+type VerifyOptions struct {
+	// Concurrency is the number of workers checking proofs in parallel.
+	// Zero or negative uses runtime.GOMAXPROCS(0).
+	Concurrency int
+
+	// ChunkSize is the number of proofs a worker takes at a time. Zero or
+	// negative sizes chunks from the batch length and concurrency.
+	ChunkSize int
+
+	// ReplayGuard, if set, registers every verified proof and rejects
+	// proofs it has seen before with ErrProofReplayed.
+	ReplayGuard ReplayGuard
+
+	// ReplayWindow is how long a proof stays registered. Zero or negative
+	// uses DefaultReplayWindow.
+	ReplayWindow time.Duration
+
+	// Freshness, if set, is checked against the disclosed messages of every
+	// proof.
+	Freshness *FreshnessPolicy
+
+	// PresentationHeaders, if set, holds the presentation header of each
+	// proof, see CreateProofWithPresentationHeader
+	PresentationHeaders [][]byte
+}
+
+// workers returns the effective worker count for a batch of numChunks chunks
+func (o VerifyOptions) workers(numChunks int) int {
+	workers := o.Concurrency
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	if workers > numChunks {
+		workers = numChunks
+	}
+	return workers
+}
+
+// chunkSize returns the effective chunk size for a batch of n proofs
+func (o VerifyOptions) chunkSize(n int) int {
+	if o.ChunkSize > 0 {
+		return o.ChunkSize
+	}
+
+	workers := o.Concurrency
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+
+	size := (n + workers*batchChunksPerWorker - 1) / (workers * batchChunksPerWorker)
+	if size < 1 {
+		size = 1
+	}
+	if size > maxBatchChunkSize {
+		size = maxBatchChunkSize
+	}
+	return size
+}
+
+// BatchVerifyProofs verifies multiple proofs of knowledge with selective
+// disclosure in batch, using as many workers as runtime.GOMAXPROCS(0)
+func BatchVerifyProofs(
+	publicKeys []*PublicKey,
+	proofs []*ProofOfKnowledge,
+	disclosedMessagesList []map[int]*big.Int,
+	headers [][]byte,
+) error {
+	return BatchVerifyProofsWithOptions(publicKeys, proofs, disclosedMessagesList, headers, VerifyOptions{})
+}
+
+// BatchVerifyProofsWithOptions verifies multiple proofs in batch.
+//
+// Proofs are split into chunks handed out to a pool of workers. Each worker
+// checks the challenges of its chunk and runs the Miller loop over the
+// chunk's randomized pairing terms; the partial results are multiplied and a
+// single final exponentiation decides the whole batch. Work therefore scales
+// with the number of workers up to the number of chunks, and the only serial
+// step is one final exponentiation per batch.
+func BatchVerifyProofsWithOptions(
+	publicKeys []*PublicKey,
+	proofs []*ProofOfKnowledge,
+	disclosedMessagesList []map[int]*big.Int,
+	headers [][]byte,
+	opts VerifyOptions,
+) error {
+	// Validate inputs
+	if len(publicKeys) != len(proofs) || len(proofs) != len(disclosedMessagesList) {
+		return fmt.Errorf("mismatched array lengths in batch verification")
+	}
+
+	if len(headers) != 0 && len(headers) != len(proofs) {
+		return fmt.Errorf("headers array length does not match proofs array length")
+	}
+
+	if len(opts.PresentationHeaders) != 0 && len(opts.PresentationHeaders) != len(proofs) {
+		return fmt.Errorf("presentation headers array length does not match proofs array length")
+	}
+
+	if len(proofs) == 0 {
+		return nil
+	}
+
+	// If there's only one proof, use the regular verification
+	if len(proofs) == 1 {
+		return VerifyProofWithOptions(publicKeys[0], proofs[0], disclosedMessagesList[0], headerAt(headers, 0), opts)
+	}
+
+	chunkSize := opts.chunkSize(len(proofs))
+	numChunks := (len(proofs) + chunkSize - 1) / chunkSize
+	workers := opts.workers(numChunks)
+
+	partials := make([]bls12381.GT, numChunks)
+
+	var (
+		next     atomic.Int64
+		failed   atomic.Bool
+		errOnce  sync.Once
+		firstErr error
+		wg       sync.WaitGroup
+	)
+
+	fail := func(err error) {
+		errOnce.Do(func() {
+			firstErr = err
+		})
+		failed.Store(true)
+	}
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for !failed.Load() {
+				chunk := int(next.Add(1) - 1)
+				if chunk >= numChunks {
+					return
+				}
+
+				start := chunk * chunkSize
+				end := start + chunkSize
+				if end > len(proofs) {
+					end = len(proofs)
+				}
+
+				partial, err := verifyProofChunk(publicKeys, proofs, disclosedMessagesList, headers, opts.PresentationHeaders, start, end)
+				if err != nil {
+					fail(err)
+					return
+				}
+				partials[chunk] = partial
+			}
+		}()
+	}
+
+	// Wait for all workers to complete
+	wg.Wait()
+
+	if firstErr != nil {
+		return firstErr
+	}
+
+	// Combine the Miller loop outputs and finish with one exponentiation
+	product := partials[0]
+	for i := 1; i < len(partials); i++ {
+		product.Mul(&product, &partials[i])
+	}
+
+	result := bls12381.FinalExponentiation(&product)
+	if !result.IsOne() {
+		return ErrInvalidSignature
+	}
+
+	if err := opts.checkFreshness(disclosedMessagesList); err != nil {
+		return err
+	}
+
+	return opts.CheckReplay(proofs)
+}
+
+// verifyProofChunk checks the challenges of proofs[start:end] and returns the
+// Miller loop over their randomized pairing terms. Each proof contributes
+// e(A' * r, W) * e(A-bar * r, -g2) for a random r, so that a single invalid
+// proof cannot be cancelled out by another one.
+func verifyProofChunk(
+	publicKeys []*PublicKey,
+	proofs []*ProofOfKnowledge,
+	disclosedMessagesList []map[int]*big.Int,
+	headers [][]byte,
+	presentationHeaders [][]byte,
+	start, end int,
+) (bls12381.GT, error) {
+	g1Points := make([]bls12381.G1Affine, 0, (end-start)*2)
+	g2Points := make([]bls12381.G2Affine, 0, (end-start)*2)
+
+	for idx := start; idx < end; idx++ {
+		publicKey := publicKeys[idx]
+		proof := proofs[idx]
+
+		domain := CalculateDomain(publicKey, headerAt(headers, idx))
+
+		// Check the Schnorr part of the proof
+		if err := checkProofChallenge(publicKey, proof, disclosedMessagesList[idx], domain, headerAt(presentationHeaders, idx)); err != nil {
+			return bls12381.GT{}, fmt.Errorf("challenge verification failed for proof %d: %w", idx, err)
+		}
+
+		// Generate a cryptographically strong random scalar for this proof
+		batchScalar, err := randomNonZeroScalar()
+		if err != nil {
+			return bls12381.GT{}, fmt.Errorf("failed to generate batch scalars: %w", err)
+		}
+
+		var aPrime, aBar bls12381.G1Affine
+		aPrime.ScalarMultiplication(&proof.APrime, batchScalar)
+		aBar.ScalarMultiplication(&proof.ABar, batchScalar)
+
+		// Negate g2 for the second pairing component
+		var negG2 bls12381.G2Affine
+		negG2.Neg(&publicKey.g2)
+
+		g1Points = append(g1Points, aPrime, aBar)
+		g2Points = append(g2Points, publicKey.w, negG2)
+	}
+
+	partial, err := bls12381.MillerLoop(g1Points, g2Points)
+	if err != nil {
+		return bls12381.GT{}, ErrPairingFailed
+	}
+
+	return partial, nil
+}
@@ -0,0 +1,135 @@
+package bbs
+
+import (
+	"fmt"
+	"math/big"
+	"runtime"
+	"testing"
+)
+
+// batchFixture derives n proofs over credentials from a single issuer key
+func batchFixture(tb testing.TB, n int) ([]*PublicKey, []*ProofOfKnowledge, []map[int]*big.Int, [][]byte) {
+	tb.Helper()
+
+	keyPair := newTestKeyPair(tb, 5)
+
+	publicKeys := make([]*PublicKey, n)
+	proofs := make([]*ProofOfKnowledge, n)
+	disclosed := make([]map[int]*big.Int, n)
+	headers := make([][]byte, n)
+
+	for i := 0; i < n; i++ {
+		messages := make([]*big.Int, 5)
+		for j := range messages {
+			messages[j] = big.NewInt(int64(i*10 + j))
+		}
+		headers[i] = []byte(fmt.Sprintf("header %d", i))
+
+		signature := signFixture(tb, keyPair, messages, headers[i])
+
+		var err error
+		proofs[i], disclosed[i], err = CreateProof(keyPair.PublicKey, signature, messages, []int{0, 3}, headers[i])
+		if err != nil {
+			tb.Fatalf("CreateProof failed: %v", err)
+		}
+		publicKeys[i] = keyPair.PublicKey
+	}
+
+	return publicKeys, proofs, disclosed, headers
+}
+
+func TestBatchVerifyOptionsSizing(t *testing.T) {
+	// Defaults follow GOMAXPROCS
+	procs := runtime.GOMAXPROCS(0)
+	if got := (VerifyOptions{}).workers(1 << 20); got != procs {
+		t.Fatalf("Expected %d workers, got %d", procs, got)
+	}
+
+	// Never more workers than chunks
+	if got := (VerifyOptions{Concurrency: 32}).workers(3); got != 3 {
+		t.Fatalf("Expected 3 workers, got %d", got)
+	}
+
+	tests := []struct {
+		opts VerifyOptions
+		n    int
+		want int
+	}{
+		{VerifyOptions{Concurrency: 4}, 2, 1},
+		{VerifyOptions{Concurrency: 4}, 160, 10},
+		{VerifyOptions{Concurrency: 4}, 100000, maxBatchChunkSize},
+		{VerifyOptions{Concurrency: 32}, 1000, 8},
+		{VerifyOptions{Concurrency: 4, ChunkSize: 7}, 100000, 7},
+	}
+
+	for _, tt := range tests {
+		if got := tt.opts.chunkSize(tt.n); got != tt.want {
+			t.Errorf("chunkSize(%d) with %+v = %d, want %d", tt.n, tt.opts, got, tt.want)
+		}
+	}
+}
+
+func TestBatchVerifyProofsWithOptions(t *testing.T) {
+	publicKeys, proofs, disclosed, headers := batchFixture(t, 12)
+
+	options := []VerifyOptions{
+		{},
+		{Concurrency: 1},
+		{Concurrency: 3},
+		{Concurrency: 16},
+		{Concurrency: 2, ChunkSize: 1},
+		{Concurrency: 4, ChunkSize: 5},
+		{ChunkSize: 100},
+	}
+
+	for _, opts := range options {
+		if err := BatchVerifyProofsWithOptions(publicKeys, proofs, disclosed, headers, opts); err != nil {
+			t.Fatalf("Batch verification with %+v failed: %v", opts, err)
+		}
+	}
+
+	// An invalid proof is caught wherever it lands in the chunking
+	for _, bad := range []int{0, 5, 11} {
+		forged := make([]map[int]*big.Int, len(disclosed))
+		copy(forged, disclosed)
+		forged[bad] = map[int]*big.Int{0: big.NewInt(999), 3: disclosed[bad][3]}
+
+		for _, opts := range options {
+			if err := BatchVerifyProofsWithOptions(publicKeys, proofs, forged, headers, opts); err == nil {
+				t.Fatalf("Batch with forged proof %d verified with %+v", bad, opts)
+			}
+		}
+	}
+
+	// A proof whose pairing fails must also be caught
+	tampered := *proofs[7]
+	tampered.ABar.Add(&tampered.ABar, &publicKeys[7].g1)
+	swapped := make([]*ProofOfKnowledge, len(proofs))
+	copy(swapped, proofs)
+	swapped[7] = &tampered
+
+	for _, opts := range options {
+		if err := BatchVerifyProofsWithOptions(publicKeys, swapped, disclosed, headers, opts); err == nil {
+			t.Fatalf("Batch with tampered proof verified with %+v", opts)
+		}
+	}
+}
+
+// BenchmarkBatchVerifyProofs measures batch verification scaling. Run with
+// -cpu to compare worker counts, e.g.
+//
+//	go test ./bbs -run '^$' -bench BatchVerifyProofs -cpu 1,4,8,32
+func BenchmarkBatchVerifyProofs(b *testing.B) {
+	for _, n := range []int{16, 256} {
+		publicKeys, proofs, disclosed, headers := batchFixture(b, n)
+
+		b.Run(fmt.Sprintf("proofs=%d", n), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if err := BatchVerifyProofs(publicKeys, proofs, disclosed, headers); err != nil {
+					b.Fatalf("BatchVerifyProofs failed: %v", err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package bbstest
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"math/big"
+	"math/rand/v2"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/anupsv/bbsplus-signatures/bbs"
+)
+
+// CiphersuiteID identifies the test-only ciphersuite
+const CiphersuiteID = "BBS_BLS12381G1_XMD:SHA-256_SSWU_RO_TEST_ONLY_"
+
+// Ciphersuite maps the messages of test credentials. Its ID differs from
+// every real suite, so a test message never equals a production one.
+var Ciphersuite = &bbs.Ciphersuite{
+	ID:            CiphersuiteID,
+	ExpandMessage: bbs.ExpandMessageXMD,
+}
+
+// Header is the signature header of test credentials
+var Header = []byte("bbstest")
+
+// keys caches the key pair of each message count
+var keys sync.Map // int -> *bbs.KeyPair
+
+// KeyPair returns the test key pair for messageCount messages. The same
+// count gives the same key in every process.
+func KeyPair(tb testing.TB, messageCount int) *bbs.KeyPair {
+	tb.Helper()
+
+	if keyPair, ok := keys.Load(messageCount); ok {
+		return keyPair.(*bbs.KeyPair)
+	}
+
+	seed := sha256.Sum256(binary.BigEndian.AppendUint32([]byte(CiphersuiteID+"KEY_"), uint32(messageCount)))
+	keyPair, err := bbs.GenerateKeyPair(messageCount, rand.NewChaCha8(seed))
+	if err != nil {
+		tb.Fatalf("GenerateKeyPair failed: %v", err)
+	}
+	actual, _ := keys.LoadOrStore(messageCount, keyPair)
+	return actual.(*bbs.KeyPair)
+}
+
+// Attribute is one named attribute of a test credential
+type Attribute struct {
+	Name  string
+	Value string
+}
+
+// Credential is a signed test credential
+type Credential struct {
+	KeyPair   *bbs.KeyPair
+	Names     []string
+	Messages  []*big.Int
+	Signature *bbs.Signature
+}
+
+// NewCredential signs attrs, in order, under the test key for their count.
+// Signatures are deterministic.
+func NewCredential(tb testing.TB, attrs ...Attribute) *Credential {
+	tb.Helper()
+
+	keyPair := KeyPair(tb, len(attrs))
+	cred := &Credential{KeyPair: keyPair}
+	for _, attr := range attrs {
+		cred.Names = append(cred.Names, attr.Name)
+		cred.Messages = append(cred.Messages, Ciphersuite.MapMessageToScalar([]byte(attr.Value)))
+	}
+
+	signature, err := bbs.DeterministicSign(keyPair.PrivateKey, keyPair.PublicKey, cred.Messages, Header, nil)
+	if err != nil {
+		tb.Fatalf("DeterministicSign failed: %v", err)
+	}
+	cred.Signature = signature
+	return cred
+}
+
+// Message returns the message of the named attribute
+func (c *Credential) Message(tb testing.TB, name string) *big.Int {
+	tb.Helper()
+
+	idx := slices.Index(c.Names, name)
+	if idx < 0 {
+		tb.Fatalf("credential has no attribute '%s'", name)
+	}
+	return c.Messages[idx]
+}
+
+// Presentation is a proof created by Present and what it was created for
+type Presentation struct {
+	PublicKey *bbs.PublicKey
+	Proof     *bbs.ProofOfKnowledge
+	Disclosed map[int]*big.Int
+	Nonce     []byte
+
+	// Names are the attribute names of the credential, by message index
+	Names []string
+}
+
+// Present proves the credential, disclosing the named attributes and using
+// nonce as the presentation header
+func (c *Credential) Present(tb testing.TB, nonce []byte, names ...string) *Presentation {
+	tb.Helper()
+
+	indices := make([]int, 0, len(names))
+	for _, name := range names {
+		idx := slices.Index(c.Names, name)
+		if idx < 0 {
+			tb.Fatalf("credential has no attribute '%s'", name)
+		}
+		indices = append(indices, idx)
+	}
+
+	proof, disclosed, err := bbs.CreateProofWithPresentationHeader(
+		c.KeyPair.PublicKey, c.Signature, c.Messages, indices, Header, nonce,
+	)
+	if err != nil {
+		tb.Fatalf("CreateProofWithPresentationHeader failed: %v", err)
+	}
+	issued.Store(proofDigest(c.KeyPair.PublicKey, proof, disclosed, Header, nonce), struct{}{})
+
+	return &Presentation{
+		PublicKey: c.KeyPair.PublicKey,
+		Proof:     proof,
+		Disclosed: disclosed,
+		Nonce:     nonce,
+		Names:     c.Names,
+	}
+}
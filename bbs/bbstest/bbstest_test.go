@@ -0,0 +1,61 @@
+package bbstest
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/anupsv/bbsplus-signatures/bbs"
+)
+
+func TestKeyPairDeterministic(t *testing.T) {
+	first := KeyPair(t, 3)
+	keys.Delete(3)
+	second := KeyPair(t, 3)
+	if first == second || first.PublicKey.Fingerprint() != second.PublicKey.Fingerprint() {
+		t.Fatalf("Expected the same key from a fresh derivation")
+	}
+	if KeyPair(t, 4).PublicKey.Fingerprint() == first.PublicKey.Fingerprint() {
+		t.Fatalf("Expected different keys for different message counts")
+	}
+}
+
+func TestPresentation(t *testing.T) {
+	cred := NewCredential(t,
+		Attribute{Name: "name", Value: "Alice"},
+		Attribute{Name: "degree", Value: "MSc"},
+		Attribute{Name: "year", Value: "2026"},
+	)
+	nonce := []byte("nonce")
+	p := cred.Present(t, nonce, "degree")
+
+	RequireProofVerifies(t, p)
+	RequireProofDiscloses(t, p, "degree")
+	if p.Disclosed[1].Cmp(cred.Message(t, "degree")) != 0 {
+		t.Fatalf("Disclosed the wrong value")
+	}
+
+	// The canned proof is a real one
+	if err := bbs.VerifyProofWithPresentationHeader(p.PublicKey, p.Proof, p.Disclosed, Header, nonce); err != nil {
+		t.Fatalf("VerifyProofWithPresentationHeader failed: %v", err)
+	}
+
+	// VerifyProof only accepts what Present created
+	for name, verify := range map[string]func() error{
+		"nonce":  func() error { return VerifyProof(p.PublicKey, p.Proof, p.Disclosed, Header, []byte("other")) },
+		"header": func() error { return VerifyProof(p.PublicKey, p.Proof, p.Disclosed, nil, nonce) },
+		"disclosed": func() error {
+			return VerifyProof(p.PublicKey, p.Proof, map[int]*big.Int{1: big.NewInt(1)}, Header, nonce)
+		},
+		"key": func() error { return VerifyProof(KeyPair(t, 5).PublicKey, p.Proof, p.Disclosed, Header, nonce) },
+	} {
+		if err := verify(); !errors.Is(err, bbs.ErrInvalidSignature) {
+			t.Fatalf("%s: expected ErrInvalidSignature, got %v", name, err)
+		}
+	}
+
+	// Test messages never match the default mapping
+	if cred.Message(t, "name").Cmp(bbs.MessageToFieldElement([]byte("Alice"))) == 0 {
+		t.Fatalf("Expected the test ciphersuite to map messages differently")
+	}
+}
@@ -0,0 +1,28 @@
+// Package bbstest provides test doubles for services that issue, present or
+// verify BBS+ credentials, in the spirit of net/http/httptest.
+//
+// KeyPair returns deterministic keys, the same in every process, and
+// NewCredential signs attributes under them with messages mapped by the
+// test-only Ciphersuite, so test data never passes for production data.
+// Presentations made with Present are genuine proofs, and they also verify
+// with VerifyProof, which recognizes the proofs this package created
+// instead of computing pairings. Unit tests that inject VerifyProof in place
+// of bbs.VerifyProofWithPresentationHeader stay fast on CI machines; a few
+// end-to-end tests should still verify for real.
+//
+// Example usage:
+//
+//	cred := bbstest.NewCredential(t,
+//	    bbstest.Attribute{Name: "name", Value: "Alice"},
+//	    bbstest.Attribute{Name: "degree", Value: "MSc"},
+//	)
+//	p := cred.Present(t, nonce, "degree")
+//
+//	svc := NewService(bbstest.VerifyProof)
+//	err := svc.Check(p.PublicKey, p.Proof, p.Disclosed, nonce)
+//	bbstest.RequireProofDiscloses(t, p, "degree")
+//
+// Nothing in this package is fit for production: the keys are public.
+//
+// Stability: stable
+package bbstest
@@ -0,0 +1,104 @@
+package bbstest
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"math/big"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/anupsv/bbsplus-signatures/bbs"
+)
+
+// issued records the digest of every proof Present created
+var issued sync.Map // [32]byte -> struct{}
+
+// VerifyProof has the signature of bbs.VerifyProofWithPresentationHeader.
+// It accepts exactly the proofs Present created, with the key, disclosed
+// messages, header and presentation header they were created for, and
+// fails everything else with bbs.ErrInvalidSignature. It computes no
+// pairings.
+func VerifyProof(
+	publicKey *bbs.PublicKey,
+	proof *bbs.ProofOfKnowledge,
+	disclosedMessages map[int]*big.Int,
+	header []byte,
+	presentationHeader []byte,
+) error {
+	if publicKey == nil || proof == nil {
+		return bbs.ErrInvalidSignature
+	}
+	if _, ok := issued.Load(proofDigest(publicKey, proof, disclosedMessages, header, presentationHeader)); !ok {
+		return bbs.ErrInvalidSignature
+	}
+	return nil
+}
+
+// proofDigest hashes everything a proof is verified against
+func proofDigest(
+	publicKey *bbs.PublicKey,
+	proof *bbs.ProofOfKnowledge,
+	disclosed map[int]*big.Int,
+	header []byte,
+	presentationHeader []byte,
+) [32]byte {
+	h := sha256.New()
+	for _, part := range [][]byte{bbs.SerializePublicKey(publicKey), bbs.SerializeProof(proof), header, presentationHeader} {
+		h.Write(binary.BigEndian.AppendUint32(nil, uint32(len(part))))
+		h.Write(part)
+	}
+
+	indices := make([]int, 0, len(disclosed))
+	for idx := range disclosed {
+		indices = append(indices, idx)
+	}
+	slices.Sort(indices)
+	for _, idx := range indices {
+		value := disclosed[idx].Bytes()
+		h.Write(binary.BigEndian.AppendUint32(nil, uint32(idx)))
+		h.Write(binary.BigEndian.AppendUint32(nil, uint32(len(value))))
+		h.Write(value)
+	}
+
+	var sum [32]byte
+	h.Sum(sum[:0])
+	return sum
+}
+
+// RequireProofVerifies fails the test unless p verifies with VerifyProof
+func RequireProofVerifies(tb testing.TB, p *Presentation) {
+	tb.Helper()
+
+	if err := VerifyProof(p.PublicKey, p.Proof, p.Disclosed, Header, p.Nonce); err != nil {
+		tb.Fatalf("proof does not verify: %v", err)
+	}
+}
+
+// RequireProofDiscloses fails the test unless p discloses exactly the named
+// attributes, and the proof hides every other one
+func RequireProofDiscloses(tb testing.TB, p *Presentation, names ...string) {
+	tb.Helper()
+
+	want := make(map[int]bool, len(names))
+	for _, name := range names {
+		idx := slices.Index(p.Names, name)
+		if idx < 0 {
+			tb.Fatalf("credential has no attribute '%s'", name)
+		}
+		want[idx] = true
+	}
+
+	for idx, name := range p.Names {
+		_, disclosed := p.Disclosed[idx]
+		_, hidden := p.Proof.MHat[idx]
+		switch {
+		case want[idx] && !disclosed:
+			tb.Fatalf("attribute '%s' is not disclosed", name)
+		case !want[idx] && disclosed:
+			tb.Fatalf("attribute '%s' is disclosed", name)
+		case disclosed == hidden:
+			tb.Fatalf("attribute '%s' is not accounted for exactly once by the proof", name)
+		}
+	}
+}
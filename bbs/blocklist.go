@@ -0,0 +1,390 @@
+package bbs
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"slices"
+
+	"github.com/anupsv/bbsplus-signatures/internal/secret"
+	"github.com/consensys/gnark-crypto/ecc"
+	bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381"
+	"github.com/consensys/gnark-crypto/ecc/bls12-381/fr"
+)
+
+// A blocklist accumulator commits to a list of blocked message values
+// b_1..b_n as V = f(alpha) * g1 with f(x) = (x + b_1)...(x + b_n). The
+// public parameters hold alpha^i * g1 up to a maximum list size and
+// alpha * g2; alpha itself is discarded at setup. Anyone can then compute V
+// from the published list, and a holder computes the non-membership witness
+// for its own value y, d = f(-y) and C = ((f(alpha) - d) / (alpha + y)) * g1,
+// without revealing y to the maintainer. d is non-zero exactly when y is not
+// blocked, and the witness satisfies
+//
+//	e(C, alpha*g2 + y*g2) * e(d*g1, g2) = e(V, g2)
+//
+// Forging a witness for a blocked value breaks q-SDH in the parameters.
+// The maintainer publishes the parameters once and a new list, with a higher
+// version, after every update; proofs are bound to the accumulator they were
+// made against.
+
+// MaxBlocklistSize bounds the parameters, and so the blocklist, accepted
+// when deserializing
+const MaxBlocklistSize = 1 << 16
+
+var (
+	// ErrBlockedValue is returned when a non-membership proof is requested
+	// for a value on the blocklist
+	ErrBlockedValue = errors.New("value is on the blocklist")
+
+	// ErrInvalidBlocklistParams is returned for parameters whose powers are
+	// not consistent
+	ErrInvalidBlocklistParams = errors.New("invalid blocklist parameters")
+)
+
+// BlocklistParams are the public parameters of blocklist accumulators
+type BlocklistParams struct {
+	powers []bls12381.G1Affine // alpha^i * g1, i = 0..maxSize
+	alpha  bls12381.G2Affine   // alpha * g2
+}
+
+// NewBlocklistParams generates parameters for lists of up to maxSize values.
+// The trapdoor is wiped before returning, so nobody, including the
+// maintainer, can forge witnesses afterwards.
+func NewBlocklistParams(maxSize int, rng io.Reader) (*BlocklistParams, error) {
+	if maxSize < 1 || maxSize > MaxBlocklistSize {
+		return nil, fmt.Errorf("blocklist size must be 1 to %d, got %d", MaxBlocklistSize, maxSize)
+	}
+	if rng == nil {
+		return nil, fmt.Errorf("blocklist parameters need a random source")
+	}
+	alpha, err := newRandomNonZeroScalar(rng)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate blocklist trapdoor: %w", err)
+	}
+	defer alpha.SetZero()
+
+	_, _, g1, g2 := bls12381.Generators()
+	params := &BlocklistParams{powers: make([]bls12381.G1Affine, maxSize+1)}
+	exponents := make([]big.Int, maxSize+1)
+	power := ScalarFromUint64(1)
+	for i := range exponents {
+		power.e.BigInt(&exponents[i])
+		power.Mul(&power, &alpha)
+	}
+	power.SetZero()
+	for i := range params.powers {
+		params.powers[i].ScalarMultiplication(&g1, &exponents[i])
+		secret.WipeInt(&exponents[i])
+	}
+	var a big.Int
+	params.alpha.ScalarMultiplication(&g2, alpha.e.BigInt(&a))
+	secret.WipeInt(&a)
+	return params, nil
+}
+
+// MaxSize returns the largest blocklist the parameters support
+func (p *BlocklistParams) MaxSize() int {
+	return len(p.powers) - 1
+}
+
+// Validate checks that the parameters are powers of a single trapdoor, with
+// a randomized batch of the pairing equations e(P_i+1, g2) = e(P_i, alpha*g2)
+func (p *BlocklistParams) Validate() error {
+	_, _, g1, g2 := bls12381.Generators()
+	if len(p.powers) < 2 || !p.powers[0].Equal(&g1) {
+		return ErrInvalidBlocklistParams
+	}
+	if p.alpha.IsInfinity() || !p.alpha.IsInSubGroup() {
+		return ErrInvalidBlocklistParams
+	}
+
+	rho := make([]fr.Element, len(p.powers)-1)
+	for i := range rho {
+		if _, err := rho[i].SetRandom(); err != nil {
+			return fmt.Errorf("failed to sample batch coefficients: %w", err)
+		}
+	}
+	var left, right bls12381.G1Affine
+	if _, err := left.MultiExp(p.powers[1:], rho, ecc.MultiExpConfig{}); err != nil {
+		return err
+	}
+	if _, err := right.MultiExp(p.powers[:len(p.powers)-1], rho, ecc.MultiExpConfig{}); err != nil {
+		return err
+	}
+	right.Neg(&right)
+	ok, err := bls12381.PairingCheck([]bls12381.G1Affine{left, right}, []bls12381.G2Affine{g2, p.alpha})
+	if err != nil || !ok {
+		return ErrInvalidBlocklistParams
+	}
+	return nil
+}
+
+// SerializeBlocklistParams serializes parameters:
+// max size (4 bytes) || alpha*g2 (96 bytes) || powers (48 bytes each)
+func SerializeBlocklistParams(p *BlocklistParams) []byte {
+	result := make([]byte, 0, 4+G2Size+len(p.powers)*G1Size)
+	result = appendUint32(result, uint32(p.MaxSize()))
+	result = appendG2(result, &p.alpha)
+	for i := range p.powers {
+		result = appendG1(result, &p.powers[i])
+	}
+	return result
+}
+
+// DeserializeBlocklistParams deserializes and validates parameters
+func DeserializeBlocklistParams(data []byte) (*BlocklistParams, error) {
+	r := &wireReader{data: data}
+	maxSize := int(r.uint32())
+	if r.err == nil && (maxSize < 1 || maxSize > MaxBlocklistSize || r.remaining() != G2Size+(maxSize+1)*G1Size) {
+		return nil, fmt.Errorf("invalid blocklist parameters data")
+	}
+	params := &BlocklistParams{alpha: r.g2(), powers: make([]bls12381.G1Affine, maxSize+1)}
+	for i := range params.powers {
+		params.powers[i] = r.g1()
+	}
+	if r.err != nil {
+		return nil, fmt.Errorf("invalid blocklist parameters data: %w", r.err)
+	}
+	if err := params.Validate(); err != nil {
+		return nil, err
+	}
+	return params, nil
+}
+
+// Blocklist is a published list of blocked values and its accumulator. It
+// is immutable; Add and Remove return the next version.
+type Blocklist struct {
+	params      *BlocklistParams
+	version     uint64
+	values      []Scalar
+	coeffs      []Scalar // f(x), lowest degree first
+	accumulator bls12381.G1Affine
+}
+
+// NewBlocklist accumulates values as version 1 of a blocklist
+func NewBlocklist(params *BlocklistParams, values []*big.Int) (*Blocklist, error) {
+	empty := &Blocklist{params: params, coeffs: []Scalar{ScalarFromUint64(1)}}
+	b, err := empty.Add(values...)
+	if err != nil {
+		return nil, err
+	}
+	b.version = 1
+	return b, nil
+}
+
+// Add returns the next version of the list with values blocked. Values
+// already on the list are ignored.
+func (b *Blocklist) Add(values ...*big.Int) (*Blocklist, error) {
+	next := b.clone()
+	for _, v := range values {
+		s, err := NewScalar(v)
+		if err != nil {
+			return nil, err
+		}
+		if next.contains(&s) {
+			continue
+		}
+		if len(next.values) == b.params.MaxSize() {
+			return nil, fmt.Errorf("blocklist is full at %d values", b.params.MaxSize())
+		}
+		next.values = append(next.values, s)
+
+		// f(x) * (x + v)
+		next.coeffs = append(next.coeffs, Scalar{})
+		for i := len(next.coeffs) - 1; i >= 0; i-- {
+			var term Scalar
+			term.Mul(&next.coeffs[i], &s)
+			if i > 0 {
+				term.Add(&term, &next.coeffs[i-1])
+			}
+			next.coeffs[i] = term
+		}
+	}
+	if err := next.accumulate(); err != nil {
+		return nil, err
+	}
+	return next, nil
+}
+
+// Remove returns the next version of the list without values, which must
+// all be on it
+func (b *Blocklist) Remove(values ...*big.Int) (*Blocklist, error) {
+	next := b.clone()
+	for _, v := range values {
+		s, err := NewScalar(v)
+		if err != nil {
+			return nil, err
+		}
+		i := slices.IndexFunc(next.values, func(x Scalar) bool { return x.Equal(&s) })
+		if i < 0 {
+			return nil, fmt.Errorf("value %s is not on the blocklist", v)
+		}
+		next.values = slices.Delete(next.values, i, i+1)
+
+		var neg Scalar
+		neg.Neg(&s)
+		next.coeffs = divideByLinear(next.coeffs, &neg)
+	}
+	if err := next.accumulate(); err != nil {
+		return nil, err
+	}
+	return next, nil
+}
+
+// clone copies b as the next version
+func (b *Blocklist) clone() *Blocklist {
+	return &Blocklist{
+		params:  b.params,
+		version: b.version + 1,
+		values:  slices.Clone(b.values),
+		coeffs:  slices.Clone(b.coeffs),
+	}
+}
+
+// contains reports whether s is on the list
+func (b *Blocklist) contains(s *Scalar) bool {
+	return slices.ContainsFunc(b.values, func(x Scalar) bool { return x.Equal(s) })
+}
+
+// accumulate computes V = f(alpha) * g1 from the coefficients
+func (b *Blocklist) accumulate() error {
+	scalars := make([]fr.Element, len(b.coeffs))
+	for i := range b.coeffs {
+		scalars[i] = b.coeffs[i].e
+	}
+	if _, err := b.accumulator.MultiExp(b.params.powers[:len(scalars)], scalars, ecc.MultiExpConfig{}); err != nil {
+		return fmt.Errorf("failed to compute blocklist accumulator: %w", err)
+	}
+	return nil
+}
+
+// Params returns the parameters the list is accumulated under
+func (b *Blocklist) Params() *BlocklistParams {
+	return b.params
+}
+
+// Version returns the version of the list, which grows with every update
+func (b *Blocklist) Version() uint64 {
+	return b.version
+}
+
+// Len returns the number of blocked values
+func (b *Blocklist) Len() int {
+	return len(b.values)
+}
+
+// Values returns the blocked values in the order they were added
+func (b *Blocklist) Values() []*big.Int {
+	out := make([]*big.Int, len(b.values))
+	for i := range b.values {
+		out[i] = b.values[i].BigInt()
+	}
+	return out
+}
+
+// Contains reports whether v is blocked
+func (b *Blocklist) Contains(v *big.Int) bool {
+	s, err := NewScalar(v)
+	return err == nil && b.contains(&s)
+}
+
+// Accumulator returns V
+func (b *Blocklist) Accumulator() bls12381.G1Affine {
+	return b.accumulator
+}
+
+// witness computes the non-membership witness (C, d) of y, failing with
+// ErrBlockedValue if y is on the list
+func (b *Blocklist) witness(y *Scalar) (bls12381.G1Affine, Scalar, error) {
+	var C bls12381.G1Affine
+
+	// d = f(-y), and q(x) = (f(x) - d) / (x + y) by synthetic division
+	var negY Scalar
+	negY.Neg(y)
+	q := divideByLinear(b.coeffs, &negY)
+	var d Scalar
+	d.Set(&b.coeffs[0])
+	if len(q) > 0 {
+		var t Scalar
+		t.Mul(&q[0], y)
+		d.Sub(&d, &t)
+	}
+	if d.IsZero() {
+		return C, d, ErrBlockedValue
+	}
+
+	if len(q) == 0 {
+		return C, d, nil
+	}
+	scalars := make([]fr.Element, len(q))
+	for i := range q {
+		scalars[i] = q[i].e
+		q[i].SetZero()
+	}
+	if _, err := C.MultiExp(b.params.powers[:len(scalars)], scalars, ecc.MultiExpConfig{}); err != nil {
+		return C, d, fmt.Errorf("failed to compute non-membership witness: %w", err)
+	}
+	for i := range scalars {
+		scalars[i].SetZero()
+	}
+	return C, d, nil
+}
+
+// divideByLinear returns the quotient of f(x) by (x - root), dropping the
+// remainder f(root)
+func divideByLinear(coeffs []Scalar, root *Scalar) []Scalar {
+	if len(coeffs) < 2 {
+		return nil
+	}
+	q := make([]Scalar, len(coeffs)-1)
+	q[len(q)-1].Set(&coeffs[len(coeffs)-1])
+	for i := len(q) - 2; i >= 0; i-- {
+		q[i].Mul(&q[i+1], root)
+		q[i].Add(&q[i], &coeffs[i+1])
+	}
+	return q
+}
+
+// SerializeBlocklist serializes a list for publication:
+// version (8 bytes) || count (4 bytes) || values (32 bytes each). The
+// accumulator is recomputed when the list is read back.
+func SerializeBlocklist(b *Blocklist) []byte {
+	result := make([]byte, 0, 12+len(b.values)*ScalarSize)
+	result = appendUint32(result, uint32(b.version>>32))
+	result = appendUint32(result, uint32(b.version))
+	result = appendUint32(result, uint32(len(b.values)))
+	for i := range b.values {
+		v := b.values[i].Bytes()
+		result = append(result, v[:]...)
+	}
+	return result
+}
+
+// DeserializeBlocklist reads a list published under params
+func DeserializeBlocklist(params *BlocklistParams, data []byte) (*Blocklist, error) {
+	r := &wireReader{data: data}
+	version := uint64(r.uint32())<<32 | uint64(r.uint32())
+	count := int(r.uint32())
+	if r.err == nil && (count > params.MaxSize() || r.remaining() != count*ScalarSize) {
+		return nil, fmt.Errorf("invalid blocklist data")
+	}
+	values := make([]*big.Int, count)
+	for i := range values {
+		values[i] = r.scalar()
+	}
+	if r.err != nil {
+		return nil, fmt.Errorf("invalid blocklist data: %w", r.err)
+	}
+
+	b, err := NewBlocklist(params, values)
+	if err != nil {
+		return nil, err
+	}
+	if b.Len() != count {
+		return nil, fmt.Errorf("invalid blocklist data: duplicate values")
+	}
+	b.version = version
+	return b, nil
+}
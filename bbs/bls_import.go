@@ -0,0 +1,87 @@
+package bbs
+
+import (
+	"fmt"
+	"math/big"
+
+	bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381"
+)
+
+// A BBS+ public key is W = g2*x plus generators that depend only on the
+// message count, so a BLS12-381 key whose public half is g2*x in G2 (the
+// "minimal signature size" variant of the IETF BLS draft) can be reused as is.
+// Keys of the "minimal public key size" variant, used by Ethereum, live in G1
+// and cannot. Their secret key can still be imported with ImportBLSSecretKey,
+// which gives the same x a new public key in G2; the issuer then has to publish
+// that key, and should weigh using one secret in two schemes.
+
+// Encoded sizes of BLS12-381 public keys
+const (
+	blsG1KeySize             = G1Size
+	blsG1UncompressedKeySize = 2 * G1Size
+	blsG2KeySize             = G2Size
+	blsG2UncompressedKeySize = 2 * G2Size
+)
+
+// ImportBLSPublicKey builds a BBS+ public key for messageCount messages from
+// a BLS12-381 public key in G2, compressed (96 bytes) or uncompressed (192
+// bytes) in the ZCash encoding used by the IETF BLS draft
+func ImportBLSPublicKey(data []byte, messageCount int) (*PublicKey, error) {
+	if messageCount < 1 {
+		return nil, fmt.Errorf("message count must be at least 1, got %d", messageCount)
+	}
+
+	// An uncompressed G1 point has the size of a compressed G2 point; the
+	// compression flag tells them apart
+	isG1 := len(data) == blsG1KeySize ||
+		(len(data) == blsG1UncompressedKeySize && !isCompactEncoding(data))
+	if isG1 {
+		return nil, fmt.Errorf("%w: %d byte key is a G1 point (minimal public key size BLS, as in Ethereum); BBS+ needs a G2 key", ErrUnsuitableBLSKey, len(data))
+	}
+	if len(data) != blsG2KeySize && len(data) != blsG2UncompressedKeySize {
+		return nil, fmt.Errorf("%w: %d bytes is not the size of a G2 point", ErrUnsuitableBLSKey, len(data))
+	}
+
+	// SetBytes checks that the point is on the curve and in the subgroup
+	var w bls12381.G2Affine
+	if _, err := w.SetBytes(data); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnsuitableBLSKey, err)
+	}
+
+	// W = g2*x with x = 0 or x = +-1 has a publicly known secret
+	_, _, _, g2 := bls12381.Generators()
+	var negG2 bls12381.G2Affine
+	negG2.Neg(&g2)
+	if w.IsInfinity() || w.Equal(&g2) || w.Equal(&negG2) {
+		return nil, fmt.Errorf("%w: trivial key", ErrUnsuitableBLSKey)
+	}
+
+	return NewPublicKey(w, messageCount), nil
+}
+
+// ImportBLSSecretKey builds a BBS+ key pair for messageCount messages from a
+// BLS12-381 secret key, a 32 byte big-endian scalar as produced by the IETF
+// BLS KeyGen
+func ImportBLSSecretKey(data []byte, messageCount int) (*KeyPair, error) {
+	if messageCount < 1 {
+		return nil, fmt.Errorf("message count must be at least 1, got %d", messageCount)
+	}
+	if len(data) != ScalarSize {
+		return nil, fmt.Errorf("%w: secret key must be %d bytes, got %d", ErrUnsuitableBLSKey, ScalarSize, len(data))
+	}
+
+	x := new(big.Int).SetBytes(data)
+	if x.Sign() == 0 || x.Cmp(Order) >= 0 {
+		return nil, fmt.Errorf("%w: secret key out of range", ErrUnsuitableBLSKey)
+	}
+
+	// Compute W = g2^x
+	_, _, _, g2 := bls12381.Generators()
+	var w bls12381.G2Affine
+	w.ScalarMultiplication(&g2, x)
+
+	return &KeyPair{
+		PrivateKey: &PrivateKey{X: x},
+		PublicKey:  NewPublicKey(w, messageCount),
+	}, nil
+}
@@ -0,0 +1,100 @@
+package bbs
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"testing"
+
+	bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381"
+)
+
+func TestImportBLSPublicKey(t *testing.T) {
+	keyPair, err := GenerateKeyPair(3, rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKeyPair failed: %v", err)
+	}
+
+	messages := []*big.Int{big.NewInt(1), big.NewInt(2), big.NewInt(3)}
+	signature, err := Sign(keyPair.PrivateKey, keyPair.PublicKey, messages, nil)
+	if err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+
+	compressed := keyPair.PublicKey.w.Bytes()
+	uncompressed := keyPair.PublicKey.w.RawBytes()
+	for _, data := range [][]byte{compressed[:], uncompressed[:]} {
+		pk, err := ImportBLSPublicKey(data, 3)
+		if err != nil {
+			t.Fatalf("ImportBLSPublicKey(%d bytes) failed: %v", len(data), err)
+		}
+		if err := Verify(pk, signature, messages, nil); err != nil {
+			t.Fatalf("signature does not verify under the imported key: %v", err)
+		}
+	}
+
+	_, _, g1, g2 := bls12381.Generators()
+	var infinity bls12381.G2Affine
+	g1Bytes := g1.Bytes()
+	g1Raw := g1.RawBytes()
+	g2Bytes := g2.Bytes()
+	infinityBytes := infinity.Bytes()
+	notOnCurve := append([]byte(nil), compressed[:]...)
+	notOnCurve[G2Size-1] ^= 1
+
+	unsuitable := map[string][]byte{
+		"G1 key":       g1Bytes[:],
+		"raw G1 key":   g1Raw[:],
+		"wrong size":   compressed[:50],
+		"generator":    g2Bytes[:],
+		"infinity":     infinityBytes[:],
+		"not on curve": notOnCurve,
+	}
+	for name, data := range unsuitable {
+		if _, err := ImportBLSPublicKey(data, 3); !errors.Is(err, ErrUnsuitableBLSKey) {
+			t.Fatalf("%s: got %v, want ErrUnsuitableBLSKey", name, err)
+		}
+	}
+
+	if _, err := ImportBLSPublicKey(compressed[:], 0); err == nil {
+		t.Fatal("ImportBLSPublicKey accepted zero messages")
+	}
+}
+
+func TestImportBLSSecretKey(t *testing.T) {
+	x, err := randomNonZeroScalar()
+	if err != nil {
+		t.Fatalf("randomNonZeroScalar failed: %v", err)
+	}
+	secret := appendScalar(nil, x)
+
+	keyPair, err := ImportBLSSecretKey(secret, 2)
+	if err != nil {
+		t.Fatalf("ImportBLSSecretKey failed: %v", err)
+	}
+
+	messages := []*big.Int{big.NewInt(7), big.NewInt(8)}
+	signature, err := Sign(keyPair.PrivateKey, keyPair.PublicKey, messages, nil)
+	if err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+	if err := Verify(keyPair.PublicKey, signature, messages, nil); err != nil {
+		t.Fatalf("Verify failed: %v", err)
+	}
+
+	// The public half imports to the same key
+	w := keyPair.PublicKey.w.Bytes()
+	pk, err := ImportBLSPublicKey(w[:], 2)
+	if err != nil {
+		t.Fatalf("ImportBLSPublicKey failed: %v", err)
+	}
+	if err := Verify(pk, signature, messages, nil); err != nil {
+		t.Fatalf("Verify with imported public key failed: %v", err)
+	}
+
+	for _, bad := range [][]byte{make([]byte, ScalarSize), appendScalar(nil, big.NewInt(1))[:16]} {
+		if _, err := ImportBLSSecretKey(bad, 2); !errors.Is(err, ErrUnsuitableBLSKey) {
+			t.Fatalf("got %v, want ErrUnsuitableBLSKey", err)
+		}
+	}
+}
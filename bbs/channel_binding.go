@@ -0,0 +1,108 @@
+package bbs
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// A proof made inside a TLS connection can be bound to that connection with
+// the tls-exporter channel binding of RFC 9266: keying material both ends
+// export from the handshake, which differs for every connection. The holder
+// folds it into the presentation header and the verifier, which exports the
+// same value from its end, checks the proof under it. A proof relayed by a
+// man in the middle onto another connection fails, since that connection
+// exports different material.
+
+// Channel binding parameters
+const (
+	channelBindingDST = "BBS_BLS12381_CHANNEL_BINDING_"
+
+	// TLSExporterLabel is the RFC 9266 exporter label of the tls-exporter
+	// channel binding
+	TLSExporterLabel = "EXPORTER-Channel-Binding"
+
+	// ChannelBindingSize is the length of the exported keying material
+	ChannelBindingSize = 32
+)
+
+// ErrChannelBinding is returned when a channel binding cannot be exported or
+// is missing
+var ErrChannelBinding = errors.New("invalid channel binding")
+
+// KeyingMaterialExporter exports keying material from a secure channel.
+// *tls.ConnectionState implements it.
+type KeyingMaterialExporter interface {
+	ExportKeyingMaterial(label string, context []byte, length int) ([]byte, error)
+}
+
+// TLSChannelBinding returns the tls-exporter channel binding of a connection,
+// such as the *tls.ConnectionState of the holder's client connection or of
+// the verifier's request. The exporter refuses TLS 1.2 connections without
+// the extended master secret, whose exported material is not unique to the
+// connection.
+func TLSChannelBinding(conn KeyingMaterialExporter) ([]byte, error) {
+	if conn == nil {
+		return nil, fmt.Errorf("%w: no connection", ErrChannelBinding)
+	}
+	binding, err := conn.ExportKeyingMaterial(TLSExporterLabel, nil, ChannelBindingSize)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrChannelBinding, err)
+	}
+	return binding, nil
+}
+
+// ChannelBoundPresentationHeader returns the presentation header ph bound to
+// a channel: DST || len(binding) || binding || ph, with the length as 8
+// bytes big-endian. An empty binding returns ph unchanged.
+func ChannelBoundPresentationHeader(ph, binding []byte) []byte {
+	if len(binding) == 0 {
+		return ph
+	}
+
+	out := make([]byte, 0, len(channelBindingDST)+8+len(binding)+len(ph))
+	out = append(out, channelBindingDST...)
+	out = binary.BigEndian.AppendUint64(out, uint64(len(binding)))
+	out = append(out, binding...)
+	return append(out, ph...)
+}
+
+// CreateProofWithChannelBinding creates a proof whose presentation header ph
+// is bound to the channel binding, see TLSChannelBinding
+func CreateProofWithChannelBinding(
+	publicKey *PublicKey,
+	signature *Signature,
+	messages []*big.Int,
+	disclosedIndices []int,
+	header []byte,
+	ph []byte,
+	binding []byte,
+) (*ProofOfKnowledge, map[int]*big.Int, error) {
+	if len(binding) == 0 {
+		return nil, nil, fmt.Errorf("%w: missing binding", ErrChannelBinding)
+	}
+	return CreateProofWithPresentationHeader(
+		publicKey, signature, messages, disclosedIndices, header, ChannelBoundPresentationHeader(ph, binding),
+	)
+}
+
+// VerifyProofWithChannelBinding verifies a proof made with
+// CreateProofWithChannelBinding against the binding of the verifier's own
+// end of the channel. A proof made on another channel fails like any proof
+// under the wrong presentation header.
+func VerifyProofWithChannelBinding(
+	publicKey *PublicKey,
+	proof *ProofOfKnowledge,
+	disclosedMessages map[int]*big.Int,
+	header []byte,
+	ph []byte,
+	binding []byte,
+) error {
+	if len(binding) == 0 {
+		return fmt.Errorf("%w: missing binding", ErrChannelBinding)
+	}
+	return VerifyProofWithPresentationHeader(
+		publicKey, proof, disclosedMessages, header, ChannelBoundPresentationHeader(ph, binding),
+	)
+}
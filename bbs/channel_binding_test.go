@@ -0,0 +1,138 @@
+package bbs
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+)
+
+// tlsPair runs a TLS handshake over an in-memory connection and returns the
+// client and server connection states
+func tlsPair(t *testing.T, cert tls.Certificate, maxVersion uint16) (*tls.ConnectionState, *tls.ConnectionState) {
+	t.Helper()
+	clientConn, serverConn := net.Pipe()
+	t.Cleanup(func() {
+		clientConn.Close()
+		serverConn.Close()
+	})
+
+	server := tls.Server(serverConn, &tls.Config{Certificates: []tls.Certificate{cert}, MaxVersion: maxVersion})
+	client := tls.Client(clientConn, &tls.Config{InsecureSkipVerify: true, MaxVersion: maxVersion})
+
+	done := make(chan error, 1)
+	go func() { done <- server.Handshake() }()
+	if err := client.Handshake(); err != nil {
+		t.Fatalf("Client handshake failed: %v", err)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("Server handshake failed: %v", err)
+	}
+	clientState, serverState := client.ConnectionState(), server.ConnectionState()
+	return &clientState, &serverState
+}
+
+func testCertificate(t *testing.T) tls.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		DNSNames:     []string{"verifier.example"},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Failed to create certificate: %v", err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+func TestChannelBinding(t *testing.T) {
+	cert := testCertificate(t)
+	holderConn, verifierConn := tlsPair(t, cert, tls.VersionTLS13)
+	_, otherConn := tlsPair(t, cert, tls.VersionTLS13)
+
+	holderBinding, err := TLSChannelBinding(holderConn)
+	if err != nil {
+		t.Fatalf("TLSChannelBinding failed: %v", err)
+	}
+	verifierBinding, err := TLSChannelBinding(verifierConn)
+	if err != nil {
+		t.Fatalf("TLSChannelBinding failed: %v", err)
+	}
+	otherBinding, err := TLSChannelBinding(otherConn)
+	if err != nil {
+		t.Fatalf("TLSChannelBinding failed: %v", err)
+	}
+	if len(holderBinding) != ChannelBindingSize || !bytes.Equal(holderBinding, verifierBinding) {
+		t.Fatalf("Expected both ends to export the same %d-byte binding", ChannelBindingSize)
+	}
+	if bytes.Equal(holderBinding, otherBinding) {
+		t.Fatalf("Expected another connection to export a different binding")
+	}
+
+	keyPair, err := GenerateKeyPair(3, rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key pair: %v", err)
+	}
+	messages := []*big.Int{big.NewInt(1), big.NewInt(2), big.NewInt(3)}
+	signature, err := Sign(keyPair.PrivateKey, keyPair.PublicKey, messages, nil)
+	if err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+	nonce := []byte("nonce")
+
+	proof, disclosed, err := CreateProofWithChannelBinding(keyPair.PublicKey, signature, messages, []int{0}, nil, nonce, holderBinding)
+	if err != nil {
+		t.Fatalf("CreateProofWithChannelBinding failed: %v", err)
+	}
+	if err := VerifyProofWithChannelBinding(keyPair.PublicKey, proof, disclosed, nil, nonce, verifierBinding); err != nil {
+		t.Fatalf("VerifyProofWithChannelBinding failed: %v", err)
+	}
+
+	// Relayed onto another connection
+	if err := VerifyProofWithChannelBinding(keyPair.PublicKey, proof, disclosed, nil, nonce, otherBinding); err == nil {
+		t.Fatalf("Expected a relayed proof to fail")
+	}
+	// Unbound verification does not accept it either
+	if err := VerifyProofWithPresentationHeader(keyPair.PublicKey, proof, disclosed, nil, nonce); err == nil {
+		t.Fatalf("Expected the proof to fail without its binding")
+	}
+	if err := VerifyProofWithChannelBinding(keyPair.PublicKey, proof, disclosed, nil, nonce, nil); !errors.Is(err, ErrChannelBinding) {
+		t.Fatalf("Expected ErrChannelBinding without a binding, got %v", err)
+	}
+	if _, _, err := CreateProofWithChannelBinding(keyPair.PublicKey, signature, messages, []int{0}, nil, nonce, nil); !errors.Is(err, ErrChannelBinding) {
+		t.Fatalf("Expected ErrChannelBinding without a binding, got %v", err)
+	}
+}
+
+func TestTLSChannelBindingTLS12(t *testing.T) {
+	// Go negotiates the extended master secret, so TLS 1.2 exports too
+	holderConn, verifierConn := tlsPair(t, testCertificate(t), tls.VersionTLS12)
+	holderBinding, err := TLSChannelBinding(holderConn)
+	if err != nil {
+		t.Fatalf("TLSChannelBinding failed: %v", err)
+	}
+	verifierBinding, err := TLSChannelBinding(verifierConn)
+	if err != nil {
+		t.Fatalf("TLSChannelBinding failed: %v", err)
+	}
+	if !bytes.Equal(holderBinding, verifierBinding) {
+		t.Fatalf("Expected both ends to export the same binding")
+	}
+
+	if _, err := TLSChannelBinding(nil); !errors.Is(err, ErrChannelBinding) {
+		t.Fatalf("Expected ErrChannelBinding without a connection, got %v", err)
+	}
+}
@@ -0,0 +1,210 @@
+package bbs
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"hash"
+	"math/big"
+
+	"github.com/anupsv/bbsplus-signatures/pkg/crypto"
+)
+
+// Ciphersuite identifiers from the BBS specification
+const (
+	SHA256CiphersuiteID   = "BBS_BLS12381G1_XMD:SHA-256_SSWU_RO_"
+	SHAKE256CiphersuiteID = "BBS_BLS12381G1_XOF:SHAKE-256_SSWU_RO_"
+
+	// SHA512CiphersuiteID is not in the specification. It names the suite
+	// that expands with SHA-512 in place of SHA-256.
+	SHA512CiphersuiteID = "BBS_BLS12381G1_XMD:SHA-512_SSWU_RO_"
+)
+
+// expandLen is the number of uniform bytes hashed to a scalar. 48 bytes
+// leave the reduction modulo Order with a bias below 2^-128.
+const expandLen = 48
+
+// Domain separation suffixes from the BBS specification
+const (
+	apiIDSuffix      = "H2G_HM2S_"
+	mapMessageSuffix = "MAP_MSG_TO_SCALAR_AS_HASH_"
+	domainSuffix     = "H2S_DOMAIN_"
+	challengeSuffix  = "H2S_CHALLENGE_"
+)
+
+// ExpandMessageFunc expands msg to length uniform bytes under dst, as
+// expand_message in RFC 9380
+type ExpandMessageFunc func(msg, dst []byte, length int) ([]byte, error)
+
+// XOF is an extendable-output function such as SHAKE256: input is written,
+// then any amount of output is read
+type XOF = crypto.XOF
+
+// Ciphersuite fixes the hash behind message mapping. MessageToFieldElement
+// hashes with DefaultCiphersuite.
+//
+// Messages used to be mapped as their SHA-256 digest reduced modulo Order,
+// which is biased and cannot be swapped for another hash. The spec mapping
+// expands the message to 48 bytes under a ciphersuite-specific DST first.
+// Signatures over messages mapped the old way only verify with a suite whose
+// LegacyMessageMapping is set, such as LegacySHA256.
+//
+// The domain and the proof challenge are likewise hashed with bare SHA-256
+// and no label unless LabeledHashing is set. Labeled hashing runs them
+// through HashToScalar under DSTs of their own, so they change with the
+// suite's hash and can never collide with each other, with the message
+// mapping or with another protocol hashing the same bytes. It is off in the
+// predefined SHA-256 suites because signatures, proofs and the on-chain
+// verifier depend on the bare hash.
+type Ciphersuite struct {
+	// ID is the ciphersuite identifier, which prefixes every DST
+	ID string
+
+	// ExpandMessage is the suite's expand_message
+	ExpandMessage ExpandMessageFunc
+
+	// LegacyMessageMapping maps messages as sha256(msg) mod Order instead of
+	// with hash_to_scalar, for data created before the spec mapping
+	LegacyMessageMapping bool
+
+	// LabeledHashing hashes the domain and the proof challenge with
+	// hash_to_scalar under the suite's domain and challenge DSTs instead of
+	// as sha256(data) mod Order
+	LabeledHashing bool
+}
+
+// BLS12381SHA256 is the BLS12-381-SHA-256 ciphersuite
+var BLS12381SHA256 = &Ciphersuite{
+	ID:            SHA256CiphersuiteID,
+	ExpandMessage: ExpandMessageXMD,
+}
+
+// LegacySHA256 maps messages the way this package did before hash_to_scalar
+var LegacySHA256 = &Ciphersuite{
+	ID:                   SHA256CiphersuiteID,
+	ExpandMessage:        ExpandMessageXMD,
+	LegacyMessageMapping: true,
+}
+
+// BLS12381SHA512 maps messages and hashes the domain and challenge with
+// expand_message_xmd over SHA-512, with labeled hashing
+var BLS12381SHA512 = &Ciphersuite{
+	ID:             SHA512CiphersuiteID,
+	ExpandMessage:  ExpandMessageXMDWith(sha512.New),
+	LabeledHashing: true,
+}
+
+// DefaultCiphersuite is the suite used by MessageToFieldElement,
+// CalculateDomain and the proof challenge. Set it to LegacySHA256 to keep
+// verifying data created with the old mapping.
+var DefaultCiphersuite = BLS12381SHA256
+
+// NewBLS12381SHAKE256 returns the BLS12-381-SHAKE-256 ciphersuite. newXOF
+// must return a fresh SHAKE256 instance, for example sha3.NewSHAKE256.
+func NewBLS12381SHAKE256(newXOF func() XOF) *Ciphersuite {
+	return &Ciphersuite{
+		ID:            SHAKE256CiphersuiteID,
+		ExpandMessage: ExpandMessageXOF(newXOF),
+	}
+}
+
+// Labeled returns a copy of cs with LabeledHashing set
+func (cs *Ciphersuite) Labeled() *Ciphersuite {
+	labeled := *cs
+	labeled.LabeledHashing = true
+	return &labeled
+}
+
+// ExpandMessageXMD is expand_message_xmd from RFC 9380 with SHA-256
+func ExpandMessageXMD(msg, dst []byte, length int) ([]byte, error) {
+	return crypto.ExpandMessageXMD(msg, dst, length)
+}
+
+// ExpandMessageXMDWith returns expand_message_xmd from RFC 9380 over the
+// Merkle-Damgard hash returned by newHash, such as sha512.New
+func ExpandMessageXMDWith(newHash func() hash.Hash) ExpandMessageFunc {
+	return ExpandMessageFunc(crypto.ExpandMessageXMDWith(newHash))
+}
+
+// ExpandMessageXOF returns expand_message_xof from RFC 9380 over the XOF
+// returned by newXOF
+func ExpandMessageXOF(newXOF func() XOF) ExpandMessageFunc {
+	return ExpandMessageFunc(crypto.ExpandMessageXOF(newXOF))
+}
+
+// HashToScalar is hash_to_scalar from the BBS specification: msg is expanded
+// to 48 bytes under dst and reduced modulo Order
+func (cs *Ciphersuite) HashToScalar(msg, dst []byte) (*big.Int, error) {
+	uniform, err := cs.ExpandMessage(msg, dst, expandLen)
+	if err != nil {
+		return nil, err
+	}
+
+	scalar := new(big.Int).SetBytes(uniform)
+	return scalar.Mod(scalar, Order), nil
+}
+
+// MapMessageToScalar maps a message to a field element, with hash_to_scalar
+// under the suite's map DST or, in legacy mode, as sha256(msg) mod Order
+func (cs *Ciphersuite) MapMessageToScalar(msg []byte) *big.Int {
+	if cs.LegacyMessageMapping {
+		return legacyMessageToFieldElement(msg)
+	}
+
+	dst := []byte(cs.ID + apiIDSuffix + mapMessageSuffix)
+	scalar, err := cs.HashToScalar(msg, dst)
+	if err != nil {
+		// The DST and output length are fixed and valid, so only a broken
+		// ExpandMessage gets here
+		panic("bbs: " + cs.ID + " expand_message failed: " + err.Error())
+	}
+	return scalar
+}
+
+// hashDomain hashes the CalculateDomain input to a scalar
+func (cs *Ciphersuite) hashDomain(data []byte) *big.Int {
+	if !cs.LabeledHashing {
+		// The digest is below 3*Order, so at most two subtractions reduce it
+		digest := sha256.Sum256(data)
+		domain := new(big.Int).SetBytes(digest[:])
+		for domain.Cmp(Order) >= 0 {
+			domain.Sub(domain, Order)
+		}
+		return domain
+	}
+	return cs.labeledScalar(data, domainSuffix)
+}
+
+// hashChallenge hashes the proof challenge input to a scalar
+func (cs *Ciphersuite) hashChallenge(data []byte) *big.Int {
+	if !cs.LabeledHashing {
+		digest := sha256.Sum256(data)
+		challenge := new(big.Int).SetBytes(digest[:])
+		return challenge.Mod(challenge, Order)
+	}
+	return cs.labeledScalar(data, challengeSuffix)
+}
+
+// labeledScalar is hash_to_scalar of data under the suite's API ID and label
+func (cs *Ciphersuite) labeledScalar(data []byte, label string) *big.Int {
+	scalar, err := cs.HashToScalar(data, []byte(cs.ID+apiIDSuffix+label))
+	if err != nil {
+		// As in MapMessageToScalar, only a broken ExpandMessage gets here
+		panic("bbs: " + cs.ID + " expand_message failed: " + err.Error())
+	}
+	return scalar
+}
+
+// CiphersuiteEncoder encodes messages with a ciphersuite's message mapping.
+// A nil Suite uses DefaultCiphersuite.
+type CiphersuiteEncoder struct {
+	Suite *Ciphersuite
+}
+
+// Encode maps msg to a field element
+func (e CiphersuiteEncoder) Encode(msg Message) FieldElement {
+	suite := e.Suite
+	if suite == nil {
+		suite = DefaultCiphersuite
+	}
+	return FieldElement{value: suite.MapMessageToScalar(msg)}
+}
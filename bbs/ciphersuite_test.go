@@ -0,0 +1,220 @@
+package bbs
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"math/big"
+	"testing"
+)
+
+func TestExpandMessageXMD(t *testing.T) {
+	// Test vectors from RFC 9380, appendix K.1
+	dst := []byte("QUUX-V01-CS02-with-expander-SHA256-128")
+	vectors := []struct {
+		msg  string
+		want string
+	}{
+		{"", "68a985b87eb6b46952128911f2a4412bbc302a9d759667f87f7a21d803f07235"},
+		{"abc", "d8ccab23b5985ccea865c6c97b6e5b8350e794e603b4b97902f53a8a0d605615"},
+	}
+
+	for _, v := range vectors {
+		out, err := ExpandMessageXMD([]byte(v.msg), dst, 32)
+		if err != nil {
+			t.Fatalf("ExpandMessageXMD failed: %v", err)
+		}
+		if hex.EncodeToString(out) != v.want {
+			t.Fatalf("ExpandMessageXMD(%q) = %x, want %s", v.msg, out, v.want)
+		}
+	}
+}
+
+// recordingXOF captures its input and outputs a SHA-256 digest of it
+type recordingXOF struct {
+	input bytes.Buffer
+	out   []byte
+}
+
+func (x *recordingXOF) Write(p []byte) (int, error) { return x.input.Write(p) }
+
+func (x *recordingXOF) Read(p []byte) (int, error) {
+	if x.out == nil {
+		h := sha256.Sum256(x.input.Bytes())
+		x.out = bytes.Repeat(h[:], 2)
+	}
+	n := copy(p, x.out)
+	x.out = x.out[n:]
+	return n, nil
+}
+
+func TestExpandMessageXOF(t *testing.T) {
+	var last *recordingXOF
+	expand := ExpandMessageXOF(func() XOF {
+		last = &recordingXOF{}
+		return last
+	})
+
+	if _, err := expand([]byte("msg"), []byte("DST"), 48); err != nil {
+		t.Fatalf("ExpandMessageXOF failed: %v", err)
+	}
+
+	// msg || I2OSP(48, 2) || DST || I2OSP(3, 1)
+	want := []byte("msg\x00\x30DST\x03")
+	if !bytes.Equal(last.input.Bytes(), want) {
+		t.Fatalf("XOF input = %q, want %q", last.input.Bytes(), want)
+	}
+
+	if _, err := expand(nil, make([]byte, 256), 48); err == nil {
+		t.Fatal("ExpandMessageXOF accepted a 256 byte DST")
+	}
+	if _, err := expand(nil, nil, 0x10000); err == nil {
+		t.Fatal("ExpandMessageXOF accepted an oversized length")
+	}
+}
+
+func TestMessageMapping(t *testing.T) {
+	msg := []byte("alice")
+
+	// The spec mapping is hash_to_scalar under the map DST
+	dst := []byte(SHA256CiphersuiteID + "H2G_HM2S_MAP_MSG_TO_SCALAR_AS_HASH_")
+	uniform, err := ExpandMessageXMD(msg, dst, 48)
+	if err != nil {
+		t.Fatalf("ExpandMessageXMD failed: %v", err)
+	}
+	want := new(big.Int).Mod(new(big.Int).SetBytes(uniform), Order)
+	if got := BLS12381SHA256.MapMessageToScalar(msg); got.Cmp(want) != 0 {
+		t.Fatalf("MapMessageToScalar = %v, want %v", got, want)
+	}
+	if MessageToFieldElement(msg).Cmp(want) != 0 {
+		t.Fatal("MessageToFieldElement does not use the default ciphersuite")
+	}
+
+	// Legacy mode keeps the old mapping
+	digest := sha256.Sum256(msg)
+	legacy := new(big.Int).Mod(new(big.Int).SetBytes(digest[:]), Order)
+	if LegacySHA256.MapMessageToScalar(msg).Cmp(legacy) != 0 {
+		t.Fatal("LegacySHA256 changed the legacy mapping")
+	}
+	if (SHA256Encoder{}).Encode(msg).Int().Cmp(legacy) != 0 {
+		t.Fatal("SHA256Encoder changed the legacy mapping")
+	}
+
+	// Switching the default switches the default encoder with it
+	DefaultCiphersuite = LegacySHA256
+	defer func() { DefaultCiphersuite = BLS12381SHA256 }()
+	if DefaultMessageEncoder.Encode(msg).Int().Cmp(legacy) != 0 {
+		t.Fatal("DefaultMessageEncoder ignores DefaultCiphersuite")
+	}
+
+	// A SHAKE suite uses its own ID in the DST
+	shake := NewBLS12381SHAKE256(func() XOF { return &recordingXOF{} })
+	if shake.MapMessageToScalar(msg).Cmp(want) == 0 {
+		t.Fatal("SHAKE suite mapped like the SHA-256 suite")
+	}
+}
+
+func TestExpandMessageXMDWith(t *testing.T) {
+	// Over SHA-256 it matches ExpandMessageXMD
+	dst := []byte("QUUX-V01-CS02-with-expander-SHA256-128")
+	for _, length := range []int{32, 48, 100} {
+		want, err := ExpandMessageXMD([]byte("abc"), dst, length)
+		if err != nil {
+			t.Fatalf("ExpandMessageXMD failed: %v", err)
+		}
+		got, err := ExpandMessageXMDWith(sha256.New)([]byte("abc"), dst, length)
+		if err != nil {
+			t.Fatalf("ExpandMessageXMDWith failed: %v", err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Fatalf("ExpandMessageXMDWith(sha256) = %x, want %x", got, want)
+		}
+	}
+
+	// Test vectors from RFC 9380, appendix K.2
+	dst = []byte("QUUX-V01-CS02-with-expander-SHA512-256")
+	vectors := []struct {
+		msg  string
+		want string
+	}{
+		{"", "6b9a7312411d92f921c6f68ca0b6380730a1a4d982c507211a90964c394179ba"},
+		{"abc", "0da749f12fbe5483eb066a5f595055679b976e93abe9be6f0f6318bce7aca8dc"},
+	}
+	for _, v := range vectors {
+		out, err := ExpandMessageXMDWith(sha512.New)([]byte(v.msg), dst, 32)
+		if err != nil {
+			t.Fatalf("ExpandMessageXMDWith failed: %v", err)
+		}
+		if hex.EncodeToString(out) != v.want {
+			t.Fatalf("ExpandMessageXMDWith(sha512, %q) = %x, want %s", v.msg, out, v.want)
+		}
+	}
+
+	if _, err := ExpandMessageXMDWith(sha256.New)(nil, nil, 256*32); err == nil {
+		t.Fatal("ExpandMessageXMDWith accepted more than 255 blocks")
+	}
+}
+
+func TestLabeledHashing(t *testing.T) {
+	// The predefined SHA-256 suites keep the bare hash
+	data := []byte("challenge input")
+	digest := sha256.Sum256(data)
+	want := new(big.Int).Mod(new(big.Int).SetBytes(digest[:]), Order)
+	if BLS12381SHA256.hashDomain(data).Cmp(want) != 0 || LegacySHA256.hashChallenge(data).Cmp(want) != 0 {
+		t.Fatal("Unlabeled hashing changed")
+	}
+
+	keyPair, err := GenerateKeyPair(3, rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKeyPair failed: %v", err)
+	}
+	pk := keyPair.PublicKey
+	header := []byte("header")
+	messages := []*big.Int{big.NewInt(1), big.NewInt(2), big.NewInt(3)}
+
+	shake := NewBLS12381SHAKE256(func() XOF { return &recordingXOF{} }).Labeled()
+	defer func() { DefaultCiphersuite = BLS12381SHA256 }()
+
+	domains := map[string]bool{CalculateDomain(pk, header).String(): true}
+	for _, suite := range []*Ciphersuite{BLS12381SHA256.Labeled(), BLS12381SHA512, shake} {
+		DefaultCiphersuite = suite
+
+		// Each suite hashes to its own domain, and the challenge label
+		// differs from the domain label
+		domain := CalculateDomain(pk, header)
+		if domains[domain.String()] {
+			t.Fatalf("%s: domain collides with another suite", suite.ID)
+		}
+		domains[domain.String()] = true
+		if suite.hashChallenge(data).Cmp(suite.hashDomain(data)) == 0 {
+			t.Fatalf("%s: challenge and domain hashes are not separated", suite.ID)
+		}
+
+		// Sign, prove and verify under the suite
+		signature, err := Sign(keyPair.PrivateKey, pk, messages, header)
+		if err != nil {
+			t.Fatalf("%s: Sign failed: %v", suite.ID, err)
+		}
+		if err := Verify(pk, signature, messages, header); err != nil {
+			t.Fatalf("%s: Verify failed: %v", suite.ID, err)
+		}
+		proof, disclosed, err := CreateProof(pk, signature, messages, []int{1}, header)
+		if err != nil {
+			t.Fatalf("%s: CreateProof failed: %v", suite.ID, err)
+		}
+		if err := VerifyProof(pk, proof, disclosed, header); err != nil {
+			t.Fatalf("%s: VerifyProof failed: %v", suite.ID, err)
+		}
+
+		// Nothing made under one hash verifies under another
+		DefaultCiphersuite = BLS12381SHA256
+		if err := Verify(pk, signature, messages, header); err == nil {
+			t.Fatalf("%s: signature verified with the unlabeled domain", suite.ID)
+		}
+		if err := VerifyProof(pk, proof, disclosed, header); err == nil {
+			t.Fatalf("%s: proof verified with the unlabeled challenge", suite.ID)
+		}
+	}
+}
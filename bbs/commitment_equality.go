@@ -0,0 +1,250 @@
+package bbs
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/anupsv/bbsplus-signatures/internal/secret"
+	"github.com/anupsv/bbsplus-signatures/pkg/crypto"
+	bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381"
+)
+
+// Domain separation tags for external commitments
+const (
+	pedersenGeneratorDST  = "BBS_BLS12381_PEDERSEN_GENERATOR_"
+	commitmentEqualityDST = "BBS_BLS12381_COMMITMENT_EQUALITY_"
+)
+
+var (
+	pedersenOnce sync.Once
+	pedersenG    bls12381.G1Affine
+	pedersenH    bls12381.G1Affine
+)
+
+// PedersenGenerators returns the generators G and H of external attribute
+// commitments C = G*value + H*blinding. They are derived by hashing to G1,
+// so nobody knows the discrete logarithm of H with respect to G.
+func PedersenGenerators() (g, h bls12381.G1Affine) {
+	pedersenOnce.Do(func() {
+		var err error
+		if pedersenG, err = crypto.HashToG1([]byte("G"), []byte(pedersenGeneratorDST)); err != nil {
+			panic(fmt.Sprintf("bbs: failed to hash Pedersen generator to G1: %v", err))
+		}
+		if pedersenH, err = crypto.HashToG1([]byte("H"), []byte(pedersenGeneratorDST)); err != nil {
+			panic(fmt.Sprintf("bbs: failed to hash Pedersen generator to G1: %v", err))
+		}
+	})
+	return pedersenG, pedersenH
+}
+
+// PedersenCommit returns the commitment G*value + H*blinding
+func PedersenCommit(value, blinding *big.Int) bls12381.G1Affine {
+	g, h := PedersenGenerators()
+
+	v := new(big.Int).Mod(value, Order)
+	r := new(big.Int).Mod(blinding, Order)
+
+	var cJac, hJac bls12381.G1Jac
+	cJac.FromAffine(&g)
+	cJac.ScalarMultiplication(&cJac, v)
+	hJac.FromAffine(&h)
+	hJac.ScalarMultiplication(&hJac, r)
+	cJac.AddAssign(&hJac)
+
+	return g1JacToAffine(cJac)
+}
+
+// CommitmentOpening is an external Pedersen commitment to a credential
+// attribute together with its opening, as held by the prover
+type CommitmentOpening struct {
+	Commitment bls12381.G1Affine
+	Blinding   *big.Int
+}
+
+// CommitAttribute commits to value with a fresh random blinding. The
+// commitment can be published, e.g. registered on a blockchain, while the
+// opening stays with the holder.
+func CommitAttribute(value *big.Int) (*CommitmentOpening, error) {
+	blinding, err := randomNonZeroScalar()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate blinding: %w", err)
+	}
+
+	return &CommitmentOpening{
+		Commitment: PedersenCommit(value, blinding),
+		Blinding:   blinding,
+	}, nil
+}
+
+// CreateProofWithCommitments creates a selective disclosure proof that also
+// shows, for every entry of openings, that the hidden message at that index
+// is the value inside the external commitment. The verifier needs the
+// commitments but learns nothing else about the messages.
+func CreateProofWithCommitments(
+	publicKey *PublicKey,
+	signature *Signature,
+	messages []*big.Int,
+	disclosedIndices []int,
+	header []byte,
+	openings map[int]*CommitmentOpening,
+) (*ProofOfKnowledge, map[int]*big.Int, error) {
+	// Validate inputs
+	if len(messages) != publicKey.messageCount {
+		return nil, nil, ErrInvalidMessageCount
+	}
+
+	disclosedMessages, err := selectDisclosed(messages, disclosedIndices)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Each commitment must open to a hidden message
+	indices := sortedKeys(openings)
+	for _, idx := range indices {
+		if idx < 0 || idx >= len(messages) {
+			return nil, nil, fmt.Errorf("invalid commitment index: %d", idx)
+		}
+		if _, disclosed := disclosedMessages[idx]; disclosed {
+			return nil, nil, fmt.Errorf("message %d is disclosed and cannot be proven equal to a commitment", idx)
+		}
+
+		opening := openings[idx]
+		if opening == nil || opening.Blinding == nil {
+			return nil, nil, fmt.Errorf("missing commitment opening at index %d", idx)
+		}
+		expected := PedersenCommit(messages[idx], opening.Blinding)
+		if !expected.Equal(&opening.Commitment) {
+			return nil, nil, fmt.Errorf("commitment at index %d does not open to the message", idx)
+		}
+	}
+
+	// Calculate domain value
+	domain := CalculateDomain(publicKey, header)
+
+	witness, err := commitProof(publicKey, signature, messages, disclosedMessages, domain, rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer witness.wipe()
+
+	// Commit to T3_k = G * mBlind_k + H * rBlind_k, reusing the message
+	// blinding of the BBS proof so both responses share m_k
+	g, h := PedersenGenerators()
+	rBlind := make(map[int]*big.Int, len(indices))
+	commitments := make([]bls12381.G1Affine, len(indices))
+	t3 := make([]bls12381.G1Affine, len(indices))
+	defer func() {
+		for _, x := range rBlind {
+			secret.WipeInt(x)
+		}
+	}()
+
+	for k, idx := range indices {
+		rBlind[idx], err = RandomScalar(rand.Reader)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to generate blinding: %w", err)
+		}
+
+		T3Jac := scalarMulSumG1(
+			[]bls12381.G1Affine{g, h},
+			[]Scalar{witness.mBlind[idx], ScalarFromBigInt(rBlind[idx])},
+		)
+
+		commitments[k] = openings[idx].Commitment
+		t3[k] = g1JacToAffine(T3Jac)
+	}
+
+	// Compute the Fiat-Shamir challenge c over the BBS and commitment parts
+	cm := &witness.commitment
+	presentationHeader := commitmentEqualityHeader(indices, commitments, t3)
+	c := computeProofChallenge(cm.APrime, cm.ABar, cm.D, cm.T1, cm.T2, sortedKeys(disclosedMessages), disclosedMessages, domain, presentationHeader)
+
+	proof := witness.respond(c)
+
+	// Compute r_k^ = rBlind_k + r_k*c for each commitment
+	proof.CommitmentHat = make(map[int]*big.Int, len(indices))
+	for _, idx := range indices {
+		rHat := new(big.Int).Mul(openings[idx].Blinding, c)
+		rHat.Add(rHat, rBlind[idx])
+		rHat.Mod(rHat, Order)
+		proof.CommitmentHat[idx] = rHat
+	}
+
+	return proof, disclosedMessages, nil
+}
+
+// VerifyProofWithCommitments verifies a proof created by
+// CreateProofWithCommitments. commitments must hold exactly the external
+// commitments the proof was made for, keyed by message index.
+func VerifyProofWithCommitments(
+	publicKey *PublicKey,
+	proof *ProofOfKnowledge,
+	disclosedMessages map[int]*big.Int,
+	header []byte,
+	commitments map[int]bls12381.G1Affine,
+) error {
+	// Calculate domain value
+	domain := CalculateDomain(publicKey, header)
+
+	T1, T2, err := recomputeProofCommitments(publicKey, proof, disclosedMessages, domain)
+	if err != nil {
+		return err
+	}
+
+	if len(commitments) != len(proof.CommitmentHat) {
+		return ErrInvalidProof
+	}
+
+	// Recompute T3_k = G * m_k^ + H * r_k^ - C_k * c
+	g, h := PedersenGenerators()
+	negC := new(big.Int).Sub(Order, proof.C)
+
+	indices := sortedKeys(commitments)
+	t3 := make([]bls12381.G1Affine, len(indices))
+	points := make([]bls12381.G1Affine, len(indices))
+	for k, idx := range indices {
+		rHat, ok := proof.CommitmentHat[idx]
+		if !ok || rHat == nil {
+			return ErrInvalidProof
+		}
+		mHat, ok := proof.MHat[idx]
+		if !ok {
+			return fmt.Errorf("message %d is not hidden by the proof", idx)
+		}
+
+		points[k] = commitments[idx]
+		T3Jac, err := MultiScalarMulG1(
+			[]bls12381.G1Affine{g, h, points[k]},
+			[]*big.Int{mHat, rHat, negC},
+		)
+		if err != nil {
+			return fmt.Errorf("failed multi-scalar multiplication: %w", err)
+		}
+		t3[k] = g1JacToAffine(T3Jac)
+	}
+
+	// Check the challenge over the BBS and commitment parts
+	presentationHeader := commitmentEqualityHeader(indices, points, t3)
+	c := computeProofChallenge(proof.APrime, proof.ABar, proof.D, T1, T2, sortedKeys(disclosedMessages), disclosedMessages, domain, presentationHeader)
+	if !ConstantTimeEq(c, proof.C) {
+		return ErrInvalidSignature
+	}
+
+	return checkProofPairing(publicKey, proof)
+}
+
+// commitmentEqualityHeader encodes the commitments and their Schnorr
+// commitments for the proof challenge
+func commitmentEqualityHeader(indices []int, commitments, t3 []bls12381.G1Affine) []byte {
+	buff := make([]byte, 0, len(commitmentEqualityDST)+4+len(indices)*(4+2*G1Size))
+	buff = append(buff, commitmentEqualityDST...)
+	buff = appendUint32(buff, uint32(len(indices)))
+	for k, idx := range indices {
+		buff = appendUint32(buff, uint32(idx))
+		buff = appendG1(buff, &commitments[k])
+		buff = appendG1(buff, &t3[k])
+	}
+	return buff
+}
@@ -0,0 +1,120 @@
+package bbs
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381"
+)
+
+func TestCommitmentEqualityProof(t *testing.T) {
+	keyPair, err := GenerateKeyPair(4, rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key pair: %v", err)
+	}
+
+	messages := []*big.Int{big.NewInt(7), big.NewInt(1990), big.NewInt(42), big.NewInt(5)}
+	header := []byte("commitment header")
+
+	signature, err := Sign(keyPair.PrivateKey, keyPair.PublicKey, messages, header)
+	if err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+
+	// The holder registers commitments to messages 1 and 3 externally
+	opening1, err := CommitAttribute(messages[1])
+	if err != nil {
+		t.Fatalf("CommitAttribute failed: %v", err)
+	}
+	opening3, err := CommitAttribute(messages[3])
+	if err != nil {
+		t.Fatalf("CommitAttribute failed: %v", err)
+	}
+	openings := map[int]*CommitmentOpening{1: opening1, 3: opening3}
+	commitments := map[int]bls12381.G1Affine{1: opening1.Commitment, 3: opening3.Commitment}
+
+	proof, disclosed, err := CreateProofWithCommitments(keyPair.PublicKey, signature, messages, []int{0}, header, openings)
+	if err != nil {
+		t.Fatalf("CreateProofWithCommitments failed: %v", err)
+	}
+
+	if err := VerifyProofWithCommitments(keyPair.PublicKey, proof, disclosed, header, commitments); err != nil {
+		t.Fatalf("VerifyProofWithCommitments failed: %v", err)
+	}
+
+	// The equality responses survive serialization
+	decoded, err := DeserializeProof(SerializeProof(proof))
+	if err != nil {
+		t.Fatalf("DeserializeProof failed: %v", err)
+	}
+	if err := VerifyProofWithCommitments(keyPair.PublicKey, decoded, disclosed, header, commitments); err != nil {
+		t.Fatalf("VerifyProofWithCommitments after round trip failed: %v", err)
+	}
+
+	// The proof does not verify without its commitments
+	if err := VerifyProof(keyPair.PublicKey, proof, disclosed, header); err == nil {
+		t.Fatal("Proof verified without its commitments")
+	}
+	if err := VerifyProofWithCommitments(keyPair.PublicKey, proof, disclosed, header, map[int]bls12381.G1Affine{1: opening1.Commitment}); err == nil {
+		t.Fatal("Proof verified with a missing commitment")
+	}
+
+	// A commitment to another value is rejected
+	other, err := CommitAttribute(big.NewInt(2000))
+	if err != nil {
+		t.Fatalf("CommitAttribute failed: %v", err)
+	}
+	wrong := map[int]bls12381.G1Affine{1: other.Commitment, 3: opening3.Commitment}
+	if err := VerifyProofWithCommitments(keyPair.PublicKey, proof, disclosed, header, wrong); err == nil {
+		t.Fatal("Proof verified against a commitment to another value")
+	}
+
+	// Swapping the commitments between indices is rejected
+	swapped := map[int]bls12381.G1Affine{1: opening3.Commitment, 3: opening1.Commitment}
+	if err := VerifyProofWithCommitments(keyPair.PublicKey, proof, disclosed, header, swapped); err == nil {
+		t.Fatal("Proof verified with swapped commitments")
+	}
+
+	// A tampered equality response is rejected
+	proof.CommitmentHat[1] = new(big.Int).Add(proof.CommitmentHat[1], big.NewInt(1))
+	if err := VerifyProofWithCommitments(keyPair.PublicKey, proof, disclosed, header, commitments); err == nil {
+		t.Fatal("Proof with tampered response verified")
+	}
+}
+
+func TestCommitmentEqualityProofRejectsBadOpenings(t *testing.T) {
+	keyPair, err := GenerateKeyPair(3, rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key pair: %v", err)
+	}
+
+	messages := []*big.Int{big.NewInt(1), big.NewInt(2), big.NewInt(3)}
+	signature, err := Sign(keyPair.PrivateKey, keyPair.PublicKey, messages, nil)
+	if err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+
+	opening, err := CommitAttribute(big.NewInt(99))
+	if err != nil {
+		t.Fatalf("CommitAttribute failed: %v", err)
+	}
+
+	// The commitment does not open to the message
+	if _, _, err := CreateProofWithCommitments(keyPair.PublicKey, signature, messages, nil, nil, map[int]*CommitmentOpening{1: opening}); err == nil {
+		t.Fatal("Proof created for a commitment to another value")
+	}
+
+	// A disclosed message cannot be proven equal to a commitment
+	opening, err = CommitAttribute(messages[1])
+	if err != nil {
+		t.Fatalf("CommitAttribute failed: %v", err)
+	}
+	if _, _, err := CreateProofWithCommitments(keyPair.PublicKey, signature, messages, []int{1}, nil, map[int]*CommitmentOpening{1: opening}); err == nil {
+		t.Fatal("Proof created for a disclosed message")
+	}
+
+	if _, _, err := CreateProofWithCommitments(keyPair.PublicKey, signature, messages, nil, nil, map[int]*CommitmentOpening{5: opening}); err == nil {
+		t.Fatal("Proof created for an out-of-range index")
+	}
+}
@@ -0,0 +1,248 @@
+package bbs
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"sync"
+
+	"github.com/anupsv/bbsplus-signatures/pkg/crypto"
+	bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381"
+)
+
+// A compact public key is W, the message count and a seed, about 130 bytes
+// whatever the message count. The generators Q1, Q2 and H_1..H_L are hashed
+// from the seed, so a key with hundreds of messages no longer takes tens of
+// kilobytes to publish, and an issuer can print the compact key in every
+// credential it issues. Expanding a compact key costs a hash to the curve
+// per generator; generators are cached per seed, so each process pays it
+// once. The standard G1 and G2 generators are always used. A compact key
+// expands to an ordinary PublicKey, so Sign, Verify and the proof functions
+// are unchanged, and its Fingerprint is that of the expanded key.
+
+// Generator seed parameters
+const (
+	generatorSeedDST = "BBS_BLS12381_SEEDED_GENERATOR_"
+
+	// GeneratorSeedSize is the size of seeds made by NewGeneratorSeed
+	GeneratorSeedSize = 32
+
+	// MaxGeneratorSeedSize bounds the seed of a compact key
+	MaxGeneratorSeedSize = 64
+
+	// maxSeedCacheEntries bounds the number of seeds whose generators are cached
+	maxSeedCacheEntries = 64
+)
+
+// compactKeyHeaderSize is the encoding of W, the message count and the seed length
+const compactKeyHeaderSize = G2Size + 4 + 1
+
+// ErrNotCompactKey is returned when a public key's generators do not come
+// from the given seed
+var ErrNotCompactKey = errors.New("public key generators are not derived from the seed")
+
+// seedCache holds the generators derived from recently used seeds
+var seedCache = struct {
+	sync.Mutex
+	generators map[string][]bls12381.G1Affine
+}{generators: make(map[string][]bls12381.G1Affine)}
+
+// NewGeneratorSeed returns a random seed for GenerateKeyPairWithSeed
+func NewGeneratorSeed() ([]byte, error) {
+	seed := make([]byte, GeneratorSeedSize)
+	if _, err := rand.Read(seed); err != nil {
+		return nil, fmt.Errorf("failed to generate generator seed: %w", err)
+	}
+	return seed, nil
+}
+
+// checkGeneratorSeed validates the length of a seed
+func checkGeneratorSeed(seed []byte) error {
+	if len(seed) == 0 || len(seed) > MaxGeneratorSeedSize {
+		return fmt.Errorf("generator seed must be 1 to %d bytes, got %d", MaxGeneratorSeedSize, len(seed))
+	}
+	return nil
+}
+
+// SeededGenerators returns count generators hashed from seed: Q1, Q2 and one
+// per message, as in a key for count-2 messages. Results are cached per seed.
+func SeededGenerators(seed []byte, count int) ([]bls12381.G1Affine, error) {
+	if err := checkGeneratorSeed(seed); err != nil {
+		return nil, err
+	}
+	if count < 0 {
+		return nil, fmt.Errorf("invalid generator count: %d", count)
+	}
+
+	seedCache.Lock()
+	defer seedCache.Unlock()
+
+	cached := seedCache.generators[string(seed)]
+	if len(cached) < count {
+		extended := slices.Grow(slices.Clone(cached), count-len(cached))
+		for i := len(cached); i < count; i++ {
+			g, err := crypto.HashToG1(appendUint32(slices.Clone(seed), uint32(i)), []byte(generatorSeedDST))
+			if err != nil {
+				return nil, fmt.Errorf("failed to hash generator %d to G1: %w", i, err)
+			}
+			extended = append(extended, g)
+		}
+		if _, ok := seedCache.generators[string(seed)]; !ok && len(seedCache.generators) >= maxSeedCacheEntries {
+			for k := range seedCache.generators {
+				delete(seedCache.generators, k)
+				break
+			}
+		}
+		seedCache.generators[string(seed)] = extended
+		cached = extended
+	}
+	return slices.Clone(cached[:count]), nil
+}
+
+// GenerateKeyPairWithSeed creates a key pair like GenerateKeyPair whose
+// generators are derived from seed, so its public key has a compact form
+func GenerateKeyPairWithSeed(messageCount int, seed []byte, rng io.Reader) (*KeyPair, error) {
+	h, err := SeededGenerators(seed, messageCount+2)
+	if err != nil {
+		return nil, err
+	}
+	keyPair, err := GenerateKeyPair(messageCount, rng)
+	if err != nil {
+		return nil, err
+	}
+	keyPair.PublicKey.h = h
+	keyPair.PublicKey.seed = slices.Clone(seed)
+	return keyPair, nil
+}
+
+// CompactPublicKey is a public key whose generators are derived from a seed
+type CompactPublicKey struct {
+	w            bls12381.G2Affine
+	messageCount int
+	seed         []byte
+}
+
+// NewCompactPublicKey builds a compact key from W, the message count and the
+// generator seed
+func NewCompactPublicKey(w bls12381.G2Affine, messageCount int, seed []byte) (*CompactPublicKey, error) {
+	if err := checkGeneratorSeed(seed); err != nil {
+		return nil, err
+	}
+	if err := DefaultLimits.checkPublicKey(messageCount, messageCount+2); err != nil {
+		return nil, err
+	}
+	return &CompactPublicKey{w: w, messageCount: messageCount, seed: slices.Clone(seed)}, nil
+}
+
+// W returns W = g2*x
+func (c *CompactPublicKey) W() bls12381.G2Affine {
+	return c.w
+}
+
+// MessageCount returns the number of messages the key signs
+func (c *CompactPublicKey) MessageCount() int {
+	return c.messageCount
+}
+
+// Seed returns a copy of the generator seed
+func (c *CompactPublicKey) Seed() []byte {
+	return slices.Clone(c.seed)
+}
+
+// Expand derives the generators and returns the full public key
+func (c *CompactPublicKey) Expand() (*PublicKey, error) {
+	h, err := SeededGenerators(c.seed, c.messageCount+2)
+	if err != nil {
+		return nil, err
+	}
+	_, _, g1, g2 := bls12381.Generators()
+	return &PublicKey{
+		w:            c.w,
+		g2:           g2,
+		g1:           g1,
+		h:            h,
+		messageCount: c.messageCount,
+		seed:         slices.Clone(c.seed),
+	}, nil
+}
+
+// Compact returns the compact form of pk, which must use the standard G1 and
+// G2 generators and generators derived from seed. A nil seed uses the seed
+// the key was generated or expanded with; a key without one, such as a
+// deserialized full key, returns ErrNotCompactKey.
+func (pk *PublicKey) Compact(seed []byte) (*CompactPublicKey, error) {
+	if seed == nil {
+		seed = pk.seed
+	}
+	if seed == nil {
+		return nil, ErrNotCompactKey
+	}
+	h, err := SeededGenerators(seed, pk.messageCount+2)
+	if err != nil {
+		return nil, err
+	}
+	_, _, g1, g2 := bls12381.Generators()
+	if !pk.g1.Equal(&g1) || !pk.g2.Equal(&g2) || !AreG1PointsEqual(pk.h, h) {
+		return nil, ErrNotCompactKey
+	}
+	return NewCompactPublicKey(pk.w, pk.messageCount, seed)
+}
+
+// IsCompact reports whether pk was generated from or expanded from a seed,
+// so that pk.Compact(nil) succeeds
+func (pk *PublicKey) IsCompact() bool {
+	return pk.seed != nil
+}
+
+// SerializeCompactPublicKey serializes a compact key:
+// W (96 bytes) || message count (4 bytes) || seed length (1 byte) || seed
+func SerializeCompactPublicKey(c *CompactPublicKey) []byte {
+	result := make([]byte, 0, compactKeyHeaderSize+len(c.seed))
+	result = appendG2(result, &c.w)
+	result = appendUint32(result, uint32(c.messageCount))
+	result = append(result, byte(len(c.seed)))
+	return append(result, c.seed...)
+}
+
+// DeserializeCompactPublicKey deserializes a compact key, refusing message
+// counts beyond DefaultLimits
+func DeserializeCompactPublicKey(data []byte) (*CompactPublicKey, error) {
+	if !isCompactEncoding(data) || len(data) < compactKeyHeaderSize+1 || len(data) > compactKeyHeaderSize+MaxGeneratorSeedSize {
+		return nil, fmt.Errorf("invalid compact public key data")
+	}
+
+	r := &wireReader{data: data}
+	w := r.g2()
+	messageCount := int(r.uint32())
+	seed := r.lengthPrefixed(1)
+	if r.err == nil && r.remaining() != 0 {
+		r.err = errors.New("trailing bytes")
+	}
+	if r.err != nil {
+		return nil, fmt.Errorf("invalid compact public key data: %w", r.err)
+	}
+	return NewCompactPublicKey(w, messageCount, seed)
+}
+
+// isCompactPublicKey tells a compact key encoding from a full one, which is
+// always longer
+func isCompactPublicKey(data []byte) bool {
+	return isCompactEncoding(data) && len(data) <= compactKeyHeaderSize+MaxGeneratorSeedSize
+}
+
+// MarshalBinary encodes a CompactPublicKey into a binary form
+func (c *CompactPublicKey) MarshalBinary() ([]byte, error) {
+	return SerializeCompactPublicKey(c), nil
+}
+
+// UnmarshalBinary decodes a CompactPublicKey from a binary form
+func (c *CompactPublicKey) UnmarshalBinary(data []byte) error {
+	decoded, err := DeserializeCompactPublicKey(data)
+	if err != nil {
+		return err
+	}
+	*c = *decoded
+	return nil
+}
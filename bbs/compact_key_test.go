@@ -0,0 +1,107 @@
+package bbs
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"testing"
+)
+
+func TestCompactPublicKey(t *testing.T) {
+	seed, err := NewGeneratorSeed()
+	if err != nil {
+		t.Fatalf("NewGeneratorSeed failed: %v", err)
+	}
+	keyPair, err := GenerateKeyPairWithSeed(100, seed, rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKeyPairWithSeed failed: %v", err)
+	}
+
+	compact, err := keyPair.PublicKey.Compact(nil)
+	if err != nil {
+		t.Fatalf("Compact failed: %v", err)
+	}
+	data := SerializeCompactPublicKey(compact)
+	if len(data) > 200 {
+		t.Fatalf("Compact key is %d bytes", len(data))
+	}
+	if full := SerializePublicKey(keyPair.PublicKey); len(full) < 4000 {
+		t.Fatalf("Full key is only %d bytes", len(full))
+	}
+
+	// Both decoders expand the compact encoding to the same key
+	decoded, err := DeserializeCompactPublicKey(data)
+	if err != nil {
+		t.Fatalf("DeserializeCompactPublicKey failed: %v", err)
+	}
+	expanded, err := decoded.Expand()
+	if err != nil {
+		t.Fatalf("Expand failed: %v", err)
+	}
+	viaFull, err := DeserializePublicKey(data)
+	if err != nil {
+		t.Fatalf("DeserializePublicKey failed: %v", err)
+	}
+	for _, pk := range []*PublicKey{expanded, viaFull} {
+		if pk.Fingerprint() != keyPair.PublicKey.Fingerprint() {
+			t.Fatalf("Expanded key differs from the generated key")
+		}
+	}
+
+	// A signature under the generated key verifies under the expanded one
+	messages := make([]*big.Int, 100)
+	for i := range messages {
+		messages[i] = big.NewInt(int64(i))
+	}
+	signature, err := Sign(keyPair.PrivateKey, keyPair.PublicKey, messages, nil)
+	if err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+	if err := Verify(viaFull, signature, messages, nil); err != nil {
+		t.Fatalf("Verify under the expanded key failed: %v", err)
+	}
+
+	// A full key that went through serialization can still be compacted
+	// with its seed, but not with another or without one
+	roundTripped, err := DeserializePublicKey(SerializePublicKey(keyPair.PublicKey))
+	if err != nil {
+		t.Fatalf("DeserializePublicKey failed: %v", err)
+	}
+	if _, err := roundTripped.Compact(nil); !errors.Is(err, ErrNotCompactKey) {
+		t.Fatalf("Expected ErrNotCompactKey without a seed, got %v", err)
+	}
+	if _, err := roundTripped.Compact(seed); err != nil {
+		t.Fatalf("Compact with the seed failed: %v", err)
+	}
+	if _, err := roundTripped.Compact([]byte("another seed")); !errors.Is(err, ErrNotCompactKey) {
+		t.Fatalf("Expected ErrNotCompactKey for another seed, got %v", err)
+	}
+
+	// Malformed encodings are rejected
+	for _, bad := range [][]byte{data[:len(data)-1], append(data[:len(data):len(data)], 0), data[:compactKeyHeaderSize]} {
+		if _, err := DeserializeCompactPublicKey(bad); err == nil {
+			t.Fatalf("Malformed compact key of %d bytes accepted", len(bad))
+		}
+	}
+}
+
+func TestSeededGenerators(t *testing.T) {
+	short, err := SeededGenerators([]byte("seed"), 3)
+	if err != nil {
+		t.Fatalf("SeededGenerators failed: %v", err)
+	}
+	long, err := SeededGenerators([]byte("seed"), 5)
+	if err != nil {
+		t.Fatalf("SeededGenerators failed: %v", err)
+	}
+	if !AreG1PointsEqual(short, long[:3]) {
+		t.Fatalf("Generators depend on the count")
+	}
+	other, _ := SeededGenerators([]byte("other"), 3)
+	if AreG1PointsEqual(short, other) {
+		t.Fatalf("Different seeds gave the same generators")
+	}
+	if _, err := SeededGenerators(nil, 3); err == nil {
+		t.Fatalf("Empty seed accepted")
+	}
+}
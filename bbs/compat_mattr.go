@@ -0,0 +1,143 @@
+package bbs
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"math/big"
+
+	"github.com/anupsv/bbsplus-signatures/pkg/crypto"
+	bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381"
+)
+
+// Credentials issued with the mattrglobal bbs-signatures library (the Node
+// package over the Rust bbs crate) predate the BBS specification. They map a
+// message to BLAKE2b-384(msg) mod Order, derive the generators of each
+// message count from the issuer's 96-byte BLS12-381 G2 key, and sign
+// B = P1 + h0*s + h1*m1 + ... + hL*mL with no domain term. MattrProfile
+// replicates these choices so such credentials can be verified while they
+// are reissued under this package's keys. It verifies signatures only:
+// mattr proofs have another layout and are not supported. mattr signatures
+// are A (compressed) || e || s like SerializeSignature, so
+// DeserializeSignature reads them.
+
+// mattrGeneratorDST is the hash_to_curve DST of the mattr generators
+const mattrGeneratorDST = "BLS12381G1_XMD:BLAKE2B_SSWU_RO_BBS+_SIGNATURES:1_0_0"
+
+// ErrInvalidMattrProfile is returned for hash constructors of the wrong size
+var ErrInvalidMattrProfile = errors.New("invalid mattr compatibility profile")
+
+// MattrProfile verifies signatures made by the mattrglobal library. The
+// package has no BLAKE2b, so the constructors are injected like the XOF of
+// NewBLS12381SHAKE256.
+type MattrProfile struct {
+	expand      ExpandMessageFunc
+	messageHash func() hash.Hash
+}
+
+// NewMattrProfile returns the mattr profile. newBlake2b512 and newBlake2b384
+// must return fresh BLAKE2b instances with 64 and 48 bytes of output, for
+// example blake2b.New512 and blake2b.New384 with a nil key.
+func NewMattrProfile(newBlake2b512, newBlake2b384 func() hash.Hash) (*MattrProfile, error) {
+	if newBlake2b512 == nil || newBlake2b384 == nil {
+		return nil, ErrInvalidMattrProfile
+	}
+	if newBlake2b512().Size() != 64 || newBlake2b384().Size() != 48 {
+		return nil, fmt.Errorf("%w: expected 64 and 48 byte digests", ErrInvalidMattrProfile)
+	}
+
+	return &MattrProfile{
+		expand:      ExpandMessageXMDWith(newBlake2b512),
+		messageHash: newBlake2b384,
+	}, nil
+}
+
+// MapMessageToScalar maps a message as the mattr library does, as its
+// BLAKE2b-384 digest reduced modulo Order
+func (p *MattrProfile) MapMessageToScalar(msg []byte) *big.Int {
+	h := p.messageHash()
+	h.Write(msg)
+	scalar := new(big.Int).SetBytes(h.Sum(nil))
+	return scalar.Mod(scalar, Order)
+}
+
+// MattrPublicKey is a mattr issuer key expanded for a message count
+type MattrPublicKey struct {
+	W            bls12381.G2Affine
+	H0           bls12381.G1Affine
+	H            []bls12381.G1Affine
+	MessageCount int
+}
+
+// PublicKey derives the generators of messageCount messages under w. h0 is
+// hashed from w || 0 || I2OSP(messageCount, 4) || I2OSP(0, 4) and hi from
+// the same input ending in I2OSP(i, 4).
+func (p *MattrProfile) PublicKey(w bls12381.G2Affine, messageCount int) (*MattrPublicKey, error) {
+	if messageCount <= 0 {
+		return nil, ErrInvalidMessageCount
+	}
+
+	wBytes := w.Bytes()
+	input := make([]byte, 0, len(wBytes)+9)
+	input = append(input, wBytes[:]...)
+	input = append(input, 0)
+	input = binary.BigEndian.AppendUint32(input, uint32(messageCount))
+
+	generator := func(i int) (bls12381.G1Affine, error) {
+		return p.hashToG1(binary.BigEndian.AppendUint32(input, uint32(i)))
+	}
+
+	pk := &MattrPublicKey{
+		W:            w,
+		H:            make([]bls12381.G1Affine, messageCount),
+		MessageCount: messageCount,
+	}
+	var err error
+	if pk.H0, err = generator(0); err != nil {
+		return nil, err
+	}
+	for i := range pk.H {
+		if pk.H[i], err = generator(i + 1); err != nil {
+			return nil, err
+		}
+	}
+	return pk, nil
+}
+
+// Verify checks a mattr signature on messages mapped with MapMessageToScalar
+func (p *MattrProfile) Verify(pk *MattrPublicKey, signature *Signature, messages []*big.Int) error {
+	if pk == nil || signature == nil {
+		return ErrInvalidSignature
+	}
+	if len(messages) != pk.MessageCount || len(pk.H) != pk.MessageCount {
+		return ErrInvalidMessageCount
+	}
+
+	return verifyWithDomain(pk.bbsKey(), signature, messages, new(big.Int))
+}
+
+// bbsKey lays pk out as a PublicKey with Q1 = h0. Under a zero domain the Q2
+// term vanishes and B is the mattr B, whatever Q2 is.
+func (pk *MattrPublicKey) bbsKey() *PublicKey {
+	_, _, g1, g2 := bls12381.Generators()
+	H := make([]bls12381.G1Affine, 0, len(pk.H)+2)
+	H = append(H, pk.H0, pk.H0)
+	H = append(H, pk.H...)
+	return &PublicKey{w: pk.W, g1: g1, g2: g2, h: H, messageCount: pk.MessageCount}
+}
+
+// hashToG1 is hash_to_curve from RFC 9380 for G1, over the profile's
+// expand_message
+func (p *MattrProfile) hashToG1(msg []byte) (bls12381.G1Affine, error) {
+	return crypto.HashToG1With(msg, []byte(mattrGeneratorDST), crypto.ExpandMessageFunc(p.expand))
+}
+
+// ExpandMessageXMDHash returns expand_message_xmd from RFC 9380 over the
+// hash returned by newHash
+//
+// Deprecated: use ExpandMessageXMDWith, which it equals.
+func ExpandMessageXMDHash(newHash func() hash.Hash) ExpandMessageFunc {
+	warnDeprecated("ExpandMessageXMDHash", "ExpandMessageXMDWith")
+	return ExpandMessageXMDWith(newHash)
+}
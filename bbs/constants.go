@@ -29,6 +29,36 @@ var (
 	// ErrInvalidArrayLengths is returned when the lengths of input arrays don't match
 	ErrInvalidArrayLengths = errors.New("mismatched input array lengths")
 
+	// ErrInvalidHolderBinding is returned when a holder binding fails verification
+	ErrInvalidHolderBinding = errors.New("invalid holder binding")
+
+	// ErrSessionUsed is returned when an interactive proof session is used twice
+	ErrSessionUsed = errors.New("interactive proof session already used")
+
+	// ErrChallengeMismatch is returned when a challenge or response belongs to another session
+	ErrChallengeMismatch = errors.New("challenge does not belong to this session")
+
+	// ErrInvalidFieldElement is returned when a value is not a reduced scalar
+	ErrInvalidFieldElement = errors.New("invalid field element")
+
+	// ErrProofReplayed is returned when a replay guard has already seen a proof
+	ErrProofReplayed = errors.New("proof already presented")
+
+	// ErrStaleCredential is returned when a credential fails a freshness policy
+	ErrStaleCredential = errors.New("credential is not fresh")
+
+	// ErrInvalidCheckpoint is returned when a prover checkpoint cannot be decrypted or decoded
+	ErrInvalidCheckpoint = errors.New("invalid prover checkpoint")
+
+	// ErrUnsuitableBLSKey is returned when an imported BLS key cannot serve as a BBS+ key
+	ErrUnsuitableBLSKey = errors.New("BLS key unsuitable for BBS+")
+
+	// ErrPolicyViolation is returned when a signature would break a key policy
+	ErrPolicyViolation = errors.New("key policy violation")
+
+	// ErrInvalidTimestampToken is returned when a verifier timestamp token is forged or too old
+	ErrInvalidTimestampToken = errors.New("invalid timestamp token")
+
 	// Order of the groups G1, G2, and GT for BLS12-381
 	// BLS12-381 curve order: 0x73eda753299d7d483339d80809a1d80553bda402fffe5bfeffffffff00000001
 	Order, _ = new(big.Int).SetString("52435875175126190479447740508185965837690552500527637822603658699938581184513", 10)
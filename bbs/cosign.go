@@ -0,0 +1,305 @@
+package bbs
+
+import (
+	"crypto/rand"
+	"fmt"
+	"maps"
+	"math/big"
+
+	bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381"
+)
+
+// Domain separation tag for co-signed credential proofs
+const coSignedProofDST = "BBS_BLS12381_CO_SIGNED_PROOF_"
+
+// A credential co-signed by several issuers, such as a university and an
+// accreditation body, carries one ordinary signature per issuer over the same
+// messages. Aggregating the issuers' keys instead would need an interactive
+// signing protocol like ThresholdSign, which independent issuers rarely run.
+//
+// A presentation holds one proof per signature, made together: the proofs
+// share a single Fiat-Shamir challenge and the blinding of every hidden
+// message, so equal responses show the hidden messages are the same under
+// every signature. The presentation header, such as a verifier nonce, is
+// bound into the shared challenge as for single proofs. The verifier checks
+// all of it in one VerifyCoSignedProof call.
+
+// CoSignedCredential holds the signatures of several issuers on one message
+// vector
+type CoSignedCredential struct {
+	PublicKeys []*PublicKey
+	Signatures []*Signature
+}
+
+// NewCoSignedCredential collects issuer signatures on messages, checking each
+// of them
+func NewCoSignedCredential(
+	publicKeys []*PublicKey,
+	signatures []*Signature,
+	messages []*big.Int,
+	header []byte,
+) (*CoSignedCredential, error) {
+	cred := &CoSignedCredential{PublicKeys: publicKeys, Signatures: signatures}
+	if err := cred.Verify(messages, header); err != nil {
+		return nil, err
+	}
+	return cred, nil
+}
+
+// Verify checks every issuer signature on messages
+func (c *CoSignedCredential) Verify(messages []*big.Int, header []byte) error {
+	if err := checkCoSigners(c.PublicKeys, len(c.Signatures)); err != nil {
+		return err
+	}
+
+	for i, pk := range c.PublicKeys {
+		if err := Verify(pk, c.Signatures[i], messages, header); err != nil {
+			return fmt.Errorf("issuer %d: %w", i, err)
+		}
+	}
+
+	return nil
+}
+
+// CoSignedProof is a presentation of a co-signed credential, with one proof
+// per issuer in the order of the credential's public keys
+type CoSignedProof struct {
+	Proofs []*ProofOfKnowledge
+}
+
+// CreateProof creates a proof disclosing the messages at disclosedIndices
+// under every issuer signature, bound to presentationHeader
+func (c *CoSignedCredential) CreateProof(
+	messages []*big.Int,
+	disclosedIndices []int,
+	header []byte,
+	presentationHeader []byte,
+) (*CoSignedProof, map[int]*big.Int, error) {
+	// Validate inputs
+	if err := checkCoSigners(c.PublicKeys, len(c.Signatures)); err != nil {
+		return nil, nil, err
+	}
+	if len(messages) != c.PublicKeys[0].messageCount {
+		return nil, nil, ErrInvalidMessageCount
+	}
+
+	disclosedMessages := make(map[int]*big.Int)
+	for _, idx := range disclosedIndices {
+		if idx < 0 || idx >= len(messages) {
+			return nil, nil, fmt.Errorf("invalid disclosed index: %d", idx)
+		}
+		if _, dup := disclosedMessages[idx]; dup {
+			return nil, nil, fmt.Errorf("duplicate disclosed index: %d", idx)
+		}
+		disclosedMessages[idx] = messages[idx]
+	}
+
+	domains := make([]*big.Int, len(c.PublicKeys))
+	witnesses := make([]*proofWitness, 0, len(c.PublicKeys))
+	defer func() {
+		for _, w := range witnesses {
+			w.wipe()
+		}
+	}()
+
+	for i, pk := range c.PublicKeys {
+		domains[i] = CalculateDomain(pk, header)
+		B := computeB(pk, c.Signatures[i].S, domains[i], messages)
+
+		witness, err := blindProof(c.Signatures[i], B, messages, disclosedMessages, rand.Reader)
+		if err != nil {
+			return nil, nil, err
+		}
+		witnesses = append(witnesses, witness)
+
+		// Reuse the first proof's message blinding so the responses match,
+		// wiping the blinding drawn for this proof
+		if i > 0 {
+			for idx := range witness.mBlind {
+				witness.mBlind[idx] = Scalar{}
+			}
+			maps.Copy(witness.mBlind, witnesses[0].mBlind)
+		}
+
+		// Compute T2 = D * r3Blind + Q1 * sBlind + sum(H_j * mBlind_j) for undisclosed j
+		t2Points, t2Scalars := witness.t2Terms(pk)
+		witness.commitment.T2 = g1JacToAffine(scalarMulSumG1(t2Points, t2Scalars))
+	}
+
+	// Compute the shared Fiat-Shamir challenge over all the commitments
+	commitments := make([]*ProofCommitment, len(witnesses))
+	for i, w := range witnesses {
+		commitments[i] = &w.commitment
+	}
+	c0 := commitments[0]
+	challengeHeader := coSignedProofHeader(presentationHeader, commitments[1:], domains[1:])
+	challenge := computeProofChallenge(c0.APrime, c0.ABar, c0.D, c0.T1, c0.T2, sortedKeys(disclosedMessages), disclosedMessages, domains[0], challengeHeader)
+
+	proof := &CoSignedProof{Proofs: make([]*ProofOfKnowledge, len(witnesses))}
+	for i, w := range witnesses {
+		proof.Proofs[i] = w.respond(challenge)
+	}
+
+	return proof, disclosedMessages, nil
+}
+
+// VerifyCoSignedProof verifies a co-signed credential presentation: every
+// proof against its issuer key, the shared challenge over presentationHeader,
+// and that the hidden messages are the same under every signature
+func VerifyCoSignedProof(
+	publicKeys []*PublicKey,
+	proof *CoSignedProof,
+	disclosedMessages map[int]*big.Int,
+	header []byte,
+	presentationHeader []byte,
+) error {
+	if proof == nil {
+		return ErrInvalidProof
+	}
+	if err := checkCoSigners(publicKeys, len(proof.Proofs)); err != nil {
+		return err
+	}
+
+	first := proof.Proofs[0]
+	if first == nil || first.C == nil {
+		return ErrInvalidProof
+	}
+
+	domains := make([]*big.Int, len(publicKeys))
+	commitments := make([]*ProofCommitment, len(publicKeys))
+	for i, pk := range publicKeys {
+		p := proof.Proofs[i]
+		if p == nil || p.C == nil || len(p.CommitmentHat) > 0 {
+			return ErrInvalidProof
+		}
+
+		// All proofs answer the same challenge with the same hidden responses
+		if !ConstantTimeEq(p.C, first.C) || !sameResponses(p.MHat, first.MHat) {
+			return fmt.Errorf("issuer %d: %w", i, ErrInvalidProof)
+		}
+
+		domains[i] = CalculateDomain(pk, header)
+		T1, T2, err := recomputeProofCommitments(pk, p, disclosedMessages, domains[i])
+		if err != nil {
+			return fmt.Errorf("issuer %d: %w", i, err)
+		}
+		commitments[i] = &ProofCommitment{APrime: p.APrime, ABar: p.ABar, D: p.D, T1: T1, T2: T2}
+	}
+
+	// Check the shared challenge
+	c0 := commitments[0]
+	challengeHeader := coSignedProofHeader(presentationHeader, commitments[1:], domains[1:])
+	c := computeProofChallenge(c0.APrime, c0.ABar, c0.D, c0.T1, c0.T2, sortedKeys(disclosedMessages), disclosedMessages, domains[0], challengeHeader)
+	if !ConstantTimeEq(c, first.C) {
+		return ErrInvalidSignature
+	}
+
+	for i, pk := range publicKeys {
+		if err := checkProofPairing(pk, proof.Proofs[i]); err != nil {
+			return fmt.Errorf("issuer %d: %w", i, err)
+		}
+	}
+
+	return nil
+}
+
+// checkCoSigners checks there are at least two issuers with matching
+// message counts and one signature or proof each
+func checkCoSigners(publicKeys []*PublicKey, count int) error {
+	if len(publicKeys) < 2 {
+		return fmt.Errorf("co-signed credential needs at least two issuers, got %d", len(publicKeys))
+	}
+	if count != len(publicKeys) {
+		return ErrInvalidArrayLengths
+	}
+
+	for _, pk := range publicKeys {
+		if pk == nil {
+			return fmt.Errorf("missing issuer public key")
+		}
+		if pk.messageCount != publicKeys[0].messageCount {
+			return ErrInvalidMessageCount
+		}
+	}
+
+	return nil
+}
+
+// sameResponses reports whether a and b hold equal responses for the same
+// message indices
+func sameResponses(a, b map[int]*big.Int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for idx, x := range a {
+		y, ok := b[idx]
+		if !ok || x == nil || y == nil || !ConstantTimeEq(x, y) {
+			return false
+		}
+	}
+	return true
+}
+
+// coSignedProofHeader encodes the presentation header, then the commitments
+// and domains of the other issuers' proofs, for the shared challenge
+func coSignedProofHeader(presentationHeader []byte, commitments []*ProofCommitment, domains []*big.Int) []byte {
+	buff := make([]byte, 0, len(coSignedProofDST)+8+len(presentationHeader)+len(commitments)*(5*G1Size+ScalarSize))
+	buff = append(buff, coSignedProofDST...)
+	buff = appendUint32(buff, uint32(len(presentationHeader)))
+	buff = append(buff, presentationHeader...)
+	buff = appendUint32(buff, uint32(len(commitments)))
+	for i, cm := range commitments {
+		for _, p := range []*bls12381.G1Affine{&cm.APrime, &cm.ABar, &cm.D, &cm.T1, &cm.T2} {
+			buff = appendG1(buff, p)
+		}
+		buff = appendScalar(buff, domains[i])
+	}
+	return buff
+}
+
+// SerializeCoSignedProof encodes a co-signed proof as count || (length ||
+// proof)* with each proof in the SerializeProof format
+func SerializeCoSignedProof(proof *CoSignedProof) []byte {
+	result := appendUint32(nil, uint32(len(proof.Proofs)))
+	for _, p := range proof.Proofs {
+		encoded := SerializeProof(p)
+		result = appendUint32(result, uint32(len(encoded)))
+		result = append(result, encoded...)
+	}
+	return result
+}
+
+// DeserializeCoSignedProof decodes a proof written by SerializeCoSignedProof.
+// The whole encoding counts against DefaultLimits.MaxProofBytes.
+func DeserializeCoSignedProof(data []byte) (*CoSignedProof, error) {
+	if err := DefaultLimits.CheckProofBytes(len(data)); err != nil {
+		return nil, err
+	}
+	r := &wireReader{data: data}
+
+	// Every proof takes at least its length prefix
+	count := r.uint32()
+	if r.err != nil || uint64(count)*4 > uint64(r.remaining()) {
+		return nil, ErrInvalidProofData
+	}
+
+	proof := &CoSignedProof{Proofs: make([]*ProofOfKnowledge, count)}
+	for i := range proof.Proofs {
+		size := r.uint32()
+		if r.err != nil || uint64(size) > uint64(r.remaining()) {
+			return nil, ErrInvalidProofData
+		}
+
+		p, err := DeserializeProof(r.next(int(size)))
+		if err != nil {
+			return nil, err
+		}
+		proof.Proofs[i] = p
+	}
+
+	if r.remaining() != 0 {
+		return nil, ErrInvalidProofData
+	}
+
+	return proof, nil
+}
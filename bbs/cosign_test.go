@@ -0,0 +1,120 @@
+package bbs
+
+import (
+	"math/big"
+	"testing"
+)
+
+func coSignedFixture(t *testing.T, messages []*big.Int, header []byte) *CoSignedCredential {
+	t.Helper()
+
+	var publicKeys []*PublicKey
+	var signatures []*Signature
+	for i := 0; i < 2; i++ {
+		keyPair, signature := signedFixture(t, messages, header)
+		publicKeys = append(publicKeys, keyPair.PublicKey)
+		signatures = append(signatures, signature)
+	}
+
+	cred, err := NewCoSignedCredential(publicKeys, signatures, messages, header)
+	if err != nil {
+		t.Fatalf("NewCoSignedCredential failed: %v", err)
+	}
+	return cred
+}
+
+func TestCoSignedProof(t *testing.T) {
+	header := []byte("diploma")
+	messages := []*big.Int{big.NewInt(1), big.NewInt(2), big.NewInt(3), big.NewInt(4)}
+	nonce := []byte("verifier nonce")
+	cred := coSignedFixture(t, messages, header)
+
+	for _, disclosedIndices := range [][]int{{0, 2}, nil, {0, 1, 2, 3}} {
+		proof, disclosed, err := cred.CreateProof(messages, disclosedIndices, header, nonce)
+		if err != nil {
+			t.Fatalf("CreateProof failed: %v", err)
+		}
+		if err := VerifyCoSignedProof(cred.PublicKeys, proof, disclosed, header, nonce); err != nil {
+			t.Fatalf("VerifyCoSignedProof(%v) failed: %v", disclosedIndices, err)
+		}
+
+		// Round trip through the wire format
+		decoded, err := DeserializeCoSignedProof(SerializeCoSignedProof(proof))
+		if err != nil {
+			t.Fatalf("DeserializeCoSignedProof failed: %v", err)
+		}
+		if err := VerifyCoSignedProof(cred.PublicKeys, decoded, disclosed, header, nonce); err != nil {
+			t.Fatalf("decoded proof failed: %v", err)
+		}
+	}
+
+	proof, disclosed, err := cred.CreateProof(messages, []int{1}, header, nonce)
+	if err != nil {
+		t.Fatalf("CreateProof failed: %v", err)
+	}
+
+	// Keys in the wrong order
+	swapped := []*PublicKey{cred.PublicKeys[1], cred.PublicKeys[0]}
+	if err := VerifyCoSignedProof(swapped, proof, disclosed, header, nonce); err == nil {
+		t.Fatal("proof verified against swapped keys")
+	}
+
+	// Wrong header
+	if err := VerifyCoSignedProof(cred.PublicKeys, proof, disclosed, []byte("other"), nonce); err == nil {
+		t.Fatal("proof verified with a different header")
+	}
+
+	// Replayed to a verifier with another nonce
+	if err := VerifyCoSignedProof(cred.PublicKeys, proof, disclosed, header, []byte("other nonce")); err == nil {
+		t.Fatal("proof verified with a different presentation header")
+	}
+	if err := VerifyCoSignedProof(cred.PublicKeys, proof, disclosed, header, nil); err == nil {
+		t.Fatal("proof verified without its presentation header")
+	}
+
+	// Wrong disclosed value
+	wrong := map[int]*big.Int{1: big.NewInt(5)}
+	if err := VerifyCoSignedProof(cred.PublicKeys, proof, wrong, header, nonce); err == nil {
+		t.Fatal("proof verified with a wrong disclosed message")
+	}
+
+	// A single proof is not a co-signed proof
+	single := &CoSignedProof{Proofs: proof.Proofs[:1]}
+	if err := VerifyCoSignedProof(cred.PublicKeys[:1], single, disclosed, header, nonce); err == nil {
+		t.Fatal("single issuer proof accepted")
+	}
+}
+
+func TestCoSignedProof_RejectsMixedCredentials(t *testing.T) {
+	header := []byte("diploma")
+	messages := []*big.Int{big.NewInt(1), big.NewInt(2), big.NewInt(3)}
+	other := []*big.Int{big.NewInt(1), big.NewInt(2), big.NewInt(9)}
+
+	cred := coSignedFixture(t, messages, header)
+	otherCred := coSignedFixture(t, other, header)
+
+	// Issuer 1 signed different messages, so the pair is not a co-signature
+	mixed := &CoSignedCredential{
+		PublicKeys: []*PublicKey{cred.PublicKeys[0], otherCred.PublicKeys[1]},
+		Signatures: []*Signature{cred.Signatures[0], otherCred.Signatures[1]},
+	}
+	if err := mixed.Verify(messages, header); err == nil {
+		t.Fatal("mixed credential verified")
+	}
+
+	// Splicing proofs of two separate presentations breaks the shared challenge
+	p1, disclosed, err := cred.CreateProof(messages, []int{0}, header, nil)
+	if err != nil {
+		t.Fatalf("CreateProof failed: %v", err)
+	}
+	p2, _, err := otherCred.CreateProof(other, []int{0}, header, nil)
+	if err != nil {
+		t.Fatalf("CreateProof failed: %v", err)
+	}
+
+	spliced := &CoSignedProof{Proofs: []*ProofOfKnowledge{p1.Proofs[0], p2.Proofs[1]}}
+	keys := []*PublicKey{cred.PublicKeys[0], otherCred.PublicKeys[1]}
+	if err := VerifyCoSignedProof(keys, spliced, disclosed, header, nil); err == nil {
+		t.Fatal("spliced proof verified")
+	}
+}
@@ -0,0 +1,70 @@
+package bbs
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+)
+
+// APIs that duplicate others are marked Deprecated in their doc comment
+// and kept until the next major version. Each also reports its first call
+// in a process, so a service finds out which code paths still use it
+// before the API goes away. By default the warning is logged; a handler set
+// with SetDeprecationHandler can count it, or fail a test run on it.
+
+// ErrDeprecated matches every DeprecationWarning with errors.Is
+var ErrDeprecated = errors.New("deprecated API")
+
+// DeprecationWarning reports the first call of a deprecated API
+type DeprecationWarning struct {
+	// API is the name of the deprecated function
+	API string
+
+	// Replacement is the function to use instead
+	Replacement string
+}
+
+// Error describes the warning
+func (w *DeprecationWarning) Error() string {
+	return fmt.Sprintf("%s is deprecated, use %s instead", w.API, w.Replacement)
+}
+
+// Is reports whether target is ErrDeprecated
+func (w *DeprecationWarning) Is(target error) bool {
+	return target == ErrDeprecated
+}
+
+var (
+	deprecationMu      sync.Mutex
+	deprecationHandler func(*DeprecationWarning)
+	deprecationSeen    = make(map[string]bool)
+)
+
+// SetDeprecationHandler sets the function that receives deprecation
+// warnings, in place of the log. nil restores the log. Each API is reported
+// once per process, whichever handler is set at the time.
+func SetDeprecationHandler(handler func(*DeprecationWarning)) {
+	deprecationMu.Lock()
+	defer deprecationMu.Unlock()
+	deprecationHandler = handler
+}
+
+// warnDeprecated reports the first call of api
+func warnDeprecated(api, replacement string) {
+	deprecationMu.Lock()
+	if deprecationSeen[api] {
+		deprecationMu.Unlock()
+		return
+	}
+	deprecationSeen[api] = true
+	handler := deprecationHandler
+	deprecationMu.Unlock()
+
+	w := &DeprecationWarning{API: api, Replacement: replacement}
+	if handler != nil {
+		handler(w)
+		return
+	}
+	log.Printf("bbs: %v", w)
+}
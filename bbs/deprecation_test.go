@@ -0,0 +1,32 @@
+package bbs
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestDeprecationWarning(t *testing.T) {
+	var warnings []*DeprecationWarning
+	SetDeprecationHandler(func(w *DeprecationWarning) {
+		warnings = append(warnings, w)
+	})
+	defer SetDeprecationHandler(nil)
+
+	// Each API is reported on its first call only
+	warnDeprecated("TestOldAPI", "TestNewAPI")
+	warnDeprecated("TestOldAPI", "TestNewAPI")
+	warnDeprecated("TestOtherAPI", "TestNewAPI")
+
+	if len(warnings) != 2 {
+		t.Fatalf("Expected 2 warnings, got %d", len(warnings))
+	}
+	if warnings[0].API != "TestOldAPI" || warnings[0].Replacement != "TestNewAPI" {
+		t.Fatalf("Unexpected warning %+v", warnings[0])
+	}
+	if !errors.Is(warnings[0], ErrDeprecated) {
+		t.Fatalf("Expected the warning to match ErrDeprecated")
+	}
+	if want := "TestOldAPI is deprecated, use TestNewAPI instead"; warnings[0].Error() != want {
+		t.Fatalf("Expected %q, got %q", want, warnings[0].Error())
+	}
+}
@@ -0,0 +1,81 @@
+package bbs
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+)
+
+// A holder often derives several presentations from one credential in a
+// session. B = P1 + Q1*s + Q2*domain + sum(H_i * m_i) depends only on the
+// signature, the messages and the header, and summing it costs one scalar
+// multiplication per message, the largest share of a proof for a credential
+// of any size. DeriveMany computes the domain and B once and shares them
+// between the proofs. Everything that blinds the signature, r1, r2 and the
+// Schnorr blinding factors, is drawn afresh for each proof, so the proofs
+// are as unlinkable as ones made by separate CreateProof calls.
+
+// DisclosureSpec describes one presentation for DeriveMany
+type DisclosureSpec struct {
+	// DisclosedIndices lists the messages the presentation reveals
+	DisclosedIndices []int
+
+	// PresentationHeader is bound into the proof's challenge, such as the
+	// nonce of the verifier it is for. It may be nil.
+	PresentationHeader []byte
+}
+
+// DerivedProof is one presentation made by DeriveMany
+type DerivedProof struct {
+	Proof             *ProofOfKnowledge
+	DisclosedMessages map[int]*big.Int
+}
+
+// DeriveMany creates one proof per spec from the same signature, in the
+// order of specs. The result equals calling CreateProofWithPresentationHeader
+// for each spec, at the cost of computing B once. Every spec is checked
+// before any proof is made.
+func DeriveMany(
+	publicKey *PublicKey,
+	signature *Signature,
+	messages []*big.Int,
+	header []byte,
+	specs []DisclosureSpec,
+) ([]DerivedProof, error) {
+	if len(messages) != publicKey.messageCount {
+		return nil, ErrInvalidMessageCount
+	}
+	if signature == nil {
+		return nil, ErrInvalidSignature
+	}
+
+	disclosed := make([]map[int]*big.Int, len(specs))
+	for i, spec := range specs {
+		d, err := selectDisclosed(messages, spec.DisclosedIndices)
+		if err != nil {
+			return nil, fmt.Errorf("presentation %d: %w", i, err)
+		}
+		disclosed[i] = d
+	}
+
+	domain := CalculateDomain(publicKey, header)
+	B := computeB(publicKey, signature.S, domain, messages)
+
+	results := make([]DerivedProof, len(specs))
+	for i, spec := range specs {
+		witness, err := blindProof(signature, B, messages, disclosed[i], rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+
+		t2Points, t2Scalars := witness.t2Terms(publicKey)
+		witness.commitment.T2 = g1JacToAffine(scalarMulSumG1(t2Points, t2Scalars))
+
+		cm := &witness.commitment
+		c := computeProofChallenge(cm.APrime, cm.ABar, cm.D, cm.T1, cm.T2, sortedKeys(disclosed[i]), disclosed[i], domain, spec.PresentationHeader)
+		results[i] = DerivedProof{Proof: witness.respond(c), DisclosedMessages: disclosed[i]}
+		witness.wipe()
+	}
+
+	return results, nil
+}
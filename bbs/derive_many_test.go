@@ -0,0 +1,96 @@
+package bbs
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+)
+
+func TestDeriveMany(t *testing.T) {
+	keyPair, err := GenerateKeyPair(5, rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key pair: %v", err)
+	}
+	messages := []*big.Int{big.NewInt(1), big.NewInt(2), big.NewInt(3), big.NewInt(4), big.NewInt(5)}
+	header := []byte("derive many header")
+	signature, err := Sign(keyPair.PrivateKey, keyPair.PublicKey, messages, header)
+	if err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+
+	specs := []DisclosureSpec{
+		{DisclosedIndices: []int{0}},
+		{DisclosedIndices: []int{1, 3}, PresentationHeader: []byte("nonce")},
+		{DisclosedIndices: []int{0}},
+		{},
+	}
+	results, err := DeriveMany(keyPair.PublicKey, signature, messages, header, specs)
+	if err != nil {
+		t.Fatalf("DeriveMany failed: %v", err)
+	}
+	if len(results) != len(specs) {
+		t.Fatalf("Expected %d proofs, got %d", len(specs), len(results))
+	}
+
+	for i, result := range results {
+		if len(result.DisclosedMessages) != len(specs[i].DisclosedIndices) {
+			t.Fatalf("Proof %d discloses %d messages, expected %d", i, len(result.DisclosedMessages), len(specs[i].DisclosedIndices))
+		}
+		err := VerifyProofWithPresentationHeader(keyPair.PublicKey, result.Proof, result.DisclosedMessages, header, specs[i].PresentationHeader)
+		if err != nil {
+			t.Fatalf("Proof %d does not verify: %v", i, err)
+		}
+	}
+
+	// Proofs of the same disclosure share no blinded values
+	if results[0].Proof.APrime.Equal(&results[2].Proof.APrime) || results[0].Proof.D.Equal(&results[2].Proof.D) {
+		t.Fatalf("Expected independent proofs for the same disclosure")
+	}
+
+	// A bad spec fails before any proof is made
+	specs = append(specs, DisclosureSpec{DisclosedIndices: []int{5}})
+	if _, err := DeriveMany(keyPair.PublicKey, signature, messages, header, specs); err == nil {
+		t.Fatalf("Expected an error for an out of range index")
+	}
+	if _, err := DeriveMany(keyPair.PublicKey, signature, messages[:4], header, nil); err != ErrInvalidMessageCount {
+		t.Fatalf("Expected ErrInvalidMessageCount, got %v", err)
+	}
+}
+
+func BenchmarkDeriveMany(b *testing.B) {
+	keyPair, err := GenerateKeyPair(20, rand.Reader)
+	if err != nil {
+		b.Fatalf("Failed to generate key pair: %v", err)
+	}
+	messages := make([]*big.Int, 20)
+	for i := range messages {
+		if messages[i], err = RandomScalar(rand.Reader); err != nil {
+			b.Fatalf("RandomScalar failed: %v", err)
+		}
+	}
+	signature, err := Sign(keyPair.PrivateKey, keyPair.PublicKey, messages, nil)
+	if err != nil {
+		b.Fatalf("Sign failed: %v", err)
+	}
+	specs := make([]DisclosureSpec, 8)
+	for i := range specs {
+		specs[i].DisclosedIndices = []int{i, i + 8}
+	}
+
+	b.Run("DeriveMany", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := DeriveMany(keyPair.PublicKey, signature, messages, nil, specs); err != nil {
+				b.Fatalf("DeriveMany failed: %v", err)
+			}
+		}
+	})
+	b.Run("CreateProof", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			for _, spec := range specs {
+				if _, _, err := CreateProof(keyPair.PublicKey, signature, messages, spec.DisclosedIndices, nil); err != nil {
+					b.Fatalf("CreateProof failed: %v", err)
+				}
+			}
+		}
+	})
+}
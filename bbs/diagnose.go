@@ -0,0 +1,261 @@
+package bbs
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381"
+)
+
+// ErrProofDiagnosed is wrapped by ProofDiagnosis.Err for a failing proof
+var ErrProofDiagnosed = errors.New("proof failed verification")
+
+// ProofCheck names one of the checks of proof verification
+type ProofCheck string
+
+const (
+	// CheckComponents requires every proof value to be present and A' not
+	// to be the identity
+	CheckComponents ProofCheck = "components"
+	// CheckSubgroup requires A', A-bar, D and W to be in their prime-order
+	// subgroups
+	CheckSubgroup ProofCheck = "subgroup"
+	// CheckIndexBounds requires every disclosed and hidden index to be a
+	// message of the key
+	CheckIndexBounds ProofCheck = "index-bounds"
+	// CheckMessageCount requires each message to be either disclosed or
+	// hidden, exactly once
+	CheckMessageCount ProofCheck = "message-count"
+	// CheckChallenge requires the recomputed challenge to match the proof's
+	CheckChallenge ProofCheck = "challenge"
+	// CheckPairing requires e(A', W) = e(A-bar, g2)
+	CheckPairing ProofCheck = "pairing"
+)
+
+// ProofCheckResult is the outcome of one check. A check is skipped when a
+// check it depends on failed.
+type ProofCheckResult struct {
+	Check   ProofCheck `json:"check"`
+	Passed  bool       `json:"passed"`
+	Skipped bool       `json:"skipped,omitempty"`
+	Detail  string     `json:"detail,omitempty"`
+}
+
+// ProofDiagnosis is the outcome of DiagnoseProof
+type ProofDiagnosis struct {
+	Valid   bool               `json:"valid"`
+	Results []ProofCheckResult `json:"results"`
+}
+
+// Failed returns the checks that failed, in the order they ran
+func (d *ProofDiagnosis) Failed() []ProofCheck {
+	var failed []ProofCheck
+	for _, result := range d.Results {
+		if !result.Passed && !result.Skipped {
+			failed = append(failed, result.Check)
+		}
+	}
+	return failed
+}
+
+// Err returns nil for a valid proof, or an error wrapping ErrProofDiagnosed
+// that names the failed checks
+func (d *ProofDiagnosis) Err() error {
+	if d.Valid {
+		return nil
+	}
+	var failed []string
+	for _, result := range d.Results {
+		if !result.Passed && !result.Skipped {
+			failed = append(failed, fmt.Sprintf("%s: %s", result.Check, result.Detail))
+		}
+	}
+	return fmt.Errorf("%w: %s", ErrProofDiagnosed, strings.Join(failed, "; "))
+}
+
+// DiagnoseProof runs the checks of VerifyProofWithPresentationHeader one by
+// one and reports which of them a proof fails, and why.
+//
+// DiagnoseProof is for debugging only. It is not constant time, it tells
+// the caller which check failed, and it checks subgroup membership of
+// in-memory points that verification assumes the decoder checked.
+// Production code should call the Verify functions, which answer only valid
+// or invalid.
+func DiagnoseProof(
+	publicKey *PublicKey,
+	proof *ProofOfKnowledge,
+	disclosedMessages map[int]*big.Int,
+	header []byte,
+	presentationHeader []byte,
+) *ProofDiagnosis {
+	d := &ProofDiagnosis{Valid: true}
+	record := func(check ProofCheck, detail string) bool {
+		result := ProofCheckResult{Check: check, Passed: detail == "", Detail: detail}
+		if !result.Passed {
+			d.Valid = false
+		}
+		d.Results = append(d.Results, result)
+		return result.Passed
+	}
+	skip := func(check ProofCheck, after ProofCheck) {
+		d.Valid = false
+		d.Results = append(d.Results, ProofCheckResult{
+			Check: check, Skipped: true, Detail: fmt.Sprintf("skipped after %s failed", after),
+		})
+	}
+
+	if publicKey == nil {
+		record(CheckComponents, "public key is missing")
+		return d
+	}
+
+	componentsOK := record(CheckComponents, diagnoseComponents(proof))
+	if !componentsOK {
+		for _, check := range []ProofCheck{CheckSubgroup, CheckIndexBounds, CheckMessageCount, CheckChallenge, CheckPairing} {
+			skip(check, CheckComponents)
+		}
+		return d
+	}
+
+	record(CheckSubgroup, diagnoseSubgroups(publicKey, proof))
+	boundsOK := record(CheckIndexBounds, diagnoseIndexBounds(publicKey, proof, disclosedMessages))
+	countOK := record(CheckMessageCount, diagnoseMessageCount(publicKey, proof, disclosedMessages))
+
+	switch {
+	case !boundsOK:
+		skip(CheckChallenge, CheckIndexBounds)
+	case !countOK:
+		skip(CheckChallenge, CheckMessageCount)
+	default:
+		record(CheckChallenge, diagnoseChallenge(publicKey, proof, disclosedMessages, header, presentationHeader))
+	}
+
+	record(CheckPairing, diagnosePairing(publicKey, proof))
+	return d
+}
+
+// diagnoseComponents describes a missing proof value
+func diagnoseComponents(proof *ProofOfKnowledge) string {
+	if proof == nil {
+		return "proof is missing"
+	}
+	for _, v := range []struct {
+		name string
+		x    *big.Int
+	}{{"c", proof.C}, {"e^", proof.EHat}, {"s^", proof.SHat}, {"r1^", proof.R1Hat}, {"r3^", proof.R3Hat}} {
+		if v.x == nil {
+			return fmt.Sprintf("%s is missing", v.name)
+		}
+	}
+	for _, idx := range sortedKeys(proof.MHat) {
+		if proof.MHat[idx] == nil {
+			return fmt.Sprintf("response for hidden message %d is missing", idx)
+		}
+	}
+	if proof.APrime.IsInfinity() {
+		return "A' is the identity, which satisfies the pairing check trivially"
+	}
+	return ""
+}
+
+// diagnoseSubgroups describes a point outside its prime-order subgroup
+func diagnoseSubgroups(publicKey *PublicKey, proof *ProofOfKnowledge) string {
+	for _, p := range []struct {
+		name  string
+		point *bls12381.G1Affine
+	}{{"A'", &proof.APrime}, {"A-bar", &proof.ABar}, {"D", &proof.D}} {
+		if !p.point.IsOnCurve() {
+			return fmt.Sprintf("%s is not on the curve", p.name)
+		}
+		if !p.point.IsInSubGroup() {
+			return fmt.Sprintf("%s is not in the G1 subgroup", p.name)
+		}
+	}
+	if !publicKey.w.IsOnCurve() || !publicKey.w.IsInSubGroup() {
+		return "public key W is not in the G2 subgroup"
+	}
+	return ""
+}
+
+// diagnoseIndexBounds describes an index outside the key's messages
+func diagnoseIndexBounds(publicKey *PublicKey, proof *ProofOfKnowledge, disclosedMessages map[int]*big.Int) string {
+	for _, idx := range sortedKeys(disclosedMessages) {
+		if idx < 0 || idx >= publicKey.messageCount {
+			return fmt.Sprintf("disclosed index %d is outside 0..%d", idx, publicKey.messageCount-1)
+		}
+		if disclosedMessages[idx] == nil {
+			return fmt.Sprintf("disclosed message %d is missing", idx)
+		}
+	}
+	for _, idx := range sortedKeys(proof.MHat) {
+		if idx < 0 || idx >= publicKey.messageCount {
+			return fmt.Sprintf("hidden index %d is outside 0..%d", idx, publicKey.messageCount-1)
+		}
+	}
+	if len(publicKey.h) < publicKey.messageCount+2 {
+		return fmt.Sprintf("public key has %d generators for %d messages", len(publicKey.h), publicKey.messageCount)
+	}
+	return ""
+}
+
+// diagnoseMessageCount describes messages that are neither or both
+// disclosed and hidden
+func diagnoseMessageCount(publicKey *PublicKey, proof *ProofOfKnowledge, disclosedMessages map[int]*big.Int) string {
+	for _, idx := range sortedKeys(proof.MHat) {
+		if _, disclosed := disclosedMessages[idx]; disclosed {
+			return fmt.Sprintf("message %d is both disclosed and hidden", idx)
+		}
+	}
+	if n := len(disclosedMessages) + len(proof.MHat); n != publicKey.messageCount {
+		return fmt.Sprintf("%d disclosed and %d hidden messages for a key with %d",
+			len(disclosedMessages), len(proof.MHat), publicKey.messageCount)
+	}
+	return ""
+}
+
+// diagnoseChallenge recomputes the challenge. On a mismatch it tries the
+// usual mistakes of leaving out the header or presentation header.
+func diagnoseChallenge(
+	publicKey *PublicKey,
+	proof *ProofOfKnowledge,
+	disclosedMessages map[int]*big.Int,
+	header []byte,
+	presentationHeader []byte,
+) string {
+	matches := func(header, presentationHeader []byte) bool {
+		domain := CalculateDomain(publicKey, header)
+		T1, T2, err := recomputeProofCommitments(publicKey, proof, disclosedMessages, domain)
+		if err != nil {
+			return false
+		}
+		c := computeProofChallenge(proof.APrime, proof.ABar, proof.D, T1, T2, sortedKeys(disclosedMessages), disclosedMessages, domain, presentationHeader)
+		return c.Cmp(proof.C) == 0
+	}
+
+	if matches(header, presentationHeader) {
+		return ""
+	}
+
+	const mismatch = "recomputed challenge does not match"
+	switch {
+	case len(header) > 0 && matches(nil, presentationHeader):
+		return mismatch + "; it matches without the header"
+	case len(presentationHeader) > 0 && matches(header, nil):
+		return mismatch + "; it matches without the presentation header"
+	}
+	return mismatch + "; a disclosed message, the header or the presentation header differs from the prover's"
+}
+
+// diagnosePairing checks A-bar = A' * x
+func diagnosePairing(publicKey *PublicKey, proof *ProofOfKnowledge) string {
+	switch err := checkProofPairing(publicKey, proof); {
+	case err == nil:
+		return ""
+	case errors.Is(err, ErrPairingFailed):
+		return fmt.Sprintf("pairing could not be computed: %v", err)
+	default:
+		return "e(A', W) != e(A-bar, g2); the signature is not from this key"
+	}
+}
@@ -0,0 +1,86 @@
+package bbs
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestDiagnoseProof(t *testing.T) {
+	keyPair, _, _, _, messages := encodingFixture(t)
+	header := []byte("header")
+	ph := []byte("nonce")
+
+	signature, err := Sign(keyPair.PrivateKey, keyPair.PublicKey, messages, header)
+	if err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+
+	proof, disclosed, err := CreateProofWithPresentationHeader(keyPair.PublicKey, signature, messages, []int{0, 2}, header, ph)
+	if err != nil {
+		t.Fatalf("CreateProof failed: %v", err)
+	}
+
+	// A valid proof passes every check
+	d := DiagnoseProof(keyPair.PublicKey, proof, disclosed, header, ph)
+	if !d.Valid || d.Err() != nil || len(d.Results) != 6 {
+		t.Fatalf("Expected a valid diagnosis, got %+v", d)
+	}
+
+	otherKey, err := GenerateKeyPair(4, rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key pair: %v", err)
+	}
+	otherKey.PublicKey.h = keyPair.PublicKey.h
+
+	tampered := *proof
+	tampered.EHat = new(big.Int).Add(proof.EHat, big.NewInt(1))
+
+	for name, tc := range map[string]struct {
+		publicKey *PublicKey
+		proof     *ProofOfKnowledge
+		disclosed map[int]*big.Int
+		header    []byte
+		ph        []byte
+		failed    ProofCheck
+		detail    string
+	}{
+		"missing header":  {keyPair.PublicKey, proof, disclosed, nil, ph, CheckChallenge, ""},
+		"extra header":    {keyPair.PublicKey, proof, disclosed, []byte("other"), ph, CheckChallenge, ""},
+		"other nonce":     {keyPair.PublicKey, proof, disclosed, header, []byte("other"), CheckChallenge, ""},
+		"changed message": {keyPair.PublicKey, proof, map[int]*big.Int{0: big.NewInt(9), 2: disclosed[2]}, header, ph, CheckChallenge, ""},
+		"tampered":        {keyPair.PublicKey, &tampered, disclosed, header, ph, CheckChallenge, ""},
+		"out of range":    {keyPair.PublicKey, proof, map[int]*big.Int{0: disclosed[0], 7: big.NewInt(1)}, header, ph, CheckIndexBounds, "outside"},
+		"overlap":         {keyPair.PublicKey, proof, map[int]*big.Int{0: disclosed[0], 1: big.NewInt(1)}, header, ph, CheckMessageCount, "both"},
+		"too few":         {keyPair.PublicKey, proof, map[int]*big.Int{0: disclosed[0]}, header, ph, CheckMessageCount, "1 disclosed"},
+		"other key":       {otherKey.PublicKey, proof, disclosed, header, ph, CheckPairing, "not from this key"},
+		"missing c":       {keyPair.PublicKey, &ProofOfKnowledge{}, disclosed, header, ph, CheckComponents, "c is missing"},
+	} {
+		d := DiagnoseProof(tc.publicKey, tc.proof, tc.disclosed, tc.header, tc.ph)
+		if d.Valid || !errors.Is(d.Err(), ErrProofDiagnosed) {
+			t.Fatalf("%s: expected an invalid diagnosis", name)
+		}
+		if !slices.Contains(d.Failed(), tc.failed) {
+			t.Fatalf("%s: expected %s to fail, got %v", name, tc.failed, d.Failed())
+		}
+		if !strings.Contains(d.Err().Error(), tc.detail) {
+			t.Fatalf("%s: expected detail %q in %v", name, tc.detail, d.Err())
+		}
+		if tc.proof != nil && VerifyProofWithPresentationHeader(tc.publicKey, tc.proof, tc.disclosed, tc.header, tc.ph) == nil {
+			t.Fatalf("%s: verification accepted a proof the diagnosis rejects", name)
+		}
+	}
+
+	// A presentation header the prover did not use is pointed out
+	proof, disclosed, err = CreateProof(keyPair.PublicKey, signature, messages, []int{1}, header)
+	if err != nil {
+		t.Fatalf("CreateProof failed: %v", err)
+	}
+	d = DiagnoseProof(keyPair.PublicKey, proof, disclosed, header, ph)
+	if !strings.Contains(d.Err().Error(), "without the presentation header") {
+		t.Fatalf("Expected a hint for the missing presentation header, got %v", d.Err())
+	}
+}
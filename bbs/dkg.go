@@ -0,0 +1,191 @@
+package bbs
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+
+	"github.com/anupsv/bbsplus-signatures/internal/secret"
+	bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381"
+)
+
+// GenerateThresholdKey has one dealer pick the secret and hand out shares,
+// so the dealer knows the key. In a dealer-less key generation every
+// participant deals instead: each picks a random polynomial of degree t-1,
+// publishes Feldman commitments g2*a_k to its coefficients and sends f(j) to
+// participant j. A participant checks every share it receives against the
+// dealer's commitments and adds them up into its key share. The key is the
+// sum of the constant terms, which nobody learns unless t participants pool
+// their shares, and its public key W is the sum of the commitments to them.
+
+// ErrInvalidDKGShare is returned for a share that does not match its
+// dealer's commitments
+var ErrInvalidDKGShare = errors.New("share does not match dealer commitments")
+
+// DKGDeal is one participant's contribution to a key generation
+//
+// Experimental: dealer-less key generation may change in a minor release.
+type DKGDeal struct {
+	Dealer      int                 // Index of the dealer (1-based)
+	Commitments []bls12381.G2Affine // g2*a_k for each coefficient, a_0 first
+	Shares      []*big.Int          // Shares[j-1] = f(j) is for participant j
+}
+
+// NewDKGDeal deals a share for each of n participants, any t of which can
+// sign
+//
+// Experimental: dealer-less key generation may change in a minor release.
+func NewDKGDeal(dealer, t, n int, rng io.Reader) (*DKGDeal, error) {
+	if t <= 0 || n <= 0 || t > n {
+		return nil, fmt.Errorf("invalid threshold parameters: t=%d, n=%d", t, n)
+	}
+	if dealer < 1 || dealer > n {
+		return nil, fmt.Errorf("invalid dealer index: %d", dealer)
+	}
+
+	_, _, _, g2 := bls12381.Generators()
+
+	// a_0 is this dealer's part of the group secret key
+	coefficients := make([]*big.Int, t)
+	defer func() { secret.WipeInts(coefficients...) }()
+
+	deal := &DKGDeal{Dealer: dealer, Commitments: make([]bls12381.G2Affine, t)}
+	for k := range coefficients {
+		coeff, err := RandomScalar(rng)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate coefficient: %w", err)
+		}
+		coefficients[k] = coeff
+		deal.Commitments[k].ScalarMultiplication(&g2, coeff)
+	}
+
+	deal.Shares = make([]*big.Int, n)
+	for j := 1; j <= n; j++ {
+		deal.Shares[j-1] = evaluatePolynomial(coefficients, j)
+	}
+	return deal, nil
+}
+
+// Wipe clears the shares of the deal. Call it once they have been sent or
+// sealed to their recipients.
+//
+// Experimental: dealer-less key generation may change in a minor release.
+func (d *DKGDeal) Wipe() {
+	secret.WipeInts(d.Shares...)
+}
+
+// evaluatePolynomial returns f(x) mod r by Horner's rule
+func evaluatePolynomial(coefficients []*big.Int, x int) *big.Int {
+	bx := big.NewInt(int64(x))
+	value := new(big.Int)
+	for k := len(coefficients) - 1; k >= 0; k-- {
+		value.Mul(value, bx)
+		value.Add(value, coefficients[k])
+		value.Mod(value, Order)
+	}
+	return value
+}
+
+// DKGShareKey returns g2*f(index) from a dealer's commitments, the public
+// counterpart of the share the dealer sends to participant index
+//
+// Experimental: dealer-less key generation may change in a minor release.
+func DKGShareKey(commitments []bls12381.G2Affine, index int) bls12381.G2Affine {
+	if len(commitments) == 0 {
+		return bls12381.G2Affine{}
+	}
+
+	bx := big.NewInt(int64(index))
+	var acc bls12381.G2Jac
+	acc.FromAffine(&commitments[len(commitments)-1])
+	for k := len(commitments) - 2; k >= 0; k-- {
+		acc.ScalarMultiplication(&acc, bx)
+		acc.AddMixed(&commitments[k])
+	}
+	return g2JacToAffine(acc)
+}
+
+// VerifyDKGShare checks a share received by participant index against its
+// dealer's commitments
+//
+// Experimental: dealer-less key generation may change in a minor release.
+func VerifyDKGShare(commitments []bls12381.G2Affine, index int, share *big.Int) error {
+	if len(commitments) == 0 || share == nil {
+		return ErrInvalidDKGShare
+	}
+
+	_, _, _, g2 := bls12381.Generators()
+	var expected bls12381.G2Affine
+	expected.ScalarMultiplication(&g2, share)
+
+	got := DKGShareKey(commitments, index)
+	if !got.Equal(&expected) {
+		return ErrInvalidDKGShare
+	}
+	return nil
+}
+
+// DKGPublicKey returns the public key of a key generation from the
+// commitments of every dealer
+//
+// Experimental: dealer-less key generation may change in a minor release.
+func DKGPublicKey(commitments [][]bls12381.G2Affine, messageCount int) (*PublicKey, error) {
+	if len(commitments) == 0 {
+		return nil, fmt.Errorf("no dealer commitments")
+	}
+	if messageCount <= 0 {
+		return nil, ErrInvalidMessageCount
+	}
+
+	// W = sum of g2*a_0 over the dealers
+	var w bls12381.G2Jac
+	for i, c := range commitments {
+		if len(c) != len(commitments[0]) {
+			return nil, fmt.Errorf("dealer %d commits to %d coefficients, expected %d", i+1, len(c), len(commitments[0]))
+		}
+		w.AddMixed(&c[0])
+	}
+	if w.Z.IsZero() {
+		return nil, ErrInvalidCurvePoint
+	}
+
+	_, _, g1, g2 := bls12381.Generators()
+	return &PublicKey{
+		g1:           g1,
+		g2:           g2,
+		w:            g2JacToAffine(w),
+		h:            GenerateGenerators(messageCount + 2),
+		messageCount: messageCount,
+	}, nil
+}
+
+// CombineDKGShares verifies the shares participant index received, one per
+// dealer, and adds them up into its key share for publicKey
+//
+// Experimental: dealer-less key generation may change in a minor release.
+func CombineDKGShares(index int, commitments [][]bls12381.G2Affine, shares []*big.Int, publicKey *PublicKey) (*KeyShare, error) {
+	if len(commitments) != len(shares) {
+		return nil, ErrInvalidArrayLengths
+	}
+
+	share := new(big.Int)
+	for i := range shares {
+		if err := VerifyDKGShare(commitments[i], index, shares[i]); err != nil {
+			secret.WipeInt(share)
+			return nil, fmt.Errorf("dealer %d: %w", i+1, err)
+		}
+		share.Add(share, shares[i])
+		share.Mod(share, Order)
+	}
+
+	var commitment bls12381.G1Affine
+	commitment.ScalarMultiplication(&publicKey.g1, share)
+
+	return &KeyShare{
+		Index:      index,
+		Share:      share,
+		PublicKey:  publicKey,
+		Commitment: commitment,
+	}, nil
+}
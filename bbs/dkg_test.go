@@ -0,0 +1,76 @@
+package bbs
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"testing"
+
+	bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381"
+)
+
+func TestDKG(t *testing.T) {
+	const threshold, participants, messageCount = 2, 3, 4
+
+	deals := make([]*DKGDeal, participants)
+	commitments := make([][]bls12381.G2Affine, participants)
+	for i := range deals {
+		deal, err := NewDKGDeal(i+1, threshold, participants, rand.Reader)
+		if err != nil {
+			t.Fatalf("NewDKGDeal failed: %v", err)
+		}
+		deals[i] = deal
+		commitments[i] = deal.Commitments
+	}
+
+	publicKey, err := DKGPublicKey(commitments, messageCount)
+	if err != nil {
+		t.Fatalf("DKGPublicKey failed: %v", err)
+	}
+
+	shares := make([]*KeyShare, participants)
+	for j := 1; j <= participants; j++ {
+		received := make([]*big.Int, participants)
+		for i, deal := range deals {
+			received[i] = deal.Shares[j-1]
+		}
+		shares[j-1], err = CombineDKGShares(j, commitments, received, publicKey)
+		if err != nil {
+			t.Fatalf("CombineDKGShares failed for participant %d: %v", j, err)
+		}
+	}
+
+	// Any two participants sign for the key nobody dealt
+	messages := []*big.Int{big.NewInt(1), big.NewInt(2), big.NewInt(3), big.NewInt(4)}
+	for _, signers := range [][]*KeyShare{{shares[0], shares[1]}, {shares[0], shares[2]}, {shares[2], shares[1]}} {
+		sig, err := ThresholdSign(signers, messages, nil)
+		if err != nil {
+			t.Fatalf("ThresholdSign failed: %v", err)
+		}
+		if err := Verify(publicKey, sig.Signature, messages, nil); err != nil {
+			t.Fatalf("Verify failed for signers %v: %v", sig.Signers, err)
+		}
+	}
+
+	// A share that does not match the dealer's commitments is caught
+	forged := []*big.Int{deals[0].Shares[0], new(big.Int).Add(deals[1].Shares[0], big.NewInt(1)), deals[2].Shares[0]}
+	if _, err := CombineDKGShares(1, commitments, forged, publicKey); !errors.Is(err, ErrInvalidDKGShare) {
+		t.Fatalf("Expected ErrInvalidDKGShare, got %v", err)
+	}
+	if err := VerifyDKGShare(deals[0].Commitments, 2, deals[0].Shares[0]); !errors.Is(err, ErrInvalidDKGShare) {
+		t.Fatalf("Expected ErrInvalidDKGShare for another participant's share, got %v", err)
+	}
+
+	if _, err := NewDKGDeal(4, threshold, participants, rand.Reader); err == nil {
+		t.Fatalf("Expected an error for a dealer outside the participants")
+	}
+
+	// Wipe clears the shares in place
+	words := deals[0].Shares[1].Bits()
+	deals[0].Wipe()
+	for i, w := range words {
+		if w != 0 {
+			t.Fatalf("Word %d of a share survived Wipe", i)
+		}
+	}
+}
@@ -38,5 +38,9 @@ Usage example:
     
     // Verify the proof
     err = bbs.VerifyProof(keyPair.PublicKey, proof, disclosed)
+
+Stability: stable
 */
-package bbs
\ No newline at end of file
+package bbs
+
+//go:generate go run ../tools/exampledoc -output ../docs/api/examples.md . ../pkg/proof ../pkg/jose
@@ -0,0 +1,85 @@
+package bbs
+
+import (
+	"crypto/sha256"
+	"math/big"
+	"sync"
+)
+
+// domainCacheKey identifies a domain value. The key digest covers W and
+// every generator, since keys with seeded generators can share W and the
+// generator count while differing in Q1, Q2 and H_i. The header is folded
+// into a digest too, so the key is a fixed-size comparable value that can be
+// built and looked up without allocating. The suite is part of the key since
+// it fixes the domain hash.
+type domainCacheKey struct {
+	suite     *Ciphersuite
+	key       [sha256.Size]byte
+	hasHeader bool
+	header    [sha256.Size]byte
+}
+
+// domainCache memoizes CalculateDomain for the signature and proof managers
+type domainCache struct {
+	mu      sync.RWMutex
+	entries map[domainCacheKey]*big.Int
+	maxSize int
+}
+
+// newDomainCache creates a cache that is cleared once it exceeds maxSize entries
+func newDomainCache(maxSize int) *domainCache {
+	return &domainCache{
+		entries: make(map[domainCacheKey]*big.Int),
+		maxSize: maxSize,
+	}
+}
+
+// get returns the domain for pk and header, computing it on a miss. The
+// returned value is shared and must not be modified.
+func (dc *domainCache) get(pk *PublicKey, header []byte) *big.Int {
+	key := domainCacheKey{
+		suite:     DefaultCiphersuite,
+		key:       publicKeyDigest(pk),
+		hasHeader: header != nil,
+	}
+	if header != nil {
+		key.header = sha256.Sum256(header)
+	}
+
+	dc.mu.RLock()
+	domain, ok := dc.entries[key]
+	dc.mu.RUnlock()
+	if ok {
+		return domain
+	}
+
+	domain = CalculateDomain(pk, header)
+
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+
+	// Clear the cache if it gets too large
+	if len(dc.entries) >= dc.maxSize {
+		dc.entries = make(map[domainCacheKey]*big.Int)
+	}
+	dc.entries[key] = domain
+
+	return domain
+}
+
+// publicKeyDigest chains SHA-256 over W and the generators of pk. Each step
+// hashes the previous digest with one point in a stack buffer, so no
+// serialized copy of the key is allocated.
+func publicKeyDigest(pk *PublicKey) [sha256.Size]byte {
+	var buf [sha256.Size + G2Size]byte
+	w := pk.w.Bytes()
+	copy(buf[sha256.Size:], w[:])
+	digest := sha256.Sum256(buf[:])
+	for i := range pk.h {
+		g := pk.h[i].Bytes()
+		copy(buf[:], digest[:])
+		copy(buf[sha256.Size:], g[:])
+		digest = sha256.Sum256(buf[:sha256.Size+len(g)])
+	}
+	return digest
+}
@@ -0,0 +1,142 @@
+package bbs
+
+import (
+	"encoding/binary"
+	"errors"
+	"math/big"
+
+	bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381"
+)
+
+// Wire format sizes. Points are always written compressed and scalars as
+// fixed-width big-endian integers, so every field has a known length.
+const (
+	// ScalarSize is the encoded size of a scalar modulo Order
+	ScalarSize = 32
+
+	// G1Size is the encoded size of a compressed G1 point
+	G1Size = bls12381.SizeOfG1AffineCompressed
+
+	// G2Size is the encoded size of a compressed G2 point
+	G2Size = bls12381.SizeOfG2AffineCompressed
+
+	// SignatureSize is the encoded size of a signature: A || e || s
+	SignatureSize = G1Size + 2*ScalarSize
+)
+
+// compressedFlag is set in the first byte of every compressed point encoding.
+// Writers before the compact format emitted uncompressed points or length
+// prefixes, neither of which has this bit set, which lets readers tell the
+// formats apart.
+const compressedFlag = 0x80
+
+// errShortBuffer is returned internally when a wireReader runs out of data
+var errShortBuffer = errors.New("short buffer")
+
+// isCompactEncoding reports whether data starts with a compressed point
+func isCompactEncoding(data []byte) bool {
+	return len(data) > 0 && data[0]&compressedFlag != 0
+}
+
+// appendScalar appends x as a fixed-width scalar
+func appendScalar(dst []byte, x *big.Int) []byte {
+	var buf [ScalarSize]byte
+	new(big.Int).Mod(x, Order).FillBytes(buf[:])
+	return append(dst, buf[:]...)
+}
+
+// appendUint32 appends v big-endian
+func appendUint32(dst []byte, v uint32) []byte {
+	return binary.BigEndian.AppendUint32(dst, v)
+}
+
+// appendG1 appends the compressed encoding of p
+func appendG1(dst []byte, p *bls12381.G1Affine) []byte {
+	b := p.Bytes()
+	return append(dst, b[:]...)
+}
+
+// appendG2 appends the compressed encoding of p
+func appendG2(dst []byte, p *bls12381.G2Affine) []byte {
+	b := p.Bytes()
+	return append(dst, b[:]...)
+}
+
+// wireReader decodes fixed-width fields; the first failure is sticky so
+// callers can check err once after reading a whole structure
+type wireReader struct {
+	data []byte
+	err  error
+}
+
+// next consumes n bytes
+func (r *wireReader) next(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if len(r.data) < n {
+		r.err = errShortBuffer
+		return nil
+	}
+	b := r.data[:n]
+	r.data = r.data[n:]
+	return b
+}
+
+// scalar reads a fixed-width scalar, rejecting values outside the field
+func (r *wireReader) scalar() *big.Int {
+	b := r.next(ScalarSize)
+	if b == nil {
+		return nil
+	}
+	x := new(big.Int).SetBytes(b)
+	if x.Cmp(Order) >= 0 {
+		r.err = errors.New("scalar out of range")
+		return nil
+	}
+	return x
+}
+
+// uint32 reads a big-endian uint32
+func (r *wireReader) uint32() uint32 {
+	b := r.next(4)
+	if b == nil {
+		return 0
+	}
+	return binary.BigEndian.Uint32(b)
+}
+
+// g1 reads a compressed or uncompressed G1 point
+func (r *wireReader) g1() bls12381.G1Affine {
+	var p bls12381.G1Affine
+	if r.err != nil {
+		return p
+	}
+	n, err := p.SetBytes(r.data)
+	if err != nil {
+		r.err = err
+		return p
+	}
+	r.data = r.data[n:]
+	return p
+}
+
+// g2 reads a compressed or uncompressed G2 point
+func (r *wireReader) g2() bls12381.G2Affine {
+	var p bls12381.G2Affine
+	if r.err != nil {
+		return p
+	}
+	n, err := p.SetBytes(r.data)
+	if err != nil {
+		r.err = err
+		return p
+	}
+	r.data = r.data[n:]
+	return p
+}
+
+// remaining returns the number of unread bytes
+func (r *wireReader) remaining() int {
+	return len(r.data)
+}
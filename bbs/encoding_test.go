@@ -0,0 +1,274 @@
+package bbs
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math/big"
+	"testing"
+)
+
+// encodingFixture signs messages and derives a proof for the encoding tests
+func encodingFixture(t *testing.T) (*KeyPair, *Signature, *ProofOfKnowledge, map[int]*big.Int, []*big.Int) {
+	t.Helper()
+
+	messages := []*big.Int{big.NewInt(1), big.NewInt(2), big.NewInt(3), big.NewInt(4)}
+	keyPair, signature := signedFixture(t, messages, nil)
+
+	proof, disclosed, err := CreateProof(keyPair.PublicKey, signature, messages, []int{0, 2}, nil)
+	if err != nil {
+		t.Fatalf("CreateProof failed: %v", err)
+	}
+
+	return keyPair, signature, proof, disclosed, messages
+}
+
+// legacyBytes encodes x as the earlier versions did: minimal big-endian bytes
+// behind a length prefix of prefixSize bytes
+func legacyBytes(prefixSize int, data []byte) []byte {
+	var out []byte
+	if prefixSize == 1 {
+		out = append(out, byte(len(data)))
+	} else {
+		out = binary.BigEndian.AppendUint32(out, uint32(len(data)))
+	}
+	return append(out, data...)
+}
+
+// legacySerializeProof reproduces the original SerializeProof output
+func legacySerializeProof(p *ProofOfKnowledge) []byte {
+	var out []byte
+	out = append(out, p.APrime.Marshal()...)
+	out = append(out, p.ABar.Marshal()...)
+	out = append(out, p.D.Marshal()...)
+	for _, x := range []*big.Int{p.C, p.EHat, p.SHat, p.R1Hat, p.R3Hat} {
+		out = append(out, legacyBytes(1, x.Bytes())...)
+	}
+	out = append(out, byte(len(p.MHat)))
+	for idx, m := range p.MHat {
+		out = binary.BigEndian.AppendUint32(out, uint32(idx))
+		out = append(out, legacyBytes(1, m.Bytes())...)
+	}
+	return out
+}
+
+// legacyMarshalProof reproduces the original ProofOfKnowledge.MarshalBinary output
+func legacyMarshalProof(p *ProofOfKnowledge) []byte {
+	var out []byte
+	out = append(out, legacyBytes(4, p.APrime.Marshal())...)
+	out = append(out, legacyBytes(4, p.ABar.Marshal())...)
+	out = append(out, legacyBytes(4, p.D.Marshal())...)
+	for _, x := range []*big.Int{p.C, p.EHat, p.SHat, p.R1Hat, p.R3Hat} {
+		out = append(out, legacyBytes(4, x.Bytes())...)
+	}
+	out = binary.BigEndian.AppendUint32(out, uint32(len(p.MHat)))
+	for idx, m := range p.MHat {
+		out = binary.BigEndian.AppendUint32(out, uint32(idx))
+		out = append(out, legacyBytes(4, m.Bytes())...)
+	}
+	return out
+}
+
+func TestCompactEncodingRoundTrip(t *testing.T) {
+	keyPair, signature, proof, disclosed, messages := encodingFixture(t)
+
+	sigBytes := SerializeSignature(signature)
+	if len(sigBytes) != SignatureSize {
+		t.Fatalf("Expected %d byte signature, got %d", SignatureSize, len(sigBytes))
+	}
+
+	decodedSig, err := DeserializeSignature(sigBytes)
+	if err != nil {
+		t.Fatalf("DeserializeSignature failed: %v", err)
+	}
+
+	pkBytes := SerializePublicKey(keyPair.PublicKey)
+	decodedPK, err := DeserializePublicKey(pkBytes)
+	if err != nil {
+		t.Fatalf("DeserializePublicKey failed: %v", err)
+	}
+
+	if err := Verify(decodedPK, decodedSig, messages, nil); err != nil {
+		t.Fatalf("Verify after round trip failed: %v", err)
+	}
+
+	proofBytes := SerializeProof(proof)
+	if want := 3*G1Size + 5*ScalarSize + 4 + len(proof.MHat)*(4+ScalarSize); len(proofBytes) != want {
+		t.Fatalf("Expected %d byte proof, got %d", want, len(proofBytes))
+	}
+
+	// Encoding is deterministic regardless of map iteration order
+	if !bytes.Equal(proofBytes, SerializeProof(proof)) {
+		t.Fatal("Proof encoding is not deterministic")
+	}
+
+	decodedProof, err := DeserializeProof(proofBytes)
+	if err != nil {
+		t.Fatalf("DeserializeProof failed: %v", err)
+	}
+
+	if err := VerifyProof(decodedPK, decodedProof, disclosed, nil); err != nil {
+		t.Fatalf("VerifyProof after round trip failed: %v", err)
+	}
+
+	skBytes := SerializePrivateKey(keyPair.PrivateKey)
+	if len(skBytes) != ScalarSize {
+		t.Fatalf("Expected %d byte private key, got %d", ScalarSize, len(skBytes))
+	}
+
+	// Compressing the three points saves 144 bytes over the legacy encoding
+	if legacy := legacySerializeProof(proof); len(legacy)-len(proofBytes) < 3*G1Size {
+		t.Fatalf("Compact proof is %d bytes, legacy %d", len(proofBytes), len(legacy))
+	}
+}
+
+func TestBinaryMarshalersRoundTrip(t *testing.T) {
+	keyPair, signature, proof, disclosed, messages := encodingFixture(t)
+
+	skBytes, _ := keyPair.PrivateKey.MarshalBinary()
+	var sk PrivateKey
+	if err := sk.UnmarshalBinary(skBytes); err != nil {
+		t.Fatalf("PrivateKey.UnmarshalBinary failed: %v", err)
+	}
+	if sk.X.Cmp(keyPair.PrivateKey.X) != 0 {
+		t.Fatal("Private key mismatch after round trip")
+	}
+
+	pkBytes, _ := keyPair.PublicKey.MarshalBinary()
+	var pk PublicKey
+	if err := pk.UnmarshalBinary(pkBytes); err != nil {
+		t.Fatalf("PublicKey.UnmarshalBinary failed: %v", err)
+	}
+
+	sigBytes, _ := signature.MarshalBinary()
+	var sig Signature
+	if err := sig.UnmarshalBinary(sigBytes); err != nil {
+		t.Fatalf("Signature.UnmarshalBinary failed: %v", err)
+	}
+
+	if err := Verify(&pk, &sig, messages, nil); err != nil {
+		t.Fatalf("Verify after round trip failed: %v", err)
+	}
+
+	proofBytes, _ := proof.MarshalBinary()
+	var decodedProof ProofOfKnowledge
+	if err := decodedProof.UnmarshalBinary(proofBytes); err != nil {
+		t.Fatalf("ProofOfKnowledge.UnmarshalBinary failed: %v", err)
+	}
+
+	if err := VerifyProof(&pk, &decodedProof, disclosed, nil); err != nil {
+		t.Fatalf("VerifyProof after round trip failed: %v", err)
+	}
+}
+
+func TestLegacyEncodingsAccepted(t *testing.T) {
+	keyPair, signature, proof, disclosed, messages := encodingFixture(t)
+	publicKey := keyPair.PublicKey
+
+	// Original SerializePublicKey: uncompressed points, no length prefixes
+	var legacyPK []byte
+	legacyPK = append(legacyPK, publicKey.w.Marshal()...)
+	legacyPK = binary.BigEndian.AppendUint32(legacyPK, uint32(publicKey.messageCount))
+	legacyPK = append(legacyPK, publicKey.g1.Marshal()...)
+	legacyPK = append(legacyPK, publicKey.g2.Marshal()...)
+	for _, h := range publicKey.h {
+		legacyPK = append(legacyPK, h.Marshal()...)
+	}
+
+	pk, err := DeserializePublicKey(legacyPK)
+	if err != nil {
+		t.Fatalf("DeserializePublicKey(legacy) failed: %v", err)
+	}
+
+	// Original PublicKey.MarshalBinary: length-prefixed fields
+	var legacyPKBinary []byte
+	legacyPKBinary = binary.BigEndian.AppendUint32(legacyPKBinary, uint32(publicKey.messageCount))
+	legacyPKBinary = append(legacyPKBinary, legacyBytes(4, publicKey.w.Marshal())...)
+	legacyPKBinary = append(legacyPKBinary, legacyBytes(4, publicKey.g1.Marshal())...)
+	legacyPKBinary = append(legacyPKBinary, legacyBytes(4, publicKey.g2.Marshal())...)
+	legacyPKBinary = binary.BigEndian.AppendUint32(legacyPKBinary, uint32(len(publicKey.h)))
+	for _, h := range publicKey.h {
+		legacyPKBinary = append(legacyPKBinary, legacyBytes(4, h.Marshal())...)
+	}
+
+	var pkBinary PublicKey
+	if err := pkBinary.UnmarshalBinary(legacyPKBinary); err != nil {
+		t.Fatalf("PublicKey.UnmarshalBinary(legacy) failed: %v", err)
+	}
+	if !bytes.Equal(SerializePublicKey(&pkBinary), SerializePublicKey(publicKey)) {
+		t.Fatal("Public key mismatch after legacy unmarshal")
+	}
+
+	// Original SerializeSignature and Signature.MarshalBinary
+	legacySig := append(signature.A.Marshal(), legacyBytes(1, signature.E.Bytes())...)
+	legacySig = append(legacySig, legacyBytes(1, signature.S.Bytes())...)
+
+	sig, err := DeserializeSignature(legacySig)
+	if err != nil {
+		t.Fatalf("DeserializeSignature(legacy) failed: %v", err)
+	}
+	if err := Verify(pk, sig, messages, nil); err != nil {
+		t.Fatalf("Verify with legacy signature failed: %v", err)
+	}
+
+	legacySigBinary := legacyBytes(4, signature.A.Marshal())
+	legacySigBinary = append(legacySigBinary, legacyBytes(4, signature.E.Bytes())...)
+	legacySigBinary = append(legacySigBinary, legacyBytes(4, signature.S.Bytes())...)
+
+	var sigBinary Signature
+	if err := sigBinary.UnmarshalBinary(legacySigBinary); err != nil {
+		t.Fatalf("Signature.UnmarshalBinary(legacy) failed: %v", err)
+	}
+	if err := Verify(pk, &sigBinary, messages, nil); err != nil {
+		t.Fatalf("Verify with legacy binary signature failed: %v", err)
+	}
+
+	// Original SerializeProof and ProofOfKnowledge.MarshalBinary
+	decodedProof, err := DeserializeProof(legacySerializeProof(proof))
+	if err != nil {
+		t.Fatalf("DeserializeProof(legacy) failed: %v", err)
+	}
+	if err := VerifyProof(pk, decodedProof, disclosed, nil); err != nil {
+		t.Fatalf("VerifyProof with legacy proof failed: %v", err)
+	}
+
+	var proofBinary ProofOfKnowledge
+	if err := proofBinary.UnmarshalBinary(legacyMarshalProof(proof)); err != nil {
+		t.Fatalf("ProofOfKnowledge.UnmarshalBinary(legacy) failed: %v", err)
+	}
+	if err := VerifyProof(pk, &proofBinary, disclosed, nil); err != nil {
+		t.Fatalf("VerifyProof with legacy binary proof failed: %v", err)
+	}
+
+	// Original PrivateKey.MarshalBinary
+	var sk PrivateKey
+	if err := sk.UnmarshalBinary(legacyBytes(4, keyPair.PrivateKey.X.Bytes())); err != nil {
+		t.Fatalf("PrivateKey.UnmarshalBinary(legacy) failed: %v", err)
+	}
+	if sk.X.Cmp(keyPair.PrivateKey.X) != 0 {
+		t.Fatal("Private key mismatch after legacy unmarshal")
+	}
+}
+
+func TestCompactEncodingRejectsMalformed(t *testing.T) {
+	_, signature, proof, _, _ := encodingFixture(t)
+
+	sigBytes := SerializeSignature(signature)
+	if _, err := DeserializeSignature(sigBytes[:SignatureSize-1]); err == nil {
+		t.Fatal("Truncated signature accepted")
+	}
+
+	// A scalar at or above the group order is not canonical
+	badSig := append([]byte(nil), sigBytes...)
+	Order.FillBytes(badSig[G1Size : G1Size+ScalarSize])
+	if _, err := DeserializeSignature(badSig); err == nil {
+		t.Fatal("Signature with out-of-range scalar accepted")
+	}
+
+	proofBytes := SerializeProof(proof)
+	if _, err := DeserializeProof(proofBytes[:len(proofBytes)-1]); err == nil {
+		t.Fatal("Truncated proof accepted")
+	}
+	if _, err := DeserializeProof(append(proofBytes, 0)); err == nil {
+		t.Fatal("Proof with trailing data accepted")
+	}
+}
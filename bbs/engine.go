@@ -0,0 +1,191 @@
+package bbs
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+)
+
+// ErrNoSigner is returned when an Engine without a signer is asked to sign
+var ErrNoSigner = errors.New("engine has no signer configured")
+
+// Engine bundles a signing backend with the pooled signature and proof
+// managers, giving issuers and verifiers a single entry point. Its options
+// are fixed by NewEngine and cannot be changed afterwards, so an engine may
+// be shared by any number of goroutines.
+type Engine struct {
+	signer     SignerBackend
+	signatures *SignatureManager
+	proofs     *ProofManager
+	cache      *VerifyCache
+
+	// selfTestErr is set when a required self-test did not pass
+	selfTestErr error
+
+	// policy, if set, is enforced by Sign with counters in policyStore
+	policy      *KeyPolicy
+	policyStore PolicyStore
+	now         func() time.Time
+}
+
+// EngineOption configures an Engine
+type EngineOption func(*Engine)
+
+// WithSigner sets the backend used by Engine.Sign
+func WithSigner(signer SignerBackend) EngineOption {
+	return func(e *Engine) {
+		e.signer = signer
+	}
+}
+
+// WithSignatureManager sets the manager used for signature verification
+func WithSignatureManager(manager *SignatureManager) EngineOption {
+	return func(e *Engine) {
+		e.signatures = manager
+	}
+}
+
+// WithProofManager sets the manager used for proof creation and verification
+func WithProofManager(manager *ProofManager) EngineOption {
+	return func(e *Engine) {
+		e.proofs = manager
+	}
+}
+
+// WithVerifyCache makes Engine.VerifyProof answer repeated proofs from cache
+func WithVerifyCache(cache *VerifyCache) EngineOption {
+	return func(e *Engine) {
+		e.cache = cache
+	}
+}
+
+// WithRequiredSelfTest makes every operation of the engine fail with
+// ErrSelfTestFailed unless SelfTest passes. The self-test runs once per
+// process, when the first such engine is created.
+func WithRequiredSelfTest() EngineOption {
+	return func(e *Engine) {
+		e.selfTestErr = cachedSelfTest().Err()
+	}
+}
+
+// WithKeyPolicy makes Engine.Sign refuse signatures outside policy, with
+// the signature counter kept in store. A nil store keeps it in memory.
+func WithKeyPolicy(policy KeyPolicy, store PolicyStore) EngineOption {
+	return func(e *Engine) {
+		if store == nil {
+			store = NewMemoryPolicyStore()
+		}
+		// A copy, so the caller cannot change the policy of a running engine
+		e.policy = policy.clone()
+		e.policyStore = store
+	}
+}
+
+// NewEngine creates an engine. Without options it can verify and derive
+// proofs but not sign; the default managers are used.
+func NewEngine(opts ...EngineOption) *Engine {
+	e := &Engine{
+		signatures: defaultManager,
+		proofs:     defaultProofManager,
+		now:        time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(e)
+	}
+
+	return e
+}
+
+// PublicKey returns the signer's public key, or nil without a signer
+func (e *Engine) PublicKey() *PublicKey {
+	if e.signer == nil {
+		return nil
+	}
+	return e.signer.PublicKey()
+}
+
+// Sign signs messages with the configured backend
+func (e *Engine) Sign(messages []*big.Int, header []byte) (*Signature, error) {
+	if e.selfTestErr != nil {
+		return nil, e.selfTestErr
+	}
+	if e.signer == nil {
+		return nil, ErrNoSigner
+	}
+	if e.policy != nil {
+		if err := e.enforcePolicy(len(messages), header); err != nil {
+			return nil, err
+		}
+	}
+	return e.signer.Sign(messages, header)
+}
+
+// enforcePolicy checks a signature against the key policy and reserves it
+// in the policy store
+func (e *Engine) enforcePolicy(messageCount int, header []byte) error {
+	if err := e.policy.check(messageCount, header, e.now()); err != nil {
+		return err
+	}
+
+	ok, err := e.policyStore.Reserve(PolicyKeyID(e.signer.PublicKey()), e.policy.MaxSignatures)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("%w: signature limit of %d reached", ErrPolicyViolation, e.policy.MaxSignatures)
+	}
+	return nil
+}
+
+// Verify verifies a signature
+func (e *Engine) Verify(publicKey *PublicKey, signature *Signature, messages []*big.Int, header []byte) error {
+	if e.selfTestErr != nil {
+		return e.selfTestErr
+	}
+	return e.signatures.VerifyWithPooling(publicKey, signature, messages, header)
+}
+
+// CreateProof creates a selective disclosure proof
+func (e *Engine) CreateProof(
+	publicKey *PublicKey,
+	signature *Signature,
+	messages []*big.Int,
+	disclosedIndices []int,
+	header []byte,
+) (*ProofOfKnowledge, map[int]*big.Int, error) {
+	if e.selfTestErr != nil {
+		return nil, nil, e.selfTestErr
+	}
+	return e.proofs.CreateProofWithPooling(publicKey, signature, messages, disclosedIndices, header)
+}
+
+// VerifyProof verifies a selective disclosure proof. With a verify cache, a
+// proof already checked in the same context gets the cached verdict.
+func (e *Engine) VerifyProof(
+	publicKey *PublicKey,
+	proof *ProofOfKnowledge,
+	disclosedMessages map[int]*big.Int,
+	header []byte,
+) error {
+	if e.selfTestErr != nil {
+		return e.selfTestErr
+	}
+	if e.cache == nil {
+		return e.proofs.VerifyProofWithPooling(publicKey, proof, disclosedMessages, header)
+	}
+
+	key, ok := verifyCacheKey(publicKey, proof, disclosedMessages, header)
+	if !ok {
+		return e.proofs.VerifyProofWithPooling(publicKey, proof, disclosedMessages, header)
+	}
+
+	if hit, err := e.cache.lookup(key); hit {
+		return err
+	}
+
+	err := e.proofs.VerifyProofWithPooling(publicKey, proof, disclosedMessages, header)
+	e.cache.store(key, err)
+	return err
+}
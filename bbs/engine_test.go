@@ -0,0 +1,108 @@
+package bbs
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestEngine(t *testing.T) {
+	keyPair, err := GenerateKeyPair(3, rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key pair: %v", err)
+	}
+
+	messages := []*big.Int{big.NewInt(1), big.NewInt(2), big.NewInt(3)}
+	header := []byte("engine header")
+
+	// Without a signer the engine can only verify
+	if _, err := NewEngine().Sign(messages, header); !errors.Is(err, ErrNoSigner) {
+		t.Fatalf("Expected ErrNoSigner, got %v", err)
+	}
+
+	engine := NewEngine(WithSigner(NewLocalSigner(keyPair)))
+
+	signature, err := engine.Sign(messages, header)
+	if err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+
+	if err := engine.Verify(engine.PublicKey(), signature, messages, header); err != nil {
+		t.Fatalf("Verify failed: %v", err)
+	}
+
+	proof, disclosed, err := engine.CreateProof(engine.PublicKey(), signature, messages, []int{1}, header)
+	if err != nil {
+		t.Fatalf("CreateProof failed: %v", err)
+	}
+	defer PutDisclosedMsgMap(disclosed)
+
+	if err := engine.VerifyProof(engine.PublicKey(), proof, disclosed, header); err != nil {
+		t.Fatalf("VerifyProof failed: %v", err)
+	}
+}
+
+// TestEngineConcurrent shares one engine and key between goroutines that
+// sign, verify and prove at once. Run it with -race.
+func TestEngineConcurrent(t *testing.T) {
+	keyPair, err := GenerateKeyPair(4, rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key pair: %v", err)
+	}
+
+	policy := KeyPolicy{MessageCounts: []int{4}}
+	engine := NewEngine(
+		WithSigner(NewLocalSigner(keyPair)),
+		WithVerifyCache(NewVerifyCache(64, time.Minute)),
+		WithKeyPolicy(policy, nil),
+	)
+	// The engine keeps its own copy of the policy
+	policy.MessageCounts[0] = 5
+
+	header := []byte("concurrent header")
+	publicKey := engine.PublicKey()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 16)
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			messages := []*big.Int{big.NewInt(int64(i)), big.NewInt(2), big.NewInt(3), big.NewInt(4)}
+			signature, err := engine.Sign(messages, header)
+			if err != nil {
+				errs <- err
+				return
+			}
+			if err := engine.Verify(publicKey, signature, messages, header); err != nil {
+				errs <- err
+				return
+			}
+
+			proof, disclosed, err := engine.CreateProof(publicKey, signature, messages, []int{0, 2}, header)
+			if err != nil {
+				errs <- err
+				return
+			}
+			defer PutDisclosedMsgMap(disclosed)
+
+			// The second check is answered from the cache
+			for j := 0; j < 2; j++ {
+				if err := engine.VerifyProof(publicKey, proof, disclosed, header); err != nil {
+					errs <- err
+					return
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Fatalf("Concurrent operation failed: %v", err)
+	}
+}
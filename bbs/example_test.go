@@ -0,0 +1,206 @@
+package bbs_test
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+
+	"github.com/anupsv/bbsplus-signatures/bbs"
+)
+
+// encode maps strings to field elements
+func encode(values ...string) []*big.Int {
+	messages := make([]*big.Int, len(values))
+	for i, v := range values {
+		messages[i] = bbs.MessageToFieldElement(bbs.MessageToBytes(v))
+	}
+	return messages
+}
+
+func ExampleSign() {
+	keyPair, err := bbs.GenerateKeyPair(3, rand.Reader)
+	if err != nil {
+		panic(err)
+	}
+
+	messages := encode("name=Alice", "birthdate=1990-01-01", "country=NL")
+	header := []byte("identity-credential-v1")
+
+	signature, err := bbs.Sign(keyPair.PrivateKey, keyPair.PublicKey, messages, header)
+	if err != nil {
+		panic(err)
+	}
+
+	fmt.Println("valid:", bbs.Verify(keyPair.PublicKey, signature, messages, header) == nil)
+	fmt.Println("other header:", bbs.Verify(keyPair.PublicKey, signature, messages, nil) == nil)
+	// Output:
+	// valid: true
+	// other header: false
+}
+
+func ExampleSignRaw() {
+	keyPair, err := bbs.GenerateKeyPair(2, rand.Reader)
+	if err != nil {
+		panic(err)
+	}
+
+	raw := []bbs.Message{bbs.Message("name=Alice"), bbs.Message("country=NL")}
+	signature, err := bbs.SignRaw(keyPair.PrivateKey, keyPair.PublicKey, raw, nil)
+	if err != nil {
+		panic(err)
+	}
+
+	// The same signature verifies over the encoded messages
+	encoded := bbs.EncodeMessages(bbs.DefaultMessageEncoder, raw)
+	fmt.Println(bbs.VerifyEncoded(keyPair.PublicKey, signature, encoded, nil) == nil)
+	// Output: true
+}
+
+func ExampleCreateProof() {
+	keyPair, err := bbs.GenerateKeyPair(3, rand.Reader)
+	if err != nil {
+		panic(err)
+	}
+
+	messages := encode("name=Alice", "birthdate=1990-01-01", "country=NL")
+	signature, err := bbs.Sign(keyPair.PrivateKey, keyPair.PublicKey, messages, nil)
+	if err != nil {
+		panic(err)
+	}
+
+	// Reveal the country only
+	proof, disclosed, err := bbs.CreateProof(keyPair.PublicKey, signature, messages, []int{2}, nil)
+	if err != nil {
+		panic(err)
+	}
+
+	fmt.Println("disclosed:", len(disclosed), "message(s)")
+	fmt.Println("valid:", bbs.VerifyProof(keyPair.PublicKey, proof, disclosed, nil) == nil)
+	// Output:
+	// disclosed: 1 message(s)
+	// valid: true
+}
+
+func ExampleBatchVerifyProofs() {
+	keyPair, err := bbs.GenerateKeyPair(2, rand.Reader)
+	if err != nil {
+		panic(err)
+	}
+
+	var (
+		keys      []*bbs.PublicKey
+		proofs    []*bbs.ProofOfKnowledge
+		disclosed []map[int]*big.Int
+	)
+	for _, name := range []string{"Alice", "Bob", "Carol"} {
+		messages := encode("name="+name, "member=yes")
+		signature, err := bbs.Sign(keyPair.PrivateKey, keyPair.PublicKey, messages, nil)
+		if err != nil {
+			panic(err)
+		}
+
+		proof, revealed, err := bbs.CreateProof(keyPair.PublicKey, signature, messages, []int{1}, nil)
+		if err != nil {
+			panic(err)
+		}
+
+		keys = append(keys, keyPair.PublicKey)
+		proofs = append(proofs, proof)
+		disclosed = append(disclosed, revealed)
+	}
+
+	err = bbs.BatchVerifyProofsWithOptions(keys, proofs, disclosed, nil, bbs.VerifyOptions{Concurrency: 2})
+	fmt.Println("batch valid:", err == nil)
+	// Output: batch valid: true
+}
+
+func ExampleThresholdSign() {
+	// Any 2 of 3 share holders can sign together
+	thresholdKey, shares, err := bbs.GenerateThresholdKey(2, 3, 2, rand.Reader)
+	if err != nil {
+		panic(err)
+	}
+
+	messages := encode("name=Alice", "role=admin")
+	thresholdSig, err := bbs.ThresholdSign([]*bbs.KeyShare{shares[0], shares[2]}, messages, nil)
+	if err != nil {
+		panic(err)
+	}
+
+	fmt.Println("signers:", thresholdSig.Signers)
+	fmt.Println("valid:", bbs.VerifyThresholdSignature(thresholdKey, thresholdSig, messages, nil) == nil)
+	// Output:
+	// signers: [1 3]
+	// valid: true
+}
+
+// Maps returned by the pooling functions come from an object pool. Return
+// them with PutDisclosedMsgMap once done, and do not keep references to them
+// afterwards: the next caller gets the same map back, cleared.
+func ExampleProofManager_CreateProofWithPooling() {
+	keyPair, err := bbs.GenerateKeyPair(2, rand.Reader)
+	if err != nil {
+		panic(err)
+	}
+
+	messages := encode("name=Alice", "country=NL")
+	signature, err := bbs.Sign(keyPair.PrivateKey, keyPair.PublicKey, messages, nil)
+	if err != nil {
+		panic(err)
+	}
+
+	manager := bbs.NewProofManager(nil, 0, 0)
+	proof, disclosed, err := manager.CreateProofWithPooling(keyPair.PublicKey, signature, messages, []int{1}, nil)
+	if err != nil {
+		panic(err)
+	}
+	defer bbs.PutDisclosedMsgMap(disclosed)
+
+	// Copy anything that must outlive the pooled map
+	country := new(big.Int).Set(disclosed[1])
+
+	fmt.Println("valid:", manager.VerifyProofWithPooling(keyPair.PublicKey, proof, disclosed, nil) == nil)
+	fmt.Println("country disclosed:", country.Cmp(messages[1]) == 0)
+	// Output:
+	// valid: true
+	// country disclosed: true
+}
+
+// Scratch objects taken from an ObjectPool go back with the matching Put
+// call, usually deferred right after the Get.
+func ExampleObjectPool() {
+	pool := bbs.NewObjectPool()
+
+	sum := pool.GetBigInt()
+	defer pool.PutBigInt(sum)
+
+	// Put the slice back as it is after the appends
+	terms := pool.GetBigIntSlice(3)
+	defer func() { pool.PutBigIntSlice(terms) }()
+
+	terms = append(terms, big.NewInt(1), big.NewInt(2), big.NewInt(3))
+	sum.SetInt64(0)
+	for _, term := range terms {
+		sum.Add(sum, term)
+	}
+
+	fmt.Println(sum)
+	// Output: 6
+}
+
+// A transaction hands out pooled objects and returns all of them on End, so
+// early returns cannot leak them.
+func ExampleObjectPool_Begin() {
+	pool := bbs.NewObjectPool()
+
+	txn := pool.Begin()
+	defer txn.End()
+
+	x := txn.BigInt().SetInt64(3)
+	y := txn.BigInt().SetInt64(4)
+	fmt.Println(x.Mul(x, y))
+	fmt.Println("outstanding:", pool.Outstanding())
+	// Output:
+	// 12
+	// outstanding: 2
+}
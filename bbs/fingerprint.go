@@ -0,0 +1,38 @@
+package bbs
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"strings"
+)
+
+// A fingerprint names a public key wherever a string identifier is needed:
+// trust registries, audit records, verification events and the fragments of
+// DID verification methods. It is the SHA-256 of SerializePublicKey, which
+// is canonical since points are always written compressed, in multibase
+// base64url. Caches that must not allocate, like the domain cache, key on
+// a digest chained over the points instead.
+
+// fingerprintMultibase is the multibase prefix of unpadded base64url
+const fingerprintMultibase = "u"
+
+// Fingerprint returns the canonical identifier of pk
+func (pk *PublicKey) Fingerprint() string {
+	sum := sha256.Sum256(SerializePublicKey(pk))
+	return fingerprintMultibase + base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// VerificationMethod returns the DID URL naming pk within the DID document of
+// did, did#fingerprint
+func (pk *PublicKey) VerificationMethod(did string) string {
+	return did + "#" + pk.Fingerprint()
+}
+
+// IsFingerprint reports whether s has the form of a key fingerprint
+func IsFingerprint(s string) bool {
+	if !strings.HasPrefix(s, fingerprintMultibase) {
+		return false
+	}
+	sum, err := base64.RawURLEncoding.DecodeString(s[len(fingerprintMultibase):])
+	return err == nil && len(sum) == sha256.Size
+}
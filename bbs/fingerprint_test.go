@@ -0,0 +1,46 @@
+package bbs
+
+import (
+	"crypto/rand"
+	"strings"
+	"testing"
+)
+
+func TestFingerprint(t *testing.T) {
+	keyPair, err := GenerateKeyPair(3, rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKeyPair failed: %v", err)
+	}
+	other, err := GenerateKeyPair(3, rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKeyPair failed: %v", err)
+	}
+
+	fingerprint := keyPair.PublicKey.Fingerprint()
+	if !IsFingerprint(fingerprint) || len(fingerprint) != 44 {
+		t.Fatalf("Unexpected fingerprint %q", fingerprint)
+	}
+	if fingerprint == other.PublicKey.Fingerprint() {
+		t.Fatalf("Different keys share a fingerprint")
+	}
+
+	// A decoded key keeps its fingerprint
+	decoded, err := DeserializePublicKey(SerializePublicKey(keyPair.PublicKey))
+	if err != nil {
+		t.Fatalf("DeserializePublicKey failed: %v", err)
+	}
+	if decoded.Fingerprint() != fingerprint {
+		t.Fatalf("Fingerprint changed after a round trip")
+	}
+
+	method := keyPair.PublicKey.VerificationMethod("did:example:issuer")
+	if method != "did:example:issuer#"+fingerprint {
+		t.Fatalf("Unexpected verification method %q", method)
+	}
+
+	for _, s := range []string{"", PolicyKeyID(keyPair.PublicKey), "u" + strings.Repeat("A", 10), "z" + fingerprint[1:]} {
+		if IsFingerprint(s) {
+			t.Fatalf("IsFingerprint(%q) = true", s)
+		}
+	}
+}
@@ -0,0 +1,216 @@
+package bbs
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+)
+
+// Domain separation tags for the reserved freshness attributes
+const (
+	issuanceTimeDST   = "BBS_BLS12381_ISSUANCE_TIME_"
+	sequenceNumberDST = "BBS_BLS12381_SEQUENCE_NUMBER_"
+)
+
+// DefaultClockSkew is how far in the future an issuance timestamp may lie
+// when FreshnessPolicy.MaxClockSkew is not set
+const DefaultClockSkew = time.Minute
+
+// An issuer that reissues credentials often can let verifiers enforce a
+// maximum credential age instead of running a revocation service. It signs
+// two reserved attributes next to the regular ones: the issuance time and a
+// sequence number that grows with every credential. Unlike regular messages
+// they are not hashed but packed into the field element behind a tag derived
+// from their DST, so a verifier can read the disclosed value back.
+
+// IssuanceTimeMessage returns the message carrying issuance time t, at
+// second precision
+func IssuanceTimeMessage(t time.Time) (*big.Int, error) {
+	if t.Unix() < 0 {
+		return nil, fmt.Errorf("issuance time before the Unix epoch: %v", t)
+	}
+	return packReserved(issuanceTimeDST, uint64(t.Unix())), nil
+}
+
+// ParseIssuanceTimeMessage reads the issuance time from a disclosed message
+func ParseIssuanceTimeMessage(msg *big.Int) (time.Time, error) {
+	v, err := unpackReserved(issuanceTimeDST, msg)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("not an issuance time message: %w", err)
+	}
+	if v > 1<<62 {
+		return time.Time{}, fmt.Errorf("issuance time out of range: %d", v)
+	}
+	return time.Unix(int64(v), 0), nil
+}
+
+// SequenceNumberMessage returns the message carrying sequence number seq
+func SequenceNumberMessage(seq uint64) *big.Int {
+	return packReserved(sequenceNumberDST, seq)
+}
+
+// ParseSequenceNumberMessage reads the sequence number from a disclosed
+// message
+func ParseSequenceNumberMessage(msg *big.Int) (uint64, error) {
+	v, err := unpackReserved(sequenceNumberDST, msg)
+	if err != nil {
+		return 0, fmt.Errorf("not a sequence number message: %w", err)
+	}
+	return v, nil
+}
+
+// packReserved places v in the low 64 bits and the DST tag in the 64 bits
+// above, keeping the element far below Order
+func packReserved(dst string, v uint64) *big.Int {
+	var buf [16]byte
+	copy(buf[:8], reservedTag(dst))
+	binary.BigEndian.PutUint64(buf[8:], v)
+	return new(big.Int).SetBytes(buf[:])
+}
+
+// unpackReserved reverses packReserved
+func unpackReserved(dst string, msg *big.Int) (uint64, error) {
+	if msg == nil || msg.Sign() < 0 || msg.BitLen() > 128 {
+		return 0, errors.New("wrong size")
+	}
+
+	var buf [16]byte
+	msg.FillBytes(buf[:])
+	if string(buf[:8]) != string(reservedTag(dst)) {
+		return 0, errors.New("wrong tag")
+	}
+	return binary.BigEndian.Uint64(buf[8:]), nil
+}
+
+// reservedTag returns the 64-bit tag of a reserved attribute
+func reservedTag(dst string) []byte {
+	h := sha256.Sum256([]byte(dst))
+	return h[:8]
+}
+
+// SequenceCounter hands out increasing credential sequence numbers. Issuers
+// persist Last across restarts and pass it to NewSequenceCounter.
+type SequenceCounter struct {
+	mu   sync.Mutex
+	last uint64
+}
+
+// NewSequenceCounter creates a counter whose first number is last+1
+func NewSequenceCounter(last uint64) *SequenceCounter {
+	return &SequenceCounter{last: last}
+}
+
+// Next returns the next sequence number
+func (sc *SequenceCounter) Next() (uint64, error) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	if sc.last == ^uint64(0) {
+		return 0, errors.New("sequence numbers exhausted")
+	}
+	sc.last++
+	return sc.last, nil
+}
+
+// Last returns the most recent sequence number handed out
+func (sc *SequenceCounter) Last() uint64 {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	return sc.last
+}
+
+// FreshnessPolicy is a verifier's requirement on the reserved freshness
+// attributes of a credential
+type FreshnessPolicy struct {
+	// TimestampIndex is the message index of the issuance time. The proof
+	// must disclose it.
+	TimestampIndex int
+
+	// MaxAge is the oldest a credential may be. Zero disables the check.
+	MaxAge time.Duration
+
+	// MaxClockSkew is how far in the future the issuance time may lie. Zero
+	// or negative uses DefaultClockSkew.
+	MaxClockSkew time.Duration
+
+	// SequenceIndex is the message index of the sequence number, or -1 if
+	// the policy does not look at it
+	SequenceIndex int
+
+	// MinSequence is the lowest sequence number accepted, for example the
+	// first one issued after a batch of credentials was withdrawn
+	MinSequence uint64
+
+	// Now returns the current time; nil uses time.Now
+	Now func() time.Time
+}
+
+// Check checks the disclosed messages of a verified proof against the policy
+func (p *FreshnessPolicy) Check(disclosedMessages map[int]*big.Int) error {
+	msg, ok := disclosedMessages[p.TimestampIndex]
+	if !ok {
+		return fmt.Errorf("%w: issuance time (message %d) not disclosed", ErrStaleCredential, p.TimestampIndex)
+	}
+
+	issued, err := ParseIssuanceTimeMessage(msg)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrStaleCredential, err)
+	}
+
+	now := time.Now()
+	if p.Now != nil {
+		now = p.Now()
+	}
+
+	skew := p.MaxClockSkew
+	if skew <= 0 {
+		skew = DefaultClockSkew
+	}
+
+	if issued.After(now.Add(skew)) {
+		return fmt.Errorf("%w: issued in the future (%v)", ErrStaleCredential, issued.UTC())
+	}
+	if p.MaxAge > 0 && now.Sub(issued) > p.MaxAge {
+		return fmt.Errorf("%w: issued %v, older than %v", ErrStaleCredential, issued.UTC(), p.MaxAge)
+	}
+
+	if p.SequenceIndex < 0 {
+		return nil
+	}
+
+	msg, ok = disclosedMessages[p.SequenceIndex]
+	if !ok {
+		return fmt.Errorf("%w: sequence number (message %d) not disclosed", ErrStaleCredential, p.SequenceIndex)
+	}
+
+	seq, err := ParseSequenceNumberMessage(msg)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrStaleCredential, err)
+	}
+	if seq < p.MinSequence {
+		return fmt.Errorf("%w: sequence number %d below %d", ErrStaleCredential, seq, p.MinSequence)
+	}
+
+	return nil
+}
+
+// checkFreshness applies the freshness policy, if any, to the disclosed
+// messages of each proof
+func (o VerifyOptions) checkFreshness(disclosedMessagesList []map[int]*big.Int) error {
+	if o.Freshness == nil {
+		return nil
+	}
+
+	for i, disclosed := range disclosedMessagesList {
+		if err := o.Freshness.Check(disclosed); err != nil {
+			return fmt.Errorf("proof %d: %w", i, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,125 @@
+package bbs
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func TestReservedAttributesRoundTrip(t *testing.T) {
+	issued := time.Unix(1760000000, 0)
+	msg, err := IssuanceTimeMessage(issued)
+	if err != nil {
+		t.Fatalf("IssuanceTimeMessage failed: %v", err)
+	}
+	got, err := ParseIssuanceTimeMessage(msg)
+	if err != nil {
+		t.Fatalf("ParseIssuanceTimeMessage failed: %v", err)
+	}
+	if !got.Equal(issued) {
+		t.Fatalf("issuance time = %v, want %v", got, issued)
+	}
+
+	seq, err := ParseSequenceNumberMessage(SequenceNumberMessage(42))
+	if err != nil || seq != 42 {
+		t.Fatalf("ParseSequenceNumberMessage = %d, %v; want 42", seq, err)
+	}
+
+	// The two attributes and hashed messages are not interchangeable
+	if _, err := ParseSequenceNumberMessage(msg); err == nil {
+		t.Fatal("issuance time parsed as sequence number")
+	}
+	if _, err := ParseIssuanceTimeMessage(MessageToFieldElement([]byte("2025-01-01"))); err == nil {
+		t.Fatal("hashed message parsed as issuance time")
+	}
+	if _, err := IssuanceTimeMessage(time.Unix(-1, 0)); err == nil {
+		t.Fatal("IssuanceTimeMessage accepted a time before the epoch")
+	}
+}
+
+func TestSequenceCounter(t *testing.T) {
+	sc := NewSequenceCounter(7)
+	for want := uint64(8); want < 11; want++ {
+		got, err := sc.Next()
+		if err != nil || got != want {
+			t.Fatalf("Next = %d, %v; want %d", got, err, want)
+		}
+	}
+	if sc.Last() != 10 {
+		t.Fatalf("Last = %d, want 10", sc.Last())
+	}
+
+	if _, err := NewSequenceCounter(^uint64(0)).Next(); err == nil {
+		t.Fatal("exhausted counter returned a number")
+	}
+}
+
+func TestFreshnessPolicy(t *testing.T) {
+	keyPair, err := GenerateKeyPair(3, rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKeyPair failed: %v", err)
+	}
+
+	issued := time.Unix(1760000000, 0)
+	timestamp, err := IssuanceTimeMessage(issued)
+	if err != nil {
+		t.Fatalf("IssuanceTimeMessage failed: %v", err)
+	}
+	messages := []*big.Int{MessageToFieldElement([]byte("alice")), timestamp, SequenceNumberMessage(100)}
+
+	sig, err := Sign(keyPair.PrivateKey, keyPair.PublicKey, messages, nil)
+	if err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+
+	proof, disclosed, err := CreateProof(keyPair.PublicKey, sig, messages, []int{1, 2}, nil)
+	if err != nil {
+		t.Fatalf("CreateProof failed: %v", err)
+	}
+
+	now := issued.Add(time.Hour)
+	policy := &FreshnessPolicy{
+		TimestampIndex: 1,
+		MaxAge:         2 * time.Hour,
+		SequenceIndex:  2,
+		MinSequence:    50,
+		Now:            func() time.Time { return now },
+	}
+
+	opts := VerifyOptions{Freshness: policy}
+	if err := VerifyProofWithOptions(keyPair.PublicKey, proof, disclosed, nil, opts); err != nil {
+		t.Fatalf("VerifyProofWithOptions failed: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		mutate func(p *FreshnessPolicy)
+	}{
+		{"too old", func(p *FreshnessPolicy) { p.MaxAge = 30 * time.Minute }},
+		{"issued in the future", func(p *FreshnessPolicy) { p.Now = func() time.Time { return issued.Add(-time.Hour) } }},
+		{"sequence withdrawn", func(p *FreshnessPolicy) { p.MinSequence = 101 }},
+		{"timestamp hidden", func(p *FreshnessPolicy) { p.TimestampIndex = 0 }},
+	}
+
+	for _, tt := range tests {
+		p := *policy
+		tt.mutate(&p)
+		err := VerifyProofWithOptions(keyPair.PublicKey, proof, disclosed, nil, VerifyOptions{Freshness: &p})
+		if !errors.Is(err, ErrStaleCredential) {
+			t.Fatalf("%s: got %v, want ErrStaleCredential", tt.name, err)
+		}
+	}
+
+	// The sequence number is only checked when the policy asks for it
+	hidden, hiddenDisclosed, err := CreateProof(keyPair.PublicKey, sig, messages, []int{1}, nil)
+	if err != nil {
+		t.Fatalf("CreateProof failed: %v", err)
+	}
+	p := *policy
+	p.SequenceIndex = -1
+	if err := VerifyProofWithOptions(keyPair.PublicKey, hidden, hiddenDisclosed, nil, VerifyOptions{Freshness: &p}); err != nil {
+		t.Fatalf("VerifyProofWithOptions without sequence failed: %v", err)
+	}
+}
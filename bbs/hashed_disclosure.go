@@ -0,0 +1,223 @@
+package bbs
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"fmt"
+	"io"
+	"math/big"
+)
+
+// A verifier that must later show which values it was shown, without
+// keeping them, logs salted digests instead. The holder draws a salt per
+// disclosed message and the proof's presentation header commits to every
+// H(salt || index || message), so the logged digests are bound to the proof.
+// The verifier stores a DisclosureLog with the proof and digests only. Anyone
+// later holding a value and its salt can open the digest, and with openings
+// for every disclosed message the proof itself can be checked again.
+
+// Domain separation tags for hashed disclosure
+const (
+	disclosureDigestDST = "BBS_BLS12381_DISCLOSURE_DIGEST_"
+	disclosureHeaderDST = "BBS_BLS12381_HASHED_DISCLOSURE_"
+)
+
+// DisclosureSaltSize is the size of the salt drawn per disclosed message
+const DisclosureSaltSize = 32
+
+// DisclosureLog is what a verifier keeps of a proof with hashed disclosure:
+// the proof and the salted digests of the disclosed messages, but neither
+// the messages nor the salts
+type DisclosureLog struct {
+	Proof              []byte           `json:"proof"`
+	Header             []byte           `json:"header,omitempty"`
+	PresentationHeader []byte           `json:"presentationHeader,omitempty"`
+	Digests            map[int][32]byte `json:"digests"`
+}
+
+// DisclosureOpening reveals the message and salt behind a logged digest
+type DisclosureOpening struct {
+	Message *big.Int
+	Salt    []byte
+}
+
+// DisclosureDigest returns H(salt || index || message) for a disclosed message
+func DisclosureDigest(index int, message *big.Int, salt []byte) [32]byte {
+	var buff []byte
+	buff = append(buff, disclosureDigestDST...)
+	buff = append(buff, uint32ToBytes(uint32(len(salt)))...)
+	buff = append(buff, salt...)
+	buff = append(buff, uint32ToBytes(uint32(index))...)
+	buff = appendScalar(buff, message)
+	return sha256.Sum256(buff)
+}
+
+// hashedDisclosureHeader returns the presentation header the proof is made
+// under: the caller's presentation header followed by the digests in index
+// order
+func hashedDisclosureHeader(presentationHeader []byte, digests map[int][32]byte) []byte {
+	var buff []byte
+	buff = append(buff, disclosureHeaderDST...)
+	buff = append(buff, uint32ToBytes(uint32(len(presentationHeader)))...)
+	buff = append(buff, presentationHeader...)
+	buff = append(buff, uint32ToBytes(uint32(len(digests)))...)
+	for _, idx := range sortedKeys(digests) {
+		digest := digests[idx]
+		buff = append(buff, uint32ToBytes(uint32(idx))...)
+		buff = append(buff, digest[:]...)
+	}
+	return buff
+}
+
+// disclosureDigests computes the digest of every disclosed message
+func disclosureDigests(disclosedMessages map[int]*big.Int, salts map[int][]byte) (map[int][32]byte, error) {
+	if len(salts) != len(disclosedMessages) {
+		return nil, fmt.Errorf("%d salts for %d disclosed messages", len(salts), len(disclosedMessages))
+	}
+
+	digests := make(map[int][32]byte, len(disclosedMessages))
+	for idx, msg := range disclosedMessages {
+		salt, ok := salts[idx]
+		if !ok || len(salt) != DisclosureSaltSize {
+			return nil, fmt.Errorf("missing or short salt for disclosed message %d", idx)
+		}
+		if msg == nil {
+			return nil, fmt.Errorf("missing disclosed message at index %d", idx)
+		}
+		digests[idx] = DisclosureDigest(idx, msg, salt)
+	}
+	return digests, nil
+}
+
+// CreateProofWithHashedDisclosure creates a proof whose presentation header
+// commits to a salted digest of each disclosed message. The salts are
+// returned with the disclosed messages and must reach the verifier.
+func CreateProofWithHashedDisclosure(
+	publicKey *PublicKey,
+	signature *Signature,
+	messages []*big.Int,
+	disclosedIndices []int,
+	header []byte,
+	presentationHeader []byte,
+) (*ProofOfKnowledge, map[int]*big.Int, map[int][]byte, error) {
+	return createProofWithHashedDisclosure(publicKey, signature, messages, disclosedIndices, header, presentationHeader, rand.Reader)
+}
+
+// createProofWithHashedDisclosure draws the salts from rng
+func createProofWithHashedDisclosure(
+	publicKey *PublicKey,
+	signature *Signature,
+	messages []*big.Int,
+	disclosedIndices []int,
+	header []byte,
+	presentationHeader []byte,
+	rng io.Reader,
+) (*ProofOfKnowledge, map[int]*big.Int, map[int][]byte, error) {
+	// Validate inputs
+	if len(messages) != publicKey.messageCount {
+		return nil, nil, nil, ErrInvalidMessageCount
+	}
+
+	// Create a map of disclosed messages and a salt for each
+	disclosedMessages := make(map[int]*big.Int, len(disclosedIndices))
+	salts := make(map[int][]byte, len(disclosedIndices))
+	for _, idx := range disclosedIndices {
+		if idx < 0 || idx >= len(messages) {
+			return nil, nil, nil, fmt.Errorf("invalid disclosed index: %d", idx)
+		}
+		if _, dup := salts[idx]; dup {
+			return nil, nil, nil, fmt.Errorf("duplicate disclosed index: %d", idx)
+		}
+		salt := make([]byte, DisclosureSaltSize)
+		if _, err := io.ReadFull(rng, salt); err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to draw disclosure salt: %w", err)
+		}
+		disclosedMessages[idx] = messages[idx]
+		salts[idx] = salt
+	}
+
+	digests, err := disclosureDigests(disclosedMessages, salts)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	// Calculate domain value
+	domain := CalculateDomain(publicKey, header)
+
+	proof, err := deriveProof(publicKey, signature, messages, disclosedMessages, domain, hashedDisclosureHeader(presentationHeader, digests))
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	return proof, disclosedMessages, salts, nil
+}
+
+// VerifyProofWithHashedDisclosure verifies a proof created by
+// CreateProofWithHashedDisclosure and returns the log to persist in place
+// of the disclosed messages and salts
+func VerifyProofWithHashedDisclosure(
+	publicKey *PublicKey,
+	proof *ProofOfKnowledge,
+	disclosedMessages map[int]*big.Int,
+	salts map[int][]byte,
+	header []byte,
+	presentationHeader []byte,
+) (*DisclosureLog, error) {
+	digests, err := disclosureDigests(disclosedMessages, salts)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := VerifyProofWithPresentationHeader(publicKey, proof, disclosedMessages, header, hashedDisclosureHeader(presentationHeader, digests)); err != nil {
+		return nil, err
+	}
+
+	return &DisclosureLog{
+		Proof:              SerializeProof(proof),
+		Header:             append([]byte(nil), header...),
+		PresentationHeader: append([]byte(nil), presentationHeader...),
+		Digests:            digests,
+	}, nil
+}
+
+// VerifyOpening checks that message and salt open the logged digest of the
+// disclosed message at index
+func (l *DisclosureLog) VerifyOpening(index int, opening DisclosureOpening) error {
+	logged, ok := l.Digests[index]
+	if !ok {
+		return fmt.Errorf("message %d was not disclosed", index)
+	}
+	if opening.Message == nil {
+		return fmt.Errorf("missing message for index %d", index)
+	}
+
+	digest := DisclosureDigest(index, opening.Message, opening.Salt)
+	if subtle.ConstantTimeCompare(digest[:], logged[:]) != 1 {
+		return fmt.Errorf("opening does not match the digest of message %d", index)
+	}
+	return nil
+}
+
+// Reverify checks the logged proof again, given an opening for every
+// disclosed message
+func (l *DisclosureLog) Reverify(publicKey *PublicKey, openings map[int]DisclosureOpening) error {
+	if len(openings) != len(l.Digests) {
+		return fmt.Errorf("%d openings for %d disclosed messages", len(openings), len(l.Digests))
+	}
+
+	disclosedMessages := make(map[int]*big.Int, len(openings))
+	for idx, opening := range openings {
+		if err := l.VerifyOpening(idx, opening); err != nil {
+			return err
+		}
+		disclosedMessages[idx] = opening.Message
+	}
+
+	proof, err := DeserializeProof(l.Proof)
+	if err != nil {
+		return err
+	}
+
+	return VerifyProofWithPresentationHeader(publicKey, proof, disclosedMessages, l.Header, hashedDisclosureHeader(l.PresentationHeader, l.Digests))
+}
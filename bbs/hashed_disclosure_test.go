@@ -0,0 +1,67 @@
+package bbs
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+)
+
+func TestHashedDisclosure(t *testing.T) {
+	keyPair, signature, _, _, messages := encodingFixture(t)
+	ph := []byte("verifier nonce")
+
+	proof, disclosed, salts, err := CreateProofWithHashedDisclosure(keyPair.PublicKey, signature, messages, []int{1, 3}, nil, ph)
+	if err != nil {
+		t.Fatalf("CreateProofWithHashedDisclosure failed: %v", err)
+	}
+
+	// The proof is bound to the digests, not to the plain presentation header
+	if err := VerifyProofWithPresentationHeader(keyPair.PublicKey, proof, disclosed, nil, ph); err == nil {
+		t.Fatalf("Expected the proof to fail without its digests")
+	}
+
+	log, err := VerifyProofWithHashedDisclosure(keyPair.PublicKey, proof, disclosed, salts, nil, ph)
+	if err != nil {
+		t.Fatalf("VerifyProofWithHashedDisclosure failed: %v", err)
+	}
+
+	// The log keeps neither messages nor salts and survives encoding
+	data, err := json.Marshal(log)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	var stored DisclosureLog
+	if err := json.Unmarshal(data, &stored); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+
+	// One opening proves a single value was disclosed
+	if err := stored.VerifyOpening(3, DisclosureOpening{Message: messages[3], Salt: salts[3]}); err != nil {
+		t.Fatalf("VerifyOpening failed: %v", err)
+	}
+	if err := stored.VerifyOpening(3, DisclosureOpening{Message: big.NewInt(99), Salt: salts[3]}); err == nil {
+		t.Fatalf("Expected an opening with another value to fail")
+	}
+	if err := stored.VerifyOpening(0, DisclosureOpening{Message: messages[0], Salt: salts[1]}); err == nil {
+		t.Fatalf("Expected an opening for an undisclosed message to fail")
+	}
+
+	// Openings for every disclosed message re-verify the proof
+	openings := map[int]DisclosureOpening{
+		1: {Message: messages[1], Salt: salts[1]},
+		3: {Message: messages[3], Salt: salts[3]},
+	}
+	if err := stored.Reverify(keyPair.PublicKey, openings); err != nil {
+		t.Fatalf("Reverify failed: %v", err)
+	}
+	delete(openings, 1)
+	if err := stored.Reverify(keyPair.PublicKey, openings); err == nil {
+		t.Fatalf("Expected Reverify to fail with a missing opening")
+	}
+
+	// Wrong salts are rejected at verification time
+	salts[1] = salts[3]
+	if _, err := VerifyProofWithHashedDisclosure(keyPair.PublicKey, proof, disclosed, salts, nil, ph); err == nil {
+		t.Fatalf("Expected verification to fail with a wrong salt")
+	}
+}
@@ -0,0 +1,56 @@
+package bbs
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+)
+
+// Shared fixtures for the tests of this package. Black-box tests of other
+// packages use bbstest instead, which this package cannot import.
+
+// newTestKeyPair generates a key pair for messageCount messages
+func newTestKeyPair(tb testing.TB, messageCount int) *KeyPair {
+	tb.Helper()
+
+	keyPair, err := GenerateKeyPair(messageCount, rand.Reader)
+	if err != nil {
+		tb.Fatalf("GenerateKeyPair failed: %v", err)
+	}
+	return keyPair
+}
+
+// randomMessages returns messageCount random messages
+func randomMessages(tb testing.TB, messageCount int) []*big.Int {
+	tb.Helper()
+
+	messages := make([]*big.Int, messageCount)
+	for i := range messages {
+		var err error
+		messages[i], err = RandomScalar(rand.Reader)
+		if err != nil {
+			tb.Fatalf("RandomScalar failed: %v", err)
+		}
+	}
+	return messages
+}
+
+// signFixture signs messages under header with keyPair
+func signFixture(tb testing.TB, keyPair *KeyPair, messages []*big.Int, header []byte) *Signature {
+	tb.Helper()
+
+	signature, err := Sign(keyPair.PrivateKey, keyPair.PublicKey, messages, header)
+	if err != nil {
+		tb.Fatalf("Sign failed: %v", err)
+	}
+	return signature
+}
+
+// signedFixture generates a key pair for messages and signs them under
+// header
+func signedFixture(tb testing.TB, messages []*big.Int, header []byte) (*KeyPair, *Signature) {
+	tb.Helper()
+
+	keyPair := newTestKeyPair(tb, len(messages))
+	return keyPair, signFixture(tb, keyPair, messages, header)
+}
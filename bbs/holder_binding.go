@@ -0,0 +1,236 @@
+package bbs
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"fmt"
+	"math/big"
+
+	bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381"
+)
+
+// Domain separation tags for holder binding
+const (
+	holderBindingDST     = "BBS_BLS12381_HOLDER_BINDING_"
+	holderKeyDST         = "BBS_BLS12381_HOLDER_KEY_"
+	holderKeyEqualityDST = "BBS_BLS12381_HOLDER_KEY_EQUALITY_"
+)
+
+// HolderBinding binds a proof to a key held on the holder's device
+// (WebAuthn style). The device signs HolderBindingChallenge(PublicKey, nonce),
+// and the proof's Fiat-Shamir challenge commits to that same value, so the
+// proof cannot be replayed with another device key or nonce.
+//
+// The credential carries HolderKeyMessage(PublicKey) at KeyIndex. That
+// message stays hidden: the proof shows it equals the device key's message
+// through a Schnorr proof sharing the message's BBS+ response, so a device
+// cannot present a credential issued to another device. The device public
+// key itself is still shown, since the verifier checks its signature.
+type HolderBinding struct {
+	// PublicKey is the device public key as PKIX (SubjectPublicKeyInfo) DER.
+	// ECDSA P-256 (secp256r1) and Ed25519 keys are supported.
+	PublicKey []byte
+
+	// Signature is the device signature over the holder binding challenge.
+	// ECDSA signatures are ASN.1 encoded over SHA-256 of the challenge,
+	// Ed25519 signatures are over the challenge itself.
+	Signature []byte
+
+	// KeyIndex is the index of the signed message carrying
+	// HolderKeyMessage(PublicKey). It is required, and that message must
+	// not be disclosed.
+	KeyIndex int
+}
+
+// HolderBindingChallenge returns the bytes a device must sign to bind a
+// presentation to its key and to the verifier supplied nonce
+func HolderBindingChallenge(devicePublicKey, nonce []byte) []byte {
+	var buff []byte
+	buff = append(buff, holderBindingDST...)
+	buff = append(buff, uint32ToBytes(uint32(len(devicePublicKey)))...)
+	buff = append(buff, devicePublicKey...)
+	buff = append(buff, uint32ToBytes(uint32(len(nonce)))...)
+	buff = append(buff, nonce...)
+
+	digest := sha256.Sum256(buff)
+	return digest[:]
+}
+
+// HolderKeyMessage maps a device public key to the message value an issuer
+// signs to tie a credential to that device
+func HolderKeyMessage(devicePublicKey []byte) *big.Int {
+	return MessageToFieldElement(append([]byte(holderKeyDST), devicePublicKey...))
+}
+
+// Verify checks the device signature over the holder binding challenge for nonce
+func (hb *HolderBinding) Verify(nonce []byte) error {
+	if hb == nil || len(hb.PublicKey) == 0 || len(hb.Signature) == 0 {
+		return ErrInvalidHolderBinding
+	}
+
+	key, err := x509.ParsePKIXPublicKey(hb.PublicKey)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidHolderBinding, err)
+	}
+
+	challenge := HolderBindingChallenge(hb.PublicKey, nonce)
+
+	switch pub := key.(type) {
+	case *ecdsa.PublicKey:
+		if pub.Curve != elliptic.P256() {
+			return fmt.Errorf("%w: unsupported curve %s", ErrInvalidHolderBinding, pub.Curve.Params().Name)
+		}
+		digest := sha256.Sum256(challenge)
+		if !ecdsa.VerifyASN1(pub, digest[:], hb.Signature) {
+			return ErrInvalidHolderBinding
+		}
+	case ed25519.PublicKey:
+		if !ed25519.Verify(pub, challenge, hb.Signature) {
+			return ErrInvalidHolderBinding
+		}
+	default:
+		return fmt.Errorf("%w: unsupported key type %T", ErrInvalidHolderBinding, key)
+	}
+
+	return nil
+}
+
+// checkKeyIndex checks that KeyIndex names a message of the credential
+// that is not disclosed
+func (hb *HolderBinding) checkKeyIndex(publicKey *PublicKey, disclosedMessages map[int]*big.Int) error {
+	if hb.KeyIndex < 0 || hb.KeyIndex >= publicKey.messageCount {
+		return fmt.Errorf("%w: invalid holder key index %d", ErrInvalidHolderBinding, hb.KeyIndex)
+	}
+	if _, disclosed := disclosedMessages[hb.KeyIndex]; disclosed {
+		return fmt.Errorf("%w: holder key message %d is disclosed", ErrInvalidHolderBinding, hb.KeyIndex)
+	}
+
+	return nil
+}
+
+// holderBindingHeader encodes the binding challenge and the Schnorr
+// commitment T3 = G * mBlind_k of the holder key message for the proof
+// challenge
+func holderBindingHeader(binding *HolderBinding, nonce []byte, t3 *bls12381.G1Affine) []byte {
+	challenge := HolderBindingChallenge(binding.PublicKey, nonce)
+
+	buff := make([]byte, 0, len(challenge)+len(holderKeyEqualityDST)+4+G1Size)
+	buff = append(buff, challenge...)
+	buff = append(buff, holderKeyEqualityDST...)
+	buff = appendUint32(buff, uint32(binding.KeyIndex))
+	return appendG1(buff, t3)
+}
+
+// CreateHolderBoundProof creates a selective disclosure proof bound to a
+// device key. The binding is checked before the proof is derived, and the
+// message at binding.KeyIndex must be HolderKeyMessage of the device key.
+func CreateHolderBoundProof(
+	publicKey *PublicKey,
+	signature *Signature,
+	messages []*big.Int,
+	disclosedIndices []int,
+	header []byte,
+	binding *HolderBinding,
+	nonce []byte,
+) (*ProofOfKnowledge, map[int]*big.Int, error) {
+	// Validate inputs
+	if len(messages) != publicKey.messageCount {
+		return nil, nil, ErrInvalidMessageCount
+	}
+
+	if err := binding.Verify(nonce); err != nil {
+		return nil, nil, err
+	}
+
+	disclosedMessages, err := selectDisclosed(messages, disclosedIndices)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := binding.checkKeyIndex(publicKey, disclosedMessages); err != nil {
+		return nil, nil, err
+	}
+	keyMessage := new(big.Int).Mod(messages[binding.KeyIndex], Order)
+	if keyMessage.Cmp(HolderKeyMessage(binding.PublicKey)) != 0 {
+		return nil, nil, fmt.Errorf("%w: credential is not bound to the device key", ErrInvalidHolderBinding)
+	}
+
+	// Calculate domain value
+	domain := CalculateDomain(publicKey, header)
+
+	witness, err := commitProof(publicKey, signature, messages, disclosedMessages, domain, rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer witness.wipe()
+
+	// Commit to T3 = G * mBlind_k, reusing the message blinding of the BBS
+	// proof so both parts answer with the same m_k^
+	g, _ := PedersenGenerators()
+	t3 := g1JacToAffine(scalarMulSumG1([]bls12381.G1Affine{g}, []Scalar{witness.mBlind[binding.KeyIndex]}))
+
+	// Compute the Fiat-Shamir challenge c over the BBS and binding parts
+	cm := &witness.commitment
+	presentationHeader := holderBindingHeader(binding, nonce, &t3)
+	c := computeProofChallenge(cm.APrime, cm.ABar, cm.D, cm.T1, cm.T2, sortedKeys(disclosedMessages), disclosedMessages, domain, presentationHeader)
+
+	return witness.respond(c), disclosedMessages, nil
+}
+
+// VerifyHolderBoundProof verifies a proof created by CreateHolderBoundProof,
+// checking the device signature, that the hidden message at
+// binding.KeyIndex is HolderKeyMessage of the device key, and the proof
+// itself
+func VerifyHolderBoundProof(
+	publicKey *PublicKey,
+	proof *ProofOfKnowledge,
+	disclosedMessages map[int]*big.Int,
+	header []byte,
+	binding *HolderBinding,
+	nonce []byte,
+) error {
+	if err := binding.Verify(nonce); err != nil {
+		return err
+	}
+
+	if err := binding.checkKeyIndex(publicKey, disclosedMessages); err != nil {
+		return err
+	}
+
+	// Calculate domain value
+	domain := CalculateDomain(publicKey, header)
+
+	T1, T2, err := recomputeProofCommitments(publicKey, proof, disclosedMessages, domain)
+	if err != nil {
+		return err
+	}
+	if len(proof.CommitmentHat) > 0 {
+		return ErrInvalidProof
+	}
+
+	// Recompute T3 = G * (m_k^ - HolderKeyMessage * c)
+	mHat, ok := proof.MHat[binding.KeyIndex]
+	if !ok || mHat == nil {
+		return ErrInvalidProof
+	}
+	x := new(big.Int).Mul(HolderKeyMessage(binding.PublicKey), proof.C)
+	x.Sub(mHat, x)
+	x.Mod(x, Order)
+
+	g, _ := PedersenGenerators()
+	var t3 bls12381.G1Affine
+	t3.ScalarMultiplication(&g, x)
+
+	// Check the challenge over the BBS and binding parts
+	presentationHeader := holderBindingHeader(binding, nonce, &t3)
+	c := computeProofChallenge(proof.APrime, proof.ABar, proof.D, T1, T2, sortedKeys(disclosedMessages), disclosedMessages, domain, presentationHeader)
+	if !ConstantTimeEq(c, proof.C) {
+		return ErrInvalidSignature
+	}
+
+	return checkProofPairing(publicKey, proof)
+}
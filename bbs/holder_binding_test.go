@@ -0,0 +1,161 @@
+package bbs
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"errors"
+	"math/big"
+	"testing"
+)
+
+// newDeviceKey returns a PKIX encoded device public key and a function that
+// signs holder binding challenges the way a WebAuthn authenticator would
+func newDeviceKey(t *testing.T, ed bool) ([]byte, func([]byte) []byte) {
+	t.Helper()
+
+	var signer crypto.Signer
+	if ed {
+		_, priv, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			t.Fatalf("Failed to generate Ed25519 key: %v", err)
+		}
+		signer = priv
+	} else {
+		priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatalf("Failed to generate P-256 key: %v", err)
+		}
+		signer = priv
+	}
+
+	pub, err := x509.MarshalPKIXPublicKey(signer.Public())
+	if err != nil {
+		t.Fatalf("Failed to marshal device key: %v", err)
+	}
+
+	sign := func(challenge []byte) []byte {
+		var sig []byte
+		var err error
+		if ed {
+			sig, err = signer.Sign(rand.Reader, challenge, crypto.Hash(0))
+		} else {
+			digest := sha256.Sum256(challenge)
+			sig, err = signer.Sign(rand.Reader, digest[:], crypto.SHA256)
+		}
+		if err != nil {
+			t.Fatalf("Device signing failed: %v", err)
+		}
+		return sig
+	}
+
+	return pub, sign
+}
+
+func TestHolderBoundProof(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		ed   bool
+	}{
+		{"P256", false},
+		{"Ed25519", true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			devicePub, deviceSign := newDeviceKey(t, tc.ed)
+
+			// Message 0 carries the device key
+			messages := []*big.Int{HolderKeyMessage(devicePub), big.NewInt(42), big.NewInt(7)}
+			header := []byte("holder binding header")
+			keyPair, signature := signedFixture(t, messages, header)
+
+			nonce := []byte("verifier nonce")
+			binding := &HolderBinding{
+				PublicKey: devicePub,
+				Signature: deviceSign(HolderBindingChallenge(devicePub, nonce)),
+				KeyIndex:  0,
+			}
+
+			proof, disclosed, err := CreateHolderBoundProof(
+				keyPair.PublicKey, signature, messages, []int{2}, header, binding, nonce,
+			)
+			if err != nil {
+				t.Fatalf("CreateHolderBoundProof failed: %v", err)
+			}
+			if _, ok := disclosed[0]; ok {
+				t.Fatal("Holder key message was disclosed")
+			}
+
+			if err := VerifyHolderBoundProof(keyPair.PublicKey, proof, disclosed, header, binding, nonce); err != nil {
+				t.Fatalf("VerifyHolderBoundProof failed: %v", err)
+			}
+
+			// The proof is bound to the challenge, so it does not verify as a plain proof
+			if err := VerifyProof(keyPair.PublicKey, proof, disclosed, header); err == nil {
+				t.Fatal("Holder bound proof should not verify without its binding")
+			}
+
+			// A different nonce invalidates the device signature
+			if err := VerifyHolderBoundProof(keyPair.PublicKey, proof, disclosed, header, binding, []byte("other")); err == nil {
+				t.Fatal("VerifyHolderBoundProof should fail with a different nonce")
+			}
+
+			// Another device cannot take over the proof, even with a valid signature
+			otherPub, otherSign := newDeviceKey(t, tc.ed)
+			otherBinding := &HolderBinding{
+				PublicKey: otherPub,
+				Signature: otherSign(HolderBindingChallenge(otherPub, nonce)),
+				KeyIndex:  0,
+			}
+			if err := VerifyHolderBoundProof(keyPair.PublicKey, proof, disclosed, header, otherBinding, nonce); err == nil {
+				t.Fatal("VerifyHolderBoundProof should fail with another device key")
+			}
+
+			// A credential that does not carry the device key is rejected
+			if _, _, err := CreateHolderBoundProof(
+				keyPair.PublicKey, signature, messages, nil, header, otherBinding, nonce,
+			); !errors.Is(err, ErrInvalidHolderBinding) {
+				t.Fatalf("Expected ErrInvalidHolderBinding for a credential bound to another device, got %v", err)
+			}
+
+			// The proof shows the device key is the message at KeyIndex
+			moved := *binding
+			moved.KeyIndex = 1
+			if err := VerifyHolderBoundProof(keyPair.PublicKey, proof, disclosed, header, &moved, nonce); err == nil {
+				t.Fatal("VerifyHolderBoundProof should fail for another key index")
+			}
+
+			// The key index is required and its message stays hidden
+			for _, keyIndex := range []int{-1, 3} {
+				unbound := *binding
+				unbound.KeyIndex = keyIndex
+				if _, _, err := CreateHolderBoundProof(
+					keyPair.PublicKey, signature, messages, nil, header, &unbound, nonce,
+				); !errors.Is(err, ErrInvalidHolderBinding) {
+					t.Fatalf("Expected ErrInvalidHolderBinding for key index %d, got %v", keyIndex, err)
+				}
+				if err := VerifyHolderBoundProof(keyPair.PublicKey, proof, disclosed, header, &unbound, nonce); !errors.Is(err, ErrInvalidHolderBinding) {
+					t.Fatalf("Expected ErrInvalidHolderBinding for key index %d, got %v", keyIndex, err)
+				}
+			}
+			if _, _, err := CreateHolderBoundProof(
+				keyPair.PublicKey, signature, messages, []int{0}, header, binding, nonce,
+			); !errors.Is(err, ErrInvalidHolderBinding) {
+				t.Fatalf("Expected ErrInvalidHolderBinding for a disclosed holder key, got %v", err)
+			}
+
+			// A forged device signature is rejected before any proof is derived
+			forged := *binding
+			forged.Signature = append([]byte{}, binding.Signature...)
+			forged.Signature[len(forged.Signature)-1] ^= 0x01
+			if _, _, err := CreateHolderBoundProof(
+				keyPair.PublicKey, signature, messages, []int{0}, header, &forged, nonce,
+			); err == nil {
+				t.Fatal("CreateHolderBoundProof should reject an invalid device signature")
+			}
+		})
+	}
+}
@@ -0,0 +1,245 @@
+package bbs
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"fmt"
+	"math/big"
+	"sync"
+
+	bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381"
+)
+
+// Interactive proofs run the Schnorr protocol behind ProofOfKnowledge as a
+// three move exchange instead of deriving the challenge with Fiat-Shamir:
+//
+//	prover                                   verifier
+//	NewProverSession      -- commitment -->
+//	                      <-- challenge --   VerifierSession.IssueChallenge
+//	CreateProofWithChallenge -- proof  -->   VerifierSession.VerifyInteractiveProof
+//
+// The challenge must be chosen after the commitment is fixed; a prover that
+// knows the challenge in advance can answer without a signature. Both
+// sessions are single use: the prover's blinding factors would reveal the
+// hidden messages if they answered two challenges, and the verifier accepts
+// exactly one response per challenge.
+
+// SessionIDSize is the size of an interactive proof session identifier
+const SessionIDSize = 16
+
+// ProofCommitment is the prover's first message in an interactive proof
+type ProofCommitment struct {
+	SessionID [SessionIDSize]byte
+	APrime    bls12381.G1Affine
+	ABar      bls12381.G1Affine
+	D         bls12381.G1Affine
+	T1        bls12381.G1Affine
+	T2        bls12381.G1Affine
+}
+
+// Challenge is the verifier's random challenge for one interactive proof
+type Challenge struct {
+	SessionID [SessionIDSize]byte
+	Value     *big.Int
+	Context   []byte // Verifier data echoed to the prover, e.g. a transaction reference
+}
+
+// ProverSession holds the prover state of one interactive proof
+type ProverSession struct {
+	mu        sync.Mutex
+	witness   *proofWitness
+	disclosed map[int]*big.Int
+	sessionID [SessionIDSize]byte
+}
+
+// NewProverSession commits to a proof disclosing the messages at
+// disclosedIndices. The commitment and disclosed messages are sent to the
+// verifier, which answers with a challenge.
+func NewProverSession(
+	publicKey *PublicKey,
+	signature *Signature,
+	messages []*big.Int,
+	disclosedIndices []int,
+	header []byte,
+) (*ProverSession, *ProofCommitment, map[int]*big.Int, error) {
+	// Validate inputs
+	if len(messages) != publicKey.messageCount {
+		return nil, nil, nil, ErrInvalidMessageCount
+	}
+
+	disclosedMessages, err := selectDisclosed(messages, disclosedIndices)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	domain := CalculateDomain(publicKey, header)
+
+	witness, err := commitProof(publicKey, signature, messages, disclosedMessages, domain, rand.Reader)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	if _, err := rand.Read(witness.commitment.SessionID[:]); err != nil {
+		witness.wipe()
+		return nil, nil, nil, fmt.Errorf("failed to generate session ID: %w", err)
+	}
+
+	session := &ProverSession{
+		witness:   witness,
+		disclosed: disclosedMessages,
+		sessionID: witness.commitment.SessionID,
+	}
+
+	commitment := witness.commitment
+	return session, &commitment, disclosedMessages, nil
+}
+
+// CreateProofWithChallenge answers the verifier's challenge. It can be called
+// successfully only once; the blinding factors are wiped afterwards.
+func (ps *ProverSession) CreateProofWithChallenge(challenge *Challenge) (*ProofOfKnowledge, map[int]*big.Int, error) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	if ps.witness == nil {
+		return nil, nil, ErrSessionUsed
+	}
+
+	// A challenge for another session is rejected without consuming this one
+	if challenge == nil || challenge.SessionID != ps.sessionID {
+		return nil, nil, ErrChallengeMismatch
+	}
+
+	if challenge.Value == nil || challenge.Value.Sign() <= 0 || challenge.Value.Cmp(Order) >= 0 {
+		return nil, nil, fmt.Errorf("invalid challenge value")
+	}
+
+	proof := ps.witness.respond(challenge.Value)
+	ps.witness.wipe()
+	ps.witness = nil
+
+	return proof, ps.disclosed, nil
+}
+
+// VerifierSession holds the verifier state of one interactive proof
+type VerifierSession struct {
+	mu         sync.Mutex
+	publicKey  *PublicKey
+	domain     *big.Int
+	context    []byte
+	commitment *ProofCommitment
+	disclosed  map[int]*big.Int
+	challenge  *Challenge
+	used       bool
+}
+
+// NewVerifierSession starts an interactive verification for a proof over
+// credentials signed by publicKey with header. context is attached to the
+// challenge for the prover's benefit.
+func NewVerifierSession(publicKey *PublicKey, header []byte, context []byte) *VerifierSession {
+	return &VerifierSession{
+		publicKey: publicKey,
+		domain:    CalculateDomain(publicKey, header),
+		context:   append([]byte(nil), context...),
+	}
+}
+
+// IssueChallenge records the prover's commitment and the messages it claims
+// to disclose, and returns a fresh random challenge. A session issues at most
+// one challenge.
+func (vs *VerifierSession) IssueChallenge(commitment *ProofCommitment, disclosedMessages map[int]*big.Int) (*Challenge, error) {
+	vs.mu.Lock()
+	defer vs.mu.Unlock()
+
+	if vs.challenge != nil || vs.used {
+		return nil, ErrSessionUsed
+	}
+
+	if commitment == nil || commitment.APrime.IsInfinity() {
+		return nil, ErrInvalidProof
+	}
+
+	for idx, msg := range disclosedMessages {
+		if idx < 0 || idx >= vs.publicKey.messageCount || msg == nil {
+			return nil, fmt.Errorf("invalid disclosed message index: %d", idx)
+		}
+	}
+
+	value, err := randomNonZeroScalar()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate challenge: %w", err)
+	}
+
+	// Copy the statement so later changes by the caller cannot alter it
+	committed := *commitment
+	vs.commitment = &committed
+	vs.disclosed = make(map[int]*big.Int, len(disclosedMessages))
+	for idx, msg := range disclosedMessages {
+		vs.disclosed[idx] = new(big.Int).Set(msg)
+	}
+
+	vs.challenge = &Challenge{
+		SessionID: commitment.SessionID,
+		Value:     value,
+		Context:   append([]byte(nil), vs.context...),
+	}
+
+	challenge := *vs.challenge
+	challenge.Value = new(big.Int).Set(value)
+	return &challenge, nil
+}
+
+// VerifyInteractiveProof checks the prover's response against the issued
+// challenge and the recorded commitment. The session is consumed by the first
+// call whether or not the proof verifies.
+func (vs *VerifierSession) VerifyInteractiveProof(proof *ProofOfKnowledge) error {
+	vs.mu.Lock()
+	defer vs.mu.Unlock()
+
+	if vs.challenge == nil {
+		return fmt.Errorf("no challenge has been issued")
+	}
+	if vs.used {
+		return ErrSessionUsed
+	}
+	vs.used = true
+
+	if proof == nil || proof.C == nil {
+		return ErrInvalidProof
+	}
+
+	// The response must answer this session's challenge for the committed points
+	if !ConstantTimeEq(proof.C, vs.challenge.Value) {
+		return ErrChallengeMismatch
+	}
+	if !proof.APrime.Equal(&vs.commitment.APrime) ||
+		!proof.ABar.Equal(&vs.commitment.ABar) ||
+		!proof.D.Equal(&vs.commitment.D) {
+		return ErrChallengeMismatch
+	}
+
+	// Recompute the commitments from the responses and compare
+	T1, T2, err := recomputeProofCommitments(vs.publicKey, proof, vs.disclosed, vs.domain)
+	if err != nil {
+		return err
+	}
+
+	t1Bytes, t2Bytes := T1.Bytes(), T2.Bytes()
+	wantT1, wantT2 := vs.commitment.T1.Bytes(), vs.commitment.T2.Bytes()
+	if subtle.ConstantTimeCompare(t1Bytes[:], wantT1[:])&subtle.ConstantTimeCompare(t2Bytes[:], wantT2[:]) != 1 {
+		return ErrInvalidSignature
+	}
+
+	return checkProofPairing(vs.publicKey, proof)
+}
+
+// DisclosedMessages returns the messages recorded when the challenge was issued
+func (vs *VerifierSession) DisclosedMessages() map[int]*big.Int {
+	vs.mu.Lock()
+	defer vs.mu.Unlock()
+
+	out := make(map[int]*big.Int, len(vs.disclosed))
+	for idx, msg := range vs.disclosed {
+		out[idx] = new(big.Int).Set(msg)
+	}
+	return out
+}
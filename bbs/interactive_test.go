@@ -0,0 +1,221 @@
+package bbs
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381"
+)
+
+// interactiveFixture signs four messages and opens a prover session
+// disclosing messages 0 and 2
+func interactiveFixture(t *testing.T) (*KeyPair, *Signature, []*big.Int, []byte) {
+	t.Helper()
+
+	messages := []*big.Int{big.NewInt(10), big.NewInt(20), big.NewInt(30), big.NewInt(40)}
+	header := []byte("interactive header")
+	keyPair, signature := signedFixture(t, messages, header)
+
+	return keyPair, signature, messages, header
+}
+
+func TestInteractiveProof(t *testing.T) {
+	keyPair, signature, messages, header := interactiveFixture(t)
+
+	prover, commitment, disclosed, err := NewProverSession(keyPair.PublicKey, signature, messages, []int{0, 2}, header)
+	if err != nil {
+		t.Fatalf("NewProverSession failed: %v", err)
+	}
+
+	verifier := NewVerifierSession(keyPair.PublicKey, header, []byte("tx-42"))
+	challenge, err := verifier.IssueChallenge(commitment, disclosed)
+	if err != nil {
+		t.Fatalf("IssueChallenge failed: %v", err)
+	}
+	if string(challenge.Context) != "tx-42" {
+		t.Fatalf("Unexpected challenge context %q", challenge.Context)
+	}
+
+	proof, _, err := prover.CreateProofWithChallenge(challenge)
+	if err != nil {
+		t.Fatalf("CreateProofWithChallenge failed: %v", err)
+	}
+
+	if err := verifier.VerifyInteractiveProof(proof); err != nil {
+		t.Fatalf("VerifyInteractiveProof failed: %v", err)
+	}
+
+	// Neither side can be reused
+	if _, _, err := prover.CreateProofWithChallenge(challenge); !errors.Is(err, ErrSessionUsed) {
+		t.Fatalf("Expected ErrSessionUsed from prover, got %v", err)
+	}
+	if err := verifier.VerifyInteractiveProof(proof); !errors.Is(err, ErrSessionUsed) {
+		t.Fatalf("Expected ErrSessionUsed from verifier, got %v", err)
+	}
+	if _, err := verifier.IssueChallenge(commitment, disclosed); !errors.Is(err, ErrSessionUsed) {
+		t.Fatalf("Expected ErrSessionUsed for a second challenge, got %v", err)
+	}
+
+	// The response does not verify as a non-interactive proof
+	if err := VerifyProof(keyPair.PublicKey, proof, disclosed, header); err == nil {
+		t.Fatal("Interactive response verified as a Fiat-Shamir proof")
+	}
+}
+
+func TestInteractiveProofSessionMismatch(t *testing.T) {
+	keyPair, signature, messages, header := interactiveFixture(t)
+
+	proverA, commitmentA, disclosedA, err := NewProverSession(keyPair.PublicKey, signature, messages, []int{1}, header)
+	if err != nil {
+		t.Fatalf("NewProverSession failed: %v", err)
+	}
+	proverB, commitmentB, disclosedB, err := NewProverSession(keyPair.PublicKey, signature, messages, []int{1}, header)
+	if err != nil {
+		t.Fatalf("NewProverSession failed: %v", err)
+	}
+
+	verifierA := NewVerifierSession(keyPair.PublicKey, header, nil)
+	challengeA, err := verifierA.IssueChallenge(commitmentA, disclosedA)
+	if err != nil {
+		t.Fatalf("IssueChallenge failed: %v", err)
+	}
+
+	verifierB := NewVerifierSession(keyPair.PublicKey, header, nil)
+	challengeB, err := verifierB.IssueChallenge(commitmentB, disclosedB)
+	if err != nil {
+		t.Fatalf("IssueChallenge failed: %v", err)
+	}
+
+	// A challenge for another session is refused and does not consume the session
+	if _, _, err := proverA.CreateProofWithChallenge(challengeB); !errors.Is(err, ErrChallengeMismatch) {
+		t.Fatalf("Expected ErrChallengeMismatch, got %v", err)
+	}
+
+	if _, _, err := proverA.CreateProofWithChallenge(challengeA); err != nil {
+		t.Fatalf("CreateProofWithChallenge failed: %v", err)
+	}
+	proofB, _, err := proverB.CreateProofWithChallenge(challengeB)
+	if err != nil {
+		t.Fatalf("CreateProofWithChallenge failed: %v", err)
+	}
+
+	// Responses cannot be swapped between sessions
+	if err := verifierA.VerifyInteractiveProof(proofB); err == nil {
+		t.Fatal("Response from another session verified")
+	}
+	if err := verifierB.VerifyInteractiveProof(proofB); err != nil {
+		t.Fatalf("VerifyInteractiveProof failed: %v", err)
+	}
+}
+
+func TestInteractiveProofRejectsForgeries(t *testing.T) {
+	keyPair, signature, messages, header := interactiveFixture(t)
+
+	// Disclosed indices are checked like those of CreateProof
+	for _, indices := range [][]int{{-1}, {4}, {1, 1}} {
+		if _, _, _, err := NewProverSession(keyPair.PublicKey, signature, messages, indices, header); err == nil {
+			t.Fatalf("NewProverSession accepted disclosed indices %v", indices)
+		}
+	}
+
+	// A tampered response fails
+	prover, commitment, disclosed, err := NewProverSession(keyPair.PublicKey, signature, messages, []int{0}, header)
+	if err != nil {
+		t.Fatalf("NewProverSession failed: %v", err)
+	}
+	verifier := NewVerifierSession(keyPair.PublicKey, header, nil)
+	challenge, err := verifier.IssueChallenge(commitment, disclosed)
+	if err != nil {
+		t.Fatalf("IssueChallenge failed: %v", err)
+	}
+	proof, _, err := prover.CreateProofWithChallenge(challenge)
+	if err != nil {
+		t.Fatalf("CreateProofWithChallenge failed: %v", err)
+	}
+	proof.SHat = new(big.Int).Add(proof.SHat, big.NewInt(1))
+	if err := verifier.VerifyInteractiveProof(proof); err == nil {
+		t.Fatal("Tampered response verified")
+	}
+
+	// Disclosed messages are fixed when the challenge is issued
+	prover, commitment, disclosed, err = NewProverSession(keyPair.PublicKey, signature, messages, []int{0}, header)
+	if err != nil {
+		t.Fatalf("NewProverSession failed: %v", err)
+	}
+	verifier = NewVerifierSession(keyPair.PublicKey, header, nil)
+	forged := map[int]*big.Int{0: big.NewInt(11)}
+	challenge, err = verifier.IssueChallenge(commitment, forged)
+	if err != nil {
+		t.Fatalf("IssueChallenge failed: %v", err)
+	}
+	forged[0] = disclosed[0]
+	proof, _, err = prover.CreateProofWithChallenge(challenge)
+	if err != nil {
+		t.Fatalf("CreateProofWithChallenge failed: %v", err)
+	}
+	if err := verifier.VerifyInteractiveProof(proof); err == nil {
+		t.Fatal("Proof verified against a wrong disclosed message")
+	}
+
+	// Without a signature, a prover can only simulate a transcript for a
+	// challenge it guessed in advance; the verifier's fresh challenge differs
+	verifier = NewVerifierSession(keyPair.PublicKey, header, nil)
+	simulated, simCommitment := simulateTranscript(t, keyPair.PublicKey, disclosed, header)
+	challenge, err = verifier.IssueChallenge(simCommitment, disclosed)
+	if err != nil {
+		t.Fatalf("IssueChallenge failed: %v", err)
+	}
+	if challenge.Value.Cmp(simulated.C) == 0 {
+		t.Fatal("Verifier issued the guessed challenge")
+	}
+	simulated.C = challenge.Value
+	if err := verifier.VerifyInteractiveProof(simulated); err == nil {
+		t.Fatal("Simulated transcript verified")
+	}
+}
+
+// simulateTranscript builds an accepting-looking transcript for a chosen
+// challenge without knowing a signature: pick the responses at random and
+// solve for the commitments
+func simulateTranscript(t *testing.T, publicKey *PublicKey, disclosed map[int]*big.Int, header []byte) (*ProofOfKnowledge, *ProofCommitment) {
+	t.Helper()
+
+	random := func() *big.Int {
+		r, err := randomNonZeroScalar()
+		if err != nil {
+			t.Fatalf("Failed to generate scalar: %v", err)
+		}
+		return r
+	}
+
+	// A-bar = A' * x is unattainable without the key, so use unrelated points
+	var aPrime, aBar, d bls12381.G1Affine
+	aPrime.ScalarMultiplication(&publicKey.g1, random())
+	aBar.ScalarMultiplication(&publicKey.g1, random())
+	d.ScalarMultiplication(&publicKey.g1, random())
+
+	proof := &ProofOfKnowledge{
+		APrime: aPrime,
+		ABar:   aBar,
+		D:      d,
+		C:      random(),
+		EHat:   random(),
+		SHat:   random(),
+		R1Hat:  random(),
+		R3Hat:  random(),
+		MHat:   make(map[int]*big.Int),
+	}
+	for i := 0; i < publicKey.messageCount; i++ {
+		if _, ok := disclosed[i]; !ok {
+			proof.MHat[i] = random()
+		}
+	}
+
+	T1, T2, err := recomputeProofCommitments(publicKey, proof, disclosed, CalculateDomain(publicKey, header))
+	if err != nil {
+		t.Fatalf("Failed to simulate commitments: %v", err)
+	}
+
+	return proof, &ProofCommitment{APrime: aPrime, ABar: aBar, D: d, T1: T1, T2: T2}
+}
@@ -42,7 +42,7 @@ func GenerateKeyPair(messageCount int, rng io.Reader) (*KeyPair, error) {
 	// Get standard generators from BLS12-381
 	_, _, g1, g2 := bls12381.Generators() // Get generators in affine form
 
-	// Compute w = g2^x (PK.W in IRTF spec)
+	// Compute w = g2^x (PK.w in IRTF spec)
 	g2Jac := bls12381.G2Jac{}
 	g2Jac.FromAffine(&g2)
 	g2Jac.ScalarMultiplication(&g2Jac, x)
@@ -59,11 +59,11 @@ func GenerateKeyPair(messageCount int, rng io.Reader) (*KeyPair, error) {
 
 	// Create public key
 	pk := &PublicKey{
-		W:            w,
-		G2:           g2,
-		G1:           g1,
-		H:            generators,
-		MessageCount: messageCount,
+		w:            w,
+		g2:           g2,
+		g1:           g1,
+		h:            generators,
+		messageCount: messageCount,
 	}
 
 	return &KeyPair{
@@ -72,14 +72,15 @@ func GenerateKeyPair(messageCount int, rng io.Reader) (*KeyPair, error) {
 	}, nil
 }
 
-// SerializePrivateKey serializes a private key to bytes
+// SerializePrivateKey serializes a private key to a fixed-width scalar
 func SerializePrivateKey(sk *PrivateKey) []byte {
-	return sk.X.Bytes()
+	return appendScalar(make([]byte, 0, ScalarSize), sk.X)
 }
 
-// DeserializePrivateKey deserializes a private key from bytes
+// DeserializePrivateKey deserializes a private key from bytes. Shorter,
+// variable-width encodings from earlier versions are also accepted.
 func DeserializePrivateKey(data []byte) (*PrivateKey, error) {
-	if len(data) == 0 {
+	if len(data) == 0 || len(data) > ScalarSize {
 		return nil, fmt.Errorf("invalid private key data")
 	}
 
@@ -98,104 +99,70 @@ func DeserializePrivateKey(data []byte) (*PrivateKey, error) {
 // SerializePublicKey serializes a public key to bytes
 func SerializePublicKey(pk *PublicKey) []byte {
 	// Format:
-	// - W point (compressed G2 point)
-	// - Message count (4 bytes)
-	// - G1 generator (compressed G1 point)
-	// - G2 generator (compressed G2 point)
-	// - H generators (array of compressed G1 points)
-
-	var result []byte
-
-	// Add W point
-	result = append(result, pk.W.Marshal()...)
-
-	// Add message count (4 bytes, big endian)
-	countBytes := make([]byte, 4)
-	countBytes[0] = byte(pk.MessageCount >> 24)
-	countBytes[1] = byte(pk.MessageCount >> 16)
-	countBytes[2] = byte(pk.MessageCount >> 8)
-	countBytes[3] = byte(pk.MessageCount)
-	result = append(result, countBytes...)
-
-	// Add G1 generator
-	result = append(result, pk.G1.Marshal()...)
-
-	// Add G2 generator
-	result = append(result, pk.G2.Marshal()...)
-
-	// Add H generators
-	for _, h := range pk.H {
-		result = append(result, h.Marshal()...)
+	// - W point (compressed G2 point) - 96 bytes
+	// - Message count (4 bytes, big endian)
+	// - G1 generator (compressed G1 point) - 48 bytes
+	// - G2 generator (compressed G2 point) - 96 bytes
+	// - H generators (compressed G1 points) - 48 bytes each
+
+	result := make([]byte, 0, 2*G2Size+4+G1Size+len(pk.h)*G1Size)
+	result = appendG2(result, &pk.w)
+	result = appendUint32(result, uint32(pk.messageCount))
+	result = appendG1(result, &pk.g1)
+	result = appendG2(result, &pk.g2)
+	for i := range pk.h {
+		result = appendG1(result, &pk.h[i])
 	}
 
 	return result
 }
 
-// DeserializePublicKey deserializes a public key from bytes
+// DeserializePublicKey deserializes a public key from bytes, refusing keys
+// larger than DefaultLimits. Keys written by earlier versions with
+// uncompressed points are also accepted, and compact keys are expanded.
 func DeserializePublicKey(data []byte) (*PublicKey, error) {
-	if len(data) < 100 { // Minimum size based on required components
-		return nil, fmt.Errorf("invalid public key data")
+	if !isCompactEncoding(data) {
+		return deserializePublicKeyLegacy(data)
 	}
-
-	// Format:
-	// - W point (compressed G2 point) - 96 bytes
-	// - Message count (4 bytes)
-	// - G1 generator (compressed G1 point) - 48 bytes
-	// - G2 generator (compressed G2 point) - 96 bytes
-	// - H generators (array of compressed G1 points) - 48 bytes each
-
-	offset := 0
-
-	// Parse W
-	var w bls12381.G2Affine
-	err := w.Unmarshal(data[offset : offset+96])
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse W: %w", err)
+	if isCompactPublicKey(data) {
+		compact, err := DeserializeCompactPublicKey(data)
+		if err != nil {
+			return nil, err
+		}
+		return compact.Expand()
 	}
-	offset += 96
-
-	// Parse message count
-	messageCount := int(data[offset])<<24 | int(data[offset+1])<<16 |
-		int(data[offset+2])<<8 | int(data[offset+3])
-	offset += 4
-
-	// Parse G1 generator
-	var g1 bls12381.G1Affine
-	err = g1.Unmarshal(data[offset : offset+48])
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse G1: %w", err)
+
+	headerSize := 2*G2Size + 4 + G1Size
+	if len(data) < headerSize || (len(data)-headerSize)%G1Size != 0 {
+		return nil, fmt.Errorf("invalid public key data")
 	}
-	offset += 48
 
-	// Parse G2 generator
-	var g2 bls12381.G2Affine
-	err = g2.Unmarshal(data[offset : offset+96])
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse G2: %w", err)
+	r := &wireReader{data: data}
+	w := r.g2()
+	messageCount := int(r.uint32())
+	g1 := r.g1()
+	g2 := r.g2()
+	if r.err != nil {
+		return nil, fmt.Errorf("invalid public key data: %w", r.err)
+	}
+	if err := DefaultLimits.checkPublicKey(messageCount, r.remaining()/G1Size); err != nil {
+		return nil, err
 	}
-	offset += 96
 
 	// Parse H generators
-	h := make([]bls12381.G1Affine, 0, messageCount+2) // Q1, Q2, and message generators
-	for i := 0; i < messageCount+2; i++ {
-		if offset+48 > len(data) {
-			return nil, fmt.Errorf("insufficient data for H generators")
-		}
-
-		var point bls12381.G1Affine
-		err = point.Unmarshal(data[offset : offset+48])
-		if err != nil {
-			return nil, fmt.Errorf("failed to parse H[%d]: %w", i, err)
+	h := make([]bls12381.G1Affine, r.remaining()/G1Size)
+	for i := range h {
+		h[i] = r.g1()
+		if r.err != nil {
+			return nil, fmt.Errorf("failed to parse H[%d]: %w", i, r.err)
 		}
-		h = append(h, point)
-		offset += 48
 	}
 
 	return &PublicKey{
-		W:            w,
-		G2:           g2,
-		G1:           g1,
-		H:            h,
-		MessageCount: messageCount,
+		w:            w,
+		g2:           g2,
+		g1:           g1,
+		h:            h,
+		messageCount: messageCount,
 	}, nil
 }
@@ -116,6 +116,8 @@ func (kr *KeyRotation) GetKeyAtIndex(index int) (*KeyPair, error) {
 }
 
 // ThresholdKey represents a key that requires t-of-n participants to sign
+//
+// Experimental: threshold signing may change in a minor release.
 type ThresholdKey struct {
 	PublicKey    *PublicKey   // The combined public key
 	Threshold    int          // Number of shares needed (t)
@@ -124,6 +126,8 @@ type ThresholdKey struct {
 }
 
 // KeyShare represents a share of a threshold key
+//
+// Experimental: threshold signing may change in a minor release.
 type KeyShare struct {
 	Index      int          // Index of this share (1-based)
 	Share      *big.Int     // The share value
@@ -132,6 +136,8 @@ type KeyShare struct {
 }
 
 // ThresholdSignature represents a threshold signature
+//
+// Experimental: threshold signing may change in a minor release.
 type ThresholdSignature struct {
 	Signature  *Signature
 	Signers    []int
@@ -139,6 +145,8 @@ type ThresholdSignature struct {
 
 // GenerateThresholdKey creates a t-of-n threshold key
 // Returns the threshold key setup and n key shares
+//
+// Experimental: threshold signing may change in a minor release.
 func GenerateThresholdKey(t, n, messageCount int, rng io.Reader) (*ThresholdKey, []*KeyShare, error) {
 	if t <= 0 || n <= 0 || t > n {
 		return nil, nil, fmt.Errorf("invalid threshold parameters: t=%d, n=%d", t, n)
@@ -191,11 +199,11 @@ func GenerateThresholdKey(t, n, messageCount int, rng io.Reader) (*ThresholdKey,
 	}
 	
 	publicKey := &PublicKey{
-		G1:           *g1,
-		G2:           *g2,
-		W:            w,
-		H:            generators,
-		MessageCount: messageCount,
+		g1:           *g1,
+		g2:           *g2,
+		w:            w,
+		h:            generators,
+		messageCount: messageCount,
 	}
 	
 	// Create the threshold key struct
@@ -246,13 +254,15 @@ func GenerateThresholdKey(t, n, messageCount int, rng io.Reader) (*ThresholdKey,
 }
 
 // ThresholdSign creates a signature using t key shares
+//
+// Experimental: threshold signing may change in a minor release.
 func ThresholdSign(shares []*KeyShare, messages []*big.Int, header []byte) (*ThresholdSignature, error) {
 	if len(shares) == 0 {
 		return nil, fmt.Errorf("no shares provided")
 	}
 	
 	publicKey := shares[0].PublicKey
-	messageCount := publicKey.MessageCount
+	messageCount := publicKey.messageCount
 	
 	if len(messages) != messageCount {
 		return nil, ErrInvalidMessageCount
@@ -292,6 +302,8 @@ func ThresholdSign(shares []*KeyShare, messages []*big.Int, header []byte) (*Thr
 }
 
 // VerifyThresholdSignature verifies a threshold signature
+//
+// Experimental: threshold signing may change in a minor release.
 func VerifyThresholdSignature(thresholdKey *ThresholdKey, thresholdSig *ThresholdSignature, messages []*big.Int, header []byte) error {
 	// Use the standard verification function
 	return Verify(thresholdKey.PublicKey, thresholdSig.Signature, messages, header)
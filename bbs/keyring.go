@@ -0,0 +1,227 @@
+package bbs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrUnknownTenant is returned for a tenant ID a KeyRing does not hold
+var ErrUnknownTenant = errors.New("unknown tenant")
+
+// A KeyRing serves many issuers from one process, as a SaaS issuer hosting
+// its customers' keys does. Each tenant gets its own Engine, so its key
+// policy and verify cache are never shared with another tenant. Requests
+// pass an authorizer hook before they reach a tenant, and every operation
+// is reported to an observer labelled with the tenant ID.
+
+// TenantConfig configures one tenant of a KeyRing
+type TenantConfig struct {
+	// Signer holds the tenant's issuer key
+	Signer SignerBackend
+
+	// Policy, if set, is enforced on every signature of the tenant, with
+	// counters in PolicyStore or in memory if PolicyStore is nil
+	Policy      *KeyPolicy
+	PolicyStore PolicyStore
+
+	// CacheSize, if positive, gives the tenant a verify cache of that many
+	// proofs kept for CacheTTL
+	CacheSize int
+	CacheTTL  time.Duration
+}
+
+// TenantAuthorizer decides whether the caller in ctx may act for tenantID.
+// A server sets the caller's identity on ctx; a non-nil error refuses the
+// request.
+type TenantAuthorizer func(ctx context.Context, tenantID string) error
+
+// KeyRingObserver is told of every KeyRing operation, for metrics labelled
+// by tenant. operation is "sign" or "verify-proof".
+type KeyRingObserver func(tenantID, operation string, elapsed time.Duration, err error)
+
+// KeyRingOption configures a KeyRing
+type KeyRingOption func(*KeyRing)
+
+// WithTenantAuthorizer sets the hook every request must pass
+func WithTenantAuthorizer(authorize TenantAuthorizer) KeyRingOption {
+	return func(r *KeyRing) {
+		r.authorize = authorize
+	}
+}
+
+// WithKeyRingObserver sets the observer told of every operation
+func WithKeyRingObserver(observe KeyRingObserver) KeyRingOption {
+	return func(r *KeyRing) {
+		r.observe = observe
+	}
+}
+
+// KeyRing holds issuer keys addressed by tenant ID
+type KeyRing struct {
+	mu        sync.RWMutex
+	tenants   map[string]*tenant
+	authorize TenantAuthorizer
+	observe   KeyRingObserver
+}
+
+// tenant is the state of one tenant
+type tenant struct {
+	engine *Engine
+	cache  *VerifyCache
+}
+
+// NewKeyRing creates an empty key ring. Without an authorizer every caller
+// may act for every tenant.
+func NewKeyRing(opts ...KeyRingOption) *KeyRing {
+	r := &KeyRing{tenants: make(map[string]*tenant)}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	return r
+}
+
+// AddTenant registers a tenant. Tenant IDs must be unique.
+func (r *KeyRing) AddTenant(tenantID string, config TenantConfig) error {
+	if tenantID == "" {
+		return fmt.Errorf("tenant ID must not be empty")
+	}
+	if config.Signer == nil {
+		return ErrNoSigner
+	}
+
+	opts := []EngineOption{WithSigner(config.Signer)}
+	t := &tenant{}
+	if config.Policy != nil {
+		opts = append(opts, WithKeyPolicy(*config.Policy, config.PolicyStore))
+	}
+	if config.CacheSize > 0 {
+		t.cache = NewVerifyCache(config.CacheSize, config.CacheTTL)
+		opts = append(opts, WithVerifyCache(t.cache))
+	}
+	t.engine = NewEngine(opts...)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.tenants[tenantID]; ok {
+		return fmt.Errorf("tenant '%s' already exists", tenantID)
+	}
+	r.tenants[tenantID] = t
+	return nil
+}
+
+// RemoveTenant drops a tenant and reports whether it existed
+func (r *KeyRing) RemoveTenant(tenantID string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	_, ok := r.tenants[tenantID]
+	delete(r.tenants, tenantID)
+	return ok
+}
+
+// Tenants returns the tenant IDs in sorted order
+func (r *KeyRing) Tenants() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	ids := make([]string, 0, len(r.tenants))
+	for id := range r.tenants {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// PublicKey returns a tenant's public key
+func (r *KeyRing) PublicKey(tenantID string) (*PublicKey, error) {
+	t, err := r.tenant(tenantID)
+	if err != nil {
+		return nil, err
+	}
+	return t.engine.PublicKey(), nil
+}
+
+// CacheStats returns the counters of a tenant's verify cache, which are
+// zero if the tenant has none
+func (r *KeyRing) CacheStats(tenantID string) (VerifyCacheStats, error) {
+	t, err := r.tenant(tenantID)
+	if err != nil || t.cache == nil {
+		return VerifyCacheStats{}, err
+	}
+	return t.cache.Stats(), nil
+}
+
+// SignFor signs messages with the key of tenantID, subject to the
+// authorizer and the tenant's policy
+func (r *KeyRing) SignFor(ctx context.Context, tenantID string, messages []*big.Int, header []byte) (*Signature, error) {
+	var signature *Signature
+	err := r.run(ctx, tenantID, "sign", func(t *tenant) error {
+		var err error
+		signature, err = t.engine.Sign(messages, header)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return signature, nil
+}
+
+// VerifyProofFor verifies a proof against the key of tenantID, using the
+// tenant's verify cache
+func (r *KeyRing) VerifyProofFor(
+	ctx context.Context,
+	tenantID string,
+	proof *ProofOfKnowledge,
+	disclosedMessages map[int]*big.Int,
+	header []byte,
+) error {
+	return r.run(ctx, tenantID, "verify-proof", func(t *tenant) error {
+		return t.engine.VerifyProof(t.engine.PublicKey(), proof, disclosedMessages, header)
+	})
+}
+
+// run authorizes the caller, runs op for the tenant and reports it to the
+// observer
+func (r *KeyRing) run(ctx context.Context, tenantID, operation string, op func(*tenant) error) error {
+	start := time.Now()
+	err := r.authorizeAndRun(ctx, tenantID, op)
+	if r.observe != nil {
+		r.observe(tenantID, operation, time.Since(start), err)
+	}
+	return err
+}
+
+// authorizeAndRun runs op for the tenant if the authorizer allows it
+func (r *KeyRing) authorizeAndRun(ctx context.Context, tenantID string, op func(*tenant) error) error {
+	if r.authorize != nil {
+		if err := r.authorize(ctx, tenantID); err != nil {
+			return fmt.Errorf("tenant '%s': %w", tenantID, err)
+		}
+	}
+
+	t, err := r.tenant(tenantID)
+	if err != nil {
+		return err
+	}
+	return op(t)
+}
+
+// tenant looks up a tenant
+func (r *KeyRing) tenant(tenantID string) (*tenant, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	t, ok := r.tenants[tenantID]
+	if !ok {
+		return nil, fmt.Errorf("%w: '%s'", ErrUnknownTenant, tenantID)
+	}
+	return t, nil
+}
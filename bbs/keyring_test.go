@@ -0,0 +1,111 @@
+package bbs
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// tenantKey is the context key the test authorizer reads
+type tenantKey struct{}
+
+func TestKeyRing(t *testing.T) {
+	errForbidden := errors.New("forbidden")
+
+	var mu sync.Mutex
+	var observed []string
+	ring := NewKeyRing(
+		WithTenantAuthorizer(func(ctx context.Context, tenantID string) error {
+			if ctx.Value(tenantKey{}) != tenantID {
+				return errForbidden
+			}
+			return nil
+		}),
+		WithKeyRingObserver(func(tenantID, operation string, _ time.Duration, err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			observed = append(observed, tenantID+"/"+operation)
+		}),
+	)
+
+	for _, id := range []string{"acme", "globex"} {
+		keyPair, err := GenerateKeyPair(2, rand.Reader)
+		if err != nil {
+			t.Fatalf("Failed to generate key pair: %v", err)
+		}
+		if err := ring.AddTenant(id, TenantConfig{
+			Signer:    NewLocalSigner(keyPair),
+			Policy:    &KeyPolicy{MaxSignatures: 1},
+			CacheSize: 8,
+		}); err != nil {
+			t.Fatalf("AddTenant failed: %v", err)
+		}
+	}
+	if err := ring.AddTenant("acme", TenantConfig{Signer: &LocalSigner{}}); err == nil {
+		t.Fatalf("Expected a duplicate tenant to be refused")
+	}
+	if !slices.Equal(ring.Tenants(), []string{"acme", "globex"}) {
+		t.Fatalf("Unexpected tenants: %v", ring.Tenants())
+	}
+
+	messages := []*big.Int{big.NewInt(1), big.NewInt(2)}
+	acme := context.WithValue(context.Background(), tenantKey{}, "acme")
+
+	// The authorizer keeps callers to their own tenant
+	if _, err := ring.SignFor(acme, "globex", messages, nil); !errors.Is(err, errForbidden) {
+		t.Fatalf("Expected the authorizer to refuse, got %v", err)
+	}
+
+	signature, err := ring.SignFor(acme, "acme", messages, nil)
+	if err != nil {
+		t.Fatalf("SignFor failed: %v", err)
+	}
+
+	// Each tenant has its own policy counter
+	if _, err := ring.SignFor(acme, "acme", messages, nil); !errors.Is(err, ErrPolicyViolation) {
+		t.Fatalf("Expected ErrPolicyViolation, got %v", err)
+	}
+	globex := context.WithValue(context.Background(), tenantKey{}, "globex")
+	if _, err := ring.SignFor(globex, "globex", messages, nil); err != nil {
+		t.Fatalf("SignFor failed for the other tenant: %v", err)
+	}
+
+	// Proofs verify against their own tenant only, each with its own cache
+	acmeKey, _ := ring.PublicKey("acme")
+	proof, disclosed, err := CreateProof(acmeKey, signature, messages, []int{0}, nil)
+	if err != nil {
+		t.Fatalf("CreateProof failed: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := ring.VerifyProofFor(acme, "acme", proof, disclosed, nil); err != nil {
+			t.Fatalf("VerifyProofFor failed: %v", err)
+		}
+	}
+	if err := ring.VerifyProofFor(globex, "globex", proof, disclosed, nil); err == nil {
+		t.Fatalf("Expected the proof to fail under another tenant's key")
+	}
+	if stats, _ := ring.CacheStats("acme"); stats.Hits != 1 {
+		t.Fatalf("Expected one cache hit for acme, got %+v", stats)
+	}
+	if stats, _ := ring.CacheStats("globex"); stats.Hits != 0 {
+		t.Fatalf("Expected no cache hits for globex, got %+v", stats)
+	}
+
+	if !ring.RemoveTenant("globex") {
+		t.Fatalf("RemoveTenant failed")
+	}
+	if _, err := ring.SignFor(globex, "globex", messages, nil); !errors.Is(err, ErrUnknownTenant) {
+		t.Fatalf("Expected ErrUnknownTenant, got %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(observed) != 8 || observed[0] != "globex/sign" || observed[6] != "globex/verify-proof" {
+		t.Fatalf("Unexpected observations: %v", observed)
+	}
+}
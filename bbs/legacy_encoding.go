@@ -0,0 +1,219 @@
+package bbs
+
+import (
+	"fmt"
+	"math/big"
+
+	bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381"
+)
+
+// Readers for the encodings written before the compact wire format. The
+// Serialize* functions used uncompressed points and scalars with a one byte
+// length prefix; the MarshalBinary methods prefixed every field with a four
+// byte length. New data is always written in the compact format.
+
+// lengthPrefixed reads a field preceded by a big-endian length of prefixSize bytes
+func (r *wireReader) lengthPrefixed(prefixSize int) []byte {
+	var n int
+	if prefixSize == 1 {
+		b := r.next(1)
+		if b == nil {
+			return nil
+		}
+		n = int(b[0])
+	} else {
+		n = int(r.uint32())
+	}
+	if r.err == nil && n > len(r.data) {
+		r.err = errShortBuffer
+	}
+	return r.next(n)
+}
+
+// legacyScalar reads a length-prefixed variable-width scalar
+func (r *wireReader) legacyScalar(prefixSize int) *big.Int {
+	b := r.lengthPrefixed(prefixSize)
+	if r.err != nil {
+		return nil
+	}
+	if len(b) > ScalarSize {
+		r.err = fmt.Errorf("scalar too long: %d bytes", len(b))
+		return nil
+	}
+	return new(big.Int).SetBytes(b)
+}
+
+// legacyG1 reads a G1 point with a four byte length prefix
+func (r *wireReader) legacyG1() bls12381.G1Affine {
+	inner := &wireReader{data: r.lengthPrefixed(4), err: r.err}
+	p := inner.g1()
+	r.err = inner.err
+	return p
+}
+
+// legacyG2 reads a G2 point with a four byte length prefix
+func (r *wireReader) legacyG2() bls12381.G2Affine {
+	inner := &wireReader{data: r.lengthPrefixed(4), err: r.err}
+	p := inner.g2()
+	r.err = inner.err
+	return p
+}
+
+// deserializeSignatureLegacy reads the original SerializeSignature format
+func deserializeSignatureLegacy(data []byte) (*Signature, error) {
+	r := &wireReader{data: data}
+	sig := &Signature{
+		A: r.g1(),
+		E: r.legacyScalar(1),
+		S: r.legacyScalar(1),
+	}
+	if r.err != nil {
+		return nil, ErrInvalidSignatureData
+	}
+	return sig, nil
+}
+
+// unmarshalSignatureLegacy reads the original Signature.MarshalBinary format
+func unmarshalSignatureLegacy(data []byte) (*Signature, error) {
+	r := &wireReader{data: data}
+	sig := &Signature{
+		A: r.legacyG1(),
+		E: r.legacyScalar(4),
+		S: r.legacyScalar(4),
+	}
+	if r.err != nil {
+		return nil, ErrInvalidSignatureData
+	}
+	return sig, nil
+}
+
+// deserializeProofLegacy reads the original SerializeProof format
+func deserializeProofLegacy(data []byte, limits Limits) (*ProofOfKnowledge, error) {
+	r := &wireReader{data: data}
+	proof := &ProofOfKnowledge{
+		APrime: r.g1(),
+		ABar:   r.g1(),
+		D:      r.g1(),
+		C:      r.legacyScalar(1),
+		EHat:   r.legacyScalar(1),
+		SHat:   r.legacyScalar(1),
+		R1Hat:  r.legacyScalar(1),
+		R3Hat:  r.legacyScalar(1),
+	}
+
+	countByte := r.next(1)
+	if r.err != nil {
+		return nil, ErrInvalidProofData
+	}
+
+	count := int(countByte[0])
+	if err := limits.CheckMHatEntries(count); err != nil {
+		return nil, err
+	}
+	proof.MHat = make(map[int]*big.Int, count)
+	for i := 0; i < count; i++ {
+		idx := int(r.uint32())
+		proof.MHat[idx] = r.legacyScalar(1)
+	}
+	if r.err != nil {
+		return nil, ErrInvalidProofData
+	}
+
+	return proof, nil
+}
+
+// unmarshalProofLegacy reads the original ProofOfKnowledge.MarshalBinary format
+func unmarshalProofLegacy(data []byte, limits Limits) (*ProofOfKnowledge, error) {
+	r := &wireReader{data: data}
+	proof := &ProofOfKnowledge{
+		APrime: r.legacyG1(),
+		ABar:   r.legacyG1(),
+		D:      r.legacyG1(),
+		C:      r.legacyScalar(4),
+		EHat:   r.legacyScalar(4),
+		SHat:   r.legacyScalar(4),
+		R1Hat:  r.legacyScalar(4),
+		R3Hat:  r.legacyScalar(4),
+	}
+
+	count := r.uint32()
+	if r.err != nil || uint64(count)*8 > uint64(r.remaining()) {
+		return nil, ErrInvalidProofData
+	}
+	if err := limits.CheckMHatEntries(int(count)); err != nil {
+		return nil, err
+	}
+
+	proof.MHat = make(map[int]*big.Int, count)
+	for i := uint32(0); i < count; i++ {
+		idx := int(int32(r.uint32()))
+		proof.MHat[idx] = r.legacyScalar(4)
+	}
+	if r.err != nil {
+		return nil, ErrInvalidProofData
+	}
+
+	return proof, nil
+}
+
+// deserializePublicKeyLegacy reads the original SerializePublicKey format:
+// W || count || G1 || G2 || H... with uncompressed points
+func deserializePublicKeyLegacy(data []byte) (*PublicKey, error) {
+	r := &wireReader{data: data}
+	pk := &PublicKey{w: r.g2()}
+	pk.messageCount = int(r.uint32())
+	pk.g1 = r.g1()
+	pk.g2 = r.g2()
+	if r.err != nil {
+		return nil, fmt.Errorf("invalid public key data: %w", r.err)
+	}
+	if err := DefaultLimits.checkPublicKey(pk.messageCount, r.remaining()/(2*G1Size)); err != nil {
+		return nil, err
+	}
+
+	for r.remaining() > 0 {
+		pk.h = append(pk.h, r.g1())
+		if r.err != nil {
+			return nil, fmt.Errorf("failed to parse H[%d]: %w", len(pk.h)-1, r.err)
+		}
+	}
+
+	return pk, nil
+}
+
+// unmarshalPublicKeyLegacy reads the original PublicKey.MarshalBinary format
+func unmarshalPublicKeyLegacy(data []byte) (*PublicKey, error) {
+	r := &wireReader{data: data}
+	pk := &PublicKey{messageCount: int(r.uint32())}
+	pk.w = r.legacyG2()
+	pk.g1 = r.legacyG1()
+	pk.g2 = r.legacyG2()
+
+	numH := r.uint32()
+	if r.err != nil || uint64(numH)*4 > uint64(r.remaining()) {
+		return nil, fmt.Errorf("invalid public key data")
+	}
+	if err := DefaultLimits.checkPublicKey(pk.messageCount, int(numH)); err != nil {
+		return nil, err
+	}
+
+	pk.h = make([]bls12381.G1Affine, numH)
+	for i := range pk.h {
+		pk.h[i] = r.legacyG1()
+	}
+	if r.err != nil {
+		return nil, fmt.Errorf("invalid public key data: %w", r.err)
+	}
+
+	return pk, nil
+}
+
+// unmarshalPrivateKeyLegacy reads the original PrivateKey.MarshalBinary format
+func unmarshalPrivateKeyLegacy(data []byte) (*PrivateKey, error) {
+	r := &wireReader{data: data}
+	x := r.legacyScalar(4)
+	if r.err != nil {
+		return nil, fmt.Errorf("invalid private key data: %w", r.err)
+	}
+	return DeserializePrivateKey(x.Bytes())
+}
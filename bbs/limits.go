@@ -0,0 +1,104 @@
+package bbs
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Every length in a proof or key encoding is chosen by whoever wrote it. The
+// decoders already refuse counts the remaining data cannot hold, but a
+// megabyte-sized proof still decodes to tens of thousands of map entries and
+// big.Ints before verification rejects it. Limits puts a hard ceiling on
+// each operation's working memory that holds whatever the encoding claims.
+
+// ErrLimitExceeded is returned when an input is larger than the limits allow
+var ErrLimitExceeded = errors.New("limit exceeded")
+
+// Limits bounds the inputs of parsing and verification. A zero field takes
+// its value from DefaultLimits.
+type Limits struct {
+	// MaxMessageCount is the largest message count of a key or credential
+	MaxMessageCount int
+
+	// MaxProofBytes is the largest encoded proof accepted
+	MaxProofBytes int
+
+	// MaxMHatEntries is the largest number of hidden message responses, and
+	// of commitment equality responses, in a proof
+	MaxMHatEntries int
+}
+
+// DefaultLimits applies to DeserializeProof, DeserializePublicKey and proof
+// verification. The defaults hold credentials of thousands of attributes;
+// services that know their schemas should lower them.
+var DefaultLimits = Limits{
+	MaxMessageCount: 4096,
+	MaxProofBytes:   256 * 1024,
+	MaxMHatEntries:  4096,
+}
+
+// withDefaults fills the zero fields of l from DefaultLimits
+func (l Limits) withDefaults() Limits {
+	if l.MaxMessageCount == 0 {
+		l.MaxMessageCount = DefaultLimits.MaxMessageCount
+	}
+	if l.MaxProofBytes == 0 {
+		l.MaxProofBytes = DefaultLimits.MaxProofBytes
+	}
+	if l.MaxMHatEntries == 0 {
+		l.MaxMHatEntries = DefaultLimits.MaxMHatEntries
+	}
+	return l
+}
+
+// CheckMessageCount returns ErrLimitExceeded for more than MaxMessageCount
+// messages
+func (l Limits) CheckMessageCount(n int) error {
+	if limit := l.withDefaults().MaxMessageCount; n > limit {
+		return fmt.Errorf("%w: %d messages, at most %d allowed", ErrLimitExceeded, n, limit)
+	}
+	return nil
+}
+
+// CheckProofBytes returns ErrLimitExceeded for an encoded proof of more than
+// MaxProofBytes
+func (l Limits) CheckProofBytes(n int) error {
+	if limit := l.withDefaults().MaxProofBytes; n > limit {
+		return fmt.Errorf("%w: proof of %d bytes, at most %d allowed", ErrLimitExceeded, n, limit)
+	}
+	return nil
+}
+
+// CheckMHatEntries returns ErrLimitExceeded for more than MaxMHatEntries
+// responses
+func (l Limits) CheckMHatEntries(n int) error {
+	if limit := l.withDefaults().MaxMHatEntries; n > limit {
+		return fmt.Errorf("%w: %d proof responses, at most %d allowed", ErrLimitExceeded, n, limit)
+	}
+	return nil
+}
+
+// CheckProof checks a decoded proof and the key it is verified under
+func (l Limits) CheckProof(publicKey *PublicKey, proof *ProofOfKnowledge) error {
+	if publicKey != nil {
+		if err := l.CheckMessageCount(publicKey.messageCount); err != nil {
+			return err
+		}
+	}
+	if proof == nil {
+		return nil
+	}
+	if err := l.CheckMHatEntries(len(proof.MHat)); err != nil {
+		return err
+	}
+	return l.CheckMHatEntries(len(proof.CommitmentHat))
+}
+
+// checkPublicKey checks the message count of a decoded key and its number of
+// generators, which is two more than the message count
+func (l Limits) checkPublicKey(messageCount, generators int) error {
+	if err := l.CheckMessageCount(messageCount); err != nil {
+		return err
+	}
+	return l.CheckMessageCount(generators - 2)
+}
@@ -0,0 +1,83 @@
+package bbs
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"math/big"
+	"testing"
+)
+
+func TestDeserializeProofLimits(t *testing.T) {
+	keyPair, err := GenerateKeyPair(6, rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKeyPair failed: %v", err)
+	}
+	messages := make([]*big.Int, 6)
+	for i := range messages {
+		messages[i] = big.NewInt(int64(i + 1))
+	}
+	signature, err := Sign(keyPair.PrivateKey, keyPair.PublicKey, messages, nil)
+	if err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+	proof, _, err := CreateProof(keyPair.PublicKey, signature, messages, []int{0}, nil)
+	if err != nil {
+		t.Fatalf("CreateProof failed: %v", err)
+	}
+	data := SerializeProof(proof)
+
+	tight := Limits{MaxMHatEntries: 4}
+	if _, err := DeserializeProofWithLimits(data, tight); !errors.Is(err, ErrLimitExceeded) {
+		t.Fatalf("Expected ErrLimitExceeded for 5 responses, got %v", err)
+	}
+	if _, err := DeserializeProofWithLimits(data, Limits{MaxProofBytes: len(data) - 1}); !errors.Is(err, ErrLimitExceeded) {
+		t.Fatalf("Expected ErrLimitExceeded for a long proof, got %v", err)
+	}
+	if _, err := DeserializeProofWithLimits(data, Limits{MaxMHatEntries: 5, MaxProofBytes: len(data)}); err != nil {
+		t.Fatalf("DeserializeProofWithLimits failed at the limit: %v", err)
+	}
+
+	// A claimed count the data could hold, but the limit does not allow
+	hostile := append([]byte(nil), data[:3*G1Size+5*ScalarSize]...)
+	hostile = binary.BigEndian.AppendUint32(hostile, 1000)
+	hostile = append(hostile, make([]byte, 1000*(4+ScalarSize))...)
+	if _, err := DeserializeProofWithLimits(hostile, Limits{MaxMHatEntries: 100}); !errors.Is(err, ErrLimitExceeded) {
+		t.Fatalf("Expected ErrLimitExceeded for a hostile count, got %v", err)
+	}
+
+	// Verification applies DefaultLimits to decoded proofs
+	saved := DefaultLimits
+	defer func() { DefaultLimits = saved }()
+	DefaultLimits.MaxMHatEntries = 4
+	disclosed := map[int]*big.Int{0: messages[0]}
+	if err := VerifyProof(keyPair.PublicKey, proof, disclosed, nil); !errors.Is(err, ErrLimitExceeded) {
+		t.Fatalf("Expected ErrLimitExceeded from VerifyProof, got %v", err)
+	}
+	if _, err := DeserializeProof(data); !errors.Is(err, ErrLimitExceeded) {
+		t.Fatalf("Expected ErrLimitExceeded from DeserializeProof, got %v", err)
+	}
+}
+
+func TestDeserializePublicKeyLimits(t *testing.T) {
+	keyPair, err := GenerateKeyPair(8, rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKeyPair failed: %v", err)
+	}
+	data := SerializePublicKey(keyPair.PublicKey)
+
+	saved := DefaultLimits
+	defer func() { DefaultLimits = saved }()
+	DefaultLimits.MaxMessageCount = 7
+	if _, err := DeserializePublicKey(data); !errors.Is(err, ErrLimitExceeded) {
+		t.Fatalf("Expected ErrLimitExceeded, got %v", err)
+	}
+	if _, err := DeserializeCompressedProof(nil, 8, nil); !errors.Is(err, ErrLimitExceeded) {
+		t.Fatalf("Expected ErrLimitExceeded for a compressed proof, got %v", err)
+	}
+
+	DefaultLimits.MaxMessageCount = 8
+	if _, err := DeserializePublicKey(data); err != nil {
+		t.Fatalf("DeserializePublicKey failed at the limit: %v", err)
+	}
+}
@@ -0,0 +1,196 @@
+package bbs
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sort"
+)
+
+// Domain separation tags for list attributes
+const (
+	listItemDST    = "BBS_BLS12381_LIST_ITEM_"
+	listPaddingDST = "BBS_BLS12381_LIST_PADDING_"
+)
+
+// List attributes (e.g. licenses held) are signed as ListSlots messages: the
+// items fill the first slots and the rest hold padding elements derived from
+// the attribute name and slot number. A proof can disclose single slots while
+// the others stay hidden, so the verifier does not learn how many items the
+// list holds beyond the slots it sees. Revealing slot k still shows the list
+// has at least k+1 items; issuers that want to hide this too can shuffle the
+// items before encoding them.
+
+// EncodeList converts the items of a list attribute into exactly ListSlots
+// field elements, padding unused slots. List mode must be enabled.
+func (mp *MessagePreprocessor) EncodeList(attribute string, items []interface{}) ([]*big.Int, error) {
+	if !mp.EnableListMode {
+		return nil, fmt.Errorf("list mode is not enabled")
+	}
+	if mp.ListSlots <= 0 {
+		return nil, fmt.Errorf("invalid list slot count: %d", mp.ListSlots)
+	}
+	if len(items) > mp.ListSlots {
+		return nil, fmt.Errorf("list %q has %d items, more than %d slots", attribute, len(items), mp.ListSlots)
+	}
+
+	elements := make([]*big.Int, mp.ListSlots)
+	for i, item := range items {
+		fe, err := mp.ListItemElement(attribute, item)
+		if err != nil {
+			return nil, fmt.Errorf("failed to preprocess item %d of %q: %w", i, attribute, err)
+		}
+		elements[i] = fe
+	}
+	for i := len(items); i < mp.ListSlots; i++ {
+		elements[i] = ListPaddingElement(attribute, i)
+	}
+
+	return elements, nil
+}
+
+// ListItemElement returns the field element an item of a list attribute is
+// signed as. Verifiers use it to check a disclosed slot.
+func (mp *MessagePreprocessor) ListItemElement(attribute string, item interface{}) (*big.Int, error) {
+	jsonData, err := json.Marshal(item)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert object to JSON: %w", err)
+	}
+
+	canonicalJSON, err := mp.canonicalJSON(jsonData)
+	if err != nil {
+		return nil, err
+	}
+
+	var buff []byte
+	buff = append(buff, listItemDST...)
+	buff = append(buff, uint32ToBytes(uint32(len(attribute)))...)
+	buff = append(buff, attribute...)
+	buff = append(buff, canonicalJSON...)
+
+	return MessageToFieldElement(buff), nil
+}
+
+// ListPaddingElement returns the padding element of an unused slot. It is
+// domain separated from items, so padding cannot be disclosed as an item.
+func ListPaddingElement(attribute string, slot int) *big.Int {
+	var buff []byte
+	buff = append(buff, listPaddingDST...)
+	buff = append(buff, uint32ToBytes(uint32(len(attribute)))...)
+	buff = append(buff, attribute...)
+	buff = append(buff, uint32ToBytes(uint32(slot))...)
+
+	return MessageToFieldElement(buff)
+}
+
+// ListSlot addresses one message of a credential: a slot of a list
+// attribute, or slot 0 of a single-valued attribute
+type ListSlot struct {
+	Attribute string
+	Slot      int
+}
+
+// MessageLayout maps named attributes to message indices. Single-valued
+// attributes take one message, list attributes a fixed number of
+// consecutive messages. Issuer, holder and verifier must build the same
+// layout.
+type MessageLayout struct {
+	offsets map[string]int
+	slots   map[string]int
+	count   int
+}
+
+// NewMessageLayout creates an empty layout
+func NewMessageLayout() *MessageLayout {
+	return &MessageLayout{
+		offsets: make(map[string]int),
+		slots:   make(map[string]int),
+	}
+}
+
+// AddAttribute appends a single-valued attribute and returns its index
+func (l *MessageLayout) AddAttribute(attribute string) (int, error) {
+	return l.AddList(attribute, 1)
+}
+
+// AddList appends a list attribute of slots messages and returns the index
+// of its first slot
+func (l *MessageLayout) AddList(attribute string, slots int) (int, error) {
+	if _, exists := l.offsets[attribute]; exists {
+		return 0, fmt.Errorf("duplicate attribute %q", attribute)
+	}
+	if slots <= 0 {
+		return 0, fmt.Errorf("invalid slot count for %q: %d", attribute, slots)
+	}
+
+	offset := l.count
+	l.offsets[attribute] = offset
+	l.slots[attribute] = slots
+	l.count += slots
+
+	return offset, nil
+}
+
+// MessageCount returns the number of messages the layout spans
+func (l *MessageLayout) MessageCount() int {
+	return l.count
+}
+
+// Index returns the message index of a slot
+func (l *MessageLayout) Index(slot ListSlot) (int, error) {
+	offset, ok := l.offsets[slot.Attribute]
+	if !ok {
+		return 0, fmt.Errorf("unknown attribute %q", slot.Attribute)
+	}
+	if slot.Slot < 0 || slot.Slot >= l.slots[slot.Attribute] {
+		return 0, fmt.Errorf("slot %d out of range for %q", slot.Slot, slot.Attribute)
+	}
+
+	return offset + slot.Slot, nil
+}
+
+// DisclosedIndices returns the message indices to disclose for slots, in
+// ascending order, for use with CreateProof
+func (l *MessageLayout) DisclosedIndices(slots ...ListSlot) ([]int, error) {
+	indices := make([]int, 0, len(slots))
+	seen := make(map[int]bool, len(slots))
+	for _, slot := range slots {
+		idx, err := l.Index(slot)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[idx] {
+			seen[idx] = true
+			indices = append(indices, idx)
+		}
+	}
+
+	sort.Ints(indices)
+	return indices, nil
+}
+
+// DisclosedSlots maps the disclosed messages of a proof back to the slots
+// they address
+func (l *MessageLayout) DisclosedSlots(disclosedMessages map[int]*big.Int) (map[ListSlot]*big.Int, error) {
+	slots := make(map[ListSlot]*big.Int, len(disclosedMessages))
+	for idx, msg := range disclosedMessages {
+		slot, err := l.slotAt(idx)
+		if err != nil {
+			return nil, err
+		}
+		slots[slot] = msg
+	}
+
+	return slots, nil
+}
+
+// slotAt returns the slot at message index idx
+func (l *MessageLayout) slotAt(idx int) (ListSlot, error) {
+	for attribute, offset := range l.offsets {
+		if idx >= offset && idx < offset+l.slots[attribute] {
+			return ListSlot{Attribute: attribute, Slot: idx - offset}, nil
+		}
+	}
+
+	return ListSlot{}, fmt.Errorf("message index %d is not in the layout", idx)
+}
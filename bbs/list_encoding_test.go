@@ -0,0 +1,120 @@
+package bbs
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+)
+
+func TestListAttributeDisclosure(t *testing.T) {
+	mp := NewMessagePreprocessor()
+	mp.EnableListMode = true
+	mp.ListSlots = 4
+
+	layout := NewMessageLayout()
+	if _, err := layout.AddAttribute("name"); err != nil {
+		t.Fatalf("AddAttribute failed: %v", err)
+	}
+	if _, err := layout.AddList("licenses", mp.ListSlots); err != nil {
+		t.Fatalf("AddList failed: %v", err)
+	}
+	if _, err := layout.AddAttribute("name"); err == nil {
+		t.Fatal("Duplicate attribute accepted")
+	}
+
+	licenses, err := mp.EncodeList("licenses", []interface{}{"car", "boat"})
+	if err != nil {
+		t.Fatalf("EncodeList failed: %v", err)
+	}
+	if len(licenses) != mp.ListSlots {
+		t.Fatalf("Expected %d slots, got %d", mp.ListSlots, len(licenses))
+	}
+
+	// Padding is deterministic and differs from items
+	if licenses[2].Cmp(ListPaddingElement("licenses", 2)) != 0 {
+		t.Fatal("Unused slot does not hold the padding element")
+	}
+	if licenses[2].Cmp(licenses[3]) == 0 {
+		t.Fatal("Padding elements of different slots are equal")
+	}
+
+	messages := append([]*big.Int{MessageToFieldElement([]byte("alice"))}, licenses...)
+	if len(messages) != layout.MessageCount() {
+		t.Fatalf("Layout spans %d messages, have %d", layout.MessageCount(), len(messages))
+	}
+
+	keyPair, err := GenerateKeyPair(layout.MessageCount(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key pair: %v", err)
+	}
+	signature, err := Sign(keyPair.PrivateKey, keyPair.PublicKey, messages, nil)
+	if err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+
+	// Disclose the second license only
+	indices, err := layout.DisclosedIndices(ListSlot{Attribute: "licenses", Slot: 1})
+	if err != nil {
+		t.Fatalf("DisclosedIndices failed: %v", err)
+	}
+	proof, disclosed, err := CreateProof(keyPair.PublicKey, signature, messages, indices, nil)
+	if err != nil {
+		t.Fatalf("CreateProof failed: %v", err)
+	}
+	if err := VerifyProof(keyPair.PublicKey, proof, disclosed, nil); err != nil {
+		t.Fatalf("VerifyProof failed: %v", err)
+	}
+
+	// The verifier maps the disclosure back to the slot and checks the item
+	slots, err := layout.DisclosedSlots(disclosed)
+	if err != nil {
+		t.Fatalf("DisclosedSlots failed: %v", err)
+	}
+	got, ok := slots[ListSlot{Attribute: "licenses", Slot: 1}]
+	if !ok || len(slots) != 1 {
+		t.Fatalf("Unexpected disclosed slots: %v", slots)
+	}
+	want, err := mp.ListItemElement("licenses", "boat")
+	if err != nil {
+		t.Fatalf("ListItemElement failed: %v", err)
+	}
+	if got.Cmp(want) != 0 {
+		t.Fatal("Disclosed slot does not match the item")
+	}
+
+	// Lists of different lengths sign the same number of messages
+	short, err := mp.EncodeList("licenses", []interface{}{"car"})
+	if err != nil {
+		t.Fatalf("EncodeList failed: %v", err)
+	}
+	if len(short) != len(licenses) {
+		t.Fatal("List length leaks through the message count")
+	}
+}
+
+func TestListEncodingErrors(t *testing.T) {
+	mp := NewMessagePreprocessor()
+	if _, err := mp.EncodeList("licenses", []interface{}{"car"}); err == nil {
+		t.Fatal("EncodeList succeeded with list mode disabled")
+	}
+
+	mp.EnableListMode = true
+	mp.ListSlots = 2
+	if _, err := mp.EncodeList("licenses", []interface{}{"a", "b", "c"}); err == nil {
+		t.Fatal("EncodeList accepted more items than slots")
+	}
+
+	layout := NewMessageLayout()
+	if _, err := layout.AddList("licenses", 2); err != nil {
+		t.Fatalf("AddList failed: %v", err)
+	}
+	if _, err := layout.Index(ListSlot{Attribute: "licenses", Slot: 2}); err == nil {
+		t.Fatal("Out-of-range slot accepted")
+	}
+	if _, err := layout.Index(ListSlot{Attribute: "age"}); err == nil {
+		t.Fatal("Unknown attribute accepted")
+	}
+	if _, err := layout.DisclosedSlots(map[int]*big.Int{5: big.NewInt(1)}); err == nil {
+		t.Fatal("Index outside the layout accepted")
+	}
+}
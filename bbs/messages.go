@@ -0,0 +1,99 @@
+package bbs
+
+import (
+	"math/big"
+)
+
+// Sign and Verify take messages that are already field elements. Callers
+// holding raw bytes must hash them first, and passing an element through
+// MessageToFieldElement a second time silently yields a different message.
+// Message and FieldElement keep the two apart in the type system: raw
+// messages go to SignRaw and VerifyRaw, which encode them, and encoded ones
+// go to SignEncoded and VerifyEncoded, which do not.
+
+// Message is a raw message that has not been mapped to a field element
+type Message []byte
+
+// FieldElement is a message mapped to a scalar in [0, Order). The zero value
+// is the element 0.
+type FieldElement struct {
+	value *big.Int
+}
+
+// NewFieldElement wraps v, which must already be reduced modulo Order. It is
+// the only way to build a FieldElement outside this package, so encoders
+// cannot produce unreduced values.
+func NewFieldElement(v *big.Int) (FieldElement, error) {
+	if v == nil || v.Sign() < 0 || v.Cmp(Order) >= 0 {
+		return FieldElement{}, ErrInvalidFieldElement
+	}
+
+	return FieldElement{value: new(big.Int).Set(v)}, nil
+}
+
+// Int returns the element as a big.Int. The result is a copy.
+func (fe FieldElement) Int() *big.Int {
+	if fe.value == nil {
+		return new(big.Int)
+	}
+	return new(big.Int).Set(fe.value)
+}
+
+// MessageEncoder maps raw messages to field elements
+type MessageEncoder interface {
+	Encode(msg Message) FieldElement
+}
+
+// SHA256Encoder encodes a message as its SHA-256 digest reduced modulo
+// Order, the legacy mapping of LegacySHA256
+type SHA256Encoder struct{}
+
+// Encode hashes msg to a field element
+func (SHA256Encoder) Encode(msg Message) FieldElement {
+	return FieldElement{value: legacyMessageToFieldElement(msg)}
+}
+
+// DefaultMessageEncoder is the encoder used by SignRaw and VerifyRaw. It
+// follows DefaultCiphersuite, the same mapping as MessageToFieldElement.
+var DefaultMessageEncoder MessageEncoder = CiphersuiteEncoder{}
+
+// EncodeMessages maps raw messages to field elements with enc
+func EncodeMessages(enc MessageEncoder, messages []Message) []FieldElement {
+	elements := make([]FieldElement, len(messages))
+	for i, msg := range messages {
+		elements[i] = enc.Encode(msg)
+	}
+	return elements
+}
+
+// FieldElementInts converts elements to the big.Int form taken by
+// CreateProof and the other lower level functions
+func FieldElementInts(elements []FieldElement) []*big.Int {
+	ints := make([]*big.Int, len(elements))
+	for i, fe := range elements {
+		ints[i] = fe.Int()
+	}
+	return ints
+}
+
+// SignRaw encodes raw messages with DefaultMessageEncoder and signs them
+func SignRaw(sk *PrivateKey, pk *PublicKey, messages []Message, header []byte) (*Signature, error) {
+	return SignEncoded(sk, pk, EncodeMessages(DefaultMessageEncoder, messages), header)
+}
+
+// SignEncoded signs messages that are already field elements
+func SignEncoded(sk *PrivateKey, pk *PublicKey, messages []FieldElement, header []byte) (*Signature, error) {
+	return Sign(sk, pk, FieldElementInts(messages), header)
+}
+
+// VerifyRaw encodes raw messages with DefaultMessageEncoder and verifies a
+// signature on them
+func VerifyRaw(pk *PublicKey, signature *Signature, messages []Message, header []byte) error {
+	return VerifyEncoded(pk, signature, EncodeMessages(DefaultMessageEncoder, messages), header)
+}
+
+// VerifyEncoded verifies a signature on messages that are already field
+// elements
+func VerifyEncoded(pk *PublicKey, signature *Signature, messages []FieldElement, header []byte) error {
+	return Verify(pk, signature, FieldElementInts(messages), header)
+}
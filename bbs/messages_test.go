@@ -0,0 +1,80 @@
+package bbs
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"testing"
+)
+
+func TestSignRawAndEncoded(t *testing.T) {
+	keyPair, err := GenerateKeyPair(3, rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKeyPair failed: %v", err)
+	}
+
+	raw := []Message{Message("alice"), Message("1990-01-01"), Message("US")}
+
+	sig, err := SignRaw(keyPair.PrivateKey, keyPair.PublicKey, raw, nil)
+	if err != nil {
+		t.Fatalf("SignRaw failed: %v", err)
+	}
+
+	// Both entry points accept the signature
+	if err := VerifyRaw(keyPair.PublicKey, sig, raw, nil); err != nil {
+		t.Fatalf("VerifyRaw failed: %v", err)
+	}
+	encoded := EncodeMessages(DefaultMessageEncoder, raw)
+	if err := VerifyEncoded(keyPair.PublicKey, sig, encoded, nil); err != nil {
+		t.Fatalf("VerifyEncoded failed: %v", err)
+	}
+
+	// The encoding matches the legacy helpers
+	for i, msg := range raw {
+		if encoded[i].Int().Cmp(MessageToFieldElement(msg)) != 0 {
+			t.Fatalf("message %d encoded differently from MessageToFieldElement", i)
+		}
+	}
+
+	// Hashing an already encoded message gives a different message
+	doubleHashed := make([]Message, len(encoded))
+	for i, fe := range encoded {
+		doubleHashed[i] = Message(fe.Int().Bytes())
+	}
+	if err := VerifyRaw(keyPair.PublicKey, sig, doubleHashed, nil); err == nil {
+		t.Fatal("VerifyRaw accepted double hashed messages")
+	}
+
+	// The signature works with the lower level proof API
+	proof, disclosed, err := CreateProof(keyPair.PublicKey, sig, FieldElementInts(encoded), []int{1}, nil)
+	if err != nil {
+		t.Fatalf("CreateProof failed: %v", err)
+	}
+	if err := VerifyProof(keyPair.PublicKey, proof, disclosed, nil); err != nil {
+		t.Fatalf("VerifyProof failed: %v", err)
+	}
+}
+
+func TestNewFieldElement(t *testing.T) {
+	fe, err := NewFieldElement(big.NewInt(42))
+	if err != nil {
+		t.Fatalf("NewFieldElement failed: %v", err)
+	}
+
+	// Int returns a copy
+	fe.Int().SetInt64(7)
+	if fe.Int().Int64() != 42 {
+		t.Fatal("FieldElement was modified through Int")
+	}
+
+	if (FieldElement{}).Int().Sign() != 0 {
+		t.Fatal("zero FieldElement is not 0")
+	}
+
+	invalid := []*big.Int{nil, big.NewInt(-1), new(big.Int).Set(Order)}
+	for _, v := range invalid {
+		if _, err := NewFieldElement(v); !errors.Is(err, ErrInvalidFieldElement) {
+			t.Fatalf("NewFieldElement(%v) = %v, want ErrInvalidFieldElement", v, err)
+		}
+	}
+}
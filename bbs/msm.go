@@ -0,0 +1,108 @@
+package bbs
+
+import (
+	"sync"
+
+	bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381"
+)
+
+// msmWindow is the window width in bits used by verifyScratch.sum. It divides
+// 64, so a window never straddles two words of a scalar.
+const msmWindow = 4
+
+// msmTableSize is the number of precomputed multiples 1P..15P per point
+const msmTableSize = 1<<msmWindow - 1
+
+// msmWindows covers every bit of a scalar below Order
+const msmWindows = (256 + msmWindow - 1) / msmWindow
+
+// verifyScratch holds the multi-scalar multiplication inputs and tables and
+// the pairing inputs of one verification. Scratch space is pooled so
+// steady-state verification does not allocate.
+type verifyScratch struct {
+	points  []bls12381.G1Affine
+	scalars [][4]uint64
+	tables  []bls12381.G1Jac
+
+	pairingG1 [2]bls12381.G1Affine
+	pairingG2 [2]bls12381.G2Affine
+}
+
+var verifyScratchPool = sync.Pool{
+	New: func() interface{} {
+		return new(verifyScratch)
+	},
+}
+
+// getVerifyScratch returns an empty scratch buffer
+func getVerifyScratch() *verifyScratch {
+	ms := verifyScratchPool.Get().(*verifyScratch)
+	ms.reset()
+	return ms
+}
+
+// putVerifyScratch returns a scratch buffer to the pool
+func putVerifyScratch(ms *verifyScratch) {
+	verifyScratchPool.Put(ms)
+}
+
+// add queues the term scalar·point
+func (ms *verifyScratch) add(point *bls12381.G1Affine, scalar *Scalar) {
+	ms.points = append(ms.points, *point)
+	ms.scalars = append(ms.scalars, scalar.e.Bits())
+}
+
+// reset drops the queued terms so the scratch can compute another sum
+func (ms *verifyScratch) reset() {
+	ms.points = ms.points[:0]
+	ms.scalars = ms.scalars[:0]
+}
+
+// sum computes the sum of the queued terms into result using an interleaved
+// (Straus) windowed multi-scalar multiplication. The doublings are shared by
+// all terms and scalars are read from their canonical 64-bit words.
+//
+// This runs in variable time and must only be used with public scalars, as
+// in verification; signing keeps using the constant-time paths.
+func (ms *verifyScratch) sum(result *bls12381.G1Jac) {
+	n := len(ms.points)
+	if cap(ms.tables) < n*msmTableSize {
+		ms.tables = make([]bls12381.G1Jac, n*msmTableSize)
+	}
+	tables := ms.tables[:n*msmTableSize]
+
+	// Precompute 1P..15P for every point
+	for i := range ms.points {
+		table := tables[i*msmTableSize : (i+1)*msmTableSize]
+		table[0].FromAffine(&ms.points[i])
+		for k := 1; k < msmTableSize; k++ {
+			table[k].Set(&table[k-1])
+			table[k].AddMixed(&ms.points[i])
+		}
+	}
+
+	result.X.SetOne()
+	result.Y.SetOne()
+	result.Z.SetZero()
+
+	for w := msmWindows - 1; w >= 0; w-- {
+		if w != msmWindows-1 {
+			for d := 0; d < msmWindow; d++ {
+				result.DoubleAssign()
+			}
+		}
+
+		bit := w * msmWindow
+		for i := range ms.scalars {
+			digit := scalarWindow(&ms.scalars[i], bit)
+			if digit != 0 {
+				result.AddAssign(&tables[i*msmTableSize+int(digit)-1])
+			}
+		}
+	}
+}
+
+// scalarWindow returns the msmWindow bits of s starting at bit
+func scalarWindow(s *[4]uint64, bit int) uint {
+	return uint(s[bit/64]>>(uint(bit)%64)) & (1<<msmWindow - 1)
+}
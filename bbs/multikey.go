@@ -0,0 +1,70 @@
+package bbs
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// A proof does not say which key signed the credential behind it, and trying
+// each key with VerifyProof costs a pairing per key. The challenge tells the
+// keys apart for less: the domain hashes the key, so the recomputed challenge
+// matches for the signer's key only. VerifyProofMultiKey drops keys of the
+// wrong size, recomputes the challenge under each remaining key and runs the
+// pairing only for a key whose challenge matches, which for a valid proof is
+// one pairing however many keys are accepted.
+
+// ErrNoMatchingKey is returned when a proof verifies under none of the keys
+var ErrNoMatchingKey = errors.New("proof verifies under none of the keys")
+
+// VerifyProofMultiKey verifies a proof against any of keys and returns the
+// index of the key it verifies under
+func VerifyProofMultiKey(
+	keys []*PublicKey,
+	proof *ProofOfKnowledge,
+	disclosedMessages map[int]*big.Int,
+	header []byte,
+) (int, error) {
+	return VerifyProofMultiKeyWithPresentationHeader(keys, proof, disclosedMessages, header, nil)
+}
+
+// VerifyProofMultiKeyWithPresentationHeader is VerifyProofMultiKey for a
+// proof created by CreateProofWithPresentationHeader
+func VerifyProofMultiKeyWithPresentationHeader(
+	keys []*PublicKey,
+	proof *ProofOfKnowledge,
+	disclosedMessages map[int]*big.Int,
+	header []byte,
+	presentationHeader []byte,
+) (int, error) {
+	if proof == nil {
+		return -1, ErrInvalidProof
+	}
+
+	// Only keys for as many messages as the proof covers can match
+	messageCount := len(disclosedMessages) + len(proof.MHat)
+	var lastErr error
+	for i, key := range keys {
+		if key == nil || key.messageCount != messageCount {
+			continue
+		}
+
+		domain := CalculateDomain(key, header)
+		if err := checkProofChallenge(key, proof, disclosedMessages, domain, presentationHeader); err != nil {
+			lastErr = err
+			continue
+		}
+		if err := checkProofPairing(key, proof); err != nil {
+			lastErr = err
+			continue
+		}
+		return i, nil
+	}
+
+	// A malformed proof fails the same way under every key, so its error says
+	// more than ErrNoMatchingKey alone
+	if lastErr != nil && !errors.Is(lastErr, ErrInvalidSignature) {
+		return -1, fmt.Errorf("%w: %w", ErrNoMatchingKey, lastErr)
+	}
+	return -1, ErrNoMatchingKey
+}
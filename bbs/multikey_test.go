@@ -0,0 +1,61 @@
+package bbs
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"testing"
+)
+
+func TestVerifyProofMultiKey(t *testing.T) {
+	keys := make([]*PublicKey, 4)
+	var signer *KeyPair
+	for i, count := range []int{5, 3, 5, 5} {
+		keyPair, err := GenerateKeyPair(count, rand.Reader)
+		if err != nil {
+			t.Fatalf("Failed to generate key pair: %v", err)
+		}
+		keys[i] = keyPair.PublicKey
+		if i == 2 {
+			signer = keyPair
+		}
+	}
+
+	messages := []*big.Int{big.NewInt(1), big.NewInt(2), big.NewInt(3), big.NewInt(4), big.NewInt(5)}
+	header := []byte("federation")
+	signature, err := Sign(signer.PrivateKey, signer.PublicKey, messages, header)
+	if err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+	proof, disclosed, err := CreateProof(signer.PublicKey, signature, messages, []int{1, 4}, header)
+	if err != nil {
+		t.Fatalf("CreateProof failed: %v", err)
+	}
+
+	// The signer's key is found among the others
+	idx, err := VerifyProofMultiKey(keys, proof, disclosed, header)
+	if err != nil {
+		t.Fatalf("VerifyProofMultiKey failed: %v", err)
+	}
+	if idx != 2 {
+		t.Fatalf("Expected key 2, got %d", idx)
+	}
+
+	// Without the signer's key no key matches
+	others := []*PublicKey{keys[0], keys[1], nil, keys[3]}
+	if idx, err := VerifyProofMultiKey(others, proof, disclosed, header); !errors.Is(err, ErrNoMatchingKey) || idx != -1 {
+		t.Fatalf("Expected ErrNoMatchingKey, got %d, %v", idx, err)
+	}
+
+	// A wrong header or forged disclosure matches no key either
+	if _, err := VerifyProofMultiKey(keys, proof, disclosed, []byte("other")); !errors.Is(err, ErrNoMatchingKey) {
+		t.Fatalf("Expected ErrNoMatchingKey for wrong header, got %v", err)
+	}
+	forged := map[int]*big.Int{1: big.NewInt(99), 4: disclosed[4]}
+	if _, err := VerifyProofMultiKey(keys, proof, forged, header); !errors.Is(err, ErrNoMatchingKey) {
+		t.Fatalf("Expected ErrNoMatchingKey for forged disclosure, got %v", err)
+	}
+	if _, err := VerifyProofMultiKey(keys, nil, disclosed, header); !errors.Is(err, ErrInvalidProof) {
+		t.Fatalf("Expected ErrInvalidProof for nil proof, got %v", err)
+	}
+}
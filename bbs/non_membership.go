@@ -0,0 +1,370 @@
+package bbs
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/anupsv/bbsplus-signatures/internal/secret"
+	"github.com/anupsv/bbsplus-signatures/pkg/crypto"
+	bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381"
+)
+
+// A non-membership proof shows that a hidden message y is not on a
+// blocklist by proving knowledge of a witness (C, d) for y with d != 0,
+// without revealing y, C or d. It runs as an external protocol on a
+// ProverTranscript, blinding y with the BBS+ blinding of the message. With
+// bases Z and K hashed to G1, the prover publishes
+//
+//	E_C = C + r1*Z    T = r1*K    E_d = d*g1 + r2*K
+//
+// and proves knowledge of y, d, r1, delta = r1*y, r2, u = 1/d and w = -u*r2
+// such that
+//
+//	e(V, g2) / e(E_C, alpha*g2) = e(y*E_C + d*g1 - delta*Z, g2) * e(-r1*Z, alpha*g2)
+//	T = r1*K    0 = y*T - delta*K    E_d = d*g1 + r2*K    g1 = u*E_d + w*K
+//
+// The third equation forces delta = r1*y, so the first is the witness
+// equation for C = E_C - r1*Z, and the last cannot be satisfied for d = 0
+// without the discrete logarithm of g1 to base K.
+
+// Domain separation for non-membership proofs
+const (
+	nonMembershipDST   = "BBS_BLS12381_NON_MEMBERSHIP_"
+	nonMembershipLabel = "blocklist-non-membership"
+)
+
+// NonMembershipProofSize is the size of a serialized NonMembershipProof
+const NonMembershipProofSize = 3*G1Size + 6*ScalarSize
+
+// nonMembershipBases returns Z and K
+var nonMembershipBases = sync.OnceValue(func() [2]bls12381.G1Affine {
+	var bases [2]bls12381.G1Affine
+	for i, name := range []string{"Z", "K"} {
+		p, err := crypto.HashToG1([]byte(name), []byte(nonMembershipDST))
+		if err != nil {
+			panic(fmt.Sprintf("bbs: failed to hash non-membership base %s: %v", name, err))
+		}
+		bases[i] = p
+	}
+	return bases
+})
+
+// NonMembershipProof is the external part of a non-membership proof; the
+// response for the hidden message is that of the BBS+ proof
+type NonMembershipProof struct {
+	EC, T, Ed                    bls12381.G1Affine
+	SD, SR1, SDelta, SR2, SU, SW *big.Int
+}
+
+// CreateNonMembershipProof creates a proof disclosing the messages at
+// disclosedIndices that also proves the hidden message at index is not on
+// blocklist. It fails with ErrBlockedValue if it is. presentationHeader,
+// such as a verifier nonce, is covered by the challenge as in
+// CreateProofWithPresentationHeader.
+func CreateNonMembershipProof(
+	publicKey *PublicKey,
+	signature *Signature,
+	messages []*big.Int,
+	disclosedIndices []int,
+	header []byte,
+	presentationHeader []byte,
+	index int,
+	blocklist *Blocklist,
+) (*ProofOfKnowledge, map[int]*big.Int, *NonMembershipProof, error) {
+	if index < 0 || index >= len(messages) {
+		return nil, nil, nil, fmt.Errorf("invalid non-membership index: %d", index)
+	}
+	for _, idx := range disclosedIndices {
+		if idx == index {
+			return nil, nil, nil, fmt.Errorf("message %d is disclosed; check it against the blocklist directly", idx)
+		}
+	}
+
+	y := ScalarFromBigInt(messages[index])
+	C, d, err := blocklist.witness(&y)
+	y.SetZero()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	transcript, err := NewProverTranscript(publicKey, signature, messages, disclosedIndices, header, presentationHeader)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	yBlind, err := transcript.MessageBlinding(index)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	defer secret.WipeInt(yBlind)
+
+	// Secrets and their blindings: d, r1, delta, r2, u, w
+	var secrets, blinds [6]Scalar
+	defer func() {
+		for i := range secrets {
+			secrets[i].SetZero()
+			blinds[i].SetZero()
+		}
+		d.SetZero()
+	}()
+	const (
+		iD = iota
+		iR1
+		iDelta
+		iR2
+		iU
+		iW
+	)
+	for i := range blinds {
+		if blinds[i], err = newRandomNonZeroScalar(rand.Reader); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+	for _, i := range []int{iR1, iR2} {
+		if secrets[i], err = newRandomNonZeroScalar(rand.Reader); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+	yScalar := ScalarFromBigInt(messages[index])
+	secrets[iD].Set(&d)
+	secrets[iDelta].Mul(&secrets[iR1], &yScalar)
+	yScalar.SetZero()
+	secrets[iU].Inverse(&d)
+	secrets[iW].Mul(&secrets[iU], &secrets[iR2])
+	secrets[iW].Neg(&secrets[iW])
+
+	bases := nonMembershipBases()
+	Z, K := bases[0], bases[1]
+	_, _, g1, _ := bls12381.Generators()
+	// Secrets and blindings enter gnark as big.Ints, which are wiped on return
+	var carried []*big.Int
+	defer func() { secret.WipeInts(carried...) }()
+	carry := func(x *big.Int) *big.Int {
+		carried = append(carried, x)
+		return x
+	}
+	s := func(i int) *big.Int { return carry(secrets[i].BigInt()) }
+	b := func(i int) *big.Int { return carry(blinds[i].BigInt()) }
+
+	EC, err := linearCombination([]bls12381.G1Affine{C, Z}, []*big.Int{big.NewInt(1), s(iR1)})
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	var T bls12381.G1Affine
+	T.ScalarMultiplication(&K, s(iR1))
+	Ed, err := linearCombination([]bls12381.G1Affine{g1, K}, []*big.Int{s(iD), s(iR2)})
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	commitments, err := nonMembershipCommitments(blocklist, EC, T, Ed, [7]*big.Int{
+		yBlind, b(iD), b(iR1), b(iDelta), b(iR2), b(iU), b(iW),
+	}, nil)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if err := transcript.Append(nonMembershipLabel, nonMembershipEntry(index, blocklist, EC, T, Ed, commitments)); err != nil {
+		return nil, nil, nil, err
+	}
+
+	c, err := transcript.Challenge()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	cScalar := ScalarFromBigInt(c)
+	var responses [6]*big.Int
+	for i := range responses {
+		var r Scalar
+		r.Mul(&cScalar, &secrets[i])
+		r.Add(&r, &blinds[i])
+		responses[i] = r.BigInt()
+	}
+
+	proof, disclosed, err := transcript.Proof()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return proof, disclosed, &NonMembershipProof{
+		EC: EC, T: T, Ed: Ed,
+		SD: responses[iD], SR1: responses[iR1], SDelta: responses[iDelta],
+		SR2: responses[iR2], SU: responses[iU], SW: responses[iW],
+	}, nil
+}
+
+// VerifyNonMembershipProof verifies a proof created by
+// CreateNonMembershipProof against the current version of blocklist
+func VerifyNonMembershipProof(
+	publicKey *PublicKey,
+	proof *ProofOfKnowledge,
+	disclosedMessages map[int]*big.Int,
+	header []byte,
+	presentationHeader []byte,
+	index int,
+	blocklist *Blocklist,
+	nmProof *NonMembershipProof,
+) error {
+	if err := nmProof.validate(); err != nil {
+		return err
+	}
+
+	transcript, err := NewVerifierTranscript(publicKey, proof, disclosedMessages, header, presentationHeader)
+	if err != nil {
+		return err
+	}
+	yHat, err := transcript.MessageResponse(index)
+	if err != nil {
+		return err
+	}
+
+	c := transcript.Challenge()
+	commitments, err := nonMembershipCommitments(blocklist, nmProof.EC, nmProof.T, nmProof.Ed, [7]*big.Int{
+		yHat, nmProof.SD, nmProof.SR1, nmProof.SDelta, nmProof.SR2, nmProof.SU, nmProof.SW,
+	}, c)
+	if err != nil {
+		return err
+	}
+	if err := transcript.Append(nonMembershipLabel, nonMembershipEntry(index, blocklist, nmProof.EC, nmProof.T, nmProof.Ed, commitments)); err != nil {
+		return err
+	}
+	return transcript.Verify()
+}
+
+// nonMembershipCommitments computes the Schnorr commitments of the five
+// equations from the scalars x = (y, d, r1, delta, r2, u, w). The prover
+// passes the blindings and a nil challenge; the verifier passes the
+// responses and the challenge c, which subtracts c times each left side.
+func nonMembershipCommitments(
+	blocklist *Blocklist,
+	EC, T, Ed bls12381.G1Affine,
+	x [7]*big.Int,
+	c *big.Int,
+) (nonMembershipCommitment, error) {
+	var out nonMembershipCommitment
+	bases := nonMembershipBases()
+	Z, K := bases[0], bases[1]
+	_, _, g1, g2 := bls12381.Generators()
+	V := blocklist.Accumulator()
+	alpha := blocklist.params.alpha
+
+	y, d, r1, delta, r2, u, w := x[0], x[1], x[2], x[3], x[4], x[5], x[6]
+	if c == nil {
+		c = new(big.Int)
+	}
+
+	// A1 = e(y*E_C + d*g1 - delta*Z - c*V, g2) * e(c*E_C - r1*Z, alpha*g2)
+	left, err := linearCombination([]bls12381.G1Affine{EC, g1, Z, V}, []*big.Int{y, d, negScalar(delta), negScalar(c)})
+	if err != nil {
+		return out, err
+	}
+	right, err := linearCombination([]bls12381.G1Affine{EC, Z}, []*big.Int{c, negScalar(r1)})
+	if err != nil {
+		return out, err
+	}
+	if out.A1, err = bls12381.Pair([]bls12381.G1Affine{left, right}, []bls12381.G2Affine{g2, alpha}); err != nil {
+		return out, fmt.Errorf("failed to compute pairing: %w", err)
+	}
+
+	// A2 = r1*K - c*T, A3 = y*T - delta*K, A4 = d*g1 + r2*K - c*E_d,
+	// A5 = u*E_d + w*K - c*g1
+	terms := []struct {
+		points  []bls12381.G1Affine
+		scalars []*big.Int
+		dst     *bls12381.G1Affine
+	}{
+		{[]bls12381.G1Affine{K, T}, []*big.Int{r1, negScalar(c)}, &out.A2},
+		{[]bls12381.G1Affine{T, K}, []*big.Int{y, negScalar(delta)}, &out.A3},
+		{[]bls12381.G1Affine{g1, K, Ed}, []*big.Int{d, r2, negScalar(c)}, &out.A4},
+		{[]bls12381.G1Affine{Ed, K, g1}, []*big.Int{u, w, negScalar(c)}, &out.A5},
+	}
+	for _, t := range terms {
+		if *t.dst, err = linearCombination(t.points, t.scalars); err != nil {
+			return out, err
+		}
+	}
+	return out, nil
+}
+
+// nonMembershipCommitment holds the commitments of the five equations
+type nonMembershipCommitment struct {
+	A1             bls12381.GT
+	A2, A3, A4, A5 bls12381.G1Affine
+}
+
+// nonMembershipEntry encodes the blocklist, the public values and the
+// commitments for the transcript
+func nonMembershipEntry(index int, blocklist *Blocklist, EC, T, Ed bls12381.G1Affine, cm nonMembershipCommitment) []byte {
+	V := blocklist.Accumulator()
+	a1 := cm.A1.Bytes()
+	buff := make([]byte, 0, 12+G2Size+8*G1Size+len(a1))
+	buff = appendUint32(buff, uint32(index))
+	buff = appendUint32(buff, uint32(blocklist.version>>32))
+	buff = appendUint32(buff, uint32(blocklist.version))
+	buff = appendG1(buff, &V)
+	buff = appendG2(buff, &blocklist.params.alpha)
+	for _, p := range []*bls12381.G1Affine{&EC, &T, &Ed, &cm.A2, &cm.A3, &cm.A4, &cm.A5} {
+		buff = appendG1(buff, p)
+	}
+	return append(buff, a1[:]...)
+}
+
+// linearCombination returns sum scalars[i] * points[i]
+func linearCombination(points []bls12381.G1Affine, scalars []*big.Int) (bls12381.G1Affine, error) {
+	jac, err := MultiScalarMulG1(points, scalars)
+	if err != nil {
+		return bls12381.G1Affine{}, fmt.Errorf("failed multi-scalar multiplication: %w", err)
+	}
+	return g1JacToAffine(jac), nil
+}
+
+// negScalar returns -x mod Order
+func negScalar(x *big.Int) *big.Int {
+	neg := new(big.Int).Neg(x)
+	return neg.Mod(neg, Order)
+}
+
+// validate checks that the points are in G1 and the responses are reduced
+func (p *NonMembershipProof) validate() error {
+	if p == nil {
+		return fmt.Errorf("%w: missing non-membership proof", ErrInvalidProofData)
+	}
+	for _, pt := range []*bls12381.G1Affine{&p.EC, &p.T, &p.Ed} {
+		if !pt.IsInSubGroup() {
+			return fmt.Errorf("%w: non-membership point not in G1", ErrInvalidProofData)
+		}
+	}
+	for _, s := range []*big.Int{p.SD, p.SR1, p.SDelta, p.SR2, p.SU, p.SW} {
+		if s == nil || s.Sign() < 0 || s.Cmp(Order) >= 0 {
+			return fmt.Errorf("%w: non-membership response out of range", ErrInvalidProofData)
+		}
+	}
+	return nil
+}
+
+// SerializeNonMembershipProof serializes a proof:
+// E_C || T || E_d (48 bytes each) || six responses (32 bytes each)
+func SerializeNonMembershipProof(p *NonMembershipProof) []byte {
+	result := make([]byte, 0, NonMembershipProofSize)
+	result = appendG1(result, &p.EC)
+	result = appendG1(result, &p.T)
+	result = appendG1(result, &p.Ed)
+	for _, s := range []*big.Int{p.SD, p.SR1, p.SDelta, p.SR2, p.SU, p.SW} {
+		result = appendScalar(result, s)
+	}
+	return result
+}
+
+// DeserializeNonMembershipProof deserializes a proof
+func DeserializeNonMembershipProof(data []byte) (*NonMembershipProof, error) {
+	if len(data) != NonMembershipProofSize || !isCompactEncoding(data) {
+		return nil, fmt.Errorf("%w: non-membership proof must be %d bytes", ErrInvalidProofData, NonMembershipProofSize)
+	}
+	r := &wireReader{data: data}
+	p := &NonMembershipProof{EC: r.g1(), T: r.g1(), Ed: r.g1()}
+	p.SD, p.SR1, p.SDelta = r.scalar(), r.scalar(), r.scalar()
+	p.SR2, p.SU, p.SW = r.scalar(), r.scalar(), r.scalar()
+	if r.err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidProofData, r.err)
+	}
+	return p, nil
+}
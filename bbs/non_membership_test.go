@@ -0,0 +1,131 @@
+package bbs
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"testing"
+)
+
+func TestNonMembership(t *testing.T) {
+	keyPair, err := GenerateKeyPair(3, rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key pair: %v", err)
+	}
+	params, err := NewBlocklistParams(8, rand.Reader)
+	if err != nil {
+		t.Fatalf("NewBlocklistParams failed: %v", err)
+	}
+	params, err = DeserializeBlocklistParams(SerializeBlocklistParams(params))
+	if err != nil {
+		t.Fatalf("DeserializeBlocklistParams failed: %v", err)
+	}
+	blocklist, err := NewBlocklist(params, []*big.Int{big.NewInt(1001), big.NewInt(1002)})
+	if err != nil {
+		t.Fatalf("NewBlocklist failed: %v", err)
+	}
+
+	passport := big.NewInt(4242)
+	messages := []*big.Int{big.NewInt(21), passport, big.NewInt(99)}
+	signature, err := Sign(keyPair.PrivateKey, keyPair.PublicKey, messages, nil)
+	if err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+
+	nonce := []byte("nonce")
+	proof, disclosed, nmProof, err := CreateNonMembershipProof(keyPair.PublicKey, signature, messages, []int{0}, nil, nonce, 1, blocklist)
+	if err != nil {
+		t.Fatalf("CreateNonMembershipProof failed: %v", err)
+	}
+	nmProof, err = DeserializeNonMembershipProof(SerializeNonMembershipProof(nmProof))
+	if err != nil {
+		t.Fatalf("DeserializeNonMembershipProof failed: %v", err)
+	}
+	if err := VerifyNonMembershipProof(keyPair.PublicKey, proof, disclosed, nil, nonce, 1, blocklist, nmProof); err != nil {
+		t.Fatalf("VerifyNonMembershipProof failed: %v", err)
+	}
+
+	// The proof is bound to the list version, the message and the nonce
+	updated, err := blocklist.Add(big.NewInt(1003))
+	if err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	if err := VerifyNonMembershipProof(keyPair.PublicKey, proof, disclosed, nil, nonce, 1, updated, nmProof); err == nil {
+		t.Fatalf("Proof verified against another list version")
+	}
+	if err := VerifyNonMembershipProof(keyPair.PublicKey, proof, disclosed, nil, nonce, 2, blocklist, nmProof); err == nil {
+		t.Fatalf("Proof verified for another message")
+	}
+	if err := VerifyNonMembershipProof(keyPair.PublicKey, proof, disclosed, nil, []byte("other"), 1, blocklist, nmProof); err == nil {
+		t.Fatalf("Proof verified with another nonce")
+	}
+	tampered := *nmProof
+	tampered.SD = new(big.Int).Add(nmProof.SD, big.NewInt(1))
+	if err := VerifyNonMembershipProof(keyPair.PublicKey, proof, disclosed, nil, nonce, 1, blocklist, &tampered); err == nil {
+		t.Fatalf("Tampered proof verified")
+	}
+
+	// Once the value is blocked the holder cannot prove non-membership
+	blocked, err := updated.Add(passport)
+	if err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	if _, _, _, err := CreateNonMembershipProof(keyPair.PublicKey, signature, messages, []int{0}, nil, nonce, 1, blocked); !errors.Is(err, ErrBlockedValue) {
+		t.Fatalf("Expected ErrBlockedValue, got %v", err)
+	}
+
+	// and can again once it is removed
+	unblocked, err := blocked.Remove(passport)
+	if err != nil {
+		t.Fatalf("Remove failed: %v", err)
+	}
+	if unblocked.Version() != 4 || unblocked.Len() != 3 {
+		t.Fatalf("Unexpected version %d with %d values", unblocked.Version(), unblocked.Len())
+	}
+	acc, want := unblocked.Accumulator(), updated.Accumulator()
+	if !acc.Equal(&want) {
+		t.Fatalf("Removing a value did not restore the accumulator")
+	}
+	proof, disclosed, nmProof, err = CreateNonMembershipProof(keyPair.PublicKey, signature, messages, nil, nil, nonce, 1, unblocked)
+	if err != nil {
+		t.Fatalf("CreateNonMembershipProof failed: %v", err)
+	}
+	if err := VerifyNonMembershipProof(keyPair.PublicKey, proof, disclosed, nil, nonce, 1, unblocked, nmProof); err != nil {
+		t.Fatalf("VerifyNonMembershipProof failed: %v", err)
+	}
+}
+
+func TestBlocklistEncoding(t *testing.T) {
+	params, err := NewBlocklistParams(4, rand.Reader)
+	if err != nil {
+		t.Fatalf("NewBlocklistParams failed: %v", err)
+	}
+	blocklist, err := NewBlocklist(params, []*big.Int{big.NewInt(7), big.NewInt(8), big.NewInt(7)})
+	if err != nil {
+		t.Fatalf("NewBlocklist failed: %v", err)
+	}
+	if blocklist.Len() != 2 || !blocklist.Contains(big.NewInt(8)) || blocklist.Contains(big.NewInt(9)) {
+		t.Fatalf("Unexpected values %v", blocklist.Values())
+	}
+
+	decoded, err := DeserializeBlocklist(params, SerializeBlocklist(blocklist))
+	if err != nil {
+		t.Fatalf("DeserializeBlocklist failed: %v", err)
+	}
+	acc, want := decoded.Accumulator(), blocklist.Accumulator()
+	if decoded.Version() != blocklist.Version() || !acc.Equal(&want) {
+		t.Fatalf("Decoded blocklist differs")
+	}
+
+	if _, err := blocklist.Add(big.NewInt(1), big.NewInt(2), big.NewInt(3)); err == nil {
+		t.Fatalf("Blocklist grew beyond the parameters")
+	}
+
+	// Parameters whose powers do not match are rejected
+	data := SerializeBlocklistParams(params)
+	other, _ := NewBlocklistParams(4, rand.Reader)
+	copy(data[4+G2Size+2*G1Size:], SerializeBlocklistParams(other)[4+G2Size+2*G1Size:4+G2Size+3*G1Size])
+	if _, err := DeserializeBlocklistParams(data); !errors.Is(err, ErrInvalidBlocklistParams) {
+		t.Fatalf("Expected ErrInvalidBlocklistParams, got %v", err)
+	}
+}
@@ -0,0 +1,202 @@
+package bbs
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/anupsv/bbsplus-signatures/internal/secret"
+	"github.com/anupsv/bbsplus-signatures/pkg/crypto"
+	bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381"
+)
+
+// A one-time-show credential carries a random serial key k as a message
+// that is never disclosed. Each presentation reveals the serial
+// S = H(epoch) * k, where H hashes the issuer's epoch label to G1, and proves
+// in the same Fiat-Shamir challenge as the BBS+ proof that S is formed from
+// the signed k. The serial is the same for every presentation in an epoch,
+// so verifiers sharing a registry of spent serials reject the second use,
+// while serials of different epochs or credentials cannot be linked without
+// k (DDH in G1). The proof runs as an external protocol on a
+// ProverTranscript: the serial's Schnorr commitment H(epoch) * blinding
+// reuses the blinding of k, so the BBS+ response for k answers both.
+
+// Domain separation for one-time-show serials
+const (
+	serialGeneratorDST = "BBS_BLS12381_ONE_TIME_SHOW_SERIAL_"
+	serialLabel        = "one-time-show-serial"
+)
+
+// ErrSerialSpent is returned when a one-time-show serial was already presented
+var ErrSerialSpent = errors.New("one-time-show serial already spent")
+
+// NewSerialKey returns a random serial key to sign as the serial message of
+// a one-time-show credential
+func NewSerialKey() (*big.Int, error) {
+	k, err := randomNonZeroScalar()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate serial key: %w", err)
+	}
+	return k, nil
+}
+
+// SerialGenerator returns H(epoch), the base of the serials of an epoch
+func SerialGenerator(epoch []byte) (bls12381.G1Affine, error) {
+	if len(epoch) == 0 {
+		return bls12381.G1Affine{}, fmt.Errorf("one-time-show epoch must not be empty")
+	}
+	return crypto.HashToG1(epoch, []byte(serialGeneratorDST))
+}
+
+// Serial returns the serial a holder reveals when presenting the credential
+// with serial message serialKey in epoch
+func Serial(serialKey *big.Int, epoch []byte) (bls12381.G1Affine, error) {
+	h, err := SerialGenerator(epoch)
+	if err != nil {
+		return bls12381.G1Affine{}, err
+	}
+	k := new(big.Int).Mod(serialKey, Order)
+	defer secret.WipeInt(k)
+	if k.Sign() == 0 {
+		return bls12381.G1Affine{}, fmt.Errorf("serial key must not be zero")
+	}
+
+	var s bls12381.G1Affine
+	s.ScalarMultiplication(&h, k)
+	return s, nil
+}
+
+// SerialHash returns the key a registry records a spent serial under
+func SerialHash(epoch []byte, serial bls12381.G1Affine) [32]byte {
+	buff := make([]byte, 0, len(serialGeneratorDST)+4+len(epoch)+G1Size)
+	buff = append(buff, serialGeneratorDST...)
+	buff = appendUint32(buff, uint32(len(epoch)))
+	buff = append(buff, epoch...)
+	buff = appendG1(buff, &serial)
+	return sha256.Sum256(buff)
+}
+
+// CreateOneTimeShowProof creates a proof disclosing the messages at
+// disclosedIndices that also proves serial to be the serial of the hidden
+// message at serialIndex in epoch. presentationHeader, such as a verifier
+// nonce, is covered by the challenge as in CreateProofWithPresentationHeader.
+func CreateOneTimeShowProof(
+	publicKey *PublicKey,
+	signature *Signature,
+	messages []*big.Int,
+	disclosedIndices []int,
+	header []byte,
+	presentationHeader []byte,
+	serialIndex int,
+	epoch []byte,
+) (*ProofOfKnowledge, map[int]*big.Int, bls12381.G1Affine, error) {
+	var serial bls12381.G1Affine
+	if serialIndex < 0 || serialIndex >= len(messages) {
+		return nil, nil, serial, fmt.Errorf("invalid serial index: %d", serialIndex)
+	}
+	for _, idx := range disclosedIndices {
+		if idx == serialIndex {
+			return nil, nil, serial, fmt.Errorf("message %d is the serial key and cannot be disclosed", idx)
+		}
+	}
+
+	h, err := SerialGenerator(epoch)
+	if err != nil {
+		return nil, nil, serial, err
+	}
+	if serial, err = Serial(messages[serialIndex], epoch); err != nil {
+		return nil, nil, serial, err
+	}
+
+	transcript, err := NewProverTranscript(publicKey, signature, messages, disclosedIndices, header, presentationHeader)
+	if err != nil {
+		return nil, nil, serial, err
+	}
+
+	// Commit to T = H(epoch) * blinding with the BBS+ blinding of k
+	blinding, err := transcript.MessageBlinding(serialIndex)
+	if err != nil {
+		return nil, nil, serial, err
+	}
+	var T bls12381.G1Affine
+	T.ScalarMultiplication(&h, blinding)
+	secret.WipeInt(blinding)
+
+	if err := transcript.Append(serialLabel, serialEntry(epoch, serial, T)); err != nil {
+		return nil, nil, serial, err
+	}
+
+	proof, disclosed, err := transcript.Proof()
+	if err != nil {
+		return nil, nil, serial, err
+	}
+	return proof, disclosed, serial, nil
+}
+
+// VerifyOneTimeShowProof verifies a proof created by CreateOneTimeShowProof.
+// It checks the cryptography only; SpendSerial records the serial once the
+// rest of the presentation is accepted.
+func VerifyOneTimeShowProof(
+	publicKey *PublicKey,
+	proof *ProofOfKnowledge,
+	disclosedMessages map[int]*big.Int,
+	header []byte,
+	presentationHeader []byte,
+	serialIndex int,
+	epoch []byte,
+	serial bls12381.G1Affine,
+) error {
+	h, err := SerialGenerator(epoch)
+	if err != nil {
+		return err
+	}
+	if !serial.IsInSubGroup() || serial.IsInfinity() {
+		return fmt.Errorf("invalid one-time-show serial")
+	}
+
+	transcript, err := NewVerifierTranscript(publicKey, proof, disclosedMessages, header, presentationHeader)
+	if err != nil {
+		return err
+	}
+	kHat, err := transcript.MessageResponse(serialIndex)
+	if err != nil {
+		return err
+	}
+
+	// Recompute T = H(epoch) * k^ - S * c
+	negC := new(big.Int).Sub(Order, transcript.Challenge())
+	TJac, err := MultiScalarMulG1([]bls12381.G1Affine{h, serial}, []*big.Int{kHat, negC})
+	if err != nil {
+		return fmt.Errorf("failed multi-scalar multiplication: %w", err)
+	}
+	T := g1JacToAffine(TJac)
+
+	if err := transcript.Append(serialLabel, serialEntry(epoch, serial, T)); err != nil {
+		return err
+	}
+	return transcript.Verify()
+}
+
+// SpendSerial records serial in registry until expiry, which should outlast
+// the epoch, and returns ErrSerialSpent if it was recorded before. Any
+// ReplayGuard serves as a registry; verifiers that share one, such as a
+// replay.RedisGuard, reject a credential shown to any of them before.
+func SpendSerial(registry ReplayGuard, epoch []byte, serial bls12381.G1Affine, expiry time.Time) error {
+	if registry.Seen(SerialHash(epoch, serial), expiry) {
+		return ErrSerialSpent
+	}
+	return nil
+}
+
+// serialEntry encodes the epoch, serial and Schnorr commitment for the
+// transcript
+func serialEntry(epoch []byte, serial, T bls12381.G1Affine) []byte {
+	buff := make([]byte, 0, 4+len(epoch)+2*G1Size)
+	buff = appendUint32(buff, uint32(len(epoch)))
+	buff = append(buff, epoch...)
+	buff = appendG1(buff, &serial)
+	buff = appendG1(buff, &T)
+	return buff
+}
@@ -0,0 +1,73 @@
+package bbs
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func TestOneTimeShow(t *testing.T) {
+	keyPair, err := GenerateKeyPair(3, rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key pair: %v", err)
+	}
+
+	serialKey, err := NewSerialKey()
+	if err != nil {
+		t.Fatalf("NewSerialKey failed: %v", err)
+	}
+	messages := []*big.Int{big.NewInt(21), serialKey, big.NewInt(99)}
+	signature, err := Sign(keyPair.PrivateKey, keyPair.PublicKey, messages, nil)
+	if err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+
+	epoch := []byte("2026-10")
+	nonce := []byte("nonce")
+	show := func() (*ProofOfKnowledge, map[int]*big.Int, [32]byte) {
+		proof, disclosed, serial, err := CreateOneTimeShowProof(keyPair.PublicKey, signature, messages, []int{0}, nil, nonce, 1, epoch)
+		if err != nil {
+			t.Fatalf("CreateOneTimeShowProof failed: %v", err)
+		}
+		if err := VerifyOneTimeShowProof(keyPair.PublicKey, proof, disclosed, nil, nonce, 1, epoch, serial); err != nil {
+			t.Fatalf("VerifyOneTimeShowProof failed: %v", err)
+		}
+
+		// Another epoch's serial, or another nonce, does not verify
+		other, _ := Serial(serialKey, []byte("2026-11"))
+		if err := VerifyOneTimeShowProof(keyPair.PublicKey, proof, disclosed, nil, nonce, 1, epoch, other); err == nil {
+			t.Fatal("VerifyOneTimeShowProof accepted a serial of another epoch")
+		}
+		if err := VerifyOneTimeShowProof(keyPair.PublicKey, proof, disclosed, nil, []byte("other"), 1, epoch, serial); err == nil {
+			t.Fatal("VerifyOneTimeShowProof accepted another nonce")
+		}
+		return proof, disclosed, SerialHash(epoch, serial)
+	}
+
+	// Two presentations differ but reveal the same serial
+	first, _, firstSerial := show()
+	second, _, secondSerial := show()
+	if ProofHash(first) == ProofHash(second) || firstSerial != secondSerial {
+		t.Fatal("presentations should differ and share the serial")
+	}
+
+	registry := NewLRUReplayGuard(16)
+	serial, _ := Serial(serialKey, epoch)
+	expiry := time.Now().Add(time.Hour)
+	if err := SpendSerial(registry, epoch, serial, expiry); err != nil {
+		t.Fatalf("SpendSerial failed: %v", err)
+	}
+	if err := SpendSerial(registry, epoch, serial, expiry); !errors.Is(err, ErrSerialSpent) {
+		t.Fatalf("second SpendSerial returned %v", err)
+	}
+
+	// The serial key cannot be disclosed
+	if _, _, _, err := CreateOneTimeShowProof(keyPair.PublicKey, signature, messages, []int{1}, nil, nil, 1, epoch); err == nil {
+		t.Fatal("CreateOneTimeShowProof disclosed the serial key")
+	}
+	if _, err := SerialGenerator(nil); err == nil {
+		t.Fatal("SerialGenerator accepted an empty epoch")
+	}
+}
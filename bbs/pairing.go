@@ -0,0 +1,104 @@
+package bbs
+
+import (
+	bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381"
+)
+
+// gnark-crypto's final exponentiation raises to the BLS parameter with
+// compressed squarings and decompresses them in a batch through a freshly
+// allocated slice, four allocations per exponentiation and twenty per
+// pairing check. pairingCheck runs the same exponentiation but decompresses
+// the two intermediate values one at a time, one extra field division each,
+// and allocates nothing, so signature verification only allocates in the
+// Miller loop.
+
+// pairingCheck reports whether the product of the pairings e(g1[i], g2[i]) is
+// one, like bls12381.PairingCheck
+func pairingCheck(g1 []bls12381.G1Affine, g2 []bls12381.G2Affine) (bool, error) {
+	f, err := bls12381.MillerLoop(g1, g2)
+	if err != nil {
+		return false, err
+	}
+	return finalExponentiationIsOne(&f), nil
+}
+
+// finalExponentiationIsOne reports whether z^((p¹²-1)/r) is one. It follows
+// bls12381.FinalExponentiation (Hayashida, Hayasaka and Teruya,
+// https://eprint.iacr.org/2020/875.pdf).
+func finalExponentiationIsOne(z *bls12381.GT) bool {
+	var result, one bls12381.GT
+	var t [3]bls12381.GT
+	one.SetOne()
+
+	// Easy part: (p⁶-1)(p²+1)
+	t[0].Conjugate(z)
+	result.Inverse(z)
+	t[0].Mul(&t[0], &result)
+	result.FrobeniusSquare(&t[0]).
+		Mul(&result, &t[0])
+	if result.Equal(&one) {
+		return true
+	}
+
+	// Hard part, up to permutation
+	t[0].CyclotomicSquare(&result)
+	exptHalf(&t[1], &t[0])
+	t[2].InverseUnitary(&result)
+	t[1].Mul(&t[1], &t[2])
+	expt(&t[2], &t[1])
+	t[1].InverseUnitary(&t[1])
+	t[1].Mul(&t[1], &t[2])
+	expt(&t[2], &t[1])
+	t[1].Frobenius(&t[1])
+	t[1].Mul(&t[1], &t[2])
+	result.Mul(&result, &t[0])
+	expt(&t[0], &t[1])
+	expt(&t[2], &t[0])
+	t[0].FrobeniusSquare(&t[1])
+	t[1].InverseUnitary(&t[1])
+	t[1].Mul(&t[1], &t[2])
+	t[1].Mul(&t[1], &t[0])
+	result.Mul(&result, &t[1])
+
+	return result.Equal(&one)
+}
+
+// exptHalf sets z to x^(t/2), where t is the negative BLS parameter and
+// |t|/2 = 2¹⁵ + 2⁴⁷ + 2⁵⁶ + 2⁵⁹ + 2⁶¹ + 2⁶². x must be in the cyclotomic
+// subgroup.
+func exptHalf(z, x *bls12381.GT) {
+	var result, acc, low bls12381.GT
+	acc.Set(x)
+	compressedSquares(&acc, 15)
+	low.DecompressKarabina(&acc)
+	compressedSquares(&acc, 32)
+	result.DecompressKarabina(&acc)
+	acc.Set(&result)
+	result.Mul(&result, &low)
+	for _, n := range [...]int{9, 3, 2, 1} {
+		cyclotomicSquares(&acc, n)
+		result.Mul(&result, &acc)
+	}
+	z.Conjugate(&result) // because t is negative
+}
+
+// expt sets z to x^t. x must be in the cyclotomic subgroup.
+func expt(z, x *bls12381.GT) {
+	exptHalf(z, x)
+	z.CyclotomicSquare(z)
+}
+
+// cyclotomicSquares squares z in place n times
+func cyclotomicSquares(z *bls12381.GT, n int) {
+	for i := 0; i < n; i++ {
+		z.CyclotomicSquare(z)
+	}
+}
+
+// compressedSquares squares z in place n times in the Karabina compressed
+// form. The result must be decompressed before any other operation.
+func compressedSquares(z *bls12381.GT, n int) {
+	for i := 0; i < n; i++ {
+		z.CyclotomicSquareCompressed(z)
+	}
+}
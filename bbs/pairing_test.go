@@ -0,0 +1,55 @@
+package bbs
+
+import (
+	"crypto/rand"
+	"testing"
+
+	bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381"
+)
+
+func TestPairingCheckMatchesGnark(t *testing.T) {
+	keyPair, err := GenerateKeyPair(1, rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key pair: %v", err)
+	}
+	pk := keyPair.PublicKey
+
+	var w, negG2 bls12381.G2Affine
+	w.ScalarMultiplication(&pk.g2, keyPair.PrivateKey.X)
+	negG2.Neg(&pk.g2)
+	var g1x bls12381.G1Affine
+	g1x.ScalarMultiplication(&pk.g1, keyPair.PrivateKey.X)
+
+	// e(P1, W) * e(P1*x, -P2) is one; e(P1, W) * e(P1, -P2) is not
+	for _, g1 := range [][]bls12381.G1Affine{{pk.g1, g1x}, {pk.g1, pk.g1}} {
+		g2 := []bls12381.G2Affine{w, negG2}
+		want, err := bls12381.PairingCheck(g1, g2)
+		if err != nil {
+			t.Fatalf("PairingCheck failed: %v", err)
+		}
+		got, err := pairingCheck(g1, g2)
+		if err != nil {
+			t.Fatalf("pairingCheck failed: %v", err)
+		}
+		if got != want {
+			t.Fatalf("pairingCheck = %v, gnark-crypto says %v", got, want)
+		}
+	}
+
+	// The exponentiation by the BLS parameter matches in the cyclotomic
+	// subgroup, where the final exponentiation uses it
+	f, err := bls12381.Pair([]bls12381.G1Affine{pk.g1}, []bls12381.G2Affine{w})
+	if err != nil {
+		t.Fatalf("Pair failed: %v", err)
+	}
+	var want, got bls12381.GT
+	want.Expt(&f)
+	expt(&got, &f)
+	if !got.Equal(&want) {
+		t.Fatalf("expt differs from gnark-crypto")
+	}
+
+	if allocs := testing.AllocsPerRun(10, func() { finalExponentiationIsOne(&f) }); allocs != 0 && !raceEnabled {
+		t.Fatalf("Final exponentiation made %.0f allocations", allocs)
+	}
+}
@@ -0,0 +1,30 @@
+// Package perf measures the latency of BBS+ operations for capacity planning.
+//
+// Go benchmarks report a mean over as many runs as fit in a second, which is
+// the right number for comparing code but not for sizing hardware. Measure
+// times each run separately and reports throughput together with the tail
+// latency, and Suite runs the whole credential lifecycle for a given message
+// count and disclosure set.
+//
+// Example usage:
+//
+//	results, err := perf.Suite{
+//	    MessageCount: 10,
+//	    Disclosed:    []int{0, 3},
+//	    Iterations:   50,
+//	}.Run()
+//	for _, r := range results {
+//	    fmt.Printf("%s: %.1f ops/sec, p95 %v\n", r.Name, r.OpsPerSec(), r.P95)
+//	}
+//
+// Suite.OpTimeout and Suite.MaxTime bound the wall-clock time of a run, so a
+// CI job finishes within its limit with the operations it managed to measure
+// marked Partial.
+//
+// WriteHTML renders the runs of a Report, usually over several message counts,
+// as a single HTML page with inline SVG charts and the Environment measured in.
+//
+// The credgen bench command runs a Suite for the attributes of a schema file.
+//
+// Stability: experimental. The API may change in a minor release.
+package perf
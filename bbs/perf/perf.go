@@ -0,0 +1,218 @@
+package perf
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/anupsv/bbsplus-signatures/bbs"
+)
+
+// ErrTimeBudget is returned by Suite.Run when MaxTime ran out before every
+// operation was measured. The results measured so far are returned with it.
+var ErrTimeBudget = errors.New("benchmark time budget exhausted")
+
+// Result summarizes the latencies of repeated runs of one operation
+type Result struct {
+	Name       string
+	Iterations int
+	Total      time.Duration
+	Mean       time.Duration
+	P50        time.Duration
+	P95        time.Duration
+	Max        time.Duration
+
+	// Samples holds the latency of every timed run in ascending order
+	Samples []time.Duration
+
+	// Partial is set when the time budget ran out before all the requested
+	// iterations; the statistics then cover only the runs that completed
+	Partial bool
+}
+
+// OpsPerSec is the single-core throughput implied by the mean latency
+func (r Result) OpsPerSec() float64 {
+	if r.Total <= 0 {
+		return 0
+	}
+	return float64(r.Iterations) / r.Total.Seconds()
+}
+
+// Measure runs op iterations times after one untimed warm-up run and
+// summarizes the latencies. It stops at the first error.
+func Measure(name string, iterations int, op func() error) (Result, error) {
+	return MeasureWithin(name, iterations, 0, op)
+}
+
+// MeasureWithin is Measure with a wall-clock budget for the warm-up and the
+// timed runs together; zero means no budget. A run in progress is never
+// interrupted, so at least one timed run completes and the budget may be
+// overrun by up to one run. When the budget stops the runs early the result
+// is marked Partial.
+func MeasureWithin(name string, iterations int, budget time.Duration, op func() error) (Result, error) {
+	if iterations < 1 {
+		return Result{}, fmt.Errorf("iterations must be at least 1, got %d", iterations)
+	}
+
+	began := time.Now()
+
+	// Warm up caches and lazily tuned code paths
+	if err := op(); err != nil {
+		return Result{}, fmt.Errorf("%s: %w", name, err)
+	}
+
+	samples := make([]time.Duration, 0, iterations)
+	for len(samples) < iterations {
+		if len(samples) > 0 && budget > 0 && time.Since(began) >= budget {
+			break
+		}
+		start := time.Now()
+		if err := op(); err != nil {
+			return Result{}, fmt.Errorf("%s: %w", name, err)
+		}
+		samples = append(samples, time.Since(start))
+	}
+
+	r := summarize(name, samples)
+	r.Partial = len(samples) < iterations
+	return r, nil
+}
+
+// summarize computes the statistics of a set of samples
+func summarize(name string, samples []time.Duration) Result {
+	sorted := append([]time.Duration(nil), samples...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	var total time.Duration
+	for _, d := range sorted {
+		total += d
+	}
+
+	return Result{
+		Name:       name,
+		Iterations: len(sorted),
+		Total:      total,
+		Mean:       total / time.Duration(len(sorted)),
+		P50:        percentile(sorted, 50),
+		P95:        percentile(sorted, 95),
+		Max:        sorted[len(sorted)-1],
+		Samples:    sorted,
+	}
+}
+
+// percentile returns the nearest-rank percentile p of sorted samples
+func percentile(sorted []time.Duration, p int) time.Duration {
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// Suite measures key generation, issuance, signature verification, proof
+// creation and proof verification for one credential shape
+type Suite struct {
+	// MessageCount is the number of messages per credential
+	MessageCount int
+
+	// Disclosed lists the message indices disclosed in proofs
+	Disclosed []int
+
+	// Header is the signature header; it does not affect timing much
+	Header []byte
+
+	// Iterations is the number of timed runs per operation
+	Iterations int
+
+	// OpTimeout bounds the time spent on each operation; zero means no bound.
+	// An operation that runs out is reported with fewer iterations and
+	// marked Partial.
+	OpTimeout time.Duration
+
+	// MaxTime bounds the whole run, setup included; zero means no bound.
+	// Operations not started when it runs out are skipped and Run returns
+	// ErrTimeBudget with the results measured so far.
+	MaxTime time.Duration
+}
+
+// Run measures each operation of the suite in turn
+func (s Suite) Run() ([]Result, error) {
+	began := time.Now()
+
+	if s.MessageCount < 1 {
+		return nil, fmt.Errorf("message count must be at least 1, got %d", s.MessageCount)
+	}
+
+	keyPair, err := bbs.GenerateKeyPair(s.MessageCount, rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate key pair: %w", err)
+	}
+
+	messages := make([]*big.Int, s.MessageCount)
+	for i := range messages {
+		messages[i], err = bbs.RandomScalar(rand.Reader)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate message: %w", err)
+		}
+	}
+
+	signature, err := bbs.Sign(keyPair.PrivateKey, keyPair.PublicKey, messages, s.Header)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign messages: %w", err)
+	}
+
+	proof, disclosed, err := bbs.CreateProof(keyPair.PublicKey, signature, messages, s.Disclosed, s.Header)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create proof: %w", err)
+	}
+
+	ops := []struct {
+		name string
+		op   func() error
+	}{
+		{"keygen", func() error {
+			_, err := bbs.GenerateKeyPair(s.MessageCount, rand.Reader)
+			return err
+		}},
+		{"issue", func() error {
+			_, err := bbs.Sign(keyPair.PrivateKey, keyPair.PublicKey, messages, s.Header)
+			return err
+		}},
+		{"verify", func() error {
+			return bbs.Verify(keyPair.PublicKey, signature, messages, s.Header)
+		}},
+		{"prove", func() error {
+			_, _, err := bbs.CreateProof(keyPair.PublicKey, signature, messages, s.Disclosed, s.Header)
+			return err
+		}},
+		{"verify-proof", func() error {
+			return bbs.VerifyProof(keyPair.PublicKey, proof, disclosed, s.Header)
+		}},
+	}
+
+	results := make([]Result, 0, len(ops))
+	for i, o := range ops {
+		budget := s.OpTimeout
+		if s.MaxTime > 0 {
+			remaining := s.MaxTime - time.Since(began)
+			if remaining <= 0 {
+				return results, fmt.Errorf("%w after %v: skipped %d of %d operations",
+					ErrTimeBudget, s.MaxTime, len(ops)-i, len(ops))
+			}
+			if budget == 0 || remaining < budget {
+				budget = remaining
+			}
+		}
+
+		r, err := MeasureWithin(o.name, s.Iterations, budget, o.op)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, r)
+	}
+
+	return results, nil
+}
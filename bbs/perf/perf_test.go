@@ -0,0 +1,156 @@
+package perf
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSummarize(t *testing.T) {
+	samples := make([]time.Duration, 100)
+	for i := range samples {
+		// Out of order on purpose
+		samples[i] = time.Duration(100-i) * time.Millisecond
+	}
+
+	r := summarize("op", samples)
+	if r.P50 != 50*time.Millisecond || r.P95 != 95*time.Millisecond || r.Max != 100*time.Millisecond {
+		t.Fatalf("unexpected percentiles: p50 %v, p95 %v, max %v", r.P50, r.P95, r.Max)
+	}
+	if r.Total != 5050*time.Millisecond || r.Mean != 50500*time.Microsecond {
+		t.Fatalf("unexpected total %v or mean %v", r.Total, r.Mean)
+	}
+	if ops := r.OpsPerSec(); ops < 19.8 || ops > 19.81 {
+		t.Fatalf("unexpected throughput %v", ops)
+	}
+
+	// A single sample is every percentile
+	r = summarize("op", []time.Duration{time.Second})
+	if r.P50 != time.Second || r.P95 != time.Second {
+		t.Fatalf("unexpected percentiles for one sample: %v, %v", r.P50, r.P95)
+	}
+}
+
+func TestMeasureStopsOnError(t *testing.T) {
+	calls := 0
+	failure := errors.New("boom")
+	_, err := Measure("op", 5, func() error {
+		calls++
+		if calls == 3 {
+			return failure
+		}
+		return nil
+	})
+	if !errors.Is(err, failure) || calls != 3 {
+		t.Fatalf("Measure returned %v after %d calls", err, calls)
+	}
+
+	if _, err := Measure("op", 0, func() error { return nil }); err == nil {
+		t.Fatal("Measure accepted zero iterations")
+	}
+}
+
+func TestSuiteRun(t *testing.T) {
+	results, err := Suite{MessageCount: 4, Disclosed: []int{1, 3}, Iterations: 2}.Run()
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	want := []string{"keygen", "issue", "verify", "prove", "verify-proof"}
+	if len(results) != len(want) {
+		t.Fatalf("got %d results, want %d", len(results), len(want))
+	}
+	for i, r := range results {
+		if r.Name != want[i] || r.Iterations != 2 || r.P95 <= 0 {
+			t.Fatalf("unexpected result %d: %+v", i, r)
+		}
+	}
+}
+
+func TestMeasureWithinBudget(t *testing.T) {
+	calls := 0
+	r, err := MeasureWithin("op", 1000, 20*time.Millisecond, func() error {
+		calls++
+		time.Sleep(5 * time.Millisecond)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("MeasureWithin failed: %v", err)
+	}
+	if !r.Partial || r.Iterations < 1 || r.Iterations >= 1000 || r.Iterations != calls-1 {
+		t.Fatalf("unexpected result after %d calls: %+v", calls, r)
+	}
+
+	// A budget that is already spent still yields one timed run
+	r, err = MeasureWithin("op", 3, time.Nanosecond, func() error {
+		time.Sleep(time.Millisecond)
+		return nil
+	})
+	if err != nil || r.Iterations != 1 || !r.Partial {
+		t.Fatalf("unexpected result %+v, %v", r, err)
+	}
+
+	// Runs that finish within the budget are not partial
+	r, err = MeasureWithin("op", 3, time.Minute, func() error { return nil })
+	if err != nil || r.Iterations != 3 || r.Partial {
+		t.Fatalf("unexpected result %+v, %v", r, err)
+	}
+}
+
+func TestSuiteRunMaxTime(t *testing.T) {
+	results, err := Suite{MessageCount: 4, Iterations: 1000, MaxTime: time.Nanosecond}.Run()
+	if !errors.Is(err, ErrTimeBudget) {
+		t.Fatalf("expected ErrTimeBudget, got %v", err)
+	}
+	if len(results) != 0 {
+		t.Fatalf("expected no results, got %+v", results)
+	}
+
+	results, err = Suite{MessageCount: 4, Iterations: 1000, OpTimeout: 10 * time.Millisecond}.Run()
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if len(results) != 5 {
+		t.Fatalf("got %d results, want 5", len(results))
+	}
+	for _, r := range results {
+		if !r.Partial || r.Iterations < 1 {
+			t.Fatalf("unexpected result %+v", r)
+		}
+	}
+}
+
+func TestWriteHTML(t *testing.T) {
+	var runs []Run
+	for _, count := range []int{2, 4} {
+		results, err := Suite{MessageCount: count, Disclosed: []int{0}, Iterations: 3}.Run()
+		if err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+		runs = append(runs, Run{MessageCount: count, Disclosed: 1, Results: results})
+	}
+	runs[0].Results[0].Partial = true
+
+	var buf bytes.Buffer
+	err := WriteHTML(&buf, Report{Environment: CurrentEnvironment(), Runs: runs})
+	if err != nil {
+		t.Fatalf("WriteHTML failed: %v", err)
+	}
+
+	page := buf.String()
+	for _, want := range []string{"<svg", "verify-proof", "Latency distribution at 4 messages", "GOMAXPROCS", `class="partial"`} {
+		if !strings.Contains(page, want) {
+			t.Errorf("report lacks %q", want)
+		}
+	}
+	// Self-contained: nothing is loaded from elsewhere
+	if strings.Contains(page, "<script src") || strings.Contains(page, "<link") {
+		t.Error("report references external resources")
+	}
+
+	if err := WriteHTML(&buf, Report{}); err == nil {
+		t.Error("WriteHTML accepted a report without runs")
+	}
+}
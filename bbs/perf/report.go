@@ -0,0 +1,347 @@
+package perf
+
+import (
+	"bufio"
+	"fmt"
+	"html/template"
+	"io"
+	"math"
+	"os"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/anupsv/bbsplus-signatures/internal/version"
+)
+
+// Environment describes the machine and build a report was measured with
+type Environment struct {
+	CPU        string
+	OS         string
+	Arch       string
+	NumCPU     int
+	GOMAXPROCS int
+	GoVersion  string
+	Library    string
+	Date       time.Time
+}
+
+// CurrentEnvironment describes the running process. The CPU model is read
+// from /proc/cpuinfo where there is one and is "unknown" elsewhere.
+func CurrentEnvironment() Environment {
+	return Environment{
+		CPU:        cpuModel(),
+		OS:         runtime.GOOS,
+		Arch:       runtime.GOARCH,
+		NumCPU:     runtime.NumCPU(),
+		GOMAXPROCS: runtime.GOMAXPROCS(0),
+		GoVersion:  runtime.Version(),
+		Library:    version.Get().String(),
+		Date:       time.Now().UTC(),
+	}
+}
+
+// cpuModel returns the first model name in /proc/cpuinfo
+func cpuModel() string {
+	f, err := os.Open("/proc/cpuinfo")
+	if err != nil {
+		return "unknown"
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), ":")
+		if ok && strings.TrimSpace(key) == "model name" {
+			return strings.TrimSpace(value)
+		}
+	}
+	return "unknown"
+}
+
+// Run holds the results of one Suite
+type Run struct {
+	MessageCount int
+	Disclosed    int
+	Results      []Result
+}
+
+// Report is a set of suite runs, typically over a range of message counts,
+// together with the environment they were measured in
+type Report struct {
+	Title       string
+	Environment Environment
+	Runs        []Run
+}
+
+// Chart geometry in SVG user units
+const (
+	chartWidth  = 640
+	chartHeight = 320
+	chartMargin = 56
+)
+
+// seriesColors are assigned to operations in order
+var seriesColors = []string{"#4e79a7", "#f28e2b", "#59a14f", "#e15759", "#b07aa1", "#76b7b2", "#edc948"}
+
+// WriteHTML writes the report as a single HTML page. The charts are inline
+// SVG and the page loads nothing else, so it can be mailed or attached to a
+// CI run as is. Hovering a data point shows its exact value.
+func WriteHTML(w io.Writer, r Report) error {
+	if len(r.Runs) == 0 {
+		return fmt.Errorf("report has no runs")
+	}
+	runs := append([]Run(nil), r.Runs...)
+	sort.SliceStable(runs, func(i, j int) bool { return runs[i].MessageCount < runs[j].MessageCount })
+
+	title := r.Title
+	if title == "" {
+		title = "BBS+ benchmark report"
+	}
+
+	// The latency distributions are drawn for the largest credential
+	largest := runs[len(runs)-1]
+	histograms := make([]namedChart, 0, len(largest.Results))
+	for i, res := range largest.Results {
+		histograms = append(histograms, namedChart{
+			Name:  res.Name,
+			Chart: histogramChart(res, seriesColors[i%len(seriesColors)]),
+		})
+	}
+
+	return reportTemplate.Execute(w, reportData{
+		Title:       title,
+		Environment: r.Environment,
+		Runs:        runs,
+		Throughput:  throughputChart(runs),
+		Largest:     largest.MessageCount,
+		Histograms:  histograms,
+	})
+}
+
+// reportData is the input of reportTemplate
+type reportData struct {
+	Title       string
+	Environment Environment
+	Runs        []Run
+	Throughput  template.HTML
+	Largest     int
+	Histograms  []namedChart
+}
+
+type namedChart struct {
+	Name  string
+	Chart template.HTML
+}
+
+// throughputChart plots single-core ops/sec against message count, one line
+// per operation
+func throughputChart(runs []Run) template.HTML {
+	var names []string
+	seen := make(map[string]bool)
+	maxX, maxY := 0.0, 0.0
+	for _, run := range runs {
+		maxX = math.Max(maxX, float64(run.MessageCount))
+		for _, res := range run.Results {
+			if !seen[res.Name] {
+				seen[res.Name] = true
+				names = append(names, res.Name)
+			}
+			maxY = math.Max(maxY, res.OpsPerSec())
+		}
+	}
+
+	var b strings.Builder
+	c := newChart(&b, maxX, maxY, "messages", "ops/sec (1 core)")
+	for i, name := range names {
+		color := seriesColors[i%len(seriesColors)]
+		var points []string
+		for _, run := range runs {
+			for _, res := range run.Results {
+				if res.Name != name {
+					continue
+				}
+				x, y := c.point(float64(run.MessageCount), res.OpsPerSec())
+				points = append(points, fmt.Sprintf("%.1f,%.1f", x, y))
+				fmt.Fprintf(&b, `<circle cx="%.1f" cy="%.1f" r="4" fill="%s"><title>%s, %d messages: %.1f ops/sec%s</title></circle>`,
+					x, y, color, template.HTMLEscapeString(name), run.MessageCount, res.OpsPerSec(), partialNote(res))
+			}
+		}
+		fmt.Fprintf(&b, `<polyline fill="none" stroke="%s" stroke-width="2" points="%s"/>`, color, strings.Join(points, " "))
+		fmt.Fprintf(&b, `<rect x="%d" y="%d" width="10" height="10" fill="%s"/><text x="%d" y="%d" font-size="11">%s</text>`,
+			chartWidth-2*chartMargin+12, chartMargin+16*i, color, chartWidth-2*chartMargin+26, chartMargin+16*i+9, template.HTMLEscapeString(name))
+	}
+	c.close()
+	return template.HTML(b.String())
+}
+
+// histogramBins is the number of bars in a latency histogram
+const histogramBins = 20
+
+// histogramChart plots the latency samples of one operation
+func histogramChart(res Result, color string) template.HTML {
+	var b strings.Builder
+	if len(res.Samples) == 0 {
+		return ""
+	}
+
+	lo, hi := res.Samples[0], res.Samples[len(res.Samples)-1]
+	width := (hi - lo) / histogramBins
+	if width <= 0 {
+		width = 1
+	}
+	counts := make([]int, histogramBins)
+	for _, d := range res.Samples {
+		bin := int((d - lo) / width)
+		if bin >= histogramBins {
+			bin = histogramBins - 1
+		}
+		counts[bin]++
+	}
+	maxCount := 0
+	for _, n := range counts {
+		maxCount = max(maxCount, n)
+	}
+
+	c := newChart(&b, float64(hi.Microseconds())+1, float64(maxCount), "latency (µs)", "runs")
+	for i, n := range counts {
+		if n == 0 {
+			continue
+		}
+		from := lo + time.Duration(i)*width
+		x0, _ := c.point(float64(from.Microseconds()), 0)
+		x1, _ := c.point(float64((from + width).Microseconds()), 0)
+		_, y := c.point(0, float64(n))
+		fmt.Fprintf(&b, `<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" fill="%s"><title>%v – %v: %d runs</title></rect>`,
+			x0, y, math.Max(x1-x0-1, 1), float64(chartHeight-chartMargin)-y, color,
+			from.Round(time.Microsecond), (from + width).Round(time.Microsecond), n)
+	}
+
+	// Mark the percentiles the tables report
+	for _, mark := range []struct {
+		label string
+		value time.Duration
+	}{{"p50", res.P50}, {"p95", res.P95}} {
+		x, _ := c.point(float64(mark.value.Microseconds()), 0)
+		fmt.Fprintf(&b, `<line x1="%.1f" x2="%.1f" y1="%d" y2="%d" stroke="#333" stroke-dasharray="4 3"/><text x="%.1f" y="%d" font-size="11">%s</text>`,
+			x, x, chartMargin, chartHeight-chartMargin, x+3, chartMargin+10, mark.label)
+	}
+	c.close()
+	return template.HTML(b.String())
+}
+
+// chart draws the frame of an SVG chart with axes starting at zero
+type chart struct {
+	b          *strings.Builder
+	maxX, maxY float64
+}
+
+func newChart(b *strings.Builder, maxX, maxY float64, xLabel, yLabel string) *chart {
+	if maxX <= 0 {
+		maxX = 1
+	}
+	if maxY <= 0 {
+		maxY = 1
+	}
+	c := &chart{b: b, maxX: maxX * 1.05, maxY: maxY * 1.1}
+
+	fmt.Fprintf(b, `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %d %d" width="%d" height="%d" font-family="sans-serif">`,
+		chartWidth, chartHeight, chartWidth, chartHeight)
+	left, bottom := chartMargin, chartHeight-chartMargin
+	right := chartWidth - 2*chartMargin
+	fmt.Fprintf(b, `<line x1="%d" y1="%d" x2="%d" y2="%d" stroke="#333"/>`, left, bottom, right, bottom)
+	fmt.Fprintf(b, `<line x1="%d" y1="%d" x2="%d" y2="%d" stroke="#333"/>`, left, chartMargin, left, bottom)
+	for i := 0; i <= 4; i++ {
+		xv, yv := c.maxX*float64(i)/4, c.maxY*float64(i)/4
+		x, _ := c.point(xv, 0)
+		_, y := c.point(0, yv)
+		fmt.Fprintf(b, `<text x="%.1f" y="%d" font-size="10" text-anchor="middle">%s</text>`, x, bottom+14, tickLabel(xv))
+		fmt.Fprintf(b, `<text x="%d" y="%.1f" font-size="10" text-anchor="end">%s</text>`, left-4, y+3, tickLabel(yv))
+		fmt.Fprintf(b, `<line x1="%d" y1="%.1f" x2="%d" y2="%.1f" stroke="#eee"/>`, left+1, y, right, y)
+	}
+	fmt.Fprintf(b, `<text x="%d" y="%d" font-size="12" text-anchor="middle">%s</text>`,
+		(left+right)/2, chartHeight-16, template.HTMLEscapeString(xLabel))
+	fmt.Fprintf(b, `<text x="14" y="%d" font-size="12" text-anchor="middle" transform="rotate(-90 14 %d)">%s</text>`,
+		chartHeight/2, chartHeight/2, template.HTMLEscapeString(yLabel))
+	return c
+}
+
+// point maps data coordinates to SVG coordinates
+func (c *chart) point(x, y float64) (float64, float64) {
+	plotWidth := float64(chartWidth - 3*chartMargin)
+	plotHeight := float64(chartHeight - 2*chartMargin)
+	return chartMargin + x/c.maxX*plotWidth, float64(chartHeight-chartMargin) - y/c.maxY*plotHeight
+}
+
+func (c *chart) close() {
+	c.b.WriteString("</svg>")
+}
+
+// tickLabel formats an axis value compactly
+func tickLabel(v float64) string {
+	switch {
+	case v >= 10000:
+		return fmt.Sprintf("%.0fk", v/1000)
+	case v >= 100 || v == math.Trunc(v):
+		return fmt.Sprintf("%.0f", v)
+	default:
+		return fmt.Sprintf("%.1f", v)
+	}
+}
+
+func partialNote(res Result) string {
+	if res.Partial {
+		return fmt.Sprintf(" (partial, %d runs)", res.Iterations)
+	}
+	return ""
+}
+
+var reportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"round": func(d time.Duration) time.Duration { return d.Round(time.Microsecond) },
+	"ops":   func(r Result) string { return fmt.Sprintf("%.1f", r.OpsPerSec()) },
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #222; }
+table { border-collapse: collapse; margin-bottom: 1.5em; }
+th, td { border: 1px solid #ccc; padding: 4px 10px; text-align: right; }
+th:first-child, td:first-child { text-align: left; }
+.partial { color: #b00; }
+.charts { display: flex; flex-wrap: wrap; gap: 1em; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+
+<h2>Environment</h2>
+<table>
+<tr><td>CPU</td><td>{{.Environment.CPU}}</td></tr>
+<tr><td>Cores / GOMAXPROCS</td><td>{{.Environment.NumCPU}} / {{.Environment.GOMAXPROCS}}</td></tr>
+<tr><td>Platform</td><td>{{.Environment.OS}}/{{.Environment.Arch}}</td></tr>
+<tr><td>Go</td><td>{{.Environment.GoVersion}}</td></tr>
+<tr><td>Library</td><td>{{.Environment.Library}}</td></tr>
+<tr><td>Measured</td><td>{{.Environment.Date.Format "2006-01-02 15:04:05 MST"}}</td></tr>
+</table>
+
+<h2>Throughput by message count</h2>
+{{.Throughput}}
+
+<h2>Latency distribution at {{.Largest}} messages</h2>
+<div class="charts">
+{{range .Histograms}}<figure><figcaption>{{.Name}}</figcaption>{{.Chart}}</figure>
+{{end}}</div>
+
+<h2>Results</h2>
+{{range .Runs}}<h3>{{.MessageCount}} messages, {{.Disclosed}} disclosed</h3>
+<table>
+<tr><th>operation</th><th>runs</th><th>ops/sec (1 core)</th><th>mean</th><th>p50</th><th>p95</th><th>max</th></tr>
+{{range .Results}}<tr{{if .Partial}} class="partial"{{end}}><td>{{.Name}}</td><td>{{.Iterations}}{{if .Partial}} (partial){{end}}</td><td>{{ops .}}</td><td>{{round .Mean}}</td><td>{{round .P50}}</td><td>{{round .P95}}</td><td>{{round .Max}}</td></tr>
+{{end}}</table>
+{{end}}
+</body>
+</html>
+`))
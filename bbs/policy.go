@@ -0,0 +1,203 @@
+package bbs
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+)
+
+// A KeyPolicy bounds what an Engine signs with its key, so automation that
+// is compromised or misbehaving cannot issue credentials outside it. The
+// signature counter lives in a PolicyStore and is reserved before signing:
+// a signature that then fails still counts, but no crash or concurrent
+// caller can push the count past MaxSignatures.
+
+// KeyPolicy limits the signatures an Engine makes. Zero fields impose no
+// limit.
+type KeyPolicy struct {
+	// MaxSignatures caps the number of signatures over the key's lifetime
+	MaxSignatures uint64 `json:"maxSignatures,omitempty"`
+
+	// MessageCounts lists the message counts the key may sign
+	MessageCounts []int `json:"messageCounts,omitempty"`
+
+	// NotAfter is the time after which the key no longer signs
+	NotAfter time.Time `json:"notAfter,omitempty"`
+
+	// Headers lists the headers the key may sign under
+	Headers [][]byte `json:"headers,omitempty"`
+}
+
+// check reports the first limit other than MaxSignatures that signing
+// messageCount messages under header at now would break
+func (p *KeyPolicy) check(messageCount int, header []byte, now time.Time) error {
+	if !p.NotAfter.IsZero() && now.After(p.NotAfter) {
+		return fmt.Errorf("%w: key expired at %s", ErrPolicyViolation, p.NotAfter.Format(time.RFC3339))
+	}
+	if len(p.MessageCounts) > 0 && !slices.Contains(p.MessageCounts, messageCount) {
+		return fmt.Errorf("%w: message count %d not allowed", ErrPolicyViolation, messageCount)
+	}
+	if len(p.Headers) > 0 && !slices.ContainsFunc(p.Headers, func(h []byte) bool { return bytes.Equal(h, header) }) {
+		return fmt.Errorf("%w: header not allowed", ErrPolicyViolation)
+	}
+	return nil
+}
+
+// clone returns a deep copy of the policy
+func (p *KeyPolicy) clone() *KeyPolicy {
+	c := *p
+	c.MessageCounts = slices.Clone(p.MessageCounts)
+	if p.Headers != nil {
+		c.Headers = make([][]byte, len(p.Headers))
+		for i, h := range p.Headers {
+			c.Headers[i] = bytes.Clone(h)
+		}
+	}
+	return &c
+}
+
+// PolicyStore keeps the signature counters of key policies
+type PolicyStore interface {
+	// Reserve increments the counter of keyID unless it has reached limit,
+	// and reports whether it did. Both steps are one atomic operation.
+	Reserve(keyID string, limit uint64) (bool, error)
+
+	// Count returns the counter of keyID
+	Count(keyID string) (uint64, error)
+}
+
+// PolicyKeyID returns the identifier a PolicyStore counts a key under: the
+// hex SHA-256 of its serialized public key. It predates Fingerprint, which
+// encodes the same digest in multibase, and stays so stored counters carry
+// over.
+func PolicyKeyID(publicKey *PublicKey) string {
+	sum := sha256.Sum256(SerializePublicKey(publicKey))
+	return hex.EncodeToString(sum[:])
+}
+
+// MemoryPolicyStore is a PolicyStore that keeps its counters in memory. They
+// reset when the process restarts.
+type MemoryPolicyStore struct {
+	mu     sync.Mutex
+	counts map[string]uint64
+}
+
+// NewMemoryPolicyStore creates an empty in-memory policy store
+func NewMemoryPolicyStore() *MemoryPolicyStore {
+	return &MemoryPolicyStore{counts: make(map[string]uint64)}
+}
+
+// Reserve implements PolicyStore
+func (s *MemoryPolicyStore) Reserve(keyID string, limit uint64) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if limit > 0 && s.counts[keyID] >= limit {
+		return false, nil
+	}
+	s.counts[keyID]++
+	return true, nil
+}
+
+// Count implements PolicyStore
+func (s *MemoryPolicyStore) Count(keyID string) (uint64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.counts[keyID], nil
+}
+
+// FilePolicyStore is a PolicyStore that keeps its counters in a JSON file,
+// rewritten atomically on every reservation. One process at a time may use
+// the file.
+type FilePolicyStore struct {
+	mu     sync.Mutex
+	path   string
+	counts map[string]uint64
+}
+
+// OpenFilePolicyStore opens the policy store at path, creating it on the
+// first reservation if it does not exist
+func OpenFilePolicyStore(path string) (*FilePolicyStore, error) {
+	s := &FilePolicyStore{path: path, counts: make(map[string]uint64)}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read policy store: %w", err)
+	}
+	if err := json.Unmarshal(data, &s.counts); err != nil {
+		return nil, fmt.Errorf("failed to decode policy store: %w", err)
+	}
+	if s.counts == nil {
+		s.counts = make(map[string]uint64)
+	}
+	return s, nil
+}
+
+// Reserve implements PolicyStore. The counter is on disk before Reserve
+// returns true.
+func (s *FilePolicyStore) Reserve(keyID string, limit uint64) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if limit > 0 && s.counts[keyID] >= limit {
+		return false, nil
+	}
+
+	s.counts[keyID]++
+	if err := s.save(); err != nil {
+		s.counts[keyID]--
+		return false, err
+	}
+	return true, nil
+}
+
+// Count implements PolicyStore
+func (s *FilePolicyStore) Count(keyID string) (uint64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.counts[keyID], nil
+}
+
+// save writes the counters to a temporary file and renames it over the store
+func (s *FilePolicyStore) save() error {
+	data, err := json.Marshal(s.counts)
+	if err != nil {
+		return fmt.Errorf("failed to encode policy store: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to write policy store: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write policy store: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write policy store: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write policy store: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("failed to write policy store: %w", err)
+	}
+	return nil
+}
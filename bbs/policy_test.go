@@ -0,0 +1,109 @@
+package bbs
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestEngineKeyPolicy(t *testing.T) {
+	keyPair, err := GenerateKeyPair(3, rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key pair: %v", err)
+	}
+
+	messages := []*big.Int{big.NewInt(1), big.NewInt(2), big.NewInt(3)}
+	header := []byte("issuer/v1")
+	store := NewMemoryPolicyStore()
+	engine := NewEngine(WithSigner(NewLocalSigner(keyPair)), WithKeyPolicy(KeyPolicy{
+		MaxSignatures: 2,
+		MessageCounts: []int{3},
+		NotAfter:      time.Now().Add(time.Hour),
+		Headers:       [][]byte{header},
+	}, store))
+
+	// Signatures outside the policy are refused and not counted
+	if _, err := engine.Sign(messages[:2], header); !errors.Is(err, ErrPolicyViolation) {
+		t.Fatalf("Expected ErrPolicyViolation for the message count, got %v", err)
+	}
+	if _, err := engine.Sign(messages, []byte("other")); !errors.Is(err, ErrPolicyViolation) {
+		t.Fatalf("Expected ErrPolicyViolation for the header, got %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		signature, err := engine.Sign(messages, header)
+		if err != nil {
+			t.Fatalf("Sign failed: %v", err)
+		}
+		if err := Verify(keyPair.PublicKey, signature, messages, header); err != nil {
+			t.Fatalf("Verify failed: %v", err)
+		}
+	}
+
+	if _, err := engine.Sign(messages, header); !errors.Is(err, ErrPolicyViolation) {
+		t.Fatalf("Expected ErrPolicyViolation past the limit, got %v", err)
+	}
+	if n, _ := store.Count(PolicyKeyID(keyPair.PublicKey)); n != 2 {
+		t.Fatalf("Expected 2 signatures counted, got %d", n)
+	}
+
+	// An expired key no longer signs
+	expired := NewEngine(WithSigner(NewLocalSigner(keyPair)), WithKeyPolicy(KeyPolicy{NotAfter: time.Now().Add(-time.Minute)}, nil))
+	if _, err := expired.Sign(messages, header); !errors.Is(err, ErrPolicyViolation) {
+		t.Fatalf("Expected ErrPolicyViolation for an expired key, got %v", err)
+	}
+}
+
+func TestPolicyStoreConcurrentLimit(t *testing.T) {
+	store := NewMemoryPolicyStore()
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	reserved := 0
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if ok, _ := store.Reserve("key", 10); ok {
+				mu.Lock()
+				reserved++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if reserved != 10 {
+		t.Fatalf("Expected 10 reservations, got %d", reserved)
+	}
+}
+
+func TestFilePolicyStorePersists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.json")
+
+	store, err := OpenFilePolicyStore(path)
+	if err != nil {
+		t.Fatalf("OpenFilePolicyStore failed: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		if ok, err := store.Reserve("key", 3); !ok || err != nil {
+			t.Fatalf("Reserve = %v, %v", ok, err)
+		}
+	}
+
+	// A restarted process sees the counter and stays within the limit
+	reopened, err := OpenFilePolicyStore(path)
+	if err != nil {
+		t.Fatalf("OpenFilePolicyStore failed: %v", err)
+	}
+	if n, _ := reopened.Count("key"); n != 3 {
+		t.Fatalf("Expected a count of 3, got %d", n)
+	}
+	if ok, err := reopened.Reserve("key", 3); ok || err != nil {
+		t.Fatalf("Expected the limit to hold after reopening, got %v, %v", ok, err)
+	}
+}
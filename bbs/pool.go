@@ -3,6 +3,8 @@ package bbs
 import (
 	"math/big"
 	"sync"
+	"sync/atomic"
+	"unsafe"
 
 	bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381"
 )
@@ -30,6 +32,12 @@ type ObjectPool struct {
 	pointIndexPool   sync.Pool      // map[int]bls12381.G1Affine
 	challengePool    sync.Pool      // for challenge data
 	msgBatchPool     sync.Pool      // for batch message operations
+
+	// Objects held by open transactions, see Txn
+	outstanding atomic.Int64
+
+	// Counters and held objects, nil unless instrumented, see Instrument
+	instruments atomic.Pointer[poolInstruments]
 }
 
 // NewObjectPool creates a new object pool
@@ -102,6 +110,20 @@ func NewObjectPool() *ObjectPool {
 			},
 		},
 	}
+
+	pool.countAllocs(&pool.bigIntPool, kindBigInt)
+	pool.countAllocs(&pool.bigIntSlicePool, kindBigIntSlice)
+	pool.countAllocs(&pool.g1JacPool, kindG1Jac)
+	pool.countAllocs(&pool.g1AffinePool, kindG1Affine)
+	pool.countAllocs(&pool.g1AffineSlicePool, kindG1AffineSlice)
+	pool.countAllocs(&pool.g2JacPool, kindG2Jac)
+	pool.countAllocs(&pool.g2AffinePool, kindG2Affine)
+	pool.countAllocs(&pool.g2AffineSlicePool, kindG2AffineSlice)
+	pool.countAllocs(&pool.scalarSlicePool, kindScalarSlice)
+	pool.countAllocs(&pool.disclosedMsgPool, kindDisclosedMsgMap)
+	pool.countAllocs(&pool.pointIndexPool, kindPointIndexMap)
+	pool.countAllocs(&pool.challengePool, kindChallengeBuffer)
+	pool.countAllocs(&pool.msgBatchPool, kindMsgBatchMap)
 	return pool
 }
 
@@ -110,12 +132,15 @@ var defaultPool = NewObjectPool()
 
 // GetBigInt gets a big.Int from the pool
 func (p *ObjectPool) GetBigInt() *big.Int {
-	return p.bigIntPool.Get().(*big.Int).SetInt64(0)
+	i := p.bigIntPool.Get().(*big.Int).SetInt64(0)
+	p.acquired(kindBigInt, uintptr(unsafe.Pointer(i)))
+	return i
 }
 
 // PutBigInt returns a big.Int to the pool
 func (p *ObjectPool) PutBigInt(i *big.Int) {
 	if i != nil {
+		p.released(kindBigInt, uintptr(unsafe.Pointer(i)))
 		p.bigIntPool.Put(i)
 	}
 }
@@ -125,38 +150,48 @@ func (p *ObjectPool) GetBigIntSlice(capacity int) []*big.Int {
 	slice := p.bigIntSlicePool.Get().([]*big.Int)
 	if cap(slice) < capacity {
 		// If capacity is too small, create a new slice
-		return make([]*big.Int, 0, capacity)
+		p.allocated(kindBigIntSlice)
+		slice = make([]*big.Int, 0, capacity)
 	}
-	return slice[:0] // Reset length to 0 but keep capacity
+	slice = slice[:0] // Reset length to 0 but keep capacity
+	p.acquired(kindBigIntSlice, sliceAddr(slice))
+	return slice
 }
 
 // PutBigIntSlice returns a slice of big.Int pointers to the pool
 func (p *ObjectPool) PutBigIntSlice(slice []*big.Int) {
 	if slice != nil {
+		p.released(kindBigIntSlice, sliceAddr(slice))
 		p.bigIntSlicePool.Put(slice)
 	}
 }
 
 // GetG1Jac gets a G1 Jacobian point from the pool
 func (p *ObjectPool) GetG1Jac() *bls12381.G1Jac {
-	return p.g1JacPool.Get().(*bls12381.G1Jac)
+	g := p.g1JacPool.Get().(*bls12381.G1Jac)
+	p.acquired(kindG1Jac, uintptr(unsafe.Pointer(g)))
+	return g
 }
 
 // PutG1Jac returns a G1 Jacobian point to the pool
 func (p *ObjectPool) PutG1Jac(g *bls12381.G1Jac) {
 	if g != nil {
+		p.released(kindG1Jac, uintptr(unsafe.Pointer(g)))
 		p.g1JacPool.Put(g)
 	}
 }
 
 // GetG1Affine gets a G1 Affine point from the pool
 func (p *ObjectPool) GetG1Affine() *bls12381.G1Affine {
-	return p.g1AffinePool.Get().(*bls12381.G1Affine)
+	g := p.g1AffinePool.Get().(*bls12381.G1Affine)
+	p.acquired(kindG1Affine, uintptr(unsafe.Pointer(g)))
+	return g
 }
 
 // PutG1Affine returns a G1 Affine point to the pool
 func (p *ObjectPool) PutG1Affine(g *bls12381.G1Affine) {
 	if g != nil {
+		p.released(kindG1Affine, uintptr(unsafe.Pointer(g)))
 		p.g1AffinePool.Put(g)
 	}
 }
@@ -165,38 +200,48 @@ func (p *ObjectPool) PutG1Affine(g *bls12381.G1Affine) {
 func (p *ObjectPool) GetG1AffineSlice(capacity int) []bls12381.G1Affine {
 	slice := p.g1AffineSlicePool.Get().([]bls12381.G1Affine)
 	if cap(slice) < capacity {
-		return make([]bls12381.G1Affine, 0, capacity)
+		p.allocated(kindG1AffineSlice)
+		slice = make([]bls12381.G1Affine, 0, capacity)
 	}
-	return slice[:0]
+	slice = slice[:0]
+	p.acquired(kindG1AffineSlice, sliceAddr(slice))
+	return slice
 }
 
 // PutG1AffineSlice returns a slice of G1 Affine points to the pool
 func (p *ObjectPool) PutG1AffineSlice(slice []bls12381.G1Affine) {
 	if slice != nil {
+		p.released(kindG1AffineSlice, sliceAddr(slice))
 		p.g1AffineSlicePool.Put(slice)
 	}
 }
 
 // GetG2Jac gets a G2 Jacobian point from the pool
 func (p *ObjectPool) GetG2Jac() *bls12381.G2Jac {
-	return p.g2JacPool.Get().(*bls12381.G2Jac)
+	g := p.g2JacPool.Get().(*bls12381.G2Jac)
+	p.acquired(kindG2Jac, uintptr(unsafe.Pointer(g)))
+	return g
 }
 
 // PutG2Jac returns a G2 Jacobian point to the pool
 func (p *ObjectPool) PutG2Jac(g *bls12381.G2Jac) {
 	if g != nil {
+		p.released(kindG2Jac, uintptr(unsafe.Pointer(g)))
 		p.g2JacPool.Put(g)
 	}
 }
 
 // GetG2Affine gets a G2 Affine point from the pool
 func (p *ObjectPool) GetG2Affine() *bls12381.G2Affine {
-	return p.g2AffinePool.Get().(*bls12381.G2Affine)
+	g := p.g2AffinePool.Get().(*bls12381.G2Affine)
+	p.acquired(kindG2Affine, uintptr(unsafe.Pointer(g)))
+	return g
 }
 
 // PutG2Affine returns a G2 Affine point to the pool
 func (p *ObjectPool) PutG2Affine(g *bls12381.G2Affine) {
 	if g != nil {
+		p.released(kindG2Affine, uintptr(unsafe.Pointer(g)))
 		p.g2AffinePool.Put(g)
 	}
 }
@@ -205,14 +250,18 @@ func (p *ObjectPool) PutG2Affine(g *bls12381.G2Affine) {
 func (p *ObjectPool) GetG2AffineSlice(capacity int) []bls12381.G2Affine {
 	slice := p.g2AffineSlicePool.Get().([]bls12381.G2Affine)
 	if cap(slice) < capacity {
-		return make([]bls12381.G2Affine, 0, capacity)
+		p.allocated(kindG2AffineSlice)
+		slice = make([]bls12381.G2Affine, 0, capacity)
 	}
-	return slice[:0]
+	slice = slice[:0]
+	p.acquired(kindG2AffineSlice, sliceAddr(slice))
+	return slice
 }
 
 // PutG2AffineSlice returns a slice of G2 Affine points to the pool
 func (p *ObjectPool) PutG2AffineSlice(slice []bls12381.G2Affine) {
 	if slice != nil {
+		p.released(kindG2AffineSlice, sliceAddr(slice))
 		p.g2AffineSlicePool.Put(slice)
 	}
 }
@@ -221,14 +270,18 @@ func (p *ObjectPool) PutG2AffineSlice(slice []bls12381.G2Affine) {
 func (p *ObjectPool) GetScalarSlice(capacity int) []*big.Int {
 	slice := p.scalarSlicePool.Get().([]*big.Int)
 	if cap(slice) < capacity {
-		return make([]*big.Int, 0, capacity)
+		p.allocated(kindScalarSlice)
+		slice = make([]*big.Int, 0, capacity)
 	}
-	return slice[:0]
+	slice = slice[:0]
+	p.acquired(kindScalarSlice, sliceAddr(slice))
+	return slice
 }
 
 // PutScalarSlice returns a slice of scalars to the pool
 func (p *ObjectPool) PutScalarSlice(slice []*big.Int) {
 	if slice != nil {
+		p.released(kindScalarSlice, sliceAddr(slice))
 		p.scalarSlicePool.Put(slice)
 	}
 }
@@ -240,12 +293,14 @@ func (p *ObjectPool) GetDisclosedMsgMap() map[int]*big.Int {
 	for k := range m {
 		delete(m, k)
 	}
+	p.acquired(kindDisclosedMsgMap, mapAddr(m))
 	return m
 }
 
 // PutDisclosedMsgMap returns a map for disclosed messages to the pool
 func (p *ObjectPool) PutDisclosedMsgMap(m map[int]*big.Int) {
 	if m != nil {
+		p.released(kindDisclosedMsgMap, mapAddr(m))
 		p.disclosedMsgPool.Put(m)
 	}
 }
@@ -257,12 +312,14 @@ func (p *ObjectPool) GetPointIndexMap() map[int]bls12381.G1Affine {
 	for k := range m {
 		delete(m, k)
 	}
+	p.acquired(kindPointIndexMap, mapAddr(m))
 	return m
 }
 
 // PutPointIndexMap returns a map for point indices to the pool
 func (p *ObjectPool) PutPointIndexMap(m map[int]bls12381.G1Affine) {
 	if m != nil {
+		p.released(kindPointIndexMap, mapAddr(m))
 		p.pointIndexPool.Put(m)
 	}
 }
@@ -271,14 +328,18 @@ func (p *ObjectPool) PutPointIndexMap(m map[int]bls12381.G1Affine) {
 func (p *ObjectPool) GetChallengeBuffer(capacity int) []byte {
 	buf := p.challengePool.Get().([]byte)
 	if cap(buf) < capacity {
-		return make([]byte, 0, capacity)
+		p.allocated(kindChallengeBuffer)
+		buf = make([]byte, 0, capacity)
 	}
-	return buf[:0]
+	buf = buf[:0]
+	p.acquired(kindChallengeBuffer, sliceAddr(buf))
+	return buf
 }
 
 // PutChallengeBuffer returns a buffer for challenge data to the pool
 func (p *ObjectPool) PutChallengeBuffer(buf []byte) {
 	if buf != nil {
+		p.released(kindChallengeBuffer, sliceAddr(buf))
 		p.challengePool.Put(buf)
 	}
 }
@@ -290,12 +351,14 @@ func (p *ObjectPool) GetMsgBatchMap() map[int][]byte {
 	for k := range m {
 		delete(m, k)
 	}
+	p.acquired(kindMsgBatchMap, mapAddr(m))
 	return m
 }
 
 // PutMsgBatchMap returns a map for batch message operations to the pool
 func (p *ObjectPool) PutMsgBatchMap(m map[int][]byte) {
 	if m != nil {
+		p.released(kindMsgBatchMap, mapAddr(m))
 		p.msgBatchPool.Put(m)
 	}
 }
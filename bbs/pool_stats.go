@@ -0,0 +1,319 @@
+package bbs
+
+import (
+	"reflect"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unsafe"
+)
+
+// Pool instrumentation is off by default and costs one atomic load per Get
+// and Put while off. Instrument turns on per-type counters; with a positive
+// leak threshold it also records where each object was taken, so objects
+// held longer than the threshold are reported with the stack that took them.
+// Recording a stack per Get is slow, so leak detection is meant for tests and
+// debugging sessions, not production traffic.
+
+// poolKind identifies one of the pools of an ObjectPool
+type poolKind int
+
+const (
+	kindBigInt poolKind = iota
+	kindBigIntSlice
+	kindG1Jac
+	kindG1Affine
+	kindG1AffineSlice
+	kindG2Jac
+	kindG2Affine
+	kindG2AffineSlice
+	kindScalarSlice
+	kindDisclosedMsgMap
+	kindPointIndexMap
+	kindChallengeBuffer
+	kindMsgBatchMap
+	numPoolKinds
+)
+
+// poolKindNames are the type names used in PoolSnapshot, matching the Get
+// and Put methods
+var poolKindNames = [numPoolKinds]string{
+	kindBigInt:          "BigInt",
+	kindBigIntSlice:     "BigIntSlice",
+	kindG1Jac:           "G1Jac",
+	kindG1Affine:        "G1Affine",
+	kindG1AffineSlice:   "G1AffineSlice",
+	kindG2Jac:           "G2Jac",
+	kindG2Affine:        "G2Affine",
+	kindG2AffineSlice:   "G2AffineSlice",
+	kindScalarSlice:     "ScalarSlice",
+	kindDisclosedMsgMap: "DisclosedMsgMap",
+	kindPointIndexMap:   "PointIndexMap",
+	kindChallengeBuffer: "ChallengeBuffer",
+	kindMsgBatchMap:     "MsgBatchMap",
+}
+
+const (
+	// maxTrackedObjects bounds the objects whose acquisition is recorded in
+	// leak detection mode; acquisitions beyond it are counted as untracked
+	maxTrackedObjects = 1 << 16
+
+	// maxLeakStackDepth bounds the frames recorded per acquisition
+	maxLeakStackDepth = 32
+)
+
+// PoolTypeStats counts the traffic of one pooled type
+type PoolTypeStats struct {
+	// Gets and Puts count objects taken from and returned to the pool
+	Gets int64
+	Puts int64
+
+	// Allocs counts Gets the pool could not serve from a returned object,
+	// including slices whose pooled capacity was too small
+	Allocs int64
+
+	// Outstanding is Gets minus Puts: objects taken and not yet returned
+	Outstanding int64
+}
+
+// HitRate returns the fraction of Gets served with a reused object
+func (s PoolTypeStats) HitRate() float64 {
+	if s.Gets == 0 {
+		return 0
+	}
+	return 1 - float64(min(s.Allocs, s.Gets))/float64(s.Gets)
+}
+
+// PoolLeak is an object held longer than the leak threshold
+type PoolLeak struct {
+	// Type is the pooled type, as in PoolSnapshot.Types
+	Type string
+
+	// Acquired is when the object was taken, and Age how long it has been held
+	Acquired time.Time
+	Age      time.Duration
+
+	// Stack is the call stack that took the object
+	Stack string
+}
+
+// PoolSnapshot is the state of an instrumented ObjectPool
+type PoolSnapshot struct {
+	// Enabled reports whether the pool is instrumented; the other fields
+	// are empty when it is not
+	Enabled bool
+
+	// Types holds the counters of each pooled type, keyed by the name used
+	// in the Get and Put methods, such as "G1Jac"
+	Types map[string]PoolTypeStats
+
+	// LeakThreshold is the age beyond which held objects are reported, or
+	// zero when leak detection is off
+	LeakThreshold time.Duration
+
+	// Leaks lists the objects held longer than LeakThreshold, oldest first
+	Leaks []PoolLeak
+
+	// Untracked counts acquisitions not recorded because too many objects
+	// were already held; their leaks cannot be reported
+	Untracked int64
+}
+
+// poolCounters are the counters of one pooled type
+type poolCounters struct {
+	gets, puts, allocs atomic.Int64
+}
+
+// heldObject records where a pooled object was taken
+type heldObject struct {
+	kind poolKind
+	at   time.Time
+	pcs  []uintptr
+}
+
+// poolInstruments is the instrumentation state of an ObjectPool
+type poolInstruments struct {
+	counters  [numPoolKinds]poolCounters
+	threshold time.Duration
+	untracked atomic.Int64
+
+	mu   sync.Mutex
+	held map[uintptr]heldObject
+}
+
+// Instrument turns on counting for the pool. A positive leakThreshold also
+// records the stack of every Get, so that objects not returned within the
+// threshold are reported by Stats. Calling Instrument again resets the
+// counters.
+//
+// Objects are tracked by address: a slice that outgrew its capacity is put
+// back as a different object, so the one taken is reported as held.
+func (p *ObjectPool) Instrument(leakThreshold time.Duration) {
+	inst := &poolInstruments{threshold: max(leakThreshold, 0)}
+	if inst.threshold > 0 {
+		inst.held = make(map[uintptr]heldObject)
+	}
+	p.instruments.Store(inst)
+}
+
+// StopInstrumentation turns counting and leak detection off and drops the
+// recorded state
+func (p *ObjectPool) StopInstrumentation() {
+	p.instruments.Store(nil)
+}
+
+// Stats returns the counters of the pool and the objects held longer than
+// the leak threshold
+func (p *ObjectPool) Stats() PoolSnapshot {
+	inst := p.instruments.Load()
+	if inst == nil {
+		return PoolSnapshot{}
+	}
+
+	snapshot := PoolSnapshot{
+		Enabled:       true,
+		Types:         make(map[string]PoolTypeStats, numPoolKinds),
+		LeakThreshold: inst.threshold,
+		Untracked:     inst.untracked.Load(),
+	}
+	for kind := range numPoolKinds {
+		c := &inst.counters[kind]
+		gets, puts := c.gets.Load(), c.puts.Load()
+		snapshot.Types[poolKindNames[kind]] = PoolTypeStats{
+			Gets:        gets,
+			Puts:        puts,
+			Allocs:      c.allocs.Load(),
+			Outstanding: gets - puts,
+		}
+	}
+
+	if inst.held != nil {
+		now := time.Now()
+		inst.mu.Lock()
+		for _, h := range inst.held {
+			if age := now.Sub(h.at); age >= inst.threshold {
+				snapshot.Leaks = append(snapshot.Leaks, PoolLeak{
+					Type:     poolKindNames[h.kind],
+					Acquired: h.at,
+					Age:      age,
+					Stack:    formatStack(h.pcs),
+				})
+			}
+		}
+		inst.mu.Unlock()
+		sort.Slice(snapshot.Leaks, func(i, j int) bool {
+			return snapshot.Leaks[i].Acquired.Before(snapshot.Leaks[j].Acquired)
+		})
+	}
+	return snapshot
+}
+
+// InstrumentPool turns on counting for the default pool, see
+// ObjectPool.Instrument
+func InstrumentPool(leakThreshold time.Duration) {
+	defaultPool.Instrument(leakThreshold)
+}
+
+// PoolStats returns the state of the default pool, see ObjectPool.Stats
+func PoolStats() PoolSnapshot {
+	return defaultPool.Stats()
+}
+
+// DefaultObjectPool returns the pool used by the package-level Get and Put
+// functions and by managers created without a pool
+func DefaultObjectPool() *ObjectPool {
+	return defaultPool
+}
+
+// countAllocs wraps the New function of sp so the pool counts the objects
+// it had to create
+func (p *ObjectPool) countAllocs(sp *sync.Pool, kind poolKind) {
+	newObject := sp.New
+	sp.New = func() interface{} {
+		p.allocated(kind)
+		return newObject()
+	}
+}
+
+// allocated counts a Get that created a new object
+func (p *ObjectPool) allocated(kind poolKind) {
+	if inst := p.instruments.Load(); inst != nil {
+		inst.counters[kind].allocs.Add(1)
+	}
+}
+
+// acquired counts a Get and, in leak detection mode, records its stack
+func (p *ObjectPool) acquired(kind poolKind, addr uintptr) {
+	inst := p.instruments.Load()
+	if inst == nil {
+		return
+	}
+	inst.counters[kind].gets.Add(1)
+	if inst.held == nil || addr == 0 {
+		return
+	}
+
+	pcs := make([]uintptr, maxLeakStackDepth)
+	// Skip runtime.Callers, acquired and the Get method
+	pcs = pcs[:runtime.Callers(3, pcs)]
+
+	inst.mu.Lock()
+	defer inst.mu.Unlock()
+	if len(inst.held) >= maxTrackedObjects {
+		inst.untracked.Add(1)
+		return
+	}
+	inst.held[addr] = heldObject{kind: kind, at: time.Now(), pcs: pcs}
+}
+
+// released counts a Put and forgets where the object was taken
+func (p *ObjectPool) released(kind poolKind, addr uintptr) {
+	inst := p.instruments.Load()
+	if inst == nil {
+		return
+	}
+	inst.counters[kind].puts.Add(1)
+	if inst.held == nil || addr == 0 {
+		return
+	}
+	inst.mu.Lock()
+	delete(inst.held, addr)
+	inst.mu.Unlock()
+}
+
+// sliceAddr identifies a pooled slice by its backing array. Slices without
+// capacity share the zero-size allocation and are not tracked.
+func sliceAddr[T any](s []T) uintptr {
+	if cap(s) == 0 {
+		return 0
+	}
+	return uintptr(unsafe.Pointer(unsafe.SliceData(s[:cap(s)])))
+}
+
+// mapAddr identifies a pooled map
+func mapAddr(m any) uintptr {
+	return uintptr(reflect.ValueOf(m).UnsafePointer())
+}
+
+// formatStack renders recorded program counters like a goroutine trace
+func formatStack(pcs []uintptr) string {
+	var b strings.Builder
+	frames := runtime.CallersFrames(pcs)
+	for {
+		frame, more := frames.Next()
+		b.WriteString(frame.Function)
+		b.WriteString("\n\t")
+		b.WriteString(frame.File)
+		b.WriteByte(':')
+		b.WriteString(strconv.Itoa(frame.Line))
+		b.WriteByte('\n')
+		if !more {
+			break
+		}
+	}
+	return b.String()
+}
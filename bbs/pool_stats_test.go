@@ -0,0 +1,73 @@
+package bbs
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPoolStats(t *testing.T) {
+	pool := NewObjectPool()
+	if pool.Stats().Enabled {
+		t.Fatal("Expected a new pool to be uninstrumented")
+	}
+
+	pool.Instrument(0)
+	txn := pool.Begin()
+	txn.BigInt()
+	txn.G1Jac()
+	txn.G1AffineSlice(1 << 10)
+	txn.End()
+	pool.PutBigInt(pool.GetBigInt())
+
+	stats := pool.Stats()
+	if !stats.Enabled || stats.Leaks != nil {
+		t.Fatalf("Unexpected snapshot: %+v", stats)
+	}
+	bigInts := stats.Types["BigInt"]
+	if bigInts.Gets != 2 || bigInts.Puts != 2 || bigInts.Outstanding != 0 {
+		t.Fatalf("Unexpected BigInt counters: %+v", bigInts)
+	}
+	if g1 := stats.Types["G1Jac"]; g1.Gets != 1 || g1.Allocs != 1 || g1.HitRate() != 0 {
+		t.Fatalf("Unexpected G1Jac counters: %+v", g1)
+	}
+	if s := stats.Types["G1AffineSlice"]; s.Allocs == 0 {
+		t.Fatalf("Expected an undersized pooled slice to count as an alloc: %+v", s)
+	}
+
+	pool.StopInstrumentation()
+	pool.GetBigInt()
+	if pool.Stats().Enabled {
+		t.Fatal("Expected StopInstrumentation to turn counting off")
+	}
+}
+
+func TestPoolLeakDetection(t *testing.T) {
+	pool := NewObjectPool()
+	pool.Instrument(time.Nanosecond)
+
+	returned := pool.GetG2Jac()
+	pool.PutG2Jac(returned)
+	held := pool.GetDisclosedMsgMap()
+	time.Sleep(time.Millisecond)
+
+	stats := pool.Stats()
+	if len(stats.Leaks) != 1 {
+		t.Fatalf("Expected one leak, got %d", len(stats.Leaks))
+	}
+	leak := stats.Leaks[0]
+	if leak.Type != "DisclosedMsgMap" || leak.Age <= 0 {
+		t.Fatalf("Unexpected leak: %+v", leak)
+	}
+	if !strings.Contains(leak.Stack, "TestPoolLeakDetection") {
+		t.Fatalf("Expected the stack to name the caller, got:\n%s", leak.Stack)
+	}
+	if stats.Types["DisclosedMsgMap"].Outstanding != 1 {
+		t.Fatalf("Expected one outstanding map, got %+v", stats.Types["DisclosedMsgMap"])
+	}
+
+	pool.PutDisclosedMsgMap(held)
+	if leaks := pool.Stats().Leaks; len(leaks) != 0 {
+		t.Fatalf("Expected no leaks after the map was returned, got %d", len(leaks))
+	}
+}
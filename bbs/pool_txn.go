@@ -0,0 +1,177 @@
+package bbs
+
+import (
+	"math/big"
+	"reflect"
+	"sync"
+
+	bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381"
+)
+
+// Txn takes temporary objects from an ObjectPool and returns all of them on
+// End, so an early error return cannot skip a Put:
+//
+//	txn := pool.Begin()
+//	defer txn.End()
+//
+// Objects that outlive the transaction, such as a map handed to the caller,
+// are released from it with Keep. A Txn must not be used after End, and is
+// not safe for concurrent use.
+type Txn struct {
+	pool *ObjectPool
+
+	bigInts       []*big.Int
+	g1Jacs        []*bls12381.G1Jac
+	g2Jacs        []*bls12381.G2Jac
+	g1Slices      [][]bls12381.G1Affine
+	g2Slices      [][]bls12381.G2Affine
+	scalarSlices  [][]*big.Int
+	disclosedMaps []map[int]*big.Int
+}
+
+// txnPool recycles the Txn values themselves
+var txnPool = sync.Pool{
+	New: func() interface{} {
+		return new(Txn)
+	},
+}
+
+// Begin starts a transaction on the pool
+func (p *ObjectPool) Begin() *Txn {
+	t := txnPool.Get().(*Txn)
+	t.pool = p
+	return t
+}
+
+// Outstanding returns the number of objects taken through transactions on the
+// pool that have not been returned or kept
+func (p *ObjectPool) Outstanding() int64 {
+	return p.outstanding.Load()
+}
+
+// Begin starts a transaction on the default pool
+func Begin() *Txn {
+	return defaultPool.Begin()
+}
+
+// BigInt takes a big.Int, set to zero
+func (t *Txn) BigInt() *big.Int {
+	i := t.pool.GetBigInt()
+	t.bigInts = append(t.bigInts, i)
+	t.pool.outstanding.Add(1)
+	return i
+}
+
+// G1Jac takes a G1 Jacobian point
+func (t *Txn) G1Jac() *bls12381.G1Jac {
+	g := t.pool.GetG1Jac()
+	t.g1Jacs = append(t.g1Jacs, g)
+	t.pool.outstanding.Add(1)
+	return g
+}
+
+// G2Jac takes a G2 Jacobian point
+func (t *Txn) G2Jac() *bls12381.G2Jac {
+	g := t.pool.GetG2Jac()
+	t.g2Jacs = append(t.g2Jacs, g)
+	t.pool.outstanding.Add(1)
+	return g
+}
+
+// G1AffineSlice takes an empty slice of G1 points. Appends within capacity
+// stay in the pooled backing array; if it grows past that, the original
+// array is still what goes back to the pool.
+func (t *Txn) G1AffineSlice(capacity int) []bls12381.G1Affine {
+	s := t.pool.GetG1AffineSlice(capacity)
+	t.g1Slices = append(t.g1Slices, s)
+	t.pool.outstanding.Add(1)
+	return s
+}
+
+// G2AffineSlice takes an empty slice of G2 points
+func (t *Txn) G2AffineSlice(capacity int) []bls12381.G2Affine {
+	s := t.pool.GetG2AffineSlice(capacity)
+	t.g2Slices = append(t.g2Slices, s)
+	t.pool.outstanding.Add(1)
+	return s
+}
+
+// ScalarSlice takes an empty slice of scalars
+func (t *Txn) ScalarSlice(capacity int) []*big.Int {
+	s := t.pool.GetScalarSlice(capacity)
+	t.scalarSlices = append(t.scalarSlices, s)
+	t.pool.outstanding.Add(1)
+	return s
+}
+
+// DisclosedMsgMap takes an empty map for disclosed messages
+func (t *Txn) DisclosedMsgMap() map[int]*big.Int {
+	m := t.pool.GetDisclosedMsgMap()
+	t.disclosedMaps = append(t.disclosedMaps, m)
+	t.pool.outstanding.Add(1)
+	return m
+}
+
+// Keep releases m from the transaction, so End leaves it alone. Whoever
+// holds it then returns it with PutDisclosedMsgMap.
+func (t *Txn) Keep(m map[int]*big.Int) {
+	ptr := reflect.ValueOf(m).UnsafePointer()
+	for i, held := range t.disclosedMaps {
+		if reflect.ValueOf(held).UnsafePointer() == ptr {
+			t.disclosedMaps = append(t.disclosedMaps[:i], t.disclosedMaps[i+1:]...)
+			t.pool.outstanding.Add(-1)
+			return
+		}
+	}
+}
+
+// End returns every object the transaction still holds to the pool
+func (t *Txn) End() {
+	p := t.pool
+	if p == nil {
+		return
+	}
+
+	n := len(t.bigInts) + len(t.g1Jacs) + len(t.g2Jacs) + len(t.g1Slices) +
+		len(t.g2Slices) + len(t.scalarSlices) + len(t.disclosedMaps)
+
+	for i, v := range t.bigInts {
+		p.PutBigInt(v)
+		t.bigInts[i] = nil
+	}
+	for i, v := range t.g1Jacs {
+		p.PutG1Jac(v)
+		t.g1Jacs[i] = nil
+	}
+	for i, v := range t.g2Jacs {
+		p.PutG2Jac(v)
+		t.g2Jacs[i] = nil
+	}
+	for i, v := range t.g1Slices {
+		p.PutG1AffineSlice(v)
+		t.g1Slices[i] = nil
+	}
+	for i, v := range t.g2Slices {
+		p.PutG2AffineSlice(v)
+		t.g2Slices[i] = nil
+	}
+	for i, v := range t.scalarSlices {
+		p.PutScalarSlice(v)
+		t.scalarSlices[i] = nil
+	}
+	for i, v := range t.disclosedMaps {
+		p.PutDisclosedMsgMap(v)
+		t.disclosedMaps[i] = nil
+	}
+	p.outstanding.Add(-int64(n))
+
+	t.pool = nil
+	t.bigInts = t.bigInts[:0]
+	t.g1Jacs = t.g1Jacs[:0]
+	t.g2Jacs = t.g2Jacs[:0]
+	t.g1Slices = t.g1Slices[:0]
+	t.g2Slices = t.g2Slices[:0]
+	t.scalarSlices = t.scalarSlices[:0]
+	t.disclosedMaps = t.disclosedMaps[:0]
+	txnPool.Put(t)
+}
@@ -0,0 +1,89 @@
+package bbs
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+)
+
+func TestTxnEnd(t *testing.T) {
+	pool := NewObjectPool()
+
+	txn := pool.Begin()
+	txn.BigInt().SetInt64(7)
+	txn.G1Jac()
+	txn.G2Jac()
+	txn.G1AffineSlice(4)
+	txn.G2AffineSlice(2)
+	txn.ScalarSlice(2)
+	kept := txn.DisclosedMsgMap()
+	txn.DisclosedMsgMap()
+
+	if n := pool.Outstanding(); n != 8 {
+		t.Fatalf("Expected 8 outstanding objects, got %d", n)
+	}
+
+	txn.Keep(kept)
+	txn.End()
+	if n := pool.Outstanding(); n != 0 {
+		t.Fatalf("Expected no outstanding objects after End, got %d", n)
+	}
+
+	// A second End is harmless
+	txn.End()
+
+	// Pooled big.Ints come back zeroed
+	txn = pool.Begin()
+	defer txn.End()
+	if v := txn.BigInt(); v.Sign() != 0 {
+		t.Fatalf("Expected a zeroed big.Int, got %v", v)
+	}
+}
+
+func TestPoolingErrorPathsReleaseObjects(t *testing.T) {
+	keyPair, err := GenerateKeyPair(3, rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key pair: %v", err)
+	}
+	messages := []*big.Int{big.NewInt(1), big.NewInt(2), big.NewInt(3)}
+
+	pool := NewObjectPool()
+	sm := NewSignatureManager(pool, 0)
+	pm := NewProofManager(pool, 0, 0)
+
+	signature, err := sm.SignWithPooling(keyPair.PrivateKey, keyPair.PublicKey, messages, nil)
+	if err != nil {
+		t.Fatalf("SignWithPooling failed: %v", err)
+	}
+
+	// Out-of-range disclosed index
+	if _, _, err := pm.CreateProofWithPooling(keyPair.PublicKey, signature, messages, []int{5}, nil); err == nil {
+		t.Fatalf("Expected an error for an invalid index")
+	}
+
+	proof, disclosed, err := pm.CreateProofWithPooling(keyPair.PublicKey, signature, messages, []int{0}, nil)
+	if err != nil {
+		t.Fatalf("CreateProofWithPooling failed: %v", err)
+	}
+	defer PutDisclosedMsgMap(disclosed)
+
+	// Failing pairing and challenge checks
+	if err := pm.VerifyProofWithPooling(keyPair.PublicKey, proof, disclosed, []byte("other header")); err == nil {
+		t.Fatalf("Expected verification under another header to fail")
+	}
+	if _, _, err := pm.ExtendProofWithPooling(proof, disclosed, []int{9}, nil, keyPair.PublicKey, signature, nil); err == nil {
+		t.Fatalf("Expected an error extending with an invalid index")
+	}
+
+	// A failing batch
+	bad := *signature
+	bad.E = big.NewInt(1)
+	pks := []*PublicKey{keyPair.PublicKey, keyPair.PublicKey}
+	if err := sm.BatchVerifySignatures(pks, []*Signature{signature, &bad}, [][]*big.Int{messages, messages}, nil); err == nil {
+		t.Fatalf("Expected batch verification to fail")
+	}
+
+	if n := pool.Outstanding(); n != 0 {
+		t.Fatalf("Expected every pooled object back after error returns, got %d outstanding", n)
+	}
+}
@@ -0,0 +1,12 @@
+// Package poolvar publishes the statistics of a bbs.ObjectPool through
+// expvar, so they appear at /debug/vars next to the runtime memory stats.
+//
+// It is a separate package because importing expvar registers its handler
+// on http.DefaultServeMux, which the bbs package must not do on its own:
+//
+//	bbs.InstrumentPool(30 * time.Second)
+//	poolvar.Publish("bbs_pool", bbs.DefaultObjectPool())
+//	http.ListenAndServe("localhost:6060", nil) // GET /debug/vars
+//
+// Stability: experimental
+package poolvar
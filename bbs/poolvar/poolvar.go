@@ -0,0 +1,20 @@
+package poolvar
+
+import (
+	"expvar"
+
+	"github.com/anupsv/bbsplus-signatures/bbs"
+)
+
+// Var returns an expvar.Var reporting pool.Stats as JSON each time it is read
+func Var(pool *bbs.ObjectPool) expvar.Var {
+	return expvar.Func(func() any {
+		return pool.Stats()
+	})
+}
+
+// Publish publishes the statistics of pool under name. Like expvar.Publish,
+// it panics if name is already in use.
+func Publish(name string, pool *bbs.ObjectPool) {
+	expvar.Publish(name, Var(pool))
+}
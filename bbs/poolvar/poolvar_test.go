@@ -0,0 +1,28 @@
+package poolvar
+
+import (
+	"encoding/json"
+	"expvar"
+	"testing"
+
+	"github.com/anupsv/bbsplus-signatures/bbs"
+)
+
+func TestPublish(t *testing.T) {
+	pool := bbs.NewObjectPool()
+	pool.Instrument(0)
+	Publish("bbs_pool_test", pool)
+	pool.PutBigInt(pool.GetBigInt())
+
+	v := expvar.Get("bbs_pool_test")
+	if v == nil {
+		t.Fatal("Expected the pool to be published")
+	}
+	var stats bbs.PoolSnapshot
+	if err := json.Unmarshal([]byte(v.String()), &stats); err != nil {
+		t.Fatalf("Failed to decode published stats: %v", err)
+	}
+	if !stats.Enabled || stats.Types["BigInt"].Gets != 1 {
+		t.Fatalf("Unexpected published stats: %+v", stats)
+	}
+}
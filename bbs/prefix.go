@@ -0,0 +1,108 @@
+package bbs
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+
+	bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381"
+)
+
+// PrecomputedPrefix caches the part of B shared by every credential whose
+// first messages are the same, such as issuer name, schema ID and country:
+//
+//	P1 + Q2*domain + H_1*m_1 + ... + H_k*m_k
+//
+// Signing with it only multiplies the varying suffix and Q1*s. The
+// signatures are ordinary BBS+ signatures over the prefix followed by the
+// suffix. A PrecomputedPrefix is immutable and safe for concurrent use.
+type PrecomputedPrefix struct {
+	publicKey *PublicKey
+	prefix    []*big.Int
+	partial   bls12381.G1Jac
+}
+
+// NewPrecomputedPrefix precomputes the shared prefix of credentials signed
+// under publicKey with header
+func NewPrecomputedPrefix(publicKey *PublicKey, prefix []*big.Int, header []byte) (*PrecomputedPrefix, error) {
+	// Validate inputs
+	if len(prefix) > publicKey.messageCount {
+		return nil, fmt.Errorf("prefix of %d messages exceeds the key's %d: %w", len(prefix), publicKey.messageCount, ErrInvalidMessageCount)
+	}
+	for i, m := range prefix {
+		if m == nil {
+			return nil, fmt.Errorf("missing prefix message at index %d", i)
+		}
+	}
+
+	p := &PrecomputedPrefix{
+		publicKey: publicKey,
+		prefix:    make([]*big.Int, len(prefix)),
+	}
+
+	// Q2*domain + H_1*m_1 + ... + H_k*m_k
+	points := []bls12381.G1Affine{publicKey.h[1]}
+	scalars := []Scalar{ScalarFromBigInt(CalculateDomain(publicKey, header))}
+	for i, m := range prefix {
+		p.prefix[i] = new(big.Int).Set(m)
+		points = append(points, publicKey.h[i+2]) // +2 because H[0] is Q1, H[1] is Q2
+		scalars = append(scalars, ScalarFromBigInt(m))
+	}
+
+	// Add P1
+	p.partial = scalarMulSumG1(points, scalars)
+	p.partial.AddMixed(&publicKey.g1)
+
+	return p, nil
+}
+
+// Len returns the number of prefix messages
+func (p *PrecomputedPrefix) Len() int {
+	return len(p.prefix)
+}
+
+// Messages returns the full message list of a credential, the prefix
+// followed by suffix, as Verify and CreateProof take it
+func (p *PrecomputedPrefix) Messages(suffix []*big.Int) []*big.Int {
+	messages := make([]*big.Int, 0, len(p.prefix)+len(suffix))
+	for _, m := range p.prefix {
+		messages = append(messages, new(big.Int).Set(m))
+	}
+	return append(messages, suffix...)
+}
+
+// Sign signs the prefix followed by suffix, like Sign with the prefix's key
+// and header
+func (p *PrecomputedPrefix) Sign(sk *PrivateKey, suffix []*big.Int) (*Signature, error) {
+	// Validate inputs
+	if len(p.prefix)+len(suffix) != p.publicKey.messageCount {
+		return nil, ErrInvalidMessageCount
+	}
+
+	// Generate random values e, s from Zp
+	e, err := RandomScalar(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate random value e: %w", err)
+	}
+
+	s, err := RandomScalar(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate random value s: %w", err)
+	}
+
+	// Complete B with Q1*s and the suffix
+	points := []bls12381.G1Affine{p.publicKey.h[0]}
+	scalars := []Scalar{ScalarFromBigInt(s)}
+	for i, m := range suffix {
+		if m == nil {
+			return nil, fmt.Errorf("missing message at index %d", len(p.prefix)+i)
+		}
+		points = append(points, p.publicKey.h[len(p.prefix)+i+2]) // +2 for Q1, Q2
+		scalars = append(scalars, ScalarFromBigInt(m))
+	}
+
+	BJac := scalarMulSumG1(points, scalars)
+	BJac.AddAssign(&p.partial)
+
+	return signB(sk, g1JacToAffine(BJac), e, s)
+}
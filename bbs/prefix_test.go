@@ -0,0 +1,142 @@
+package bbs
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"testing"
+)
+
+// prefixSchema is a university credential: issuer, schema ID, country and
+// credential type are shared, the remaining attributes vary per holder
+var prefixSchema = struct {
+	prefix []string
+	suffix []string
+}{
+	prefix: []string{"did:example:university", "https://example.edu/schemas/degree/v2", "NL", "UniversityDegreeCredential"},
+	suffix: []string{"Alice", "Smith", "1990-01-01", "MSc", "Computer Science", "2024-06-30", "cum laude", "S1234567"},
+}
+
+// prefixFixture returns a key for the schema with its encoded prefix and suffix
+func prefixFixture(tb testing.TB) (*KeyPair, []*big.Int, []*big.Int) {
+	tb.Helper()
+
+	keyPair := newTestKeyPair(tb, len(prefixSchema.prefix)+len(prefixSchema.suffix))
+
+	encode := func(values []string) []*big.Int {
+		messages := make([]*big.Int, len(values))
+		for i, v := range values {
+			messages[i] = MessageToFieldElement([]byte(v))
+		}
+		return messages
+	}
+	return keyPair, encode(prefixSchema.prefix), encode(prefixSchema.suffix)
+}
+
+func TestPrecomputedPrefix(t *testing.T) {
+	keyPair, prefix, suffix := prefixFixture(t)
+	header := []byte("degree credentials")
+
+	pp, err := NewPrecomputedPrefix(keyPair.PublicKey, prefix, header)
+	if err != nil {
+		t.Fatalf("NewPrecomputedPrefix failed: %v", err)
+	}
+	if pp.Len() != len(prefix) {
+		t.Fatalf("Len = %d, want %d", pp.Len(), len(prefix))
+	}
+
+	signature, err := pp.Sign(keyPair.PrivateKey, suffix)
+	if err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+
+	// The signature is an ordinary signature over prefix || suffix
+	messages := pp.Messages(suffix)
+	if err := Verify(keyPair.PublicKey, signature, messages, header); err != nil {
+		t.Fatalf("Verify failed: %v", err)
+	}
+	if err := Verify(keyPair.PublicKey, signature, messages, []byte("other header")); err == nil {
+		t.Fatalf("Signature verified under another header")
+	}
+
+	proof, disclosed, err := CreateProof(keyPair.PublicKey, signature, messages, []int{0, 1, 7}, header)
+	if err != nil {
+		t.Fatalf("CreateProof failed: %v", err)
+	}
+	if err := VerifyProof(keyPair.PublicKey, proof, disclosed, header); err != nil {
+		t.Fatalf("VerifyProof failed: %v", err)
+	}
+
+	// Changing the caller's prefix afterwards does not affect the cache
+	prefix[0].SetInt64(1)
+	signature, err = pp.Sign(keyPair.PrivateKey, suffix)
+	if err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+	if err := Verify(keyPair.PublicKey, signature, messages, header); err != nil {
+		t.Fatalf("Verify after modifying the prefix failed: %v", err)
+	}
+
+	// An empty prefix signs the whole message list
+	empty, err := NewPrecomputedPrefix(keyPair.PublicKey, nil, header)
+	if err != nil {
+		t.Fatalf("NewPrecomputedPrefix failed: %v", err)
+	}
+	signature, err = empty.Sign(keyPair.PrivateKey, messages)
+	if err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+	if err := Verify(keyPair.PublicKey, signature, messages, header); err != nil {
+		t.Fatalf("Verify with an empty prefix failed: %v", err)
+	}
+}
+
+func TestPrecomputedPrefixErrors(t *testing.T) {
+	keyPair, prefix, suffix := prefixFixture(t)
+
+	tooLong := append(append([]*big.Int{}, prefix...), suffix...)
+	if _, err := NewPrecomputedPrefix(keyPair.PublicKey, append(tooLong, big.NewInt(1)), nil); !errors.Is(err, ErrInvalidMessageCount) {
+		t.Fatalf("Expected ErrInvalidMessageCount, got %v", err)
+	}
+	if _, err := NewPrecomputedPrefix(keyPair.PublicKey, []*big.Int{nil}, nil); err == nil {
+		t.Fatalf("Expected an error for a nil prefix message")
+	}
+
+	pp, err := NewPrecomputedPrefix(keyPair.PublicKey, prefix, nil)
+	if err != nil {
+		t.Fatalf("NewPrecomputedPrefix failed: %v", err)
+	}
+	if _, err := pp.Sign(keyPair.PrivateKey, suffix[1:]); !errors.Is(err, ErrInvalidMessageCount) {
+		t.Fatalf("Expected ErrInvalidMessageCount, got %v", err)
+	}
+	short := append([]*big.Int{nil}, suffix[1:]...)
+	if _, err := pp.Sign(keyPair.PrivateKey, short); err == nil {
+		t.Fatalf("Expected an error for a nil suffix message")
+	}
+}
+
+func BenchmarkPrecomputedPrefix(b *testing.B) {
+	keyPair, prefix, suffix := prefixFixture(b)
+	header := []byte("degree credentials")
+	messages := append(append([]*big.Int{}, prefix...), suffix...)
+
+	b.Run(fmt.Sprintf("Sign/%d", len(messages)), func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := Sign(keyPair.PrivateKey, keyPair.PublicKey, messages, header); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	pp, err := NewPrecomputedPrefix(keyPair.PublicKey, prefix, header)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Run(fmt.Sprintf("PrefixSign/%d+%d", len(prefix), len(suffix)), func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := pp.Sign(keyPair.PrivateKey, suffix); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -21,6 +21,8 @@ type MessagePreprocessor struct {
 	IntegerConversion    string // "native" or "string" depending on how integers should be encoded
 	FloatPrecision       int // Number of decimal places to retain for floating point numbers
 	EnableMerkleMode     bool // Whether to use Merkle tree mode for large datasets
+	EnableListMode       bool // Whether to encode list attributes as fixed-size slot vectors
+	ListSlots            int // Number of slots list attributes are padded to in list mode
 }
 
 // NewMessagePreprocessor creates a new preprocessor with default settings
@@ -32,11 +34,24 @@ func NewMessagePreprocessor() *MessagePreprocessor {
 		IntegerConversion:   "native",
 		FloatPrecision:      6,
 		EnableMerkleMode:    false,
+		EnableListMode:      false,
+		ListSlots:           8,
 	}
 }
 
 // PreprocessJSON converts a JSON message into a fieldElement suitable for signing
 func (mp *MessagePreprocessor) PreprocessJSON(jsonData []byte) (*big.Int, error) {
+	canonicalJSON, err := mp.canonicalJSON(jsonData)
+	if err != nil {
+		return nil, err
+	}
+	
+	// Hash the canonical form and convert to field element
+	return MessageToFieldElement(canonicalJSON), nil
+}
+
+// canonicalJSON re-encodes a JSON message in canonical form
+func (mp *MessagePreprocessor) canonicalJSON(jsonData []byte) ([]byte, error) {
 	// Parse the JSON into a generic structure
 	var data interface{}
 	if err := json.Unmarshal(jsonData, &data); err != nil {
@@ -55,8 +70,7 @@ func (mp *MessagePreprocessor) PreprocessJSON(jsonData []byte) (*big.Int, error)
 		return nil, fmt.Errorf("failed to re-encode JSON: %w", err)
 	}
 	
-	// Hash the canonical form and convert to field element
-	return MessageToFieldElement(canonicalJSON), nil
+	return canonicalJSON, nil
 }
 
 // PreprocessXML converts an XML message into a field element suitable for signing
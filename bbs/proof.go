@@ -4,9 +4,9 @@ package bbs
 import (
 	"crypto/rand"
 	"fmt"
+	"io"
 	"math/big"
 	"sort"
-	"sync"
 
 	bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381"
 )
@@ -19,171 +19,339 @@ func CreateProof(
 	messages []*big.Int,
 	disclosedIndices []int,
 	header []byte,
+) (*ProofOfKnowledge, map[int]*big.Int, error) {
+	return CreateProofWithPresentationHeader(publicKey, signature, messages, disclosedIndices, header, nil)
+}
+
+// CreateProofWithPresentationHeader creates a proof like CreateProof whose
+// challenge also covers presentationHeader, such as a verifier nonce. The
+// proof only verifies with the same presentation header.
+func CreateProofWithPresentationHeader(
+	publicKey *PublicKey,
+	signature *Signature,
+	messages []*big.Int,
+	disclosedIndices []int,
+	header []byte,
+	presentationHeader []byte,
+) (*ProofOfKnowledge, map[int]*big.Int, error) {
+	return CreateProofWithRNG(publicKey, signature, messages, disclosedIndices, header, presentationHeader, rand.Reader)
+}
+
+// CreateProofWithRNG is CreateProofWithPresentationHeader drawing the proof
+// randomness from rng. A seeded rng reproduces a proof exactly, for test
+// vectors and fixtures; anything else must pass a cryptographically secure
+// rng, since randomness reused across two proofs reveals the hidden
+// messages.
+func CreateProofWithRNG(
+	publicKey *PublicKey,
+	signature *Signature,
+	messages []*big.Int,
+	disclosedIndices []int,
+	header []byte,
+	presentationHeader []byte,
+	rng io.Reader,
 ) (*ProofOfKnowledge, map[int]*big.Int, error) {
 	// Validate inputs
-	if len(messages) != publicKey.MessageCount {
+	if len(messages) != publicKey.messageCount {
 		return nil, nil, ErrInvalidMessageCount
 	}
-	
-	// Create a map for faster lookup of disclosed indices
-	disclosedMap := make(map[int]bool)
-	for _, idx := range disclosedIndices {
-		disclosedMap[idx] = true
+
+	disclosedMessages, err := selectDisclosed(messages, disclosedIndices)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Calculate domain value
+	domain := CalculateDomain(publicKey, header)
+
+	proof, err := deriveProofWithRNG(publicKey, signature, messages, disclosedMessages, domain, presentationHeader, rng)
+	if err != nil {
+		return nil, nil, err
 	}
-	
-	// Create a map of disclosed messages
+
+	return proof, disclosedMessages, nil
+}
+
+// selectDisclosed maps each disclosed index to its message
+func selectDisclosed(messages []*big.Int, disclosedIndices []int) (map[int]*big.Int, error) {
 	disclosedMessages := make(map[int]*big.Int)
 	for _, idx := range disclosedIndices {
 		if idx < 0 || idx >= len(messages) {
-			return nil, nil, fmt.Errorf("invalid disclosed index: %d", idx)
+			return nil, fmt.Errorf("invalid disclosed index: %d", idx)
+		}
+		if _, dup := disclosedMessages[idx]; dup {
+			return nil, fmt.Errorf("duplicate disclosed index: %d", idx)
 		}
 		disclosedMessages[idx] = messages[idx]
 	}
-	
-	// Calculate domain - use it in later operations
-	_ = CalculateDomain(publicKey, header)
+	return disclosedMessages, nil
+}
+
+// deriveProof computes a proof of knowledge of signature over messages that
+// discloses the entries of disclosedMessages
+//
+// With B = P1 + Q1*s + Q2*domain + H_1*m_1 + ... + H_L*m_L and A = B/(x+e),
+// the prover picks random r1, r2 and publishes
+//
+//	D      = B * r2
+//	A'     = A * (r1 * r2)
+//	A-bar  = D * r1 - A' * e      (which equals A' * x)
+//
+// together with a Schnorr proof of knowledge of (e, r1, r3 = 1/r2, s, hidden m_j) for
+//
+//	A-bar - D*r1 + A'*e = 0
+//	P1 + Q2*domain + sum(disclosed H_i*m_i) = D*r3 - Q1*s - sum(hidden H_j*m_j)
+func deriveProof(
+	publicKey *PublicKey,
+	signature *Signature,
+	messages []*big.Int,
+	disclosedMessages map[int]*big.Int,
+	domain *big.Int,
+	presentationHeader []byte,
+) (*ProofOfKnowledge, error) {
+	return deriveProofWithRNG(publicKey, signature, messages, disclosedMessages, domain, presentationHeader, rand.Reader)
+}
 
-	// Generate randomness r for signature blinding
-	r, err := RandomScalar(rand.Reader)
+// deriveProofWithRNG is deriveProof drawing the proof randomness from rng
+func deriveProofWithRNG(
+	publicKey *PublicKey,
+	signature *Signature,
+	messages []*big.Int,
+	disclosedMessages map[int]*big.Int,
+	domain *big.Int,
+	presentationHeader []byte,
+	rng io.Reader,
+) (*ProofOfKnowledge, error) {
+	witness, err := commitProof(publicKey, signature, messages, disclosedMessages, domain, rng)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to generate random value: %w", err)
-	}
-
-	// Compute A' = A * g1^r
-	APrimeJac := bls12381.G1Jac{}
-	APrimeJac.FromAffine(&signature.A)
-	
-	g1rJac := bls12381.G1Jac{}
-	g1rJac.FromAffine(&publicKey.G1)
-	g1rJac.ScalarMultiplication(&g1rJac, r)
-	APrimeJac.AddAssign(&g1rJac)
-	
-	// Convert to affine
-	APrime := g1JacToAffine(APrimeJac)
-	
-	// Compute A-bar = A' * B^r where:
-	// B = P1 + Q1*s + Q2*domain + H_1*m_1 + ... + H_L*m_L
-	// We'll focus on the messages that are NOT being disclosed,
-	// as these are the ones that need to be blinded with randomness
-	
-	// Initialize A-bar with A'
-	ABarJac := bls12381.G1Jac{}
-	ABarJac.FromAffine(&APrime)
-	
-	// Compute blinded messages contribution to A-bar
-	for i := 0; i < len(messages); i++ {
-		if disclosedMap[i] {
-			continue // Skip disclosed messages
-		}
-		
-		// Compute h_i^{m_i * r} for hidden messages
-		msg := messages[i]
-		mr := new(big.Int).Mul(msg, r)
-		mr.Mod(mr, Order)
-		
-		himrJac := bls12381.G1Jac{}
-		himrJac.FromAffine(&publicKey.H[i+2]) // +2 for Q1, Q2
-		himrJac.ScalarMultiplication(&himrJac, mr)
-		ABarJac.AddAssign(&himrJac)
-	}
-	
-	// Convert to affine
-	ABar := g1JacToAffine(ABarJac)
-
-	// Generate random blinding factors
-	eBlind, err := RandomScalar(rand.Reader)
+		return nil, err
+	}
+	defer witness.wipe()
+
+	// Compute the Fiat-Shamir challenge c
+	cm := &witness.commitment
+	c := computeProofChallenge(cm.APrime, cm.ABar, cm.D, cm.T1, cm.T2, sortedKeys(disclosedMessages), disclosedMessages, domain, presentationHeader)
+
+	return witness.respond(c), nil
+}
+
+// proofWitness is the prover state between committing to a proof and
+// answering its challenge. The blinding factors must never be used to answer
+// two different challenges, as that reveals the hidden messages and e.
+type proofWitness struct {
+	commitment ProofCommitment
+
+	// Secrets
+	e, s     Scalar
+	r1, r3   Scalar
+	messages map[int]Scalar
+
+	// Blinding factors
+	eBlind, r1Blind, r3Blind, sBlind Scalar
+	mBlind                           map[int]Scalar
+}
+
+// commitProof blinds the signature with randomness from rng and computes the
+// Schnorr commitments T1 and T2, the first move of the proof
+func commitProof(
+	publicKey *PublicKey,
+	signature *Signature,
+	messages []*big.Int,
+	disclosedMessages map[int]*big.Int,
+	domain *big.Int,
+	rng io.Reader,
+) (*proofWitness, error) {
+	// Recompute B from the signature and all messages
+	B := computeB(publicKey, signature.S, domain, messages)
+
+	witness, err := blindProof(signature, B, messages, disclosedMessages, rng)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to generate blinding: %w", err)
+		return nil, err
 	}
 
-	sBlind, err := RandomScalar(rand.Reader)
+	// Compute T2 = D * r3Blind + Q1 * sBlind + sum(H_j * mBlind_j) for undisclosed j
+	t2Points, t2Scalars := witness.t2Terms(publicKey)
+	witness.commitment.T2 = g1JacToAffine(scalarMulSumG1(t2Points, t2Scalars))
+
+	return witness, nil
+}
+
+// blindProof draws the proof randomness from rng for a signature whose B is
+// already known and computes every commitment except T2
+func blindProof(
+	signature *Signature,
+	B bls12381.G1Affine,
+	messages []*big.Int,
+	disclosedMessages map[int]*big.Int,
+	rng io.Reader,
+) (*proofWitness, error) {
+	w, r2, err := drawProofBlinding(signature, messages, disclosedMessages, rng)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to generate blinding: %w", err)
+		return nil, err
 	}
-	
-	// Generate blinding factor for domain
-	domainBlind, err := RandomScalar(rand.Reader)
+	defer r2.SetZero()
+
+	w.blindD(B, &r2)
+	w.blindABar(signature, &r2)
+	return w, nil
+}
+
+// drawProofBlinding draws r1, r2 and the blinding factors of the Schnorr
+// commitments from rng. The caller must zero r2 once D and A' are computed.
+func drawProofBlinding(
+	signature *Signature,
+	messages []*big.Int,
+	disclosedMessages map[int]*big.Int,
+	rng io.Reader,
+) (*proofWitness, Scalar, error) {
+	w := &proofWitness{
+		e:        ScalarFromBigInt(signature.E),
+		s:        ScalarFromBigInt(signature.S),
+		messages: make(map[int]Scalar),
+		mBlind:   make(map[int]Scalar),
+	}
+
+	// Generate randomness r1, r2 for signature blinding
+	r1, err := newRandomNonZeroScalar(rng)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to generate domain blinding: %w", err)
+		return nil, Scalar{}, fmt.Errorf("failed to generate random value: %w", err)
+	}
+
+	r2, err := newRandomNonZeroScalar(rng)
+	if err != nil {
+		return nil, Scalar{}, fmt.Errorf("failed to generate random value: %w", err)
+	}
+	w.r1 = r1
+
+	// Generate random blinding factors for the Schnorr commitments
+	for _, blind := range []*Scalar{&w.eBlind, &w.r1Blind, &w.r3Blind, &w.sBlind} {
+		if *blind, err = NewRandomScalar(rng); err != nil {
+			r2.SetZero()
+			return nil, Scalar{}, fmt.Errorf("failed to generate blinding: %w", err)
+		}
 	}
 
 	// Create blinding factors for undisclosed messages
-	mBlind := make(map[int]*big.Int)
 	for i := 0; i < len(messages); i++ {
-		if !disclosedMap[i] {
-			mBlind[i], err = RandomScalar(rand.Reader)
+		if _, disclosed := disclosedMessages[i]; !disclosed {
+			mBlind, err := NewRandomScalar(rng)
 			if err != nil {
-				return nil, nil, fmt.Errorf("failed to generate blinding: %w", err)
+				r2.SetZero()
+				return nil, Scalar{}, fmt.Errorf("failed to generate blinding: %w", err)
 			}
+			w.mBlind[i] = mBlind
+			w.messages[i] = ScalarFromBigInt(messages[i])
 		}
 	}
 
-	// Compute commitment D = Q1^sBlind * Q2^domainBlind * ∏(H_i^mBlind_i) for all undisclosed i
-
-	// Start with Q1^sBlind
-	DJac := bls12381.G1Jac{}
-	q1sBlindJac := bls12381.G1Jac{}
-	q1sBlindJac.FromAffine(&publicKey.H[0])
-	q1sBlindJac.ScalarMultiplication(&q1sBlindJac, sBlind)
-	DJac.AddAssign(&q1sBlindJac)
-	
-	// Add Q2^domainBlind
-	q2dBlindJac := bls12381.G1Jac{}
-	q2dBlindJac.FromAffine(&publicKey.H[1])
-	q2dBlindJac.ScalarMultiplication(&q2dBlindJac, domainBlind)
-	DJac.AddAssign(&q2dBlindJac)
-	
-	// Add H_i^mBlind_i for each undisclosed message
-	for i := 0; i < len(messages); i++ {
-		if !disclosedMap[i] {
-			hiJac := bls12381.G1Jac{}
-			hiJac.FromAffine(&publicKey.H[i+2]) // +2 for Q1, Q2
-			hiJac.ScalarMultiplication(&hiJac, mBlind[i])
-			DJac.AddAssign(&hiJac)
-		}
-	}
-	
-	// Convert to affine
-	D := g1JacToAffine(DJac)
-	
-	// Compute the Fiat-Shamir challenge c
-	c := ComputeProofChallenge(APrime, ABar, D, disclosedIndices, disclosedMessages)
-	
-	// Compute e^ = e*c + eBlind
-	eHat := new(big.Int).Mul(signature.E, c)
-	eHat.Add(eHat, eBlind)
-	eHat.Mod(eHat, Order)
-	
-	// Compute s^ = s*c + sBlind
-	sHat := new(big.Int).Mul(signature.S, c)
-	sHat.Add(sHat, sBlind)
-	sHat.Mod(sHat, Order)
-	
-	// Compute m_i^ = m_i*c + mBlind_i for each undisclosed message
+	return w, r2, nil
+}
+
+// blindD computes D = B * r2 and r3 = 1/r2
+func (w *proofWitness) blindD(B bls12381.G1Affine, r2 *Scalar) {
+	w.commitment.D = g1JacToAffine(scalarMulSumG1([]bls12381.G1Affine{B}, []Scalar{*r2}))
+	w.r3.Inverse(r2)
+}
+
+// blindABar computes A', A-bar and T1 once D is known
+func (w *proofWitness) blindABar(signature *Signature, r2 *Scalar) {
+	D := w.commitment.D
+
+	// Compute A' = A * (r1 * r2)
+	var r1r2 Scalar
+	defer r1r2.SetZero()
+	r1r2.Mul(&w.r1, r2)
+	APrime := g1JacToAffine(scalarMulSumG1([]bls12381.G1Affine{signature.A}, []Scalar{r1r2}))
+
+	// Compute A-bar = D * r1 - A' * e
+	var negE Scalar
+	defer negE.SetZero()
+	negE.Neg(&w.e)
+	ABar := g1JacToAffine(scalarMulSumG1(
+		[]bls12381.G1Affine{D, APrime},
+		[]Scalar{w.r1, negE},
+	))
+
+	// Compute T1 = A' * eBlind + D * r1Blind
+	T1 := g1JacToAffine(scalarMulSumG1(
+		[]bls12381.G1Affine{APrime, D},
+		[]Scalar{w.eBlind, w.r1Blind},
+	))
+
+	w.commitment.APrime = APrime
+	w.commitment.ABar = ABar
+	w.commitment.T1 = T1
+}
+
+// t2Terms returns the points and scalars whose sum is the commitment T2
+func (w *proofWitness) t2Terms(publicKey *PublicKey) ([]bls12381.G1Affine, []Scalar) {
+	points := []bls12381.G1Affine{w.commitment.D, publicKey.h[0]}
+	scalars := []Scalar{w.r3Blind, w.sBlind}
+	for _, idx := range sortedKeys(w.mBlind) {
+		points = append(points, publicKey.h[idx+2]) // +2 for Q1, Q2
+		scalars = append(scalars, w.mBlind[idx])
+	}
+	return points, scalars
+}
+
+// respond computes the Schnorr responses for challenge c, the third move of
+// the proof
+func (w *proofWitness) respond(c *big.Int) *ProofOfKnowledge {
+	challenge := ScalarFromBigInt(c)
+	var t Scalar
+	defer t.SetZero()
+
+	// Compute e^ = eBlind + e*c
+	var eHat Scalar
+	eHat.Add(&w.eBlind, t.Mul(&w.e, &challenge))
+
+	// Compute r1^ = r1Blind - r1*c
+	var r1Hat Scalar
+	r1Hat.Sub(&w.r1Blind, t.Mul(&w.r1, &challenge))
+
+	// Compute r3^ = r3Blind - r3*c where r3 = 1/r2
+	var r3Hat Scalar
+	r3Hat.Sub(&w.r3Blind, t.Mul(&w.r3, &challenge))
+
+	// Compute s^ = sBlind + s*c
+	var sHat Scalar
+	sHat.Add(&w.sBlind, t.Mul(&w.s, &challenge))
+
+	// Compute m_j^ = mBlind_j + m_j*c for each undisclosed message
 	mHat := make(map[int]*big.Int)
-	for i := 0; i < len(messages); i++ {
-		if !disclosedMap[i] {
-			mHat[i] = new(big.Int).Mul(messages[i], c)
-			mHat[i].Add(mHat[i], mBlind[i])
-			mHat[i].Mod(mHat[i], Order)
-		}
+	for idx, blind := range w.mBlind {
+		m := w.messages[idx]
+		var hat Scalar
+		hat.Add(&blind, t.Mul(&m, &challenge))
+		mHat[idx] = hat.BigInt()
 	}
-	
-	// Compute r^ = r*c + rBlind
-	// For our implementation, we don't need r^ as it's used for signature binding in the original BBS scheme
-	
-	// Create the final proof
-	proof := &ProofOfKnowledge{
-		APrime: APrime,
-		ABar:   ABar,
-		D:      D,
-		C:      c,
-		EHat:   eHat,
-		SHat:   sHat,
+
+	return &ProofOfKnowledge{
+		APrime: w.commitment.APrime,
+		ABar:   w.commitment.ABar,
+		D:      w.commitment.D,
+		C:      new(big.Int).Set(c),
+		EHat:   eHat.BigInt(),
+		SHat:   sHat.BigInt(),
+		R1Hat:  r1Hat.BigInt(),
+		R3Hat:  r3Hat.BigInt(),
 		MHat:   mHat,
 	}
-	
-	return proof, disclosedMessages, nil
+}
+
+// wipe clears the witness secrets and blinding factors
+func (w *proofWitness) wipe() {
+	for _, x := range []*Scalar{&w.e, &w.s, &w.r1, &w.r3, &w.eBlind, &w.r1Blind, &w.r3Blind, &w.sBlind} {
+		x.SetZero()
+	}
+	for idx := range w.mBlind {
+		w.mBlind[idx] = Scalar{}
+	}
+	for idx := range w.messages {
+		w.messages[idx] = Scalar{}
+	}
+	w.messages = nil
+	w.mBlind = nil
 }
 
 // VerifyProof verifies a zero-knowledge proof of knowledge
@@ -194,544 +362,292 @@ func VerifyProof(
 	disclosedMessages map[int]*big.Int,
 	header []byte,
 ) error {
-	// Validate inputs
-	for idx := range disclosedMessages {
-		if idx < 0 || idx >= publicKey.MessageCount {
-			return fmt.Errorf("invalid disclosed message index: %d", idx)
-		}
-	}
-	
-	// Recompute the challenge to verify correct formation
-	// Get the indices for disclosed messages
-	disclosedIndices := make([]int, 0, len(disclosedMessages))
-	for idx := range disclosedMessages {
-		disclosedIndices = append(disclosedIndices, idx)
-	}
-	
-	// Sort indices for deterministic challenge computation
-	sort.Ints(disclosedIndices)
-	
-	// Compute the challenge
-	c := ComputeProofChallenge(proof.APrime, proof.ABar, proof.D, disclosedIndices, disclosedMessages)
-	
-	// Check if the computed challenge matches the one in the proof
-	if c.Cmp(proof.C) != 0 {
-		return ErrInvalidSignature
-	}
-	
+	return VerifyProofWithPresentationHeader(publicKey, proof, disclosedMessages, header, nil)
+}
+
+// VerifyProofWithPresentationHeader verifies a proof created by
+// CreateProofWithPresentationHeader
+func VerifyProofWithPresentationHeader(
+	publicKey *PublicKey,
+	proof *ProofOfKnowledge,
+	disclosedMessages map[int]*big.Int,
+	header []byte,
+	presentationHeader []byte,
+) error {
 	// Calculate domain value
 	domain := CalculateDomain(publicKey, header)
-	
-	// Prepare points and scalars for MultiScalarMul
-	// We need to compute: g1b = P1 + Q1*S^ + Q2*domain + ∑(H_i*m_i) - D*c
-	
-	// This is used for e(B, P2) = e(A', W)*e(A', P2)^e^
-	// Start with P1 (g1)
-	points := []bls12381.G1Affine{publicKey.G1}
-	scalars := []*big.Int{big.NewInt(1)}
-	
-	// Add Q1*S^
-	points = append(points, publicKey.H[0])
-	scalars = append(scalars, proof.SHat)
-	
-	// Add Q2*domain
-	points = append(points, publicKey.H[1])
-	scalars = append(scalars, domain)
-	
-	// Add each H_i*m_i for disclosed messages
-	for idx, msg := range disclosedMessages {
-		// For disclosed messages, we use the disclosed value directly
-		points = append(points, publicKey.H[idx+2]) // +2 for Q1, Q2
-		scalars = append(scalars, msg)
-	}
-	
-	// Add each H_i*m_i^ for undisclosed messages
-	for idx, msgHat := range proof.MHat {
-		points = append(points, publicKey.H[idx+2]) // +2 for Q1, Q2
-		scalars = append(scalars, msgHat)
-	}
-	
-	// Subtract D*c (add D*(-c))
-	points = append(points, proof.D)
-	negC := new(big.Int).Neg(proof.C)
-	negC.Mod(negC, Order)
-	scalars = append(scalars, negC)
-	PutBigInt(negC) // We're done with this temporary value
-	
-	// Perform multi-scalar multiplication
-	g1bJac := GetG1Jac()
-	defer PutG1Jac(g1bJac)
-	
-	// Use gnark-crypto's implementation for MSM
-	resultJac, err := MultiScalarMulG1(points, scalars)
-	if err != nil {
-		// Clean up before returning error
-		for _, scalar := range scalars {
-			PutBigInt(scalar)
-		}
-		return fmt.Errorf("failed multi-scalar multiplication: %w", err)
-	}
-	
-	// Set g1bJac to the result
-	*g1bJac = resultJac
-	
-	// Convert to affine
-	g1b := g1JacToAffine(*g1bJac)
-	
-	// Return temporary values to the pool
-	for _, scalar := range scalars {
-		PutBigInt(scalar)
-	}
-	
-	// Now compute T = ABar^c * D using multi-scalar multiplication
-	TPoints := GetG1AffineSlice(2)
-	defer PutG1AffineSlice(TPoints)
-	
-	TPoints = append(TPoints, proof.ABar, proof.D)
-	
-	// Create scalars (C and 1)
-	one := GetBigInt().SetInt64(1)
-	TScalars := GetScalarSlice(2)
-	defer PutScalarSlice(TScalars)
-	
-	TScalars = append(TScalars, proof.C, one)
-	
-	// Get Jacobian point from pool
-	TJac := GetG1Jac()
-	defer PutG1Jac(TJac)
-	
-	// Use our implementation for MSM
-	tResult, err := MultiScalarMulG1(TPoints, TScalars)
-	if err != nil {
-		PutBigInt(one)
-		return fmt.Errorf("failed multi-scalar multiplication: %w", err)
-	}
-	
-	// Assign the result
-	*TJac = tResult
-	
-	// Convert to affine
-	T := g1JacToAffine(*TJac)
-	
-	// Return temporary values to the pool
-	PutBigInt(one)
-	
-	// e(APrime, W) * e(g1b, -g2) * e(T, g2) = 1
-	
+
+	// Check the Schnorr part of the proof
+	if err := checkProofChallenge(publicKey, proof, disclosedMessages, domain, presentationHeader); err != nil {
+		return err
+	}
+
+	return checkProofPairing(publicKey, proof)
+}
+
+// checkProofPairing checks that A-bar = A' * x for the key behind publicKey
+func checkProofPairing(publicKey *PublicKey, proof *ProofOfKnowledge) error {
 	// Negate g2 for the second pairing
 	negG2Jac := bls12381.G2Jac{}
-	negG2Jac.FromAffine(&publicKey.G2)
+	negG2Jac.FromAffine(&publicKey.g2)
 	negG2Jac.Neg(&negG2Jac)
 	negG2 := g2JacToAffine(negG2Jac)
-	
-	// Check pairing equation: e(APrime, W) * e(g1b, -g2) * e(T, g2) = 1
-	// We use the Pair function which computes the product of pairings
+
+	// Check pairing equation: e(A', W) * e(A-bar, -g2) = 1
+	// This holds exactly when A-bar = A' * x
 	pairingResult, err := bls12381.Pair(
-		[]bls12381.G1Affine{proof.APrime, g1b, T},
-		[]bls12381.G2Affine{publicKey.W, negG2, publicKey.G2},
+		[]bls12381.G1Affine{proof.APrime, proof.ABar},
+		[]bls12381.G2Affine{publicKey.w, negG2},
 	)
 	if err != nil {
 		return ErrPairingFailed
 	}
-	
+
 	// Check if the pairing result is 1
 	if !pairingResult.IsOne() {
 		return ErrInvalidSignature
 	}
-	
+
 	return nil
 }
 
-// BatchVerifyProofs verifies multiple proofs of knowledge with selective disclosure in batch
-func BatchVerifyProofs(
-	publicKeys []*PublicKey,
-	proofs []*ProofOfKnowledge,
-	disclosedMessagesList []map[int]*big.Int,
-	headers [][]byte,
+// checkProofChallenge validates the shape of a proof and recomputes its
+// Fiat-Shamir challenge. It covers everything but the final pairing check.
+func checkProofChallenge(
+	publicKey *PublicKey,
+	proof *ProofOfKnowledge,
+	disclosedMessages map[int]*big.Int,
+	domain *big.Int,
+	presentationHeader []byte,
 ) error {
-	// Validate inputs
-	if len(publicKeys) != len(proofs) || len(proofs) != len(disclosedMessagesList) {
-		return fmt.Errorf("mismatched array lengths in batch verification")
-	}
-	
-	if len(headers) != 0 && len(headers) != len(proofs) {
-		return fmt.Errorf("headers array length does not match proofs array length")
-	}
-	
-	if len(proofs) == 0 {
-		return nil
-	}
-	
-	// If there's only one proof, use the regular verification
-	if len(proofs) == 1 {
-		return VerifyProof(publicKeys[0], proofs[0], disclosedMessagesList[0], headers[0])
-	}
-	
-	// First, verify all challenges independently with concurrent processing
-	errChan := make(chan error, len(proofs))
-	concurrencyLimit := 4 // Adjust based on environment
-	
-	// Create a semaphore for limiting concurrency
-	sem := make(chan struct{}, concurrencyLimit)
-	
-	// Use a wait group to know when all goroutines complete
-	var wg sync.WaitGroup
-	
-	for i, proof := range proofs {
-		wg.Add(1)
-		
-		// Create a closure to capture loop variables
-		go func(idx int, p *ProofOfKnowledge, disclosed map[int]*big.Int) {
-			// Acquire semaphore
-			sem <- struct{}{}
-			
-			// Release semaphore and mark as done when finished
-			defer func() {
-				<-sem
-				wg.Done()
-			}()
-			
-			// Get the indices for disclosed messages
-			disclosedIndices := make([]int, 0, len(disclosed))
-			for messageIdx := range disclosed {
-				disclosedIndices = append(disclosedIndices, messageIdx)
-			}
-			
-			// Sort indices for deterministic challenge computation
-			sort.Ints(disclosedIndices)
-			
-			// Compute the challenge
-			c := ComputeProofChallenge(
-				p.APrime, 
-				p.ABar, 
-				p.D, 
-				disclosedIndices, 
-				disclosed,
-			)
-			
-			// Check if the computed challenge matches the one in the proof
-			if c.Cmp(p.C) != 0 {
-				errChan <- fmt.Errorf("challenge verification failed for proof %d", idx)
-				return
-			}
-		}(i, proof, disclosedMessagesList[i])
-	}
-	
-	// Wait for all verifications to complete
-	wg.Wait()
-	
-	// Check if any verifications failed
-	select {
-	case err := <-errChan:
+	T1, T2, err := recomputeProofCommitments(publicKey, proof, disclosedMessages, domain)
+	if err != nil {
+		return err
+	}
+
+	// Compute the challenge
+	c := computeProofChallenge(proof.APrime, proof.ABar, proof.D, T1, T2, sortedKeys(disclosedMessages), disclosedMessages, domain, presentationHeader)
+
+	// Check if the computed challenge matches the one in the proof
+	if !ConstantTimeEq(c, proof.C) {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
+
+// recomputeProofCommitments validates the shape of a proof and recomputes
+// the Schnorr commitments T1 and T2 from its challenge and responses
+func recomputeProofCommitments(
+	publicKey *PublicKey,
+	proof *ProofOfKnowledge,
+	disclosedMessages map[int]*big.Int,
+	domain *big.Int,
+) (bls12381.G1Affine, bls12381.G1Affine, error) {
+	if err := validateProofShape(publicKey, proof, disclosedMessages); err != nil {
+		return bls12381.G1Affine{}, bls12381.G1Affine{}, err
+	}
+
+	// The proof values are public, so both sums use the variable-time
+	// multi-scalar multiplication of verification
+	scratch := getVerifyScratch()
+	defer putVerifyScratch(scratch)
+
+	c := ScalarFromBigInt(proof.C)
+	var scalar Scalar
+
+	// Recompute T1 = A-bar * c + A' * e^ + D * r1^
+	scratch.add(&proof.ABar, &c)
+	scratch.add(&proof.APrime, scalar.fromBigInt(proof.EHat))
+	scratch.add(&proof.D, scalar.fromBigInt(proof.R1Hat))
+
+	var T1Jac bls12381.G1Jac
+	scratch.sum(&T1Jac)
+	T1 := g1JacToAffine(T1Jac)
+
+	// Recompute T2 = Bv * c + D * r3^ + Q1 * s^ + sum(H_j * m_j^) for undisclosed j
+	// where Bv = P1 + Q2*domain + sum(H_i * m_i) for disclosed i. Bv * c is
+	// expanded into P1*c + Q2*(domain*c) + sum(H_i * (m_i*c)) so one sum
+	// covers T2.
+	scratch.reset()
+	scratch.add(&publicKey.g1, &c)
+	scratch.add(&publicKey.h[1], scalar.fromBigInt(domain).Mul(&scalar, &c))
+	for _, idx := range sortedKeys(disclosedMessages) {
+		scratch.add(&publicKey.h[idx+2], scalar.fromBigInt(disclosedMessages[idx]).Mul(&scalar, &c)) // +2 for Q1, Q2
+	}
+	scratch.add(&proof.D, scalar.fromBigInt(proof.R3Hat))
+	scratch.add(&publicKey.h[0], scalar.fromBigInt(proof.SHat))
+	for _, idx := range sortedKeys(proof.MHat) {
+		scratch.add(&publicKey.h[idx+2], scalar.fromBigInt(proof.MHat[idx])) // +2 for Q1, Q2
+	}
+
+	var T2Jac bls12381.G1Jac
+	scratch.sum(&T2Jac)
+	T2 := g1JacToAffine(T2Jac)
+
+	return T1, T2, nil
+}
+
+// validateProofShape checks that every message index is accounted for exactly
+// once, either as a disclosed message or as a hidden response, and that no
+// proof component is missing
+func validateProofShape(
+	publicKey *PublicKey,
+	proof *ProofOfKnowledge,
+	disclosedMessages map[int]*big.Int,
+) error {
+	if proof == nil || proof.C == nil || proof.EHat == nil || proof.SHat == nil ||
+		proof.R1Hat == nil || proof.R3Hat == nil {
+		return ErrInvalidProof
+	}
+
+	// A' at infinity would make the pairing check hold trivially
+	if proof.APrime.IsInfinity() {
+		return ErrInvalidProof
+	}
+
+	if err := DefaultLimits.CheckProof(publicKey, proof); err != nil {
 		return err
-	default:
-		// All verifications passed
-	}
-	
-	// Generate random scalars for batch verification using constant-time operations
-	batchScalars := GetScalarSlice(len(proofs))
-	defer PutScalarSlice(batchScalars)
-	
-	// Generate cryptographically strong random scalars
-	for i := range batchScalars {
-		var err error
-		batchScalars[i], err = ConstantTimeRandom(rand.Reader, Order)
-		if err != nil {
-			return fmt.Errorf("failed to generate batch scalars: %w", err)
-		}
 	}
-	
-	// Prepare points for the final pairing check
-	// Pre-allocate the arrays with the expected capacity
-	pointCapacity := len(proofs) * 2 // Each proof contributes approximately 2 points
-	g1Points := GetG1AffineSlice(pointCapacity)
-	defer PutG1AffineSlice(g1Points)
-	
-	g2Points := make([]bls12381.G2Affine, 0, pointCapacity)
-	
-	// Process each proof with memory pooling
-	for i, proof := range proofs {
-		publicKey := publicKeys[i]
-		disclosedMessages := disclosedMessagesList[i]
-		
-		// Get the domain value (using pooled big.Int)
-		var domain *big.Int
-		if i < len(headers) && headers[i] != nil {
-			domain = CalculateDomain(publicKey, headers[i])
-		} else {
-			domain = CalculateDomain(publicKey, nil)
+
+	for idx, msg := range disclosedMessages {
+		if idx < 0 || idx >= publicKey.messageCount {
+			return fmt.Errorf("invalid disclosed message index: %d", idx)
 		}
-		
-		// Multiply by batch scalar for this proof
-		batchScalar := batchScalars[i]
-		
-		// Compute the g1b point (same as in single verification)
-		// Use memory pooling for these arrays
-		pointsCount := 3 + len(disclosedMessages) // Base points + message points
-		points := GetG1AffineSlice(pointsCount)
-		scalars := GetScalarSlice(pointsCount)
-		
-		// Start with P1 * batchScalar
-		points = append(points, publicKey.G1)
-		scalars = append(scalars, batchScalar)
-		
-		// Add Q1*S^
-		points = append(points, publicKey.H[0])
-		sHatBatch := GetBigInt()
-		sHatBatch.Mul(proof.SHat, batchScalar)
-		sHatBatch.Mod(sHatBatch, Order)
-		scalars = append(scalars, sHatBatch)
-		
-		// Add Q2*domain
-		points = append(points, publicKey.H[1])
-		domainBatch := GetBigInt()
-		domainBatch.Mul(domain, batchScalar)
-		domainBatch.Mod(domainBatch, Order)
-		scalars = append(scalars, domainBatch)
-		
-		// Add each H_i*m_i for disclosed messages
-		for idx, msg := range disclosedMessages {
-			// For disclosed messages, we use the disclosed value directly
-			// Use memory pooling for intermediate values
-			msgC := GetBigInt().Mul(msg, proof.C)
-			negMsgC := GetBigInt().Neg(msgC)
-			negMsgC.Mod(negMsgC, Order)
-			negMsgCBatch := GetBigInt().Mul(negMsgC, batchScalar)
-			negMsgCBatch.Mod(negMsgCBatch, Order)
-			
-			// Add to points and scalars
-			points = append(points, publicKey.H[idx+2]) // +2 for Q1, Q2
-			scalars = append(scalars, negMsgCBatch)
-			
-			// Return temporary values to pool
-			PutBigInt(msgC)
-			PutBigInt(negMsgC)
+		if msg == nil {
+			return fmt.Errorf("missing disclosed message at index %d", idx)
 		}
-		
-		// Perform multi-scalar multiplication
-		g1bJac := GetG1Jac()
-		resultJac, err := MultiScalarMulG1(points, scalars)
-		if err != nil {
-			// Clean up before returning error
-			PutG1AffineSlice(points)
-			for _, s := range scalars {
-				PutBigInt(s)
-			}
-			PutScalarSlice(scalars)
-			PutG1Jac(g1bJac)
-			
-			return fmt.Errorf("failed multi-scalar multiplication: %w", err)
+	}
+
+	for idx, mHat := range proof.MHat {
+		if idx < 0 || idx >= publicKey.messageCount {
+			return fmt.Errorf("invalid hidden message index: %d", idx)
 		}
-		*g1bJac = resultJac
-		
-		// Convert to affine
-		g1b := g1JacToAffine(*g1bJac)
-		
-		// Return temporary values to pool
-		PutG1AffineSlice(points)
-		for _, s := range scalars {
-			PutBigInt(s)
+		if mHat == nil {
+			return ErrInvalidProof
 		}
-		PutScalarSlice(scalars)
-		PutG1Jac(g1bJac)
-		
-		// Add e(g1b, -g2) to the pairing check
-		negG2Jac := bls12381.G2Jac{}
-		negG2Jac.FromAffine(&publicKey.G2)
-		negG2Jac.Neg(&negG2Jac)
-		negG2 := g2JacToAffine(negG2Jac)
-		
-		g1Points = append(g1Points, g1b)
-		g2Points = append(g2Points, negG2)
-		
-		// Compute T = ABar^c * D using multi-scalar multiplication
-		TPoints := GetG1AffineSlice(2)
-		TPoints = append(TPoints, proof.ABar, proof.D)
-		
-		// Create scalars (C*batchScalar and batchScalar)
-		cBatch := GetBigInt().Mul(proof.C, batchScalar)
-		cBatch.Mod(cBatch, Order)
-		oneBatch := GetBigInt().Set(batchScalar)
-		
-		TScalars := GetScalarSlice(2)
-		TScalars = append(TScalars, cBatch, oneBatch)
-		
-		// Perform multi-scalar multiplication
-		TJac := GetG1Jac()
-		tjResult, err := MultiScalarMulG1(TPoints, TScalars)
-		if err != nil {
-			// Clean up before returning error
-			PutG1AffineSlice(TPoints)
-			PutBigInt(cBatch)
-			PutBigInt(oneBatch)
-			PutScalarSlice(TScalars)
-			PutG1Jac(TJac)
-			
-			return fmt.Errorf("failed multi-scalar multiplication: %w", err)
+		if _, disclosed := disclosedMessages[idx]; disclosed {
+			return fmt.Errorf("message %d is both disclosed and hidden", idx)
 		}
-		*TJac = tjResult
-		
-		// Convert to affine
-		T := g1JacToAffine(*TJac)
-		
-		// Add e(T, g2) to the pairing check
-		g1Points = append(g1Points, T)
-		g2Points = append(g2Points, publicKey.G2)
-		
-		// Return temporary values to pool
-		PutG1AffineSlice(TPoints)
-		PutBigInt(cBatch)
-		PutBigInt(oneBatch)
-		PutScalarSlice(TScalars)
-		PutG1Jac(TJac)
-	}
-	
-	// Perform the batch pairing check
-	pairingResult, err := bls12381.Pair(g1Points, g2Points)
+	}
+
+	if len(disclosedMessages)+len(proof.MHat) != publicKey.messageCount {
+		return ErrInvalidMessageCount
+	}
+
+	return nil
+}
+
+// computeB computes B = P1 + Q1*s + Q2*domain + H_1*m_1 + ... + H_L*m_L
+func computeB(publicKey *PublicKey, s, domain *big.Int, messages []*big.Int) bls12381.G1Affine {
+	points := make([]bls12381.G1Affine, 0, len(messages)+2)
+	scalars := make([]Scalar, 0, len(messages)+2)
+
+	// Q1 * s and Q2 * domain
+	points = append(points, publicKey.h[0], publicKey.h[1])
+	scalars = append(scalars, ScalarFromBigInt(s), ScalarFromBigInt(domain))
+
+	// Each H_i * m_i
+	for i, m := range messages {
+		points = append(points, publicKey.h[i+2]) // +2 because H[0] is Q1, H[1] is Q2
+		scalars = append(scalars, ScalarFromBigInt(m))
+	}
+
+	// Add P1
+	BJac := scalarMulSumG1(points, scalars)
+	BJac.AddMixed(&publicKey.g1)
+
+	return g1JacToAffine(BJac)
+}
+
+// randomNonZeroScalar samples a uniformly random scalar in [1, Order-1]
+func randomNonZeroScalar() (*big.Int, error) {
+	r, err := newRandomNonZeroScalar(rand.Reader)
 	if err != nil {
-		return ErrPairingFailed
+		return nil, err
 	}
-	
-	// Check if the pairing result is 1 (using constant-time comparison when possible)
-	if !pairingResult.IsOne() {
-		return ErrInvalidSignature
+	return r.BigInt(), nil
+}
+
+// sortedKeys returns the keys of m in ascending order
+func sortedKeys[V any](m map[int]V) []int {
+	keys := make([]int, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Ints(keys)
+	return keys
+}
+
+// headerAt returns the header for the i-th entry of a batch, or nil when
+// headers are omitted
+func headerAt(headers [][]byte, i int) []byte {
+	if i < len(headers) {
+		return headers[i]
 	}
-	
 	return nil
 }
 
-// ExtendProofOriginal extends an existing proof to disclose additional attributes
-// - proof: The original proof
-// - disclosedMessages: The currently disclosed messages
-// - additionalIndices: The indices of additional messages to disclose
-// - secretMessages: A map of all message values (both disclosed and undisclosed)
-// - publicKey: The public key for verification
-// Returns: 
-// - A new proof with additional disclosed attributes
-// - An updated map of disclosed messages
-// - An error, if any occurred
-func ExtendProofOriginal(
+// prepareProofExtension validates an extension request and returns the full
+// message vector together with the widened disclosure map
+func prepareProofExtension(
 	proof *ProofOfKnowledge,
 	disclosedMessages map[int]*big.Int,
 	additionalIndices []int,
 	secretMessages map[int]*big.Int,
 	publicKey *PublicKey,
-) (*ProofOfKnowledge, map[int]*big.Int, error) {
+	signature *Signature,
+	domain *big.Int,
+	newDisclosedMessages map[int]*big.Int,
+) ([]*big.Int, error) {
+	if signature == nil {
+		return nil, fmt.Errorf("original signature is required to extend a proof")
+	}
+
 	// Validate inputs
 	for _, idx := range additionalIndices {
+		if idx < 0 || idx >= publicKey.messageCount {
+			return nil, fmt.Errorf("invalid message index: %d", idx)
+		}
+
 		if _, ok := disclosedMessages[idx]; ok {
-			return nil, nil, fmt.Errorf("message at index %d is already disclosed", idx)
+			return nil, fmt.Errorf("message at index %d is already disclosed", idx)
 		}
-		
-		if _, ok := secretMessages[idx]; !ok {
-			return nil, nil, fmt.Errorf("secret message at index %d not provided", idx)
+	}
+
+	// Re-derivation needs every message, not only the newly disclosed ones
+	messages := make([]*big.Int, publicKey.messageCount)
+	for i := range messages {
+		msg, ok := secretMessages[i]
+		if !ok || msg == nil {
+			return nil, fmt.Errorf("secret message at index %d not provided", i)
+		}
+		messages[i] = msg
+	}
+
+	// The witness must match what the original proof disclosed
+	for idx, msg := range disclosedMessages {
+		if idx < 0 || idx >= publicKey.messageCount {
+			return nil, fmt.Errorf("invalid disclosed message index: %d", idx)
 		}
-		
-		if idx < 0 || idx >= publicKey.MessageCount {
-			return nil, nil, fmt.Errorf("invalid message index: %d", idx)
+		if msg == nil || msg.Cmp(messages[idx]) != 0 {
+			return nil, fmt.Errorf("disclosed message at index %d does not match secret message", idx)
 		}
 	}
-	
-	// Create the new disclosed messages map
-	newDisclosedMessages := make(map[int]*big.Int)
+
+	// Only proofs that verify can be extended
+	if err := checkProofChallenge(publicKey, proof, disclosedMessages, domain, nil); err != nil {
+		return nil, fmt.Errorf("original proof is invalid: %w", err)
+	}
+
 	for idx, msg := range disclosedMessages {
 		newDisclosedMessages[idx] = new(big.Int).Set(msg)
 	}
-	
+
 	// Add the additional messages
 	for _, idx := range additionalIndices {
-		newDisclosedMessages[idx] = new(big.Int).Set(secretMessages[idx])
-	}
-	
-	// Generate the new blinding factors
-	e, err := RandomScalar(rand.Reader)
-	if err != nil {
-		return nil, nil, fmt.Errorf("failed to generate blinding: %w", err)
+		newDisclosedMessages[idx] = new(big.Int).Set(messages[idx])
 	}
-	
-	s, err := RandomScalar(rand.Reader)
-	if err != nil {
-		return nil, nil, fmt.Errorf("failed to generate blinding: %w", err)
-	}
-	
-	// The approach is to:
-	// 1. Compute the commitment to the newly disclosed messages
-	// 2. Update the ABar commitment
-	// 3. Update the D commitment 
-	// 4. Generate a new challenge value
-	// 5. Create the final proof
-	
-	// Prepare the commitment for newly disclosed messages
-	// We need to remove their blinding from ABar and add to APrime
-	ABarJac := bls12381.G1Jac{}
-	ABarJac.FromAffine(&proof.ABar)
-	
-	// Update APrime to include the newly disclosed messages
-	APrimeJac := bls12381.G1Jac{}
-	APrimeJac.FromAffine(&proof.APrime)
-	
-	// For each newly disclosed message, remove it from blinding
-	for _, idx := range additionalIndices {
-		// Get the message value
-		msg := secretMessages[idx]
-		
-		// Compute h_i^(-msg * C)
-		hiJac := bls12381.G1Jac{}
-		hiJac.FromAffine(&publicKey.H[idx+2]) // +2 for Q1, Q2
-		
-		// Compute -msg * C
-		negMsgC := new(big.Int).Mul(msg, proof.C)
-		negMsgC.Neg(negMsgC)
-		negMsgC.Mod(negMsgC, Order)
-		
-		// Compute h_i^(-msg * C)
-		hiJac.ScalarMultiplication(&hiJac, negMsgC)
-		
-		// Update ABar: ABar = ABar * h_i^(-msg * C)
-		ABarJac.AddAssign(&hiJac)
-	}
-	
-	// Convert to affine
-	newABar := g1JacToAffine(ABarJac)
-	
-	// Generate a new challenge value
-	c := ComputeProofChallenge(
-		proof.APrime,
-		newABar,
-		proof.D,
-		additionalIndices,
-		newDisclosedMessages,
-	)
-	
-	// Compute the final proof with E' = E + e
-	eHat := new(big.Int).Add(proof.EHat, e)
-	eHat.Mod(eHat, Order)
-	
-	// Compute S' = S + s
-	sHat := new(big.Int).Add(proof.SHat, s)
-	sHat.Mod(sHat, Order)
-	
-	// Create MHat for the new proof - copy existing values and remove newly disclosed indices
-	newMHat := make(map[int]*big.Int)
-	for idx, val := range proof.MHat {
-		newMHat[idx] = new(big.Int).Set(val)
-	}
-	
-	// Remove entries for newly disclosed messages
-	for _, idx := range additionalIndices {
-		delete(newMHat, idx)
-	}
-	
-	// Create the new proof
-	newProof := &ProofOfKnowledge{
-		APrime: proof.APrime,
-		ABar:   newABar,
-		D:      proof.D,
-		C:      c,
-		EHat:   eHat,
-		SHat:   sHat,
-		MHat:   newMHat,
-	}
-	
-	return newProof, newDisclosedMessages, nil
-}
\ No newline at end of file
+
+	return messages, nil
+}
@@ -0,0 +1,169 @@
+package bbs
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// The compressed proof encoding drops what a verifier already knows. The
+// hidden message indices are the complement of the disclosed indices within
+// the key's message count, so the m^ entries are written without index or
+// count, in index order:
+//
+//	A' || Abar || D || c || e^ || s^ || r1^ || r3^ || m^*
+//
+// For U hidden messages this saves 4 + 4*U bytes over SerializeProof. The
+// challenge c stays: it replaces the commitments T1 and T2, which verifiers
+// recompute from it, and at one scalar it is the smallest form of the
+// Schnorr proof. Commitment equality responses follow as in SerializeProof.
+
+// CompressedProofSize returns the size of a compressed proof without
+// commitment equalities for a key with messageCount messages of which
+// disclosedCount are disclosed
+func CompressedProofSize(messageCount, disclosedCount int) int {
+	return 3*G1Size + (5+messageCount-disclosedCount)*ScalarSize
+}
+
+// SerializeCompressedProof encodes a proof in the compressed encoding
+func SerializeCompressedProof(proof *ProofOfKnowledge) []byte {
+	result := make([]byte, 0, 3*G1Size+(5+len(proof.MHat))*ScalarSize)
+
+	// Add the points
+	result = appendG1(result, &proof.APrime)
+	result = appendG1(result, &proof.ABar)
+	result = appendG1(result, &proof.D)
+
+	// Add the challenge and responses
+	result = appendScalar(result, proof.C)
+	result = appendScalar(result, proof.EHat)
+	result = appendScalar(result, proof.SHat)
+	result = appendScalar(result, proof.R1Hat)
+	result = appendScalar(result, proof.R3Hat)
+
+	// Add the undisclosed message responses in index order, without indices
+	for _, idx := range sortedKeys(proof.MHat) {
+		result = appendScalar(result, proof.MHat[idx])
+	}
+
+	// Add the commitment equality responses, if any, in index order
+	if len(proof.CommitmentHat) > 0 {
+		indices := sortedKeys(proof.CommitmentHat)
+		result = appendUint32(result, uint32(len(indices)))
+		for _, idx := range indices {
+			result = appendUint32(result, uint32(idx))
+			result = appendScalar(result, proof.CommitmentHat[idx])
+		}
+	}
+
+	return result
+}
+
+// DeserializeCompressedProof decodes a compressed proof for a key with
+// messageCount messages, reconstructing the hidden indices from
+// disclosedIndices. Both the key and the proof must be within DefaultLimits.
+func DeserializeCompressedProof(data []byte, messageCount int, disclosedIndices []int) (*ProofOfKnowledge, error) {
+	if err := DefaultLimits.CheckMessageCount(messageCount); err != nil {
+		return nil, err
+	}
+	if err := DefaultLimits.CheckProofBytes(len(data)); err != nil {
+		return nil, err
+	}
+	hidden, err := hiddenIndices(messageCount, disclosedIndices)
+	if err != nil {
+		return nil, err
+	}
+
+	size := CompressedProofSize(messageCount, len(disclosedIndices))
+	if len(data) < size || !isCompactEncoding(data) {
+		return nil, ErrInvalidProofData
+	}
+
+	r := &wireReader{data: data}
+	proof := &ProofOfKnowledge{
+		APrime: r.g1(),
+		ABar:   r.g1(),
+		D:      r.g1(),
+		C:      r.scalar(),
+		EHat:   r.scalar(),
+		SHat:   r.scalar(),
+		R1Hat:  r.scalar(),
+		R3Hat:  r.scalar(),
+		MHat:   make(map[int]*big.Int, len(hidden)),
+	}
+	for _, idx := range hidden {
+		proof.MHat[idx] = r.scalar()
+	}
+	if r.err != nil {
+		return nil, ErrInvalidProofData
+	}
+
+	if r.remaining() == 0 {
+		return proof, nil
+	}
+
+	// Commitment equality responses follow when present
+	count := r.uint32()
+	if r.err != nil || count == 0 || uint64(count)*(4+ScalarSize) != uint64(r.remaining()) {
+		return nil, ErrInvalidProofData
+	}
+	if err := DefaultLimits.CheckMHatEntries(int(count)); err != nil {
+		return nil, err
+	}
+
+	proof.CommitmentHat = make(map[int]*big.Int, count)
+	for i := uint32(0); i < count; i++ {
+		idx := int(r.uint32())
+		if _, dup := proof.CommitmentHat[idx]; dup {
+			return nil, ErrInvalidProofData
+		}
+		proof.CommitmentHat[idx] = r.scalar()
+	}
+	if r.err != nil {
+		return nil, ErrInvalidProofData
+	}
+
+	return proof, nil
+}
+
+// VerifyCompressedProof decodes a compressed proof against the key and the
+// disclosed messages and verifies it like VerifyProofWithPresentationHeader
+func VerifyCompressedProof(
+	publicKey *PublicKey,
+	data []byte,
+	disclosedMessages map[int]*big.Int,
+	header []byte,
+	presentationHeader []byte,
+) error {
+	proof, err := DeserializeCompressedProof(data, publicKey.messageCount, sortedKeys(disclosedMessages))
+	if err != nil {
+		return err
+	}
+	return VerifyProofWithPresentationHeader(publicKey, proof, disclosedMessages, header, presentationHeader)
+}
+
+// hiddenIndices returns the indices below messageCount that are not
+// disclosed, in ascending order
+func hiddenIndices(messageCount int, disclosedIndices []int) ([]int, error) {
+	if messageCount < 0 {
+		return nil, ErrInvalidMessageCount
+	}
+
+	disclosed := make([]bool, messageCount)
+	for _, idx := range disclosedIndices {
+		if idx < 0 || idx >= messageCount {
+			return nil, fmt.Errorf("invalid disclosed message index: %d", idx)
+		}
+		if disclosed[idx] {
+			return nil, fmt.Errorf("duplicate disclosed index: %d", idx)
+		}
+		disclosed[idx] = true
+	}
+
+	hidden := make([]int, 0, messageCount-len(disclosedIndices))
+	for idx, d := range disclosed {
+		if !d {
+			hidden = append(hidden, idx)
+		}
+	}
+	return hidden, nil
+}
@@ -0,0 +1,87 @@
+package bbs
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+)
+
+func TestCompressedProofRoundTrip(t *testing.T) {
+	keyPair, _, proof, disclosed, _ := encodingFixture(t)
+
+	data := SerializeCompressedProof(proof)
+	if len(data) != CompressedProofSize(4, 2) {
+		t.Fatalf("Compressed proof is %d bytes, expected %d", len(data), CompressedProofSize(4, 2))
+	}
+
+	// Two hidden messages save the count and two indices
+	if saved := len(SerializeProof(proof)) - len(data); saved != 4+2*4 {
+		t.Fatalf("Compressed proof saves %d bytes, expected %d", saved, 4+2*4)
+	}
+
+	if err := VerifyCompressedProof(keyPair.PublicKey, data, disclosed, nil, nil); err != nil {
+		t.Fatalf("VerifyCompressedProof failed: %v", err)
+	}
+
+	decoded, err := DeserializeCompressedProof(data, 4, []int{2, 0})
+	if err != nil {
+		t.Fatalf("DeserializeCompressedProof failed: %v", err)
+	}
+	if len(decoded.MHat) != 2 || decoded.MHat[1].Cmp(proof.MHat[1]) != 0 || decoded.MHat[3].Cmp(proof.MHat[3]) != 0 {
+		t.Fatalf("Decoded responses do not match")
+	}
+}
+
+func TestCompressedProofWithCommitments(t *testing.T) {
+	keyPair, signature, _, _, messages := encodingFixture(t)
+
+	proof, disclosed, err := CreateProof(keyPair.PublicKey, signature, messages, []int{0}, nil)
+	if err != nil {
+		t.Fatalf("CreateProof failed: %v", err)
+	}
+	proof.CommitmentHat = map[int]*big.Int{1: big.NewInt(7), 3: big.NewInt(9)}
+
+	decoded, err := DeserializeCompressedProof(SerializeCompressedProof(proof), 4, []int{0})
+	if err != nil {
+		t.Fatalf("DeserializeCompressedProof failed: %v", err)
+	}
+	if len(decoded.CommitmentHat) != 2 || decoded.CommitmentHat[3].Int64() != 9 {
+		t.Fatalf("Commitment responses do not match")
+	}
+	if len(disclosed) != 1 {
+		t.Fatalf("Unexpected disclosed messages: %v", disclosed)
+	}
+}
+
+func TestCompressedProofRejectsWrongContext(t *testing.T) {
+	keyPair, _, proof, disclosed, _ := encodingFixture(t)
+	data := SerializeCompressedProof(proof)
+
+	// A different disclosure pattern places the responses on other messages
+	other := map[int]*big.Int{0: disclosed[0], 1: big.NewInt(2)}
+	if err := VerifyCompressedProof(keyPair.PublicKey, data, other, nil, nil); err == nil {
+		t.Fatalf("Expected verification to fail with other disclosed indices")
+	}
+
+	for name, tc := range map[string]struct {
+		data      []byte
+		count     int
+		disclosed []int
+	}{
+		"truncated":          {data[:len(data)-1], 4, []int{0, 2}},
+		"more disclosed":     {data, 4, []int{0, 1, 2}},
+		"fewer disclosed":    {data, 4, []int{0}},
+		"index out of range": {data, 4, []int{0, 4}},
+		"duplicate index":    {data, 4, []int{0, 0}},
+		"empty":              {nil, 4, []int{0, 2}},
+	} {
+		if _, err := DeserializeCompressedProof(tc.data, tc.count, tc.disclosed); err == nil {
+			t.Fatalf("%s: expected DeserializeCompressedProof to fail", name)
+		}
+	}
+
+	// Trailing bytes that are not a commitment section are rejected
+	if _, err := DeserializeCompressedProof(append(data, 0, 0, 0, 1), 4, []int{0, 2}); !errors.Is(err, ErrInvalidProofData) {
+		t.Fatalf("Expected ErrInvalidProofData, got %v", err)
+	}
+}
@@ -173,6 +173,8 @@ func TestProofManager_ExtendProofWithPooling(t *testing.T) {
 		additionalIndices,
 		secretMessages,
 		pk,
+		signature,
+		nil,
 	)
 	if err != nil {
 		t.Fatalf("ExtendProofWithPooling failed: %v", err)
@@ -213,6 +215,8 @@ func TestProofManager_ExtendProofWithPooling(t *testing.T) {
 		additionalIndices,
 		secretMessages,
 		pk,
+		signature,
+		nil,
 	)
 	if err != nil {
 		t.Fatalf("Global ExtendProofWithPooling failed: %v", err)
@@ -0,0 +1,97 @@
+package bbs
+
+import (
+	"context"
+	"crypto/rand"
+	"math/big"
+)
+
+// Stages of proof creation, in the order CreateProofWithProgress runs them.
+// D needs B, the sum over every message, and T2 the sum over the hidden
+// ones, so those two stages take longest for large credentials.
+const (
+	ProofStageBlinding  = "blinding"  // drawing the blinding factors
+	ProofStageD         = "D"         // B and D = B*r2
+	ProofStageABar      = "ABar"      // A', A-bar and T1
+	ProofStageChallenge = "challenge" // T2 and the Fiat-Shamir challenge
+	ProofStageResponses = "responses" // the Schnorr responses
+)
+
+// proofStages lists the stages for progress reports
+var proofStages = []string{ProofStageBlinding, ProofStageD, ProofStageABar, ProofStageChallenge, ProofStageResponses}
+
+// ProofProgressFunc is called after each stage of proof creation with the
+// stage finished and the number of stages done out of total
+type ProofProgressFunc func(stage string, done, total int)
+
+// CreateProofWithProgress creates the same proof as
+// CreateProofWithPresentationHeader, calling progress after each stage and
+// checking ctx before the next. A cancelled proof returns ctx.Err() and its
+// randomness is wiped. progress may be nil.
+func CreateProofWithProgress(
+	ctx context.Context,
+	publicKey *PublicKey,
+	signature *Signature,
+	messages []*big.Int,
+	disclosedIndices []int,
+	header []byte,
+	presentationHeader []byte,
+	progress ProofProgressFunc,
+) (*ProofOfKnowledge, map[int]*big.Int, error) {
+	if len(messages) != publicKey.messageCount {
+		return nil, nil, ErrInvalidMessageCount
+	}
+	disclosedMessages, err := selectDisclosed(messages, disclosedIndices)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	// finish reports a stage and checks for cancellation before the next
+	done := 0
+	finish := func() error {
+		if progress != nil {
+			progress(proofStages[done], done+1, len(proofStages))
+		}
+		done++
+		return ctx.Err()
+	}
+
+	witness, r2, err := drawProofBlinding(signature, messages, disclosedMessages, rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer witness.wipe()
+	defer r2.SetZero()
+	if err := finish(); err != nil {
+		return nil, nil, err
+	}
+
+	domain := CalculateDomain(publicKey, header)
+	witness.blindD(computeB(publicKey, signature.S, domain, messages), &r2)
+	if err := finish(); err != nil {
+		return nil, nil, err
+	}
+
+	witness.blindABar(signature, &r2)
+	r2.SetZero()
+	if err := finish(); err != nil {
+		return nil, nil, err
+	}
+
+	t2Points, t2Scalars := witness.t2Terms(publicKey)
+	witness.commitment.T2 = g1JacToAffine(scalarMulSumG1(t2Points, t2Scalars))
+	cm := &witness.commitment
+	c := computeProofChallenge(cm.APrime, cm.ABar, cm.D, cm.T1, cm.T2, sortedKeys(disclosedMessages), disclosedMessages, domain, presentationHeader)
+	if err := finish(); err != nil {
+		return nil, nil, err
+	}
+
+	// The proof is complete, so cancelling now changes nothing
+	proof := witness.respond(c)
+	finish()
+
+	return proof, disclosedMessages, nil
+}
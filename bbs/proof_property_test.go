@@ -0,0 +1,268 @@
+package bbs
+
+import (
+	"crypto/rand"
+	"math/big"
+	mrand "math/rand"
+	"testing"
+	"testing/quick"
+)
+
+// proofScenario is a randomly generated signing and disclosure setup used by
+// the property-based soundness tests
+type proofScenario struct {
+	publicKey  *PublicKey
+	otherKey   *PublicKey
+	messages   []*big.Int
+	header     []byte
+	proof      *ProofOfKnowledge
+	disclosed  map[int]*big.Int
+	hiddenIdxs []int
+}
+
+// newProofScenario draws a message count, message values, a disclosure subset
+// and a header from rng, then signs and derives an honest proof
+func newProofScenario(t *testing.T, rng *mrand.Rand) *proofScenario {
+	t.Helper()
+
+	messageCount := 1 + rng.Intn(6)
+
+	keyPair, err := GenerateKeyPair(messageCount, rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key pair: %v", err)
+	}
+
+	otherKeyPair, err := GenerateKeyPair(messageCount, rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key pair: %v", err)
+	}
+
+	messages := make([]*big.Int, messageCount)
+	for i := range messages {
+		buf := make([]byte, 32)
+		rng.Read(buf)
+		messages[i] = new(big.Int).Mod(new(big.Int).SetBytes(buf), Order)
+	}
+
+	var disclosedIndices, hiddenIdxs []int
+	for i := 0; i < messageCount; i++ {
+		if rng.Intn(2) == 0 {
+			disclosedIndices = append(disclosedIndices, i)
+		} else {
+			hiddenIdxs = append(hiddenIdxs, i)
+		}
+	}
+
+	header := make([]byte, rng.Intn(16))
+	rng.Read(header)
+
+	signature, err := Sign(keyPair.PrivateKey, keyPair.PublicKey, messages, header)
+	if err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+
+	proof, disclosed, err := CreateProof(keyPair.PublicKey, signature, messages, disclosedIndices, header)
+	if err != nil {
+		t.Fatalf("CreateProof failed: %v", err)
+	}
+
+	return &proofScenario{
+		publicKey:  keyPair.PublicKey,
+		otherKey:   otherKeyPair.PublicKey,
+		messages:   messages,
+		header:     header,
+		proof:      proof,
+		disclosed:  disclosed,
+		hiddenIdxs: hiddenIdxs,
+	}
+}
+
+// copyDisclosed returns a shallow copy of a disclosed message map
+func copyDisclosed(disclosed map[int]*big.Int) map[int]*big.Int {
+	out := make(map[int]*big.Int, len(disclosed))
+	for idx, msg := range disclosed {
+		out[idx] = msg
+	}
+	return out
+}
+
+// propertyConfig keeps the number of iterations small since each one performs
+// key generation, signing and several pairings
+var propertyConfig = &quick.Config{MaxCount: 50}
+
+func TestProofProperty_HonestProofsVerify(t *testing.T) {
+	property := func(seed int64) bool {
+		s := newProofScenario(t, mrand.New(mrand.NewSource(seed)))
+		return VerifyProof(s.publicKey, s.proof, s.disclosed, s.header) == nil
+	}
+
+	if err := quick.Check(property, propertyConfig); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestProofProperty_TamperedDisclosureFails(t *testing.T) {
+	property := func(seed int64) bool {
+		rng := mrand.New(mrand.NewSource(seed))
+		s := newProofScenario(t, rng)
+
+		// Replace one disclosed value, or claim a hidden one, depending on
+		// what the scenario has available
+		forged := copyDisclosed(s.disclosed)
+		if len(forged) > 0 {
+			idx := sortedKeys(forged)[rng.Intn(len(forged))]
+			forged[idx] = new(big.Int).Add(forged[idx], big.NewInt(1+rng.Int63n(1000)))
+		} else {
+			idx := s.hiddenIdxs[rng.Intn(len(s.hiddenIdxs))]
+			forged[idx] = s.messages[idx]
+		}
+
+		return VerifyProof(s.publicKey, s.proof, forged, s.header) != nil
+	}
+
+	if err := quick.Check(property, propertyConfig); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestProofProperty_SwappedDisclosedValuesFail(t *testing.T) {
+	property := func(seed int64) bool {
+		rng := mrand.New(mrand.NewSource(seed))
+		s := newProofScenario(t, rng)
+
+		indices := sortedKeys(s.disclosed)
+		if len(indices) < 2 {
+			return true // Nothing to swap
+		}
+
+		i := indices[rng.Intn(len(indices))]
+		j := indices[rng.Intn(len(indices))]
+		if i == j || s.disclosed[i].Cmp(s.disclosed[j]) == 0 {
+			return true // Swap is a no-op
+		}
+
+		forged := copyDisclosed(s.disclosed)
+		forged[i], forged[j] = forged[j], forged[i]
+
+		return VerifyProof(s.publicKey, s.proof, forged, s.header) != nil
+	}
+
+	if err := quick.Check(property, propertyConfig); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestProofProperty_DroppedDisclosureFails(t *testing.T) {
+	property := func(seed int64) bool {
+		rng := mrand.New(mrand.NewSource(seed))
+		s := newProofScenario(t, rng)
+
+		if len(s.disclosed) == 0 {
+			return true // Nothing to drop
+		}
+
+		forged := copyDisclosed(s.disclosed)
+		delete(forged, sortedKeys(forged)[rng.Intn(len(forged))])
+
+		return VerifyProof(s.publicKey, s.proof, forged, s.header) != nil
+	}
+
+	if err := quick.Check(property, propertyConfig); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestProofProperty_DifferentHeaderFails(t *testing.T) {
+	property := func(seed int64, otherHeader []byte) bool {
+		s := newProofScenario(t, mrand.New(mrand.NewSource(seed)))
+
+		if string(otherHeader) == string(s.header) {
+			otherHeader = append(otherHeader, 0x01)
+		}
+
+		return VerifyProof(s.publicKey, s.proof, s.disclosed, otherHeader) != nil
+	}
+
+	if err := quick.Check(property, propertyConfig); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestProofProperty_ProofsDoNotTransferBetweenKeys(t *testing.T) {
+	property := func(seed int64) bool {
+		s := newProofScenario(t, mrand.New(mrand.NewSource(seed)))
+		return VerifyProof(s.otherKey, s.proof, s.disclosed, s.header) != nil
+	}
+
+	if err := quick.Check(property, propertyConfig); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestProofProperty_MixedProofsFail(t *testing.T) {
+	property := func(seed int64) bool {
+		rng := mrand.New(mrand.NewSource(seed))
+		s1 := newProofScenario(t, rng)
+		s2 := newProofScenario(t, rng)
+
+		// Present the second proof with the first proof's disclosures, both
+		// under the first key and in a batch
+		if VerifyProof(s1.publicKey, s2.proof, s1.disclosed, s1.header) == nil {
+			return false
+		}
+
+		err := BatchVerifyProofs(
+			[]*PublicKey{s1.publicKey, s2.publicKey},
+			[]*ProofOfKnowledge{s2.proof, s1.proof},
+			[]map[int]*big.Int{s1.disclosed, s2.disclosed},
+			[][]byte{s1.header, s2.header},
+		)
+		return err != nil
+	}
+
+	if err := quick.Check(property, propertyConfig); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestProofProperty_TamperedResponsesFail(t *testing.T) {
+	property := func(seed int64) bool {
+		rng := mrand.New(mrand.NewSource(seed))
+		s := newProofScenario(t, rng)
+
+		delta := big.NewInt(1 + rng.Int63n(1000))
+		bump := func(v *big.Int) *big.Int {
+			out := new(big.Int).Add(v, delta)
+			return out.Mod(out, Order)
+		}
+
+		tampered := *s.proof
+		tampered.MHat = copyDisclosed(s.proof.MHat)
+
+		switch rng.Intn(6) {
+		case 0:
+			tampered.C = bump(tampered.C)
+		case 1:
+			tampered.EHat = bump(tampered.EHat)
+		case 2:
+			tampered.SHat = bump(tampered.SHat)
+		case 3:
+			tampered.R1Hat = bump(tampered.R1Hat)
+		case 4:
+			tampered.R3Hat = bump(tampered.R3Hat)
+		case 5:
+			if len(tampered.MHat) == 0 {
+				tampered.C = bump(tampered.C)
+				break
+			}
+			idx := sortedKeys(tampered.MHat)[rng.Intn(len(tampered.MHat))]
+			tampered.MHat[idx] = bump(tampered.MHat[idx])
+		}
+
+		return VerifyProof(s.publicKey, &tampered, s.disclosed, s.header) != nil
+	}
+
+	if err := quick.Check(property, propertyConfig); err != nil {
+		t.Fatal(err)
+	}
+}
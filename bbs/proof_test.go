@@ -0,0 +1,249 @@
+package bbs
+
+import (
+	"bytes"
+	"math/big"
+	mrand "math/rand/v2"
+	"testing"
+)
+
+// newExtendProofFixture signs random messages and derives a proof disclosing
+// the given indices
+func newExtendProofFixture(t *testing.T, messageCount int, disclosedIndices []int, header []byte) (
+	*PublicKey, *Signature, []*big.Int, *ProofOfKnowledge, map[int]*big.Int,
+) {
+	t.Helper()
+
+	messages := randomMessages(t, messageCount)
+	keyPair, signature := signedFixture(t, messages, header)
+
+	proof, disclosed, err := CreateProof(keyPair.PublicKey, signature, messages, disclosedIndices, header)
+	if err != nil {
+		t.Fatalf("CreateProof failed: %v", err)
+	}
+
+	return keyPair.PublicKey, signature, messages, proof, disclosed
+}
+
+func secretMessageMap(messages []*big.Int) map[int]*big.Int {
+	secretMessages := make(map[int]*big.Int, len(messages))
+	for i, msg := range messages {
+		secretMessages[i] = msg
+	}
+	return secretMessages
+}
+
+func TestExtendProof_Verifies(t *testing.T) {
+	header := []byte("extend header")
+	pk, signature, messages, proof, disclosed := newExtendProofFixture(t, 5, []int{1}, header)
+
+	extended, extendedDisclosed, err := ExtendProof(
+		proof, disclosed, []int{0, 4}, secretMessageMap(messages), pk, signature, header,
+	)
+	if err != nil {
+		t.Fatalf("ExtendProof failed: %v", err)
+	}
+	defer PutDisclosedMsgMap(extendedDisclosed)
+
+	if err := VerifyProof(pk, extended, extendedDisclosed, header); err != nil {
+		t.Fatalf("Extended proof does not verify: %v", err)
+	}
+
+	if len(extendedDisclosed) != 3 || len(extended.MHat) != 2 {
+		t.Fatalf("Unexpected disclosure: %d disclosed, %d hidden", len(extendedDisclosed), len(extended.MHat))
+	}
+
+	for _, idx := range []int{0, 1, 4} {
+		if extendedDisclosed[idx].Cmp(messages[idx]) != 0 {
+			t.Fatalf("Wrong disclosed value at index %d", idx)
+		}
+	}
+
+	// The extended proof can itself be extended again
+	extended2, extendedDisclosed2, err := ExtendProof(
+		extended, extendedDisclosed, []int{2}, secretMessageMap(messages), pk, signature, header,
+	)
+	if err != nil {
+		t.Fatalf("Second ExtendProof failed: %v", err)
+	}
+	defer PutDisclosedMsgMap(extendedDisclosed2)
+
+	if err := VerifyProof(pk, extended2, extendedDisclosed2, header); err != nil {
+		t.Fatalf("Twice extended proof does not verify: %v", err)
+	}
+}
+
+func TestExtendProof_RejectsTamperedResult(t *testing.T) {
+	header := []byte("extend header")
+	pk, signature, messages, proof, disclosed := newExtendProofFixture(t, 4, []int{0}, header)
+
+	extended, extendedDisclosed, err := ExtendProof(
+		proof, disclosed, []int{2}, secretMessageMap(messages), pk, signature, header,
+	)
+	if err != nil {
+		t.Fatalf("ExtendProof failed: %v", err)
+	}
+	defer PutDisclosedMsgMap(extendedDisclosed)
+
+	// Changing a newly disclosed value must invalidate the proof
+	forged := make(map[int]*big.Int)
+	for idx, msg := range extendedDisclosed {
+		forged[idx] = msg
+	}
+	forged[2] = new(big.Int).Add(messages[2], big.NewInt(1))
+	if err := VerifyProof(pk, extended, forged, header); err == nil {
+		t.Fatal("Extended proof should not verify with a modified disclosed message")
+	}
+
+	// Verifying against the old disclosure set must fail
+	if err := VerifyProof(pk, extended, disclosed, header); err == nil {
+		t.Fatal("Extended proof should not verify against the original disclosed set")
+	}
+
+	// Tampering with a response must fail
+	tampered := *extended
+	tampered.EHat = new(big.Int).Add(extended.EHat, big.NewInt(1))
+	if err := VerifyProof(pk, &tampered, extendedDisclosed, header); err == nil {
+		t.Fatal("Extended proof should not verify with a modified response")
+	}
+}
+
+func TestExtendProof_RejectsInvalidInputs(t *testing.T) {
+	header := []byte("extend header")
+	pk, signature, messages, proof, disclosed := newExtendProofFixture(t, 4, []int{0}, header)
+	secretMessages := secretMessageMap(messages)
+
+	// Tampered original proof
+	tampered := *proof
+	tampered.SHat = new(big.Int).Add(proof.SHat, big.NewInt(1))
+	if _, _, err := ExtendProof(&tampered, disclosed, []int{1}, secretMessages, pk, signature, header); err == nil {
+		t.Fatal("ExtendProof should reject a tampered original proof")
+	}
+
+	// Wrong header for the original proof
+	if _, _, err := ExtendProof(proof, disclosed, []int{1}, secretMessages, pk, signature, []byte("other")); err == nil {
+		t.Fatal("ExtendProof should reject a header mismatch")
+	}
+
+	// Missing signature
+	if _, _, err := ExtendProof(proof, disclosed, []int{1}, secretMessages, pk, nil, header); err == nil {
+		t.Fatal("ExtendProof should require the original signature")
+	}
+
+	// Already disclosed and out of range indices
+	if _, _, err := ExtendProof(proof, disclosed, []int{0}, secretMessages, pk, signature, header); err == nil {
+		t.Fatal("ExtendProof should reject an already disclosed index")
+	}
+	if _, _, err := ExtendProof(proof, disclosed, []int{4}, secretMessages, pk, signature, header); err == nil {
+		t.Fatal("ExtendProof should reject an out of range index")
+	}
+
+	// Incomplete witness
+	partial := secretMessageMap(messages)
+	delete(partial, 3)
+	if _, _, err := ExtendProof(proof, disclosed, []int{1}, partial, pk, signature, header); err == nil {
+		t.Fatal("ExtendProof should require all secret messages")
+	}
+
+	// Witness that contradicts the disclosed values
+	mismatched := secretMessageMap(messages)
+	mismatched[0] = new(big.Int).Add(messages[0], big.NewInt(1))
+	if _, _, err := ExtendProof(proof, disclosed, []int{1}, mismatched, pk, signature, header); err == nil {
+		t.Fatal("ExtendProof should reject secret messages that contradict the disclosed ones")
+	}
+}
+
+func TestProof_ZeroDisclosure(t *testing.T) {
+	header := []byte("possession only")
+	pk, signature, messages, proof, disclosed := newExtendProofFixture(t, 3, nil, header)
+
+	if len(disclosed) != 0 || len(proof.MHat) != 3 {
+		t.Fatalf("Unexpected disclosure: %d disclosed, %d hidden", len(disclosed), len(proof.MHat))
+	}
+
+	if err := VerifyProof(pk, proof, disclosed, header); err != nil {
+		t.Fatalf("VerifyProof failed: %v", err)
+	}
+	if err := VerifyProof(pk, proof, nil, header); err != nil {
+		t.Fatalf("VerifyProof with nil disclosure failed: %v", err)
+	}
+
+	// Claiming a disclosure the proof does not make must fail
+	claimed := map[int]*big.Int{0: messages[0]}
+	if err := VerifyProof(pk, proof, claimed, header); err == nil {
+		t.Fatal("VerifyProof should reject a disclosure the proof does not make")
+	}
+
+	// Serialization round trip
+	decoded, err := DeserializeProof(SerializeProof(proof))
+	if err != nil {
+		t.Fatalf("DeserializeProof failed: %v", err)
+	}
+	if err := VerifyProof(pk, decoded, disclosed, header); err != nil {
+		t.Fatalf("Deserialized proof does not verify: %v", err)
+	}
+
+	// Pooled creation and batch verification
+	pooled, pooledDisclosed, err := CreateProofWithPooling(pk, signature, messages, []int{}, header)
+	if err != nil {
+		t.Fatalf("CreateProofWithPooling failed: %v", err)
+	}
+	defer PutDisclosedMsgMap(pooledDisclosed)
+
+	err = BatchVerifyProofs(
+		[]*PublicKey{pk, pk},
+		[]*ProofOfKnowledge{proof, pooled},
+		[]map[int]*big.Int{disclosed, pooledDisclosed},
+		[][]byte{header, header},
+	)
+	if err != nil {
+		t.Fatalf("BatchVerifyProofs failed: %v", err)
+	}
+
+	// A zero disclosure proof can be extended later
+	extended, extendedDisclosed, err := ExtendProof(
+		proof, disclosed, []int{1}, secretMessageMap(messages), pk, signature, header,
+	)
+	if err != nil {
+		t.Fatalf("ExtendProof failed: %v", err)
+	}
+	defer PutDisclosedMsgMap(extendedDisclosed)
+
+	if err := VerifyProof(pk, extended, extendedDisclosed, header); err != nil {
+		t.Fatalf("Extended proof does not verify: %v", err)
+	}
+}
+
+func TestCreateProof_InvalidIndices(t *testing.T) {
+	messages := []*big.Int{big.NewInt(1), big.NewInt(2), big.NewInt(3)}
+	keyPair, signature := signedFixture(t, messages, nil)
+
+	for _, indices := range [][]int{{-1}, {3}, {0, 2, 0}} {
+		if _, _, err := CreateProof(keyPair.PublicKey, signature, messages, indices, nil); err == nil {
+			t.Fatalf("CreateProof accepted disclosed indices %v", indices)
+		}
+	}
+}
+
+func TestCreateProofWithRNG_Reproducible(t *testing.T) {
+	publicKey, signature, messages, _, _ := newExtendProofFixture(t, 4, nil, nil)
+
+	prove := func(seed byte) []byte {
+		rng := mrand.NewChaCha8([32]byte{seed})
+		proof, disclosed, err := CreateProofWithRNG(publicKey, signature, messages, []int{1}, nil, []byte("ph"), rng)
+		if err != nil {
+			t.Fatalf("CreateProofWithRNG failed: %v", err)
+		}
+		if err := VerifyProofWithPresentationHeader(publicKey, proof, disclosed, nil, []byte("ph")); err != nil {
+			t.Fatalf("VerifyProofWithPresentationHeader failed: %v", err)
+		}
+		return SerializeProof(proof)
+	}
+
+	if !bytes.Equal(prove(1), prove(1)) {
+		t.Fatalf("Expected the same seed to reproduce the proof")
+	}
+	if bytes.Equal(prove(1), prove(2)) {
+		t.Fatalf("Expected different seeds to give different proofs")
+	}
+}
@@ -0,0 +1,76 @@
+package bbs
+
+import (
+	"slices"
+
+	bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381"
+)
+
+// A PublicKey is shared freely: by engines and managers, caches keyed on it
+// and the goroutines of a batch verification. Its fields are unexported so
+// nothing can change a key once it is built; the accessors return copies.
+// Keys are built by GenerateKeyPair, the Deserialize functions and the
+// constructors below, and only UnmarshalBinary overwrites one, which must
+// not happen while it is in use.
+
+// NewPublicKey completes W with the standard generators for messageCount
+// messages
+func NewPublicKey(w bls12381.G2Affine, messageCount int) *PublicKey {
+	_, _, g1, g2 := bls12381.Generators()
+
+	return &PublicKey{
+		w:            w,
+		g2:           g2,
+		g1:           g1,
+		h:            GenerateGenerators(messageCount + 2),
+		messageCount: messageCount,
+	}
+}
+
+// NewPublicKeyWithGenerators builds a public key from all of its parts. h
+// holds Q1, Q2 and a generator per message, and is copied.
+func NewPublicKeyWithGenerators(w, g2 bls12381.G2Affine, g1 bls12381.G1Affine, h []bls12381.G1Affine, messageCount int) *PublicKey {
+	return &PublicKey{
+		w:            w,
+		g2:           g2,
+		g1:           g1,
+		h:            slices.Clone(h),
+		messageCount: messageCount,
+	}
+}
+
+// W returns W = g2*x
+func (pk *PublicKey) W() bls12381.G2Affine {
+	return pk.w
+}
+
+// G1 returns the generator of G1
+func (pk *PublicKey) G1() bls12381.G1Affine {
+	return pk.g1
+}
+
+// G2 returns the generator of G2
+func (pk *PublicKey) G2() bls12381.G2Affine {
+	return pk.g2
+}
+
+// MessageCount returns the number of messages the key signs
+func (pk *PublicKey) MessageCount() int {
+	return pk.messageCount
+}
+
+// Generators returns a copy of the generators: Q1, Q2 and one per message
+func (pk *PublicKey) Generators() []bls12381.G1Affine {
+	return slices.Clone(pk.h)
+}
+
+// Generator returns generator i, where 0 is Q1, 1 is Q2 and message j has
+// generator j+2. It panics if i is out of range.
+func (pk *PublicKey) Generator(i int) bls12381.G1Affine {
+	return pk.h[i]
+}
+
+// GeneratorCount returns the number of generators
+func (pk *PublicKey) GeneratorCount() int {
+	return len(pk.h)
+}
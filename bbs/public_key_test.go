@@ -0,0 +1,36 @@
+package bbs
+
+import (
+	"crypto/rand"
+	"testing"
+)
+
+func TestPublicKeyImmutable(t *testing.T) {
+	keyPair, err := GenerateKeyPair(2, rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key pair: %v", err)
+	}
+	pk := keyPair.PublicKey
+
+	// Changing the returned generators leaves the key alone
+	generators := pk.Generators()
+	q1 := pk.Generator(0)
+	generators[0] = generators[1]
+	if g := pk.Generator(0); !g.Equal(&q1) {
+		t.Fatalf("Generators returned the key's own slice")
+	}
+
+	// A key built from parts keeps its own copy too
+	parts := pk.Generators()
+	built := NewPublicKeyWithGenerators(pk.W(), pk.G2(), pk.G1(), parts, pk.MessageCount())
+	parts[0] = parts[1]
+	if g := built.Generator(0); !g.Equal(&q1) {
+		t.Fatalf("NewPublicKeyWithGenerators kept the caller's slice")
+	}
+
+	// NewPublicKey completes W with the same generators GenerateKeyPair uses
+	derived := NewPublicKey(pk.W(), pk.MessageCount())
+	if derived.Fingerprint() != pk.Fingerprint() {
+		t.Fatalf("NewPublicKey differs from the generated key")
+	}
+}
@@ -0,0 +1,153 @@
+package bbs
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+)
+
+// DefaultReplayWindow is how long a verified proof stays registered with a
+// ReplayGuard when VerifyOptions.ReplayWindow is not set
+const DefaultReplayWindow = 10 * time.Minute
+
+// Proofs are randomized, so an honest holder never presents the same proof
+// twice. A verifier that sees a proof again is looking at a copy, which a
+// ReplayGuard detects by remembering the hashes of the proofs it accepted.
+// Registration happens after verification, so invalid proofs never fill the
+// guard.
+
+// ReplayGuard remembers proofs a verifier has accepted
+type ReplayGuard interface {
+	// Seen reports whether proofHash is registered and has not expired. If
+	// not, it registers proofHash until expiry. Both steps are one atomic
+	// operation, so of two concurrent calls with the same hash only one
+	// returns false.
+	Seen(proofHash [32]byte, expiry time.Time) bool
+}
+
+// ProofHash returns the hash a ReplayGuard registers a proof under
+func ProofHash(proof *ProofOfKnowledge) [32]byte {
+	return sha256.Sum256(SerializeProof(proof))
+}
+
+// VerifyProofWithOptions verifies a proof like VerifyProof, and then checks it
+// against opts.Freshness and opts.ReplayGuard if they are set. The first entry
+// of opts.PresentationHeaders, if any, is the proof's presentation header.
+func VerifyProofWithOptions(
+	publicKey *PublicKey,
+	proof *ProofOfKnowledge,
+	disclosedMessages map[int]*big.Int,
+	header []byte,
+	opts VerifyOptions,
+) error {
+	if err := VerifyProofWithPresentationHeader(publicKey, proof, disclosedMessages, header, headerAt(opts.PresentationHeaders, 0)); err != nil {
+		return err
+	}
+
+	if err := opts.checkFreshness([]map[int]*big.Int{disclosedMessages}); err != nil {
+		return err
+	}
+
+	return opts.CheckReplay([]*ProofOfKnowledge{proof})
+}
+
+// CheckReplay registers proofs with the replay guard, failing on the first
+// one seen before. It does not verify them; call it only for proofs that
+// passed verification.
+func (o VerifyOptions) CheckReplay(proofs []*ProofOfKnowledge) error {
+	if o.ReplayGuard == nil {
+		return nil
+	}
+
+	expiry := o.replayExpiry()
+	for i, proof := range proofs {
+		if o.ReplayGuard.Seen(ProofHash(proof), expiry) {
+			return fmt.Errorf("proof %d: %w", i, ErrProofReplayed)
+		}
+	}
+
+	return nil
+}
+
+// replayExpiry returns when a proof registered now leaves the replay guard
+func (o VerifyOptions) replayExpiry() time.Time {
+	window := o.ReplayWindow
+	if window <= 0 {
+		window = DefaultReplayWindow
+	}
+	return time.Now().Add(window)
+}
+
+// LRUReplayGuard is an in-memory ReplayGuard holding up to a fixed number of
+// proofs. When full it evicts the least recently registered proof, which can
+// then be replayed; size it for the number of proofs expected per window.
+type LRUReplayGuard struct {
+	mu       sync.Mutex
+	capacity int
+	entries  map[[32]byte]*list.Element
+	order    *list.List
+	now      func() time.Time
+}
+
+// replayEntry is a registered proof
+type replayEntry struct {
+	hash   [32]byte
+	expiry time.Time
+}
+
+// NewLRUReplayGuard creates an in-memory replay guard for up to capacity
+// proofs
+func NewLRUReplayGuard(capacity int) *LRUReplayGuard {
+	if capacity <= 0 {
+		capacity = 1
+	}
+
+	return &LRUReplayGuard{
+		capacity: capacity,
+		entries:  make(map[[32]byte]*list.Element),
+		order:    list.New(),
+		now:      time.Now,
+	}
+}
+
+// Seen implements ReplayGuard
+func (g *LRUReplayGuard) Seen(proofHash [32]byte, expiry time.Time) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := g.now()
+	if elem, ok := g.entries[proofHash]; ok {
+		entry := elem.Value.(*replayEntry)
+		if now.Before(entry.expiry) {
+			return true
+		}
+		g.order.Remove(elem)
+		delete(g.entries, proofHash)
+	}
+
+	// Drop expired entries from the old end before evicting live ones
+	for g.order.Len() > 0 {
+		oldest := g.order.Back()
+		entry := oldest.Value.(*replayEntry)
+		if now.Before(entry.expiry) && g.order.Len() < g.capacity {
+			break
+		}
+		g.order.Remove(oldest)
+		delete(g.entries, entry.hash)
+	}
+
+	g.entries[proofHash] = g.order.PushFront(&replayEntry{hash: proofHash, expiry: expiry})
+	return false
+}
+
+// Len returns the number of registered proofs, including expired ones not
+// yet dropped
+func (g *LRUReplayGuard) Len() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.order.Len()
+}
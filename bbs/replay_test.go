@@ -0,0 +1,89 @@
+package bbs
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func TestVerifyProofRejectsReplay(t *testing.T) {
+	keyPair, err := GenerateKeyPair(3, rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKeyPair failed: %v", err)
+	}
+
+	messages := EncodeMessages(DefaultMessageEncoder, []Message{Message("a"), Message("b"), Message("c")})
+	sig, err := SignEncoded(keyPair.PrivateKey, keyPair.PublicKey, messages, nil)
+	if err != nil {
+		t.Fatalf("SignEncoded failed: %v", err)
+	}
+
+	proof, disclosed, err := CreateProof(keyPair.PublicKey, sig, FieldElementInts(messages), []int{0}, nil)
+	if err != nil {
+		t.Fatalf("CreateProof failed: %v", err)
+	}
+
+	opts := VerifyOptions{ReplayGuard: NewLRUReplayGuard(16)}
+
+	// An invalid proof is not registered
+	tampered := *proof
+	tampered.C = new(big.Int).Add(proof.C, big.NewInt(1))
+	if err := VerifyProofWithOptions(keyPair.PublicKey, &tampered, disclosed, nil, opts); err == nil {
+		t.Fatal("tampered proof verified")
+	}
+
+	if err := VerifyProofWithOptions(keyPair.PublicKey, proof, disclosed, nil, opts); err != nil {
+		t.Fatalf("VerifyProofWithOptions failed: %v", err)
+	}
+
+	err = VerifyProofWithOptions(keyPair.PublicKey, proof, disclosed, nil, opts)
+	if !errors.Is(err, ErrProofReplayed) {
+		t.Fatalf("replayed proof: got %v, want ErrProofReplayed", err)
+	}
+
+	// Batches reject proofs seen before and duplicates within the batch
+	other, otherDisclosed, err := CreateProof(keyPair.PublicKey, sig, FieldElementInts(messages), []int{0}, nil)
+	if err != nil {
+		t.Fatalf("CreateProof failed: %v", err)
+	}
+	keys := []*PublicKey{keyPair.PublicKey, keyPair.PublicKey}
+	err = BatchVerifyProofsWithOptions(keys, []*ProofOfKnowledge{other, other},
+		[]map[int]*big.Int{otherDisclosed, otherDisclosed}, nil, opts)
+	if !errors.Is(err, ErrProofReplayed) {
+		t.Fatalf("duplicate in batch: got %v, want ErrProofReplayed", err)
+	}
+}
+
+func TestLRUReplayGuard(t *testing.T) {
+	now := time.Unix(1000, 0)
+	guard := NewLRUReplayGuard(2)
+	guard.now = func() time.Time { return now }
+
+	a, b, c := [32]byte{1}, [32]byte{2}, [32]byte{3}
+	expiry := now.Add(time.Minute)
+
+	if guard.Seen(a, expiry) {
+		t.Fatal("new hash reported as seen")
+	}
+	if !guard.Seen(a, expiry) {
+		t.Fatal("registered hash not reported as seen")
+	}
+
+	// Filling the guard evicts the oldest entry
+	guard.Seen(b, expiry)
+	guard.Seen(c, expiry)
+	if guard.Len() != 2 {
+		t.Fatalf("Len = %d, want 2", guard.Len())
+	}
+	if guard.Seen(a, expiry) {
+		t.Fatal("evicted hash reported as seen")
+	}
+
+	// Expired entries are forgotten
+	now = now.Add(2 * time.Minute)
+	if guard.Seen(c, now.Add(time.Minute)) {
+		t.Fatal("expired hash reported as seen")
+	}
+}
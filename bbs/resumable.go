@@ -0,0 +1,478 @@
+package bbs
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/anupsv/bbsplus-signatures/internal/secret"
+	bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381"
+)
+
+// CheckpointKeySize is the size of the key that encrypts prover checkpoints
+const CheckpointKeySize = 32
+
+// checkpointAAD prefixes the public key in the associated data of a checkpoint
+const checkpointAAD = "BBS_RESUMABLE_PROVER_V1_"
+
+// Creating a proof costs one scalar multiplication per message for B and one
+// per hidden message for T2, which for large credentials can overrun the time
+// slice a phone browser gives a script. ResumableProver splits both sums into
+// steps of a chosen size and can checkpoint between any two of them. A
+// checkpoint holds the signature, the messages and the proof randomness, so
+// it is sealed with AES-GCM under a caller key and bound to the public key.
+//
+// Resuming the same checkpoint twice is safe. Before the randomness is drawn
+// the two runs draw their own; afterwards both derive the same Fiat-Shamir
+// challenge and return the same proof, so the blinding factors never answer
+// two different challenges.
+
+// Resumable prover phases
+const (
+	phaseB    = iota // summing B over the messages
+	phaseT2          // summing T2 over the hidden messages
+	phaseDone        // commitments complete, challenge not yet answered
+)
+
+// ResumableProver creates a proof in bounded steps
+type ResumableProver struct {
+	mu        sync.Mutex
+	publicKey *PublicKey
+	disclosed map[int]*big.Int
+	domain    *big.Int
+	phase     int
+	next      int // next term of the current phase
+	acc       bls12381.G1Jac
+	finished  bool
+
+	// Inputs, until the randomness is drawn
+	signature *Signature
+	messages  []*big.Int
+
+	// Proof state, once the randomness is drawn
+	witness *proofWitness
+}
+
+// NewResumableProver prepares a proof disclosing the messages at
+// disclosedIndices. No scalar multiplications over the messages happen until
+// Step is called.
+func NewResumableProver(
+	publicKey *PublicKey,
+	signature *Signature,
+	messages []*big.Int,
+	disclosedIndices []int,
+	header []byte,
+) (*ResumableProver, error) {
+	// Validate inputs
+	if len(messages) != publicKey.messageCount {
+		return nil, ErrInvalidMessageCount
+	}
+
+	disclosedMessages := make(map[int]*big.Int)
+	for _, idx := range disclosedIndices {
+		if idx < 0 || idx >= len(messages) {
+			return nil, fmt.Errorf("invalid disclosed index: %d", idx)
+		}
+		if _, dup := disclosedMessages[idx]; dup {
+			return nil, fmt.Errorf("duplicate disclosed index: %d", idx)
+		}
+		disclosedMessages[idx] = messages[idx]
+	}
+
+	domain := CalculateDomain(publicKey, header)
+
+	rp := &ResumableProver{
+		publicKey: publicKey,
+		disclosed: disclosedMessages,
+		domain:    domain,
+		phase:     phaseB,
+		signature: &Signature{
+			A: signature.A,
+			E: new(big.Int).Set(signature.E),
+			S: new(big.Int).Set(signature.S),
+		},
+		messages: make([]*big.Int, len(messages)),
+	}
+	for i, m := range messages {
+		rp.messages[i] = new(big.Int).Set(m)
+	}
+
+	// Start B with P1 + Q1*s + Q2*domain, which does not depend on the messages
+	acc, err := MultiScalarMulG1(
+		[]bls12381.G1Affine{publicKey.g1, publicKey.h[0], publicKey.h[1]},
+		[]*big.Int{big.NewInt(1), signature.S, domain},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed multi-scalar multiplication: %w", err)
+	}
+	rp.acc = acc
+
+	return rp, nil
+}
+
+// Step performs up to n scalar multiplications and reports whether all
+// commitments are done
+func (rp *ResumableProver) Step(n int) (bool, error) {
+	rp.mu.Lock()
+	defer rp.mu.Unlock()
+
+	if rp.finished {
+		return false, errors.New("resumable prover already finished")
+	}
+	if n < 1 {
+		n = 1
+	}
+
+	for n > 0 && rp.phase != phaseDone {
+		var points []bls12381.G1Affine
+		var scalars []Scalar
+		if rp.phase == phaseB {
+			for i := 0; i < len(rp.messages); i++ {
+				points = append(points, rp.publicKey.h[i+2]) // +2 for Q1, Q2
+			}
+			scalars = scalarsFromBigInts(rp.messages)
+		} else {
+			points, scalars = rp.witness.t2Terms(rp.publicKey)
+		}
+
+		end := rp.next + n
+		if end > len(scalars) {
+			end = len(scalars)
+		}
+
+		if end > rp.next {
+			chunk := scalarMulSumG1(points[rp.next:end], scalars[rp.next:end])
+			rp.acc.AddAssign(&chunk)
+		}
+		n -= end - rp.next
+		rp.next = end
+
+		if rp.next < len(scalars) {
+			break
+		}
+
+		if err := rp.finishPhase(); err != nil {
+			return false, err
+		}
+	}
+
+	return rp.phase == phaseDone, nil
+}
+
+// finishPhase moves to the next phase once the current sum is complete
+func (rp *ResumableProver) finishPhase() error {
+	switch rp.phase {
+	case phaseB:
+		B := g1JacToAffine(rp.acc)
+		witness, err := blindProof(rp.signature, B, rp.messages, rp.disclosed, rand.Reader)
+		if err != nil {
+			return err
+		}
+		rp.witness = witness
+		rp.wipeInputs()
+		rp.acc = bls12381.G1Jac{}
+		rp.phase = phaseT2
+	case phaseT2:
+		rp.witness.commitment.T2 = g1JacToAffine(rp.acc)
+		rp.phase = phaseDone
+	}
+	rp.next = 0
+
+	return nil
+}
+
+// Progress returns the number of scalar multiplications done and the total
+func (rp *ResumableProver) Progress() (done, total int) {
+	rp.mu.Lock()
+	defer rp.mu.Unlock()
+
+	hidden := rp.publicKey.messageCount - len(rp.disclosed)
+	bTerms, t2Terms := rp.publicKey.messageCount, 2+hidden
+	total = bTerms + t2Terms
+
+	switch rp.phase {
+	case phaseB:
+		return rp.next, total
+	case phaseT2:
+		return bTerms + rp.next, total
+	default:
+		return total, total
+	}
+}
+
+// Proof answers the Fiat-Shamir challenge once all steps are done and wipes
+// the prover state. It can be called once.
+func (rp *ResumableProver) Proof() (*ProofOfKnowledge, map[int]*big.Int, error) {
+	rp.mu.Lock()
+	defer rp.mu.Unlock()
+
+	if rp.finished {
+		return nil, nil, errors.New("resumable prover already finished")
+	}
+	if rp.phase != phaseDone {
+		return nil, nil, errors.New("proof commitments not complete")
+	}
+	rp.finished = true
+	defer rp.witness.wipe()
+
+	// Compute the Fiat-Shamir challenge c
+	cm := &rp.witness.commitment
+	c := computeProofChallenge(cm.APrime, cm.ABar, cm.D, cm.T1, cm.T2, sortedKeys(rp.disclosed), rp.disclosed, rp.domain, nil)
+
+	disclosed := make(map[int]*big.Int, len(rp.disclosed))
+	for idx, m := range rp.disclosed {
+		disclosed[idx] = new(big.Int).Set(m)
+	}
+
+	return rp.witness.respond(c), disclosed, nil
+}
+
+// wipeInputs clears the signature once the witness holds a copy, and drops
+// the messages, which the witness shares
+func (rp *ResumableProver) wipeInputs() {
+	if rp.signature != nil {
+		secret.WipeInts(rp.signature.E, rp.signature.S)
+		rp.signature = nil
+	}
+	rp.messages = nil
+}
+
+// checkpointState is the plaintext of a checkpoint
+type checkpointState struct {
+	Phase     int              `json:"phase"`
+	Next      int              `json:"next"`
+	Acc       []byte           `json:"acc"`
+	Domain    *big.Int         `json:"domain"`
+	Disclosed map[int]*big.Int `json:"disclosed"`
+
+	// Phase B
+	A        []byte     `json:"a,omitempty"`
+	E        *big.Int   `json:"e,omitempty"`
+	S        *big.Int   `json:"s,omitempty"`
+	Messages []*big.Int `json:"messages,omitempty"`
+
+	// Later phases
+	APrime  []byte           `json:"aPrime,omitempty"`
+	ABar    []byte           `json:"aBar,omitempty"`
+	D       []byte           `json:"d,omitempty"`
+	T1      []byte           `json:"t1,omitempty"`
+	T2      []byte           `json:"t2,omitempty"`
+	R1      *big.Int         `json:"r1,omitempty"`
+	R3      *big.Int         `json:"r3,omitempty"`
+	EBlind  *big.Int         `json:"eBlind,omitempty"`
+	R1Blind *big.Int         `json:"r1Blind,omitempty"`
+	R3Blind *big.Int         `json:"r3Blind,omitempty"`
+	SBlind  *big.Int         `json:"sBlind,omitempty"`
+	Hidden  map[int]*big.Int `json:"hidden,omitempty"`
+	MBlind  map[int]*big.Int `json:"mBlind,omitempty"`
+}
+
+// Checkpoint returns the prover state sealed under key, which must be
+// CheckpointKeySize bytes. The prover stays usable.
+func (rp *ResumableProver) Checkpoint(key []byte) ([]byte, error) {
+	rp.mu.Lock()
+	defer rp.mu.Unlock()
+
+	if rp.finished {
+		return nil, errors.New("resumable prover already finished")
+	}
+
+	acc := g1JacToAffine(rp.acc)
+	accBytes := acc.Bytes()
+	state := checkpointState{
+		Phase:     rp.phase,
+		Next:      rp.next,
+		Acc:       accBytes[:],
+		Domain:    rp.domain,
+		Disclosed: rp.disclosed,
+	}
+
+	if rp.phase == phaseB {
+		a := rp.signature.A.Bytes()
+		state.A = a[:]
+		state.E = rp.signature.E
+		state.S = rp.signature.S
+		state.Messages = rp.messages
+	} else {
+		w := rp.witness
+		state.APrime = pointBytes(w.commitment.APrime)
+		state.ABar = pointBytes(w.commitment.ABar)
+		state.D = pointBytes(w.commitment.D)
+		state.T1 = pointBytes(w.commitment.T1)
+		state.T2 = pointBytes(w.commitment.T2)
+		state.E, state.S, state.R1, state.R3 = w.e.BigInt(), w.s.BigInt(), w.r1.BigInt(), w.r3.BigInt()
+		state.EBlind, state.R1Blind = w.eBlind.BigInt(), w.r1Blind.BigInt()
+		state.R3Blind, state.SBlind = w.r3Blind.BigInt(), w.sBlind.BigInt()
+		state.Hidden = make(map[int]*big.Int, len(w.messages))
+		state.MBlind = make(map[int]*big.Int, len(w.mBlind))
+		for idx, m := range w.messages {
+			state.Hidden[idx] = m.BigInt()
+		}
+		for idx, blind := range w.mBlind {
+			state.MBlind[idx] = blind.BigInt()
+		}
+	}
+
+	plaintext, err := json.Marshal(&state)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode checkpoint: %w", err)
+	}
+	defer secret.WipeBytes(plaintext)
+
+	aead, err := checkpointAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	return aead.Seal(nonce, nonce, plaintext, checkpointAD(rp.publicKey)), nil
+}
+
+// ResumeProver restores a prover from a checkpoint sealed under key for
+// publicKey
+func ResumeProver(publicKey *PublicKey, checkpoint, key []byte) (*ResumableProver, error) {
+	aead, err := checkpointAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(checkpoint) < aead.NonceSize() {
+		return nil, ErrInvalidCheckpoint
+	}
+
+	nonce, ciphertext := checkpoint[:aead.NonceSize()], checkpoint[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, checkpointAD(publicKey))
+	if err != nil {
+		return nil, ErrInvalidCheckpoint
+	}
+	defer secret.WipeBytes(plaintext)
+
+	var state checkpointState
+	if err := json.Unmarshal(plaintext, &state); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCheckpoint, err)
+	}
+
+	rp := &ResumableProver{
+		publicKey: publicKey,
+		disclosed: state.Disclosed,
+		domain:    state.Domain,
+		phase:     state.Phase,
+		next:      state.Next,
+	}
+	if rp.disclosed == nil {
+		rp.disclosed = make(map[int]*big.Int)
+	}
+	if rp.domain == nil || state.Next < 0 {
+		return nil, ErrInvalidCheckpoint
+	}
+	for idx, m := range rp.disclosed {
+		if idx < 0 || idx >= publicKey.messageCount || m == nil {
+			return nil, ErrInvalidCheckpoint
+		}
+	}
+
+	var acc bls12381.G1Affine
+	if _, err := acc.SetBytes(state.Acc); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCheckpoint, err)
+	}
+	rp.acc.FromAffine(&acc)
+
+	switch state.Phase {
+	case phaseB:
+		if len(state.Messages) != publicKey.messageCount || state.Next > len(state.Messages) ||
+			!allSet(append([]*big.Int{state.E, state.S}, state.Messages...)) {
+			return nil, ErrInvalidCheckpoint
+		}
+		rp.signature = &Signature{E: state.E, S: state.S}
+		if _, err := rp.signature.A.SetBytes(state.A); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidCheckpoint, err)
+		}
+		rp.messages = state.Messages
+	case phaseT2, phaseDone:
+		if !allSet([]*big.Int{state.E, state.S, state.R1, state.R3, state.EBlind, state.R1Blind, state.R3Blind, state.SBlind}) ||
+			len(state.MBlind)+len(rp.disclosed) != publicKey.messageCount || state.Next > 2+len(state.MBlind) {
+			return nil, ErrInvalidCheckpoint
+		}
+		for idx, blind := range state.MBlind {
+			if _, disclosed := rp.disclosed[idx]; disclosed || idx < 0 || idx >= publicKey.messageCount ||
+				blind == nil || state.Hidden[idx] == nil {
+				return nil, ErrInvalidCheckpoint
+			}
+		}
+
+		w := &proofWitness{
+			e: ScalarFromBigInt(state.E), s: ScalarFromBigInt(state.S),
+			r1: ScalarFromBigInt(state.R1), r3: ScalarFromBigInt(state.R3),
+			eBlind: ScalarFromBigInt(state.EBlind), r1Blind: ScalarFromBigInt(state.R1Blind),
+			r3Blind: ScalarFromBigInt(state.R3Blind), sBlind: ScalarFromBigInt(state.SBlind),
+			messages: make(map[int]Scalar, len(state.Hidden)),
+			mBlind:   make(map[int]Scalar, len(state.MBlind)),
+		}
+		for idx, blind := range state.MBlind {
+			w.mBlind[idx] = ScalarFromBigInt(blind)
+			w.messages[idx] = ScalarFromBigInt(state.Hidden[idx])
+		}
+		points := []struct {
+			dst *bls12381.G1Affine
+			src []byte
+		}{
+			{&w.commitment.APrime, state.APrime},
+			{&w.commitment.ABar, state.ABar},
+			{&w.commitment.D, state.D},
+			{&w.commitment.T1, state.T1},
+			{&w.commitment.T2, state.T2},
+		}
+		for _, p := range points {
+			if _, err := p.dst.SetBytes(p.src); err != nil {
+				return nil, fmt.Errorf("%w: %v", ErrInvalidCheckpoint, err)
+			}
+		}
+		rp.witness = w
+	default:
+		return nil, ErrInvalidCheckpoint
+	}
+
+	return rp, nil
+}
+
+// checkpointAEAD returns the AES-GCM cipher for key
+func checkpointAEAD(key []byte) (cipher.AEAD, error) {
+	if len(key) != CheckpointKeySize {
+		return nil, fmt.Errorf("checkpoint key must be %d bytes, got %d", CheckpointKeySize, len(key))
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// checkpointAD binds a checkpoint to the public key it was created for
+func checkpointAD(publicKey *PublicKey) []byte {
+	return append([]byte(checkpointAAD), SerializePublicKey(publicKey)...)
+}
+
+// allSet reports whether none of xs is nil
+func allSet(xs []*big.Int) bool {
+	for _, x := range xs {
+		if x == nil {
+			return false
+		}
+	}
+	return true
+}
+
+// pointBytes returns the compressed encoding of p
+func pointBytes(p bls12381.G1Affine) []byte {
+	b := p.Bytes()
+	return b[:]
+}
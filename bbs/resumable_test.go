@@ -0,0 +1,165 @@
+package bbs
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"testing"
+)
+
+func resumableFixture(t *testing.T, messageCount int) (*KeyPair, *Signature, []*big.Int) {
+	t.Helper()
+
+	messages := randomMessages(t, messageCount)
+	keyPair, signature := signedFixture(t, messages, []byte("header"))
+
+	return keyPair, signature, messages
+}
+
+func TestResumableProver_Steps(t *testing.T) {
+	keyPair, signature, messages := resumableFixture(t, 7)
+
+	for _, stepSize := range []int{1, 3, 100} {
+		prover, err := NewResumableProver(keyPair.PublicKey, signature, messages, []int{1, 4}, []byte("header"))
+		if err != nil {
+			t.Fatalf("NewResumableProver failed: %v", err)
+		}
+
+		if _, _, err := prover.Proof(); err == nil {
+			t.Fatal("Proof succeeded before the commitments were done")
+		}
+
+		lastDone := -1
+		for {
+			done, err := prover.Step(stepSize)
+			if err != nil {
+				t.Fatalf("Step failed: %v", err)
+			}
+
+			progress, total := prover.Progress()
+			if progress <= lastDone || progress > total {
+				t.Fatalf("step size %d: progress %d/%d after %d", stepSize, progress, total, lastDone)
+			}
+			lastDone = progress
+
+			if done {
+				if progress != total {
+					t.Fatalf("done at %d/%d", progress, total)
+				}
+				break
+			}
+		}
+
+		proof, disclosed, err := prover.Proof()
+		if err != nil {
+			t.Fatalf("Proof failed: %v", err)
+		}
+		if err := VerifyProof(keyPair.PublicKey, proof, disclosed, []byte("header")); err != nil {
+			t.Fatalf("step size %d: VerifyProof failed: %v", stepSize, err)
+		}
+
+		if _, _, err := prover.Proof(); err == nil {
+			t.Fatal("Proof succeeded twice")
+		}
+	}
+}
+
+func TestResumableProver_Checkpoint(t *testing.T) {
+	keyPair, signature, messages := resumableFixture(t, 6)
+
+	key := make([]byte, CheckpointKeySize)
+	if _, err := rand.Read(key); err != nil {
+		t.Fatalf("rand.Read failed: %v", err)
+	}
+
+	// Checkpoint after every step, covering both phases and the done state
+	prover, err := NewResumableProver(keyPair.PublicKey, signature, messages, []int{0, 5}, []byte("header"))
+	if err != nil {
+		t.Fatalf("NewResumableProver failed: %v", err)
+	}
+
+	for done := false; !done; {
+		checkpoint, err := prover.Checkpoint(key)
+		if err != nil {
+			t.Fatalf("Checkpoint failed: %v", err)
+		}
+
+		prover, err = ResumeProver(keyPair.PublicKey, checkpoint, key)
+		if err != nil {
+			t.Fatalf("ResumeProver failed: %v", err)
+		}
+
+		done, err = prover.Step(2)
+		if err != nil {
+			t.Fatalf("Step failed: %v", err)
+		}
+	}
+
+	checkpoint, err := prover.Checkpoint(key)
+	if err != nil {
+		t.Fatalf("Checkpoint failed: %v", err)
+	}
+
+	proof, disclosed, err := prover.Proof()
+	if err != nil {
+		t.Fatalf("Proof failed: %v", err)
+	}
+	if err := VerifyProof(keyPair.PublicKey, proof, disclosed, []byte("header")); err != nil {
+		t.Fatalf("VerifyProof failed: %v", err)
+	}
+
+	// Resuming after the randomness is drawn gives the same proof again
+	resumed, err := ResumeProver(keyPair.PublicKey, checkpoint, key)
+	if err != nil {
+		t.Fatalf("ResumeProver failed: %v", err)
+	}
+	again, _, err := resumed.Proof()
+	if err != nil {
+		t.Fatalf("Proof failed: %v", err)
+	}
+	if !bytes.Equal(SerializeProof(proof), SerializeProof(again)) {
+		t.Fatal("resumed checkpoint produced a different proof")
+	}
+
+	// Wrong key, wrong public key and tampering are all rejected
+	otherKey := make([]byte, CheckpointKeySize)
+	if _, err := ResumeProver(keyPair.PublicKey, checkpoint, otherKey); !errors.Is(err, ErrInvalidCheckpoint) {
+		t.Fatalf("wrong key: got %v, want ErrInvalidCheckpoint", err)
+	}
+
+	otherKeyPair, _, _ := resumableFixture(t, 6)
+	if _, err := ResumeProver(otherKeyPair.PublicKey, checkpoint, key); !errors.Is(err, ErrInvalidCheckpoint) {
+		t.Fatalf("wrong public key: got %v, want ErrInvalidCheckpoint", err)
+	}
+
+	tampered := append([]byte(nil), checkpoint...)
+	tampered[len(tampered)-1] ^= 1
+	if _, err := ResumeProver(keyPair.PublicKey, tampered, key); !errors.Is(err, ErrInvalidCheckpoint) {
+		t.Fatalf("tampered: got %v, want ErrInvalidCheckpoint", err)
+	}
+
+	if _, err := prover.Checkpoint(key[:16]); err == nil {
+		t.Fatal("Checkpoint accepted a short key")
+	}
+}
+
+func TestResumableProver_ZeroDisclosure(t *testing.T) {
+	keyPair, signature, messages := resumableFixture(t, 3)
+
+	prover, err := NewResumableProver(keyPair.PublicKey, signature, messages, nil, []byte("header"))
+	if err != nil {
+		t.Fatalf("NewResumableProver failed: %v", err)
+	}
+	if done, err := prover.Step(1000); err != nil || !done {
+		t.Fatalf("Step returned %v, %v", done, err)
+	}
+
+	proof, disclosed, err := prover.Proof()
+	if err != nil {
+		t.Fatalf("Proof failed: %v", err)
+	}
+	if err := VerifyProof(keyPair.PublicKey, proof, disclosed, []byte("header")); err != nil {
+		t.Fatalf("VerifyProof failed: %v", err)
+	}
+}
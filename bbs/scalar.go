@@ -0,0 +1,200 @@
+package bbs
+
+import (
+	"fmt"
+	"io"
+	"math/big"
+
+	"github.com/anupsv/bbsplus-signatures/internal/secret"
+	bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381"
+	"github.com/consensys/gnark-crypto/ecc/bls12-381/fr"
+)
+
+// Scalar is an element of the scalar field of BLS12-381. It is backed by
+// fr.Element, so arithmetic does not allocate and Add, Sub, Mul and Neg run
+// in constant time.
+// Signing, verification and proofs use it internally; the big.Int APIs
+// convert at the boundary.
+//
+// The zero value is the scalar 0. Methods follow the z.Op(x, y) form of
+// math/big and gnark-crypto and return z.
+type Scalar struct {
+	e fr.Element
+}
+
+// NewScalar converts x to a Scalar. It fails unless 0 <= x < Order.
+func NewScalar(x *big.Int) (Scalar, error) {
+	if x == nil || x.Sign() < 0 || x.Cmp(Order) >= 0 {
+		return Scalar{}, ErrInvalidFieldElement
+	}
+	var s Scalar
+	s.fromBigInt(x)
+	return s, nil
+}
+
+// ScalarFromBigInt converts x to a Scalar, reducing it modulo Order
+func ScalarFromBigInt(x *big.Int) Scalar {
+	var s Scalar
+	s.fromBigInt(x)
+	return s
+}
+
+// ScalarFromUint64 converts v to a Scalar
+func ScalarFromUint64(v uint64) Scalar {
+	var s Scalar
+	s.e.SetUint64(v)
+	return s
+}
+
+// ScalarFromBytes decodes a canonical big-endian encoding of ScalarSize
+// bytes
+func ScalarFromBytes(b []byte) (Scalar, error) {
+	if len(b) != ScalarSize {
+		return Scalar{}, fmt.Errorf("%w: scalar must be %d bytes, got %d", ErrInvalidFieldElement, ScalarSize, len(b))
+	}
+	var s Scalar
+	if err := s.e.SetBytesCanonical(b); err != nil {
+		return Scalar{}, ErrInvalidFieldElement
+	}
+	return s, nil
+}
+
+// NewRandomScalar samples a uniformly random scalar from rng
+func NewRandomScalar(rng io.Reader) (Scalar, error) {
+	var buf [ScalarSize]byte
+	defer secret.WipeBytes(buf[:])
+
+	// Rejection sampling over the bit length of Order
+	mask := byte(1)<<(Order.BitLen()%8) - 1
+	for {
+		if _, err := io.ReadFull(rng, buf[:]); err != nil {
+			return Scalar{}, fmt.Errorf("failed to generate random bytes: %w", err)
+		}
+		buf[0] &= mask
+
+		e, err := fr.BigEndian.Element(&buf)
+		if err == nil {
+			return Scalar{e: e}, nil
+		}
+	}
+}
+
+// fromBigInt sets z to x reduced modulo Order
+func (z *Scalar) fromBigInt(x *big.Int) *Scalar {
+	z.e.SetBigInt(x)
+	return z
+}
+
+// BigInt returns s as a new big.Int
+func (s *Scalar) BigInt() *big.Int {
+	return s.e.BigInt(new(big.Int))
+}
+
+// Bytes returns the canonical big-endian encoding of s
+func (s *Scalar) Bytes() [ScalarSize]byte {
+	return s.e.Bytes()
+}
+
+// Set sets z to x
+func (z *Scalar) Set(x *Scalar) *Scalar {
+	z.e.Set(&x.e)
+	return z
+}
+
+// SetZero sets z to 0, which also wipes a secret
+func (z *Scalar) SetZero() *Scalar {
+	z.e.SetZero()
+	return z
+}
+
+// Add sets z to x + y
+func (z *Scalar) Add(x, y *Scalar) *Scalar {
+	z.e.Add(&x.e, &y.e)
+	return z
+}
+
+// Sub sets z to x - y
+func (z *Scalar) Sub(x, y *Scalar) *Scalar {
+	z.e.Sub(&x.e, &y.e)
+	return z
+}
+
+// Mul sets z to x * y
+func (z *Scalar) Mul(x, y *Scalar) *Scalar {
+	z.e.Mul(&x.e, &y.e)
+	return z
+}
+
+// Neg sets z to -x
+func (z *Scalar) Neg(x *Scalar) *Scalar {
+	z.e.Neg(&x.e)
+	return z
+}
+
+// Inverse sets z to 1/x. The inverse of 0 is 0.
+func (z *Scalar) Inverse(x *Scalar) *Scalar {
+	z.e.Inverse(&x.e)
+	return z
+}
+
+// IsZero reports whether s is 0
+func (s *Scalar) IsZero() bool {
+	return s.e.IsZero()
+}
+
+// Equal reports whether s and x are equal, in constant time
+func (s *Scalar) Equal(x *Scalar) bool {
+	return s.e.Equal(&x.e)
+}
+
+// String returns s in decimal
+func (s Scalar) String() string {
+	return s.e.String()
+}
+
+// scalarsFromBigInts converts xs, reducing each modulo Order
+func scalarsFromBigInts(xs []*big.Int) []Scalar {
+	out := make([]Scalar, len(xs))
+	for i, x := range xs {
+		out[i].fromBigInt(x)
+	}
+	return out
+}
+
+// newRandomNonZeroScalar samples a uniformly random scalar in [1, Order-1]
+func newRandomNonZeroScalar(rng io.Reader) (Scalar, error) {
+	for {
+		s, err := NewRandomScalar(rng)
+		if err != nil {
+			return Scalar{}, err
+		}
+		if !s.IsZero() {
+			return s, nil
+		}
+	}
+}
+
+// scalarMulSumG1 computes the sum of scalars[i]·points[i] with one scalar
+// multiplication per term. Unlike verifyScratch.sum it is meant for secret
+// scalars, as in signing and proof creation.
+func scalarMulSumG1(points []bls12381.G1Affine, scalars []Scalar) bls12381.G1Jac {
+	var result, term bls12381.G1Jac
+	result.X.SetOne()
+	result.Y.SetOne()
+	result.Z.SetZero()
+
+	// One big.Int carries every scalar into gnark, and its words are wiped
+	// afterwards
+	var k big.Int
+	defer secret.WipeInt(&k)
+
+	for i := range points {
+		if points[i].IsInfinity() {
+			continue
+		}
+		term.FromAffine(&points[i])
+		term.ScalarMultiplication(&term, scalars[i].e.BigInt(&k))
+		result.AddAssign(&term)
+	}
+	return result
+}
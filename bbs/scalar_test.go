@@ -0,0 +1,116 @@
+package bbs
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"testing"
+)
+
+func TestScalarArithmetic(t *testing.T) {
+	for i := 0; i < 20; i++ {
+		xInt, _ := rand.Int(rand.Reader, Order)
+		yInt, _ := rand.Int(rand.Reader, Order)
+		x, y := ScalarFromBigInt(xInt), ScalarFromBigInt(yInt)
+
+		var z Scalar
+		check := func(op string, got *Scalar, want *big.Int) {
+			t.Helper()
+			want.Mod(want, Order)
+			if got.BigInt().Cmp(want) != 0 {
+				t.Fatalf("%s = %s, want %s", op, got, want)
+			}
+		}
+		check("Add", z.Add(&x, &y), new(big.Int).Add(xInt, yInt))
+		check("Sub", z.Sub(&x, &y), new(big.Int).Sub(xInt, yInt))
+		check("Mul", z.Mul(&x, &y), new(big.Int).Mul(xInt, yInt))
+		check("Neg", z.Neg(&x), new(big.Int).Neg(xInt))
+		if !x.IsZero() {
+			check("Inverse", z.Inverse(&x), new(big.Int).ModInverse(xInt, Order))
+		}
+	}
+
+	var zero Scalar
+	if !zero.IsZero() || !zero.Inverse(&zero).IsZero() {
+		t.Fatalf("The inverse of 0 should be 0")
+	}
+
+	// Scalar arithmetic must not allocate
+	x, y := ScalarFromUint64(3), ScalarFromUint64(5)
+	var z Scalar
+	allocs := testing.AllocsPerRun(100, func() {
+		z.Mul(&x, &y)
+		z.Add(&z, &x)
+		z.Inverse(&z)
+	})
+	if allocs != 0 {
+		t.Fatalf("Scalar arithmetic allocated %v times", allocs)
+	}
+}
+
+func TestScalarConversions(t *testing.T) {
+	xInt, _ := rand.Int(rand.Reader, Order)
+	x, err := NewScalar(xInt)
+	if err != nil {
+		t.Fatalf("NewScalar failed: %v", err)
+	}
+	if x.BigInt().Cmp(xInt) != 0 {
+		t.Fatalf("BigInt did not round-trip")
+	}
+
+	// Bytes is the fixed-width encoding used on the wire
+	b := x.Bytes()
+	if !bytes.Equal(b[:], appendScalar(nil, xInt)) {
+		t.Fatalf("Bytes does not match the wire encoding")
+	}
+	y, err := ScalarFromBytes(b[:])
+	if err != nil {
+		t.Fatalf("ScalarFromBytes failed: %v", err)
+	}
+	if !y.Equal(&x) {
+		t.Fatalf("ScalarFromBytes did not round-trip")
+	}
+
+	// Out-of-range values are rejected strictly and reduced leniently
+	for _, bad := range []*big.Int{nil, big.NewInt(-1), Order, new(big.Int).Add(Order, big.NewInt(7))} {
+		if _, err := NewScalar(bad); !errors.Is(err, ErrInvalidFieldElement) {
+			t.Fatalf("NewScalar(%v) = %v, want ErrInvalidFieldElement", bad, err)
+		}
+	}
+	seven := ScalarFromUint64(7)
+	reduced := ScalarFromBigInt(new(big.Int).Add(Order, big.NewInt(7)))
+	if !reduced.Equal(&seven) {
+		t.Fatalf("ScalarFromBigInt did not reduce modulo Order")
+	}
+
+	orderBytes := appendScalar(nil, new(big.Int).Sub(Order, big.NewInt(1)))
+	orderBytes[ScalarSize-1]++
+	if _, err := ScalarFromBytes(orderBytes); !errors.Is(err, ErrInvalidFieldElement) {
+		t.Fatalf("ScalarFromBytes accepted Order: %v", err)
+	}
+	if _, err := ScalarFromBytes(b[:31]); !errors.Is(err, ErrInvalidFieldElement) {
+		t.Fatalf("ScalarFromBytes accepted a short encoding: %v", err)
+	}
+}
+
+func TestNewRandomScalar(t *testing.T) {
+	seen := make(map[[ScalarSize]byte]bool)
+	for i := 0; i < 100; i++ {
+		s, err := NewRandomScalar(rand.Reader)
+		if err != nil {
+			t.Fatalf("NewRandomScalar failed: %v", err)
+		}
+		if s.BigInt().Cmp(Order) >= 0 {
+			t.Fatalf("NewRandomScalar returned a value out of range")
+		}
+		seen[s.Bytes()] = true
+	}
+	if len(seen) != 100 {
+		t.Fatalf("NewRandomScalar repeated values")
+	}
+
+	if _, err := NewRandomScalar(bytes.NewReader(nil)); err == nil {
+		t.Fatalf("Expected an error from an empty reader")
+	}
+}
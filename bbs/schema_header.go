@@ -0,0 +1,29 @@
+package bbs
+
+import "encoding/binary"
+
+// Domain separation tag for schema bound headers
+const schemaDST = "BBS_BLS12381_SCHEMA_"
+
+// An issuer binds a credential to its schema by signing under
+// SchemaHeader(header, schemaHash), with a hash of the attribute names,
+// types and encodings in signing order. A verifier rebuilds the header from
+// the schema it expects, so a proof cannot be checked against another
+// schema that gives the same message indices different meanings. Combined
+// with an audience the header is AudienceHeader(SchemaHeader(header,
+// schemaHash), audience).
+
+// SchemaHeader returns header bound to schemaHash:
+// DST || len(schemaHash) || schemaHash || header, with the length as 8
+// bytes big-endian. An empty schemaHash returns header unchanged.
+func SchemaHeader(header, schemaHash []byte) []byte {
+	if len(schemaHash) == 0 {
+		return header
+	}
+
+	out := make([]byte, 0, len(schemaDST)+8+len(schemaHash)+len(header))
+	out = append(out, schemaDST...)
+	out = binary.BigEndian.AppendUint64(out, uint64(len(schemaHash)))
+	out = append(out, schemaHash...)
+	return append(out, header...)
+}
@@ -0,0 +1,46 @@
+package bbs
+
+import (
+	"bytes"
+	"crypto/rand"
+	"math/big"
+	"testing"
+)
+
+func TestSchemaHeader(t *testing.T) {
+	keyPair, err := GenerateKeyPair(2, rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key pair: %v", err)
+	}
+	messages := []*big.Int{big.NewInt(1), big.NewInt(2)}
+	schemaHash := bytes.Repeat([]byte{0xab}, 32)
+
+	signature, err := Sign(keyPair.PrivateKey, keyPair.PublicKey, messages, SchemaHeader(nil, schemaHash))
+	if err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+	proof, disclosed, err := CreateProof(keyPair.PublicKey, signature, messages, []int{1}, SchemaHeader(nil, schemaHash))
+	if err != nil {
+		t.Fatalf("CreateProof failed: %v", err)
+	}
+	if err := VerifyProof(keyPair.PublicKey, proof, disclosed, SchemaHeader(nil, schemaHash)); err != nil {
+		t.Fatalf("VerifyProof failed for the schema: %v", err)
+	}
+
+	// A verifier expecting another schema, or none, rejects the proof
+	other := bytes.Repeat([]byte{0xcd}, 32)
+	if err := VerifyProof(keyPair.PublicKey, proof, disclosed, SchemaHeader(nil, other)); err == nil {
+		t.Fatalf("Expected verification to fail for another schema")
+	}
+	if err := VerifyProof(keyPair.PublicKey, proof, disclosed, nil); err == nil {
+		t.Fatalf("Expected verification to fail without the schema")
+	}
+
+	// Schema hash and header cannot be shifted into each other
+	if bytes.Equal(SchemaHeader([]byte("bc"), []byte("a")), SchemaHeader([]byte("c"), []byte("ab"))) {
+		t.Fatalf("Schema headers collide")
+	}
+	if !bytes.Equal(SchemaHeader([]byte("issuer/v1"), nil), []byte("issuer/v1")) {
+		t.Fatalf("Expected an empty schema hash to leave the header unchanged")
+	}
+}
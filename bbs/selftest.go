@@ -0,0 +1,334 @@
+package bbs
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381"
+)
+
+// ErrSelfTestFailed is returned when the self-test does not pass
+var ErrSelfTestFailed = errors.New("self-test failed")
+
+// Known-answer vectors. The key, messages and headers are derived from fixed
+// labels; the signature is DeterministicSign over them and the proof
+// discloses selfTestDisclosed under selfTestPresentationHeader.
+const (
+	selfTestSignatureHex = "" +
+		"b6189c8a2147d2a79ef97445d095e2ee0a3d0fa915563c835f23dea3ee200ea4" +
+		"c0d189d18df5a72ba50eab124a60e5564596454be4cd509b4ced9556dc8e3688" +
+		"89e093496329ec2208a6e5dfc2cd004350c2f5ddb5295c7bd91b5093ba405066" +
+		"e0c5a18d063af3528daabfa8aa25f19a"
+
+	selfTestProofHex = "" +
+		"af1f124e9b575a95414b0011694737303539a2fd831fbcdc3bc4a28d40f5c8db" +
+		"510575118a0ee3485e2a95a2b1ae36e297932b5cfe8fc59e42ed39d56144a4c1" +
+		"a2357dda68207d69701514179ab0adf945e99e47ca294c61085d5d66c20fcd25" +
+		"aee8d496b9be9319ec02de09a32f7e48028965c19adf5fe90c1b8c723df94793" +
+		"81d1ab592fb6f0058cd845cbad596b9a1c39b558a0976ecb9a0cb3ce9be89f5e" +
+		"e6f78ee8bc1190ad071495634e3f648819da06069f9a496261b335509bf9265d" +
+		"74018c4cf74c52272e19c37c47473a136d21ed1b19f2d8e9c95c67a86d00c9da" +
+		"b444731a479f4e1e8c7829737a77dd4e2de76089e650a59171082a38724b5802" +
+		"6e3a80c56f6da8e71949e6dba872c21e540bd72857ddb87fea4d84dfd9f7b258" +
+		"77cd67464d417a08f206840d1339dc30000000010000000133d3e0447a919b4b" +
+		"bb4f6068c52815b2e9d390f8757b658a124f98df80730a71"
+)
+
+var (
+	selfTestHeader             = []byte("BBS_SELF_TEST_HEADER")
+	selfTestPresentationHeader = []byte("BBS_SELF_TEST_PRESENTATION_HEADER")
+	selfTestDisclosed          = []int{0, 2}
+)
+
+// selfTestMessageCount is the number of messages in the known-answer vectors
+const selfTestMessageCount = 3
+
+// SelfTestResult is the outcome of one self-test
+type SelfTestResult struct {
+	Name     string        `json:"name"`
+	Passed   bool          `json:"passed"`
+	Error    string        `json:"error,omitempty"`
+	Duration time.Duration `json:"duration"`
+}
+
+// SelfTestReport is the outcome of SelfTest
+type SelfTestReport struct {
+	Passed  bool             `json:"passed"`
+	Results []SelfTestResult `json:"results"`
+}
+
+// Err returns nil if every test passed, or an error wrapping
+// ErrSelfTestFailed that names the failed tests
+func (r *SelfTestReport) Err() error {
+	if r.Passed {
+		return nil
+	}
+	var failed []string
+	for _, result := range r.Results {
+		if !result.Passed {
+			failed = append(failed, fmt.Sprintf("%s: %s", result.Name, result.Error))
+		}
+	}
+	return fmt.Errorf("%w: %s", ErrSelfTestFailed, strings.Join(failed, "; "))
+}
+
+// SelfTest runs the known-answer tests for signing, signature verification
+// and proof verification, a proof round trip, a pairing sanity check and a
+// health check of the system random number generator. Deployments that must
+// not operate on a faulty module run it at startup, or configure the Engine
+// with WithRequiredSelfTest.
+func SelfTest() *SelfTestReport {
+	return selfTest(rand.Reader)
+}
+
+// selfTest runs the self-tests drawing randomness from rng
+func selfTest(rng io.Reader) *SelfTestReport {
+	tests := []struct {
+		name string
+		run  func() error
+	}{
+		{"pairing", selfTestPairing},
+		{"rng-health", func() error { return selfTestRNG(rng) }},
+		{"sign-kat", selfTestSign},
+		{"verify-kat", selfTestVerify},
+		{"proof-kat", selfTestProofVerify},
+		{"proof-roundtrip", selfTestProofRoundTrip},
+	}
+
+	report := &SelfTestReport{Passed: true}
+	for _, test := range tests {
+		start := time.Now()
+		err := runSelfTest(test.run)
+		result := SelfTestResult{Name: test.name, Passed: err == nil, Duration: time.Since(start)}
+		if err != nil {
+			result.Error = err.Error()
+			report.Passed = false
+		}
+		report.Results = append(report.Results, result)
+	}
+
+	return report
+}
+
+// runSelfTest runs one test, turning a panic into a failure
+func runSelfTest(run func() error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return run()
+}
+
+var (
+	selfTestOnce   sync.Once
+	selfTestResult *SelfTestReport
+)
+
+// cachedSelfTest runs SelfTest once per process
+func cachedSelfTest() *SelfTestReport {
+	selfTestOnce.Do(func() {
+		selfTestResult = SelfTest()
+	})
+	return selfTestResult
+}
+
+// selfTestKeyPair returns the fixed key pair of the known-answer vectors
+func selfTestKeyPair() *KeyPair {
+	seed := sha256.Sum256([]byte("BBS_SELF_TEST_KEY"))
+	x := new(big.Int).SetBytes(seed[:])
+	x.Mod(x, Order)
+
+	_, _, g1, g2 := bls12381.Generators()
+	var w bls12381.G2Affine
+	w.ScalarMultiplication(&g2, x)
+
+	return &KeyPair{
+		PrivateKey: &PrivateKey{X: x},
+		PublicKey: &PublicKey{
+			w:            w,
+			g2:           g2,
+			g1:           g1,
+			h:            GenerateGenerators(selfTestMessageCount + 2),
+			messageCount: selfTestMessageCount,
+		},
+	}
+}
+
+// selfTestMessages returns the messages of the known-answer vectors
+func selfTestMessages() []*big.Int {
+	messages := make([]*big.Int, selfTestMessageCount)
+	for i := range messages {
+		messages[i] = MessageToFieldElement([]byte(fmt.Sprintf("BBS_SELF_TEST_MESSAGE_%d", i)))
+	}
+	return messages
+}
+
+// selfTestDisclosedMessages returns the disclosed messages of the proof vector
+func selfTestDisclosedMessages() map[int]*big.Int {
+	messages := selfTestMessages()
+	disclosed := make(map[int]*big.Int, len(selfTestDisclosed))
+	for _, idx := range selfTestDisclosed {
+		disclosed[idx] = messages[idx]
+	}
+	return disclosed
+}
+
+// selfTestPairing checks bilinearity and non-degeneracy of the pairing
+func selfTestPairing() error {
+	_, _, g1, g2 := bls12381.Generators()
+	a, b := big.NewInt(0x5eed), big.NewInt(0xbb5)
+
+	var aG1, abG1 bls12381.G1Affine
+	var bG2 bls12381.G2Affine
+	aG1.ScalarMultiplication(&g1, a)
+	abG1.ScalarMultiplication(&g1, new(big.Int).Mul(a, b))
+	bG2.ScalarMultiplication(&g2, b)
+
+	base, err := bls12381.Pair([]bls12381.G1Affine{g1}, []bls12381.G2Affine{g2})
+	if err != nil {
+		return err
+	}
+	if base.IsOne() {
+		return errors.New("pairing is degenerate")
+	}
+
+	// e(a*P, b*Q) = e(ab*P, Q)
+	lhs, err := bls12381.Pair([]bls12381.G1Affine{aG1}, []bls12381.G2Affine{bG2})
+	if err != nil {
+		return err
+	}
+	rhs, err := bls12381.Pair([]bls12381.G1Affine{abG1}, []bls12381.G2Affine{g2})
+	if err != nil {
+		return err
+	}
+	if !lhs.Equal(&rhs) {
+		return errors.New("pairing is not bilinear")
+	}
+
+	return nil
+}
+
+// selfTestRNG draws blocks from rng and rejects a source that fails,
+// returns all zeros or repeats a block, as the continuous random number
+// generator test of FIPS 140-2 does
+func selfTestRNG(rng io.Reader) error {
+	const blocks = 16
+	var prev, block [32]byte
+	zero := [32]byte{}
+
+	for i := 0; i < blocks; i++ {
+		if _, err := io.ReadFull(rng, block[:]); err != nil {
+			return fmt.Errorf("random source failed: %w", err)
+		}
+		if block == zero {
+			return errors.New("random source returned zeros")
+		}
+		if i > 0 && block == prev {
+			return errors.New("random source repeated a block")
+		}
+		prev = block
+	}
+
+	return nil
+}
+
+// selfTestSign checks DeterministicSign against the signature vector
+func selfTestSign() error {
+	keyPair := selfTestKeyPair()
+	signature, err := DeterministicSign(keyPair.PrivateKey, keyPair.PublicKey, selfTestMessages(), selfTestHeader, nil)
+	if err != nil {
+		return err
+	}
+
+	if hex.EncodeToString(SerializeSignature(signature)) != selfTestSignatureHex {
+		return errors.New("signature does not match the known answer")
+	}
+	return nil
+}
+
+// selfTestVerify checks that the signature vector verifies and a modified
+// message does not
+func selfTestVerify() error {
+	keyPair := selfTestKeyPair()
+	signature, err := DeserializeSignature(mustDecodeHex(selfTestSignatureHex))
+	if err != nil {
+		return err
+	}
+
+	messages := selfTestMessages()
+	if err := Verify(keyPair.PublicKey, signature, messages, selfTestHeader); err != nil {
+		return fmt.Errorf("known-answer signature rejected: %w", err)
+	}
+
+	messages[1] = new(big.Int).Add(messages[1], big.NewInt(1))
+	if err := Verify(keyPair.PublicKey, signature, messages, selfTestHeader); err == nil {
+		return errors.New("signature over modified messages accepted")
+	}
+	return nil
+}
+
+// selfTestProofVerify checks that the proof vector verifies and fails with
+// a modified disclosed message or presentation header
+func selfTestProofVerify() error {
+	keyPair := selfTestKeyPair()
+	proof, err := DeserializeProof(mustDecodeHex(selfTestProofHex))
+	if err != nil {
+		return err
+	}
+
+	disclosed := selfTestDisclosedMessages()
+	if err := VerifyProofWithPresentationHeader(keyPair.PublicKey, proof, disclosed, selfTestHeader, selfTestPresentationHeader); err != nil {
+		return fmt.Errorf("known-answer proof rejected: %w", err)
+	}
+
+	if err := VerifyProofWithPresentationHeader(keyPair.PublicKey, proof, disclosed, selfTestHeader, nil); err == nil {
+		return errors.New("proof accepted without its presentation header")
+	}
+	disclosed[0] = new(big.Int).Add(disclosed[0], big.NewInt(1))
+	if err := VerifyProofWithPresentationHeader(keyPair.PublicKey, proof, disclosed, selfTestHeader, selfTestPresentationHeader); err == nil {
+		return errors.New("proof over modified messages accepted")
+	}
+	return nil
+}
+
+// selfTestProofRoundTrip creates a fresh proof from the signature vector
+// and verifies it
+func selfTestProofRoundTrip() error {
+	keyPair := selfTestKeyPair()
+	signature, err := DeserializeSignature(mustDecodeHex(selfTestSignatureHex))
+	if err != nil {
+		return err
+	}
+
+	proof, disclosed, err := CreateProofWithPresentationHeader(
+		keyPair.PublicKey, signature, selfTestMessages(), selfTestDisclosed, selfTestHeader, selfTestPresentationHeader,
+	)
+	if err != nil {
+		return err
+	}
+
+	// Fresh proofs are randomized, so they must differ from the vector
+	if bytes.Equal(SerializeProof(proof), mustDecodeHex(selfTestProofHex)) {
+		return errors.New("proof randomness repeated")
+	}
+	return VerifyProofWithPresentationHeader(keyPair.PublicKey, proof, disclosed, selfTestHeader, selfTestPresentationHeader)
+}
+
+// mustDecodeHex decodes a constant hex vector
+func mustDecodeHex(s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		panic(fmt.Sprintf("bbs: invalid self-test vector: %v", err))
+	}
+	return b
+}
@@ -0,0 +1,98 @@
+package bbs
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"io"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+func TestSelfTest(t *testing.T) {
+	report := SelfTest()
+	if err := report.Err(); err != nil {
+		t.Fatalf("SelfTest failed: %v", err)
+	}
+
+	names := make([]string, len(report.Results))
+	for i, result := range report.Results {
+		names[i] = result.Name
+	}
+	if got := strings.Join(names, ","); got != "pairing,rng-health,sign-kat,verify-kat,proof-kat,proof-roundtrip" {
+		t.Fatalf("Unexpected self-tests: %s", got)
+	}
+}
+
+// repeatReader returns the same block forever
+type repeatReader struct{}
+
+func (repeatReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = byte(i%32 + 1)
+	}
+	return len(p), nil
+}
+
+func TestSelfTestRNGFailures(t *testing.T) {
+	for name, rng := range map[string]io.Reader{
+		"zeros":    bytes.NewReader(make([]byte, 1024)),
+		"repeated": repeatReader{},
+		"short":    bytes.NewReader([]byte{1, 2, 3}),
+	} {
+		report := selfTest(rng)
+		if report.Passed {
+			t.Fatalf("%s: expected the self-test to fail", name)
+		}
+		err := report.Err()
+		if !errors.Is(err, ErrSelfTestFailed) || !strings.Contains(err.Error(), "rng-health") {
+			t.Fatalf("%s: unexpected error %v", name, err)
+		}
+	}
+}
+
+func TestDeterministicSignScalars(t *testing.T) {
+	keyPair := selfTestKeyPair()
+	messages := selfTestMessages()
+
+	signature, err := DeterministicSign(keyPair.PrivateKey, keyPair.PublicKey, messages, nil, nil)
+	if err != nil {
+		t.Fatalf("DeterministicSign failed: %v", err)
+	}
+	if signature.E.Cmp(signature.S) == 0 {
+		t.Fatalf("DeterministicSign derived e and s from the same stream")
+	}
+	if err := Verify(keyPair.PublicKey, signature, messages, nil); err != nil {
+		t.Fatalf("Verify failed: %v", err)
+	}
+}
+
+func TestEngineRequiredSelfTest(t *testing.T) {
+	keyPair, err := GenerateKeyPair(2, rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key pair: %v", err)
+	}
+	messages := []*big.Int{big.NewInt(1), big.NewInt(2)}
+
+	engine := NewEngine(WithSigner(NewLocalSigner(keyPair)), WithRequiredSelfTest())
+	signature, err := engine.Sign(messages, nil)
+	if err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+
+	// An engine whose self-test failed refuses every operation
+	engine.selfTestErr = selfTest(bytes.NewReader(nil)).Err()
+	if _, err := engine.Sign(messages, nil); !errors.Is(err, ErrSelfTestFailed) {
+		t.Fatalf("Sign: expected ErrSelfTestFailed, got %v", err)
+	}
+	if err := engine.Verify(keyPair.PublicKey, signature, messages, nil); !errors.Is(err, ErrSelfTestFailed) {
+		t.Fatalf("Verify: expected ErrSelfTestFailed, got %v", err)
+	}
+	if _, _, err := engine.CreateProof(keyPair.PublicKey, signature, messages, []int{0}, nil); !errors.Is(err, ErrSelfTestFailed) {
+		t.Fatalf("CreateProof: expected ErrSelfTestFailed, got %v", err)
+	}
+	if err := engine.VerifyProof(keyPair.PublicKey, &ProofOfKnowledge{}, nil, nil); !errors.Is(err, ErrSelfTestFailed) {
+		t.Fatalf("VerifyProof: expected ErrSelfTestFailed, got %v", err)
+	}
+}
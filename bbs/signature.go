@@ -12,7 +12,7 @@ import (
 // Implementation follows the IRTF cfrg-bbs-signatures specification
 func Sign(sk *PrivateKey, pk *PublicKey, messages []*big.Int, header []byte) (*Signature, error) {
 	// Validate inputs
-	if len(messages) != pk.MessageCount {
+	if len(messages) != pk.messageCount {
 		return nil, ErrInvalidMessageCount
 	}
 	
@@ -30,52 +30,35 @@ func Sign(sk *PrivateKey, pk *PublicKey, messages []*big.Int, header []byte) (*S
 		return nil, fmt.Errorf("failed to generate random value s: %w", err)
 	}
 
-	// Compute B = P1 * (1) + Q1 * (s) + Q2 * (domain) + H1 * (m1) + ... + HL * (mL)
-	// In our implementation:
-	// - P1 is pk.G1
-	// - Q1 is pk.H[0]
-	// - Q2 is pk.H[1]
-	// - H1...HL are pk.H[2...]
-	
-	// Start with g1 (P1)
-	BJac := bls12381.G1Jac{}
-	BJac.FromAffine(&pk.G1)
-
-	// Add Q1 * s
-	q1sJac := bls12381.G1Jac{}
-	q1sJac.FromAffine(&pk.H[0])
-	q1sJac.ScalarMultiplication(&q1sJac, s)
-	BJac.AddAssign(&q1sJac)
-	
-	// Add Q2 * domain
-	q2domJac := bls12381.G1Jac{}
-	q2domJac.FromAffine(&pk.H[1])
-	q2domJac.ScalarMultiplication(&q2domJac, domain)
-	BJac.AddAssign(&q2domJac)
+	return signWithScalars(sk, pk, messages, domain, e, s)
+}
 
-	// Add each H_i * m_i
-	for i, m := range messages {
-		hiJac := bls12381.G1Jac{}
-		hiJac.FromAffine(&pk.H[i+2]) // +2 because H[0] is Q1, H[1] is Q2
-		hiJac.ScalarMultiplication(&hiJac, m)
-		BJac.AddAssign(&hiJac)
-	}
-	
-	// Convert to affine
-	B := g1JacToAffine(BJac)
+// signWithScalars computes the signature with the given e and s
+func signWithScalars(sk *PrivateKey, pk *PublicKey, messages []*big.Int, domain, e, s *big.Int) (*Signature, error) {
+	// Compute B = P1 + Q1*s + Q2*domain + H1*m1 + ... + HL*mL
+	B := computeB(pk, s, domain, messages)
 
+	return signB(sk, B, e, s)
+}
+
+// signB completes a signature once B is known
+func signB(sk *PrivateKey, B bls12381.G1Affine, e, s *big.Int) (*Signature, error) {
 	// Compute A = B^(1/(x+e))
-	// First, compute 1/(x+e)
-	xPlusE := new(big.Int).Add(sk.X, e)
-	inv := new(big.Int).ModInverse(xPlusE, Order)
-	if inv == nil {
+	// First, compute 1/(x+e) in the scalar field
+	x := ScalarFromBigInt(sk.X)
+	defer x.SetZero()
+	eScalar := ScalarFromBigInt(e)
+
+	var inv Scalar
+	defer inv.SetZero()
+	inv.Add(&x, &eScalar)
+	if inv.IsZero() {
 		return nil, fmt.Errorf("failed to compute modular inverse")
 	}
+	inv.Inverse(&inv)
 
 	// Then, compute A = B^(1/(x+e))
-	AJac := bls12381.G1Jac{}
-	AJac.FromAffine(&B)
-	AJac.ScalarMultiplication(&AJac, inv)
+	AJac := scalarMulSumG1([]bls12381.G1Affine{B}, []Scalar{inv})
 	
 	// Convert to affine
 	A := g1JacToAffine(AJac)
@@ -91,73 +74,56 @@ func Sign(sk *PrivateKey, pk *PublicKey, messages []*big.Int, header []byte) (*S
 // Implementation follows the IRTF cfrg-bbs-signatures specification
 func Verify(pk *PublicKey, signature *Signature, messages []*big.Int, header []byte) error {
 	// Validate inputs
-	if len(messages) != pk.MessageCount {
+	if len(messages) != pk.messageCount {
 		return ErrInvalidMessageCount
 	}
 
-	// Calculate domain value
-	domain := CalculateDomain(pk, header)
-	
-	// Recompute B = P1 * (1) + Q1 * (s) + Q2 * (domain) + H1 * (m1) + ... + HL * (mL)
-	// Start with g1 (P1)
-	BJac := bls12381.G1Jac{}
-	BJac.FromAffine(&pk.G1)
-
-	// Add Q1 * s
-	q1sJac := bls12381.G1Jac{}
-	q1sJac.FromAffine(&pk.H[0])
-	q1sJac.ScalarMultiplication(&q1sJac, signature.S)
-	BJac.AddAssign(&q1sJac)
-	
-	// Add Q2 * domain
-	q2domJac := bls12381.G1Jac{}
-	q2domJac.FromAffine(&pk.H[1])
-	q2domJac.ScalarMultiplication(&q2domJac, domain)
-	BJac.AddAssign(&q2domJac)
+	return verifyWithDomain(pk, signature, messages, CalculateDomain(pk, header))
+}
 
-	// Add each H_i * m_i
+// verifyWithDomain checks a signature against a precomputed domain.
+//
+// The specification checks e(A, W + P2*e) * e(B, -P2) = 1 with
+// B = P1 + Q1*s + Q2*domain + H1*m1 + ... + HL*mL. Since
+// e(A, P2*e) = e(A*e, P2), this is the same as e(A, W) * e(B - A*e, -P2) = 1,
+// which moves the G2 scalar multiplication into the single G1 multi-scalar
+// multiplication that computes B - A*e.
+func verifyWithDomain(pk *PublicKey, signature *Signature, messages []*big.Int, domain *big.Int) error {
+	if len(pk.h) < len(messages)+2 {
+		return ErrInvalidMessageCount
+	}
+
+	scratch := getVerifyScratch()
+	defer putVerifyScratch(scratch)
+
+	// Queue Q1*s + Q2*domain + H1*m1 + ... + HL*mL - A*e
+	var negA bls12381.G1Affine
+	negA.Neg(&signature.A)
+
+	var scalar Scalar
+	scratch.add(&pk.h[0], scalar.fromBigInt(signature.S))
+	scratch.add(&pk.h[1], scalar.fromBigInt(domain))
 	for i, m := range messages {
-		hiJac := bls12381.G1Jac{}
-		hiJac.FromAffine(&pk.H[i+2]) // +2 because H[0] is Q1, H[1] is Q2
-		hiJac.ScalarMultiplication(&hiJac, m)
-		BJac.AddAssign(&hiJac)
+		scratch.add(&pk.h[i+2], scalar.fromBigInt(m)) // +2 because H[0] is Q1, H[1] is Q2
 	}
-	
-	// Convert to affine
-	B := g1JacToAffine(BJac)
-
-	// Compute w * g2^e = W + P2 * e
-	// Start with w (same as W)
-	wg2eJac := bls12381.G2Jac{}
-	wg2eJac.FromAffine(&pk.W)
-
-	// Add g2^e (P2 * e)
-	g2eJac := bls12381.G2Jac{}
-	g2eJac.FromAffine(&pk.G2)
-	g2eJac.ScalarMultiplication(&g2eJac, signature.E)
-	wg2eJac.AddAssign(&g2eJac)
-	
-	// Convert to affine
-	wg2e := g2JacToAffine(wg2eJac)
-
-	// Negate g2 for the second pairing
-	negG2Jac := bls12381.G2Jac{}
-	negG2Jac.FromAffine(&pk.G2)
-	negG2Jac.Neg(&negG2Jac)
-	negG2 := g2JacToAffine(negG2Jac)
-
-	// Check e(A, W + P2*e) * e(B, -P2) = 1
-	// This is equivalent to e(A, W + P2*e) = e(B, P2)
-	pairingResult, err := bls12381.Pair(
-		[]bls12381.G1Affine{signature.A, B},
-		[]bls12381.G2Affine{wg2e, negG2},
-	)
+	scratch.add(&negA, scalar.fromBigInt(signature.E))
+
+	// Add P1 to complete B - A*e
+	var bJac bls12381.G1Jac
+	scratch.sum(&bJac)
+	bJac.AddMixed(&pk.g1)
+
+	scratch.pairingG1[0] = signature.A
+	scratch.pairingG1[1].FromJacobian(&bJac)
+	scratch.pairingG2[0] = pk.w
+	scratch.pairingG2[1].Neg(&pk.g2)
+
+	ok, err := pairingCheck(scratch.pairingG1[:], scratch.pairingG2[:])
 	if err != nil {
 		return ErrPairingFailed
 	}
-	
-	// Check if the pairing result is 1
-	if !pairingResult.IsOne() {
+
+	if !ok {
 		return ErrInvalidSignature
 	}
 
@@ -173,7 +139,7 @@ func SignWithCommitment(
 	commitments map[int]*big.Int,
 	header []byte,
 ) (*Signature, error) {
-	if len(messages) != pk.MessageCount {
+	if len(messages) != pk.messageCount {
 		return nil, ErrInvalidMessageCount
 	}
 	
@@ -201,7 +167,7 @@ func VerifyWithCommitment(
 	commitIndices []int,
 	header []byte,
 ) error {
-	if len(messages) != pk.MessageCount {
+	if len(messages) != pk.messageCount {
 		return ErrInvalidMessageCount
 	}
 	
@@ -220,3 +220,43 @@ func TestSignatureManager_DomainCaching(t *testing.T) {
 	// We can't directly verify the cache behavior, but we can ensure
 	// that the code handles cache cleanup properly
 }
+
+func TestSignatureManager_SeededKeysShareSecret(t *testing.T) {
+	// One secret key under two generator seeds gives keys with the same W
+	// and message count but different generators
+	seedA, err := NewGeneratorSeed()
+	if err != nil {
+		t.Fatalf("NewGeneratorSeed failed: %v", err)
+	}
+	seedB, err := NewGeneratorSeed()
+	if err != nil {
+		t.Fatalf("NewGeneratorSeed failed: %v", err)
+	}
+	keyPair, err := GenerateKeyPairWithSeed(3, seedA, rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKeyPairWithSeed failed: %v", err)
+	}
+	compact, err := NewCompactPublicKey(keyPair.PublicKey.w, 3, seedB)
+	if err != nil {
+		t.Fatalf("NewCompactPublicKey failed: %v", err)
+	}
+	pkB, err := compact.Expand()
+	if err != nil {
+		t.Fatalf("Expand failed: %v", err)
+	}
+
+	messages := []*big.Int{big.NewInt(1), big.NewInt(2), big.NewInt(3)}
+	manager := NewSignatureManager(nil, 0)
+	for _, pk := range []*PublicKey{keyPair.PublicKey, pkB} {
+		signature, err := manager.SignWithPooling(keyPair.PrivateKey, pk, messages, nil)
+		if err != nil {
+			t.Fatalf("SignWithPooling failed: %v", err)
+		}
+		if err := Verify(pk, signature, messages, nil); err != nil {
+			t.Fatalf("Signature from the manager does not verify: %v", err)
+		}
+		if err := manager.VerifyWithPooling(pk, signature, messages, nil); err != nil {
+			t.Fatalf("VerifyWithPooling failed: %v", err)
+		}
+	}
+}
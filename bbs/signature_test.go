@@ -0,0 +1,110 @@
+package bbs
+
+import (
+	"math/big"
+	"testing"
+)
+
+// verifyAllocTarget bounds the allocations of one Verify call. What remains
+// is the domain hash and the input slices of gnark-crypto's Miller loop; the
+// final exponentiation and the multi-scalar multiplication do not allocate.
+const verifyAllocTarget = 10
+
+// verifyFixture signs a 10-message credential
+func verifyFixture(tb testing.TB) (*KeyPair, *Signature, []*big.Int, []byte) {
+	tb.Helper()
+
+	messages := randomMessages(tb, 10)
+	header := []byte("verify header")
+	keyPair, signature := signedFixture(tb, messages, header)
+
+	return keyPair, signature, messages, header
+}
+
+func TestVerifyAllocations(t *testing.T) {
+	if raceEnabled {
+		t.Skip("allocation counts are not stable under the race detector")
+	}
+
+	keyPair, signature, messages, header := verifyFixture(t)
+
+	verifyAllocs := testing.AllocsPerRun(20, func() {
+		if err := Verify(keyPair.PublicKey, signature, messages, header); err != nil {
+			t.Fatalf("Verify failed: %v", err)
+		}
+	})
+	if verifyAllocs >= verifyAllocTarget {
+		t.Fatalf("Verify made %.0f allocations, target is under %d", verifyAllocs, verifyAllocTarget)
+	}
+
+	pooledAllocs := testing.AllocsPerRun(20, func() {
+		if err := VerifyWithPooling(keyPair.PublicKey, signature, messages, header); err != nil {
+			t.Fatalf("VerifyWithPooling failed: %v", err)
+		}
+	})
+	if pooledAllocs >= verifyAllocTarget {
+		t.Fatalf("VerifyWithPooling made %.0f allocations, target is under %d", pooledAllocs, verifyAllocTarget)
+	}
+}
+
+func TestVerifyRejectsModifiedSignature(t *testing.T) {
+	keyPair, signature, messages, header := verifyFixture(t)
+
+	// Tamper with e, s and A in turn
+	tampered := *signature
+	tampered.E = new(big.Int).Add(signature.E, big.NewInt(1))
+	if err := Verify(keyPair.PublicKey, &tampered, messages, header); err != ErrInvalidSignature {
+		t.Fatalf("Expected ErrInvalidSignature for modified e, got %v", err)
+	}
+
+	tampered = *signature
+	tampered.S = new(big.Int).Add(signature.S, big.NewInt(1))
+	if err := Verify(keyPair.PublicKey, &tampered, messages, header); err != ErrInvalidSignature {
+		t.Fatalf("Expected ErrInvalidSignature for modified s, got %v", err)
+	}
+
+	tampered = *signature
+	tampered.A.Add(&signature.A, &keyPair.PublicKey.g1)
+	if err := Verify(keyPair.PublicKey, &tampered, messages, header); err != ErrInvalidSignature {
+		t.Fatalf("Expected ErrInvalidSignature for modified A, got %v", err)
+	}
+
+	// Scalars outside [0, Order) are reduced rather than misread
+	unreduced := make([]*big.Int, len(messages))
+	for i, m := range messages {
+		unreduced[i] = new(big.Int).Add(m, Order)
+	}
+	if err := Verify(keyPair.PublicKey, signature, unreduced, header); err != nil {
+		t.Fatalf("Verify with unreduced messages failed: %v", err)
+	}
+}
+
+// BenchmarkVerify measures verification of a 10-message credential. Profile
+// allocations with:
+//
+//	go test ./bbs -run '^$' -bench BenchmarkVerify -benchmem -memprofile mem.out
+//	go tool pprof -sample_index=alloc_objects mem.out
+func BenchmarkVerify(b *testing.B) {
+	keyPair, signature, messages, header := verifyFixture(b)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := Verify(keyPair.PublicKey, signature, messages, header); err != nil {
+			b.Fatalf("Verify failed: %v", err)
+		}
+	}
+}
+
+// BenchmarkVerifyWithPooling measures verification through the cached domain
+func BenchmarkVerifyWithPooling(b *testing.B) {
+	keyPair, signature, messages, header := verifyFixture(b)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := VerifyWithPooling(keyPair.PublicKey, signature, messages, header); err != nil {
+			b.Fatalf("VerifyWithPooling failed: %v", err)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package bbs
+
+import (
+	"math/big"
+)
+
+// SignerBackend creates BBS+ signatures with a private key that may be held
+// outside the process, such as in an HSM or a cloud KMS
+type SignerBackend interface {
+	// PublicKey returns the public key matching the backend's private key
+	PublicKey() *PublicKey
+
+	// Sign creates a BBS+ signature on messages
+	Sign(messages []*big.Int, header []byte) (*Signature, error)
+}
+
+// LocalSigner is a SignerBackend holding the private key in process memory
+type LocalSigner struct {
+	keyPair *KeyPair
+}
+
+// NewLocalSigner creates a SignerBackend for an in-memory key pair
+func NewLocalSigner(keyPair *KeyPair) *LocalSigner {
+	return &LocalSigner{keyPair: keyPair}
+}
+
+// PublicKey returns the public key of the key pair
+func (ls *LocalSigner) PublicKey() *PublicKey {
+	return ls.keyPair.PublicKey
+}
+
+// Sign creates a BBS+ signature on messages
+func (ls *LocalSigner) Sign(messages []*big.Int, header []byte) (*Signature, error) {
+	return Sign(ls.keyPair.PrivateKey, ls.keyPair.PublicKey, messages, header)
+}
@@ -0,0 +1,317 @@
+package bbs
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381"
+)
+
+// Over NFC a proof of a few hundred bytes arrives in frames of a few dozen,
+// with tens of milliseconds between them. StreamVerifier does the work that
+// does not need the whole proof while the rest is in flight: the domain once
+// the headers are known; the bounds checks, the disclosed part of T2 and the
+// challenge input after T2 once the disclosed messages are known; and the
+// decoding, subgroup and index checks of each proof field as its bytes come
+// in. Finish is left with two multi-scalar multiplications, one hash and
+// the pairing.
+//
+// The proof body is read in the compact encoding of SerializeProof. Legacy
+// encodings and proofs with commitment equalities are rejected.
+
+// Stream verifier stages
+const (
+	streamHeader    = iota // waiting for the headers
+	streamDisclosed        // waiting for the disclosed messages
+	streamBody             // reading the proof body
+)
+
+// Proof body fields in encoding order, before the hidden message responses
+const (
+	fieldAPrime = iota
+	fieldABar
+	fieldD
+	fieldC
+	fieldEHat
+	fieldSHat
+	fieldR1Hat
+	fieldR3Hat
+	fieldCount
+	fieldMHat
+)
+
+// errStreamIncomplete is returned by Finish before the whole proof arrived
+var errStreamIncomplete = errors.New("proof stream incomplete")
+
+// StreamVerifier verifies a proof whose parts arrive one after another: the
+// headers, then the disclosed messages, then the encoded proof in any number
+// of chunks. It is not safe for concurrent use.
+type StreamVerifier struct {
+	publicKey *PublicKey
+	limits    Limits
+	stage     int
+	err       error
+
+	// Known once the headers and disclosed messages are written
+	domain             *big.Int
+	presentationHeader []byte
+	disclosed          map[int]*big.Int
+	bv                 bls12381.G1Affine // P1 + Q2*domain + sum(H_i * m_i) for disclosed i
+	suffix             []byte            // challenge input after T2
+
+	// Proof body decoding
+	proof    ProofOfKnowledge
+	field    int
+	count    int
+	received int
+	pending  []byte
+}
+
+// NewStreamVerifier starts verifying a proof under publicKey. Zero fields of
+// limits fall back to DefaultLimits.
+func NewStreamVerifier(publicKey *PublicKey, limits Limits) (*StreamVerifier, error) {
+	if publicKey == nil {
+		return nil, fmt.Errorf("public key is required")
+	}
+	if err := limits.CheckMessageCount(publicKey.messageCount); err != nil {
+		return nil, err
+	}
+	return &StreamVerifier{publicKey: publicKey, limits: limits}, nil
+}
+
+// WriteHeader sets the signature header and presentation header and computes
+// the domain
+func (v *StreamVerifier) WriteHeader(header, presentationHeader []byte) error {
+	if err := v.expect(streamHeader); err != nil {
+		return err
+	}
+
+	v.domain = CalculateDomain(v.publicKey, header)
+	v.presentationHeader = append([]byte(nil), presentationHeader...)
+	v.stage = streamDisclosed
+	return nil
+}
+
+// WriteDisclosed sets the disclosed messages. It checks their indices and
+// precomputes their part of T2 and of the challenge input.
+func (v *StreamVerifier) WriteDisclosed(disclosed map[int]*big.Int) error {
+	if err := v.expect(streamDisclosed); err != nil {
+		return err
+	}
+
+	for idx, msg := range disclosed {
+		if idx < 0 || idx >= v.publicKey.messageCount {
+			return v.fail(fmt.Errorf("invalid disclosed message index: %d", idx))
+		}
+		if msg == nil {
+			return v.fail(fmt.Errorf("missing disclosed message at index %d", idx))
+		}
+	}
+	v.disclosed = make(map[int]*big.Int, len(disclosed))
+	for idx, msg := range disclosed {
+		v.disclosed[idx] = msg
+	}
+
+	// Bv = P1 + Q2*domain + sum(H_i * m_i), the part of T2 the challenge
+	// multiplies
+	scratch := getVerifyScratch()
+	defer putVerifyScratch(scratch)
+
+	var one, scalar Scalar
+	one.e.SetOne()
+	scratch.add(&v.publicKey.g1, &one)
+	scratch.add(&v.publicKey.h[1], scalar.fromBigInt(v.domain))
+	indices := sortedKeys(v.disclosed)
+	for _, idx := range indices {
+		scratch.add(&v.publicKey.h[idx+2], scalar.fromBigInt(v.disclosed[idx])) // +2 for Q1, Q2
+	}
+	var bvJac bls12381.G1Jac
+	scratch.sum(&bvJac)
+	v.bv = g1JacToAffine(bvJac)
+
+	v.suffix = appendChallengeSuffix(nil, indices, v.disclosed, v.domain, v.presentationHeader)
+	v.stage = streamBody
+	return nil
+}
+
+// Write adds the next chunk of the encoded proof. Each field is checked as
+// soon as its bytes are in, so a malformed or oversized proof fails on the
+// chunk that shows it.
+func (v *StreamVerifier) Write(p []byte) (int, error) {
+	if err := v.expect(streamBody); err != nil {
+		return 0, err
+	}
+	if err := v.limits.CheckProofBytes(v.received + len(p)); err != nil {
+		return 0, v.fail(err)
+	}
+	if v.received == 0 && len(p) > 0 && !isCompactEncoding(p) {
+		return 0, v.fail(fmt.Errorf("%w: streamed proofs must use the compact encoding", ErrInvalidProofData))
+	}
+	v.received += len(p)
+	v.pending = append(v.pending, p...)
+
+	for {
+		size := v.fieldSize()
+		if size == 0 {
+			if len(v.pending) > 0 {
+				return 0, v.fail(fmt.Errorf("%w: unexpected data after the proof", ErrInvalidProofData))
+			}
+			break
+		}
+		if len(v.pending) < size {
+			break
+		}
+		if err := v.readField(v.pending[:size]); err != nil {
+			return 0, v.fail(err)
+		}
+		v.pending = v.pending[size:]
+	}
+
+	return len(p), nil
+}
+
+// Finish checks the challenge and the pairing once the whole proof is in
+func (v *StreamVerifier) Finish() error {
+	if err := v.expect(streamBody); err != nil {
+		return err
+	}
+	if v.fieldSize() != 0 {
+		return errStreamIncomplete
+	}
+
+	proof := &v.proof
+	scratch := getVerifyScratch()
+	defer putVerifyScratch(scratch)
+
+	c := ScalarFromBigInt(proof.C)
+	var scalar Scalar
+
+	// T1 = A-bar * c + A' * e^ + D * r1^
+	scratch.add(&proof.ABar, &c)
+	scratch.add(&proof.APrime, scalar.fromBigInt(proof.EHat))
+	scratch.add(&proof.D, scalar.fromBigInt(proof.R1Hat))
+	var T1Jac bls12381.G1Jac
+	scratch.sum(&T1Jac)
+	T1 := g1JacToAffine(T1Jac)
+
+	// T2 = Bv * c + D * r3^ + Q1 * s^ + sum(H_j * m_j^) for undisclosed j
+	scratch.reset()
+	scratch.add(&v.bv, &c)
+	scratch.add(&proof.D, scalar.fromBigInt(proof.R3Hat))
+	scratch.add(&v.publicKey.h[0], scalar.fromBigInt(proof.SHat))
+	for _, idx := range sortedKeys(proof.MHat) {
+		scratch.add(&v.publicKey.h[idx+2], scalar.fromBigInt(proof.MHat[idx])) // +2 for Q1, Q2
+	}
+	var T2Jac bls12381.G1Jac
+	scratch.sum(&T2Jac)
+	T2 := g1JacToAffine(T2Jac)
+
+	// The challenge input is A' || A-bar || D || T1 || T2 || suffix
+	input := make([]byte, 0, 5*2*G1Size+len(v.suffix))
+	for _, p := range []*bls12381.G1Affine{&proof.APrime, &proof.ABar, &proof.D, &T1, &T2} {
+		input = append(input, p.Marshal()...)
+	}
+	input = append(input, v.suffix...)
+	if !ConstantTimeEq(DefaultCiphersuite.hashChallenge(input), proof.C) {
+		return ErrInvalidSignature
+	}
+
+	return checkProofPairing(v.publicKey, proof)
+}
+
+// expect fails unless the verifier is at stage and no earlier step failed
+func (v *StreamVerifier) expect(stage int) error {
+	if v.err != nil {
+		return v.err
+	}
+	if v.stage != stage {
+		return fmt.Errorf("proof stream out of order: the headers, disclosed messages and proof body must be written in turn")
+	}
+	return nil
+}
+
+// fail records err so every later call returns it
+func (v *StreamVerifier) fail(err error) error {
+	v.err = err
+	return err
+}
+
+// fieldSize returns the encoded size of the next proof field, or 0 once the
+// proof is complete
+func (v *StreamVerifier) fieldSize() int {
+	switch {
+	case v.field <= fieldD:
+		return G1Size
+	case v.field < fieldCount:
+		return ScalarSize
+	case v.field == fieldCount:
+		return 4
+	case len(v.proof.MHat) < v.count:
+		return 4 + ScalarSize
+	default:
+		return 0
+	}
+}
+
+// readField decodes and checks the next proof field from data
+func (v *StreamVerifier) readField(data []byte) error {
+	r := &wireReader{data: data}
+	proof := &v.proof
+
+	switch v.field {
+	case fieldAPrime:
+		proof.APrime = r.g1()
+		// A' at infinity would make the pairing check hold trivially
+		if r.err == nil && proof.APrime.IsInfinity() {
+			return ErrInvalidProof
+		}
+	case fieldABar:
+		proof.ABar = r.g1()
+	case fieldD:
+		proof.D = r.g1()
+	case fieldC:
+		proof.C = r.scalar()
+	case fieldEHat:
+		proof.EHat = r.scalar()
+	case fieldSHat:
+		proof.SHat = r.scalar()
+	case fieldR1Hat:
+		proof.R1Hat = r.scalar()
+	case fieldR3Hat:
+		proof.R3Hat = r.scalar()
+	case fieldCount:
+		count := int(r.uint32())
+		if err := v.limits.CheckMHatEntries(count); err != nil {
+			return err
+		}
+		if len(v.disclosed)+count != v.publicKey.messageCount {
+			return ErrInvalidMessageCount
+		}
+		v.count = count
+		proof.MHat = make(map[int]*big.Int, count)
+	case fieldMHat:
+		idx := int(r.uint32())
+		mHat := r.scalar()
+		if r.err != nil {
+			return ErrInvalidProofData
+		}
+		if idx < 0 || idx >= v.publicKey.messageCount {
+			return fmt.Errorf("invalid hidden message index: %d", idx)
+		}
+		if _, dup := proof.MHat[idx]; dup {
+			return ErrInvalidProofData
+		}
+		if _, disclosed := v.disclosed[idx]; disclosed {
+			return fmt.Errorf("message %d is both disclosed and hidden", idx)
+		}
+		proof.MHat[idx] = mHat
+		return nil
+	}
+
+	if r.err != nil {
+		return ErrInvalidProofData
+	}
+	v.field++
+	return nil
+}
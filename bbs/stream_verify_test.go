@@ -0,0 +1,95 @@
+package bbs
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"math/big"
+	"testing"
+)
+
+func TestStreamVerifier(t *testing.T) {
+	keyPair, err := GenerateKeyPair(5, rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKeyPair failed: %v", err)
+	}
+	messages := make([]*big.Int, 5)
+	for i := range messages {
+		messages[i] = big.NewInt(int64(i + 1))
+	}
+	header := []byte("header")
+	signature, err := Sign(keyPair.PrivateKey, keyPair.PublicKey, messages, header)
+	if err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+	proof, disclosed, err := CreateProofWithPresentationHeader(keyPair.PublicKey, signature, messages, []int{1, 3}, header, []byte("nonce"))
+	if err != nil {
+		t.Fatalf("CreateProofWithPresentationHeader failed: %v", err)
+	}
+	data := SerializeProof(proof)
+
+	stream := func(disclosed map[int]*big.Int, data []byte, chunk int) error {
+		v, err := NewStreamVerifier(keyPair.PublicKey, Limits{})
+		if err != nil {
+			return err
+		}
+		if err := v.WriteHeader(header, []byte("nonce")); err != nil {
+			return err
+		}
+		if err := v.WriteDisclosed(disclosed); err != nil {
+			return err
+		}
+		for len(data) > 0 {
+			n := min(chunk, len(data))
+			if _, err := v.Write(data[:n]); err != nil {
+				return err
+			}
+			data = data[n:]
+		}
+		return v.Finish()
+	}
+
+	// Any framing verifies
+	for _, chunk := range []int{1, 7, 32, len(data)} {
+		if err := stream(disclosed, data, chunk); err != nil {
+			t.Fatalf("Stream of %d-byte chunks failed: %v", chunk, err)
+		}
+	}
+
+	// Other disclosed values fail the challenge
+	altered := map[int]*big.Int{1: messages[1], 3: big.NewInt(99)}
+	if err := stream(altered, data, 16); !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("Expected ErrInvalidSignature, got %v", err)
+	}
+
+	// A truncated proof is incomplete, a longer one has trailing data
+	if err := stream(disclosed, data[:len(data)-1], 16); err == nil {
+		t.Fatalf("Expected a truncated proof to fail")
+	}
+	if err := stream(disclosed, append(append([]byte(nil), data...), 0), 16); !errors.Is(err, ErrInvalidProofData) {
+		t.Fatalf("Expected ErrInvalidProofData for trailing data, got %v", err)
+	}
+
+	// A count that does not match the disclosed messages fails on its chunk
+	countAt := 3*G1Size + 5*ScalarSize
+	hostile := append([]byte(nil), data[:countAt]...)
+	hostile = binary.BigEndian.AppendUint32(hostile, 4)
+	v, _ := NewStreamVerifier(keyPair.PublicKey, Limits{})
+	_ = v.WriteHeader(header, []byte("nonce"))
+	_ = v.WriteDisclosed(disclosed)
+	if _, err := v.Write(hostile); !errors.Is(err, ErrInvalidMessageCount) {
+		t.Fatalf("Expected ErrInvalidMessageCount, got %v", err)
+	}
+	if err := v.Finish(); !errors.Is(err, ErrInvalidMessageCount) {
+		t.Fatalf("Expected the failure to stick, got %v", err)
+	}
+
+	// Parts must arrive in order
+	v, _ = NewStreamVerifier(keyPair.PublicKey, Limits{})
+	if _, err := v.Write(data); err == nil {
+		t.Fatalf("Expected the proof body before the headers to fail")
+	}
+	if err := v.WriteDisclosed(disclosed); err == nil {
+		t.Fatalf("Expected the disclosed messages before the headers to fail")
+	}
+}
@@ -0,0 +1,162 @@
+package bbs
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math/big"
+	"time"
+)
+
+// A verifier that wants proofs made within the last N seconds cannot trust
+// the holder's clock. Instead it puts a timestamp token it signed into the
+// proof request, and the holder makes the proof under that token as its
+// presentation header. A proof under the token cannot have been made
+// before the token existed, so checking the token's age against the
+// verifier's own clock bounds the age of the proof. The token carries a
+// random nonce as well, so it doubles as a proof request nonce.
+
+// timestampTokenDST domain-separates the signed part of timestamp tokens
+const timestampTokenDST = "BBS_BLS12381_TIMESTAMP_TOKEN_"
+
+// Sizes of the timestamp token encoding
+const (
+	TimestampNonceSize = 16
+	TimestampTokenSize = 8 + TimestampNonceSize + ed25519.SignatureSize
+)
+
+// TimestampToken is a verifier-signed time and nonce for a proof request
+type TimestampToken struct {
+	Time      time.Time
+	Nonce     [TimestampNonceSize]byte
+	Signature []byte
+}
+
+// signedBytes returns the bytes the token signature covers
+func (t *TimestampToken) signedBytes() []byte {
+	buff := append([]byte(timestampTokenDST), make([]byte, 8)...)
+	binary.BigEndian.PutUint64(buff[len(timestampTokenDST):], uint64(t.Time.UnixNano()))
+	return append(buff, t.Nonce[:]...)
+}
+
+// Bytes encodes the token as time (Unix nanoseconds) || nonce || signature.
+// A proof under the token uses these bytes as its presentation header.
+func (t *TimestampToken) Bytes() []byte {
+	buff := binary.BigEndian.AppendUint64(make([]byte, 0, TimestampTokenSize), uint64(t.Time.UnixNano()))
+	buff = append(buff, t.Nonce[:]...)
+	return append(buff, t.Signature...)
+}
+
+// ParseTimestampToken decodes a token encoded with Bytes. It does not check
+// the signature.
+func ParseTimestampToken(data []byte) (*TimestampToken, error) {
+	if len(data) != TimestampTokenSize {
+		return nil, fmt.Errorf("%w: token is %d bytes, expected %d", ErrInvalidTimestampToken, len(data), TimestampTokenSize)
+	}
+
+	t := &TimestampToken{Time: time.Unix(0, int64(binary.BigEndian.Uint64(data))).UTC()}
+	copy(t.Nonce[:], data[8:])
+	t.Signature = append([]byte(nil), data[8+TimestampNonceSize:]...)
+	return t, nil
+}
+
+// IssueTimestampToken signs the current time and a fresh nonce with the
+// verifier's key
+func IssueTimestampToken(key ed25519.PrivateKey) (*TimestampToken, error) {
+	return issueTimestampToken(key, time.Now(), rand.Reader)
+}
+
+// issueTimestampToken signs now and a nonce drawn from rng
+func issueTimestampToken(key ed25519.PrivateKey, now time.Time, rng io.Reader) (*TimestampToken, error) {
+	if len(key) != ed25519.PrivateKeySize {
+		return nil, fmt.Errorf("invalid timestamp signing key")
+	}
+
+	t := &TimestampToken{Time: time.Unix(0, now.UnixNano()).UTC()}
+	if _, err := io.ReadFull(rng, t.Nonce[:]); err != nil {
+		return nil, fmt.Errorf("failed to draw timestamp nonce: %w", err)
+	}
+	t.Signature = ed25519.Sign(key, t.signedBytes())
+	return t, nil
+}
+
+// TimestampPolicy is a verifier's requirement on the timestamp token a
+// proof was made under
+type TimestampPolicy struct {
+	// PublicKey verifies the token signature
+	PublicKey ed25519.PublicKey
+
+	// MaxAge is the oldest a token may be when the proof is checked
+	MaxAge time.Duration
+
+	// MaxClockSkew is how far in the future the token time may lie, for
+	// tokens signed on another machine. Zero or negative uses
+	// DefaultClockSkew.
+	MaxClockSkew time.Duration
+
+	// Now returns the current time; nil uses time.Now
+	Now func() time.Time
+}
+
+// Check checks the token's signature and age
+func (p *TimestampPolicy) Check(token *TimestampToken) error {
+	if token == nil {
+		return fmt.Errorf("%w: missing token", ErrInvalidTimestampToken)
+	}
+	if len(p.PublicKey) != ed25519.PublicKeySize || !ed25519.Verify(p.PublicKey, token.signedBytes(), token.Signature) {
+		return fmt.Errorf("%w: bad signature", ErrInvalidTimestampToken)
+	}
+
+	now := time.Now()
+	if p.Now != nil {
+		now = p.Now()
+	}
+
+	skew := p.MaxClockSkew
+	if skew <= 0 {
+		skew = DefaultClockSkew
+	}
+
+	if token.Time.After(now.Add(skew)) {
+		return fmt.Errorf("%w: issued in the future (%v)", ErrInvalidTimestampToken, token.Time)
+	}
+	if p.MaxAge > 0 && now.Sub(token.Time) > p.MaxAge {
+		return fmt.Errorf("%w: issued %v, older than %v", ErrInvalidTimestampToken, token.Time, p.MaxAge)
+	}
+
+	return nil
+}
+
+// CreateProofWithTimestamp creates a proof bound to the verifier's timestamp
+// token
+func CreateProofWithTimestamp(
+	publicKey *PublicKey,
+	signature *Signature,
+	messages []*big.Int,
+	disclosedIndices []int,
+	header []byte,
+	token *TimestampToken,
+) (*ProofOfKnowledge, map[int]*big.Int, error) {
+	if token == nil {
+		return nil, nil, fmt.Errorf("%w: missing token", ErrInvalidTimestampToken)
+	}
+	return CreateProofWithPresentationHeader(publicKey, signature, messages, disclosedIndices, header, token.Bytes())
+}
+
+// VerifyProofWithTimestamp checks the token against the policy and then the
+// proof made under it
+func VerifyProofWithTimestamp(
+	publicKey *PublicKey,
+	proof *ProofOfKnowledge,
+	disclosedMessages map[int]*big.Int,
+	header []byte,
+	token *TimestampToken,
+	policy *TimestampPolicy,
+) error {
+	if err := policy.Check(token); err != nil {
+		return err
+	}
+	return VerifyProofWithPresentationHeader(publicKey, proof, disclosedMessages, header, token.Bytes())
+}
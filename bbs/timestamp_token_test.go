@@ -0,0 +1,75 @@
+package bbs
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTimestampTokenProof(t *testing.T) {
+	keyPair, signature, _, _, messages := encodingFixture(t)
+
+	verifierPub, verifierKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate verifier key: %v", err)
+	}
+
+	// The verifier sends a token with its proof request
+	token, err := IssueTimestampToken(verifierKey)
+	if err != nil {
+		t.Fatalf("IssueTimestampToken failed: %v", err)
+	}
+	received, err := ParseTimestampToken(token.Bytes())
+	if err != nil {
+		t.Fatalf("ParseTimestampToken failed: %v", err)
+	}
+
+	proof, disclosed, err := CreateProofWithTimestamp(keyPair.PublicKey, signature, messages, []int{0}, nil, received)
+	if err != nil {
+		t.Fatalf("CreateProofWithTimestamp failed: %v", err)
+	}
+
+	now := token.Time
+	policy := &TimestampPolicy{PublicKey: verifierPub, MaxAge: 30 * time.Second, Now: func() time.Time { return now }}
+
+	now = token.Time.Add(10 * time.Second)
+	if err := VerifyProofWithTimestamp(keyPair.PublicKey, proof, disclosed, nil, token, policy); err != nil {
+		t.Fatalf("VerifyProofWithTimestamp failed: %v", err)
+	}
+
+	// Too late, by the verifier's clock
+	now = token.Time.Add(31 * time.Second)
+	if err := VerifyProofWithTimestamp(keyPair.PublicKey, proof, disclosed, nil, token, policy); !errors.Is(err, ErrInvalidTimestampToken) {
+		t.Fatalf("Expected ErrInvalidTimestampToken for an old token, got %v", err)
+	}
+	now = token.Time
+
+	// A newer token cannot be swapped in for the one the proof was made under
+	newer, err := IssueTimestampToken(verifierKey)
+	if err != nil {
+		t.Fatalf("IssueTimestampToken failed: %v", err)
+	}
+	policy.Now = nil
+	if err := VerifyProofWithTimestamp(keyPair.PublicKey, proof, disclosed, nil, newer, policy); err == nil {
+		t.Fatalf("Expected verification to fail under another token")
+	}
+
+	// A token with a moved time no longer carries a valid signature
+	forged := *token
+	forged.Time = time.Now()
+	if err := policy.Check(&forged); !errors.Is(err, ErrInvalidTimestampToken) {
+		t.Fatalf("Expected ErrInvalidTimestampToken for a forged token, got %v", err)
+	}
+
+	// Tokens from the future are refused beyond the clock skew
+	policy.Now = func() time.Time { return token.Time.Add(-2 * DefaultClockSkew) }
+	if err := policy.Check(token); !errors.Is(err, ErrInvalidTimestampToken) {
+		t.Fatalf("Expected ErrInvalidTimestampToken for a future token, got %v", err)
+	}
+
+	if _, err := ParseTimestampToken(token.Bytes()[1:]); !errors.Is(err, ErrInvalidTimestampToken) {
+		t.Fatalf("Expected ErrInvalidTimestampToken for a short token, got %v", err)
+	}
+}
@@ -0,0 +1,298 @@
+package bbs
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+)
+
+// Transcripts compose a BBS+ proof with external sigma protocols, such as a
+// proof that a hidden attribute is the preimage of a hash, under a single
+// Fiat-Shamir challenge:
+//
+//	prover                                      verifier
+//	NewProverTranscript                         NewVerifierTranscript
+//	MessageBlinding(j) -> external commitments  Challenge() -> recompute them
+//	Append(label, external commitments)         Append(label, recomputed)
+//	Challenge() -> external responses           MessageResponse(j)
+//	Proof()        -- proof, external proof --> Verify()
+//
+// The challenge is derived from the BBS+ commitments, the disclosed messages,
+// the domain, the presentation header and every appended entry, so none of
+// them can be changed after the challenge is known. Soundness of the
+// composition rests on the caller:
+//
+//   - Everything the external protocol commits to must be appended before
+//     the prover's Challenge call; anything appended later is not bound.
+//   - An external protocol that speaks about hidden message j must blind m_j
+//     with MessageBlinding(j). The BBS+ response m^_j = blinding + m_j*c is
+//     then the response the external protocol would give for m_j, and the
+//     verifier checks the external equations with MessageResponse(j). Fresh
+//     blinding for m_j proves something about an unrelated value.
+//   - Labels must be unique per protocol and the verifier must append the
+//     same labels with the same data, in the same order.
+//   - Both transcripts are single use. The prover's blinding factors reveal
+//     the hidden messages if they answer two challenges, so nothing derived
+//     from MessageBlinding may be reused in another proof.
+//
+// A transcript without entries produces the proof CreateProofWithPresentationHeader
+// would, so it also verifies with VerifyProofWithPresentationHeader.
+
+// transcriptDST separates transcript entries from other presentation headers
+const transcriptDST = "BBS_BLS12381_EXTERNAL_TRANSCRIPT_"
+
+// ErrTranscriptClosed is returned when a transcript is used after its
+// challenge was derived, or after it was consumed
+var ErrTranscriptClosed = errors.New("transcript closed")
+
+// transcriptEntry is one labeled contribution of an external protocol
+type transcriptEntry struct {
+	label string
+	data  []byte
+}
+
+// transcriptHeader encodes the presentation header and the external entries
+// as the presentation header of the challenge
+func transcriptHeader(presentationHeader []byte, entries []transcriptEntry) []byte {
+	if len(entries) == 0 {
+		return presentationHeader
+	}
+
+	size := len(transcriptDST) + 8 + len(presentationHeader)
+	for _, e := range entries {
+		size += 8 + len(e.label) + len(e.data)
+	}
+	buff := make([]byte, 0, size)
+	buff = append(buff, transcriptDST...)
+	buff = appendUint32(buff, uint32(len(presentationHeader)))
+	buff = append(buff, presentationHeader...)
+	buff = appendUint32(buff, uint32(len(entries)))
+	for _, e := range entries {
+		buff = appendUint32(buff, uint32(len(e.label)))
+		buff = append(buff, e.label...)
+		buff = appendUint32(buff, uint32(len(e.data)))
+		buff = append(buff, e.data...)
+	}
+	return buff
+}
+
+// checkEntry validates an entry before it is appended
+func checkEntry(label string) error {
+	if label == "" {
+		return fmt.Errorf("transcript entry label must not be empty")
+	}
+	return nil
+}
+
+// ProverTranscript holds a committed BBS+ proof whose challenge has not been
+// derived yet
+//
+// Experimental: transcript composition may change in a minor release.
+type ProverTranscript struct {
+	mu                 sync.Mutex
+	witness            *proofWitness
+	disclosed          map[int]*big.Int
+	domain             *big.Int
+	presentationHeader []byte
+	entries            []transcriptEntry
+	challenge          *big.Int
+}
+
+// NewProverTranscript commits to a proof disclosing the messages at
+// disclosedIndices, like the first half of CreateProofWithPresentationHeader
+//
+// Experimental: transcript composition may change in a minor release.
+func NewProverTranscript(
+	publicKey *PublicKey,
+	signature *Signature,
+	messages []*big.Int,
+	disclosedIndices []int,
+	header []byte,
+	presentationHeader []byte,
+) (*ProverTranscript, error) {
+	// Validate inputs
+	if len(messages) != publicKey.messageCount {
+		return nil, ErrInvalidMessageCount
+	}
+
+	disclosedMessages, err := selectDisclosed(messages, disclosedIndices)
+	if err != nil {
+		return nil, err
+	}
+
+	domain := CalculateDomain(publicKey, header)
+
+	witness, err := commitProof(publicKey, signature, messages, disclosedMessages, domain, rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ProverTranscript{
+		witness:            witness,
+		disclosed:          disclosedMessages,
+		domain:             domain,
+		presentationHeader: append([]byte(nil), presentationHeader...),
+	}, nil
+}
+
+// MessageBlinding returns the blinding of hidden message index. The external
+// protocol must blind the same message with it; see the soundness notes
+// above. It is a secret of the proof and must not leave the prover.
+func (t *ProverTranscript) MessageBlinding(index int) (*big.Int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.witness == nil {
+		return nil, ErrTranscriptClosed
+	}
+	blinding, ok := t.witness.mBlind[index]
+	if !ok {
+		return nil, fmt.Errorf("message %d is not hidden by the proof", index)
+	}
+	return blinding.BigInt(), nil
+}
+
+// Append adds the commitments of an external protocol to the transcript. It
+// fails once the challenge has been derived.
+func (t *ProverTranscript) Append(label string, data []byte) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.witness == nil || t.challenge != nil {
+		return ErrTranscriptClosed
+	}
+	if err := checkEntry(label); err != nil {
+		return err
+	}
+	t.entries = append(t.entries, transcriptEntry{label: label, data: append([]byte(nil), data...)})
+	return nil
+}
+
+// Challenge derives the Fiat-Shamir challenge over the proof and every entry
+// appended so far and closes the transcript to further entries. Later calls
+// return the same challenge.
+func (t *ProverTranscript) Challenge() (*big.Int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.witness == nil {
+		return nil, ErrTranscriptClosed
+	}
+	if t.challenge == nil {
+		cm := &t.witness.commitment
+		t.challenge = computeProofChallenge(cm.APrime, cm.ABar, cm.D, cm.T1, cm.T2,
+			sortedKeys(t.disclosed), t.disclosed, t.domain, transcriptHeader(t.presentationHeader, t.entries))
+	}
+	return new(big.Int).Set(t.challenge), nil
+}
+
+// Proof answers the challenge, deriving it first if Challenge was not called,
+// and wipes the blinding factors. It can be called successfully only once.
+func (t *ProverTranscript) Proof() (*ProofOfKnowledge, map[int]*big.Int, error) {
+	if _, err := t.Challenge(); err != nil {
+		return nil, nil, err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.witness == nil {
+		return nil, nil, ErrTranscriptClosed
+	}
+	proof := t.witness.respond(t.challenge)
+	t.witness.wipe()
+	t.witness = nil
+
+	return proof, t.disclosed, nil
+}
+
+// VerifierTranscript checks a proof created with a ProverTranscript
+//
+// Experimental: transcript composition may change in a minor release.
+type VerifierTranscript struct {
+	mu                 sync.Mutex
+	publicKey          *PublicKey
+	proof              *ProofOfKnowledge
+	disclosed          map[int]*big.Int
+	domain             *big.Int
+	presentationHeader []byte
+	entries            []transcriptEntry
+	used               bool
+}
+
+// NewVerifierTranscript checks the shape of proof so its challenge and
+// responses can be handed to the external verifier
+//
+// Experimental: transcript composition may change in a minor release.
+func NewVerifierTranscript(
+	publicKey *PublicKey,
+	proof *ProofOfKnowledge,
+	disclosedMessages map[int]*big.Int,
+	header []byte,
+	presentationHeader []byte,
+) (*VerifierTranscript, error) {
+	if err := validateProofShape(publicKey, proof, disclosedMessages); err != nil {
+		return nil, err
+	}
+
+	return &VerifierTranscript{
+		publicKey:          publicKey,
+		proof:              proof,
+		disclosed:          disclosedMessages,
+		domain:             CalculateDomain(publicKey, header),
+		presentationHeader: append([]byte(nil), presentationHeader...),
+	}, nil
+}
+
+// Challenge returns the challenge the proof claims. The external verifier
+// recomputes its commitments from it and appends them; Verify then checks
+// that the claim was honest.
+func (t *VerifierTranscript) Challenge() *big.Int {
+	return new(big.Int).Set(t.proof.C)
+}
+
+// MessageResponse returns the response m^_j of hidden message index, which
+// the external verifier uses in place of its own response for m_j
+func (t *VerifierTranscript) MessageResponse(index int) (*big.Int, error) {
+	mHat, ok := t.proof.MHat[index]
+	if !ok {
+		return nil, fmt.Errorf("message %d is not hidden by the proof", index)
+	}
+	return new(big.Int).Set(mHat), nil
+}
+
+// Append adds the recomputed commitments of an external protocol, with the
+// label and in the order the prover used
+func (t *VerifierTranscript) Append(label string, data []byte) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.used {
+		return ErrTranscriptClosed
+	}
+	if err := checkEntry(label); err != nil {
+		return err
+	}
+	t.entries = append(t.entries, transcriptEntry{label: label, data: append([]byte(nil), data...)})
+	return nil
+}
+
+// Verify recomputes the challenge over the proof and the appended entries
+// and checks the proof. It can be called only once.
+func (t *VerifierTranscript) Verify() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.used {
+		return ErrTranscriptClosed
+	}
+	t.used = true
+
+	header := transcriptHeader(t.presentationHeader, t.entries)
+	if err := checkProofChallenge(t.publicKey, t.proof, t.disclosed, t.domain, header); err != nil {
+		return err
+	}
+	return checkProofPairing(t.publicKey, t.proof)
+}
@@ -0,0 +1,151 @@
+package bbs
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"testing"
+
+	bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381"
+)
+
+// dlogCommitment computes G*scalar for the external protocol of the tests,
+// a Schnorr proof that a hidden message is the discrete logarithm of Y = G*m
+func dlogCommitment(t *testing.T, points []bls12381.G1Affine, scalars []*big.Int) []byte {
+	t.Helper()
+	sum, err := MultiScalarMulG1(points, scalars)
+	if err != nil {
+		t.Fatalf("MultiScalarMulG1 failed: %v", err)
+	}
+	p := g1JacToAffine(sum)
+	return appendG1(nil, &p)
+}
+
+func TestTranscriptExternalProtocol(t *testing.T) {
+	keyPair, err := GenerateKeyPair(4, rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key pair: %v", err)
+	}
+
+	messages := []*big.Int{big.NewInt(7), big.NewInt(1990), big.NewInt(42), big.NewInt(5)}
+	header := []byte("transcript header")
+	nonce := []byte("verifier nonce")
+
+	signature, err := Sign(keyPair.PrivateKey, keyPair.PublicKey, messages, header)
+	if err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+
+	// Y = G*m_2 is public, m_2 stays hidden
+	g, _ := PedersenGenerators()
+	Y := PedersenCommit(messages[2], big.NewInt(0))
+
+	// Prover: commit to G*blinding_2 with the BBS+ blinding of m_2
+	prover, err := NewProverTranscript(keyPair.PublicKey, signature, messages, []int{0}, header, nonce)
+	if err != nil {
+		t.Fatalf("NewProverTranscript failed: %v", err)
+	}
+	if _, err := prover.MessageBlinding(0); err == nil {
+		t.Fatal("MessageBlinding returned the blinding of a disclosed message")
+	}
+	blinding, err := prover.MessageBlinding(2)
+	if err != nil {
+		t.Fatalf("MessageBlinding failed: %v", err)
+	}
+	if err := prover.Append("dlog", dlogCommitment(t, []bls12381.G1Affine{g}, []*big.Int{blinding})); err != nil {
+		t.Fatalf("Append failed: %v", err)
+	}
+	c, err := prover.Challenge()
+	if err != nil {
+		t.Fatalf("Challenge failed: %v", err)
+	}
+	if err := prover.Append("late", nil); !errors.Is(err, ErrTranscriptClosed) {
+		t.Fatalf("Append after Challenge returned %v", err)
+	}
+	proof, disclosed, err := prover.Proof()
+	if err != nil {
+		t.Fatalf("Proof failed: %v", err)
+	}
+	if proof.C.Cmp(c) != 0 {
+		t.Fatal("proof challenge differs from the transcript challenge")
+	}
+	if _, _, err := prover.Proof(); !errors.Is(err, ErrTranscriptClosed) {
+		t.Fatalf("second Proof returned %v", err)
+	}
+
+	// Verifier: recompute G*m^_2 - Y*c and check the shared challenge
+	verify := func(Y bls12381.G1Affine, label string) error {
+		verifier, err := NewVerifierTranscript(keyPair.PublicKey, proof, disclosed, header, nonce)
+		if err != nil {
+			t.Fatalf("NewVerifierTranscript failed: %v", err)
+		}
+		mHat, err := verifier.MessageResponse(2)
+		if err != nil {
+			t.Fatalf("MessageResponse failed: %v", err)
+		}
+		negC := new(big.Int).Sub(Order, verifier.Challenge())
+		if err := verifier.Append(label, dlogCommitment(t, []bls12381.G1Affine{g, Y}, []*big.Int{mHat, negC})); err != nil {
+			t.Fatalf("Append failed: %v", err)
+		}
+		return verifier.Verify()
+	}
+	if err := verify(Y, "dlog"); err != nil {
+		t.Fatalf("Verify failed: %v", err)
+	}
+
+	// Another Y, or the entry under another label, changes the challenge
+	if err := verify(PedersenCommit(messages[1], big.NewInt(0)), "dlog"); err == nil {
+		t.Fatal("Verify accepted the wrong discrete logarithm")
+	}
+	if err := verify(Y, "other"); err == nil {
+		t.Fatal("Verify accepted a different label")
+	}
+
+	// The external entry is bound: the plain verifier rejects the proof
+	if err := VerifyProofWithPresentationHeader(keyPair.PublicKey, proof, disclosed, header, nonce); err == nil {
+		t.Fatal("VerifyProofWithPresentationHeader ignored the external entry")
+	}
+}
+
+func TestTranscriptWithoutEntries(t *testing.T) {
+	keyPair, err := GenerateKeyPair(3, rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key pair: %v", err)
+	}
+	messages := []*big.Int{big.NewInt(1), big.NewInt(2), big.NewInt(3)}
+	signature, err := Sign(keyPair.PrivateKey, keyPair.PublicKey, messages, nil)
+	if err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+
+	prover, err := NewProverTranscript(keyPair.PublicKey, signature, messages, []int{1}, nil, []byte("ph"))
+	if err != nil {
+		t.Fatalf("NewProverTranscript failed: %v", err)
+	}
+	if err := prover.Append("", []byte("x")); err == nil {
+		t.Fatal("Append accepted an empty label")
+	}
+	proof, disclosed, err := prover.Proof()
+	if err != nil {
+		t.Fatalf("Proof failed: %v", err)
+	}
+	if _, err := prover.MessageBlinding(0); !errors.Is(err, ErrTranscriptClosed) {
+		t.Fatalf("MessageBlinding after Proof returned %v", err)
+	}
+
+	// Without entries the proof is an ordinary one
+	if err := VerifyProofWithPresentationHeader(keyPair.PublicKey, proof, disclosed, nil, []byte("ph")); err != nil {
+		t.Fatalf("VerifyProofWithPresentationHeader failed: %v", err)
+	}
+
+	verifier, err := NewVerifierTranscript(keyPair.PublicKey, proof, disclosed, nil, []byte("ph"))
+	if err != nil {
+		t.Fatalf("NewVerifierTranscript failed: %v", err)
+	}
+	if err := verifier.Verify(); err != nil {
+		t.Fatalf("Verify failed: %v", err)
+	}
+	if err := verifier.Verify(); !errors.Is(err, ErrTranscriptClosed) {
+		t.Fatalf("second Verify returned %v", err)
+	}
+}
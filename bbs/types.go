@@ -18,13 +18,15 @@ type PrivateKey struct {
 	X *big.Int // Secret scalar
 }
 
-// PublicKey represents a BBS+ public key
+// PublicKey represents a BBS+ public key. It is read through accessors and
+// never changes once built, so one key may be shared between goroutines.
 type PublicKey struct {
-	W            bls12381.G2Affine // W = g2^x
-	G2           bls12381.G2Affine // Generator of G2
-	G1           bls12381.G1Affine // Generator of G1
-	H            []bls12381.G1Affine // Message-specific generators
-	MessageCount int             // Number of messages this key can sign
+	w            bls12381.G2Affine // W = g2^x
+	g2           bls12381.G2Affine // Generator of G2
+	g1           bls12381.G1Affine // Generator of G1
+	h            []bls12381.G1Affine // Message-specific generators
+	messageCount int             // Number of messages this key can sign
+	seed         []byte          // Seed h was derived from, if any
 }
 
 // KeyPair represents a BBS+ key pair
@@ -42,245 +44,170 @@ type Signature struct {
 
 // ProofOfKnowledge represents a BBS+ proof of knowledge of a signature
 type ProofOfKnowledge struct {
-	APrime bls12381.G1Affine
-	ABar   bls12381.G1Affine
-	D      bls12381.G1Affine
-	C      *big.Int
+	APrime bls12381.G1Affine // Randomized signature point A * r1 * r2
+	ABar   bls12381.G1Affine // APrime raised to the secret key, computed without it
+	D      bls12381.G1Affine // Randomized message commitment B * r2
+	C      *big.Int          // Fiat-Shamir challenge
 	EHat   *big.Int
 	SHat   *big.Int
+	R1Hat  *big.Int
+	R3Hat  *big.Int
 	MHat   map[int]*big.Int // Unrevealed messages commitments
+
+	// CommitmentHat holds the blinding responses of hidden messages proven
+	// equal to external commitments, see CreateProofWithCommitments
+	CommitmentHat map[int]*big.Int
 }
 
-// SerializeSignature converts a signature to bytes
+// SerializeSignature converts a signature to bytes: A (compressed) || e || s
 func SerializeSignature(sig *Signature) []byte {
-	var result []byte
-	
-	// Add A
-	result = append(result, sig.A.Marshal()...)
-	
-	// Add E (with length prefix)
-	eBytes := sig.E.Bytes()
-	result = append(result, byte(len(eBytes)))
-	result = append(result, eBytes...)
-	
-	// Add S (with length prefix)
-	sBytes := sig.S.Bytes()
-	result = append(result, byte(len(sBytes)))
-	result = append(result, sBytes...)
-	
+	result := make([]byte, 0, SignatureSize)
+	result = appendG1(result, &sig.A)
+	result = appendScalar(result, sig.E)
+	result = appendScalar(result, sig.S)
 	return result
 }
 
-// DeserializeSignature converts bytes to a signature
+// DeserializeSignature converts bytes to a signature. Signatures written by
+// earlier versions, with an uncompressed A and length-prefixed scalars, are
+// also accepted.
 func DeserializeSignature(data []byte) (*Signature, error) {
-	if len(data) < 50 { // Minimum size needed for a valid signature
-		return nil, ErrInvalidSignatureData
-	}
-	
-	var offset int
-	
-	// Parse A
-	var a bls12381.G1Affine
-	err := a.Unmarshal(data[offset:offset+48])
-	if err != nil {
-		return nil, ErrInvalidSignatureData
+	if !isCompactEncoding(data) {
+		return deserializeSignatureLegacy(data)
 	}
-	offset += 48
-	
-	// Parse E
-	eLength := int(data[offset])
-	offset++
-	if offset+eLength > len(data) {
+
+	if len(data) != SignatureSize {
 		return nil, ErrInvalidSignatureData
 	}
-	e := new(big.Int).SetBytes(data[offset:offset+eLength])
-	offset += eLength
-	
-	// Parse S
-	if offset >= len(data) {
-		return nil, ErrInvalidSignatureData
+
+	r := &wireReader{data: data}
+	sig := &Signature{
+		A: r.g1(),
+		E: r.scalar(),
+		S: r.scalar(),
 	}
-	sLength := int(data[offset])
-	offset++
-	if offset+sLength > len(data) {
+	if r.err != nil {
 		return nil, ErrInvalidSignatureData
 	}
-	s := new(big.Int).SetBytes(data[offset:offset+sLength])
-	
-	return &Signature{
-		A: a,
-		E: e,
-		S: s,
-	}, nil
+
+	return sig, nil
 }
 
-// SerializeProof converts a proof to bytes
+// SerializeProof converts a proof to bytes:
+// A' || Abar || D || c || e^ || s^ || r1^ || r3^ || count || (index || m^)*
+// with points compressed, scalars fixed width and the m^ entries sorted by
+// message index. Proofs with commitment equalities append
+// count || (index || r^)* in the same layout.
 func SerializeProof(proof *ProofOfKnowledge) []byte {
-	var result []byte
-	
-	// Add APrime
-	result = append(result, proof.APrime.Marshal()...)
-	
-	// Add ABar
-	result = append(result, proof.ABar.Marshal()...)
-	
-	// Add D
-	result = append(result, proof.D.Marshal()...)
-	
-	// Add C (with length prefix)
-	cBytes := proof.C.Bytes()
-	result = append(result, byte(len(cBytes)))
-	result = append(result, cBytes...)
-	
-	// Add EHat (with length prefix)
-	eHatBytes := proof.EHat.Bytes()
-	result = append(result, byte(len(eHatBytes)))
-	result = append(result, eHatBytes...)
-	
-	// Add SHat (with length prefix)
-	sHatBytes := proof.SHat.Bytes()
-	result = append(result, byte(len(sHatBytes)))
-	result = append(result, sHatBytes...)
-	
-	// Add number of undisclosed messages
-	result = append(result, byte(len(proof.MHat)))
-	
-	// Add MHat values in sorted order by index
-	indices := make([]int, 0, len(proof.MHat))
-	for idx := range proof.MHat {
-		indices = append(indices, idx)
-	}
-	
-	// We'll sort indices for deterministic serialization
+	result := make([]byte, 0, 3*G1Size+5*ScalarSize+4+len(proof.MHat)*(4+ScalarSize))
+
+	// Add the points
+	result = appendG1(result, &proof.APrime)
+	result = appendG1(result, &proof.ABar)
+	result = appendG1(result, &proof.D)
+
+	// Add the challenge and responses
+	result = appendScalar(result, proof.C)
+	result = appendScalar(result, proof.EHat)
+	result = appendScalar(result, proof.SHat)
+	result = appendScalar(result, proof.R1Hat)
+	result = appendScalar(result, proof.R3Hat)
+
+	// Add the undisclosed message responses in index order
+	indices := sortedKeys(proof.MHat)
+	result = appendUint32(result, uint32(len(indices)))
 	for _, idx := range indices {
-		mHat := proof.MHat[idx]
-		
-		// Add index (4 bytes, big-endian)
-		idxBytes := make([]byte, 4)
-		idxBytes[0] = byte(idx >> 24)
-		idxBytes[1] = byte(idx >> 16)
-		idxBytes[2] = byte(idx >> 8)
-		idxBytes[3] = byte(idx)
-		result = append(result, idxBytes...)
-		
-		// Add mHat value (with length prefix)
-		mHatBytes := mHat.Bytes()
-		result = append(result, byte(len(mHatBytes)))
-		result = append(result, mHatBytes...)
+		result = appendUint32(result, uint32(idx))
+		result = appendScalar(result, proof.MHat[idx])
+	}
+
+	// Add the commitment equality responses, if any, in index order
+	if len(proof.CommitmentHat) > 0 {
+		indices = sortedKeys(proof.CommitmentHat)
+		result = appendUint32(result, uint32(len(indices)))
+		for _, idx := range indices {
+			result = appendUint32(result, uint32(idx))
+			result = appendScalar(result, proof.CommitmentHat[idx])
+		}
 	}
-	
+
 	return result
 }
 
-// DeserializeProof converts bytes to a proof
+// DeserializeProof converts bytes to a proof under DefaultLimits. Proofs
+// written by earlier versions, with uncompressed points and length-prefixed
+// scalars, are also accepted.
 func DeserializeProof(data []byte) (*ProofOfKnowledge, error) {
-	if len(data) < 150 { // Minimum size needed for a valid proof
-		return nil, ErrInvalidProofData
-	}
-	
-	var offset int
-	
-	// Parse APrime
-	var aPrime bls12381.G1Affine
-	err := aPrime.Unmarshal(data[offset:offset+48])
-	if err != nil {
-		return nil, ErrInvalidProofData
+	return DeserializeProofWithLimits(data, DefaultLimits)
+}
+
+// DeserializeProofWithLimits is DeserializeProof with the given limits,
+// checked before anything is allocated
+func DeserializeProofWithLimits(data []byte, limits Limits) (*ProofOfKnowledge, error) {
+	if err := limits.CheckProofBytes(len(data)); err != nil {
+		return nil, err
 	}
-	offset += 48
-	
-	// Parse ABar
-	var aBar bls12381.G1Affine
-	err = aBar.Unmarshal(data[offset:offset+48])
-	if err != nil {
-		return nil, ErrInvalidProofData
+	if !isCompactEncoding(data) {
+		return deserializeProofLegacy(data, limits)
 	}
-	offset += 48
-	
-	// Parse D
-	var d bls12381.G1Affine
-	err = d.Unmarshal(data[offset:offset+48])
-	if err != nil {
-		return nil, ErrInvalidProofData
+
+	r := &wireReader{data: data}
+	proof := &ProofOfKnowledge{
+		APrime: r.g1(),
+		ABar:   r.g1(),
+		D:      r.g1(),
+		C:      r.scalar(),
+		EHat:   r.scalar(),
+		SHat:   r.scalar(),
+		R1Hat:  r.scalar(),
+		R3Hat:  r.scalar(),
 	}
-	offset += 48
-	
-	// Parse C
-	if offset >= len(data) {
+
+	// Bound the count by the remaining data before allocating
+	count := r.uint32()
+	if r.err != nil || uint64(count)*(4+ScalarSize) > uint64(r.remaining()) {
 		return nil, ErrInvalidProofData
 	}
-	cLength := int(data[offset])
-	offset++
-	if offset+cLength > len(data) {
-		return nil, ErrInvalidProofData
+	if err := limits.CheckMHatEntries(int(count)); err != nil {
+		return nil, err
 	}
-	c := new(big.Int).SetBytes(data[offset:offset+cLength])
-	offset += cLength
-	
-	// Parse EHat
-	if offset >= len(data) {
-		return nil, ErrInvalidProofData
+
+	proof.MHat = make(map[int]*big.Int, count)
+	for i := uint32(0); i < count; i++ {
+		idx := int(r.uint32())
+		if _, dup := proof.MHat[idx]; dup {
+			return nil, ErrInvalidProofData
+		}
+		proof.MHat[idx] = r.scalar()
 	}
-	eHatLength := int(data[offset])
-	offset++
-	if offset+eHatLength > len(data) {
+	if r.err != nil {
 		return nil, ErrInvalidProofData
 	}
-	eHat := new(big.Int).SetBytes(data[offset:offset+eHatLength])
-	offset += eHatLength
-	
-	// Parse SHat
-	if offset >= len(data) {
-		return nil, ErrInvalidProofData
+
+	if r.remaining() == 0 {
+		return proof, nil
 	}
-	sHatLength := int(data[offset])
-	offset++
-	if offset+sHatLength > len(data) {
+
+	// Commitment equality responses follow when present
+	count = r.uint32()
+	if r.err != nil || count == 0 || uint64(count)*(4+ScalarSize) != uint64(r.remaining()) {
 		return nil, ErrInvalidProofData
 	}
-	sHat := new(big.Int).SetBytes(data[offset:offset+sHatLength])
-	offset += sHatLength
-	
-	// Parse number of undisclosed messages
-	if offset >= len(data) {
-		return nil, ErrInvalidProofData
+	if err := limits.CheckMHatEntries(int(count)); err != nil {
+		return nil, err
 	}
-	mHatCount := int(data[offset])
-	offset++
-	
-	// Parse MHat values
-	mHat := make(map[int]*big.Int, mHatCount)
-	for i := 0; i < mHatCount; i++ {
-		if offset+4 > len(data) {
-			return nil, ErrInvalidProofData
-		}
-		
-		// Parse index
-		idx := int(data[offset])<<24 | int(data[offset+1])<<16 | 
-		      int(data[offset+2])<<8 | int(data[offset+3])
-		offset += 4
-		
-		// Parse mHat value
-		if offset >= len(data) {
-			return nil, ErrInvalidProofData
-		}
-		mHatLength := int(data[offset])
-		offset++
-		if offset+mHatLength > len(data) {
+
+	proof.CommitmentHat = make(map[int]*big.Int, count)
+	for i := uint32(0); i < count; i++ {
+		idx := int(r.uint32())
+		if _, dup := proof.CommitmentHat[idx]; dup {
 			return nil, ErrInvalidProofData
 		}
-		mHatValue := new(big.Int).SetBytes(data[offset:offset+mHatLength])
-		offset += mHatLength
-		
-		mHat[idx] = mHatValue
+		proof.CommitmentHat[idx] = r.scalar()
+	}
+	if r.err != nil {
+		return nil, ErrInvalidProofData
 	}
-	
-	return &ProofOfKnowledge{
-		APrime: aPrime,
-		ABar:   aBar,
-		D:      d,
-		C:      c,
-		EHat:   eHat,
-		SHat:   sHat,
-		MHat:   mHat,
-	}, nil
-}
\ No newline at end of file
+
+	return proof, nil
+}
@@ -2,12 +2,15 @@ package bbs
 
 import (
 	"crypto/sha256"
+	"encoding/binary"
 	"errors"
 	"fmt"
 	"io"
 	"math/big"
 	"sort"
+	"sync"
 
+	"github.com/anupsv/bbsplus-signatures/pkg/crypto"
 	bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381"
 )
 
@@ -19,8 +22,15 @@ var (
 
 // Domain separation tags are defined in constants.go
 
-// MessageToFieldElement converts a byte array to a field element
+// MessageToFieldElement converts a byte array to a field element with the
+// message mapping of DefaultCiphersuite
 func MessageToFieldElement(message []byte) *big.Int {
+	return DefaultCiphersuite.MapMessageToScalar(message)
+}
+
+// legacyMessageToFieldElement is the original mapping, sha256(message) mod
+// Order
+func legacyMessageToFieldElement(message []byte) *big.Int {
 	// Hash the message using SHA-256
 	h := sha256.Sum256(message)
 
@@ -29,7 +39,9 @@ func MessageToFieldElement(message []byte) *big.Int {
 	return elem.Mod(elem, Order)
 }
 
-// MessageToBytes converts a message string to a suitable byte representation
+// MessageToBytes converts a message string to a suitable byte representation.
+// Binary messages need no conversion: pass their bytes to
+// MessageToFieldElement as they are.
 func MessageToBytes(message string) []byte {
 	return []byte(message)
 }
@@ -44,8 +56,7 @@ func RandomScalar(rng io.Reader) (*big.Int, error) {
 // This helps prevent timing attacks that could leak information about generated values
 func ConstantTimeRandom(rng io.Reader, max *big.Int) (*big.Int, error) {
 	// Calculate the number of bytes needed to represent max
-	// Add 64 extra bits to ensure we have enough entropy after rejection sampling
-	byteLen := (max.BitLen() + 64 + 7) / 8
+	byteLen := (max.BitLen() + 7) / 8
 	
 	// Create a mask for the most significant byte to avoid modulo bias
 	bits := max.BitLen() % 8
@@ -182,47 +193,46 @@ func g2JacToAffine(p bls12381.G2Jac) bls12381.G2Affine {
 }
 
 // Compute a domain value from a public key and optional header
-// This is used in the signing and verification algorithms
+// This is used in the signing and verification algorithms, and hashed with
+// DefaultCiphersuite
 func CalculateDomain(publicKey *PublicKey, header []byte) *big.Int {
-	// Concatenate public key parameters to compute a domain
-	var buff []byte
-	
+	// Concatenate public key parameters to compute a domain, in a pooled
+	// buffer so the hot verification path does not allocate for it
+	bufPtr := domainBufferPool.Get().(*[]byte)
+	defer domainBufferPool.Put(bufPtr)
+	buff := (*bufPtr)[:0]
+
 	// Append L
-	msgCount := make([]byte, 4)
-	msgCount[0] = byte(publicKey.MessageCount >> 24)
-	msgCount[1] = byte(publicKey.MessageCount >> 16)
-	msgCount[2] = byte(publicKey.MessageCount >> 8)
-	msgCount[3] = byte(publicKey.MessageCount)
-	buff = append(buff, msgCount...)
-	
-	// Append Q_1 point
-	buff = append(buff, publicKey.H[0].Marshal()...)
-	
-	// Append Q_2 point
-	buff = append(buff, publicKey.H[1].Marshal()...)
-	
-	// Append remaining H[i] points
-	for i := 2; i < len(publicKey.H); i++ {
-		buff = append(buff, publicKey.H[i].Marshal()...)
+	buff = binary.BigEndian.AppendUint32(buff, uint32(publicKey.messageCount))
+
+	// Append Q_1, Q_2 and the message generators H[i]
+	for i := range publicKey.h {
+		raw := publicKey.h[i].RawBytes()
+		buff = append(buff, raw[:]...)
 	}
-	
+
 	// Append public key W and generators
-	buff = append(buff, publicKey.W.Marshal()...)
-	buff = append(buff, publicKey.G1.Marshal()...)
-	buff = append(buff, publicKey.G2.Marshal()...)
-	
+	w := publicKey.w.RawBytes()
+	buff = append(buff, w[:]...)
+	g1 := publicKey.g1.RawBytes()
+	buff = append(buff, g1[:]...)
+	g2 := publicKey.g2.RawBytes()
+	buff = append(buff, g2[:]...)
+
 	// Append header if present
-	if header != nil {
-		buff = append(buff, header...)
-	}
-	
-	// Hash the buffer and interpret as a big integer mod Order
-	h := sha256.New()
-	h.Write(buff)
-	digest := h.Sum(nil)
-	
-	domain := new(big.Int).SetBytes(digest)
-	return domain.Mod(domain, Order)
+	buff = append(buff, header...)
+	*bufPtr = buff
+
+	// Hash the buffer to a scalar as DefaultCiphersuite says
+	return DefaultCiphersuite.hashDomain(buff)
+}
+
+// domainBufferPool recycles the CalculateDomain input buffers
+var domainBufferPool = sync.Pool{
+	New: func() interface{} {
+		buf := make([]byte, 0, 1024)
+		return &buf
+	},
 }
 
 // GenerateGenerators generates message-specific generators
@@ -235,37 +245,13 @@ func GenerateGenerators(count int) []bls12381.G1Affine {
 		// Create a seed specific to this generator
 		seed := []byte(fmt.Sprintf("BBS_BLS12381_GENERATOR_%d", i))
 		
-		// Hash the seed to get bytes
-		h := sha256.Sum256(seed)
-		
-		// Create a Jacobian point and set it
-		gJac := bls12381.G1Jac{}
-		gJac.X.SetBytes(h[:16])
-		gJac.Y.SetBytes(h[16:])
-		gJac.Z.SetOne()
-		
-		// Map to the curve
-		generators[i].FromJacobian(&gJac)
-		
-		// Multiply by cofactor to ensure it's in the correct subgroup
-		cofactorJac := bls12381.G1Jac{}
-		cofactorJac.FromAffine(&generators[i])
-		
-		// Use generator as a base point to ensure we get a point on the curve
-		g1 := bls12381.G1Affine{}
-		g1.X.SetOne()
-		g1.Y.SetOne()
-		
-		baseJac := bls12381.G1Jac{}
-		baseJac.FromAffine(&g1)
-		
-		// Scale by a deterministic scalar (hash of i)
-		iBytes := []byte{byte(i)}
-		scalar := new(big.Int).SetBytes(append(h[:], iBytes...))
-		scalar.Mod(scalar, Order)
-		
-		baseJac.ScalarMultiplication(&baseJac, scalar)
-		generators[i].FromJacobian(&baseJac)
+		// Hash the seed to a point in the prime-order subgroup of G1.
+		// HashToG1 only fails for an oversized DST, which DST_G1 is not.
+		g, err := crypto.HashToG1(seed, []byte(DST_G1))
+		if err != nil {
+			panic(fmt.Sprintf("bbs: failed to hash generator %d to G1: %v", i, err))
+		}
+		generators[i] = g
 	}
 	
 	return generators
@@ -287,14 +273,37 @@ func AreG1PointsEqual(a, b []bls12381.G1Affine) bool {
 }
 
 // ComputeProofChallenge computes a Fiat-Shamir challenge for a proof
+// The challenge binds the randomized signature (A', A-bar, D), the Schnorr
+// commitments (T1, T2), the disclosed messages and the signing domain. It is
+// hashed with DefaultCiphersuite.
 func ComputeProofChallenge(
 	APrime bls12381.G1Affine,
 	ABar bls12381.G1Affine,
 	D bls12381.G1Affine,
+	T1 bls12381.G1Affine,
+	T2 bls12381.G1Affine,
+	disclosedIndices []int,
+	disclosedMessages map[int]*big.Int,
+	domain *big.Int,
+) *big.Int {
+	return computeProofChallenge(APrime, ABar, D, T1, T2, disclosedIndices, disclosedMessages, domain, nil)
+}
+
+// computeProofChallenge is ComputeProofChallenge with an optional presentation
+// header, which binds the proof to context chosen at presentation time (such
+// as a holder binding challenge) without changing the signed header
+func computeProofChallenge(
+	APrime bls12381.G1Affine,
+	ABar bls12381.G1Affine,
+	D bls12381.G1Affine,
+	T1 bls12381.G1Affine,
+	T2 bls12381.G1Affine,
 	disclosedIndices []int,
 	disclosedMessages map[int]*big.Int,
+	domain *big.Int,
+	presentationHeader []byte,
 ) *big.Int {
-	// Build challenge input bytes: (A', A-bar, D, disclosed message indices, disclosed message values)
+	// Build challenge input bytes: (A', A-bar, D, T1, T2, disclosed message indices, disclosed message values, domain)
 	var buff []byte
 	
 	// Add A'
@@ -306,43 +315,66 @@ func ComputeProofChallenge(
 	// Add D
 	buff = append(buff, D.Marshal()...)
 	
+	// Add the Schnorr commitments
+	buff = append(buff, T1.Marshal()...)
+	buff = append(buff, T2.Marshal()...)
+	
+	buff = appendChallengeSuffix(buff, disclosedIndices, disclosedMessages, domain, presentationHeader)
+	
+	// Hash the buffer to a scalar as DefaultCiphersuite says
+	return DefaultCiphersuite.hashChallenge(buff)
+}
+
+// appendChallengeSuffix appends the part of the challenge input that follows
+// the Schnorr commitments: the disclosed messages, the domain and the
+// presentation header. It depends only on public inputs, so a verifier can
+// encode it before the proof arrives.
+func appendChallengeSuffix(
+	buff []byte,
+	disclosedIndices []int,
+	disclosedMessages map[int]*big.Int,
+	domain *big.Int,
+	presentationHeader []byte,
+) []byte {
 	// Add sorted indices of disclosed messages
 	// Ensure deterministic ordering of indices
-	sortedIndices := make([]int, 0, len(disclosedIndices))
+	sortedIndices := make([]int, len(disclosedIndices))
 	copy(sortedIndices, disclosedIndices)
 	sort.Ints(sortedIndices)
 	
+	// Add the number of disclosed messages so the encoding is unambiguous
+	buff = append(buff, uint32ToBytes(uint32(len(sortedIndices)))...)
+	
 	for _, idx := range sortedIndices {
 		// Convert index to 4 bytes
-		idxBytes := make([]byte, 4)
-		idxBytes[0] = byte(idx >> 24)
-		idxBytes[1] = byte(idx >> 16)
-		idxBytes[2] = byte(idx >> 8)
-		idxBytes[3] = byte(idx)
-		buff = append(buff, idxBytes...)
+		buff = append(buff, uint32ToBytes(uint32(idx))...)
 		
 		// Convert message value to bytes
 		msgBytes := disclosedMessages[idx].Bytes()
 		
 		// Add length prefix (4 bytes) followed by actual bytes
-		lenBytes := make([]byte, 4)
-		lenBytes[0] = byte(len(msgBytes) >> 24)
-		lenBytes[1] = byte(len(msgBytes) >> 16)
-		lenBytes[2] = byte(len(msgBytes) >> 8)
-		lenBytes[3] = byte(len(msgBytes))
-		
-		buff = append(buff, lenBytes...)
+		buff = append(buff, uint32ToBytes(uint32(len(msgBytes)))...)
 		buff = append(buff, msgBytes...)
 	}
 	
-	// Hash the buffer
-	h := sha256.New()
-	h.Write(buff)
-	digest := h.Sum(nil)
+	// Add the domain
+	domainBytes := domain.Bytes()
+	buff = append(buff, uint32ToBytes(uint32(len(domainBytes)))...)
+	buff = append(buff, domainBytes...)
 	
-	// Interpret as big.Int and reduce modulo order
-	challenge := new(big.Int).SetBytes(digest)
-	return challenge.Mod(challenge, Order)
+	// Add the presentation header, if any
+	// Omitting it entirely keeps challenges without one unchanged
+	if len(presentationHeader) > 0 {
+		buff = append(buff, uint32ToBytes(uint32(len(presentationHeader)))...)
+		buff = append(buff, presentationHeader...)
+	}
+
+	return buff
+}
+
+// uint32ToBytes encodes v as 4 big-endian bytes
+func uint32ToBytes(v uint32) []byte {
+	return []byte{byte(v >> 24), byte(v >> 16), byte(v >> 8), byte(v)}
 }
 
 // Note: Object pooling functions are defined in pool.go
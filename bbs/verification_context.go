@@ -0,0 +1,63 @@
+package bbs
+
+import (
+	"math/big"
+
+	bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381"
+)
+
+// VerificationContext holds what proof verification derives from a public
+// key and header alone: the domain and the Miller loop lines of W and -g2.
+// A verifier that checks many proofs under the same key and header builds it
+// once. It is immutable and safe for concurrent use.
+type VerificationContext struct {
+	publicKey *PublicKey
+	domain    *big.Int
+	lines     [][2][len(bls12381.LoopCounter) - 1]bls12381.LineEvaluationAff
+}
+
+// NewVerificationContext precomputes the verification context of publicKey
+// and header
+func NewVerificationContext(publicKey *PublicKey, header []byte) *VerificationContext {
+	var negG2 bls12381.G2Affine
+	negG2.Neg(&publicKey.g2)
+
+	return &VerificationContext{
+		publicKey: publicKey,
+		domain:    CalculateDomain(publicKey, header),
+		lines: [][2][len(bls12381.LoopCounter) - 1]bls12381.LineEvaluationAff{
+			bls12381.PrecomputeLines(publicKey.w),
+			bls12381.PrecomputeLines(negG2),
+		},
+	}
+}
+
+// PublicKey returns the public key of the context
+func (vc *VerificationContext) PublicKey() *PublicKey {
+	return vc.publicKey
+}
+
+// VerifyProof verifies a proof like VerifyProofWithPresentationHeader with
+// the context's key and header
+func (vc *VerificationContext) VerifyProof(
+	proof *ProofOfKnowledge,
+	disclosedMessages map[int]*big.Int,
+	presentationHeader []byte,
+) error {
+	if err := checkProofChallenge(vc.publicKey, proof, disclosedMessages, vc.domain, presentationHeader); err != nil {
+		return err
+	}
+
+	// e(A', W) * e(A-bar, -g2) = 1. The fixed-Q Miller loop evaluates the
+	// lines in place, so it gets a copy.
+	lines := [][2][len(bls12381.LoopCounter) - 1]bls12381.LineEvaluationAff{vc.lines[0], vc.lines[1]}
+	ok, err := bls12381.PairingCheckFixedQ([]bls12381.G1Affine{proof.APrime, proof.ABar}, lines)
+	if err != nil {
+		return ErrPairingFailed
+	}
+	if !ok {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
@@ -0,0 +1,61 @@
+package bbs
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+)
+
+func TestVerificationContext(t *testing.T) {
+	keyPair, err := GenerateKeyPair(3, rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKeyPair failed: %v", err)
+	}
+	messages := []*big.Int{big.NewInt(1), big.NewInt(2), big.NewInt(3)}
+	header := []byte("header")
+
+	signature, err := Sign(keyPair.PrivateKey, keyPair.PublicKey, messages, header)
+	if err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+	proof, disclosed, err := CreateProofWithPresentationHeader(keyPair.PublicKey, signature, messages, []int{0}, header, []byte("nonce"))
+	if err != nil {
+		t.Fatalf("CreateProofWithPresentationHeader failed: %v", err)
+	}
+
+	ctx := NewVerificationContext(keyPair.PublicKey, header)
+	if err := ctx.VerifyProof(proof, disclosed, []byte("nonce")); err != nil {
+		t.Fatalf("VerifyProof failed: %v", err)
+	}
+	if err := ctx.VerifyProof(proof, disclosed, []byte("other")); err == nil {
+		t.Fatal("proof verified under another presentation header")
+	}
+	if err := NewVerificationContext(keyPair.PublicKey, nil).VerifyProof(proof, disclosed, []byte("nonce")); err == nil {
+		t.Fatal("proof verified under another header")
+	}
+
+	// The pairing check catches a proof for another key
+	other, err := GenerateKeyPair(3, rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKeyPair failed: %v", err)
+	}
+	if err := NewVerificationContext(other.PublicKey, header).VerifyProof(proof, disclosed, []byte("nonce")); err == nil {
+		t.Fatal("proof verified under another key")
+	}
+}
+
+func BenchmarkVerificationContext(b *testing.B) {
+	keyPair, signature, messages, header := verifyFixture(b)
+	proof, disclosed, err := CreateProof(keyPair.PublicKey, signature, messages, []int{0, 1}, header)
+	if err != nil {
+		b.Fatalf("CreateProof failed: %v", err)
+	}
+	ctx := NewVerificationContext(keyPair.PublicKey, header)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := ctx.VerifyProof(proof, disclosed, nil); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,198 @@
+package bbs
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"math/big"
+	"sync"
+	"time"
+)
+
+// DefaultVerifyCacheTTL is how long a verdict stays cached when
+// NewVerifyCache is given a non-positive TTL
+const DefaultVerifyCacheTTL = time.Minute
+
+// Verifiers behind load balancers or retrying clients often see the same
+// presentation several times. A VerifyCache remembers the verdict for a
+// proof in its verification context, so the Engine can answer repeats
+// without the pairings. The key covers the proof encoding, the public key,
+// the disclosed messages and the header; proofs carrying a scalar outside
+// [0, Order) are never cached, since the encoding reduces scalars and would
+// otherwise map a rejected proof onto an accepted one.
+//
+// A cache answers "is this proof valid", not "has it been seen". Do not use
+// it in place of a ReplayGuard.
+
+// VerifyCacheStats counts cache lookups
+type VerifyCacheStats struct {
+	Hits      uint64
+	Misses    uint64
+	Evictions uint64
+	Size      int
+}
+
+// HitRate returns the fraction of lookups answered from the cache
+func (s VerifyCacheStats) HitRate() float64 {
+	total := s.Hits + s.Misses
+	if total == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(total)
+}
+
+// VerifyCache is a bounded, expiring cache of proof verification verdicts.
+// It is safe for concurrent use.
+type VerifyCache struct {
+	mu       sync.Mutex
+	capacity int
+	ttl      time.Duration
+	entries  map[[32]byte]*list.Element
+	order    *list.List
+	stats    VerifyCacheStats
+	now      func() time.Time
+}
+
+// verifyCacheEntry is a cached verdict; err is nil for a valid proof
+type verifyCacheEntry struct {
+	key    [32]byte
+	err    error
+	expiry time.Time
+}
+
+// NewVerifyCache creates a cache holding up to capacity verdicts for ttl each
+func NewVerifyCache(capacity int, ttl time.Duration) *VerifyCache {
+	if capacity <= 0 {
+		capacity = 1
+	}
+	if ttl <= 0 {
+		ttl = DefaultVerifyCacheTTL
+	}
+
+	return &VerifyCache{
+		capacity: capacity,
+		ttl:      ttl,
+		entries:  make(map[[32]byte]*list.Element),
+		order:    list.New(),
+		now:      time.Now,
+	}
+}
+
+// Stats returns the current counters
+func (vc *VerifyCache) Stats() VerifyCacheStats {
+	vc.mu.Lock()
+	defer vc.mu.Unlock()
+
+	stats := vc.stats
+	stats.Size = vc.order.Len()
+	return stats
+}
+
+// lookup returns the cached verdict for key, if any
+func (vc *VerifyCache) lookup(key [32]byte) (bool, error) {
+	vc.mu.Lock()
+	defer vc.mu.Unlock()
+
+	elem, ok := vc.entries[key]
+	if ok {
+		entry := elem.Value.(*verifyCacheEntry)
+		if vc.now().Before(entry.expiry) {
+			vc.order.MoveToFront(elem)
+			vc.stats.Hits++
+			return true, entry.err
+		}
+		vc.order.Remove(elem)
+		delete(vc.entries, key)
+	}
+
+	vc.stats.Misses++
+	return false, nil
+}
+
+// store records the verdict for key, evicting the least recently used entry
+// when full
+func (vc *VerifyCache) store(key [32]byte, err error) {
+	vc.mu.Lock()
+	defer vc.mu.Unlock()
+
+	expiry := vc.now().Add(vc.ttl)
+	if elem, ok := vc.entries[key]; ok {
+		entry := elem.Value.(*verifyCacheEntry)
+		entry.err = err
+		entry.expiry = expiry
+		vc.order.MoveToFront(elem)
+		return
+	}
+
+	for vc.order.Len() >= vc.capacity {
+		oldest := vc.order.Back()
+		vc.order.Remove(oldest)
+		delete(vc.entries, oldest.Value.(*verifyCacheEntry).key)
+		vc.stats.Evictions++
+	}
+
+	vc.entries[key] = vc.order.PushFront(&verifyCacheEntry{key: key, err: err, expiry: expiry})
+}
+
+// verifyCacheKey hashes a proof together with its verification context. It
+// returns false if the proof cannot be cached.
+func verifyCacheKey(
+	publicKey *PublicKey,
+	proof *ProofOfKnowledge,
+	disclosedMessages map[int]*big.Int,
+	header []byte,
+) ([32]byte, bool) {
+	if publicKey == nil || validateProofShape(publicKey, proof, disclosedMessages) != nil {
+		return [32]byte{}, false
+	}
+
+	// Scalars are reduced when encoded, so only reduced ones have a unique key
+	scalars := []*big.Int{proof.C, proof.EHat, proof.SHat, proof.R1Hat, proof.R3Hat}
+	for _, x := range proof.MHat {
+		scalars = append(scalars, x)
+	}
+	for _, x := range proof.CommitmentHat {
+		scalars = append(scalars, x)
+	}
+	for _, x := range disclosedMessages {
+		scalars = append(scalars, x)
+	}
+	for _, x := range scalars {
+		if x == nil || x.Sign() < 0 || x.Cmp(Order) >= 0 {
+			return [32]byte{}, false
+		}
+	}
+
+	h := sha256.New()
+	h.Write([]byte("BBS_VERIFY_CACHE_V1_"))
+
+	// The suite fixes the domain and challenge hashes a verdict depends on
+	h.Write(appendUint32(nil, uint32(len(DefaultCiphersuite.ID))))
+	h.Write([]byte(DefaultCiphersuite.ID))
+	if DefaultCiphersuite.LabeledHashing {
+		h.Write([]byte{1})
+	} else {
+		h.Write([]byte{0})
+	}
+
+	pkBytes := SerializePublicKey(publicKey)
+	h.Write(appendUint32(nil, uint32(len(pkBytes))))
+	h.Write(pkBytes)
+
+	proofBytes := SerializeProof(proof)
+	h.Write(appendUint32(nil, uint32(len(proofBytes))))
+	h.Write(proofBytes)
+
+	indices := sortedKeys(disclosedMessages)
+	h.Write(appendUint32(nil, uint32(len(indices))))
+	for _, idx := range indices {
+		h.Write(appendUint32(nil, uint32(idx)))
+		h.Write(appendScalar(nil, disclosedMessages[idx]))
+	}
+
+	h.Write(appendUint32(nil, uint32(len(header))))
+	h.Write(header)
+
+	var key [32]byte
+	h.Sum(key[:0])
+	return key, true
+}
@@ -0,0 +1,93 @@
+package bbs
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func TestEngineVerifyCache(t *testing.T) {
+	keyPair, err := GenerateKeyPair(3, rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key pair: %v", err)
+	}
+
+	messages := []*big.Int{big.NewInt(1), big.NewInt(2), big.NewInt(3)}
+	header := []byte("cache header")
+
+	signature, err := Sign(keyPair.PrivateKey, keyPair.PublicKey, messages, header)
+	if err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+
+	proof, disclosed, err := CreateProof(keyPair.PublicKey, signature, messages, []int{0}, header)
+	if err != nil {
+		t.Fatalf("CreateProof failed: %v", err)
+	}
+
+	cache := NewVerifyCache(2, time.Minute)
+	engine := NewEngine(WithVerifyCache(cache))
+
+	// The second verification is answered from the cache
+	for i := 0; i < 2; i++ {
+		if err := engine.VerifyProof(keyPair.PublicKey, proof, disclosed, header); err != nil {
+			t.Fatalf("VerifyProof %d failed: %v", i, err)
+		}
+	}
+	stats := cache.Stats()
+	if stats.Hits != 1 || stats.Misses != 1 || stats.Size != 1 {
+		t.Fatalf("unexpected stats after repeat: %+v", stats)
+	}
+	if stats.HitRate() != 0.5 {
+		t.Fatalf("HitRate = %v, want 0.5", stats.HitRate())
+	}
+
+	// A different context is a different entry, and failures are cached too
+	for i := 0; i < 2; i++ {
+		if err := engine.VerifyProof(keyPair.PublicKey, proof, disclosed, []byte("other header")); err == nil {
+			t.Fatal("VerifyProof accepted the wrong header")
+		}
+	}
+	wrongMessage := map[int]*big.Int{0: big.NewInt(5)}
+	if err := engine.VerifyProof(keyPair.PublicKey, proof, wrongMessage, header); err == nil {
+		t.Fatal("VerifyProof accepted the wrong disclosed message")
+	}
+	stats = cache.Stats()
+	if stats.Hits != 2 || stats.Misses != 3 || stats.Evictions != 1 || stats.Size != 2 {
+		t.Fatalf("unexpected stats after failures: %+v", stats)
+	}
+
+	// An unreduced challenge bypasses the cache and is rejected
+	unreduced := *proof
+	unreduced.C = new(big.Int).Add(proof.C, Order)
+	if _, ok := verifyCacheKey(keyPair.PublicKey, &unreduced, disclosed, header); ok {
+		t.Fatal("unreduced proof got a cache key")
+	}
+	if err := engine.VerifyProof(keyPair.PublicKey, proof, disclosed, header); err != nil {
+		t.Fatalf("VerifyProof failed: %v", err)
+	}
+	if err := engine.VerifyProof(keyPair.PublicKey, &unreduced, disclosed, header); err == nil {
+		t.Fatal("VerifyProof accepted an unreduced challenge")
+	}
+}
+
+func TestVerifyCacheExpiry(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	cache := NewVerifyCache(4, time.Minute)
+	cache.now = func() time.Time { return now }
+
+	key := [32]byte{1}
+	cache.store(key, nil)
+	if hit, _ := cache.lookup(key); !hit {
+		t.Fatal("fresh entry missed")
+	}
+
+	now = now.Add(2 * time.Minute)
+	if hit, _ := cache.lookup(key); hit {
+		t.Fatal("expired entry hit")
+	}
+	if stats := cache.Stats(); stats.Size != 0 {
+		t.Fatalf("expired entry kept: %+v", stats)
+	}
+}
@@ -0,0 +1,917 @@
+// Command ceremony runs a t-of-n key ceremony for a threshold issuer
+// without a trusted dealer.
+//
+// Every participant creates a participant key, whose public half goes into
+// the ceremony file. Each then deals: it commits to a random polynomial and
+// encrypts a share of it to every other participant. From all deals a
+// participant checks and decrypts its shares, adds them up into its key
+// share and signs a transcript of the ceremony. The transcripts of all
+// participants must agree before the key is used.
+//
+// Usage:
+//
+//	ceremony participant -name alice -output alice.key -public alice.pub.json
+//	ceremony init -threshold 2 -messages 10 -participants alice.pub.json,bob.pub.json,carol.pub.json -output ceremony.json
+//	ceremony deal -ceremony ceremony.json -key alice.key -output deal-alice.json
+//	ceremony finalize -ceremony ceremony.json -key alice.key -deals deal-alice.json,deal-bob.json,deal-carol.json -share alice.share.json -transcript transcript-alice.json
+//	ceremony verify -transcripts transcript-alice.json,transcript-bob.json,transcript-carol.json -output transcript.json
+package main
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"math/big"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/anupsv/bbsplus-signatures/bbs"
+	"github.com/anupsv/bbsplus-signatures/internal/secret"
+	bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381"
+)
+
+// Domain separation of the ceremony's keys and signatures
+const (
+	shareKeyLabel       = "BBS_CEREMONY_SHARE_KEY_V1"
+	dealSigLabel        = "BBS_CEREMONY_DEAL_V1"
+	transcriptSigLabel  = "BBS_CEREMONY_TRANSCRIPT_V1"
+	ceremonyIDSize      = 16
+	privateFileMode     = 0600
+	publicFileMode      = 0644
+	maxCeremonyFileSize = 16 << 20
+)
+
+// Command represents a subcommand
+type Command struct {
+	Name        string
+	Description string
+	Execute     func(args []string) error
+}
+
+// ParticipantKey is a participant's private key file. Byte strings are hex.
+type ParticipantKey struct {
+	Name          string `json:"name,omitempty"`
+	EncryptionKey string `json:"encryptionKey"` // X25519 private key
+	SigningKey    string `json:"signingKey"`    // Ed25519 seed
+}
+
+// Participant is the public half of a participant key
+type Participant struct {
+	Index           int    `json:"index,omitempty"`
+	Name            string `json:"name,omitempty"`
+	EncryptionKey   string `json:"encryptionKey"`   // X25519 public key
+	VerificationKey string `json:"verificationKey"` // Ed25519 public key
+}
+
+// Ceremony holds the parameters all participants agree on before dealing
+type Ceremony struct {
+	ID           string        `json:"id"`
+	Threshold    int           `json:"threshold"`
+	MessageCount int           `json:"messageCount"`
+	Participants []Participant `json:"participants"`
+}
+
+// Deal is a participant's signed dealing message
+type Deal struct {
+	Ceremony    string           `json:"ceremony"`
+	Dealer      int              `json:"dealer"`
+	Commitments []string         `json:"commitments"` // compressed G2 points g2*a_k
+	Shares      []EncryptedShare `json:"shares"`
+	Signature   string           `json:"signature,omitempty"`
+}
+
+// EncryptedShare is a share encrypted to its recipient's encryption key
+type EncryptedShare struct {
+	Recipient    int    `json:"recipient"`
+	EphemeralKey string `json:"ephemeralKey"`
+	Ciphertext   string `json:"ciphertext"` // nonce followed by the AES-GCM sealed share
+}
+
+// ShareFile holds a participant's key share
+type ShareFile struct {
+	Ceremony     string `json:"ceremony"`
+	Index        int    `json:"index"`
+	Threshold    int    `json:"threshold"`
+	Participants int    `json:"participants"`
+	Share        string `json:"share"`
+	PublicKey    string `json:"publicKey"`
+	Fingerprint  string `json:"fingerprint"`
+}
+
+// Transcript records the outcome of a ceremony. Each participant signs the
+// same transcript.
+type Transcript struct {
+	Ceremony    Ceremony          `json:"ceremony"`
+	Deals       []string          `json:"deals"` // SHA-256 of each dealer's deal
+	PublicKey   string            `json:"publicKey"`
+	Fingerprint string            `json:"fingerprint"`
+	ShareKeys   []string          `json:"shareKeys"` // g2*share of each participant
+	Signatures  map[string]string `json:"signatures,omitempty"`
+}
+
+func main() {
+	commands := []Command{
+		{
+			Name:        "participant",
+			Description: "Create a participant key",
+			Execute:     cmdParticipant,
+		},
+		{
+			Name:        "init",
+			Description: "Write the ceremony parameters",
+			Execute:     cmdInit,
+		},
+		{
+			Name:        "deal",
+			Description: "Deal encrypted shares to every participant",
+			Execute:     cmdDeal,
+		},
+		{
+			Name:        "finalize",
+			Description: "Check the deals, derive the key share and sign the transcript",
+			Execute:     cmdFinalize,
+		},
+		{
+			Name:        "verify",
+			Description: "Check and merge the signed transcripts",
+			Execute:     cmdVerify,
+		},
+	}
+
+	if len(os.Args) < 2 {
+		showHelp(commands)
+		os.Exit(1)
+	}
+
+	for _, cmd := range commands {
+		if cmd.Name == os.Args[1] {
+			if err := cmd.Execute(os.Args[2:]); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "Unknown command: %s\n\n", os.Args[1])
+	showHelp(commands)
+	os.Exit(1)
+}
+
+// showHelp lists the commands
+func showHelp(commands []Command) {
+	fmt.Println("BBS+ key ceremony - dealer-less key generation for threshold issuers")
+	fmt.Println("\nUsage:")
+	fmt.Println("  ceremony <command> [options]")
+
+	fmt.Println("\nAvailable Commands:")
+	for _, cmd := range commands {
+		fmt.Printf("  %-12s %s\n", cmd.Name, cmd.Description)
+	}
+
+	fmt.Println("\nRun 'ceremony <command> -h' for more information about a command")
+}
+
+// cmdParticipant creates a participant key and writes its public half
+func cmdParticipant(args []string) error {
+	flagSet := flag.NewFlagSet("participant", flag.ExitOnError)
+	name := flagSet.String("name", "", "Name of the participant")
+	output := flagSet.String("output", "participant.key", "Output file for the private participant key")
+	public := flagSet.String("public", "participant.pub.json", "Output file for the public participant key")
+	flagSet.Parse(args)
+
+	encryptionKey, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return fmt.Errorf("failed to generate encryption key: %w", err)
+	}
+	verificationKey, signingKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return fmt.Errorf("failed to generate signing key: %w", err)
+	}
+
+	key := ParticipantKey{
+		Name:          *name,
+		EncryptionKey: hex.EncodeToString(encryptionKey.Bytes()),
+		SigningKey:    hex.EncodeToString(signingKey.Seed()),
+	}
+	if err := writeJSON(*output, key, privateFileMode); err != nil {
+		return err
+	}
+
+	participant := Participant{
+		Name:            *name,
+		EncryptionKey:   hex.EncodeToString(encryptionKey.PublicKey().Bytes()),
+		VerificationKey: hex.EncodeToString(verificationKey),
+	}
+	if err := writeJSON(*public, participant, publicFileMode); err != nil {
+		return err
+	}
+
+	fmt.Printf("Participant key saved to %s, public key to %s\n", *output, *public)
+	return nil
+}
+
+// cmdInit writes the ceremony parameters
+func cmdInit(args []string) error {
+	flagSet := flag.NewFlagSet("init", flag.ExitOnError)
+	threshold := flagSet.Int("threshold", 2, "Number of participants needed to sign (t)")
+	messageCount := flagSet.Int("messages", 10, "Number of messages the key signs")
+	participantFiles := flagSet.String("participants", "", "Comma-separated public participant key files, in index order")
+	output := flagSet.String("output", "ceremony.json", "Output file for the ceremony parameters")
+	flagSet.Parse(args)
+
+	paths := splitList(*participantFiles)
+	c, err := NewCeremony(*threshold, *messageCount, paths)
+	if err != nil {
+		return err
+	}
+	if err := writeJSON(*output, c, publicFileMode); err != nil {
+		return err
+	}
+
+	fmt.Printf("Ceremony %s: %d-of-%d, %d messages, saved to %s\n", c.ID, c.Threshold, len(c.Participants), c.MessageCount, *output)
+	return nil
+}
+
+// NewCeremony sets up a ceremony between the participants in the public key
+// files, which are numbered from 1 in the order given
+func NewCeremony(threshold, messageCount int, participantFiles []string) (*Ceremony, error) {
+	n := len(participantFiles)
+	if threshold <= 0 || threshold > n {
+		return nil, fmt.Errorf("invalid threshold parameters: t=%d, n=%d", threshold, n)
+	}
+	if err := bbs.DefaultLimits.CheckMessageCount(messageCount); err != nil || messageCount < 1 {
+		return nil, fmt.Errorf("invalid message count: %d", messageCount)
+	}
+
+	var id [ceremonyIDSize]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		return nil, err
+	}
+	c := &Ceremony{ID: hex.EncodeToString(id[:]), Threshold: threshold, MessageCount: messageCount}
+
+	seen := make(map[string]bool, n)
+	for i, path := range participantFiles {
+		var p Participant
+		if err := readJSON(path, &p); err != nil {
+			return nil, err
+		}
+		if _, err := encryptionKey(p); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		if _, err := verificationKey(p); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		if seen[p.VerificationKey] {
+			return nil, fmt.Errorf("%s: participant listed twice", path)
+		}
+		seen[p.VerificationKey] = true
+
+		p.Index = i + 1
+		c.Participants = append(c.Participants, p)
+	}
+
+	return c, nil
+}
+
+// cmdDeal deals shares to every participant
+func cmdDeal(args []string) error {
+	flagSet := flag.NewFlagSet("deal", flag.ExitOnError)
+	ceremonyFile := flagSet.String("ceremony", "ceremony.json", "Ceremony parameters file")
+	keyFile := flagSet.String("key", "participant.key", "Private participant key file")
+	output := flagSet.String("output", "deal.json", "Output file for the deal")
+	flagSet.Parse(args)
+
+	c, key, err := loadCeremonyAndKey(*ceremonyFile, *keyFile)
+	if err != nil {
+		return err
+	}
+	deal, err := NewDeal(c, key)
+	if err != nil {
+		return err
+	}
+	if err := writeJSON(*output, deal, publicFileMode); err != nil {
+		return err
+	}
+
+	fmt.Printf("Deal of participant %d saved to %s\n", deal.Dealer, *output)
+	return nil
+}
+
+// NewDeal deals the shares of a participant, each encrypted to its
+// recipient, and signs the deal
+func NewDeal(c *Ceremony, key *ParticipantKey) (*Deal, error) {
+	dealer, err := c.indexOf(key)
+	if err != nil {
+		return nil, err
+	}
+
+	dkg, err := bbs.NewDKGDeal(dealer, c.Threshold, len(c.Participants), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	defer dkg.Wipe()
+
+	deal := &Deal{Ceremony: c.ID, Dealer: dealer}
+	for i := range dkg.Commitments {
+		b := dkg.Commitments[i].Bytes()
+		deal.Commitments = append(deal.Commitments, hex.EncodeToString(b[:]))
+	}
+
+	for _, p := range c.Participants {
+		recipientKey, err := encryptionKey(p)
+		if err != nil {
+			return nil, fmt.Errorf("participant %d: %w", p.Index, err)
+		}
+		var share [bbs.ScalarSize]byte
+		dkg.Shares[p.Index-1].FillBytes(share[:])
+		sealed, err := sealShare(recipientKey, share[:], shareAD(c.ID, dealer, p.Index))
+		clear(share[:])
+		secret.WipeInt(dkg.Shares[p.Index-1])
+		if err != nil {
+			return nil, fmt.Errorf("failed to encrypt share for participant %d: %w", p.Index, err)
+		}
+		sealed.Recipient = p.Index
+		deal.Shares = append(deal.Shares, sealed)
+	}
+
+	signingKey, err := key.signingKey()
+	if err != nil {
+		return nil, err
+	}
+	digest, err := deal.digest()
+	if err != nil {
+		return nil, err
+	}
+	deal.Signature = hex.EncodeToString(ed25519.Sign(signingKey, signedMessage(dealSigLabel, digest)))
+
+	return deal, nil
+}
+
+// cmdFinalize derives the participant's key share from all deals
+func cmdFinalize(args []string) error {
+	flagSet := flag.NewFlagSet("finalize", flag.ExitOnError)
+	ceremonyFile := flagSet.String("ceremony", "ceremony.json", "Ceremony parameters file")
+	keyFile := flagSet.String("key", "participant.key", "Private participant key file")
+	dealFiles := flagSet.String("deals", "", "Comma-separated deal files of all participants")
+	shareFile := flagSet.String("share", "share.json", "Output file for the key share")
+	transcriptFile := flagSet.String("transcript", "transcript.json", "Output file for the signed transcript")
+	flagSet.Parse(args)
+
+	c, key, err := loadCeremonyAndKey(*ceremonyFile, *keyFile)
+	if err != nil {
+		return err
+	}
+
+	var deals []*Deal
+	for _, path := range splitList(*dealFiles) {
+		deal := &Deal{}
+		if err := readJSON(path, deal); err != nil {
+			return err
+		}
+		deals = append(deals, deal)
+	}
+
+	share, transcript, err := Finalize(c, key, deals)
+	if err != nil {
+		return err
+	}
+	if err := writeJSON(*shareFile, share, privateFileMode); err != nil {
+		return err
+	}
+	if err := writeJSON(*transcriptFile, transcript, publicFileMode); err != nil {
+		return err
+	}
+
+	fmt.Printf("Key share %d of %s saved to %s, signed transcript to %s\n", share.Index, share.Fingerprint, *shareFile, *transcriptFile)
+	return nil
+}
+
+// Finalize checks the deals of every participant, decrypts and verifies the
+// shares dealt to key, and returns the resulting key share together with
+// the transcript signed by key
+func Finalize(c *Ceremony, key *ParticipantKey, deals []*Deal) (*ShareFile, *Transcript, error) {
+	index, err := c.indexOf(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	privateKey, err := key.encryptionKey()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	n := len(c.Participants)
+	byDealer := make([]*Deal, n)
+	for _, deal := range deals {
+		if deal.Dealer < 1 || deal.Dealer > n {
+			return nil, nil, fmt.Errorf("deal from unknown participant %d", deal.Dealer)
+		}
+		if byDealer[deal.Dealer-1] != nil {
+			return nil, nil, fmt.Errorf("participant %d dealt twice", deal.Dealer)
+		}
+		byDealer[deal.Dealer-1] = deal
+	}
+
+	commitments := make([][]bls12381.G2Affine, n)
+	shares := make([]*big.Int, n)
+	defer func() { secret.WipeInts(shares...) }()
+	digests := make([]string, n)
+	for i, deal := range byDealer {
+		if deal == nil {
+			return nil, nil, fmt.Errorf("missing deal of participant %d", i+1)
+		}
+		if commitments[i], err = c.checkDeal(deal); err != nil {
+			return nil, nil, fmt.Errorf("deal of participant %d: %w", i+1, err)
+		}
+		if shares[i], err = openShare(privateKey, c.ID, deal, index); err != nil {
+			return nil, nil, fmt.Errorf("deal of participant %d: %w", i+1, err)
+		}
+		digest, err := deal.digest()
+		if err != nil {
+			return nil, nil, err
+		}
+		digests[i] = hex.EncodeToString(digest)
+	}
+
+	publicKey, err := bbs.DKGPublicKey(commitments, c.MessageCount)
+	if err != nil {
+		return nil, nil, err
+	}
+	keyShare, err := bbs.CombineDKGShares(index, commitments, shares, publicKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var shareBytes [bbs.ScalarSize]byte
+	keyShare.Share.FillBytes(shareBytes[:])
+	secret.WipeInt(keyShare.Share)
+	shareFile := &ShareFile{
+		Ceremony:     c.ID,
+		Index:        index,
+		Threshold:    c.Threshold,
+		Participants: n,
+		Share:        hex.EncodeToString(shareBytes[:]),
+		PublicKey:    hex.EncodeToString(bbs.SerializePublicKey(publicKey)),
+		Fingerprint:  publicKey.Fingerprint(),
+	}
+	clear(shareBytes[:])
+
+	transcript := &Transcript{
+		Ceremony:    *c,
+		Deals:       digests,
+		PublicKey:   shareFile.PublicKey,
+		Fingerprint: shareFile.Fingerprint,
+		ShareKeys:   shareKeys(commitments),
+	}
+	if err := transcript.sign(index, key); err != nil {
+		return nil, nil, err
+	}
+
+	return shareFile, transcript, nil
+}
+
+// cmdVerify checks that every participant signed the same transcript
+func cmdVerify(args []string) error {
+	flagSet := flag.NewFlagSet("verify", flag.ExitOnError)
+	transcriptFiles := flagSet.String("transcripts", "", "Comma-separated signed transcripts of all participants")
+	output := flagSet.String("output", "", "Output file for the merged transcript (optional)")
+	flagSet.Parse(args)
+
+	var transcripts []*Transcript
+	for _, path := range splitList(*transcriptFiles) {
+		t := &Transcript{}
+		if err := readJSON(path, t); err != nil {
+			return err
+		}
+		transcripts = append(transcripts, t)
+	}
+
+	merged, err := MergeTranscripts(transcripts)
+	if err != nil {
+		return err
+	}
+	if *output != "" {
+		if err := writeJSON(*output, merged, publicFileMode); err != nil {
+			return err
+		}
+	}
+
+	fmt.Printf("All %d participants signed the transcript of ceremony %s\n", len(merged.Ceremony.Participants), merged.Ceremony.ID)
+	fmt.Printf("Public key fingerprint: %s\n", merged.Fingerprint)
+	return nil
+}
+
+// MergeTranscripts checks the signatures of transcripts, which must all be
+// of the same ceremony outcome, and merges them. Every participant must
+// have signed.
+func MergeTranscripts(transcripts []*Transcript) (*Transcript, error) {
+	if len(transcripts) == 0 {
+		return nil, fmt.Errorf("no transcripts")
+	}
+
+	merged := *transcripts[0]
+	merged.Signatures = make(map[string]string)
+	want, err := merged.digest()
+	if err != nil {
+		return nil, err
+	}
+
+	for i, t := range transcripts {
+		digest, err := t.digest()
+		if err != nil {
+			return nil, err
+		}
+		if !bytes.Equal(digest, want) {
+			return nil, fmt.Errorf("transcript %d differs from transcript 1", i+1)
+		}
+		for signer, sig := range t.Signatures {
+			if err := merged.verifySignature(signer, sig, digest); err != nil {
+				return nil, fmt.Errorf("transcript %d: %w", i+1, err)
+			}
+			merged.Signatures[signer] = sig
+		}
+	}
+
+	for _, p := range merged.Ceremony.Participants {
+		if _, ok := merged.Signatures[strconv.Itoa(p.Index)]; !ok {
+			return nil, fmt.Errorf("participant %d has not signed the transcript", p.Index)
+		}
+	}
+
+	return &merged, nil
+}
+
+// LoadShare reads a share file as a key share for bbs.ThresholdSign
+func LoadShare(path string) (*bbs.KeyShare, error) {
+	var f ShareFile
+	if err := readJSON(path, &f); err != nil {
+		return nil, err
+	}
+
+	shareBytes, err := hex.DecodeString(f.Share)
+	if err != nil || len(shareBytes) != bbs.ScalarSize {
+		return nil, fmt.Errorf("invalid share in %s", path)
+	}
+	pkBytes, err := hex.DecodeString(f.PublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid public key in %s: %w", path, err)
+	}
+	publicKey, err := bbs.DeserializePublicKey(pkBytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid public key in %s: %w", path, err)
+	}
+
+	share := new(big.Int).SetBytes(shareBytes)
+	clear(shareBytes)
+	g1 := publicKey.G1()
+	var commitment bls12381.G1Affine
+	commitment.ScalarMultiplication(&g1, share)
+
+	return &bbs.KeyShare{
+		Index:      f.Index,
+		Share:      share,
+		PublicKey:  publicKey,
+		Commitment: commitment,
+	}, nil
+}
+
+// indexOf returns the index of the participant holding key
+func (c *Ceremony) indexOf(key *ParticipantKey) (int, error) {
+	signingKey, err := key.signingKey()
+	if err != nil {
+		return 0, err
+	}
+	public := hex.EncodeToString(signingKey.Public().(ed25519.PublicKey))
+	for _, p := range c.Participants {
+		if p.VerificationKey == public {
+			return p.Index, nil
+		}
+	}
+	return 0, fmt.Errorf("participant key is not part of ceremony %s", c.ID)
+}
+
+// checkDeal checks a deal's signature and shape and decodes its commitments
+func (c *Ceremony) checkDeal(deal *Deal) ([]bls12381.G2Affine, error) {
+	if deal.Ceremony != c.ID {
+		return nil, fmt.Errorf("deal is for ceremony %s", deal.Ceremony)
+	}
+
+	vk, err := verificationKey(c.Participants[deal.Dealer-1])
+	if err != nil {
+		return nil, err
+	}
+	sig, err := hex.DecodeString(deal.Signature)
+	if err != nil {
+		return nil, fmt.Errorf("invalid signature: %w", err)
+	}
+	digest, err := deal.digest()
+	if err != nil {
+		return nil, err
+	}
+	if !ed25519.Verify(vk, signedMessage(dealSigLabel, digest), sig) {
+		return nil, fmt.Errorf("signature does not verify")
+	}
+
+	if len(deal.Commitments) != c.Threshold {
+		return nil, fmt.Errorf("%d commitments, expected %d", len(deal.Commitments), c.Threshold)
+	}
+	commitments := make([]bls12381.G2Affine, len(deal.Commitments))
+	for k, s := range deal.Commitments {
+		b, err := hex.DecodeString(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid commitment %d: %w", k, err)
+		}
+		if _, err := commitments[k].SetBytes(b); err != nil {
+			return nil, fmt.Errorf("invalid commitment %d: %w", k, err)
+		}
+	}
+	return commitments, nil
+}
+
+// digest returns the SHA-256 of the deal without its signature
+func (d *Deal) digest() ([]byte, error) {
+	unsigned := *d
+	unsigned.Signature = ""
+	data, err := json.Marshal(unsigned)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(data)
+	return sum[:], nil
+}
+
+// digest returns the SHA-256 of the transcript without its signatures
+func (t *Transcript) digest() ([]byte, error) {
+	unsigned := *t
+	unsigned.Signatures = nil
+	data, err := json.Marshal(unsigned)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(data)
+	return sum[:], nil
+}
+
+// sign adds the signature of participant index to the transcript
+func (t *Transcript) sign(index int, key *ParticipantKey) error {
+	signingKey, err := key.signingKey()
+	if err != nil {
+		return err
+	}
+	digest, err := t.digest()
+	if err != nil {
+		return err
+	}
+	if t.Signatures == nil {
+		t.Signatures = make(map[string]string)
+	}
+	t.Signatures[strconv.Itoa(index)] = hex.EncodeToString(ed25519.Sign(signingKey, signedMessage(transcriptSigLabel, digest)))
+	return nil
+}
+
+// verifySignature checks the signature of the participant numbered signer
+// on the transcript digest
+func (t *Transcript) verifySignature(signer, sig string, digest []byte) error {
+	index, err := strconv.Atoi(signer)
+	if err != nil || index < 1 || index > len(t.Ceremony.Participants) {
+		return fmt.Errorf("signature of unknown participant %s", signer)
+	}
+	vk, err := verificationKey(t.Ceremony.Participants[index-1])
+	if err != nil {
+		return err
+	}
+	sigBytes, err := hex.DecodeString(sig)
+	if err != nil || !ed25519.Verify(vk, signedMessage(transcriptSigLabel, digest), sigBytes) {
+		return fmt.Errorf("signature of participant %d does not verify", index)
+	}
+	return nil
+}
+
+// shareKeys returns g2*share of every participant, summed over the dealers'
+// commitments
+func shareKeys(commitments [][]bls12381.G2Affine) []string {
+	keys := make([]string, len(commitments))
+	for j := range keys {
+		var sum bls12381.G2Jac
+		for _, c := range commitments {
+			point := bbs.DKGShareKey(c, j+1)
+			sum.AddMixed(&point)
+		}
+		var affine bls12381.G2Affine
+		affine.FromJacobian(&sum)
+		b := affine.Bytes()
+		keys[j] = hex.EncodeToString(b[:])
+	}
+	return keys
+}
+
+// sealShare encrypts a share to recipient with an ephemeral X25519 key
+func sealShare(recipient *ecdh.PublicKey, share, ad []byte) (EncryptedShare, error) {
+	ephemeral, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return EncryptedShare{}, err
+	}
+	secret, err := ephemeral.ECDH(recipient)
+	if err != nil {
+		return EncryptedShare{}, err
+	}
+	aead, err := shareAEAD(secret, ephemeral.PublicKey().Bytes(), recipient.Bytes())
+	if err != nil {
+		return EncryptedShare{}, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return EncryptedShare{}, err
+	}
+	return EncryptedShare{
+		EphemeralKey: hex.EncodeToString(ephemeral.PublicKey().Bytes()),
+		Ciphertext:   hex.EncodeToString(aead.Seal(nonce, nonce, share, ad)),
+	}, nil
+}
+
+// openShare decrypts the share dealt to participant index
+func openShare(privateKey *ecdh.PrivateKey, ceremonyID string, deal *Deal, index int) (*big.Int, error) {
+	var sealed *EncryptedShare
+	for i := range deal.Shares {
+		if deal.Shares[i].Recipient == index {
+			sealed = &deal.Shares[i]
+			break
+		}
+	}
+	if sealed == nil {
+		return nil, fmt.Errorf("no share for participant %d", index)
+	}
+
+	ephemeralBytes, err := hex.DecodeString(sealed.EphemeralKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ephemeral key: %w", err)
+	}
+	ephemeral, err := ecdh.X25519().NewPublicKey(ephemeralBytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ephemeral key: %w", err)
+	}
+	ciphertext, err := hex.DecodeString(sealed.Ciphertext)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ciphertext: %w", err)
+	}
+
+	secret, err := privateKey.ECDH(ephemeral)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := shareAEAD(secret, ephemeralBytes, privateKey.PublicKey().Bytes())
+	if err != nil {
+		return nil, err
+	}
+	if len(ciphertext) < aead.NonceSize() {
+		return nil, fmt.Errorf("share does not decrypt")
+	}
+	nonce, ciphertext := ciphertext[:aead.NonceSize()], ciphertext[aead.NonceSize():]
+	share, err := aead.Open(nil, nonce, ciphertext, shareAD(ceremonyID, deal.Dealer, index))
+	if err != nil || len(share) != bbs.ScalarSize {
+		return nil, fmt.Errorf("share does not decrypt")
+	}
+	defer clear(share)
+
+	return new(big.Int).SetBytes(share), nil
+}
+
+// shareAEAD derives the AES-GCM cipher of a share from the X25519 secret and
+// both public keys
+func shareAEAD(secret, ephemeral, recipient []byte) (cipher.AEAD, error) {
+	h := sha256.New()
+	h.Write([]byte(shareKeyLabel))
+	h.Write(secret)
+	h.Write(ephemeral)
+	h.Write(recipient)
+	key := h.Sum(nil)
+	defer clear(key)
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// shareAD binds an encrypted share to its ceremony, dealer and recipient
+func shareAD(ceremonyID string, dealer, recipient int) []byte {
+	return []byte(fmt.Sprintf("%s|%s|%d|%d", shareKeyLabel, ceremonyID, dealer, recipient))
+}
+
+// signedMessage prefixes a digest with the label of what is signed
+func signedMessage(label string, digest []byte) []byte {
+	return append([]byte(label), digest...)
+}
+
+// encryptionKey decodes the private X25519 key
+func (k *ParticipantKey) encryptionKey() (*ecdh.PrivateKey, error) {
+	b, err := hex.DecodeString(k.EncryptionKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid encryption key: %w", err)
+	}
+	return ecdh.X25519().NewPrivateKey(b)
+}
+
+// signingKey decodes the Ed25519 signing key
+func (k *ParticipantKey) signingKey() (ed25519.PrivateKey, error) {
+	seed, err := hex.DecodeString(k.SigningKey)
+	if err != nil || len(seed) != ed25519.SeedSize {
+		return nil, fmt.Errorf("invalid signing key")
+	}
+	return ed25519.NewKeyFromSeed(seed), nil
+}
+
+// encryptionKey decodes a participant's public X25519 key
+func encryptionKey(p Participant) (*ecdh.PublicKey, error) {
+	b, err := hex.DecodeString(p.EncryptionKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid encryption key: %w", err)
+	}
+	return ecdh.X25519().NewPublicKey(b)
+}
+
+// verificationKey decodes a participant's Ed25519 public key
+func verificationKey(p Participant) (ed25519.PublicKey, error) {
+	b, err := hex.DecodeString(p.VerificationKey)
+	if err != nil || len(b) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("invalid verification key")
+	}
+	return ed25519.PublicKey(b), nil
+}
+
+// loadCeremonyAndKey reads the ceremony parameters and a participant key
+func loadCeremonyAndKey(ceremonyFile, keyFile string) (*Ceremony, *ParticipantKey, error) {
+	c := &Ceremony{}
+	if err := readJSON(ceremonyFile, c); err != nil {
+		return nil, nil, err
+	}
+	for i, p := range c.Participants {
+		if p.Index != i+1 {
+			return nil, nil, fmt.Errorf("%s: participant %d has index %d", ceremonyFile, i+1, p.Index)
+		}
+	}
+	if c.Threshold <= 0 || c.Threshold > len(c.Participants) {
+		return nil, nil, fmt.Errorf("%s: invalid threshold parameters: t=%d, n=%d", ceremonyFile, c.Threshold, len(c.Participants))
+	}
+
+	key := &ParticipantKey{}
+	if err := readJSON(keyFile, key); err != nil {
+		return nil, nil, err
+	}
+	return c, key, nil
+}
+
+// splitList splits a comma-separated list of file names
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// readJSON reads the JSON file at path into v
+func readJSON(path string, v interface{}) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if info.Size() > maxCeremonyFileSize {
+		return fmt.Errorf("%s: %w: %d bytes", path, bbs.ErrLimitExceeded, info.Size())
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return nil
+}
+
+// writeJSON writes v as indented JSON to path
+func writeJSON(path string, v interface{}, perm os.FileMode) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode JSON: %w", err)
+	}
+	data = append(data, '\n')
+
+	if err := os.WriteFile(path, data, perm); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"math/big"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/anupsv/bbsplus-signatures/bbs"
+)
+
+func TestCeremony(t *testing.T) {
+	dir := t.TempDir()
+	path := func(name string) string { return filepath.Join(dir, name) }
+	names := []string{"alice", "bob", "carol"}
+
+	var publicFiles []string
+	for _, name := range names {
+		if err := cmdParticipant([]string{"-name", name, "-output", path(name + ".key"), "-public", path(name + ".pub.json")}); err != nil {
+			t.Fatalf("participant failed: %v", err)
+		}
+		publicFiles = append(publicFiles, path(name+".pub.json"))
+	}
+	if err := cmdInit([]string{"-threshold", "2", "-messages", "3", "-participants", strings.Join(publicFiles, ","), "-output", path("ceremony.json")}); err != nil {
+		t.Fatalf("init failed: %v", err)
+	}
+
+	var dealFiles []string
+	for _, name := range names {
+		dealFile := path("deal-" + name + ".json")
+		if err := cmdDeal([]string{"-ceremony", path("ceremony.json"), "-key", path(name + ".key"), "-output", dealFile}); err != nil {
+			t.Fatalf("deal failed: %v", err)
+		}
+		dealFiles = append(dealFiles, dealFile)
+	}
+
+	var transcriptFiles []string
+	for _, name := range names {
+		transcriptFile := path("transcript-" + name + ".json")
+		err := cmdFinalize([]string{
+			"-ceremony", path("ceremony.json"), "-key", path(name + ".key"), "-deals", strings.Join(dealFiles, ","),
+			"-share", path(name + ".share.json"), "-transcript", transcriptFile,
+		})
+		if err != nil {
+			t.Fatalf("finalize failed for %s: %v", name, err)
+		}
+		transcriptFiles = append(transcriptFiles, transcriptFile)
+	}
+	if err := cmdVerify([]string{"-transcripts", strings.Join(transcriptFiles, ","), "-output", path("transcript.json")}); err != nil {
+		t.Fatalf("verify failed: %v", err)
+	}
+
+	// Two of the shares sign for the ceremony's key
+	alice, err := LoadShare(path("alice.share.json"))
+	if err != nil {
+		t.Fatalf("LoadShare failed: %v", err)
+	}
+	carol, err := LoadShare(path("carol.share.json"))
+	if err != nil {
+		t.Fatalf("LoadShare failed: %v", err)
+	}
+	messages := []*big.Int{big.NewInt(7), big.NewInt(8), big.NewInt(9)}
+	sig, err := bbs.ThresholdSign([]*bbs.KeyShare{alice, carol}, messages, nil)
+	if err != nil {
+		t.Fatalf("ThresholdSign failed: %v", err)
+	}
+	var merged Transcript
+	if err := readJSON(path("transcript.json"), &merged); err != nil {
+		t.Fatalf("readJSON failed: %v", err)
+	}
+	if merged.Fingerprint != alice.PublicKey.Fingerprint() {
+		t.Fatalf("Transcript names another key than the shares")
+	}
+	if err := bbs.Verify(alice.PublicKey, sig.Signature, messages, nil); err != nil {
+		t.Fatalf("Verify failed: %v", err)
+	}
+
+	// A tampered deal is rejected
+	var deal Deal
+	if err := readJSON(dealFiles[1], &deal); err != nil {
+		t.Fatalf("readJSON failed: %v", err)
+	}
+	deal.Commitments[0], deal.Commitments[1] = deal.Commitments[1], deal.Commitments[0]
+	tampered := path("deal-tampered.json")
+	if err := writeJSON(tampered, deal, publicFileMode); err != nil {
+		t.Fatalf("writeJSON failed: %v", err)
+	}
+	err = cmdFinalize([]string{
+		"-ceremony", path("ceremony.json"), "-key", path("alice.key"), "-deals", strings.Join([]string{dealFiles[0], tampered, dealFiles[2]}, ","),
+		"-share", path("bad.share.json"), "-transcript", path("bad.json"),
+	})
+	if err == nil || !strings.Contains(err.Error(), "participant 2") {
+		t.Fatalf("Expected the deal of participant 2 to be rejected, got %v", err)
+	}
+
+	// Transcripts must all be signed and agree
+	if err := cmdVerify([]string{"-transcripts", strings.Join(transcriptFiles[:2], ",")}); err == nil {
+		t.Fatalf("Expected an error for a missing signature")
+	}
+	var transcript Transcript
+	if err := readJSON(transcriptFiles[2], &transcript); err != nil {
+		t.Fatalf("readJSON failed: %v", err)
+	}
+	transcript.Fingerprint = "other"
+	if _, err := MergeTranscripts([]*Transcript{&merged, &transcript}); err == nil {
+		t.Fatalf("Expected an error for differing transcripts")
+	}
+}
@@ -0,0 +1,663 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+	"unicode/utf8"
+
+	"github.com/anupsv/bbsplus-signatures/bbs"
+	"github.com/anupsv/bbsplus-signatures/pkg/keys"
+	"github.com/anupsv/bbsplus-signatures/pkg/multiformat"
+	bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381"
+)
+
+// inspect decodes an artifact without any key but the ones it carries, and
+// checks that its parts agree with each other. It never verifies a
+// signature or proof: that is what verify and verify-proof are for, and an
+// artifact that fails them is usually the one being inspected.
+
+// inspection is what inspect found in an artifact
+type inspection struct {
+	Kind   string  `json:"kind"`
+	Format string  `json:"format"`
+	Size   int     `json:"size"`
+	Fields []field `json:"fields"`
+	Checks []check `json:"checks"`
+}
+
+// field is a structural detail of an artifact
+type field struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// check is the outcome of one consistency check
+type check struct {
+	Name  string `json:"name"`
+	OK    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+}
+
+// add records a field
+func (in *inspection) add(name, format string, args ...interface{}) {
+	in.Fields = append(in.Fields, field{Name: name, Value: fmt.Sprintf(format, args...)})
+}
+
+// check records the outcome of a check and reports whether it passed
+func (in *inspection) check(name string, err error) bool {
+	c := check{Name: name, OK: err == nil}
+	if err != nil {
+		c.Error = err.Error()
+	}
+	in.Checks = append(in.Checks, c)
+	return err == nil
+}
+
+// failed returns the number of failed checks
+func (in *inspection) failed() int {
+	n := 0
+	for _, c := range in.Checks {
+		if !c.OK {
+			n++
+		}
+	}
+	return n
+}
+
+// print writes the inspection as text
+func (in *inspection) print(out io.Writer) {
+	fmt.Fprintf(out, "%s (%s, %d bytes)\n", in.Kind, in.Format, in.Size)
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	for _, f := range in.Fields {
+		fmt.Fprintf(w, "  %s\t%s\n", f.Name, f.Value)
+	}
+	w.Flush()
+
+	fmt.Fprintln(out, "Checks:")
+	for _, c := range in.Checks {
+		if c.OK {
+			fmt.Fprintf(out, "  ok    %s\n", c.Name)
+		} else {
+			fmt.Fprintf(out, "  FAIL  %s: %s\n", c.Name, c.Error)
+		}
+	}
+}
+
+// Inspect command
+func cmdInspect(args []string) error {
+	flagSet := flag.NewFlagSet("inspect", flag.ExitOnError)
+	asJSON := flagSet.Bool("json", false, "Print the inspection as JSON")
+	flagSet.Parse(args)
+
+	if flagSet.NArg() != 1 {
+		return fmt.Errorf("usage: credgen inspect [-json] <file>")
+	}
+	path := flagSet.Arg(0)
+
+	var data []byte
+	var err error
+	if path == "-" {
+		data, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		data, err = ioutil.ReadFile(path)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	in, err := inspectArtifact(data)
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		out, err := json.MarshalIndent(in, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode inspection: %w", err)
+		}
+		fmt.Println(string(out))
+	} else {
+		in.print(os.Stdout)
+	}
+
+	if n := in.failed(); n > 0 {
+		return fmt.Errorf("%d of %d consistency checks failed", n, len(in.Checks))
+	}
+	return nil
+}
+
+// inspectArtifact recognises an artifact and inspects it
+func inspectArtifact(data []byte) (*inspection, error) {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 {
+		return nil, fmt.Errorf("empty input")
+	}
+
+	if trimmed[0] == '{' {
+		return inspectJSON(trimmed)
+	}
+
+	if utf8.Valid(trimmed) && !bytes.ContainsAny(trimmed, " \t\r\n") {
+		text := string(trimmed)
+		if strings.Count(text, ".") == 2 {
+			return inspectJWS(text)
+		}
+		if codec, payload, err := multiformat.Decode(text); err == nil {
+			return inspectMultibase(codec, payload, len(trimmed))
+		}
+		if raw, err := base64.StdEncoding.DecodeString(text); err == nil {
+			return inspectBinary(raw, "base64", len(trimmed))
+		}
+		if raw, err := base64.RawURLEncoding.DecodeString(text); err == nil {
+			return inspectBinary(raw, "base64url", len(trimmed))
+		}
+		if raw, err := hex.DecodeString(text); err == nil {
+			return inspectBinary(raw, "hex", len(trimmed))
+		}
+	}
+
+	return inspectBinary(data, "binary", len(data))
+}
+
+// inspectJSON inspects the JSON files credgen writes
+func inspectJSON(data []byte) (*inspection, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON: %w", err)
+	}
+	has := func(names ...string) bool {
+		for _, name := range names {
+			if _, ok := fields[name]; !ok {
+				return false
+			}
+		}
+		return true
+	}
+
+	switch {
+	case has("version", "metadata", "publicKey"):
+		return inspectKeyFile(data, "JSON")
+	case has("proof", "disclosedIndices"):
+		var p CredentialProof
+		if err := json.Unmarshal(data, &p); err != nil {
+			return nil, fmt.Errorf("failed to parse proof JSON: %w", err)
+		}
+		in := &inspection{Kind: "credential proof", Format: "JSON", Size: len(data)}
+		inspectCredentialProof(in, &p)
+		return in, nil
+	case has("signature", "messages"):
+		var c Credential
+		if err := json.Unmarshal(data, &c); err != nil {
+			return nil, fmt.Errorf("failed to parse credential JSON: %w", err)
+		}
+		in := &inspection{Kind: "credential", Format: "JSON", Size: len(data)}
+		inspectCredential(in, &c)
+		return in, nil
+	default:
+		return nil, fmt.Errorf("unrecognised JSON artifact")
+	}
+}
+
+// inspectKeyFile inspects a JSON or CBOR key file. Its MAC is checked with
+// the default key; a file sealed with another key fails that check only.
+func inspectKeyFile(data []byte, format string) (*inspection, error) {
+	in := &inspection{Kind: "key file", Format: format, Size: len(data)}
+
+	kf, err := keys.Unmarshal(data, nil)
+	if errors.Is(err, keys.ErrIntegrityCheckFailed) && format == "JSON" {
+		kf = &keys.KeyFile{}
+		if jerr := json.Unmarshal(bytes.TrimSpace(data), kf); jerr != nil {
+			return nil, fmt.Errorf("failed to parse key file: %w", jerr)
+		}
+	} else if err != nil && !errors.Is(err, keys.ErrIntegrityCheckFailed) {
+		return nil, err
+	}
+	in.check("integrity (default MAC key)", err)
+	if kf == nil {
+		return in, nil
+	}
+
+	in.add("Version", "%d", kf.Version)
+	in.add("Usage", "%s", kf.Metadata.Usage)
+	in.add("Ciphersuite", "%s", kf.Metadata.Ciphersuite)
+	in.add("Created", "%s", kf.Metadata.Created.Format(time.RFC3339))
+	in.add("Private key", "%t", len(kf.PrivateKey) > 0)
+
+	keyPair, err := kf.KeyPair()
+	if !in.check("key pair decodes and matches the metadata", err) {
+		return in, nil
+	}
+	inspectPublicKey(in, keyPair.PublicKey, kf.PublicKey)
+	if keyPair.PrivateKey != nil {
+		in.check("private key matches public key", checkKeyPair(keyPair))
+	}
+	return in, nil
+}
+
+// checkKeyPair checks that W = g2*x
+func checkKeyPair(keyPair *bbs.KeyPair) error {
+	g2 := keyPair.PublicKey.G2()
+	var w bls12381.G2Affine
+	w.ScalarMultiplication(&g2, keyPair.PrivateKey.X)
+	if expected := keyPair.PublicKey.W(); !w.Equal(&expected) {
+		return fmt.Errorf("g2*x differs from W")
+	}
+	return nil
+}
+
+// inspectPublicKey adds the details of a public key and its encoding
+func inspectPublicKey(in *inspection, pk *bbs.PublicKey, encoded []byte) {
+	in.add("Message count", "%d", pk.MessageCount())
+	in.add("Fingerprint", "%s", pk.Fingerprint())
+	if compact, err := bbs.DeserializeCompactPublicKey(encoded); err == nil {
+		in.add("Key encoding", "compact, %d bytes, seed %x", len(encoded), compact.Seed())
+	} else {
+		in.add("Key encoding", "full, %d bytes", len(encoded))
+	}
+	in.check("message count within limits", bbs.DefaultLimits.CheckMessageCount(pk.MessageCount()))
+}
+
+// decodePublicKey decodes a base64 public key
+func decodePublicKey(encoded string) (*bbs.PublicKey, []byte, error) {
+	raw, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, nil, err
+	}
+	pk, err := bbs.DeserializePublicKey(raw)
+	return pk, raw, err
+}
+
+// inspectCredential inspects a credential file
+func inspectCredential(in *inspection, c *Credential) {
+	if c.Schema != "" {
+		in.add("Schema", "%s", c.Schema)
+	}
+	in.add("Issuer", "%s", c.Issuer)
+	in.add("Issued", "%s", c.DateIssued)
+	if c.DateExpires != "" {
+		in.add("Expires", "%s", c.DateExpires)
+	}
+	if c.SchemaHash != "" {
+		in.add("Schema hash", "%s", c.SchemaHash)
+	}
+
+	suite, err := messageSuite(c.Mapping)
+	if in.check("message mapping is known", err) {
+		in.add("Ciphersuite", "%s", suite.ID)
+	}
+
+	order, err := c.attributeOrder()
+	if in.check("attribute order lists every attribute once", err) {
+		in.add("Attributes", "%d: %s", len(order), strings.Join(order, ", "))
+	}
+
+	pk, raw, err := decodePublicKey(c.PublicKey)
+	if in.check("public key decodes", err) {
+		inspectPublicKey(in, pk, raw)
+		if order != nil {
+			var mismatch error
+			if len(order) != pk.MessageCount() {
+				mismatch = fmt.Errorf("%d attributes, key signs %d messages", len(order), pk.MessageCount())
+			}
+			in.check("attribute count matches the key", mismatch)
+		}
+	}
+
+	sigBytes, err := base64.StdEncoding.DecodeString(c.Signature)
+	if err == nil {
+		in.add("Signature", "%d bytes", len(sigBytes))
+		_, err = bbs.DeserializeSignature(sigBytes)
+	}
+	in.check("signature decodes", err)
+
+	if suite != nil && order != nil {
+		_, err := encodeAttributes(suite, order, c.Messages, c.Types)
+		in.check("attribute values match their types", err)
+	}
+	if c.SchemaHash != "" {
+		_, err := hex.DecodeString(c.SchemaHash)
+		in.check("schema hash is hex", err)
+	}
+	in.check("dates are valid", checkDates(c.DateIssued, c.DateExpires))
+}
+
+// checkDates checks that the issuance and expiry dates parse and are in order
+func checkDates(issued, expires string) error {
+	issuedAt, err := time.Parse(time.RFC3339, issued)
+	if err != nil {
+		return fmt.Errorf("issuance date: %w", err)
+	}
+	if expires == "" {
+		return nil
+	}
+	expiresAt, err := time.Parse(time.RFC3339, expires)
+	if err != nil {
+		return fmt.Errorf("expiry date: %w", err)
+	}
+	if !expiresAt.After(issuedAt) {
+		return fmt.Errorf("expires %s, not after issuance %s", expires, issued)
+	}
+	return nil
+}
+
+// inspectCredentialProof inspects a proof file
+func inspectCredentialProof(in *inspection, p *CredentialProof) {
+	if p.Schema != "" {
+		in.add("Schema", "%s", p.Schema)
+	}
+	in.add("Issuer", "%s", p.Issuer)
+	in.add("Generated", "%s", p.DateGenerated)
+	if p.SchemaHash != "" {
+		in.add("Schema hash", "%s", p.SchemaHash)
+	}
+	suite, err := messageSuite(p.Mapping)
+	if in.check("message mapping is known", err) {
+		in.add("Ciphersuite", "%s", suite.ID)
+	}
+
+	names := make([]string, 0, len(p.DisclosedIndices))
+	for name := range p.DisclosedIndices {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool { return p.DisclosedIndices[names[i]] < p.DisclosedIndices[names[j]] })
+	disclosed := make([]string, len(names))
+	indices := make([]int, len(names))
+	for i, name := range names {
+		indices[i] = p.DisclosedIndices[name]
+		disclosed[i] = fmt.Sprintf("%s@%d", name, indices[i])
+	}
+	in.add("Disclosed", "%d: %s", len(disclosed), strings.Join(disclosed, ", "))
+
+	proofBytes, err := base64.StdEncoding.DecodeString(p.Proof)
+	var proof *bbs.ProofOfKnowledge
+	if err == nil {
+		proof, err = bbs.DeserializeProof(proofBytes)
+	}
+	if in.check("proof decodes", err) {
+		inspectProof(in, proof, len(proofBytes))
+	}
+
+	pk, raw, err := decodePublicKey(p.PublicKey)
+	if !in.check("public key decodes", err) {
+		return
+	}
+	inspectPublicKey(in, pk, raw)
+	if proof != nil {
+		in.check("disclosed and hidden messages cover the key", checkPartition(pk.MessageCount(), indices, proof))
+	}
+	_, err = p.disclosedMessageMap(pk.MessageCount())
+	in.check("disclosed attributes map to messages", err)
+}
+
+// inspectProof adds the details of a decoded proof
+func inspectProof(in *inspection, proof *bbs.ProofOfKnowledge, size int) {
+	hidden := sortedKeys(proof.MHat)
+	in.add("Proof", "%d bytes", size)
+	in.add("Hidden", "%d: %s", len(hidden), joinInts(hidden))
+	if len(proof.CommitmentHat) > 0 {
+		in.add("Commitment equalities", "%s", joinInts(sortedKeys(proof.CommitmentHat)))
+	}
+
+	var stray error
+	for idx := range proof.CommitmentHat {
+		if _, ok := proof.MHat[idx]; !ok {
+			stray = fmt.Errorf("commitment response for message %d, which is not hidden", idx)
+			break
+		}
+	}
+	in.check("commitment responses belong to hidden messages", stray)
+}
+
+// checkPartition checks that the disclosed indices and the hidden messages of
+// a proof are disjoint and together are every message of the key
+func checkPartition(messageCount int, disclosed []int, proof *bbs.ProofOfKnowledge) error {
+	seen := make(map[int]string, messageCount)
+	for idx := range proof.MHat {
+		seen[idx] = "hidden"
+	}
+	for _, idx := range disclosed {
+		if seen[idx] != "" {
+			return fmt.Errorf("message %d is both disclosed and %s", idx, seen[idx])
+		}
+		seen[idx] = "disclosed"
+	}
+	for idx := range seen {
+		if idx < 0 || idx >= messageCount {
+			return fmt.Errorf("message %d out of range [0, %d)", idx, messageCount)
+		}
+	}
+	if len(seen) != messageCount {
+		return fmt.Errorf("%d of %d messages are disclosed or hidden", len(seen), messageCount)
+	}
+	return nil
+}
+
+// jwsHeader holds the header parameters inspect shows
+type jwsHeader struct {
+	Alg string          `json:"alg"`
+	Typ string          `json:"typ"`
+	Kid string          `json:"kid"`
+	JWK json.RawMessage `json:"jwk"`
+}
+
+// jwsPayload holds the claims inspect shows
+type jwsPayload struct {
+	Issuer    string `json:"iss"`
+	Subject   string `json:"sub"`
+	Audience  string `json:"aud"`
+	Nonce     string `json:"nonce"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+
+	// Presentations
+	BBS *struct {
+		Proof     string            `json:"proof"`
+		Disclosed map[string]string `json:"disclosed"`
+	} `json:"bbs"`
+
+	// Key attestations
+	Key         string `json:"bbsKey"`
+	Fingerprint string `json:"bbsKeyFingerprint"`
+}
+
+// inspectJWS inspects a JOSE envelope without checking its signature
+func inspectJWS(token string) (*inspection, error) {
+	parts := strings.Split(token, ".")
+	hdrBytes, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode JWS header: %w", err)
+	}
+	var hdr jwsHeader
+	if err := json.Unmarshal(hdrBytes, &hdr); err != nil {
+		return nil, fmt.Errorf("failed to parse JWS header: %w", err)
+	}
+
+	in := &inspection{Kind: "JWS " + hdr.Typ, Format: "compact JWS", Size: len(token)}
+	in.add("Algorithm", "%s", hdr.Alg)
+	if hdr.Kid != "" {
+		in.add("Key ID", "%s", hdr.Kid)
+	}
+	in.check("header carries the signing key", boolErr(hdr.JWK != nil, "no jwk header parameter"))
+
+	if parts[1] == "" {
+		in.add("Payload", "detached")
+		return in, nil
+	}
+	payloadBytes, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err == nil {
+		err = json.Unmarshal(payloadBytes, new(map[string]json.RawMessage))
+	}
+	if !in.check("payload is a JSON object", err) {
+		return in, nil
+	}
+	var payload jwsPayload
+	in.check("claims have the expected types", json.Unmarshal(payloadBytes, &payload))
+
+	for _, c := range []field{{"Issuer", payload.Issuer}, {"Subject", payload.Subject}, {"Audience", payload.Audience}, {"Nonce", payload.Nonce}} {
+		if c.Value != "" {
+			in.add(c.Name, "%s", c.Value)
+		}
+	}
+	if payload.IssuedAt != 0 {
+		in.add("Issued", "%s", time.Unix(payload.IssuedAt, 0).UTC().Format(time.RFC3339))
+	}
+	if payload.ExpiresAt != 0 {
+		in.add("Expires", "%s", time.Unix(payload.ExpiresAt, 0).UTC().Format(time.RFC3339))
+		in.check("expiry after issuance", boolErr(payload.ExpiresAt > payload.IssuedAt, "exp is not after iat"))
+	}
+
+	if payload.BBS != nil {
+		inspectPresentationClaim(in, payload.BBS.Proof, payload.BBS.Disclosed)
+	}
+	if payload.Key != "" {
+		raw, err := base64.RawURLEncoding.DecodeString(payload.Key)
+		var pk *bbs.PublicKey
+		if err == nil {
+			pk, err = bbs.DeserializePublicKey(raw)
+		}
+		if in.check("attested key decodes", err) {
+			inspectPublicKey(in, pk, raw)
+			in.check("attested key matches its fingerprint",
+				boolErr(pk.Fingerprint() == payload.Fingerprint, "fingerprint claim "+payload.Fingerprint))
+		}
+	}
+	return in, nil
+}
+
+// inspectPresentationClaim inspects the proof of a JOSE presentation
+func inspectPresentationClaim(in *inspection, encodedProof string, disclosed map[string]string) {
+	indices := make([]int, 0, len(disclosed))
+	var badIndex error
+	for key, value := range disclosed {
+		idx, err := strconv.Atoi(key)
+		if err == nil {
+			if _, ok := new(big.Int).SetString(value, 10); !ok {
+				err = fmt.Errorf("message %d is not a decimal scalar", idx)
+			}
+		}
+		if err != nil && badIndex == nil {
+			badIndex = err
+		}
+		indices = append(indices, idx)
+	}
+	sort.Ints(indices)
+	in.add("Disclosed", "%d: %s", len(indices), joinInts(indices))
+	in.check("disclosed messages are indexed scalars", badIndex)
+
+	raw, err := base64.RawURLEncoding.DecodeString(encodedProof)
+	var proof *bbs.ProofOfKnowledge
+	if err == nil {
+		proof, err = bbs.DeserializeProof(raw)
+	}
+	if !in.check("proof decodes", err) {
+		return
+	}
+	inspectProof(in, proof, len(raw))
+
+	// Without the issuer key the message count is what the proof implies
+	messageCount := len(proof.MHat) + len(indices)
+	in.add("Message count", "%d (disclosed + hidden)", messageCount)
+	in.check("disclosed and hidden messages cover the key", checkPartition(messageCount, indices, proof))
+}
+
+// inspectMultibase inspects a multibase artifact
+func inspectMultibase(codec uint64, data []byte, size int) (*inspection, error) {
+	in := &inspection{Kind: multiformat.CodecName(codec), Format: "multibase", Size: size}
+	switch codec {
+	case multiformat.CodecPublicKey:
+		pk, err := bbs.DeserializePublicKey(data)
+		if in.check("public key decodes", err) {
+			inspectPublicKey(in, pk, data)
+		}
+	case multiformat.CodecSignature:
+		_, err := bbs.DeserializeSignature(data)
+		in.add("Signature", "%d bytes", len(data))
+		in.check("signature decodes", err)
+	case multiformat.CodecProof:
+		proof, err := bbs.DeserializeProof(data)
+		if in.check("proof decodes", err) {
+			inspectProof(in, proof, len(data))
+		}
+	case multiformat.CodecBLS12381G2Priv:
+		in.add("Private key", "%d bytes (not shown)", len(data))
+		in.check("private key decodes", new(bbs.PrivateKey).UnmarshalBinary(data))
+	default:
+		return nil, fmt.Errorf("unsupported multicodec %s", multiformat.CodecName(codec))
+	}
+	return in, nil
+}
+
+// inspectBinary recognises a raw key file, public key, signature or proof
+func inspectBinary(data []byte, format string, size int) (*inspection, error) {
+	if _, err := keys.Unmarshal(data, nil); err == nil || errors.Is(err, keys.ErrIntegrityCheckFailed) {
+		in, err := inspectKeyFile(data, "CBOR")
+		if err == nil && format != "binary" {
+			in.Format, in.Size = format+" CBOR", size
+		}
+		return in, err
+	}
+
+	if pk, err := bbs.DeserializePublicKey(data); err == nil {
+		in := &inspection{Kind: "public key", Format: format, Size: size}
+		inspectPublicKey(in, pk, data)
+		return in, nil
+	}
+	if len(data) == bbs.SignatureSize {
+		if _, err := bbs.DeserializeSignature(data); err == nil {
+			in := &inspection{Kind: "signature", Format: format, Size: size}
+			in.add("Signature", "%d bytes", len(data))
+			in.check("signature decodes", nil)
+			return in, nil
+		}
+	}
+	if proof, err := bbs.DeserializeProof(data); err == nil {
+		in := &inspection{Kind: "proof", Format: format, Size: size}
+		inspectProof(in, proof, len(data))
+		return in, nil
+	}
+	return nil, fmt.Errorf("unrecognised artifact (%d bytes)", len(data))
+}
+
+// boolErr returns nil if ok, an error with msg otherwise
+func boolErr(ok bool, msg string) error {
+	if ok {
+		return nil
+	}
+	return errors.New(msg)
+}
+
+// sortedKeys returns the keys of m in order
+func sortedKeys(m map[int]*big.Int) []int {
+	keys := make([]int, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Ints(keys)
+	return keys
+}
+
+// joinInts formats indices as a comma-separated list
+func joinInts(values []int) string {
+	if len(values) == 0 {
+		return "(none)"
+	}
+	parts := make([]string, len(values))
+	for i, v := range values {
+		parts[i] = strconv.Itoa(v)
+	}
+	return strings.Join(parts, ", ")
+}
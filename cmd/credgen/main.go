@@ -4,17 +4,27 @@ package main
 import (
 	"crypto/rand"
 	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"math/big"
 	"os"
+	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
+	"text/tabwriter"
 	"time"
 
 	"github.com/anupsv/bbsplus-signatures/bbs"
+	"github.com/anupsv/bbsplus-signatures/bbs/perf"
+	"github.com/anupsv/bbsplus-signatures/internal/version"
+	credpkg "github.com/anupsv/bbsplus-signatures/pkg/credential"
+	"github.com/anupsv/bbsplus-signatures/pkg/keys"
 )
 
 // Command represents a subcommand
@@ -30,19 +40,64 @@ type Credential struct {
 	PublicKey   string            `json:"publicKey"`
 	Signature   string            `json:"signature"`
 	Messages    map[string]string `json:"messages"`
+	Attributes  []string          `json:"attributes,omitempty"`
 	DateIssued  string            `json:"dateIssued"`
 	DateExpires string            `json:"dateExpires,omitempty"`
 	Issuer      string            `json:"issuer"`
+	Mapping     string            `json:"messageMapping,omitempty"`
+
+	// Types holds the attributes that are not hashed strings
+	Types map[string]attributeType `json:"attributeTypes,omitempty"`
+
+	// SchemaHash is the hex hash of the schema the signature is bound to,
+	// if the credential was issued with one that declares its attributes
+	SchemaHash string `json:"schemaHash,omitempty"`
+}
+
+// attributeType is the type and encoding of an attribute. Messages hold the
+// canonical text of every value, which the type parses back.
+type attributeType struct {
+	Type     credpkg.AttributeType `json:"type"`
+	Encoding credpkg.Encoding      `json:"encoding"`
 }
 
+// Credentials record how attribute values were mapped to messages. Those
+// issued before the field existed used sha256(value) mod r and have no
+// mapping, so an empty one selects the legacy ciphersuite.
+const mappingHashToScalar = "hash-to-scalar"
+
+// Attribute names map to message indices by their position in the
+// credential's attribute order, which is fixed at issuance: the schema's
+// "attributes" array if it has one, sorted names otherwise. A proof records
+// the index of each disclosed attribute, so a verifier never has to guess it
+// from the names it happens to see.
+//
+// A schema that declares the attributes is also bound into the signature:
+// credentials are signed under bbs.SchemaHeader with the hash of the
+// schema's "$id" and attribute declarations, and a proof of such a
+// credential only verifies for a verifier that supplies the same schema.
+
 // CredentialProof represents a selective disclosure proof for a credential
 type CredentialProof struct {
-	Schema            string            `json:"schema"`
-	PublicKey         string            `json:"publicKey"`
-	Proof             string            `json:"proof"`
-	DisclosedMessages map[string]string `json:"disclosedMessages"`
-	DateGenerated     string            `json:"dateGenerated"`
-	Issuer            string            `json:"issuer"`
+	Schema            string                   `json:"schema"`
+	PublicKey         string                   `json:"publicKey"`
+	Proof             string                   `json:"proof"`
+	DisclosedMessages map[string]string        `json:"disclosedMessages"`
+	DisclosedIndices  map[string]int           `json:"disclosedIndices"`
+	DateGenerated     string                   `json:"dateGenerated"`
+	Issuer            string                   `json:"issuer"`
+	Mapping           string                   `json:"messageMapping,omitempty"`
+	Types             map[string]attributeType `json:"attributeTypes,omitempty"`
+	SchemaHash        string                   `json:"schemaHash,omitempty"`
+}
+
+// decodeJSON parses a credential or proof file strictly, as
+// credential.ParseCredential does: unknown, duplicate and case-folded fields
+// are refused, since tools using other JSON libraries could read different
+// values from the same file. lenient accepts them for files written by older
+// tools.
+func decodeJSON(data []byte, v any, lenient bool) error {
+	return credpkg.DecodeOptions{Lenient: lenient}.Unmarshal(data, v)
 }
 
 func main() {
@@ -73,6 +128,36 @@ func main() {
 			Description: "Verify a selective disclosure proof",
 			Execute:     cmdVerifyProof,
 		},
+		{
+			Name:        "audit",
+			Description: "Check an issuance journal (audit verify-log)",
+			Execute:     cmdAudit,
+		},
+		{
+			Name:        "migrate",
+			Description: "Re-issue credentials under a larger key",
+			Execute:     cmdMigrate,
+		},
+		{
+			Name:        "blocklist",
+			Description: "Publish and update a blocklist accumulator",
+			Execute:     cmdBlocklist,
+		},
+		{
+			Name:        "inspect",
+			Description: "Decode and check a key, credential, proof or presentation",
+			Execute:     cmdInspect,
+		},
+		{
+			Name:        "bench",
+			Description: "Measure credential operations on this machine",
+			Execute:     cmdBench,
+		},
+		{
+			Name:        "version",
+			Description: "Show build and ciphersuite information",
+			Execute:     cmdVersion,
+		},
 	}
 
 	// Show help if no command provided
@@ -120,12 +205,22 @@ func cmdKeyGen(args []string) error {
 	flagSet := flag.NewFlagSet("keygen", flag.ExitOnError)
 	attributeCount := flagSet.Int("attributes", 10, "Number of attributes/messages in the credential")
 	outputFile := flagSet.String("output", "keypair.json", "Output file for the key pair")
+	format := flagSet.String("format", "json", "Key file format: json or cbor")
 	flagSet.Parse(args)
 
 	if *attributeCount < 1 {
 		return fmt.Errorf("attribute count must be at least 1")
 	}
 
+	keyFormat := keys.FormatJSON
+	switch *format {
+	case "json":
+	case "cbor":
+		keyFormat = keys.FormatCBOR
+	default:
+		return fmt.Errorf("unknown key file format: %s", *format)
+	}
+
 	// Generate key pair
 	fmt.Printf("Generating key pair for %d attributes...\n", *attributeCount)
 	keyPair, err := bbs.GenerateKeyPair(*attributeCount, rand.Reader)
@@ -133,41 +228,18 @@ func cmdKeyGen(args []string) error {
 		return fmt.Errorf("failed to generate key pair: %w", err)
 	}
 
-	// Serialize private key
-	privKeyBytes, err := keyPair.PrivateKey.MarshalBinary()
-	if err != nil {
-		return fmt.Errorf("failed to serialize private key: %w", err)
-	}
-
-	// Serialize public key
-	pubKeyBytes, err := keyPair.PublicKey.MarshalBinary()
+	// Save as a key file envelope
+	keyFile, err := keys.NewKeyFile(keyPair, keys.UsageSigning)
 	if err != nil {
-		return fmt.Errorf("failed to serialize public key: %w", err)
+		return fmt.Errorf("failed to serialize key pair: %w", err)
 	}
 
-	// Create JSON output
-	output := struct {
-		AttributeCount int    `json:"attributeCount"`
-		PrivateKey     string `json:"privateKey"`
-		PublicKey      string `json:"publicKey"`
-	}{
-		AttributeCount: *attributeCount,
-		PrivateKey:     base64.StdEncoding.EncodeToString(privKeyBytes),
-		PublicKey:      base64.StdEncoding.EncodeToString(pubKeyBytes),
-	}
-
-	// Save to file
-	data, err := json.MarshalIndent(output, "", "  ")
-	if err != nil {
-		return fmt.Errorf("failed to marshal key pair to JSON: %w", err)
-	}
-
-	err = ioutil.WriteFile(*outputFile, data, 0600)
+	err = keys.Save(*outputFile, keyFile, keyFormat, nil)
 	if err != nil {
 		return fmt.Errorf("failed to write key pair to file: %w", err)
 	}
 
-	fmt.Printf("Key pair generated and saved to %s\n", *outputFile)
+	fmt.Printf("Key pair generated and saved to %s (fingerprint %s)\n", *outputFile, keyPair.PublicKey.Fingerprint())
 	return nil
 }
 
@@ -178,50 +250,29 @@ func cmdIssueCredential(args []string) error {
 	keyFile := flagSet.String("key", "keypair.json", "Key pair file")
 	schemaFile := flagSet.String("schema", "", "Schema file for the credential attributes")
 	attributesFile := flagSet.String("attributes", "", "JSON file containing attribute values")
+	filesFlag := flagSet.String("files", "", "Comma-separated name=path bytes attributes read raw from files")
 	outputFile := flagSet.String("output", "credential.json", "Output file for the credential")
 	issuer := flagSet.String("issuer", "BBS+ Test Issuer", "Issuer identifier")
+	journalFile := flagSet.String("journal", "", "Issuance journal to record the credential in (optional)")
 	flagSet.Parse(args)
 
 	// Load key pair
-	keyPairData, err := ioutil.ReadFile(*keyFile)
-	if err != nil {
-		return fmt.Errorf("failed to read key pair file: %w", err)
-	}
-
-	var keyPairJson struct {
-		AttributeCount int    `json:"attributeCount"`
-		PrivateKey     string `json:"privateKey"`
-		PublicKey      string `json:"publicKey"`
-	}
-
-	err = json.Unmarshal(keyPairData, &keyPairJson)
-	if err != nil {
-		return fmt.Errorf("failed to parse key pair JSON: %w", err)
-	}
-
-	// Decode private key
-	privKeyBytes, err := base64.StdEncoding.DecodeString(keyPairJson.PrivateKey)
+	keyPairFile, err := keys.Load(*keyFile, nil)
 	if err != nil {
-		return fmt.Errorf("failed to decode private key: %w", err)
+		return fmt.Errorf("failed to load key pair: %w", err)
 	}
 
-	privateKey := &bbs.PrivateKey{}
-	err = privateKey.UnmarshalBinary(privKeyBytes)
+	keyPair, err := keyPairFile.KeyPair()
 	if err != nil {
-		return fmt.Errorf("failed to unmarshal private key: %w", err)
+		return fmt.Errorf("failed to decode key pair: %w", err)
 	}
 
-	// Decode public key
-	pubKeyBytes, err := base64.StdEncoding.DecodeString(keyPairJson.PublicKey)
-	if err != nil {
-		return fmt.Errorf("failed to decode public key: %w", err)
+	if keyPair.PrivateKey == nil {
+		return fmt.Errorf("key file %s does not contain a private key", *keyFile)
 	}
 
-	publicKey := &bbs.PublicKey{}
-	err = publicKey.UnmarshalBinary(pubKeyBytes)
-	if err != nil {
-		return fmt.Errorf("failed to unmarshal public key: %w", err)
-	}
+	privateKey := keyPair.PrivateKey
+	publicKey := keyPair.PublicKey
 
 	// Load schema if provided
 	var schemaJson map[string]interface{}
@@ -238,46 +289,68 @@ func cmdIssueCredential(args []string) error {
 	}
 
 	// Load attributes
-	if *attributesFile == "" {
+	files, err := readFileAttributes(*filesFlag)
+	if err != nil {
+		return err
+	}
+	if *attributesFile == "" && files == nil {
 		return fmt.Errorf("attributes file is required")
 	}
 
-	attributesData, err := ioutil.ReadFile(*attributesFile)
-	if err != nil {
-		return fmt.Errorf("failed to read attributes file: %w", err)
-	}
+	var rawAttributes map[string]json.RawMessage
+	if *attributesFile != "" {
+		attributesData, err := ioutil.ReadFile(*attributesFile)
+		if err != nil {
+			return fmt.Errorf("failed to read attributes file: %w", err)
+		}
 
-	var attributesJson map[string]string
-	err = json.Unmarshal(attributesData, &attributesJson)
-	if err != nil {
-		return fmt.Errorf("failed to parse attributes JSON: %w", err)
+		err = json.Unmarshal(attributesData, &rawAttributes)
+		if err != nil {
+			return fmt.Errorf("failed to parse attributes JSON: %w", err)
+		}
 	}
 
 	// Check attribute count
-	if len(attributesJson) != keyPairJson.AttributeCount {
+	if len(rawAttributes)+len(files) != publicKey.MessageCount() {
 		return fmt.Errorf("attribute count mismatch: key supports %d attributes, but %d provided",
-			keyPairJson.AttributeCount, len(attributesJson))
+			publicKey.MessageCount(), len(rawAttributes)+len(files))
+	}
+
+	// Fix the attribute order and types, from the schema if it declares them
+	specs, err := schemaAttributes(schemaJson)
+	if err != nil {
+		return err
+	}
+	attributesJson, types, err := parseAttributeValues(rawAttributes, specs)
+	if err != nil {
+		return err
+	}
+	types, err = addFileAttributes(attributesJson, types, files, specs)
+	if err != nil {
+		return err
 	}
 
-	// Create ordered list of attributes
-	attributeNames := make([]string, 0, len(attributesJson))
-	for name := range attributesJson {
-		attributeNames = append(attributeNames, name)
+	attributeNames := specNames(specs)
+	if attributeNames == nil {
+		attributeNames = sortedNames(attributesJson)
 	}
 
-	// Sort attribute names for deterministic ordering
-	sort.Strings(attributeNames)
+	if err := validateAttributeOrder(attributeNames, attributesJson); err != nil {
+		return err
+	}
 
 	// Convert attributes to messages
-	messages := make([]*big.Int, len(attributeNames))
-	for i, name := range attributeNames {
-		value := attributesJson[name]
-		msgBytes := bbs.MessageToBytes(value)
-		messages[i] = bbs.MessageToFieldElement(msgBytes)
+	messages, err := encodeAttributes(bbs.BLS12381SHA256, attributeNames, attributesJson, types)
+	if err != nil {
+		return err
 	}
 
-	// Sign messages
-	signature, err := bbs.Sign(privateKey, publicKey, messages, nil)
+	// Sign messages, bound to the schema if it declares the attributes
+	schemaHash, err := schemaHashOf(schemaJson)
+	if err != nil {
+		return err
+	}
+	signature, err := bbs.Sign(privateKey, publicKey, messages, bbs.SchemaHeader(nil, schemaHash))
 	if err != nil {
 		return fmt.Errorf("failed to sign messages: %w", err)
 	}
@@ -292,11 +365,15 @@ func cmdIssueCredential(args []string) error {
 	now := time.Now().Format(time.RFC3339)
 	credential := Credential{
 		Schema:     *schemaFile,
-		PublicKey:  keyPairJson.PublicKey,
+		PublicKey:  base64.StdEncoding.EncodeToString(keyPairFile.PublicKey),
 		Signature:  base64.StdEncoding.EncodeToString(signatureBytes),
 		Messages:   attributesJson,
+		Attributes: attributeNames,
 		DateIssued: now,
 		Issuer:     *issuer,
+		Mapping:    mappingHashToScalar,
+		Types:      types,
+		SchemaHash: hex.EncodeToString(schemaHash),
 	}
 
 	// Save credential to file
@@ -305,6 +382,20 @@ func cmdIssueCredential(args []string) error {
 		return fmt.Errorf("failed to marshal credential to JSON: %w", err)
 	}
 
+	// Record the issuance before handing out the credential
+	if *journalFile != "" {
+		journal, err := credpkg.OpenJournal(*journalFile)
+		if err != nil {
+			return err
+		}
+		record, err := journal.Append(*schemaFile, credentialData, publicKey)
+		journal.Close()
+		if err != nil {
+			return fmt.Errorf("failed to record issuance: %w", err)
+		}
+		fmt.Printf("Issuance recorded in %s as record %d\n", *journalFile, record.Sequence)
+	}
+
 	err = ioutil.WriteFile(*outputFile, credentialData, 0644)
 	if err != nil {
 		return fmt.Errorf("failed to write credential to file: %w", err)
@@ -319,6 +410,7 @@ func cmdVerifyCredential(args []string) error {
 	// Parse flags
 	flagSet := flag.NewFlagSet("verify", flag.ExitOnError)
 	credentialFile := flagSet.String("credential", "credential.json", "Credential file to verify")
+	lenient := flagSet.Bool("lenient", false, "Accept unknown, duplicate and case-folded JSON fields, as written by older tools")
 	flagSet.Parse(args)
 
 	// Load credential
@@ -328,54 +420,13 @@ func cmdVerifyCredential(args []string) error {
 	}
 
 	var credential Credential
-	err = json.Unmarshal(credentialData, &credential)
+	err = decodeJSON(credentialData, &credential, *lenient)
 	if err != nil {
 		return fmt.Errorf("failed to parse credential JSON: %w", err)
 	}
 
-	// Decode public key
-	pubKeyBytes, err := base64.StdEncoding.DecodeString(credential.PublicKey)
-	if err != nil {
-		return fmt.Errorf("failed to decode public key: %w", err)
-	}
-
-	publicKey := &bbs.PublicKey{}
-	err = publicKey.UnmarshalBinary(pubKeyBytes)
-	if err != nil {
-		return fmt.Errorf("failed to unmarshal public key: %w", err)
-	}
-
-	// Decode signature
-	signatureBytes, err := base64.StdEncoding.DecodeString(credential.Signature)
-	if err != nil {
-		return fmt.Errorf("failed to decode signature: %w", err)
-	}
-
-	signature := &bbs.Signature{}
-	err = signature.UnmarshalBinary(signatureBytes)
-	if err != nil {
-		return fmt.Errorf("failed to unmarshal signature: %w", err)
-	}
-
-	// Get attribute names and sort them
-	attributeNames := make([]string, 0, len(credential.Messages))
-	for name := range credential.Messages {
-		attributeNames = append(attributeNames, name)
-	}
-	sort.Strings(attributeNames)
-
-	// Convert attributes to messages
-	messages := make([]*big.Int, len(attributeNames))
-	for i, name := range attributeNames {
-		value := credential.Messages[name]
-		msgBytes := bbs.MessageToBytes(value)
-		messages[i] = bbs.MessageToFieldElement(msgBytes)
-	}
-
-	// Verify signature
-	err = bbs.Verify(publicKey, signature, messages, nil)
-	if err != nil {
-		return fmt.Errorf("credential verification failed: %w", err)
+	if err := credential.verify(); err != nil {
+		return err
 	}
 
 	fmt.Println("Credential verified successfully!")
@@ -387,8 +438,9 @@ func cmdCreateProof(args []string) error {
 	// Parse flags
 	flagSet := flag.NewFlagSet("prove", flag.ExitOnError)
 	credentialFile := flagSet.String("credential", "credential.json", "Credential file")
-	disclosedAttrs := flagSet.String("disclose", "", "Comma-separated list of attribute names to disclose")
+	disclosedAttrs := flagSet.String("disclose", "", "Comma-separated list of attribute names to disclose (none if empty)")
 	outputFile := flagSet.String("output", "proof.json", "Output file for the proof")
+	lenient := flagSet.Bool("lenient", false, "Accept unknown, duplicate and case-folded JSON fields, as written by older tools")
 	flagSet.Parse(args)
 
 	// Load credential
@@ -398,36 +450,30 @@ func cmdCreateProof(args []string) error {
 	}
 
 	var credential Credential
-	err = json.Unmarshal(credentialData, &credential)
+	err = decodeJSON(credentialData, &credential, *lenient)
 	if err != nil {
 		return fmt.Errorf("failed to parse credential JSON: %w", err)
 	}
 
-	// Parse disclosed attributes
-	if *disclosedAttrs == "" {
-		return fmt.Errorf("at least one attribute must be disclosed")
-	}
-
-	disclosedNames := strings.Split(*disclosedAttrs, ",")
-	for i := range disclosedNames {
-		disclosedNames[i] = strings.TrimSpace(disclosedNames[i])
-	}
-
-	// Validate disclosed attributes
-	for _, name := range disclosedNames {
-		if _, ok := credential.Messages[name]; !ok {
-			return fmt.Errorf("attribute '%s' not found in credential", name)
+	// Parse disclosed attributes. Disclosing none proves possession of the
+	// credential only.
+	var disclosedNames []string
+	if *disclosedAttrs != "" {
+		disclosedNames = strings.Split(*disclosedAttrs, ",")
+		for i := range disclosedNames {
+			disclosedNames[i] = strings.TrimSpace(disclosedNames[i])
+			if disclosedNames[i] == "" {
+				return fmt.Errorf("empty attribute name in -disclose")
+			}
 		}
 	}
 
-	// Get all attribute names and sort them
-	attributeNames := make([]string, 0, len(credential.Messages))
-	for name := range credential.Messages {
-		attributeNames = append(attributeNames, name)
+	// Map attribute names to their indices in issuance order
+	attributeNames, err := credential.attributeOrder()
+	if err != nil {
+		return err
 	}
-	sort.Strings(attributeNames)
 
-	// Map attribute names to indices
 	nameToIndex := make(map[string]int)
 	for i, name := range attributeNames {
 		nameToIndex[name] = i
@@ -435,16 +481,27 @@ func cmdCreateProof(args []string) error {
 
 	// Get indices of disclosed attributes
 	disclosedIndices := make([]int, len(disclosedNames))
+	nameIndices := make(map[string]int, len(disclosedNames))
 	for i, name := range disclosedNames {
-		disclosedIndices[i] = nameToIndex[name]
+		idx, ok := nameToIndex[name]
+		if !ok {
+			return fmt.Errorf("attribute '%s' not found in credential", name)
+		}
+		if _, dup := nameIndices[name]; dup {
+			return fmt.Errorf("attribute '%s' disclosed twice", name)
+		}
+		disclosedIndices[i] = idx
+		nameIndices[name] = idx
 	}
 
 	// Convert attributes to messages
-	messages := make([]*big.Int, len(attributeNames))
-	for i, name := range attributeNames {
-		value := credential.Messages[name]
-		msgBytes := bbs.MessageToBytes(value)
-		messages[i] = bbs.MessageToFieldElement(msgBytes)
+	suite, err := messageSuite(credential.Mapping)
+	if err != nil {
+		return err
+	}
+	messages, err := encodeAttributes(suite, attributeNames, credential.Messages, credential.Types)
+	if err != nil {
+		return err
 	}
 
 	// Decode public key
@@ -472,7 +529,11 @@ func cmdCreateProof(args []string) error {
 	}
 
 	// Create proof
-	proof, _, err := bbs.CreateProof(publicKey, signature, messages, disclosedIndices, nil)
+	header, err := credential.header()
+	if err != nil {
+		return err
+	}
+	proof, _, err := bbs.CreateProof(publicKey, signature, messages, disclosedIndices, header)
 	if err != nil {
 		return fmt.Errorf("failed to create proof: %w", err)
 	}
@@ -485,10 +546,17 @@ func cmdCreateProof(args []string) error {
 
 	// Create disclosed messages map with attribute names
 	disclosedMessages := make(map[string]string)
+	var disclosedTypes map[string]attributeType
 	for i := range disclosedIndices {
 		name := disclosedNames[i]
 		value := credential.Messages[name]
 		disclosedMessages[name] = value
+		if typ, ok := credential.Types[name]; ok {
+			if disclosedTypes == nil {
+				disclosedTypes = make(map[string]attributeType)
+			}
+			disclosedTypes[name] = typ
+		}
 	}
 
 	// Create proof object
@@ -498,8 +566,12 @@ func cmdCreateProof(args []string) error {
 		PublicKey:         credential.PublicKey,
 		Proof:             base64.StdEncoding.EncodeToString(proofBytes),
 		DisclosedMessages: disclosedMessages,
+		DisclosedIndices:  nameIndices,
 		DateGenerated:     now,
 		Issuer:            credential.Issuer,
+		Mapping:           credential.Mapping,
+		Types:             disclosedTypes,
+		SchemaHash:        credential.SchemaHash,
 	}
 
 	// Save proof to file
@@ -514,10 +586,7 @@ func cmdCreateProof(args []string) error {
 	}
 
 	fmt.Printf("Proof created and saved to %s\n", *outputFile)
-	fmt.Println("Disclosed attributes:")
-	for name, value := range disclosedMessages {
-		fmt.Printf("  %s: %s\n", name, value)
-	}
+	printDisclosed(disclosedMessages)
 
 	return nil
 }
@@ -527,6 +596,9 @@ func cmdVerifyProof(args []string) error {
 	// Parse flags
 	flagSet := flag.NewFlagSet("verify-proof", flag.ExitOnError)
 	proofFile := flagSet.String("proof", "proof.json", "Proof file to verify")
+	schemaFile := flagSet.String("schema", "", "Schema file whose attribute order the disclosed indices must match")
+	schemaHashFlag := flagSet.String("schema-hash", "", "Hex hash of the expected schema, instead of the schema file")
+	lenient := flagSet.Bool("lenient", false, "Accept unknown, duplicate and case-folded JSON fields, as written by older tools")
 	flagSet.Parse(args)
 
 	// Load proof
@@ -536,7 +608,7 @@ func cmdVerifyProof(args []string) error {
 	}
 
 	var credentialProof CredentialProof
-	err = json.Unmarshal(proofData, &credentialProof)
+	err = decodeJSON(proofData, &credentialProof, *lenient)
 	if err != nil {
 		return fmt.Errorf("failed to parse proof JSON: %w", err)
 	}
@@ -565,33 +637,1092 @@ func cmdVerifyProof(args []string) error {
 		return fmt.Errorf("failed to unmarshal proof: %w", err)
 	}
 
-	// Get attribute names and sort them
-	disclosedNames := make([]string, 0, len(credentialProof.DisclosedMessages))
-	for name := range credentialProof.DisclosedMessages {
-		disclosedNames = append(disclosedNames, name)
+	// The prover states which index each attribute sits at. Without a schema
+	// the verifier takes its word for the names; the proof still binds every
+	// value to its index.
+	var expectedHash []byte
+	if *schemaHashFlag != "" {
+		expectedHash, err = hex.DecodeString(*schemaHashFlag)
+		if err != nil {
+			return fmt.Errorf("failed to decode schema hash: %w", err)
+		}
+	}
+	if *schemaFile != "" {
+		schemaData, err := ioutil.ReadFile(*schemaFile)
+		if err != nil {
+			return fmt.Errorf("failed to read schema file: %w", err)
+		}
+
+		var schemaJson map[string]interface{}
+		if err := json.Unmarshal(schemaData, &schemaJson); err != nil {
+			return fmt.Errorf("failed to parse schema JSON: %w", err)
+		}
+
+		specs, err := schemaAttributes(schemaJson)
+		if err != nil {
+			return err
+		}
+		if specs == nil {
+			return fmt.Errorf("schema %s does not define an attribute order", *schemaFile)
+		}
+		if err := checkSchemaIndices(specNames(specs), publicKey.MessageCount(), credentialProof.DisclosedIndices); err != nil {
+			return err
+		}
+		if err := checkSchemaTypes(specs, credentialProof.DisclosedMessages, credentialProof.Types); err != nil {
+			return err
+		}
+		if expectedHash, err = schemaHashOf(schemaJson); err != nil {
+			return err
+		}
+	}
+
+	// A proof of a schema bound credential verifies under the schema the
+	// verifier supplies, never the one the proof claims
+	header, err := proofHeader(credentialProof.SchemaHash, expectedHash)
+	if err != nil {
+		return err
 	}
-	sort.Strings(disclosedNames)
 
 	// Convert disclosed messages to map[int]*big.Int
-	// Use indices starting from 0 for disclosed messages
-	disclosedMsgs := make(map[int]*big.Int)
-	for i, name := range disclosedNames {
-		value := credentialProof.DisclosedMessages[name]
-		msgBytes := bbs.MessageToBytes(value)
-		disclosedMsgs[i] = bbs.MessageToFieldElement(msgBytes)
+	disclosedMsgs, err := credentialProof.disclosedMessageMap(publicKey.MessageCount())
+	if err != nil {
+		return err
 	}
 
 	// Verify proof
-	err = bbs.VerifyProof(publicKey, proof, disclosedMsgs, nil)
+	err = bbs.VerifyProof(publicKey, proof, disclosedMsgs, header)
 	if err != nil {
 		return fmt.Errorf("proof verification failed: %w", err)
 	}
 
 	fmt.Println("Proof verified successfully!")
-	fmt.Println("Disclosed attributes:")
-	for name, value := range credentialProof.DisclosedMessages {
-		fmt.Printf("  %s: %s\n", name, value)
+	printDisclosed(credentialProof.DisclosedMessages)
+
+	return nil
+}
+
+// Audit command
+func cmdAudit(args []string) error {
+	if len(args) < 1 || args[0] != "verify-log" {
+		return fmt.Errorf("usage: credgen audit verify-log -journal <file> [-credential <file>]")
+	}
+
+	// Parse flags
+	flagSet := flag.NewFlagSet("audit verify-log", flag.ExitOnError)
+	journalFile := flagSet.String("journal", "issuance.log", "Issuance journal to verify")
+	credentialFile := flagSet.String("credential", "", "Credential file to look up in the journal (optional)")
+	flagSet.Parse(args[1:])
+
+	records, err := credpkg.VerifyJournalFile(*journalFile)
+	if err != nil {
+		return err
+	}
+
+	head := "(empty)"
+	if len(records) > 0 {
+		head = fmt.Sprintf("record %d issued %s", len(records), records[len(records)-1].Time.Format(time.RFC3339))
+	}
+	fmt.Printf("Journal verified: %d records, latest %s\n", len(records), head)
+
+	if *credentialFile == "" {
+		return nil
+	}
+
+	credentialData, err := ioutil.ReadFile(*credentialFile)
+	if err != nil {
+		return fmt.Errorf("failed to read credential file: %w", err)
+	}
+
+	hash := credpkg.CredentialHash(credentialData)
+	for _, record := range records {
+		if record.CredentialHash == hash {
+			fmt.Printf("Credential issued %s (record %d, schema %q, key %s)\n",
+				record.Time.Format(time.RFC3339), record.Sequence, record.Schema, record.KeyFingerprint)
+			return nil
+		}
+	}
+
+	return fmt.Errorf("credential %s is not in the journal", *credentialFile)
+}
+
+// Blocklist command. The maintainer creates the parameters once with init
+// and publishes them with every version of the list; holders prove that a
+// hidden attribute is not on the list with bbs.CreateNonMembershipProof.
+func cmdBlocklist(args []string) error {
+	usage := fmt.Errorf("usage: credgen blocklist init|add|remove|show -params <file> -list <file> [values...]")
+	if len(args) < 1 {
+		return usage
+	}
+	action := args[0]
+
+	flagSet := flag.NewFlagSet("blocklist "+action, flag.ExitOnError)
+	paramsFile := flagSet.String("params", "blocklist.params", "Accumulator parameters file")
+	listFile := flagSet.String("list", "blocklist.bin", "Blocklist file")
+	maxSize := flagSet.Int("max", 1024, "Largest list the parameters support (init)")
+	mapping := flagSet.String("mapping", mappingHashToScalar, "Message mapping of the credentials the values appear in")
+	flagSet.Parse(args[1:])
+
+	suite, err := messageSuite(*mapping)
+	if err != nil {
+		return err
+	}
+	values := make([]*big.Int, flagSet.NArg())
+	for i, value := range flagSet.Args() {
+		values[i] = suite.MapMessageToScalar([]byte(value))
+	}
+
+	var blocklist *bbs.Blocklist
+	switch action {
+	case "init":
+		params, err := bbs.NewBlocklistParams(*maxSize, rand.Reader)
+		if err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(*paramsFile, bbs.SerializeBlocklistParams(params), 0644); err != nil {
+			return fmt.Errorf("failed to write parameters: %w", err)
+		}
+		if blocklist, err = bbs.NewBlocklist(params, values); err != nil {
+			return err
+		}
+	case "add", "remove", "show":
+		paramsData, err := ioutil.ReadFile(*paramsFile)
+		if err != nil {
+			return fmt.Errorf("failed to read parameters: %w", err)
+		}
+		params, err := bbs.DeserializeBlocklistParams(paramsData)
+		if err != nil {
+			return fmt.Errorf("failed to load parameters: %w", err)
+		}
+		listData, err := ioutil.ReadFile(*listFile)
+		if err != nil {
+			return fmt.Errorf("failed to read blocklist: %w", err)
+		}
+		if blocklist, err = bbs.DeserializeBlocklist(params, listData); err != nil {
+			return fmt.Errorf("failed to load blocklist: %w", err)
+		}
+		switch action {
+		case "add":
+			blocklist, err = blocklist.Add(values...)
+		case "remove":
+			blocklist, err = blocklist.Remove(values...)
+		}
+		if err != nil {
+			return err
+		}
+	default:
+		return usage
+	}
+
+	if action != "show" {
+		if err := ioutil.WriteFile(*listFile, bbs.SerializeBlocklist(blocklist), 0644); err != nil {
+			return fmt.Errorf("failed to write blocklist: %w", err)
+		}
+	}
+	accumulator := blocklist.Accumulator()
+	accumulatorBytes := accumulator.Bytes()
+	fmt.Printf("Blocklist version %d: %d of %d values, accumulator %s\n",
+		blocklist.Version(), blocklist.Len(), blocklist.Params().MaxSize(), hex.EncodeToString(accumulatorBytes[:]))
+	return nil
+}
+
+// Migrate command
+func cmdMigrate(args []string) error {
+	// Parse flags
+	flagSet := flag.NewFlagSet("migrate", flag.ExitOnError)
+	oldKeyFile := flagSet.String("old-key", "", "Key pair file the credentials were issued under")
+	newKeyFile := flagSet.String("key", "", "Key pair file to re-issue the credentials under")
+	attributesFile := flagSet.String("attributes", "", "JSON file with the values of the added attributes")
+	schemaFile := flagSet.String("schema", "", "Schema file for the migrated credentials (optional)")
+	outputDir := flagSet.String("output-dir", "migrated", "Directory for the migrated credentials")
+	linksFile := flagSet.String("links", "migration-links.json", "Output file for the signed migration links")
+	lenient := flagSet.Bool("lenient", false, "Accept unknown, duplicate and case-folded JSON fields, as written by older tools")
+	flagSet.Parse(args)
+
+	credentialFiles := flagSet.Args()
+	if *oldKeyFile == "" || *newKeyFile == "" || len(credentialFiles) == 0 {
+		return fmt.Errorf("usage: credgen migrate -old-key <file> -key <file> [-attributes <file>] <credential>...")
+	}
+
+	// Load both key pairs
+	oldKeyPair, _, err := loadSigningKey(*oldKeyFile)
+	if err != nil {
+		return err
+	}
+	newKeyPair, newPublicKeyBytes, err := loadSigningKey(*newKeyFile)
+	if err != nil {
+		return err
+	}
+	oldFingerprint := credpkg.KeyFingerprint(oldKeyPair.PublicKey)
+
+	// A schema may fix the order and types of the migrated attributes, and
+	// binds them like at issuance
+	var specs []credpkg.AttributeSpec
+	var schemaHash []byte
+	if *schemaFile != "" {
+		schemaData, err := ioutil.ReadFile(*schemaFile)
+		if err != nil {
+			return fmt.Errorf("failed to read schema file: %w", err)
+		}
+
+		var schemaJson map[string]interface{}
+		if err := json.Unmarshal(schemaData, &schemaJson); err != nil {
+			return fmt.Errorf("failed to parse schema JSON: %w", err)
+		}
+
+		specs, err = schemaAttributes(schemaJson)
+		if err != nil {
+			return err
+		}
+		if schemaHash, err = schemaHashOf(schemaJson); err != nil {
+			return err
+		}
+	}
+
+	// Values of the added attributes, the same for every credential
+	added, addedTypes := map[string]string{}, map[string]attributeType{}
+	if *attributesFile != "" {
+		attributesData, err := ioutil.ReadFile(*attributesFile)
+		if err != nil {
+			return fmt.Errorf("failed to read attributes file: %w", err)
+		}
+		var rawAttributes map[string]json.RawMessage
+		if err := json.Unmarshal(attributesData, &rawAttributes); err != nil {
+			return fmt.Errorf("failed to parse attributes JSON: %w", err)
+		}
+		added, addedTypes, err = parseAttributeValues(rawAttributes, specs)
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := os.MkdirAll(*outputDir, 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
 	}
 
+	links := make([]*credpkg.MigrationLink, 0, len(credentialFiles))
+	for _, path := range credentialFiles {
+		credentialData, err := ioutil.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read credential file: %w", err)
+		}
+
+		var credential Credential
+		if err := decodeJSON(credentialData, &credential, *lenient); err != nil {
+			return fmt.Errorf("%s: failed to parse credential JSON: %w", path, err)
+		}
+
+		// Only migrate valid credentials of the old key
+		if err := credential.verify(); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		pubKeyBytes, err := base64.StdEncoding.DecodeString(credential.PublicKey)
+		if err != nil {
+			return fmt.Errorf("%s: failed to decode public key: %w", path, err)
+		}
+		publicKey := &bbs.PublicKey{}
+		if err := publicKey.UnmarshalBinary(pubKeyBytes); err != nil {
+			return fmt.Errorf("%s: failed to unmarshal public key: %w", path, err)
+		}
+		if credpkg.KeyFingerprint(publicKey) != oldFingerprint {
+			return fmt.Errorf("%s: credential was not issued under %s", path, *oldKeyFile)
+		}
+
+		// Without a new schema a bound credential keeps its schema, which
+		// does not declare any added attribute
+		bound := schemaHash
+		if *schemaFile == "" && credential.SchemaHash != "" {
+			if len(added) > 0 {
+				return fmt.Errorf("%s: credential is bound to a schema; pass -schema for the added attributes", path)
+			}
+			if bound, err = hex.DecodeString(credential.SchemaHash); err != nil {
+				return fmt.Errorf("%s: failed to decode schema hash: %w", path, err)
+			}
+		}
+
+		migrated, addedNames, err := migrateCredential(&credential, added, addedTypes, specNames(specs), bound, newKeyPair, newPublicKeyBytes)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		if *schemaFile != "" {
+			migrated.Schema = *schemaFile
+		}
+
+		migratedData, err := json.MarshalIndent(migrated, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal credential to JSON: %w", err)
+		}
+
+		link, err := credpkg.NewMigrationLink(oldKeyPair, newKeyPair.PublicKey, migrated.Schema, credentialData, migratedData, addedNames)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+
+		outputFile := filepath.Join(*outputDir, filepath.Base(path))
+		if err := ioutil.WriteFile(outputFile, migratedData, 0644); err != nil {
+			return fmt.Errorf("failed to write credential to file: %w", err)
+		}
+		links = append(links, link)
+	}
+
+	linksData, err := json.MarshalIndent(links, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal migration links to JSON: %w", err)
+	}
+	if err := ioutil.WriteFile(*linksFile, linksData, 0644); err != nil {
+		return fmt.Errorf("failed to write migration links to file: %w", err)
+	}
+
+	fmt.Printf("Migrated %d credentials to %s, links saved to %s\n", len(links), *outputDir, *linksFile)
+	return nil
+}
+
+// migrateCredential re-signs a credential's attributes and the added ones
+// under the new key, bound to schemaHash if it is not nil. The old
+// attributes keep their order and types and the added ones follow in name
+// order, unless a schema order is given. It returns the migrated credential
+// and the added attribute names.
+func migrateCredential(c *Credential, added map[string]string, addedTypes map[string]attributeType, schemaOrder []string, schemaHash []byte, keyPair *bbs.KeyPair, publicKeyBytes []byte) (*Credential, []string, error) {
+	oldOrder, err := c.attributeOrder()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	values := make(map[string]string, len(c.Messages)+len(added))
+	for name, value := range c.Messages {
+		values[name] = value
+	}
+	var types map[string]attributeType
+	for name, typ := range c.Types {
+		types = setType(types, name, typ)
+	}
+	addedNames := sortedNames(added)
+	for _, name := range addedNames {
+		if _, ok := values[name]; ok {
+			return nil, nil, fmt.Errorf("attribute '%s' already in credential", name)
+		}
+		values[name] = added[name]
+		if typ, ok := addedTypes[name]; ok {
+			types = setType(types, name, typ)
+		}
+	}
+
+	order := append(append([]string(nil), oldOrder...), addedNames...)
+	if schemaOrder != nil {
+		order = schemaOrder
+	}
+	if err := validateAttributeOrder(order, values); err != nil {
+		return nil, nil, err
+	}
+	if len(order) != keyPair.PublicKey.MessageCount() {
+		return nil, nil, fmt.Errorf("attribute count mismatch: key supports %d attributes, but %d provided",
+			keyPair.PublicKey.MessageCount(), len(order))
+	}
+
+	// Migrated credentials always use the current message mapping
+	messages, err := encodeAttributes(bbs.BLS12381SHA256, order, values, types)
+	if err != nil {
+		return nil, nil, err
+	}
+	signature, err := bbs.Sign(keyPair.PrivateKey, keyPair.PublicKey, messages, bbs.SchemaHeader(nil, schemaHash))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to sign messages: %w", err)
+	}
+	signatureBytes, err := signature.MarshalBinary()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to serialize signature: %w", err)
+	}
+
+	return &Credential{
+		Schema:      c.Schema,
+		PublicKey:   base64.StdEncoding.EncodeToString(publicKeyBytes),
+		Signature:   base64.StdEncoding.EncodeToString(signatureBytes),
+		Messages:    values,
+		Attributes:  order,
+		DateIssued:  time.Now().Format(time.RFC3339),
+		DateExpires: c.DateExpires,
+		Issuer:      c.Issuer,
+		Mapping:     mappingHashToScalar,
+		Types:       types,
+		SchemaHash:  hex.EncodeToString(schemaHash),
+	}, addedNames, nil
+}
+
+// loadSigningKey loads a key pair with a private key and returns it with the
+// encoded public key
+func loadSigningKey(path string) (*bbs.KeyPair, []byte, error) {
+	keyPairFile, err := keys.Load(path, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load key pair: %w", err)
+	}
+
+	keyPair, err := keyPairFile.KeyPair()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to decode key pair: %w", err)
+	}
+
+	if keyPair.PrivateKey == nil {
+		return nil, nil, fmt.Errorf("key file %s does not contain a private key", path)
+	}
+
+	return keyPair, keyPairFile.PublicKey, nil
+}
+
+// Benchmark command
+func cmdBench(args []string) error {
+	// Parse flags
+	flagSet := flag.NewFlagSet("bench", flag.ExitOnError)
+	schemaFile := flagSet.String("schema", "", "Schema file whose attributes define the credential shape")
+	attributeCount := flagSet.Int("attributes", 10, "Number of attributes when no schema is given")
+	disclosedAttrs := flagSet.String("disclose", "", "Comma-separated list of schema attribute names to disclose in proofs")
+	discloseCount := flagSet.Int("disclose-count", 0, "Number of attributes to disclose when no schema is given")
+	iterations := flagSet.Int("iterations", 50, "Timed runs per operation")
+	opTimeout := flagSet.Duration("op-timeout", 0, "Time budget per operation, e.g. 10s (0 for none)")
+	maxTime := flagSet.Duration("max-time", 0, "Time budget for the whole run, e.g. 1m (0 for none)")
+	sweep := flagSet.String("counts", "", "Comma-separated message counts to measure instead of -attributes, e.g. 5,10,20")
+	format := flagSet.String("format", "text", "Output format: text or html")
+	outputFile := flagSet.String("output", "", "Write the results to this file instead of stdout")
+	flagSet.Parse(args)
+
+	if *opTimeout < 0 || *maxTime < 0 {
+		return fmt.Errorf("time budgets must not be negative")
+	}
+	if *format != "text" && *format != "html" {
+		return fmt.Errorf("unknown format '%s', expected text or html", *format)
+	}
+
+	// A sweep repeats the suite for several credential sizes, disclosing
+	// the first attributes of each as far as it has them
+	var counts []int
+	if *sweep != "" {
+		if *schemaFile != "" {
+			return fmt.Errorf("-counts cannot be combined with -schema")
+		}
+		*attributeCount = 0
+		for _, field := range strings.Split(*sweep, ",") {
+			n, err := strconv.Atoi(strings.TrimSpace(field))
+			if err != nil || n < 1 {
+				return fmt.Errorf("invalid message count '%s'", strings.TrimSpace(field))
+			}
+			counts = append(counts, n)
+			*attributeCount = max(*attributeCount, n)
+		}
+	}
+
+	// Work out the credential shape
+	var disclosedIndices []int
+	messageCount := *attributeCount
+	if *schemaFile != "" {
+		schemaData, err := ioutil.ReadFile(*schemaFile)
+		if err != nil {
+			return fmt.Errorf("failed to read schema file: %w", err)
+		}
+
+		var schemaJson map[string]interface{}
+		if err := json.Unmarshal(schemaData, &schemaJson); err != nil {
+			return fmt.Errorf("failed to parse schema JSON: %w", err)
+		}
+
+		order, err := schemaAttributeOrder(schemaJson)
+		if err != nil {
+			return err
+		}
+		if len(order) == 0 {
+			return fmt.Errorf("schema %s does not define any attributes", *schemaFile)
+		}
+		messageCount = len(order)
+
+		position := make(map[string]int, len(order))
+		for i, name := range order {
+			position[name] = i
+		}
+
+		if *disclosedAttrs != "" {
+			for _, name := range strings.Split(*disclosedAttrs, ",") {
+				idx, ok := position[strings.TrimSpace(name)]
+				if !ok {
+					return fmt.Errorf("attribute '%s' not found in schema", strings.TrimSpace(name))
+				}
+				disclosedIndices = append(disclosedIndices, idx)
+			}
+		}
+	} else {
+		if *disclosedAttrs != "" {
+			return fmt.Errorf("-disclose needs a -schema; use -disclose-count without one")
+		}
+		if *discloseCount < 0 || *discloseCount > messageCount {
+			return fmt.Errorf("disclose count must be between 0 and %d", messageCount)
+		}
+		for i := 0; i < *discloseCount; i++ {
+			disclosedIndices = append(disclosedIndices, i)
+		}
+	}
+
+	if counts == nil {
+		counts = []int{messageCount}
+	}
+
+	report := perf.Report{Environment: perf.CurrentEnvironment()}
+	began := time.Now()
+	var budgetErr error
+	for _, count := range counts {
+		disclosed := disclosedIndices
+		if len(disclosed) > count {
+			disclosed = disclosed[:count]
+		}
+
+		// Later sizes get what the earlier ones left of the total budget
+		suiteTime := *maxTime
+		if suiteTime > 0 {
+			suiteTime -= time.Since(began)
+			if suiteTime <= 0 {
+				budgetErr = fmt.Errorf("%w after %v: skipped %d messages", perf.ErrTimeBudget, *maxTime, count)
+				continue
+			}
+		}
+
+		fmt.Fprintf(os.Stderr, "Measuring %d attributes, %d disclosed, %d runs per operation...\n",
+			count, len(disclosed), *iterations)
+
+		results, err := perf.Suite{
+			MessageCount: count,
+			Disclosed:    disclosed,
+			Iterations:   *iterations,
+			OpTimeout:    *opTimeout,
+			MaxTime:      suiteTime,
+		}.Run()
+		if err != nil && !errors.Is(err, perf.ErrTimeBudget) {
+			return fmt.Errorf("benchmark failed: %w", err)
+		}
+		if err != nil {
+			budgetErr = err
+		}
+		if len(results) > 0 {
+			report.Runs = append(report.Runs, perf.Run{MessageCount: count, Disclosed: len(disclosed), Results: results})
+		}
+	}
+
+	// Running out of time is not a failure: the results measured are still valid
+	if budgetErr != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", budgetErr)
+	}
+
+	out := os.Stdout
+	if *outputFile != "" {
+		f, err := os.Create(*outputFile)
+		if err != nil {
+			return fmt.Errorf("failed to create output file: %w", err)
+		}
+		defer f.Close()
+		out = f
+	}
+
+	switch *format {
+	case "text":
+		printBenchText(out, report, len(counts) > 1)
+	case "html":
+		if len(report.Runs) == 0 {
+			return fmt.Errorf("no operation was measured within the time budget")
+		}
+		if err := perf.WriteHTML(out, report); err != nil {
+			return fmt.Errorf("failed to write report: %w", err)
+		}
+	}
+
+	if *outputFile != "" {
+		fmt.Fprintf(os.Stderr, "Report written to %s\n", *outputFile)
+	}
+	return nil
+}
+
+// printBenchText writes the benchmark results as aligned tables
+func printBenchText(out io.Writer, report perf.Report, titled bool) {
+	// Operations are independent, so throughput scales with cores
+	cores := report.Environment.NumCPU
+	for _, run := range report.Runs {
+		if titled {
+			fmt.Fprintf(out, "\n%d attributes, %d disclosed:\n", run.MessageCount, run.Disclosed)
+		}
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintf(w, "operation\truns\tops/sec (1 core)\tops/sec (%d cores, est.)\tmean\tp95\n", cores)
+		for _, r := range run.Results {
+			runs := strconv.Itoa(r.Iterations)
+			if r.Partial {
+				runs += " (partial)"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%.1f\t%.1f\t%v\t%v\n",
+				r.Name, runs, r.OpsPerSec(), r.OpsPerSec()*float64(cores), r.Mean.Round(time.Microsecond), r.P95.Round(time.Microsecond))
+		}
+		w.Flush()
+	}
+}
+
+// printDisclosed lists disclosed attributes, or notes that none were
+func printDisclosed(disclosed map[string]string) {
+	fmt.Println("Disclosed attributes:")
+	if len(disclosed) == 0 {
+		fmt.Println("  (none)")
+		return
+	}
+	for name, value := range disclosed {
+		fmt.Printf("  %s: %s\n", name, value)
+	}
+}
+
+// verify checks the credential's signature over its attributes
+func (c *Credential) verify() error {
+	// Decode public key
+	pubKeyBytes, err := base64.StdEncoding.DecodeString(c.PublicKey)
+	if err != nil {
+		return fmt.Errorf("failed to decode public key: %w", err)
+	}
+
+	publicKey := &bbs.PublicKey{}
+	err = publicKey.UnmarshalBinary(pubKeyBytes)
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal public key: %w", err)
+	}
+
+	// Decode signature
+	signatureBytes, err := base64.StdEncoding.DecodeString(c.Signature)
+	if err != nil {
+		return fmt.Errorf("failed to decode signature: %w", err)
+	}
+
+	signature := &bbs.Signature{}
+	err = signature.UnmarshalBinary(signatureBytes)
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal signature: %w", err)
+	}
+
+	// Convert attributes to messages in issuance order
+	attributeNames, err := c.attributeOrder()
+	if err != nil {
+		return err
+	}
+	suite, err := messageSuite(c.Mapping)
+	if err != nil {
+		return err
+	}
+	messages, err := encodeAttributes(suite, attributeNames, c.Messages, c.Types)
+	if err != nil {
+		return err
+	}
+
+	// Verify signature
+	header, err := c.header()
+	if err != nil {
+		return err
+	}
+	err = bbs.Verify(publicKey, signature, messages, header)
+	if err != nil {
+		return fmt.Errorf("credential verification failed: %w", err)
+	}
+
+	return nil
+}
+
+// attributeOrder returns the attribute order fixed at issuance, or sorted
+// names for credentials issued before the order was recorded
+func (c *Credential) attributeOrder() ([]string, error) {
+	if c.Attributes == nil {
+		return sortedNames(c.Messages), nil
+	}
+
+	if err := validateAttributeOrder(c.Attributes, c.Messages); err != nil {
+		return nil, err
+	}
+	return c.Attributes, nil
+}
+
+// disclosedMessageMap maps the disclosed attributes of a proof to their
+// message indices, checking that every attribute has exactly one valid index
+func (p *CredentialProof) disclosedMessageMap(messageCount int) (map[int]*big.Int, error) {
+	if len(p.DisclosedIndices) != len(p.DisclosedMessages) {
+		return nil, fmt.Errorf("proof has %d disclosed attributes but %d indices",
+			len(p.DisclosedMessages), len(p.DisclosedIndices))
+	}
+
+	suite, err := messageSuite(p.Mapping)
+	if err != nil {
+		return nil, err
+	}
+
+	disclosed := make(map[int]*big.Int, len(p.DisclosedMessages))
+	for name, value := range p.DisclosedMessages {
+		idx, ok := p.DisclosedIndices[name]
+		if !ok {
+			return nil, fmt.Errorf("no index for disclosed attribute '%s'", name)
+		}
+		if idx < 0 || idx >= messageCount {
+			return nil, fmt.Errorf("index %d of attribute '%s' out of range [0, %d)", idx, name, messageCount)
+		}
+		if _, dup := disclosed[idx]; dup {
+			return nil, fmt.Errorf("index %d disclosed more than once", idx)
+		}
+		attr, err := typedAttribute(name, value, p.Types)
+		if err != nil {
+			return nil, err
+		}
+		if disclosed[idx], err = attr.Message(suite); err != nil {
+			return nil, err
+		}
+	}
+
+	return disclosed, nil
+}
+
+// schemaAttributeOrder reads the attribute order from the "attributes" array
+// of a schema. It returns nil if the schema does not have one.
+func schemaAttributeOrder(schema map[string]interface{}) ([]string, error) {
+	specs, err := schemaAttributes(schema)
+	if err != nil {
+		return nil, err
+	}
+	return specNames(specs), nil
+}
+
+// schemaAttributes reads the "attributes" array of a schema. Entries are
+// names, or objects with a name and optionally a type and an encoding. It
+// returns nil if the schema does not have one.
+func schemaAttributes(schema map[string]interface{}) ([]credpkg.AttributeSpec, error) {
+	raw, ok := schema["attributes"]
+	if !ok {
+		return nil, nil
+	}
+
+	list, ok := raw.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("schema attributes must be an array of names")
+	}
+
+	specs := make([]credpkg.AttributeSpec, len(list))
+	for i, v := range list {
+		switch entry := v.(type) {
+		case string:
+			specs[i].Name = entry
+		case map[string]interface{}:
+			name, _ := entry["name"].(string)
+			typ, _ := entry["type"].(string)
+			encoding, _ := entry["encoding"].(string)
+			specs[i] = credpkg.AttributeSpec{Name: name, Type: credpkg.AttributeType(typ), Encoding: credpkg.Encoding(encoding)}
+		}
+		if specs[i].Name == "" {
+			return nil, fmt.Errorf("schema attribute %d is not a name", i)
+		}
+	}
+
+	return specs, nil
+}
+
+// schemaHashOf returns the hash of a schema's "$id" and attribute
+// declarations, or nil if it does not declare its attributes
+func schemaHashOf(schema map[string]interface{}) ([]byte, error) {
+	specs, err := schemaAttributes(schema)
+	if err != nil || specs == nil {
+		return nil, err
+	}
+	id, _ := schema["$id"].(string)
+	return credpkg.SchemaHash(id, specs), nil
+}
+
+// header returns the header the credential is signed under
+func (c *Credential) header() ([]byte, error) {
+	if c.SchemaHash == "" {
+		return nil, nil
+	}
+	schemaHash, err := hex.DecodeString(c.SchemaHash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode schema hash: %w", err)
+	}
+	return bbs.SchemaHeader(nil, schemaHash), nil
+}
+
+// proofHeader returns the header to verify a proof under: bound to the
+// expected schema hash if the proof records one. A bound proof needs the
+// verifier to supply the schema.
+func proofHeader(recorded string, expected []byte) ([]byte, error) {
+	if recorded == "" {
+		return nil, nil
+	}
+	if expected == nil {
+		return nil, fmt.Errorf("proof is bound to schema hash %s; pass -schema or -schema-hash", recorded)
+	}
+	if hex.EncodeToString(expected) != recorded {
+		return nil, fmt.Errorf("%w: proof is bound to schema hash %s, expected %x", credpkg.ErrSchemaMismatch, recorded, expected)
+	}
+	return bbs.SchemaHeader(nil, expected), nil
+}
+
+// specNames returns the names of declared attributes, or nil for none
+func specNames(specs []credpkg.AttributeSpec) []string {
+	if specs == nil {
+		return nil
+	}
+	names := make([]string, len(specs))
+	for i, spec := range specs {
+		names[i] = spec.Name
+	}
+	return names
+}
+
+// parseAttributeValues reads attribute values given as JSON strings,
+// integers or booleans. A schema's declared type takes precedence over the
+// JSON one, so a time or bytes attribute is declared and given as a string.
+// It returns the canonical text of every value and the types of those that
+// are not hashed strings.
+func parseAttributeValues(raw map[string]json.RawMessage, specs []credpkg.AttributeSpec) (map[string]string, map[string]attributeType, error) {
+	declared := make(map[string]credpkg.AttributeSpec, len(specs))
+	for _, spec := range specs {
+		declared[spec.Name] = spec
+	}
+
+	values := make(map[string]string, len(raw))
+	var types map[string]attributeType
+	for name, value := range raw {
+		var literal interface{}
+		if err := json.Unmarshal(value, &literal); err != nil {
+			return nil, nil, fmt.Errorf("failed to parse attribute '%s': %w", name, err)
+		}
+
+		var text string
+		typ := credpkg.TypeString
+		switch v := literal.(type) {
+		case string:
+			text = v
+		case bool:
+			text, typ = strconv.FormatBool(v), credpkg.TypeBool
+		case float64:
+			text, typ = strings.TrimSpace(string(value)), credpkg.TypeInt
+		default:
+			return nil, nil, fmt.Errorf("attribute '%s' must be a string, integer or boolean", name)
+		}
+
+		spec := declared[name]
+		if spec.Type != "" {
+			typ = spec.Type
+		}
+		attr, err := credpkg.ParseAttribute(name, typ, text)
+		if err != nil {
+			return nil, nil, err
+		}
+		if spec.Encoding != "" {
+			attr = attr.WithEncoding(spec.Encoding)
+		}
+		if _, err := attr.Message(nil); err != nil {
+			return nil, nil, err
+		}
+
+		values[name] = attr.Text()
+		if attr.Type != credpkg.TypeString || attr.Encoding != credpkg.EncodingHash {
+			types = setType(types, name, attributeType{Type: attr.Type, Encoding: attr.Encoding})
+		}
+	}
+
+	return values, types, nil
+}
+
+// readFileAttributes reads the name=path list of the -files flag. It
+// returns nil for an empty list.
+func readFileAttributes(list string) (map[string][]byte, error) {
+	if list == "" {
+		return nil, nil
+	}
+
+	files := make(map[string][]byte)
+	for _, entry := range strings.Split(list, ",") {
+		name, path, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok || name == "" || path == "" {
+			return nil, fmt.Errorf("file attribute '%s' is not name=path", entry)
+		}
+		if _, ok := files[name]; ok {
+			return nil, fmt.Errorf("file attribute '%s' given twice", name)
+		}
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read attribute '%s': %w", name, err)
+		}
+		files[name] = data
+	}
+	return files, nil
+}
+
+// addFileAttributes adds the raw contents of files as bytes attributes to
+// values and types. A schema may declare them bytes, and choose nothing
+// else.
+func addFileAttributes(values map[string]string, types map[string]attributeType, files map[string][]byte, specs []credpkg.AttributeSpec) (map[string]attributeType, error) {
+	for name, data := range files {
+		if _, ok := values[name]; ok {
+			return nil, fmt.Errorf("attribute '%s' given in the attributes file and as a file", name)
+		}
+		attr := credpkg.BytesAttribute(name, data)
+		for _, spec := range specs {
+			if spec.Name != name {
+				continue
+			}
+			if spec.Type != "" && spec.Type != credpkg.TypeBytes {
+				return nil, fmt.Errorf("%w: '%s' is read from a file, declared %s", credpkg.ErrInvalidAttribute, name, spec.Type)
+			}
+			if spec.Encoding != "" {
+				attr = attr.WithEncoding(spec.Encoding)
+			}
+		}
+		if _, err := attr.Message(nil); err != nil {
+			return nil, err
+		}
+
+		values[name] = attr.Text()
+		types = setType(types, name, attributeType{Type: attr.Type, Encoding: attr.Encoding})
+	}
+	return types, nil
+}
+
+// setType records the type of an attribute, creating the map if needed
+func setType(types map[string]attributeType, name string, typ attributeType) map[string]attributeType {
+	if types == nil {
+		types = make(map[string]attributeType)
+	}
+	types[name] = typ
+	return types
+}
+
+// typedAttribute returns the attribute of the given name and text, a hashed
+// string unless types says otherwise
+func typedAttribute(name, text string, types map[string]attributeType) (credpkg.Attribute, error) {
+	typ, ok := types[name]
+	if !ok {
+		return credpkg.StringAttribute(name, text), nil
+	}
+
+	attr, err := credpkg.ParseAttribute(name, typ.Type, text)
+	if err != nil {
+		return credpkg.Attribute{}, err
+	}
+	return attr.WithEncoding(typ.Encoding), nil
+}
+
+// validateAttributeOrder checks that order lists every attribute in values
+// exactly once
+func validateAttributeOrder(order []string, values map[string]string) error {
+	if len(order) != len(values) {
+		return fmt.Errorf("attribute order lists %d attributes, credential has %d", len(order), len(values))
+	}
+
+	seen := make(map[string]bool, len(order))
+	for _, name := range order {
+		if seen[name] {
+			return fmt.Errorf("attribute '%s' listed twice in attribute order", name)
+		}
+		if _, ok := values[name]; !ok {
+			return fmt.Errorf("attribute '%s' has no value", name)
+		}
+		seen[name] = true
+	}
+
+	return nil
+}
+
+// checkSchemaIndices checks that disclosed attribute indices match their
+// positions in a schema's attribute order
+func checkSchemaIndices(order []string, messageCount int, indices map[string]int) error {
+	if len(order) != messageCount {
+		return fmt.Errorf("schema lists %d attributes, key supports %d", len(order), messageCount)
+	}
+
+	position := make(map[string]int, len(order))
+	for i, name := range order {
+		position[name] = i
+	}
+
+	for name, idx := range indices {
+		want, ok := position[name]
+		if !ok {
+			return fmt.Errorf("disclosed attribute '%s' is not in the schema", name)
+		}
+		if idx != want {
+			return fmt.Errorf("attribute '%s' disclosed at index %d, schema places it at %d", name, idx, want)
+		}
+	}
+
+	return nil
+}
+
+// checkSchemaTypes checks that disclosed attributes have the types a schema
+// declares for them
+func checkSchemaTypes(specs []credpkg.AttributeSpec, disclosed map[string]string, types map[string]attributeType) error {
+	for _, spec := range specs {
+		if _, ok := disclosed[spec.Name]; !ok {
+			continue
+		}
+		attr, err := typedAttribute(spec.Name, disclosed[spec.Name], types)
+		if err != nil {
+			return err
+		}
+		if (spec.Type != "" && attr.Type != spec.Type) || (spec.Encoding != "" && attr.Encoding != spec.Encoding) {
+			return fmt.Errorf("attribute '%s' disclosed as %s (%s), schema declares %s (%s)",
+				spec.Name, attr.Type, attr.Encoding, spec.Type, spec.Encoding)
+		}
+	}
+	return nil
+}
+
+// sortedNames returns the attribute names of values in sorted order
+func sortedNames(values map[string]string) []string {
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// encodeAttributes maps attribute values to messages in the given order
+func encodeAttributes(suite *bbs.Ciphersuite, order []string, values map[string]string, types map[string]attributeType) ([]*big.Int, error) {
+	messages := make([]*big.Int, len(order))
+	for i, name := range order {
+		attr, err := typedAttribute(name, values[name], types)
+		if err != nil {
+			return nil, err
+		}
+		if messages[i], err = attr.Message(suite); err != nil {
+			return nil, err
+		}
+	}
+	return messages, nil
+}
+
+// messageSuite returns the ciphersuite for a credential's message mapping
+func messageSuite(mapping string) (*bbs.Ciphersuite, error) {
+	switch mapping {
+	case "":
+		return bbs.LegacySHA256, nil
+	case mappingHashToScalar:
+		return bbs.BLS12381SHA256, nil
+	default:
+		return nil, fmt.Errorf("unknown message mapping '%s'", mapping)
+	}
+}
+
+// Show version command
+func cmdVersion(args []string) error {
+	// Parse flags
+	flagSet := flag.NewFlagSet("version", flag.ExitOnError)
+	asJSON := flagSet.Bool("json", false, "Print the build information as JSON")
+	flagSet.Parse(args)
+
+	info := version.Get()
+	if *asJSON {
+		out, err := json.MarshalIndent(info, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode build information: %w", err)
+		}
+		fmt.Println(string(out))
+		return nil
+	}
+
+	fmt.Printf("credgen %s\n", info)
+	fmt.Println("Ciphersuites:")
+	for _, id := range info.Ciphersuites {
+		fmt.Printf("  %s\n", id)
+	}
 	return nil
 }
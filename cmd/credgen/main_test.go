@@ -0,0 +1,763 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"math/big"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/anupsv/bbsplus-signatures/bbs"
+	credpkg "github.com/anupsv/bbsplus-signatures/pkg/credential"
+	"github.com/anupsv/bbsplus-signatures/pkg/jose"
+	"github.com/anupsv/bbsplus-signatures/pkg/keys"
+	"github.com/anupsv/bbsplus-signatures/pkg/multiformat"
+)
+
+// issueTestCredential issues a credential with four attributes in dir and
+// returns its path
+func issueTestCredential(t *testing.T, dir string, schema map[string]interface{}) string {
+	t.Helper()
+
+	keyFile := filepath.Join(dir, "keypair.json")
+	if err := cmdKeyGen([]string{"-attributes", "4", "-output", keyFile}); err != nil {
+		t.Fatalf("keygen failed: %v", err)
+	}
+
+	attributes := map[string]string{
+		"birthdate": "1990-01-01",
+		"country":   "NL",
+		"name":      "Alice",
+		"zip":       "1011AB",
+	}
+	attributesFile := filepath.Join(dir, "attributes.json")
+	writeJSON(t, attributesFile, attributes)
+
+	args := []string{"-key", keyFile, "-attributes", attributesFile}
+	if schema != nil {
+		schemaFile := filepath.Join(dir, "schema.json")
+		writeJSON(t, schemaFile, schema)
+		args = append(args, "-schema", schemaFile)
+	}
+
+	credentialFile := filepath.Join(dir, "credential.json")
+	args = append(args, "-output", credentialFile)
+	if err := cmdIssueCredential(args); err != nil {
+		t.Fatalf("issue failed: %v", err)
+	}
+	if err := cmdVerifyCredential([]string{"-credential", credentialFile}); err != nil {
+		t.Fatalf("verify failed: %v", err)
+	}
+
+	return credentialFile
+}
+
+func writeJSON(t *testing.T, path string, v interface{}) {
+	t.Helper()
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	if err := ioutil.WriteFile(path, data, 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+}
+
+func TestProveNonContiguousDisclosure(t *testing.T) {
+	dir := t.TempDir()
+	credentialFile := issueTestCredential(t, dir, nil)
+
+	// None of these is a prefix of the sorted attribute list
+	disclosures := []string{"country", "zip", "birthdate,name", "zip,country", "country,name,zip", ""}
+	for _, disclose := range disclosures {
+		proofFile := filepath.Join(dir, "proof.json")
+		err := cmdCreateProof([]string{"-credential", credentialFile, "-disclose", disclose, "-output", proofFile})
+		if err != nil {
+			t.Fatalf("prove %q failed: %v", disclose, err)
+		}
+		if err := cmdVerifyProof([]string{"-proof", proofFile}); err != nil {
+			t.Fatalf("verify-proof %q failed: %v", disclose, err)
+		}
+	}
+}
+
+func TestProveSchemaOrder(t *testing.T) {
+	dir := t.TempDir()
+	schema := map[string]interface{}{
+		"attributes": []string{"zip", "name", "country", "birthdate"},
+	}
+	credentialFile := issueTestCredential(t, dir, schema)
+
+	proofFile := filepath.Join(dir, "proof.json")
+	err := cmdCreateProof([]string{"-credential", credentialFile, "-disclose", "name,birthdate", "-output", proofFile})
+	if err != nil {
+		t.Fatalf("prove failed: %v", err)
+	}
+
+	data, err := ioutil.ReadFile(proofFile)
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	var proof CredentialProof
+	if err := json.Unmarshal(data, &proof); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if proof.DisclosedIndices["name"] != 1 || proof.DisclosedIndices["birthdate"] != 3 {
+		t.Fatalf("unexpected disclosed indices: %v", proof.DisclosedIndices)
+	}
+
+	schemaFile := filepath.Join(dir, "schema.json")
+	if err := cmdVerifyProof([]string{"-proof", proofFile, "-schema", schemaFile}); err != nil {
+		t.Fatalf("verify-proof with schema failed: %v", err)
+	}
+
+	// A schema with a different order must be rejected before verification
+	otherSchema := filepath.Join(dir, "other.json")
+	writeJSON(t, otherSchema, map[string]interface{}{
+		"attributes": []string{"birthdate", "country", "name", "zip"},
+	})
+	if err := cmdVerifyProof([]string{"-proof", proofFile, "-schema", otherSchema}); err == nil {
+		t.Fatal("verify-proof accepted indices that disagree with the schema")
+	}
+}
+
+func TestTypedAttributes(t *testing.T) {
+	dir := t.TempDir()
+	keyFile := filepath.Join(dir, "keypair.json")
+	if err := cmdKeyGen([]string{"-attributes", "4", "-output", keyFile}); err != nil {
+		t.Fatalf("keygen failed: %v", err)
+	}
+
+	schemaFile := filepath.Join(dir, "schema.json")
+	writeJSON(t, schemaFile, map[string]interface{}{
+		"attributes": []interface{}{
+			"name",
+			map[string]string{"name": "birthdate", "type": "time"},
+			map[string]string{"name": "points", "type": "int64"},
+			map[string]string{"name": "member", "type": "bool", "encoding": "hash"},
+		},
+	})
+	attributesFile := filepath.Join(dir, "attributes.json")
+	writeJSON(t, attributesFile, map[string]interface{}{
+		"name":      "Alice",
+		"birthdate": "1990-01-01T00:00:00+02:00",
+		"points":    1200,
+		"member":    true,
+	})
+
+	credentialFile := filepath.Join(dir, "credential.json")
+	err := cmdIssueCredential([]string{"-key", keyFile, "-schema", schemaFile, "-attributes", attributesFile, "-output", credentialFile})
+	if err != nil {
+		t.Fatalf("issue failed: %v", err)
+	}
+	if err := cmdVerifyCredential([]string{"-credential", credentialFile}); err != nil {
+		t.Fatalf("verify failed: %v", err)
+	}
+
+	data, err := ioutil.ReadFile(credentialFile)
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	var credential Credential
+	if err := json.Unmarshal(data, &credential); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if credential.Messages["birthdate"] != "1989-12-31T22:00:00Z" || credential.Types["points"].Encoding != credpkg.EncodingInteger {
+		t.Fatalf("unexpected typed credential: %v %v", credential.Messages, credential.Types)
+	}
+	if _, ok := credential.Types["name"]; ok {
+		t.Fatalf("string attribute recorded a type")
+	}
+
+	proofFile := filepath.Join(dir, "proof.json")
+	if err := cmdCreateProof([]string{"-credential", credentialFile, "-disclose", "points,member", "-output", proofFile}); err != nil {
+		t.Fatalf("prove failed: %v", err)
+	}
+	if err := cmdVerifyProof([]string{"-proof", proofFile, "-schema", schemaFile}); err != nil {
+		t.Fatalf("verify-proof failed: %v", err)
+	}
+
+	// The integer-encoded value is read back from its message
+	disclosed, err := loadProof(t, proofFile).disclosedMessageMap(4)
+	if err != nil {
+		t.Fatalf("disclosedMessageMap failed: %v", err)
+	}
+	points, err := credpkg.DecodeAttribute(credpkg.AttributeSpec{Name: "points", Type: credpkg.TypeInt, Encoding: credpkg.EncodingInteger}, disclosed[2])
+	if err != nil || points.Value() != int64(1200) {
+		t.Fatalf("DecodeAttribute = %v, %v", points.Value(), err)
+	}
+
+	// The proof is bound to the schema it was issued under, so even a schema
+	// with the same order but no types is another schema
+	otherSchema := filepath.Join(dir, "other.json")
+	writeJSON(t, otherSchema, map[string]interface{}{
+		"attributes": []interface{}{"name", "birthdate", "points", "member"},
+	})
+	if err := cmdVerifyProof([]string{"-proof", proofFile, "-schema", otherSchema}); !errors.Is(err, credpkg.ErrSchemaMismatch) {
+		t.Fatalf("verify-proof with an untyped schema returned %v, want ErrSchemaMismatch", err)
+	}
+
+	// A schema declaring another type rejects the proof
+	writeJSON(t, otherSchema, map[string]interface{}{
+		"attributes": []interface{}{"name", "birthdate", map[string]string{"name": "points", "type": "string"}, "member"},
+	})
+	if err := cmdVerifyProof([]string{"-proof", proofFile, "-schema", otherSchema}); err == nil {
+		t.Fatal("verify-proof accepted a type the schema does not declare")
+	}
+}
+
+func TestFileAttributes(t *testing.T) {
+	dir := t.TempDir()
+	keyFile := filepath.Join(dir, "keypair.json")
+	if err := cmdKeyGen([]string{"-attributes", "3", "-output", keyFile}); err != nil {
+		t.Fatalf("keygen failed: %v", err)
+	}
+
+	photo := []byte{0x89, 'P', 'N', 'G', 0x00, 0xff}
+	photoFile := filepath.Join(dir, "photo.png")
+	if err := ioutil.WriteFile(photoFile, photo, 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	printFile := filepath.Join(dir, "print.bin")
+	if err := ioutil.WriteFile(printFile, []byte{1, 2, 3}, 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	schemaFile := filepath.Join(dir, "schema.json")
+	writeJSON(t, schemaFile, map[string]interface{}{
+		"attributes": []interface{}{"name", map[string]string{"name": "photo", "type": "bytes"}, "print"},
+	})
+	attributesFile := filepath.Join(dir, "attributes.json")
+	writeJSON(t, attributesFile, map[string]string{"name": "Alice"})
+
+	credentialFile := filepath.Join(dir, "credential.json")
+	err := cmdIssueCredential([]string{"-key", keyFile, "-schema", schemaFile, "-attributes", attributesFile,
+		"-files", "photo=" + photoFile + ",print=" + printFile, "-output", credentialFile})
+	if err != nil {
+		t.Fatalf("issue failed: %v", err)
+	}
+	if err := cmdVerifyCredential([]string{"-credential", credentialFile}); err != nil {
+		t.Fatalf("verify failed: %v", err)
+	}
+
+	// The raw bytes are signed, and stored as base64url
+	data, err := ioutil.ReadFile(credentialFile)
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	var credential Credential
+	if err := json.Unmarshal(data, &credential); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if credential.Messages["photo"] != base64.RawURLEncoding.EncodeToString(photo) || credential.Types["print"].Type != credpkg.TypeBytes {
+		t.Fatalf("unexpected file attributes: %v %v", credential.Messages, credential.Types)
+	}
+	want := bbs.BLS12381SHA256.MapMessageToScalar(photo)
+	messages, err := encodeAttributes(bbs.BLS12381SHA256, credential.Attributes, credential.Messages, credential.Types)
+	if err != nil {
+		t.Fatalf("encodeAttributes failed: %v", err)
+	}
+	if messages[1].Cmp(want) != 0 {
+		t.Fatal("photo was not signed as its raw bytes")
+	}
+
+	proofFile := filepath.Join(dir, "proof.json")
+	if err := cmdCreateProof([]string{"-credential", credentialFile, "-disclose", "photo", "-output", proofFile}); err != nil {
+		t.Fatalf("prove failed: %v", err)
+	}
+	if err := cmdVerifyProof([]string{"-proof", proofFile, "-schema", schemaFile}); err != nil {
+		t.Fatalf("verify-proof failed: %v", err)
+	}
+
+	// A file cannot fill an attribute declared with another type
+	writeJSON(t, schemaFile, map[string]interface{}{
+		"attributes": []interface{}{"name", map[string]string{"name": "photo", "type": "int64"}, "print"},
+	})
+	err = cmdIssueCredential([]string{"-key", keyFile, "-schema", schemaFile, "-attributes", attributesFile,
+		"-files", "photo=" + photoFile + ",print=" + printFile, "-output", credentialFile})
+	if err == nil {
+		t.Fatal("issue read a file into an int64 attribute")
+	}
+}
+
+// loadProof reads a proof file
+func loadProof(t *testing.T, path string) *CredentialProof {
+	t.Helper()
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	var proof CredentialProof
+	if err := json.Unmarshal(data, &proof); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	return &proof
+}
+
+func TestSchemaBinding(t *testing.T) {
+	dir := t.TempDir()
+	schema := map[string]interface{}{
+		"$id":        "https://example.gov/schemas/id",
+		"attributes": []string{"zip", "name", "country", "birthdate"},
+	}
+	credentialFile := issueTestCredential(t, dir, schema)
+	schemaFile := filepath.Join(dir, "schema.json")
+
+	proofFile := filepath.Join(dir, "proof.json")
+	if err := cmdCreateProof([]string{"-credential", credentialFile, "-disclose", "name", "-output", proofFile}); err != nil {
+		t.Fatalf("prove failed: %v", err)
+	}
+	proof := loadProof(t, proofFile)
+	if proof.SchemaHash == "" {
+		t.Fatal("proof of a schema bound credential records no schema hash")
+	}
+
+	// The verifier must supply the schema, as a file or its hash
+	if err := cmdVerifyProof([]string{"-proof", proofFile}); err == nil {
+		t.Fatal("verify-proof accepted a bound proof without a schema")
+	}
+	if err := cmdVerifyProof([]string{"-proof", proofFile, "-schema", schemaFile}); err != nil {
+		t.Fatalf("verify-proof with schema failed: %v", err)
+	}
+	if err := cmdVerifyProof([]string{"-proof", proofFile, "-schema-hash", proof.SchemaHash}); err != nil {
+		t.Fatalf("verify-proof with schema hash failed: %v", err)
+	}
+
+	// The same attribute order under another schema identifier is rejected
+	otherSchema := filepath.Join(dir, "other.json")
+	schema["$id"] = "https://example.gov/schemas/other"
+	writeJSON(t, otherSchema, schema)
+	if err := cmdVerifyProof([]string{"-proof", proofFile, "-schema", otherSchema}); !errors.Is(err, credpkg.ErrSchemaMismatch) {
+		t.Fatalf("verify-proof returned %v, want ErrSchemaMismatch", err)
+	}
+
+	// Stripping the schema hash from the proof leaves it unverifiable
+	proof.SchemaHash = ""
+	writeJSON(t, proofFile, proof)
+	if err := cmdVerifyProof([]string{"-proof", proofFile}); err == nil {
+		t.Fatal("verify-proof accepted a proof stripped of its schema hash")
+	}
+}
+
+func TestVerifyProofRejectsBadIndices(t *testing.T) {
+	dir := t.TempDir()
+	credentialFile := issueTestCredential(t, dir, nil)
+
+	proofFile := filepath.Join(dir, "proof.json")
+	err := cmdCreateProof([]string{"-credential", credentialFile, "-disclose", "country,zip", "-output", proofFile})
+	if err != nil {
+		t.Fatalf("prove failed: %v", err)
+	}
+
+	data, err := ioutil.ReadFile(proofFile)
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+
+	tampered := []struct {
+		name    string
+		indices map[string]int
+	}{
+		{"swapped", map[string]int{"country": 3, "zip": 1}},
+		{"missing", map[string]int{"country": 1}},
+		{"out of range", map[string]int{"country": 1, "zip": 4}},
+		{"duplicate", map[string]int{"country": 1, "zip": 1}},
+		{"legacy", nil},
+	}
+
+	for _, tc := range tampered {
+		var proof CredentialProof
+		if err := json.Unmarshal(data, &proof); err != nil {
+			t.Fatalf("Unmarshal failed: %v", err)
+		}
+		proof.DisclosedIndices = tc.indices
+
+		tamperedFile := filepath.Join(dir, "tampered.json")
+		writeJSON(t, tamperedFile, proof)
+		if err := cmdVerifyProof([]string{"-proof", tamperedFile}); err == nil {
+			t.Fatalf("%s: verify-proof accepted tampered indices", tc.name)
+		}
+	}
+
+	// Duplicate and unknown names are rejected when proving
+	for _, disclose := range []string{"zip,zip", "email"} {
+		err := cmdCreateProof([]string{"-credential", credentialFile, "-disclose", disclose, "-output", proofFile})
+		if err == nil {
+			t.Fatalf("prove accepted -disclose %q", disclose)
+		}
+	}
+}
+
+func TestBenchSchema(t *testing.T) {
+	dir := t.TempDir()
+	schemaFile := filepath.Join(dir, "schema.json")
+	writeJSON(t, schemaFile, map[string]interface{}{
+		"attributes": []string{"name", "birthdate", "country"},
+	})
+
+	err := cmdBench([]string{"-schema", schemaFile, "-disclose", "country", "-iterations", "1"})
+	if err != nil {
+		t.Fatalf("bench failed: %v", err)
+	}
+
+	if err := cmdBench([]string{"-schema", schemaFile, "-disclose", "email", "-iterations", "1"}); err == nil {
+		t.Fatal("bench accepted an attribute missing from the schema")
+	}
+
+	// Running out of time reports what was measured instead of failing
+	err = cmdBench([]string{"-attributes", "3", "-iterations", "1000", "-max-time", "1ns"})
+	if err != nil {
+		t.Fatalf("bench with an exhausted budget failed: %v", err)
+	}
+	err = cmdBench([]string{"-attributes", "3", "-iterations", "1000", "-op-timeout", "5ms"})
+	if err != nil {
+		t.Fatalf("bench with an operation timeout failed: %v", err)
+	}
+}
+
+func TestBenchHTMLReport(t *testing.T) {
+	reportFile := filepath.Join(t.TempDir(), "report.html")
+	err := cmdBench([]string{"-counts", "2,3", "-disclose-count", "1", "-iterations", "2", "-format", "html", "-output", reportFile})
+	if err != nil {
+		t.Fatalf("bench failed: %v", err)
+	}
+
+	page, err := ioutil.ReadFile(reportFile)
+	if err != nil {
+		t.Fatalf("failed to read report: %v", err)
+	}
+	for _, want := range []string{"<svg", "2 messages, 1 disclosed", "3 messages, 1 disclosed"} {
+		if !strings.Contains(string(page), want) {
+			t.Errorf("report lacks %q", want)
+		}
+	}
+
+	if err := cmdBench([]string{"-format", "pdf"}); err == nil {
+		t.Fatal("bench accepted an unknown format")
+	}
+}
+
+func TestBlocklist(t *testing.T) {
+	dir := t.TempDir()
+	files := []string{"-params", filepath.Join(dir, "blocklist.params"), "-list", filepath.Join(dir, "blocklist.bin")}
+	run := func(action string, extra ...string) error {
+		args := append([]string{action}, files...)
+		return cmdBlocklist(append(append(args, "-max", "4"), extra...))
+	}
+
+	if err := run("init", "P1234"); err != nil {
+		t.Fatalf("blocklist init failed: %v", err)
+	}
+	if err := run("add", "P5678"); err != nil {
+		t.Fatalf("blocklist add failed: %v", err)
+	}
+	if err := run("remove", "P9999"); err == nil {
+		t.Fatal("blocklist removed a value that is not on it")
+	}
+
+	paramsData, _ := ioutil.ReadFile(files[1])
+	params, err := bbs.DeserializeBlocklistParams(paramsData)
+	if err != nil {
+		t.Fatalf("DeserializeBlocklistParams failed: %v", err)
+	}
+	listData, _ := ioutil.ReadFile(files[3])
+	blocklist, err := bbs.DeserializeBlocklist(params, listData)
+	if err != nil {
+		t.Fatalf("DeserializeBlocklist failed: %v", err)
+	}
+	blocked := bbs.BLS12381SHA256.MapMessageToScalar([]byte("P5678"))
+	if blocklist.Version() != 2 || blocklist.Len() != 2 || !blocklist.Contains(blocked) {
+		t.Fatalf("Unexpected blocklist version %d with %d values", blocklist.Version(), blocklist.Len())
+	}
+
+	if err := cmdBlocklist([]string{"publish"}); err == nil {
+		t.Fatal("blocklist accepted an unknown action")
+	}
+}
+
+func TestLegacyMessageMapping(t *testing.T) {
+	dir := t.TempDir()
+	credentialFile := issueTestCredential(t, dir, nil)
+
+	var credential Credential
+	data, err := ioutil.ReadFile(credentialFile)
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	if err := json.Unmarshal(data, &credential); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if credential.Mapping != mappingHashToScalar {
+		t.Fatalf("credential issued with mapping %q", credential.Mapping)
+	}
+
+	// Re-sign the credential the way older versions did, without a mapping
+	keyFile, err := keys.Load(filepath.Join(dir, "keypair.json"), nil)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	keyPair, err := keyFile.KeyPair()
+	if err != nil {
+		t.Fatalf("KeyPair failed: %v", err)
+	}
+	messages, err := encodeAttributes(bbs.LegacySHA256, credential.Attributes, credential.Messages, credential.Types)
+	if err != nil {
+		t.Fatalf("encodeAttributes failed: %v", err)
+	}
+	signature, err := bbs.Sign(keyPair.PrivateKey, keyPair.PublicKey, messages, nil)
+	if err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+	signatureBytes, err := signature.MarshalBinary()
+	if err != nil {
+		t.Fatalf("MarshalBinary failed: %v", err)
+	}
+	credential.Signature = base64.StdEncoding.EncodeToString(signatureBytes)
+	credential.Mapping = ""
+
+	legacyFile := filepath.Join(dir, "legacy.json")
+	writeJSON(t, legacyFile, credential)
+
+	if err := cmdVerifyCredential([]string{"-credential", legacyFile}); err != nil {
+		t.Fatalf("verify of legacy credential failed: %v", err)
+	}
+	proofFile := filepath.Join(dir, "proof.json")
+	if err := cmdCreateProof([]string{"-credential", legacyFile, "-disclose", "name", "-output", proofFile}); err != nil {
+		t.Fatalf("prove failed: %v", err)
+	}
+	if err := cmdVerifyProof([]string{"-proof", proofFile}); err != nil {
+		t.Fatalf("verify-proof failed: %v", err)
+	}
+
+	// Claiming the new mapping for a legacy signature fails
+	credential.Mapping = mappingHashToScalar
+	writeJSON(t, legacyFile, credential)
+	if err := cmdVerifyCredential([]string{"-credential", legacyFile}); err == nil {
+		t.Fatal("legacy signature verified under hash-to-scalar")
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	dir := t.TempDir()
+	credentialFile := issueTestCredential(t, dir, nil)
+
+	newKeyFile := filepath.Join(dir, "newkey.json")
+	if err := cmdKeyGen([]string{"-attributes", "5", "-output", newKeyFile}); err != nil {
+		t.Fatalf("keygen failed: %v", err)
+	}
+	addedFile := filepath.Join(dir, "added.json")
+	writeJSON(t, addedFile, map[string]string{"email": "alice@example.com"})
+
+	outputDir := filepath.Join(dir, "migrated")
+	linksFile := filepath.Join(dir, "links.json")
+	err := cmdMigrate([]string{
+		"-old-key", filepath.Join(dir, "keypair.json"), "-key", newKeyFile, "-attributes", addedFile,
+		"-output-dir", outputDir, "-links", linksFile, credentialFile,
+	})
+	if err != nil {
+		t.Fatalf("migrate failed: %v", err)
+	}
+
+	migratedFile := filepath.Join(outputDir, "credential.json")
+	if err := cmdVerifyCredential([]string{"-credential", migratedFile}); err != nil {
+		t.Fatalf("verify of migrated credential failed: %v", err)
+	}
+	proofFile := filepath.Join(dir, "proof.json")
+	if err := cmdCreateProof([]string{"-credential", migratedFile, "-disclose", "email,name", "-output", proofFile}); err != nil {
+		t.Fatalf("prove failed: %v", err)
+	}
+	if err := cmdVerifyProof([]string{"-proof", proofFile}); err != nil {
+		t.Fatalf("verify-proof failed: %v", err)
+	}
+
+	// The link is signed by the old key and joins the two files
+	var links []*credpkg.MigrationLink
+	linksData, err := ioutil.ReadFile(linksFile)
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	if err := json.Unmarshal(linksData, &links); err != nil || len(links) != 1 {
+		t.Fatalf("Expected one migration link, got %d (%v)", len(links), err)
+	}
+	oldKey, err := keys.Load(filepath.Join(dir, "keypair.json"), nil)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	oldKeyPair, err := oldKey.KeyPair()
+	if err != nil {
+		t.Fatalf("KeyPair failed: %v", err)
+	}
+	oldData, _ := ioutil.ReadFile(credentialFile)
+	newData, _ := ioutil.ReadFile(migratedFile)
+	if err := links[0].VerifyEncoded(oldKeyPair.PublicKey, oldData, newData); err != nil {
+		t.Fatalf("VerifyEncoded failed: %v", err)
+	}
+
+	// Credentials of another key are refused
+	err = cmdMigrate([]string{
+		"-old-key", newKeyFile, "-key", newKeyFile, "-attributes", addedFile,
+		"-output-dir", outputDir, "-links", linksFile, credentialFile,
+	})
+	if err == nil {
+		t.Fatal("migrate accepted a credential of another key")
+	}
+}
+
+func TestInspect(t *testing.T) {
+	dir := t.TempDir()
+	credentialFile := issueTestCredential(t, dir, nil)
+	proofFile := filepath.Join(dir, "proof.json")
+	if err := cmdCreateProof([]string{"-credential", credentialFile, "-disclose", "country,zip", "-output", proofFile}); err != nil {
+		t.Fatalf("prove failed: %v", err)
+	}
+
+	kinds := map[string]string{
+		"keypair.json":    "key file",
+		"credential.json": "credential",
+		"proof.json":      "credential proof",
+	}
+	for name, kind := range kinds {
+		data, err := ioutil.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatalf("ReadFile failed: %v", err)
+		}
+		in, err := inspectArtifact(data)
+		if err != nil {
+			t.Fatalf("%s: inspect failed: %v", name, err)
+		}
+		if in.Kind != kind || in.failed() != 0 {
+			t.Fatalf("%s: expected a consistent %s, got %+v", name, kind, in)
+		}
+	}
+	if err := cmdInspect([]string{proofFile}); err != nil {
+		t.Fatalf("inspect failed: %v", err)
+	}
+
+	// The bare proof and public key, as base64 and multibase
+	proof := loadProof(t, proofFile)
+	in, err := inspectArtifact([]byte(proof.Proof + "\n"))
+	if err != nil || in.Kind != "proof" || in.Format != "base64" {
+		t.Fatalf("Expected a base64 proof, got %+v (%v)", in, err)
+	}
+	pkBytes, err := base64.StdEncoding.DecodeString(proof.PublicKey)
+	if err != nil {
+		t.Fatalf("Failed to decode public key: %v", err)
+	}
+	publicKey, err := bbs.DeserializePublicKey(pkBytes)
+	if err != nil {
+		t.Fatalf("Failed to deserialize public key: %v", err)
+	}
+	in, err = inspectArtifact([]byte(multiformat.FormatPublicKey(publicKey)))
+	if err != nil || in.Kind != "bbs-public-key" || in.failed() != 0 {
+		t.Fatalf("Expected a multibase public key, got %+v (%v)", in, err)
+	}
+
+	// A disclosed index that is also hidden is caught without the verifier
+	proof.DisclosedIndices["zip"] = 0
+	tampered := filepath.Join(dir, "tampered.json")
+	writeJSON(t, tampered, proof)
+	if err := cmdInspect([]string{tampered}); err == nil {
+		t.Fatalf("Expected inspect to fail for overlapping indices")
+	}
+
+	if _, err := inspectArtifact([]byte("not an artifact")); err == nil {
+		t.Fatalf("Expected an unrecognised artifact to fail")
+	}
+}
+
+func TestInspectJOSE(t *testing.T) {
+	keyPair, err := bbs.GenerateKeyPair(3, rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKeyPair failed: %v", err)
+	}
+	messages := []*big.Int{big.NewInt(1), big.NewInt(2), big.NewInt(3)}
+	signature, err := bbs.Sign(keyPair.PrivateKey, keyPair.PublicKey, messages, nil)
+	if err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+	proof, disclosed, err := bbs.CreateProof(keyPair.PublicKey, signature, messages, []int{1}, nil)
+	if err != nil {
+		t.Fatalf("CreateProof failed: %v", err)
+	}
+	_, deviceKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+
+	presentation := &jose.Presentation{Context: jose.Context{Audience: "rp"}, Proof: proof, Disclosed: disclosed}
+	token, _, err := jose.EncodePresentation(presentation, deviceKey, jose.EncodeOptions{})
+	if err != nil {
+		t.Fatalf("EncodePresentation failed: %v", err)
+	}
+	in, err := inspectArtifact([]byte(token))
+	if err != nil {
+		t.Fatalf("inspect failed: %v", err)
+	}
+	if in.Kind != "JWS "+jose.TypePresentation || in.failed() != 0 {
+		t.Fatalf("Expected a consistent presentation, got %+v", in)
+	}
+
+	attestation, err := jose.EncodeKeyAttestation(&jose.KeyAttestation{Issuer: "issuer", PublicKey: keyPair.PublicKey}, deviceKey, jose.EncodeOptions{})
+	if err != nil {
+		t.Fatalf("EncodeKeyAttestation failed: %v", err)
+	}
+	in, err = inspectArtifact([]byte(attestation))
+	if err != nil {
+		t.Fatalf("inspect failed: %v", err)
+	}
+	if in.Kind != "JWS "+jose.TypeKeyAttestation || in.failed() != 0 {
+		t.Fatalf("Expected a consistent key attestation, got %+v", in)
+	}
+}
+
+func TestStrictFiles(t *testing.T) {
+	dir := t.TempDir()
+	credentialFile := issueTestCredential(t, dir, nil)
+	proofFile := filepath.Join(dir, "proof.json")
+	if err := cmdCreateProof([]string{"-credential", credentialFile, "-disclose", "name", "-output", proofFile}); err != nil {
+		t.Fatalf("prove failed: %v", err)
+	}
+
+	// smuggle rewrites path with extra appended to its top-level object
+	smuggle := func(path, extra string) string {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatalf("ReadFile failed: %v", err)
+		}
+		out := filepath.Join(dir, "smuggled-"+filepath.Base(path))
+		doc := strings.TrimSpace(string(data))
+		if err := ioutil.WriteFile(out, []byte(doc[:len(doc)-1]+","+extra+"}"), 0644); err != nil {
+			t.Fatalf("WriteFile failed: %v", err)
+		}
+		return out
+	}
+
+	for _, extra := range []string{`"extra":true`, `"Issuer":"mallory"`, `"issuer":"mallory"`} {
+		credential := smuggle(credentialFile, extra)
+		if err := cmdVerifyCredential([]string{"-credential", credential}); !errors.Is(err, credpkg.ErrMalformedJSON) {
+			t.Fatalf("verify %s: expected ErrMalformedJSON, got %v", extra, err)
+		}
+		err := cmdCreateProof([]string{"-credential", credential, "-output", filepath.Join(dir, "p.json")})
+		if !errors.Is(err, credpkg.ErrMalformedJSON) {
+			t.Fatalf("prove %s: expected ErrMalformedJSON, got %v", extra, err)
+		}
+		if err := cmdVerifyProof([]string{"-proof", smuggle(proofFile, extra)}); !errors.Is(err, credpkg.ErrMalformedJSON) {
+			t.Fatalf("verify-proof %s: expected ErrMalformedJSON, got %v", extra, err)
+		}
+	}
+
+	// The opt-out reads files of older tools
+	if err := cmdVerifyCredential([]string{"-credential", smuggle(credentialFile, `"extra":true`), "-lenient"}); err != nil {
+		t.Fatalf("lenient verify failed: %v", err)
+	}
+	if err := cmdVerifyProof([]string{"-proof", smuggle(proofFile, `"extra":true`), "-lenient"}); err != nil {
+		t.Fatalf("lenient verify-proof failed: %v", err)
+	}
+}
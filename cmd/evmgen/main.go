@@ -0,0 +1,216 @@
+// Command evmgen prepares BBS+ public keys and proofs for on-chain
+// verification with the reference Solidity verifier
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/anupsv/bbsplus-signatures/bbs"
+	"github.com/anupsv/bbsplus-signatures/pkg/evm"
+	"github.com/anupsv/bbsplus-signatures/pkg/keys"
+)
+
+// Command represents a subcommand
+type Command struct {
+	Name        string
+	Description string
+	Execute     func(args []string) error
+}
+
+// DeploymentArgs holds the BBSVerifier constructor arguments
+type DeploymentArgs struct {
+	PublicKey    string `json:"publicKey"`
+	Domain       string `json:"domain"`
+	MessageCount int    `json:"messageCount"`
+}
+
+// ProofArgs holds the BBSVerifier.verifyProof arguments
+type ProofArgs struct {
+	Proof             string   `json:"proof"`
+	DisclosedIndices  []uint64 `json:"disclosedIndices"`
+	DisclosedMessages []string `json:"disclosedMessages"`
+}
+
+func main() {
+	// Define available commands
+	commands := []Command{
+		{
+			Name:        "pubkey",
+			Description: "Export a public key and domain as verifier constructor arguments",
+			Execute:     cmdPublicKey,
+		},
+		{
+			Name:        "proof",
+			Description: "Encode a proof as verifyProof calldata",
+			Execute:     cmdProof,
+		},
+		{
+			Name:        "solidity",
+			Description: "Write the reference Solidity verifier",
+			Execute:     cmdSolidity,
+		},
+	}
+
+	// Show help if no command provided
+	if len(os.Args) < 2 {
+		showHelp(commands)
+		os.Exit(1)
+	}
+
+	// Find and execute the requested command
+	cmdName := os.Args[1]
+	for _, cmd := range commands {
+		if cmd.Name == cmdName {
+			err := cmd.Execute(os.Args[2:])
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			os.Exit(0)
+		}
+	}
+
+	// Command not found
+	fmt.Fprintf(os.Stderr, "Unknown command: %s\n\n", cmdName)
+	showHelp(commands)
+	os.Exit(1)
+}
+
+// Display help information
+func showHelp(commands []Command) {
+	fmt.Println("BBS+ EVM Generator - Prepare BBS+ proofs for on-chain verification (EIP-2537)")
+	fmt.Println("\nUsage:")
+	fmt.Println("  evmgen <command> [options]")
+
+	fmt.Println("\nAvailable Commands:")
+	for _, cmd := range commands {
+		fmt.Printf("  %-12s %s\n", cmd.Name, cmd.Description)
+	}
+
+	fmt.Println("\nRun 'evmgen <command> -h' for more information about a command")
+}
+
+// Export public key command
+func cmdPublicKey(args []string) error {
+	// Parse flags
+	flagSet := flag.NewFlagSet("pubkey", flag.ExitOnError)
+	keyFile := flagSet.String("key", "keypair.json", "Key file of the issuer")
+	header := flagSet.String("header", "", "Header the credentials are signed with")
+	outputFile := flagSet.String("output", "", "Output file for the constructor arguments (optional)")
+	flagSet.Parse(args)
+
+	kf, err := keys.Load(*keyFile, nil)
+	if err != nil {
+		return fmt.Errorf("failed to load key file: %w", err)
+	}
+
+	keyPair, err := kf.KeyPair()
+	if err != nil {
+		return err
+	}
+
+	pkBytes, err := evm.EncodePublicKey(keyPair.PublicKey)
+	if err != nil {
+		return fmt.Errorf("failed to encode public key: %w", err)
+	}
+
+	var headerBytes []byte
+	if *header != "" {
+		headerBytes = []byte(*header)
+	}
+	domain := bbs.CalculateDomain(keyPair.PublicKey, headerBytes)
+
+	return writeJSON(*outputFile, DeploymentArgs{
+		PublicKey:    "0x" + hex.EncodeToString(pkBytes),
+		Domain:       "0x" + domain.Text(16),
+		MessageCount: keyPair.PublicKey.MessageCount(),
+	})
+}
+
+// Encode proof command
+func cmdProof(args []string) error {
+	// Parse flags
+	flagSet := flag.NewFlagSet("proof", flag.ExitOnError)
+	proofFile := flagSet.String("proof", "proof.bin", "Serialized proof (bbs.SerializeProof output)")
+	disclosedFile := flagSet.String("disclosed", "disclosed.json", "Disclosed messages as a JSON object of index to field element")
+	outputFile := flagSet.String("output", "", "Output file for the calldata (optional)")
+	flagSet.Parse(args)
+
+	proofData, err := os.ReadFile(*proofFile)
+	if err != nil {
+		return fmt.Errorf("failed to read proof file: %w", err)
+	}
+
+	proof, err := bbs.DeserializeProof(proofData)
+	if err != nil {
+		return fmt.Errorf("failed to deserialize proof: %w", err)
+	}
+
+	disclosedData, err := os.ReadFile(*disclosedFile)
+	if err != nil {
+		return fmt.Errorf("failed to read disclosed messages: %w", err)
+	}
+
+	var disclosed map[int]*big.Int
+	if err := json.Unmarshal(disclosedData, &disclosed); err != nil {
+		return fmt.Errorf("failed to parse disclosed messages: %w", err)
+	}
+
+	calldata, err := evm.EncodeProof(proof, disclosed)
+	if err != nil {
+		return fmt.Errorf("failed to encode proof: %w", err)
+	}
+
+	out := ProofArgs{
+		Proof:             "0x" + hex.EncodeToString(calldata.Proof),
+		DisclosedIndices:  calldata.DisclosedIndices,
+		DisclosedMessages: make([]string, len(calldata.DisclosedMessages)),
+	}
+	if out.DisclosedIndices == nil {
+		out.DisclosedIndices = []uint64{}
+	}
+	for i, msg := range calldata.DisclosedMessages {
+		out.DisclosedMessages[i] = "0x" + msg.Text(16)
+	}
+
+	return writeJSON(*outputFile, out)
+}
+
+// Write Solidity verifier command
+func cmdSolidity(args []string) error {
+	// Parse flags
+	flagSet := flag.NewFlagSet("solidity", flag.ExitOnError)
+	outputFile := flagSet.String("output", "BBSVerifier.sol", "Output file for the contract")
+	flagSet.Parse(args)
+
+	if err := os.WriteFile(*outputFile, []byte(evm.VerifierSource), 0644); err != nil {
+		return fmt.Errorf("failed to write contract: %w", err)
+	}
+
+	fmt.Printf("Verifier written to %s\n", *outputFile)
+	return nil
+}
+
+// writeJSON writes v as indented JSON to path, or to stdout if path is empty
+func writeJSON(path string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode JSON: %w", err)
+	}
+	data = append(data, '\n')
+
+	if path == "" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,362 @@
+// Command vectors writes reproducible BBS+ fixtures for interop debugging
+// and regression pinning.
+//
+// From a seed it derives a key pair, signs a set of messages and creates a
+// proof, and writes all of them as JSON together with every encoding this
+// module supports and the intermediate values other implementations most
+// often disagree on: the mapped messages, the domain, B, and the challenge
+// and Schnorr commitments of the proof. The same seed and parameters give
+// the same bytes on every platform.
+//
+// Usage:
+//
+//	vectors -seed interop-1 -messages 5 -disclose 0,2 -header h -output fixture.json
+//	vectors -check fixture.json
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"math/big"
+	"math/rand/v2"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/anupsv/bbsplus-signatures/bbs"
+	"github.com/anupsv/bbsplus-signatures/pkg/evm"
+	bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381"
+)
+
+// Params are the inputs a fixture is generated from
+type Params struct {
+	Seed               string `json:"seed"`
+	MessageCount       int    `json:"messageCount"`
+	Disclosed          []int  `json:"disclosed"`
+	Header             string `json:"header"`
+	PresentationHeader string `json:"presentationHeader"`
+}
+
+// Fixture is one complete set of test vectors. Byte strings are hex, points
+// compressed.
+type Fixture struct {
+	Params      Params           `json:"params"`
+	Ciphersuite string           `json:"ciphersuite"`
+	KeyPair     KeyFixture       `json:"keyPair"`
+	Messages    []MessageFixture `json:"messages"`
+	Signature   SignatureFixture `json:"signature"`
+	Proof       ProofFixture     `json:"proof"`
+}
+
+// KeyFixture holds the key pair in each encoding
+type KeyFixture struct {
+	PrivateKey  string   `json:"privateKey"`
+	PublicKey   string   `json:"publicKey"`
+	Fingerprint string   `json:"fingerprint"`
+	EVM         string   `json:"evm"`
+	Generators  []string `json:"generators"`
+}
+
+// MessageFixture is a message and the scalar it maps to
+type MessageFixture struct {
+	Text   string `json:"text"`
+	Scalar string `json:"scalar"`
+}
+
+// SignatureFixture holds the signature and the values it is computed from
+type SignatureFixture struct {
+	Encoded string `json:"encoded"`
+	A       string `json:"A"`
+	E       string `json:"e"`
+	S       string `json:"s"`
+	Domain  string `json:"domain"`
+	B       string `json:"B"`
+}
+
+// ProofFixture holds the proof in each encoding and its intermediate values
+type ProofFixture struct {
+	Compact    string            `json:"compact"`
+	Compressed string            `json:"compressed"`
+	EVM        string            `json:"evm"`
+	Disclosed  map[string]string `json:"disclosed"`
+	Challenge  string            `json:"challenge"`
+	T1         string            `json:"T1"`
+	T2         string            `json:"T2"`
+}
+
+func main() {
+	seed := flag.String("seed", "bbsplus-vectors", "Seed the keys and proof randomness are derived from")
+	messageCount := flag.Int("messages", 5, "Number of messages to sign")
+	disclose := flag.String("disclose", "0,2", "Comma-separated indices of the disclosed messages")
+	header := flag.String("header", "", "Signature header")
+	presentationHeader := flag.String("presentation-header", "", "Presentation header of the proof")
+	output := flag.String("output", "", "Output file (default stdout)")
+	check := flag.String("check", "", "Regenerate the fixture in this file and report any difference")
+	flag.Parse()
+
+	if *check != "" {
+		if err := checkFixture(*check); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("%s is reproduced exactly\n", *check)
+		return
+	}
+
+	disclosed, err := parseIndices(*disclose)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	fixture, err := Generate(Params{
+		Seed:               *seed,
+		MessageCount:       *messageCount,
+		Disclosed:          disclosed,
+		Header:             *header,
+		PresentationHeader: *presentationHeader,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if err := writeJSON(*output, fixture); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// Generate derives the fixture for p. Key generation and proof randomness
+// are drawn from ChaCha8 keyed with SHA-256 of the seed, and the signature
+// is deterministic.
+func Generate(p Params) (*Fixture, error) {
+	if p.MessageCount <= 0 {
+		return nil, fmt.Errorf("message count must be positive")
+	}
+	rng := rand.NewChaCha8(sha256.Sum256([]byte(p.Seed)))
+	header := []byte(p.Header)
+	presentationHeader := []byte(p.PresentationHeader)
+
+	keyPair, err := bbs.GenerateKeyPair(p.MessageCount, rng)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate key pair: %w", err)
+	}
+	pk := keyPair.PublicKey
+	evmKey, err := evm.EncodePublicKey(pk)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode public key: %w", err)
+	}
+
+	f := &Fixture{
+		Params:      p,
+		Ciphersuite: bbs.DefaultCiphersuite.ID,
+		KeyPair: KeyFixture{
+			PrivateKey:  hex.EncodeToString(bbs.SerializePrivateKey(keyPair.PrivateKey)),
+			PublicKey:   hex.EncodeToString(bbs.SerializePublicKey(pk)),
+			Fingerprint: pk.Fingerprint(),
+			EVM:         hex.EncodeToString(evmKey),
+		},
+	}
+	generators := pk.Generators()
+	for i := range generators {
+		f.KeyPair.Generators = append(f.KeyPair.Generators, g1Hex(&generators[i]))
+	}
+
+	messages := make([]*big.Int, p.MessageCount)
+	for i := range messages {
+		text := fmt.Sprintf("message-%d", i+1)
+		messages[i] = bbs.DefaultCiphersuite.MapMessageToScalar([]byte(text))
+		f.Messages = append(f.Messages, MessageFixture{Text: text, Scalar: scalarHex(messages[i])})
+	}
+
+	signature, err := bbs.DeterministicSign(keyPair.PrivateKey, pk, messages, header, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign: %w", err)
+	}
+	domain := bbs.CalculateDomain(pk, header)
+
+	// B = P1 + Q1*s + Q2*domain + sum(H_i * m_i)
+	points := []bls12381.G1Affine{pk.G1(), generators[0], generators[1]}
+	scalars := []*big.Int{big.NewInt(1), signature.S, domain}
+	for i, m := range messages {
+		points = append(points, generators[i+2])
+		scalars = append(scalars, m)
+	}
+	B, err := multiExp(points, scalars)
+	if err != nil {
+		return nil, err
+	}
+	f.Signature = SignatureFixture{
+		Encoded: hex.EncodeToString(bbs.SerializeSignature(signature)),
+		A:       g1Hex(&signature.A),
+		E:       scalarHex(signature.E),
+		S:       scalarHex(signature.S),
+		Domain:  scalarHex(domain),
+		B:       g1Hex(&B),
+	}
+
+	proof, disclosed, err := bbs.CreateProofWithRNG(pk, signature, messages, p.Disclosed, header, presentationHeader, rng)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create proof: %w", err)
+	}
+	if err := bbs.VerifyProofWithPresentationHeader(pk, proof, disclosed, header, presentationHeader); err != nil {
+		return nil, fmt.Errorf("generated proof does not verify: %w", err)
+	}
+	calldata, err := evm.EncodeProof(proof, disclosed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode proof: %w", err)
+	}
+
+	T1, T2, err := proofCommitments(pk, proof, disclosed, domain)
+	if err != nil {
+		return nil, err
+	}
+	f.Proof = ProofFixture{
+		Compact:    hex.EncodeToString(bbs.SerializeProof(proof)),
+		Compressed: hex.EncodeToString(bbs.SerializeCompressedProof(proof)),
+		EVM:        hex.EncodeToString(calldata.Proof),
+		Disclosed:  make(map[string]string, len(disclosed)),
+		Challenge:  scalarHex(proof.C),
+		T1:         g1Hex(&T1),
+		T2:         g1Hex(&T2),
+	}
+	for idx, m := range disclosed {
+		f.Proof.Disclosed[strconv.Itoa(idx)] = scalarHex(m)
+	}
+
+	return f, nil
+}
+
+// proofCommitments recomputes the Schnorr commitments the challenge hashes:
+//
+//	T1 = A-bar*c + A'*e^ + D*r1^
+//	T2 = (P1 + Q2*domain + sum(H_i * m_i))*c + D*r3^ + Q1*s^ + sum(H_j * m^_j)
+func proofCommitments(pk *bbs.PublicKey, proof *bbs.ProofOfKnowledge, disclosed map[int]*big.Int, domain *big.Int) (bls12381.G1Affine, bls12381.G1Affine, error) {
+	T1, err := multiExp(
+		[]bls12381.G1Affine{proof.ABar, proof.APrime, proof.D},
+		[]*big.Int{proof.C, proof.EHat, proof.R1Hat},
+	)
+	if err != nil {
+		return T1, T1, err
+	}
+
+	mulC := func(x *big.Int) *big.Int {
+		y := new(big.Int).Mul(x, proof.C)
+		return y.Mod(y, bbs.Order)
+	}
+	points := []bls12381.G1Affine{pk.G1(), pk.Generator(1), proof.D, pk.Generator(0)}
+	scalars := []*big.Int{proof.C, mulC(domain), proof.R3Hat, proof.SHat}
+	for i := 0; i < pk.MessageCount(); i++ {
+		points = append(points, pk.Generator(i+2))
+		if m, ok := disclosed[i]; ok {
+			scalars = append(scalars, mulC(m))
+		} else {
+			scalars = append(scalars, proof.MHat[i])
+		}
+	}
+	T2, err := multiExp(points, scalars)
+	return T1, T2, err
+}
+
+// multiExp returns sum(points[i] * scalars[i]) in affine form
+func multiExp(points []bls12381.G1Affine, scalars []*big.Int) (bls12381.G1Affine, error) {
+	var result bls12381.G1Affine
+	sum, err := bbs.MultiScalarMulG1(points, scalars)
+	if err != nil {
+		return result, err
+	}
+	result.FromJacobian(&sum)
+	return result, nil
+}
+
+// checkFixture regenerates the fixture in path from its params and compares
+// section by section
+func checkFixture(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	var pinned Fixture
+	if err := json.Unmarshal(data, &pinned); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	fresh, err := Generate(pinned.Params)
+	if err != nil {
+		return err
+	}
+
+	var differ []string
+	for _, section := range []struct {
+		name        string
+		pinned, now any
+	}{
+		{"ciphersuite", pinned.Ciphersuite, fresh.Ciphersuite},
+		{"keyPair", pinned.KeyPair, fresh.KeyPair},
+		{"messages", pinned.Messages, fresh.Messages},
+		{"signature", pinned.Signature, fresh.Signature},
+		{"proof", pinned.Proof, fresh.Proof},
+	} {
+		a, _ := json.Marshal(section.pinned)
+		b, _ := json.Marshal(section.now)
+		if !bytes.Equal(a, b) {
+			differ = append(differ, section.name)
+		}
+	}
+	if len(differ) > 0 {
+		return fmt.Errorf("%s differs from the regenerated fixture in: %s", path, strings.Join(differ, ", "))
+	}
+	return nil
+}
+
+// parseIndices parses comma-separated message indices
+func parseIndices(s string) ([]int, error) {
+	indices := []int{}
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		idx, err := strconv.Atoi(part)
+		if err != nil {
+			return nil, fmt.Errorf("invalid index '%s'", part)
+		}
+		indices = append(indices, idx)
+	}
+	return indices, nil
+}
+
+// scalarHex encodes a scalar as 32 bytes of hex
+func scalarHex(x *big.Int) string {
+	var buf [bbs.ScalarSize]byte
+	x.FillBytes(buf[:])
+	return hex.EncodeToString(buf[:])
+}
+
+// g1Hex encodes a point compressed in hex
+func g1Hex(p *bls12381.G1Affine) string {
+	b := p.Bytes()
+	return hex.EncodeToString(b[:])
+}
+
+// writeJSON writes v as indented JSON to path, or to stdout if path is empty
+func writeJSON(path string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode JSON: %w", err)
+	}
+	data = append(data, '\n')
+
+	if path == "" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
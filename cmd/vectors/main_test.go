@@ -0,0 +1,59 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestGenerateReproducible(t *testing.T) {
+	params := Params{Seed: "test", MessageCount: 4, Disclosed: []int{0, 3}, Header: "h", PresentationHeader: "ph"}
+
+	first, err := Generate(params)
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	second, err := Generate(params)
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	if !reflect.DeepEqual(first, second) {
+		t.Fatalf("Expected the same seed to reproduce the fixture")
+	}
+
+	params.Seed = "other"
+	third, err := Generate(params)
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	if third.KeyPair.PublicKey == first.KeyPair.PublicKey || third.Proof.Compact == first.Proof.Compact {
+		t.Fatalf("Expected another seed to give another fixture")
+	}
+}
+
+func TestCheckFixture(t *testing.T) {
+	fixture, err := Generate(Params{Seed: "pinned", MessageCount: 3, Disclosed: []int{1}})
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "fixture.json")
+	if err := writeJSON(path, fixture); err != nil {
+		t.Fatalf("writeJSON failed: %v", err)
+	}
+	if err := checkFixture(path); err != nil {
+		t.Fatalf("checkFixture failed: %v", err)
+	}
+
+	// A pinned value that no longer matches is reported by section
+	fixture.Signature.Domain = strings.Repeat("00", 32)
+	data, _ := json.Marshal(fixture)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	if err := checkFixture(path); err == nil || !strings.Contains(err.Error(), "signature") {
+		t.Fatalf("Expected the signature section to differ, got %v", err)
+	}
+}
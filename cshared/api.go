@@ -0,0 +1,162 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"math/big"
+
+	"github.com/anupsv/bbsplus-signatures/bbs"
+	"github.com/anupsv/bbsplus-signatures/internal/version"
+)
+
+// Error codes of the C API. The values match bbsplus.h.
+const (
+	codeOK                 = 0
+	codeInvalidArgument    = 1
+	codeInvalidKey         = 2
+	codeInvalidSignature   = 3
+	codeInvalidProof       = 4
+	codeVerificationFailed = 5
+	codeInternal           = 6
+)
+
+// abiVersion is BBS_ABI_VERSION
+const abiVersion = 1
+
+// versionJSON returns the build metadata as JSON
+func versionJSON() string {
+	out, err := json.Marshal(version.Get())
+	if err != nil {
+		return "{}"
+	}
+	return string(out)
+}
+
+// errorMessages describes the error codes
+var errorMessages = map[int]string{
+	codeOK:                 "ok",
+	codeInvalidArgument:    "invalid argument",
+	codeInvalidKey:         "invalid key",
+	codeInvalidSignature:   "invalid signature encoding",
+	codeInvalidProof:       "invalid proof encoding",
+	codeVerificationFailed: "verification failed",
+	codeInternal:           "internal error",
+}
+
+// The functions below implement the C API on Go types; ffi.go converts the
+// C buffers. Each returns an error code alongside its result.
+
+// keygen generates a key pair
+func keygen(messageCount int) ([]byte, []byte, int) {
+	if messageCount <= 0 {
+		return nil, nil, codeInvalidArgument
+	}
+
+	keyPair, err := bbs.GenerateKeyPair(messageCount, rand.Reader)
+	if err != nil {
+		return nil, nil, codeInternal
+	}
+
+	return bbs.SerializePrivateKey(keyPair.PrivateKey), bbs.SerializePublicKey(keyPair.PublicKey), codeOK
+}
+
+// sign signs raw messages
+func sign(secretKey, publicKey []byte, messages [][]byte, header []byte) ([]byte, int) {
+	sk, err := bbs.DeserializePrivateKey(secretKey)
+	if err != nil {
+		return nil, codeInvalidKey
+	}
+	pk, err := bbs.DeserializePublicKey(publicKey)
+	if err != nil {
+		return nil, codeInvalidKey
+	}
+	if len(messages) != pk.MessageCount() {
+		return nil, codeInvalidArgument
+	}
+
+	signature, err := bbs.Sign(sk, pk, encodeMessages(messages), header)
+	if err != nil {
+		return nil, codeInternal
+	}
+
+	return bbs.SerializeSignature(signature), codeOK
+}
+
+// verify verifies a signature on raw messages
+func verify(publicKey, signature []byte, messages [][]byte, header []byte) int {
+	pk, err := bbs.DeserializePublicKey(publicKey)
+	if err != nil {
+		return codeInvalidKey
+	}
+	sig, err := bbs.DeserializeSignature(signature)
+	if err != nil {
+		return codeInvalidSignature
+	}
+	if len(messages) != pk.MessageCount() {
+		return codeInvalidArgument
+	}
+
+	if err := bbs.Verify(pk, sig, encodeMessages(messages), header); err != nil {
+		return codeVerificationFailed
+	}
+	return codeOK
+}
+
+// createProof creates a proof disclosing the messages at disclosedIndices
+func createProof(publicKey, signature []byte, messages [][]byte, disclosedIndices []int, header []byte) ([]byte, int) {
+	pk, err := bbs.DeserializePublicKey(publicKey)
+	if err != nil {
+		return nil, codeInvalidKey
+	}
+	sig, err := bbs.DeserializeSignature(signature)
+	if err != nil {
+		return nil, codeInvalidSignature
+	}
+	if len(messages) != pk.MessageCount() {
+		return nil, codeInvalidArgument
+	}
+
+	proof, _, err := bbs.CreateProof(pk, sig, encodeMessages(messages), disclosedIndices, header)
+	if err != nil {
+		return nil, codeInvalidArgument
+	}
+
+	return bbs.SerializeProof(proof), codeOK
+}
+
+// verifyProof verifies a proof against raw disclosed messages
+func verifyProof(publicKey, proof []byte, disclosedIndices []int, disclosedMessages [][]byte, header []byte) int {
+	pk, err := bbs.DeserializePublicKey(publicKey)
+	if err != nil {
+		return codeInvalidKey
+	}
+	p, err := bbs.DeserializeProof(proof)
+	if err != nil {
+		return codeInvalidProof
+	}
+	if len(disclosedIndices) != len(disclosedMessages) {
+		return codeInvalidArgument
+	}
+
+	disclosed := make(map[int]*big.Int, len(disclosedIndices))
+	for i, idx := range disclosedIndices {
+		if _, dup := disclosed[idx]; dup {
+			return codeInvalidArgument
+		}
+		disclosed[idx] = bbs.MessageToFieldElement(disclosedMessages[i])
+	}
+
+	if err := bbs.VerifyProof(pk, p, disclosed, header); err != nil {
+		return codeVerificationFailed
+	}
+	return codeOK
+}
+
+// encodeMessages maps raw messages to field elements
+func encodeMessages(messages [][]byte) []*big.Int {
+	encoded := make([]*big.Int, len(messages))
+	for i, msg := range messages {
+		encoded[i] = bbs.MessageToFieldElement(msg)
+	}
+	return encoded
+}
@@ -0,0 +1,51 @@
+package main
+
+import "testing"
+
+func TestAPI(t *testing.T) {
+	messages := [][]byte{[]byte("alice"), []byte("1990-01-01"), []byte("NL")}
+	header := []byte("header")
+
+	sk, pk, code := keygen(3)
+	if code != codeOK {
+		t.Fatalf("keygen failed: %s", errorMessages[code])
+	}
+
+	signature, code := sign(sk, pk, messages, header)
+	if code != codeOK {
+		t.Fatalf("sign failed: %s", errorMessages[code])
+	}
+	if code := verify(pk, signature, messages, header); code != codeOK {
+		t.Fatalf("verify failed: %s", errorMessages[code])
+	}
+
+	proof, code := createProof(pk, signature, messages, []int{2, 0}, header)
+	if code != codeOK {
+		t.Fatalf("createProof failed: %s", errorMessages[code])
+	}
+	if code := verifyProof(pk, proof, []int{2, 0}, [][]byte{messages[2], messages[0]}, header); code != codeOK {
+		t.Fatalf("verifyProof failed: %s", errorMessages[code])
+	}
+
+	// Failures map to their error codes
+	tests := []struct {
+		name string
+		code int
+		want int
+	}{
+		{"keygen without messages", func() int { _, _, c := keygen(0); return c }(), codeInvalidArgument},
+		{"sign with a bad key", func() int { _, c := sign([]byte{}, pk, messages, nil); return c }(), codeInvalidKey},
+		{"sign too few messages", func() int { _, c := sign(sk, pk, messages[:2], nil); return c }(), codeInvalidArgument},
+		{"verify wrong header", verify(pk, signature, messages, nil), codeVerificationFailed},
+		{"verify bad signature", verify(pk, signature[:10], messages, header), codeInvalidSignature},
+		{"prove duplicate index", func() int { _, c := createProof(pk, signature, messages, []int{1, 1}, header); return c }(), codeInvalidArgument},
+		{"verify proof bad encoding", verifyProof(pk, proof[:20], nil, nil, header), codeInvalidProof},
+		{"verify proof wrong message", verifyProof(pk, proof, []int{2, 0}, [][]byte{messages[0], messages[2]}, header), codeVerificationFailed},
+		{"verify proof mismatched lists", verifyProof(pk, proof, []int{2, 0}, messages[:1], header), codeInvalidArgument},
+	}
+	for _, tt := range tests {
+		if tt.code != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, errorMessages[tt.code], errorMessages[tt.want])
+		}
+	}
+}
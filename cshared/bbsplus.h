@@ -0,0 +1,103 @@
+/*
+ * C API of the BBS+ signatures library.
+ *
+ * Keys, signatures and proofs are passed as byte buffers in the library's
+ * wire formats. Messages are raw bytes and are mapped to field elements
+ * inside the library, the same way the Go API's MessageToFieldElement does.
+ *
+ * Every function returns BBS_OK or an error code; bbs_error_message turns a
+ * code into a static description. Output buffers are allocated by the
+ * library and must be released with bbs_buffer_free.
+ */
+
+#ifndef BBSPLUS_H
+#define BBSPLUS_H
+
+#include <stddef.h>
+#include <stdint.h>
+
+#ifdef __cplusplus
+extern "C" {
+#endif
+
+/* Version of this API. It changes only on incompatible changes. */
+#define BBS_ABI_VERSION 1
+
+/* Error codes */
+#define BBS_OK 0
+#define BBS_ERR_INVALID_ARGUMENT 1
+#define BBS_ERR_INVALID_KEY 2
+#define BBS_ERR_INVALID_SIGNATURE 3
+#define BBS_ERR_INVALID_PROOF 4
+#define BBS_ERR_VERIFICATION_FAILED 5
+#define BBS_ERR_INTERNAL 6
+
+/* A byte buffer. Output buffers are allocated by the library and the
+ * caller releases them with bbs_buffer_free. */
+typedef struct {
+	uint8_t *data;
+	size_t len;
+} bbs_buffer;
+
+/* The library's own build only needs the types and constants above */
+#ifndef BBS_BUILDING_LIBRARY
+
+/* bbs_abi_version returns BBS_ABI_VERSION of the loaded library */
+int bbs_abi_version(void);
+
+/* bbs_version returns the build metadata of the loaded library as a JSON
+ * object with version, commit, buildDate, goVersion and ciphersuites. The
+ * string is static. */
+const char *bbs_version(void);
+
+/* bbs_error_message describes an error code. The string is static. */
+const char *bbs_error_message(int code);
+
+/* bbs_buffer_free releases an output buffer and resets it to empty */
+void bbs_buffer_free(bbs_buffer *buf);
+
+/* bbs_keygen generates a key pair for message_count messages */
+int bbs_keygen(size_t message_count, bbs_buffer *secret_key, bbs_buffer *public_key);
+
+/* bbs_sign signs message_count messages. header may be NULL. */
+int bbs_sign(
+	const uint8_t *secret_key, size_t secret_key_len,
+	const uint8_t *public_key, size_t public_key_len,
+	const bbs_buffer *messages, size_t message_count,
+	const uint8_t *header, size_t header_len,
+	bbs_buffer *signature);
+
+/* bbs_verify returns BBS_OK for a valid signature and
+ * BBS_ERR_VERIFICATION_FAILED for an invalid one */
+int bbs_verify(
+	const uint8_t *public_key, size_t public_key_len,
+	const uint8_t *signature, size_t signature_len,
+	const bbs_buffer *messages, size_t message_count,
+	const uint8_t *header, size_t header_len);
+
+/* bbs_create_proof creates a proof disclosing the messages at
+ * disclosed_indices, which must be distinct and in range */
+int bbs_create_proof(
+	const uint8_t *public_key, size_t public_key_len,
+	const uint8_t *signature, size_t signature_len,
+	const bbs_buffer *messages, size_t message_count,
+	const size_t *disclosed_indices, size_t disclosed_count,
+	const uint8_t *header, size_t header_len,
+	bbs_buffer *proof);
+
+/* bbs_verify_proof verifies a proof against the disclosed messages, given as
+ * parallel arrays of indices and raw messages. It returns BBS_OK for a valid
+ * proof and BBS_ERR_VERIFICATION_FAILED for an invalid one. */
+int bbs_verify_proof(
+	const uint8_t *public_key, size_t public_key_len,
+	const uint8_t *proof, size_t proof_len,
+	const size_t *disclosed_indices, const bbs_buffer *disclosed_messages, size_t disclosed_count,
+	const uint8_t *header, size_t header_len);
+
+#endif /* BBS_BUILDING_LIBRARY */
+
+#ifdef __cplusplus
+}
+#endif
+
+#endif /* BBSPLUS_H */
@@ -0,0 +1,62 @@
+// Signs three messages, proves knowledge of the signature disclosing the
+// second one and verifies the proof through the C API. Built and run by
+// `make test`.
+
+#include <stdio.h>
+#include <string.h>
+
+#include "bbsplus.h"
+
+#define CHECK(call)                                                          \
+	do {                                                                     \
+		int code = (call);                                                   \
+		if (code != BBS_OK) {                                                \
+			fprintf(stderr, "%s: %s\n", #call, bbs_error_message(code)); \
+			return 1;                                                        \
+		}                                                                    \
+	} while (0)
+
+static bbs_buffer message(const char *s) {
+	bbs_buffer buf = {(uint8_t *)s, strlen(s)};
+	return buf;
+}
+
+int main(void) {
+	if (bbs_abi_version() != BBS_ABI_VERSION) {
+		fprintf(stderr, "library ABI version %d, header %d\n", bbs_abi_version(), BBS_ABI_VERSION);
+		return 1;
+	}
+	printf("libbbsplus %s\n", bbs_version());
+
+	bbs_buffer messages[3] = {message("alice"), message("1990-01-01"), message("NL")};
+	const uint8_t header[] = "example header";
+	size_t header_len = sizeof(header) - 1;
+
+	bbs_buffer sk = {0}, pk = {0}, sig = {0}, proof = {0};
+	CHECK(bbs_keygen(3, &sk, &pk));
+	CHECK(bbs_sign(sk.data, sk.len, pk.data, pk.len, messages, 3, header, header_len, &sig));
+	CHECK(bbs_verify(pk.data, pk.len, sig.data, sig.len, messages, 3, header, header_len));
+
+	size_t disclosed[1] = {1};
+	CHECK(bbs_create_proof(pk.data, pk.len, sig.data, sig.len, messages, 3, disclosed, 1,
+		header, header_len, &proof));
+	CHECK(bbs_verify_proof(pk.data, pk.len, proof.data, proof.len, disclosed, &messages[1], 1,
+		header, header_len));
+
+	// A different disclosed message must not verify
+	bbs_buffer wrong = message("1970-01-01");
+	int code = bbs_verify_proof(pk.data, pk.len, proof.data, proof.len, disclosed, &wrong, 1,
+		header, header_len);
+	if (code != BBS_ERR_VERIFICATION_FAILED) {
+		fprintf(stderr, "wrong message: got %d (%s)\n", code, bbs_error_message(code));
+		return 1;
+	}
+
+	printf("signature %zu bytes, proof %zu bytes: ok\n", sig.len, proof.len);
+
+	bbs_buffer_free(&sk);
+	bbs_buffer_free(&pk);
+	bbs_buffer_free(&sig);
+	bbs_buffer_free(&proof);
+	return 0;
+}
@@ -0,0 +1,204 @@
+//go:build cgo
+
+package main
+
+/*
+#include <stdlib.h>
+
+#define BBS_BUILDING_LIBRARY
+#include "bbsplus.h"
+*/
+import "C"
+
+import (
+	"sync"
+	"unsafe"
+
+	"github.com/anupsv/bbsplus-signatures/internal/secret"
+)
+
+// Input buffers are copied into Go memory before use, and outputs are
+// copied into C.malloc'd memory, so no Go pointer crosses the boundary.
+
+//export bbs_abi_version
+func bbs_abi_version() C.int {
+	return abiVersion
+}
+
+//export bbs_version
+func bbs_version() *C.char {
+	return cStrings.get(versionJSON())
+}
+
+//export bbs_error_message
+func bbs_error_message(code C.int) *C.char {
+	msg, ok := errorMessages[int(code)]
+	if !ok {
+		msg = "unknown error"
+	}
+	return cStrings.get(msg)
+}
+
+//export bbs_buffer_free
+func bbs_buffer_free(buf *C.bbs_buffer) {
+	if buf == nil {
+		return
+	}
+	C.free(unsafe.Pointer(buf.data))
+	buf.data = nil
+	buf.len = 0
+}
+
+//export bbs_keygen
+func bbs_keygen(messageCount C.size_t, secretKey, publicKey *C.bbs_buffer) C.int {
+	if secretKey == nil || publicKey == nil {
+		return codeInvalidArgument
+	}
+
+	sk, pk, code := keygen(int(messageCount))
+	if code != codeOK {
+		return C.int(code)
+	}
+
+	setBuffer(secretKey, sk)
+	setBuffer(publicKey, pk)
+	secret.WipeBytes(sk)
+	return codeOK
+}
+
+//export bbs_sign
+func bbs_sign(
+	secretKey *C.uint8_t, secretKeyLen C.size_t,
+	publicKey *C.uint8_t, publicKeyLen C.size_t,
+	messages *C.bbs_buffer, messageCount C.size_t,
+	header *C.uint8_t, headerLen C.size_t,
+	signature *C.bbs_buffer,
+) C.int {
+	if signature == nil {
+		return codeInvalidArgument
+	}
+
+	sk := goBytes(secretKey, secretKeyLen)
+	defer secret.WipeBytes(sk)
+
+	sig, code := sign(sk, goBytes(publicKey, publicKeyLen), goMessages(messages, messageCount),
+		goBytes(header, headerLen))
+	if code != codeOK {
+		return C.int(code)
+	}
+
+	setBuffer(signature, sig)
+	return codeOK
+}
+
+//export bbs_verify
+func bbs_verify(
+	publicKey *C.uint8_t, publicKeyLen C.size_t,
+	signature *C.uint8_t, signatureLen C.size_t,
+	messages *C.bbs_buffer, messageCount C.size_t,
+	header *C.uint8_t, headerLen C.size_t,
+) C.int {
+	return C.int(verify(goBytes(publicKey, publicKeyLen), goBytes(signature, signatureLen),
+		goMessages(messages, messageCount), goBytes(header, headerLen)))
+}
+
+//export bbs_create_proof
+func bbs_create_proof(
+	publicKey *C.uint8_t, publicKeyLen C.size_t,
+	signature *C.uint8_t, signatureLen C.size_t,
+	messages *C.bbs_buffer, messageCount C.size_t,
+	disclosedIndices *C.size_t, disclosedCount C.size_t,
+	header *C.uint8_t, headerLen C.size_t,
+	proof *C.bbs_buffer,
+) C.int {
+	if proof == nil {
+		return codeInvalidArgument
+	}
+
+	p, code := createProof(goBytes(publicKey, publicKeyLen), goBytes(signature, signatureLen),
+		goMessages(messages, messageCount), goIndices(disclosedIndices, disclosedCount),
+		goBytes(header, headerLen))
+	if code != codeOK {
+		return C.int(code)
+	}
+
+	setBuffer(proof, p)
+	return codeOK
+}
+
+//export bbs_verify_proof
+func bbs_verify_proof(
+	publicKey *C.uint8_t, publicKeyLen C.size_t,
+	proof *C.uint8_t, proofLen C.size_t,
+	disclosedIndices *C.size_t, disclosedMessages *C.bbs_buffer, disclosedCount C.size_t,
+	header *C.uint8_t, headerLen C.size_t,
+) C.int {
+	return C.int(verifyProof(goBytes(publicKey, publicKeyLen), goBytes(proof, proofLen),
+		goIndices(disclosedIndices, disclosedCount), goMessages(disclosedMessages, disclosedCount),
+		goBytes(header, headerLen)))
+}
+
+// goBytes copies a C byte array
+func goBytes(data *C.uint8_t, n C.size_t) []byte {
+	if data == nil || n == 0 {
+		return nil
+	}
+	return C.GoBytes(unsafe.Pointer(data), C.int(n))
+}
+
+// goMessages copies an array of message buffers
+func goMessages(messages *C.bbs_buffer, n C.size_t) [][]byte {
+	if messages == nil || n == 0 {
+		return nil
+	}
+
+	buffers := unsafe.Slice(messages, int(n))
+	result := make([][]byte, len(buffers))
+	for i, buf := range buffers {
+		result[i] = goBytes(buf.data, buf.len)
+	}
+	return result
+}
+
+// goIndices copies an array of indices
+func goIndices(indices *C.size_t, n C.size_t) []int {
+	if indices == nil || n == 0 {
+		return nil
+	}
+
+	values := unsafe.Slice(indices, int(n))
+	result := make([]int, len(values))
+	for i, v := range values {
+		result[i] = int(v)
+	}
+	return result
+}
+
+// setBuffer copies data into a new C buffer
+func setBuffer(buf *C.bbs_buffer, data []byte) {
+	buf.data = (*C.uint8_t)(C.CBytes(data))
+	buf.len = C.size_t(len(data))
+}
+
+// cStrings holds the C copies of the error messages, which live as long as
+// the library
+var cStrings = &stringTable{strings: make(map[string]*C.char)}
+
+// stringTable interns C strings
+type stringTable struct {
+	mu      sync.Mutex
+	strings map[string]*C.char
+}
+
+// get returns the C copy of s, allocating it on first use
+func (st *stringTable) get(s string) *C.char {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	cs, ok := st.strings[s]
+	if !ok {
+		cs = C.CString(s)
+		st.strings[s] = cs
+	}
+	return cs
+}
@@ -0,0 +1,6 @@
+// Package main builds the BBS+ C shared library (libbbsplus) for FFI
+// consumers. bbsplus.h declares the API; see README.md for building.
+package main
+
+// main is required by -buildmode=c-shared and never runs
+func main() {}
@@ -6,13 +6,17 @@ toolchain go1.23.8
 
 require (
 	github.com/consensys/gnark-crypto v0.17.0
+	github.com/miekg/pkcs11 v1.1.1
+	github.com/redis/go-redis/v9 v9.7.3
 	github.com/wcharczuk/go-chart/v2 v2.1.1
 )
 
 require (
 	github.com/bits-and-blooms/bitset v1.20.0 // indirect
 	github.com/blend/go-sdk v1.20240719.1 // indirect
+	github.com/cespare/xxhash/v2 v2.2.0 // indirect
 	github.com/consensys/bavard v0.1.29 // indirect
+	github.com/dgryski/go-rendezvous v0.0.0-20200823014737-9f7001d12a5f // indirect
 	github.com/golang/freetype v0.0.0-20170609003504-e2365dfdc4a0 // indirect
 	github.com/mmcloughlin/addchain v0.4.0 // indirect
 	golang.org/x/image v0.16.0 // indirect
@@ -0,0 +1,63 @@
+package fetch
+
+import "time"
+
+// Breaker states
+const (
+	breakerClosed = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+// breaker is the circuit breaker of one kind; the Client's mutex guards it
+type breaker struct {
+	state     int
+	failures  int
+	openUntil time.Time
+	trial     bool // a half-open trial lookup is in flight
+}
+
+// allow reports whether a lookup may go to the upstream at now
+func (b *breaker) allow(now time.Time) bool {
+	switch b.state {
+	case breakerOpen:
+		if now.Before(b.openUntil) {
+			return false
+		}
+		b.state = breakerHalfOpen
+		b.trial = true
+		return true
+	case breakerHalfOpen:
+		if b.trial {
+			return false
+		}
+		b.trial = true
+		return true
+	default:
+		return true
+	}
+}
+
+// success closes the breaker
+func (b *breaker) success() {
+	b.state = breakerClosed
+	b.failures = 0
+	b.trial = false
+}
+
+// failure counts a failed lookup, opening the breaker at the threshold or
+// after a failed trial
+func (b *breaker) failure(now time.Time, policy Policy) {
+	b.trial = false
+	b.failures++
+	if b.state == breakerHalfOpen || b.failures >= policy.FailureThreshold {
+		b.state = breakerOpen
+		b.openUntil = now.Add(policy.OpenDuration)
+	}
+}
+
+// release ends a lookup that neither succeeded nor failed, letting another
+// trial through
+func (b *breaker) release() {
+	b.trial = false
+}
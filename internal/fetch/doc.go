@@ -0,0 +1,17 @@
+// Package fetch makes remote lookups, such as DID documents, status lists
+// and trust registries, safe to run on a verifier's request path.
+//
+// A Client runs each lookup with a timeout per attempt and retries transient
+// failures with jittered exponential backoff. A failed lookup is remembered
+// for a while, so a key that does not resolve is not fetched again on every
+// presentation, and a circuit breaker per resource type stops calling an
+// upstream that keeps failing until it has had time to recover. Every knob
+// is set per resource type with a Policy:
+//
+//	client := fetch.NewClient(map[fetch.Kind]fetch.Policy{
+//		fetch.KindStatusList: {Timeout: 500 * time.Millisecond, Retries: 1},
+//	})
+//	doc, err := client.Fetch(ctx, fetch.KindDID, did, fetch.HTTPGet(nil, url, 1<<20))
+//
+// This is an internal package not intended for direct use by applications.
+package fetch
@@ -0,0 +1,275 @@
+package fetch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+// Kind names a type of remote resource; each kind has its own policy and
+// circuit breaker
+type Kind string
+
+// Resource kinds
+const (
+	KindDID        Kind = "did"
+	KindStatusList Kind = "status-list"
+	KindRegistry   Kind = "registry"
+)
+
+var (
+	// ErrCircuitOpen is returned without calling the upstream while the
+	// breaker of its kind is open
+	ErrCircuitOpen = errors.New("circuit open")
+
+	// ErrNegativeCached is returned, wrapping the original error, for a key
+	// whose last lookup failed less than NegativeTTL ago
+	ErrNegativeCached = errors.New("lookup failed recently")
+)
+
+// Policy configures the lookups of one kind. Zero fields take the values of
+// DefaultPolicy.
+type Policy struct {
+	// Timeout bounds each attempt
+	Timeout time.Duration
+
+	// Retries is the number of attempts after the first; negative disables
+	// retries
+	Retries int
+
+	// BaseDelay and MaxDelay bound the backoff before retry n, drawn
+	// uniformly from [0, min(MaxDelay, BaseDelay*2^n))
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+
+	// NegativeTTL is how long a failed key is answered from the cache;
+	// negative disables negative caching
+	NegativeTTL time.Duration
+
+	// FailureThreshold consecutive failed lookups open the breaker, for
+	// OpenDuration. One trial lookup is let through after that; its success
+	// closes the breaker and its failure opens it again.
+	FailureThreshold int
+	OpenDuration     time.Duration
+}
+
+// DefaultPolicy returns the policy for kinds without one
+func DefaultPolicy() Policy {
+	return Policy{
+		Timeout:          2 * time.Second,
+		Retries:          2,
+		BaseDelay:        100 * time.Millisecond,
+		MaxDelay:         time.Second,
+		NegativeTTL:      30 * time.Second,
+		FailureThreshold: 5,
+		OpenDuration:     30 * time.Second,
+	}
+}
+
+// withDefaults fills the zero fields of p from DefaultPolicy
+func (p Policy) withDefaults() Policy {
+	d := DefaultPolicy()
+	if p.Timeout == 0 {
+		p.Timeout = d.Timeout
+	}
+	if p.Retries == 0 {
+		p.Retries = d.Retries
+	}
+	if p.BaseDelay == 0 {
+		p.BaseDelay = d.BaseDelay
+	}
+	if p.MaxDelay == 0 {
+		p.MaxDelay = d.MaxDelay
+	}
+	if p.NegativeTTL == 0 {
+		p.NegativeTTL = d.NegativeTTL
+	}
+	if p.FailureThreshold == 0 {
+		p.FailureThreshold = d.FailureThreshold
+	}
+	if p.OpenDuration == 0 {
+		p.OpenDuration = d.OpenDuration
+	}
+	return p
+}
+
+// Func performs one attempt of a lookup
+type Func func(ctx context.Context) ([]byte, error)
+
+// permanentError marks a failure that retrying cannot fix
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent marks err as not worth retrying, like a document that does not
+// exist. It still counts as a failure for negative caching but not for the
+// breaker, since the upstream answered.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// isPermanent reports whether err was marked with Permanent
+func isPermanent(err error) bool {
+	var p *permanentError
+	return errors.As(err, &p)
+}
+
+// negativeEntry is a remembered failure
+type negativeEntry struct {
+	err     error
+	expires time.Time
+}
+
+// Client runs lookups under the policy of their kind. It is safe for
+// concurrent use.
+type Client struct {
+	mu       sync.Mutex
+	policies map[Kind]Policy
+	breakers map[Kind]*breaker
+	negative map[string]negativeEntry
+
+	// now and sleep are replaced in tests
+	now   func() time.Time
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+// NewClient returns a client with the given policies; other kinds use
+// DefaultPolicy
+func NewClient(policies map[Kind]Policy) *Client {
+	c := &Client{
+		policies: make(map[Kind]Policy, len(policies)),
+		breakers: make(map[Kind]*breaker),
+		negative: make(map[string]negativeEntry),
+		now:      time.Now,
+		sleep:    sleepContext,
+	}
+	for kind, p := range policies {
+		c.policies[kind] = p.withDefaults()
+	}
+	return c
+}
+
+// policy returns the policy of kind
+func (c *Client) policy(kind Kind) Policy {
+	if p, ok := c.policies[kind]; ok {
+		return p
+	}
+	return DefaultPolicy()
+}
+
+// Fetch looks up key, a name of the resource unique within its kind, with
+// fn. Failures are answered from the negative cache until they expire, and
+// nothing is attempted while the kind's breaker is open.
+func (c *Client) Fetch(ctx context.Context, kind Kind, key string, fn Func) ([]byte, error) {
+	policy := c.policy(kind)
+	cacheKey := string(kind) + "\x00" + key
+
+	c.mu.Lock()
+	if entry, ok := c.negative[cacheKey]; ok {
+		if c.now().Before(entry.expires) {
+			c.mu.Unlock()
+			return nil, fmt.Errorf("%w: %s %s: %w", ErrNegativeCached, kind, key, entry.err)
+		}
+		delete(c.negative, cacheKey)
+	}
+	b := c.breakers[kind]
+	if b == nil {
+		b = &breaker{}
+		c.breakers[kind] = b
+	}
+	if !b.allow(c.now()) {
+		c.mu.Unlock()
+		return nil, fmt.Errorf("%w: %s", ErrCircuitOpen, kind)
+	}
+	c.mu.Unlock()
+
+	data, err := c.attempt(ctx, policy, fn)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	switch {
+	case err == nil:
+		b.success()
+	case isPermanent(err):
+		b.success()
+	case ctx.Err() != nil:
+		// The caller gave up; that says nothing about the upstream
+		b.release()
+		return nil, err
+	default:
+		b.failure(c.now(), policy)
+	}
+	if err != nil {
+		if policy.NegativeTTL > 0 {
+			c.negative[cacheKey] = negativeEntry{err: err, expires: c.now().Add(policy.NegativeTTL)}
+		}
+		return nil, fmt.Errorf("fetch %s %s: %w", kind, key, err)
+	}
+	return data, nil
+}
+
+// Forget drops the negative cache entry of key, for example after the
+// upstream was fixed
+func (c *Client) Forget(kind Kind, key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.negative, string(kind)+"\x00"+key)
+}
+
+// attempt runs fn with retries, stopping at the first success or permanent
+// error
+func (c *Client) attempt(ctx context.Context, policy Policy, fn Func) ([]byte, error) {
+	var err error
+	for n := 0; n <= max(policy.Retries, 0); n++ {
+		if n > 0 {
+			if serr := c.sleep(ctx, backoff(policy, n-1)); serr != nil {
+				return nil, err
+			}
+		}
+
+		attemptCtx, cancel := context.WithTimeout(ctx, policy.Timeout)
+		var data []byte
+		data, err = fn(attemptCtx)
+		cancel()
+		if err == nil {
+			return data, nil
+		}
+		if isPermanent(err) || ctx.Err() != nil {
+			return nil, err
+		}
+	}
+	return nil, err
+}
+
+// backoff draws the delay before retry n with full jitter
+func backoff(policy Policy, n int) time.Duration {
+	ceiling := policy.MaxDelay
+	if n < 32 && policy.BaseDelay<<n > 0 && policy.BaseDelay<<n < ceiling {
+		ceiling = policy.BaseDelay << n
+	}
+	if ceiling <= 0 {
+		return 0
+	}
+	return rand.N(ceiling)
+}
+
+// sleepContext waits for d or until ctx is done
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,148 @@
+package fetch
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// testClient returns a client with a manual clock and no backoff delays
+func testClient(policies map[Kind]Policy) (*Client, *time.Time) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := NewClient(policies)
+	c.now = func() time.Time { return now }
+	c.sleep = func(context.Context, time.Duration) error { return nil }
+	return c, &now
+}
+
+func TestFetchRetriesAndNegativeCache(t *testing.T) {
+	c, now := testClient(map[Kind]Policy{KindDID: {Retries: 2, NegativeTTL: time.Minute, FailureThreshold: 100}})
+	ctx := context.Background()
+	upstream := errors.New("upstream down")
+
+	// Transient failures are retried
+	calls := 0
+	data, err := c.Fetch(ctx, KindDID, "did:example:a", func(context.Context) ([]byte, error) {
+		calls++
+		if calls < 3 {
+			return nil, upstream
+		}
+		return []byte("doc"), nil
+	})
+	if err != nil || string(data) != "doc" || calls != 3 {
+		t.Fatalf("Fetch = %q, %v after %d calls", data, err, calls)
+	}
+
+	// A lookup that fails every attempt is remembered
+	calls = 0
+	failing := func(context.Context) ([]byte, error) { calls++; return nil, upstream }
+	if _, err := c.Fetch(ctx, KindDID, "did:example:b", failing); !errors.Is(err, upstream) || calls != 3 {
+		t.Fatalf("Fetch = %v after %d calls", err, calls)
+	}
+	if _, err := c.Fetch(ctx, KindDID, "did:example:b", failing); !errors.Is(err, ErrNegativeCached) || !errors.Is(err, upstream) || calls != 3 {
+		t.Fatalf("Expected a cached failure, got %v after %d calls", err, calls)
+	}
+
+	// until it expires or is forgotten
+	*now = now.Add(2 * time.Minute)
+	if _, err := c.Fetch(ctx, KindDID, "did:example:b", failing); errors.Is(err, ErrNegativeCached) || calls != 6 {
+		t.Fatalf("Expected a fresh lookup, got %v after %d calls", err, calls)
+	}
+	c.Forget(KindDID, "did:example:b")
+	if _, err := c.Fetch(ctx, KindDID, "did:example:b", failing); errors.Is(err, ErrNegativeCached) {
+		t.Fatalf("Forgotten key answered from the cache")
+	}
+
+	// Permanent failures are not retried
+	calls = 0
+	permanent := func(context.Context) ([]byte, error) { calls++; return nil, Permanent(upstream) }
+	if _, err := c.Fetch(ctx, KindDID, "did:example:c", permanent); !errors.Is(err, upstream) || calls != 1 {
+		t.Fatalf("Fetch = %v after %d calls", err, calls)
+	}
+}
+
+func TestFetchCircuitBreaker(t *testing.T) {
+	c, now := testClient(map[Kind]Policy{KindStatusList: {Retries: -1, NegativeTTL: -1, FailureThreshold: 2, OpenDuration: time.Minute}})
+	ctx := context.Background()
+
+	calls := 0
+	healthy := false
+	fn := func(context.Context) ([]byte, error) {
+		calls++
+		if healthy {
+			return []byte("list"), nil
+		}
+		return nil, errors.New("upstream down")
+	}
+
+	for i := range 2 {
+		if _, err := c.Fetch(ctx, KindStatusList, "list", fn); err == nil || errors.Is(err, ErrCircuitOpen) {
+			t.Fatalf("Lookup %d: %v", i, err)
+		}
+	}
+	// Open: other keys of the kind fail fast, other kinds are unaffected
+	if _, err := c.Fetch(ctx, KindStatusList, "other", fn); !errors.Is(err, ErrCircuitOpen) || calls != 2 {
+		t.Fatalf("Expected ErrCircuitOpen, got %v after %d calls", err, calls)
+	}
+	if _, err := c.Fetch(ctx, KindRegistry, "registry", func(context.Context) ([]byte, error) { return nil, nil }); err != nil {
+		t.Fatalf("Other kind failed: %v", err)
+	}
+
+	// A failed trial opens it again, a successful one closes it
+	*now = now.Add(2 * time.Minute)
+	if _, err := c.Fetch(ctx, KindStatusList, "list", fn); errors.Is(err, ErrCircuitOpen) || calls != 3 {
+		t.Fatalf("Expected a trial lookup, got %v after %d calls", err, calls)
+	}
+	if _, err := c.Fetch(ctx, KindStatusList, "list", fn); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("Expected ErrCircuitOpen after a failed trial, got %v", err)
+	}
+	*now = now.Add(2 * time.Minute)
+	healthy = true
+	for i := range 3 {
+		if _, err := c.Fetch(ctx, KindStatusList, "list", fn); err != nil {
+			t.Fatalf("Lookup %d after recovery: %v", i, err)
+		}
+	}
+}
+
+func TestFetchTimeout(t *testing.T) {
+	c, _ := testClient(map[Kind]Policy{KindRegistry: {Timeout: 10 * time.Millisecond, Retries: -1}})
+	start := time.Now()
+	_, err := c.Fetch(context.Background(), KindRegistry, "slow", func(ctx context.Context) ([]byte, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	if !errors.Is(err, context.DeadlineExceeded) || time.Since(start) > time.Second {
+		t.Fatalf("Expected the attempt to time out, got %v after %v", err, time.Since(start))
+	}
+}
+
+func TestHTTPGet(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/doc":
+			w.Write([]byte("document"))
+		case "/big":
+			w.Write(make([]byte, 100))
+		case "/busy":
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	if body, err := HTTPGet(nil, server.URL+"/doc", 64)(ctx); err != nil || string(body) != "document" {
+		t.Fatalf("HTTPGet = %q, %v", body, err)
+	}
+	for path, permanent := range map[string]bool{"/missing": true, "/big": true, "/busy": false} {
+		_, err := HTTPGet(server.Client(), server.URL+path, 64)(ctx)
+		if err == nil || isPermanent(err) != permanent {
+			t.Fatalf("%s: error %v, permanent %v", path, err, isPermanent(err))
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package fetch
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// HTTPGet returns a Func that GETs url with client, or http.DefaultClient
+// if nil, and reads at most maxBytes of the body. Client errors (4xx other
+// than 408 and 429) are permanent; server errors and transport failures are
+// retried.
+func HTTPGet(client *http.Client, url string, maxBytes int64) Func {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return func(ctx context.Context) ([]byte, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return nil, Permanent(err)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			err := fmt.Errorf("GET %s: %s", url, resp.Status)
+			if resp.StatusCode >= 400 && resp.StatusCode < 500 &&
+				resp.StatusCode != http.StatusRequestTimeout && resp.StatusCode != http.StatusTooManyRequests {
+				return nil, Permanent(err)
+			}
+			return nil, err
+		}
+
+		body, err := io.ReadAll(io.LimitReader(resp.Body, maxBytes+1))
+		if err != nil {
+			return nil, err
+		}
+		if int64(len(body)) > maxBytes {
+			return nil, Permanent(fmt.Errorf("GET %s: response larger than %d bytes", url, maxBytes))
+		}
+		return body, nil
+	}
+}
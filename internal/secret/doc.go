@@ -0,0 +1,13 @@
+// Package secret clears secret values from memory once they are no longer
+// needed.
+//
+// Setting a big.Int to zero only shortens it: the words of the old value stay
+// in its backing array until something else overwrites them, so a private key
+// "cleared" with SetInt64(0) is still in the heap. WipeInt overwrites the
+// words first.
+//
+// Store keeps secrets such as private keys behind opaque handles for the
+// language bindings, wiping each one when its handle is destroyed or expires.
+//
+// This is an internal package not intended for direct use by applications.
+package secret
@@ -0,0 +1,34 @@
+package secret
+
+import (
+	"math/big"
+)
+
+// WipeInt overwrites the words of x and sets it to zero. x may be nil.
+// The whole backing array is overwritten, since a big.Int reused for
+// several values may hold words of a longer earlier value past its length.
+func WipeInt(x *big.Int) {
+	if x == nil {
+		return
+	}
+	words := x.Bits()
+	words = words[:cap(words)]
+	for i := range words {
+		words[i] = 0
+	}
+	x.SetInt64(0)
+}
+
+// WipeInts wipes each of xs
+func WipeInts(xs ...*big.Int) {
+	for _, x := range xs {
+		WipeInt(x)
+	}
+}
+
+// WipeBytes overwrites data with zeros
+func WipeBytes(data []byte) {
+	for i := range data {
+		data[i] = 0
+	}
+}
@@ -0,0 +1,46 @@
+package secret
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestWipeInt(t *testing.T) {
+	x, _ := new(big.Int).SetString("73eda753299d7d483339d80809a1d80553bda402fffe5bfeffffffff00000000", 16)
+	words := x.Bits()
+
+	WipeInt(x)
+	if x.Sign() != 0 {
+		t.Fatalf("Expected zero, got %v", x)
+	}
+	// The backing array is cleared, not just truncated
+	for i, w := range words {
+		if w != 0 {
+			t.Fatalf("Word %d survived the wipe: %x", i, w)
+		}
+	}
+
+	// as are words of an earlier, longer value past the current length
+	y := new(big.Int)
+	y.SetString("73eda753299d7d483339d80809a1d80553bda402fffe5bfeffffffff00000000", 16)
+	y.SetInt64(7)
+	words = y.Bits()
+	WipeInt(y)
+	for i, w := range words[:cap(words)] {
+		if w != 0 {
+			t.Fatalf("Word %d past the length survived the wipe: %x", i, w)
+		}
+	}
+
+	WipeInt(nil)
+}
+
+func TestWipeBytes(t *testing.T) {
+	data := []byte{1, 2, 3}
+	WipeBytes(data)
+	for i, b := range data {
+		if b != 0 {
+			t.Fatalf("Byte %d survived the wipe: %x", i, b)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package secret
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Store holds secrets behind opaque handles, so a language binding can keep a
+// private key in Go memory and hand its caller a handle instead. A secret is
+// wiped when its handle is destroyed or its optional TTL runs out.
+type Store[T any] struct {
+	prefix string
+	wipe   func(T)
+
+	mu      sync.Mutex
+	entries map[string]*storeEntry[T]
+}
+
+// storeEntry is a secret held behind a handle
+type storeEntry[T any] struct {
+	value T
+	timer *time.Timer
+}
+
+// NewStore returns an empty store whose handles start with prefix. wipe
+// clears a secret as it leaves the store.
+func NewStore[T any](prefix string, wipe func(T)) *Store[T] {
+	return &Store[T]{prefix: prefix, wipe: wipe, entries: make(map[string]*storeEntry[T])}
+}
+
+// Add stores value and returns its handle. A positive ttl destroys it
+// automatically.
+func (s *Store[T]) Add(value T, ttl time.Duration) (string, error) {
+	var id [16]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		return "", err
+	}
+	handle := s.prefix + hex.EncodeToString(id[:])
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry := &storeEntry[T]{value: value}
+	if ttl > 0 {
+		entry.timer = time.AfterFunc(ttl, func() { s.Destroy(handle) })
+	}
+	s.entries[handle] = entry
+
+	return handle, nil
+}
+
+// Get returns the secret behind handle
+func (s *Store[T]) Get(handle string) (T, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[handle]
+	if !ok {
+		var zero T
+		return zero, false
+	}
+	return entry.value, true
+}
+
+// Destroy wipes and removes the secret behind handle, reporting whether it
+// existed
+func (s *Store[T]) Destroy(handle string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[handle]
+	if !ok {
+		return false
+	}
+	if entry.timer != nil {
+		entry.timer.Stop()
+	}
+	s.wipe(entry.value)
+	delete(s.entries, handle)
+
+	return true
+}
+
+// IsHandle reports whether str has the form of a handle of this store
+func (s *Store[T]) IsHandle(str string) bool {
+	return strings.HasPrefix(str, s.prefix)
+}
@@ -0,0 +1,62 @@
+package secret
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStore(t *testing.T) {
+	store := NewStore("key:", WipeInt)
+
+	x := new(big.Int).Lsh(big.NewInt(1), 200)
+	handle, err := store.Add(x, 0)
+	if err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	if !strings.HasPrefix(handle, "key:") || !store.IsHandle(handle) || store.IsHandle("00ff") {
+		t.Fatalf("Unexpected handle %q", handle)
+	}
+	if got, ok := store.Get(handle); !ok || got != x {
+		t.Fatalf("Get = %v, %v", got, ok)
+	}
+
+	words := x.Bits()
+	if !store.Destroy(handle) {
+		t.Fatal("Destroy reported a missing handle")
+	}
+	for i, w := range words[:cap(words)] {
+		if w != 0 {
+			t.Fatalf("Word %d survived Destroy", i)
+		}
+	}
+	if _, ok := store.Get(handle); ok {
+		t.Fatal("Get found a destroyed handle")
+	}
+	if store.Destroy(handle) {
+		t.Fatal("Destroy reported a destroyed handle")
+	}
+}
+
+func TestStoreTTL(t *testing.T) {
+	wiped := make(chan *big.Int, 1)
+	store := NewStore("key:", func(x *big.Int) { wiped <- x })
+
+	x := big.NewInt(7)
+	handle, err := store.Add(x, time.Millisecond)
+	if err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	select {
+	case got := <-wiped:
+		if got != x {
+			t.Fatalf("TTL wiped %v", got)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("TTL did not destroy the secret")
+	}
+	if _, ok := store.Get(handle); ok {
+		t.Fatal("Get found an expired handle")
+	}
+}
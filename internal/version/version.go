@@ -0,0 +1,103 @@
+// Package version holds the build metadata every binding reports.
+//
+// Release builds set the variables with -ldflags, as scripts/ldflags.sh does:
+//
+//	go build -ldflags "$(scripts/ldflags.sh)" ./cmd/credgen
+//
+// Builds without them fall back to the VCS information the Go toolchain
+// stamps into binaries built from a checkout. The build date is the commit
+// date rather than the time of the build, so rebuilding a commit gives the
+// same metadata.
+//
+// This is an internal package not intended for direct use by applications.
+package version
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+
+	"github.com/anupsv/bbsplus-signatures/bbs"
+)
+
+// Library version components
+const (
+	Major = 1
+	Minor = 0
+	Patch = 0
+)
+
+// Set with -ldflags "-X github.com/anupsv/bbsplus-signatures/internal/version.Commit=..."
+var (
+	// Version overrides the library version, for example with a release tag
+	Version = ""
+
+	// Commit is the git commit the library was built from
+	Commit = ""
+
+	// BuildDate is the commit date in RFC 3339 form
+	BuildDate = ""
+)
+
+// Info is the build metadata of the library
+type Info struct {
+	Version      string   `json:"version"`
+	Commit       string   `json:"commit"`
+	BuildDate    string   `json:"buildDate"`
+	Modified     bool     `json:"modified,omitempty"`
+	GoVersion    string   `json:"goVersion"`
+	Ciphersuites []string `json:"ciphersuites"`
+}
+
+// Get returns the build metadata
+func Get() Info {
+	info := Info{
+		Version:      Version,
+		Commit:       Commit,
+		BuildDate:    BuildDate,
+		GoVersion:    runtime.Version(),
+		Ciphersuites: []string{bbs.SHA256CiphersuiteID, bbs.SHAKE256CiphersuiteID},
+	}
+	if info.Version == "" {
+		info.Version = fmt.Sprintf("%d.%d.%d", Major, Minor, Patch)
+	}
+
+	// Fill in what -ldflags left out from the toolchain's VCS stamp
+	if build, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range build.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				if info.Commit == "" {
+					info.Commit = setting.Value
+				}
+			case "vcs.time":
+				if info.BuildDate == "" {
+					info.BuildDate = setting.Value
+				}
+			case "vcs.modified":
+				info.Modified = setting.Value == "true"
+			}
+		}
+	}
+
+	if info.Commit == "" {
+		info.Commit = "unknown"
+	}
+	if info.BuildDate == "" {
+		info.BuildDate = "unknown"
+	}
+
+	return info
+}
+
+// String formats the metadata on one line
+func (i Info) String() string {
+	commit := i.Commit
+	if len(commit) > 12 {
+		commit = commit[:12]
+	}
+	if i.Modified {
+		commit += "-dirty"
+	}
+	return fmt.Sprintf("%s (commit %s, built %s, %s)", i.Version, commit, i.BuildDate, i.GoVersion)
+}
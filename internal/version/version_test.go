@@ -0,0 +1,39 @@
+package version
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/anupsv/bbsplus-signatures/bbs"
+)
+
+func TestGet(t *testing.T) {
+	info := Get()
+	if info.Version != "1.0.0" {
+		t.Fatalf("Version = %s, want 1.0.0", info.Version)
+	}
+	if info.Commit == "" || info.BuildDate == "" || info.GoVersion == "" {
+		t.Fatalf("Incomplete build information: %+v", info)
+	}
+	if len(info.Ciphersuites) != 2 || info.Ciphersuites[0] != bbs.SHA256CiphersuiteID {
+		t.Fatalf("Ciphersuites = %v", info.Ciphersuites)
+	}
+}
+
+func TestLinkerOverrides(t *testing.T) {
+	defer func(version, commit, date string) {
+		Version, Commit, BuildDate = version, commit, date
+	}(Version, Commit, BuildDate)
+
+	Version = "2.1.0"
+	Commit = "0123456789abcdef0123456789abcdef01234567"
+	BuildDate = "2026-01-02T03:04:05Z"
+
+	info := Get()
+	if info.Version != Version || info.Commit != Commit || info.BuildDate != BuildDate {
+		t.Fatalf("Get ignored the linker variables: %+v", info)
+	}
+	if s := info.String(); !strings.HasPrefix(s, "2.1.0 (commit 0123456789ab") || !strings.Contains(s, BuildDate) {
+		t.Fatalf("String() = %s", s)
+	}
+}
@@ -0,0 +1,485 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+
+	"github.com/anupsv/bbsplus-signatures/bbs"
+	"github.com/anupsv/bbsplus-signatures/internal/secret"
+	buildinfo "github.com/anupsv/bbsplus-signatures/internal/version"
+)
+
+// The addon exposes the API of the WASM module. Arguments cross the C
+// boundary as a JSON array and results come back as a JSON object with the
+// same fields the WASM functions return, so index.js only has to stringify
+// and parse.
+
+// apiFunc is one API function. It returns the response object; failures set
+// success to false rather than returning an error.
+type apiFunc func(args []json.RawMessage) map[string]interface{}
+
+// api maps the JS function names to their implementations
+var api = map[string]apiFunc{
+	"version":         version,
+	"generateKeyPair": generateKeyPair,
+	"importKey":       importKey,
+	"destroyKey":      destroyKey,
+	"sign":            sign,
+	"verify":          verify,
+	"createProof":     createProof,
+	"verifyProof":     verifyProof,
+}
+
+// call runs the named function on a JSON array of arguments and returns the
+// JSON response
+func call(method string, argsJSON []byte) []byte {
+	var response map[string]interface{}
+
+	fn, ok := api[method]
+	if !ok {
+		response = errorResponse(fmt.Sprintf("Unknown function: %s", method))
+	} else {
+		var args []json.RawMessage
+		if err := json.Unmarshal(argsJSON, &args); err != nil {
+			response = errorResponse(fmt.Sprintf("Invalid arguments: %v", err))
+		} else {
+			response = fn(args)
+		}
+	}
+
+	out, err := json.Marshal(response)
+	if err != nil {
+		out, _ = json.Marshal(errorResponse(fmt.Sprintf("Failed to encode response: %v", err)))
+	}
+	return out
+}
+
+// messageList is the { messages: [...] } argument of sign and verify
+type messageList struct {
+	Messages []message `json:"messages"`
+}
+
+// message is one message: a string, signed as its UTF-8 bytes, or the
+// { bytes: "<base64url>" } index.js sends for a Uint8Array or Buffer,
+// signed as the raw bytes
+type message []byte
+
+// UnmarshalJSON reads either form of a message
+func (m *message) UnmarshalJSON(data []byte) error {
+	var s string
+	if json.Unmarshal(data, &s) == nil {
+		*m = bbs.MessageToBytes(s)
+		return nil
+	}
+
+	var binary struct {
+		Bytes *string `json:"bytes"`
+	}
+	if err := json.Unmarshal(data, &binary); err != nil || binary.Bytes == nil {
+		return fmt.Errorf("message must be a string or a Uint8Array")
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(*binary.Bytes)
+	if err != nil {
+		return fmt.Errorf("invalid binary message: %w", err)
+	}
+	*m = raw
+	return nil
+}
+
+// proofRequest is the argument of createProof
+type proofRequest struct {
+	PublicKey        string    `json:"publicKey"`
+	Signature        string    `json:"signature"`
+	Messages         []message `json:"messages"`
+	DisclosedIndices []int     `json:"disclosedIndices"`
+	Header           string    `json:"header"`
+}
+
+// verifyRequest is the argument of verifyProof
+type verifyRequest struct {
+	PublicKey         string            `json:"publicKey"`
+	Proof             string            `json:"proof"`
+	DisclosedMessages map[string]string `json:"disclosedMessages"`
+	Header            string            `json:"header"`
+}
+
+// keyOptionsArg is the options argument of generateKeyPair and importKey
+type keyOptionsArg struct {
+	KeyHandle  bool    `json:"keyHandle"`
+	TTLSeconds float64 `json:"ttlSeconds"`
+}
+
+// version returns the build metadata of the addon
+func version(args []json.RawMessage) map[string]interface{} {
+	info := buildinfo.Get()
+	return map[string]interface{}{
+		"version":      info.Version,
+		"buildDate":    info.BuildDate,
+		"commit":       info.Commit,
+		"modified":     info.Modified,
+		"goVersion":    info.GoVersion,
+		"ciphersuites": info.Ciphersuites,
+	}
+}
+
+// generateKeyPair generates a BBS+ key pair
+func generateKeyPair(args []json.RawMessage) map[string]interface{} {
+	// messageCount is optional and defaults to 5
+	messageCount := 5
+	if len(args) > 0 {
+		var n float64
+		if json.Unmarshal(args[0], &n) == nil {
+			messageCount = int(n)
+		}
+	}
+
+	keyPair, err := bbs.GenerateKeyPair(messageCount, rand.Reader)
+	if err != nil {
+		return errorResponse(fmt.Sprintf("Failed to generate key pair: %v", err))
+	}
+
+	if len(args) > 1 {
+		var opts keyOptionsArg
+		if json.Unmarshal(args[1], &opts) == nil && opts.KeyHandle {
+			return keyHandleResponse(keyPair, opts.TTLSeconds)
+		}
+	}
+
+	return map[string]interface{}{
+		"success":      true,
+		"privateKey":   hex.EncodeToString(bbs.SerializePrivateKey(keyPair.PrivateKey)),
+		"publicKey":    hex.EncodeToString(bbs.SerializePublicKey(keyPair.PublicKey)),
+		"messageCount": messageCount,
+	}
+}
+
+// importKey stores a key pair in Go memory and returns a handle for sign
+func importKey(args []json.RawMessage) map[string]interface{} {
+	if len(args) < 2 {
+		return errorResponse("importKey requires privateKey and publicKey")
+	}
+
+	privKeyBytes, err := hexArg(args[0])
+	if err != nil {
+		return errorResponse(fmt.Sprintf("Invalid private key format: %v", err))
+	}
+	defer secret.WipeBytes(privKeyBytes)
+
+	privKey, err := bbs.DeserializePrivateKey(privKeyBytes)
+	if err != nil {
+		return errorResponse(fmt.Sprintf("Failed to deserialize private key: %v", err))
+	}
+
+	pubKey, errMsg := publicKeyArg(args[1])
+	if errMsg != "" {
+		secret.WipeInt(privKey.X)
+		return errorResponse(errMsg)
+	}
+
+	var opts keyOptionsArg
+	if len(args) > 2 {
+		json.Unmarshal(args[2], &opts)
+	}
+
+	return keyHandleResponse(&bbs.KeyPair{PrivateKey: privKey, PublicKey: pubKey}, opts.TTLSeconds)
+}
+
+// destroyKey zeroizes the key behind a handle
+func destroyKey(args []json.RawMessage) map[string]interface{} {
+	var handle string
+	if len(args) < 1 || json.Unmarshal(args[0], &handle) != nil || !keys.IsHandle(handle) {
+		return errorResponse("destroyKey requires a key handle")
+	}
+
+	return map[string]interface{}{
+		"success":   true,
+		"destroyed": keys.Destroy(handle),
+	}
+}
+
+// sign creates a BBS+ signature. The first argument is a hex private key or
+// a key handle; with a handle the public key may be null.
+func sign(args []json.RawMessage) map[string]interface{} {
+	if len(args) < 3 {
+		return errorResponse("Sign requires privateKey, publicKey, and messages")
+	}
+
+	var privKey *bbs.PrivateKey
+	var pubKey *bbs.PublicKey
+	var handle string
+	if json.Unmarshal(args[0], &handle) == nil && keys.IsHandle(handle) {
+		keyPair, ok := keys.Get(handle)
+		if !ok {
+			return errorResponse("Unknown or destroyed key handle")
+		}
+		privKey = keyPair.PrivateKey
+		pubKey = keyPair.PublicKey
+	} else {
+		privKeyBytes, err := hexArg(args[0])
+		if err != nil {
+			return errorResponse(fmt.Sprintf("Invalid private key format: %v", err))
+		}
+		defer secret.WipeBytes(privKeyBytes)
+
+		privKey, err = bbs.DeserializePrivateKey(privKeyBytes)
+		if err != nil {
+			return errorResponse(fmt.Sprintf("Failed to deserialize private key: %v", err))
+		}
+	}
+
+	if pubKey == nil || string(args[1]) != "null" {
+		var errMsg string
+		pubKey, errMsg = publicKeyArg(args[1])
+		if errMsg != "" {
+			return errorResponse(errMsg)
+		}
+	}
+
+	messages, errMsg := messagesArg(args[2])
+	if errMsg != "" {
+		return errorResponse(errMsg)
+	}
+
+	var header []byte
+	if len(args) > 3 {
+		header = headerArg(args[3])
+	}
+
+	signature, err := bbs.Sign(privKey, pubKey, messages, header)
+	if err != nil {
+		return errorResponse(fmt.Sprintf("Failed to create signature: %v", err))
+	}
+
+	return map[string]interface{}{
+		"success":   true,
+		"signature": hex.EncodeToString(bbs.SerializeSignature(signature)),
+	}
+}
+
+// verify verifies a BBS+ signature
+func verify(args []json.RawMessage) map[string]interface{} {
+	if len(args) < 3 {
+		return errorResponse("Verify requires publicKey, signature, and messages")
+	}
+
+	pubKey, errMsg := publicKeyArg(args[0])
+	if errMsg != "" {
+		return errorResponse(errMsg)
+	}
+
+	sigBytes, err := hexArg(args[1])
+	if err != nil {
+		return errorResponse(fmt.Sprintf("Invalid signature format: %v", err))
+	}
+	signature, err := bbs.DeserializeSignature(sigBytes)
+	if err != nil {
+		return errorResponse(fmt.Sprintf("Failed to deserialize signature: %v", err))
+	}
+
+	messages, errMsg := messagesArg(args[2])
+	if errMsg != "" {
+		return errorResponse(errMsg)
+	}
+
+	var header []byte
+	if len(args) > 3 {
+		header = headerArg(args[3])
+	}
+
+	if err := bbs.Verify(pubKey, signature, messages, header); err != nil {
+		return map[string]interface{}{
+			"success": true,
+			"valid":   false,
+			"error":   err.Error(),
+		}
+	}
+
+	return map[string]interface{}{
+		"success": true,
+		"valid":   true,
+	}
+}
+
+// createProof creates a BBS+ proof of knowledge
+func createProof(args []json.RawMessage) map[string]interface{} {
+	var request proofRequest
+	if len(args) < 1 || json.Unmarshal(args[0], &request) != nil {
+		return errorResponse("CreateProof requires a proof request object")
+	}
+
+	pubKey, errMsg := publicKeyHex(request.PublicKey)
+	if errMsg != "" {
+		return errorResponse(errMsg)
+	}
+
+	sigBytes, err := hex.DecodeString(request.Signature)
+	if err != nil {
+		return errorResponse(fmt.Sprintf("Invalid signature format: %v", err))
+	}
+	signature, err := bbs.DeserializeSignature(sigBytes)
+	if err != nil {
+		return errorResponse(fmt.Sprintf("Failed to deserialize signature: %v", err))
+	}
+
+	if len(request.Messages) == 0 {
+		return errorResponse("Messages must be a non-empty array")
+	}
+
+	proof, disclosedMsgs, err := bbs.CreateProof(
+		pubKey,
+		signature,
+		encodeMessages(request.Messages),
+		request.DisclosedIndices,
+		optionalHeader(request.Header),
+	)
+	if err != nil {
+		return errorResponse(fmt.Sprintf("Failed to create proof: %v", err))
+	}
+
+	disclosed := make(map[string]interface{}, len(disclosedMsgs))
+	for idx, msg := range disclosedMsgs {
+		disclosed[strconv.Itoa(idx)] = msg.String()
+	}
+
+	return map[string]interface{}{
+		"success":           true,
+		"proof":             hex.EncodeToString(bbs.SerializeProof(proof)),
+		"disclosedMessages": disclosed,
+	}
+}
+
+// verifyProof verifies a BBS+ proof of knowledge
+func verifyProof(args []json.RawMessage) map[string]interface{} {
+	var request verifyRequest
+	if len(args) < 1 || json.Unmarshal(args[0], &request) != nil {
+		return errorResponse("VerifyProof requires a verification request object")
+	}
+
+	pubKey, errMsg := publicKeyHex(request.PublicKey)
+	if errMsg != "" {
+		return errorResponse(errMsg)
+	}
+
+	proofBytes, err := hex.DecodeString(request.Proof)
+	if err != nil {
+		return errorResponse(fmt.Sprintf("Invalid proof format: %v", err))
+	}
+	proof, err := bbs.DeserializeProof(proofBytes)
+	if err != nil {
+		return errorResponse(fmt.Sprintf("Failed to deserialize proof: %v", err))
+	}
+
+	// A missing object means nothing was disclosed
+	disclosedMsgs := make(map[int]*big.Int, len(request.DisclosedMessages))
+	for key, valueStr := range request.DisclosedMessages {
+		index, err := strconv.Atoi(key)
+		if err != nil {
+			return errorResponse(fmt.Sprintf("Invalid disclosed message index: %s", key))
+		}
+
+		value, ok := new(big.Int).SetString(valueStr, 10)
+		if !ok {
+			return errorResponse(fmt.Sprintf("Invalid disclosed message value: %s", valueStr))
+		}
+
+		disclosedMsgs[index] = value
+	}
+
+	if err := bbs.VerifyProof(pubKey, proof, disclosedMsgs, optionalHeader(request.Header)); err != nil {
+		return map[string]interface{}{
+			"success":  true,
+			"verified": false,
+			"error":    err.Error(),
+		}
+	}
+
+	return map[string]interface{}{
+		"success":  true,
+		"verified": true,
+	}
+}
+
+// hexArg decodes a hex string argument
+func hexArg(arg json.RawMessage) ([]byte, error) {
+	var s string
+	if err := json.Unmarshal(arg, &s); err != nil {
+		return nil, fmt.Errorf("expected a hex string")
+	}
+	return hex.DecodeString(s)
+}
+
+// publicKeyArg decodes a hex public key argument. It returns an error
+// message on failure.
+func publicKeyArg(arg json.RawMessage) (*bbs.PublicKey, string) {
+	var s string
+	if err := json.Unmarshal(arg, &s); err != nil {
+		return nil, "Invalid public key format: expected a hex string"
+	}
+	return publicKeyHex(s)
+}
+
+// publicKeyHex decodes a hex public key. It returns an error message on
+// failure.
+func publicKeyHex(s string) (*bbs.PublicKey, string) {
+	pubKeyBytes, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Sprintf("Invalid public key format: %v", err)
+	}
+	pubKey, err := bbs.DeserializePublicKey(pubKeyBytes)
+	if err != nil {
+		return nil, fmt.Sprintf("Failed to deserialize public key: %v", err)
+	}
+	return pubKey, ""
+}
+
+// messagesArg decodes a { messages: [...] } argument. It returns an error
+// message on failure.
+func messagesArg(arg json.RawMessage) ([]*big.Int, string) {
+	var list messageList
+	if err := json.Unmarshal(arg, &list); err != nil {
+		return nil, "Messages parameter must be an object with messages array"
+	}
+	if len(list.Messages) == 0 {
+		return nil, "Messages must be a non-empty array"
+	}
+	return encodeMessages(list.Messages), ""
+}
+
+// encodeMessages converts messages to field elements
+func encodeMessages(values []message) []*big.Int {
+	messages := make([]*big.Int, len(values))
+	for i, msg := range values {
+		messages[i] = bbs.MessageToFieldElement(msg)
+	}
+	return messages
+}
+
+// headerArg decodes an optional header argument
+func headerArg(arg json.RawMessage) []byte {
+	var s string
+	if json.Unmarshal(arg, &s) != nil {
+		return nil
+	}
+	return optionalHeader(s)
+}
+
+// optionalHeader returns the UTF-8 bytes of a header, or nil if it is empty
+func optionalHeader(s string) []byte {
+	if s == "" {
+		return nil
+	}
+	return []byte(s)
+}
+
+// errorResponse creates a failed response
+func errorResponse(message string) map[string]interface{} {
+	return map[string]interface{}{
+		"success": false,
+		"error":   message,
+	}
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// callJSON invokes an API function with args and decodes the response
+func callJSON(t *testing.T, method string, args ...interface{}) map[string]interface{} {
+	t.Helper()
+
+	argsJSON, err := json.Marshal(args)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+
+	var response map[string]interface{}
+	if err := json.Unmarshal(call(method, argsJSON), &response); err != nil {
+		t.Fatalf("%s returned invalid JSON: %v", method, err)
+	}
+	return response
+}
+
+func TestAPI(t *testing.T) {
+	messages := map[string]interface{}{"messages": []string{"alice", "1990-01-01", "NL"}}
+
+	keyPair := callJSON(t, "generateKeyPair", 3)
+	if keyPair["success"] != true {
+		t.Fatalf("generateKeyPair failed: %v", keyPair["error"])
+	}
+
+	signature := callJSON(t, "sign", keyPair["privateKey"], keyPair["publicKey"], messages, "header")
+	if signature["success"] != true {
+		t.Fatalf("sign failed: %v", signature["error"])
+	}
+
+	verified := callJSON(t, "verify", keyPair["publicKey"], signature["signature"], messages, "header")
+	if verified["valid"] != true {
+		t.Fatalf("verify failed: %v", verified["error"])
+	}
+
+	proof := callJSON(t, "createProof", map[string]interface{}{
+		"publicKey":        keyPair["publicKey"],
+		"signature":        signature["signature"],
+		"messages":         messages["messages"],
+		"disclosedIndices": []int{0, 2},
+		"header":           "header",
+	})
+	if proof["success"] != true {
+		t.Fatalf("createProof failed: %v", proof["error"])
+	}
+
+	request := map[string]interface{}{
+		"publicKey":         keyPair["publicKey"],
+		"proof":             proof["proof"],
+		"disclosedMessages": proof["disclosedMessages"],
+		"header":            "header",
+	}
+	if result := callJSON(t, "verifyProof", request); result["verified"] != true {
+		t.Fatalf("verifyProof failed: %v", result["error"])
+	}
+
+	// A different header fails verification but not the call
+	request["header"] = "other"
+	if result := callJSON(t, "verifyProof", request); result["success"] != true || result["verified"] != false {
+		t.Fatalf("verifyProof with the wrong header returned %v", result)
+	}
+
+	if result := callJSON(t, "nope"); result["success"] != false {
+		t.Fatal("unknown function succeeded")
+	}
+}
+
+func TestKeyHandles(t *testing.T) {
+	messages := map[string]interface{}{"messages": []string{"alice", "bob"}}
+
+	keyPair := callJSON(t, "generateKeyPair", 2)
+	imported := callJSON(t, "importKey", keyPair["privateKey"], keyPair["publicKey"])
+	if imported["success"] != true {
+		t.Fatalf("importKey failed: %v", imported["error"])
+	}
+	handle := imported["keyHandle"]
+
+	signature := callJSON(t, "sign", handle, nil, messages)
+	if signature["success"] != true {
+		t.Fatalf("sign with handle failed: %v", signature["error"])
+	}
+	if result := callJSON(t, "verify", keyPair["publicKey"], signature["signature"], messages); result["valid"] != true {
+		t.Fatalf("verify failed: %v", result["error"])
+	}
+
+	// Generated handles never expose the private key
+	generated := callJSON(t, "generateKeyPair", 2, map[string]interface{}{"keyHandle": true})
+	if _, ok := generated["privateKey"]; ok || generated["keyHandle"] == nil {
+		t.Fatalf("generateKeyPair with keyHandle returned %v", generated)
+	}
+
+	if result := callJSON(t, "destroyKey", handle); result["destroyed"] != true {
+		t.Fatalf("destroyKey returned %v", result)
+	}
+	if result := callJSON(t, "sign", handle, nil, messages); result["success"] != false {
+		t.Fatal("sign succeeded with a destroyed handle")
+	}
+}
+
+func TestBinaryMessages(t *testing.T) {
+	// 0xff is not valid UTF-8, so the bytes cannot pass as a string
+	photo := map[string]interface{}{"bytes": "AP_6"}
+	messages := map[string]interface{}{"messages": []interface{}{"alice", photo}}
+
+	keyPair := callJSON(t, "generateKeyPair", 2)
+	signature := callJSON(t, "sign", keyPair["privateKey"], keyPair["publicKey"], messages)
+	if signature["success"] != true {
+		t.Fatalf("sign failed: %v", signature["error"])
+	}
+	if result := callJSON(t, "verify", keyPair["publicKey"], signature["signature"], messages); result["valid"] != true {
+		t.Fatalf("verify failed: %v", result["error"])
+	}
+
+	// A string with the same base64url text is another message
+	asText := map[string]interface{}{"messages": []interface{}{"alice", "AP_6"}}
+	if result := callJSON(t, "verify", keyPair["publicKey"], signature["signature"], asText); result["valid"] != false {
+		t.Fatal("verify accepted the base64url text for the bytes")
+	}
+
+	proof := callJSON(t, "createProof", map[string]interface{}{
+		"publicKey":        keyPair["publicKey"],
+		"signature":        signature["signature"],
+		"messages":         messages["messages"],
+		"disclosedIndices": []int{1},
+	})
+	if proof["success"] != true {
+		t.Fatalf("createProof failed: %v", proof["error"])
+	}
+
+	bad := map[string]interface{}{"messages": []interface{}{"alice", map[string]interface{}{"bytes": "not base64!"}}}
+	if result := callJSON(t, "sign", keyPair["privateKey"], keyPair["publicKey"], bad); result["success"] != false {
+		t.Fatal("sign accepted invalid base64url")
+	}
+}
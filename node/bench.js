@@ -0,0 +1,88 @@
+// Compares the native addon with the WASM module on the same operations.
+// Run with `make bench`, which builds both into build/.
+//
+//   node bench.js [iterations] [messageCount]
+
+'use strict';
+
+const fs = require('fs');
+const path = require('path');
+
+const iterations = parseInt(process.argv[2] || '20', 10);
+const messageCount = parseInt(process.argv[3] || '10', 10);
+
+// loadWasm instantiates the WASM module and returns its BBS object
+async function loadWasm() {
+  globalThis.crypto ??= require('crypto').webcrypto;
+  require(path.join(__dirname, 'build', 'wasm_exec.js'));
+
+  const go = new Go();
+  const wasm = fs.readFileSync(path.join(__dirname, 'build', 'bbs.wasm'));
+  const { instance } = await WebAssembly.instantiate(wasm, go.importObject);
+  go.run(instance);
+  return globalThis.BBS;
+}
+
+// measure runs op iterations times after one warm-up run and returns the
+// mean time in milliseconds
+function measure(op) {
+  op();
+  const start = process.hrtime.bigint();
+  for (let i = 0; i < iterations; i++) {
+    op();
+  }
+  return Number(process.hrtime.bigint() - start) / 1e6 / iterations;
+}
+
+// run measures each operation against one API and returns the mean times
+function run(api) {
+  const messages = Array.from({ length: messageCount }, (_, i) => `message ${i}`);
+  const keyPair = api.generateKeyPair(messageCount);
+  const signature = api.sign(keyPair.privateKey, keyPair.publicKey, { messages });
+  const request = {
+    publicKey: keyPair.publicKey,
+    signature: signature.signature,
+    messages,
+    disclosedIndices: [0, 1],
+  };
+  const proof = api.createProof(request);
+  const verifyRequest = {
+    publicKey: keyPair.publicKey,
+    proof: proof.proof,
+    disclosedMessages: proof.disclosedMessages,
+  };
+
+  if (!api.verifyProof(verifyRequest).verified) {
+    throw new Error('proof did not verify');
+  }
+
+  return {
+    keygen: measure(() => api.generateKeyPair(messageCount)),
+    sign: measure(() => api.sign(keyPair.privateKey, keyPair.publicKey, { messages })),
+    verify: measure(() => api.verify(keyPair.publicKey, signature.signature, { messages })),
+    prove: measure(() => api.createProof(request)),
+    'verify-proof': measure(() => api.verifyProof(verifyRequest)),
+  };
+}
+
+async function main() {
+  const native = run(require('./index.js'));
+  const wasm = run(await loadWasm());
+
+  console.log(`${messageCount} messages, ${iterations} iterations, mean ms per operation\n`);
+  console.log('operation'.padEnd(14) + 'native'.padStart(10) + 'wasm'.padStart(10) + 'speedup'.padStart(10));
+  for (const op of Object.keys(native)) {
+    console.log(
+      op.padEnd(14) +
+        native[op].toFixed(2).padStart(10) +
+        wasm[op].toFixed(2).padStart(10) +
+        `${(wasm[op] / native[op]).toFixed(1)}x`.padStart(10),
+    );
+  }
+  process.exit(0);
+}
+
+main().catch((err) => {
+  console.error(err);
+  process.exit(1);
+});
@@ -0,0 +1,25 @@
+//go:build cgo
+
+package main
+
+/*
+#include <stdlib.h>
+*/
+import "C"
+
+import "unsafe"
+
+// bbs_call runs an API function on a JSON array of arguments. The caller
+// frees the returned JSON string with bbs_free.
+//
+//export bbs_call
+func bbs_call(method *C.char, args *C.char) *C.char {
+	return C.CString(string(call(C.GoString(method), []byte(C.GoString(args)))))
+}
+
+// bbs_free releases a string returned by bbs_call
+//
+//export bbs_free
+func bbs_free(s *C.char) {
+	C.free(unsafe.Pointer(s))
+}
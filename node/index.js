@@ -0,0 +1,45 @@
+// BBS+ Node.js native addon. The functions match the WASM module's BBS
+// object; arguments and results use the same hex encodings.
+
+'use strict';
+
+const native = require('./build/bbs.node');
+
+// Byte messages cross the JSON boundary as { bytes: "<base64url>" }. The
+// replacer looks at this[key] because JSON.stringify has already turned a
+// Buffer into { type, data } by the time it calls the replacer.
+function encodeBytes(key, value) {
+  const original = this[key];
+  if (original instanceof Uint8Array) {
+    return { bytes: Buffer.from(original.buffer, original.byteOffset, original.byteLength).toString('base64url') };
+  }
+  return value;
+}
+
+function call(method, args) {
+  return JSON.parse(native.call(method, JSON.stringify(Array.from(args), encodeBytes)));
+}
+
+module.exports = {
+  version: (...args) => call('version', args),
+  generateKeyPair: (...args) => call('generateKeyPair', args),
+  importKey: (...args) => call('importKey', args),
+  destroyKey: (...args) => call('destroyKey', args),
+  sign: (...args) => call('sign', args),
+  verify: (...args) => call('verify', args),
+  createProof: (...args) => call('createProof', args),
+  verifyProof: (...args) => call('verifyProof', args),
+
+  // Native proofs are fast enough not to need chunking. This keeps code
+  // written against the WASM module working; checkpoints are not supported.
+  createProofChunked: async (request, onProgress) => {
+    if (request && request.checkpoint) {
+      return { success: false, error: 'Checkpoints are not supported by the native addon' };
+    }
+    const result = call('createProof', [request]);
+    if (result.success && typeof onProgress === 'function') {
+      onProgress({ done: 1, total: 1 });
+    }
+    return result;
+  },
+};
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/anupsv/bbsplus-signatures/bbs"
+	"github.com/anupsv/bbsplus-signatures/internal/secret"
+)
+
+// Key handles work as in the WASM module: importKey keeps the private key in
+// Go memory and returns an opaque handle, and the key is zeroized when the
+// handle is destroyed or its optional TTL runs out.
+
+// keyHandlePrefix marks a sign argument as a handle rather than a hex key
+const keyHandlePrefix = "bbskey:"
+
+// keys holds the imported keys
+var keys = secret.NewStore(keyHandlePrefix, func(keyPair *bbs.KeyPair) {
+	secret.WipeInt(keyPair.PrivateKey.X)
+})
+
+// keyHandleResponse stores keyPair and returns its handle and public key
+func keyHandleResponse(keyPair *bbs.KeyPair, ttlSeconds float64) map[string]interface{} {
+	var ttl time.Duration
+	if ttlSeconds > 0 {
+		ttl = time.Duration(ttlSeconds * float64(time.Second))
+	}
+
+	handle, err := keys.Add(keyPair, ttl)
+	if err != nil {
+		secret.WipeInt(keyPair.PrivateKey.X)
+		return errorResponse(fmt.Sprintf("Failed to store key: %v", err))
+	}
+
+	return map[string]interface{}{
+		"success":      true,
+		"keyHandle":    handle,
+		"publicKey":    hex.EncodeToString(bbs.SerializePublicKey(keyPair.PublicKey)),
+		"messageCount": keyPair.PublicKey.MessageCount(),
+	}
+}
@@ -0,0 +1,7 @@
+// Package main builds the BBS+ Node.js native addon. The Go code is compiled
+// into a C shared library (see Makefile), which src/addon.c wraps as an N-API
+// module with the same API as the WASM module.
+package main
+
+// main is required by -buildmode=c-shared and never runs
+func main() {}
@@ -0,0 +1,74 @@
+// N-API wrapper around the Go shared library. It exposes a single function,
+// call(method, argsJson), which index.js uses to build the BBS API.
+
+#include <stdlib.h>
+#include <node_api.h>
+
+#include "libbbs.h"
+
+#define CHECK(env, call)                                        \
+	do {                                                        \
+		if ((call) != napi_ok) {                                \
+			napi_throw_error((env), NULL, "N-API call failed"); \
+			return NULL;                                        \
+		}                                                       \
+	} while (0)
+
+// stringArg copies a JS string argument into a new C string
+static char *stringArg(napi_env env, napi_value value) {
+	size_t len;
+	if (napi_get_value_string_utf8(env, value, NULL, 0, &len) != napi_ok) {
+		return NULL;
+	}
+
+	char *buf = malloc(len + 1);
+	if (buf == NULL) {
+		return NULL;
+	}
+	if (napi_get_value_string_utf8(env, value, buf, len + 1, &len) != napi_ok) {
+		free(buf);
+		return NULL;
+	}
+	return buf;
+}
+
+// Call runs bbs_call and returns its JSON response as a string
+static napi_value Call(napi_env env, napi_callback_info info) {
+	size_t argc = 2;
+	napi_value argv[2];
+	CHECK(env, napi_get_cb_info(env, info, &argc, argv, NULL, NULL));
+
+	if (argc < 2) {
+		napi_throw_type_error(env, NULL, "call requires a method and a JSON argument array");
+		return NULL;
+	}
+
+	char *method = stringArg(env, argv[0]);
+	char *args = stringArg(env, argv[1]);
+	if (method == NULL || args == NULL) {
+		free(method);
+		free(args);
+		napi_throw_type_error(env, NULL, "method and arguments must be strings");
+		return NULL;
+	}
+
+	char *response = bbs_call(method, args);
+	free(method);
+	free(args);
+
+	napi_value result;
+	napi_status status = napi_create_string_utf8(env, response, NAPI_AUTO_LENGTH, &result);
+	bbs_free(response);
+	CHECK(env, status);
+
+	return result;
+}
+
+static napi_value Init(napi_env env, napi_value exports) {
+	napi_value fn;
+	CHECK(env, napi_create_function(env, "call", NAPI_AUTO_LENGTH, Call, NULL, &fn));
+	CHECK(env, napi_set_named_property(env, exports, "call", fn));
+	return exports;
+}
+
+NAPI_MODULE(NODE_GYP_MODULE_NAME, Init)
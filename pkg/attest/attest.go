@@ -0,0 +1,220 @@
+package attest
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/anupsv/bbsplus-signatures/bbs"
+	"github.com/anupsv/bbsplus-signatures/pkg/jose"
+)
+
+// ExtensionOID identifies the certificate extension carrying a BBS+ public
+// key. It is an unregistered OID in the UUID arc 2.25; deployments with an
+// arc of their own may set it before issuing or verifying certificates.
+var ExtensionOID = asn1.ObjectIdentifier{2, 25, 1544741815}
+
+var (
+	// ErrNoKeyExtension is returned for a certificate without the extension
+	ErrNoKeyExtension = errors.New("certificate carries no BBS+ public key")
+
+	// ErrKeyMismatch is returned when an anchor vouches for another key
+	// than the one in the bundle
+	ErrKeyMismatch = errors.New("anchor attests a different BBS+ public key")
+
+	// ErrUnanchored is returned for a bundle without a certificate chain or
+	// attestation, or one whose anchors the policy cannot check
+	ErrUnanchored = errors.New("BBS+ public key is not anchored")
+)
+
+// Extension returns the certificate extension carrying publicKey as the DER
+// OCTET STRING of its canonical encoding
+func Extension(publicKey *bbs.PublicKey) pkix.Extension {
+	// Marshalling a byte slice cannot fail
+	value, _ := asn1.Marshal(bbs.SerializePublicKey(publicKey))
+	return pkix.Extension{Id: ExtensionOID, Value: value}
+}
+
+// PublicKeyFromCertificate returns the BBS+ public key in cert's extension.
+// It does not check the certificate; see VerifyCertificateChain.
+func PublicKeyFromCertificate(cert *x509.Certificate) (*bbs.PublicKey, error) {
+	for _, ext := range cert.Extensions {
+		if !ext.Id.Equal(ExtensionOID) {
+			continue
+		}
+		var encoded []byte
+		rest, err := asn1.Unmarshal(ext.Value, &encoded)
+		if err != nil || len(rest) != 0 {
+			return nil, fmt.Errorf("malformed BBS+ public key extension")
+		}
+		return bbs.DeserializePublicKey(encoded)
+	}
+	return nil, ErrNoKeyExtension
+}
+
+// VerifyCertificateChain verifies chain, leaf first, under opts and returns
+// the BBS+ public key of the leaf. Intermediates in the chain are added to
+// opts.Intermediates. Without opts.KeyUsages any extended key usage is
+// accepted, since issuer certificates are not TLS certificates.
+func VerifyCertificateChain(chain []*x509.Certificate, opts x509.VerifyOptions) (*bbs.PublicKey, error) {
+	if len(chain) == 0 {
+		return nil, fmt.Errorf("empty certificate chain")
+	}
+	leaf := chain[0]
+
+	publicKey, err := PublicKeyFromCertificate(leaf)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.Intermediates == nil {
+		opts.Intermediates = x509.NewCertPool()
+	} else {
+		opts.Intermediates = opts.Intermediates.Clone()
+	}
+	for _, cert := range chain[1:] {
+		opts.Intermediates.AddCert(cert)
+	}
+	if len(opts.KeyUsages) == 0 {
+		opts.KeyUsages = []x509.ExtKeyUsage{x509.ExtKeyUsageAny}
+	}
+
+	// The extension is understood here, so marking it critical is allowed
+	if i := slices.IndexFunc(leaf.UnhandledCriticalExtensions, ExtensionOID.Equal); i >= 0 {
+		handled := *leaf
+		handled.UnhandledCriticalExtensions = slices.Delete(slices.Clone(leaf.UnhandledCriticalExtensions), i, i+1)
+		leaf = &handled
+	}
+	if _, err := leaf.Verify(opts); err != nil {
+		return nil, fmt.Errorf("certificate chain does not verify: %w", err)
+	}
+	return publicKey, nil
+}
+
+// TrustPolicy names the anchors a verifier trusts
+type TrustPolicy struct {
+	// Roots are the CA certificates certificate chains must lead to
+	Roots *x509.CertPool
+
+	// Attesters are the PKIX DER keys trusted to sign key attestations
+	Attesters [][]byte
+
+	// Now returns the current time; nil uses time.Now
+	Now func() time.Time
+}
+
+// now returns the policy's current time
+func (p TrustPolicy) now() time.Time {
+	if p.Now != nil {
+		return p.Now()
+	}
+	return time.Now()
+}
+
+// Bundle is a BBS+ public key with the anchors vouching for it
+type Bundle struct {
+	// PublicKey is the issuer's public key (Base64-encoded)
+	PublicKey string `json:"publicKey"`
+
+	// Certificates is the X.509 chain, leaf first, as Base64 DER
+	Certificates []string `json:"x5c,omitempty"`
+
+	// Attestation is a JWS key attestation
+	Attestation string `json:"attestation,omitempty"`
+}
+
+// NewCertificateBundle packages publicKey with the certificate chain
+// carrying it, leaf first
+func NewCertificateBundle(publicKey *bbs.PublicKey, chain []*x509.Certificate) (*Bundle, error) {
+	if len(chain) == 0 {
+		return nil, fmt.Errorf("empty certificate chain")
+	}
+	certKey, err := PublicKeyFromCertificate(chain[0])
+	if err != nil {
+		return nil, err
+	}
+	if certKey.Fingerprint() != publicKey.Fingerprint() {
+		return nil, ErrKeyMismatch
+	}
+
+	b := NewAttestationBundle(publicKey, "")
+	for _, cert := range chain {
+		b.Certificates = append(b.Certificates, base64.StdEncoding.EncodeToString(cert.Raw))
+	}
+	return b, nil
+}
+
+// NewAttestationBundle packages publicKey with a JWS key attestation from
+// jose.EncodeKeyAttestation
+func NewAttestationBundle(publicKey *bbs.PublicKey, token string) *Bundle {
+	return &Bundle{
+		PublicKey:   base64.StdEncoding.EncodeToString(bbs.SerializePublicKey(publicKey)),
+		Attestation: token,
+	}
+}
+
+// Verify checks every anchor in the bundle under policy and returns the key
+// once all of them vouch for it. A bundle without anchors, or with an anchor
+// the policy has no trust roots for, fails with ErrUnanchored.
+func (b *Bundle) Verify(policy TrustPolicy) (*bbs.PublicKey, error) {
+	keyBytes, err := base64.StdEncoding.DecodeString(b.PublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode public key: %w", err)
+	}
+	publicKey, err := bbs.DeserializePublicKey(keyBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to deserialize public key: %w", err)
+	}
+	fingerprint := publicKey.Fingerprint()
+
+	if len(b.Certificates) == 0 && b.Attestation == "" {
+		return nil, ErrUnanchored
+	}
+
+	if len(b.Certificates) > 0 {
+		if policy.Roots == nil {
+			return nil, fmt.Errorf("%w: no trusted roots for the certificate chain", ErrUnanchored)
+		}
+		chain := make([]*x509.Certificate, len(b.Certificates))
+		for i, encoded := range b.Certificates {
+			der, err := base64.StdEncoding.DecodeString(encoded)
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode certificate %d: %w", i, err)
+			}
+			if chain[i], err = x509.ParseCertificate(der); err != nil {
+				return nil, fmt.Errorf("failed to parse certificate %d: %w", i, err)
+			}
+		}
+		certKey, err := VerifyCertificateChain(chain, x509.VerifyOptions{Roots: policy.Roots, CurrentTime: policy.now()})
+		if err != nil {
+			return nil, err
+		}
+		if certKey.Fingerprint() != fingerprint {
+			return nil, ErrKeyMismatch
+		}
+	}
+
+	if b.Attestation != "" {
+		if len(policy.Attesters) == 0 {
+			return nil, fmt.Errorf("%w: no trusted attesters for the attestation", ErrUnanchored)
+		}
+		var att *jose.KeyAttestation
+		for _, attester := range policy.Attesters {
+			if att, err = jose.DecodeKeyAttestation(b.Attestation, jose.DecodeOptions{ExpectedKey: attester, Now: policy.Now}); err == nil {
+				break
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("attestation is not signed by a trusted attester: %w", err)
+		}
+		if att.PublicKey.Fingerprint() != fingerprint {
+			return nil, ErrKeyMismatch
+		}
+	}
+	return publicKey, nil
+}
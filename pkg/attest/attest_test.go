@@ -0,0 +1,167 @@
+package attest
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/anupsv/bbsplus-signatures/bbs"
+	"github.com/anupsv/bbsplus-signatures/pkg/jose"
+)
+
+// testCA creates a self-signed CA
+func testCA(t *testing.T) (*x509.Certificate, *ecdsa.PrivateKey) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Example Enterprise CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("CreateCertificate failed: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("ParseCertificate failed: %v", err)
+	}
+	return cert, key
+}
+
+// issuerCertificate has the CA certify an issuer carrying publicKey
+func issuerCertificate(t *testing.T, ca *x509.Certificate, caKey *ecdsa.PrivateKey, publicKey *bbs.PublicKey, critical bool) *x509.Certificate {
+	t.Helper()
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	ext := Extension(publicKey)
+	ext.Critical = critical
+	template := &x509.Certificate{
+		SerialNumber:    big.NewInt(2),
+		Subject:         pkix.Name{CommonName: "Credential Issuer"},
+		NotBefore:       time.Now().Add(-time.Hour),
+		NotAfter:        time.Now().Add(time.Hour),
+		KeyUsage:        x509.KeyUsageDigitalSignature,
+		ExtraExtensions: []pkix.Extension{ext},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca, &key.PublicKey, caKey)
+	if err != nil {
+		t.Fatalf("CreateCertificate failed: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("ParseCertificate failed: %v", err)
+	}
+	return cert
+}
+
+func TestCertificateBundle(t *testing.T) {
+	keyPair, err := bbs.GenerateKeyPair(3, rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key pair: %v", err)
+	}
+	ca, caKey := testCA(t)
+	roots := x509.NewCertPool()
+	roots.AddCert(ca)
+
+	for _, critical := range []bool{false, true} {
+		leaf := issuerCertificate(t, ca, caKey, keyPair.PublicKey, critical)
+		bundle, err := NewCertificateBundle(keyPair.PublicKey, []*x509.Certificate{leaf})
+		if err != nil {
+			t.Fatalf("NewCertificateBundle failed: %v", err)
+		}
+
+		// The bundle survives JSON
+		data, _ := json.Marshal(bundle)
+		var decoded Bundle
+		if err := json.Unmarshal(data, &decoded); err != nil {
+			t.Fatalf("Unmarshal failed: %v", err)
+		}
+		publicKey, err := decoded.Verify(TrustPolicy{Roots: roots})
+		if err != nil {
+			t.Fatalf("Verify failed (critical %v): %v", critical, err)
+		}
+		if publicKey.Fingerprint() != keyPair.PublicKey.Fingerprint() {
+			t.Fatalf("Verify returned another key")
+		}
+	}
+
+	// Another CA's roots, or a swapped key, are rejected
+	other, _ := testCA(t)
+	otherRoots := x509.NewCertPool()
+	otherRoots.AddCert(other)
+	leaf := issuerCertificate(t, ca, caKey, keyPair.PublicKey, false)
+	bundle, _ := NewCertificateBundle(keyPair.PublicKey, []*x509.Certificate{leaf})
+	if _, err := bundle.Verify(TrustPolicy{Roots: otherRoots}); err == nil {
+		t.Fatalf("Chain verified under another root")
+	}
+	if _, err := bundle.Verify(TrustPolicy{}); !errors.Is(err, ErrUnanchored) {
+		t.Fatalf("Expected ErrUnanchored without roots, got %v", err)
+	}
+	otherKey, _ := bbs.GenerateKeyPair(3, rand.Reader)
+	swapped := *bundle
+	swapped.PublicKey = NewAttestationBundle(otherKey.PublicKey, "").PublicKey
+	if _, err := swapped.Verify(TrustPolicy{Roots: roots}); !errors.Is(err, ErrKeyMismatch) {
+		t.Fatalf("Expected ErrKeyMismatch, got %v", err)
+	}
+	if _, err := NewCertificateBundle(otherKey.PublicKey, []*x509.Certificate{leaf}); !errors.Is(err, ErrKeyMismatch) {
+		t.Fatalf("Expected ErrKeyMismatch, got %v", err)
+	}
+	if _, err := NewCertificateBundle(keyPair.PublicKey, []*x509.Certificate{ca}); !errors.Is(err, ErrNoKeyExtension) {
+		t.Fatalf("Expected ErrNoKeyExtension, got %v", err)
+	}
+}
+
+func TestAttestationBundle(t *testing.T) {
+	keyPair, err := bbs.GenerateKeyPair(3, rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key pair: %v", err)
+	}
+	attesterKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	attesterDER, _ := x509.MarshalPKIXPublicKey(&attesterKey.PublicKey)
+
+	token, err := jose.EncodeKeyAttestation(&jose.KeyAttestation{
+		Issuer:    "https://issuer.example",
+		Attester:  "https://ca.example",
+		PublicKey: keyPair.PublicKey,
+		ExpiresAt: time.Now().Add(time.Hour),
+	}, attesterKey, jose.EncodeOptions{})
+	if err != nil {
+		t.Fatalf("EncodeKeyAttestation failed: %v", err)
+	}
+	bundle := NewAttestationBundle(keyPair.PublicKey, token)
+
+	otherAttester, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	otherDER, _ := x509.MarshalPKIXPublicKey(&otherAttester.PublicKey)
+	publicKey, err := bundle.Verify(TrustPolicy{Attesters: [][]byte{otherDER, attesterDER}})
+	if err != nil {
+		t.Fatalf("Verify failed: %v", err)
+	}
+	if publicKey.Fingerprint() != keyPair.PublicKey.Fingerprint() {
+		t.Fatalf("Verify returned another key")
+	}
+
+	if _, err := bundle.Verify(TrustPolicy{Attesters: [][]byte{otherDER}}); err == nil {
+		t.Fatalf("Attestation verified under an untrusted attester")
+	}
+	later := func() time.Time { return time.Now().Add(2 * time.Hour) }
+	if _, err := bundle.Verify(TrustPolicy{Attesters: [][]byte{attesterDER}, Now: later}); err == nil {
+		t.Fatalf("Expired attestation verified")
+	}
+	if _, err := (&Bundle{PublicKey: bundle.PublicKey}).Verify(TrustPolicy{Attesters: [][]byte{attesterDER}}); !errors.Is(err, ErrUnanchored) {
+		t.Fatalf("Expected ErrUnanchored, got %v", err)
+	}
+}
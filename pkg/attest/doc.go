@@ -0,0 +1,23 @@
+// Package attest anchors BBS+ issuer keys in existing PKI.
+//
+// An issuer key can be vouched for in two ways. An X.509 certificate from
+// the enterprise CA can carry the key's canonical encoding in a non-critical
+// extension, identified by ExtensionOID, next to the certificate's own
+// subject key. Or a parent authority can sign a JWS key attestation with
+// jose.EncodeKeyAttestation. A Bundle packages the key with either or both,
+// and Bundle.Verify validates every anchor it carries against a TrustPolicy
+// before returning the key:
+//
+//	// Issuer
+//	template.ExtraExtensions = append(template.ExtraExtensions, attest.Extension(keyPair.PublicKey))
+//	// ... have the CA sign the certificate
+//	bundle, err := attest.NewCertificateBundle(keyPair.PublicKey, []*x509.Certificate{leaf, intermediate})
+//
+//	// Verifier
+//	publicKey, err := bundle.Verify(attest.TrustPolicy{Roots: enterpriseRoots})
+//
+// Bundles encode as JSON with the certificate chain in an "x5c" array,
+// leaf first, as in JOSE.
+//
+// Stability: experimental
+package attest
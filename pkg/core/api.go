@@ -1,12 +1,13 @@
 package core
 
 import (
-	"crypto/rand"
 	"fmt"
 	"io"
 	"math/big"
 
+	"github.com/anupsv/bbsplus-signatures/bbs"
 	"github.com/anupsv/bbsplus-signatures/internal/common"
+	bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381"
 )
 
 // GenerateKeyPair creates a new BBS+ key pair for the given number of messages.
@@ -16,17 +17,12 @@ func GenerateKeyPair(messageCount int, rng io.Reader) (*KeyPair, error) {
 		return nil, common.ErrInvalidParameter
 	}
 
-	// Use crypto/rand if no randomness source is provided
-	if rng == nil {
-		rng = rand.Reader
-	}
-
-	return nil, fmt.Errorf("key generation not implemented")
+	return bbs.GenerateKeyPair(messageCount, rng)
 }
 
 // DerivePublicKey derives a public key from a private key for the given number of messages.
 func DerivePublicKey(privateKey *big.Int, messageCount int) (*PublicKey, error) {
-	if privateKey == nil || privateKey.Sign() <= 0 {
+	if privateKey == nil || privateKey.Sign() <= 0 || privateKey.Cmp(Order) >= 0 {
 		return nil, common.ErrInvalidParameter
 	}
 
@@ -34,37 +30,94 @@ func DerivePublicKey(privateKey *big.Int, messageCount int) (*PublicKey, error)
 		return nil, common.ErrInvalidParameter
 	}
 
-	return nil, fmt.Errorf("public key derivation not implemented")
+	// W = g2^x, with the generators bbs.GenerateKeyPair uses
+	_, _, _, g2 := bls12381.Generators()
+
+	var w bls12381.G2Affine
+	w.ScalarMultiplication(&g2, privateKey)
+
+	return bbs.NewPublicKey(w, messageCount), nil
 }
 
 // Sign creates a BBS+ signature on the given messages using the provided key pair.
 // The optional header provides domain separation.
 func Sign(privateKey *PrivateKey, publicKey *PublicKey, messages []*big.Int, header []byte) (*Signature, error) {
+	return SignWithOptions(privateKey, publicKey, messages, SignOptions{Options: Options{Header: header}})
+}
+
+// SignWithOptions creates a BBS+ signature as configured by opts
+func SignWithOptions(privateKey *PrivateKey, publicKey *PublicKey, messages []*big.Int, opts SignOptions) (*Signature, error) {
+	if err := opts.checkCompatMattr(false); err != nil {
+		return nil, err
+	}
+
 	// Validate inputs
 	if privateKey == nil || publicKey == nil {
 		return nil, common.ErrInvalidParameter
 	}
 
-	if len(messages) != publicKey.MessageCount {
+	if len(messages) != publicKey.MessageCount() {
 		return nil, common.ErrMismatchedLengths
 	}
 
-	return nil, fmt.Errorf("signature creation not implemented")
+	if err := opts.checkScalars(messages...); err != nil {
+		return nil, err
+	}
+
+	switch opts.SideChannel {
+	case SideChannelDefault:
+		return bbs.Sign(privateKey, publicKey, messages, opts.Header)
+
+	case SideChannelDeterministic:
+		return bbs.DeterministicSign(privateKey, publicKey, messages, opts.Header, nil)
+
+	case SideChannelHardened:
+		signature, err := bbs.DeterministicSign(privateKey, publicKey, messages, opts.Header, nil)
+		if err != nil {
+			return nil, err
+		}
+		if err := bbs.Verify(publicKey, signature, messages, opts.Header); err != nil {
+			return nil, fmt.Errorf("signature failed self-check: %w", err)
+		}
+		return signature, nil
+	}
+
+	return nil, fmt.Errorf("unknown side-channel mode %d: %w", opts.SideChannel, common.ErrInvalidParameter)
 }
 
 // Verify checks if a BBS+ signature is valid for the given messages and public key.
 // The optional header must match the one used during signing.
 func Verify(publicKey *PublicKey, signature *Signature, messages []*big.Int, header []byte) error {
+	return VerifyWithOptions(publicKey, signature, messages, VerifyOptions{Options: Options{Header: header}})
+}
+
+// VerifyWithOptions checks a BBS+ signature as configured by opts
+func VerifyWithOptions(publicKey *PublicKey, signature *Signature, messages []*big.Int, opts VerifyOptions) error {
 	// Validate inputs
 	if publicKey == nil || signature == nil {
 		return common.ErrInvalidParameter
 	}
 
-	if len(messages) != publicKey.MessageCount {
+	if len(messages) != publicKey.MessageCount() {
 		return common.ErrMismatchedLengths
 	}
 
-	return fmt.Errorf("signature verification not implemented")
+	if err := opts.checkSignature(signature, messages); err != nil {
+		return err
+	}
+
+	if opts.CompatMattr != nil {
+		if err := opts.checkCompatMattr(true); err != nil {
+			return err
+		}
+		mattrKey, err := opts.CompatMattr.PublicKey(publicKey.W(), publicKey.MessageCount())
+		if err != nil {
+			return err
+		}
+		return opts.CompatMattr.Verify(mattrKey, signature, messages)
+	}
+
+	return bbs.Verify(publicKey, signature, messages, opts.Header)
 }
 
 // CreateProof generates a selective disclosure proof for the given messages.
@@ -77,12 +130,27 @@ func CreateProof(
 	disclosedIndices []int,
 	header []byte,
 ) (*ProofOfKnowledge, map[int]*big.Int, error) {
+	return CreateProofWithOptions(publicKey, signature, messages, disclosedIndices, ProofOptions{Options: Options{Header: header}})
+}
+
+// CreateProofWithOptions generates a selective disclosure proof as configured by opts
+func CreateProofWithOptions(
+	publicKey *PublicKey,
+	signature *Signature,
+	messages []*big.Int,
+	disclosedIndices []int,
+	opts ProofOptions,
+) (*ProofOfKnowledge, map[int]*big.Int, error) {
+	if err := opts.checkCompatMattr(false); err != nil {
+		return nil, nil, err
+	}
+
 	// Validate inputs
 	if publicKey == nil || signature == nil {
 		return nil, nil, common.ErrInvalidParameter
 	}
 
-	if len(messages) != publicKey.MessageCount {
+	if len(messages) != publicKey.MessageCount() {
 		return nil, nil, common.ErrMismatchedLengths
 	}
 
@@ -93,13 +161,16 @@ func CreateProof(
 		}
 	}
 
-	// Create a map of disclosed messages
-	disclosedMessages := make(map[int]*big.Int)
-	for _, idx := range disclosedIndices {
-		disclosedMessages[idx] = messages[idx]
+	if opts.Strictness == Strict {
+		if err := opts.checkSignature(signature, messages); err != nil {
+			return nil, nil, err
+		}
+		if err := bbs.Verify(publicKey, signature, messages, opts.Header); err != nil {
+			return nil, nil, err
+		}
 	}
 
-	return nil, disclosedMessages, fmt.Errorf("proof creation not implemented")
+	return bbs.CreateProofWithPresentationHeader(publicKey, signature, messages, disclosedIndices, opts.Header, opts.PresentationHeader)
 }
 
 // VerifyProof checks if a selective disclosure proof is valid.
@@ -110,12 +181,30 @@ func VerifyProof(
 	disclosedMessages map[int]*big.Int,
 	header []byte,
 ) error {
+	return VerifyProofWithOptions(publicKey, proof, disclosedMessages, VerifyOptions{Options: Options{Header: header}})
+}
+
+// VerifyProofWithOptions checks a selective disclosure proof as configured by opts
+func VerifyProofWithOptions(
+	publicKey *PublicKey,
+	proof *ProofOfKnowledge,
+	disclosedMessages map[int]*big.Int,
+	opts VerifyOptions,
+) error {
+	if err := opts.checkCompatMattr(false); err != nil {
+		return err
+	}
+
 	// Validate inputs
 	if publicKey == nil || proof == nil {
 		return common.ErrInvalidParameter
 	}
 
-	return fmt.Errorf("proof verification not implemented")
+	if err := opts.checkProof(proof, disclosedMessages); err != nil {
+		return err
+	}
+
+	return bbs.VerifyProofWithPresentationHeader(publicKey, proof, disclosedMessages, opts.Header, opts.PresentationHeader)
 }
 
 // BatchVerifyProofs verifies multiple proofs in a batch for improved performance.
@@ -126,10 +215,59 @@ func BatchVerifyProofs(
 	disclosedMessagesList []map[int]*big.Int,
 	headers [][]byte,
 ) error {
+	return BatchVerifyProofsWithOptions(keys, proofs, disclosedMessagesList, headers, VerifyOptions{})
+}
+
+// BatchVerifyProofsWithOptions verifies multiple proofs in a batch as
+// configured by opts. A non-empty headers gives the header of each proof;
+// otherwise every proof uses opts.Header. opts.PresentationHeader applies to
+// every proof.
+func BatchVerifyProofsWithOptions(
+	keys []*PublicKey,
+	proofs []*ProofOfKnowledge,
+	disclosedMessagesList []map[int]*big.Int,
+	headers [][]byte,
+	opts VerifyOptions,
+) error {
+	if err := opts.checkCompatMattr(false); err != nil {
+		return err
+	}
+
 	// Validate inputs
 	if len(keys) != len(proofs) || len(proofs) != len(disclosedMessagesList) {
 		return common.ErrMismatchedLengths
 	}
 
-	return fmt.Errorf("batch proof verification not implemented")
-}
\ No newline at end of file
+	if len(headers) != 0 && len(headers) != len(proofs) {
+		return common.ErrMismatchedLengths
+	}
+
+	for i := range proofs {
+		if keys[i] == nil || proofs[i] == nil {
+			return common.ErrInvalidParameter
+		}
+		if err := opts.checkProof(proofs[i], disclosedMessagesList[i]); err != nil {
+			return fmt.Errorf("proof %d: %w", i, err)
+		}
+	}
+
+	if len(headers) == 0 && len(opts.Header) > 0 {
+		headers = repeat(opts.Header, len(proofs))
+	}
+
+	batchOpts := bbs.VerifyOptions{Concurrency: opts.Parallelism}
+	if len(opts.PresentationHeader) > 0 {
+		batchOpts.PresentationHeaders = repeat(opts.PresentationHeader, len(proofs))
+	}
+
+	return bbs.BatchVerifyProofsWithOptions(keys, proofs, disclosedMessagesList, headers, batchOpts)
+}
+
+// repeat returns a slice holding value n times
+func repeat(value []byte, n int) [][]byte {
+	values := make([][]byte, n)
+	for i := range values {
+		values[i] = value
+	}
+	return values
+}
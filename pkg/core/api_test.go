@@ -0,0 +1,299 @@
+package core
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha512"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/anupsv/bbsplus-signatures/bbs"
+	"github.com/anupsv/bbsplus-signatures/bbs/bbstest"
+)
+
+// coreFixture returns a bbstest credential of four attributes, signed under
+// bbstest.Header
+func coreFixture(t *testing.T) (*KeyPair, []*big.Int, *Signature) {
+	t.Helper()
+
+	cred := bbstest.NewCredential(t,
+		bbstest.Attribute{Name: "name", Value: "alice"},
+		bbstest.Attribute{Name: "birthdate", Value: "1990-01-01"},
+		bbstest.Attribute{Name: "country", Value: "NL"},
+		bbstest.Attribute{Name: "tier", Value: "gold"},
+	)
+	return cred.KeyPair, cred.Messages, cred.Signature
+}
+
+// unreduced returns x + Order, which equals x as a field element
+func unreduced(x *big.Int) *big.Int {
+	return new(big.Int).Add(x, Order)
+}
+
+func TestDerivePublicKey(t *testing.T) {
+	keyPair, err := GenerateKeyPair(3, rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKeyPair failed: %v", err)
+	}
+
+	publicKey, err := DerivePublicKey(keyPair.PrivateKey.X, 3)
+	if err != nil {
+		t.Fatalf("DerivePublicKey failed: %v", err)
+	}
+	if !bytes.Equal(bbs.SerializePublicKey(publicKey), bbs.SerializePublicKey(keyPair.PublicKey)) {
+		t.Fatalf("Derived public key differs from the generated one")
+	}
+
+	if _, err := DerivePublicKey(Order, 3); !errors.Is(err, ErrInvalidParameter) {
+		t.Fatalf("Expected ErrInvalidParameter for an unreduced key, got %v", err)
+	}
+}
+
+func TestSignSideChannelModes(t *testing.T) {
+	keyPair, messages, _ := coreFixture(t)
+
+	sign := func(mode SideChannelMode) *Signature {
+		t.Helper()
+		signature, err := SignWithOptions(keyPair.PrivateKey, keyPair.PublicKey, messages, SignOptions{SideChannel: mode})
+		if err != nil {
+			t.Fatalf("SignWithOptions(%d) failed: %v", mode, err)
+		}
+		if err := Verify(keyPair.PublicKey, signature, messages, nil); err != nil {
+			t.Fatalf("Verify(%d) failed: %v", mode, err)
+		}
+		return signature
+	}
+
+	// Randomized signing never repeats, deterministic signing always does
+	if sign(SideChannelDefault).E.Cmp(sign(SideChannelDefault).E) == 0 {
+		t.Fatalf("Default mode produced the same signature twice")
+	}
+	if sign(SideChannelDeterministic).E.Cmp(sign(SideChannelDeterministic).E) != 0 {
+		t.Fatalf("Deterministic mode produced different signatures")
+	}
+	if sign(SideChannelHardened).E.Cmp(sign(SideChannelDeterministic).E) != 0 {
+		t.Fatalf("Hardened mode should sign deterministically")
+	}
+
+	// A signing fault, simulated with a key that does not match the public
+	// key, escapes the default mode but not the hardened one
+	other, err := GenerateKeyPair(4, rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKeyPair failed: %v", err)
+	}
+
+	faulty, err := SignWithOptions(other.PrivateKey, keyPair.PublicKey, messages, SignOptions{})
+	if err != nil {
+		t.Fatalf("SignWithOptions failed: %v", err)
+	}
+	if err := Verify(keyPair.PublicKey, faulty, messages, nil); err == nil {
+		t.Fatalf("Faulty signature verified")
+	}
+
+	_, err = SignWithOptions(other.PrivateKey, keyPair.PublicKey, messages, SignOptions{SideChannel: SideChannelHardened})
+	if !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("Expected hardened signing to catch the fault, got %v", err)
+	}
+
+	if _, err := SignWithOptions(keyPair.PrivateKey, keyPair.PublicKey, messages, SignOptions{SideChannel: 99}); !errors.Is(err, ErrInvalidParameter) {
+		t.Fatalf("Expected ErrInvalidParameter for an unknown mode, got %v", err)
+	}
+}
+
+func TestHeaderOption(t *testing.T) {
+	keyPair, messages, signature := coreFixture(t)
+
+	if err := VerifyWithOptions(keyPair.PublicKey, signature, messages, VerifyOptions{Options: Options{Header: bbstest.Header}}); err != nil {
+		t.Fatalf("VerifyWithOptions failed: %v", err)
+	}
+	if err := VerifyWithOptions(keyPair.PublicKey, signature, messages, VerifyOptions{Options: Options{Header: []byte("other")}}); err == nil {
+		t.Fatalf("Signature verified under another header")
+	}
+}
+
+func TestStrictness(t *testing.T) {
+	keyPair, messages, signature := coreFixture(t)
+	header := bbstest.Header
+	lenient := VerifyOptions{Options: Options{Header: header}}
+	strict := VerifyOptions{Options: Options{Header: header, Strictness: Strict}}
+
+	// Unreduced messages are the same field elements
+	shifted := append([]*big.Int(nil), messages...)
+	shifted[0] = unreduced(shifted[0])
+	if err := VerifyWithOptions(keyPair.PublicKey, signature, shifted, lenient); err != nil {
+		t.Fatalf("Lenient verification rejected an unreduced message: %v", err)
+	}
+	if err := VerifyWithOptions(keyPair.PublicKey, signature, shifted, strict); !errors.Is(err, ErrInvalidFieldElement) {
+		t.Fatalf("Expected ErrInvalidFieldElement for an unreduced message, got %v", err)
+	}
+
+	_, err := SignWithOptions(keyPair.PrivateKey, keyPair.PublicKey, shifted, SignOptions{Options: strict.Options})
+	if !errors.Is(err, ErrInvalidFieldElement) {
+		t.Fatalf("Expected strict signing to reject an unreduced message, got %v", err)
+	}
+
+	// So is an unreduced e
+	malleated := &Signature{A: signature.A, E: unreduced(signature.E), S: signature.S}
+	if err := VerifyWithOptions(keyPair.PublicKey, malleated, messages, lenient); err != nil {
+		t.Fatalf("Lenient verification rejected an unreduced e: %v", err)
+	}
+	if err := VerifyWithOptions(keyPair.PublicKey, malleated, messages, strict); !errors.Is(err, ErrInvalidFieldElement) {
+		t.Fatalf("Expected ErrInvalidFieldElement for an unreduced e, got %v", err)
+	}
+
+	// And an unreduced proof response
+	proof, disclosed, err := CreateProof(keyPair.PublicKey, signature, messages, []int{1}, header)
+	if err != nil {
+		t.Fatalf("CreateProof failed: %v", err)
+	}
+	proof.EHat = unreduced(proof.EHat)
+	if err := VerifyProofWithOptions(keyPair.PublicKey, proof, disclosed, lenient); err != nil {
+		t.Fatalf("Lenient verification rejected an unreduced response: %v", err)
+	}
+	if err := VerifyProofWithOptions(keyPair.PublicKey, proof, disclosed, strict); !errors.Is(err, ErrInvalidFieldElement) {
+		t.Fatalf("Expected ErrInvalidFieldElement for an unreduced response, got %v", err)
+	}
+	err = BatchVerifyProofsWithOptions([]*PublicKey{keyPair.PublicKey}, []*ProofOfKnowledge{proof}, []map[int]*big.Int{disclosed}, nil, strict)
+	if !errors.Is(err, ErrInvalidFieldElement) {
+		t.Fatalf("Expected batch ErrInvalidFieldElement for an unreduced response, got %v", err)
+	}
+
+	// Strict proving refuses a signature that does not verify
+	forged := &Signature{A: signature.A, E: signature.S, S: signature.E}
+	if _, _, err := CreateProofWithOptions(keyPair.PublicKey, forged, messages, []int{1}, ProofOptions{Options: lenient.Options}); err != nil {
+		t.Fatalf("Lenient proving failed: %v", err)
+	}
+	if _, _, err := CreateProofWithOptions(keyPair.PublicKey, forged, messages, []int{1}, ProofOptions{Options: strict.Options}); !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("Expected strict proving to reject a bad signature, got %v", err)
+	}
+}
+
+func TestPresentationHeaderOption(t *testing.T) {
+	keyPair, messages, signature := coreFixture(t)
+	base := Options{Header: bbstest.Header}
+	nonce := []byte("verifier nonce")
+
+	proof, disclosed, err := CreateProofWithOptions(keyPair.PublicKey, signature, messages, []int{0, 2}, ProofOptions{Options: base, PresentationHeader: nonce})
+	if err != nil {
+		t.Fatalf("CreateProofWithOptions failed: %v", err)
+	}
+
+	if err := VerifyProofWithOptions(keyPair.PublicKey, proof, disclosed, VerifyOptions{Options: base, PresentationHeader: nonce}); err != nil {
+		t.Fatalf("VerifyProofWithOptions failed: %v", err)
+	}
+	if err := VerifyProofWithOptions(keyPair.PublicKey, proof, disclosed, VerifyOptions{Options: base, PresentationHeader: []byte("other nonce")}); err == nil {
+		t.Fatalf("Proof verified under another presentation header")
+	}
+	if err := VerifyProof(keyPair.PublicKey, proof, disclosed, base.Header); err == nil {
+		t.Fatalf("Proof verified without its presentation header")
+	}
+
+	keys := []*PublicKey{keyPair.PublicKey, keyPair.PublicKey}
+	proofs := []*ProofOfKnowledge{proof, proof}
+	disclosedList := []map[int]*big.Int{disclosed, disclosed}
+	if err := BatchVerifyProofsWithOptions(keys, proofs, disclosedList, nil, VerifyOptions{Options: base, PresentationHeader: nonce}); err != nil {
+		t.Fatalf("BatchVerifyProofsWithOptions failed: %v", err)
+	}
+	if err := BatchVerifyProofsWithOptions(keys, proofs, disclosedList, nil, VerifyOptions{Options: base}); err == nil {
+		t.Fatalf("Batch verified without the presentation header")
+	}
+}
+
+func TestCiphersuiteOption(t *testing.T) {
+	raw := [][]byte{[]byte("alice"), []byte("1990-01-01")}
+
+	spec := Options{}.Messages(raw...)
+	legacy := Options{Ciphersuite: bbs.LegacySHA256}.Messages(raw...)
+	if spec[0].Cmp(legacy[0]) == 0 {
+		t.Fatalf("Ciphersuites mapped a message identically")
+	}
+	if legacy[0].Cmp(bbs.LegacySHA256.MapMessageToScalar(raw[0])) != 0 {
+		t.Fatalf("Messages ignored the ciphersuite")
+	}
+
+	keyPair, err := GenerateKeyPair(len(raw), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKeyPair failed: %v", err)
+	}
+	signature, err := Sign(keyPair.PrivateKey, keyPair.PublicKey, legacy, nil)
+	if err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+	if err := Verify(keyPair.PublicKey, signature, legacy, nil); err != nil {
+		t.Fatalf("Verify failed: %v", err)
+	}
+	if err := Verify(keyPair.PublicKey, signature, spec, nil); err == nil {
+		t.Fatalf("Signature verified with another ciphersuite's mapping")
+	}
+}
+
+func TestCompatMattrOption(t *testing.T) {
+	profile, err := bbs.NewMattrProfile(sha512.New, sha512.New384)
+	if err != nil {
+		t.Fatalf("NewMattrProfile failed: %v", err)
+	}
+	opts := Options{CompatMattr: profile}
+
+	raw := [][]byte{[]byte("alice"), []byte("1990-01-01")}
+	messages := opts.Messages(raw...)
+	if messages[0].Cmp(profile.MapMessageToScalar(raw[0])) != 0 {
+		t.Fatalf("Messages ignored CompatMattr")
+	}
+
+	keyPair, err := GenerateKeyPair(len(raw), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKeyPair failed: %v", err)
+	}
+	if _, err := SignWithOptions(keyPair.PrivateKey, keyPair.PublicKey, messages, SignOptions{Options: opts}); !errors.Is(err, ErrCompatMattr) {
+		t.Fatalf("Expected ErrCompatMattr from Sign, got %v", err)
+	}
+	signature, err := Sign(keyPair.PrivateKey, keyPair.PublicKey, messages, nil)
+	if err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+	if _, _, err := CreateProofWithOptions(keyPair.PublicKey, signature, messages, nil, ProofOptions{Options: opts}); !errors.Is(err, ErrCompatMattr) {
+		t.Fatalf("Expected ErrCompatMattr from CreateProof, got %v", err)
+	}
+
+	// A BBS signature is not a mattr signature
+	if err := VerifyWithOptions(keyPair.PublicKey, signature, messages, VerifyOptions{Options: opts}); !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("Expected ErrInvalidSignature, got %v", err)
+	}
+	opts.Header = []byte("header")
+	if err := VerifyWithOptions(keyPair.PublicKey, signature, messages, VerifyOptions{Options: opts}); !errors.Is(err, ErrCompatMattr) {
+		t.Fatalf("Expected ErrCompatMattr for a header, got %v", err)
+	}
+}
+
+func TestBatchParallelism(t *testing.T) {
+	keyPair, messages, signature := coreFixture(t)
+	header := bbstest.Header
+
+	keys := make([]*PublicKey, 8)
+	proofs := make([]*ProofOfKnowledge, 8)
+	disclosedList := make([]map[int]*big.Int, 8)
+	for i := range proofs {
+		proof, disclosed, err := CreateProof(keyPair.PublicKey, signature, messages, []int{i % 4}, header)
+		if err != nil {
+			t.Fatalf("CreateProof failed: %v", err)
+		}
+		keys[i], proofs[i], disclosedList[i] = keyPair.PublicKey, proof, disclosed
+	}
+
+	for _, parallelism := range []int{0, 1, 4} {
+		opts := VerifyOptions{Options: Options{Header: header}, Parallelism: parallelism}
+		if err := BatchVerifyProofsWithOptions(keys, proofs, disclosedList, nil, opts); err != nil {
+			t.Fatalf("BatchVerifyProofsWithOptions(parallelism %d) failed: %v", parallelism, err)
+		}
+	}
+
+	// Per-proof headers take precedence over opts.Header
+	headers := make([][]byte, len(proofs))
+	for i := range headers {
+		headers[i] = []byte("other")
+	}
+	if err := BatchVerifyProofsWithOptions(keys, proofs, disclosedList, headers, VerifyOptions{Options: Options{Header: header}}); err == nil {
+		t.Fatalf("Batch verified under the wrong per-proof headers")
+	}
+}
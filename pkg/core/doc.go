@@ -20,16 +20,35 @@
 //     // Verify proof
 //     err = core.VerifyProof(keyPair.PublicKey, proof, disclosedMsgs, nil)
 //
+// Each function has a WithOptions variant taking SignOptions, ProofOptions or
+// VerifyOptions, which add presentation headers, ciphersuite message mapping,
+// strict scalar checks, side-channel hardened signing and batch parallelism.
+//
 // The core package leverages the crypto, proof, and utils packages internally
 // but presents a simplified API for most common operations.
+//
+// Stability: stable
 package core
 
+import (
+	"github.com/anupsv/bbsplus-signatures/internal/version"
+)
+
 // Version information
 const (
 	// Major version component
-	VersionMajor = 1
+	VersionMajor = version.Major
 	// Minor version component
-	VersionMinor = 0
+	VersionMinor = version.Minor
 	// Patch version component
-	VersionPatch = 0
-)
\ No newline at end of file
+	VersionPatch = version.Patch
+)
+
+// BuildInfo is the build metadata of the library
+type BuildInfo = version.Info
+
+// Build returns the version, commit, build date and supported ciphersuites
+// the library was built with
+func Build() BuildInfo {
+	return version.Get()
+}
\ No newline at end of file
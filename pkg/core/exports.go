@@ -3,16 +3,17 @@ package core
 import (
 	"math/big"
 
+	"github.com/anupsv/bbsplus-signatures/bbs"
 	"github.com/anupsv/bbsplus-signatures/internal/common"
 )
 
 // Public error variables from the BBS+ library
 var (
 	// ErrInvalidSignature indicates a signature verification failure
-	ErrInvalidSignature = common.ErrInvalidSignature
+	ErrInvalidSignature = bbs.ErrInvalidSignature
 
 	// ErrInvalidProof indicates a proof verification failure
-	ErrInvalidProof = common.ErrInvalidProof
+	ErrInvalidProof = bbs.ErrInvalidProof
 
 	// ErrInvalidPublicKey indicates an invalid public key
 	ErrInvalidPublicKey = common.ErrInvalidPublicKey
@@ -22,6 +23,9 @@ var (
 
 	// ErrMismatchedLengths indicates mismatched lengths in inputs
 	ErrMismatchedLengths = common.ErrMismatchedLengths
+
+	// ErrInvalidFieldElement indicates a scalar outside [0, Order) under Strict
+	ErrInvalidFieldElement = bbs.ErrInvalidFieldElement
 )
 
 // BLS12-381 curve constants
@@ -0,0 +1,181 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/anupsv/bbsplus-signatures/bbs"
+)
+
+// Strictness selects how strictly scalars are checked
+type Strictness int
+
+const (
+	// Lenient uses every scalar modulo Order, as the bbs package does. A
+	// message m and m + Order sign and verify alike.
+	Lenient Strictness = iota
+
+	// Strict rejects messages, signature scalars and proof responses outside
+	// [0, Order) with ErrInvalidFieldElement, so every accepted value has a
+	// single encoding. CreateProof also verifies the signature before
+	// deriving a proof from it.
+	Strict
+)
+
+// SideChannelMode selects how signing guards the private key
+type SideChannelMode int
+
+const (
+	// SideChannelDefault draws e and s from crypto/rand
+	SideChannelDefault SideChannelMode = iota
+
+	// SideChannelDeterministic derives e and s from the key and the signed
+	// data, similar to RFC 6979, so a weak or observable random number
+	// generator cannot leak the key. Signing the same messages twice gives
+	// the same signature.
+	SideChannelDeterministic
+
+	// SideChannelHardened signs deterministically and verifies the signature
+	// before returning it. A fault injected while computing A = B/(x+e)
+	// yields a signature that can reveal x; hardened signing returns
+	// ErrInvalidSignature instead of releasing it.
+	SideChannelHardened
+)
+
+// Options are the settings shared by every operation
+type Options struct {
+	// Header is bound into the signature and must match on every operation
+	Header []byte
+
+	// Ciphersuite maps raw messages to scalars in Messages. Nil uses
+	// bbs.DefaultCiphersuite.
+	Ciphersuite *bbs.Ciphersuite
+
+	// Strictness selects how strictly scalars are checked
+	Strictness Strictness
+
+	// CompatMattr verifies credentials issued with the mattrglobal library
+	// during a migration: Messages maps with its message mapping and Verify
+	// checks its signatures under generators derived from the key's W and
+	// MessageCount. Signing and proofs are not supported in this mode, and
+	// Header must be empty.
+	CompatMattr *bbs.MattrProfile
+}
+
+// SignOptions configures Sign
+type SignOptions struct {
+	Options
+
+	// SideChannel selects how signing guards the private key
+	SideChannel SideChannelMode
+}
+
+// ProofOptions configures CreateProof
+type ProofOptions struct {
+	Options
+
+	// PresentationHeader is bound into the proof challenge, for example a
+	// verifier nonce. The proof only verifies with the same value.
+	PresentationHeader []byte
+}
+
+// VerifyOptions configures Verify, VerifyProof and BatchVerifyProofs
+type VerifyOptions struct {
+	Options
+
+	// PresentationHeader must match the one the proof was created with.
+	// Signatures have none and ignore it.
+	PresentationHeader []byte
+
+	// Parallelism is the number of workers verifying a batch. Zero or
+	// negative uses runtime.GOMAXPROCS(0); one verifies the batch
+	// sequentially.
+	Parallelism int
+}
+
+// Messages maps raw messages to scalars with the ciphersuite in o
+func (o Options) Messages(raw ...[]byte) []*big.Int {
+	if o.CompatMattr != nil {
+		messages := make([]*big.Int, len(raw))
+		for i, msg := range raw {
+			messages[i] = o.CompatMattr.MapMessageToScalar(msg)
+		}
+		return messages
+	}
+
+	suite := o.Ciphersuite
+	if suite == nil {
+		suite = bbs.DefaultCiphersuite
+	}
+
+	messages := make([]*big.Int, len(raw))
+	for i, msg := range raw {
+		messages[i] = suite.MapMessageToScalar(msg)
+	}
+	return messages
+}
+
+// ErrCompatMattr is returned for operations CompatMattr does not support
+var ErrCompatMattr = errors.New("not supported in mattr compatibility mode")
+
+// checkCompatMattr rejects every operation but verifying a signature with
+// no header under CompatMattr
+func (o Options) checkCompatMattr(signatureOnly bool) error {
+	if o.CompatMattr == nil {
+		return nil
+	}
+	if !signatureOnly {
+		return fmt.Errorf("%w: only signatures can be verified", ErrCompatMattr)
+	}
+	if len(o.Header) != 0 {
+		return fmt.Errorf("%w: mattr signatures have no header", ErrCompatMattr)
+	}
+	return nil
+}
+
+// checkScalars returns ErrInvalidFieldElement under Strict if any scalar is
+// outside [0, Order)
+func (o Options) checkScalars(scalars ...*big.Int) error {
+	if o.Strictness != Strict {
+		return nil
+	}
+
+	for _, x := range scalars {
+		if x == nil || x.Sign() < 0 || x.Cmp(Order) >= 0 {
+			return ErrInvalidFieldElement
+		}
+	}
+	return nil
+}
+
+// checkSignature checks the scalars of a signature and its messages
+func (o Options) checkSignature(signature *Signature, messages []*big.Int) error {
+	if err := o.checkScalars(signature.E, signature.S); err != nil {
+		return err
+	}
+	return o.checkScalars(messages...)
+}
+
+// checkProof checks the responses of a proof and its disclosed messages
+func (o Options) checkProof(proof *ProofOfKnowledge, disclosedMessages map[int]*big.Int) error {
+	if err := o.checkScalars(proof.C, proof.EHat, proof.SHat, proof.R1Hat, proof.R3Hat); err != nil {
+		return err
+	}
+	for _, x := range proof.MHat {
+		if err := o.checkScalars(x); err != nil {
+			return err
+		}
+	}
+	for _, x := range proof.CommitmentHat {
+		if err := o.checkScalars(x); err != nil {
+			return err
+		}
+	}
+	for _, x := range disclosedMessages {
+		if err := o.checkScalars(x); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,531 @@
+package credential
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"time"
+
+	"github.com/anupsv/bbsplus-signatures/bbs"
+)
+
+// An attribute is a named, typed value with a rule for turning it into the
+// message the issuer signs. Hashed attributes sign the value's canonical
+// text, so string attributes sign exactly what credentials did before types
+// existed. Integer-encoded attributes place the value in the message itself,
+// which lets a verifier read a disclosed value back and compare it.
+//
+// A hashed message of a guessable value, such as "yes" or a country code,
+// can be found by hashing every candidate, should it ever be exposed.
+// Salted attributes hash the value behind a random salt the issuer draws,
+// so a hidden message reveals nothing without the salt. The salt is stored
+// in the credential and travels with the attribute when it is disclosed, so
+// verifiers recompute the message from the presentation alone.
+
+// ErrInvalidAttribute is returned for attributes whose value does not fit
+// their type or encoding
+var ErrInvalidAttribute = errors.New("invalid attribute")
+
+// AttributeType is the type of an attribute value
+type AttributeType string
+
+const (
+	TypeString AttributeType = "string"
+	TypeInt    AttributeType = "int64"
+	TypeBool   AttributeType = "bool"
+	TypeTime   AttributeType = "time"
+	TypeBytes  AttributeType = "bytes"
+)
+
+// Encoding is the rule that maps an attribute value to its message
+type Encoding string
+
+const (
+	// EncodingHash maps the value's canonical text with the message mapping
+	// of the ciphersuite
+	EncodingHash Encoding = "hash"
+
+	// EncodingInteger packs an int64, bool or time value into the message
+	// behind a tag of its type. Order is preserved within a type, and
+	// DecodeAttribute reads a disclosed value back.
+	EncodingInteger Encoding = "integer"
+
+	// EncodingSaltedHash maps the value's canonical text behind a random
+	// salt. The issuer draws the salt when the attribute has none.
+	EncodingSaltedHash Encoding = "salted-hash"
+)
+
+const (
+	// integerTagDST derives the type tags of integer-encoded messages
+	integerTagDST = "BBS_CREDENTIAL_ATTRIBUTE_INTEGER_"
+
+	// saltedHashDST separates salted messages from unsalted ones
+	saltedHashDST = "BBS_CREDENTIAL_ATTRIBUTE_SALTED_HASH_"
+
+	// AttributeSaltSize is the length of attribute salts
+	AttributeSaltSize = 32
+)
+
+// AttributeSpec declares an attribute of a schema
+type AttributeSpec struct {
+	Name     string        `json:"name"`
+	Type     AttributeType `json:"type"`
+	Encoding Encoding      `json:"encoding,omitempty"`
+}
+
+// Attribute is a named, typed credential attribute
+type Attribute struct {
+	Name     string
+	Type     AttributeType
+	Encoding Encoding
+
+	// value is a string, int64, bool, time.Time or []byte by Type
+	value any
+
+	// salt is the salt of a salted attribute, nil until it is drawn
+	salt []byte
+}
+
+// StringAttribute returns a hashed string attribute
+func StringAttribute(name, value string) Attribute {
+	return Attribute{Name: name, Type: TypeString, Encoding: EncodingHash, value: value}
+}
+
+// IntAttribute returns an integer-encoded int64 attribute
+func IntAttribute(name string, value int64) Attribute {
+	return Attribute{Name: name, Type: TypeInt, Encoding: EncodingInteger, value: value}
+}
+
+// BoolAttribute returns an integer-encoded bool attribute
+func BoolAttribute(name string, value bool) Attribute {
+	return Attribute{Name: name, Type: TypeBool, Encoding: EncodingInteger, value: value}
+}
+
+// TimeAttribute returns an integer-encoded time attribute, at second
+// precision in UTC
+func TimeAttribute(name string, value time.Time) Attribute {
+	return Attribute{Name: name, Type: TypeTime, Encoding: EncodingInteger, value: value.UTC().Truncate(time.Second)}
+}
+
+// BytesAttribute returns a hashed bytes attribute holding a copy of value
+func BytesAttribute(name string, value []byte) Attribute {
+	return Attribute{Name: name, Type: TypeBytes, Encoding: EncodingHash, value: bytes.Clone(value)}
+}
+
+// ParseAttribute parses an attribute from its canonical text, as returned by
+// Text, with the default encoding of its type
+func ParseAttribute(name string, typ AttributeType, text string) (Attribute, error) {
+	switch typ {
+	case TypeString, "":
+		return StringAttribute(name, text), nil
+	case TypeInt:
+		v, err := strconv.ParseInt(text, 10, 64)
+		if err != nil {
+			return Attribute{}, fmt.Errorf("%w: '%s' is not an int64: %v", ErrInvalidAttribute, name, err)
+		}
+		return IntAttribute(name, v), nil
+	case TypeBool:
+		v, err := strconv.ParseBool(text)
+		if err != nil {
+			return Attribute{}, fmt.Errorf("%w: '%s' is not a bool: %v", ErrInvalidAttribute, name, err)
+		}
+		return BoolAttribute(name, v), nil
+	case TypeTime:
+		v, err := time.Parse(time.RFC3339, text)
+		if err != nil {
+			return Attribute{}, fmt.Errorf("%w: '%s' is not an RFC 3339 time: %v", ErrInvalidAttribute, name, err)
+		}
+		return TimeAttribute(name, v), nil
+	case TypeBytes:
+		v, err := base64.RawURLEncoding.DecodeString(text)
+		if err != nil {
+			return Attribute{}, fmt.Errorf("%w: '%s' is not base64url: %v", ErrInvalidAttribute, name, err)
+		}
+		return BytesAttribute(name, v), nil
+	default:
+		return Attribute{}, fmt.Errorf("%w: unknown type '%s' of '%s'", ErrInvalidAttribute, typ, name)
+	}
+}
+
+// WithEncoding returns a copy of the attribute with another encoding.
+// Changing the encoding drops any salt.
+func (a Attribute) WithEncoding(encoding Encoding) Attribute {
+	if encoding != a.Encoding {
+		a.salt = nil
+	}
+	a.Encoding = encoding
+	return a
+}
+
+// WithSalt returns a copy of the attribute salted with salt, which must be
+// AttributeSaltSize bytes. Issuers need not call it: salted attributes
+// without a salt get a random one when they are signed.
+func (a Attribute) WithSalt(salt []byte) Attribute {
+	a.Encoding = EncodingSaltedHash
+	a.salt = bytes.Clone(salt)
+	return a
+}
+
+// Salt returns the salt of a salted attribute, or nil
+func (a Attribute) Salt() []byte {
+	return bytes.Clone(a.salt)
+}
+
+// NewAttributeSalt draws a random attribute salt
+func NewAttributeSalt() ([]byte, error) {
+	salt := make([]byte, AttributeSaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("failed to generate attribute salt: %w", err)
+	}
+	return salt, nil
+}
+
+// salted returns the attribute with a fresh salt if it is salted and has
+// none yet
+func (a Attribute) salted() (Attribute, error) {
+	if a.Encoding != EncodingSaltedHash || a.salt != nil {
+		return a, nil
+	}
+	salt, err := NewAttributeSalt()
+	if err != nil {
+		return Attribute{}, err
+	}
+	a.salt = salt
+	return a, nil
+}
+
+// Spec returns the name, type and encoding of the attribute
+func (a Attribute) Spec() AttributeSpec {
+	return AttributeSpec{Name: a.Name, Type: a.Type, Encoding: a.Encoding}
+}
+
+// Value returns the value as a string, int64, bool, time.Time or []byte
+func (a Attribute) Value() any {
+	if b, ok := a.value.([]byte); ok {
+		return bytes.Clone(b)
+	}
+	return a.value
+}
+
+// Text returns the canonical text of the value: the string itself, decimal
+// integers, "true" or "false", RFC 3339 times and unpadded base64url bytes
+func (a Attribute) Text() string {
+	switch v := a.value.(type) {
+	case string:
+		return v
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case bool:
+		return strconv.FormatBool(v)
+	case time.Time:
+		return v.Format(time.RFC3339)
+	case []byte:
+		return base64.RawURLEncoding.EncodeToString(v)
+	default:
+		return ""
+	}
+}
+
+// Equal reports whether two attributes have the same name, type, encoding,
+// salt and value
+func (a Attribute) Equal(b Attribute) bool {
+	if a.Name != b.Name || a.Type != b.Type || a.Encoding != b.Encoding || !bytes.Equal(a.salt, b.salt) {
+		return false
+	}
+	if x, ok := a.value.([]byte); ok {
+		y, ok := b.value.([]byte)
+		return ok && bytes.Equal(x, y)
+	}
+	if x, ok := a.value.(time.Time); ok {
+		y, ok := b.value.(time.Time)
+		return ok && x.Equal(y)
+	}
+	return a.value == b.value
+}
+
+// Compare orders two int64, bool or time attributes of the same type by
+// value, for predicates such as minimum ages or expiry dates
+func (a Attribute) Compare(b Attribute) (int, error) {
+	x, err := a.integer()
+	if err != nil {
+		return 0, err
+	}
+	if a.Type != b.Type {
+		return 0, fmt.Errorf("%w: cannot compare %s with %s", ErrInvalidAttribute, a.Type, b.Type)
+	}
+	y, err := b.integer()
+	if err != nil {
+		return 0, err
+	}
+	switch {
+	case x < y:
+		return -1, nil
+	case x > y:
+		return 1, nil
+	default:
+		return 0, nil
+	}
+}
+
+// Message maps the attribute to the message it is signed as. A nil suite
+// uses bbs.DefaultCiphersuite.
+func (a Attribute) Message(suite *bbs.Ciphersuite) (*big.Int, error) {
+	if err := a.validate(); err != nil {
+		return nil, err
+	}
+
+	switch a.Encoding {
+	case EncodingHash, EncodingSaltedHash:
+		if suite == nil {
+			suite = bbs.DefaultCiphersuite
+		}
+		data := []byte(a.Text())
+		if b, ok := a.value.([]byte); ok {
+			data = b
+		}
+		if a.Encoding == EncodingSaltedHash {
+			data = saltedMessage(a.salt, data)
+		}
+		return suite.MapMessageToScalar(data), nil
+	default:
+		v, err := a.integer()
+		if err != nil {
+			return nil, err
+		}
+		// Offset binary keeps negative values below positive ones
+		return packInteger(a.Type, uint64(v)^(1<<63)), nil
+	}
+}
+
+// DecodeAttribute reads an integer-encoded attribute back from its disclosed
+// message
+func DecodeAttribute(spec AttributeSpec, msg *big.Int) (Attribute, error) {
+	if spec.Encoding != EncodingInteger || (spec.Type != TypeInt && spec.Type != TypeBool && spec.Type != TypeTime) {
+		return Attribute{}, fmt.Errorf("%w: only integer-encoded attributes can be decoded", ErrInvalidAttribute)
+	}
+
+	var buf [16]byte
+	if msg == nil || msg.Sign() < 0 || msg.BitLen() > 128 {
+		return Attribute{}, fmt.Errorf("%w: message of '%s' is not integer-encoded", ErrInvalidAttribute, spec.Name)
+	}
+	msg.FillBytes(buf[:])
+	if !bytes.Equal(buf[:8], integerTag(spec.Type)) {
+		return Attribute{}, fmt.Errorf("%w: message of '%s' is not an integer-encoded %s", ErrInvalidAttribute, spec.Name, spec.Type)
+	}
+	v := int64(binary.BigEndian.Uint64(buf[8:]) ^ (1 << 63))
+
+	switch spec.Type {
+	case TypeInt:
+		return IntAttribute(spec.Name, v), nil
+	case TypeBool:
+		if v != 0 && v != 1 {
+			return Attribute{}, fmt.Errorf("%w: '%s' is not a bool", ErrInvalidAttribute, spec.Name)
+		}
+		return BoolAttribute(spec.Name, v == 1), nil
+	default:
+		return TimeAttribute(spec.Name, time.Unix(v, 0)), nil
+	}
+}
+
+// validate checks that the value fits the type and the encoding
+func (a Attribute) validate() error {
+	if a.Name == "" {
+		return fmt.Errorf("%w: attribute has no name", ErrInvalidAttribute)
+	}
+
+	var ok bool
+	switch a.Type {
+	case TypeString:
+		_, ok = a.value.(string)
+	case TypeInt:
+		_, ok = a.value.(int64)
+	case TypeBool:
+		_, ok = a.value.(bool)
+	case TypeTime:
+		_, ok = a.value.(time.Time)
+	case TypeBytes:
+		_, ok = a.value.([]byte)
+	}
+	if !ok {
+		return fmt.Errorf("%w: '%s' has no %s value", ErrInvalidAttribute, a.Name, a.Type)
+	}
+
+	if a.Encoding != EncodingSaltedHash && a.salt != nil {
+		return fmt.Errorf("%w: '%s' has a salt but is %s-encoded", ErrInvalidAttribute, a.Name, a.Encoding)
+	}
+	switch a.Encoding {
+	case EncodingHash:
+	case EncodingSaltedHash:
+		if len(a.salt) != AttributeSaltSize {
+			return fmt.Errorf("%w: salted attribute '%s' needs a %d-byte salt", ErrInvalidAttribute, a.Name, AttributeSaltSize)
+		}
+	case EncodingInteger:
+		if a.Type == TypeString || a.Type == TypeBytes {
+			return fmt.Errorf("%w: %s attribute '%s' cannot be integer-encoded", ErrInvalidAttribute, a.Type, a.Name)
+		}
+	default:
+		return fmt.Errorf("%w: unknown encoding '%s' of '%s'", ErrInvalidAttribute, a.Encoding, a.Name)
+	}
+	return nil
+}
+
+// integer returns the int64 of an int64, bool or time value
+func (a Attribute) integer() (int64, error) {
+	switch v := a.value.(type) {
+	case int64:
+		return v, nil
+	case bool:
+		if v {
+			return 1, nil
+		}
+		return 0, nil
+	case time.Time:
+		return v.Unix(), nil
+	default:
+		return 0, fmt.Errorf("%w: %s attribute '%s' has no order", ErrInvalidAttribute, a.Type, a.Name)
+	}
+}
+
+// saltedMessage returns the input hashed for a salted value:
+// DST || len(salt) || salt || data, with the length as 8 bytes big-endian
+func saltedMessage(salt, data []byte) []byte {
+	out := make([]byte, 0, len(saltedHashDST)+8+len(salt)+len(data))
+	out = append(out, saltedHashDST...)
+	out = binary.BigEndian.AppendUint64(out, uint64(len(salt)))
+	out = append(out, salt...)
+	return append(out, data...)
+}
+
+// packInteger places v in the low 64 bits and the tag of typ in the 64 bits
+// above, keeping the element far below Order
+func packInteger(typ AttributeType, v uint64) *big.Int {
+	var buf [16]byte
+	copy(buf[:8], integerTag(typ))
+	binary.BigEndian.PutUint64(buf[8:], v)
+	return new(big.Int).SetBytes(buf[:])
+}
+
+// integerTag returns the 8-byte tag of integer-encoded values of typ
+func integerTag(typ AttributeType) []byte {
+	sum := sha256.Sum256([]byte(integerTagDST + string(typ)))
+	return sum[:8]
+}
+
+// attributeJSON is the wire form of an Attribute. Values are JSON strings,
+// numbers and booleans, times RFC 3339 strings and bytes unpadded base64url.
+// Salts are unpadded base64url.
+type attributeJSON struct {
+	Name     string          `json:"name"`
+	Type     AttributeType   `json:"type"`
+	Encoding Encoding        `json:"encoding"`
+	Value    json.RawMessage `json:"value"`
+	Salt     string          `json:"salt,omitempty"`
+}
+
+// MarshalJSON serializes the attribute with its type, encoding and salt
+func (a Attribute) MarshalJSON() ([]byte, error) {
+	if err := a.validate(); err != nil {
+		return nil, err
+	}
+
+	var value any = a.Text()
+	switch v := a.value.(type) {
+	case int64, bool:
+		value = v
+	}
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(attributeJSON{
+		Name:     a.Name,
+		Type:     a.Type,
+		Encoding: a.Encoding,
+		Value:    raw,
+		Salt:     base64.RawURLEncoding.EncodeToString(a.salt),
+	})
+}
+
+// UnmarshalJSON deserializes an attribute written by MarshalJSON with the
+// strict decoder
+func (a *Attribute) UnmarshalJSON(data []byte) error {
+	return a.decode(data, DecodeOptions{})
+}
+
+// decode deserializes an attribute written by MarshalJSON with opts
+func (a *Attribute) decode(data []byte, opts DecodeOptions) error {
+	var temp attributeJSON
+	if err := opts.unmarshal(data, &temp); err != nil {
+		return err
+	}
+	if !opts.Lenient && (len(temp.Value) == 0 || string(temp.Value) == "null") {
+		return fmt.Errorf("%w: '%s' has no value", ErrInvalidAttribute, temp.Name)
+	}
+
+	var text string
+	switch temp.Type {
+	case TypeInt:
+		var v int64
+		if err := json.Unmarshal(temp.Value, &v); err != nil {
+			return fmt.Errorf("%w: '%s' is not an int64", ErrInvalidAttribute, temp.Name)
+		}
+		text = strconv.FormatInt(v, 10)
+	case TypeBool:
+		var v bool
+		if err := json.Unmarshal(temp.Value, &v); err != nil {
+			return fmt.Errorf("%w: '%s' is not a bool", ErrInvalidAttribute, temp.Name)
+		}
+		text = strconv.FormatBool(v)
+	default:
+		if err := json.Unmarshal(temp.Value, &text); err != nil {
+			return fmt.Errorf("%w: '%s' is not a string", ErrInvalidAttribute, temp.Name)
+		}
+	}
+
+	parsed, err := ParseAttribute(temp.Name, temp.Type, text)
+	if err != nil {
+		return err
+	}
+	if temp.Encoding != "" {
+		parsed.Encoding = temp.Encoding
+	}
+	if temp.Salt != "" {
+		if parsed.salt, err = base64.RawURLEncoding.DecodeString(temp.Salt); err != nil {
+			return fmt.Errorf("%w: salt of '%s' is not base64url: %v", ErrInvalidAttribute, temp.Name, err)
+		}
+	}
+	if err := parsed.validate(); err != nil {
+		return err
+	}
+	*a = parsed
+	return nil
+}
+
+// decodeAttributes deserializes an array of attributes with opts. An empty
+// or null array has no attributes.
+func decodeAttributes(raw json.RawMessage, opts DecodeOptions) ([]Attribute, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	var items []json.RawMessage
+	if err := json.Unmarshal(raw, &items); err != nil {
+		return nil, err
+	}
+	if items == nil {
+		return nil, nil
+	}
+	attributes := make([]Attribute, len(items))
+	for i, item := range items {
+		if err := attributes[i].decode(item, opts); err != nil {
+			return nil, err
+		}
+	}
+	return attributes, nil
+}
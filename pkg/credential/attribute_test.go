@@ -0,0 +1,243 @@
+package credential
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/anupsv/bbsplus-signatures/bbs"
+)
+
+func TestTypedCredential(t *testing.T) {
+	keyPair, err := bbs.GenerateKeyPair(5, rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key pair: %v", err)
+	}
+
+	birth := time.Date(1990, 4, 1, 0, 0, 0, 0, time.UTC)
+	cred, err := NewBuilder().
+		SetSchema("https://example.com/schemas/member").
+		DeclareAttributes(
+			AttributeSpec{Name: "name", Type: TypeString},
+			AttributeSpec{Name: "birthDate", Type: TypeTime},
+			AttributeSpec{Name: "points", Type: TypeInt},
+			AttributeSpec{Name: "active", Type: TypeBool},
+			AttributeSpec{Name: "photo", Type: TypeBytes},
+		).
+		Add(BytesAttribute("photo", []byte{0xff, 0x00, 0x10})).
+		Add(IntAttribute("points", -42), BoolAttribute("active", true)).
+		AddAttribute("name", "Alice").
+		Add(TimeAttribute("birthDate", birth)).
+		Issue(keyPair)
+	if err != nil {
+		t.Fatalf("Issue failed: %v", err)
+	}
+
+	// Signed in declared order, not the order added
+	if names := cred.AttributeNames(); !slices.Equal(names, []string{"name", "birthDate", "points", "active", "photo"}) {
+		t.Fatalf("Unexpected attribute order %v", names)
+	}
+
+	data, err := json.Marshal(cred)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	var decoded Credential
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if err := decoded.Verify(); err != nil {
+		t.Fatalf("Verify after round trip failed: %v", err)
+	}
+	if !slices.EqualFunc(decoded.Attributes, cred.Attributes, Attribute.Equal) {
+		t.Fatalf("Round trip changed the attributes")
+	}
+
+	points, _ := decoded.Attribute("points")
+	if v, ok := points.Value().(int64); !ok || v != -42 {
+		t.Fatalf("Expected int64 -42, got %v", points.Value())
+	}
+
+	// A declaration of another type is rejected
+	_, err = NewBuilder().
+		DeclareAttributes(AttributeSpec{Name: "points", Type: TypeString}).
+		Add(IntAttribute("points", 1)).
+		Issue(keyPair)
+	if !errors.Is(err, ErrInvalidAttribute) {
+		t.Fatalf("Expected ErrInvalidAttribute, got %v", err)
+	}
+}
+
+func TestIntegerEncoding(t *testing.T) {
+	birth := TimeAttribute("birthDate", time.Date(2001, 7, 9, 10, 30, 0, 0, time.UTC))
+	cutoff := TimeAttribute("birthDate", time.Date(2008, 7, 9, 0, 0, 0, 0, time.UTC))
+
+	msg, err := birth.Message(nil)
+	if err != nil {
+		t.Fatalf("Message failed: %v", err)
+	}
+	decoded, err := DecodeAttribute(birth.Spec(), msg)
+	if err != nil {
+		t.Fatalf("DecodeAttribute failed: %v", err)
+	}
+	if !decoded.Equal(birth) {
+		t.Fatalf("Decoded %v, expected %v", decoded.Text(), birth.Text())
+	}
+	if c, err := decoded.Compare(cutoff); err != nil || c >= 0 {
+		t.Fatalf("Expected the birth date before the cutoff, got %d, %v", c, err)
+	}
+
+	// Messages keep the order of negative and positive values
+	low, _ := IntAttribute("n", -5).Message(nil)
+	high, _ := IntAttribute("n", 3).Message(nil)
+	if low.Cmp(high) >= 0 {
+		t.Fatalf("Integer encoding does not preserve order")
+	}
+
+	// The tag tells types apart
+	if _, err := DecodeAttribute(AttributeSpec{Name: "n", Type: TypeBool, Encoding: EncodingInteger}, high); !errors.Is(err, ErrInvalidAttribute) {
+		t.Fatalf("Expected ErrInvalidAttribute for another type, got %v", err)
+	}
+	if _, err := StringAttribute("s", "x").WithEncoding(EncodingInteger).Message(nil); !errors.Is(err, ErrInvalidAttribute) {
+		t.Fatalf("Expected ErrInvalidAttribute for an integer-encoded string, got %v", err)
+	}
+}
+
+func TestSaltedAttributes(t *testing.T) {
+	keyPair, err := bbs.GenerateKeyPair(3, rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key pair: %v", err)
+	}
+
+	cred, err := NewBuilder().
+		SetSchema("https://example.com/schemas/member").
+		DeclareAttributes(
+			AttributeSpec{Name: "name", Type: TypeString},
+			AttributeSpec{Name: "vaccinated", Type: TypeString, Encoding: EncodingSaltedHash},
+			AttributeSpec{Name: "adult", Type: TypeBool},
+		).
+		AddAttribute("name", "Alice").
+		AddAttribute("vaccinated", "yes").
+		Add(BoolAttribute("adult", true)).
+		SaltAttributes("adult").
+		Issue(keyPair)
+	if err != nil {
+		t.Fatalf("Issue failed: %v", err)
+	}
+
+	// The declared and the configured attribute are salted, the other not
+	for _, name := range []string{"vaccinated", "adult"} {
+		attr, _ := cred.Attribute(name)
+		if attr.Encoding != EncodingSaltedHash || len(attr.Salt()) != AttributeSaltSize {
+			t.Fatalf("Expected '%s' salted, got %s with %d salt bytes", name, attr.Encoding, len(attr.Salt()))
+		}
+	}
+	if name, _ := cred.Attribute("name"); name.Salt() != nil {
+		t.Fatalf("Unsalted attribute has a salt")
+	}
+
+	// A guess hashed without the salt does not find the message
+	vaccinated, _ := cred.Attribute("vaccinated")
+	msg, err := vaccinated.Message(nil)
+	if err != nil {
+		t.Fatalf("Message failed: %v", err)
+	}
+	guess, _ := StringAttribute("vaccinated", "yes").Message(nil)
+	if msg.Cmp(guess) == 0 {
+		t.Fatalf("Salted message equals the unsalted hash")
+	}
+
+	// The salt survives the round trip, so a disclosed attribute carries
+	// everything needed to recompute its message
+	data, err := json.Marshal(cred)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	var decoded Credential
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if err := decoded.Verify(); err != nil {
+		t.Fatalf("Verify after round trip failed: %v", err)
+	}
+	data, err = json.Marshal(vaccinated)
+	if err != nil {
+		t.Fatalf("Marshal attribute failed: %v", err)
+	}
+	var disclosed Attribute
+	if err := json.Unmarshal(data, &disclosed); err != nil {
+		t.Fatalf("Unmarshal attribute failed: %v", err)
+	}
+	if again, err := disclosed.Message(nil); err != nil || again.Cmp(msg) != 0 {
+		t.Fatalf("Disclosed attribute maps to another message: %v", err)
+	}
+
+	// Another salt gives another message
+	salt, err := NewAttributeSalt()
+	if err != nil {
+		t.Fatalf("NewAttributeSalt failed: %v", err)
+	}
+	if other, _ := vaccinated.WithSalt(salt).Message(nil); other.Cmp(msg) == 0 {
+		t.Fatalf("Different salts give the same message")
+	}
+
+	// Salts of the wrong size, and salts on unsalted encodings, are rejected
+	if _, err := StringAttribute("s", "x").WithSalt(salt[:8]).Message(nil); !errors.Is(err, ErrInvalidAttribute) {
+		t.Fatalf("Expected ErrInvalidAttribute for a short salt, got %v", err)
+	}
+	if _, err := json.Marshal(StringAttribute("s", "x").WithEncoding(EncodingSaltedHash)); err == nil {
+		t.Fatalf("Expected an unsalted salted attribute to be rejected")
+	}
+	if _, err := NewBuilder().AddAttribute("name", "Alice").SaltAttributes("missing").Issue(keyPair); err == nil {
+		t.Fatalf("Expected an unknown salted attribute to be rejected")
+	}
+}
+
+func TestLegacyCredentialJSON(t *testing.T) {
+	keyPair, err := bbs.GenerateKeyPair(2, rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key pair: %v", err)
+	}
+	cred, err := NewBuilder().AddAttribute("name", "Alice").AddAttribute("age", "30").Issue(keyPair)
+	if err != nil {
+		t.Fatalf("Issue failed: %v", err)
+	}
+
+	// Credentials written before types held a map and its order, and were
+	// signed without a schema hash
+	messages, err := cred.messages()
+	if err != nil {
+		t.Fatalf("messages failed: %v", err)
+	}
+	signature, err := bbs.Sign(keyPair.PrivateKey, keyPair.PublicKey, messages, nil)
+	if err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+	legacy, err := json.Marshal(map[string]any{
+		"schema":         cred.Schema,
+		"publicKey":      cred.PublicKey,
+		"signature":      base64.StdEncoding.EncodeToString(bbs.SerializeSignature(signature)),
+		"attributes":     map[string]string{"name": "Alice", "age": "30"},
+		"attributeOrder": []string{"name", "age"},
+		"issuer":         cred.Issuer,
+		"issuanceDate":   cred.IssuanceDate,
+	})
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+
+	var decoded Credential
+	if err := json.Unmarshal(legacy, &decoded); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if err := decoded.Verify(); err != nil {
+		t.Fatalf("Legacy credential does not verify: %v", err)
+	}
+	if age, _ := decoded.Attribute("age"); age.Type != TypeString || age.Text() != "30" {
+		t.Fatalf("Unexpected legacy attribute %+v", age)
+	}
+}
@@ -0,0 +1,340 @@
+package credential
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"iter"
+	"maps"
+	"math/big"
+	"slices"
+	"time"
+
+	"github.com/anupsv/bbsplus-signatures/bbs"
+)
+
+// Bulk issuance commits to every credential of a batch in a manifest the
+// issuer signs. The manifest holds the root of a Merkle tree over salted
+// credential hashes, so an auditor handed one credential and its inclusion
+// proof can check that the credential belongs to an authorized batch
+// without seeing the others. Salts come from a per-batch seed, so the issuer
+// can rebuild any inclusion proof from the seed and the credential hashes.
+//
+// The manifest is signed with the issuer's BBS+ key as a one-message
+// signature: the key's W with generators for a single message, under a
+// header reserved for manifests.
+
+// Domain separation tags for batch manifests
+const (
+	batchSaltDST     = "BBS_CREDENTIAL_BATCH_SALT_V1_"
+	batchManifestDST = "BBS_CREDENTIAL_BATCH_MANIFEST_V1_"
+)
+
+// Merkle tree node prefixes, so a leaf can never pass for an inner node
+const (
+	merkleLeafPrefix = 0x00
+	merkleNodePrefix = 0x01
+)
+
+// BatchSeedSize is the size of the secret seed the salts of a batch derive from
+const BatchSeedSize = 32
+
+// ErrNotInBatch is returned when a credential is not part of a batch manifest
+var ErrNotInBatch = errors.New("credential not in batch")
+
+// Issuer issues credentials under one key pair
+type Issuer struct {
+	name    string
+	keyPair *bbs.KeyPair
+	journal *Journal
+}
+
+// NewIssuer creates an issuer that names itself name in its credentials
+func NewIssuer(name string, keyPair *bbs.KeyPair) *Issuer {
+	return &Issuer{name: name, keyPair: keyPair}
+}
+
+// SetJournal records every credential the issuer issues in journal
+func (i *Issuer) SetJournal(journal *Journal) *Issuer {
+	i.journal = journal
+	return i
+}
+
+// BatchManifest commits to the credentials of a batch
+type BatchManifest struct {
+	BatchID        string    `json:"batchId"`
+	Schema         string    `json:"schema"`
+	Issuer         string    `json:"issuer"`
+	KeyFingerprint string    `json:"keyFingerprint"`
+	Count          int       `json:"count"`
+	Root           string    `json:"root"` // hex Merkle root of the salted credential hashes
+	Created        time.Time `json:"created"`
+	Signature      string    `json:"signature"` // Base64 BBS+ signature of the manifest
+}
+
+// Batch is the result of IssueBatch
+type Batch struct {
+	Credentials []*Credential
+	Manifest    *BatchManifest
+
+	// Seed derives the salts of the batch. Keep it with the issuer to
+	// produce inclusion proofs later.
+	Seed []byte
+
+	leaves [][32]byte
+}
+
+// All yields the position and credential of every member of the batch, the
+// position being the index InclusionProof takes
+func (b *Batch) All() iter.Seq2[int, *Credential] {
+	return slices.All(b.Credentials)
+}
+
+// BatchInclusionProof shows that a credential is leaf Index of a batch
+type BatchInclusionProof struct {
+	Index int      `json:"index"`
+	Salt  string   `json:"salt"` // hex
+	Path  []string `json:"path"` // hex sibling hashes from the leaf up
+}
+
+// IssueBatch issues one credential per attribute map, signing attributes in
+// name order, and a manifest committing to all of them
+func (i *Issuer) IssueBatch(schema string, attributes []map[string]string) (*Batch, error) {
+	if i.keyPair == nil || i.keyPair.PrivateKey == nil || i.keyPair.PublicKey == nil {
+		return nil, fmt.Errorf("issuer key pair with a private key is required")
+	}
+	if len(attributes) == 0 {
+		return nil, fmt.Errorf("batch needs at least one credential")
+	}
+
+	seed := make([]byte, BatchSeedSize)
+	if _, err := rand.Read(seed); err != nil {
+		return nil, fmt.Errorf("failed to draw batch seed: %w", err)
+	}
+	batchID := make([]byte, 16)
+	if _, err := rand.Read(batchID); err != nil {
+		return nil, fmt.Errorf("failed to draw batch ID: %w", err)
+	}
+
+	batch := &Batch{
+		Seed: seed,
+		Manifest: &BatchManifest{
+			BatchID:        hex.EncodeToString(batchID),
+			Schema:         schema,
+			Issuer:         i.name,
+			KeyFingerprint: KeyFingerprint(i.keyPair.PublicKey),
+			Count:          len(attributes),
+			Created:        time.Now().UTC(),
+		},
+	}
+
+	for n, attrs := range attributes {
+		if len(attrs) == 0 {
+			return nil, fmt.Errorf("credential %d has no attributes", n)
+		}
+
+		builder := NewBuilder().SetSchema(schema).SetIssuer(i.name).SetJournal(i.journal)
+		for _, name := range slices.Sorted(maps.Keys(attrs)) {
+			builder.AddAttribute(name, attrs[name])
+		}
+		cred, err := builder.Issue(i.keyPair)
+		if err != nil {
+			return nil, fmt.Errorf("credential %d: %w", n, err)
+		}
+
+		leaf, err := batchLeaf(cred, BatchSalt(seed, batch.Manifest.BatchID, n))
+		if err != nil {
+			return nil, err
+		}
+		batch.Credentials = append(batch.Credentials, cred)
+		batch.leaves = append(batch.leaves, leaf)
+	}
+
+	root := merkleRoot(batch.leaves)
+	batch.Manifest.Root = hex.EncodeToString(root[:])
+
+	signature, err := bbs.Sign(i.keyPair.PrivateKey, oneMessageKey(i.keyPair.PublicKey), batch.Manifest.messages(), []byte(batchManifestDST))
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign batch manifest: %w", err)
+	}
+	batch.Manifest.Signature = base64.StdEncoding.EncodeToString(bbs.SerializeSignature(signature))
+
+	return batch, nil
+}
+
+// InclusionProof returns the proof that credential n belongs to the batch
+func (b *Batch) InclusionProof(n int) (*BatchInclusionProof, error) {
+	if n < 0 || n >= len(b.leaves) {
+		return nil, fmt.Errorf("no credential %d in a batch of %d", n, len(b.leaves))
+	}
+
+	proof := &BatchInclusionProof{
+		Index: n,
+		Salt:  hex.EncodeToString(BatchSalt(b.Seed, b.Manifest.BatchID, n)),
+	}
+	level := b.leaves
+	for idx := n; len(level) > 1; idx /= 2 {
+		sibling := idx ^ 1
+		if sibling < len(level) {
+			proof.Path = append(proof.Path, hex.EncodeToString(level[sibling][:]))
+		}
+		level = merkleLevel(level)
+	}
+	return proof, nil
+}
+
+// BatchSalt derives the salt of credential n of a batch from the batch seed
+func BatchSalt(seed []byte, batchID string, n int) []byte {
+	mac := hmac.New(sha256.New, seed)
+	mac.Write([]byte(batchSaltDST))
+	mac.Write(binary.BigEndian.AppendUint32(nil, uint32(len(batchID))))
+	mac.Write([]byte(batchID))
+	mac.Write(binary.BigEndian.AppendUint32(nil, uint32(n)))
+	return mac.Sum(nil)
+}
+
+// Verify checks the manifest signature under the issuer's public key
+func (m *BatchManifest) Verify(publicKey *bbs.PublicKey) error {
+	if !matchesKeyFingerprint(m.KeyFingerprint, publicKey) {
+		return fmt.Errorf("manifest was signed by another key")
+	}
+
+	sigBytes, err := base64.StdEncoding.DecodeString(m.Signature)
+	if err != nil {
+		return fmt.Errorf("failed to decode manifest signature: %w", err)
+	}
+	signature, err := bbs.DeserializeSignature(sigBytes)
+	if err != nil {
+		return fmt.Errorf("failed to deserialize manifest signature: %w", err)
+	}
+
+	return bbs.Verify(oneMessageKey(publicKey), signature, m.messages(), []byte(batchManifestDST))
+}
+
+// VerifyBatchInclusion checks that the manifest is signed by the issuer key
+// and that cred is in the batch it commits to
+func VerifyBatchInclusion(publicKey *bbs.PublicKey, manifest *BatchManifest, cred *Credential, proof *BatchInclusionProof) error {
+	if err := manifest.Verify(publicKey); err != nil {
+		return err
+	}
+	if cred.Schema != manifest.Schema || cred.Issuer != manifest.Issuer {
+		return fmt.Errorf("%w: schema or issuer differs from the manifest", ErrNotInBatch)
+	}
+	if proof.Index < 0 || proof.Index >= manifest.Count {
+		return fmt.Errorf("%w: index %d outside a batch of %d", ErrNotInBatch, proof.Index, manifest.Count)
+	}
+
+	salt, err := hex.DecodeString(proof.Salt)
+	if err != nil {
+		return fmt.Errorf("%w: invalid salt", ErrNotInBatch)
+	}
+	node, err := batchLeaf(cred, salt)
+	if err != nil {
+		return err
+	}
+
+	// Walk up, consuming a sibling wherever the node has one
+	path := proof.Path
+	for idx, width := proof.Index, manifest.Count; width > 1; idx, width = idx/2, (width+1)/2 {
+		sibling := idx ^ 1
+		if sibling >= width {
+			continue
+		}
+		if len(path) == 0 {
+			return fmt.Errorf("%w: inclusion path too short", ErrNotInBatch)
+		}
+		siblingHash, err := hex.DecodeString(path[0])
+		if err != nil || len(siblingHash) != sha256.Size {
+			return fmt.Errorf("%w: invalid path hash", ErrNotInBatch)
+		}
+		path = path[1:]
+		if idx%2 == 0 {
+			node = merkleNode(node[:], siblingHash)
+		} else {
+			node = merkleNode(siblingHash, node[:])
+		}
+	}
+	if len(path) != 0 {
+		return fmt.Errorf("%w: inclusion path too long", ErrNotInBatch)
+	}
+
+	if hex.EncodeToString(node[:]) != manifest.Root {
+		return fmt.Errorf("%w: root mismatch", ErrNotInBatch)
+	}
+	return nil
+}
+
+// messages returns the single message the manifest signature covers
+func (m *BatchManifest) messages() []*big.Int {
+	var buf []byte
+	buf = append(buf, batchManifestDST...)
+	for _, field := range []string{m.BatchID, m.Schema, m.Issuer, m.KeyFingerprint, m.Root, m.Created.UTC().Format(time.RFC3339Nano)} {
+		buf = appendLengthPrefixed(buf, []byte(field))
+	}
+	buf = binary.BigEndian.AppendUint64(buf, uint64(m.Count))
+	return []*big.Int{bbs.MessageToFieldElement(buf)}
+}
+
+// oneMessageKey returns the issuer key with generators for a single
+// message, for the manifests and links the issuer signs
+func oneMessageKey(publicKey *bbs.PublicKey) *bbs.PublicKey {
+	return bbs.NewPublicKeyWithGenerators(publicKey.W(), publicKey.G2(), publicKey.G1(), bbs.GenerateGenerators(3), 1)
+}
+
+// batchLeaf returns the Merkle leaf of a credential: H(0x00 || salt || hash)
+func batchLeaf(cred *Credential, salt []byte) ([32]byte, error) {
+	hash, err := hashCredential(cred)
+	if err != nil {
+		return [32]byte{}, err
+	}
+	raw, err := hex.DecodeString(hash)
+	if err != nil {
+		return [32]byte{}, err
+	}
+
+	h := sha256.New()
+	h.Write([]byte{merkleLeafPrefix})
+	h.Write(salt)
+	h.Write(raw)
+	var leaf [32]byte
+	h.Sum(leaf[:0])
+	return leaf, nil
+}
+
+// merkleNode returns H(0x01 || left || right)
+func merkleNode(left, right []byte) [32]byte {
+	h := sha256.New()
+	h.Write([]byte{merkleNodePrefix})
+	h.Write(left)
+	h.Write(right)
+	var node [32]byte
+	h.Sum(node[:0])
+	return node
+}
+
+// merkleLevel hashes pairs of nodes into the next level. An odd last node is
+// carried up unchanged rather than paired with itself.
+func merkleLevel(level [][32]byte) [][32]byte {
+	next := make([][32]byte, 0, (len(level)+1)/2)
+	for i := 0; i+1 < len(level); i += 2 {
+		next = append(next, merkleNode(level[i][:], level[i+1][:]))
+	}
+	if len(level)%2 == 1 {
+		next = append(next, level[len(level)-1])
+	}
+	return next
+}
+
+// merkleRoot returns the root of the tree over leaves
+func merkleRoot(leaves [][32]byte) [32]byte {
+	level := leaves
+	for len(level) > 1 {
+		level = merkleLevel(level)
+	}
+	return level[0]
+}
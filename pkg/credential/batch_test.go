@@ -0,0 +1,106 @@
+package credential
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/anupsv/bbsplus-signatures/bbs"
+)
+
+func TestIssueBatch(t *testing.T) {
+	keyPair, err := bbs.GenerateKeyPair(2, rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key pair: %v", err)
+	}
+	issuer := NewIssuer("https://registry.example.gov", keyPair)
+
+	// Odd sizes exercise the nodes carried up unpaired
+	for _, size := range []int{1, 2, 5, 8} {
+		attributes := make([]map[string]string, size)
+		for i := range attributes {
+			attributes[i] = map[string]string{"name": fmt.Sprintf("citizen-%d", i), "region": "north"}
+		}
+
+		batch, err := issuer.IssueBatch("https://example.gov/schemas/id", attributes)
+		if err != nil {
+			t.Fatalf("IssueBatch failed: %v", err)
+		}
+		if len(batch.Credentials) != size || batch.Manifest.Count != size {
+			t.Fatalf("Expected %d credentials, got %d", size, len(batch.Credentials))
+		}
+
+		// The manifest survives encoding
+		data, err := json.Marshal(batch.Manifest)
+		if err != nil {
+			t.Fatalf("Marshal failed: %v", err)
+		}
+		var manifest BatchManifest
+		if err := json.Unmarshal(data, &manifest); err != nil {
+			t.Fatalf("Unmarshal failed: %v", err)
+		}
+
+		for i, cred := range batch.All() {
+			if err := cred.Verify(); err != nil {
+				t.Fatalf("Credential %d does not verify: %v", i, err)
+			}
+			proof, err := batch.InclusionProof(i)
+			if err != nil {
+				t.Fatalf("InclusionProof failed: %v", err)
+			}
+			if err := VerifyBatchInclusion(keyPair.PublicKey, &manifest, cred, proof); err != nil {
+				t.Fatalf("size %d, credential %d: VerifyBatchInclusion failed: %v", size, i, err)
+			}
+		}
+	}
+}
+
+func TestBatchInclusionRejects(t *testing.T) {
+	keyPair, err := bbs.GenerateKeyPair(1, rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key pair: %v", err)
+	}
+	otherKey, err := bbs.GenerateKeyPair(1, rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key pair: %v", err)
+	}
+	issuer := NewIssuer("issuer", keyPair)
+
+	batch, err := issuer.IssueBatch("schema", []map[string]string{{"n": "1"}, {"n": "2"}, {"n": "3"}})
+	if err != nil {
+		t.Fatalf("IssueBatch failed: %v", err)
+	}
+	outsider, err := issuer.IssueBatch("schema", []map[string]string{{"n": "1"}})
+	if err != nil {
+		t.Fatalf("IssueBatch failed: %v", err)
+	}
+
+	proof, err := batch.InclusionProof(1)
+	if err != nil {
+		t.Fatalf("InclusionProof failed: %v", err)
+	}
+
+	// A credential from another batch is not included
+	if err := VerifyBatchInclusion(keyPair.PublicKey, batch.Manifest, outsider.Credentials[0], proof); !errors.Is(err, ErrNotInBatch) {
+		t.Fatalf("Expected ErrNotInBatch, got %v", err)
+	}
+
+	// The right credential at the wrong index is not included
+	moved := *proof
+	moved.Index = 0
+	if err := VerifyBatchInclusion(keyPair.PublicKey, batch.Manifest, batch.Credentials[1], &moved); !errors.Is(err, ErrNotInBatch) {
+		t.Fatalf("Expected ErrNotInBatch for a moved index, got %v", err)
+	}
+
+	// A manifest with a changed count or root loses its signature
+	tampered := *batch.Manifest
+	tampered.Count = 4
+	if err := tampered.Verify(keyPair.PublicKey); err == nil {
+		t.Fatalf("Expected a tampered manifest to fail")
+	}
+	if err := batch.Manifest.Verify(otherKey.PublicKey); err == nil {
+		t.Fatalf("Expected the manifest to fail under another key")
+	}
+}
@@ -1,9 +1,16 @@
 package credential
 
 import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"math/big"
+	"slices"
 	"time"
+
+	"github.com/anupsv/bbsplus-signatures/bbs"
 )
 
 // Credential represents a BBS+ credential with attributes
@@ -17,8 +24,8 @@ type Credential struct {
 	// Signature is the BBS+ signature (Base64-encoded)
 	Signature string `json:"signature"`
 
-	// Attributes contains the credential attributes
-	Attributes map[string]string `json:"attributes"`
+	// Attributes contains the credential attributes in signing order
+	Attributes []Attribute `json:"attributes"`
 
 	// Issuer identifies the credential issuer
 	Issuer string `json:"issuer"`
@@ -29,23 +36,28 @@ type Credential struct {
 	// ExpirationDate is when the credential expires (if applicable)
 	ExpirationDate *time.Time `json:"expirationDate,omitempty"`
 
-	// private data for storage
-	attrNames []string    // Ordered attribute names
+	// SchemaHash is the hex hash of the schema the signature is bound to.
+	// Credentials issued before the binding have none.
+	SchemaHash string `json:"schemaHash,omitempty"`
+
+	// IDSalt is the hex salt of the attribute commitment behind the
+	// credential ID, if the credential was issued with one. It is not
+	// signed and should be kept as private as the attributes.
+	IDSalt string `json:"idSalt,omitempty"`
 }
 
 // Builder provides a fluent interface for creating credentials
 type Builder struct {
 	credential Credential
+	specs      []AttributeSpec
+	journal    *Journal
+	assignID   bool
+	salted     []string
 }
 
 // NewBuilder creates a new credential builder
 func NewBuilder() *Builder {
-	return &Builder{
-		credential: Credential{
-			Attributes: make(map[string]string),
-			attrNames:  make([]string, 0),
-		},
-	}
+	return &Builder{}
 }
 
 // SetSchema sets the credential schema
@@ -66,17 +78,151 @@ func (b *Builder) SetExpirationDate(expiration time.Time) *Builder {
 	return b
 }
 
-// AddAttribute adds an attribute to the credential
+// AddAttribute adds a string attribute to the credential
 func (b *Builder) AddAttribute(name, value string) *Builder {
-	b.credential.Attributes[name] = value
-	b.credential.attrNames = append(b.credential.attrNames, name)
+	return b.Add(StringAttribute(name, value))
+}
+
+// Add adds typed attributes to the credential
+func (b *Builder) Add(attributes ...Attribute) *Builder {
+	b.credential.Attributes = append(b.credential.Attributes, attributes...)
+	return b
+}
+
+// DeclareAttributes fixes the signing order by a schema's attribute
+// declarations instead of the order attributes are added in. Issue checks
+// that the attributes match the declarations, and applies a declared
+// encoding.
+func (b *Builder) DeclareAttributes(specs ...AttributeSpec) *Builder {
+	b.specs = specs
+	return b
+}
+
+// SaltAttributes hashes the named attributes behind random salts, so their
+// hidden messages cannot be found by hashing guesses of the value. Salting
+// replaces the attributes' encoding, so integer-encoded values can no longer
+// be decoded or compared from their messages.
+func (b *Builder) SaltAttributes(names ...string) *Builder {
+	b.salted = append(b.salted, names...)
+	return b
+}
+
+// SetJournal records every credential issued by the builder in journal
+func (b *Builder) SetJournal(journal *Journal) *Builder {
+	b.journal = journal
 	return b
 }
 
-// Issue signs the credential with the issuer's key pair
-func (b *Builder) Issue() (*Credential, error) {
-	b.credential.IssuanceDate = time.Now()
-	return &b.credential, fmt.Errorf("BBS+ signature creation not implemented")
+// AssignID gives the credential a deterministic ID, signed as the reserved
+// attribute IDAttribute after all others. The issuer key must sign one
+// attribute more than the credential has.
+func (b *Builder) AssignID() *Builder {
+	b.assignID = true
+	return b
+}
+
+// Issue signs the credential with the issuer's key pair. Attributes are
+// signed in their declared order, or the order they were added in. With a
+// journal set, the credential is only returned once its issuance is on disk.
+func (b *Builder) Issue(keyPair *bbs.KeyPair) (*Credential, error) {
+	if keyPair == nil || keyPair.PrivateKey == nil || keyPair.PublicKey == nil {
+		return nil, fmt.Errorf("issuer key pair with a private key is required")
+	}
+
+	cred := b.credential
+	cred.Attributes = slices.Clone(b.credential.Attributes)
+	if b.specs != nil {
+		ordered, err := declaredOrder(cred.Attributes, b.specs)
+		if err != nil {
+			return nil, err
+		}
+		cred.Attributes = ordered
+	}
+	if err := checkAttributeNames(cred.Attributes); err != nil {
+		return nil, err
+	}
+	for _, name := range b.salted {
+		idx := cred.attributeIndex(name)
+		if idx < 0 {
+			return nil, fmt.Errorf("salted attribute '%s' not found in credential", name)
+		}
+		cred.Attributes[idx] = cred.Attributes[idx].WithEncoding(EncodingSaltedHash)
+	}
+	if _, ok := cred.Attribute(IDAttribute); ok {
+		return nil, fmt.Errorf("attribute name '%s' is reserved", IDAttribute)
+	}
+	if b.assignID {
+		if err := cred.assignID(keyPair.PublicKey); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := cred.sign(keyPair); err != nil {
+		return nil, err
+	}
+
+	if b.journal != nil {
+		encoded, err := cred.MarshalJSON()
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode credential: %w", err)
+		}
+		if _, err := b.journal.Append(cred.Schema, encoded, keyPair.PublicKey); err != nil {
+			return nil, fmt.Errorf("failed to record issuance: %w", err)
+		}
+	}
+
+	return &cred, nil
+}
+
+// sign signs the attributes in order, bound to the hash of the schema, and
+// stamps the credential as issued now. Salted attributes without a salt get
+// a fresh one.
+func (c *Credential) sign(keyPair *bbs.KeyPair) error {
+	for i, attr := range c.Attributes {
+		salted, err := attr.salted()
+		if err != nil {
+			return err
+		}
+		c.Attributes[i] = salted
+	}
+	messages, err := c.messages()
+	if err != nil {
+		return err
+	}
+	schemaHash := SchemaHash(c.Schema, c.schemaSpecs())
+	signature, err := bbs.Sign(keyPair.PrivateKey, keyPair.PublicKey, messages, bbs.SchemaHeader(nil, schemaHash))
+	if err != nil {
+		return fmt.Errorf("failed to sign credential: %w", err)
+	}
+
+	c.IssuanceDate = time.Now()
+	c.SchemaHash = hex.EncodeToString(schemaHash)
+	c.PublicKey = encodePublicKey(keyPair.PublicKey)
+	c.Signature = base64.StdEncoding.EncodeToString(bbs.SerializeSignature(signature))
+	return nil
+}
+
+// encodePublicKey encodes the issuer key for the PublicKey field, in compact
+// form if the key's generators come from a seed
+func encodePublicKey(publicKey *bbs.PublicKey) string {
+	if compact, err := publicKey.Compact(nil); err == nil {
+		return base64.StdEncoding.EncodeToString(bbs.SerializeCompactPublicKey(compact))
+	}
+	return base64.StdEncoding.EncodeToString(bbs.SerializePublicKey(publicKey))
+}
+
+// issuedUnder reports whether the credential names publicKey as its issuer
+// key, in either encoding
+func (c *Credential) issuedUnder(publicKey *bbs.PublicKey) bool {
+	pubKeyBytes, err := base64.StdEncoding.DecodeString(c.PublicKey)
+	if err != nil {
+		return false
+	}
+	issuerKey, err := bbs.DeserializePublicKey(pubKeyBytes)
+	if err != nil {
+		return false
+	}
+	return issuerKey.Fingerprint() == publicKey.Fingerprint()
 }
 
 // Verify checks if the credential is valid
@@ -85,43 +231,131 @@ func (c *Credential) Verify() error {
 	if c.ExpirationDate != nil && time.Now().After(*c.ExpirationDate) {
 		return fmt.Errorf("credential has expired")
 	}
+	return c.verifySignature()
+}
+
+// verifySignature checks the issuer's signature over the attributes
+func (c *Credential) verifySignature() error {
+	pubKeyBytes, err := base64.StdEncoding.DecodeString(c.PublicKey)
+	if err != nil {
+		return fmt.Errorf("failed to decode public key: %w", err)
+	}
+	publicKey, err := bbs.DeserializePublicKey(pubKeyBytes)
+	if err != nil {
+		return fmt.Errorf("failed to deserialize public key: %w", err)
+	}
 
-	return fmt.Errorf("BBS+ signature verification not implemented")
+	sigBytes, err := base64.StdEncoding.DecodeString(c.Signature)
+	if err != nil {
+		return fmt.Errorf("failed to decode signature: %w", err)
+	}
+	signature, err := bbs.DeserializeSignature(sigBytes)
+	if err != nil {
+		return fmt.Errorf("failed to deserialize signature: %w", err)
+	}
+
+	messages, err := c.messages()
+	if err != nil {
+		return err
+	}
+	header, err := c.header()
+	if err != nil {
+		return err
+	}
+	return bbs.Verify(publicKey, signature, messages, header)
 }
 
-// CreatePresentation creates a selective disclosure presentation
-func (c *Credential) CreatePresentation(disclosedAttrs []string) (*Presentation, error) {
-	// Find indices of disclosed attributes
-	disclosedIndices := make([]int, len(disclosedAttrs))
-	for i, attr := range disclosedAttrs {
-		found := false
-		for j, name := range c.attrNames {
-			if name == attr {
-				disclosedIndices[i] = j
-				found = true
-				break
-			}
+// Attribute returns the named attribute
+func (c *Credential) Attribute(name string) (Attribute, bool) {
+	idx := c.attributeIndex(name)
+	if idx < 0 {
+		return Attribute{}, false
+	}
+	return c.Attributes[idx], true
+}
+
+// AttributeNames returns the attribute names in signing order
+func (c *Credential) AttributeNames() []string {
+	names := make([]string, len(c.Attributes))
+	for i, attr := range c.Attributes {
+		names[i] = attr.Name
+	}
+	return names
+}
+
+// attributeIndex returns the signing position of the named attribute, or -1
+func (c *Credential) attributeIndex(name string) int {
+	return slices.IndexFunc(c.Attributes, func(a Attribute) bool { return a.Name == name })
+}
+
+// messages maps the attributes to field elements in signing order
+func (c *Credential) messages() ([]*big.Int, error) {
+	messages := make([]*big.Int, len(c.Attributes))
+	for i, attr := range c.Attributes {
+		msg, err := attr.Message(nil)
+		if err != nil {
+			return nil, err
 		}
-		if !found {
-			return nil, fmt.Errorf("attribute '%s' not found in credential", attr)
+		messages[i] = msg
+	}
+	return messages, nil
+}
+
+// declaredOrder orders attributes by their declarations, checking their
+// types and applying declared encodings
+func declaredOrder(attributes []Attribute, specs []AttributeSpec) ([]Attribute, error) {
+	if len(specs) != len(attributes) {
+		return nil, fmt.Errorf("schema declares %d attributes, credential has %d", len(specs), len(attributes))
+	}
+
+	ordered := make([]Attribute, len(specs))
+	for i, spec := range specs {
+		idx := slices.IndexFunc(attributes, func(a Attribute) bool { return a.Name == spec.Name })
+		if idx < 0 {
+			return nil, fmt.Errorf("declared attribute '%s' has no value", spec.Name)
+		}
+		attr := attributes[idx]
+		if spec.Type != "" && spec.Type != attr.Type {
+			return nil, fmt.Errorf("%w: '%s' is %s, declared %s", ErrInvalidAttribute, spec.Name, attr.Type, spec.Type)
+		}
+		if spec.Encoding != "" {
+			attr = attr.WithEncoding(spec.Encoding)
 		}
+		ordered[i] = attr
 	}
+	return ordered, nil
+}
 
+// checkAttributeNames checks that no attribute name is used twice
+func checkAttributeNames(attributes []Attribute) error {
+	seen := make(map[string]bool, len(attributes))
+	for _, attr := range attributes {
+		if seen[attr.Name] {
+			return fmt.Errorf("attribute '%s' added more than once", attr.Name)
+		}
+		seen[attr.Name] = true
+	}
+	return nil
+}
+
+// CreatePresentation creates a selective disclosure presentation
+func (c *Credential) CreatePresentation(disclosedAttrs []string) (*Presentation, error) {
 	// Create a presentation
 	presentation := &Presentation{
 		Schema:     c.Schema,
-		Attributes: make(map[string]string),
+		SchemaHash: c.SchemaHash,
+		Attributes: make([]Attribute, 0, len(disclosedAttrs)),
 		Issuer:     c.Issuer,
 		Created:    time.Now(),
 	}
 
 	// Add disclosed attributes
-	for _, idx := range disclosedIndices {
-		if idx >= 0 && idx < len(c.attrNames) {
-			name := c.attrNames[idx]
-			value := c.Attributes[name]
-			presentation.Attributes[name] = value
+	for _, name := range disclosedAttrs {
+		attr, ok := c.Attribute(name)
+		if !ok {
+			return nil, fmt.Errorf("attribute '%s' not found in credential", name)
 		}
+		presentation.Attributes = append(presentation.Attributes, attr)
 	}
 
 	return presentation, fmt.Errorf("BBS+ proof generation not implemented")
@@ -131,13 +365,15 @@ func (c *Credential) CreatePresentation(disclosedAttrs []string) (*Presentation,
 func (c *Credential) MarshalJSON() ([]byte, error) {
 	// Create a copy without private fields
 	type credentialExport struct {
-		Schema         string            `json:"schema"`
-		PublicKey      string            `json:"publicKey"`
-		Signature      string            `json:"signature"`
-		Attributes     map[string]string `json:"attributes"`
-		Issuer         string            `json:"issuer"`
-		IssuanceDate   time.Time         `json:"issuanceDate"`
-		ExpirationDate *time.Time        `json:"expirationDate,omitempty"`
+		Schema         string      `json:"schema"`
+		PublicKey      string      `json:"publicKey"`
+		Signature      string      `json:"signature"`
+		Attributes     []Attribute `json:"attributes"`
+		Issuer         string      `json:"issuer"`
+		IssuanceDate   time.Time   `json:"issuanceDate"`
+		ExpirationDate *time.Time  `json:"expirationDate,omitempty"`
+		SchemaHash     string      `json:"schemaHash,omitempty"`
+		IDSalt         string      `json:"idSalt,omitempty"`
 	}
 
 	export := credentialExport{
@@ -148,26 +384,46 @@ func (c *Credential) MarshalJSON() ([]byte, error) {
 		Issuer:         c.Issuer,
 		IssuanceDate:   c.IssuanceDate,
 		ExpirationDate: c.ExpirationDate,
+		SchemaHash:     c.SchemaHash,
+		IDSalt:         c.IDSalt,
 	}
 
 	return json.Marshal(export)
 }
 
-// UnmarshalJSON deserializes a credential from JSON
+// UnmarshalJSON deserializes a credential from JSON with the strict
+// decoder. Credentials written before attributes had types, with an
+// attribute map and its order, are read as string attributes.
 func (c *Credential) UnmarshalJSON(data []byte) error {
+	return c.decode(data, DecodeOptions{})
+}
+
+// decode deserializes a credential from JSON with opts
+func (c *Credential) decode(data []byte, opts DecodeOptions) error {
 	// Create a temporary type to avoid recursion
 	type credentialImport struct {
-		Schema         string            `json:"schema"`
-		PublicKey      string            `json:"publicKey"`
-		Signature      string            `json:"signature"`
-		Attributes     map[string]string `json:"attributes"`
+		Schema         string          `json:"schema"`
+		PublicKey      string          `json:"publicKey"`
+		Signature      string          `json:"signature"`
+		Attributes     json.RawMessage `json:"attributes"`
+		AttributeOrder []string        `json:"attributeOrder,omitempty"`
 		Issuer         string            `json:"issuer"`
 		IssuanceDate   time.Time         `json:"issuanceDate"`
 		ExpirationDate *time.Time        `json:"expirationDate,omitempty"`
+		SchemaHash     string            `json:"schemaHash,omitempty"`
+		IDSalt         string            `json:"idSalt,omitempty"`
 	}
 
 	var temp credentialImport
-	if err := json.Unmarshal(data, &temp); err != nil {
+	if err := opts.unmarshal(data, &temp); err != nil {
+		return err
+	}
+
+	attributes, err := importAttributes(temp.Attributes, temp.AttributeOrder, opts)
+	if err != nil {
+		return err
+	}
+	if err := checkAttributeNames(attributes); err != nil {
 		return err
 	}
 
@@ -175,16 +431,45 @@ func (c *Credential) UnmarshalJSON(data []byte) error {
 	c.Schema = temp.Schema
 	c.PublicKey = temp.PublicKey
 	c.Signature = temp.Signature
-	c.Attributes = temp.Attributes
+	c.Attributes = attributes
 	c.Issuer = temp.Issuer
 	c.IssuanceDate = temp.IssuanceDate
 	c.ExpirationDate = temp.ExpirationDate
+	c.SchemaHash = temp.SchemaHash
+	c.IDSalt = temp.IDSalt
 
-	// Build attribute names list
-	c.attrNames = make([]string, 0, len(c.Attributes))
-	for name := range c.Attributes {
-		c.attrNames = append(c.attrNames, name)
+	return nil
+}
+
+// importAttributes reads an attribute array, or a legacy attribute map in
+// the given order
+func importAttributes(raw json.RawMessage, order []string, opts DecodeOptions) ([]Attribute, error) {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 || raw[0] != '{' {
+		attributes, err := decodeAttributes(raw, opts)
+		if err != nil {
+			return nil, err
+		}
+		if len(order) != 0 {
+			return nil, fmt.Errorf("attribute order given for typed attributes")
+		}
+		return attributes, nil
 	}
 
-	return nil
-}
\ No newline at end of file
+	var values map[string]string
+	if err := json.Unmarshal(raw, &values); err != nil {
+		return nil, err
+	}
+	if len(order) != len(values) {
+		return nil, fmt.Errorf("attribute order lists %d attributes, credential has %d", len(order), len(values))
+	}
+	attributes := make([]Attribute, len(order))
+	for i, name := range order {
+		value, ok := values[name]
+		if !ok {
+			return nil, fmt.Errorf("attribute order names unknown attribute '%s'", name)
+		}
+		attributes[i] = StringAttribute(name, value)
+	}
+	return attributes, nil
+}
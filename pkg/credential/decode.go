@@ -0,0 +1,259 @@
+package credential
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+)
+
+// Credentials and presentations are parsed strictly. encoding/json ignores
+// unknown fields, matches field names regardless of case, keeps the last of
+// duplicate keys and reads null as the zero value. A verifier and a holder
+// using different JSON libraries can then read the same document as
+// different attributes, for example {"value":"a","Value":"b"}. A strict
+// decoder refuses such documents instead: every field must be declared with
+// its exact name, keys are unique, null is only read into optional fields
+// and nesting is bounded.
+
+// ErrMalformedJSON is returned for a credential, presentation or attribute
+// document the strict decoder refuses
+var ErrMalformedJSON = errors.New("malformed credential JSON")
+
+// DefaultMaxJSONDepth bounds the nesting of objects and arrays. Credentials
+// nest four levels deep.
+const DefaultMaxJSONDepth = 32
+
+// DecodeOptions configures ParseCredential and ParsePresentation. The zero
+// value is the strict decoder UnmarshalJSON uses.
+type DecodeOptions struct {
+	// Lenient accepts unknown fields, duplicate keys, field names in
+	// another case and null values, as encoding/json does. It is an opt-out
+	// for reading documents written by older or foreign software; values
+	// are still type checked.
+	Lenient bool
+
+	// MaxDepth bounds the nesting of objects and arrays, in lenient mode
+	// too. Zero uses DefaultMaxJSONDepth.
+	MaxDepth int
+
+	// MaxSize bounds the document size in bytes. Zero uses
+	// MaxCredentialSize.
+	MaxSize int
+}
+
+// ParseCredential parses a credential written by Credential.MarshalJSON
+func ParseCredential(data []byte, opts DecodeOptions) (*Credential, error) {
+	if err := opts.checkSize(data); err != nil {
+		return nil, err
+	}
+	c := &Credential{}
+	if err := c.decode(data, opts); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// ParsePresentation parses a presentation written by
+// Presentation.MarshalJSON
+func ParsePresentation(data []byte, opts DecodeOptions) (*Presentation, error) {
+	if err := opts.checkSize(data); err != nil {
+		return nil, err
+	}
+	p := &Presentation{}
+	if err := p.decode(data, opts); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Unmarshal decodes data into v, a pointer to a struct with JSON tags, with
+// the checks of the options. It is for JSON documents of other types that
+// carry credential data, such as the files of a command-line tool: nested
+// structs, maps and slices are checked like the top-level object, and types
+// with their own UnmarshalJSON decode themselves.
+func (o DecodeOptions) Unmarshal(data []byte, v any) error {
+	if err := o.checkSize(data); err != nil {
+		return err
+	}
+	return o.unmarshal(data, v)
+}
+
+// checkSize rejects documents larger than MaxSize
+func (o DecodeOptions) checkSize(data []byte) error {
+	limit := o.MaxSize
+	if limit <= 0 {
+		limit = MaxCredentialSize
+	}
+	if len(data) > limit {
+		return fmt.Errorf("%w: %d bytes exceed the limit of %d", ErrMalformedJSON, len(data), limit)
+	}
+	return nil
+}
+
+// unmarshal decodes data into v, a pointer to a struct with JSON tags
+func (o DecodeOptions) unmarshal(data []byte, v any) error {
+	maxDepth := o.MaxDepth
+	if maxDepth <= 0 {
+		maxDepth = DefaultMaxJSONDepth
+	}
+	if err := checkStructure(data, maxDepth, !o.Lenient); err != nil {
+		return err
+	}
+	if o.Lenient {
+		return json.Unmarshal(data, v)
+	}
+
+	if err := checkFields(data, reflect.TypeOf(v)); err != nil {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	return dec.Decode(v)
+}
+
+// jsonFrame is an object or array being walked by checkStructure
+type jsonFrame struct {
+	object  bool
+	wantKey bool
+	keys    map[string]bool
+}
+
+// checkStructure walks the tokens of data, rejecting documents nested deeper
+// than maxDepth, trailing values and, if unique is set, duplicate keys
+func checkStructure(data []byte, maxDepth int, unique bool) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var stack []*jsonFrame
+	done := false
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrMalformedJSON, err)
+		}
+		if done {
+			return fmt.Errorf("%w: data after the top-level value", ErrMalformedJSON)
+		}
+
+		var top *jsonFrame
+		if len(stack) > 0 {
+			top = stack[len(stack)-1]
+		}
+		if delim, ok := tok.(json.Delim); ok && (delim == '}' || delim == ']') {
+			stack = stack[:len(stack)-1]
+			done = len(stack) == 0
+			continue
+		}
+		if top != nil && top.wantKey {
+			key := tok.(string)
+			if unique && top.keys[key] {
+				return fmt.Errorf("%w: duplicate key '%s'", ErrMalformedJSON, key)
+			}
+			top.keys[key] = true
+			top.wantKey = false
+			continue
+		}
+
+		// A value: the next token of an enclosing object is a key again
+		if top != nil && top.object {
+			top.wantKey = true
+		}
+		if delim, ok := tok.(json.Delim); ok {
+			if len(stack) >= maxDepth {
+				return fmt.Errorf("%w: nested deeper than %d", ErrMalformedJSON, maxDepth)
+			}
+			object := delim == '{'
+			stack = append(stack, &jsonFrame{object: object, wantKey: object, keys: make(map[string]bool)})
+			continue
+		}
+		done = len(stack) == 0
+	}
+}
+
+// checkFields rejects keys of the objects in data that are not the exact
+// JSON name of a field of t, and null values of fields that are not
+// optional. It descends into struct, map and slice fields. Types with their
+// own UnmarshalJSON, and documents of the wrong shape, are left to the
+// decoder.
+func checkFields(data []byte, t reflect.Type) error {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if reflect.PointerTo(t).Implements(unmarshalerType) {
+		return nil
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(data, &fields); err != nil {
+			return nil
+		}
+		declared := jsonFields(t)
+		for key, raw := range fields {
+			field, ok := declared[key]
+			if !ok {
+				return fmt.Errorf("%w: unknown field '%s'", ErrMalformedJSON, key)
+			}
+			if string(raw) == "null" {
+				switch field.Kind() {
+				case reflect.Pointer, reflect.Slice, reflect.Map, reflect.Interface:
+					continue
+				}
+				return fmt.Errorf("%w: field '%s' is null", ErrMalformedJSON, key)
+			}
+			if err := checkFields(raw, field); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		var entries map[string]json.RawMessage
+		if err := json.Unmarshal(data, &entries); err != nil {
+			return nil
+		}
+		for _, raw := range entries {
+			if err := checkFields(raw, t.Elem()); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		var items []json.RawMessage
+		if err := json.Unmarshal(data, &items); err != nil {
+			return nil
+		}
+		for _, raw := range items {
+			if err := checkFields(raw, t.Elem()); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// unmarshalerType is the type of json.Unmarshaler
+var unmarshalerType = reflect.TypeFor[json.Unmarshaler]()
+
+// jsonFields maps the JSON names of the fields of a struct type to their
+// types
+func jsonFields(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" || !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fields[name] = field.Type
+	}
+	return fields
+}
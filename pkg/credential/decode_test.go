@@ -0,0 +1,142 @@
+package credential
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/anupsv/bbsplus-signatures/bbs"
+)
+
+func TestStrictDecoding(t *testing.T) {
+	keyPair, err := bbs.GenerateKeyPair(2, rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key pair: %v", err)
+	}
+	cred, err := NewBuilder().AddAttribute("name", "Alice").Add(IntAttribute("points", 7)).Issue(keyPair)
+	if err != nil {
+		t.Fatalf("Issue failed: %v", err)
+	}
+	data, err := json.Marshal(cred)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+
+	// What MarshalJSON writes parses strictly
+	parsed, err := ParseCredential(data, DecodeOptions{})
+	if err != nil {
+		t.Fatalf("ParseCredential failed: %v", err)
+	}
+	if err := parsed.Verify(); err != nil {
+		t.Fatalf("Verify after parsing failed: %v", err)
+	}
+
+	object := string(data[:len(data)-1])
+	smuggled := map[string]string{
+		"unknown field":           object + `,"extra":true}`,
+		"case variant":            object + `,"Issuer":"mallory"}`,
+		"duplicate key":           object + `,"issuer":"mallory"}`,
+		"null string":             object + `,"idSalt":null}`,
+		"trailing value":          string(data) + ` {}`,
+		"attribute case variant":  strings.Replace(string(data), `"name":"name"`, `"name":"name","Value":"Mallory"`, 1),
+		"attribute duplicate key": strings.Replace(string(data), `"name":"name"`, `"name":"name","name":"points"`, 1),
+	}
+	for name, doc := range smuggled {
+		if _, err := ParseCredential([]byte(doc), DecodeOptions{}); !errors.Is(err, ErrMalformedJSON) {
+			t.Fatalf("%s: expected ErrMalformedJSON, got %v", name, err)
+		}
+	}
+	var c Credential
+	if err := json.Unmarshal([]byte(smuggled["case variant"]), &c); !errors.Is(err, ErrMalformedJSON) {
+		t.Fatalf("Expected ErrMalformedJSON from Unmarshal, got %v", err)
+	}
+
+	// Values are type checked, and an attribute needs one
+	for _, doc := range []string{
+		`{"name":"points","type":"int","encoding":"integer","value":"7"}`,
+		`{"name":"name","type":"string","encoding":"hash","value":null}`,
+		`{"name":"name","type":"string","encoding":"hash"}`,
+	} {
+		var a Attribute
+		if err := json.Unmarshal([]byte(doc), &a); !errors.Is(err, ErrInvalidAttribute) {
+			t.Fatalf("Expected ErrInvalidAttribute for %s, got %v", doc, err)
+		}
+	}
+
+	// Nesting is bounded
+	deep := `{"schema":` + strings.Repeat("[", 40) + strings.Repeat("]", 40) + `}`
+	if _, err := ParseCredential([]byte(deep), DecodeOptions{Lenient: true}); !errors.Is(err, ErrMalformedJSON) {
+		t.Fatalf("Expected ErrMalformedJSON for deep nesting, got %v", err)
+	}
+	if _, err := ParseCredential(data, DecodeOptions{MaxSize: 16}); !errors.Is(err, ErrMalformedJSON) {
+		t.Fatalf("Expected ErrMalformedJSON for an oversized document, got %v", err)
+	}
+
+	// The lenient opt-out reads unknown fields as encoding/json does
+	lenient, err := ParseCredential([]byte(smuggled["unknown field"]), DecodeOptions{Lenient: true})
+	if err != nil {
+		t.Fatalf("Lenient ParseCredential failed: %v", err)
+	}
+	if err := lenient.Verify(); err != nil {
+		t.Fatalf("Verify after lenient parsing failed: %v", err)
+	}
+
+	// Presentations are decoded the same way
+	presentation := &Presentation{
+		Schema:     cred.Schema,
+		Proof:      "cHJvb2Y",
+		Attributes: cred.Attributes[:1],
+		Issuer:     cred.Issuer,
+		Created:    cred.IssuanceDate,
+	}
+	data, err = json.Marshal(presentation)
+	if err != nil {
+		t.Fatalf("Marshal presentation failed: %v", err)
+	}
+	if _, err := ParsePresentation(data, DecodeOptions{}); err != nil {
+		t.Fatalf("ParsePresentation failed: %v", err)
+	}
+	extra := []byte(string(data[:len(data)-1]) + `,"extra":true}`)
+	if _, err := ParsePresentation(extra, DecodeOptions{}); !errors.Is(err, ErrMalformedJSON) {
+		t.Fatalf("Expected ErrMalformedJSON for a presentation, got %v", err)
+	}
+	if _, err := ParsePresentation(extra, DecodeOptions{Lenient: true}); err != nil {
+		t.Fatalf("Lenient ParsePresentation failed: %v", err)
+	}
+}
+
+func TestDecodeOptionsUnmarshal(t *testing.T) {
+	type entry struct {
+		Type string `json:"type"`
+	}
+	type document struct {
+		Name    string           `json:"name"`
+		Entries map[string]entry `json:"entries"`
+		List    []entry          `json:"list,omitempty"`
+	}
+
+	var doc document
+	if err := (DecodeOptions{}).Unmarshal([]byte(`{"name":"a","entries":{"x":{"type":"int"}},"list":[{"type":"bool"}]}`), &doc); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if doc.Entries["x"].Type != "int" || doc.List[0].Type != "bool" {
+		t.Fatalf("Unexpected document: %+v", doc)
+	}
+
+	// Nested objects are held to the same rules as the top level
+	for _, data := range []string{
+		`{"name":"a","entries":{"x":{"Type":"int"}}}`,
+		`{"name":"a","entries":{"x":{"type":"int","extra":1}}}`,
+		`{"name":"a","list":[{"type":"int","type":"bool"}]}`,
+		`{"name":"a","list":[{"type":null}]}`,
+	} {
+		if err := (DecodeOptions{}).Unmarshal([]byte(data), &doc); !errors.Is(err, ErrMalformedJSON) {
+			t.Fatalf("Expected ErrMalformedJSON for %s, got %v", data, err)
+		}
+	}
+	if err := (DecodeOptions{Lenient: true}).Unmarshal([]byte(`{"name":"a","entries":{"x":{"Type":"int"}}}`), &doc); err != nil {
+		t.Fatalf("Lenient Unmarshal failed: %v", err)
+	}
+}
@@ -19,8 +19,9 @@
 //     credBuilder.AddAttribute("age", "30")
 //     credBuilder.AddAttribute("email", "john@example.com")
 //     
-//     // Issue the credential
-//     cred, err := credBuilder.Issue(issuerKeyPair)
+//     // Issue the credential, recording it in an issuance journal
+//     journal, err := credential.OpenJournal("issuance.log")
+//     cred, err := credBuilder.SetJournal(journal).Issue(issuerKeyPair)
 //     
 //     // Serialize to JSON
 //     jsonBytes, err := cred.MarshalJSON()
@@ -30,6 +31,8 @@
 //
 // This package builds on the core BBS+ functionality to provide
 // higher-level credential operations.
+//
+// Stability: stable
 package credential
 
 // Constants for credential handling
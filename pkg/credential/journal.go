@@ -0,0 +1,305 @@
+package credential
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/anupsv/bbsplus-signatures/bbs"
+)
+
+// An issuance journal is an append-only file with one JSON line per issued
+// credential. Each line holds a record and the SHA-256 of the record's exact
+// bytes, and each record holds the hash of the line before it, so editing,
+// dropping or reordering lines breaks the chain from that point on. The
+// journal proves what was issued when to anyone who trusts its latest hash,
+// which should therefore be copied somewhere else from time to time.
+
+// genesisHash is the previous hash of the first record
+var genesisHash = hex.EncodeToString(make([]byte, sha256.Size))
+
+// ErrJournalCorrupt is returned when a journal's hash chain does not verify
+var ErrJournalCorrupt = errors.New("issuance journal corrupt")
+
+// JournalRecord describes one issued credential
+type JournalRecord struct {
+	Sequence       uint64    `json:"seq"`
+	Time           time.Time `json:"time"`
+	Schema         string    `json:"schema"`
+	CredentialHash string    `json:"credentialHash"` // SHA-256 of the credential as issued
+	KeyFingerprint string    `json:"keyFingerprint"` // KeyFingerprint of the issuer public key
+	PrevHash       string    `json:"prevHash"`
+}
+
+// journalLine is the on-disk form of a record
+type journalLine struct {
+	Record json.RawMessage `json:"record"`
+	Hash   string          `json:"hash"`
+}
+
+// KeyFingerprint returns the fingerprint of the issuer key recorded in
+// journals, manifests and links, publicKey.Fingerprint
+func KeyFingerprint(publicKey *bbs.PublicKey) string {
+	return publicKey.Fingerprint()
+}
+
+// matchesKeyFingerprint reports whether fingerprint names publicKey. Records
+// written before bbs.PublicKey.Fingerprint hold the hex SHA-256 of the key,
+// which is still accepted.
+func matchesKeyFingerprint(fingerprint string, publicKey *bbs.PublicKey) bool {
+	if bbs.IsFingerprint(fingerprint) {
+		return fingerprint == publicKey.Fingerprint()
+	}
+	sum := sha256.Sum256(bbs.SerializePublicKey(publicKey))
+	return fingerprint == hex.EncodeToString(sum[:])
+}
+
+// CredentialHash returns the hex SHA-256 of an encoded credential
+func CredentialHash(encoded []byte) string {
+	sum := sha256.Sum256(encoded)
+	return hex.EncodeToString(sum[:])
+}
+
+// Journal appends issuance records to a file. Appends from several
+// processes are serialized with a file lock where the platform has one.
+type Journal struct {
+	mu       sync.Mutex
+	file     *os.File
+	offset   int64 // bytes verified so far
+	head     string
+	sequence uint64
+}
+
+// OpenJournal opens or creates the journal at path and verifies its chain
+func OpenJournal(path string) (*Journal, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open journal: %w", err)
+	}
+
+	j := &Journal{file: file, head: genesisHash}
+
+	if err := lockFile(file); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to lock journal: %w", err)
+	}
+	defer unlockFile(file)
+
+	if err := j.catchUp(); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return j, nil
+}
+
+// Append records the issuance of encodedCredential, the exact bytes handed
+// to the holder, under publicKey. The record is on disk when Append returns.
+func (j *Journal) Append(schema string, encodedCredential []byte, publicKey *bbs.PublicKey) (*JournalRecord, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.file == nil {
+		return nil, errors.New("journal closed")
+	}
+
+	if err := lockFile(j.file); err != nil {
+		return nil, fmt.Errorf("failed to lock journal: %w", err)
+	}
+	defer unlockFile(j.file)
+
+	// Pick up records other processes appended since the last call
+	if err := j.catchUp(); err != nil {
+		return nil, err
+	}
+
+	record := &JournalRecord{
+		Sequence:       j.sequence + 1,
+		Time:           time.Now().UTC(),
+		Schema:         schema,
+		CredentialHash: CredentialHash(encodedCredential),
+		KeyFingerprint: KeyFingerprint(publicKey),
+		PrevHash:       j.head,
+	}
+
+	recordJSON, err := json.Marshal(record)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode journal record: %w", err)
+	}
+	hash := sha256.Sum256(recordJSON)
+
+	line, err := json.Marshal(journalLine{Record: recordJSON, Hash: hex.EncodeToString(hash[:])})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode journal record: %w", err)
+	}
+	line = append(line, '\n')
+
+	if _, err := j.file.Write(line); err != nil {
+		return nil, fmt.Errorf("failed to write journal: %w", err)
+	}
+	if err := j.file.Sync(); err != nil {
+		return nil, fmt.Errorf("failed to sync journal: %w", err)
+	}
+
+	j.offset += int64(len(line))
+	j.head = hex.EncodeToString(hash[:])
+	j.sequence = record.Sequence
+
+	return record, nil
+}
+
+// Head returns the hash of the latest record and its sequence number
+func (j *Journal) Head() (string, uint64) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	return j.head, j.sequence
+}
+
+// Close closes the journal file
+func (j *Journal) Close() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.file == nil {
+		return nil
+	}
+	err := j.file.Close()
+	j.file = nil
+	return err
+}
+
+// catchUp verifies the records after offset and advances the head
+func (j *Journal) catchUp() error {
+	if _, err := j.file.Seek(j.offset, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to read journal: %w", err)
+	}
+
+	v := &journalVerifier{head: j.head, sequence: j.sequence}
+	n, err := v.verify(j.file, nil)
+	if err != nil {
+		return err
+	}
+
+	j.offset += n
+	j.head, j.sequence = v.head, v.sequence
+	return nil
+}
+
+// VerifyJournal checks the hash chain of a journal and returns its records.
+// Errors wrap ErrJournalCorrupt and name the first bad line.
+func VerifyJournal(r io.Reader) ([]*JournalRecord, error) {
+	var records []*JournalRecord
+	v := &journalVerifier{head: genesisHash}
+	visit := func(rec *JournalRecord) bool {
+		records = append(records, rec)
+		return true
+	}
+	if _, err := v.verify(r, visit); err != nil {
+		return records, err
+	}
+	return records, nil
+}
+
+// JournalRecords checks the hash chain of a journal as it reads it and yields
+// each record once its line has been verified, so a journal of any length is
+// audited in constant memory. A corrupt line or a read error is yielded as
+// the last pair, with a nil record.
+func JournalRecords(r io.Reader) iter.Seq2[*JournalRecord, error] {
+	return func(yield func(*JournalRecord, error) bool) {
+		v := &journalVerifier{head: genesisHash}
+		stopped := false
+		visit := func(rec *JournalRecord) bool {
+			stopped = !yield(rec, nil)
+			return !stopped
+		}
+		if _, err := v.verify(r, visit); err != nil && !stopped {
+			yield(nil, err)
+		}
+	}
+}
+
+// VerifyJournalFile is VerifyJournal for the journal at path
+func VerifyJournalFile(path string) ([]*JournalRecord, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open journal: %w", err)
+	}
+	defer file.Close()
+
+	return VerifyJournal(file)
+}
+
+// journalVerifier follows a hash chain across lines
+type journalVerifier struct {
+	head     string
+	sequence uint64
+}
+
+// verify reads complete lines from r, checking each against the chain, and
+// returns the number of bytes consumed. It stops early without an error when
+// visit returns false.
+func (v *journalVerifier) verify(r io.Reader, visit func(*JournalRecord) bool) (int64, error) {
+	reader := bufio.NewReader(r)
+	var consumed int64
+
+	for {
+		raw, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			if len(raw) != 0 {
+				return consumed, fmt.Errorf("%w: record %d: truncated line", ErrJournalCorrupt, v.sequence+1)
+			}
+			return consumed, nil
+		}
+		if err != nil {
+			return consumed, fmt.Errorf("failed to read journal: %w", err)
+		}
+
+		record, hash, err := parseJournalLine(bytes.TrimSuffix(raw, []byte("\n")))
+		if err != nil {
+			return consumed, fmt.Errorf("%w: record %d: %v", ErrJournalCorrupt, v.sequence+1, err)
+		}
+		if record.Sequence != v.sequence+1 {
+			return consumed, fmt.Errorf("%w: record %d: sequence number %d", ErrJournalCorrupt, v.sequence+1, record.Sequence)
+		}
+		if record.PrevHash != v.head {
+			return consumed, fmt.Errorf("%w: record %d: chain broken", ErrJournalCorrupt, record.Sequence)
+		}
+
+		consumed += int64(len(raw))
+		v.head, v.sequence = hash, record.Sequence
+		if visit != nil && !visit(record) {
+			return consumed, nil
+		}
+	}
+}
+
+// parseJournalLine decodes a line and checks the hash of its record
+func parseJournalLine(raw []byte) (*JournalRecord, string, error) {
+	var line journalLine
+	if err := json.Unmarshal(raw, &line); err != nil {
+		return nil, "", err
+	}
+
+	sum := sha256.Sum256(line.Record)
+	hash := hex.EncodeToString(sum[:])
+	if hash != line.Hash {
+		return nil, "", errors.New("record hash mismatch")
+	}
+
+	var record JournalRecord
+	if err := json.Unmarshal(line.Record, &record); err != nil {
+		return nil, "", err
+	}
+
+	return &record, hash, nil
+}
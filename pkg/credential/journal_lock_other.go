@@ -0,0 +1,18 @@
+//go:build !unix
+
+package credential
+
+import (
+	"os"
+)
+
+// lockFile is a no-op where advisory locks are unavailable. Only one process
+// may append to a journal at a time.
+func lockFile(f *os.File) error {
+	return nil
+}
+
+// unlockFile is a no-op where advisory locks are unavailable
+func unlockFile(f *os.File) error {
+	return nil
+}
@@ -0,0 +1,236 @@
+package credential
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/anupsv/bbsplus-signatures/bbs"
+)
+
+func issueWithJournal(t *testing.T, keyPair *bbs.KeyPair, journal *Journal, name string) *Credential {
+	t.Helper()
+
+	cred, err := NewBuilder().
+		SetSchema("https://example.com/schemas/identity").
+		SetIssuer("Example University").
+		AddAttribute("name", name).
+		AddAttribute("degree", "MSc").
+		SetJournal(journal).
+		Issue(keyPair)
+	if err != nil {
+		t.Fatalf("Issue failed: %v", err)
+	}
+	return cred
+}
+
+func TestIssueAndVerify(t *testing.T) {
+	keyPair, err := bbs.GenerateKeyPair(2, rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKeyPair failed: %v", err)
+	}
+
+	cred := issueWithJournal(t, keyPair, nil, "Alice")
+	if err := cred.Verify(); err != nil {
+		t.Fatalf("Verify failed: %v", err)
+	}
+
+	// The signing order survives a JSON round trip
+	data, err := cred.MarshalJSON()
+	if err != nil {
+		t.Fatalf("MarshalJSON failed: %v", err)
+	}
+	var decoded Credential
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if err := decoded.Verify(); err != nil {
+		t.Fatalf("Verify after round trip failed: %v", err)
+	}
+
+	decoded.Attributes[1] = StringAttribute("degree", "PhD")
+	if err := decoded.Verify(); err == nil {
+		t.Fatal("Verify accepted a modified attribute")
+	}
+
+	// The key must match the attribute count
+	if _, err := NewBuilder().AddAttribute("name", "Bob").Issue(keyPair); err == nil {
+		t.Fatal("Issue accepted the wrong number of attributes")
+	}
+}
+
+func TestJournal(t *testing.T) {
+	keyPair, err := bbs.GenerateKeyPair(2, rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKeyPair failed: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "issuance.log")
+	journal, err := OpenJournal(path)
+	if err != nil {
+		t.Fatalf("OpenJournal failed: %v", err)
+	}
+
+	// A second handle stands in for another issuing process
+	other, err := OpenJournal(path)
+	if err != nil {
+		t.Fatalf("OpenJournal failed: %v", err)
+	}
+
+	issued := []*Credential{
+		issueWithJournal(t, keyPair, journal, "Alice"),
+		issueWithJournal(t, keyPair, other, "Bob"),
+		issueWithJournal(t, keyPair, journal, "Carol"),
+	}
+	journal.Close()
+	other.Close()
+
+	records, err := VerifyJournalFile(path)
+	if err != nil {
+		t.Fatalf("VerifyJournalFile failed: %v", err)
+	}
+	if len(records) != len(issued) {
+		t.Fatalf("got %d records, want %d", len(records), len(issued))
+	}
+	for i, cred := range issued {
+		encoded, err := cred.MarshalJSON()
+		if err != nil {
+			t.Fatalf("MarshalJSON failed: %v", err)
+		}
+		rec := records[i]
+		if rec.Sequence != uint64(i+1) || rec.CredentialHash != CredentialHash(encoded) ||
+			rec.KeyFingerprint != KeyFingerprint(keyPair.PublicKey) || rec.Schema != cred.Schema {
+			t.Fatalf("record %d does not match credential: %+v", i, rec)
+		}
+	}
+
+	// Reopening continues the chain
+	journal, err = OpenJournal(path)
+	if err != nil {
+		t.Fatalf("OpenJournal failed: %v", err)
+	}
+	issueWithJournal(t, keyPair, journal, "Dave")
+	if _, seq := journal.Head(); seq != 4 {
+		t.Fatalf("head at sequence %d, want 4", seq)
+	}
+	journal.Close()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	lines := strings.SplitAfter(string(data), "\n")
+	lines = lines[:len(lines)-1]
+
+	tampered := map[string]string{
+		"edited":    strings.Replace(string(data), "identity", "passport", 1),
+		"dropped":   lines[0] + lines[2] + lines[3],
+		"reordered": lines[0] + lines[2] + lines[1] + lines[3],
+		"truncated": string(data[:len(data)-10]),
+		// Fixing the hash of an edited record breaks the next link instead
+		"rehashed": lines[0] + backdateLine(t, lines[1]) + lines[2] + lines[3],
+	}
+
+	for name, content := range tampered {
+		if _, err := VerifyJournal(strings.NewReader(content)); !errors.Is(err, ErrJournalCorrupt) {
+			t.Fatalf("%s: got %v, want ErrJournalCorrupt", name, err)
+		}
+	}
+
+	// Streaming yields the verified records, then the error
+	var streamed []uint64
+	var last error
+	for rec, err := range JournalRecords(strings.NewReader(tampered["dropped"])) {
+		if err != nil {
+			last = err
+			continue
+		}
+		streamed = append(streamed, rec.Sequence)
+	}
+	if len(streamed) != 1 || streamed[0] != 1 || !errors.Is(last, ErrJournalCorrupt) {
+		t.Fatalf("JournalRecords yielded %v then %v", streamed, last)
+	}
+	streamed = nil
+	for rec, err := range JournalRecords(strings.NewReader(string(data))) {
+		if err != nil {
+			t.Fatalf("JournalRecords failed: %v", err)
+		}
+		streamed = append(streamed, rec.Sequence)
+		if rec.Sequence == 2 {
+			break
+		}
+	}
+	if len(streamed) != 2 {
+		t.Fatalf("JournalRecords did not stop early: %v", streamed)
+	}
+
+	// Appending to a corrupt journal is refused
+	if err := os.WriteFile(path, []byte(tampered["dropped"]), 0600); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	if _, err := OpenJournal(path); !errors.Is(err, ErrJournalCorrupt) {
+		t.Fatalf("OpenJournal on corrupt journal: got %v, want ErrJournalCorrupt", err)
+	}
+}
+
+// backdateLine moves the record time of a line back a year and recomputes
+// the record hash
+func backdateLine(t *testing.T, line string) string {
+	t.Helper()
+
+	var jl journalLine
+	if err := json.Unmarshal([]byte(line), &jl); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	var rec JournalRecord
+	if err := json.Unmarshal(jl.Record, &rec); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	rec.Time = rec.Time.AddDate(-1, 0, 0)
+
+	recordJSON, err := json.Marshal(&rec)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	sum := sha256.Sum256(recordJSON)
+	jl.Record = recordJSON
+	jl.Hash = hex.EncodeToString(sum[:])
+
+	out, err := json.Marshal(jl)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	return string(out) + "\n"
+}
+
+func TestKeyFingerprint(t *testing.T) {
+	keyPair, err := bbs.GenerateKeyPair(2, rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key pair: %v", err)
+	}
+	other, err := bbs.GenerateKeyPair(2, rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key pair: %v", err)
+	}
+
+	// Records written before fingerprints hold the hex SHA-256 of the key
+	sum := sha256.Sum256(bbs.SerializePublicKey(keyPair.PublicKey))
+	legacy := hex.EncodeToString(sum[:])
+
+	for fingerprint, want := range map[string]bool{
+		KeyFingerprint(keyPair.PublicKey): true,
+		legacy:                            true,
+		KeyFingerprint(other.PublicKey):   false,
+		"":                                false,
+	} {
+		if got := matchesKeyFingerprint(fingerprint, keyPair.PublicKey); got != want {
+			t.Fatalf("matchesKeyFingerprint(%q) = %v, want %v", fingerprint, got, want)
+		}
+	}
+}
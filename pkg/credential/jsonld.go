@@ -0,0 +1,270 @@
+package credential
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+)
+
+// JSON-LD vocabulary for Data Integrity proofs
+const (
+	// CredentialsContextV1 is the W3C Verifiable Credentials context
+	CredentialsContextV1 = "https://www.w3.org/2018/credentials/v1"
+
+	// DataIntegrityProofType is the proof type of Data Integrity proofs
+	DataIntegrityProofType = "DataIntegrityProof"
+
+	// BBSCryptosuite names the BBS cryptosuite of a Data Integrity proof
+	BBSCryptosuite = "bbs-2023"
+
+	// ProofPurposeAssertion is the proof purpose of issuer assertions, which
+	// derived proofs keep
+	ProofPurposeAssertion = "assertionMethod"
+
+	// multibaseBase64URL prefixes unpadded base64url in multibase
+	multibaseBase64URL = 'u'
+)
+
+// DataIntegrityProof is the proof object of a JSON-LD credential
+type DataIntegrityProof struct {
+	Type               string    `json:"type"`
+	Cryptosuite        string    `json:"cryptosuite"`
+	Created            time.Time `json:"created"`
+	VerificationMethod string    `json:"verificationMethod"`
+	ProofPurpose       string    `json:"proofPurpose"`
+	ProofValue         string    `json:"proofValue"`
+	Challenge          string    `json:"challenge,omitempty"`
+}
+
+// NewDataIntegrityProof wraps serialized BBS+ proof bytes in a Data Integrity
+// proof
+func NewDataIntegrityProof(proof []byte, verificationMethod string, created time.Time) *DataIntegrityProof {
+	return &DataIntegrityProof{
+		Type:               DataIntegrityProofType,
+		Cryptosuite:        BBSCryptosuite,
+		Created:            created.UTC().Truncate(time.Second),
+		VerificationMethod: verificationMethod,
+		ProofPurpose:       ProofPurposeAssertion,
+		ProofValue:         EncodeMultibase(proof),
+	}
+}
+
+// ProofBytes checks the proof type and returns the decoded proof value
+func (p *DataIntegrityProof) ProofBytes() ([]byte, error) {
+	if p.Type != DataIntegrityProofType {
+		return nil, fmt.Errorf("unsupported proof type '%s'", p.Type)
+	}
+	if p.Cryptosuite != BBSCryptosuite {
+		return nil, fmt.Errorf("unsupported cryptosuite '%s'", p.Cryptosuite)
+	}
+	if p.VerificationMethod == "" {
+		return nil, fmt.Errorf("proof has no verification method")
+	}
+
+	return DecodeMultibase(p.ProofValue)
+}
+
+// EncodeMultibase encodes data as multibase base64url without padding
+func EncodeMultibase(data []byte) string {
+	return string(multibaseBase64URL) + base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeMultibase decodes a multibase base64url string
+func DecodeMultibase(value string) ([]byte, error) {
+	if value == "" {
+		return nil, fmt.Errorf("empty multibase value")
+	}
+	if value[0] != multibaseBase64URL {
+		return nil, fmt.Errorf("unsupported multibase encoding '%c'", value[0])
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(value[1:])
+	if err != nil {
+		return nil, fmt.Errorf("invalid multibase value: %w", err)
+	}
+	return data, nil
+}
+
+// credentialSchemaJSONLD references the credential schema
+type credentialSchemaJSONLD struct {
+	ID   string `json:"id"`
+	Type string `json:"type"`
+}
+
+// presentationJSONLD is a presentation as a JSON-LD credential
+type presentationJSONLD struct {
+	Context           []string                `json:"@context"`
+	Type              []string                `json:"type"`
+	Issuer            string                  `json:"issuer"`
+	CredentialSchema  *credentialSchemaJSONLD `json:"credentialSchema,omitempty"`
+	CredentialSubject map[string]any          `json:"credentialSubject"`
+	Proof             *DataIntegrityProof     `json:"proof"`
+}
+
+// MarshalJSONLD serializes the presentation as a JSON-LD verifiable
+// credential whose proof is a bbs-2023 Data Integrity proof. The disclosed
+// attributes become the credential subject, int64 and bool values as JSON
+// numbers and booleans and the others as their canonical text, and the
+// nonce the proof challenge. VerificationMethod must be set.
+func (p *Presentation) MarshalJSONLD() ([]byte, error) {
+	if p.VerificationMethod == "" {
+		return nil, fmt.Errorf("presentation has no verification method")
+	}
+
+	proof, err := base64.StdEncoding.DecodeString(p.Proof)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode proof: %w", err)
+	}
+
+	doc := presentationJSONLD{
+		Context:           []string{CredentialsContextV1, DefaultCredentialContext},
+		Type:              []string{"VerifiableCredential"},
+		Issuer:            p.Issuer,
+		CredentialSubject: make(map[string]any, len(p.Attributes)),
+		Proof:             NewDataIntegrityProof(proof, p.VerificationMethod, p.Created),
+	}
+	if p.Schema != "" {
+		doc.CredentialSchema = &credentialSchemaJSONLD{ID: p.Schema, Type: "JsonSchema"}
+	}
+	for _, attr := range p.Attributes {
+		switch v := attr.Value().(type) {
+		case int64, bool:
+			doc.CredentialSubject[attr.Name] = v
+		default:
+			doc.CredentialSubject[attr.Name] = attr.Text()
+		}
+	}
+	doc.Proof.Challenge = p.NonceUsed
+
+	return json.Marshal(doc)
+}
+
+// UnmarshalJSONLD deserializes a presentation written by MarshalJSONLD.
+// Subject numbers and booleans become int64 and bool attributes and other
+// values string attributes, in name order. Like UnmarshalJSON it refuses
+// unknown, duplicate and case-folded fields; ParsePresentationJSONLD takes
+// DecodeOptions for documents written by other software.
+func (p *Presentation) UnmarshalJSONLD(data []byte) error {
+	return p.decodeJSONLD(data, DecodeOptions{})
+}
+
+// ParsePresentationJSONLD parses a presentation written by
+// Presentation.MarshalJSONLD
+func ParsePresentationJSONLD(data []byte, opts DecodeOptions) (*Presentation, error) {
+	if err := opts.checkSize(data); err != nil {
+		return nil, err
+	}
+	p := &Presentation{}
+	if err := p.decodeJSONLD(data, opts); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// decodeJSONLD deserializes a JSON-LD presentation with opts
+func (p *Presentation) decodeJSONLD(data []byte, opts DecodeOptions) error {
+	var doc struct {
+		Context           json.RawMessage            `json:"@context"`
+		Type              json.RawMessage            `json:"type"`
+		Issuer            string                     `json:"issuer"`
+		CredentialSchema  *credentialSchemaJSONLD    `json:"credentialSchema,omitempty"`
+		CredentialSubject map[string]json.RawMessage `json:"credentialSubject"`
+		Proof             *DataIntegrityProof        `json:"proof"`
+	}
+	if err := opts.unmarshal(data, &doc); err != nil {
+		return err
+	}
+
+	// @context and type may each be a single string or an array
+	contexts, err := stringOrArray(doc.Context)
+	if err != nil {
+		return fmt.Errorf("invalid @context: %w", err)
+	}
+	if !contains(contexts, CredentialsContextV1) {
+		return fmt.Errorf("document is not a verifiable credential: missing context %s", CredentialsContextV1)
+	}
+	types, err := stringOrArray(doc.Type)
+	if err != nil {
+		return fmt.Errorf("invalid type: %w", err)
+	}
+	if !contains(types, "VerifiableCredential") {
+		return fmt.Errorf("document is not a verifiable credential")
+	}
+
+	if doc.Proof == nil {
+		return fmt.Errorf("document has no proof")
+	}
+	proof, err := doc.Proof.ProofBytes()
+	if err != nil {
+		return err
+	}
+
+	attributes := make([]Attribute, 0, len(doc.CredentialSubject))
+	for _, name := range slices.Sorted(maps.Keys(doc.CredentialSubject)) {
+		attr, err := subjectAttribute(name, doc.CredentialSubject[name])
+		if err != nil {
+			return err
+		}
+		attributes = append(attributes, attr)
+	}
+
+	p.Schema = ""
+	if doc.CredentialSchema != nil {
+		p.Schema = doc.CredentialSchema.ID
+	}
+	p.Proof = base64.StdEncoding.EncodeToString(proof)
+	p.Attributes = attributes
+	p.Issuer = doc.Issuer
+	p.Created = doc.Proof.Created
+	p.NonceUsed = doc.Proof.Challenge
+	p.VerificationMethod = doc.Proof.VerificationMethod
+
+	return nil
+}
+
+// subjectAttribute reads a credential subject value as an attribute
+func subjectAttribute(name string, raw json.RawMessage) (Attribute, error) {
+	if string(raw) == "null" {
+		return Attribute{}, fmt.Errorf("%w: subject value '%s' is null", ErrInvalidAttribute, name)
+	}
+	var n int64
+	if err := json.Unmarshal(raw, &n); err == nil {
+		return IntAttribute(name, n), nil
+	}
+	var b bool
+	if err := json.Unmarshal(raw, &b); err == nil {
+		return BoolAttribute(name, b), nil
+	}
+	var text string
+	if err := json.Unmarshal(raw, &text); err != nil {
+		return Attribute{}, fmt.Errorf("%w: subject value '%s' is not a string, integer or boolean", ErrInvalidAttribute, name)
+	}
+	return StringAttribute(name, text), nil
+}
+
+// stringOrArray decodes a JSON-LD value that is a string or a string array
+func stringOrArray(raw json.RawMessage) ([]string, error) {
+	var single string
+	if err := json.Unmarshal(raw, &single); err == nil {
+		return []string{single}, nil
+	}
+
+	var values []string
+	if err := json.Unmarshal(raw, &values); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// contains reports whether values holds value
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,171 @@
+package credential
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/anupsv/bbsplus-signatures/bbs"
+)
+
+func jsonldPresentation(t *testing.T) (*Presentation, []byte) {
+	t.Helper()
+
+	keyPair, err := bbs.GenerateKeyPair(2, rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKeyPair failed: %v", err)
+	}
+	messages := []*big.Int{
+		bbs.MessageToFieldElement([]byte("Alice")),
+		bbs.MessageToFieldElement([]byte("MSc")),
+	}
+	signature, err := bbs.Sign(keyPair.PrivateKey, keyPair.PublicKey, messages, nil)
+	if err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+	proof, _, err := bbs.CreateProof(keyPair.PublicKey, signature, messages, []int{1}, nil)
+	if err != nil {
+		t.Fatalf("CreateProof failed: %v", err)
+	}
+	proofBytes := bbs.SerializeProof(proof)
+
+	return &Presentation{
+		Schema:             "https://example.com/schemas/identity",
+		Proof:              base64.StdEncoding.EncodeToString(proofBytes),
+		Attributes:         []Attribute{StringAttribute("degree", "MSc"), IntAttribute("year", 2024)},
+		Issuer:             "did:example:university",
+		Created:            time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC),
+		NonceUsed:          "n-0S6_WzA2Mj",
+		VerificationMethod: "did:example:university#bbs-key-1",
+	}, proofBytes
+}
+
+func TestPresentationJSONLD(t *testing.T) {
+	presentation, proofBytes := jsonldPresentation(t)
+
+	data, err := presentation.MarshalJSONLD()
+	if err != nil {
+		t.Fatalf("MarshalJSONLD failed: %v", err)
+	}
+
+	// The document carries a Data Integrity proof with a multibase value
+	var doc map[string]interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	proof := doc["proof"].(map[string]interface{})
+	for field, want := range map[string]string{
+		"type":               DataIntegrityProofType,
+		"cryptosuite":        BBSCryptosuite,
+		"proofPurpose":       ProofPurposeAssertion,
+		"verificationMethod": presentation.VerificationMethod,
+		"created":            "2026-03-01T12:00:00Z",
+		"challenge":          presentation.NonceUsed,
+	} {
+		if proof[field] != want {
+			t.Fatalf("proof.%s = %v, want %s", field, proof[field], want)
+		}
+	}
+	proofValue := proof["proofValue"].(string)
+	if !strings.HasPrefix(proofValue, "u") {
+		t.Fatalf("proofValue %q is not multibase base64url", proofValue)
+	}
+	decoded, err := DecodeMultibase(proofValue)
+	if err != nil || !bytes.Equal(decoded, proofBytes) {
+		t.Fatalf("proofValue does not decode to the proof: %v", err)
+	}
+	if doc["credentialSubject"].(map[string]interface{})["degree"] != "MSc" {
+		t.Fatalf("credentialSubject is missing the disclosed attribute")
+	}
+
+	// And reads back into the same presentation
+	var restored Presentation
+	if err := restored.UnmarshalJSONLD(data); err != nil {
+		t.Fatalf("UnmarshalJSONLD failed: %v", err)
+	}
+	if restored.Proof != presentation.Proof || restored.Schema != presentation.Schema ||
+		restored.Issuer != presentation.Issuer || restored.NonceUsed != presentation.NonceUsed ||
+		restored.VerificationMethod != presentation.VerificationMethod ||
+		!restored.Created.Equal(presentation.Created) ||
+		!slices.EqualFunc(restored.Attributes, presentation.Attributes, Attribute.Equal) {
+		t.Fatalf("Round trip changed the presentation: %+v", restored)
+	}
+}
+
+func TestPresentationJSONLDRejects(t *testing.T) {
+	presentation, _ := jsonldPresentation(t)
+
+	noMethod := *presentation
+	noMethod.VerificationMethod = ""
+	if _, err := noMethod.MarshalJSONLD(); err == nil {
+		t.Fatalf("Expected an error without a verification method")
+	}
+
+	data, err := presentation.MarshalJSONLD()
+	if err != nil {
+		t.Fatalf("MarshalJSONLD failed: %v", err)
+	}
+
+	for name, edit := range map[string]func(doc map[string]interface{}){
+		"context":     func(doc map[string]interface{}) { doc["@context"] = "https://example.com/other" },
+		"cryptosuite": func(doc map[string]interface{}) { doc["proof"].(map[string]interface{})["cryptosuite"] = "ecdsa-2019" },
+		"multibase":   func(doc map[string]interface{}) { doc["proof"].(map[string]interface{})["proofValue"] = "z3mJ" },
+		"no proof":    func(doc map[string]interface{}) { delete(doc, "proof") },
+		"unknown":     func(doc map[string]interface{}) { doc["extra"] = true },
+		"proof field": func(doc map[string]interface{}) { doc["proof"].(map[string]interface{})["nonce"] = "n" },
+		"folded":      func(doc map[string]interface{}) { doc["Issuer"] = doc["issuer"]; delete(doc, "issuer") },
+		"null value": func(doc map[string]interface{}) {
+			for name := range doc["credentialSubject"].(map[string]interface{}) {
+				doc["credentialSubject"].(map[string]interface{})[name] = nil
+			}
+		},
+	} {
+		var doc map[string]interface{}
+		if err := json.Unmarshal(data, &doc); err != nil {
+			t.Fatalf("Unmarshal failed: %v", err)
+		}
+		edit(doc)
+		edited, err := json.Marshal(doc)
+		if err != nil {
+			t.Fatalf("Marshal failed: %v", err)
+		}
+
+		var restored Presentation
+		if err := restored.UnmarshalJSONLD(edited); err == nil {
+			t.Fatalf("%s: expected UnmarshalJSONLD to fail", name)
+		}
+	}
+}
+
+func TestParsePresentationJSONLD(t *testing.T) {
+	presentation, _ := jsonldPresentation(t)
+	data, err := presentation.MarshalJSONLD()
+	if err != nil {
+		t.Fatalf("MarshalJSONLD failed: %v", err)
+	}
+	extra := bytes.Replace(data, []byte(`{"@context":`), []byte(`{"id":"urn:uuid:1","@context":`), 1)
+
+	if _, err := ParsePresentationJSONLD(data, DecodeOptions{}); err != nil {
+		t.Fatalf("ParsePresentationJSONLD failed: %v", err)
+	}
+	if _, err := ParsePresentationJSONLD(extra, DecodeOptions{}); !errors.Is(err, ErrMalformedJSON) {
+		t.Fatalf("Expected ErrMalformedJSON, got %v", err)
+	}
+	restored, err := ParsePresentationJSONLD(extra, DecodeOptions{Lenient: true})
+	if err != nil {
+		t.Fatalf("Lenient ParsePresentationJSONLD failed: %v", err)
+	}
+	if restored.Issuer != presentation.Issuer {
+		t.Fatalf("Lenient parse changed the issuer: %s", restored.Issuer)
+	}
+	if _, err := ParsePresentationJSONLD(data, DecodeOptions{MaxSize: 16}); !errors.Is(err, ErrMalformedJSON) {
+		t.Fatalf("Expected ErrMalformedJSON for an oversized document, got %v", err)
+	}
+}
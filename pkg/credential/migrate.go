@@ -0,0 +1,214 @@
+package credential
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"maps"
+	"math/big"
+	"slices"
+	"time"
+
+	"github.com/anupsv/bbsplus-signatures/bbs"
+)
+
+// A key signs a fixed number of messages, so adding attributes to a schema
+// needs a new, larger key. Migration re-issues each credential under the new
+// key with its old attribute values, followed by the added ones, and records
+// a MigrationLink between the old and new credential hashes. The old key
+// signs the link, so a verifier that trusts the old key can follow it to the
+// new key.
+//
+// Links cover credential hashes of any encoding, so tools with their own
+// credential files use NewMigrationLink directly.
+
+// migrationDST is the header of migration link signatures
+const migrationDST = "BBS_CREDENTIAL_MIGRATION_V1_"
+
+// ErrInvalidMigration is returned when a migration link does not match its
+// credentials or keys
+var ErrInvalidMigration = errors.New("invalid credential migration")
+
+// MigrationLink records that a credential was re-issued under a new key
+type MigrationLink struct {
+	Time              time.Time `json:"time"`
+	Schema            string    `json:"schema"`
+	OldCredentialHash string    `json:"oldCredentialHash"`
+	NewCredentialHash string    `json:"newCredentialHash"`
+	OldKeyFingerprint string    `json:"oldKeyFingerprint"`
+	NewKeyFingerprint string    `json:"newKeyFingerprint"`
+	AddedAttributes   []string  `json:"addedAttributes,omitempty"`
+	Signature         string    `json:"signature"` // Base64 BBS+ signature of the old key
+}
+
+// NewMigrationLink signs a link from the encoded old credential to the
+// encoded new one with the old key
+func NewMigrationLink(oldKey *bbs.KeyPair, newKey *bbs.PublicKey, schema string, oldEncoded, newEncoded []byte, added []string) (*MigrationLink, error) {
+	if oldKey == nil || oldKey.PrivateKey == nil || oldKey.PublicKey == nil {
+		return nil, fmt.Errorf("old key pair with a private key is required")
+	}
+	if newKey == nil {
+		return nil, fmt.Errorf("new public key is required")
+	}
+
+	link := &MigrationLink{
+		Time:              time.Now().UTC(),
+		Schema:            schema,
+		OldCredentialHash: CredentialHash(oldEncoded),
+		NewCredentialHash: CredentialHash(newEncoded),
+		OldKeyFingerprint: KeyFingerprint(oldKey.PublicKey),
+		NewKeyFingerprint: KeyFingerprint(newKey),
+		AddedAttributes:   slices.Clone(added),
+	}
+
+	signature, err := bbs.Sign(oldKey.PrivateKey, oneMessageKey(oldKey.PublicKey), link.messages(), []byte(migrationDST))
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign migration link: %w", err)
+	}
+	link.Signature = base64.StdEncoding.EncodeToString(bbs.SerializeSignature(signature))
+	return link, nil
+}
+
+// Verify checks the link signature under the old key
+func (l *MigrationLink) Verify(oldKey *bbs.PublicKey) error {
+	if !matchesKeyFingerprint(l.OldKeyFingerprint, oldKey) {
+		return fmt.Errorf("%w: link was signed by another key", ErrInvalidMigration)
+	}
+
+	sigBytes, err := base64.StdEncoding.DecodeString(l.Signature)
+	if err != nil {
+		return fmt.Errorf("%w: failed to decode signature: %v", ErrInvalidMigration, err)
+	}
+	signature, err := bbs.DeserializeSignature(sigBytes)
+	if err != nil {
+		return fmt.Errorf("%w: failed to deserialize signature: %v", ErrInvalidMigration, err)
+	}
+
+	if err := bbs.Verify(oneMessageKey(oldKey), signature, l.messages(), []byte(migrationDST)); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidMigration, err)
+	}
+	return nil
+}
+
+// VerifyEncoded checks the link signature and that it joins the two encoded
+// credentials
+func (l *MigrationLink) VerifyEncoded(oldKey *bbs.PublicKey, oldEncoded, newEncoded []byte) error {
+	if err := l.Verify(oldKey); err != nil {
+		return err
+	}
+	if l.OldCredentialHash != CredentialHash(oldEncoded) || l.NewCredentialHash != CredentialHash(newEncoded) {
+		return fmt.Errorf("%w: link is for other credentials", ErrInvalidMigration)
+	}
+	return nil
+}
+
+// Migrate re-issues cred under newKey. The old attributes keep their values
+// and order; the added ones follow in name order as string attributes. newKey must sign exactly
+// the combined number of attributes, and oldKey must be the key cred was
+// issued under.
+func Migrate(oldKey, newKey *bbs.KeyPair, cred *Credential, added map[string]string) (*Credential, *MigrationLink, error) {
+	if oldKey == nil || oldKey.PrivateKey == nil || oldKey.PublicKey == nil {
+		return nil, nil, fmt.Errorf("old key pair with a private key is required")
+	}
+	if newKey == nil || newKey.PrivateKey == nil || newKey.PublicKey == nil {
+		return nil, nil, fmt.Errorf("new key pair with a private key is required")
+	}
+	if !cred.issuedUnder(oldKey.PublicKey) {
+		return nil, nil, fmt.Errorf("%w: credential was issued under another key", ErrInvalidMigration)
+	}
+	if err := cred.Verify(); err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrInvalidMigration, err)
+	}
+
+	if want := len(cred.Attributes) + len(added); newKey.PublicKey.MessageCount() != want {
+		return nil, nil, fmt.Errorf("%w: new key signs %d attributes, migrated credential has %d",
+			ErrInvalidMigration, newKey.PublicKey.MessageCount(), want)
+	}
+
+	// The ID stays, but no longer commits to the attributes
+	migrated := *cred
+	migrated.Attributes = slices.Clone(cred.Attributes)
+	migrated.IDSalt = ""
+	addedNames := slices.Sorted(maps.Keys(added))
+	for _, name := range addedNames {
+		if _, ok := cred.Attribute(name); ok {
+			return nil, nil, fmt.Errorf("%w: attribute '%s' already in credential", ErrInvalidMigration, name)
+		}
+		migrated.Attributes = append(migrated.Attributes, StringAttribute(name, added[name]))
+	}
+	if err := migrated.sign(newKey); err != nil {
+		return nil, nil, err
+	}
+
+	oldEncoded, err := cred.MarshalJSON()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to encode credential: %w", err)
+	}
+	newEncoded, err := migrated.MarshalJSON()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to encode credential: %w", err)
+	}
+
+	link, err := NewMigrationLink(oldKey, newKey.PublicKey, cred.Schema, oldEncoded, newEncoded, addedNames)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &migrated, link, nil
+}
+
+// VerifyMigration checks that the old key signed the link, that the link
+// joins oldCred to newCred, that newCred keeps every old attribute value in
+// order and adds just the linked attributes, and that newCred is validly
+// signed by the key the link names
+func VerifyMigration(oldKey *bbs.PublicKey, link *MigrationLink, oldCred, newCred *Credential) error {
+	oldEncoded, err := oldCred.MarshalJSON()
+	if err != nil {
+		return fmt.Errorf("failed to encode credential: %w", err)
+	}
+	newEncoded, err := newCred.MarshalJSON()
+	if err != nil {
+		return fmt.Errorf("failed to encode credential: %w", err)
+	}
+	if err := link.VerifyEncoded(oldKey, oldEncoded, newEncoded); err != nil {
+		return err
+	}
+
+	pubKeyBytes, err := base64.StdEncoding.DecodeString(newCred.PublicKey)
+	if err != nil {
+		return fmt.Errorf("failed to decode public key: %w", err)
+	}
+	newKey, err := bbs.DeserializePublicKey(pubKeyBytes)
+	if err != nil {
+		return fmt.Errorf("failed to deserialize public key: %w", err)
+	}
+	if !matchesKeyFingerprint(link.NewKeyFingerprint, newKey) {
+		return fmt.Errorf("%w: migrated credential is under another key", ErrInvalidMigration)
+	}
+
+	expected := append(oldCred.AttributeNames(), link.AddedAttributes...)
+	if !slices.Equal(newCred.AttributeNames(), expected) || newCred.Schema != oldCred.Schema || newCred.Issuer != oldCred.Issuer {
+		return fmt.Errorf("%w: migrated credential has other attributes", ErrInvalidMigration)
+	}
+	for i, attr := range oldCred.Attributes {
+		if !newCred.Attributes[i].Equal(attr) {
+			return fmt.Errorf("%w: attribute '%s' changed", ErrInvalidMigration, attr.Name)
+		}
+	}
+
+	return newCred.Verify()
+}
+
+// messages returns the single message the link signature covers
+func (l *MigrationLink) messages() []*big.Int {
+	var buf []byte
+	buf = append(buf, migrationDST...)
+	for _, field := range []string{l.Schema, l.OldCredentialHash, l.NewCredentialHash, l.OldKeyFingerprint, l.NewKeyFingerprint, l.Time.UTC().Format(time.RFC3339Nano)} {
+		buf = appendLengthPrefixed(buf, []byte(field))
+	}
+	buf = binary.BigEndian.AppendUint32(buf, uint32(len(l.AddedAttributes)))
+	for _, name := range l.AddedAttributes {
+		buf = appendLengthPrefixed(buf, []byte(name))
+	}
+	return []*big.Int{bbs.MessageToFieldElement(buf)}
+}
@@ -0,0 +1,74 @@
+package credential
+
+import (
+	"crypto/rand"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/anupsv/bbsplus-signatures/bbs"
+)
+
+func TestMigrate(t *testing.T) {
+	oldKey, err := bbs.GenerateKeyPair(2, rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key pair: %v", err)
+	}
+	newKey, err := bbs.GenerateKeyPair(4, rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key pair: %v", err)
+	}
+
+	cred, err := NewBuilder().
+		SetSchema("https://example.com/schemas/member").
+		SetIssuer("https://example.com").
+		AddAttribute("name", "Alice").
+		AddAttribute("level", "gold").
+		Issue(oldKey)
+	if err != nil {
+		t.Fatalf("Issue failed: %v", err)
+	}
+
+	migrated, link, err := Migrate(oldKey, newKey, cred, map[string]string{"region": "EU", "since": "2020"})
+	if err != nil {
+		t.Fatalf("Migrate failed: %v", err)
+	}
+	if err := migrated.Verify(); err != nil {
+		t.Fatalf("Migrated credential does not verify: %v", err)
+	}
+	if err := VerifyMigration(oldKey.PublicKey, link, cred, migrated); err != nil {
+		t.Fatalf("VerifyMigration failed: %v", err)
+	}
+
+	// Old attributes keep their positions
+	if names := migrated.AttributeNames(); names[0] != "name" || names[1] != "level" || names[2] != "region" {
+		t.Fatalf("Unexpected attribute order %v", names)
+	}
+
+	// The new key cannot vouch for the link
+	if err := link.Verify(newKey.PublicKey); !errors.Is(err, ErrInvalidMigration) {
+		t.Fatalf("Expected ErrInvalidMigration under the new key, got %v", err)
+	}
+
+	// A changed old value is caught even when re-signed under the new key
+	changed := *migrated
+	changed.Attributes = slices.Clone(migrated.Attributes)
+	changed.Attributes[0] = StringAttribute("name", "Mallory")
+	if err := changed.sign(newKey); err != nil {
+		t.Fatalf("sign failed: %v", err)
+	}
+	if err := VerifyMigration(oldKey.PublicKey, link, cred, &changed); !errors.Is(err, ErrInvalidMigration) {
+		t.Fatalf("Expected ErrInvalidMigration for another credential, got %v", err)
+	}
+
+	// The new key must fit the migrated attributes exactly
+	if _, _, err := Migrate(oldKey, newKey, cred, map[string]string{"region": "EU"}); !errors.Is(err, ErrInvalidMigration) {
+		t.Fatalf("Expected ErrInvalidMigration for a key of the wrong size, got %v", err)
+	}
+	if _, _, err := Migrate(oldKey, newKey, cred, map[string]string{"name": "Bob", "x": "y"}); !errors.Is(err, ErrInvalidMigration) {
+		t.Fatalf("Expected ErrInvalidMigration for an existing attribute, got %v", err)
+	}
+	if _, _, err := Migrate(newKey, newKey, cred, map[string]string{"region": "EU", "since": "2020"}); !errors.Is(err, ErrInvalidMigration) {
+		t.Fatalf("Expected ErrInvalidMigration for another old key, got %v", err)
+	}
+}
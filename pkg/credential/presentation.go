@@ -1,21 +1,30 @@
 package credential
 
 import (
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 )
 
+// ErrForbiddenDisclosure is returned when a presentation discloses an
+// attribute the verifier requires to stay hidden
+var ErrForbiddenDisclosure = errors.New("forbidden attribute disclosed")
+
 // Presentation represents a selective disclosure presentation of a credential
 type Presentation struct {
 	// Schema identifies the credential schema
 	Schema string `json:"schema"`
+
+	// SchemaHash is the hex hash of the schema the credential is bound to
+	SchemaHash string `json:"schemaHash,omitempty"`
 	
 	// Proof is the BBS+ selective disclosure proof (Base64-encoded)
 	Proof string `json:"proof"`
 	
 	// Attributes contains the disclosed credential attributes
-	Attributes map[string]string `json:"attributes"`
+	Attributes []Attribute `json:"attributes"`
 	
 	// Issuer identifies the original credential issuer
 	Issuer string `json:"issuer"`
@@ -25,6 +34,20 @@ type Presentation struct {
 	
 	// NonceUsed is the nonce used in the presentation (if any)
 	NonceUsed string `json:"nonceUsed,omitempty"`
+
+	// VerificationMethod identifies the issuer key the proof verifies under,
+	// as a URL such as a DID key reference
+	VerificationMethod string `json:"verificationMethod,omitempty"`
+}
+
+// Attribute returns the named disclosed attribute
+func (p *Presentation) Attribute(name string) (Attribute, bool) {
+	for _, attr := range p.Attributes {
+		if attr.Name == name {
+			return attr, true
+		}
+	}
+	return Attribute{}, false
 }
 
 // Verifier provides a fluent interface for verifying presentations
@@ -32,7 +55,9 @@ type Verifier struct {
 	presentation   *Presentation
 	expectedIssuer string
 	expectedSchema string
+	schemaHash     []byte
 	nonce          string
+	forbidden      []string
 }
 
 // NewVerifier creates a new presentation verifier
@@ -58,12 +83,28 @@ func (v *Verifier) ExpectSchema(schema string) *Verifier {
 	return v
 }
 
+// ExpectSchemaHash requires the presentation to be of a credential bound to
+// the schema hash, as returned by SchemaHash for the schema the verifier
+// expects. Presentations of credentials bound to a schema are rejected
+// unless the verifier supplies it.
+func (v *Verifier) ExpectSchemaHash(hash []byte) *Verifier {
+	v.schemaHash = hash
+	return v
+}
+
 // SetNonce sets the nonce to verify in the presentation
 func (v *Verifier) SetNonce(nonce string) *Verifier {
 	v.nonce = nonce
 	return v
 }
 
+// ForbidDisclosure rejects presentations that disclose any of the named
+// attributes, such as a full birth date where an age predicate suffices
+func (v *Verifier) ForbidDisclosure(names ...string) *Verifier {
+	v.forbidden = append(v.forbidden, names...)
+	return v
+}
+
 // Verify checks if the presentation is valid
 func (v *Verifier) Verify() error {
 	if v.presentation == nil {
@@ -82,10 +123,26 @@ func (v *Verifier) Verify() error {
 			v.expectedSchema, v.presentation.Schema)
 	}
 	
+	// Check the schema binding. The proof verifies under the schema hash
+	// the verifier supplies, never the one the presentation claims.
+	if v.schemaHash == nil && v.presentation.SchemaHash != "" {
+		return fmt.Errorf("%w: presentation is bound to a schema, but none is expected", ErrSchemaMismatch)
+	}
+	if v.schemaHash != nil && v.presentation.SchemaHash != hex.EncodeToString(v.schemaHash) {
+		return fmt.Errorf("%w: presentation is not bound to the expected schema", ErrSchemaMismatch)
+	}
+	
 	// Check nonce if provided
 	if v.nonce != "" && v.presentation.NonceUsed != v.nonce {
 		return fmt.Errorf("incorrect nonce used in presentation")
 	}
+
+	// Check that no forbidden attribute is disclosed
+	for _, name := range v.forbidden {
+		if _, ok := v.presentation.Attribute(name); ok {
+			return fmt.Errorf("%w: attribute '%s' must not be disclosed", ErrForbiddenDisclosure, name)
+		}
+	}
 	
 	return fmt.Errorf("BBS+ proof verification not implemented")
 }
@@ -95,49 +152,67 @@ func (p *Presentation) MarshalJSON() ([]byte, error) {
 	// Create a copy without private fields
 	type presentationExport struct {
 		Schema    string            `json:"schema"`
+		SchemaHash string           `json:"schemaHash,omitempty"`
 		Proof     string            `json:"proof"`
-		Attributes map[string]string `json:"attributes"`
+		Attributes []Attribute `json:"attributes"`
 		Issuer    string            `json:"issuer"`
 		Created   time.Time         `json:"created"`
 		NonceUsed string            `json:"nonceUsed,omitempty"`
+		VerificationMethod string   `json:"verificationMethod,omitempty"`
 	}
 	
 	export := presentationExport{
 		Schema:    p.Schema,
+		SchemaHash: p.SchemaHash,
 		Proof:     p.Proof,
 		Attributes: p.Attributes,
 		Issuer:    p.Issuer,
 		Created:   p.Created,
 		NonceUsed: p.NonceUsed,
+		VerificationMethod: p.VerificationMethod,
 	}
 	
 	return json.Marshal(export)
 }
 
-// UnmarshalJSON deserializes a presentation from JSON
+// UnmarshalJSON deserializes a presentation from JSON with the strict
+// decoder
 func (p *Presentation) UnmarshalJSON(data []byte) error {
+	return p.decode(data, DecodeOptions{})
+}
+
+// decode deserializes a presentation from JSON with opts
+func (p *Presentation) decode(data []byte, opts DecodeOptions) error {
 	// Create a temporary type to avoid recursion
 	type presentationImport struct {
 		Schema    string            `json:"schema"`
+		SchemaHash string           `json:"schemaHash,omitempty"`
 		Proof     string            `json:"proof"`
-		Attributes map[string]string `json:"attributes"`
+		Attributes json.RawMessage `json:"attributes"`
 		Issuer    string            `json:"issuer"`
 		Created   time.Time         `json:"created"`
 		NonceUsed string            `json:"nonceUsed,omitempty"`
+		VerificationMethod string   `json:"verificationMethod,omitempty"`
 	}
 	
 	var temp presentationImport
-	if err := json.Unmarshal(data, &temp); err != nil {
+	if err := opts.unmarshal(data, &temp); err != nil {
+		return err
+	}
+	attributes, err := decodeAttributes(temp.Attributes, opts)
+	if err != nil {
 		return err
 	}
 	
 	// Copy imported data
 	p.Schema = temp.Schema
+	p.SchemaHash = temp.SchemaHash
 	p.Proof = temp.Proof
-	p.Attributes = temp.Attributes
+	p.Attributes = attributes
 	p.Issuer = temp.Issuer
 	p.Created = temp.Created
 	p.NonceUsed = temp.NonceUsed
+	p.VerificationMethod = temp.VerificationMethod
 	
 	return nil
 }
\ No newline at end of file
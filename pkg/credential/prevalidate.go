@@ -0,0 +1,201 @@
+package credential
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// A verifier that rejects a presentation says little about why, and often
+// cannot say more without learning what the holder chose to hide. Everything
+// it checks is known to the holder beforehand, though: the credential, the
+// attributes about to be disclosed and the verifier's request.
+// PrevalidatePresentation runs those checks in the wallet and lists every
+// problem it finds, so the holder can fix the selection, fetch a renewed
+// credential or decline, before anything is sent.
+
+// ErrPrevalidation is wrapped by every problem PrevalidatePresentation finds
+var ErrPrevalidation = errors.New("presentation would be rejected")
+
+// Checks a problem can come from
+const (
+	CheckExpiry     = "expiry"
+	CheckSignature  = "signature"
+	CheckSchema     = "schema"
+	CheckDisclosure = "disclosure"
+	CheckPredicate  = "predicate"
+	CheckRevocation = "revocation"
+)
+
+// PresentationRequest is what a holder knows of a verifier's checks before
+// presenting. Empty fields are not checked.
+type PresentationRequest struct {
+	// Template is the verifier's presentation template
+	Template *Template `json:"template,omitempty"`
+
+	// Issuer is the issuer the verifier accepts
+	Issuer string `json:"issuer,omitempty"`
+
+	// Schema is the credential schema the verifier accepts. It defaults to
+	// the template's schema.
+	Schema string `json:"schema,omitempty"`
+
+	// Attributes declare the schema's attributes, which the credential must
+	// hold with the declared types
+	Attributes []AttributeSpec `json:"attributes,omitempty"`
+
+	// Forbidden names attributes the verifier rejects the disclosure of
+	Forbidden []string `json:"forbidden,omitempty"`
+
+	// SequenceAttribute names the int64 attribute holding the credential's
+	// sequence number, which the verifier looks up in Revoked
+	SequenceAttribute string `json:"sequenceAttribute,omitempty"`
+
+	// Revoked holds the revoked sequence numbers, for example from the
+	// issuer's latest revocation update
+	Revoked []uint64 `json:"revoked,omitempty"`
+}
+
+// Problem is one reason a verifier would reject a presentation
+type Problem struct {
+	// Check is the check that failed, such as CheckPredicate
+	Check string `json:"check"`
+
+	// Attribute is the attribute the problem is with, if any
+	Attribute string `json:"attribute,omitempty"`
+
+	// Message tells the holder what is wrong
+	Message string `json:"message"`
+}
+
+// PrevalidationError lists the problems found by PrevalidatePresentation
+type PrevalidationError struct {
+	Problems []Problem
+}
+
+// Error joins the problem messages
+func (e *PrevalidationError) Error() string {
+	messages := make([]string, len(e.Problems))
+	for i, p := range e.Problems {
+		messages[i] = p.Message
+	}
+	return fmt.Sprintf("%v: %s", ErrPrevalidation, strings.Join(messages, "; "))
+}
+
+// Unwrap returns ErrPrevalidation
+func (e *PrevalidationError) Unwrap() error {
+	return ErrPrevalidation
+}
+
+// PrevalidatePresentation runs the verifier's checks of a presentation of c
+// disclosing the named attributes against request: expiry, the issuer's
+// signature, the schema, the disclosures the request requires and forbids,
+// its predicates and revocation. It returns a *PrevalidationError listing
+// every problem found, or nil if the verifier should accept the
+// presentation.
+func PrevalidatePresentation(c *Credential, disclosed []string, request *PresentationRequest) error {
+	if c == nil {
+		return fmt.Errorf("credential is required")
+	}
+	if request == nil {
+		request = &PresentationRequest{}
+	}
+
+	var problems []Problem
+	report := func(check, attribute, format string, args ...any) {
+		problems = append(problems, Problem{Check: check, Attribute: attribute, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if c.ExpirationDate != nil && time.Now().After(*c.ExpirationDate) {
+		report(CheckExpiry, "", "credential expired on %s", c.ExpirationDate.Format(time.RFC3339))
+	}
+	if err := c.verifySignature(); err != nil {
+		report(CheckSignature, "", "credential signature does not verify: %v", err)
+	}
+
+	// Schema conformance
+	schema := request.Schema
+	if schema == "" && request.Template != nil {
+		schema = request.Template.Schema
+	}
+	if schema != "" && c.Schema != schema {
+		report(CheckSchema, "", "credential is of schema %s, not %s", c.Schema, schema)
+	}
+	if request.Issuer != "" && c.Issuer != request.Issuer {
+		report(CheckSchema, "", "credential is issued by %s, not %s", c.Issuer, request.Issuer)
+	}
+	for _, spec := range request.Attributes {
+		attr, ok := c.Attribute(spec.Name)
+		if !ok {
+			report(CheckSchema, spec.Name, "credential has no attribute '%s'", spec.Name)
+		} else if attr.Type != spec.Type {
+			report(CheckSchema, spec.Name, "attribute '%s' is of type %s, not %s", spec.Name, attr.Type, spec.Type)
+		}
+	}
+
+	// The disclosure set
+	for _, name := range disclosed {
+		if _, ok := c.Attribute(name); !ok {
+			report(CheckDisclosure, name, "attribute '%s' not found in credential", name)
+		}
+		if contains(request.Forbidden, name) {
+			report(CheckDisclosure, name, "attribute '%s' must not be disclosed", name)
+		}
+	}
+	if t := request.Template; t != nil {
+		if err := t.Validate(); err != nil {
+			report(CheckDisclosure, "", "request template is invalid: %v", err)
+		}
+		for _, name := range disclosed {
+			i := slices.IndexFunc(t.Fields, func(f TemplateField) bool { return f.Attribute == name })
+			switch {
+			case i < 0:
+				report(CheckDisclosure, name, "attribute '%s' is not requested", name)
+			case !t.Fields[i].Disclose:
+				report(CheckDisclosure, name, "attribute '%s' is requested as a predicate and must not be disclosed", name)
+			}
+		}
+		for _, f := range t.Fields {
+			if f.Optional {
+				continue
+			}
+			if f.Disclose && !contains(disclosed, f.Attribute) {
+				report(CheckDisclosure, f.Attribute, "required attribute '%s' is not disclosed", f.Attribute)
+			}
+			if f.Predicate == nil {
+				continue
+			}
+			attr, ok := c.Attribute(f.Attribute)
+			if !ok {
+				report(CheckPredicate, f.Attribute, "attribute '%s' not found in credential", f.Attribute)
+				continue
+			}
+			if holds, err := f.Predicate.Holds(attr); err != nil {
+				report(CheckPredicate, f.Attribute, "predicate on '%s' cannot be proven: %v", f.Attribute, err)
+			} else if !holds {
+				report(CheckPredicate, f.Attribute, "attribute '%s' is not %s %s", f.Attribute, f.Predicate.Op, f.Predicate.Bound.Text())
+			}
+		}
+	}
+
+	// Revocation
+	if name := request.SequenceAttribute; name != "" {
+		attr, ok := c.Attribute(name)
+		seq, isInt := attr.Value().(int64)
+		switch {
+		case !ok:
+			report(CheckRevocation, name, "credential has no sequence number attribute '%s'", name)
+		case !isInt || seq < 0:
+			report(CheckRevocation, name, "attribute '%s' is not a sequence number", name)
+		case slices.Contains(request.Revoked, uint64(seq)):
+			report(CheckRevocation, name, "credential %d is revoked", seq)
+		}
+	}
+
+	if len(problems) > 0 {
+		return &PrevalidationError{Problems: problems}
+	}
+	return nil
+}
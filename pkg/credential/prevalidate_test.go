@@ -0,0 +1,144 @@
+package credential
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/anupsv/bbsplus-signatures/bbs"
+)
+
+func TestPrevalidatePresentation(t *testing.T) {
+	keyPair, err := bbs.GenerateKeyPair(4, rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key pair: %v", err)
+	}
+	cred, err := NewBuilder().
+		SetSchema("https://example.com/schemas/member").
+		SetIssuer("did:example:club").
+		AddAttribute("name", "Alice").
+		Add(TimeAttribute("birthDate", time.Date(1990, 4, 1, 0, 0, 0, 0, time.UTC))).
+		AddAttribute("email", "alice@example.com").
+		Add(IntAttribute("serial", 17)).
+		Issue(keyPair)
+	if err != nil {
+		t.Fatalf("Issue failed: %v", err)
+	}
+
+	adult := TimeAttribute("birthDate", time.Date(2008, 10, 16, 0, 0, 0, 0, time.UTC))
+	request := &PresentationRequest{
+		Template: &Template{
+			ID:     "bar-entry",
+			Schema: "https://example.com/schemas/member",
+			Fields: []TemplateField{
+				{Attribute: "name", Disclose: true},
+				{Attribute: "birthDate", Predicate: &Predicate{Op: PredicateLessOrEqual, Bound: adult}},
+				{Attribute: "email", Disclose: true, Optional: true},
+			},
+		},
+		Issuer:            "did:example:club",
+		Attributes:        []AttributeSpec{{Name: "birthDate", Type: TypeTime}},
+		Forbidden:         []string{"serial"},
+		SequenceAttribute: "serial",
+		Revoked:           []uint64{3, 5},
+	}
+
+	// The request travels as JSON, and a presentation that fits it passes
+	data, err := json.Marshal(request)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	var received PresentationRequest
+	if err := json.Unmarshal(data, &received); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if err := PrevalidatePresentation(cred, []string{"name"}, &received); err != nil {
+		t.Fatalf("PrevalidatePresentation failed: %v", err)
+	}
+
+	// Each problem is reported, with the check and attribute it concerns
+	tests := map[string]struct {
+		cred      func(Credential) Credential
+		disclosed []string
+		request   func(PresentationRequest) PresentationRequest
+		want      []Problem
+	}{
+		"missing required": {
+			want: []Problem{{Check: CheckDisclosure, Attribute: "name"}},
+		},
+		"over-disclosure": {
+			disclosed: []string{"name", "birthDate", "serial"},
+			want: []Problem{
+				{Check: CheckDisclosure, Attribute: "serial"},
+				{Check: CheckDisclosure, Attribute: "birthDate"},
+				{Check: CheckDisclosure, Attribute: "serial"},
+			},
+		},
+		"predicate": {
+			disclosed: []string{"name"},
+			cred: func(c Credential) Credential {
+				c.Attributes = slices.Clone(c.Attributes)
+				c.Attributes[1] = TimeAttribute("birthDate", time.Date(2010, 1, 1, 0, 0, 0, 0, time.UTC))
+				return c
+			},
+			want: []Problem{{Check: CheckSignature}, {Check: CheckPredicate, Attribute: "birthDate"}},
+		},
+		"schema": {
+			disclosed: []string{"name"},
+			request: func(r PresentationRequest) PresentationRequest {
+				r.Schema = "https://example.com/schemas/other"
+				r.Issuer = "did:example:other"
+				r.Attributes = []AttributeSpec{{Name: "birthDate", Type: TypeString}, {Name: "level", Type: TypeInt}}
+				return r
+			},
+			want: []Problem{
+				{Check: CheckSchema}, {Check: CheckSchema},
+				{Check: CheckSchema, Attribute: "birthDate"}, {Check: CheckSchema, Attribute: "level"},
+			},
+		},
+		"revoked": {
+			disclosed: []string{"name"},
+			request: func(r PresentationRequest) PresentationRequest {
+				r.Revoked = []uint64{17}
+				return r
+			},
+			want: []Problem{{Check: CheckRevocation, Attribute: "serial"}},
+		},
+		"expired": {
+			disclosed: []string{"name"},
+			cred: func(c Credential) Credential {
+				expired := time.Now().Add(-time.Hour)
+				c.ExpirationDate = &expired
+				return c
+			},
+			want: []Problem{{Check: CheckExpiry}},
+		},
+	}
+
+	for name, tt := range tests {
+		c, r := *cred, *request
+		if tt.cred != nil {
+			c = tt.cred(c)
+		}
+		if tt.request != nil {
+			r = tt.request(r)
+		}
+
+		err := PrevalidatePresentation(&c, tt.disclosed, &r)
+		if !errors.Is(err, ErrPrevalidation) {
+			t.Fatalf("%s: expected ErrPrevalidation, got %v", name, err)
+		}
+		var perr *PrevalidationError
+		if !errors.As(err, &perr) || len(perr.Problems) != len(tt.want) {
+			t.Fatalf("%s: expected %d problems, got %v", name, len(tt.want), err)
+		}
+		for i, p := range perr.Problems {
+			if p.Check != tt.want[i].Check || p.Attribute != tt.want[i].Attribute || p.Message == "" {
+				t.Fatalf("%s: problem %d is %+v, want %+v", name, i, p, tt.want[i])
+			}
+		}
+	}
+}
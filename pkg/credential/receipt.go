@@ -0,0 +1,246 @@
+package credential
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/anupsv/bbsplus-signatures/bbs"
+)
+
+// A credential issued with Builder.AssignID carries a deterministic ID as
+// its last signed attribute. The ID hashes the issuer key fingerprint, the
+// schema and a salted commitment to the other attributes, so it names
+// exactly one issuance yet reveals nothing about the values without the
+// salt, which stays with the holder in the credential. The issuer can then
+// sign a Receipt of the ID, the commitment and the issuance date for the
+// holder. Should the issuer later dispute what it issued, the receipt alone
+// shows that a credential with that ID was issued, and opening the
+// commitment to an arbiter with the credential shows its attributes.
+//
+// Receipts are signed like batch manifests: with the issuer's BBS+ key as a
+// one-message signature, under a header reserved for receipts.
+
+// Domain separation tags for credential IDs and receipts
+const (
+	credentialIDDST        = "BBS_CREDENTIAL_ID_V1_"
+	attributeCommitmentDST = "BBS_CREDENTIAL_ATTRIBUTE_COMMITMENT_V1_"
+	receiptDST             = "BBS_CREDENTIAL_RECEIPT_V1_"
+)
+
+// IDAttribute is the name of the reserved attribute holding the credential
+// ID. Builders refuse attributes of that name.
+const IDAttribute = "_credentialId"
+
+// IDSaltSize is the size of the salt of the attribute commitment
+const IDSaltSize = 32
+
+var (
+	// ErrInvalidCredentialID is returned when a credential ID does not
+	// match the credential
+	ErrInvalidCredentialID = errors.New("invalid credential ID")
+
+	// ErrInvalidReceipt is returned when a receipt is not signed by the
+	// issuer or does not describe a credential
+	ErrInvalidReceipt = errors.New("invalid receipt")
+)
+
+// Receipt is the issuer's signed statement that it issued a credential
+type Receipt struct {
+	CredentialID        string    `json:"credentialId"`
+	Schema              string    `json:"schema"`
+	Issuer              string    `json:"issuer"`
+	KeyFingerprint      string    `json:"keyFingerprint"`
+	AttributeCommitment string    `json:"attributeCommitment"` // hex salted hash of the attributes
+	IssuanceDate        time.Time `json:"issuanceDate"`
+	Signature           string    `json:"signature"` // Base64 BBS+ signature of the receipt
+}
+
+// ID returns the credential ID, if the credential has one
+func (c *Credential) ID() (string, bool) {
+	attr, ok := c.Attribute(IDAttribute)
+	if !ok || attr.Type != TypeString {
+		return "", false
+	}
+	return attr.Text(), true
+}
+
+// VerifyID checks that the credential ID was derived from the issuer key,
+// the schema and the attributes. It only holds for the credential as
+// issued: updates and migrations keep the ID but drop the salt.
+func (c *Credential) VerifyID() error {
+	id, ok := c.ID()
+	if !ok {
+		return fmt.Errorf("%w: credential has no ID", ErrInvalidCredentialID)
+	}
+	fingerprint, commitment, err := c.idInputs()
+	if err != nil {
+		return err
+	}
+	if credentialID(fingerprint, c.Schema, commitment) != id {
+		return fmt.Errorf("%w: ID does not match the credential", ErrInvalidCredentialID)
+	}
+	return nil
+}
+
+// assignID draws a salt and appends the ID attribute for the attributes so
+// far. publicKey is the key the credential is signed with.
+func (c *Credential) assignID(publicKey *bbs.PublicKey) error {
+	salt := make([]byte, IDSaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return fmt.Errorf("failed to draw ID salt: %w", err)
+	}
+
+	commitment := attributeCommitment(c.Attributes, salt)
+	id := credentialID(KeyFingerprint(publicKey), c.Schema, commitment)
+	c.Attributes = append(c.Attributes, StringAttribute(IDAttribute, id))
+	c.IDSalt = hex.EncodeToString(salt)
+	return nil
+}
+
+// idInputs returns the key fingerprint and attribute commitment the ID of
+// the credential is derived from
+func (c *Credential) idInputs() (string, string, error) {
+	n := len(c.Attributes) - 1
+	if n < 0 || c.Attributes[n].Name != IDAttribute {
+		return "", "", fmt.Errorf("%w: ID is not the last attribute", ErrInvalidCredentialID)
+	}
+	salt, err := hex.DecodeString(c.IDSalt)
+	if err != nil || len(salt) != IDSaltSize {
+		return "", "", fmt.Errorf("%w: credential has no valid ID salt", ErrInvalidCredentialID)
+	}
+
+	pubKeyBytes, err := base64.StdEncoding.DecodeString(c.PublicKey)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to decode public key: %w", err)
+	}
+	publicKey, err := bbs.DeserializePublicKey(pubKeyBytes)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to deserialize public key: %w", err)
+	}
+
+	return KeyFingerprint(publicKey), attributeCommitment(c.Attributes[:n], salt), nil
+}
+
+// attributeCommitment returns the hex salted hash of attributes in order
+func attributeCommitment(attributes []Attribute, salt []byte) string {
+	var buf []byte
+	buf = append(buf, attributeCommitmentDST...)
+	buf = appendLengthPrefixed(buf, salt)
+	buf = binary.BigEndian.AppendUint32(buf, uint32(len(attributes)))
+	for _, attr := range attributes {
+		for _, field := range []string{attr.Name, string(attr.Type), string(attr.Encoding), attr.Text()} {
+			buf = appendLengthPrefixed(buf, []byte(field))
+		}
+	}
+	sum := sha256.Sum256(buf)
+	return hex.EncodeToString(sum[:])
+}
+
+// credentialID returns the hex ID of a credential
+func credentialID(fingerprint, schema, commitment string) string {
+	var buf []byte
+	buf = append(buf, credentialIDDST...)
+	for _, field := range []string{fingerprint, schema, commitment} {
+		buf = appendLengthPrefixed(buf, []byte(field))
+	}
+	sum := sha256.Sum256(buf)
+	return hex.EncodeToString(sum[:])
+}
+
+// NewReceipt signs a receipt for a credential issued under keyPair with an
+// ID. The receipt holds no attribute values.
+func NewReceipt(keyPair *bbs.KeyPair, cred *Credential) (*Receipt, error) {
+	if keyPair == nil || keyPair.PrivateKey == nil || keyPair.PublicKey == nil {
+		return nil, fmt.Errorf("issuer key pair with a private key is required")
+	}
+	if !cred.issuedUnder(keyPair.PublicKey) {
+		return nil, fmt.Errorf("%w: credential was issued under another key", ErrInvalidReceipt)
+	}
+	if err := cred.VerifyID(); err != nil {
+		return nil, err
+	}
+	id, _ := cred.ID()
+	fingerprint, commitment, err := cred.idInputs()
+	if err != nil {
+		return nil, err
+	}
+
+	receipt := &Receipt{
+		CredentialID:        id,
+		Schema:              cred.Schema,
+		Issuer:              cred.Issuer,
+		KeyFingerprint:      fingerprint,
+		AttributeCommitment: commitment,
+		IssuanceDate:        cred.IssuanceDate.UTC(),
+	}
+
+	signature, err := bbs.Sign(keyPair.PrivateKey, oneMessageKey(keyPair.PublicKey), receipt.messages(), []byte(receiptDST))
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign receipt: %w", err)
+	}
+	receipt.Signature = base64.StdEncoding.EncodeToString(bbs.SerializeSignature(signature))
+	return receipt, nil
+}
+
+// Verify checks the receipt signature under the issuer's public key and
+// that the ID follows from the commitment
+func (r *Receipt) Verify(publicKey *bbs.PublicKey) error {
+	if !matchesKeyFingerprint(r.KeyFingerprint, publicKey) {
+		return fmt.Errorf("%w: receipt was signed by another key", ErrInvalidReceipt)
+	}
+	if credentialID(r.KeyFingerprint, r.Schema, r.AttributeCommitment) != r.CredentialID {
+		return fmt.Errorf("%w: ID does not match the commitment", ErrInvalidReceipt)
+	}
+
+	sigBytes, err := base64.StdEncoding.DecodeString(r.Signature)
+	if err != nil {
+		return fmt.Errorf("failed to decode receipt signature: %w", err)
+	}
+	signature, err := bbs.DeserializeSignature(sigBytes)
+	if err != nil {
+		return fmt.Errorf("failed to deserialize receipt signature: %w", err)
+	}
+
+	return bbs.Verify(oneMessageKey(publicKey), signature, r.messages(), []byte(receiptDST))
+}
+
+// VerifyReceipt checks that the receipt is signed by the issuer key and
+// that cred, with its ID salt, is the credential it describes
+func VerifyReceipt(publicKey *bbs.PublicKey, receipt *Receipt, cred *Credential) error {
+	if err := receipt.Verify(publicKey); err != nil {
+		return err
+	}
+	if err := cred.VerifyID(); err != nil {
+		return err
+	}
+
+	id, _ := cred.ID()
+	_, commitment, err := cred.idInputs()
+	if err != nil {
+		return err
+	}
+	if id != receipt.CredentialID || commitment != receipt.AttributeCommitment {
+		return fmt.Errorf("%w: credential differs from the receipt", ErrInvalidReceipt)
+	}
+	if cred.Schema != receipt.Schema || cred.Issuer != receipt.Issuer || !cred.IssuanceDate.Equal(receipt.IssuanceDate) {
+		return fmt.Errorf("%w: schema, issuer or issuance date differs from the receipt", ErrInvalidReceipt)
+	}
+	return nil
+}
+
+// messages returns the single message the receipt signature covers
+func (r *Receipt) messages() []*big.Int {
+	var buf []byte
+	buf = append(buf, receiptDST...)
+	for _, field := range []string{r.CredentialID, r.Schema, r.Issuer, r.KeyFingerprint, r.AttributeCommitment, r.IssuanceDate.UTC().Format(time.RFC3339Nano)} {
+		buf = appendLengthPrefixed(buf, []byte(field))
+	}
+	return []*big.Int{bbs.MessageToFieldElement(buf)}
+}
@@ -0,0 +1,171 @@
+package credential
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/anupsv/bbsplus-signatures/bbs"
+)
+
+func TestCredentialReceipt(t *testing.T) {
+	keyPair, err := bbs.GenerateKeyPair(3, rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key pair: %v", err)
+	}
+
+	cred, err := NewBuilder().
+		SetSchema("https://example.gov/schemas/id").
+		SetIssuer("https://registry.example.gov").
+		AddAttribute("name", "Alice").
+		Add(IntAttribute("age", 30)).
+		AssignID().
+		Issue(keyPair)
+	if err != nil {
+		t.Fatalf("Issue failed: %v", err)
+	}
+	if err := cred.Verify(); err != nil {
+		t.Fatalf("Credential does not verify: %v", err)
+	}
+	id, ok := cred.ID()
+	if !ok || cred.AttributeNames()[2] != IDAttribute {
+		t.Fatalf("Credential has no ID attribute: %v", cred.AttributeNames())
+	}
+
+	// The ID and salt survive encoding
+	data, err := json.Marshal(cred)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	var decoded Credential
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if err := decoded.VerifyID(); err != nil {
+		t.Fatalf("VerifyID failed: %v", err)
+	}
+
+	receipt, err := NewReceipt(keyPair, cred)
+	if err != nil {
+		t.Fatalf("NewReceipt failed: %v", err)
+	}
+	if receipt.CredentialID != id {
+		t.Fatalf("Receipt ID %s, credential ID %s", receipt.CredentialID, id)
+	}
+	data, err = json.Marshal(receipt)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	var decodedReceipt Receipt
+	if err := json.Unmarshal(data, &decodedReceipt); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if err := decodedReceipt.Verify(keyPair.PublicKey); err != nil {
+		t.Fatalf("Receipt does not verify: %v", err)
+	}
+	if err := VerifyReceipt(keyPair.PublicKey, &decodedReceipt, &decoded); err != nil {
+		t.Fatalf("VerifyReceipt failed: %v", err)
+	}
+
+	// The same attributes give another ID under a fresh salt
+	again, err := NewBuilder().
+		SetSchema(cred.Schema).
+		SetIssuer(cred.Issuer).
+		AddAttribute("name", "Alice").
+		Add(IntAttribute("age", 30)).
+		AssignID().
+		Issue(keyPair)
+	if err != nil {
+		t.Fatalf("Issue failed: %v", err)
+	}
+	if otherID, _ := again.ID(); otherID == id {
+		t.Fatal("Two issuances share an ID")
+	}
+	if err := VerifyReceipt(keyPair.PublicKey, receipt, again); !errors.Is(err, ErrInvalidReceipt) {
+		t.Fatalf("Receipt matched another credential: %v", err)
+	}
+
+	// A different salt does not open the commitment
+	tampered := decoded
+	tampered.IDSalt = again.IDSalt
+	if err := tampered.VerifyID(); !errors.Is(err, ErrInvalidCredentialID) {
+		t.Fatalf("VerifyID accepted another salt: %v", err)
+	}
+
+	// A receipt edited after signing does not verify
+	forged := *receipt
+	forged.Issuer = "https://evil.example"
+	if err := forged.Verify(keyPair.PublicKey); err == nil {
+		t.Fatal("Edited receipt verified")
+	}
+	otherKey, err := bbs.GenerateKeyPair(3, rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key pair: %v", err)
+	}
+	if err := receipt.Verify(otherKey.PublicKey); !errors.Is(err, ErrInvalidReceipt) {
+		t.Fatalf("Receipt verified under another key: %v", err)
+	}
+	if _, err := NewReceipt(otherKey, cred); !errors.Is(err, ErrInvalidReceipt) {
+		t.Fatalf("NewReceipt signed a credential of another key: %v", err)
+	}
+}
+
+func TestCredentialIDReserved(t *testing.T) {
+	keyPair, err := bbs.GenerateKeyPair(1, rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key pair: %v", err)
+	}
+	if _, err := NewBuilder().AddAttribute(IDAttribute, "mine").Issue(keyPair); err == nil {
+		t.Fatal("Issue accepted the reserved ID attribute")
+	}
+
+	// Without AssignID there is no ID to verify
+	cred, err := NewBuilder().AddAttribute("name", "Alice").Issue(keyPair)
+	if err != nil {
+		t.Fatalf("Issue failed: %v", err)
+	}
+	if _, ok := cred.ID(); ok {
+		t.Fatal("Credential without AssignID has an ID")
+	}
+	if err := cred.VerifyID(); !errors.Is(err, ErrInvalidCredentialID) {
+		t.Fatalf("VerifyID returned %v, want ErrInvalidCredentialID", err)
+	}
+}
+
+func TestCredentialCompactIssuerKey(t *testing.T) {
+	seed, err := bbs.NewGeneratorSeed()
+	if err != nil {
+		t.Fatalf("NewGeneratorSeed failed: %v", err)
+	}
+	keyPair, err := bbs.GenerateKeyPairWithSeed(2, seed, rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key pair: %v", err)
+	}
+
+	cred, err := NewBuilder().
+		SetSchema("https://example.gov/schemas/id").
+		AddAttribute("name", "Alice").
+		AssignID().
+		Issue(keyPair)
+	if err != nil {
+		t.Fatalf("Issue failed: %v", err)
+	}
+	if err := cred.Verify(); err != nil {
+		t.Fatalf("Credential does not verify: %v", err)
+	}
+	compact, _ := keyPair.PublicKey.Compact(nil)
+	if cred.PublicKey != base64.StdEncoding.EncodeToString(bbs.SerializeCompactPublicKey(compact)) {
+		t.Fatalf("Credential does not carry the compact key")
+	}
+
+	// The full key, as loaded from a key file, still names the issuer
+	full, err := bbs.DeserializePublicKey(bbs.SerializePublicKey(keyPair.PublicKey))
+	if err != nil {
+		t.Fatalf("DeserializePublicKey failed: %v", err)
+	}
+	if _, err := NewReceipt(&bbs.KeyPair{PrivateKey: keyPair.PrivateKey, PublicKey: full}, cred); err != nil {
+		t.Fatalf("NewReceipt failed: %v", err)
+	}
+}
@@ -0,0 +1,92 @@
+package credential
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/anupsv/bbsplus-signatures/bbs"
+)
+
+// Issued credentials are signed under bbs.SchemaHeader with the hash of
+// their schema: the schema identifier and the name, type and encoding of
+// every attribute in signing order. Proofs derived from the credential only
+// verify under the same header, so a verifier that supplies the schema it
+// expects cannot be shown a credential whose message indices mean something
+// else under another schema. The reserved ID attribute is left out, so
+// credentials with and without an ID share the hash of their schema.
+//
+// Credentials issued before the binding have no schema hash and stay signed
+// under an empty header.
+
+// schemaHashDST is the domain separation tag of schema hashes
+const schemaHashDST = "BBS_CREDENTIAL_SCHEMA_V1_"
+
+// ErrSchemaMismatch is returned when a credential or presentation is bound
+// to another schema than the one expected
+var ErrSchemaMismatch = errors.New("schema mismatch")
+
+// SchemaHash returns the hash of a schema: its identifier and its attribute
+// declarations in signing order. A declaration without a type is a string,
+// and one without an encoding uses the default encoding of its type.
+func SchemaHash(schema string, specs []AttributeSpec) []byte {
+	var buf []byte
+	buf = append(buf, schemaHashDST...)
+	buf = appendLengthPrefixed(buf, []byte(schema))
+	buf = binary.BigEndian.AppendUint32(buf, uint32(len(specs)))
+	for _, spec := range specs {
+		spec = canonicalSpec(spec)
+		for _, field := range []string{spec.Name, string(spec.Type), string(spec.Encoding)} {
+			buf = appendLengthPrefixed(buf, []byte(field))
+		}
+	}
+	sum := sha256.Sum256(buf)
+	return sum[:]
+}
+
+// canonicalSpec fills in the default type and encoding of a declaration
+func canonicalSpec(spec AttributeSpec) AttributeSpec {
+	if spec.Type == "" {
+		spec.Type = TypeString
+	}
+	if spec.Encoding == "" {
+		switch spec.Type {
+		case TypeString, TypeBytes:
+			spec.Encoding = EncodingHash
+		default:
+			spec.Encoding = EncodingInteger
+		}
+	}
+	return spec
+}
+
+// schemaSpecs returns the declarations of the attributes the schema hash
+// covers
+func (c *Credential) schemaSpecs() []AttributeSpec {
+	specs := make([]AttributeSpec, 0, len(c.Attributes))
+	for _, attr := range c.Attributes {
+		if attr.Name == IDAttribute {
+			continue
+		}
+		specs = append(specs, attr.Spec())
+	}
+	return specs
+}
+
+// header returns the header the credential is signed under, checking that
+// a recorded schema hash matches the schema and attributes
+func (c *Credential) header() ([]byte, error) {
+	if c.SchemaHash == "" {
+		return nil, nil
+	}
+	recorded, err := hex.DecodeString(c.SchemaHash)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid schema hash: %v", ErrSchemaMismatch, err)
+	}
+	if hex.EncodeToString(SchemaHash(c.Schema, c.schemaSpecs())) != c.SchemaHash {
+		return nil, fmt.Errorf("%w: schema hash does not match the attributes", ErrSchemaMismatch)
+	}
+	return bbs.SchemaHeader(nil, recorded), nil
+}
@@ -0,0 +1,100 @@
+package credential
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"github.com/anupsv/bbsplus-signatures/bbs"
+)
+
+func TestSchemaHash(t *testing.T) {
+	keyPair, err := bbs.GenerateKeyPair(3, rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key pair: %v", err)
+	}
+	specs := []AttributeSpec{
+		{Name: "name"},
+		{Name: "age", Type: TypeInt},
+	}
+	cred, err := NewBuilder().
+		SetSchema("https://example.gov/schemas/id").
+		DeclareAttributes(specs...).
+		AddAttribute("name", "Alice").
+		Add(IntAttribute("age", 30)).
+		AssignID().
+		Issue(keyPair)
+	if err != nil {
+		t.Fatalf("Issue failed: %v", err)
+	}
+
+	// Default types and encodings are filled in, and the ID is left out
+	want := SchemaHash(cred.Schema, specs)
+	if cred.SchemaHash != hex.EncodeToString(want) {
+		t.Fatalf("SchemaHash = %s, want %x", cred.SchemaHash, want)
+	}
+	if err := cred.Verify(); err != nil {
+		t.Fatalf("Credential does not verify: %v", err)
+	}
+
+	// Order, types and the schema identifier all change the hash
+	for _, other := range [][]byte{
+		SchemaHash(cred.Schema, []AttributeSpec{specs[1], specs[0]}),
+		SchemaHash(cred.Schema, []AttributeSpec{specs[0], {Name: "age"}}),
+		SchemaHash("https://example.gov/schemas/other", specs),
+	} {
+		if bytes.Equal(other, want) {
+			t.Fatal("Different schemas share a hash")
+		}
+	}
+
+	// A credential moved to another schema no longer verifies
+	moved := *cred
+	moved.Schema = "https://example.gov/schemas/other"
+	if err := moved.Verify(); !errors.Is(err, ErrSchemaMismatch) {
+		t.Fatalf("Verify returned %v, want ErrSchemaMismatch", err)
+	}
+
+	// Nor does one whose hash was swapped for that schema's
+	moved.SchemaHash = hex.EncodeToString(SchemaHash(moved.Schema, specs))
+	if err := moved.Verify(); err == nil {
+		t.Fatal("Credential verified under another schema")
+	}
+
+	// Dropping the hash leaves the signature without its header
+	unbound := *cred
+	unbound.SchemaHash = ""
+	if err := unbound.Verify(); err == nil {
+		t.Fatal("Credential verified without its schema hash")
+	}
+}
+
+func TestPresentationSchemaHash(t *testing.T) {
+	presentation := &Presentation{
+		Schema:     "https://example.gov/schemas/id",
+		SchemaHash: hex.EncodeToString(SchemaHash("https://example.gov/schemas/id", nil)),
+	}
+
+	// A verifier must supply the schema of a bound presentation
+	err := NewVerifier().SetPresentation(presentation).Verify()
+	if !errors.Is(err, ErrSchemaMismatch) {
+		t.Fatalf("Verify returned %v, want ErrSchemaMismatch", err)
+	}
+	err = NewVerifier().
+		SetPresentation(presentation).
+		ExpectSchemaHash(SchemaHash("https://example.gov/schemas/other", nil)).
+		Verify()
+	if !errors.Is(err, ErrSchemaMismatch) {
+		t.Fatalf("Verify returned %v, want ErrSchemaMismatch", err)
+	}
+
+	err = NewVerifier().
+		SetPresentation(presentation).
+		ExpectSchemaHash(SchemaHash(presentation.Schema, nil)).
+		Verify()
+	if errors.Is(err, ErrSchemaMismatch) {
+		t.Fatalf("Verify rejected the expected schema: %v", err)
+	}
+}
@@ -0,0 +1,182 @@
+package credential
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+)
+
+// A holder's stored credentials are only as private as the file they live
+// in. Sealing encrypts each attribute value on its own with AES-GCM under a
+// key derived from the wallet master key with HKDF-SHA256 and the attribute
+// path (credential ID and attribute name), so a leaked store reveals
+// attribute names but no values, and a presentation only decrypts the
+// attributes it discloses.
+
+// WalletKeySize is the minimum size of a wallet master key
+const WalletKeySize = 32
+
+// attributeKeyInfo prefixes the HKDF info of attribute keys
+const attributeKeyInfo = "BBS_WALLET_ATTRIBUTE_KEY_V1"
+
+// SealedAttributes holds the attribute values of one credential encrypted
+// at rest. Each value is base64 of nonce || ciphertext.
+type SealedAttributes struct {
+	// ID identifies the credential within the wallet
+	ID string `json:"id"`
+
+	// Values maps attribute names to sealed values
+	Values map[string]string `json:"values"`
+}
+
+// AttributeKeyring seals and opens credential attributes under a wallet
+// master key
+type AttributeKeyring struct {
+	masterKey []byte
+}
+
+// NewAttributeKeyring creates a keyring for masterKey, which must hold at
+// least WalletKeySize bytes of key material. The key is copied.
+func NewAttributeKeyring(masterKey []byte) (*AttributeKeyring, error) {
+	if len(masterKey) < WalletKeySize {
+		return nil, fmt.Errorf("wallet master key must be at least %d bytes, got %d", WalletKeySize, len(masterKey))
+	}
+
+	return &AttributeKeyring{masterKey: append([]byte(nil), masterKey...)}, nil
+}
+
+// Destroy zeroizes the master key. The keyring cannot be used afterwards.
+func (k *AttributeKeyring) Destroy() {
+	for i := range k.masterKey {
+		k.masterKey[i] = 0
+	}
+	k.masterKey = nil
+}
+
+// SealAttributes encrypts every attribute of the credential with the given
+// wallet ID
+func (k *AttributeKeyring) SealAttributes(id string, attributes map[string]string) (*SealedAttributes, error) {
+	sealed := &SealedAttributes{ID: id, Values: make(map[string]string, len(attributes))}
+	for name, value := range attributes {
+		ciphertext, err := k.seal(id, name, []byte(value))
+		if err != nil {
+			return nil, fmt.Errorf("failed to seal attribute '%s': %w", name, err)
+		}
+		sealed.Values[name] = ciphertext
+	}
+
+	return sealed, nil
+}
+
+// OpenAttributes decrypts the named attributes and leaves the others sealed
+func (k *AttributeKeyring) OpenAttributes(sealed *SealedAttributes, names ...string) (map[string]string, error) {
+	values := make(map[string]string, len(names))
+	for _, name := range names {
+		ciphertext, ok := sealed.Values[name]
+		if !ok {
+			return nil, fmt.Errorf("attribute '%s' not found in credential", name)
+		}
+		value, err := k.open(sealed.ID, name, ciphertext)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open attribute '%s': %w", name, err)
+		}
+		values[name] = string(value)
+	}
+
+	return values, nil
+}
+
+// seal encrypts one attribute value
+func (k *AttributeKeyring) seal(id, name string, value []byte) (string, error) {
+	aead, err := k.attributeAEAD(id, name)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	sealed := aead.Seal(nonce, nonce, value, attributePath(id, name))
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// open decrypts one attribute value
+func (k *AttributeKeyring) open(id, name, sealed string) ([]byte, error) {
+	data, err := base64.StdEncoding.DecodeString(sealed)
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := k.attributeAEAD(id, name)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < aead.NonceSize() {
+		return nil, fmt.Errorf("sealed value too short")
+	}
+
+	return aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], attributePath(id, name))
+}
+
+// attributeAEAD returns AES-256-GCM under the key for one attribute
+func (k *AttributeKeyring) attributeAEAD(id, name string) (cipher.AEAD, error) {
+	if k.masterKey == nil {
+		return nil, fmt.Errorf("keyring destroyed")
+	}
+
+	info := append([]byte(attributeKeyInfo), attributePath(id, name)...)
+	key := hkdfSHA256(k.masterKey, nil, info, 32)
+	defer func() {
+		for i := range key {
+			key[i] = 0
+		}
+	}()
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// attributePath encodes the credential ID and attribute name unambiguously.
+// It is both the key derivation context and the associated data, so sealed
+// values cannot be moved between attributes or credentials.
+func attributePath(id, name string) []byte {
+	path := binary.BigEndian.AppendUint32(nil, uint32(len(id)))
+	path = append(path, id...)
+	return append(path, name...)
+}
+
+// hkdfSHA256 is HKDF from RFC 5869 with SHA-256
+func hkdfSHA256(secret, salt, info []byte, length int) []byte {
+	if salt == nil {
+		salt = make([]byte, sha256.Size)
+	}
+
+	// Extract
+	extract := hmac.New(sha256.New, salt)
+	extract.Write(secret)
+	prk := extract.Sum(nil)
+
+	// Expand
+	expand := hmac.New(sha256.New, prk)
+	var out, block []byte
+	for counter := byte(1); len(out) < length; counter++ {
+		expand.Reset()
+		expand.Write(block)
+		expand.Write(info)
+		expand.Write([]byte{counter})
+		block = expand.Sum(nil)
+		out = append(out, block...)
+	}
+
+	return out[:length]
+}
@@ -0,0 +1,88 @@
+package credential
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestHKDFSHA256(t *testing.T) {
+	// RFC 5869 test case 1
+	ikm := bytes.Repeat([]byte{0x0b}, 22)
+	salt, _ := hex.DecodeString("000102030405060708090a0b0c")
+	info, _ := hex.DecodeString("f0f1f2f3f4f5f6f7f8f9")
+	want := "3cb25f25faacd57a90434f64d0362f2a2d2d0a90cf1a5a4c5db02d56ecc4c5bf34007208d5b887185865"
+
+	if got := hex.EncodeToString(hkdfSHA256(ikm, salt, info, 42)); got != want {
+		t.Fatalf("hkdfSHA256 = %s, want %s", got, want)
+	}
+}
+
+func TestSealedAttributes(t *testing.T) {
+	masterKey := make([]byte, WalletKeySize)
+	if _, err := rand.Read(masterKey); err != nil {
+		t.Fatalf("rand.Read failed: %v", err)
+	}
+	keyring, err := NewAttributeKeyring(masterKey)
+	if err != nil {
+		t.Fatalf("NewAttributeKeyring failed: %v", err)
+	}
+
+	attributes := map[string]string{"name": "Alice", "degree": "MSc", "birthdate": "1990-01-01"}
+	sealed, err := keyring.SealAttributes("cred-1", attributes)
+	if err != nil {
+		t.Fatalf("SealAttributes failed: %v", err)
+	}
+
+	// The stored form leaks no values
+	stored, err := json.Marshal(sealed)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	for _, value := range attributes {
+		if strings.Contains(string(stored), value) {
+			t.Fatalf("Sealed store contains %q", value)
+		}
+	}
+
+	// Only the requested attributes are opened
+	opened, err := keyring.OpenAttributes(sealed, "degree")
+	if err != nil {
+		t.Fatalf("OpenAttributes failed: %v", err)
+	}
+	if len(opened) != 1 || opened["degree"] != "MSc" {
+		t.Fatalf("OpenAttributes = %v", opened)
+	}
+
+	// A sealed value cannot be moved to another attribute or credential
+	swapped := &SealedAttributes{ID: "cred-1", Values: map[string]string{"name": sealed.Values["degree"]}}
+	if _, err := keyring.OpenAttributes(swapped, "name"); err == nil {
+		t.Fatalf("Opened a value moved to another attribute")
+	}
+	moved := &SealedAttributes{ID: "cred-2", Values: sealed.Values}
+	if _, err := keyring.OpenAttributes(moved, "degree"); err == nil {
+		t.Fatalf("Opened a value moved to another credential")
+	}
+
+	// Another wallet key opens nothing
+	otherKey := bytes.Repeat([]byte{1}, WalletKeySize)
+	other, err := NewAttributeKeyring(otherKey)
+	if err != nil {
+		t.Fatalf("NewAttributeKeyring failed: %v", err)
+	}
+	if _, err := other.OpenAttributes(sealed, "degree"); err == nil {
+		t.Fatalf("Opened a value with another wallet key")
+	}
+
+	keyring.Destroy()
+	if _, err := keyring.OpenAttributes(sealed, "degree"); err == nil {
+		t.Fatalf("Opened a value after Destroy")
+	}
+
+	if _, err := NewAttributeKeyring(masterKey[:16]); err == nil {
+		t.Fatalf("Expected an error for a short master key")
+	}
+}
@@ -0,0 +1,43 @@
+package credential
+
+import (
+	"crypto/rand"
+	"fmt"
+
+	"github.com/anupsv/bbsplus-signatures/bbs"
+	bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381"
+)
+
+// SerialKeySize is the number of random bytes in a serial key attribute
+const SerialKeySize = 32
+
+// SerialKeyAttribute returns a bytes attribute holding a fresh random serial
+// key, making the credential one-time-show: each presentation reveals the
+// serial bbs.Serial derives from it, the same within an epoch. The attribute
+// must never be disclosed.
+func SerialKeyAttribute(name string) (Attribute, error) {
+	key := make([]byte, SerialKeySize)
+	if _, err := rand.Read(key); err != nil {
+		return Attribute{}, fmt.Errorf("failed to generate serial key: %w", err)
+	}
+	return BytesAttribute(name, key), nil
+}
+
+// Serial returns the one-time-show serial of the credential in epoch, with
+// the serial key in the named attribute, and the attribute's signing index
+// for bbs.CreateOneTimeShowProof
+func (c *Credential) Serial(name string, epoch []byte) (bls12381.G1Affine, int, error) {
+	index := c.attributeIndex(name)
+	if index < 0 {
+		return bls12381.G1Affine{}, -1, fmt.Errorf("attribute '%s' not found in credential", name)
+	}
+	msg, err := c.Attributes[index].Message(nil)
+	if err != nil {
+		return bls12381.G1Affine{}, -1, err
+	}
+	serial, err := bbs.Serial(msg, epoch)
+	if err != nil {
+		return bls12381.G1Affine{}, -1, err
+	}
+	return serial, index, nil
+}
@@ -0,0 +1,273 @@
+package credential
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// A template is a verifier's request for a presentation, published ahead of
+// time: which attributes to disclose, which to prove predicates over, which
+// the holder may decline, and how a wallet should show each of them. The
+// holder's wallet fills the template from a credential, the holder reviews
+// it, and PresentTemplate checks the filled copy against the verifier's
+// original before any proof is made. A wallet UI that adds a field, flips a
+// predicate into a disclosure or fills a value the holder declined produces
+// an error instead of an over-disclosing presentation.
+
+// ErrTemplateMismatch is returned when a filled template departs from the
+// template it was filled from
+var ErrTemplateMismatch = errors.New("filled template does not match the original")
+
+// PredicateOp compares an attribute with a bound
+//
+// Experimental: predicates are checked on disclosed values until predicate
+// proofs exist, and may change when they do.
+type PredicateOp string
+
+const (
+	PredicateLess           PredicateOp = "lt"
+	PredicateLessOrEqual    PredicateOp = "le"
+	PredicateGreater        PredicateOp = "gt"
+	PredicateGreaterOrEqual PredicateOp = "ge"
+)
+
+// Predicate is a comparison of an int64, bool or time attribute with a
+// bound, such as a birth date before a cutoff
+//
+// Experimental: predicates are checked on disclosed values until predicate
+// proofs exist, and may change when they do.
+type Predicate struct {
+	Op    PredicateOp `json:"op"`
+	Bound Attribute   `json:"bound"`
+}
+
+// Holds reports whether attr satisfies the predicate
+func (p Predicate) Holds(attr Attribute) (bool, error) {
+	cmp, err := attr.Compare(p.Bound)
+	if err != nil {
+		return false, err
+	}
+	switch p.Op {
+	case PredicateLess:
+		return cmp < 0, nil
+	case PredicateLessOrEqual:
+		return cmp <= 0, nil
+	case PredicateGreater:
+		return cmp > 0, nil
+	case PredicateGreaterOrEqual:
+		return cmp >= 0, nil
+	default:
+		return false, fmt.Errorf("unknown predicate operator '%s'", p.Op)
+	}
+}
+
+// equal reports whether two predicates are the same
+func (p *Predicate) equal(q *Predicate) bool {
+	if p == nil || q == nil {
+		return p == q
+	}
+	return p.Op == q.Op && p.Bound.Equal(q.Bound)
+}
+
+// DisplayHint tells a wallet how to show a template field
+type DisplayHint struct {
+	// Label names the field, such as "Date of birth"
+	Label string `json:"label,omitempty"`
+
+	// Text describes the field. {attribute} is replaced with the attribute
+	// name, {value} with the filled value and {bound} with the predicate
+	// bound.
+	Text string `json:"text,omitempty"`
+}
+
+// TemplateField asks for one attribute, either disclosed or proven to
+// satisfy a predicate
+type TemplateField struct {
+	Attribute string      `json:"attribute"`
+	Disclose  bool        `json:"disclose,omitempty"`
+	Predicate *Predicate  `json:"predicate,omitempty"`
+	Optional  bool        `json:"optional,omitempty"`
+	Display   DisplayHint `json:"display,omitempty"`
+
+	// Value is the placeholder the holder fills with a disclosed attribute
+	Value *Attribute `json:"value,omitempty"`
+
+	// Declined marks an optional field the holder chose not to present
+	Declined bool `json:"declined,omitempty"`
+}
+
+// Render returns the display text with its placeholders substituted.
+// Placeholders with nothing to substitute are left as they are.
+func (f TemplateField) Render() string {
+	pairs := []string{"{attribute}", f.Attribute}
+	if f.Value != nil {
+		pairs = append(pairs, "{value}", f.Value.Text())
+	}
+	if f.Predicate != nil {
+		pairs = append(pairs, "{bound}", f.Predicate.Bound.Text())
+	}
+	return strings.NewReplacer(pairs...).Replace(f.Display.Text)
+}
+
+// Template is a verifier's presentation template
+type Template struct {
+	// ID names the template
+	ID string `json:"id"`
+
+	// Verifier identifies the relying party
+	Verifier string `json:"verifier"`
+
+	// Schema is the credential schema the template applies to
+	Schema string `json:"schema,omitempty"`
+
+	// Purpose tells the holder why the verifier asks
+	Purpose string `json:"purpose,omitempty"`
+
+	// Nonce is the challenge the presentation must be bound to
+	Nonce []byte `json:"nonce,omitempty"`
+
+	// Fields are the requested attributes
+	Fields []TemplateField `json:"fields"`
+}
+
+// Validate checks a template as the verifier publishes it: each field names
+// a distinct attribute and either discloses it or carries a valid predicate,
+// and no placeholder is filled
+func (t *Template) Validate() error {
+	if len(t.Fields) == 0 {
+		return fmt.Errorf("template '%s' requests no attributes", t.ID)
+	}
+
+	seen := make(map[string]bool, len(t.Fields))
+	for _, f := range t.Fields {
+		if f.Attribute == "" {
+			return fmt.Errorf("template field without an attribute name")
+		}
+		if seen[f.Attribute] {
+			return fmt.Errorf("attribute '%s' requested more than once", f.Attribute)
+		}
+		seen[f.Attribute] = true
+
+		if f.Disclose == (f.Predicate != nil) {
+			return fmt.Errorf("field '%s' must either disclose or carry a predicate", f.Attribute)
+		}
+		if f.Predicate != nil {
+			if _, err := f.Predicate.Holds(f.Predicate.Bound); err != nil {
+				return fmt.Errorf("field '%s': %w", f.Attribute, err)
+			}
+		}
+		if f.Value != nil || f.Declined {
+			return fmt.Errorf("field '%s' is already filled", f.Attribute)
+		}
+	}
+	return nil
+}
+
+// Fill fills the template from c. The named optional fields are declined;
+// every other field must be satisfiable from c.
+func (t *Template) Fill(c *Credential, decline ...string) (*Template, error) {
+	if err := t.Validate(); err != nil {
+		return nil, err
+	}
+	if t.Schema != "" && c.Schema != t.Schema {
+		return nil, fmt.Errorf("template '%s' is for schema %s, not %s", t.ID, t.Schema, c.Schema)
+	}
+
+	filled := *t
+	filled.Nonce = bytes.Clone(t.Nonce)
+	filled.Fields = make([]TemplateField, len(t.Fields))
+	for i, f := range t.Fields {
+		if contains(decline, f.Attribute) {
+			if !f.Optional {
+				return nil, fmt.Errorf("field '%s' is required", f.Attribute)
+			}
+			f.Declined = true
+			filled.Fields[i] = f
+			continue
+		}
+
+		attr, ok := c.Attribute(f.Attribute)
+		if !ok {
+			return nil, fmt.Errorf("attribute '%s' not found in credential", f.Attribute)
+		}
+		if f.Disclose {
+			f.Value = &attr
+		} else if holds, err := f.Predicate.Holds(attr); err != nil {
+			return nil, fmt.Errorf("field '%s': %w", f.Attribute, err)
+		} else if !holds {
+			return nil, fmt.Errorf("attribute '%s' does not satisfy the predicate", f.Attribute)
+		}
+		filled.Fields[i] = f
+	}
+	return &filled, nil
+}
+
+// CheckFilled checks that filled was filled from t and nothing else
+// changed: the same fields with the same predicates, values only where t
+// asks for a disclosure, and declines only of optional fields
+func (t *Template) CheckFilled(filled *Template) error {
+	if filled.ID != t.ID || filled.Verifier != t.Verifier || filled.Schema != t.Schema ||
+		filled.Purpose != t.Purpose || !bytes.Equal(filled.Nonce, t.Nonce) {
+		return fmt.Errorf("%w: template '%s' was altered", ErrTemplateMismatch, t.ID)
+	}
+	if len(filled.Fields) != len(t.Fields) {
+		return fmt.Errorf("%w: %d fields, expected %d", ErrTemplateMismatch, len(filled.Fields), len(t.Fields))
+	}
+
+	for i, f := range filled.Fields {
+		o := t.Fields[i]
+		if f.Attribute != o.Attribute || f.Disclose != o.Disclose || f.Optional != o.Optional ||
+			f.Display != o.Display || !f.Predicate.equal(o.Predicate) {
+			return fmt.Errorf("%w: field %d ('%s') was altered", ErrTemplateMismatch, i, o.Attribute)
+		}
+
+		switch {
+		case f.Declined && !o.Optional:
+			return fmt.Errorf("%w: required field '%s' declined", ErrTemplateMismatch, o.Attribute)
+		case f.Declined || !o.Disclose:
+			if f.Value != nil {
+				return fmt.Errorf("%w: field '%s' must not carry a value", ErrTemplateMismatch, o.Attribute)
+			}
+		case f.Value == nil || f.Value.Name != o.Attribute:
+			return fmt.Errorf("%w: field '%s' is not filled", ErrTemplateMismatch, o.Attribute)
+		}
+	}
+	return nil
+}
+
+// PresentTemplate creates a presentation of c disclosing the attributes
+// filled in filled, once CheckFilled accepts it against original, every
+// filled value is the credential's own and every predicate holds
+func (c *Credential) PresentTemplate(original, filled *Template) (*Presentation, error) {
+	if err := original.Validate(); err != nil {
+		return nil, err
+	}
+	if err := original.CheckFilled(filled); err != nil {
+		return nil, err
+	}
+
+	var disclosed []string
+	for _, f := range filled.Fields {
+		if f.Declined {
+			continue
+		}
+		attr, ok := c.Attribute(f.Attribute)
+		if !ok {
+			return nil, fmt.Errorf("attribute '%s' not found in credential", f.Attribute)
+		}
+		if f.Predicate != nil {
+			if holds, err := f.Predicate.Holds(attr); err != nil || !holds {
+				return nil, fmt.Errorf("attribute '%s' does not satisfy the predicate", f.Attribute)
+			}
+			continue
+		}
+		if !attr.Equal(*f.Value) {
+			return nil, fmt.Errorf("%w: field '%s' does not hold the credential's value", ErrTemplateMismatch, f.Attribute)
+		}
+		disclosed = append(disclosed, f.Attribute)
+	}
+
+	return c.CreatePresentation(disclosed)
+}
@@ -0,0 +1,121 @@
+package credential
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/anupsv/bbsplus-signatures/bbs"
+)
+
+func TestPresentationTemplate(t *testing.T) {
+	keyPair, err := bbs.GenerateKeyPair(3, rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key pair: %v", err)
+	}
+	cred, err := NewBuilder().
+		SetSchema("https://example.com/schemas/member").
+		AddAttribute("name", "Alice").
+		Add(TimeAttribute("birthDate", time.Date(1990, 4, 1, 0, 0, 0, 0, time.UTC))).
+		AddAttribute("email", "alice@example.com").
+		Issue(keyPair)
+	if err != nil {
+		t.Fatalf("Issue failed: %v", err)
+	}
+
+	adult := TimeAttribute("birthDate", time.Date(2008, 10, 16, 0, 0, 0, 0, time.UTC))
+	original := &Template{
+		ID:       "bar-entry",
+		Verifier: "https://bar.example",
+		Schema:   "https://example.com/schemas/member",
+		Nonce:    []byte("nonce"),
+		Fields: []TemplateField{
+			{Attribute: "name", Disclose: true, Display: DisplayHint{Label: "Name", Text: "Shares the name {value}"}},
+			{Attribute: "birthDate", Predicate: &Predicate{Op: PredicateLessOrEqual, Bound: adult}, Display: DisplayHint{Text: "Born on or before {bound}"}},
+			{Attribute: "email", Disclose: true, Optional: true},
+		},
+	}
+
+	// The template travels as JSON to the wallet and back
+	data, err := json.Marshal(original)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	var received Template
+	if err := json.Unmarshal(data, &received); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+
+	filled, err := received.Fill(cred, "email")
+	if err != nil {
+		t.Fatalf("Fill failed: %v", err)
+	}
+	if got := filled.Fields[0].Render(); got != "Shares the name Alice" {
+		t.Fatalf("Render: got %q", got)
+	}
+	if got := filled.Fields[1].Render(); got != "Born on or before 2008-10-16T00:00:00Z" {
+		t.Fatalf("Render: got %q", got)
+	}
+	if err := original.CheckFilled(filled); err != nil {
+		t.Fatalf("CheckFilled failed: %v", err)
+	}
+
+	// Only the filled disclosure reaches the presentation
+	presentation, err := cred.PresentTemplate(original, filled)
+	if errors.Is(err, ErrTemplateMismatch) || presentation == nil {
+		t.Fatalf("PresentTemplate rejected the filled template: %v", err)
+	}
+	if len(presentation.Attributes) != 1 || presentation.Attributes[0].Name != "name" {
+		t.Fatalf("Presentation discloses %v", presentation.Attributes)
+	}
+
+	// Mistakes a wallet UI could make
+	birthDate, _ := cred.Attribute("birthDate")
+	email, _ := cred.Attribute("email")
+	other := StringAttribute("name", "Bob")
+	for name, tamper := range map[string]func(*Template){
+		"predicate disclosed": func(f *Template) { f.Fields[1].Value = &birthDate },
+		"predicate dropped":   func(f *Template) { f.Fields[1].Predicate = nil; f.Fields[1].Disclose = true },
+		"declined filled":     func(f *Template) { f.Fields[2].Value = &email },
+		"required declined":   func(f *Template) { f.Fields[0].Value = nil; f.Fields[0].Declined = true },
+		"field added":         func(f *Template) { f.Fields = append(f.Fields, TemplateField{Attribute: "x", Disclose: true}) },
+		"nonce changed":       func(f *Template) { f.Nonce = []byte("other") },
+	} {
+		tampered, _ := received.Fill(cred, "email")
+		tamper(tampered)
+		if _, err := cred.PresentTemplate(original, tampered); !errors.Is(err, ErrTemplateMismatch) {
+			t.Fatalf("%s: expected ErrTemplateMismatch, got %v", name, err)
+		}
+	}
+	tampered, _ := received.Fill(cred, "email")
+	tampered.Fields[0].Value = &other
+	if _, err := cred.PresentTemplate(original, tampered); !errors.Is(err, ErrTemplateMismatch) {
+		t.Fatalf("Expected ErrTemplateMismatch for a foreign value, got %v", err)
+	}
+
+	// Holders cannot decline required fields or present unsatisfied predicates
+	if _, err := original.Fill(cred, "name"); err == nil {
+		t.Fatalf("Expected declining a required field to fail")
+	}
+	strict := *original
+	strict.Fields = append([]TemplateField(nil), original.Fields...)
+	strict.Fields[1].Predicate = &Predicate{Op: PredicateLess, Bound: TimeAttribute("birthDate", time.Date(1980, 1, 1, 0, 0, 0, 0, time.UTC))}
+	if _, err := strict.Fill(cred); err == nil {
+		t.Fatalf("Expected an unsatisfied predicate to fail")
+	}
+
+	// Invalid templates
+	for name, fields := range map[string][]TemplateField{
+		"neither":      {{Attribute: "name"}},
+		"both":         {{Attribute: "birthDate", Disclose: true, Predicate: &Predicate{Op: PredicateLess, Bound: adult}}},
+		"string bound": {{Attribute: "name", Predicate: &Predicate{Op: PredicateLess, Bound: other}}},
+		"duplicate":    {{Attribute: "name", Disclose: true}, {Attribute: "name", Disclose: true}},
+		"prefilled":    {{Attribute: "name", Disclose: true, Value: &other}},
+	} {
+		if err := (&Template{ID: name, Fields: fields}).Validate(); err == nil {
+			t.Fatalf("%s: expected Validate to fail", name)
+		}
+	}
+}
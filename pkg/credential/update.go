@@ -0,0 +1,300 @@
+package credential
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/anupsv/bbsplus-signatures/bbs"
+)
+
+// A credential update changes some attributes of an issued credential with
+// the holder's consent. The issuer proposes the change, the holder signs the
+// proposal's digest with their holder-binding device key, and the issuer
+// re-issues the credential. The UpdateRecord keeps the proposal and the
+// consent and links the hashes of the old and new credential, so an auditor
+// holding both can check that the change was the one the holder accepted.
+
+// updateDST domain-separates update proposal digests
+const updateDST = "BBS_CREDENTIAL_UPDATE_V1_"
+
+// ErrInvalidUpdate is returned when an update does not match its credential
+// or lacks the holder's consent
+var ErrInvalidUpdate = errors.New("invalid credential update")
+
+// AttributeChange sets the attribute at Index, named Name, to Value, the
+// canonical text of the new value in the attribute's type
+type AttributeChange struct {
+	Index int    `json:"index"`
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// UpdateProposal is an issuer's proposed change to a credential
+type UpdateProposal struct {
+	// CredentialHash is the hash of the credential to update
+	CredentialHash string `json:"credentialHash"`
+
+	// Changes lists the changed attributes in index order
+	Changes []AttributeChange `json:"changes"`
+
+	// HolderKey is the holder's device key as PKIX DER; it must sign the
+	// consent
+	HolderKey []byte `json:"holderKey"`
+
+	// Created is when the issuer made the proposal
+	Created time.Time `json:"created"`
+}
+
+// UpdateRecord is the audit record of an applied update
+type UpdateRecord struct {
+	Time              time.Time         `json:"time"`
+	Schema            string            `json:"schema"`
+	OldCredentialHash string            `json:"oldCredentialHash"`
+	NewCredentialHash string            `json:"newCredentialHash"`
+	KeyFingerprint    string            `json:"keyFingerprint"`
+	Proposal          UpdateProposal    `json:"proposal"`
+	Consent           bbs.HolderBinding `json:"consent"`
+}
+
+// ProposeUpdate proposes setting the named attributes of cred to new values.
+// holderKey is the device key the holder must consent with.
+func ProposeUpdate(cred *Credential, changes map[string]string, holderKey []byte) (*UpdateProposal, error) {
+	if len(changes) == 0 {
+		return nil, fmt.Errorf("update changes no attributes")
+	}
+	if _, err := x509.ParsePKIXPublicKey(holderKey); err != nil {
+		return nil, fmt.Errorf("invalid holder key: %w", err)
+	}
+
+	hash, err := hashCredential(cred)
+	if err != nil {
+		return nil, err
+	}
+
+	proposal := &UpdateProposal{
+		CredentialHash: hash,
+		HolderKey:      bytes.Clone(holderKey),
+		Created:        time.Now().UTC(),
+	}
+	for name, value := range changes {
+		idx := cred.attributeIndex(name)
+		if idx < 0 {
+			return nil, fmt.Errorf("attribute '%s' not found in credential", name)
+		}
+		proposal.Changes = append(proposal.Changes, AttributeChange{Index: idx, Name: name, Value: value})
+	}
+	slices.SortFunc(proposal.Changes, func(a, b AttributeChange) int { return a.Index - b.Index })
+
+	return proposal, nil
+}
+
+// Digest returns the value the holder's consent signs
+func (p *UpdateProposal) Digest() []byte {
+	var buf []byte
+	buf = append(buf, updateDST...)
+	buf = appendLengthPrefixed(buf, []byte(p.CredentialHash))
+	buf = binary.BigEndian.AppendUint32(buf, uint32(len(p.Changes)))
+	for _, change := range p.Changes {
+		buf = binary.BigEndian.AppendUint32(buf, uint32(change.Index))
+		buf = appendLengthPrefixed(buf, []byte(change.Name))
+		buf = appendLengthPrefixed(buf, []byte(change.Value))
+	}
+	buf = appendLengthPrefixed(buf, p.HolderKey)
+	buf = binary.BigEndian.AppendUint64(buf, uint64(p.Created.UnixNano()))
+
+	digest := sha256.Sum256(buf)
+	return digest[:]
+}
+
+// apply returns a copy of cred with the changes applied, unsigned
+func (p *UpdateProposal) apply(cred *Credential) (*Credential, error) {
+	if len(p.Changes) == 0 {
+		return nil, fmt.Errorf("%w: no changes", ErrInvalidUpdate)
+	}
+
+	// The ID stays, but no longer commits to the attributes
+	updated := *cred
+	updated.Attributes = slices.Clone(cred.Attributes)
+	updated.IDSalt = ""
+	for i, change := range p.Changes {
+		if i > 0 && change.Index <= p.Changes[i-1].Index {
+			return nil, fmt.Errorf("%w: changes not in index order", ErrInvalidUpdate)
+		}
+		if change.Index < 0 || change.Index >= len(updated.Attributes) || updated.Attributes[change.Index].Name != change.Name {
+			return nil, fmt.Errorf("%w: no attribute '%s' at index %d", ErrInvalidUpdate, change.Name, change.Index)
+		}
+		if change.Name == IDAttribute {
+			return nil, fmt.Errorf("%w: the credential ID cannot change", ErrInvalidUpdate)
+		}
+		old := updated.Attributes[change.Index]
+		attr, err := ParseAttribute(change.Name, old.Type, change.Value)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidUpdate, err)
+		}
+		updated.Attributes[change.Index] = attr.WithEncoding(old.Encoding)
+	}
+	return &updated, nil
+}
+
+// AcceptUpdate is the holder's consent to a proposal for their credential.
+// It checks that the proposal is for cred and names the device key, and
+// signs the proposal's digest with device.
+func AcceptUpdate(cred *Credential, proposal *UpdateProposal, device crypto.Signer) (*bbs.HolderBinding, error) {
+	hash, err := hashCredential(cred)
+	if err != nil {
+		return nil, err
+	}
+	if proposal.CredentialHash != hash {
+		return nil, fmt.Errorf("%w: proposal is for another credential", ErrInvalidUpdate)
+	}
+	if _, err := proposal.apply(cred); err != nil {
+		return nil, err
+	}
+
+	publicKey, err := x509.MarshalPKIXPublicKey(device.Public())
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode device key: %w", err)
+	}
+	if !bytes.Equal(publicKey, proposal.HolderKey) {
+		return nil, fmt.Errorf("%w: proposal names another holder key", ErrInvalidUpdate)
+	}
+
+	signature, err := signConsent(device, bbs.HolderBindingChallenge(publicKey, proposal.Digest()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign consent: %w", err)
+	}
+
+	return &bbs.HolderBinding{PublicKey: publicKey, Signature: signature, KeyIndex: -1}, nil
+}
+
+// ApplyUpdate re-issues cred with the proposed changes once the holder has
+// consented, and returns the new credential and its audit record
+func ApplyUpdate(keyPair *bbs.KeyPair, cred *Credential, proposal *UpdateProposal, consent *bbs.HolderBinding) (*Credential, *UpdateRecord, error) {
+	if keyPair == nil || keyPair.PrivateKey == nil || keyPair.PublicKey == nil {
+		return nil, nil, fmt.Errorf("issuer key pair with a private key is required")
+	}
+	if !cred.issuedUnder(keyPair.PublicKey) {
+		return nil, nil, fmt.Errorf("%w: credential was issued under another key", ErrInvalidUpdate)
+	}
+
+	oldHash, err := hashCredential(cred)
+	if err != nil {
+		return nil, nil, err
+	}
+	if proposal.CredentialHash != oldHash {
+		return nil, nil, fmt.Errorf("%w: proposal is for another credential", ErrInvalidUpdate)
+	}
+	if err := checkConsent(proposal, consent); err != nil {
+		return nil, nil, err
+	}
+
+	updated, err := proposal.apply(cred)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := updated.sign(keyPair); err != nil {
+		return nil, nil, err
+	}
+
+	newHash, err := hashCredential(updated)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return updated, &UpdateRecord{
+		Time:              updated.IssuanceDate.UTC(),
+		Schema:            updated.Schema,
+		OldCredentialHash: oldHash,
+		NewCredentialHash: newHash,
+		KeyFingerprint:    KeyFingerprint(keyPair.PublicKey),
+		Proposal:          *proposal,
+		Consent:           *consent,
+	}, nil
+}
+
+// VerifyUpdateRecord checks that newCred is oldCred with exactly the changes
+// of the record's proposal, that the holder consented to that proposal, and
+// that newCred carries a valid signature of the same issuer key
+func VerifyUpdateRecord(record *UpdateRecord, oldCred, newCred *Credential) error {
+	oldHash, err := hashCredential(oldCred)
+	if err != nil {
+		return err
+	}
+	newHash, err := hashCredential(newCred)
+	if err != nil {
+		return err
+	}
+	if record.OldCredentialHash != oldHash || record.Proposal.CredentialHash != oldHash {
+		return fmt.Errorf("%w: record is for another credential", ErrInvalidUpdate)
+	}
+	if record.NewCredentialHash != newHash {
+		return fmt.Errorf("%w: record names another updated credential", ErrInvalidUpdate)
+	}
+	if err := checkConsent(&record.Proposal, &record.Consent); err != nil {
+		return err
+	}
+
+	expected, err := record.Proposal.apply(oldCred)
+	if err != nil {
+		return err
+	}
+	if newCred.PublicKey != oldCred.PublicKey || newCred.Schema != oldCred.Schema || newCred.Issuer != oldCred.Issuer ||
+		!slices.EqualFunc(newCred.Attributes, expected.Attributes, Attribute.Equal) {
+		return fmt.Errorf("%w: updated credential differs from the proposal", ErrInvalidUpdate)
+	}
+
+	return newCred.Verify()
+}
+
+// checkConsent checks that the proposal's holder key signed its digest
+func checkConsent(proposal *UpdateProposal, consent *bbs.HolderBinding) error {
+	if consent == nil || !bytes.Equal(consent.PublicKey, proposal.HolderKey) {
+		return fmt.Errorf("%w: consent is not from the holder key", ErrInvalidUpdate)
+	}
+	if err := consent.Verify(proposal.Digest()); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidUpdate, err)
+	}
+	return nil
+}
+
+// signConsent signs a holder binding challenge the way HolderBinding.Verify
+// checks it: ECDSA over its SHA-256, Ed25519 over the challenge itself
+func signConsent(device crypto.Signer, challenge []byte) ([]byte, error) {
+	switch device.Public().(type) {
+	case *ecdsa.PublicKey:
+		digest := sha256.Sum256(challenge)
+		return device.Sign(rand.Reader, digest[:], crypto.SHA256)
+	case ed25519.PublicKey:
+		return device.Sign(rand.Reader, challenge, crypto.Hash(0))
+	default:
+		return nil, fmt.Errorf("unsupported device key type %T", device.Public())
+	}
+}
+
+// hashCredential returns the CredentialHash of a credential's JSON encoding
+func hashCredential(cred *Credential) (string, error) {
+	if cred == nil {
+		return "", fmt.Errorf("credential is required")
+	}
+	encoded, err := cred.MarshalJSON()
+	if err != nil {
+		return "", fmt.Errorf("failed to encode credential: %w", err)
+	}
+	return CredentialHash(encoded), nil
+}
+
+// appendLengthPrefixed appends data behind its 4-byte big-endian length
+func appendLengthPrefixed(buf, data []byte) []byte {
+	buf = binary.BigEndian.AppendUint32(buf, uint32(len(data)))
+	return append(buf, data...)
+}
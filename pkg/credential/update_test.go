@@ -0,0 +1,141 @@
+package credential
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/anupsv/bbsplus-signatures/bbs"
+)
+
+func TestCredentialUpdate(t *testing.T) {
+	keyPair, err := bbs.GenerateKeyPair(3, rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key pair: %v", err)
+	}
+	cred, err := NewBuilder().
+		SetSchema("https://example.com/schemas/employee").
+		AddAttribute("name", "Alice").
+		AddAttribute("role", "engineer").
+		AddAttribute("team", "blue").
+		Issue(keyPair)
+	if err != nil {
+		t.Fatalf("Issue failed: %v", err)
+	}
+
+	device, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate device key: %v", err)
+	}
+	holderKey, err := x509.MarshalPKIXPublicKey(device.Public())
+	if err != nil {
+		t.Fatalf("Failed to marshal device key: %v", err)
+	}
+
+	// Issuer proposes, holder consents, issuer re-issues
+	proposal, err := ProposeUpdate(cred, map[string]string{"team": "red", "role": "lead"}, holderKey)
+	if err != nil {
+		t.Fatalf("ProposeUpdate failed: %v", err)
+	}
+	if len(proposal.Changes) != 2 || proposal.Changes[0].Index != 1 || proposal.Changes[1].Index != 2 {
+		t.Fatalf("Unexpected changes: %+v", proposal.Changes)
+	}
+
+	consent, err := AcceptUpdate(cred, proposal, device)
+	if err != nil {
+		t.Fatalf("AcceptUpdate failed: %v", err)
+	}
+
+	updated, record, err := ApplyUpdate(keyPair, cred, proposal, consent)
+	if err != nil {
+		t.Fatalf("ApplyUpdate failed: %v", err)
+	}
+	if err := updated.Verify(); err != nil {
+		t.Fatalf("Updated credential does not verify: %v", err)
+	}
+	if updated.Attributes[2].Text() != "red" || updated.Attributes[1].Text() != "lead" || cred.Attributes[2].Text() != "blue" {
+		t.Fatalf("Unexpected attributes: %v, original %v", updated.Attributes, cred.Attributes)
+	}
+	if record.OldCredentialHash == record.NewCredentialHash {
+		t.Fatalf("Record does not link distinct credentials")
+	}
+
+	// The record survives encoding and verifies against both credentials
+	data, err := json.Marshal(record)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	var decoded UpdateRecord
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if err := VerifyUpdateRecord(&decoded, cred, updated); err != nil {
+		t.Fatalf("VerifyUpdateRecord failed: %v", err)
+	}
+
+	// A re-issued credential with other values does not match the record
+	other, _, err := ApplyUpdate(keyPair, cred, proposal, consent)
+	if err != nil {
+		t.Fatalf("ApplyUpdate failed: %v", err)
+	}
+	other.Attributes[0] = StringAttribute("name", "Mallory")
+	if err := VerifyUpdateRecord(&decoded, cred, other); !errors.Is(err, ErrInvalidUpdate) {
+		t.Fatalf("Expected ErrInvalidUpdate for another credential, got %v", err)
+	}
+}
+
+func TestCredentialUpdateRequiresConsent(t *testing.T) {
+	keyPair, err := bbs.GenerateKeyPair(2, rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key pair: %v", err)
+	}
+	cred, err := NewBuilder().AddAttribute("name", "Alice").AddAttribute("level", "1").Issue(keyPair)
+	if err != nil {
+		t.Fatalf("Issue failed: %v", err)
+	}
+
+	_, device, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate device key: %v", err)
+	}
+	_, stranger, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate device key: %v", err)
+	}
+	holderKey, _ := x509.MarshalPKIXPublicKey(device.Public())
+
+	proposal, err := ProposeUpdate(cred, map[string]string{"level": "2"}, holderKey)
+	if err != nil {
+		t.Fatalf("ProposeUpdate failed: %v", err)
+	}
+
+	// Only the named holder key can consent
+	if _, err := AcceptUpdate(cred, proposal, stranger); !errors.Is(err, ErrInvalidUpdate) {
+		t.Fatalf("Expected ErrInvalidUpdate for another device, got %v", err)
+	}
+
+	consent, err := AcceptUpdate(cred, proposal, device)
+	if err != nil {
+		t.Fatalf("AcceptUpdate failed: %v", err)
+	}
+
+	// The consent covers exactly the proposal it signed
+	changed := *proposal
+	changed.Changes = []AttributeChange{{Index: 1, Name: "level", Value: "9"}}
+	if _, _, err := ApplyUpdate(keyPair, cred, &changed, consent); !errors.Is(err, ErrInvalidUpdate) {
+		t.Fatalf("Expected ErrInvalidUpdate for a changed proposal, got %v", err)
+	}
+	if _, _, err := ApplyUpdate(keyPair, cred, proposal, nil); !errors.Is(err, ErrInvalidUpdate) {
+		t.Fatalf("Expected ErrInvalidUpdate without consent, got %v", err)
+	}
+
+	// A proposal for an unknown attribute is refused
+	if _, err := ProposeUpdate(cred, map[string]string{"email": "a@example.com"}, holderKey); err == nil {
+		t.Fatalf("Expected an error for an unknown attribute")
+	}
+}
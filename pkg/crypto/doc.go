@@ -19,6 +19,8 @@
 //
 // Most applications will not need to use this package directly and should
 // use the core package instead.
+//
+// Stability: stable
 package crypto
 
 // Domain separation tags
@@ -0,0 +1,165 @@
+package crypto
+
+import (
+	"crypto/sha256"
+	"errors"
+	"hash"
+	"io"
+
+	bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381"
+	"github.com/consensys/gnark-crypto/ecc/bls12-381/fp"
+)
+
+// Hashing to the curve follows RFC 9380: the message is expanded to uniform
+// bytes under a DST, reduced to field elements and mapped to the curve with
+// the simplified SWU map, then the cofactor is cleared. HashToG1 and
+// HashToG2 are the random-oracle suites BLS12381G1_XMD:SHA-256_SSWU_RO_ and
+// BLS12381G2_XMD:SHA-256_SSWU_RO_. The expand_message functions are exposed
+// on their own so that the bbs package hashes messages to scalars and
+// derives generators with the same code.
+
+// hashToFieldLen is L in RFC 9380 for the base field of BLS12-381: 64 bytes
+// per field element leave the reduction modulo p with a negligible bias
+const hashToFieldLen = 64
+
+var (
+	// ErrInvalidExpandLength is returned for an expand_message output
+	// length RFC 9380 does not allow
+	ErrInvalidExpandLength = errors.New("invalid expand_message length")
+
+	// ErrInvalidDST is returned for a DST longer than 255 bytes
+	ErrInvalidDST = errors.New("invalid domain size (>255 bytes)")
+)
+
+// ExpandMessageFunc expands msg to length uniform bytes under dst, as
+// expand_message in RFC 9380
+type ExpandMessageFunc func(msg, dst []byte, length int) ([]byte, error)
+
+// XOF is an extendable-output function such as SHAKE256: input is written,
+// then any amount of output is read
+type XOF interface {
+	io.Writer
+	io.Reader
+}
+
+// expandSHA256 is expand_message_xmd over SHA-256
+var expandSHA256 = ExpandMessageXMDWith(sha256.New)
+
+// ExpandMessageXMD is expand_message_xmd from RFC 9380 with SHA-256. Unlike
+// gnark-crypto's ExpandMsgXmd it refuses lengths RFC 9380 does not allow
+// instead of panicking on those below 32 bytes.
+func ExpandMessageXMD(msg, dst []byte, length int) ([]byte, error) {
+	return expandSHA256(msg, dst, length)
+}
+
+// ExpandMessageXMDWith returns expand_message_xmd from RFC 9380 over the
+// Merkle-Damgard hash returned by newHash, such as sha512.New
+func ExpandMessageXMDWith(newHash func() hash.Hash) ExpandMessageFunc {
+	return func(msg, dst []byte, length int) ([]byte, error) {
+		h := newHash()
+		size := h.Size()
+		ell := (length + size - 1) / size
+		if length <= 0 || length > 0xffff || ell > 255 {
+			return nil, ErrInvalidExpandLength
+		}
+		if len(dst) > 255 {
+			return nil, ErrInvalidDST
+		}
+		dstPrime := append(append([]byte(nil), dst...), byte(len(dst)))
+
+		// b_0 = H(Z_pad || msg || I2OSP(len_in_bytes, 2) || I2OSP(0, 1) || DST_prime)
+		h.Write(make([]byte, h.BlockSize()))
+		h.Write(msg)
+		h.Write([]byte{byte(length >> 8), byte(length), 0})
+		h.Write(dstPrime)
+		b0 := h.Sum(nil)
+
+		// b_i = H(strxor(b_0, b_(i-1)) || I2OSP(i, 1) || DST_prime), b_1
+		// hashing b_0 itself
+		out := make([]byte, 0, ell*size)
+		bi := make([]byte, size)
+		for i := 1; i <= ell; i++ {
+			for j := range bi {
+				bi[j] ^= b0[j]
+			}
+			h.Reset()
+			h.Write(bi)
+			h.Write([]byte{byte(i)})
+			h.Write(dstPrime)
+			bi = h.Sum(bi[:0])
+			out = append(out, bi...)
+		}
+		return out[:length], nil
+	}
+}
+
+// ExpandMessageXOF returns expand_message_xof from RFC 9380 over the XOF
+// returned by newXOF
+func ExpandMessageXOF(newXOF func() XOF) ExpandMessageFunc {
+	return func(msg, dst []byte, length int) ([]byte, error) {
+		if length <= 0 || length > 0xffff {
+			return nil, ErrInvalidExpandLength
+		}
+		if len(dst) > 255 {
+			return nil, ErrInvalidDST
+		}
+
+		// msg_prime = msg || I2OSP(len_in_bytes, 2) || DST || I2OSP(len(DST), 1)
+		xof := newXOF()
+		xof.Write(msg)
+		xof.Write([]byte{byte(length >> 8), byte(length)})
+		xof.Write(dst)
+		xof.Write([]byte{byte(len(dst))})
+
+		out := make([]byte, length)
+		if _, err := io.ReadFull(xof, out); err != nil {
+			return nil, err
+		}
+		return out, nil
+	}
+}
+
+// HashToG1 hashes msg to a point of the prime-order subgroup of G1 under
+// dst, with expand_message_xmd over SHA-256 and the SSWU map
+func HashToG1(msg, dst []byte) (bls12381.G1Affine, error) {
+	if len(dst) > 255 {
+		return bls12381.G1Affine{}, ErrInvalidDST
+	}
+	return bls12381.HashToG1(msg, dst)
+}
+
+// HashToG2 hashes msg to a point of the prime-order subgroup of G2 under
+// dst, with expand_message_xmd over SHA-256 and the SSWU map
+func HashToG2(msg, dst []byte) (bls12381.G2Affine, error) {
+	if len(dst) > 255 {
+		return bls12381.G2Affine{}, ErrInvalidDST
+	}
+	return bls12381.HashToG2(msg, dst)
+}
+
+// HashToG1With is HashToG1 over another expand_message, for suites such as
+// BLS12381G1_XMD:BLAKE2B_SSWU_RO_. With ExpandMessageXMD it equals
+// HashToG1.
+func HashToG1With(msg, dst []byte, expand ExpandMessageFunc) (bls12381.G1Affine, error) {
+	uniform, err := expand(msg, dst, 2*hashToFieldLen)
+	if err != nil {
+		return bls12381.G1Affine{}, err
+	}
+
+	// Mapping both field elements and adding the points is the same as
+	// adding before clearing the cofactor, as the isogeny and the cofactor
+	// clearing are homomorphisms
+	var u0, u1 fp.Element
+	u0.SetBytes(uniform[:hashToFieldLen])
+	u1.SetBytes(uniform[hashToFieldLen:])
+	q0 := bls12381.MapToG1(u0)
+	q1 := bls12381.MapToG1(u1)
+
+	var sum bls12381.G1Jac
+	sum.FromAffine(&q0)
+	sum.AddMixed(&q1)
+
+	var point bls12381.G1Affine
+	point.FromJacobian(&sum)
+	return point, nil
+}
@@ -0,0 +1,147 @@
+package crypto
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc/bls12-381/fp"
+	fieldhash "github.com/consensys/gnark-crypto/field/hash"
+)
+
+// hexElement formats a base field element as RFC 9380 prints it
+func hexElement(e fp.Element) string {
+	return strings.Repeat("0", 96-len(e.Text(16))) + e.Text(16)
+}
+
+func TestHashToG1(t *testing.T) {
+	// Test vector from RFC 9380, appendix J.9.1
+	dst := []byte("QUUX-V01-CS02-with-BLS12381G1_XMD:SHA-256_SSWU_RO_")
+	p, err := HashToG1(nil, dst)
+	if err != nil {
+		t.Fatalf("HashToG1 failed: %v", err)
+	}
+	wantX := "052926add2207b76ca4fa57a8734416c8dc95e24501772c814278700eed6d1e4e8cf62d9c09db0fac349612b759e79a1"
+	wantY := "08ba738453bfed09cb546dbb0783dbb3a5f1f566ed67bb6be0e8c67e2e81a4cc68ee29813bb7994998f3eae0c9c6a265"
+	if hexElement(p.X) != wantX || hexElement(p.Y) != wantY {
+		t.Fatalf("HashToG1(\"\") = (%s, %s), want (%s, %s)", hexElement(p.X), hexElement(p.Y), wantX, wantY)
+	}
+
+	// Over expand_message_xmd with SHA-256, HashToG1With is HashToG1
+	for _, expand := range []ExpandMessageFunc{ExpandMessageXMD, ExpandMessageXMDWith(sha256.New)} {
+		q, err := HashToG1With(nil, dst, expand)
+		if err != nil {
+			t.Fatalf("HashToG1With failed: %v", err)
+		}
+		if !q.Equal(&p) {
+			t.Fatal("HashToG1With differs from HashToG1")
+		}
+	}
+
+	if _, err := HashToG1(nil, make([]byte, 256)); !errors.Is(err, ErrInvalidDST) {
+		t.Fatalf("HashToG1 with a 256 byte DST returned %v, want ErrInvalidDST", err)
+	}
+}
+
+func TestHashToG2(t *testing.T) {
+	// Test vector from RFC 9380, appendix J.10.1
+	dst := []byte("QUUX-V01-CS02-with-BLS12381G2_XMD:SHA-256_SSWU_RO_")
+	p, err := HashToG2(nil, dst)
+	if err != nil {
+		t.Fatalf("HashToG2 failed: %v", err)
+	}
+	wantX0 := "0141ebfbdca40eb85b87142e130ab689c673cf60f1a3e98d69335266f30d9b8d4ac44c1038e9dcdd5393faf5c41fb78a"
+	wantX1 := "05cb8437535e20ecffaef7752baddf98034139c38452458baeefab379ba13dff5bf5dd71b72418717047f5b0f37da03d"
+	if hexElement(p.X.A0) != wantX0 || hexElement(p.X.A1) != wantX1 {
+		t.Fatalf("HashToG2(\"\").X = %s + I*%s, want %s + I*%s", hexElement(p.X.A0), hexElement(p.X.A1), wantX0, wantX1)
+	}
+	if !p.IsInSubGroup() {
+		t.Fatal("HashToG2 returned a point outside the subgroup")
+	}
+}
+
+func TestExpandMessageXMD(t *testing.T) {
+	// Test vectors from RFC 9380, appendix K.1
+	dst := []byte("QUUX-V01-CS02-with-expander-SHA256-128")
+	vectors := []struct {
+		msg  string
+		want string
+	}{
+		{"", "68a985b87eb6b46952128911f2a4412bbc302a9d759667f87f7a21d803f07235"},
+		{"abc", "d8ccab23b5985ccea865c6c97b6e5b8350e794e603b4b97902f53a8a0d605615"},
+	}
+	for _, v := range vectors {
+		out, err := ExpandMessageXMD([]byte(v.msg), dst, 32)
+		if err != nil {
+			t.Fatalf("ExpandMessageXMD failed: %v", err)
+		}
+		if hex.EncodeToString(out) != v.want {
+			t.Fatalf("ExpandMessageXMD(%q) = %x, want %s", v.msg, out, v.want)
+		}
+	}
+
+	// It matches gnark-crypto wherever that does not panic, and handles the
+	// short lengths where it does
+	for _, length := range []int{32, 48, 100, 128, 255} {
+		want, err := fieldhash.ExpandMsgXmd([]byte("abc"), dst, length)
+		if err != nil {
+			t.Fatalf("ExpandMsgXmd failed: %v", err)
+		}
+		got, err := ExpandMessageXMD([]byte("abc"), dst, length)
+		if err != nil {
+			t.Fatalf("ExpandMessageXMD failed: %v", err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Fatalf("Expansions to %d bytes differ", length)
+		}
+	}
+	if out, err := ExpandMessageXMD([]byte("abc"), dst, 1); err != nil || len(out) != 1 {
+		t.Fatalf("ExpandMessageXMD to 1 byte returned %x, %v", out, err)
+	}
+}
+
+func TestExpandMessageXMDWith(t *testing.T) {
+	if _, err := ExpandMessageXMDWith(sha256.New)(nil, nil, 256*32); !errors.Is(err, ErrInvalidExpandLength) {
+		t.Fatalf("ExpandMessageXMDWith of 256 blocks returned %v, want ErrInvalidExpandLength", err)
+	}
+	if _, err := ExpandMessageXMDWith(sha256.New)(nil, make([]byte, 256), 32); !errors.Is(err, ErrInvalidDST) {
+		t.Fatalf("ExpandMessageXMDWith with a 256 byte DST returned %v, want ErrInvalidDST", err)
+	}
+}
+
+// fixedXOF records its input and reads as zeros
+type fixedXOF struct {
+	input bytes.Buffer
+}
+
+func (x *fixedXOF) Write(p []byte) (int, error) { return x.input.Write(p) }
+
+func (x *fixedXOF) Read(p []byte) (int, error) {
+	clear(p)
+	return len(p), nil
+}
+
+func TestExpandMessageXOF(t *testing.T) {
+	xof := &fixedXOF{}
+	expand := ExpandMessageXOF(func() XOF { return xof })
+
+	out, err := expand([]byte("msg"), []byte("DST"), 64)
+	if err != nil {
+		t.Fatalf("ExpandMessageXOF failed: %v", err)
+	}
+	if len(out) != 64 {
+		t.Fatalf("ExpandMessageXOF returned %d bytes, want 64", len(out))
+	}
+
+	// msg || I2OSP(64, 2) || DST || I2OSP(3, 1)
+	if want := []byte("msg\x00\x40DST\x03"); !bytes.Equal(xof.input.Bytes(), want) {
+		t.Fatalf("XOF input = %q, want %q", xof.input.Bytes(), want)
+	}
+
+	if _, err := expand(nil, nil, 0); !errors.Is(err, ErrInvalidExpandLength) {
+		t.Fatalf("ExpandMessageXOF of 0 bytes returned %v, want ErrInvalidExpandLength", err)
+	}
+}
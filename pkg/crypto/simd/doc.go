@@ -0,0 +1,23 @@
+// Package simd provides multi-scalar multiplication in G1 with several
+// interchangeable strategies and a tuner that picks between them.
+//
+// The strategies are plain Go; the package name is kept for compatibility.
+// Which one is fastest depends on the input size and the host, so
+// OptimizationAuto measures the candidates for an input size the first time
+// it is seen and reuses that decision for the rest of the process.
+//
+// Example usage:
+//
+//	// Let the tuner pick the strategy
+//	result, err := simd.MultiScalarMulG1(points, scalars, simd.OptimizationAuto)
+//
+//	// Force a strategy
+//	result, err = simd.MultiScalarMulG1(points, scalars, simd.OptimizationPippenger)
+//
+// Run tools/msmbench to print the crossover points on the host machine.
+//
+// All strategies run in variable time and must only be used with public
+// scalars.
+//
+// Stability: experimental. The API may change in a minor release.
+package simd
@@ -0,0 +1,101 @@
+package simd
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/anupsv/bbsplus-signatures/internal/common"
+	bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381"
+	"github.com/consensys/gnark-crypto/ecc/bls12-381/fr"
+)
+
+// OptimizationLevel selects the multi-scalar multiplication strategy
+type OptimizationLevel int
+
+const (
+	// OptimizationNone multiplies every point by double-and-add
+	OptimizationNone OptimizationLevel = iota
+
+	// OptimizationGLV multiplies every point using the GLV endomorphism
+	OptimizationGLV
+
+	// OptimizationPippenger uses the bucket method with a window chosen
+	// from the input size
+	OptimizationPippenger
+
+	// OptimizationAuto uses the strategy DefaultTuner measured as fastest
+	// for the input size on this machine
+	OptimizationAuto
+)
+
+// MultiScalarMulG1 computes the sum of points[i] * scalars[i] with the
+// strategy selected by level. Scalars are reduced modulo the group order.
+func MultiScalarMulG1(points []bls12381.G1Affine, scalars []*big.Int, level OptimizationLevel) (bls12381.G1Affine, error) {
+	if len(points) != len(scalars) {
+		return bls12381.G1Affine{}, common.ErrMismatchedLengths
+	}
+
+	frScalars := make([]fr.Element, len(scalars))
+	for i, scalar := range scalars {
+		if scalar == nil {
+			return bls12381.G1Affine{}, fmt.Errorf("nil scalar at index %d", i)
+		}
+		frScalars[i].SetBigInt(scalar)
+	}
+
+	strategy, err := strategyFor(level, len(points))
+	if err != nil {
+		return bls12381.G1Affine{}, err
+	}
+
+	result := strategy.run(points, frScalars)
+
+	var resultAffine bls12381.G1Affine
+	resultAffine.FromJacobian(&result)
+	return resultAffine, nil
+}
+
+// MultiScalarMulG1With computes the sum of points[i] * scalars[i] with a
+// specific strategy, for benchmarking and testing
+func MultiScalarMulG1With(strategy Strategy, points []bls12381.G1Affine, scalars []fr.Element) (bls12381.G1Affine, error) {
+	if len(points) != len(scalars) {
+		return bls12381.G1Affine{}, common.ErrMismatchedLengths
+	}
+	if strategy.run == nil {
+		return bls12381.G1Affine{}, fmt.Errorf("invalid MSM strategy")
+	}
+
+	result := strategy.run(points, scalars)
+
+	var resultAffine bls12381.G1Affine
+	resultAffine.FromJacobian(&result)
+	return resultAffine, nil
+}
+
+// strategyFor maps an optimization level to a strategy for n points
+func strategyFor(level OptimizationLevel, n int) (Strategy, error) {
+	switch level {
+	case OptimizationNone:
+		return strategies[0], nil
+	case OptimizationGLV:
+		return strategies[1], nil
+	case OptimizationPippenger:
+		return StrategyByName(fmt.Sprintf("pippenger-w%d", pippengerWindow(n)))
+	case OptimizationAuto:
+		return DefaultTuner.Select(n), nil
+	default:
+		return Strategy{}, fmt.Errorf("unknown optimization level: %d", level)
+	}
+}
+
+// pippengerWindow picks the window about log2(n) - 2 that the textbook cost
+// model favours, from the available window sizes
+func pippengerWindow(n int) int {
+	best := pippengerWindows[0]
+	for _, c := range pippengerWindows {
+		if 1<<(c+2) <= n {
+			best = c
+		}
+	}
+	return best
+}
@@ -0,0 +1,157 @@
+package simd
+
+import (
+	"fmt"
+	"math/big"
+	"sync"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381"
+	"github.com/consensys/gnark-crypto/ecc/bls12-381/fr"
+)
+
+// referenceMSM computes the expected result with gnark-crypto
+func referenceMSM(t *testing.T, points []bls12381.G1Affine, scalars []fr.Element) bls12381.G1Affine {
+	t.Helper()
+	var want bls12381.G1Affine
+	if _, err := want.MultiExp(points, scalars, ecc.MultiExpConfig{}); err != nil {
+		t.Fatalf("MultiExp failed: %v", err)
+	}
+	return want
+}
+
+func TestStrategiesAgree(t *testing.T) {
+	for _, n := range []int{1, 3, 17, 70} {
+		points, scalars := syntheticInput(n)
+
+		// Edge cases: a zero scalar, the point at infinity, a scalar of all ones
+		scalars[0].SetZero()
+		if n > 1 {
+			points[1] = bls12381.G1Affine{}
+		}
+		if n > 2 {
+			scalars[2].SetInt64(-1)
+		}
+
+		want := referenceMSM(t, points, scalars)
+		for _, s := range Strategies() {
+			got, err := MultiScalarMulG1With(s, points, scalars)
+			if err != nil {
+				t.Fatalf("%s failed: %v", s.Name, err)
+			}
+			if !got.Equal(&want) {
+				t.Fatalf("%s disagrees with MultiExp for %d points", s.Name, n)
+			}
+		}
+	}
+}
+
+func TestMultiScalarMulG1Levels(t *testing.T) {
+	points, frScalars := syntheticInput(33)
+	scalars := make([]*big.Int, len(frScalars))
+	for i := range frScalars {
+		scalars[i] = frScalars[i].BigInt(new(big.Int))
+	}
+	// Unreduced and negative scalars are taken modulo the group order
+	scalars[0].Add(scalars[0], fr.Modulus())
+	scalars[1].Sub(scalars[1], fr.Modulus())
+
+	want := referenceMSM(t, points, frScalars)
+	for _, level := range []OptimizationLevel{OptimizationNone, OptimizationGLV, OptimizationPippenger, OptimizationAuto} {
+		got, err := MultiScalarMulG1(points, scalars, level)
+		if err != nil {
+			t.Fatalf("MultiScalarMulG1(%d) failed: %v", level, err)
+		}
+		if !got.Equal(&want) {
+			t.Fatalf("MultiScalarMulG1(%d) returned a wrong result", level)
+		}
+	}
+
+	if _, err := MultiScalarMulG1(points, scalars[:1], OptimizationAuto); err == nil {
+		t.Fatal("Mismatched lengths accepted")
+	}
+	if _, err := MultiScalarMulG1(points, scalars, OptimizationLevel(99)); err == nil {
+		t.Fatal("Unknown optimization level accepted")
+	}
+
+	// Empty input is the point at infinity
+	empty, err := MultiScalarMulG1(nil, nil, OptimizationAuto)
+	if err != nil || !empty.IsInfinity() {
+		t.Fatalf("Empty MSM = %v, %v", empty, err)
+	}
+}
+
+func TestTunerCachesDecision(t *testing.T) {
+	tuner := NewTuner()
+
+	first := tuner.Select(20)
+	if first.run == nil {
+		t.Fatal("Tuner returned no strategy")
+	}
+
+	// 17..32 share a size class and are not measured again
+	if again := tuner.Select(32); again.Name != first.Name {
+		t.Fatalf("Decision changed within a size class: %s then %s", first.Name, again.Name)
+	}
+	decisions := tuner.Decisions()
+	if len(decisions) != 1 || decisions[32] != first.Name {
+		t.Fatalf("Unexpected decisions: %v", decisions)
+	}
+}
+
+func TestTunerConcurrentDecisions(t *testing.T) {
+	tuner := NewTuner()
+
+	// Decisions may be read while a class is still being measured
+	var wg sync.WaitGroup
+	for _, n := range []int{3, 3, 40, 40} {
+		wg.Add(1)
+		go func(n int) {
+			defer wg.Done()
+			tuner.Select(n)
+		}(n)
+	}
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	for measuring := true; measuring; {
+		select {
+		case <-done:
+			measuring = false
+		default:
+		}
+		for class, name := range tuner.Decisions() {
+			if name == "" {
+				t.Fatalf("Class %d has an empty decision", class)
+			}
+		}
+	}
+
+	if got := tuner.Decisions(); len(got) != 2 {
+		t.Fatalf("Expected 2 decisions, got %v", got)
+	}
+}
+
+func TestSizeClass(t *testing.T) {
+	for n, want := range map[int]int{0: 1, 1: 1, 2: 2, 3: 4, 17: 32, 4096: 4096, 100000: 4096} {
+		if got := sizeClass(n); got != want {
+			t.Errorf("sizeClass(%d) = %d, want %d", n, got, want)
+		}
+	}
+}
+
+func BenchmarkStrategies(b *testing.B) {
+	for _, n := range []int{4, 16, 64, 256} {
+		points, scalars := syntheticInput(n)
+		for _, s := range candidates(n) {
+			b.Run(fmt.Sprintf("%s/n=%d", s.Name, n), func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					s.run(points, scalars)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package simd
+
+import (
+	"fmt"
+	"math/big"
+
+	bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381"
+	"github.com/consensys/gnark-crypto/ecc/bls12-381/fr"
+)
+
+// Strategy is one multi-scalar multiplication algorithm
+type Strategy struct {
+	// Name identifies the strategy, e.g. "pippenger-w8"
+	Name string
+
+	run func(points []bls12381.G1Affine, scalars []fr.Element) bls12381.G1Jac
+}
+
+// pippengerWindows are the window sizes of the Pippenger strategies
+var pippengerWindows = []int{4, 6, 8, 10, 12}
+
+// strategies lists every strategy, cheapest setup first
+var strategies = buildStrategies()
+
+func buildStrategies() []Strategy {
+	list := []Strategy{
+		{Name: "naive", run: naiveMSM},
+		{Name: "glv", run: glvMSM},
+	}
+	for _, c := range pippengerWindows {
+		c := c
+		list = append(list, Strategy{
+			Name: fmt.Sprintf("pippenger-w%d", c),
+			run: func(points []bls12381.G1Affine, scalars []fr.Element) bls12381.G1Jac {
+				return pippengerMSM(points, scalars, c)
+			},
+		})
+	}
+	return list
+}
+
+// Strategies returns all available strategies
+func Strategies() []Strategy {
+	return append([]Strategy(nil), strategies...)
+}
+
+// StrategyByName returns the strategy called name
+func StrategyByName(name string) (Strategy, error) {
+	for _, s := range strategies {
+		if s.Name == name {
+			return s, nil
+		}
+	}
+	return Strategy{}, fmt.Errorf("unknown MSM strategy %q", name)
+}
+
+// identity returns the point at infinity in Jacobian coordinates
+func identity() bls12381.G1Jac {
+	var p bls12381.G1Jac
+	p.X.SetOne()
+	p.Y.SetOne()
+	return p
+}
+
+// naiveMSM multiplies every point by double-and-add and sums the products
+func naiveMSM(points []bls12381.G1Affine, scalars []fr.Element) bls12381.G1Jac {
+	result := identity()
+	for i := range points {
+		bits := scalars[i].Bits()
+		term := identity()
+		for b := fr.Bits - 1; b >= 0; b-- {
+			term.DoubleAssign()
+			if bits[b/64]>>(uint(b)%64)&1 == 1 {
+				term.AddMixed(&points[i])
+			}
+		}
+		result.AddAssign(&term)
+	}
+	return result
+}
+
+// glvMSM multiplies every point using the GLV endomorphism and sums the products
+func glvMSM(points []bls12381.G1Affine, scalars []fr.Element) bls12381.G1Jac {
+	var scalar big.Int
+	result := identity()
+	for i := range points {
+		if scalars[i].IsZero() || points[i].IsInfinity() {
+			continue
+		}
+		var term bls12381.G1Jac
+		term.FromAffine(&points[i])
+		term.ScalarMultiplication(&term, scalars[i].BigInt(&scalar))
+		result.AddAssign(&term)
+	}
+	return result
+}
+
+// pippengerMSM is the bucket method with c-bit windows. Each window sorts
+// the points into 2^c - 1 buckets by digit and sums the buckets with a
+// running sum, so the cost per window is about n + 2^(c+1) additions.
+func pippengerMSM(points []bls12381.G1Affine, scalars []fr.Element, c int) bls12381.G1Jac {
+	bits := make([][4]uint64, len(scalars))
+	for i := range scalars {
+		bits[i] = scalars[i].Bits()
+	}
+
+	buckets := make([]bls12381.G1Jac, 1<<c-1)
+	numWindows := (fr.Bits + c - 1) / c
+
+	result := identity()
+	for w := numWindows - 1; w >= 0; w-- {
+		for k := 0; k < c && w != numWindows-1; k++ {
+			result.DoubleAssign()
+		}
+
+		for j := range buckets {
+			buckets[j] = identity()
+		}
+		for i := range points {
+			if d := window(&bits[i], w*c, c); d != 0 {
+				buckets[d-1].AddMixed(&points[i])
+			}
+		}
+
+		// Sum_j j*bucket_j via running sums from the top bucket down
+		running, windowSum := identity(), identity()
+		for j := len(buckets) - 1; j >= 0; j-- {
+			running.AddAssign(&buckets[j])
+			windowSum.AddAssign(&running)
+		}
+		result.AddAssign(&windowSum)
+	}
+
+	return result
+}
+
+// window returns the c bits of a little-endian scalar starting at bit
+func window(bits *[4]uint64, bit, c int) uint64 {
+	limb, shift := bit/64, uint(bit%64)
+	d := bits[limb] >> shift
+	if shift+uint(c) > 64 && limb+1 < len(bits) {
+		d |= bits[limb+1] << (64 - shift)
+	}
+	return d & (1<<uint(c) - 1)
+}
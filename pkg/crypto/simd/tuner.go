@@ -0,0 +1,191 @@
+package simd
+
+import (
+	"math/bits"
+	"sync"
+	"time"
+
+	bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381"
+	"github.com/consensys/gnark-crypto/ecc/bls12-381/fr"
+)
+
+// maxTunedSize is the largest size class the tuner measures; larger inputs
+// use its decision
+const maxTunedSize = 4096
+
+// minMeasureTime is how long a candidate is repeated for, at most
+// maxMeasureRounds times, to get a stable timing on small inputs
+const (
+	minMeasureTime   = time.Millisecond
+	maxMeasureRounds = 5
+)
+
+// Measurement is the time one strategy took for one input size
+type Measurement struct {
+	Strategy string
+	Size     int
+	Duration time.Duration
+}
+
+// Tuner picks the fastest strategy per input size class. A size class is the
+// next power of two; each class is measured once, the first time it is
+// needed, and the decision is kept for the life of the tuner.
+type Tuner struct {
+	mu        sync.Mutex
+	decisions map[int]*decision
+}
+
+// decision is the strategy of one size class. strategy and done are written
+// under the tuner's mutex once the measurement finishes, so Decisions can
+// read them while another class is still being measured.
+type decision struct {
+	once     sync.Once
+	strategy Strategy
+	done     bool
+}
+
+// DefaultTuner is the process-wide tuner consulted by OptimizationAuto
+var DefaultTuner = NewTuner()
+
+// NewTuner creates a tuner with no decisions
+func NewTuner() *Tuner {
+	return &Tuner{decisions: make(map[int]*decision)}
+}
+
+// Select returns the strategy for inputs of n points, measuring the
+// candidates for n's size class if this is the first request for it
+func (t *Tuner) Select(n int) Strategy {
+	class := sizeClass(n)
+
+	t.mu.Lock()
+	d, ok := t.decisions[class]
+	if !ok {
+		d = &decision{}
+		t.decisions[class] = d
+	}
+	t.mu.Unlock()
+
+	d.once.Do(func() {
+		best := Measurement{Duration: -1}
+		for _, m := range Measure(class, candidates(class)) {
+			if best.Duration < 0 || m.Duration < best.Duration {
+				best = m
+			}
+		}
+		strategy, _ := StrategyByName(best.Strategy)
+
+		t.mu.Lock()
+		d.strategy = strategy
+		d.done = true
+		t.mu.Unlock()
+	})
+
+	return d.strategy
+}
+
+// Decisions returns the strategy chosen for every size class measured so far
+func (t *Tuner) Decisions() map[int]string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	out := make(map[int]string, len(t.decisions))
+	for class, d := range t.decisions {
+		if d.done {
+			out[class] = d.strategy.Name
+		}
+	}
+	return out
+}
+
+// Measure times each strategy on a synthetic input of n points
+func Measure(n int, list []Strategy) []Measurement {
+	points, scalars := syntheticInput(n)
+
+	results := make([]Measurement, 0, len(list))
+	for _, s := range list {
+		best := time.Duration(-1)
+		var total time.Duration
+		for round := 0; round < maxMeasureRounds && total < minMeasureTime; round++ {
+			start := time.Now()
+			s.run(points, scalars)
+			elapsed := time.Since(start)
+
+			total += elapsed
+			if best < 0 || elapsed < best {
+				best = elapsed
+			}
+		}
+		results = append(results, Measurement{Strategy: s.Name, Size: n, Duration: best})
+	}
+
+	return results
+}
+
+// sizeClass rounds n up to a power of two, capped at maxTunedSize
+func sizeClass(n int) int {
+	if n <= 1 {
+		return 1
+	}
+	if n >= maxTunedSize {
+		return maxTunedSize
+	}
+	return 1 << bits.Len(uint(n-1))
+}
+
+// IsCandidate reports whether the tuner measures s for inputs of n points
+func IsCandidate(s Strategy, n int) bool {
+	for _, c := range candidates(n) {
+		if c.Name == s.Name {
+			return true
+		}
+	}
+	return false
+}
+
+// candidates returns the strategies worth measuring for n points. Strategies
+// that lose by a wide margin at that size are skipped to bound tuning time:
+// naive and GLV only compete on small inputs, and a Pippenger window only
+// while its 2^c buckets are within a few powers of two of n.
+func candidates(n int) []Strategy {
+	var list []Strategy
+	for _, s := range strategies {
+		switch s.Name {
+		case "naive":
+			if n <= 4 {
+				list = append(list, s)
+			}
+		case "glv":
+			if n <= 128 {
+				list = append(list, s)
+			}
+		}
+	}
+	for i, c := range pippengerWindows {
+		if n >= 1<<max(c-4, 0) && n <= 1<<(c+4) {
+			list = append(list, strategies[2+i])
+		}
+	}
+	return list
+}
+
+// syntheticInput returns n distinct points and random scalars. The points
+// are consecutive multiples of the generator, which costs one addition each.
+func syntheticInput(n int) ([]bls12381.G1Affine, []fr.Element) {
+	_, _, g1, _ := bls12381.Generators()
+
+	jacs := make([]bls12381.G1Jac, n)
+	var acc bls12381.G1Jac
+	acc.FromAffine(&g1)
+	for i := range jacs {
+		jacs[i] = acc
+		acc.AddMixed(&g1)
+	}
+	points := bls12381.BatchJacobianToAffineG1(jacs)
+
+	scalars := make([]fr.Element, n)
+	for i := range scalars {
+		scalars[i].SetRandom()
+	}
+
+	return points, scalars
+}
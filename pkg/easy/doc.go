@@ -0,0 +1,28 @@
+// Package easy issues, presents and verifies BBS+ credentials with one call
+// each. Attributes are plain string maps and credentials, presentations and
+// keys are opaque byte slices, so applications never handle field elements,
+// message indices or wire formats.
+//
+// Example usage:
+//
+//	// Issuer: create a key once and publish its public part
+//	issuerKey, err := easy.GenerateIssuerKey()
+//	issuerPub := issuerKey.PublicKey()
+//
+//	// Issuer: sign a credential for the holder
+//	cred, err := easy.IssueCredential(issuerKey, map[string]string{
+//	    "name": "Alice", "birthdate": "1990-01-01", "country": "NL",
+//	})
+//
+//	// Holder: reveal only the country, bound to the verifier's nonce
+//	presentation, err := easy.Present(cred, []string{"country"}, nonce)
+//
+//	// Verifier: check it and read the disclosed attributes
+//	attrs, err := easy.VerifyPresentation(issuerPub, presentation, nonce)
+//
+// Attributes are signed in name order, each bound to its name, under a
+// header fixed for this package. A credential verifies under the issuer key
+// whatever its number of attributes.
+//
+// Stability: stable
+package easy
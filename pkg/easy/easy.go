@@ -0,0 +1,274 @@
+package easy
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/anupsv/bbsplus-signatures/bbs"
+	credpkg "github.com/anupsv/bbsplus-signatures/pkg/credential"
+	bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381"
+)
+
+// formatVersion is the version of the credential and presentation encodings
+const formatVersion = 1
+
+// header domain-separates signatures made by this package
+var header = []byte("bbsplus-signatures/easy/v1")
+
+// ErrInvalidPresentation is returned when a presentation does not verify
+var ErrInvalidPresentation = errors.New("invalid presentation")
+
+// strict decodes credentials and presentations, refusing unknown, duplicate
+// and case-folded fields
+var strict = credpkg.DecodeOptions{}
+
+// IssuerKey is an issuer's signing key
+type IssuerKey struct {
+	privateKey *bbs.PrivateKey
+	w          bls12381.G2Affine
+}
+
+// GenerateIssuerKey creates a random issuer key
+func GenerateIssuerKey() (*IssuerKey, error) {
+	x, err := bbs.RandomScalar(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate issuer key: %w", err)
+	}
+	return newIssuerKey(x)
+}
+
+// IssuerKeyFromBytes restores an issuer key saved with Bytes
+func IssuerKeyFromBytes(data []byte) (*IssuerKey, error) {
+	privateKey, err := bbs.DeserializePrivateKey(data)
+	if err != nil {
+		return nil, err
+	}
+	return newIssuerKey(privateKey.X)
+}
+
+// newIssuerKey computes the public part W = g2^x of a key
+func newIssuerKey(x *big.Int) (*IssuerKey, error) {
+	if x.Sign() == 0 {
+		return nil, fmt.Errorf("issuer key must not be zero")
+	}
+
+	_, _, _, g2 := bls12381.Generators()
+	key := &IssuerKey{privateKey: &bbs.PrivateKey{X: x}}
+	key.w.ScalarMultiplication(&g2, x)
+	return key, nil
+}
+
+// Bytes returns the secret key for storage. Keep it private.
+func (k *IssuerKey) Bytes() []byte {
+	return bbs.SerializePrivateKey(k.privateKey)
+}
+
+// PublicKey returns the public key verifiers check presentations against
+func (k *IssuerKey) PublicKey() []byte {
+	w := k.w.Bytes()
+	return w[:]
+}
+
+// credential is the encoding of an issued credential
+type credential struct {
+	Version    int               `json:"v"`
+	Issuer     []byte            `json:"issuer"`
+	Attributes map[string]string `json:"attributes"`
+	Signature  []byte            `json:"signature"`
+}
+
+// presentation is the encoding of a presentation. Names lists every
+// attribute of the credential, so the verifier can place the disclosed ones.
+type presentation struct {
+	Version   int               `json:"v"`
+	Names     []string          `json:"names"`
+	Disclosed map[string]string `json:"disclosed"`
+	Proof     []byte            `json:"proof"`
+}
+
+// IssueCredential signs attributes for a holder and returns the credential
+func IssueCredential(issuerKey *IssuerKey, attributes map[string]string) ([]byte, error) {
+	if issuerKey == nil {
+		return nil, fmt.Errorf("issuer key is required")
+	}
+	names, err := attributeNames(attributes)
+	if err != nil {
+		return nil, err
+	}
+
+	publicKey := issuerPublicKey(issuerKey.w, len(names))
+	signature, err := bbs.Sign(issuerKey.privateKey, publicKey, encodeAttributes(names, attributes), header)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign credential: %w", err)
+	}
+
+	return json.Marshal(&credential{
+		Version:    formatVersion,
+		Issuer:     issuerKey.PublicKey(),
+		Attributes: attributes,
+		Signature:  bbs.SerializeSignature(signature),
+	})
+}
+
+// Present creates a presentation of cred that reveals only the named
+// attributes and is bound to the verifier's nonce
+func Present(cred []byte, reveal []string, nonce []byte) ([]byte, error) {
+	if len(nonce) == 0 {
+		return nil, fmt.Errorf("a verifier nonce is required")
+	}
+
+	var c credential
+	if err := strict.Unmarshal(cred, &c); err != nil {
+		return nil, fmt.Errorf("failed to decode credential: %w", err)
+	}
+	if c.Version != formatVersion {
+		return nil, fmt.Errorf("unsupported credential version %d", c.Version)
+	}
+	names, err := attributeNames(c.Attributes)
+	if err != nil {
+		return nil, err
+	}
+
+	w, err := decodeIssuer(c.Issuer)
+	if err != nil {
+		return nil, err
+	}
+	signature, err := bbs.DeserializeSignature(c.Signature)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode signature: %w", err)
+	}
+
+	// Resolve the revealed names to indices
+	disclosed := make(map[string]string, len(reveal))
+	indices := make([]int, 0, len(reveal))
+	for _, name := range reveal {
+		if _, dup := disclosed[name]; dup {
+			return nil, fmt.Errorf("attribute '%s' revealed twice", name)
+		}
+		value, ok := c.Attributes[name]
+		if !ok {
+			return nil, fmt.Errorf("attribute '%s' not found in credential", name)
+		}
+		disclosed[name] = value
+		indices = append(indices, sort.SearchStrings(names, name))
+	}
+
+	publicKey := issuerPublicKey(w, len(names))
+	proof, _, err := bbs.CreateProofWithPresentationHeader(
+		publicKey, signature, encodeAttributes(names, c.Attributes), indices, header, nonce,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create proof: %w", err)
+	}
+
+	return json.Marshal(&presentation{
+		Version:   formatVersion,
+		Names:     names,
+		Disclosed: disclosed,
+		Proof:     bbs.SerializeCompressedProof(proof),
+	})
+}
+
+// VerifyPresentation checks a presentation against the issuer's public key
+// and the nonce the verifier sent, and returns the disclosed attributes
+func VerifyPresentation(issuerPublicKeyBytes, pres, nonce []byte) (map[string]string, error) {
+	if len(nonce) == 0 {
+		return nil, fmt.Errorf("a verifier nonce is required")
+	}
+
+	w, err := decodeIssuer(issuerPublicKeyBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	var p presentation
+	if err := strict.Unmarshal(pres, &p); err != nil {
+		return nil, fmt.Errorf("failed to decode presentation: %w", err)
+	}
+	if p.Version != formatVersion {
+		return nil, fmt.Errorf("unsupported presentation version %d", p.Version)
+	}
+
+	// The names must be the canonical order the credential was signed in
+	if len(p.Names) == 0 || !sort.StringsAreSorted(p.Names) {
+		return nil, fmt.Errorf("%w: attribute names not in signing order", ErrInvalidPresentation)
+	}
+	for i := 1; i < len(p.Names); i++ {
+		if p.Names[i] == p.Names[i-1] {
+			return nil, fmt.Errorf("%w: attribute '%s' listed twice", ErrInvalidPresentation, p.Names[i])
+		}
+	}
+
+	disclosedMessages := make(map[int]*big.Int, len(p.Disclosed))
+	for name, value := range p.Disclosed {
+		idx := sort.SearchStrings(p.Names, name)
+		if idx == len(p.Names) || p.Names[idx] != name {
+			return nil, fmt.Errorf("%w: disclosed attribute '%s' not in the credential", ErrInvalidPresentation, name)
+		}
+		disclosedMessages[idx] = encodeAttribute(name, value)
+	}
+
+	publicKey := issuerPublicKey(w, len(p.Names))
+	if err := bbs.VerifyCompressedProof(publicKey, p.Proof, disclosedMessages, header, nonce); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPresentation, err)
+	}
+
+	return p.Disclosed, nil
+}
+
+// attributeNames returns the attribute names in signing order
+func attributeNames(attributes map[string]string) ([]string, error) {
+	if len(attributes) == 0 {
+		return nil, fmt.Errorf("credential needs at least one attribute")
+	}
+
+	names := make([]string, 0, len(attributes))
+	for name := range attributes {
+		if name == "" {
+			return nil, fmt.Errorf("attribute names must not be empty")
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// encodeAttributes maps the attributes to messages in the order of names
+func encodeAttributes(names []string, attributes map[string]string) []*big.Int {
+	messages := make([]*big.Int, len(names))
+	for i, name := range names {
+		messages[i] = encodeAttribute(name, attributes[name])
+	}
+	return messages
+}
+
+// encodeAttribute maps one attribute to a message. The length-prefixed name
+// binds the value to its attribute.
+func encodeAttribute(name, value string) *big.Int {
+	data := binary.BigEndian.AppendUint32(nil, uint32(len(name)))
+	data = append(data, name...)
+	data = append(data, value...)
+	return bbs.MessageToFieldElement(data)
+}
+
+// decodeIssuer decodes an issuer public key
+func decodeIssuer(data []byte) (bls12381.G2Affine, error) {
+	var w bls12381.G2Affine
+	if _, err := w.SetBytes(data); err != nil {
+		return w, fmt.Errorf("invalid issuer public key: %w", err)
+	}
+	if w.IsInfinity() {
+		return w, fmt.Errorf("invalid issuer public key: point at infinity")
+	}
+	return w, nil
+}
+
+// issuerPublicKey expands W into the public key for messageCount attributes
+func issuerPublicKey(w bls12381.G2Affine, messageCount int) *bbs.PublicKey {
+	return bbs.NewPublicKey(w, messageCount)
+}
@@ -0,0 +1,189 @@
+package easy
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestIssuePresentVerify(t *testing.T) {
+	issuerKey, err := GenerateIssuerKey()
+	if err != nil {
+		t.Fatalf("GenerateIssuerKey failed: %v", err)
+	}
+	attributes := map[string]string{"name": "Alice", "birthdate": "1990-01-01", "country": "NL", "degree": "MSc"}
+
+	cred, err := IssueCredential(issuerKey, attributes)
+	if err != nil {
+		t.Fatalf("IssueCredential failed: %v", err)
+	}
+
+	nonce := []byte("verifier nonce")
+	pres, err := Present(cred, []string{"country", "degree"}, nonce)
+	if err != nil {
+		t.Fatalf("Present failed: %v", err)
+	}
+	if bytes.Contains(pres, []byte("Alice")) || bytes.Contains(pres, []byte("1990-01-01")) {
+		t.Fatalf("Presentation leaks hidden attributes: %s", pres)
+	}
+
+	disclosed, err := VerifyPresentation(issuerKey.PublicKey(), pres, nonce)
+	if err != nil {
+		t.Fatalf("VerifyPresentation failed: %v", err)
+	}
+	if len(disclosed) != 2 || disclosed["country"] != "NL" || disclosed["degree"] != "MSc" {
+		t.Fatalf("Unexpected disclosed attributes: %v", disclosed)
+	}
+
+	// A restored key issues credentials under the same public key
+	restored, err := IssuerKeyFromBytes(issuerKey.Bytes())
+	if err != nil {
+		t.Fatalf("IssuerKeyFromBytes failed: %v", err)
+	}
+	if !bytes.Equal(restored.PublicKey(), issuerKey.PublicKey()) {
+		t.Fatalf("Restored key has another public key")
+	}
+
+	// Revealing nothing still proves possession of a valid credential
+	pres, err = Present(cred, nil, nonce)
+	if err != nil {
+		t.Fatalf("Present failed: %v", err)
+	}
+	if disclosed, err := VerifyPresentation(issuerKey.PublicKey(), pres, nonce); err != nil || len(disclosed) != 0 {
+		t.Fatalf("VerifyPresentation = %v, %v", disclosed, err)
+	}
+}
+
+func TestVerifyPresentationRejects(t *testing.T) {
+	issuerKey, err := GenerateIssuerKey()
+	if err != nil {
+		t.Fatalf("GenerateIssuerKey failed: %v", err)
+	}
+	otherKey, err := GenerateIssuerKey()
+	if err != nil {
+		t.Fatalf("GenerateIssuerKey failed: %v", err)
+	}
+	cred, err := IssueCredential(issuerKey, map[string]string{"name": "Alice", "role": "user", "team": "blue"})
+	if err != nil {
+		t.Fatalf("IssueCredential failed: %v", err)
+	}
+
+	nonce := []byte("nonce-1")
+	pres, err := Present(cred, []string{"role"}, nonce)
+	if err != nil {
+		t.Fatalf("Present failed: %v", err)
+	}
+
+	// tamper rewrites the decoded presentation
+	tamper := func(edit func(p *presentation)) []byte {
+		var p presentation
+		if err := json.Unmarshal(pres, &p); err != nil {
+			t.Fatalf("Unmarshal failed: %v", err)
+		}
+		edit(&p)
+		out, err := json.Marshal(&p)
+		if err != nil {
+			t.Fatalf("Marshal failed: %v", err)
+		}
+		return out
+	}
+
+	for name, tc := range map[string]struct {
+		issuer []byte
+		pres   []byte
+		nonce  []byte
+	}{
+		"other issuer":   {otherKey.PublicKey(), pres, nonce},
+		"other nonce":    {issuerKey.PublicKey(), pres, []byte("nonce-2")},
+		"changed value":  {issuerKey.PublicKey(), tamper(func(p *presentation) { p.Disclosed["role"] = "admin" }), nonce},
+		"renamed":        {issuerKey.PublicKey(), tamper(func(p *presentation) { p.Disclosed = map[string]string{"team": "user"} }), nonce},
+		"reordered":      {issuerKey.PublicKey(), tamper(func(p *presentation) { p.Names = []string{"name", "team", "role"} }), nonce},
+		"extra name":     {issuerKey.PublicKey(), tamper(func(p *presentation) { p.Names = append(p.Names, "zone") }), nonce},
+		"unknown name":   {issuerKey.PublicKey(), tamper(func(p *presentation) { p.Disclosed["admin"] = "yes" }), nonce},
+		"corrupt proof":  {issuerKey.PublicKey(), tamper(func(p *presentation) { p.Proof = p.Proof[1:] }), nonce},
+		"corrupt issuer": {issuerKey.PublicKey()[1:], pres, nonce},
+		"unknown field":  {issuerKey.PublicKey(), bytes.Replace(pres, []byte(`{"v":`), []byte(`{"x":0,"v":`), 1), nonce},
+		"folded field":   {issuerKey.PublicKey(), bytes.Replace(pres, []byte(`{"v":`), []byte(`{"V":`), 1), nonce},
+	} {
+		if _, err := VerifyPresentation(tc.issuer, tc.pres, tc.nonce); err == nil {
+			t.Fatalf("%s: expected VerifyPresentation to fail", name)
+		}
+	}
+
+	if _, err := VerifyPresentation(issuerKey.PublicKey(), pres, nonce); err != nil {
+		t.Fatalf("VerifyPresentation failed: %v", err)
+	}
+	if _, err := VerifyPresentation(issuerKey.PublicKey(), pres, []byte("nonce-2")); !errors.Is(err, ErrInvalidPresentation) {
+		t.Fatalf("Expected ErrInvalidPresentation, got %v", err)
+	}
+}
+
+func TestIssueAndPresentErrors(t *testing.T) {
+	issuerKey, err := GenerateIssuerKey()
+	if err != nil {
+		t.Fatalf("GenerateIssuerKey failed: %v", err)
+	}
+
+	if _, err := IssueCredential(issuerKey, nil); err == nil {
+		t.Fatalf("Expected an error for a credential without attributes")
+	}
+	if _, err := IssueCredential(issuerKey, map[string]string{"": "x"}); err == nil {
+		t.Fatalf("Expected an error for an empty attribute name")
+	}
+	if _, err := IssueCredential(nil, map[string]string{"a": "x"}); err == nil {
+		t.Fatalf("Expected an error without an issuer key")
+	}
+
+	cred, err := IssueCredential(issuerKey, map[string]string{"a": "x", "b": "y"})
+	if err != nil {
+		t.Fatalf("IssueCredential failed: %v", err)
+	}
+	for name, reveal := range map[string][]string{
+		"unknown":   {"c"},
+		"duplicate": {"a", "a"},
+	} {
+		if _, err := Present(cred, reveal, []byte("n")); err == nil {
+			t.Fatalf("%s: expected Present to fail", name)
+		}
+	}
+	if _, err := Present(cred, []string{"a"}, nil); err == nil {
+		t.Fatalf("Expected an error without a nonce")
+	}
+	if _, err := Present([]byte("{}"), nil, []byte("n")); err == nil {
+		t.Fatalf("Expected an error for an invalid credential")
+	}
+	if _, err := Present(bytes.Replace(cred, []byte(`{"v":`), []byte(`{"x":0,"v":`), 1), []string{"a"}, []byte("n")); err == nil {
+		t.Fatalf("Expected an error for a credential with an unknown field")
+	}
+}
+
+func Example() {
+	issuerKey, err := GenerateIssuerKey()
+	if err != nil {
+		panic(err)
+	}
+
+	cred, err := IssueCredential(issuerKey, map[string]string{
+		"name":      "Alice",
+		"birthdate": "1990-01-01",
+		"country":   "NL",
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	nonce := []byte("fresh verifier nonce")
+	pres, err := Present(cred, []string{"country"}, nonce)
+	if err != nil {
+		panic(err)
+	}
+
+	attrs, err := VerifyPresentation(issuerKey.PublicKey(), pres, nonce)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(attrs)
+	// Output: map[country:NL]
+}
@@ -0,0 +1,90 @@
+package envelope
+
+import (
+	"time"
+
+	"github.com/anupsv/bbsplus-signatures/pkg/credential"
+)
+
+// Built-in body types
+const (
+	TypeOffer            = "bbs/credential-offer"
+	TypeRequest          = "bbs/proof-request"
+	TypePresentation     = "bbs/presentation"
+	TypeRevocationUpdate = "bbs/revocation-update"
+)
+
+// Offer is an issuer's offer of a credential to a holder
+type Offer struct {
+	// Issuer identifies the issuer
+	Issuer string `json:"issuer"`
+
+	// Schema identifies the credential schema
+	Schema string `json:"schema"`
+
+	// KeyFingerprint names the key the credential will be signed with
+	KeyFingerprint string `json:"keyFingerprint"`
+
+	// Attributes are the attributes the credential will hold, in order
+	Attributes []credential.AttributeSpec `json:"attributes,omitempty"`
+
+	// Nonce binds the holder's reply to this offer
+	Nonce []byte `json:"nonce,omitempty"`
+
+	// Expires is when the offer lapses
+	Expires time.Time `json:"expires"`
+}
+
+// Request asks a holder for a presentation
+type Request struct {
+	// Verifier identifies the relying party
+	Verifier string `json:"verifier"`
+
+	// Schema is the credential schema the presentation must be of
+	Schema string `json:"schema,omitempty"`
+
+	// Disclose names the attributes to disclose
+	Disclose []string `json:"disclose"`
+
+	// Nonce is the challenge the presentation must be bound to
+	Nonce []byte `json:"nonce"`
+
+	// Expires is when the request lapses
+	Expires time.Time `json:"expires"`
+}
+
+// RevocationUpdate lists the credentials an issuer revoked since its
+// previous update
+type RevocationUpdate struct {
+	// Issuer identifies the issuer
+	Issuer string `json:"issuer"`
+
+	// KeyFingerprint names the key the revoked credentials were signed with
+	KeyFingerprint string `json:"keyFingerprint"`
+
+	// Sequence numbers the updates of the issuer, one more each time
+	Sequence uint64 `json:"sequence"`
+
+	// Revoked holds the sequence numbers of the revoked credentials, see
+	// bbs.SequenceNumberMessage
+	Revoked []uint64 `json:"revoked"`
+
+	// Time is when the update was published
+	Time time.Time `json:"time"`
+}
+
+// newDefaultRegistry returns a registry with the built-in body types
+func newDefaultRegistry() *Registry {
+	r := NewRegistry()
+	for typ, newBody := range map[string]func() any{
+		TypeOffer:            func() any { return &Offer{} },
+		TypeRequest:          func() any { return &Request{} },
+		TypePresentation:     func() any { return &credential.Presentation{} },
+		TypeRevocationUpdate: func() any { return &RevocationUpdate{} },
+	} {
+		if err := r.Register(typ, 1, newBody); err != nil {
+			panic(err)
+		}
+	}
+	return r
+}
@@ -0,0 +1,35 @@
+// Package envelope carries issuance and presentation protocol messages in
+// one transport-agnostic format.
+//
+// An Envelope names the type and version of its body, holds the body as
+// JSON and carries any number of detached signatures over the three. The
+// same envelope travels as the JSON body of an HTTPS request (MediaType), as
+// the body of a DIDComm message, or as a compact base64url string short
+// enough for a QR code. Offers, proof requests, presentations and
+// revocation updates are registered out of the box; integrators register
+// their own body types with Register.
+//
+// Signatures are made with a crypto.Signer, ES256 for P-256 keys and EdDSA
+// for Ed25519 keys, and name their key with a key ID the receiver resolves.
+// A signature covers the type, version and exact body bytes, so envelopes
+// need no canonical JSON.
+//
+// Example usage:
+//
+//	// Verifier
+//	env, err := envelope.New(envelope.TypeRequest, &envelope.Request{
+//	    Verifier: "https://rp.example",
+//	    Disclose: []string{"degree"},
+//	    Nonce:    nonce,
+//	})
+//	err = env.Sign("rp-key-1", verifierKey)
+//	qr, err := env.Compact()
+//
+//	// Holder
+//	env, err := envelope.ParseCompact(qr)
+//	err = env.Verify("rp-key-1", verifierPub)
+//	body, err := env.Decode()
+//	req := body.(*envelope.Request)
+//
+// Stability: stable
+package envelope
@@ -0,0 +1,187 @@
+package envelope
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// MediaType is the content type of an envelope sent as JSON over HTTPS
+const MediaType = "application/bbs-envelope+json"
+
+// MaxSize is the largest encoded envelope Unmarshal and ParseCompact accept
+const MaxSize = 1 << 20
+
+// Signature algorithms
+const (
+	AlgES256 = "ES256"
+	AlgEdDSA = "EdDSA"
+)
+
+// signatureDST separates envelope signatures from other uses of the keys
+const signatureDST = "BBS_ENVELOPE_SIGNATURE_V1_"
+
+var (
+	// ErrInvalidEnvelope is returned for envelopes that cannot be decoded
+	ErrInvalidEnvelope = errors.New("invalid envelope")
+
+	// ErrInvalidSignature is returned when no signature of the key verifies
+	ErrInvalidSignature = errors.New("invalid envelope signature")
+)
+
+// Envelope is one protocol message
+type Envelope struct {
+	// Type names the body type, such as TypeOffer
+	Type string `json:"type"`
+
+	// Version is the version of the body type
+	Version int `json:"version"`
+
+	// Body is the JSON encoding of the body
+	Body json.RawMessage `json:"body"`
+
+	// Signatures are detached signatures over the type, version and body
+	Signatures []Signature `json:"signatures,omitempty"`
+}
+
+// Signature is one signature over an envelope
+type Signature struct {
+	// KeyID names the signing key for the receiver to resolve
+	KeyID string `json:"kid"`
+
+	// Alg is AlgES256 or AlgEdDSA
+	Alg string `json:"alg"`
+
+	// Value is the ASN.1 ECDSA signature or the Ed25519 signature
+	Value []byte `json:"sig"`
+}
+
+// New wraps body in an envelope of the latest version of typ registered in
+// DefaultRegistry
+func New(typ string, body any) (*Envelope, error) {
+	return DefaultRegistry.New(typ, body)
+}
+
+// Decode decodes the body with the type registered in DefaultRegistry
+func (e *Envelope) Decode() (any, error) {
+	return DefaultRegistry.Decode(e)
+}
+
+// Sign adds a signature by key, named keyID. The body must not change
+// afterwards.
+func (e *Envelope) Sign(keyID string, key crypto.Signer) error {
+	digest := sha256.Sum256(e.signingInput())
+
+	var sig Signature
+	switch pub := key.Public().(type) {
+	case *ecdsa.PublicKey:
+		if pub.Curve != elliptic.P256() {
+			return fmt.Errorf("unsupported ECDSA curve %s", pub.Curve.Params().Name)
+		}
+		value, err := key.Sign(rand.Reader, digest[:], crypto.SHA256)
+		if err != nil {
+			return fmt.Errorf("failed to sign envelope: %w", err)
+		}
+		sig = Signature{KeyID: keyID, Alg: AlgES256, Value: value}
+	case ed25519.PublicKey:
+		value, err := key.Sign(rand.Reader, e.signingInput(), crypto.Hash(0))
+		if err != nil {
+			return fmt.Errorf("failed to sign envelope: %w", err)
+		}
+		sig = Signature{KeyID: keyID, Alg: AlgEdDSA, Value: value}
+	default:
+		return fmt.Errorf("unsupported key type %T", pub)
+	}
+
+	e.Signatures = append(e.Signatures, sig)
+	return nil
+}
+
+// Verify checks that a signature named keyID verifies under pub
+func (e *Envelope) Verify(keyID string, pub crypto.PublicKey) error {
+	input := e.signingInput()
+	digest := sha256.Sum256(input)
+
+	for _, sig := range e.Signatures {
+		if sig.KeyID != keyID {
+			continue
+		}
+		switch k := pub.(type) {
+		case *ecdsa.PublicKey:
+			if sig.Alg == AlgES256 && k.Curve == elliptic.P256() && ecdsa.VerifyASN1(k, digest[:], sig.Value) {
+				return nil
+			}
+		case ed25519.PublicKey:
+			if sig.Alg == AlgEdDSA && len(k) == ed25519.PublicKeySize && ed25519.Verify(k, input, sig.Value) {
+				return nil
+			}
+		default:
+			return fmt.Errorf("unsupported key type %T", pub)
+		}
+	}
+
+	return fmt.Errorf("%w: no valid signature by '%s'", ErrInvalidSignature, keyID)
+}
+
+// signingInput encodes what a signature covers: the DST, then the type,
+// version and body, with the type and body length-prefixed
+func (e *Envelope) signingInput() []byte {
+	buf := make([]byte, 0, len(signatureDST)+12+len(e.Type)+len(e.Body))
+	buf = append(buf, signatureDST...)
+	buf = binary.BigEndian.AppendUint32(buf, uint32(len(e.Type)))
+	buf = append(buf, e.Type...)
+	buf = binary.BigEndian.AppendUint32(buf, uint32(e.Version))
+	buf = binary.BigEndian.AppendUint32(buf, uint32(len(e.Body)))
+	return append(buf, e.Body...)
+}
+
+// Marshal encodes the envelope as JSON, for HTTPS and DIDComm
+func (e *Envelope) Marshal() ([]byte, error) {
+	return json.Marshal(e)
+}
+
+// Unmarshal decodes a JSON envelope. The body stays encoded until Decode.
+func Unmarshal(data []byte) (*Envelope, error) {
+	if len(data) > MaxSize {
+		return nil, fmt.Errorf("%w: %d bytes, at most %d allowed", ErrInvalidEnvelope, len(data), MaxSize)
+	}
+
+	var e Envelope
+	if err := json.Unmarshal(data, &e); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidEnvelope, err)
+	}
+	if e.Type == "" || e.Version <= 0 || len(e.Body) == 0 {
+		return nil, fmt.Errorf("%w: missing type, version or body", ErrInvalidEnvelope)
+	}
+	return &e, nil
+}
+
+// Compact encodes the envelope as unpadded base64url JSON, for QR codes and
+// URLs
+func (e *Envelope) Compact() (string, error) {
+	data, err := e.Marshal()
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// ParseCompact decodes an envelope written by Compact
+func ParseCompact(s string) (*Envelope, error) {
+	if base64.RawURLEncoding.DecodedLen(len(s)) > MaxSize {
+		return nil, fmt.Errorf("%w: too large", ErrInvalidEnvelope)
+	}
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidEnvelope, err)
+	}
+	return Unmarshal(data)
+}
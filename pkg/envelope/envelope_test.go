@@ -0,0 +1,168 @@
+package envelope
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/anupsv/bbsplus-signatures/pkg/credential"
+)
+
+func TestEnvelopeRoundTrip(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+	edPub, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+
+	request := &Request{
+		Verifier: "https://rp.example",
+		Disclose: []string{"degree"},
+		Nonce:    []byte("nonce"),
+		Expires:  time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+	env, err := New(TypeRequest, request)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	if err := env.Sign("rp-ec", ecKey); err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+	if err := env.Sign("rp-ed", edKey); err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+
+	// The same envelope over JSON and as a compact string
+	data, err := env.Marshal()
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	compact, err := env.Compact()
+	if err != nil {
+		t.Fatalf("Compact failed: %v", err)
+	}
+	fromJSON, err := Unmarshal(data)
+	if err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	fromCompact, err := ParseCompact(compact)
+	if err != nil {
+		t.Fatalf("ParseCompact failed: %v", err)
+	}
+
+	for _, received := range []*Envelope{fromJSON, fromCompact} {
+		if err := received.Verify("rp-ec", &ecKey.PublicKey); err != nil {
+			t.Fatalf("Verify ES256 failed: %v", err)
+		}
+		if err := received.Verify("rp-ed", edPub); err != nil {
+			t.Fatalf("Verify EdDSA failed: %v", err)
+		}
+		body, err := received.Decode()
+		if err != nil {
+			t.Fatalf("Decode failed: %v", err)
+		}
+		decoded, ok := body.(*Request)
+		if !ok || decoded.Verifier != request.Verifier || !slices.Equal(decoded.Disclose, request.Disclose) || !decoded.Expires.Equal(request.Expires) {
+			t.Fatalf("Decoded %#v", body)
+		}
+	}
+
+	// Signatures cover the type, version and body
+	for name, tamper := range map[string]func(*Envelope){
+		"type":    func(e *Envelope) { e.Type = TypeOffer },
+		"version": func(e *Envelope) { e.Version = 2 },
+		"body":    func(e *Envelope) { e.Body = []byte(`{"verifier":"https://evil.example"}`) },
+	} {
+		tampered, _ := Unmarshal(data)
+		tamper(tampered)
+		if err := tampered.Verify("rp-ed", edPub); !errors.Is(err, ErrInvalidSignature) {
+			t.Fatalf("%s: expected ErrInvalidSignature, got %v", name, err)
+		}
+	}
+	if err := fromJSON.Verify("rp-ed", &ecKey.PublicKey); !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("Expected ErrInvalidSignature under another key, got %v", err)
+	}
+}
+
+// loyaltyCard is an integrator's own body type
+type loyaltyCard struct {
+	Points int `json:"points"`
+}
+
+func TestRegistry(t *testing.T) {
+	registry := NewRegistry()
+	if err := registry.Register("example/loyalty", 1, func() any { return &loyaltyCard{} }); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+	if err := registry.Register("example/loyalty", 1, func() any { return &loyaltyCard{} }); err == nil {
+		t.Fatalf("Expected a duplicate registration to fail")
+	}
+	if err := registry.Register("example/points", 1, func() any { return 0 }); err == nil {
+		t.Fatalf("Expected a non-pointer body type to fail")
+	}
+
+	env, err := registry.New("example/loyalty", &loyaltyCard{Points: 7})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	body, err := registry.Decode(env)
+	if err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+	if card, ok := body.(*loyaltyCard); !ok || card.Points != 7 {
+		t.Fatalf("Decoded %#v", body)
+	}
+
+	// The body must match the registered type
+	if _, err := registry.New("example/loyalty", &Request{}); err == nil {
+		t.Fatalf("Expected a body of another type to fail")
+	}
+
+	// Unknown types and versions
+	if _, err := registry.New(TypeOffer, &Offer{}); !errors.Is(err, ErrUnknownType) {
+		t.Fatalf("Expected ErrUnknownType, got %v", err)
+	}
+	env.Version = 2
+	if _, err := registry.Decode(env); !errors.Is(err, ErrUnknownType) {
+		t.Fatalf("Expected ErrUnknownType for version 2, got %v", err)
+	}
+}
+
+func TestBuiltinBodies(t *testing.T) {
+	for typ, body := range map[string]any{
+		TypeOffer: &Offer{
+			Issuer:     "did:example:issuer",
+			Schema:     "https://example.edu/schemas/degree",
+			Attributes: []credential.AttributeSpec{{Name: "degree", Type: credential.TypeString}},
+		},
+		TypePresentation:     &credential.Presentation{Schema: "https://example.edu/schemas/degree", Proof: "cHJvb2Y"},
+		TypeRevocationUpdate: &RevocationUpdate{Issuer: "did:example:issuer", Sequence: 3, Revoked: []uint64{17, 42}},
+	} {
+		env, err := New(typ, body)
+		if err != nil {
+			t.Fatalf("%s: New failed: %v", typ, err)
+		}
+		if _, err := env.Decode(); err != nil {
+			t.Fatalf("%s: Decode failed: %v", typ, err)
+		}
+	}
+
+	for name, data := range map[string]string{
+		"not JSON":   "{",
+		"no type":    `{"version":1,"body":{}}`,
+		"no version": `{"type":"bbs/proof-request","body":{}}`,
+		"no body":    `{"type":"bbs/proof-request","version":1}`,
+	} {
+		if _, err := Unmarshal([]byte(data)); !errors.Is(err, ErrInvalidEnvelope) {
+			t.Fatalf("%s: expected ErrInvalidEnvelope, got %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package envelope
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// ErrUnknownType is returned for body types and versions not registered
+var ErrUnknownType = errors.New("unknown envelope body type")
+
+// Registry maps body types and versions to the Go types they decode to
+type Registry struct {
+	mu     sync.RWMutex
+	bodies map[bodyKey]func() any
+	latest map[string]int
+}
+
+// bodyKey identifies a registered body
+type bodyKey struct {
+	typ     string
+	version int
+}
+
+// DefaultRegistry holds the built-in body types and those added with
+// Register
+var DefaultRegistry = newDefaultRegistry()
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{
+		bodies: make(map[bodyKey]func() any),
+		latest: make(map[string]int),
+	}
+}
+
+// Register adds a body type to DefaultRegistry
+func Register(typ string, version int, newBody func() any) error {
+	return DefaultRegistry.Register(typ, version, newBody)
+}
+
+// Register adds version of typ, whose bodies decode into the pointer
+// newBody returns
+func (r *Registry) Register(typ string, version int, newBody func() any) error {
+	if typ == "" || version <= 0 || newBody == nil {
+		return fmt.Errorf("body type needs a name, a positive version and a constructor")
+	}
+	if reflect.ValueOf(newBody()).Kind() != reflect.Pointer {
+		return fmt.Errorf("body type '%s' must decode into a pointer", typ)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := bodyKey{typ, version}
+	if _, ok := r.bodies[key]; ok {
+		return fmt.Errorf("body type '%s' version %d already registered", typ, version)
+	}
+	r.bodies[key] = newBody
+	if version > r.latest[typ] {
+		r.latest[typ] = version
+	}
+	return nil
+}
+
+// New wraps body in an envelope of the latest registered version of typ.
+// body must be of the type that version decodes to.
+func (r *Registry) New(typ string, body any) (*Envelope, error) {
+	r.mu.RLock()
+	version := r.latest[typ]
+	newBody := r.bodies[bodyKey{typ, version}]
+	r.mu.RUnlock()
+	if newBody == nil {
+		return nil, fmt.Errorf("%w: '%s'", ErrUnknownType, typ)
+	}
+	if want := reflect.TypeOf(newBody()); reflect.TypeOf(body) != want {
+		return nil, fmt.Errorf("body of type '%s' must be %v, got %T", typ, want, body)
+	}
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode body: %w", err)
+	}
+	return &Envelope{Type: typ, Version: version, Body: data}, nil
+}
+
+// Decode decodes the body of e into a new value of its registered type
+func (r *Registry) Decode(e *Envelope) (any, error) {
+	r.mu.RLock()
+	newBody := r.bodies[bodyKey{e.Type, e.Version}]
+	r.mu.RUnlock()
+	if newBody == nil {
+		return nil, fmt.Errorf("%w: '%s' version %d", ErrUnknownType, e.Type, e.Version)
+	}
+
+	body := newBody()
+	if err := json.Unmarshal(e.Body, body); err != nil {
+		return nil, fmt.Errorf("%w: body: %v", ErrInvalidEnvelope, err)
+	}
+	return body, nil
+}
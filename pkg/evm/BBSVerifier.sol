@@ -0,0 +1,278 @@
+// SPDX-License-Identifier: MIT
+pragma solidity ^0.8.24;
+
+/// @title BBSVerifier
+/// @notice Reference verifier for BBS+ selective disclosure proofs over
+/// BLS12-381, using the EIP-2537 precompiles. It accepts exactly the proofs
+/// bbs.VerifyProof accepts for the issuer key and header it was deployed
+/// with. Constructor arguments and calldata are produced by pkg/evm and
+/// cmd/evmgen.
+contract BBSVerifier {
+    // EIP-2537 precompiles
+    address private constant G1_MSM = address(0x0c);
+    address private constant PAIRING_CHECK = address(0x0f);
+
+    // Order of the BLS12-381 prime order subgroup
+    uint256 private constant R = 0x73eda753299d7d483339d80809a1d80553bda402fffe5bfeffffffff00000001;
+
+    uint256 private constant G1_LEN = 128;
+    uint256 private constant G2_LEN = 256;
+    uint256 private constant MSM_TERM_LEN = G1_LEN + 32;
+
+    // Proof layout: A' || A-bar || D || c || e^ || s^ || r1^ || r3^ || (index || m^)*
+    uint256 private constant A_PRIME = 0;
+    uint256 private constant A_BAR = G1_LEN;
+    uint256 private constant D_POINT = 2 * G1_LEN;
+    uint256 private constant SCALARS = 3 * G1_LEN;
+    uint256 private constant PROOF_FIXED_LEN = 3 * G1_LEN + 5 * 32;
+
+    // Public key layout: count || W || -P2 || P1 || Q1 || Q2 || H_1 ... H_L
+    uint256 private constant PK_W = 32;
+    uint256 private constant PK_NEG_P2 = PK_W + G2_LEN;
+    uint256 private constant PK_P1 = PK_NEG_P2 + G2_LEN;
+    uint256 private constant PK_Q1 = PK_P1 + G1_LEN;
+    uint256 private constant PK_Q2 = PK_Q1 + G1_LEN;
+    uint256 private constant PK_H = PK_Q2 + G1_LEN;
+
+    /// @notice Number of messages signed under the issuer key
+    uint256 public immutable messageCount;
+
+    /// @notice Domain bound to the issuer key and header, see bbs.CalculateDomain
+    uint256 public immutable domain;
+
+    /// @notice Encoded issuer public key
+    bytes public publicKey;
+
+    constructor(bytes memory publicKey_, uint256 domain_) {
+        require(publicKey_.length >= 32, "BBSVerifier: invalid public key");
+        uint256 count;
+        assembly {
+            count := mload(add(publicKey_, 32))
+        }
+        require(count > 0 && count <= 256, "BBSVerifier: unsupported message count");
+        require(publicKey_.length == PK_H + count * G1_LEN, "BBSVerifier: invalid public key length");
+        require(domain_ < R, "BBSVerifier: invalid domain");
+
+        messageCount = count;
+        domain = domain_;
+        publicKey = publicKey_;
+    }
+
+    /// @notice Verifies a selective disclosure proof
+    /// @param proof Proof encoded by evm.EncodeProof
+    /// @param disclosedIndices Disclosed message indices in ascending order
+    /// @param disclosedMessages Disclosed messages, reduced modulo R
+    /// @return True if the proof is valid for the disclosed messages
+    function verifyProof(
+        bytes calldata proof,
+        uint256[] calldata disclosedIndices,
+        uint256[] calldata disclosedMessages
+    ) external view returns (bool) {
+        (bool valid, uint256 hiddenCount) = _checkShape(proof, disclosedIndices, disclosedMessages);
+        if (!valid) return false;
+
+        bytes memory pk = publicKey;
+
+        // Recompute the Schnorr commitments
+        bytes memory t1 = _commitmentT1(proof);
+        if (t1.length == 0) return false;
+        bytes memory t2 = _commitmentT2(proof, hiddenCount, disclosedIndices, disclosedMessages, pk);
+        if (t2.length == 0) return false;
+
+        // Recompute the Fiat-Shamir challenge as bbs.VerifyProof does
+        if (_challenge(proof, t1, t2, disclosedIndices, disclosedMessages) != _word(proof, SCALARS)) return false;
+
+        return _pairing(proof, pk);
+    }
+
+    /// @dev Checks that every index is disclosed or hidden exactly once, that
+    /// all scalars are reduced and that A' is not the point at infinity, which
+    /// would make the pairing check hold trivially
+    function _checkShape(
+        bytes calldata proof,
+        uint256[] calldata disclosedIndices,
+        uint256[] calldata disclosedMessages
+    ) private view returns (bool, uint256 hiddenCount) {
+        uint256 count = messageCount;
+
+        if (disclosedIndices.length != disclosedMessages.length) return (false, 0);
+        if (proof.length < PROOF_FIXED_LEN || (proof.length - PROOF_FIXED_LEN) % 64 != 0) return (false, 0);
+        hiddenCount = (proof.length - PROOF_FIXED_LEN) / 64;
+        if (hiddenCount + disclosedIndices.length != count) return (false, 0);
+
+        uint256 seen;
+        for (uint256 i = 0; i < disclosedIndices.length; i++) {
+            uint256 idx = disclosedIndices[i];
+            if (idx >= count || (i > 0 && idx <= disclosedIndices[i - 1])) return (false, 0);
+            if (disclosedMessages[i] >= R) return (false, 0);
+            seen |= 1 << idx;
+        }
+        for (uint256 k = 0; k < hiddenCount; k++) {
+            uint256 idx = _word(proof, PROOF_FIXED_LEN + 64 * k);
+            if (idx >= count || (seen >> idx) & 1 == 1) return (false, 0);
+            if (_word(proof, PROOF_FIXED_LEN + 64 * k + 32) >= R) return (false, 0);
+            seen |= 1 << idx;
+        }
+
+        for (uint256 j = 0; j < 5; j++) {
+            if (_word(proof, SCALARS + 32 * j) >= R) return (false, 0);
+        }
+
+        if (_isInfinity(proof[A_PRIME:A_PRIME + G1_LEN])) return (false, 0);
+
+        return (true, hiddenCount);
+    }
+
+    /// @dev T1 = A-bar * c + A' * e^ + D * r1^
+    function _commitmentT1(bytes calldata proof) private view returns (bytes memory) {
+        bytes memory input = new bytes(3 * MSM_TERM_LEN);
+        _putTerm(input, 0, proof[A_BAR:A_BAR + G1_LEN], 0, _word(proof, SCALARS));
+        _putTerm(input, 1, proof[A_PRIME:A_PRIME + G1_LEN], 0, _word(proof, SCALARS + 32));
+        _putTerm(input, 2, proof[D_POINT:D_POINT + G1_LEN], 0, _word(proof, SCALARS + 96));
+        return _msm(input);
+    }
+
+    /// @dev T2 = (P1 + Q2 * domain + sum(H_i * m_i)) * c + D * r3^ + Q1 * s^ + sum(H_j * m^_j)
+    function _commitmentT2(
+        bytes calldata proof,
+        uint256 hiddenCount,
+        uint256[] calldata disclosedIndices,
+        uint256[] calldata disclosedMessages,
+        bytes memory pk
+    ) private view returns (bytes memory) {
+        uint256 c = _word(proof, SCALARS);
+
+        bytes memory input = new bytes((disclosedIndices.length + hiddenCount + 4) * MSM_TERM_LEN);
+        _putTerm(input, 0, pk, PK_P1, c);
+        _putTerm(input, 1, pk, PK_Q2, mulmod(domain, c, R));
+        _putTerm(input, 2, proof[D_POINT:D_POINT + G1_LEN], 0, _word(proof, SCALARS + 128));
+        _putTerm(input, 3, pk, PK_Q1, _word(proof, SCALARS + 64));
+
+        uint256 term = 4;
+        for (uint256 i = 0; i < disclosedIndices.length; i++) {
+            _putTerm(input, term++, pk, PK_H + disclosedIndices[i] * G1_LEN, mulmod(disclosedMessages[i], c, R));
+        }
+        for (uint256 k = 0; k < hiddenCount; k++) {
+            uint256 idx = _word(proof, PROOF_FIXED_LEN + 64 * k);
+            _putTerm(input, term++, pk, PK_H + idx * G1_LEN, _word(proof, PROOF_FIXED_LEN + 64 * k + 32));
+        }
+
+        return _msm(input);
+    }
+
+    /// @dev Checks e(A', W) * e(A-bar, -P2) = 1
+    function _pairing(bytes calldata proof, bytes memory pk) private view returns (bool) {
+        bytes memory input = new bytes(2 * (G1_LEN + G2_LEN));
+        _copy(input, 0, proof[A_PRIME:A_PRIME + G1_LEN], 0, G1_LEN);
+        _copy(input, G1_LEN, pk, PK_W, G2_LEN);
+        _copy(input, G1_LEN + G2_LEN, proof[A_BAR:A_BAR + G1_LEN], 0, G1_LEN);
+        _copy(input, 2 * G1_LEN + G2_LEN, pk, PK_NEG_P2, G2_LEN);
+
+        (bool ok, bytes memory result) = PAIRING_CHECK.staticcall(input);
+        return ok && result.length == 32 && uint256(bytes32(result)) == 1;
+    }
+
+    /// @dev Hashes A', A-bar, D, T1, T2, the disclosed messages and the
+    /// domain. Points use the 96 byte uncompressed encoding and integers
+    /// their minimal big-endian bytes behind a 4 byte length.
+    function _challenge(
+        bytes calldata proof,
+        bytes memory t1,
+        bytes memory t2,
+        uint256[] calldata disclosedIndices,
+        uint256[] calldata disclosedMessages
+    ) private view returns (uint256) {
+        bytes memory buff = abi.encodePacked(
+            _raw(proof[A_PRIME:A_PRIME + G1_LEN]),
+            _raw(proof[A_BAR:A_BAR + G1_LEN]),
+            _raw(proof[D_POINT:D_POINT + G1_LEN]),
+            _raw(t1),
+            _raw(t2)
+        );
+        buff = abi.encodePacked(buff, uint32(disclosedIndices.length));
+        for (uint256 i = 0; i < disclosedIndices.length; i++) {
+            bytes memory m = _minimalBytes(disclosedMessages[i]);
+            buff = abi.encodePacked(buff, uint32(disclosedIndices[i]), uint32(m.length), m);
+        }
+        bytes memory dom = _minimalBytes(domain);
+        buff = abi.encodePacked(buff, uint32(dom.length), dom);
+        return uint256(sha256(buff)) % R;
+    }
+
+    /// @dev Runs the G1 multi-scalar multiplication precompile. Returns an
+    /// empty result on failure and for the point at infinity, which no valid
+    /// proof produces.
+    function _msm(bytes memory input) private view returns (bytes memory) {
+        (bool ok, bytes memory result) = G1_MSM.staticcall(input);
+        if (!ok || result.length != G1_LEN || _isInfinity(result)) return "";
+        return result;
+    }
+
+    /// @dev Writes the point at src[offset:] and scalar as MSM term number index
+    function _putTerm(bytes memory dst, uint256 index, bytes memory src, uint256 offset, uint256 scalar) private pure {
+        uint256 at = index * MSM_TERM_LEN;
+        _copy(dst, at, src, offset, G1_LEN);
+        assembly {
+            mstore(add(add(dst, 32), add(at, 128)), scalar)
+        }
+    }
+
+    /// @dev Copies length bytes, a multiple of 32, from src[srcOffset:] to dst[dstOffset:]
+    function _copy(bytes memory dst, uint256 dstOffset, bytes memory src, uint256 srcOffset, uint256 length)
+        private
+        pure
+    {
+        assembly {
+            let to := add(add(dst, 32), dstOffset)
+            let from := add(add(src, 32), srcOffset)
+            for { let i := 0 } lt(i, length) { i := add(i, 32) } {
+                mstore(add(to, i), mload(add(from, i)))
+            }
+        }
+    }
+
+    /// @dev Converts an EIP-2537 G1 point to the 96 byte x || y encoding
+    function _raw(bytes memory p) private pure returns (bytes memory out) {
+        out = new bytes(96);
+        assembly {
+            let o := add(out, 32)
+            let s := add(p, 32)
+            mstore(o, mload(add(s, 16)))
+            mstore(add(o, 16), mload(add(s, 32)))
+            mstore(add(o, 48), mload(add(s, 80)))
+            mstore(add(o, 64), mload(add(s, 96)))
+        }
+    }
+
+    /// @dev Returns the minimal big-endian encoding of v, empty for zero
+    function _minimalBytes(uint256 v) private pure returns (bytes memory out) {
+        uint256 n = 0;
+        for (uint256 t = v; t != 0; t >>= 8) {
+            n++;
+        }
+        out = new bytes(n);
+        for (uint256 i = 0; i < n; i++) {
+            out[n - 1 - i] = bytes1(uint8(v >> (8 * i)));
+        }
+    }
+
+    /// @dev Reports whether an encoded G1 point is the point at infinity
+    function _isInfinity(bytes memory p) private pure returns (bool) {
+        uint256 acc;
+        for (uint256 i = 0; i < G1_LEN; i += 32) {
+            uint256 w;
+            assembly {
+                w := mload(add(add(p, 32), i))
+            }
+            acc |= w;
+        }
+        return acc == 0;
+    }
+
+    /// @dev Reads the 32 byte word at data[offset:]
+    function _word(bytes calldata data, uint256 offset) private pure returns (uint256 w) {
+        assembly {
+            w := calldataload(add(data.offset, offset))
+        }
+    }
+}
@@ -0,0 +1,120 @@
+package evm
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/anupsv/bbsplus-signatures/bbs"
+	bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381"
+)
+
+// proofFixedSize is the size of the proof encoding before the hidden message
+// responses: A' || A-bar || D || c || e^ || s^ || r1^ || r3^
+const proofFixedSize = 3*G1Size + 5*ScalarSize
+
+// ProofCalldata holds the arguments of BBSVerifier.verifyProof
+type ProofCalldata struct {
+	// Proof is A' || A-bar || D || c || e^ || s^ || r1^ || r3^ followed by
+	// (index || m^) for every hidden message, all words 32 bytes
+	Proof []byte
+
+	// DisclosedIndices lists the disclosed message indices in ascending order
+	DisclosedIndices []uint64
+
+	// DisclosedMessages holds the disclosed messages in the same order
+	DisclosedMessages []*big.Int
+}
+
+// EncodePublicKey returns the constructor argument of BBSVerifier for
+// publicKey:
+// count || W || -P2 || P1 || Q1 || Q2 || H_1 ... H_L
+// The negated G2 generator is stored so the contract does not negate it on
+// every verification.
+func EncodePublicKey(publicKey *bbs.PublicKey) ([]byte, error) {
+	if publicKey == nil || publicKey.MessageCount() <= 0 || publicKey.MessageCount() > MaxMessages {
+		return nil, fmt.Errorf("unsupported message count")
+	}
+	generators := publicKey.Generators()
+	if len(generators) != publicKey.MessageCount()+2 {
+		return nil, fmt.Errorf("public key has %d generators, expected %d", len(generators), publicKey.MessageCount()+2)
+	}
+
+	w, g1, g2 := publicKey.W(), publicKey.G1(), publicKey.G2()
+	var negG2 bls12381.G2Affine
+	negG2.Neg(&g2)
+
+	result := make([]byte, 0, ScalarSize+2*G2Size+(len(generators)+1)*G1Size)
+	result, _ = appendScalar(result, big.NewInt(int64(publicKey.MessageCount())))
+	result = appendG2(result, &w)
+	result = appendG2(result, &negG2)
+	result = appendG1(result, &g1)
+	for i := range generators {
+		result = appendG1(result, &generators[i])
+	}
+
+	return result, nil
+}
+
+// EncodeProof formats a proof created by bbs.CreateProof and its disclosed
+// messages for BBSVerifier.verifyProof. Disclosed messages must be reduced
+// modulo the group order, as bbs.MessageToFieldElement returns them.
+func EncodeProof(proof *bbs.ProofOfKnowledge, disclosedMessages map[int]*big.Int) (*ProofCalldata, error) {
+	if proof == nil {
+		return nil, bbs.ErrInvalidProof
+	}
+	if len(proof.CommitmentHat) > 0 {
+		return nil, fmt.Errorf("%w: commitment equalities", ErrUnsupportedProof)
+	}
+
+	result := make([]byte, 0, proofFixedSize+len(proof.MHat)*2*ScalarSize)
+
+	// Add the points
+	result = appendG1(result, &proof.APrime)
+	result = appendG1(result, &proof.ABar)
+	result = appendG1(result, &proof.D)
+
+	// Add the challenge and responses
+	var err error
+	for _, s := range []*big.Int{proof.C, proof.EHat, proof.SHat, proof.R1Hat, proof.R3Hat} {
+		if result, err = appendScalar(result, s); err != nil {
+			return nil, fmt.Errorf("%w: %v", bbs.ErrInvalidProof, err)
+		}
+	}
+
+	// Add the hidden message responses in index order
+	for _, idx := range sortedIndices(proof.MHat) {
+		if idx < 0 {
+			return nil, fmt.Errorf("invalid hidden message index: %d", idx)
+		}
+		result, _ = appendScalar(result, big.NewInt(int64(idx)))
+		if result, err = appendScalar(result, proof.MHat[idx]); err != nil {
+			return nil, fmt.Errorf("%w: %v", bbs.ErrInvalidProof, err)
+		}
+	}
+
+	calldata := &ProofCalldata{Proof: result}
+	for _, idx := range sortedIndices(disclosedMessages) {
+		msg := disclosedMessages[idx]
+		if idx < 0 {
+			return nil, fmt.Errorf("invalid disclosed message index: %d", idx)
+		}
+		if msg == nil || msg.Sign() < 0 || msg.Cmp(bbs.Order) >= 0 {
+			return nil, fmt.Errorf("%w: disclosed message %d is not reduced", ErrUnsupportedProof, idx)
+		}
+		calldata.DisclosedIndices = append(calldata.DisclosedIndices, uint64(idx))
+		calldata.DisclosedMessages = append(calldata.DisclosedMessages, new(big.Int).Set(msg))
+	}
+
+	return calldata, nil
+}
+
+// sortedIndices returns the keys of m in ascending order
+func sortedIndices(m map[int]*big.Int) []int {
+	keys := make([]int, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Ints(keys)
+	return keys
+}
@@ -0,0 +1,25 @@
+// Package evm formats BBS+ public keys and proofs for verification by EVM
+// smart contracts using the BLS12-381 precompiles of EIP-2537.
+//
+// Points use the EIP-2537 encoding: every base field element is padded to 64
+// bytes, so a G1 point takes 128 bytes and a G2 point 256 bytes. Scalars are
+// 32 byte big-endian words.
+//
+// Example usage:
+//
+//	// Deploy the reference verifier for an issuer key and header
+//	pkBytes, err := evm.EncodePublicKey(publicKey)
+//	domain := bbs.CalculateDomain(publicKey, header)
+//	// new BBSVerifier(pkBytes, domain), with the source from evm.VerifierSource
+//
+//	// Submit a presentation
+//	calldata, err := evm.EncodeProof(proof, disclosed)
+//	// verifier.verifyProof(calldata.Proof, calldata.DisclosedIndices, calldata.DisclosedMessages)
+//
+// The reference contract recomputes the Fiat-Shamir challenge exactly as
+// bbs.VerifyProof does, so any proof created by bbs.CreateProof for the
+// header the contract was deployed with can be checked on-chain. Proofs with
+// a holder binding or commitment equalities are not supported.
+//
+// Stability: experimental. The API may change in a minor release.
+package evm
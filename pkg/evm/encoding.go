@@ -0,0 +1,113 @@
+package evm
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/anupsv/bbsplus-signatures/bbs"
+	bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381"
+	"github.com/consensys/gnark-crypto/ecc/bls12-381/fp"
+)
+
+// Encoded sizes in bytes
+const (
+	// FpSize is the size of a padded base field element
+	FpSize = 64
+
+	// G1Size is the size of an encoded G1 point
+	G1Size = 2 * FpSize
+
+	// G2Size is the size of an encoded G2 point
+	G2Size = 4 * FpSize
+
+	// ScalarSize is the size of an encoded scalar
+	ScalarSize = 32
+
+	// fpPadding is the number of leading zero bytes of a padded field element
+	fpPadding = FpSize - fp.Bytes
+)
+
+// MaxMessages is the largest message count the reference verifier supports
+const MaxMessages = 256
+
+// ErrUnsupportedProof is returned for proofs the reference verifier cannot check
+var ErrUnsupportedProof = errors.New("proof is not supported on-chain")
+
+// appendFp appends a field element padded to FpSize bytes
+func appendFp(dst []byte, e *fp.Element) []byte {
+	var padding [fpPadding]byte
+	b := e.Bytes()
+	dst = append(dst, padding[:]...)
+	return append(dst, b[:]...)
+}
+
+// appendG1 appends the EIP-2537 encoding of p; the point at infinity is all zeros
+func appendG1(dst []byte, p *bls12381.G1Affine) []byte {
+	dst = appendFp(dst, &p.X)
+	return appendFp(dst, &p.Y)
+}
+
+// appendG2 appends the EIP-2537 encoding of p: x.c0 || x.c1 || y.c0 || y.c1
+func appendG2(dst []byte, p *bls12381.G2Affine) []byte {
+	dst = appendFp(dst, &p.X.A0)
+	dst = appendFp(dst, &p.X.A1)
+	dst = appendFp(dst, &p.Y.A0)
+	return appendFp(dst, &p.Y.A1)
+}
+
+// appendScalar appends s as a 32 byte word. s must be in [0, Order).
+func appendScalar(dst []byte, s *big.Int) ([]byte, error) {
+	if s == nil || s.Sign() < 0 || s.Cmp(bbs.Order) >= 0 {
+		return nil, fmt.Errorf("scalar out of range")
+	}
+	var b [ScalarSize]byte
+	s.FillBytes(b[:])
+	return append(dst, b[:]...), nil
+}
+
+// EncodeG1 returns the EIP-2537 encoding of a G1 point
+func EncodeG1(p *bls12381.G1Affine) []byte {
+	return appendG1(make([]byte, 0, G1Size), p)
+}
+
+// EncodeG2 returns the EIP-2537 encoding of a G2 point
+func EncodeG2(p *bls12381.G2Affine) []byte {
+	return appendG2(make([]byte, 0, G2Size), p)
+}
+
+// decodeFp reads a padded field element, rejecting non-canonical encodings
+func decodeFp(data []byte) (fp.Element, error) {
+	for _, b := range data[:fpPadding] {
+		if b != 0 {
+			return fp.Element{}, fmt.Errorf("invalid field element padding")
+		}
+	}
+	var e fp.Element
+	if err := e.SetBytesCanonical(data[fpPadding:FpSize]); err != nil {
+		return fp.Element{}, fmt.Errorf("invalid field element: %w", err)
+	}
+	return e, nil
+}
+
+// DecodeG1 parses an EIP-2537 G1 point and checks it is in the prime order
+// subgroup, as the precompiles do
+func DecodeG1(data []byte) (bls12381.G1Affine, error) {
+	var p bls12381.G1Affine
+	if len(data) != G1Size {
+		return p, fmt.Errorf("invalid G1 point length: %d", len(data))
+	}
+
+	var err error
+	if p.X, err = decodeFp(data[:FpSize]); err != nil {
+		return p, err
+	}
+	if p.Y, err = decodeFp(data[FpSize:]); err != nil {
+		return p, err
+	}
+
+	if !p.IsInfinity() && !p.IsInSubGroup() {
+		return p, fmt.Errorf("G1 point not in subgroup")
+	}
+	return p, nil
+}
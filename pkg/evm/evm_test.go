@@ -0,0 +1,276 @@
+package evm
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/anupsv/bbsplus-signatures/bbs"
+	bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381"
+)
+
+// referenceVerify follows BBSVerifier.verifyProof step by step on the
+// encoded public key and calldata, with the precompiles replaced by
+// gnark-crypto. It keeps the contract logic checked against bbs.VerifyProof.
+func referenceVerify(t *testing.T, pkBytes []byte, domain *big.Int, cd *ProofCalldata) bool {
+	t.Helper()
+
+	word := func(b []byte, off int) *big.Int { return new(big.Int).SetBytes(b[off : off+32]) }
+	g1 := func(b []byte, off int) (bls12381.G1Affine, bool) {
+		p, err := DecodeG1(b[off : off+G1Size])
+		return p, err == nil
+	}
+	g2 := func(b []byte, off int) bls12381.G2Affine {
+		var p bls12381.G2Affine
+		x0, _ := decodeFp(b[off:])
+		x1, _ := decodeFp(b[off+FpSize:])
+		y0, _ := decodeFp(b[off+2*FpSize:])
+		y1, _ := decodeFp(b[off+3*FpSize:])
+		p.X.A0, p.X.A1, p.Y.A0, p.Y.A1 = x0, x1, y0, y1
+		return p
+	}
+
+	// Constructor layout
+	count := int(word(pkBytes, 0).Int64())
+	const pkW = 32
+	const pkNegP2 = pkW + G2Size
+	const pkP1 = pkNegP2 + G2Size
+	const pkQ1 = pkP1 + G1Size
+	const pkQ2 = pkQ1 + G1Size
+	const pkH = pkQ2 + G1Size
+	if len(pkBytes) != pkH+count*G1Size {
+		t.Fatalf("Unexpected public key length %d", len(pkBytes))
+	}
+	point := func(off int) bls12381.G1Affine {
+		p, ok := g1(pkBytes, off)
+		if !ok {
+			t.Fatalf("Invalid public key point at %d", off)
+		}
+		return p
+	}
+
+	// _checkShape
+	proof := cd.Proof
+	if len(cd.DisclosedIndices) != len(cd.DisclosedMessages) {
+		return false
+	}
+	if len(proof) < proofFixedSize || (len(proof)-proofFixedSize)%64 != 0 {
+		return false
+	}
+	hiddenCount := (len(proof) - proofFixedSize) / 64
+	if hiddenCount+len(cd.DisclosedIndices) != count {
+		return false
+	}
+	seen := make(map[uint64]bool)
+	for i, idx := range cd.DisclosedIndices {
+		if idx >= uint64(count) || (i > 0 && idx <= cd.DisclosedIndices[i-1]) || cd.DisclosedMessages[i].Cmp(bbs.Order) >= 0 {
+			return false
+		}
+		seen[idx] = true
+	}
+	for k := 0; k < hiddenCount; k++ {
+		idx := word(proof, proofFixedSize+64*k)
+		if !idx.IsUint64() || idx.Uint64() >= uint64(count) || seen[idx.Uint64()] ||
+			word(proof, proofFixedSize+64*k+32).Cmp(bbs.Order) >= 0 {
+			return false
+		}
+		seen[idx.Uint64()] = true
+	}
+	scalars := 3 * G1Size
+	for j := 0; j < 5; j++ {
+		if word(proof, scalars+32*j).Cmp(bbs.Order) >= 0 {
+			return false
+		}
+	}
+	aPrime, ok1 := g1(proof, 0)
+	aBar, ok2 := g1(proof, G1Size)
+	d, ok3 := g1(proof, 2*G1Size)
+	if !ok1 || !ok2 || !ok3 || aPrime.IsInfinity() {
+		return false
+	}
+	c := word(proof, scalars)
+
+	msm := func(points []bls12381.G1Affine, s []*big.Int) bls12381.G1Affine {
+		var r bls12381.G1Affine
+		jac, err := bbs.MultiScalarMulG1(points, s)
+		if err != nil {
+			t.Fatalf("MSM failed: %v", err)
+		}
+		r.FromJacobian(&jac)
+		return r
+	}
+	mulmod := func(a, b *big.Int) *big.Int {
+		r := new(big.Int).Mul(a, b)
+		return r.Mod(r, bbs.Order)
+	}
+
+	// _commitmentT1 and _commitmentT2
+	t1 := msm([]bls12381.G1Affine{aBar, aPrime, d}, []*big.Int{c, word(proof, scalars+32), word(proof, scalars+96)})
+
+	points := []bls12381.G1Affine{point(pkP1), point(pkQ2), d, point(pkQ1)}
+	s := []*big.Int{c, mulmod(domain, c), word(proof, scalars+128), word(proof, scalars+64)}
+	for i, idx := range cd.DisclosedIndices {
+		points = append(points, point(pkH+int(idx)*G1Size))
+		s = append(s, mulmod(cd.DisclosedMessages[i], c))
+	}
+	for k := 0; k < hiddenCount; k++ {
+		idx := int(word(proof, proofFixedSize+64*k).Int64())
+		points = append(points, point(pkH+idx*G1Size))
+		s = append(s, word(proof, proofFixedSize+64*k+32))
+	}
+	t2 := msm(points, s)
+	if t1.IsInfinity() || t2.IsInfinity() {
+		return false
+	}
+
+	// _challenge
+	u32 := func(v int) []byte { return []byte{byte(v >> 24), byte(v >> 16), byte(v >> 8), byte(v)} }
+	var buff []byte
+	for _, p := range []bls12381.G1Affine{aPrime, aBar, d, t1, t2} {
+		raw := p.RawBytes()
+		buff = append(buff, raw[:]...)
+	}
+	buff = append(buff, u32(len(cd.DisclosedIndices))...)
+	for i, idx := range cd.DisclosedIndices {
+		m := cd.DisclosedMessages[i].Bytes()
+		buff = append(buff, u32(int(idx))...)
+		buff = append(buff, u32(len(m))...)
+		buff = append(buff, m...)
+	}
+	dom := domain.Bytes()
+	buff = append(buff, u32(len(dom))...)
+	buff = append(buff, dom...)
+	digest := sha256.Sum256(buff)
+	challenge := new(big.Int).SetBytes(digest[:])
+	if challenge.Mod(challenge, bbs.Order).Cmp(c) != 0 {
+		return false
+	}
+
+	// _pairing
+	ok, err := bls12381.PairingCheck(
+		[]bls12381.G1Affine{aPrime, aBar},
+		[]bls12381.G2Affine{g2(pkBytes, pkW), g2(pkBytes, pkNegP2)},
+	)
+	return err == nil && ok
+}
+
+func newTestProof(t *testing.T, disclosedIndices []int) (*bbs.PublicKey, *bbs.ProofOfKnowledge, map[int]*big.Int, []byte) {
+	t.Helper()
+
+	keyPair, err := bbs.GenerateKeyPair(5, rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key pair: %v", err)
+	}
+
+	messages := []*big.Int{
+		big.NewInt(0),
+		bbs.MessageToFieldElement([]byte("alice")),
+		big.NewInt(255),
+		big.NewInt(256),
+		new(big.Int).Sub(bbs.Order, big.NewInt(1)),
+	}
+	header := []byte("evm header")
+
+	signature, err := bbs.Sign(keyPair.PrivateKey, keyPair.PublicKey, messages, header)
+	if err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+
+	proof, disclosed, err := bbs.CreateProof(keyPair.PublicKey, signature, messages, disclosedIndices, header)
+	if err != nil {
+		t.Fatalf("CreateProof failed: %v", err)
+	}
+
+	return keyPair.PublicKey, proof, disclosed, header
+}
+
+func TestEncodeG1Generator(t *testing.T) {
+	_, _, g1, _ := bls12381.Generators()
+
+	// EIP-2537 encoding of the G1 generator
+	want := strings.Repeat("00", 16) +
+		"17f1d3a73197d7942695638c4fa9ac0fc3688c4f9774b905a14e3a3f171bac586c55e83ff97a1aeffb3af00adb22c6bb" +
+		strings.Repeat("00", 16) +
+		"08b3f481e3aaa0f1a09e30ed741d8ae4fcf5e095d5d00af600db18cb2c04b3edd03cc744a2888ae40caa232946c5e7e1"
+
+	if got := hex.EncodeToString(EncodeG1(&g1)); got != want {
+		t.Fatalf("Unexpected G1 encoding:\n got %s\nwant %s", got, want)
+	}
+
+	decoded, err := DecodeG1(EncodeG1(&g1))
+	if err != nil {
+		t.Fatalf("DecodeG1 failed: %v", err)
+	}
+	if !decoded.Equal(&g1) {
+		t.Fatal("G1 round trip mismatch")
+	}
+
+	var infinity bls12381.G1Affine
+	if !bytes.Equal(EncodeG1(&infinity), make([]byte, G1Size)) {
+		t.Fatal("Point at infinity is not encoded as zeros")
+	}
+}
+
+func TestReferenceVerifier(t *testing.T) {
+	for _, disclosedIndices := range [][]int{nil, {1}, {0, 2, 4}, {0, 1, 2, 3, 4}} {
+		publicKey, proof, disclosed, header := newTestProof(t, disclosedIndices)
+
+		pkBytes, err := EncodePublicKey(publicKey)
+		if err != nil {
+			t.Fatalf("EncodePublicKey failed: %v", err)
+		}
+		domain := bbs.CalculateDomain(publicKey, header)
+
+		calldata, err := EncodeProof(proof, disclosed)
+		if err != nil {
+			t.Fatalf("EncodeProof failed: %v", err)
+		}
+
+		if !referenceVerify(t, pkBytes, domain, calldata) {
+			t.Fatalf("Reference verifier rejected a valid proof disclosing %v", disclosedIndices)
+		}
+
+		// A wrong domain, i.e. another header, is rejected
+		if referenceVerify(t, pkBytes, new(big.Int).Add(domain, big.NewInt(1)), calldata) {
+			t.Fatal("Reference verifier accepted a proof for another domain")
+		}
+
+		// A tampered response is rejected
+		tampered := *calldata
+		tampered.Proof = append([]byte(nil), calldata.Proof...)
+		tampered.Proof[3*G1Size+2*ScalarSize-1] ^= 1
+		if referenceVerify(t, pkBytes, domain, &tampered) {
+			t.Fatal("Reference verifier accepted a tampered proof")
+		}
+
+		// A changed disclosed message is rejected
+		if len(calldata.DisclosedMessages) > 0 {
+			changed := *calldata
+			changed.DisclosedMessages = append([]*big.Int(nil), calldata.DisclosedMessages...)
+			changed.DisclosedMessages[0] = new(big.Int).Add(changed.DisclosedMessages[0], big.NewInt(1))
+			if referenceVerify(t, pkBytes, domain, &changed) {
+				t.Fatal("Reference verifier accepted a changed disclosed message")
+			}
+		}
+	}
+}
+
+func TestEncodeProofRejectsUnsupported(t *testing.T) {
+	_, proof, disclosed, _ := newTestProof(t, []int{0})
+
+	proof.CommitmentHat = map[int]*big.Int{1: big.NewInt(1)}
+	if _, err := EncodeProof(proof, disclosed); !errors.Is(err, ErrUnsupportedProof) {
+		t.Fatalf("Expected ErrUnsupportedProof, got %v", err)
+	}
+	proof.CommitmentHat = nil
+
+	disclosed[0] = new(big.Int).Add(bbs.Order, big.NewInt(1))
+	if _, err := EncodeProof(proof, disclosed); !errors.Is(err, ErrUnsupportedProof) {
+		t.Fatalf("Expected ErrUnsupportedProof for an unreduced message, got %v", err)
+	}
+}
@@ -0,0 +1,9 @@
+package evm
+
+import _ "embed"
+
+// VerifierSource is the Solidity source of the reference BBSVerifier
+// contract. It needs the EIP-2537 precompiles and solc 0.8.24 or later.
+//
+//go:embed BBSVerifier.sol
+var VerifierSource string
@@ -0,0 +1,38 @@
+// Package hybrid pairs BBS+ presentations with a hash-based issuer
+// counter-signature, as a fallback trust anchor should pairings on
+// BLS12-381 ever fall to a quantum computer.
+//
+// At issuance the issuer commits to every signed message with a salted
+// digest and signs the digests with a hash-based key, such as SLH-DSA
+// (FIPS 205), through a CounterSigner. The holder keeps the resulting
+// Endorsement with the salts. A Presentation carries the BBS+ proof, made
+// under a presentation header bound to the endorsement, together with the
+// endorsement and the salts of the disclosed messages:
+//
+//	// Issuer
+//	endorsement, salts, err := hybrid.Endorse(slhdsa, keyPair.PublicKey, messages, header)
+//
+//	// Holder
+//	p, err := hybrid.Present(publicKey, signature, messages, []int{0, 2}, endorsement, salts, nonce)
+//
+//	// Verifier
+//	disclosed, err := p.Verify(publicKey, nonce, hybrid.Policy{
+//		Mode:               hybrid.ModeHybrid,
+//		Verifiers:          []hybrid.CounterVerifier{slhdsaVerifier},
+//		TrustedCounterKeys: [][]byte{issuerCounterKey},
+//	})
+//
+// The policy mode selects what a verifier relies on: both signatures, the
+// BBS+ proof alone, or the counter-signature alone. The counter-signature
+// only vouches for the disclosed values. It does not prove possession of
+// the credential, and the endorsement is the same in every presentation, so
+// hybrid presentations are linkable by it.
+//
+// The package implements no hash-based scheme itself; callers adapt one,
+// such as an SLH-DSA implementation, to CounterSigner and CounterVerifier.
+// Presentations are versioned and carry named extensions, which a verifier
+// ignores unless they are marked critical, so later additions remain
+// readable by today's verifiers.
+//
+// Stability: experimental
+package hybrid
@@ -0,0 +1,358 @@
+package hybrid
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+
+	"github.com/anupsv/bbsplus-signatures/bbs"
+	"github.com/anupsv/bbsplus-signatures/pkg/credential"
+)
+
+// Version is the presentation container version this package writes, and
+// the highest it reads
+const Version = 1
+
+// Counter-signature algorithms, named as in FIPS 205. Any name a
+// CounterVerifier reports is accepted; these are the ones expected.
+const (
+	AlgSLHDSASHA2128s  = "SLH-DSA-SHA2-128s"
+	AlgSLHDSASHA2128f  = "SLH-DSA-SHA2-128f"
+	AlgSLHDSASHAKE128s = "SLH-DSA-SHAKE-128s"
+	AlgSLHDSASHAKE128f = "SLH-DSA-SHAKE-128f"
+)
+
+// Domain separation tags
+const (
+	endorsementDST = "BBS_HYBRID_ENDORSEMENT_V1_"
+	headerDST      = "BBS_HYBRID_PRESENTATION_V1_"
+)
+
+var (
+	// ErrUnsupportedVersion is returned for a presentation of a later
+	// container version
+	ErrUnsupportedVersion = errors.New("unsupported hybrid presentation version")
+
+	// ErrCriticalExtension is returned for a presentation marking an
+	// extension critical that the verifier does not understand
+	ErrCriticalExtension = errors.New("unsupported critical extension")
+
+	// ErrCounterSignature is returned when the counter-signature is
+	// missing, untrusted or invalid
+	ErrCounterSignature = errors.New("invalid counter-signature")
+
+	// ErrOpening is returned when a disclosed message does not open its
+	// committed digest
+	ErrOpening = errors.New("disclosed message does not match its digest")
+)
+
+// CounterSigner signs with the issuer's hash-based key
+type CounterSigner interface {
+	// Algorithm names the signature scheme, such as AlgSLHDSASHA2128s
+	Algorithm() string
+
+	// PublicKey returns the encoded verification key
+	PublicKey() []byte
+
+	// Sign signs message
+	Sign(message []byte) ([]byte, error)
+}
+
+// CounterVerifier checks signatures of one hash-based scheme
+type CounterVerifier interface {
+	// Algorithm names the signature scheme, as the signer does
+	Algorithm() string
+
+	// Verify reports whether signature is valid for message under publicKey
+	Verify(publicKey, message, signature []byte) bool
+}
+
+// Endorsement is the issuer's counter-signature over the salted digests of
+// a credential's messages
+type Endorsement struct {
+	// Algorithm names the counter-signature scheme
+	Algorithm string `json:"alg"`
+
+	// CounterKey is the issuer's hash-based verification key
+	CounterKey []byte `json:"counterKey"`
+
+	// IssuerKey is the fingerprint of the issuer's BBS+ public key
+	IssuerKey string `json:"issuerKey"`
+
+	// Header is the BBS+ header the credential is signed under
+	Header []byte `json:"header,omitempty"`
+
+	// Digests are bbs.DisclosureDigest of each message, in signing order
+	Digests [][]byte `json:"digests"`
+
+	// Signature is the counter-signature over the fields above
+	Signature []byte `json:"signature"`
+}
+
+// Endorse commits to messages with fresh salts and counter-signs the
+// commitment. The issuer hands the endorsement and the salts to the holder
+// with the credential; the salts are as private as the messages.
+func Endorse(signer CounterSigner, publicKey *bbs.PublicKey, messages []*big.Int, header []byte) (*Endorsement, [][]byte, error) {
+	if signer == nil || publicKey == nil {
+		return nil, nil, fmt.Errorf("endorsement needs a counter-signer and a public key")
+	}
+	if len(messages) != publicKey.MessageCount() {
+		return nil, nil, fmt.Errorf("%w: %d messages for a key of %d", bbs.ErrInvalidMessageCount, len(messages), publicKey.MessageCount())
+	}
+
+	salts := make([][]byte, len(messages))
+	digests := make([][]byte, len(messages))
+	for i, msg := range messages {
+		salts[i] = make([]byte, bbs.DisclosureSaltSize)
+		if _, err := rand.Read(salts[i]); err != nil {
+			return nil, nil, fmt.Errorf("failed to generate salt: %w", err)
+		}
+		digest := bbs.DisclosureDigest(i, msg, salts[i])
+		digests[i] = digest[:]
+	}
+
+	e := &Endorsement{
+		Algorithm:  signer.Algorithm(),
+		CounterKey: signer.PublicKey(),
+		IssuerKey:  publicKey.Fingerprint(),
+		Header:     bytes.Clone(header),
+		Digests:    digests,
+	}
+	signature, err := signer.Sign(e.statement())
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to counter-sign: %w", err)
+	}
+	e.Signature = signature
+	return e, salts, nil
+}
+
+// statement returns the bytes the counter-signature covers
+func (e *Endorsement) statement() []byte {
+	out := []byte(endorsementDST)
+	for _, field := range [][]byte{[]byte(e.Algorithm), e.CounterKey, []byte(e.IssuerKey), e.Header} {
+		out = appendLengthPrefixed(out, field)
+	}
+	out = binary.BigEndian.AppendUint32(out, uint32(len(e.Digests)))
+	for _, digest := range e.Digests {
+		out = appendLengthPrefixed(out, digest)
+	}
+	return out
+}
+
+// opens reports whether msg and salt open the digest at index
+func (e *Endorsement) opens(index int, msg *big.Int, salt []byte) bool {
+	if index < 0 || index >= len(e.Digests) || msg == nil {
+		return false
+	}
+	digest := bbs.DisclosureDigest(index, msg, salt)
+	return bytes.Equal(digest[:], e.Digests[index])
+}
+
+// Presentation is the hybrid presentation container
+type Presentation struct {
+	// Version is the container version
+	Version int `json:"version"`
+
+	// Proof is the serialized BBS+ proof, bound to the endorsement
+	Proof []byte `json:"proof"`
+
+	// Disclosed are the disclosed messages by index
+	Disclosed map[int]*big.Int `json:"disclosed"`
+
+	// Endorsement is the issuer's counter-signature
+	Endorsement *Endorsement `json:"endorsement"`
+
+	// Openings are the salts of the disclosed messages by index
+	Openings map[int][]byte `json:"openings"`
+
+	// Extensions carry data added by later versions or other parties.
+	// Verifiers ignore extensions they do not understand unless they are
+	// named in Critical.
+	Extensions map[string]json.RawMessage `json:"extensions,omitempty"`
+	Critical   []string                   `json:"critical,omitempty"`
+}
+
+// Present proves knowledge of the signature while disclosing the messages
+// at disclosed, and attaches the endorsement with the salts of the
+// disclosed messages. ph is the verifier's nonce or other presentation
+// header.
+func Present(
+	publicKey *bbs.PublicKey,
+	signature *bbs.Signature,
+	messages []*big.Int,
+	disclosed []int,
+	endorsement *Endorsement,
+	salts [][]byte,
+	ph []byte,
+) (*Presentation, error) {
+	if endorsement == nil {
+		return nil, fmt.Errorf("%w: no endorsement", ErrCounterSignature)
+	}
+	if len(salts) != len(messages) || len(endorsement.Digests) != len(messages) {
+		return nil, fmt.Errorf("endorsement covers %d messages with %d salts, credential has %d",
+			len(endorsement.Digests), len(salts), len(messages))
+	}
+
+	openings := make(map[int][]byte, len(disclosed))
+	for _, idx := range disclosed {
+		if idx < 0 || idx >= len(messages) {
+			return nil, fmt.Errorf("disclosed index %d out of range", idx)
+		}
+		if !endorsement.opens(idx, messages[idx], salts[idx]) {
+			return nil, fmt.Errorf("%w: message %d", ErrOpening, idx)
+		}
+		openings[idx] = bytes.Clone(salts[idx])
+	}
+
+	proof, disclosedMsgs, err := bbs.CreateProofWithPresentationHeader(
+		publicKey, signature, messages, disclosed, endorsement.Header, endorsement.presentationHeader(ph),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &Presentation{
+		Version:     Version,
+		Proof:       bbs.SerializeProof(proof),
+		Disclosed:   disclosedMsgs,
+		Endorsement: endorsement,
+		Openings:    openings,
+	}, nil
+}
+
+// presentationHeader binds ph to the endorsement, so a proof cannot be
+// paired with the endorsement of another credential
+func (e *Endorsement) presentationHeader(ph []byte) []byte {
+	binding := sha256.Sum256(appendLengthPrefixed(e.statement(), e.Signature))
+	out := appendLengthPrefixed([]byte(headerDST), binding[:])
+	return append(out, ph...)
+}
+
+// Mode selects which signatures a verifier relies on
+type Mode int
+
+const (
+	// ModeHybrid requires both the BBS+ proof and the counter-signature
+	ModeHybrid Mode = iota
+
+	// ModeClassical checks the BBS+ proof only
+	ModeClassical
+
+	// ModePostQuantum checks the counter-signature and the openings only,
+	// for when BBS+ can no longer be trusted. The presentation header is
+	// not checked, so such presentations can be replayed.
+	ModePostQuantum
+)
+
+// Policy is what a verifier requires of a hybrid presentation
+type Policy struct {
+	// Mode selects the signatures checked; the zero value is ModeHybrid
+	Mode Mode
+
+	// Verifiers check counter-signatures, one per accepted algorithm
+	Verifiers []CounterVerifier
+
+	// TrustedCounterKeys are the issuers' hash-based keys accepted. They
+	// are required unless Mode is ModeClassical.
+	TrustedCounterKeys [][]byte
+
+	// Extensions are the extensions the verifier understands and may
+	// therefore be marked critical
+	Extensions []string
+}
+
+// Verify checks the presentation under publicKey, the verifier's
+// presentation header ph and policy, and returns the disclosed messages
+func (p *Presentation) Verify(publicKey *bbs.PublicKey, ph []byte, policy Policy) (map[int]*big.Int, error) {
+	if p.Version < 1 || p.Version > Version {
+		return nil, fmt.Errorf("%w: %d", ErrUnsupportedVersion, p.Version)
+	}
+	for _, name := range p.Critical {
+		if !slices.Contains(policy.Extensions, name) {
+			return nil, fmt.Errorf("%w: %s", ErrCriticalExtension, name)
+		}
+	}
+	if p.Endorsement == nil {
+		return nil, fmt.Errorf("%w: no endorsement", ErrCounterSignature)
+	}
+	if publicKey == nil || p.Endorsement.IssuerKey != publicKey.Fingerprint() {
+		return nil, fmt.Errorf("endorsement is for another issuer key")
+	}
+
+	if policy.Mode != ModeClassical {
+		if err := p.verifyCounterSignature(policy); err != nil {
+			return nil, err
+		}
+	}
+	if policy.Mode != ModePostQuantum {
+		proof, err := bbs.DeserializeProof(p.Proof)
+		if err != nil {
+			return nil, err
+		}
+		err = bbs.VerifyProofWithPresentationHeader(
+			publicKey, proof, p.Disclosed, p.Endorsement.Header, p.Endorsement.presentationHeader(ph),
+		)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return p.Disclosed, nil
+}
+
+// verifyCounterSignature checks the endorsement against the policy and the
+// disclosed messages against their digests
+func (p *Presentation) verifyCounterSignature(policy Policy) error {
+	e := p.Endorsement
+	if !slices.ContainsFunc(policy.TrustedCounterKeys, func(k []byte) bool { return bytes.Equal(k, e.CounterKey) }) {
+		return fmt.Errorf("%w: untrusted counter key", ErrCounterSignature)
+	}
+	i := slices.IndexFunc(policy.Verifiers, func(v CounterVerifier) bool { return v.Algorithm() == e.Algorithm })
+	if i < 0 {
+		return fmt.Errorf("%w: no verifier for %s", ErrCounterSignature, e.Algorithm)
+	}
+	if !policy.Verifiers[i].Verify(e.CounterKey, e.statement(), e.Signature) {
+		return ErrCounterSignature
+	}
+
+	if len(p.Openings) != len(p.Disclosed) {
+		return fmt.Errorf("%w: %d openings for %d disclosed messages", ErrOpening, len(p.Openings), len(p.Disclosed))
+	}
+	for idx, msg := range p.Disclosed {
+		salt, ok := p.Openings[idx]
+		if !ok || !e.opens(idx, msg, salt) {
+			return fmt.Errorf("%w: message %d", ErrOpening, idx)
+		}
+	}
+	return nil
+}
+
+// Marshal encodes the presentation as JSON
+func (p *Presentation) Marshal() ([]byte, error) {
+	return json.Marshal(p)
+}
+
+// ParsePresentation decodes a presentation written by Marshal. The zero
+// options refuse unknown, duplicate and case-folded fields; Lenient accepts
+// the unknown fields of later minor revisions. A later container version is
+// refused either way.
+func ParsePresentation(data []byte, opts credential.DecodeOptions) (*Presentation, error) {
+	var p Presentation
+	if err := opts.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("failed to decode hybrid presentation: %w", err)
+	}
+	if p.Version < 1 || p.Version > Version {
+		return nil, fmt.Errorf("%w: %d", ErrUnsupportedVersion, p.Version)
+	}
+	return &p, nil
+}
+
+// appendLengthPrefixed appends data behind its length as 4 bytes big-endian
+func appendLengthPrefixed(out, data []byte) []byte {
+	out = binary.BigEndian.AppendUint32(out, uint32(len(data)))
+	return append(out, data...)
+}
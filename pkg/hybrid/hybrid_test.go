@@ -0,0 +1,183 @@
+package hybrid
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/anupsv/bbsplus-signatures/bbs"
+	"github.com/anupsv/bbsplus-signatures/pkg/credential"
+)
+
+// testSigner stands in for a hash-based scheme. Ed25519 is not post-quantum;
+// it only exercises the container and the policy.
+type testSigner struct {
+	key ed25519.PrivateKey
+}
+
+func (s testSigner) Algorithm() string { return "test-ed25519" }
+
+func (s testSigner) PublicKey() []byte { return s.key.Public().(ed25519.PublicKey) }
+
+func (s testSigner) Sign(message []byte) ([]byte, error) { return ed25519.Sign(s.key, message), nil }
+
+type testVerifier struct{}
+
+func (testVerifier) Algorithm() string { return "test-ed25519" }
+
+func (testVerifier) Verify(publicKey, message, signature []byte) bool {
+	return len(publicKey) == ed25519.PublicKeySize && ed25519.Verify(publicKey, message, signature)
+}
+
+func TestHybridPresentation(t *testing.T) {
+	keyPair, err := bbs.GenerateKeyPair(3, rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key pair: %v", err)
+	}
+	_, counterKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate counter key: %v", err)
+	}
+	signer := testSigner{key: counterKey}
+
+	messages := []*big.Int{big.NewInt(7), big.NewInt(11), big.NewInt(13)}
+	header := []byte("schema")
+	signature, err := bbs.Sign(keyPair.PrivateKey, keyPair.PublicKey, messages, header)
+	if err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+	endorsement, salts, err := Endorse(signer, keyPair.PublicKey, messages, header)
+	if err != nil {
+		t.Fatalf("Endorse failed: %v", err)
+	}
+
+	nonce := []byte("nonce")
+	p, err := Present(keyPair.PublicKey, signature, messages, []int{0, 2}, endorsement, salts, nonce)
+	if err != nil {
+		t.Fatalf("Present failed: %v", err)
+	}
+	data, err := p.Marshal()
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	p, err = ParsePresentation(data, credential.DecodeOptions{})
+	if err != nil {
+		t.Fatalf("ParsePresentation failed: %v", err)
+	}
+
+	policy := Policy{Verifiers: []CounterVerifier{testVerifier{}}, TrustedCounterKeys: [][]byte{signer.PublicKey()}}
+	for _, mode := range []Mode{ModeHybrid, ModeClassical, ModePostQuantum} {
+		policy.Mode = mode
+		disclosed, err := p.Verify(keyPair.PublicKey, nonce, policy)
+		if err != nil {
+			t.Fatalf("Verify in mode %d failed: %v", mode, err)
+		}
+		if len(disclosed) != 2 || disclosed[2].Cmp(messages[2]) != 0 {
+			t.Fatalf("Unexpected disclosed messages %v", disclosed)
+		}
+	}
+
+	// The classical mode needs no counter-signature verifier
+	if _, err := p.Verify(keyPair.PublicKey, nonce, Policy{Mode: ModeClassical}); err != nil {
+		t.Fatalf("Classical verify failed: %v", err)
+	}
+
+	// An untrusted counter key fails unless only BBS+ is checked
+	if _, err := p.Verify(keyPair.PublicKey, nonce, Policy{Verifiers: policy.Verifiers}); !errors.Is(err, ErrCounterSignature) {
+		t.Fatalf("Expected ErrCounterSignature for an untrusted key, got %v", err)
+	}
+
+	// A broken BBS+ proof still passes the post-quantum fallback alone
+	broken := *p
+	broken.Proof = []byte{1, 2, 3}
+	policy.Mode = ModePostQuantum
+	if _, err := broken.Verify(keyPair.PublicKey, nonce, policy); err != nil {
+		t.Fatalf("Post-quantum verify of a broken proof failed: %v", err)
+	}
+	policy.Mode = ModeHybrid
+	if _, err := broken.Verify(keyPair.PublicKey, nonce, policy); err == nil {
+		t.Fatalf("Hybrid verify accepted a broken proof")
+	}
+
+	// A changed disclosed value does not open its digest
+	changed := *p
+	changed.Disclosed = map[int]*big.Int{0: big.NewInt(8), 2: messages[2]}
+	policy.Mode = ModePostQuantum
+	if _, err := changed.Verify(keyPair.PublicKey, nonce, policy); !errors.Is(err, ErrOpening) {
+		t.Fatalf("Expected ErrOpening, got %v", err)
+	}
+
+	// A forged digest breaks the counter-signature
+	forged := *p
+	forgedEndorsement := *p.Endorsement
+	forgedEndorsement.Digests = append([][]byte{make([]byte, 32)}, p.Endorsement.Digests[1:]...)
+	forged.Endorsement = &forgedEndorsement
+	if _, err := forged.Verify(keyPair.PublicKey, nonce, policy); !errors.Is(err, ErrCounterSignature) {
+		t.Fatalf("Expected ErrCounterSignature for a forged digest, got %v", err)
+	}
+
+	// The proof is bound to the verifier's nonce
+	policy.Mode = ModeHybrid
+	if _, err := p.Verify(keyPair.PublicKey, []byte("other"), policy); err == nil {
+		t.Fatalf("Verify accepted another nonce")
+	}
+}
+
+func TestHybridForwardCompatibility(t *testing.T) {
+	keyPair, err := bbs.GenerateKeyPair(1, rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key pair: %v", err)
+	}
+	_, counterKey, _ := ed25519.GenerateKey(rand.Reader)
+	signer := testSigner{key: counterKey}
+	messages := []*big.Int{big.NewInt(1)}
+	signature, _ := bbs.Sign(keyPair.PrivateKey, keyPair.PublicKey, messages, nil)
+	endorsement, salts, err := Endorse(signer, keyPair.PublicKey, messages, nil)
+	if err != nil {
+		t.Fatalf("Endorse failed: %v", err)
+	}
+	p, err := Present(keyPair.PublicKey, signature, messages, nil, endorsement, salts, nil)
+	if err != nil {
+		t.Fatalf("Present failed: %v", err)
+	}
+	policy := Policy{Verifiers: []CounterVerifier{testVerifier{}}, TrustedCounterKeys: [][]byte{signer.PublicKey()}}
+
+	// Unknown fields are refused unless the decoder is lenient, and
+	// non-critical extensions are ignored
+	p.Extensions = map[string]json.RawMessage{"status": json.RawMessage(`{"list":"https://example.com/status"}`)}
+	data, _ := p.Marshal()
+	var raw map[string]any
+	_ = json.Unmarshal(data, &raw)
+	raw["future"] = true
+	data, _ = json.Marshal(raw)
+	if _, err := ParsePresentation(data, credential.DecodeOptions{}); !errors.Is(err, credential.ErrMalformedJSON) {
+		t.Fatalf("Expected ErrMalformedJSON, got %v", err)
+	}
+	parsed, err := ParsePresentation(data, credential.DecodeOptions{Lenient: true})
+	if err != nil {
+		t.Fatalf("Lenient ParsePresentation with an unknown field failed: %v", err)
+	}
+	if _, err := parsed.Verify(keyPair.PublicKey, nil, policy); err != nil {
+		t.Fatalf("Verify with a non-critical extension failed: %v", err)
+	}
+
+	// A critical extension must be understood
+	parsed.Critical = []string{"status"}
+	if _, err := parsed.Verify(keyPair.PublicKey, nil, policy); !errors.Is(err, ErrCriticalExtension) {
+		t.Fatalf("Expected ErrCriticalExtension, got %v", err)
+	}
+	policy.Extensions = []string{"status"}
+	if _, err := parsed.Verify(keyPair.PublicKey, nil, policy); err != nil {
+		t.Fatalf("Verify with an understood critical extension failed: %v", err)
+	}
+
+	// A later container version is refused
+	raw["version"] = Version + 1
+	data, _ = json.Marshal(raw)
+	if _, err := ParsePresentation(data, credential.DecodeOptions{Lenient: true}); !errors.Is(err, ErrUnsupportedVersion) {
+		t.Fatalf("Expected ErrUnsupportedVersion, got %v", err)
+	}
+}
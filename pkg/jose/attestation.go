@@ -0,0 +1,118 @@
+package jose
+
+import (
+	"crypto"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/anupsv/bbsplus-signatures/bbs"
+)
+
+// TypeKeyAttestation is the token type of key attestations
+const TypeKeyAttestation = "bbs-key-attestation+jwt"
+
+// ErrUntrustedAttestation is returned when a key attestation is decoded
+// without the key of the authority expected to sign it
+var ErrUntrustedAttestation = errors.New("key attestation needs the attester's key")
+
+// KeyAttestation is a statement by a parent authority, such as an
+// enterprise CA, that an issuer holds a BBS+ public key
+//
+// Experimental: key attestations may change in a minor release.
+type KeyAttestation struct {
+	// Issuer identifies the issuer the key belongs to (sub)
+	Issuer string
+
+	// Attester identifies the authority making the statement (iss)
+	Attester string
+
+	// PublicKey is the attested key
+	PublicKey *bbs.PublicKey
+
+	// IssuedAt and ExpiresAt bound the attestation (iat, exp). Zero
+	// IssuedAt means now when encoding; zero ExpiresAt means no expiry.
+	IssuedAt  time.Time
+	ExpiresAt time.Time
+
+	// AttesterKey is the PKIX DER key the attestation was signed with. It
+	// is set when decoding and ignored when encoding.
+	AttesterKey []byte
+}
+
+// attestationPayload is the JWT payload of a key attestation
+type attestationPayload struct {
+	claims
+	Attester    string `json:"iss"`
+	Issuer      string `json:"sub"`
+	Key         string `json:"bbsKey"`
+	Fingerprint string `json:"bbsKeyFingerprint"`
+}
+
+// EncodeKeyAttestation signs a key attestation with the attester's key
+//
+// Experimental: key attestations may change in a minor release.
+func EncodeKeyAttestation(att *KeyAttestation, attesterKey crypto.Signer, opts EncodeOptions) (string, error) {
+	if att == nil || att.PublicKey == nil {
+		return "", fmt.Errorf("key attestation needs a public key")
+	}
+
+	payload := attestationPayload{
+		claims:      newClaims(Context{IssuedAt: att.IssuedAt, ExpiresAt: att.ExpiresAt}),
+		Attester:    att.Attester,
+		Issuer:      att.Issuer,
+		Key:         b64.EncodeToString(bbs.SerializePublicKey(att.PublicKey)),
+		Fingerprint: att.PublicKey.Fingerprint(),
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode key attestation: %w", err)
+	}
+	return sign(data, TypeKeyAttestation, opts.KeyID, attesterKey, false)
+}
+
+// DecodeKeyAttestation checks a key attestation and returns it. Unlike the
+// other envelopes the signer must be known: opts.ExpectedKey is required and
+// is the PKIX DER key of the trusted attester.
+//
+// Experimental: key attestations may change in a minor release.
+func DecodeKeyAttestation(token string, opts DecodeOptions) (*KeyAttestation, error) {
+	if opts.ExpectedKey == nil {
+		return nil, ErrUntrustedAttestation
+	}
+	data, attesterKey, err := verify(token, nil, TypeKeyAttestation, opts.ExpectedKey)
+	if err != nil {
+		return nil, err
+	}
+
+	var payload attestationPayload
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return nil, fmt.Errorf("%w: payload: %v", ErrInvalidJWS, err)
+	}
+	if err := opts.check(payload.claims); err != nil {
+		return nil, err
+	}
+
+	keyBytes, err := b64.DecodeString(payload.Key)
+	if err != nil {
+		return nil, fmt.Errorf("%w: key: %v", ErrInvalidJWS, err)
+	}
+	publicKey, err := bbs.DeserializePublicKey(keyBytes)
+	if err != nil {
+		return nil, fmt.Errorf("%w: key: %v", ErrInvalidJWS, err)
+	}
+	if publicKey.Fingerprint() != payload.Fingerprint {
+		return nil, fmt.Errorf("%w: key does not match its fingerprint", ErrInvalidJWS)
+	}
+
+	ctx := payload.claims.context()
+	return &KeyAttestation{
+		Issuer:      payload.Issuer,
+		Attester:    payload.Attester,
+		PublicKey:   publicKey,
+		IssuedAt:    ctx.IssuedAt,
+		ExpiresAt:   ctx.ExpiresAt,
+		AttesterKey: attesterKey,
+	}, nil
+}
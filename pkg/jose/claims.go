@@ -0,0 +1,116 @@
+package jose
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// DefaultLeeway is the clock skew tolerated on iat and exp when
+// DecodeOptions.Leeway is not set
+const DefaultLeeway = time.Minute
+
+// ErrInvalidClaims is returned when the aud, nonce, iat or exp claim of an
+// envelope does not meet the DecodeOptions
+var ErrInvalidClaims = errors.New("invalid JWT claims")
+
+// claims are the registered JWT claims describing a presentation context
+type claims struct {
+	Audience  string `json:"aud,omitempty"`
+	Nonce     string `json:"nonce,omitempty"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp,omitempty"`
+}
+
+// newClaims builds claims from a context, defaulting iat to now
+func newClaims(ctx Context) claims {
+	c := claims{
+		Audience: ctx.Audience,
+		Nonce:    ctx.Nonce,
+		IssuedAt: ctx.IssuedAt.Unix(),
+	}
+	if ctx.IssuedAt.IsZero() {
+		c.IssuedAt = time.Now().Unix()
+	}
+	if !ctx.ExpiresAt.IsZero() {
+		c.ExpiresAt = ctx.ExpiresAt.Unix()
+	}
+	return c
+}
+
+// context converts claims back to a Context
+func (c claims) context() Context {
+	ctx := Context{
+		Audience: c.Audience,
+		Nonce:    c.Nonce,
+		IssuedAt: time.Unix(c.IssuedAt, 0),
+	}
+	if c.ExpiresAt != 0 {
+		ctx.ExpiresAt = time.Unix(c.ExpiresAt, 0)
+	}
+	return ctx
+}
+
+// Context is the presentation context carried in the standard claims
+type Context struct {
+	// Audience is the relying party the envelope is meant for (aud)
+	Audience string
+
+	// Nonce is the relying party's challenge (nonce)
+	Nonce string
+
+	// IssuedAt is when the envelope was created (iat). Zero means now when
+	// encoding.
+	IssuedAt time.Time
+
+	// ExpiresAt is when the envelope stops being valid (exp), zero for none
+	ExpiresAt time.Time
+}
+
+// DecodeOptions are the checks applied when parsing an envelope
+type DecodeOptions struct {
+	// ExpectedKey, if set, is the PKIX DER public key the envelope must be
+	// signed with. Otherwise any key in the header is accepted and returned.
+	ExpectedKey []byte
+
+	// Audience, if set, must equal the aud claim
+	Audience string
+
+	// Nonce, if set, must equal the nonce claim
+	Nonce string
+
+	// Leeway is the clock skew tolerated on iat and exp. Zero or negative
+	// uses DefaultLeeway.
+	Leeway time.Duration
+
+	// Now returns the current time; nil uses time.Now
+	Now func() time.Time
+}
+
+// check applies the options to decoded claims
+func (o DecodeOptions) check(c claims) error {
+	if o.Audience != "" && c.Audience != o.Audience {
+		return fmt.Errorf("%w: aud %q, want %q", ErrInvalidClaims, c.Audience, o.Audience)
+	}
+	if o.Nonce != "" && c.Nonce != o.Nonce {
+		return fmt.Errorf("%w: nonce mismatch", ErrInvalidClaims)
+	}
+
+	now := time.Now()
+	if o.Now != nil {
+		now = o.Now()
+	}
+	leeway := o.Leeway
+	if leeway <= 0 {
+		leeway = DefaultLeeway
+	}
+
+	if time.Unix(c.IssuedAt, 0).After(now.Add(leeway)) {
+		return fmt.Errorf("%w: issued in the future", ErrInvalidClaims)
+	}
+	if c.ExpiresAt != 0 && now.Add(-leeway).After(time.Unix(c.ExpiresAt, 0)) {
+		return fmt.Errorf("%w: expired", ErrInvalidClaims)
+	}
+
+	return nil
+}
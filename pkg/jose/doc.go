@@ -0,0 +1,37 @@
+// Package jose wraps BBS+ proof requests and presentations in JWS envelopes
+// for relying parties that only consume JOSE.
+//
+// A presentation is carried as the payload of a compact JWS signed by the
+// holder's device key (ES256 for P-256 keys, EdDSA for Ed25519 keys), the
+// same key used for holder binding. The standard aud, nonce, iat and exp
+// claims describe the presentation context, and the BBS+ proof, its
+// disclosed messages and header sit in a "bbs" claim. The device key travels
+// as a JWK in the protected header. The payload can be detached, leaving the
+// middle part of the token empty.
+//
+// The JWS signature alone does not stop a proof from being lifted into
+// another envelope. Holder-bound presentations close that gap: the proof is
+// bound to the device key and to PresentationNonce(aud, nonce), and
+// Presentation.Verify checks both.
+//
+// Example usage:
+//
+//	// Holder
+//	nonce := jose.PresentationNonce(req.Audience, req.Nonce)
+//	p, disclosed, err := proof.NewBuilder().
+//	    SetPublicKey(publicKey).
+//	    SetSignature(signature).
+//	    SetMessages(messages).
+//	    Disclose(req.Disclose...).
+//	    SetNonce(nonce).
+//	    SetHolderBinding(devicePub, deviceSign(proof.HolderBindingChallenge(devicePub, nonce))).
+//	    SetHolderKeyIndex(keyIndex).
+//	    Build()
+//	token, _, err := jose.EncodePresentation(&jose.Presentation{...}, deviceKey, jose.EncodeOptions{})
+//
+//	// Verifier
+//	pres, err := jose.DecodePresentation(token, nil, jose.DecodeOptions{Audience: "https://rp.example"})
+//	err = pres.Verify(publicKey)
+//
+// Stability: stable
+package jose
@@ -0,0 +1,58 @@
+package jose_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"fmt"
+	"math/big"
+
+	"github.com/anupsv/bbsplus-signatures/bbs"
+	"github.com/anupsv/bbsplus-signatures/pkg/jose"
+)
+
+func ExampleEncodePresentation() {
+	keyPair, err := bbs.GenerateKeyPair(2, rand.Reader)
+	if err != nil {
+		panic(err)
+	}
+
+	messages := []*big.Int{
+		bbs.MessageToFieldElement([]byte("name=Alice")),
+		bbs.MessageToFieldElement([]byte("country=NL")),
+	}
+	signature, err := bbs.Sign(keyPair.PrivateKey, keyPair.PublicKey, messages, nil)
+	if err != nil {
+		panic(err)
+	}
+
+	proof, disclosed, err := bbs.CreateProof(keyPair.PublicKey, signature, messages, []int{1}, nil)
+	if err != nil {
+		panic(err)
+	}
+
+	deviceKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		panic(err)
+	}
+
+	token, _, err := jose.EncodePresentation(&jose.Presentation{
+		Context:   jose.Context{Audience: "https://rp.example", Nonce: "8f1c2a"},
+		Proof:     proof,
+		Disclosed: disclosed,
+	}, deviceKey, jose.EncodeOptions{})
+	if err != nil {
+		panic(err)
+	}
+
+	pres, err := jose.DecodePresentation(token, nil, jose.DecodeOptions{
+		Audience: "https://rp.example",
+		Nonce:    "8f1c2a",
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	fmt.Println("valid:", pres.Verify(keyPair.PublicKey) == nil)
+	// Output: valid: true
+}
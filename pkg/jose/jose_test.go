@@ -0,0 +1,288 @@
+package jose
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/anupsv/bbsplus-signatures/bbs"
+)
+
+// newDeviceKey returns a device signer and its PKIX DER public key
+func newDeviceKey(t *testing.T, ed bool) (crypto.Signer, []byte) {
+	t.Helper()
+
+	var key crypto.Signer
+	var err error
+	if ed {
+		_, key, err = ed25519.GenerateKey(rand.Reader)
+	} else {
+		key, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	}
+	if err != nil {
+		t.Fatalf("failed to generate device key: %v", err)
+	}
+
+	pub, err := x509.MarshalPKIXPublicKey(key.Public())
+	if err != nil {
+		t.Fatalf("MarshalPKIXPublicKey failed: %v", err)
+	}
+	return key, pub
+}
+
+// deviceSign signs a holder binding challenge the way bbs.HolderBinding expects
+func deviceSign(t *testing.T, key crypto.Signer, challenge []byte) []byte {
+	t.Helper()
+
+	var sig []byte
+	var err error
+	if _, ok := key.(ed25519.PrivateKey); ok {
+		sig, err = key.Sign(rand.Reader, challenge, crypto.Hash(0))
+	} else {
+		digest := sha256.Sum256(challenge)
+		sig, err = key.Sign(rand.Reader, digest[:], crypto.SHA256)
+	}
+	if err != nil {
+		t.Fatalf("device signing failed: %v", err)
+	}
+	return sig
+}
+
+// holderBoundPresentation creates a presentation bound to key for ctx
+func holderBoundPresentation(t *testing.T, keyPair *bbs.KeyPair, key crypto.Signer, pub []byte, ctx Context) *Presentation {
+	t.Helper()
+
+	messages := []*big.Int{
+		bbs.MessageToFieldElement([]byte("alice")),
+		bbs.MessageToFieldElement([]byte("1990-01-01")),
+		bbs.HolderKeyMessage(pub),
+	}
+	sig, err := bbs.Sign(keyPair.PrivateKey, keyPair.PublicKey, messages, nil)
+	if err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+
+	nonce := PresentationNonce(ctx.Audience, ctx.Nonce)
+	binding := &bbs.HolderBinding{
+		PublicKey: pub,
+		Signature: deviceSign(t, key, bbs.HolderBindingChallenge(pub, nonce)),
+		KeyIndex:  2,
+	}
+	proof, disclosed, err := bbs.CreateHolderBoundProof(keyPair.PublicKey, sig, messages, []int{0}, nil, binding, nonce)
+	if err != nil {
+		t.Fatalf("CreateHolderBoundProof failed: %v", err)
+	}
+
+	return &Presentation{
+		Context:        ctx,
+		Proof:          proof,
+		Disclosed:      disclosed,
+		HolderBinding:  binding.Signature,
+		HolderKeyIndex: binding.KeyIndex,
+	}
+}
+
+func TestPresentationRoundTrip(t *testing.T) {
+	keyPair, err := bbs.GenerateKeyPair(3, rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKeyPair failed: %v", err)
+	}
+
+	for _, tc := range []struct {
+		name     string
+		ed       bool
+		detached bool
+	}{
+		{"ES256", false, false},
+		{"EdDSA", true, false},
+		{"ES256 detached", false, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			key, pub := newDeviceKey(t, tc.ed)
+			ctx := Context{Audience: "https://rp.example", Nonce: "n-0S6_WzA2Mj", ExpiresAt: time.Now().Add(time.Minute)}
+			p := holderBoundPresentation(t, keyPair, key, pub, ctx)
+
+			token, payload, err := EncodePresentation(p, key, EncodeOptions{Detached: tc.detached, KeyID: "device-1"})
+			if err != nil {
+				t.Fatalf("EncodePresentation failed: %v", err)
+			}
+			if tc.detached != (strings.Split(token, ".")[1] == "") || tc.detached != (payload != nil) {
+				t.Fatalf("detached = %v but token %q, payload %d bytes", tc.detached, token, len(payload))
+			}
+
+			opts := DecodeOptions{Audience: ctx.Audience, Nonce: ctx.Nonce, ExpectedKey: pub}
+			decoded, err := DecodePresentation(token, payload, opts)
+			if err != nil {
+				t.Fatalf("DecodePresentation failed: %v", err)
+			}
+			if err := decoded.Verify(keyPair.PublicKey); err != nil {
+				t.Fatalf("Verify failed: %v", err)
+			}
+			if decoded.Disclosed[0].Cmp(p.Disclosed[0]) != 0 || decoded.ExpiresAt.Unix() != ctx.ExpiresAt.Unix() {
+				t.Fatal("decoded presentation does not match")
+			}
+		})
+	}
+}
+
+func TestPresentationRejected(t *testing.T) {
+	keyPair, err := bbs.GenerateKeyPair(3, rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKeyPair failed: %v", err)
+	}
+
+	key, pub := newDeviceKey(t, false)
+	ctx := Context{Audience: "https://rp.example", Nonce: "abc"}
+	p := holderBoundPresentation(t, keyPair, key, pub, ctx)
+
+	token, _, err := EncodePresentation(p, key, EncodeOptions{})
+	if err != nil {
+		t.Fatalf("EncodePresentation failed: %v", err)
+	}
+
+	// Claims that do not match
+	if _, err := DecodePresentation(token, nil, DecodeOptions{Audience: "https://other.example"}); !errors.Is(err, ErrInvalidClaims) {
+		t.Fatalf("wrong aud: got %v, want ErrInvalidClaims", err)
+	}
+	if _, err := DecodePresentation(token, nil, DecodeOptions{Nonce: "xyz"}); !errors.Is(err, ErrInvalidClaims) {
+		t.Fatalf("wrong nonce: got %v, want ErrInvalidClaims", err)
+	}
+	late := func() time.Time { return time.Now().Add(time.Hour) }
+	expiring := *p
+	expiring.ExpiresAt = time.Now().Add(time.Minute)
+	expiringToken, _, err := EncodePresentation(&expiring, key, EncodeOptions{})
+	if err != nil {
+		t.Fatalf("EncodePresentation failed: %v", err)
+	}
+	if _, err := DecodePresentation(expiringToken, nil, DecodeOptions{Now: late}); !errors.Is(err, ErrInvalidClaims) {
+		t.Fatalf("expired: got %v, want ErrInvalidClaims", err)
+	}
+
+	// A tampered payload or another expected key
+	parts := strings.Split(token, ".")
+	tampered := parts[0] + "." + parts[1][:len(parts[1])-2] + "AA." + parts[2]
+	if _, err := DecodePresentation(tampered, nil, DecodeOptions{}); !errors.Is(err, ErrInvalidJWS) {
+		t.Fatalf("tampered: got %v, want ErrInvalidJWS", err)
+	}
+	_, otherPub := newDeviceKey(t, true)
+	if _, err := DecodePresentation(token, nil, DecodeOptions{ExpectedKey: otherPub}); !errors.Is(err, ErrInvalidJWS) {
+		t.Fatalf("unexpected key: got %v, want ErrInvalidJWS", err)
+	}
+
+	// The holder key index travels with the holder binding
+	var payload map[string]any
+	raw, _ := b64.DecodeString(parts[1])
+	_ = json.Unmarshal(raw, &payload)
+	delete(payload["bbs"].(map[string]any), "holderKeyIndex")
+	raw, _ = json.Marshal(payload)
+	unindexed, err := sign(raw, TypePresentation, "", key, false)
+	if err != nil {
+		t.Fatalf("sign failed: %v", err)
+	}
+	if _, err := DecodePresentation(unindexed, nil, DecodeOptions{}); !errors.Is(err, ErrInvalidJWS) {
+		t.Fatalf("no holder key index: got %v, want ErrInvalidJWS", err)
+	}
+
+	// A proof lifted into an envelope signed by another key, or with other
+	// claims, fails the holder binding
+	otherKey, _ := newDeviceKey(t, false)
+	liftedToken, _, err := EncodePresentation(p, otherKey, EncodeOptions{})
+	if err != nil {
+		t.Fatalf("EncodePresentation failed: %v", err)
+	}
+	lifted, err := DecodePresentation(liftedToken, nil, DecodeOptions{})
+	if err != nil {
+		t.Fatalf("DecodePresentation failed: %v", err)
+	}
+	if err := lifted.Verify(keyPair.PublicKey); err == nil {
+		t.Fatal("proof verified in an envelope signed by another key")
+	}
+
+	moved := *p
+	moved.Audience = "https://other.example"
+	movedToken, _, err := EncodePresentation(&moved, key, EncodeOptions{})
+	if err != nil {
+		t.Fatalf("EncodePresentation failed: %v", err)
+	}
+	decoded, err := DecodePresentation(movedToken, nil, DecodeOptions{})
+	if err != nil {
+		t.Fatalf("DecodePresentation failed: %v", err)
+	}
+	if err := decoded.Verify(keyPair.PublicKey); err == nil {
+		t.Fatal("proof verified with another audience")
+	}
+}
+
+func TestProofRequestRoundTrip(t *testing.T) {
+	key, pub := newDeviceKey(t, true)
+	req := &ProofRequest{
+		Context:       Context{Audience: "https://rp.example", Nonce: "abc"},
+		Disclose:      []int{0, 3},
+		Header:        []byte("credential-v1"),
+		HolderBinding: true,
+	}
+
+	token, _, err := EncodeProofRequest(req, key, EncodeOptions{})
+	if err != nil {
+		t.Fatalf("EncodeProofRequest failed: %v", err)
+	}
+
+	decoded, err := DecodeProofRequest(token, nil, DecodeOptions{ExpectedKey: pub})
+	if err != nil {
+		t.Fatalf("DecodeProofRequest failed: %v", err)
+	}
+	if decoded.Nonce != "abc" || len(decoded.Disclose) != 2 || decoded.Disclose[1] != 3 ||
+		string(decoded.Header) != "credential-v1" || !decoded.HolderBinding {
+		t.Fatalf("decoded request does not match: %+v", decoded)
+	}
+
+	// A presentation token is not a proof request
+	if _, err := DecodePresentation(token, nil, DecodeOptions{}); !errors.Is(err, ErrInvalidJWS) {
+		t.Fatalf("request decoded as presentation: %v", err)
+	}
+}
+
+func TestKeyAttestationRoundTrip(t *testing.T) {
+	keyPair, err := bbs.GenerateKeyPair(3, rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key pair: %v", err)
+	}
+	key, pub := newDeviceKey(t, true)
+
+	token, err := EncodeKeyAttestation(&KeyAttestation{
+		Issuer:    "https://issuer.example",
+		Attester:  "https://ca.example",
+		PublicKey: keyPair.PublicKey,
+	}, key, EncodeOptions{KeyID: "ca-2026"})
+	if err != nil {
+		t.Fatalf("EncodeKeyAttestation failed: %v", err)
+	}
+
+	att, err := DecodeKeyAttestation(token, DecodeOptions{ExpectedKey: pub})
+	if err != nil {
+		t.Fatalf("DecodeKeyAttestation failed: %v", err)
+	}
+	if att.Issuer != "https://issuer.example" || att.Attester != "https://ca.example" ||
+		att.PublicKey.Fingerprint() != keyPair.PublicKey.Fingerprint() {
+		t.Fatalf("decoded attestation does not match: %+v", att)
+	}
+
+	// The attester must be named
+	if _, err := DecodeKeyAttestation(token, DecodeOptions{}); !errors.Is(err, ErrUntrustedAttestation) {
+		t.Fatalf("Expected ErrUntrustedAttestation, got %v", err)
+	}
+	_, otherPub := newDeviceKey(t, false)
+	if _, err := DecodeKeyAttestation(token, DecodeOptions{ExpectedKey: otherPub}); !errors.Is(err, ErrInvalidJWS) {
+		t.Fatalf("Attestation decoded under another key: %v", err)
+	}
+}
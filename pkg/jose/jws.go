@@ -0,0 +1,234 @@
+package jose
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// JWS algorithms
+const (
+	AlgES256 = "ES256"
+	AlgEdDSA = "EdDSA"
+)
+
+// ErrInvalidJWS is returned when an envelope is malformed or its signature
+// does not verify
+var ErrInvalidJWS = errors.New("invalid JWS")
+
+// b64 is the base64url encoding without padding used throughout JOSE
+var b64 = base64.RawURLEncoding
+
+// protectedHeader is the JWS protected header
+type protectedHeader struct {
+	Alg string `json:"alg"`
+	Typ string `json:"typ,omitempty"`
+	Kid string `json:"kid,omitempty"`
+	JWK *jwk   `json:"jwk"`
+}
+
+// jwk is a public JSON Web Key for a P-256 or Ed25519 key
+type jwk struct {
+	Kty string `json:"kty"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y,omitempty"`
+}
+
+// sign produces a compact JWS over payload. With detached set the payload
+// part of the token is left empty.
+func sign(payload []byte, typ, kid string, key crypto.Signer, detached bool) (string, error) {
+	alg, pub, err := keyAlgorithm(key.Public())
+	if err != nil {
+		return "", err
+	}
+
+	hdr, err := json.Marshal(protectedHeader{Alg: alg, Typ: typ, Kid: kid, JWK: pub})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode JWS header: %w", err)
+	}
+
+	signingInput := b64.EncodeToString(hdr) + "." + b64.EncodeToString(payload)
+
+	var sig []byte
+	switch alg {
+	case AlgES256:
+		digest := sha256.Sum256([]byte(signingInput))
+		der, err := key.Sign(rand.Reader, digest[:], crypto.SHA256)
+		if err != nil {
+			return "", fmt.Errorf("failed to sign JWS: %w", err)
+		}
+		if sig, err = ecdsaRaw(der); err != nil {
+			return "", err
+		}
+	case AlgEdDSA:
+		if sig, err = key.Sign(rand.Reader, []byte(signingInput), crypto.Hash(0)); err != nil {
+			return "", fmt.Errorf("failed to sign JWS: %w", err)
+		}
+	}
+
+	parts := strings.SplitN(signingInput, ".", 2)
+	if detached {
+		parts[1] = ""
+	}
+	return parts[0] + "." + parts[1] + "." + b64.EncodeToString(sig), nil
+}
+
+// verify checks a compact JWS and returns its payload and signing key as
+// PKIX DER. detachedPayload is used when the token has no payload part. If
+// expectedKey is set the header key must equal it.
+func verify(token string, detachedPayload []byte, typ string, expectedKey []byte) ([]byte, []byte, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, nil, fmt.Errorf("%w: expected 3 parts, got %d", ErrInvalidJWS, len(parts))
+	}
+
+	hdrBytes, err := b64.DecodeString(parts[0])
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: header: %v", ErrInvalidJWS, err)
+	}
+	var hdr protectedHeader
+	if err := json.Unmarshal(hdrBytes, &hdr); err != nil {
+		return nil, nil, fmt.Errorf("%w: header: %v", ErrInvalidJWS, err)
+	}
+	if hdr.Typ != typ {
+		return nil, nil, fmt.Errorf("%w: unexpected typ %q", ErrInvalidJWS, hdr.Typ)
+	}
+	if hdr.JWK == nil {
+		return nil, nil, fmt.Errorf("%w: missing jwk", ErrInvalidJWS)
+	}
+
+	pub, err := hdr.JWK.publicKey()
+	if err != nil {
+		return nil, nil, err
+	}
+	alg, _, err := keyAlgorithm(pub)
+	if err != nil {
+		return nil, nil, err
+	}
+	if hdr.Alg != alg {
+		return nil, nil, fmt.Errorf("%w: alg %q does not match %s key", ErrInvalidJWS, hdr.Alg, alg)
+	}
+
+	pkix, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrInvalidJWS, err)
+	}
+	if expectedKey != nil && !bytes.Equal(pkix, expectedKey) {
+		return nil, nil, fmt.Errorf("%w: signed by an unexpected key", ErrInvalidJWS)
+	}
+
+	payloadPart := parts[1]
+	if payloadPart == "" {
+		if detachedPayload == nil {
+			return nil, nil, fmt.Errorf("%w: payload is detached but none was given", ErrInvalidJWS)
+		}
+		payloadPart = b64.EncodeToString(detachedPayload)
+	} else if detachedPayload != nil {
+		return nil, nil, fmt.Errorf("%w: payload given for a token with an attached payload", ErrInvalidJWS)
+	}
+
+	sig, err := b64.DecodeString(parts[2])
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: signature: %v", ErrInvalidJWS, err)
+	}
+
+	signingInput := []byte(parts[0] + "." + payloadPart)
+	switch k := pub.(type) {
+	case *ecdsa.PublicKey:
+		if len(sig) != 64 {
+			return nil, nil, fmt.Errorf("%w: bad ES256 signature length", ErrInvalidJWS)
+		}
+		digest := sha256.Sum256(signingInput)
+		r := new(big.Int).SetBytes(sig[:32])
+		s := new(big.Int).SetBytes(sig[32:])
+		if !ecdsa.Verify(k, digest[:], r, s) {
+			return nil, nil, fmt.Errorf("%w: signature does not verify", ErrInvalidJWS)
+		}
+	case ed25519.PublicKey:
+		if !ed25519.Verify(k, signingInput, sig) {
+			return nil, nil, fmt.Errorf("%w: signature does not verify", ErrInvalidJWS)
+		}
+	}
+
+	payload, err := b64.DecodeString(payloadPart)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: payload: %v", ErrInvalidJWS, err)
+	}
+	return payload, pkix, nil
+}
+
+// keyAlgorithm returns the JWS algorithm and JWK for a supported public key
+func keyAlgorithm(pub crypto.PublicKey) (string, *jwk, error) {
+	switch k := pub.(type) {
+	case *ecdsa.PublicKey:
+		if k.Curve != elliptic.P256() {
+			return "", nil, fmt.Errorf("unsupported ECDSA curve %s", k.Curve.Params().Name)
+		}
+		var x, y [32]byte
+		k.X.FillBytes(x[:])
+		k.Y.FillBytes(y[:])
+		return AlgES256, &jwk{Kty: "EC", Crv: "P-256", X: b64.EncodeToString(x[:]), Y: b64.EncodeToString(y[:])}, nil
+	case ed25519.PublicKey:
+		return AlgEdDSA, &jwk{Kty: "OKP", Crv: "Ed25519", X: b64.EncodeToString(k)}, nil
+	default:
+		return "", nil, fmt.Errorf("unsupported key type %T", pub)
+	}
+}
+
+// publicKey parses a JWK, checking that EC points are on the curve
+func (j *jwk) publicKey() (crypto.PublicKey, error) {
+	x, err := b64.DecodeString(j.X)
+	if err != nil {
+		return nil, fmt.Errorf("%w: jwk x: %v", ErrInvalidJWS, err)
+	}
+
+	switch {
+	case j.Kty == "EC" && j.Crv == "P-256":
+		y, err := b64.DecodeString(j.Y)
+		if err != nil || len(x) != 32 || len(y) != 32 {
+			return nil, fmt.Errorf("%w: bad P-256 jwk", ErrInvalidJWS)
+		}
+		point := append(append([]byte{4}, x...), y...)
+		if _, err := ecdh.P256().NewPublicKey(point); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidJWS, err)
+		}
+		return &ecdsa.PublicKey{
+			Curve: elliptic.P256(),
+			X:     new(big.Int).SetBytes(x),
+			Y:     new(big.Int).SetBytes(y),
+		}, nil
+	case j.Kty == "OKP" && j.Crv == "Ed25519":
+		if len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("%w: bad Ed25519 jwk", ErrInvalidJWS)
+		}
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, fmt.Errorf("%w: unsupported jwk %s/%s", ErrInvalidJWS, j.Kty, j.Crv)
+	}
+}
+
+// ecdsaRaw converts an ASN.1 ECDSA signature to the 64-byte r||s form JWS uses
+func ecdsaRaw(der []byte) ([]byte, error) {
+	var sig struct{ R, S *big.Int }
+	if _, err := asn1.Unmarshal(der, &sig); err != nil {
+		return nil, fmt.Errorf("failed to parse ECDSA signature: %w", err)
+	}
+	raw := make([]byte, 64)
+	sig.R.FillBytes(raw[:32])
+	sig.S.FillBytes(raw[32:])
+	return raw, nil
+}
@@ -0,0 +1,219 @@
+package jose
+
+import (
+	"crypto"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+
+	"github.com/anupsv/bbsplus-signatures/bbs"
+)
+
+// Token types of the envelopes
+const (
+	TypePresentation = "bbs-presentation+jwt"
+	TypeProofRequest = "bbs-proof-request+jwt"
+)
+
+// presentationNonceDST separates presentation nonces from other uses of the
+// aud and nonce claims
+const presentationNonceDST = "BBS_BLS12381_JOSE_PRESENTATION_NONCE_"
+
+// Presentation is a BBS+ proof with the context it is presented in
+type Presentation struct {
+	Context
+
+	// Proof is the selective disclosure proof
+	Proof *bbs.ProofOfKnowledge
+
+	// Disclosed holds the disclosed messages by index
+	Disclosed map[int]*big.Int
+
+	// Header is the header the credential was signed with
+	Header []byte
+
+	// HolderBinding is the device signature over the holder binding
+	// challenge, empty if the proof is not holder bound
+	HolderBinding []byte
+
+	// HolderKeyIndex is the index of the hidden message carrying
+	// bbs.HolderKeyMessage of the device key, for holder-bound proofs
+	HolderKeyIndex int
+
+	// DeviceKey is the PKIX DER key the envelope was signed with. It is set
+	// when decoding and ignored when encoding.
+	DeviceKey []byte
+}
+
+// presentationPayload is the JWT payload of a presentation
+type presentationPayload struct {
+	claims
+	BBS presentationClaim `json:"bbs"`
+}
+
+// presentationClaim holds the BBS+ part of a presentation
+type presentationClaim struct {
+	Proof          string            `json:"proof"`
+	Disclosed      map[string]string `json:"disclosed"`
+	Header         string            `json:"header,omitempty"`
+	HolderBinding  string            `json:"holderBinding,omitempty"`
+	HolderKeyIndex *int              `json:"holderKeyIndex,omitempty"`
+}
+
+// EncodeOptions tune how an envelope is produced
+type EncodeOptions struct {
+	// Detached leaves the payload out of the token. It is returned
+	// separately and must be passed to the decoder.
+	Detached bool
+
+	// KeyID is set as the kid header parameter
+	KeyID string
+}
+
+// PresentationNonce returns the holder binding nonce for a presentation to
+// audience with the relying party's nonce. Binding the proof to it stops
+// the proof from being moved into an envelope with other claims.
+func PresentationNonce(audience, nonce string) []byte {
+	var buff []byte
+	buff = append(buff, presentationNonceDST...)
+	buff = binary.BigEndian.AppendUint32(buff, uint32(len(audience)))
+	buff = append(buff, audience...)
+	buff = binary.BigEndian.AppendUint32(buff, uint32(len(nonce)))
+	buff = append(buff, nonce...)
+
+	digest := sha256.Sum256(buff)
+	return digest[:]
+}
+
+// EncodePresentation signs p with the holder's device key and returns the
+// compact JWS. With opts.Detached the payload is returned separately and the
+// token carries none.
+func EncodePresentation(p *Presentation, deviceKey crypto.Signer, opts EncodeOptions) (string, []byte, error) {
+	if p == nil || p.Proof == nil {
+		return "", nil, fmt.Errorf("presentation has no proof")
+	}
+
+	payload := presentationPayload{
+		claims: newClaims(p.Context),
+		BBS: presentationClaim{
+			Proof:         b64.EncodeToString(bbs.SerializeProof(p.Proof)),
+			Disclosed:     make(map[string]string, len(p.Disclosed)),
+			Header:        b64.EncodeToString(p.Header),
+			HolderBinding: b64.EncodeToString(p.HolderBinding),
+		},
+	}
+	for idx, msg := range p.Disclosed {
+		payload.BBS.Disclosed[strconv.Itoa(idx)] = msg.String()
+	}
+	if len(p.HolderBinding) > 0 {
+		payload.BBS.HolderKeyIndex = &p.HolderKeyIndex
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to encode presentation: %w", err)
+	}
+
+	token, err := sign(data, TypePresentation, opts.KeyID, deviceKey, opts.Detached)
+	if err != nil {
+		return "", nil, err
+	}
+	if !opts.Detached {
+		data = nil
+	}
+	return token, data, nil
+}
+
+// DecodePresentation checks the JWS signature and claims of a presentation
+// envelope and returns its contents. detachedPayload is required for tokens
+// encoded with EncodeOptions.Detached and must be nil otherwise. The BBS+
+// proof itself is not verified; call Verify.
+func DecodePresentation(token string, detachedPayload []byte, opts DecodeOptions) (*Presentation, error) {
+	data, deviceKey, err := verify(token, detachedPayload, TypePresentation, opts.ExpectedKey)
+	if err != nil {
+		return nil, err
+	}
+
+	var payload presentationPayload
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return nil, fmt.Errorf("%w: payload: %v", ErrInvalidJWS, err)
+	}
+	if err := opts.check(payload.claims); err != nil {
+		return nil, err
+	}
+
+	proofBytes, err := b64.DecodeString(payload.BBS.Proof)
+	if err != nil {
+		return nil, fmt.Errorf("%w: proof: %v", ErrInvalidJWS, err)
+	}
+	proof, err := bbs.DeserializeProof(proofBytes)
+	if err != nil {
+		return nil, fmt.Errorf("%w: proof: %v", ErrInvalidJWS, err)
+	}
+
+	disclosed := make(map[int]*big.Int, len(payload.BBS.Disclosed))
+	for key, value := range payload.BBS.Disclosed {
+		idx, err := strconv.Atoi(key)
+		if err != nil || idx < 0 {
+			return nil, fmt.Errorf("%w: disclosed index %q", ErrInvalidJWS, key)
+		}
+		msg, ok := new(big.Int).SetString(value, 10)
+		if !ok {
+			return nil, fmt.Errorf("%w: disclosed message %d", ErrInvalidJWS, idx)
+		}
+		disclosed[idx] = msg
+	}
+
+	header, err := b64.DecodeString(payload.BBS.Header)
+	if err != nil {
+		return nil, fmt.Errorf("%w: header: %v", ErrInvalidJWS, err)
+	}
+	holderBinding, err := b64.DecodeString(payload.BBS.HolderBinding)
+	if err != nil {
+		return nil, fmt.Errorf("%w: holder binding: %v", ErrInvalidJWS, err)
+	}
+	if len(header) == 0 {
+		header = nil
+	}
+	if len(holderBinding) == 0 {
+		holderBinding = nil
+	}
+	var holderKeyIndex int
+	if holderBinding != nil {
+		if payload.BBS.HolderKeyIndex == nil || *payload.BBS.HolderKeyIndex < 0 {
+			return nil, fmt.Errorf("%w: holder binding without a holder key index", ErrInvalidJWS)
+		}
+		holderKeyIndex = *payload.BBS.HolderKeyIndex
+	}
+
+	return &Presentation{
+		Context:        payload.claims.context(),
+		Proof:          proof,
+		Disclosed:      disclosed,
+		Header:         header,
+		HolderBinding:  holderBinding,
+		HolderKeyIndex: holderKeyIndex,
+		DeviceKey:      deviceKey,
+	}, nil
+}
+
+// Verify verifies the BBS+ proof of a decoded presentation. A holder-bound
+// proof must be bound to the key that signed the envelope and to
+// PresentationNonce of its aud and nonce claims.
+func (p *Presentation) Verify(publicKey *bbs.PublicKey) error {
+	if p.HolderBinding == nil {
+		return bbs.VerifyProof(publicKey, p.Proof, p.Disclosed, p.Header)
+	}
+
+	binding := &bbs.HolderBinding{
+		PublicKey: p.DeviceKey,
+		Signature: p.HolderBinding,
+		KeyIndex:  p.HolderKeyIndex,
+	}
+	return bbs.VerifyHolderBoundProof(
+		publicKey, p.Proof, p.Disclosed, p.Header, binding, PresentationNonce(p.Audience, p.Nonce),
+	)
+}
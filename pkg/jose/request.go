@@ -0,0 +1,104 @@
+package jose
+
+import (
+	"crypto"
+	"encoding/json"
+	"fmt"
+)
+
+// ProofRequest is what a relying party asks a holder to present
+type ProofRequest struct {
+	Context
+
+	// Disclose lists the message indices to disclose
+	Disclose []int
+
+	// Header is the header the credential is expected to be signed with
+	Header []byte
+
+	// HolderBinding asks for a proof bound to the holder's device key
+	HolderBinding bool
+
+	// VerifierKey is the PKIX DER key the request was signed with. It is set
+	// when decoding and ignored when encoding.
+	VerifierKey []byte
+}
+
+// requestPayload is the JWT payload of a proof request
+type requestPayload struct {
+	claims
+	Disclose      []int  `json:"disclose"`
+	Header        string `json:"header,omitempty"`
+	HolderBinding bool   `json:"holderBinding,omitempty"`
+}
+
+// EncodeProofRequest signs a proof request with the relying party's key
+func EncodeProofRequest(req *ProofRequest, verifierKey crypto.Signer, opts EncodeOptions) (string, []byte, error) {
+	if req == nil {
+		return "", nil, fmt.Errorf("proof request is nil")
+	}
+
+	payload := requestPayload{
+		claims:        newClaims(req.Context),
+		Disclose:      req.Disclose,
+		Header:        b64.EncodeToString(req.Header),
+		HolderBinding: req.HolderBinding,
+	}
+	if payload.Disclose == nil {
+		payload.Disclose = []int{}
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to encode proof request: %w", err)
+	}
+
+	token, err := sign(data, TypeProofRequest, opts.KeyID, verifierKey, opts.Detached)
+	if err != nil {
+		return "", nil, err
+	}
+	if !opts.Detached {
+		data = nil
+	}
+	return token, data, nil
+}
+
+// DecodeProofRequest checks the JWS signature and claims of a proof request
+// and returns it. Holders should set opts.ExpectedKey to the relying party's
+// key when they know it.
+func DecodeProofRequest(token string, detachedPayload []byte, opts DecodeOptions) (*ProofRequest, error) {
+	data, verifierKey, err := verify(token, detachedPayload, TypeProofRequest, opts.ExpectedKey)
+	if err != nil {
+		return nil, err
+	}
+
+	var payload requestPayload
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return nil, fmt.Errorf("%w: payload: %v", ErrInvalidJWS, err)
+	}
+	if err := opts.check(payload.claims); err != nil {
+		return nil, err
+	}
+
+	header, err := b64.DecodeString(payload.Header)
+	if err != nil {
+		return nil, fmt.Errorf("%w: header: %v", ErrInvalidJWS, err)
+	}
+	if len(header) == 0 {
+		header = nil
+	}
+
+	for _, idx := range payload.Disclose {
+		if idx < 0 {
+			return nil, fmt.Errorf("%w: disclosed index %d", ErrInvalidJWS, idx)
+		}
+	}
+
+	return &ProofRequest{
+		Context:       payload.claims.context(),
+		Disclose:      payload.Disclose,
+		Header:        header,
+		HolderBinding: payload.HolderBinding,
+		VerifierKey:   verifierKey,
+	}, nil
+}
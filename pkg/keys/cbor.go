@@ -0,0 +1,240 @@
+package keys
+
+import (
+	"encoding/binary"
+	"fmt"
+	"time"
+)
+
+// This file holds a minimal CBOR (RFC 8949) codec for the KeyFile envelope.
+// Only the major types the envelope needs are supported: unsigned integers,
+// byte strings, text strings and maps with text keys.
+
+const (
+	cborUint  = 0
+	cborBytes = 2
+	cborText  = 3
+	cborMap   = 5
+)
+
+// appendCBORHead appends the initial byte and argument of a data item
+func appendCBORHead(buf []byte, major byte, n uint64) []byte {
+	switch {
+	case n < 24:
+		return append(buf, major<<5|byte(n))
+	case n <= 0xff:
+		return append(buf, major<<5|24, byte(n))
+	case n <= 0xffff:
+		return binary.BigEndian.AppendUint16(append(buf, major<<5|25), uint16(n))
+	case n <= 0xffffffff:
+		return binary.BigEndian.AppendUint32(append(buf, major<<5|26), uint32(n))
+	default:
+		return binary.BigEndian.AppendUint64(append(buf, major<<5|27), n)
+	}
+}
+
+func appendCBORText(buf []byte, s string) []byte {
+	return append(appendCBORHead(buf, cborText, uint64(len(s))), s...)
+}
+
+func appendCBORBytes(buf []byte, b []byte) []byte {
+	return append(appendCBORHead(buf, cborBytes, uint64(len(b))), b...)
+}
+
+// marshalCBOR encodes a key file using the same field names as the JSON form
+func marshalCBOR(kf *KeyFile) []byte {
+	fields := 4
+	if len(kf.PrivateKey) > 0 {
+		fields++
+	}
+
+	var buf []byte
+	buf = appendCBORHead(buf, cborMap, uint64(fields))
+
+	buf = appendCBORText(buf, "version")
+	buf = appendCBORHead(buf, cborUint, uint64(kf.Version))
+
+	buf = appendCBORText(buf, "metadata")
+	buf = appendCBORHead(buf, cborMap, 4)
+	buf = appendCBORText(buf, "created")
+	buf = appendCBORText(buf, kf.Metadata.Created.UTC().Format(time.RFC3339Nano))
+	buf = appendCBORText(buf, "usage")
+	buf = appendCBORText(buf, kf.Metadata.Usage)
+	buf = appendCBORText(buf, "ciphersuite")
+	buf = appendCBORText(buf, kf.Metadata.Ciphersuite)
+	buf = appendCBORText(buf, "messageCount")
+	buf = appendCBORHead(buf, cborUint, uint64(kf.Metadata.MessageCount))
+
+	if len(kf.PrivateKey) > 0 {
+		buf = appendCBORText(buf, "privateKey")
+		buf = appendCBORBytes(buf, kf.PrivateKey)
+	}
+
+	buf = appendCBORText(buf, "publicKey")
+	buf = appendCBORBytes(buf, kf.PublicKey)
+
+	buf = appendCBORText(buf, "mac")
+	buf = appendCBORBytes(buf, kf.MAC)
+
+	return buf
+}
+
+// cborDecoder reads data items from a byte slice
+type cborDecoder struct {
+	data []byte
+	pos  int
+}
+
+// head reads the initial byte and argument of the next data item
+func (d *cborDecoder) head() (byte, uint64, error) {
+	if d.pos >= len(d.data) {
+		return 0, 0, fmt.Errorf("%w: unexpected end of CBOR data", ErrInvalidKeyFile)
+	}
+
+	initial := d.data[d.pos]
+	d.pos++
+	major, info := initial>>5, initial&0x1f
+
+	var size int
+	switch {
+	case info < 24:
+		return major, uint64(info), nil
+	case info == 24:
+		size = 1
+	case info == 25:
+		size = 2
+	case info == 26:
+		size = 4
+	case info == 27:
+		size = 8
+	default:
+		return 0, 0, fmt.Errorf("%w: unsupported CBOR argument %d", ErrInvalidKeyFile, info)
+	}
+
+	if len(d.data)-d.pos < size {
+		return 0, 0, fmt.Errorf("%w: unexpected end of CBOR data", ErrInvalidKeyFile)
+	}
+
+	var n uint64
+	for _, b := range d.data[d.pos : d.pos+size] {
+		n = n<<8 | uint64(b)
+	}
+	d.pos += size
+
+	return major, n, nil
+}
+
+// expect reads a data item head and checks its major type
+func (d *cborDecoder) expect(major byte) (uint64, error) {
+	got, n, err := d.head()
+	if err != nil {
+		return 0, err
+	}
+	if got != major {
+		return 0, fmt.Errorf("%w: unexpected CBOR major type %d", ErrInvalidKeyFile, got)
+	}
+	return n, nil
+}
+
+// bytes reads the payload of a byte or text string
+func (d *cborDecoder) bytes(major byte) ([]byte, error) {
+	n, err := d.expect(major)
+	if err != nil {
+		return nil, err
+	}
+	if n > uint64(len(d.data)-d.pos) {
+		return nil, fmt.Errorf("%w: CBOR string exceeds input", ErrInvalidKeyFile)
+	}
+	out := append([]byte{}, d.data[d.pos:d.pos+int(n)]...)
+	d.pos += int(n)
+	return out, nil
+}
+
+func (d *cborDecoder) text() (string, error) {
+	b, err := d.bytes(cborText)
+	return string(b), err
+}
+
+func (d *cborDecoder) int() (int, error) {
+	n, err := d.expect(cborUint)
+	if err != nil {
+		return 0, err
+	}
+	if n > 1<<31-1 {
+		return 0, fmt.Errorf("%w: CBOR integer out of range", ErrInvalidKeyFile)
+	}
+	return int(n), nil
+}
+
+// fields iterates over a map with text keys, calling fn for each key
+func (d *cborDecoder) fields(fn func(key string) error) error {
+	n, err := d.expect(cborMap)
+	if err != nil {
+		return err
+	}
+	if n > uint64(len(d.data)-d.pos) {
+		return fmt.Errorf("%w: CBOR map exceeds input", ErrInvalidKeyFile)
+	}
+
+	for i := uint64(0); i < n; i++ {
+		key, err := d.text()
+		if err != nil {
+			return err
+		}
+		if err := fn(key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// unmarshalCBOR decodes a key file produced by marshalCBOR
+func unmarshalCBOR(data []byte, kf *KeyFile) error {
+	d := &cborDecoder{data: data}
+
+	err := d.fields(func(key string) error {
+		var err error
+		switch key {
+		case "version":
+			kf.Version, err = d.int()
+		case "metadata":
+			err = d.fields(func(key string) error {
+				var err error
+				switch key {
+				case "created":
+					var created string
+					if created, err = d.text(); err == nil {
+						kf.Metadata.Created, err = time.Parse(time.RFC3339Nano, created)
+					}
+				case "usage":
+					kf.Metadata.Usage, err = d.text()
+				case "ciphersuite":
+					kf.Metadata.Ciphersuite, err = d.text()
+				case "messageCount":
+					kf.Metadata.MessageCount, err = d.int()
+				default:
+					err = fmt.Errorf("%w: unknown metadata field %q", ErrInvalidKeyFile, key)
+				}
+				return err
+			})
+		case "privateKey":
+			kf.PrivateKey, err = d.bytes(cborBytes)
+		case "publicKey":
+			kf.PublicKey, err = d.bytes(cborBytes)
+		case "mac":
+			kf.MAC, err = d.bytes(cborBytes)
+		default:
+			err = fmt.Errorf("%w: unknown field %q", ErrInvalidKeyFile, key)
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	if d.pos != len(d.data) {
+		return fmt.Errorf("%w: trailing data after CBOR key file", ErrInvalidKeyFile)
+	}
+
+	return nil
+}
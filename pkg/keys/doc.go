@@ -0,0 +1,22 @@
+// Package keys provides a persistence format for BBS+ key pairs.
+//
+// A KeyFile wraps the serialized key material together with metadata
+// (creation time, intended usage, ciphersuite and message count) and an
+// integrity MAC. Key files can be stored as JSON or CBOR; Load detects the
+// encoding automatically.
+//
+// Example usage:
+//
+//     // Save a freshly generated key pair
+//     kf, err := keys.NewKeyFile(keyPair, keys.UsageSigning)
+//     err = keys.Save("issuer.key", kf, keys.FormatJSON, nil)
+//
+//     // Load it back
+//     kf, err = keys.Load("issuer.key", nil)
+//     keyPair, err := kf.KeyPair()
+//
+// The MAC key is optional. Without one, the MAC only detects corruption;
+// with a secret MAC key it also detects tampering.
+//
+// Stability: stable
+package keys
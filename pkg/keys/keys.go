@@ -0,0 +1,268 @@
+package keys
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/anupsv/bbsplus-signatures/bbs"
+	"github.com/anupsv/bbsplus-signatures/internal/secret"
+	bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381"
+)
+
+// Version is the current key file format version
+const Version = 1
+
+// Ciphersuite identifies the BBS+ ciphersuite the keys belong to
+const Ciphersuite = "BBS_BLS12381G1_XMD:SHA-256_SSWU_RO_"
+
+// Key usages recorded in the metadata
+const (
+	// UsageSigning marks a key pair used to issue credentials
+	UsageSigning = "signing"
+
+	// UsageVerification marks a public key kept for verification only
+	UsageVerification = "verification"
+)
+
+// Format selects the encoding of a key file
+type Format int
+
+const (
+	// FormatJSON encodes key files as indented JSON
+	FormatJSON Format = iota
+
+	// FormatCBOR encodes key files as CBOR (RFC 8949)
+	FormatCBOR
+)
+
+// macDST is the default MAC key, used when the caller does not supply one
+const macDST = "BBS_BLS12381_KEYFILE_MAC_V1_"
+
+var (
+	// ErrInvalidKeyFile is returned when a key file is malformed
+	ErrInvalidKeyFile = errors.New("invalid key file")
+
+	// ErrIntegrityCheckFailed is returned when the key file MAC does not match
+	ErrIntegrityCheckFailed = errors.New("key file integrity check failed")
+)
+
+// Metadata describes the key material in a key file
+type Metadata struct {
+	// Created is when the key pair was generated
+	Created time.Time `json:"created"`
+
+	// Usage is the intended usage of the key, e.g. UsageSigning
+	Usage string `json:"usage"`
+
+	// Ciphersuite identifies the BBS+ ciphersuite
+	Ciphersuite string `json:"ciphersuite"`
+
+	// MessageCount is the number of messages the key supports
+	MessageCount int `json:"messageCount"`
+}
+
+// KeyFile is the envelope persisted to disk
+type KeyFile struct {
+	// Version is the key file format version
+	Version int `json:"version"`
+
+	// Metadata describes the key material
+	Metadata Metadata `json:"metadata"`
+
+	// PrivateKey is the serialized private key, empty for public-only files
+	PrivateKey []byte `json:"privateKey,omitempty"`
+
+	// PublicKey is the serialized public key
+	PublicKey []byte `json:"publicKey"`
+
+	// MAC is an HMAC-SHA256 over all other fields
+	MAC []byte `json:"mac"`
+}
+
+// NewKeyFile creates a key file for keyPair. A nil private key produces a
+// public-only key file.
+func NewKeyFile(keyPair *bbs.KeyPair, usage string) (*KeyFile, error) {
+	if keyPair == nil || keyPair.PublicKey == nil {
+		return nil, fmt.Errorf("%w: missing public key", ErrInvalidKeyFile)
+	}
+
+	publicKey, err := keyPair.PublicKey.MarshalBinary()
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize public key: %w", err)
+	}
+
+	kf := &KeyFile{
+		Version: Version,
+		Metadata: Metadata{
+			Created:      time.Now().UTC(),
+			Usage:        usage,
+			Ciphersuite:  Ciphersuite,
+			MessageCount: keyPair.PublicKey.MessageCount(),
+		},
+		PublicKey: publicKey,
+	}
+
+	if keyPair.PrivateKey != nil {
+		kf.PrivateKey, err = keyPair.PrivateKey.MarshalBinary()
+		if err != nil {
+			return nil, fmt.Errorf("failed to serialize private key: %w", err)
+		}
+	}
+
+	return kf, nil
+}
+
+// KeyPair decodes the key material. PrivateKey is nil for public-only files.
+func (kf *KeyFile) KeyPair() (*bbs.KeyPair, error) {
+	publicKey := &bbs.PublicKey{}
+	if err := publicKey.UnmarshalBinary(kf.PublicKey); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal public key: %w", err)
+	}
+
+	if publicKey.MessageCount() != kf.Metadata.MessageCount {
+		return nil, fmt.Errorf("%w: message count %d does not match public key (%d)",
+			ErrInvalidKeyFile, kf.Metadata.MessageCount, publicKey.MessageCount())
+	}
+
+	keyPair := &bbs.KeyPair{
+		PublicKey: publicKey,
+	}
+
+	if len(kf.PrivateKey) > 0 {
+		privateKey := &bbs.PrivateKey{}
+		if err := privateKey.UnmarshalBinary(kf.PrivateKey); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal private key: %w", err)
+		}
+
+		// The MAC only covers the stored bytes, so W = g2*x is checked too
+		g2 := publicKey.G2()
+		var w bls12381.G2Affine
+		w.ScalarMultiplication(&g2, privateKey.X)
+		if expected := publicKey.W(); !w.Equal(&expected) {
+			secret.WipeInt(privateKey.X)
+			return nil, fmt.Errorf("%w: private key does not match the public key", ErrInvalidKeyFile)
+		}
+		keyPair.PrivateKey = privateKey
+	}
+
+	return keyPair, nil
+}
+
+// Seal computes the MAC over the key file contents. A nil macKey uses the
+// default key, which only protects against corruption.
+func (kf *KeyFile) Seal(macKey []byte) {
+	kf.MAC = kf.computeMAC(macKey)
+}
+
+// CheckIntegrity verifies the MAC and the metadata of the key file
+func (kf *KeyFile) CheckIntegrity(macKey []byte) error {
+	if kf.Version != Version {
+		return fmt.Errorf("%w: unsupported version %d", ErrInvalidKeyFile, kf.Version)
+	}
+
+	if kf.Metadata.Ciphersuite != Ciphersuite {
+		return fmt.Errorf("%w: unsupported ciphersuite %q", ErrInvalidKeyFile, kf.Metadata.Ciphersuite)
+	}
+
+	if !hmac.Equal(kf.MAC, kf.computeMAC(macKey)) {
+		return ErrIntegrityCheckFailed
+	}
+
+	return nil
+}
+
+// computeMAC computes HMAC-SHA256 over a canonical, encoding independent
+// serialization of every field but the MAC
+func (kf *KeyFile) computeMAC(macKey []byte) []byte {
+	if len(macKey) == 0 {
+		macKey = []byte(macDST)
+	}
+
+	var buff []byte
+	appendField := func(field []byte) {
+		buff = binary.BigEndian.AppendUint32(buff, uint32(len(field)))
+		buff = append(buff, field...)
+	}
+
+	buff = binary.BigEndian.AppendUint32(buff, uint32(kf.Version))
+	appendField([]byte(kf.Metadata.Created.UTC().Format(time.RFC3339Nano)))
+	appendField([]byte(kf.Metadata.Usage))
+	appendField([]byte(kf.Metadata.Ciphersuite))
+	buff = binary.BigEndian.AppendUint32(buff, uint32(kf.Metadata.MessageCount))
+	appendField(kf.PrivateKey)
+	appendField(kf.PublicKey)
+
+	mac := hmac.New(sha256.New, macKey)
+	mac.Write(buff)
+	return mac.Sum(nil)
+}
+
+// Marshal seals the key file with macKey and encodes it in the given format
+func Marshal(kf *KeyFile, format Format, macKey []byte) ([]byte, error) {
+	kf.Seal(macKey)
+
+	switch format {
+	case FormatJSON:
+		return json.MarshalIndent(kf, "", "  ")
+	case FormatCBOR:
+		return marshalCBOR(kf), nil
+	default:
+		return nil, fmt.Errorf("unknown key file format: %d", format)
+	}
+}
+
+// Unmarshal decodes a JSON or CBOR key file and checks its integrity
+func Unmarshal(data []byte, macKey []byte) (*KeyFile, error) {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 {
+		return nil, fmt.Errorf("%w: empty input", ErrInvalidKeyFile)
+	}
+
+	kf := &KeyFile{}
+	if trimmed[0] == '{' {
+		if err := json.Unmarshal(trimmed, kf); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidKeyFile, err)
+		}
+	} else {
+		if err := unmarshalCBOR(data, kf); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := kf.CheckIntegrity(macKey); err != nil {
+		return nil, err
+	}
+
+	return kf, nil
+}
+
+// Save writes the key file to path with owner-only permissions
+func Save(path string, kf *KeyFile, format Format, macKey []byte) error {
+	data, err := Marshal(kf, format, macKey)
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write key file: %w", err)
+	}
+
+	return nil
+}
+
+// Load reads a key file from path and checks its integrity
+func Load(path string, macKey []byte) (*KeyFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key file: %w", err)
+	}
+
+	return Unmarshal(data, macKey)
+}
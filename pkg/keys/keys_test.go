@@ -0,0 +1,149 @@
+package keys
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"path/filepath"
+	"testing"
+
+	"github.com/anupsv/bbsplus-signatures/bbs"
+)
+
+func newTestKeyFile(t *testing.T) (*bbs.KeyPair, *KeyFile) {
+	t.Helper()
+
+	keyPair, err := bbs.GenerateKeyPair(3, rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key pair: %v", err)
+	}
+
+	kf, err := NewKeyFile(keyPair, UsageSigning)
+	if err != nil {
+		t.Fatalf("NewKeyFile failed: %v", err)
+	}
+
+	return keyPair, kf
+}
+
+func TestSaveLoadRoundTrip(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		format Format
+		macKey []byte
+	}{
+		{"JSON", FormatJSON, nil},
+		{"CBOR", FormatCBOR, nil},
+		{"JSONWithMACKey", FormatJSON, []byte("secret")},
+		{"CBORWithMACKey", FormatCBOR, []byte("secret")},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			keyPair, kf := newTestKeyFile(t)
+			path := filepath.Join(t.TempDir(), "issuer.key")
+
+			if err := Save(path, kf, tc.format, tc.macKey); err != nil {
+				t.Fatalf("Save failed: %v", err)
+			}
+
+			loaded, err := Load(path, tc.macKey)
+			if err != nil {
+				t.Fatalf("Load failed: %v", err)
+			}
+
+			if loaded.Metadata.Usage != UsageSigning || loaded.Metadata.MessageCount != 3 ||
+				loaded.Metadata.Ciphersuite != Ciphersuite || !loaded.Metadata.Created.Equal(kf.Metadata.Created) {
+				t.Fatalf("Metadata mismatch: %+v", loaded.Metadata)
+			}
+
+			restored, err := loaded.KeyPair()
+			if err != nil {
+				t.Fatalf("KeyPair failed: %v", err)
+			}
+
+			if restored.PrivateKey.X.Cmp(keyPair.PrivateKey.X) != 0 {
+				t.Fatal("Private key mismatch")
+			}
+
+			// The restored key must still sign and verify
+			messages := []*big.Int{big.NewInt(1), big.NewInt(2), big.NewInt(3)}
+			signature, err := bbs.Sign(restored.PrivateKey, restored.PublicKey, messages, nil)
+			if err != nil {
+				t.Fatalf("Sign failed: %v", err)
+			}
+			if err := bbs.Verify(keyPair.PublicKey, signature, messages, nil); err != nil {
+				t.Fatalf("Verify failed: %v", err)
+			}
+		})
+	}
+}
+
+func TestLoadRejectsTampering(t *testing.T) {
+	for _, format := range []Format{FormatJSON, FormatCBOR} {
+		_, kf := newTestKeyFile(t)
+
+		data, err := Marshal(kf, format, []byte("secret"))
+		if err != nil {
+			t.Fatalf("Marshal failed: %v", err)
+		}
+
+		// Wrong MAC key
+		if _, err := Unmarshal(data, []byte("other")); !errors.Is(err, ErrIntegrityCheckFailed) {
+			t.Fatalf("Unmarshal with wrong MAC key: got %v, want %v", err, ErrIntegrityCheckFailed)
+		}
+
+		// Modified metadata
+		kf.Metadata.Usage = UsageVerification
+		if err := kf.CheckIntegrity([]byte("secret")); !errors.Is(err, ErrIntegrityCheckFailed) {
+			t.Fatalf("CheckIntegrity after modification: got %v, want %v", err, ErrIntegrityCheckFailed)
+		}
+
+		// Truncated input
+		if _, err := Unmarshal(data[:len(data)/2], []byte("secret")); err == nil {
+			t.Fatal("Unmarshal should reject truncated input")
+		}
+	}
+}
+
+func TestKeyPairRejectsMismatchedPrivateKey(t *testing.T) {
+	_, kf := newTestKeyFile(t)
+	other, _ := newTestKeyFile(t)
+
+	privateKey, err := other.PrivateKey.MarshalBinary()
+	if err != nil {
+		t.Fatalf("MarshalBinary failed: %v", err)
+	}
+	kf.PrivateKey = privateKey
+	kf.Seal(nil)
+
+	if _, err := kf.KeyPair(); !errors.Is(err, ErrInvalidKeyFile) {
+		t.Fatalf("KeyPair with mismatched private key: got %v, want %v", err, ErrInvalidKeyFile)
+	}
+}
+
+func TestPublicOnlyKeyFile(t *testing.T) {
+	keyPair, _ := newTestKeyFile(t)
+
+	kf, err := NewKeyFile(&bbs.KeyPair{PublicKey: keyPair.PublicKey}, UsageVerification)
+	if err != nil {
+		t.Fatalf("NewKeyFile failed: %v", err)
+	}
+
+	data, err := Marshal(kf, FormatCBOR, nil)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+
+	loaded, err := Unmarshal(data, nil)
+	if err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+
+	restored, err := loaded.KeyPair()
+	if err != nil {
+		t.Fatalf("KeyPair failed: %v", err)
+	}
+
+	if restored.PrivateKey != nil {
+		t.Fatal("Public-only key file should not contain a private key")
+	}
+}
@@ -0,0 +1,47 @@
+//go:build awskms
+
+package kms
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+)
+
+// AWSClient is the subset of the AWS KMS client used by AWSKMS
+type AWSClient interface {
+	Encrypt(ctx context.Context, params *kms.EncryptInput, optFns ...func(*kms.Options)) (*kms.EncryptOutput, error)
+	Decrypt(ctx context.Context, params *kms.DecryptInput, optFns ...func(*kms.Options)) (*kms.DecryptOutput, error)
+}
+
+// AWSKMS wraps data keys with an AWS KMS symmetric key
+type AWSKMS struct {
+	Client AWSClient
+
+	// KeyID is a key ID, ARN or alias
+	KeyID string
+}
+
+// Encrypt implements KeyEncrypter
+func (a *AWSKMS) Encrypt(ctx context.Context, plaintext []byte) ([]byte, error) {
+	out, err := a.Client.Encrypt(ctx, &kms.EncryptInput{
+		KeyId:     &a.KeyID,
+		Plaintext: plaintext,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out.CiphertextBlob, nil
+}
+
+// Decrypt implements KeyEncrypter
+func (a *AWSKMS) Decrypt(ctx context.Context, ciphertext []byte) ([]byte, error) {
+	out, err := a.Client.Decrypt(ctx, &kms.DecryptInput{
+		KeyId:          &a.KeyID,
+		CiphertextBlob: ciphertext,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out.Plaintext, nil
+}
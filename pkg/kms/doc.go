@@ -0,0 +1,25 @@
+// Package kms stores BBS+ private keys envelope-encrypted under a cloud KMS key.
+//
+// Seal encrypts the serialized private key with a fresh AES-256-GCM data key
+// and wraps the data key with the KMS; Open reverses it. Signer is a
+// bbs.SignerBackend that unwraps the key on first use, keeps the plaintext
+// in locked (non-swappable) memory for a configurable TTL and wipes it
+// afterwards, so an Engine built with it unwraps transparently when signing.
+//
+// The KMS is reached through the KeyEncrypter interface. Adapters for the
+// cloud SDKs live behind build tags to keep the default build free of them:
+//
+//	go get github.com/aws/aws-sdk-go-v2/service/kms   // -tags awskms
+//	go get cloud.google.com/go/kms                    // -tags gcpkms
+//
+// Example usage:
+//
+//	kek := &kms.AWSKMS{Client: client, KeyID: "alias/bbs-issuer"}
+//	envelope, err := kms.Seal(ctx, kek, keyPair.PrivateKey)
+//
+//	signer, err := kms.NewSigner(kek, envelope, keyPair.PublicKey, 5*time.Minute)
+//	engine := bbs.NewEngine(bbs.WithSigner(signer))
+//	signature, err := engine.Sign(messages, header)
+//
+// Stability: experimental. The API may change in a minor release.
+package kms
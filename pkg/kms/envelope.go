@@ -0,0 +1,114 @@
+package kms
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+
+	"github.com/anupsv/bbsplus-signatures/bbs"
+	"github.com/anupsv/bbsplus-signatures/internal/secret"
+)
+
+// KeyEncrypter is a key encryption key held by a KMS
+type KeyEncrypter interface {
+	// Encrypt encrypts plaintext with the KMS key
+	Encrypt(ctx context.Context, plaintext []byte) ([]byte, error)
+
+	// Decrypt decrypts ciphertext produced by Encrypt
+	Decrypt(ctx context.Context, ciphertext []byte) ([]byte, error)
+}
+
+// envelopeAAD binds the ciphertext to its purpose
+const envelopeAAD = "BBS_BLS12381_KMS_ENVELOPE_V1"
+
+// ErrInvalidEnvelope is returned when an envelope cannot be opened
+var ErrInvalidEnvelope = errors.New("invalid key envelope")
+
+// Envelope is an envelope-encrypted private key
+type Envelope struct {
+	// WrappedKey is the data key encrypted by the KMS
+	WrappedKey []byte `json:"wrappedKey"`
+
+	// Nonce is the AES-GCM nonce
+	Nonce []byte `json:"nonce"`
+
+	// Ciphertext is the private key encrypted with the data key
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// Seal envelope-encrypts privateKey under kek
+func Seal(ctx context.Context, kek KeyEncrypter, privateKey *bbs.PrivateKey) (*Envelope, error) {
+	dataKey := make([]byte, 32)
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, fmt.Errorf("failed to generate data key: %w", err)
+	}
+	defer secret.WipeBytes(dataKey)
+
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	plaintext := bbs.SerializePrivateKey(privateKey)
+	defer secret.WipeBytes(plaintext)
+
+	wrappedKey, err := kek.Encrypt(ctx, dataKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to wrap data key: %w", err)
+	}
+
+	return &Envelope{
+		WrappedKey: wrappedKey,
+		Nonce:      nonce,
+		Ciphertext: aead.Seal(nil, nonce, plaintext, []byte(envelopeAAD)),
+	}, nil
+}
+
+// Open decrypts the envelope into dst, which must be large enough for the
+// serialized private key, and returns the used prefix of dst. Decrypting into
+// a caller supplied buffer lets Signer keep the plaintext in locked memory.
+func Open(ctx context.Context, kek KeyEncrypter, envelope *Envelope, dst []byte) ([]byte, error) {
+	dataKey, err := kek.Decrypt(ctx, envelope.WrappedKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unwrap data key: %w", err)
+	}
+	defer secret.WipeBytes(dataKey)
+
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(envelope.Nonce) != aead.NonceSize() {
+		return nil, ErrInvalidEnvelope
+	}
+
+	plaintextLen := len(envelope.Ciphertext) - aead.Overhead()
+	if plaintextLen <= 0 || plaintextLen > len(dst) {
+		return nil, ErrInvalidEnvelope
+	}
+
+	plaintext, err := aead.Open(dst[:0], envelope.Nonce, envelope.Ciphertext, []byte(envelopeAAD))
+	if err != nil {
+		return nil, ErrInvalidEnvelope
+	}
+
+	return plaintext, nil
+}
+
+// newAEAD creates an AES-256-GCM cipher for a data key
+func newAEAD(dataKey []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(dataKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
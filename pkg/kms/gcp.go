@@ -0,0 +1,49 @@
+//go:build gcpkms
+
+package kms
+
+import (
+	"context"
+
+	"cloud.google.com/go/kms/apiv1/kmspb"
+	"github.com/googleapis/gax-go/v2"
+)
+
+// GCPClient is the subset of the Cloud KMS key management client used by GCPKMS
+type GCPClient interface {
+	Encrypt(ctx context.Context, req *kmspb.EncryptRequest, opts ...gax.CallOption) (*kmspb.EncryptResponse, error)
+	Decrypt(ctx context.Context, req *kmspb.DecryptRequest, opts ...gax.CallOption) (*kmspb.DecryptResponse, error)
+}
+
+// GCPKMS wraps data keys with a Cloud KMS symmetric key
+type GCPKMS struct {
+	Client GCPClient
+
+	// KeyName is the crypto key resource name,
+	// projects/*/locations/*/keyRings/*/cryptoKeys/*
+	KeyName string
+}
+
+// Encrypt implements KeyEncrypter
+func (g *GCPKMS) Encrypt(ctx context.Context, plaintext []byte) ([]byte, error) {
+	resp, err := g.Client.Encrypt(ctx, &kmspb.EncryptRequest{
+		Name:      g.KeyName,
+		Plaintext: plaintext,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Ciphertext, nil
+}
+
+// Decrypt implements KeyEncrypter
+func (g *GCPKMS) Decrypt(ctx context.Context, ciphertext []byte) ([]byte, error) {
+	resp, err := g.Client.Decrypt(ctx, &kmspb.DecryptRequest{
+		Name:       g.KeyName,
+		Ciphertext: ciphertext,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Plaintext, nil
+}
@@ -0,0 +1,300 @@
+package kms
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/anupsv/bbsplus-signatures/bbs"
+)
+
+// fakeKMS emulates a KMS key with local AES-GCM and counts decrypt calls
+type fakeKMS struct {
+	aead     cipher.AEAD
+	decrypts int32
+	fail     bool
+}
+
+func newFakeKMS(t *testing.T) *fakeKMS {
+	t.Helper()
+
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		t.Fatalf("Failed to generate KMS key: %v", err)
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		t.Fatalf("Failed to create cipher: %v", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		t.Fatalf("Failed to create GCM: %v", err)
+	}
+
+	return &fakeKMS{aead: aead}
+}
+
+func (f *fakeKMS) Encrypt(ctx context.Context, plaintext []byte) ([]byte, error) {
+	nonce := make([]byte, f.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return f.aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+func (f *fakeKMS) Decrypt(ctx context.Context, ciphertext []byte) ([]byte, error) {
+	atomic.AddInt32(&f.decrypts, 1)
+	if f.fail {
+		return nil, errors.New("AccessDeniedException")
+	}
+
+	n := f.aead.NonceSize()
+	if len(ciphertext) < n {
+		return nil, errors.New("InvalidCiphertextException")
+	}
+	return f.aead.Open(nil, ciphertext[:n], ciphertext[n:], nil)
+}
+
+func testMessages(n int) []*big.Int {
+	messages := make([]*big.Int, n)
+	for i := range messages {
+		messages[i] = big.NewInt(int64(100 + i))
+	}
+	return messages
+}
+
+func TestSealOpen(t *testing.T) {
+	keyPair, err := bbs.GenerateKeyPair(3, rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key pair: %v", err)
+	}
+
+	kek := newFakeKMS(t)
+	envelope, err := Seal(context.Background(), kek, keyPair.PrivateKey)
+	if err != nil {
+		t.Fatalf("Seal failed: %v", err)
+	}
+
+	plaintext, err := Open(context.Background(), kek, envelope, make([]byte, plaintextSize))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+
+	sk, err := bbs.DeserializePrivateKey(plaintext)
+	if err != nil {
+		t.Fatalf("DeserializePrivateKey failed: %v", err)
+	}
+	if sk.X.Cmp(keyPair.PrivateKey.X) != 0 {
+		t.Fatal("Opened private key does not match")
+	}
+
+	// A tampered ciphertext must be rejected
+	envelope.Ciphertext[0] ^= 0x01
+	if _, err := Open(context.Background(), kek, envelope, make([]byte, plaintextSize)); !errors.Is(err, ErrInvalidEnvelope) {
+		t.Fatalf("Expected ErrInvalidEnvelope, got %v", err)
+	}
+
+	// So must an envelope opened under a different KMS key
+	envelope.Ciphertext[0] ^= 0x01
+	if _, err := Open(context.Background(), newFakeKMS(t), envelope, make([]byte, plaintextSize)); err == nil {
+		t.Fatal("Open succeeded with the wrong KMS key")
+	}
+}
+
+func TestSignerEngineIntegration(t *testing.T) {
+	keyPair, err := bbs.GenerateKeyPair(3, rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key pair: %v", err)
+	}
+
+	kek := newFakeKMS(t)
+	envelope, err := Seal(context.Background(), kek, keyPair.PrivateKey)
+	if err != nil {
+		t.Fatalf("Seal failed: %v", err)
+	}
+
+	signer, err := NewSigner(kek, envelope, keyPair.PublicKey, time.Hour)
+	if err != nil {
+		t.Fatalf("NewSigner failed: %v", err)
+	}
+	defer signer.Purge()
+
+	engine := bbs.NewEngine(bbs.WithSigner(signer))
+	messages := testMessages(3)
+
+	for i := 0; i < 3; i++ {
+		signature, err := engine.Sign(messages, []byte("header"))
+		if err != nil {
+			t.Fatalf("Sign failed: %v", err)
+		}
+		if err := engine.Verify(engine.PublicKey(), signature, messages, []byte("header")); err != nil {
+			t.Fatalf("Verify failed: %v", err)
+		}
+	}
+
+	// The key is unwrapped once and then served from the cache
+	if got := atomic.LoadInt32(&kek.decrypts); got != 1 {
+		t.Fatalf("Expected 1 KMS decrypt, got %d", got)
+	}
+
+	// Purging forces the next signature to unwrap again
+	signer.Purge()
+	if _, err := engine.Sign(messages, nil); err != nil {
+		t.Fatalf("Sign after purge failed: %v", err)
+	}
+	if got := atomic.LoadInt32(&kek.decrypts); got != 2 {
+		t.Fatalf("Expected 2 KMS decrypts, got %d", got)
+	}
+}
+
+func TestSignerTTL(t *testing.T) {
+	keyPair, err := bbs.GenerateKeyPair(2, rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key pair: %v", err)
+	}
+
+	kek := newFakeKMS(t)
+	envelope, err := Seal(context.Background(), kek, keyPair.PrivateKey)
+	if err != nil {
+		t.Fatalf("Seal failed: %v", err)
+	}
+
+	signer, err := NewSigner(kek, envelope, keyPair.PublicKey, 20*time.Millisecond)
+	if err != nil {
+		t.Fatalf("NewSigner failed: %v", err)
+	}
+	defer signer.Purge()
+
+	messages := testMessages(2)
+	if _, err := signer.Sign(messages, nil); err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+
+	time.Sleep(50 * time.Millisecond)
+
+	signer.mu.Lock()
+	cached := signer.cached
+	signer.mu.Unlock()
+	if cached != nil {
+		t.Fatal("Plaintext key still cached after TTL")
+	}
+
+	if _, err := signer.Sign(messages, nil); err != nil {
+		t.Fatalf("Sign after expiry failed: %v", err)
+	}
+	if got := atomic.LoadInt32(&kek.decrypts); got != 2 {
+		t.Fatalf("Expected 2 KMS decrypts, got %d", got)
+	}
+}
+
+func TestSignerStaleExpiry(t *testing.T) {
+	keyPair, err := bbs.GenerateKeyPair(2, rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key pair: %v", err)
+	}
+
+	kek := newFakeKMS(t)
+	envelope, err := Seal(context.Background(), kek, keyPair.PrivateKey)
+	if err != nil {
+		t.Fatalf("Seal failed: %v", err)
+	}
+
+	signer, err := NewSigner(kek, envelope, keyPair.PublicKey, time.Hour)
+	if err != nil {
+		t.Fatalf("NewSigner failed: %v", err)
+	}
+	defer signer.Purge()
+
+	messages := testMessages(2)
+	if _, err := signer.Sign(messages, nil); err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+	signer.mu.Lock()
+	stale := signer.generation
+	signer.mu.Unlock()
+
+	// A timer of the first unwrap that fires after the key was unwrapped
+	// again leaves the new key cached
+	signer.Purge()
+	if _, err := signer.Sign(messages, nil); err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+	signer.expire(stale)
+
+	signer.mu.Lock()
+	cached, current := signer.cached, signer.generation
+	signer.mu.Unlock()
+	if cached == nil {
+		t.Fatal("Stale expiry purged the freshly unwrapped key")
+	}
+
+	signer.expire(current)
+	signer.mu.Lock()
+	cached = signer.cached
+	signer.mu.Unlock()
+	if cached != nil {
+		t.Fatal("Expiry of the current unwrap left the key cached")
+	}
+}
+
+func TestSignerNoCache(t *testing.T) {
+	keyPair, err := bbs.GenerateKeyPair(2, rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key pair: %v", err)
+	}
+
+	kek := newFakeKMS(t)
+	envelope, err := Seal(context.Background(), kek, keyPair.PrivateKey)
+	if err != nil {
+		t.Fatalf("Seal failed: %v", err)
+	}
+
+	signer, err := NewSigner(kek, envelope, keyPair.PublicKey, 0)
+	if err != nil {
+		t.Fatalf("NewSigner failed: %v", err)
+	}
+
+	messages := testMessages(2)
+	for i := 0; i < 2; i++ {
+		if _, err := signer.Sign(messages, nil); err != nil {
+			t.Fatalf("Sign failed: %v", err)
+		}
+	}
+
+	if signer.cached != nil {
+		t.Fatal("Plaintext key cached with zero TTL")
+	}
+	if got := atomic.LoadInt32(&kek.decrypts); got != 2 {
+		t.Fatalf("Expected 2 KMS decrypts, got %d", got)
+	}
+}
+
+func TestSignerUnwrapFailure(t *testing.T) {
+	keyPair, err := bbs.GenerateKeyPair(2, rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key pair: %v", err)
+	}
+
+	kek := newFakeKMS(t)
+	envelope, err := Seal(context.Background(), kek, keyPair.PrivateKey)
+	if err != nil {
+		t.Fatalf("Seal failed: %v", err)
+	}
+
+	signer, err := NewSigner(kek, envelope, keyPair.PublicKey, time.Minute)
+	if err != nil {
+		t.Fatalf("NewSigner failed: %v", err)
+	}
+
+	kek.fail = true
+	if _, err := signer.Sign(testMessages(2), nil); err == nil {
+		t.Fatal("Sign succeeded although the KMS refused to unwrap")
+	}
+}
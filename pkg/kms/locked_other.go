@@ -0,0 +1,27 @@
+//go:build !unix
+
+package kms
+
+import "github.com/anupsv/bbsplus-signatures/internal/secret"
+
+// lockedBuffer falls back to ordinary memory where locking is unavailable.
+// The plaintext is still wiped when the buffer is destroyed.
+type lockedBuffer struct {
+	data []byte
+}
+
+// newLockedBuffer allocates size bytes
+func newLockedBuffer(size int) (*lockedBuffer, error) {
+	return &lockedBuffer{data: make([]byte, size)}, nil
+}
+
+// Bytes returns the buffer memory
+func (lb *lockedBuffer) Bytes() []byte {
+	return lb.data
+}
+
+// Destroy wipes the buffer
+func (lb *lockedBuffer) Destroy() {
+	secret.WipeBytes(lb.data)
+	lb.data = nil
+}
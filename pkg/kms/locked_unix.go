@@ -0,0 +1,43 @@
+//go:build unix
+
+package kms
+
+import (
+	"syscall"
+
+	"github.com/anupsv/bbsplus-signatures/internal/secret"
+)
+
+// lockedBuffer is memory outside the Go heap that is locked into RAM so the
+// plaintext key is never written to swap
+type lockedBuffer struct {
+	data []byte
+}
+
+// newLockedBuffer maps and locks size bytes
+func newLockedBuffer(size int) (*lockedBuffer, error) {
+	data, err := syscall.Mmap(-1, 0, size, syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_ANON|syscall.MAP_PRIVATE)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := syscall.Mlock(data); err != nil {
+		syscall.Munmap(data)
+		return nil, err
+	}
+
+	return &lockedBuffer{data: data}, nil
+}
+
+// Bytes returns the locked memory
+func (lb *lockedBuffer) Bytes() []byte {
+	return lb.data
+}
+
+// Destroy wipes, unlocks and unmaps the memory
+func (lb *lockedBuffer) Destroy() {
+	secret.WipeBytes(lb.data)
+	syscall.Munlock(lb.data)
+	syscall.Munmap(lb.data)
+	lb.data = nil
+}
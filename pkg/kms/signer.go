@@ -0,0 +1,154 @@
+package kms
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/anupsv/bbsplus-signatures/bbs"
+	"github.com/anupsv/bbsplus-signatures/internal/secret"
+)
+
+// plaintextSize bounds the serialized private key, a scalar below the group order
+const plaintextSize = 32
+
+// DefaultUnwrapTimeout bounds a single KMS unwrap call
+const DefaultUnwrapTimeout = 10 * time.Second
+
+// Signer is a bbs.SignerBackend that unwraps an envelope-encrypted private
+// key on demand and caches the plaintext in locked memory for a TTL
+type Signer struct {
+	kek       KeyEncrypter
+	envelope  *Envelope
+	publicKey *bbs.PublicKey
+	ttl       time.Duration
+
+	// UnwrapTimeout bounds each KMS call made while signing
+	UnwrapTimeout time.Duration
+
+	mu      sync.Mutex
+	cached  *lockedBuffer
+	keyLen  int
+	expires time.Time
+	timer   *time.Timer
+
+	// generation counts unwraps, so an expiry timer that fired while Sign
+	// held the lock does not purge the key unwrapped after it
+	generation uint64
+}
+
+// Signer implements bbs.SignerBackend
+var _ bbs.SignerBackend = (*Signer)(nil)
+
+// NewSigner creates a signer for an envelope produced by Seal. A ttl of zero
+// unwraps the key for every signature and never caches it.
+func NewSigner(kek KeyEncrypter, envelope *Envelope, publicKey *bbs.PublicKey, ttl time.Duration) (*Signer, error) {
+	if kek == nil || envelope == nil || publicKey == nil {
+		return nil, fmt.Errorf("key encrypter, envelope and public key are required")
+	}
+
+	if ttl < 0 {
+		return nil, fmt.Errorf("invalid cache TTL: %v", ttl)
+	}
+
+	return &Signer{
+		kek:           kek,
+		envelope:      envelope,
+		publicKey:     publicKey,
+		ttl:           ttl,
+		UnwrapTimeout: DefaultUnwrapTimeout,
+	}, nil
+}
+
+// PublicKey returns the public key matching the wrapped private key
+func (s *Signer) PublicKey() *bbs.PublicKey {
+	return s.publicKey
+}
+
+// Sign signs messages, unwrapping the private key if it is not cached
+func (s *Signer) Sign(messages []*big.Int, header []byte) (*bbs.Signature, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.cached == nil || time.Now().After(s.expires) {
+		s.purgeLocked()
+		if err := s.unwrapLocked(); err != nil {
+			return nil, err
+		}
+	}
+
+	sk, err := bbs.DeserializePrivateKey(s.cached.Bytes()[:s.keyLen])
+	if err != nil {
+		s.purgeLocked()
+		return nil, fmt.Errorf("failed to decode unwrapped private key: %w", err)
+	}
+	defer secret.WipeInt(sk.X)
+
+	if s.ttl == 0 {
+		defer s.purgeLocked()
+	}
+
+	return bbs.Sign(sk, s.publicKey, messages, header)
+}
+
+// Purge wipes the cached plaintext key immediately
+func (s *Signer) Purge() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.purgeLocked()
+}
+
+// unwrapLocked decrypts the envelope into a fresh locked buffer
+func (s *Signer) unwrapLocked() error {
+	buf, err := newLockedBuffer(plaintextSize)
+	if err != nil {
+		return fmt.Errorf("failed to allocate locked memory: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.UnwrapTimeout)
+	defer cancel()
+
+	plaintext, err := Open(ctx, s.kek, s.envelope, buf.Bytes())
+	if err != nil {
+		buf.Destroy()
+		return err
+	}
+
+	s.cached = buf
+	s.keyLen = len(plaintext)
+	s.expires = time.Now().Add(s.ttl)
+	s.generation++
+
+	if s.ttl > 0 {
+		generation := s.generation
+		s.timer = time.AfterFunc(s.ttl, func() { s.expire(generation) })
+	}
+
+	return nil
+}
+
+// expire purges the key of an unwrap when its TTL runs out, unless it has
+// already been replaced
+func (s *Signer) expire(generation uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.generation == generation {
+		s.purgeLocked()
+	}
+}
+
+// purgeLocked destroys the cached key and stops its expiry timer
+func (s *Signer) purgeLocked() {
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+
+	if s.cached != nil {
+		s.cached.Destroy()
+		s.cached = nil
+		s.keyLen = 0
+	}
+}
@@ -0,0 +1,32 @@
+// Package mobile is a gomobile-friendly facade over the bbs package for
+// Android and iOS wallets.
+//
+// gomobile can only bind signatures made of basic types, byte slices and
+// pointers to exported structs, so keys, signatures and proofs are byte
+// slices in the bbs wire formats, and lists of messages or indices are
+// built with MessageList, IndexList and DisclosedMessages. Messages are raw
+// bytes, mapped to field elements inside the package.
+//
+// Build the bindings with:
+//
+//	gomobile bind -target=android -o bbsplus.aar ./pkg/mobile
+//	gomobile bind -target=ios -o Bbsplus.xcframework ./pkg/mobile
+//
+// Example usage:
+//
+//	messages := mobile.NewMessageList()
+//	messages.Add([]byte("alice"))
+//	messages.Add([]byte("1990-01-01"))
+//
+//	keyPair, err := mobile.GenerateKeyPair(2)
+//	signature, err := mobile.Sign(keyPair.PrivateKey, keyPair.PublicKey, messages, nil)
+//
+//	disclose := mobile.NewIndexList()
+//	disclose.Add(0)
+//	proof, err := mobile.CreateProof(keyPair.PublicKey, signature, messages, disclose, nil)
+//	err = mobile.VerifyProof(keyPair.PublicKey, proof.Proof, proof.Disclosed, nil)
+//
+// The examples directory has the same flow in Kotlin and Swift.
+//
+// Stability: experimental. The API may change in a minor release.
+package mobile
@@ -0,0 +1,24 @@
+// Sample use of the Android bindings built with
+//   gomobile bind -target=android -o bbsplus.aar ./pkg/mobile
+// gomobile maps Go errors to exceptions.
+
+import mobile.Mobile
+
+fun bbsExample() {
+    val messages = Mobile.newMessageList()
+    messages.add("alice".toByteArray())
+    messages.add("1990-01-01".toByteArray())
+    messages.add("NL".toByteArray())
+
+    // Issuer
+    val keyPair = Mobile.generateKeyPair(messages.len())
+    val signature = Mobile.sign(keyPair.privateKey, keyPair.publicKey, messages, null)
+
+    // Holder: disclose the country only
+    val disclose = Mobile.newIndexList()
+    disclose.add(2)
+    val proof = Mobile.createProof(keyPair.publicKey, signature, messages, disclose, "nonce-123".toByteArray())
+
+    // Verifier
+    Mobile.verifyProof(keyPair.publicKey, proof.proof, proof.disclosed, "nonce-123".toByteArray())
+}
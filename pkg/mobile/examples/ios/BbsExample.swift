@@ -0,0 +1,27 @@
+// Sample use of the iOS bindings built with
+//   gomobile bind -target=ios -o Bbsplus.xcframework ./pkg/mobile
+// gomobile maps Go errors to thrown NSErrors.
+
+import Foundation
+import Mobile
+
+func bbsExample() throws {
+    let messages = MobileNewMessageList()!
+    messages.add("alice".data(using: .utf8))
+    messages.add("1990-01-01".data(using: .utf8))
+    messages.add("NL".data(using: .utf8))
+
+    // Issuer
+    var error: NSError?
+    guard let keyPair = MobileGenerateKeyPair(messages.len(), &error) else { throw error! }
+    guard let signature = MobileSign(keyPair.privateKey, keyPair.publicKey, messages, nil, &error) else { throw error! }
+
+    // Holder: disclose the country only
+    let disclose = MobileNewIndexList()!
+    disclose.add(2)
+    let nonce = "nonce-123".data(using: .utf8)
+    guard let proof = MobileCreateProof(keyPair.publicKey, signature, messages, disclose, nonce, &error) else { throw error! }
+
+    // Verifier
+    try MobileVerifyProof(keyPair.publicKey, proof.proof, proof.disclosed, nonce)
+}
@@ -0,0 +1,245 @@
+package mobile
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"github.com/anupsv/bbsplus-signatures/bbs"
+	"github.com/anupsv/bbsplus-signatures/internal/version"
+)
+
+// MessageList is an ordered list of raw messages
+type MessageList struct {
+	messages [][]byte
+}
+
+// NewMessageList creates an empty message list
+func NewMessageList() *MessageList {
+	return &MessageList{}
+}
+
+// Add appends a message. The list keeps a copy.
+func (l *MessageList) Add(msg []byte) {
+	l.messages = append(l.messages, append([]byte{}, msg...))
+}
+
+// Len returns the number of messages
+func (l *MessageList) Len() int {
+	return len(l.messages)
+}
+
+// Get returns message i
+func (l *MessageList) Get(i int) ([]byte, error) {
+	if i < 0 || i >= len(l.messages) {
+		return nil, fmt.Errorf("message index %d out of range [0, %d)", i, len(l.messages))
+	}
+	return append([]byte{}, l.messages[i]...), nil
+}
+
+// IndexList is a list of message indices
+type IndexList struct {
+	indices []int
+}
+
+// NewIndexList creates an empty index list
+func NewIndexList() *IndexList {
+	return &IndexList{}
+}
+
+// Add appends an index
+func (l *IndexList) Add(index int) {
+	l.indices = append(l.indices, index)
+}
+
+// Len returns the number of indices
+func (l *IndexList) Len() int {
+	return len(l.indices)
+}
+
+// Get returns index i
+func (l *IndexList) Get(i int) (int, error) {
+	if i < 0 || i >= len(l.indices) {
+		return 0, fmt.Errorf("index %d out of range [0, %d)", i, len(l.indices))
+	}
+	return l.indices[i], nil
+}
+
+// DisclosedMessages pairs disclosed raw messages with their indices, in the
+// order they were added
+type DisclosedMessages struct {
+	indices  []int
+	messages [][]byte
+}
+
+// NewDisclosedMessages creates an empty set of disclosed messages
+func NewDisclosedMessages() *DisclosedMessages {
+	return &DisclosedMessages{}
+}
+
+// Add records message msg at index
+func (d *DisclosedMessages) Add(index int, msg []byte) {
+	d.indices = append(d.indices, index)
+	d.messages = append(d.messages, append([]byte{}, msg...))
+}
+
+// Len returns the number of disclosed messages
+func (d *DisclosedMessages) Len() int {
+	return len(d.indices)
+}
+
+// Index returns the message index of entry i
+func (d *DisclosedMessages) Index(i int) (int, error) {
+	if i < 0 || i >= len(d.indices) {
+		return 0, fmt.Errorf("entry %d out of range [0, %d)", i, len(d.indices))
+	}
+	return d.indices[i], nil
+}
+
+// Message returns the raw message of entry i
+func (d *DisclosedMessages) Message(i int) ([]byte, error) {
+	if i < 0 || i >= len(d.messages) {
+		return nil, fmt.Errorf("entry %d out of range [0, %d)", i, len(d.messages))
+	}
+	return append([]byte{}, d.messages[i]...), nil
+}
+
+// KeyPair is a serialized key pair
+type KeyPair struct {
+	PrivateKey   []byte
+	PublicKey    []byte
+	MessageCount int
+}
+
+// Proof is a serialized proof with the messages it discloses
+type Proof struct {
+	Proof     []byte
+	Disclosed *DisclosedMessages
+}
+
+// GenerateKeyPair generates a key pair for messageCount messages
+func GenerateKeyPair(messageCount int) (*KeyPair, error) {
+	keyPair, err := bbs.GenerateKeyPair(messageCount, rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	return &KeyPair{
+		PrivateKey:   bbs.SerializePrivateKey(keyPair.PrivateKey),
+		PublicKey:    bbs.SerializePublicKey(keyPair.PublicKey),
+		MessageCount: messageCount,
+	}, nil
+}
+
+// Sign signs messages. header may be nil.
+func Sign(privateKey, publicKey []byte, messages *MessageList, header []byte) ([]byte, error) {
+	sk, err := bbs.DeserializePrivateKey(privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid private key: %w", err)
+	}
+	pk, err := bbs.DeserializePublicKey(publicKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid public key: %w", err)
+	}
+
+	signature, err := bbs.Sign(sk, pk, encodeMessages(messages), header)
+	if err != nil {
+		return nil, err
+	}
+
+	return bbs.SerializeSignature(signature), nil
+}
+
+// Verify verifies a signature on messages, returning an error if it is
+// invalid
+func Verify(publicKey, signature []byte, messages *MessageList, header []byte) error {
+	pk, err := bbs.DeserializePublicKey(publicKey)
+	if err != nil {
+		return fmt.Errorf("invalid public key: %w", err)
+	}
+	sig, err := bbs.DeserializeSignature(signature)
+	if err != nil {
+		return fmt.Errorf("invalid signature: %w", err)
+	}
+
+	return bbs.Verify(pk, sig, encodeMessages(messages), header)
+}
+
+// CreateProof derives a proof disclosing the messages at the given indices
+func CreateProof(publicKey, signature []byte, messages *MessageList, disclosed *IndexList, header []byte) (*Proof, error) {
+	pk, err := bbs.DeserializePublicKey(publicKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid public key: %w", err)
+	}
+	sig, err := bbs.DeserializeSignature(signature)
+	if err != nil {
+		return nil, fmt.Errorf("invalid signature: %w", err)
+	}
+
+	var indices []int
+	if disclosed != nil {
+		indices = disclosed.indices
+	}
+
+	proof, _, err := bbs.CreateProof(pk, sig, encodeMessages(messages), indices, header)
+	if err != nil {
+		return nil, err
+	}
+
+	// Disclose the raw messages, which the verifier maps itself
+	result := &Proof{Proof: bbs.SerializeProof(proof), Disclosed: NewDisclosedMessages()}
+	for _, idx := range indices {
+		result.Disclosed.Add(idx, messages.messages[idx])
+	}
+
+	return result, nil
+}
+
+// VerifyProof verifies a proof against the disclosed messages, returning an
+// error if it is invalid
+func VerifyProof(publicKey, proof []byte, disclosed *DisclosedMessages, header []byte) error {
+	pk, err := bbs.DeserializePublicKey(publicKey)
+	if err != nil {
+		return fmt.Errorf("invalid public key: %w", err)
+	}
+	p, err := bbs.DeserializeProof(proof)
+	if err != nil {
+		return fmt.Errorf("invalid proof: %w", err)
+	}
+
+	disclosedMessages := make(map[int]*big.Int)
+	if disclosed != nil {
+		for i, idx := range disclosed.indices {
+			if _, dup := disclosedMessages[idx]; dup {
+				return fmt.Errorf("message %d disclosed more than once", idx)
+			}
+			disclosedMessages[idx] = bbs.MessageToFieldElement(disclosed.messages[i])
+		}
+	}
+
+	return bbs.VerifyProof(pk, p, disclosedMessages, header)
+}
+
+// Version returns the library's build metadata as a JSON object with
+// version, commit, buildDate, goVersion and ciphersuites
+func Version() string {
+	out, err := json.Marshal(version.Get())
+	if err != nil {
+		return "{}"
+	}
+	return string(out)
+}
+
+// encodeMessages maps raw messages to field elements
+func encodeMessages(messages *MessageList) []*big.Int {
+	if messages == nil {
+		return nil
+	}
+
+	encoded := make([]*big.Int, len(messages.messages))
+	for i, msg := range messages.messages {
+		encoded[i] = bbs.MessageToFieldElement(msg)
+	}
+	return encoded
+}
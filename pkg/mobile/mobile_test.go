@@ -0,0 +1,69 @@
+package mobile
+
+import "testing"
+
+func TestMobileFlow(t *testing.T) {
+	messages := NewMessageList()
+	for _, msg := range []string{"alice", "1990-01-01", "NL"} {
+		messages.Add([]byte(msg))
+	}
+	header := []byte("wallet")
+
+	keyPair, err := GenerateKeyPair(messages.Len())
+	if err != nil {
+		t.Fatalf("GenerateKeyPair failed: %v", err)
+	}
+
+	signature, err := Sign(keyPair.PrivateKey, keyPair.PublicKey, messages, header)
+	if err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+	if err := Verify(keyPair.PublicKey, signature, messages, header); err != nil {
+		t.Fatalf("Verify failed: %v", err)
+	}
+
+	disclose := NewIndexList()
+	disclose.Add(2)
+	disclose.Add(0)
+
+	proof, err := CreateProof(keyPair.PublicKey, signature, messages, disclose, header)
+	if err != nil {
+		t.Fatalf("CreateProof failed: %v", err)
+	}
+	if proof.Disclosed.Len() != 2 {
+		t.Fatalf("proof discloses %d messages, want 2", proof.Disclosed.Len())
+	}
+	if msg, _ := proof.Disclosed.Message(0); string(msg) != "NL" {
+		t.Fatalf("first disclosed message is %q, want NL", msg)
+	}
+
+	if err := VerifyProof(keyPair.PublicKey, proof.Proof, proof.Disclosed, header); err != nil {
+		t.Fatalf("VerifyProof failed: %v", err)
+	}
+
+	// The verifier rebuilds the disclosed messages from what it was sent
+	forged := NewDisclosedMessages()
+	forged.Add(2, []byte("US"))
+	forged.Add(0, []byte("alice"))
+	if err := VerifyProof(keyPair.PublicKey, proof.Proof, forged, header); err == nil {
+		t.Fatal("VerifyProof accepted a changed message")
+	}
+
+	// Nothing disclosed
+	proof, err = CreateProof(keyPair.PublicKey, signature, messages, nil, header)
+	if err != nil {
+		t.Fatalf("CreateProof without disclosure failed: %v", err)
+	}
+	if err := VerifyProof(keyPair.PublicKey, proof.Proof, nil, header); err != nil {
+		t.Fatalf("VerifyProof without disclosure failed: %v", err)
+	}
+
+	// Out of range accessors fail instead of panicking
+	if _, err := messages.Get(3); err == nil {
+		t.Fatal("Get out of range succeeded")
+	}
+	disclose.Add(7)
+	if _, err := CreateProof(keyPair.PublicKey, signature, messages, disclose, header); err == nil {
+		t.Fatal("CreateProof accepted an out of range index")
+	}
+}
@@ -0,0 +1,66 @@
+package multiformat
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// base58btc is the Bitcoin base58 alphabet
+const base58btc = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+// bigDigits are the digits big.Int uses for base 58, in the same order
+const bigDigits = "0123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUV"
+
+// Conversion between the two digit sets of base 58. big.Int does the
+// arithmetic, which stays fast for large inputs.
+var (
+	toBase58BTC   = strings.NewReplacer(pairs(bigDigits, base58btc)...)
+	fromBase58BTC = strings.NewReplacer(pairs(base58btc, bigDigits)...)
+)
+
+// pairs returns the replacer arguments mapping each digit of from to the
+// digit of to at the same position
+func pairs(from, to string) []string {
+	p := make([]string, 0, 2*len(from))
+	for i := range from {
+		p = append(p, from[i:i+1], to[i:i+1])
+	}
+	return p
+}
+
+// encodeBase58 encodes data in base58btc. Each leading zero byte becomes a
+// leading '1'.
+func encodeBase58(data []byte) string {
+	zeros := 0
+	for zeros < len(data) && data[zeros] == 0 {
+		zeros++
+	}
+	s := strings.Repeat("1", zeros)
+	if zeros == len(data) {
+		return s
+	}
+	return s + toBase58BTC.Replace(new(big.Int).SetBytes(data[zeros:]).Text(58))
+}
+
+// decodeBase58 decodes base58btc
+func decodeBase58(s string) ([]byte, error) {
+	if i := strings.IndexFunc(s, func(r rune) bool { return !strings.ContainsRune(base58btc, r) }); i >= 0 {
+		return nil, fmt.Errorf("invalid base58 character at %d", i)
+	}
+
+	zeros := 0
+	for zeros < len(s) && s[zeros] == '1' {
+		zeros++
+	}
+	data := make([]byte, zeros)
+	if zeros == len(s) {
+		return data, nil
+	}
+
+	n, ok := new(big.Int).SetString(fromBase58BTC.Replace(s[zeros:]), 58)
+	if !ok {
+		return nil, fmt.Errorf("invalid base58 value")
+	}
+	return append(data, n.Bytes()...), nil
+}
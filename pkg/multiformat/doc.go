@@ -0,0 +1,24 @@
+// Package multiformat wraps BBS+ keys, signatures and proofs in
+// self-describing multibase strings.
+//
+// Each artifact is prefixed with an unsigned-varint multicodec code naming
+// what it is, and the result is multibase encoded: base58btc ('z') by
+// default, or unpadded base64url ('u'). A string pasted into the wrong field
+// then fails with the codec it holds instead of deserializing as garbage,
+// and tools that speak multiformats can tell the artifacts apart.
+//
+// Private keys use the registered bls12_381-g2-priv code. The multicodec
+// table has no codes for BBS+ public keys with their generators, signatures
+// or proofs, so those use codes from its private-use range; other systems
+// reading them must be told the codes in this package.
+//
+// Example usage:
+//
+//	s := multiformat.FormatProof(proof) // "z..."
+//	proof, err := multiformat.ParseProof(s)
+//
+//	codec, data, err := multiformat.Decode(s)
+//	fmt.Println(multiformat.CodecName(codec)) // "bbs-proof"
+//
+// Stability: stable
+package multiformat
@@ -0,0 +1,188 @@
+package multiformat
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/anupsv/bbsplus-signatures/bbs"
+)
+
+// Multicodec codes of the artifacts
+const (
+	// CodecBLS12381G2Priv is the registered bls12_381-g2-priv code
+	CodecBLS12381G2Priv uint64 = 0x130a
+
+	// Private-use codes for BBS+ artifacts in the encodings of package bbs
+	CodecPublicKey uint64 = 0x3b5001
+	CodecSignature uint64 = 0x3b5002
+	CodecProof     uint64 = 0x3b5003
+)
+
+// codecNames names the known codes
+var codecNames = map[uint64]string{
+	CodecBLS12381G2Priv: "bls12_381-g2-priv",
+	CodecPublicKey:      "bbs-public-key",
+	CodecSignature:      "bbs-signature",
+	CodecProof:          "bbs-proof",
+}
+
+// Multibase prefixes
+const (
+	Base58BTC    byte = 'z'
+	Base64URL    byte = 'u'
+	DefaultBase       = Base58BTC
+	maxVarintLen      = 9
+)
+
+var (
+	// ErrInvalidMultibase is returned for strings that are not multibase in
+	// a supported base
+	ErrInvalidMultibase = errors.New("invalid multibase value")
+
+	// ErrUnexpectedCodec is returned when a value holds another artifact
+	// than the one asked for
+	ErrUnexpectedCodec = errors.New("unexpected multicodec")
+)
+
+// CodecName returns the name of a code, or its hex value if unknown
+func CodecName(codec uint64) string {
+	if name, ok := codecNames[codec]; ok {
+		return name
+	}
+	return fmt.Sprintf("0x%x", codec)
+}
+
+// Encode prefixes data with codec and encodes it in base, Base58BTC or
+// Base64URL
+func Encode(codec uint64, data []byte, base byte) (string, error) {
+	tagged := binary.AppendUvarint(make([]byte, 0, maxVarintLen+len(data)), codec)
+	tagged = append(tagged, data...)
+
+	switch base {
+	case Base58BTC:
+		return string(base) + encodeBase58(tagged), nil
+	case Base64URL:
+		return string(base) + base64.RawURLEncoding.EncodeToString(tagged), nil
+	default:
+		return "", fmt.Errorf("%w: unsupported base '%c'", ErrInvalidMultibase, base)
+	}
+}
+
+// Decode decodes a multibase string and splits off its multicodec code
+func Decode(s string) (uint64, []byte, error) {
+	if s == "" {
+		return 0, nil, fmt.Errorf("%w: empty value", ErrInvalidMultibase)
+	}
+	if len(s) > maxEncodedLen() {
+		return 0, nil, fmt.Errorf("%w: %d characters", bbs.ErrLimitExceeded, len(s))
+	}
+
+	var tagged []byte
+	var err error
+	switch s[0] {
+	case Base58BTC:
+		tagged, err = decodeBase58(s[1:])
+	case Base64URL:
+		tagged, err = base64.RawURLEncoding.DecodeString(s[1:])
+	default:
+		return 0, nil, fmt.Errorf("%w: unsupported base '%c'", ErrInvalidMultibase, s[0])
+	}
+	if err != nil {
+		return 0, nil, fmt.Errorf("%w: %v", ErrInvalidMultibase, err)
+	}
+
+	codec, n := binary.Uvarint(tagged)
+	if n <= 0 {
+		return 0, nil, fmt.Errorf("%w: missing multicodec prefix", ErrInvalidMultibase)
+	}
+	return codec, tagged[n:], nil
+}
+
+// decodeAs decodes s and checks that it holds codec
+func decodeAs(s string, codec uint64) ([]byte, error) {
+	got, data, err := Decode(s)
+	if err != nil {
+		return nil, err
+	}
+	if got != codec {
+		return nil, fmt.Errorf("%w: expected %s, got %s", ErrUnexpectedCodec, CodecName(codec), CodecName(got))
+	}
+	return data, nil
+}
+
+// maxEncodedLen bounds the length of a string Decode accepts by the largest
+// artifact bbs.DefaultLimits allows, so oversized input is refused before
+// it is decoded
+func maxEncodedLen() int {
+	limits := bbs.DefaultLimits
+	size := max(limits.MaxProofBytes, 2*bbs.G2Size+4+bbs.G1Size+(limits.MaxMessageCount+2)*bbs.G1Size)
+	// base58 takes log(256)/log(58) < 1.37 characters a byte
+	return 1 + (size+maxVarintLen)*137/100 + 1
+}
+
+// mustEncode encodes with DefaultBase, which cannot fail
+func mustEncode(codec uint64, data []byte) string {
+	s, err := Encode(codec, data, DefaultBase)
+	if err != nil {
+		panic("multiformat: " + err.Error())
+	}
+	return s
+}
+
+// FormatPublicKey encodes a public key
+func FormatPublicKey(pk *bbs.PublicKey) string {
+	return mustEncode(CodecPublicKey, bbs.SerializePublicKey(pk))
+}
+
+// ParsePublicKey decodes a public key written by FormatPublicKey
+func ParsePublicKey(s string) (*bbs.PublicKey, error) {
+	data, err := decodeAs(s, CodecPublicKey)
+	if err != nil {
+		return nil, err
+	}
+	return bbs.DeserializePublicKey(data)
+}
+
+// FormatPrivateKey encodes a private key
+func FormatPrivateKey(sk *bbs.PrivateKey) string {
+	return mustEncode(CodecBLS12381G2Priv, bbs.SerializePrivateKey(sk))
+}
+
+// ParsePrivateKey decodes a private key written by FormatPrivateKey
+func ParsePrivateKey(s string) (*bbs.PrivateKey, error) {
+	data, err := decodeAs(s, CodecBLS12381G2Priv)
+	if err != nil {
+		return nil, err
+	}
+	return bbs.DeserializePrivateKey(data)
+}
+
+// FormatSignature encodes a signature
+func FormatSignature(sig *bbs.Signature) string {
+	return mustEncode(CodecSignature, bbs.SerializeSignature(sig))
+}
+
+// ParseSignature decodes a signature written by FormatSignature
+func ParseSignature(s string) (*bbs.Signature, error) {
+	data, err := decodeAs(s, CodecSignature)
+	if err != nil {
+		return nil, err
+	}
+	return bbs.DeserializeSignature(data)
+}
+
+// FormatProof encodes a proof in the compact encoding of bbs.SerializeProof
+func FormatProof(proof *bbs.ProofOfKnowledge) string {
+	return mustEncode(CodecProof, bbs.SerializeProof(proof))
+}
+
+// ParseProof decodes a proof written by FormatProof
+func ParseProof(s string) (*bbs.ProofOfKnowledge, error) {
+	data, err := decodeAs(s, CodecProof)
+	if err != nil {
+		return nil, err
+	}
+	return bbs.DeserializeProof(data)
+}
@@ -0,0 +1,121 @@
+package multiformat
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/anupsv/bbsplus-signatures/bbs"
+)
+
+func TestBase58(t *testing.T) {
+	// "Hello World!" is the example of the multibase specification
+	for data, want := range map[string]string{
+		"Hello World!":   "2NEpo7TZRRrLZSi2U",
+		"\x00yes mani !": "17paNL19xttacUY",
+		"\x00\x00":       "11",
+		"":               "",
+	} {
+		if got := encodeBase58([]byte(data)); got != want {
+			t.Fatalf("encodeBase58(%q) = %s, want %s", data, got, want)
+		}
+		decoded, err := decodeBase58(want)
+		if err != nil {
+			t.Fatalf("decodeBase58(%s) failed: %v", want, err)
+		}
+		if !bytes.Equal(decoded, []byte(data)) {
+			t.Fatalf("decodeBase58(%s) = %q, want %q", want, decoded, data)
+		}
+	}
+
+	for _, bad := range []string{"0", "O", "I", "l", "2NEpo7TZRRrLZSi2U+"} {
+		if _, err := decodeBase58(bad); err == nil {
+			t.Fatalf("decodeBase58(%q) accepted an invalid character", bad)
+		}
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	keyPair, err := bbs.GenerateKeyPair(4, rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKeyPair failed: %v", err)
+	}
+	msgs := []*big.Int{big.NewInt(1), big.NewInt(2), big.NewInt(3), big.NewInt(4)}
+	signature, err := bbs.Sign(keyPair.PrivateKey, keyPair.PublicKey, msgs, nil)
+	if err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+	proof, disclosed, err := bbs.CreateProof(keyPair.PublicKey, signature, msgs, []int{0, 2}, nil)
+	if err != nil {
+		t.Fatalf("CreateProof failed: %v", err)
+	}
+
+	// Private keys carry the registered bls12_381-g2-priv prefix 0x8a26
+	sk := FormatPrivateKey(keyPair.PrivateKey)
+	_, data, err := Decode(sk)
+	if err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+	tagged, _ := decodeBase58(sk[1:])
+	if !bytes.Equal(tagged[:2], []byte{0x8a, 0x26}) || len(data) != bbs.ScalarSize {
+		t.Fatalf("Private key encoded as %x", tagged)
+	}
+
+	pk, err := ParsePublicKey(FormatPublicKey(keyPair.PublicKey))
+	if err != nil {
+		t.Fatalf("ParsePublicKey failed: %v", err)
+	}
+	privateKey, err := ParsePrivateKey(sk)
+	if err != nil || privateKey.X.Cmp(keyPair.PrivateKey.X) != 0 {
+		t.Fatalf("ParsePrivateKey failed: %v", err)
+	}
+	sig, err := ParseSignature(FormatSignature(signature))
+	if err != nil {
+		t.Fatalf("ParseSignature failed: %v", err)
+	}
+	if err := bbs.Verify(pk, sig, msgs, nil); err != nil {
+		t.Fatalf("Verify after round trip failed: %v", err)
+	}
+	formatted := FormatProof(proof)
+	if !strings.HasPrefix(formatted, "z") {
+		t.Fatalf("Expected base58btc, got %s", formatted[:1])
+	}
+	p, err := ParseProof(formatted)
+	if err != nil {
+		t.Fatalf("ParseProof failed: %v", err)
+	}
+	if err := bbs.VerifyProof(pk, p, disclosed, nil); err != nil {
+		t.Fatalf("VerifyProof after round trip failed: %v", err)
+	}
+
+	// base64url decodes the same
+	b64, err := Encode(CodecProof, bbs.SerializeProof(proof), Base64URL)
+	if err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+	if _, err := ParseProof(b64); err != nil {
+		t.Fatalf("ParseProof of base64url failed: %v", err)
+	}
+
+	// A value of another artifact names what it holds
+	if _, err := ParseSignature(formatted); !errors.Is(err, ErrUnexpectedCodec) || !strings.Contains(err.Error(), "bbs-proof") {
+		t.Fatalf("Expected ErrUnexpectedCodec naming bbs-proof, got %v", err)
+	}
+
+	for name, s := range map[string]string{
+		"empty":     "",
+		"base16":    "f00",
+		"no codec":  "z",
+		"bad digit": "z0OIl",
+	} {
+		if _, _, err := Decode(s); !errors.Is(err, ErrInvalidMultibase) {
+			t.Fatalf("%s: expected ErrInvalidMultibase, got %v", name, err)
+		}
+	}
+	if _, _, err := Decode("z" + strings.Repeat("2", maxEncodedLen())); !errors.Is(err, bbs.ErrLimitExceeded) {
+		t.Fatalf("Expected ErrLimitExceeded, got %v", err)
+	}
+}
@@ -0,0 +1,240 @@
+package pkcs11
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/anupsv/bbsplus-signatures/bbs"
+	"github.com/anupsv/bbsplus-signatures/internal/secret"
+	bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381"
+)
+
+var (
+	// ErrBackendClosed is returned when a closed backend is used
+	ErrBackendClosed = errors.New("pkcs11 backend is closed")
+
+	// ErrKeyMismatch is returned when the wrapped key does not match the public key
+	ErrKeyMismatch = errors.New("wrapped private key does not match public key")
+)
+
+// Session is the subset of a logged-in PKCS#11 session the backend needs
+type Session interface {
+	// Encrypt encrypts plaintext with the secret key labelled keyLabel
+	Encrypt(keyLabel string, plaintext []byte) ([]byte, error)
+
+	// Decrypt decrypts ciphertext with the secret key labelled keyLabel
+	Decrypt(keyLabel string, ciphertext []byte) ([]byte, error)
+
+	// Close logs out and closes the session
+	Close() error
+}
+
+// Module is a loaded PKCS#11 library
+type Module interface {
+	// OpenSession opens a session on slot and logs in with pin
+	OpenSession(slot uint, pin string) (Session, error)
+
+	// Close finalizes the library
+	Close() error
+}
+
+// Config selects the token and wrapping key used by the backend
+type Config struct {
+	// Slot is the PKCS#11 slot ID of the token
+	Slot uint
+
+	// PIN is the user PIN of the token
+	PIN string
+
+	// KeyLabel is the CKA_LABEL of the secret key wrapping the BBS+ private key
+	KeyLabel string
+
+	// MaxSessions bounds the number of concurrently open sessions
+	MaxSessions int
+}
+
+// Backend is a bbs.SignerBackend backed by a PKCS#11 token
+type Backend struct {
+	module     Module
+	cfg        Config
+	wrappedKey []byte
+	publicKey  *bbs.PublicKey
+
+	// Pool of idle sessions and a semaphore bounding open sessions
+	mu     sync.Mutex
+	idle   []Session
+	sem    chan struct{}
+	closed bool
+}
+
+// Backend implements bbs.SignerBackend
+var _ bbs.SignerBackend = (*Backend)(nil)
+
+// NewBackend creates a backend for a private key wrapped with WrapPrivateKey.
+// The wrapped key is unwrapped once to check that it matches publicKey.
+func NewBackend(module Module, cfg Config, wrappedKey []byte, publicKey *bbs.PublicKey) (*Backend, error) {
+	if module == nil || publicKey == nil || len(wrappedKey) == 0 {
+		return nil, fmt.Errorf("module, wrapped key and public key are required")
+	}
+
+	if cfg.KeyLabel == "" {
+		return nil, fmt.Errorf("wrapping key label is required")
+	}
+
+	if cfg.MaxSessions <= 0 {
+		cfg.MaxSessions = 4 // Default session pool size
+	}
+
+	b := &Backend{
+		module:     module,
+		cfg:        cfg,
+		wrappedKey: append([]byte{}, wrappedKey...),
+		publicKey:  publicKey,
+		sem:        make(chan struct{}, cfg.MaxSessions),
+	}
+
+	// Check the wrapped key against the public key: W must equal G2 * x
+	err := b.withPrivateKey(func(sk *bbs.PrivateKey) error {
+		g2, w := publicKey.G2(), publicKey.W()
+		var derived bls12381.G2Affine
+		derived.ScalarMultiplication(&g2, sk.X)
+		if !derived.Equal(&w) {
+			return ErrKeyMismatch
+		}
+		return nil
+	})
+	if err != nil {
+		b.Close()
+		return nil, err
+	}
+
+	return b, nil
+}
+
+// WrapPrivateKey encrypts privateKey under the token key named in cfg. The
+// result is safe to store alongside the public key.
+func WrapPrivateKey(module Module, cfg Config, privateKey *bbs.PrivateKey) ([]byte, error) {
+	session, err := module.OpenSession(cfg.Slot, cfg.PIN)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open session: %w", err)
+	}
+	defer session.Close()
+
+	plaintext := bbs.SerializePrivateKey(privateKey)
+	defer secret.WipeBytes(plaintext)
+
+	wrapped, err := session.Encrypt(cfg.KeyLabel, plaintext)
+	if err != nil {
+		return nil, fmt.Errorf("failed to wrap private key: %w", err)
+	}
+
+	return wrapped, nil
+}
+
+// PublicKey returns the public key matching the wrapped private key
+func (b *Backend) PublicKey() *bbs.PublicKey {
+	return b.publicKey
+}
+
+// Sign unwraps the private key inside a token session and signs messages
+func (b *Backend) Sign(messages []*big.Int, header []byte) (*bbs.Signature, error) {
+	var signature *bbs.Signature
+	err := b.withPrivateKey(func(sk *bbs.PrivateKey) error {
+		var err error
+		signature, err = bbs.Sign(sk, b.publicKey, messages, header)
+		return err
+	})
+	return signature, err
+}
+
+// Close closes all idle sessions. Sessions in use are closed when released.
+func (b *Backend) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+
+	var firstErr error
+	for _, session := range b.idle {
+		if err := session.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	b.idle = nil
+
+	return firstErr
+}
+
+// withPrivateKey unwraps the private key, passes it to fn and wipes it afterwards
+func (b *Backend) withPrivateKey(fn func(sk *bbs.PrivateKey) error) error {
+	session, err := b.acquire()
+	if err != nil {
+		return err
+	}
+
+	plaintext, err := session.Decrypt(b.cfg.KeyLabel, b.wrappedKey)
+	if err != nil {
+		// The session may be unusable, do not return it to the pool
+		b.discard(session)
+		return fmt.Errorf("failed to unwrap private key: %w", err)
+	}
+	b.release(session)
+	defer secret.WipeBytes(plaintext)
+
+	sk, err := bbs.DeserializePrivateKey(plaintext)
+	if err != nil {
+		return fmt.Errorf("failed to decode unwrapped private key: %w", err)
+	}
+	defer secret.WipeInt(sk.X)
+
+	return fn(sk)
+}
+
+// acquire returns an idle session or opens a new one, blocking while
+// MaxSessions sessions are in use
+func (b *Backend) acquire() (Session, error) {
+	b.sem <- struct{}{}
+
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		<-b.sem
+		return nil, ErrBackendClosed
+	}
+	if n := len(b.idle); n > 0 {
+		session := b.idle[n-1]
+		b.idle = b.idle[:n-1]
+		b.mu.Unlock()
+		return session, nil
+	}
+	b.mu.Unlock()
+
+	session, err := b.module.OpenSession(b.cfg.Slot, b.cfg.PIN)
+	if err != nil {
+		<-b.sem
+		return nil, fmt.Errorf("failed to open session: %w", err)
+	}
+
+	return session, nil
+}
+
+// release returns a session to the pool
+func (b *Backend) release(session Session) {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		session.Close()
+	} else {
+		b.idle = append(b.idle, session)
+		b.mu.Unlock()
+	}
+	<-b.sem
+}
+
+// discard closes a session instead of returning it to the pool
+func (b *Backend) discard(session Session) {
+	session.Close()
+	<-b.sem
+}
@@ -0,0 +1,181 @@
+package pkcs11
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/anupsv/bbsplus-signatures/bbs"
+)
+
+// softModule emulates a token holding AES-GCM secret keys
+type softModule struct {
+	keys    map[string][]byte
+	pin     string
+	open    int32
+	maxOpen int32
+}
+
+func newSoftModule(t *testing.T) *softModule {
+	t.Helper()
+
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		t.Fatalf("Failed to generate wrapping key: %v", err)
+	}
+
+	return &softModule{keys: map[string][]byte{"bbs-wrap": key}, pin: "1234"}
+}
+
+func (m *softModule) OpenSession(slot uint, pin string) (Session, error) {
+	if pin != m.pin {
+		return nil, errors.New("CKR_PIN_INCORRECT")
+	}
+
+	open := atomic.AddInt32(&m.open, 1)
+	for {
+		max := atomic.LoadInt32(&m.maxOpen)
+		if open <= max || atomic.CompareAndSwapInt32(&m.maxOpen, max, open) {
+			break
+		}
+	}
+
+	return &softSession{module: m}, nil
+}
+
+func (m *softModule) Close() error {
+	return nil
+}
+
+type softSession struct {
+	module *softModule
+}
+
+func (s *softSession) aead(label string) (cipher.AEAD, error) {
+	key, ok := s.module.keys[label]
+	if !ok {
+		return nil, errors.New("CKR_KEY_HANDLE_INVALID")
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+func (s *softSession) Encrypt(label string, plaintext []byte) ([]byte, error) {
+	aead, err := s.aead(label)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	return aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+func (s *softSession) Decrypt(label string, ciphertext []byte) ([]byte, error) {
+	aead, err := s.aead(label)
+	if err != nil {
+		return nil, err
+	}
+
+	n := aead.NonceSize()
+	if len(ciphertext) < n {
+		return nil, errors.New("CKR_ENCRYPTED_DATA_INVALID")
+	}
+
+	return aead.Open(nil, ciphertext[:n], ciphertext[n:], nil)
+}
+
+func (s *softSession) Close() error {
+	atomic.AddInt32(&s.module.open, -1)
+	return nil
+}
+
+func TestBackendSign(t *testing.T) {
+	module := newSoftModule(t)
+	cfg := Config{PIN: "1234", KeyLabel: "bbs-wrap", MaxSessions: 2}
+
+	keyPair, err := bbs.GenerateKeyPair(3, rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key pair: %v", err)
+	}
+
+	wrapped, err := WrapPrivateKey(module, cfg, keyPair.PrivateKey)
+	if err != nil {
+		t.Fatalf("WrapPrivateKey failed: %v", err)
+	}
+
+	backend, err := NewBackend(module, cfg, wrapped, keyPair.PublicKey)
+	if err != nil {
+		t.Fatalf("NewBackend failed: %v", err)
+	}
+	defer backend.Close()
+
+	messages := []*big.Int{big.NewInt(1), big.NewInt(2), big.NewInt(3)}
+
+	// Sign concurrently to exercise the session pool
+	var wg sync.WaitGroup
+	errs := make(chan error, 8)
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			signature, err := backend.Sign(messages, []byte("header"))
+			if err == nil {
+				err = bbs.Verify(keyPair.PublicKey, signature, messages, []byte("header"))
+			}
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("Backend signature failed: %v", err)
+		}
+	}
+
+	if max := atomic.LoadInt32(&module.maxOpen); max > int32(cfg.MaxSessions) {
+		t.Fatalf("Opened %d concurrent sessions, limit is %d", max, cfg.MaxSessions)
+	}
+}
+
+func TestNewBackendRejectsMismatchedKey(t *testing.T) {
+	module := newSoftModule(t)
+	cfg := Config{PIN: "1234", KeyLabel: "bbs-wrap"}
+
+	keyPair, err := bbs.GenerateKeyPair(2, rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key pair: %v", err)
+	}
+	otherKeyPair, err := bbs.GenerateKeyPair(2, rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key pair: %v", err)
+	}
+
+	wrapped, err := WrapPrivateKey(module, cfg, keyPair.PrivateKey)
+	if err != nil {
+		t.Fatalf("WrapPrivateKey failed: %v", err)
+	}
+
+	if _, err := NewBackend(module, cfg, wrapped, otherKeyPair.PublicKey); !errors.Is(err, ErrKeyMismatch) {
+		t.Fatalf("NewBackend with wrong public key: got %v, want %v", err, ErrKeyMismatch)
+	}
+
+	if _, err := NewBackend(module, Config{PIN: "0000", KeyLabel: "bbs-wrap"}, wrapped, keyPair.PublicKey); err == nil {
+		t.Fatal("NewBackend should fail with a wrong PIN")
+	}
+}
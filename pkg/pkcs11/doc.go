@@ -0,0 +1,27 @@
+// Package pkcs11 provides a bbs.SignerBackend that keeps the issuer's BBS+
+// private key in a PKCS#11 token.
+//
+// HSMs rarely support BLS12-381 scalar arithmetic, so the backend stores the
+// private key wrapped (encrypted) under a secret key that never leaves the
+// token. On each signature the wrapped key is unwrapped inside a pooled
+// session, used, and wiped from memory.
+//
+// The backend itself works against the Module interface. The binding to a
+// real PKCS#11 library, github.com/miekg/pkcs11, lives behind the pkcs11
+// build tag, so the default build stays free of cgo:
+//
+//     go build -tags pkcs11 ./...
+//
+// Example usage:
+//
+//     module, err := pkcs11.OpenModule("/usr/lib/softhsm/libsofthsm2.so")
+//     cfg := pkcs11.Config{Slot: 0, PIN: "1234", KeyLabel: "bbs-wrap", MaxSessions: 4}
+//
+//     // One-time provisioning of the wrapped private key
+//     wrapped, err := pkcs11.WrapPrivateKey(module, cfg, keyPair.PrivateKey)
+//
+//     backend, err := pkcs11.NewBackend(module, cfg, wrapped, keyPair.PublicKey)
+//     signature, err := backend.Sign(messages, header)
+//
+// Stability: experimental. The API may change in a minor release.
+package pkcs11
@@ -0,0 +1,157 @@
+//go:build pkcs11
+
+package pkcs11
+
+import (
+	"crypto/rand"
+	"fmt"
+	"sync"
+
+	p11 "github.com/miekg/pkcs11"
+)
+
+// ivSize is the AES block size used for CBC initialization vectors
+const ivSize = 16
+
+// cryptokiModule is a Module backed by a PKCS#11 shared library. The login
+// state belongs to the application and the token, not to one session, so
+// the module logs in with the first session on a slot and logs out once, in
+// Close.
+type cryptokiModule struct {
+	ctx *p11.Ctx
+
+	mu       sync.Mutex
+	loggedIn map[uint]bool
+}
+
+// OpenModule loads and initializes the PKCS#11 library at path
+func OpenModule(path string) (Module, error) {
+	ctx := p11.New(path)
+	if ctx == nil {
+		return nil, fmt.Errorf("failed to load PKCS#11 module %s", path)
+	}
+
+	if err := ctx.Initialize(); err != nil {
+		ctx.Destroy()
+		return nil, fmt.Errorf("failed to initialize PKCS#11 module: %w", err)
+	}
+
+	return &cryptokiModule{ctx: ctx, loggedIn: make(map[uint]bool)}, nil
+}
+
+// OpenSession opens a read-write session on slot and logs in as the user
+func (m *cryptokiModule) OpenSession(slot uint, pin string) (Session, error) {
+	handle, err := m.ctx.OpenSession(slot, p11.CKF_SERIAL_SESSION|p11.CKF_RW_SESSION)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := m.ctx.Login(handle, p11.CKU_USER, pin); err != nil && err != p11.Error(p11.CKR_USER_ALREADY_LOGGED_IN) {
+		m.ctx.CloseSession(handle)
+		return nil, err
+	}
+	m.mu.Lock()
+	m.loggedIn[slot] = true
+	m.mu.Unlock()
+
+	return &cryptokiSession{ctx: m.ctx, handle: handle}, nil
+}
+
+// Close logs out of every slot it logged in to, then finalizes and unloads
+// the library
+func (m *cryptokiModule) Close() error {
+	m.mu.Lock()
+	for slot := range m.loggedIn {
+		if handle, err := m.ctx.OpenSession(slot, p11.CKF_SERIAL_SESSION); err == nil {
+			m.ctx.Logout(handle)
+			m.ctx.CloseSession(handle)
+		}
+		delete(m.loggedIn, slot)
+	}
+	m.mu.Unlock()
+
+	err := m.ctx.Finalize()
+	m.ctx.Destroy()
+	return err
+}
+
+// cryptokiSession is a Session using AES-CBC with PKCS#7 padding in the token
+type cryptokiSession struct {
+	ctx    *p11.Ctx
+	handle p11.SessionHandle
+}
+
+// findKey looks up the secret key with the given label
+func (s *cryptokiSession) findKey(label string) (p11.ObjectHandle, error) {
+	template := []*p11.Attribute{
+		p11.NewAttribute(p11.CKA_CLASS, p11.CKO_SECRET_KEY),
+		p11.NewAttribute(p11.CKA_LABEL, label),
+	}
+
+	if err := s.ctx.FindObjectsInit(s.handle, template); err != nil {
+		return 0, err
+	}
+	objects, _, err := s.ctx.FindObjects(s.handle, 1)
+	if finalErr := s.ctx.FindObjectsFinal(s.handle); err == nil {
+		err = finalErr
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	if len(objects) == 0 {
+		return 0, fmt.Errorf("secret key %q not found", label)
+	}
+
+	return objects[0], nil
+}
+
+// Encrypt encrypts plaintext, returning the IV followed by the ciphertext
+func (s *cryptokiSession) Encrypt(keyLabel string, plaintext []byte) ([]byte, error) {
+	key, err := s.findKey(keyLabel)
+	if err != nil {
+		return nil, err
+	}
+
+	iv := make([]byte, ivSize)
+	if _, err := rand.Read(iv); err != nil {
+		return nil, err
+	}
+
+	mechanism := []*p11.Mechanism{p11.NewMechanism(p11.CKM_AES_CBC_PAD, iv)}
+	if err := s.ctx.EncryptInit(s.handle, mechanism, key); err != nil {
+		return nil, err
+	}
+
+	ciphertext, err := s.ctx.Encrypt(s.handle, plaintext)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(iv, ciphertext...), nil
+}
+
+// Decrypt decrypts data produced by Encrypt
+func (s *cryptokiSession) Decrypt(keyLabel string, ciphertext []byte) ([]byte, error) {
+	if len(ciphertext) <= ivSize {
+		return nil, fmt.Errorf("wrapped key too short")
+	}
+
+	key, err := s.findKey(keyLabel)
+	if err != nil {
+		return nil, err
+	}
+
+	mechanism := []*p11.Mechanism{p11.NewMechanism(p11.CKM_AES_CBC_PAD, ciphertext[:ivSize])}
+	if err := s.ctx.DecryptInit(s.handle, mechanism, key); err != nil {
+		return nil, err
+	}
+
+	return s.ctx.Decrypt(s.handle, ciphertext[ivSize:])
+}
+
+// Close closes the session. It does not log out, which would log out every
+// other session of the application on the token.
+func (s *cryptokiSession) Close() error {
+	return s.ctx.CloseSession(s.handle)
+}
@@ -0,0 +1,192 @@
+package proof
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/anupsv/bbsplus-signatures/bbs"
+)
+
+// ErrNotConfigured is the reason of checks the spec does not configure
+var ErrNotConfigured = errors.New("check not configured")
+
+// Status is the outcome of one check of a graded verification
+type Status int
+
+const (
+	// StatusUnknown means the check could not be made: it is not configured,
+	// its data source failed, or it depends on a signature that did not
+	// verify
+	StatusUnknown Status = iota
+
+	// StatusPass means the check passed
+	StatusPass
+
+	// StatusFail means the check failed
+	StatusFail
+)
+
+// String returns the name of the status
+func (s Status) String() string {
+	switch s {
+	case StatusPass:
+		return "pass"
+	case StatusFail:
+		return "fail"
+	default:
+		return "unknown"
+	}
+}
+
+// Outcome is the status of one check, with the reason if it did not pass
+type Outcome struct {
+	Status Status
+	Err    error
+}
+
+func pass() Outcome             { return Outcome{Status: StatusPass} }
+func fail(err error) Outcome    { return Outcome{Status: StatusFail, Err: err} }
+func unknown(err error) Outcome { return Outcome{Status: StatusUnknown, Err: err} }
+
+// Assessment grades a request check by check instead of stopping at the
+// first failure, so a relying party can apply its own risk policy, for
+// example accepting a valid proof while the revocation list is unreachable.
+type Assessment struct {
+	// Signature is the proof itself under the issuer key
+	Signature Outcome
+
+	// Revocation is the IsRevoked lookup of the disclosed sequence number
+	Revocation Outcome
+
+	// Schema is whether the proof discloses every required attribute and
+	// none of those that must stay hidden
+	Schema Outcome
+
+	// Freshness is the freshness policy on the disclosed messages
+	Freshness Outcome
+
+	// Trust is the trust registry's view of the issuer key
+	Trust Outcome
+}
+
+// Passed reports whether every configured check passed, which is when
+// Verify accepts the request
+func (a *Assessment) Passed() bool {
+	for _, o := range a.outcomes() {
+		if o.Status != StatusPass && !errors.Is(o.Err, ErrNotConfigured) {
+			return false
+		}
+	}
+	return true
+}
+
+// Err returns the reasons of the failed checks, or nil if none failed.
+// Unknown checks are not errors.
+func (a *Assessment) Err() error {
+	var errs []error
+	for _, o := range a.outcomes() {
+		if o.Status == StatusFail {
+			errs = append(errs, o.Err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (a *Assessment) outcomes() []Outcome {
+	return []Outcome{a.Signature, a.Revocation, a.Schema, a.Freshness, a.Trust}
+}
+
+// Assess checks a request like Verify but grades every check on its own.
+// Revocation and freshness read disclosed values, which only the signature
+// vouches for, so they are unknown unless the signature passes.
+func (c *CompiledSpec) Assess(req Request) *Assessment {
+	start := time.Now()
+	a := c.assess(req)
+	c.record(req, start, a.Passed(), a.Err())
+	return a
+}
+
+// assess grades a request without recording an event
+func (c *CompiledSpec) assess(req Request) *Assessment {
+	a := &Assessment{
+		Signature:  unknown(nil),
+		Revocation: unknown(fmt.Errorf("%w: no revocation check", ErrNotConfigured)),
+		Freshness:  unknown(fmt.Errorf("%w: no freshness policy", ErrNotConfigured)),
+		Trust:      unknown(fmt.Errorf("%w: no trust registry", ErrNotConfigured)),
+	}
+
+	a.Schema = pass()
+	for _, idx := range c.required {
+		if _, ok := req.Disclosed[idx]; !ok {
+			a.Schema = fail(fmt.Errorf("required message %d not disclosed", idx))
+			break
+		}
+	}
+
+	ctx, err := c.context(req.KeyID)
+	if err != nil {
+		a.Signature = unknown(err)
+		a.Trust = fail(err)
+		return a
+	}
+	if a.Schema.Status == StatusPass {
+		if err := c.hiding.check(req.Disclosed, ctx.PublicKey().MessageCount()); err != nil {
+			a.Schema = fail(err)
+		}
+	}
+
+	if c.trustRegistry != nil {
+		trusted, err := c.trustRegistry(req.KeyID, ctx.PublicKey())
+		switch {
+		case err != nil:
+			a.Trust = unknown(fmt.Errorf("trust registry lookup failed: %w", err))
+		case !trusted:
+			a.Trust = fail(fmt.Errorf("issuer key '%s' is not trusted", req.KeyID))
+		default:
+			a.Trust = pass()
+		}
+	}
+
+	presentationHeader := req.Nonce
+	if c.presentationHeader != nil {
+		presentationHeader = c.presentationHeader(req.Nonce)
+	}
+	err = c.checkLimits(req)
+	if err == nil {
+		err = ctx.VerifyProof(req.Proof, req.Disclosed, presentationHeader)
+	}
+	if err != nil {
+		a.Signature = fail(err)
+		if c.freshness != nil {
+			a.Freshness = unknown(fmt.Errorf("signature did not verify"))
+		}
+		if c.isRevoked != nil {
+			a.Revocation = unknown(fmt.Errorf("signature did not verify"))
+		}
+		return a
+	}
+	a.Signature = pass()
+
+	if c.freshness != nil {
+		if err := c.freshness.Check(req.Disclosed); err != nil {
+			a.Freshness = fail(err)
+		} else {
+			a.Freshness = pass()
+		}
+	}
+
+	if c.isRevoked != nil {
+		seq, err := bbs.ParseSequenceNumberMessage(req.Disclosed[c.freshness.SequenceIndex])
+		switch {
+		case err != nil:
+			a.Revocation = unknown(err)
+		case c.isRevoked(seq):
+			a.Revocation = fail(fmt.Errorf("credential %d is revoked", seq))
+		default:
+			a.Revocation = pass()
+		}
+	}
+
+	return a
+}
@@ -0,0 +1,133 @@
+package proof
+
+import (
+	"crypto/rand"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/anupsv/bbsplus-signatures/bbs"
+)
+
+func TestAssess(t *testing.T) {
+	trusted, err := bbs.GenerateKeyPair(4, rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKeyPair failed: %v", err)
+	}
+	delisted, err := bbs.GenerateKeyPair(4, rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKeyPair failed: %v", err)
+	}
+	unreachable, err := bbs.GenerateKeyPair(4, rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKeyPair failed: %v", err)
+	}
+
+	spec := &ProofSpec{
+		Keys: map[string]*bbs.PublicKey{
+			"trusted": trusted.PublicKey, "delisted": delisted.PublicKey, "unreachable": unreachable.PublicKey,
+		},
+		RequiredIndices: []int{1},
+		Freshness:       &bbs.FreshnessPolicy{TimestampIndex: 2, MaxAge: time.Hour, SequenceIndex: 3},
+		IsRevoked:       func(seq uint64) bool { return seq == 13 },
+		TrustRegistry: func(keyID string, _ *bbs.PublicKey) (bool, error) {
+			if keyID == "unreachable" {
+				return false, errors.New("registry timeout")
+			}
+			return keyID == "trusted", nil
+		},
+	}
+	compiled, err := spec.Compile()
+	if err != nil {
+		t.Fatalf("Compile failed: %v", err)
+	}
+
+	present := func(keyID string, keyPair *bbs.KeyPair, seq uint64, disclose []int) Request {
+		signature, messages := specCredential(t, keyPair, seq, nil)
+		proof, disclosed, err := bbs.CreateProofWithPresentationHeader(keyPair.PublicKey, signature, messages, disclose, nil, []byte("n"))
+		if err != nil {
+			t.Fatalf("CreateProofWithPresentationHeader failed: %v", err)
+		}
+		return Request{KeyID: keyID, Proof: proof, Disclosed: disclosed, Nonce: []byte("n")}
+	}
+
+	type grades struct{ signature, revocation, schema, freshness, trust Status }
+	for name, tc := range map[string]struct {
+		req    Request
+		want   grades
+		passed bool
+	}{
+		"all pass": {
+			req:    present("trusted", trusted, 1, []int{1, 2, 3}),
+			want:   grades{StatusPass, StatusPass, StatusPass, StatusPass, StatusPass},
+			passed: true,
+		},
+		"revoked": {
+			req:  present("trusted", trusted, 13, []int{1, 2, 3}),
+			want: grades{StatusPass, StatusFail, StatusPass, StatusPass, StatusPass},
+		},
+		"delisted issuer": {
+			req:  present("delisted", delisted, 1, []int{1, 2, 3}),
+			want: grades{StatusPass, StatusPass, StatusPass, StatusPass, StatusFail},
+		},
+		"registry down": {
+			req:  present("unreachable", unreachable, 1, []int{1, 2, 3}),
+			want: grades{StatusPass, StatusPass, StatusPass, StatusPass, StatusUnknown},
+		},
+		"sequence hidden": {
+			req:  present("trusted", trusted, 1, []int{1, 2}),
+			want: grades{StatusPass, StatusUnknown, StatusPass, StatusFail, StatusPass},
+		},
+		"missing required": {
+			req:  present("trusted", trusted, 1, []int{2, 3}),
+			want: grades{StatusPass, StatusPass, StatusFail, StatusPass, StatusPass},
+		},
+		"bad signature": {
+			req:  func() Request { r := present("trusted", trusted, 1, []int{1, 2, 3}); r.Nonce = []byte("x"); return r }(),
+			want: grades{StatusFail, StatusUnknown, StatusPass, StatusUnknown, StatusPass},
+		},
+		"unknown key": {
+			req:  func() Request { r := present("trusted", trusted, 1, []int{1, 2, 3}); r.KeyID = "other"; return r }(),
+			want: grades{StatusUnknown, StatusUnknown, StatusPass, StatusUnknown, StatusFail},
+		},
+	} {
+		a := compiled.Assess(tc.req)
+		got := grades{a.Signature.Status, a.Revocation.Status, a.Schema.Status, a.Freshness.Status, a.Trust.Status}
+		if got != tc.want {
+			t.Fatalf("%s: expected %v, got %v", name, tc.want, got)
+		}
+		if a.Passed() != tc.passed {
+			t.Fatalf("%s: expected Passed %v", name, tc.passed)
+		}
+
+		// Verify accepts exactly what passes
+		if err := compiled.Verify(tc.req); (err == nil) != tc.passed {
+			t.Fatalf("%s: Verify returned %v", name, err)
+		}
+	}
+}
+
+func TestAssessUnconfigured(t *testing.T) {
+	keyPair, err := bbs.GenerateKeyPair(4, rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKeyPair failed: %v", err)
+	}
+	signature, messages := specCredential(t, keyPair, 1, nil)
+
+	compiled, err := (&ProofSpec{Keys: map[string]*bbs.PublicKey{"": keyPair.PublicKey}}).Compile()
+	if err != nil {
+		t.Fatalf("Compile failed: %v", err)
+	}
+	proof, disclosed, err := bbs.CreateProofWithPresentationHeader(keyPair.PublicKey, signature, messages, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("CreateProofWithPresentationHeader failed: %v", err)
+	}
+
+	a := compiled.Assess(Request{Proof: proof, Disclosed: disclosed})
+	if !a.Passed() || a.Err() != nil {
+		t.Fatalf("Expected a pass, got %v", a.Err())
+	}
+	if a.Trust.Status != StatusUnknown || !errors.Is(a.Trust.Err, ErrNotConfigured) {
+		t.Fatalf("Expected an unconfigured trust check, got %v", a.Trust)
+	}
+}
@@ -0,0 +1,354 @@
+package proof
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/anupsv/bbsplus-signatures/bbs"
+	bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381"
+)
+
+// Disclosure errors. They are returned by Err as soon as they are known and
+// by Build.
+var (
+	// ErrDisclosureOutOfRange is returned for a disclosed index outside the
+	// signed messages
+	ErrDisclosureOutOfRange = errors.New("disclosed index out of range")
+
+	// ErrUnknownAttribute is returned by DiscloseByName for a name the
+	// schema does not have
+	ErrUnknownAttribute = errors.New("unknown attribute")
+)
+
+// Builder provides a fluent interface for creating selective disclosure proofs
+type Builder struct {
+	publicKey     *bbs.PublicKey
+	signature     *bbs.Signature
+	messages      []*big.Int
+	disclosed     map[int]bool
+	disclosureErr error
+	header        []byte
+	schemaHash    []byte
+	audience      string
+	nonce         []byte
+	holderBinding *bbs.HolderBinding
+	commitments   map[int]*bbs.CommitmentOpening
+	oneTimeShow   *oneTimeShow
+	channel       []byte
+	groups        groupSet
+	progress      func(stage string, done, total int)
+}
+
+// oneTimeShow is the serial message and epoch of a one-time-show proof
+type oneTimeShow struct {
+	index  int
+	epoch  []byte
+	serial bls12381.G1Affine
+}
+
+// NewBuilder creates a new proof builder
+func NewBuilder() *Builder {
+	return &Builder{}
+}
+
+// SetPublicKey sets the issuer public key the signature verifies under
+func (b *Builder) SetPublicKey(publicKey *bbs.PublicKey) *Builder {
+	b.publicKey = publicKey
+	return b
+}
+
+// SetSignature sets the signature to derive the proof from
+func (b *Builder) SetSignature(signature *bbs.Signature) *Builder {
+	b.signature = signature
+	return b
+}
+
+// SetMessages sets all signed messages
+func (b *Builder) SetMessages(messages []*big.Int) *Builder {
+	b.messages = messages
+	return b
+}
+
+// SetHeader sets the header the signature was created with
+func (b *Builder) SetHeader(header []byte) *Builder {
+	b.header = header
+	return b
+}
+
+// SetSchemaHash sets the hash of the schema the credential is bound to,
+// when the issuer signed under bbs.SchemaHeader(header, schemaHash)
+func (b *Builder) SetSchemaHash(schemaHash []byte) *Builder {
+	b.schemaHash = schemaHash
+	return b
+}
+
+// SetAudience sets the audience the credential is bound to, when the
+// issuer signed under bbs.AudienceHeader(header, audience)
+func (b *Builder) SetAudience(audience string) *Builder {
+	b.audience = audience
+	return b
+}
+
+// Disclose adds message indices to reveal in the proof. Disclosing an index
+// twice is the same as disclosing it once. An index that is negative, or
+// beyond the messages or public key already set, is rejected right away and
+// reported by Err and Build; others are checked again by Build.
+func (b *Builder) Disclose(indices ...int) *Builder {
+	for _, idx := range indices {
+		if err := b.checkDisclosure(idx); err != nil {
+			b.failDisclosure(err)
+			continue
+		}
+		if b.disclosed == nil {
+			b.disclosed = make(map[int]bool)
+		}
+		b.disclosed[idx] = true
+	}
+	return b
+}
+
+// DiscloseByName discloses the named attributes, where schema names the
+// signed messages in order
+func (b *Builder) DiscloseByName(schema []string, names ...string) *Builder {
+	for _, name := range names {
+		idx := indexOf(schema, name)
+		if idx < 0 {
+			b.failDisclosure(fmt.Errorf("%w: '%s'", ErrUnknownAttribute, name))
+			continue
+		}
+		b.Disclose(idx)
+	}
+	return b
+}
+
+// SetAttributeGroups sets the groups of the credential's schema, where
+// attributeNames names the signed messages in order. Build then refuses to
+// disclose part of a group that must be disclosed whole.
+func (b *Builder) SetAttributeGroups(attributeNames []string, groups ...AttributeGroup) *Builder {
+	set, err := resolveGroups(attributeNames, groups)
+	if err != nil {
+		b.failDisclosure(err)
+		return b
+	}
+	b.groups = set
+	return b
+}
+
+// DiscloseGroup discloses every attribute of the named groups, as set by
+// SetAttributeGroups
+func (b *Builder) DiscloseGroup(names ...string) *Builder {
+	for _, name := range names {
+		group, err := b.groups.lookup(name)
+		if err != nil {
+			b.failDisclosure(err)
+			continue
+		}
+		b.Disclose(group.indices...)
+	}
+	return b
+}
+
+// DisclosedIndices returns the indices to disclose in ascending order
+func (b *Builder) DisclosedIndices() []int {
+	indices := make([]int, 0, len(b.disclosed))
+	for idx := range b.disclosed {
+		indices = append(indices, idx)
+	}
+	sort.Ints(indices)
+	return indices
+}
+
+// ClearDisclosures removes every index to disclose, and any error a
+// disclosure caused
+func (b *Builder) ClearDisclosures() *Builder {
+	b.disclosed = nil
+	b.disclosureErr = nil
+	return b
+}
+
+// Err returns the first error a disclosure caused since the last
+// ClearDisclosures, or nil
+func (b *Builder) Err() error {
+	return b.disclosureErr
+}
+
+// checkDisclosure checks idx against the message count known so far
+func (b *Builder) checkDisclosure(idx int) error {
+	if count := b.messageCount(); idx < 0 || (count >= 0 && idx >= count) {
+		return fmt.Errorf("%w: %d", ErrDisclosureOutOfRange, idx)
+	}
+	return nil
+}
+
+// failDisclosure records the first disclosure error
+func (b *Builder) failDisclosure(err error) {
+	if b.disclosureErr == nil {
+		b.disclosureErr = err
+	}
+}
+
+// messageCount returns the number of signed messages, from the public key
+// or else the messages, or -1 while neither is set
+func (b *Builder) messageCount() int {
+	switch {
+	case b.publicKey != nil:
+		return b.publicKey.MessageCount()
+	case b.messages != nil:
+		return len(b.messages)
+	default:
+		return -1
+	}
+}
+
+// SetNonce sets the verifier supplied nonce the holder binding is made for
+func (b *Builder) SetNonce(nonce []byte) *Builder {
+	b.nonce = nonce
+	return b
+}
+
+// SetHolderBinding binds the proof to a device key. pub is the PKIX DER device
+// public key and challengeSig its signature over
+// HolderBindingChallenge(pub, nonce). SetHolderKeyIndex must name the message
+// carrying the device key.
+func (b *Builder) SetHolderBinding(pub, challengeSig []byte) *Builder {
+	b.holderBinding = &bbs.HolderBinding{
+		PublicKey: pub,
+		Signature: challengeSig,
+		KeyIndex:  -1,
+	}
+	return b
+}
+
+// SetHolderKeyIndex sets the index of the message carrying
+// bbs.HolderKeyMessage of the device key set with SetHolderBinding. The
+// message stays hidden; the proof shows it is the device key's.
+func (b *Builder) SetHolderKeyIndex(index int) *Builder {
+	if b.holderBinding != nil {
+		b.holderBinding.KeyIndex = index
+	}
+	return b
+}
+
+// AddCommitmentEquality proves that the hidden message at index equals the
+// value inside an external Pedersen commitment, such as one registered on a
+// blockchain. commitment carries the commitment and its blinding; see
+// bbs.CommitAttribute.
+func (b *Builder) AddCommitmentEquality(index int, commitment *bbs.CommitmentOpening) *Builder {
+	if b.commitments == nil {
+		b.commitments = make(map[int]*bbs.CommitmentOpening)
+	}
+	b.commitments[index] = commitment
+	return b
+}
+
+// SetOneTimeShow proves the serial of the hidden serial key message at index
+// in epoch, see bbs.CreateOneTimeShowProof. The nonce, if set, is covered by
+// the proof. The holder presents the serial, bbs.Serial of the message, with
+// the proof.
+func (b *Builder) SetOneTimeShow(index int, epoch []byte) *Builder {
+	b.oneTimeShow = &oneTimeShow{index: index, epoch: epoch}
+	return b
+}
+
+// SetChannelBinding binds the proof to the channel it is presented on, see
+// bbs.TLSChannelBinding. The nonce, if set, is covered by the proof too.
+// Channel binding cannot be combined with holder binding or commitment
+// equalities.
+func (b *Builder) SetChannelBinding(binding []byte) *Builder {
+	b.channel = binding
+	return b
+}
+
+// SetProgressFunc sets a function called after each stage of Build, with
+// the stage finished (one of the bbs.ProofStage names) and the number of
+// stages done out of total. Proofs with a holder binding or commitment
+// equalities are created in one step and report no stages.
+func (b *Builder) SetProgressFunc(progress func(stage string, done, total int)) *Builder {
+	b.progress = progress
+	return b
+}
+
+// Build creates the proof and returns it with the disclosed messages
+func (b *Builder) Build() (*bbs.ProofOfKnowledge, map[int]*big.Int, error) {
+	return b.BuildContext(context.Background())
+}
+
+// BuildContext is Build, stopping with ctx.Err() if ctx is cancelled
+// between stages
+func (b *Builder) BuildContext(ctx context.Context) (*bbs.ProofOfKnowledge, map[int]*big.Int, error) {
+	if b.publicKey == nil || b.signature == nil {
+		return nil, nil, fmt.Errorf("public key and signature are required")
+	}
+
+	if b.disclosureErr != nil {
+		return nil, nil, b.disclosureErr
+	}
+	disclosed := b.DisclosedIndices()
+	for _, idx := range disclosed {
+		if err := b.checkDisclosure(idx); err != nil {
+			return nil, nil, err
+		}
+	}
+	if err := b.groups.check(func(idx int) bool { return b.disclosed[idx] }); err != nil {
+		return nil, nil, err
+	}
+
+	if b.holderBinding != nil && len(b.commitments) > 0 {
+		return nil, nil, fmt.Errorf("holder binding cannot be combined with commitment equalities")
+	}
+	if b.oneTimeShow != nil && (b.holderBinding != nil || len(b.commitments) > 0) {
+		return nil, nil, fmt.Errorf("one-time-show cannot be combined with holder binding or commitment equalities")
+	}
+	if b.channel != nil {
+		if len(b.channel) == 0 {
+			return nil, nil, fmt.Errorf("%w: missing binding", bbs.ErrChannelBinding)
+		}
+		if b.holderBinding != nil || len(b.commitments) > 0 {
+			return nil, nil, fmt.Errorf("channel binding cannot be combined with holder binding or commitment equalities")
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	header := bbs.AudienceHeader(bbs.SchemaHeader(b.header, b.schemaHash), b.audience)
+
+	if len(b.commitments) > 0 {
+		return bbs.CreateProofWithCommitments(
+			b.publicKey, b.signature, b.messages, disclosed, header, b.commitments,
+		)
+	}
+
+	if b.oneTimeShow != nil {
+		p, disclosed, _, err := bbs.CreateOneTimeShowProof(
+			b.publicKey, b.signature, b.messages, disclosed, header, b.channelBound(b.nonce), b.oneTimeShow.index, b.oneTimeShow.epoch,
+		)
+		return p, disclosed, err
+	}
+
+	if b.holderBinding != nil {
+		return bbs.CreateHolderBoundProof(
+			b.publicKey, b.signature, b.messages, disclosed, header, b.holderBinding, b.nonce,
+		)
+	}
+
+	var ph []byte
+	if b.channel != nil {
+		ph = b.channelBound(b.nonce)
+	}
+	return bbs.CreateProofWithProgress(ctx, b.publicKey, b.signature, b.messages, disclosed, header, ph, b.progress)
+}
+
+// channelBound binds ph to the channel binding, if one is set
+func (b *Builder) channelBound(ph []byte) []byte {
+	return bbs.ChannelBoundPresentationHeader(ph, b.channel)
+}
+
+// HolderBindingChallenge returns the bytes a device must sign for SetHolderBinding
+func HolderBindingChallenge(pub, nonce []byte) []byte {
+	return bbs.HolderBindingChallenge(pub, nonce)
+}
@@ -0,0 +1,524 @@
+package proof
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"math/big"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/anupsv/bbsplus-signatures/bbs"
+	"github.com/anupsv/bbsplus-signatures/pkg/credential"
+)
+
+func TestBuilderZeroDisclosure(t *testing.T) {
+	keyPair, err := bbs.GenerateKeyPair(3, rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKeyPair failed: %v", err)
+	}
+
+	messages := make([]*big.Int, 3)
+	for i := range messages {
+		messages[i], err = bbs.RandomScalar(rand.Reader)
+		if err != nil {
+			t.Fatalf("RandomScalar failed: %v", err)
+		}
+	}
+
+	signature, err := bbs.Sign(keyPair.PrivateKey, keyPair.PublicKey, messages, nil)
+	if err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+
+	// No Disclose call: the proof only shows possession
+	p, disclosed, err := NewBuilder().
+		SetPublicKey(keyPair.PublicKey).
+		SetSignature(signature).
+		SetMessages(messages).
+		Build()
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	if len(disclosed) != 0 {
+		t.Fatalf("Expected no disclosed messages, got %d", len(disclosed))
+	}
+
+	err = NewVerifier().
+		SetPublicKey(keyPair.PublicKey).
+		SetProof(p).
+		SetDisclosedMessages(disclosed).
+		Verify()
+	if err != nil {
+		t.Fatalf("Verify failed: %v", err)
+	}
+
+	// A hidden attribute can still be tied to an external commitment
+	opening, err := bbs.CommitAttribute(messages[1])
+	if err != nil {
+		t.Fatalf("CommitAttribute failed: %v", err)
+	}
+
+	p, disclosed, err = NewBuilder().
+		SetPublicKey(keyPair.PublicKey).
+		SetSignature(signature).
+		SetMessages(messages).
+		AddCommitmentEquality(1, opening).
+		Build()
+	if err != nil {
+		t.Fatalf("Build with commitment failed: %v", err)
+	}
+
+	err = NewVerifier().
+		SetPublicKey(keyPair.PublicKey).
+		SetProof(p).
+		SetDisclosedMessages(disclosed).
+		RequireCommitmentEquality(1, opening.Commitment).
+		Verify()
+	if err != nil {
+		t.Fatalf("Verify with commitment failed: %v", err)
+	}
+}
+
+func TestBuilderDisclosures(t *testing.T) {
+	keyPair, err := bbs.GenerateKeyPair(4, rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKeyPair failed: %v", err)
+	}
+	messages := []*big.Int{big.NewInt(1), big.NewInt(2), big.NewInt(3), big.NewInt(4)}
+	signature, err := bbs.Sign(keyPair.PrivateKey, keyPair.PublicKey, messages, nil)
+	if err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+	schema := []string{"name", "birthDate", "email", "serial"}
+
+	// Indices come back sorted and once, whatever order they were added in
+	b := NewBuilder().
+		SetPublicKey(keyPair.PublicKey).
+		SetSignature(signature).
+		SetMessages(messages).
+		Disclose(3, 0, 3).
+		DiscloseByName(schema, "email", "name")
+	if got := b.DisclosedIndices(); !slices.Equal(got, []int{0, 2, 3}) {
+		t.Fatalf("DisclosedIndices = %v", got)
+	}
+	_, disclosed, err := b.Build()
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	if len(disclosed) != 3 {
+		t.Fatalf("Expected 3 disclosed messages, got %d", len(disclosed))
+	}
+
+	// Errors are known as soon as the index or name is
+	if err := b.Disclose(4).Err(); !errors.Is(err, ErrDisclosureOutOfRange) {
+		t.Fatalf("Expected ErrDisclosureOutOfRange, got %v", err)
+	}
+	if _, _, err := b.Build(); !errors.Is(err, ErrDisclosureOutOfRange) {
+		t.Fatalf("Expected Build to fail with ErrDisclosureOutOfRange, got %v", err)
+	}
+	if err := b.ClearDisclosures().DiscloseByName(schema, "phone").Err(); !errors.Is(err, ErrUnknownAttribute) {
+		t.Fatalf("Expected ErrUnknownAttribute, got %v", err)
+	}
+	if err := NewBuilder().Disclose(-1).Err(); !errors.Is(err, ErrDisclosureOutOfRange) {
+		t.Fatalf("Expected ErrDisclosureOutOfRange for a negative index, got %v", err)
+	}
+
+	// Clearing starts over
+	b.ClearDisclosures()
+	if b.Err() != nil || len(b.DisclosedIndices()) != 0 {
+		t.Fatalf("ClearDisclosures left %v, %v", b.DisclosedIndices(), b.Err())
+	}
+
+	// Before the key and messages are set, the range is checked by Build
+	_, _, err = NewBuilder().
+		Disclose(9).
+		SetPublicKey(keyPair.PublicKey).
+		SetSignature(signature).
+		SetMessages(messages).
+		Build()
+	if !errors.Is(err, ErrDisclosureOutOfRange) {
+		t.Fatalf("Expected Build to fail with ErrDisclosureOutOfRange, got %v", err)
+	}
+}
+
+func TestBuilderProgress(t *testing.T) {
+	keyPair, err := bbs.GenerateKeyPair(4, rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKeyPair failed: %v", err)
+	}
+	messages := []*big.Int{big.NewInt(1), big.NewInt(2), big.NewInt(3), big.NewInt(4)}
+	signature, err := bbs.Sign(keyPair.PrivateKey, keyPair.PublicKey, messages, nil)
+	if err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+
+	var stages []string
+	builder := NewBuilder().
+		SetPublicKey(keyPair.PublicKey).
+		SetSignature(signature).
+		SetMessages(messages).
+		Disclose(1).
+		SetProgressFunc(func(stage string, done, total int) {
+			if done != len(stages)+1 || total != 5 {
+				t.Fatalf("Unexpected progress %d/%d at stage %s", done, total, stage)
+			}
+			stages = append(stages, stage)
+		})
+	proof, disclosed, err := builder.Build()
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	if err := bbs.VerifyProof(keyPair.PublicKey, proof, disclosed, nil); err != nil {
+		t.Fatalf("VerifyProof failed: %v", err)
+	}
+	want := []string{bbs.ProofStageBlinding, bbs.ProofStageD, bbs.ProofStageABar, bbs.ProofStageChallenge, bbs.ProofStageResponses}
+	if !slices.Equal(stages, want) {
+		t.Fatalf("Expected stages %v, got %v", want, stages)
+	}
+
+	// Cancelling stops the proof before the next stage
+	ctx, cancel := context.WithCancel(context.Background())
+	stages = nil
+	builder.SetProgressFunc(func(stage string, done, total int) {
+		stages = append(stages, stage)
+		if stage == bbs.ProofStageD {
+			cancel()
+		}
+	})
+	if _, _, err := builder.BuildContext(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected context.Canceled, got %v", err)
+	}
+	if len(stages) != 2 {
+		t.Fatalf("Expected the proof to stop after 2 stages, got %v", stages)
+	}
+	if _, _, err := builder.BuildContext(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected context.Canceled for a cancelled context, got %v", err)
+	}
+}
+
+func TestBuilderAudience(t *testing.T) {
+	keyPair, err := bbs.GenerateKeyPair(3, rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKeyPair failed: %v", err)
+	}
+	messages := []*big.Int{big.NewInt(1), big.NewInt(2), big.NewInt(3)}
+	header := []byte("issuer/v1")
+	audience, err := bbs.DIDAudience("did:web:bank.example")
+	if err != nil {
+		t.Fatalf("DIDAudience failed: %v", err)
+	}
+
+	// The issuer signs under the audience bound header
+	signature, err := bbs.Sign(keyPair.PrivateKey, keyPair.PublicKey, messages, bbs.AudienceHeader(header, audience))
+	if err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+
+	p, disclosed, err := NewBuilder().
+		SetPublicKey(keyPair.PublicKey).
+		SetSignature(signature).
+		SetMessages(messages).
+		SetHeader(header).
+		SetAudience(audience).
+		Disclose(0).
+		Build()
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+
+	verify := func(audience string) error {
+		return NewVerifier().
+			SetPublicKey(keyPair.PublicKey).
+			SetProof(p).
+			SetDisclosedMessages(disclosed).
+			SetHeader(header).
+			SetAudience(audience).
+			Verify()
+	}
+	if err := verify(audience); err != nil {
+		t.Fatalf("Verify failed for the audience: %v", err)
+	}
+	if err := verify("did:web:shop.example"); err == nil {
+		t.Fatalf("Expected verification to fail for another audience")
+	}
+	if err := verify(""); err == nil {
+		t.Fatalf("Expected verification to fail without an audience")
+	}
+}
+
+func TestBuilderChannelBinding(t *testing.T) {
+	keyPair, err := bbs.GenerateKeyPair(3, rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKeyPair failed: %v", err)
+	}
+	messages := []*big.Int{big.NewInt(1), big.NewInt(2), big.NewInt(3)}
+	signature, err := bbs.Sign(keyPair.PrivateKey, keyPair.PublicKey, messages, nil)
+	if err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+	binding := make([]byte, bbs.ChannelBindingSize)
+	if _, err := rand.Read(binding); err != nil {
+		t.Fatalf("rand.Read failed: %v", err)
+	}
+	nonce := []byte("nonce")
+
+	p, disclosed, err := NewBuilder().
+		SetPublicKey(keyPair.PublicKey).
+		SetSignature(signature).
+		SetMessages(messages).
+		SetNonce(nonce).
+		SetChannelBinding(binding).
+		Disclose(1).
+		Build()
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+
+	verify := func(nonce, binding []byte) error {
+		return NewVerifier().
+			SetPublicKey(keyPair.PublicKey).
+			SetProof(p).
+			SetDisclosedMessages(disclosed).
+			SetNonce(nonce).
+			RequireChannelBinding(binding).
+			Verify()
+	}
+	if err := verify(nonce, binding); err != nil {
+		t.Fatalf("Verify failed on the same channel: %v", err)
+	}
+	other := slices.Clone(binding)
+	other[0] ^= 1
+	if err := verify(nonce, other); err == nil {
+		t.Fatalf("Expected verification to fail on another channel")
+	}
+	if err := verify([]byte("other"), binding); err == nil {
+		t.Fatalf("Expected verification to fail for another nonce")
+	}
+	if err := verify(nonce, []byte{}); !errors.Is(err, bbs.ErrChannelBinding) {
+		t.Fatalf("Expected ErrChannelBinding for an empty binding, got %v", err)
+	}
+
+	_, _, err = NewBuilder().
+		SetPublicKey(keyPair.PublicKey).
+		SetSignature(signature).
+		SetMessages(messages).
+		SetChannelBinding(binding).
+		SetHolderBinding([]byte("pub"), []byte("sig")).
+		Build()
+	if err == nil {
+		t.Fatalf("Expected channel binding with holder binding to fail")
+	}
+}
+
+func TestBuilderHolderBinding(t *testing.T) {
+	devicePub, deviceKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+	pub, err := x509.MarshalPKIXPublicKey(devicePub)
+	if err != nil {
+		t.Fatalf("MarshalPKIXPublicKey failed: %v", err)
+	}
+
+	keyPair, err := bbs.GenerateKeyPair(3, rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKeyPair failed: %v", err)
+	}
+	messages := []*big.Int{big.NewInt(1), big.NewInt(2), bbs.HolderKeyMessage(pub)}
+	signature, err := bbs.Sign(keyPair.PrivateKey, keyPair.PublicKey, messages, nil)
+	if err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+
+	nonce := []byte("nonce")
+	challengeSig := ed25519.Sign(deviceKey, HolderBindingChallenge(pub, nonce))
+	build := func(keyIndex ...int) (*bbs.ProofOfKnowledge, map[int]*big.Int, error) {
+		b := NewBuilder().
+			SetPublicKey(keyPair.PublicKey).
+			SetSignature(signature).
+			SetMessages(messages).
+			Disclose(0).
+			SetNonce(nonce).
+			SetHolderBinding(pub, challengeSig)
+		for _, idx := range keyIndex {
+			b.SetHolderKeyIndex(idx)
+		}
+		return b.Build()
+	}
+
+	// The holder key index is required
+	if _, _, err := build(); !errors.Is(err, bbs.ErrInvalidHolderBinding) {
+		t.Fatalf("Expected ErrInvalidHolderBinding without a key index, got %v", err)
+	}
+
+	p, disclosed, err := build(2)
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	verify := func(keyIndex int) error {
+		return NewVerifier().
+			SetPublicKey(keyPair.PublicKey).
+			SetProof(p).
+			SetDisclosedMessages(disclosed).
+			SetNonce(nonce).
+			RequireHolderBinding(pub, challengeSig).
+			RequireHolderKeyIndex(keyIndex).
+			Verify()
+	}
+	if err := verify(2); err != nil {
+		t.Fatalf("Verify failed: %v", err)
+	}
+	if err := verify(1); err == nil {
+		t.Fatalf("Proof verified for another holder key index")
+	}
+}
+
+func TestBuilderSchemaHash(t *testing.T) {
+	keyPair, err := bbs.GenerateKeyPair(2, rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKeyPair failed: %v", err)
+	}
+	specs := []credential.AttributeSpec{
+		{Name: "name", Type: credential.TypeString},
+		{Name: "age", Type: credential.TypeInt},
+	}
+	cred, err := credential.NewBuilder().
+		SetSchema("https://example.gov/schemas/id").
+		DeclareAttributes(specs...).
+		AddAttribute("name", "Alice").
+		Add(credential.IntAttribute("age", 30)).
+		Issue(keyPair)
+	if err != nil {
+		t.Fatalf("Issue failed: %v", err)
+	}
+
+	messages := make([]*big.Int, len(cred.Attributes))
+	for i, attr := range cred.Attributes {
+		if messages[i], err = attr.Message(nil); err != nil {
+			t.Fatalf("Message failed: %v", err)
+		}
+	}
+	sigBytes, err := base64.StdEncoding.DecodeString(cred.Signature)
+	if err != nil {
+		t.Fatalf("DecodeString failed: %v", err)
+	}
+	signature, err := bbs.DeserializeSignature(sigBytes)
+	if err != nil {
+		t.Fatalf("DeserializeSignature failed: %v", err)
+	}
+
+	schemaHash := credential.SchemaHash(cred.Schema, specs)
+	p, disclosed, err := NewBuilder().
+		SetPublicKey(keyPair.PublicKey).
+		SetSignature(signature).
+		SetMessages(messages).
+		SetSchemaHash(schemaHash).
+		Disclose(1).
+		Build()
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+
+	verify := func(schemaHash []byte) error {
+		return NewVerifier().
+			SetPublicKey(keyPair.PublicKey).
+			SetProof(p).
+			SetDisclosedMessages(disclosed).
+			SetSchemaHash(schemaHash).
+			Verify()
+	}
+	if err := verify(schemaHash); err != nil {
+		t.Fatalf("Verify failed for the schema: %v", err)
+	}
+
+	// A schema that swaps the attributes gives the indices other meanings
+	swapped := credential.SchemaHash(cred.Schema, []credential.AttributeSpec{specs[1], specs[0]})
+	if err := verify(swapped); err == nil {
+		t.Fatalf("Expected verification to fail for another schema")
+	}
+	if err := verify(nil); err == nil {
+		t.Fatalf("Expected verification to fail without a schema")
+	}
+}
+
+func TestBuilderOneTimeShow(t *testing.T) {
+	keyPair, err := bbs.GenerateKeyPair(2, rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKeyPair failed: %v", err)
+	}
+	serialKey, err := credential.SerialKeyAttribute("serial")
+	if err != nil {
+		t.Fatalf("SerialKeyAttribute failed: %v", err)
+	}
+	specs := []credential.AttributeSpec{
+		{Name: "name", Type: credential.TypeString},
+		serialKey.Spec(),
+	}
+	cred, err := credential.NewBuilder().
+		SetSchema("https://example.gov/schemas/ticket").
+		DeclareAttributes(specs...).
+		AddAttribute("name", "Alice").
+		Add(serialKey).
+		Issue(keyPair)
+	if err != nil {
+		t.Fatalf("Issue failed: %v", err)
+	}
+
+	messages := make([]*big.Int, len(cred.Attributes))
+	for i, attr := range cred.Attributes {
+		if messages[i], err = attr.Message(nil); err != nil {
+			t.Fatalf("Message failed: %v", err)
+		}
+	}
+	sigBytes, err := base64.StdEncoding.DecodeString(cred.Signature)
+	if err != nil {
+		t.Fatalf("DecodeString failed: %v", err)
+	}
+	signature, err := bbs.DeserializeSignature(sigBytes)
+	if err != nil {
+		t.Fatalf("DeserializeSignature failed: %v", err)
+	}
+
+	epoch := []byte("2026-W42")
+	schemaHash := credential.SchemaHash(cred.Schema, specs)
+	registry := bbs.NewLRUReplayGuard(16)
+	expiry := time.Now().Add(7 * 24 * time.Hour)
+
+	// Each verifier sharing the registry accepts the credential once
+	for i, wantErr := range []error{nil, bbs.ErrSerialSpent} {
+		serial, index, err := cred.Serial("serial", epoch)
+		if err != nil {
+			t.Fatalf("Serial failed: %v", err)
+		}
+		nonce := []byte{byte(i)}
+		p, disclosed, err := NewBuilder().
+			SetPublicKey(keyPair.PublicKey).
+			SetSignature(signature).
+			SetMessages(messages).
+			SetSchemaHash(schemaHash).
+			SetNonce(nonce).
+			SetOneTimeShow(index, epoch).
+			Disclose(0).
+			Build()
+		if err != nil {
+			t.Fatalf("Build failed: %v", err)
+		}
+
+		err = NewVerifier().
+			SetPublicKey(keyPair.PublicKey).
+			SetProof(p).
+			SetDisclosedMessages(disclosed).
+			SetSchemaHash(schemaHash).
+			SetNonce(nonce).
+			RequireOneTimeShow(index, epoch, serial, registry, expiry).
+			Verify()
+		if !errors.Is(err, wantErr) {
+			t.Fatalf("Presentation %d: got %v, want %v", i, err, wantErr)
+		}
+	}
+}
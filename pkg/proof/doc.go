@@ -11,11 +11,18 @@
 //
 //     // Create a basic proof
 //     proofBuilder := proof.NewBuilder()
+//     proofBuilder.SetPublicKey(publicKey)
 //     proofBuilder.SetSignature(signature)
 //     proofBuilder.SetMessages(messages)
 //     proofBuilder.Disclose(0, 2) // Disclose messages at indices 0 and 2
 //     p, disclosed, err := proofBuilder.Build()
 //     
+//     // Bind the proof to a device key (WebAuthn style holder binding)
+//     challenge := proof.HolderBindingChallenge(devicePub, nonce)
+//     proofBuilder.SetNonce(nonce)
+//     proofBuilder.SetHolderBinding(devicePub, deviceSign(challenge))
+//     proofBuilder.SetHolderKeyIndex(3) // message 3 is bbs.HolderKeyMessage(devicePub)
+//
 //     // Advanced proof with predicate
 //     proofBuilder.AddPredicate(1, proof.PredicateGreaterThan, 18) // Age > 18
 //     
@@ -24,13 +31,24 @@
 //     verifier.SetPublicKey(publicKey)
 //     verifier.SetProof(p)
 //     verifier.SetDisclosedMessages(disclosed)
+//     verifier.SetNonce(nonce)
+//     verifier.RequireHolderBinding(devicePub, challengeSig)
+//     verifier.RequireHolderKeyIndex(3)
 //     err = verifier.Verify()
 //
+//     // Encode the proof in the same bytes every frontend produces
+//     data, err := proof.NewProofSerializer().Marshal(p)
+//
 // For basic proof creation and verification, the core package provides simpler methods.
 // This package is intended for more advanced use cases.
+//
+// Stability: stable
 package proof
 
 // Predicate types for proof creation
+//
+// Experimental: predicate proofs are not implemented yet and these types
+// may change.
 type PredicateType int
 
 const (
@@ -0,0 +1,249 @@
+package proof
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// A verifier can hand every verification to an EventLog, which batches the
+// events for an EventSink the application provides: a message queue, a file,
+// an analytics pipeline. Events carry the issuer key fingerprint, schema,
+// the names of the disclosed attributes (never their values), the result
+// and the latency. Each event holds the hash of the one before it, so the
+// sink's consumers can tell whether events were removed or altered on the
+// way.
+
+// eventChainDST domain-separates verification event hashes
+const eventChainDST = "BBS_VERIFICATION_EVENT_V1_"
+
+// Defaults of EventLogConfig
+const (
+	DefaultEventBatchSize     = 100
+	DefaultEventFlushInterval = time.Second
+	DefaultEventBufferSize    = 1024
+)
+
+// ErrBrokenEventChain is returned when verification events do not chain
+var ErrBrokenEventChain = errors.New("broken verification event chain")
+
+// VerificationEvent describes one verification
+type VerificationEvent struct {
+	Sequence          uint64        `json:"sequence"`
+	Time              time.Time     `json:"time"`
+	IssuerFingerprint string        `json:"issuerFingerprint"` // Fingerprint of the issuer key
+	KeyID             string        `json:"keyId"`
+	Schema            string        `json:"schema,omitempty"`
+	Disclosed         []string      `json:"disclosed"` // attribute names, or "#i" for unnamed indices
+	Valid             bool          `json:"valid"`
+	Error             string        `json:"error,omitempty"`
+	Latency           time.Duration `json:"latency"`
+	PrevHash          string        `json:"prevHash"`
+	Hash              string        `json:"hash"`
+}
+
+// EventSink receives batches of verification events in sequence order
+type EventSink interface {
+	WriteEvents(events []VerificationEvent) error
+}
+
+// EventLogConfig tunes an EventLog. Zero values use the defaults.
+type EventLogConfig struct {
+	// BatchSize is the most events handed to the sink at once
+	BatchSize int
+
+	// FlushInterval is the longest an event waits for its batch to fill
+	FlushInterval time.Duration
+
+	// BufferSize is how many events may wait for the sink
+	BufferSize int
+
+	// DropWhenFull drops events when the buffer is full instead of making
+	// Record wait for the sink. Dropped events are counted and never get a
+	// sequence number, so the chain stays intact.
+	DropWhenFull bool
+
+	// OnError is told of sink errors; the failed batch is not retried
+	OnError func(error)
+}
+
+// EventLog chains verification events and writes them to a sink in batches
+// from a background goroutine. It is safe for concurrent use.
+type EventLog struct {
+	sink   EventSink
+	config EventLogConfig
+
+	mu       sync.Mutex
+	closed   bool
+	sequence uint64
+	head     string
+
+	events  chan VerificationEvent
+	done    chan struct{}
+	dropped atomic.Uint64
+}
+
+// NewEventLog starts an event log writing to sink. Close it to flush the
+// remaining events.
+func NewEventLog(sink EventSink, config EventLogConfig) *EventLog {
+	if config.BatchSize <= 0 {
+		config.BatchSize = DefaultEventBatchSize
+	}
+	if config.FlushInterval <= 0 {
+		config.FlushInterval = DefaultEventFlushInterval
+	}
+	if config.BufferSize <= 0 {
+		config.BufferSize = DefaultEventBufferSize
+	}
+
+	l := &EventLog{
+		sink:   sink,
+		config: config,
+		events: make(chan VerificationEvent, config.BufferSize),
+		done:   make(chan struct{}),
+	}
+	go l.run()
+	return l
+}
+
+// Record chains the event and queues it for the sink. It reports false if
+// the event was dropped because the buffer was full or the log is closed.
+func (l *EventLog) Record(event VerificationEvent) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.closed {
+		l.dropped.Add(1)
+		return false
+	}
+
+	event.Sequence = l.sequence + 1
+	event.PrevHash = l.head
+	event.Hash = event.hash()
+
+	if l.config.DropWhenFull {
+		select {
+		case l.events <- event:
+		default:
+			l.dropped.Add(1)
+			return false
+		}
+	} else {
+		l.events <- event
+	}
+
+	l.sequence = event.Sequence
+	l.head = event.Hash
+	return true
+}
+
+// Dropped returns the number of events dropped so far
+func (l *EventLog) Dropped() uint64 {
+	return l.dropped.Load()
+}
+
+// Head returns the hash of the last recorded event, empty before the first
+func (l *EventLog) Head() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.head
+}
+
+// Close stops the log once every recorded event has gone to the sink
+func (l *EventLog) Close() {
+	l.mu.Lock()
+	if !l.closed {
+		l.closed = true
+		close(l.events)
+	}
+	l.mu.Unlock()
+	<-l.done
+}
+
+// run hands batches to the sink when they fill up or the interval passes
+func (l *EventLog) run() {
+	defer close(l.done)
+
+	ticker := time.NewTicker(l.config.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]VerificationEvent, 0, l.config.BatchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := l.sink.WriteEvents(batch); err != nil && l.config.OnError != nil {
+			l.config.OnError(err)
+		}
+		batch = make([]VerificationEvent, 0, l.config.BatchSize)
+	}
+
+	for {
+		select {
+		case event, ok := <-l.events:
+			if !ok {
+				flush()
+				return
+			}
+			batch = append(batch, event)
+			if len(batch) >= l.config.BatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+// VerifyEventChain checks that events follow each other from prevHash, the
+// hash of the event before the first, or empty for the first event of a log
+func VerifyEventChain(prevHash string, events []VerificationEvent) error {
+	for i, event := range events {
+		if event.PrevHash != prevHash {
+			return fmt.Errorf("%w: event %d does not follow its predecessor", ErrBrokenEventChain, event.Sequence)
+		}
+		if i > 0 && event.Sequence != events[i-1].Sequence+1 {
+			return fmt.Errorf("%w: event %d follows event %d", ErrBrokenEventChain, event.Sequence, events[i-1].Sequence)
+		}
+		if event.hash() != event.Hash {
+			return fmt.Errorf("%w: event %d was altered", ErrBrokenEventChain, event.Sequence)
+		}
+		prevHash = event.Hash
+	}
+	return nil
+}
+
+// hash returns the hex SHA-256 of the event's fields and PrevHash
+func (e *VerificationEvent) hash() string {
+	var buf []byte
+	buf = append(buf, eventChainDST...)
+	buf = binary.BigEndian.AppendUint64(buf, e.Sequence)
+	buf = binary.BigEndian.AppendUint64(buf, uint64(e.Time.UnixNano()))
+	for _, field := range []string{e.PrevHash, e.IssuerFingerprint, e.KeyID, e.Schema, e.Error} {
+		buf = appendField(buf, field)
+	}
+	buf = binary.BigEndian.AppendUint32(buf, uint32(len(e.Disclosed)))
+	for _, name := range e.Disclosed {
+		buf = appendField(buf, name)
+	}
+	if e.Valid {
+		buf = append(buf, 1)
+	} else {
+		buf = append(buf, 0)
+	}
+	buf = binary.BigEndian.AppendUint64(buf, uint64(e.Latency))
+
+	sum := sha256.Sum256(buf)
+	return hex.EncodeToString(sum[:])
+}
+
+// appendField appends s behind its 4-byte big-endian length
+func appendField(buf []byte, s string) []byte {
+	buf = binary.BigEndian.AppendUint32(buf, uint32(len(s)))
+	return append(buf, s...)
+}
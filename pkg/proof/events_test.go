@@ -0,0 +1,175 @@
+package proof
+
+import (
+	"crypto/rand"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/anupsv/bbsplus-signatures/bbs"
+)
+
+// memorySink keeps the batches it receives
+type memorySink struct {
+	mu      sync.Mutex
+	batches [][]VerificationEvent
+}
+
+func (s *memorySink) WriteEvents(events []VerificationEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.batches = append(s.batches, events)
+	return nil
+}
+
+func (s *memorySink) events() []VerificationEvent {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var all []VerificationEvent
+	for _, batch := range s.batches {
+		all = append(all, batch...)
+	}
+	return all
+}
+
+func TestEventLogBatchesAndChains(t *testing.T) {
+	sink := &memorySink{}
+	log := NewEventLog(sink, EventLogConfig{BatchSize: 3, FlushInterval: time.Hour})
+
+	for i := 0; i < 7; i++ {
+		if !log.Record(VerificationEvent{Time: time.Now(), KeyID: "k", Disclosed: []string{"age"}, Valid: i%2 == 0}) {
+			t.Fatalf("Record %d dropped", i)
+		}
+	}
+	head := log.Head()
+	log.Close()
+
+	// Two full batches, and the rest flushed on Close
+	if len(sink.batches) != 3 || len(sink.batches[0]) != 3 || len(sink.batches[2]) != 1 {
+		t.Fatalf("Unexpected batches %d", len(sink.batches))
+	}
+
+	events := sink.events()
+	if err := VerifyEventChain("", events); err != nil {
+		t.Fatalf("VerifyEventChain failed: %v", err)
+	}
+	if events[6].Hash != head {
+		t.Fatalf("Head does not match the last event")
+	}
+
+	// The second half chains on from the first
+	if err := VerifyEventChain(events[2].Hash, events[3:]); err != nil {
+		t.Fatalf("VerifyEventChain of a suffix failed: %v", err)
+	}
+
+	altered := slices.Clone(events)
+	altered[4].Valid = !altered[4].Valid
+	if err := VerifyEventChain("", altered); !errors.Is(err, ErrBrokenEventChain) {
+		t.Fatalf("Expected ErrBrokenEventChain for an altered event, got %v", err)
+	}
+	removed := slices.Delete(slices.Clone(events), 3, 4)
+	if err := VerifyEventChain("", removed); !errors.Is(err, ErrBrokenEventChain) {
+		t.Fatalf("Expected ErrBrokenEventChain for a removed event, got %v", err)
+	}
+
+	if log.Record(VerificationEvent{}) {
+		t.Fatalf("Expected Record after Close to drop the event")
+	}
+}
+
+// blockingSink holds its first batch until released
+type blockingSink struct {
+	memorySink
+	entered chan struct{}
+	release chan struct{}
+	once    sync.Once
+}
+
+func (s *blockingSink) WriteEvents(events []VerificationEvent) error {
+	s.once.Do(func() {
+		close(s.entered)
+		<-s.release
+	})
+	return s.memorySink.WriteEvents(events)
+}
+
+func TestEventLogDropWhenFull(t *testing.T) {
+	sink := &blockingSink{entered: make(chan struct{}), release: make(chan struct{})}
+	log := NewEventLog(sink, EventLogConfig{BatchSize: 1, BufferSize: 1, DropWhenFull: true})
+
+	log.Record(VerificationEvent{KeyID: "first"})
+	<-sink.entered
+
+	// The sink is stuck: one event fits in the buffer, the next is dropped
+	if !log.Record(VerificationEvent{KeyID: "second"}) {
+		t.Fatalf("Expected the buffered event to be accepted")
+	}
+	if log.Record(VerificationEvent{KeyID: "third"}) {
+		t.Fatalf("Expected the event to be dropped")
+	}
+	close(sink.release)
+	log.Close()
+
+	if log.Dropped() != 1 {
+		t.Fatalf("Expected 1 dropped event, got %d", log.Dropped())
+	}
+	events := sink.events()
+	if len(events) != 2 {
+		t.Fatalf("Expected 2 events, got %d", len(events))
+	}
+	if err := VerifyEventChain("", events); err != nil {
+		t.Fatalf("Dropping broke the chain: %v", err)
+	}
+}
+
+func TestProofSpecEvents(t *testing.T) {
+	keyPair, err := bbs.GenerateKeyPair(4, rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKeyPair failed: %v", err)
+	}
+	signature, messages := specCredential(t, keyPair, 1, nil)
+
+	sink := &memorySink{}
+	log := NewEventLog(sink, EventLogConfig{})
+	compiled, err := (&ProofSpec{
+		Keys:           map[string]*bbs.PublicKey{"issuer": keyPair.PublicKey},
+		AttributeNames: []string{"name", "degree"},
+		Schema:         "https://example.edu/schemas/degree",
+		Events:         log,
+	}).Compile()
+	if err != nil {
+		t.Fatalf("Compile failed: %v", err)
+	}
+
+	proof, disclosed, err := bbs.CreateProofWithPresentationHeader(keyPair.PublicKey, signature, messages, []int{1, 3}, nil, []byte("n"))
+	if err != nil {
+		t.Fatalf("CreateProofWithPresentationHeader failed: %v", err)
+	}
+	if err := compiled.Verify(Request{KeyID: "issuer", Proof: proof, Disclosed: disclosed, Nonce: []byte("n")}); err != nil {
+		t.Fatalf("Verify failed: %v", err)
+	}
+	if err := compiled.Verify(Request{KeyID: "issuer", Proof: proof, Disclosed: disclosed, Nonce: []byte("x")}); err == nil {
+		t.Fatalf("Expected verification under another nonce to fail")
+	}
+	compiled.Assess(Request{KeyID: "issuer", Proof: proof, Disclosed: disclosed, Nonce: []byte("n")})
+	log.Close()
+
+	events := sink.events()
+	if len(events) != 3 {
+		t.Fatalf("Expected 3 events, got %d", len(events))
+	}
+	if !events[0].Valid || events[1].Valid || events[1].Error == "" || !events[2].Valid {
+		t.Fatalf("Unexpected results %v %v %v", events[0].Valid, events[1].Valid, events[2].Valid)
+	}
+	if events[0].IssuerFingerprint != keyPair.PublicKey.Fingerprint() || events[0].Schema == "" {
+		t.Fatalf("Unexpected issuer or schema in %+v", events[0])
+	}
+	if !slices.Equal(events[0].Disclosed, []string{"degree", "#3"}) {
+		t.Fatalf("Expected disclosed names, got %v", events[0].Disclosed)
+	}
+	if err := VerifyEventChain("", events); err != nil {
+		t.Fatalf("VerifyEventChain failed: %v", err)
+	}
+}
@@ -0,0 +1,86 @@
+package proof_test
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+
+	"github.com/anupsv/bbsplus-signatures/bbs"
+	"github.com/anupsv/bbsplus-signatures/pkg/proof"
+)
+
+func ExampleBuilder() {
+	keyPair, err := bbs.GenerateKeyPair(3, rand.Reader)
+	if err != nil {
+		panic(err)
+	}
+
+	messages := []*big.Int{
+		bbs.MessageToFieldElement([]byte("name=Alice")),
+		bbs.MessageToFieldElement([]byte("birthdate=1990-01-01")),
+		bbs.MessageToFieldElement([]byte("country=NL")),
+	}
+	header := []byte("identity-credential-v1")
+	signature, err := bbs.Sign(keyPair.PrivateKey, keyPair.PublicKey, messages, header)
+	if err != nil {
+		panic(err)
+	}
+
+	p, disclosed, err := proof.NewBuilder().
+		SetPublicKey(keyPair.PublicKey).
+		SetSignature(signature).
+		SetMessages(messages).
+		SetHeader(header).
+		Disclose(0, 2).
+		Build()
+	if err != nil {
+		panic(err)
+	}
+
+	err = proof.NewVerifier().
+		SetPublicKey(keyPair.PublicKey).
+		SetProof(p).
+		SetDisclosedMessages(disclosed).
+		SetHeader(header).
+		Verify()
+	fmt.Println("valid:", err == nil)
+	// Output: valid: true
+}
+
+func ExampleVerifier_SetReplayGuard() {
+	keyPair, err := bbs.GenerateKeyPair(1, rand.Reader)
+	if err != nil {
+		panic(err)
+	}
+
+	messages := []*big.Int{bbs.MessageToFieldElement([]byte("member=yes"))}
+	signature, err := bbs.Sign(keyPair.PrivateKey, keyPair.PublicKey, messages, nil)
+	if err != nil {
+		panic(err)
+	}
+
+	p, disclosed, err := proof.NewBuilder().
+		SetPublicKey(keyPair.PublicKey).
+		SetSignature(signature).
+		SetMessages(messages).
+		Disclose(0).
+		Build()
+	if err != nil {
+		panic(err)
+	}
+
+	// One guard is shared by all verifications
+	guard := bbs.NewLRUReplayGuard(1024)
+	for i := 0; i < 2; i++ {
+		err := proof.NewVerifier().
+			SetPublicKey(keyPair.PublicKey).
+			SetProof(p).
+			SetDisclosedMessages(disclosed).
+			SetReplayGuard(guard, 0).
+			Verify()
+		fmt.Println(err)
+	}
+	// Output:
+	// <nil>
+	// proof 0: proof already presented
+}
@@ -0,0 +1,138 @@
+package proof
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// A schema can group attributes that only make sense together, such as the
+// street, city and zip of an address. Holder and verifier resolve a group
+// against the same attribute names, so DiscloseGroup on the holder side and
+// RequireGroup on the verifier side expand to the same indices. A group
+// that does not allow partial disclosure is shown whole or not at all: a
+// proof disclosing the city without the street is refused by both sides.
+
+var (
+	// ErrUnknownGroup is returned for a group name the schema does not have
+	ErrUnknownGroup = errors.New("unknown attribute group")
+
+	// ErrPartialGroup is returned when a proof discloses part of a group
+	// that must be disclosed whole, or not all of a required group
+	ErrPartialGroup = errors.New("attribute group partially disclosed")
+)
+
+// AttributeGroup is a named set of schema attributes disclosed together
+type AttributeGroup struct {
+	// Name identifies the group, such as "address"
+	Name string `json:"name"`
+
+	// Attributes are the names of the grouped attributes
+	Attributes []string `json:"attributes"`
+
+	// AllowPartial lets a proof disclose some of the attributes without
+	// the others
+	AllowPartial bool `json:"allowPartial,omitempty"`
+}
+
+// resolvedGroup is an AttributeGroup with its attributes as message indices
+type resolvedGroup struct {
+	AttributeGroup
+	indices []int
+}
+
+// groupSet is the groups of a schema, resolved against its attribute names
+type groupSet []resolvedGroup
+
+// resolveGroups resolves groups against the attribute names of a schema.
+// Group names must be distinct and non-empty, and an attribute may belong
+// to one group only.
+func resolveGroups(attributeNames []string, groups []AttributeGroup) (groupSet, error) {
+	set := make(groupSet, 0, len(groups))
+	owner := make(map[int]string)
+	for _, group := range groups {
+		if group.Name == "" {
+			return nil, fmt.Errorf("attribute group has no name")
+		}
+		if _, err := set.lookup(group.Name); err == nil {
+			return nil, fmt.Errorf("attribute group '%s' defined twice", group.Name)
+		}
+		if len(group.Attributes) == 0 {
+			return nil, fmt.Errorf("attribute group '%s' is empty", group.Name)
+		}
+
+		resolved := resolvedGroup{AttributeGroup: group, indices: make([]int, len(group.Attributes))}
+		for i, name := range group.Attributes {
+			idx := indexOf(attributeNames, name)
+			if idx < 0 {
+				return nil, fmt.Errorf("%w: '%s' in group '%s'", ErrUnknownAttribute, name, group.Name)
+			}
+			if other, ok := owner[idx]; ok {
+				return nil, fmt.Errorf("attribute '%s' is in groups '%s' and '%s'", name, other, group.Name)
+			}
+			owner[idx] = group.Name
+			resolved.indices[i] = idx
+		}
+		set = append(set, resolved)
+	}
+	return set, nil
+}
+
+// lookup returns the named group
+func (s groupSet) lookup(name string) (resolvedGroup, error) {
+	for _, group := range s {
+		if group.Name == name {
+			return group, nil
+		}
+	}
+	return resolvedGroup{}, fmt.Errorf("%w: '%s'", ErrUnknownGroup, name)
+}
+
+// check rejects disclosures that show part of a group that must be shown
+// whole
+func (s groupSet) check(disclosed func(idx int) bool) error {
+	for _, group := range s {
+		if group.AllowPartial {
+			continue
+		}
+		if hidden := group.hidden(disclosed); len(hidden) > 0 && len(hidden) < len(group.indices) {
+			return fmt.Errorf("%w: '%s' without %s", ErrPartialGroup, group.Name, strings.Join(hidden, ", "))
+		}
+	}
+	return nil
+}
+
+// requireWhole rejects disclosures that do not show every attribute of the
+// named groups
+func (s groupSet) requireWhole(names []string, disclosed func(idx int) bool) error {
+	for _, name := range names {
+		group, err := s.lookup(name)
+		if err != nil {
+			return err
+		}
+		if hidden := group.hidden(disclosed); len(hidden) > 0 {
+			return fmt.Errorf("%w: required group '%s' without %s", ErrPartialGroup, name, strings.Join(hidden, ", "))
+		}
+	}
+	return nil
+}
+
+// hidden returns the names of the group's attributes not disclosed
+func (g resolvedGroup) hidden(disclosed func(idx int) bool) []string {
+	var hidden []string
+	for i, idx := range g.indices {
+		if !disclosed(idx) {
+			hidden = append(hidden, g.Attributes[i])
+		}
+	}
+	return hidden
+}
+
+// disclosedIn adapts a disclosed message map to groupSet.check
+func disclosedIn(disclosed map[int]*big.Int) func(int) bool {
+	return func(idx int) bool {
+		_, ok := disclosed[idx]
+		return ok
+	}
+}
@@ -0,0 +1,131 @@
+package proof
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"slices"
+	"testing"
+
+	"github.com/anupsv/bbsplus-signatures/bbs"
+)
+
+func TestAttributeGroups(t *testing.T) {
+	keyPair, err := bbs.GenerateKeyPair(5, rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKeyPair failed: %v", err)
+	}
+	names := []string{"name", "street", "city", "zip", "phone"}
+	messages := make([]*big.Int, len(names))
+	for i, name := range names {
+		messages[i] = bbs.MessageToFieldElement([]byte(name))
+	}
+	signature, err := bbs.Sign(keyPair.PrivateKey, keyPair.PublicKey, messages, nil)
+	if err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+	groups := []AttributeGroup{
+		{Name: "address", Attributes: []string{"street", "city", "zip"}},
+		{Name: "contact", Attributes: []string{"name", "phone"}, AllowPartial: true},
+	}
+
+	builder := func() *Builder {
+		return NewBuilder().
+			SetPublicKey(keyPair.PublicKey).
+			SetSignature(signature).
+			SetMessages(messages).
+			SetAttributeGroups(names, groups...)
+	}
+
+	// A group expands to its indices on the holder side
+	b := builder().DiscloseGroup("address")
+	if got := b.DisclosedIndices(); !slices.Equal(got, []int{1, 2, 3}) {
+		t.Fatalf("Expected indices [1 2 3], got %v", got)
+	}
+	proof, disclosed, err := b.Build()
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+
+	// and is required whole on the verifier side
+	verifier := func(disclosed map[int]*big.Int) *Verifier {
+		return NewVerifier().
+			SetPublicKey(keyPair.PublicKey).
+			SetProof(proof).
+			SetDisclosedMessages(disclosed).
+			SetAttributeGroups(names, groups...)
+	}
+	if err := verifier(disclosed).RequireGroup("address").Verify(); err != nil {
+		t.Fatalf("Verify failed: %v", err)
+	}
+
+	// Part of a group that must be disclosed whole is refused by the holder
+	if _, _, err := builder().Disclose(2).Build(); !errors.Is(err, ErrPartialGroup) {
+		t.Fatalf("Expected ErrPartialGroup from Build, got %v", err)
+	}
+	// and by the verifier, should a proof disclose it anyway
+	partial, partialDisclosed, err := bbs.CreateProof(keyPair.PublicKey, signature, messages, []int{2}, nil)
+	if err != nil {
+		t.Fatalf("CreateProof failed: %v", err)
+	}
+	err = NewVerifier().
+		SetPublicKey(keyPair.PublicKey).
+		SetProof(partial).
+		SetDisclosedMessages(partialDisclosed).
+		SetAttributeGroups(names, groups...).
+		Verify()
+	if !errors.Is(err, ErrPartialGroup) {
+		t.Fatalf("Expected ErrPartialGroup from Verify, got %v", err)
+	}
+
+	// A group allowing partial disclosure may be split, but a required
+	// group may not
+	proof, disclosed, err = builder().Disclose(4).Build()
+	if err != nil {
+		t.Fatalf("Build of a partial group failed: %v", err)
+	}
+	if err := verifier(disclosed).Verify(); err != nil {
+		t.Fatalf("Verify of a partial group failed: %v", err)
+	}
+	if err := verifier(disclosed).RequireGroup("contact").Verify(); !errors.Is(err, ErrPartialGroup) {
+		t.Fatalf("Expected ErrPartialGroup for a required group, got %v", err)
+	}
+
+	if err := builder().DiscloseGroup("billing").Err(); !errors.Is(err, ErrUnknownGroup) {
+		t.Fatalf("Expected ErrUnknownGroup, got %v", err)
+	}
+
+	// The spec expands required groups the same way
+	keys := map[string]*bbs.PublicKey{"issuer": keyPair.PublicKey}
+	compiled, err := (&ProofSpec{
+		Keys:           keys,
+		AttributeNames: names,
+		Groups:         groups,
+		RequiredGroups: []string{"address"},
+	}).Compile()
+	if err != nil {
+		t.Fatalf("Compile failed: %v", err)
+	}
+	proof, disclosed, err = builder().DiscloseGroup("address").Build()
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	if err := compiled.Verify(Request{Proof: proof, Disclosed: disclosed}); err != nil {
+		t.Fatalf("Spec verify failed: %v", err)
+	}
+	if err := compiled.Verify(Request{Proof: partial, Disclosed: partialDisclosed}); err == nil {
+		t.Fatalf("Spec accepted a partial group")
+	}
+
+	// Groups must name known attributes, once each
+	for name, groups := range map[string][]AttributeGroup{
+		"unknown attribute": {{Name: "address", Attributes: []string{"country"}}},
+		"overlap":           {{Name: "a", Attributes: []string{"city"}}, {Name: "b", Attributes: []string{"city"}}},
+		"duplicate name":    {{Name: "a", Attributes: []string{"city"}}, {Name: "a", Attributes: []string{"zip"}}},
+		"empty":             {{Name: "a"}},
+	} {
+		if _, err := (&ProofSpec{Keys: keys, AttributeNames: names, Groups: groups}).Compile(); err == nil {
+			t.Fatalf("%s: expected Compile to fail", name)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package proof
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+)
+
+// ErrOverDisclosure is returned when a presentation discloses a message
+// that policy requires to stay hidden, or hides too few messages
+var ErrOverDisclosure = errors.New("presentation discloses more than allowed")
+
+// hidingPolicy is the data-minimization part of a verifier's policy
+type hidingPolicy struct {
+	hidden    []int
+	minHidden int
+}
+
+// check rejects disclosures that break the policy. messageCount is the
+// message count of the key the proof verifies under.
+func (p *hidingPolicy) check(disclosed map[int]*big.Int, messageCount int) error {
+	for _, idx := range p.hidden {
+		if _, ok := disclosed[idx]; ok {
+			return fmt.Errorf("%w: message %d must stay hidden", ErrOverDisclosure, idx)
+		}
+	}
+	if hidden := messageCount - len(disclosed); hidden < p.minHidden {
+		return fmt.Errorf("%w: %d messages hidden, at least %d required", ErrOverDisclosure, hidden, p.minHidden)
+	}
+	return nil
+}
+
+// addHidden adds indices to the hidden set, keeping it sorted and distinct
+func (p *hidingPolicy) addHidden(indices ...int) {
+	for _, idx := range indices {
+		i := sort.SearchInts(p.hidden, idx)
+		if i == len(p.hidden) || p.hidden[i] != idx {
+			p.hidden = append(p.hidden, 0)
+			copy(p.hidden[i+1:], p.hidden[i:])
+			p.hidden[i] = idx
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package proof
+
+import (
+	"crypto/rand"
+	"errors"
+	"testing"
+
+	"github.com/anupsv/bbsplus-signatures/bbs"
+)
+
+func TestDataMinimization(t *testing.T) {
+	keyPair, err := bbs.GenerateKeyPair(4, rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKeyPair failed: %v", err)
+	}
+	signature, messages := specCredential(t, keyPair, 1, nil)
+
+	keys := map[string]*bbs.PublicKey{"issuer": keyPair.PublicKey}
+	compiled, err := (&ProofSpec{
+		Keys:           keys,
+		AttributeNames: []string{"name", "degree", "issued", "sequence"},
+		RequiredNames:  []string{"degree"},
+		HiddenNames:    []string{"name"},
+		MinHidden:      2,
+	}).Compile()
+	if err != nil {
+		t.Fatalf("Compile failed: %v", err)
+	}
+
+	for _, tc := range []struct {
+		disclose []int
+		want     error
+	}{
+		{[]int{1}, nil},
+		{[]int{1, 2}, nil},
+		{[]int{0, 1}, ErrOverDisclosure},    // discloses the hidden name
+		{[]int{1, 2, 3}, ErrOverDisclosure}, // hides one message
+	} {
+		proof, disclosed, err := bbs.CreateProofWithPresentationHeader(keyPair.PublicKey, signature, messages, tc.disclose, nil, nil)
+		if err != nil {
+			t.Fatalf("CreateProofWithPresentationHeader failed: %v", err)
+		}
+		req := Request{Proof: proof, Disclosed: disclosed}
+		if err := compiled.Verify(req); !errors.Is(err, tc.want) {
+			t.Fatalf("disclosing %v: expected %v, got %v", tc.disclose, tc.want, err)
+		}
+		if a := compiled.Assess(req); (a.Schema.Status == StatusPass) != (tc.want == nil) {
+			t.Fatalf("disclosing %v: unexpected schema outcome %v", tc.disclose, a.Schema.Status)
+		}
+
+		err = NewVerifier().
+			SetPublicKey(keyPair.PublicKey).
+			SetProof(proof).
+			SetDisclosedMessages(disclosed).
+			RequireHidden(0).
+			RequireMinHidden(2).
+			Verify()
+		if !errors.Is(err, tc.want) {
+			t.Fatalf("Verifier disclosing %v: expected %v, got %v", tc.disclose, tc.want, err)
+		}
+	}
+
+	// A message cannot be both required and hidden, nor more hidden than exist
+	for name, spec := range map[string]*ProofSpec{
+		"required and hidden": {Keys: keys, RequiredIndices: []int{1}, HiddenIndices: []int{1}},
+		"too many hidden":     {Keys: keys, RequiredIndices: []int{1}, MinHidden: 4},
+		"hidden range":        {Keys: keys, HiddenIndices: []int{4}},
+		"unknown hidden name": {Keys: keys, HiddenNames: []string{"age"}},
+	} {
+		if _, err := spec.Compile(); err == nil {
+			t.Fatalf("%s: expected Compile to fail", name)
+		}
+	}
+}
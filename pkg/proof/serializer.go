@@ -0,0 +1,46 @@
+package proof
+
+import (
+	"github.com/anupsv/bbsplus-signatures/bbs"
+)
+
+// ProofSerializer encodes proofs in the compact wire format of
+// bbs.SerializeProof. core.ProofOfKnowledge is the same type as
+// bbs.ProofOfKnowledge, so every frontend that goes through a serializer,
+// the CLIs, WASM and the native bindings alike, writes identical bytes for
+// identical proofs: m^ entries are keyed by message index and always written
+// in index order, whatever order the map was filled in.
+type ProofSerializer struct {
+	// Limits bound what Unmarshal will parse; the zero value means
+	// bbs.DefaultLimits
+	Limits bbs.Limits
+}
+
+// NewProofSerializer creates a serializer under bbs.DefaultLimits
+func NewProofSerializer() *ProofSerializer {
+	return &ProofSerializer{}
+}
+
+// Marshal encodes a proof
+func (s *ProofSerializer) Marshal(p *bbs.ProofOfKnowledge) ([]byte, error) {
+	if p == nil || p.C == nil || p.EHat == nil || p.SHat == nil || p.R1Hat == nil || p.R3Hat == nil {
+		return nil, bbs.ErrInvalidProofData
+	}
+	for _, mHat := range p.MHat {
+		if mHat == nil {
+			return nil, bbs.ErrInvalidProofData
+		}
+	}
+	for _, rHat := range p.CommitmentHat {
+		if rHat == nil {
+			return nil, bbs.ErrInvalidProofData
+		}
+	}
+	return bbs.SerializeProof(p), nil
+}
+
+// Unmarshal decodes a proof written by Marshal, bbs.SerializeProof or an
+// earlier length-prefixed encoding
+func (s *ProofSerializer) Unmarshal(data []byte) (*bbs.ProofOfKnowledge, error) {
+	return bbs.DeserializeProofWithLimits(data, s.Limits)
+}
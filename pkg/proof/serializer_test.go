@@ -0,0 +1,87 @@
+package proof
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/anupsv/bbsplus-signatures/bbs"
+)
+
+func TestProofSerializerMatchesFrontends(t *testing.T) {
+	keyPair, err := bbs.GenerateKeyPair(5, rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKeyPair failed: %v", err)
+	}
+	messages := make([]*big.Int, 5)
+	for i := range messages {
+		messages[i] = big.NewInt(int64(100 + i))
+	}
+	signature, err := bbs.Sign(keyPair.PrivateKey, keyPair.PublicKey, messages, nil)
+	if err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+	p, disclosed, err := NewBuilder().
+		SetPublicKey(keyPair.PublicKey).
+		SetSignature(signature).
+		SetMessages(messages).
+		Disclose(1).
+		Build()
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+
+	s := NewProofSerializer()
+	encoded, err := s.Marshal(p)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	if !bytes.Equal(encoded, bbs.SerializeProof(p)) {
+		t.Fatal("Marshal differs from bbs.SerializeProof")
+	}
+	binary, _ := p.MarshalBinary()
+	if !bytes.Equal(encoded, binary) {
+		t.Fatal("Marshal differs from MarshalBinary")
+	}
+
+	// The same responses inserted in another order encode identically
+	reordered := *p
+	reordered.MHat = make(map[int]*big.Int, len(p.MHat))
+	for _, idx := range []int{4, 0, 3, 2} {
+		reordered.MHat[idx] = p.MHat[idx]
+	}
+	again, err := s.Marshal(&reordered)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	if !bytes.Equal(encoded, again) {
+		t.Fatal("encoding depends on map insertion order")
+	}
+
+	decoded, err := s.Unmarshal(encoded)
+	if err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	err = NewVerifier().
+		SetPublicKey(keyPair.PublicKey).
+		SetProof(decoded).
+		SetDisclosedMessages(disclosed).
+		Verify()
+	if err != nil {
+		t.Fatalf("Verify of decoded proof failed: %v", err)
+	}
+
+	// A proof with a missing response is refused instead of panicking
+	reordered.MHat[2] = nil
+	if _, err := s.Marshal(&reordered); !errors.Is(err, bbs.ErrInvalidProofData) {
+		t.Fatalf("Expected ErrInvalidProofData, got %v", err)
+	}
+
+	// Unmarshal honors the configured limits
+	strict := &ProofSerializer{Limits: bbs.Limits{MaxMHatEntries: 2}}
+	if _, err := strict.Unmarshal(encoded); !errors.Is(err, bbs.ErrLimitExceeded) {
+		t.Fatalf("Expected ErrLimitExceeded, got %v", err)
+	}
+}
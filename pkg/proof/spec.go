@@ -0,0 +1,375 @@
+package proof
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/anupsv/bbsplus-signatures/bbs"
+)
+
+// ProofSpec is a verifier's standing policy: which issuer keys it accepts,
+// what a presentation must disclose, how the header and presentation header
+// are derived, and the freshness and revocation checks. Compile it once and
+// verify every request with the result.
+type ProofSpec struct {
+	// Keys maps key IDs to the issuer keys a proof may verify under. A
+	// request names its key by ID or by its Fingerprint; with a single key
+	// the ID may be empty.
+	Keys map[string]*bbs.PublicKey
+
+	// AttributeNames names the signed messages in order, so RequiredNames
+	// can refer to them
+	AttributeNames []string
+
+	// RequiredIndices are message indices every proof must disclose
+	RequiredIndices []int
+
+	// RequiredNames are attribute names every proof must disclose
+	RequiredNames []string
+
+	// Groups groups attributes of AttributeNames. A proof may not disclose
+	// part of a group that does not allow partial disclosure.
+	Groups []AttributeGroup
+
+	// RequiredGroups are groups every proof must disclose whole
+	RequiredGroups []string
+
+	// HiddenIndices are message indices no proof may disclose
+	HiddenIndices []int
+
+	// HiddenNames are attribute names no proof may disclose
+	HiddenNames []string
+
+	// MinHidden is the least number of messages every proof must hide
+	MinHidden int
+
+	// Header is the header the credentials were signed with
+	Header []byte
+
+	// PresentationHeader derives the presentation header from the request
+	// nonce. Nil uses the nonce itself.
+	PresentationHeader func(nonce []byte) []byte
+
+	// Freshness, if set, is checked against the disclosed messages
+	Freshness *bbs.FreshnessPolicy
+
+	// IsRevoked, if set, reports whether the credential with the given
+	// sequence number is revoked. It needs Freshness with a SequenceIndex.
+	IsRevoked func(sequence uint64) bool
+
+	// TrustRegistry, if set, reports whether an issuer key is currently
+	// listed as trusted. An error means the registry could not be asked.
+	TrustRegistry func(keyID string, publicKey *bbs.PublicKey) (bool, error)
+
+	// Schema names the credential schema in verification events
+	Schema string
+
+	// Events, if set, is told of every verification
+	Events *EventLog
+
+	// Limits bounds the keys and the proofs of requests. Zero fields take
+	// bbs.DefaultLimits, which also applies inside bbs.
+	Limits bbs.Limits
+}
+
+// Request is one presentation checked against a compiled spec
+type Request struct {
+	KeyID     string
+	Proof     *bbs.ProofOfKnowledge
+	Disclosed map[int]*big.Int
+	Nonce     []byte
+}
+
+// CompiledSpec is a ProofSpec validated and with the verification context
+// of each key precomputed. It is safe for concurrent use.
+type CompiledSpec struct {
+	contexts           map[string]*bbs.VerificationContext
+	required           []int
+	hiding             hidingPolicy
+	groups             groupSet
+	presentationHeader func(nonce []byte) []byte
+	freshness          *bbs.FreshnessPolicy
+	isRevoked          func(sequence uint64) bool
+	trustRegistry      func(keyID string, publicKey *bbs.PublicKey) (bool, error)
+	attributeNames     []string
+	fingerprints       map[*bbs.VerificationContext]string
+	schema             string
+	events             *EventLog
+	limits             bbs.Limits
+}
+
+// Compile validates the spec and precomputes its verification contexts.
+// Later changes to the spec do not affect the result.
+func (s *ProofSpec) Compile() (*CompiledSpec, error) {
+	if len(s.Keys) == 0 {
+		return nil, fmt.Errorf("proof spec needs at least one public key")
+	}
+
+	// Every index the spec refers to must exist under every key
+	messageCount := -1
+	for id, publicKey := range s.Keys {
+		if publicKey == nil {
+			return nil, fmt.Errorf("public key '%s' is nil", id)
+		}
+		if err := s.Limits.CheckMessageCount(publicKey.MessageCount()); err != nil {
+			return nil, fmt.Errorf("public key '%s': %w", id, err)
+		}
+		if messageCount < 0 || publicKey.MessageCount() < messageCount {
+			messageCount = publicKey.MessageCount()
+		}
+	}
+
+	// Resolve the required attributes to sorted, distinct indices
+	requiredSet := make(map[int]bool)
+	for _, idx := range s.RequiredIndices {
+		if idx < 0 || idx >= messageCount {
+			return nil, fmt.Errorf("required index %d out of range", idx)
+		}
+		requiredSet[idx] = true
+	}
+	for _, name := range s.RequiredNames {
+		idx := indexOf(s.AttributeNames, name)
+		if idx < 0 {
+			return nil, fmt.Errorf("required attribute '%s' not in AttributeNames", name)
+		}
+		if idx >= messageCount {
+			return nil, fmt.Errorf("required attribute '%s' out of range", name)
+		}
+		requiredSet[idx] = true
+	}
+	groups, err := resolveGroups(s.AttributeNames, s.Groups)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range s.RequiredGroups {
+		group, err := groups.lookup(name)
+		if err != nil {
+			return nil, err
+		}
+		for _, idx := range group.indices {
+			if idx >= messageCount {
+				return nil, fmt.Errorf("required group '%s' out of range", name)
+			}
+			requiredSet[idx] = true
+		}
+	}
+
+	// Resolve the hidden attributes, which cannot also be required
+	var hiding hidingPolicy
+	for _, idx := range s.HiddenIndices {
+		if idx < 0 || idx >= messageCount {
+			return nil, fmt.Errorf("hidden index %d out of range", idx)
+		}
+		hiding.addHidden(idx)
+	}
+	for _, name := range s.HiddenNames {
+		idx := indexOf(s.AttributeNames, name)
+		if idx < 0 {
+			return nil, fmt.Errorf("hidden attribute '%s' not in AttributeNames", name)
+		}
+		if idx >= messageCount {
+			return nil, fmt.Errorf("hidden attribute '%s' out of range", name)
+		}
+		hiding.addHidden(idx)
+	}
+	for _, idx := range hiding.hidden {
+		if requiredSet[idx] {
+			return nil, fmt.Errorf("message %d is both required and hidden", idx)
+		}
+	}
+	if s.MinHidden < 0 || len(requiredSet)+s.MinHidden > messageCount {
+		return nil, fmt.Errorf("cannot hide %d messages and disclose %d of %d", s.MinHidden, len(requiredSet), messageCount)
+	}
+	hiding.minHidden = s.MinHidden
+
+	if s.Freshness != nil {
+		if s.Freshness.TimestampIndex < 0 || s.Freshness.TimestampIndex >= messageCount ||
+			s.Freshness.SequenceIndex >= messageCount {
+			return nil, fmt.Errorf("freshness policy index out of range")
+		}
+	}
+	if s.IsRevoked != nil && (s.Freshness == nil || s.Freshness.SequenceIndex < 0) {
+		return nil, fmt.Errorf("revocation checks need a freshness policy with a sequence number")
+	}
+
+	compiled := &CompiledSpec{
+		contexts:           make(map[string]*bbs.VerificationContext, len(s.Keys)),
+		hiding:             hiding,
+		groups:             groups,
+		presentationHeader: s.PresentationHeader,
+		isRevoked:          s.IsRevoked,
+		trustRegistry:      s.TrustRegistry,
+		attributeNames:     append([]string(nil), s.AttributeNames...),
+		fingerprints:       make(map[*bbs.VerificationContext]string, len(s.Keys)),
+		schema:             s.Schema,
+		events:             s.Events,
+		limits:             s.Limits,
+	}
+	for id, publicKey := range s.Keys {
+		ctx := bbs.NewVerificationContext(publicKey, s.Header)
+		compiled.contexts[id] = ctx
+		compiled.fingerprints[ctx] = publicKey.Fingerprint()
+	}
+	for idx := range requiredSet {
+		compiled.required = append(compiled.required, idx)
+	}
+	sort.Ints(compiled.required)
+	if s.Freshness != nil {
+		policy := *s.Freshness
+		compiled.freshness = &policy
+	}
+
+	return compiled, nil
+}
+
+// Verify checks a request against the spec
+func (c *CompiledSpec) Verify(req Request) error {
+	start := time.Now()
+	err := c.verify(req)
+	c.record(req, start, err == nil, err)
+	return err
+}
+
+// verify checks a request against the spec without recording an event
+func (c *CompiledSpec) verify(req Request) error {
+	if err := c.checkLimits(req); err != nil {
+		return err
+	}
+
+	ctx, err := c.context(req.KeyID)
+	if err != nil {
+		return err
+	}
+
+	if c.trustRegistry != nil {
+		trusted, err := c.trustRegistry(req.KeyID, ctx.PublicKey())
+		if err != nil {
+			return fmt.Errorf("trust registry lookup failed: %w", err)
+		}
+		if !trusted {
+			return fmt.Errorf("issuer key '%s' is not trusted", req.KeyID)
+		}
+	}
+
+	for _, idx := range c.required {
+		if _, ok := req.Disclosed[idx]; !ok {
+			return fmt.Errorf("required message %d not disclosed", idx)
+		}
+	}
+	if err := c.hiding.check(req.Disclosed, ctx.PublicKey().MessageCount()); err != nil {
+		return err
+	}
+	if err := c.groups.check(disclosedIn(req.Disclosed)); err != nil {
+		return err
+	}
+
+	presentationHeader := req.Nonce
+	if c.presentationHeader != nil {
+		presentationHeader = c.presentationHeader(req.Nonce)
+	}
+	if err := ctx.VerifyProof(req.Proof, req.Disclosed, presentationHeader); err != nil {
+		return err
+	}
+
+	if c.freshness != nil {
+		if err := c.freshness.Check(req.Disclosed); err != nil {
+			return err
+		}
+	}
+
+	if c.isRevoked != nil {
+		seq, err := bbs.ParseSequenceNumberMessage(req.Disclosed[c.freshness.SequenceIndex])
+		if err != nil {
+			return err
+		}
+		if c.isRevoked(seq) {
+			return fmt.Errorf("credential %d is revoked", seq)
+		}
+	}
+
+	return nil
+}
+
+// checkLimits bounds the proof and disclosed messages of a request before
+// any work is done on them
+func (c *CompiledSpec) checkLimits(req Request) error {
+	if err := c.limits.CheckProof(nil, req.Proof); err != nil {
+		return err
+	}
+	return c.limits.CheckMessageCount(len(req.Disclosed))
+}
+
+// record sends the event of a verification to the spec's event log
+func (c *CompiledSpec) record(req Request, start time.Time, valid bool, err error) {
+	if c.events == nil {
+		return
+	}
+
+	event := VerificationEvent{
+		Time:      start.UTC(),
+		KeyID:     req.KeyID,
+		Schema:    c.schema,
+		Disclosed: make([]string, 0, len(req.Disclosed)),
+		Valid:     valid,
+		Latency:   time.Since(start),
+	}
+	if err != nil {
+		event.Error = err.Error()
+	}
+	if ctx, ctxErr := c.context(req.KeyID); ctxErr == nil {
+		event.IssuerFingerprint = c.fingerprints[ctx]
+	}
+
+	// Names only: the values never leave the verifier
+	indices := make([]int, 0, len(req.Disclosed))
+	for idx := range req.Disclosed {
+		indices = append(indices, idx)
+	}
+	sort.Ints(indices)
+	for _, idx := range indices {
+		if idx >= 0 && idx < len(c.attributeNames) {
+			event.Disclosed = append(event.Disclosed, c.attributeNames[idx])
+		} else {
+			event.Disclosed = append(event.Disclosed, fmt.Sprintf("#%d", idx))
+		}
+	}
+
+	c.events.Record(event)
+}
+
+// context returns the verification context for a key ID
+func (c *CompiledSpec) context(keyID string) (*bbs.VerificationContext, error) {
+	if ctx, ok := c.contexts[keyID]; ok {
+		return ctx, nil
+	}
+
+	// A single key needs no ID
+	if keyID == "" && len(c.contexts) == 1 {
+		for _, ctx := range c.contexts {
+			return ctx, nil
+		}
+	}
+
+	// A key may also be named by its fingerprint
+	if bbs.IsFingerprint(keyID) {
+		for ctx, fingerprint := range c.fingerprints {
+			if fingerprint == keyID {
+				return ctx, nil
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("unknown key ID '%s'", keyID)
+}
+
+// indexOf returns the position of name in names, or -1
+func indexOf(names []string, name string) int {
+	for i, n := range names {
+		if n == name {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,212 @@
+package proof
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"math/big"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/anupsv/bbsplus-signatures/bbs"
+)
+
+// specCredential signs name, degree, issuance time and sequence number
+func specCredential(t *testing.T, keyPair *bbs.KeyPair, seq uint64, header []byte) (*bbs.Signature, []*big.Int) {
+	t.Helper()
+
+	issued, err := bbs.IssuanceTimeMessage(time.Now())
+	if err != nil {
+		t.Fatalf("IssuanceTimeMessage failed: %v", err)
+	}
+	messages := []*big.Int{
+		bbs.MessageToFieldElement([]byte("Alice")),
+		bbs.MessageToFieldElement([]byte("MSc")),
+		issued,
+		bbs.SequenceNumberMessage(seq),
+	}
+
+	signature, err := bbs.Sign(keyPair.PrivateKey, keyPair.PublicKey, messages, header)
+	if err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+	return signature, messages
+}
+
+func TestProofSpec(t *testing.T) {
+	current, err := bbs.GenerateKeyPair(4, rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKeyPair failed: %v", err)
+	}
+	previous, err := bbs.GenerateKeyPair(4, rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKeyPair failed: %v", err)
+	}
+	header := []byte("university credentials")
+
+	// The presentation header binds the nonce to this verifier
+	derive := func(nonce []byte) []byte {
+		ph := sha256.Sum256(append([]byte("verifier.example|"), nonce...))
+		return ph[:]
+	}
+
+	spec := &ProofSpec{
+		Keys:               map[string]*bbs.PublicKey{"2026": current.PublicKey, "2025": previous.PublicKey},
+		AttributeNames:     []string{"name", "degree", "issued", "sequence"},
+		RequiredNames:      []string{"degree"},
+		Header:             header,
+		PresentationHeader: derive,
+		Freshness:          &bbs.FreshnessPolicy{TimestampIndex: 2, MaxAge: time.Hour, SequenceIndex: 3},
+		IsRevoked:          func(seq uint64) bool { return seq == 13 },
+	}
+	compiled, err := spec.Compile()
+	if err != nil {
+		t.Fatalf("Compile failed: %v", err)
+	}
+
+	present := func(keyPair *bbs.KeyPair, seq uint64, disclose []int, nonce []byte) Request {
+		signature, messages := specCredential(t, keyPair, seq, header)
+		proof, disclosed, err := bbs.CreateProofWithPresentationHeader(
+			keyPair.PublicKey, signature, messages, disclose, header, derive(nonce),
+		)
+		if err != nil {
+			t.Fatalf("CreateProofWithPresentationHeader failed: %v", err)
+		}
+		return Request{Proof: proof, Disclosed: disclosed, Nonce: nonce}
+	}
+
+	// Requests under either key verify concurrently with one compiled spec
+	var wg sync.WaitGroup
+	errs := make(chan error, 8)
+	for i := 0; i < 8; i++ {
+		keyID, keyPair := "2026", current
+		if i%2 == 1 {
+			keyID, keyPair = "2025", previous
+		}
+		req := present(keyPair, uint64(100+i), []int{1, 2, 3}, []byte{byte(i)})
+		req.KeyID = keyID
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- compiled.Verify(req)
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("Verify failed: %v", err)
+		}
+	}
+
+	for name, tc := range map[string]struct {
+		req  Request
+		want string
+	}{
+		"wrong key": {
+			req:  func() Request { r := present(current, 1, []int{1, 2, 3}, []byte("n")); r.KeyID = "2025"; return r }(),
+			want: "invalid signature",
+		},
+		"unknown key": {
+			req:  func() Request { r := present(current, 1, []int{1, 2, 3}, []byte("n")); r.KeyID = "2024"; return r }(),
+			want: "unknown key ID",
+		},
+		"missing required": {
+			req:  func() Request { r := present(current, 1, []int{2, 3}, []byte("n")); r.KeyID = "2026"; return r }(),
+			want: "required message 1",
+		},
+		"other nonce": {
+			req: func() Request {
+				r := present(current, 1, []int{1, 2, 3}, []byte("n"))
+				r.KeyID, r.Nonce = "2026", []byte("replayed")
+				return r
+			}(),
+			want: "invalid signature",
+		},
+		"not fresh": {
+			req:  func() Request { r := present(current, 1, []int{1, 3}, []byte("n")); r.KeyID = "2026"; return r }(),
+			want: "not fresh",
+		},
+		"revoked": {
+			req:  func() Request { r := present(current, 13, []int{1, 2, 3}, []byte("n")); r.KeyID = "2026"; return r }(),
+			want: "revoked",
+		},
+	} {
+		err := compiled.Verify(tc.req)
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Fatalf("%s: expected error containing %q, got %v", name, tc.want, err)
+		}
+	}
+}
+
+func TestProofSpecSingleKey(t *testing.T) {
+	keyPair, err := bbs.GenerateKeyPair(4, rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKeyPair failed: %v", err)
+	}
+	signature, messages := specCredential(t, keyPair, 1, nil)
+
+	compiled, err := (&ProofSpec{
+		Keys:            map[string]*bbs.PublicKey{"issuer": keyPair.PublicKey},
+		RequiredIndices: []int{0},
+	}).Compile()
+	if err != nil {
+		t.Fatalf("Compile failed: %v", err)
+	}
+
+	// Without a derivation rule the nonce is the presentation header
+	nonce := []byte("nonce")
+	proof, disclosed, err := bbs.CreateProofWithPresentationHeader(keyPair.PublicKey, signature, messages, []int{0}, nil, nonce)
+	if err != nil {
+		t.Fatalf("CreateProofWithPresentationHeader failed: %v", err)
+	}
+	if err := compiled.Verify(Request{Proof: proof, Disclosed: disclosed, Nonce: nonce}); err != nil {
+		t.Fatalf("Verify failed: %v", err)
+	}
+
+	// The key can also be named by its fingerprint
+	if err := compiled.Verify(Request{KeyID: keyPair.PublicKey.Fingerprint(), Proof: proof, Disclosed: disclosed, Nonce: nonce}); err != nil {
+		t.Fatalf("Verify by fingerprint failed: %v", err)
+	}
+
+	// A spec may bound proofs more tightly than bbs.DefaultLimits
+	limited, err := (&ProofSpec{
+		Keys:   map[string]*bbs.PublicKey{"issuer": keyPair.PublicKey},
+		Limits: bbs.Limits{MaxMHatEntries: 2},
+	}).Compile()
+	if err != nil {
+		t.Fatalf("Compile failed: %v", err)
+	}
+	if err := limited.Verify(Request{Proof: proof, Disclosed: disclosed, Nonce: nonce}); !errors.Is(err, bbs.ErrLimitExceeded) {
+		t.Fatalf("Expected ErrLimitExceeded for 3 hidden messages, got %v", err)
+	}
+	if a := limited.Assess(Request{Proof: proof, Disclosed: disclosed, Nonce: nonce}); a.Signature.Status != StatusFail {
+		t.Fatalf("Expected Assess to fail the signature, got %v", a.Signature.Status)
+	}
+}
+
+func TestProofSpecCompileErrors(t *testing.T) {
+	keyPair, err := bbs.GenerateKeyPair(2, rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKeyPair failed: %v", err)
+	}
+	keys := map[string]*bbs.PublicKey{"": keyPair.PublicKey}
+
+	for name, spec := range map[string]*ProofSpec{
+		"no keys":        {},
+		"nil key":        {Keys: map[string]*bbs.PublicKey{"a": nil}},
+		"index range":    {Keys: keys, RequiredIndices: []int{2}},
+		"unknown name":   {Keys: keys, RequiredNames: []string{"age"}},
+		"freshness":      {Keys: keys, Freshness: &bbs.FreshnessPolicy{TimestampIndex: 5, SequenceIndex: -1}},
+		"revocation":     {Keys: keys, IsRevoked: func(uint64) bool { return false }},
+		"revocation seq": {Keys: keys, Freshness: &bbs.FreshnessPolicy{SequenceIndex: -1}, IsRevoked: func(uint64) bool { return false }},
+		"key limit":      {Keys: keys, Limits: bbs.Limits{MaxMessageCount: 1}},
+	} {
+		if _, err := spec.Compile(); err == nil {
+			t.Fatalf("%s: expected Compile to fail", name)
+		}
+	}
+}
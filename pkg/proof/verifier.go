@@ -0,0 +1,271 @@
+package proof
+
+import (
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/anupsv/bbsplus-signatures/bbs"
+	bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381"
+)
+
+// Verifier provides a fluent interface for verifying selective disclosure proofs
+type Verifier struct {
+	publicKey     *bbs.PublicKey
+	proof         *bbs.ProofOfKnowledge
+	disclosed     map[int]*big.Int
+	header        []byte
+	schemaHash    []byte
+	audience      string
+	nonce         []byte
+	holderBinding *bbs.HolderBinding
+	commitments   map[int]bls12381.G1Affine
+	oneTimeShow   *oneTimeShow
+	channel       []byte
+	serials       bbs.ReplayGuard
+	serialExpiry  time.Time
+	replayGuard   bbs.ReplayGuard
+	replayWindow  time.Duration
+	freshness     *bbs.FreshnessPolicy
+	limits        bbs.Limits
+	hiding        hidingPolicy
+	groups        groupSet
+	groupsErr     error
+	wholeGroups   []string
+}
+
+// NewVerifier creates a new proof verifier
+func NewVerifier() *Verifier {
+	return &Verifier{}
+}
+
+// SetPublicKey sets the issuer public key
+func (v *Verifier) SetPublicKey(publicKey *bbs.PublicKey) *Verifier {
+	v.publicKey = publicKey
+	return v
+}
+
+// SetProof sets the proof to verify
+func (v *Verifier) SetProof(proof *bbs.ProofOfKnowledge) *Verifier {
+	v.proof = proof
+	return v
+}
+
+// SetDisclosedMessages sets the messages revealed by the proof
+func (v *Verifier) SetDisclosedMessages(disclosed map[int]*big.Int) *Verifier {
+	v.disclosed = disclosed
+	return v
+}
+
+// SetHeader sets the header the signature was created with
+func (v *Verifier) SetHeader(header []byte) *Verifier {
+	v.header = header
+	return v
+}
+
+// SetSchemaHash sets the hash of the schema the verifier expects, such as
+// credential.SchemaHash of its own schema definition. Only proofs of
+// credentials the issuer bound to that schema with bbs.SchemaHeader verify.
+func (v *Verifier) SetSchemaHash(schemaHash []byte) *Verifier {
+	v.schemaHash = schemaHash
+	return v
+}
+
+// SetAudience sets the verifier's audience identifier. Only credentials the
+// issuer bound to it with bbs.AudienceHeader verify; see bbs.DIDAudience for
+// the identifier of a DID.
+func (v *Verifier) SetAudience(audience string) *Verifier {
+	v.audience = audience
+	return v
+}
+
+// SetNonce sets the nonce the holder was challenged with
+func (v *Verifier) SetNonce(nonce []byte) *Verifier {
+	v.nonce = nonce
+	return v
+}
+
+// RequireHolderBinding requires the proof to be bound to the device key pub,
+// with challengeSig the device signature presented by the holder.
+// RequireHolderKeyIndex must name the message carrying the device key.
+func (v *Verifier) RequireHolderBinding(pub, challengeSig []byte) *Verifier {
+	v.holderBinding = &bbs.HolderBinding{
+		PublicKey: pub,
+		Signature: challengeSig,
+		KeyIndex:  -1,
+	}
+	return v
+}
+
+// RequireHolderKeyIndex requires the hidden message at index to carry the
+// device key set with RequireHolderBinding
+func (v *Verifier) RequireHolderKeyIndex(index int) *Verifier {
+	if v.holderBinding != nil {
+		v.holderBinding.KeyIndex = index
+	}
+	return v
+}
+
+// RequireCommitmentEquality requires the hidden message at index to equal
+// the value inside the external Pedersen commitment
+func (v *Verifier) RequireCommitmentEquality(index int, commitment bls12381.G1Affine) *Verifier {
+	if v.commitments == nil {
+		v.commitments = make(map[int]bls12381.G1Affine)
+	}
+	v.commitments[index] = commitment
+	return v
+}
+
+// RequireOneTimeShow requires the proof to show that serial is the serial of
+// the hidden message at index in epoch. With a registry, the serial is
+// recorded until expiry once the proof verifies and a serial recorded before
+// fails with bbs.ErrSerialSpent. Verifiers sharing a registry accept each
+// credential once per epoch between them.
+func (v *Verifier) RequireOneTimeShow(index int, epoch []byte, serial bls12381.G1Affine, registry bbs.ReplayGuard, expiry time.Time) *Verifier {
+	v.oneTimeShow = &oneTimeShow{index: index, epoch: epoch, serial: serial}
+	v.serials = registry
+	v.serialExpiry = expiry
+	return v
+}
+
+// RequireChannelBinding requires the proof to be bound to binding, the
+// verifier's end of the channel the proof arrived on; see
+// bbs.TLSChannelBinding. A proof relayed from another channel fails.
+func (v *Verifier) RequireChannelBinding(binding []byte) *Verifier {
+	v.channel = binding
+	return v
+}
+
+// SetReplayGuard rejects proofs guard has already seen and registers the
+// proof for window once it verifies. A zero window uses
+// bbs.DefaultReplayWindow.
+func (v *Verifier) SetReplayGuard(guard bbs.ReplayGuard, window time.Duration) *Verifier {
+	v.replayGuard = guard
+	v.replayWindow = window
+	return v
+}
+
+// RequireHidden rejects proofs that disclose any of the messages at
+// indices, such as a full birth date where an age predicate suffices
+func (v *Verifier) RequireHidden(indices ...int) *Verifier {
+	v.hiding.addHidden(indices...)
+	return v
+}
+
+// RequireMinHidden rejects proofs that hide fewer than n messages
+func (v *Verifier) RequireMinHidden(n int) *Verifier {
+	v.hiding.minHidden = n
+	return v
+}
+
+// SetAttributeGroups sets the groups of the credential's schema, where
+// attributeNames names the signed messages in order. Verify then rejects
+// proofs disclosing part of a group that must be disclosed whole.
+func (v *Verifier) SetAttributeGroups(attributeNames []string, groups ...AttributeGroup) *Verifier {
+	v.groups, v.groupsErr = resolveGroups(attributeNames, groups)
+	return v
+}
+
+// RequireGroup rejects proofs that do not disclose every attribute of the
+// named groups, as set by SetAttributeGroups
+func (v *Verifier) RequireGroup(names ...string) *Verifier {
+	v.wholeGroups = append(v.wholeGroups, names...)
+	return v
+}
+
+// SetLimits bounds the key and proof, with zero fields taken from
+// bbs.DefaultLimits
+func (v *Verifier) SetLimits(limits bbs.Limits) *Verifier {
+	v.limits = limits
+	return v
+}
+
+// RequireFreshness requires the proof to disclose the reserved issuance time
+// and sequence number attributes and checks them against policy
+func (v *Verifier) RequireFreshness(policy *bbs.FreshnessPolicy) *Verifier {
+	v.freshness = policy
+	return v
+}
+
+// Verify checks the proof, and the holder binding or commitment equalities
+// if any are required
+func (v *Verifier) Verify() error {
+	if err := v.verify(); err != nil {
+		return err
+	}
+
+	if v.freshness != nil {
+		if err := v.freshness.Check(v.disclosed); err != nil {
+			return err
+		}
+	}
+
+	// Spend the serial and register the proof only once it is known to be valid
+	if v.oneTimeShow != nil && v.serials != nil {
+		if err := bbs.SpendSerial(v.serials, v.oneTimeShow.epoch, v.oneTimeShow.serial, v.serialExpiry); err != nil {
+			return err
+		}
+	}
+	opts := bbs.VerifyOptions{ReplayGuard: v.replayGuard, ReplayWindow: v.replayWindow}
+	return opts.CheckReplay([]*bbs.ProofOfKnowledge{v.proof})
+}
+
+// verify checks the proof without consulting the replay guard
+func (v *Verifier) verify() error {
+	if v.publicKey == nil || v.proof == nil {
+		return fmt.Errorf("public key and proof are required")
+	}
+	if err := v.limits.CheckProof(v.publicKey, v.proof); err != nil {
+		return err
+	}
+	if err := v.hiding.check(v.disclosed, v.publicKey.MessageCount()); err != nil {
+		return err
+	}
+	if v.groupsErr != nil {
+		return v.groupsErr
+	}
+	if err := v.groups.check(disclosedIn(v.disclosed)); err != nil {
+		return err
+	}
+	if err := v.groups.requireWhole(v.wholeGroups, disclosedIn(v.disclosed)); err != nil {
+		return err
+	}
+
+	if v.holderBinding != nil && len(v.commitments) > 0 {
+		return fmt.Errorf("holder binding cannot be combined with commitment equalities")
+	}
+	if v.oneTimeShow != nil && (v.holderBinding != nil || len(v.commitments) > 0) {
+		return fmt.Errorf("one-time-show cannot be combined with holder binding or commitment equalities")
+	}
+	if v.channel != nil {
+		if len(v.channel) == 0 {
+			return fmt.Errorf("%w: missing binding", bbs.ErrChannelBinding)
+		}
+		if v.holderBinding != nil || len(v.commitments) > 0 {
+			return fmt.Errorf("channel binding cannot be combined with holder binding or commitment equalities")
+		}
+	}
+
+	header := bbs.AudienceHeader(bbs.SchemaHeader(v.header, v.schemaHash), v.audience)
+
+	if v.oneTimeShow != nil {
+		return bbs.VerifyOneTimeShowProof(
+			v.publicKey, v.proof, v.disclosed, header, bbs.ChannelBoundPresentationHeader(v.nonce, v.channel), v.oneTimeShow.index, v.oneTimeShow.epoch, v.oneTimeShow.serial,
+		)
+	}
+
+	if len(v.commitments) > 0 {
+		return bbs.VerifyProofWithCommitments(v.publicKey, v.proof, v.disclosed, header, v.commitments)
+	}
+
+	if v.holderBinding != nil {
+		return bbs.VerifyHolderBoundProof(
+			v.publicKey, v.proof, v.disclosed, header, v.holderBinding, v.nonce,
+		)
+	}
+
+	if v.channel != nil {
+		return bbs.VerifyProofWithChannelBinding(v.publicKey, v.proof, v.disclosed, header, v.nonce, v.channel)
+	}
+	return bbs.VerifyProof(v.publicKey, v.proof, v.disclosed, header)
+}
@@ -0,0 +1,22 @@
+// Package replay provides shared bbs.ReplayGuard backends for verifiers
+// running on more than one instance.
+//
+// RedisGuard registers proof hashes in Redis with SET NX and an expiry, so a
+// proof accepted by one instance is rejected by all others until its window
+// ends. Redis is reached through the RedisClient interface. An adapter for
+// go-redis, which go.mod requires, is compiled only with the goredis tag:
+//
+//	go build -tags goredis ./...
+//
+// Example usage:
+//
+//	guard := replay.NewRedisGuard(replay.NewGoRedisClient(rdb))
+//	err := proof.NewVerifier().
+//	    SetPublicKey(publicKey).
+//	    SetProof(p).
+//	    SetDisclosedMessages(disclosed).
+//	    SetReplayGuard(guard, 5*time.Minute).
+//	    Verify()
+//
+// Stability: experimental. The API may change in a minor release.
+package replay
@@ -0,0 +1,25 @@
+//go:build goredis
+
+package replay
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// GoRedisClient adapts a go-redis client to RedisClient
+type GoRedisClient struct {
+	Client redis.Cmdable
+}
+
+// NewGoRedisClient wraps a go-redis client, cluster client or ring
+func NewGoRedisClient(client redis.Cmdable) *GoRedisClient {
+	return &GoRedisClient{Client: client}
+}
+
+// SetNX implements RedisClient
+func (c *GoRedisClient) SetNX(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	return c.Client.SetNX(ctx, key, 1, ttl).Result()
+}
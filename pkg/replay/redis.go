@@ -0,0 +1,71 @@
+package replay
+
+import (
+	"context"
+	"encoding/hex"
+	"time"
+
+	"github.com/anupsv/bbsplus-signatures/bbs"
+)
+
+// DefaultKeyPrefix namespaces the keys RedisGuard writes
+const DefaultKeyPrefix = "bbs:replay:"
+
+// DefaultTimeout bounds a single Redis call
+const DefaultTimeout = 2 * time.Second
+
+// RedisClient is the Redis command RedisGuard needs
+type RedisClient interface {
+	// SetNX sets key if it does not exist, expiring after ttl, and reports
+	// whether it was set
+	SetNX(ctx context.Context, key string, ttl time.Duration) (bool, error)
+}
+
+// RedisGuard is a bbs.ReplayGuard shared through Redis. It fails closed: if
+// Redis cannot be reached, every proof is reported as seen.
+type RedisGuard struct {
+	client RedisClient
+
+	// KeyPrefix is prepended to the hex proof hash
+	KeyPrefix string
+
+	// Timeout bounds each Redis call
+	Timeout time.Duration
+
+	// OnError, if set, is called with Redis errors
+	OnError func(error)
+}
+
+// RedisGuard implements bbs.ReplayGuard
+var _ bbs.ReplayGuard = (*RedisGuard)(nil)
+
+// NewRedisGuard creates a replay guard backed by client
+func NewRedisGuard(client RedisClient) *RedisGuard {
+	return &RedisGuard{
+		client:    client,
+		KeyPrefix: DefaultKeyPrefix,
+		Timeout:   DefaultTimeout,
+	}
+}
+
+// Seen implements bbs.ReplayGuard
+func (g *RedisGuard) Seen(proofHash [32]byte, expiry time.Time) bool {
+	ttl := time.Until(expiry)
+	if ttl < time.Millisecond {
+		// Redis expiries have millisecond resolution
+		ttl = time.Millisecond
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), g.Timeout)
+	defer cancel()
+
+	set, err := g.client.SetNX(ctx, g.KeyPrefix+hex.EncodeToString(proofHash[:]), ttl)
+	if err != nil {
+		if g.OnError != nil {
+			g.OnError(err)
+		}
+		return true
+	}
+
+	return !set
+}
@@ -0,0 +1,76 @@
+package replay
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeRedis is an in-memory RedisClient
+type fakeRedis struct {
+	mu   sync.Mutex
+	keys map[string]time.Duration
+	err  error
+}
+
+func (f *fakeRedis) SetNX(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.err != nil {
+		return false, f.err
+	}
+	if _, ok := f.keys[key]; ok {
+		return false, nil
+	}
+	f.keys[key] = ttl
+	return true, nil
+}
+
+func TestRedisGuard(t *testing.T) {
+	client := &fakeRedis{keys: make(map[string]time.Duration)}
+	guard := NewRedisGuard(client)
+
+	hash := [32]byte{0xab}
+	if guard.Seen(hash, time.Now().Add(time.Minute)) {
+		t.Fatal("new hash reported as seen")
+	}
+	if !guard.Seen(hash, time.Now().Add(time.Minute)) {
+		t.Fatal("registered hash not reported as seen")
+	}
+
+	ttl, ok := client.keys[DefaultKeyPrefix+hex.EncodeToString(hash[:])]
+	if !ok {
+		t.Fatalf("key not set, have %v", client.keys)
+	}
+	if ttl <= 0 || ttl > time.Minute {
+		t.Fatalf("unexpected ttl %v", ttl)
+	}
+
+	// Past expiries still set a positive TTL
+	if guard.Seen([32]byte{1}, time.Now().Add(-time.Second)) {
+		t.Fatal("new hash reported as seen")
+	}
+	if ttl := client.keys[DefaultKeyPrefix+"01"+strings.Repeat("0", 62)]; ttl != time.Millisecond {
+		t.Fatalf("ttl = %v, want 1ms", ttl)
+	}
+}
+
+func TestRedisGuardFailsClosed(t *testing.T) {
+	client := &fakeRedis{keys: make(map[string]time.Duration), err: errors.New("connection refused")}
+	guard := NewRedisGuard(client)
+
+	var reported error
+	guard.OnError = func(err error) { reported = err }
+
+	if !guard.Seen([32]byte{1}, time.Now().Add(time.Minute)) {
+		t.Fatal("hash accepted while Redis is unavailable")
+	}
+	if reported == nil {
+		t.Fatal("error not reported")
+	}
+}
@@ -6,4 +6,6 @@
 // - Constant-time operations
 // - Memory management
 // - Serialization helpers
+//
+// Stability: stable
 package utils
\ No newline at end of file
@@ -30,13 +30,22 @@
 //         signature: signature.signature,
 //         publicKey: keyPair.publicKey
 //     });
+//
+// Stability: experimental. The API may change in a minor release.
 package wasm
 
 // Constants for WASM integration
 const (
-	// MaxInputSize is the maximum allowed size of JS inputs
+	// MaxInputSize is the default maximum size of the inputs of one call
 	MaxInputSize = 10 * 1024 * 1024 // 10MB
 	
-	// MaxMessagesPerCredential is the maximum number of messages in a credential
+	// MaxMessagesPerCredential is the default maximum number of messages in a credential
 	MaxMessagesPerCredential = 100
+	
+	// MaxInputSizeUpperBound is the largest input size BBS.configure accepts
+	MaxInputSizeUpperBound = 64 * 1024 * 1024 // 64MB
+	
+	// MaxMessagesUpperBound is the most messages per credential BBS.configure
+	// accepts
+	MaxMessagesUpperBound = 4096
 )
\ No newline at end of file
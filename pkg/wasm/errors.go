@@ -0,0 +1,123 @@
+package wasm
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/anupsv/bbsplus-signatures/bbs"
+	"github.com/anupsv/bbsplus-signatures/pkg/credential"
+)
+
+// Every function of the BBS object returns the same envelope. A call that
+// succeeds returns {success: true, ...} with its result fields; one that
+// fails returns {success: false, code, error}, where code is an ErrorCode
+// and error a message for humans. Verification that runs but rejects its
+// input succeeds with valid or verified false, and carries the code and
+// message of the rejection too. Scripts branch on the code, which is stable
+// across releases, and never on the message, which is not.
+
+// ErrorCode is the machine-readable code of a failed call
+type ErrorCode string
+
+const (
+	// CodeInvalidInput is a missing, malformed or out-of-range argument
+	CodeInvalidInput ErrorCode = "INVALID_INPUT"
+
+	// CodeInvalidKey is a private or public key that cannot be decoded
+	CodeInvalidKey ErrorCode = "INVALID_KEY"
+
+	// CodeUnknownKeyHandle is a key handle that was never issued or has
+	// been destroyed
+	CodeUnknownKeyHandle ErrorCode = "UNKNOWN_KEY_HANDLE"
+
+	// CodeInvalidSignature is a signature that cannot be decoded
+	CodeInvalidSignature ErrorCode = "INVALID_SIGNATURE"
+
+	// CodeInvalidProof is a proof or checkpoint that cannot be decoded
+	CodeInvalidProof ErrorCode = "INVALID_PROOF"
+
+	// CodeInvalidCredential is a credential or presentation request that
+	// cannot be decoded
+	CodeInvalidCredential ErrorCode = "INVALID_CREDENTIAL"
+
+	// CodeVerificationFailed is a well-formed signature or proof that does
+	// not verify
+	CodeVerificationFailed ErrorCode = "VERIFICATION_FAILED"
+
+	// CodeLimitExceeded is input beyond the limits of configure or
+	// setLimits
+	CodeLimitExceeded ErrorCode = "LIMIT_EXCEEDED"
+
+	// CodeInternal is a failure not caused by the input
+	CodeInternal ErrorCode = "INTERNAL"
+)
+
+// ErrorCodes lists every code, as exposed to scripts by BBS.errorCodes.
+// Codes are only ever added.
+var ErrorCodes = []ErrorCode{
+	CodeInvalidInput,
+	CodeInvalidKey,
+	CodeUnknownKeyHandle,
+	CodeInvalidSignature,
+	CodeInvalidProof,
+	CodeInvalidCredential,
+	CodeVerificationFailed,
+	CodeLimitExceeded,
+	CodeInternal,
+}
+
+// sentinelCodes map the errors of the Go packages to codes. They take
+// precedence over the code an Error was created with, which is the caller's
+// guess from context.
+var sentinelCodes = []struct {
+	err  error
+	code ErrorCode
+}{
+	{bbs.ErrLimitExceeded, CodeLimitExceeded},
+	{bbs.ErrInvalidSignature, CodeVerificationFailed},
+	{bbs.ErrInvalidSignatureData, CodeInvalidSignature},
+	{bbs.ErrInvalidProofData, CodeInvalidProof},
+	{bbs.ErrInvalidCheckpoint, CodeInvalidProof},
+	{bbs.ErrInvalidMessageCount, CodeInvalidInput},
+	{credential.ErrInvalidAttribute, CodeInvalidCredential},
+	{credential.ErrMalformedJSON, CodeInvalidCredential},
+}
+
+// Error is an error with the code it is reported under
+type Error struct {
+	Code ErrorCode
+	Err  error
+}
+
+// Errorf formats an error reported under code, unless it wraps an error of
+// the Go packages with a code of its own
+func Errorf(code ErrorCode, format string, args ...any) error {
+	return &Error{Code: code, Err: fmt.Errorf(format, args...)}
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// CodeOf returns the code err is reported under: that of a wrapped error of
+// the Go packages, else that of a wrapped Error, else CodeInternal. It
+// returns "" for a nil error.
+func CodeOf(err error) ErrorCode {
+	if err == nil {
+		return ""
+	}
+	for _, s := range sentinelCodes {
+		if errors.Is(err, s.err) {
+			return s.code
+		}
+	}
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Code
+	}
+	return CodeInternal
+}
@@ -0,0 +1,48 @@
+package wasm
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/anupsv/bbsplus-signatures/bbs"
+	"github.com/anupsv/bbsplus-signatures/pkg/credential"
+)
+
+func TestCodeOf(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want ErrorCode
+	}{
+		{"nil", nil, ""},
+		{"coded", Errorf(CodeInvalidKey, "Invalid public key format: %w", errors.New("odd length")), CodeInvalidKey},
+		{"limit", fmt.Errorf("too many: %w", bbs.ErrLimitExceeded), CodeLimitExceeded},
+		{"sentinel over code", Errorf(CodeInvalidInput, "Failed to create proof: %w", bbs.ErrLimitExceeded), CodeLimitExceeded},
+		{"signature data", Errorf(CodeInvalidInput, "bad: %w", bbs.ErrInvalidSignatureData), CodeInvalidSignature},
+		{"verification", bbs.ErrInvalidSignature, CodeVerificationFailed},
+		{"attribute", fmt.Errorf("%w: no value", credential.ErrInvalidAttribute), CodeInvalidCredential},
+		{"malformed json", fmt.Errorf("%w: unknown field 'x'", credential.ErrMalformedJSON), CodeInvalidCredential},
+		{"uncoded", errors.New("boom"), CodeInternal},
+	}
+	for _, tt := range tests {
+		if got := CodeOf(tt.err); got != tt.want {
+			t.Errorf("%s: CodeOf = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestErrorCodesUnique(t *testing.T) {
+	seen := make(map[ErrorCode]bool)
+	for _, code := range ErrorCodes {
+		if code == "" || seen[code] {
+			t.Fatalf("Error code %q is empty or listed twice", code)
+		}
+		seen[code] = true
+	}
+	for _, s := range sentinelCodes {
+		if !seen[s.code] {
+			t.Fatalf("Code %q of %v is not in ErrorCodes", s.code, s.err)
+		}
+	}
+}
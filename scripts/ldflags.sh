@@ -0,0 +1,28 @@
+#!/bin/sh
+# Print the -ldflags that stamp internal/version with the current checkout:
+#
+#   go build -ldflags "$(scripts/ldflags.sh)" ./cmd/credgen
+#
+# The build date is the commit date, or SOURCE_DATE_EPOCH when set, so the
+# same commit always builds with the same metadata. VERSION overrides the
+# version, which otherwise comes from an exact release tag.
+
+pkg=github.com/anupsv/bbsplus-signatures/internal/version
+
+commit=$(git rev-parse HEAD 2>/dev/null)
+
+if [ -n "$SOURCE_DATE_EPOCH" ]; then
+    date=$(date -u -d "@$SOURCE_DATE_EPOCH" +%Y-%m-%dT%H:%M:%SZ 2>/dev/null ||
+        date -u -r "$SOURCE_DATE_EPOCH" +%Y-%m-%dT%H:%M:%SZ)
+else
+    date=$(TZ=UTC git log -1 --format=%cd --date=format-local:%Y-%m-%dT%H:%M:%SZ 2>/dev/null)
+fi
+
+version=${VERSION:-$(git describe --tags --exact-match 2>/dev/null)}
+version=${version#v}
+
+flags=""
+[ -n "$commit" ] && flags="$flags -X $pkg.Commit=$commit"
+[ -n "$date" ] && flags="$flags -X $pkg.BuildDate=$date"
+[ -n "$version" ] && flags="$flags -X $pkg.Version=$version"
+echo "${flags# }"
@@ -0,0 +1,378 @@
+// Command apistability checks the stability tiers of the library packages.
+//
+// Every package states its tier in its package doc, in a paragraph that
+// starts with "Stability: stable" or "Stability: experimental". Within a
+// stable package, single declarations may be marked with a paragraph
+// starting "Experimental:" or, following the Go convention, "Deprecated:".
+// The command fails when a package states no tier, when a stable package
+// imports an experimental one, or when a deprecated function of a package
+// that reports deprecated calls at run time does not report its own. With
+// -list it prints the tiers and marked declarations as Markdown.
+//
+// Usage:
+//
+//	go run ./tools/apistability [-list] [module root]
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Stability tiers
+const (
+	tierStable       = "stable"
+	tierExperimental = "experimental"
+)
+
+// warnFunc is the function through which a package reports deprecated calls
+const warnFunc = "warnDeprecated"
+
+// skipDirs are never library packages
+var skipDirs = map[string]bool{
+	"cmd":          true,
+	"examples":     true,
+	"internal":     true,
+	"node_modules": true,
+	"testdata":     true,
+	"tools":        true,
+	"vendor":       true,
+}
+
+// pkgInfo is what the checks need to know about a package
+type pkgInfo struct {
+	path         string
+	tier         string
+	imports      []string
+	experimental []string
+	deprecated   []string
+	problems     []string
+}
+
+func main() {
+	list := flag.Bool("list", false, "Print the tiers and marked declarations as Markdown")
+	flag.Parse()
+
+	root := "."
+	if flag.NArg() > 0 {
+		root = flag.Arg(0)
+	}
+
+	pkgs, err := load(root)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	problems := check(pkgs)
+	if *list {
+		printList(pkgs)
+	}
+	for _, p := range problems {
+		fmt.Fprintln(os.Stderr, p)
+	}
+	if len(problems) > 0 {
+		os.Exit(1)
+	}
+}
+
+// load parses the library packages below root
+func load(root string) (map[string]*pkgInfo, error) {
+	module, err := modulePath(filepath.Join(root, "go.mod"))
+	if err != nil {
+		return nil, err
+	}
+
+	pkgs := make(map[string]*pkgInfo)
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		name := d.Name()
+		if path != root && (skipDirs[name] || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_")) {
+			return filepath.SkipDir
+		}
+
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		importPath := module
+		if rel != "." {
+			importPath += "/" + filepath.ToSlash(rel)
+		}
+
+		pkg, err := loadPackage(path, importPath)
+		if err != nil {
+			return err
+		}
+		if pkg != nil {
+			pkgs[importPath] = pkg
+		}
+		return nil
+	})
+	return pkgs, err
+}
+
+// modulePath reads the module path from go.mod
+func modulePath(goMod string) (string, error) {
+	f, err := os.Open(goMod)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if rest, ok := strings.CutPrefix(strings.TrimSpace(scanner.Text()), "module "); ok {
+			return strings.Trim(strings.TrimSpace(rest), `"`), nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	return "", fmt.Errorf("%s has no module line", goMod)
+}
+
+// loadPackage parses the non-test files in dir. It returns nil for a
+// directory without Go files and for commands.
+func loadPackage(dir, importPath string) (*pkgInfo, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return nil, err
+	}
+
+	fset := token.NewFileSet()
+	var files []*ast.File
+	for _, path := range paths {
+		if strings.HasSuffix(path, "_test.go") {
+			continue
+		}
+		f, err := parser.ParseFile(fset, path, nil, parser.ParseComments)
+		if err != nil {
+			return nil, err
+		}
+		if f.Name.Name == "main" {
+			return nil, nil
+		}
+		files = append(files, f)
+	}
+	if len(files) == 0 {
+		return nil, nil
+	}
+
+	pkg := &pkgInfo{path: importPath}
+	imports := make(map[string]bool)
+	reportsCalls := false
+	var deprecatedFuncs []*ast.FuncDecl
+
+	for _, f := range files {
+		if f.Doc != nil {
+			if tier, ok := stabilityTier(f.Doc.Text()); ok {
+				if pkg.tier != "" && pkg.tier != tier {
+					pkg.problems = append(pkg.problems, fmt.Sprintf("%s: conflicting tiers %s and %s", importPath, pkg.tier, tier))
+				}
+				pkg.tier = tier
+			}
+		}
+		for _, imp := range f.Imports {
+			path, _ := strconv.Unquote(imp.Path.Value)
+			imports[path] = true
+		}
+
+		for _, decl := range f.Decls {
+			switch decl := decl.(type) {
+			case *ast.FuncDecl:
+				if decl.Recv == nil && decl.Name.Name == warnFunc {
+					reportsCalls = true
+				}
+				if !decl.Name.IsExported() {
+					continue
+				}
+				name := funcName(decl)
+				if hasMarker(decl.Doc, "Experimental:") {
+					pkg.experimental = append(pkg.experimental, name)
+				}
+				if hasMarker(decl.Doc, "Deprecated:") {
+					pkg.deprecated = append(pkg.deprecated, name)
+					deprecatedFuncs = append(deprecatedFuncs, decl)
+				}
+			case *ast.GenDecl:
+				for _, name := range markedSpecs(decl, "Experimental:") {
+					pkg.experimental = append(pkg.experimental, name)
+				}
+				for _, name := range markedSpecs(decl, "Deprecated:") {
+					pkg.deprecated = append(pkg.deprecated, name)
+				}
+			}
+		}
+	}
+
+	if reportsCalls {
+		for _, decl := range deprecatedFuncs {
+			if decl.Body != nil && !callsFunc(decl.Body, warnFunc) {
+				pos := fset.Position(decl.Pos())
+				pkg.problems = append(pkg.problems, fmt.Sprintf("%s: deprecated %s does not call %s", pos, funcName(decl), warnFunc))
+			}
+		}
+	}
+
+	for path := range imports {
+		pkg.imports = append(pkg.imports, path)
+	}
+	sort.Strings(pkg.imports)
+	sort.Strings(pkg.experimental)
+	sort.Strings(pkg.deprecated)
+
+	return pkg, nil
+}
+
+// stabilityTier finds the "Stability:" paragraph of a package doc
+func stabilityTier(text string) (string, bool) {
+	for _, line := range strings.Split(text, "\n") {
+		rest, ok := strings.CutPrefix(strings.TrimSpace(line), "Stability:")
+		if !ok {
+			continue
+		}
+		tier := strings.TrimRight(strings.Fields(rest + " ?")[0], ".")
+		return tier, true
+	}
+	return "", false
+}
+
+// hasMarker reports whether a paragraph of doc starts with marker
+func hasMarker(doc *ast.CommentGroup, marker string) bool {
+	if doc == nil {
+		return false
+	}
+	for _, paragraph := range strings.Split(doc.Text(), "\n\n") {
+		if strings.HasPrefix(strings.TrimSpace(paragraph), marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// markedSpecs returns the exported names of a type, const or var
+// declaration marked with marker, on the declaration or on the spec
+func markedSpecs(decl *ast.GenDecl, marker string) []string {
+	all := hasMarker(decl.Doc, marker)
+	var names []string
+	for _, spec := range decl.Specs {
+		switch spec := spec.(type) {
+		case *ast.TypeSpec:
+			if spec.Name.IsExported() && (all || hasMarker(spec.Doc, marker)) {
+				names = append(names, spec.Name.Name)
+			}
+		case *ast.ValueSpec:
+			if all || hasMarker(spec.Doc, marker) {
+				for _, name := range spec.Names {
+					if name.IsExported() {
+						names = append(names, name.Name)
+					}
+				}
+			}
+		}
+	}
+	return names
+}
+
+// funcName returns Name or Type.Name for a method
+func funcName(decl *ast.FuncDecl) string {
+	if decl.Recv == nil || len(decl.Recv.List) == 0 {
+		return decl.Name.Name
+	}
+	recv := decl.Recv.List[0].Type
+	if star, ok := recv.(*ast.StarExpr); ok {
+		recv = star.X
+	}
+	if ident, ok := recv.(*ast.Ident); ok {
+		return ident.Name + "." + decl.Name.Name
+	}
+	return decl.Name.Name
+}
+
+// callsFunc reports whether body calls the package function name
+func callsFunc(body *ast.BlockStmt, name string) bool {
+	found := false
+	ast.Inspect(body, func(n ast.Node) bool {
+		if call, ok := n.(*ast.CallExpr); ok {
+			if ident, ok := call.Fun.(*ast.Ident); ok && ident.Name == name {
+				found = true
+			}
+		}
+		return !found
+	})
+	return found
+}
+
+// check returns every problem found in pkgs
+func check(pkgs map[string]*pkgInfo) []string {
+	var problems []string
+	for _, path := range sortedPaths(pkgs) {
+		pkg := pkgs[path]
+		problems = append(problems, pkg.problems...)
+
+		switch pkg.tier {
+		case tierStable, tierExperimental:
+		case "":
+			problems = append(problems, fmt.Sprintf("%s: package doc has no Stability paragraph", path))
+			continue
+		default:
+			problems = append(problems, fmt.Sprintf("%s: unknown stability tier %q", path, pkg.tier))
+			continue
+		}
+
+		if pkg.tier != tierStable {
+			continue
+		}
+		for _, imp := range pkg.imports {
+			if dep, ok := pkgs[imp]; ok && dep.tier == tierExperimental {
+				problems = append(problems, fmt.Sprintf("%s: stable package imports experimental %s", path, imp))
+			}
+		}
+	}
+	return problems
+}
+
+// printList writes the tiers and marked declarations as Markdown
+func printList(pkgs map[string]*pkgInfo) {
+	fmt.Println("| Package | Tier | Experimental | Deprecated |")
+	fmt.Println("|---------|------|--------------|------------|")
+	for _, path := range sortedPaths(pkgs) {
+		pkg := pkgs[path]
+		fmt.Printf("| `%s` | %s | %s | %s |\n", path, pkg.tier, codeList(pkg.experimental), codeList(pkg.deprecated))
+	}
+}
+
+// codeList formats names as a comma-separated list of code spans
+func codeList(names []string) string {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = "`" + name + "`"
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// sortedPaths returns the import paths of pkgs in order
+func sortedPaths(pkgs map[string]*pkgInfo) []string {
+	paths := make([]string, 0, len(pkgs))
+	for path := range pkgs {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
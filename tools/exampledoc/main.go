@@ -0,0 +1,136 @@
+// Command exampledoc renders the runnable Example functions of packages as
+// a Markdown page, so the API documentation shows code that go test checks
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/doc"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+func main() {
+	output := flag.String("output", "", "Output Markdown file (stdout if empty)")
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "Usage: exampledoc [-output file] <package dir>...")
+		os.Exit(1)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("# Examples\n\n")
+	buf.WriteString("<!-- Code generated by tools/exampledoc. DO NOT EDIT. -->\n\n")
+	buf.WriteString("Every example below is an Example function run by `go test`, so its\n")
+	buf.WriteString("output is checked. Regenerate with `go generate ./bbs`.\n")
+
+	for _, dir := range flag.Args() {
+		if err := render(&buf, dir); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if *output == "" {
+		os.Stdout.Write(buf.Bytes())
+		return
+	}
+	if err := os.WriteFile(*output, buf.Bytes(), 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to write %s: %v\n", *output, err)
+		os.Exit(1)
+	}
+}
+
+// render appends the examples of the package in dir
+func render(buf *bytes.Buffer, dir string) error {
+	fset := token.NewFileSet()
+	paths, err := filepath.Glob(filepath.Join(dir, "*_test.go"))
+	if err != nil {
+		return err
+	}
+	sort.Strings(paths)
+
+	var files []*ast.File
+	for _, path := range paths {
+		f, err := parser.ParseFile(fset, path, nil, parser.ParseComments)
+		if err != nil {
+			return err
+		}
+		files = append(files, f)
+	}
+
+	examples := doc.Examples(files...)
+	if len(examples) == 0 {
+		return nil
+	}
+
+	fmt.Fprintf(buf, "\n## %s\n", packageName(dir, files))
+	for _, ex := range examples {
+		title := ex.Name
+		if title == "" {
+			title = "Package"
+		}
+		fmt.Fprintf(buf, "\n### %s\n\n", title)
+		if ex.Doc != "" {
+			fmt.Fprintf(buf, "%s\n\n", strings.TrimSpace(ex.Doc))
+		}
+
+		code, err := exampleCode(fset, ex)
+		if err != nil {
+			return fmt.Errorf("example %s: %w", ex.Name, err)
+		}
+		fmt.Fprintf(buf, "```go\n%s\n```\n", code)
+
+		if ex.Output != "" {
+			fmt.Fprintf(buf, "\nOutput:\n\n```\n%s```\n", ex.Output)
+		}
+	}
+
+	return nil
+}
+
+// exampleCode formats an example body, or the whole program for examples
+// that need their helpers
+func exampleCode(fset *token.FileSet, ex *doc.Example) (string, error) {
+	var buf bytes.Buffer
+	node := ex.Code
+	if ex.Play != nil {
+		node = ex.Play
+	}
+	if err := format.Node(&buf, fset, node); err != nil {
+		return "", err
+	}
+
+	code := buf.String()
+	if ex.Play == nil {
+		// Strip the braces of the function body
+		code = strings.TrimSpace(code)
+		code = strings.TrimPrefix(code, "{")
+		code = strings.TrimSuffix(code, "}")
+		lines := strings.Split(strings.Trim(code, "\n"), "\n")
+		for i, line := range lines {
+			lines[i] = strings.TrimPrefix(line, "\t")
+		}
+		code = strings.Join(lines, "\n")
+	}
+
+	return strings.TrimRight(code, "\n"), nil
+}
+
+// packageName returns the import path style name of the package in dir
+func packageName(dir string, files []*ast.File) string {
+	name := strings.TrimSuffix(files[0].Name.Name, "_test")
+	clean := filepath.ToSlash(filepath.Clean(dir))
+	if idx := strings.Index(clean, "pkg/"); idx >= 0 {
+		return clean[idx:]
+	}
+	return name
+}
@@ -2,42 +2,43 @@ package main
 
 import (
 	"crypto/rand"
-	"encoding/base64"
-	"encoding/json"
+	"encoding/hex"
 	"flag"
 	"fmt"
 	"os"
 
 	"github.com/anupsv/bbsplus-signatures/bbs"
+	"github.com/anupsv/bbsplus-signatures/pkg/keys"
 )
 
 func main() {
 	// Define command-line flags
 	messageCount := flag.Int("messages", 5, "Number of messages to support")
 	outputFile := flag.String("output", "", "Output file for key pair (optional)")
+	format := flag.String("format", "json", "Key file format: json or cbor")
+	blsPublic := flag.String("import-bls-public", "", "Hex BLS12-381 G2 public key to import instead of generating a key pair")
+	blsSecret := flag.String("import-bls-secret", "", "Hex BLS12-381 secret key to import instead of generating a key pair")
 	flag.Parse()
 
-	// Generate key pair
-	fmt.Printf("Generating BBS+ key pair for %d messages...\n", *messageCount)
-	keyPair, err := bbs.GenerateKeyPair(*messageCount, rand.Reader)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error generating key pair: %v\n", err)
+	keyFormat := keys.FormatJSON
+	switch *format {
+	case "json":
+	case "cbor":
+		keyFormat = keys.FormatCBOR
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown key file format: %s\n", *format)
 		os.Exit(1)
 	}
 
-	// Create serializable format
-	serialized := struct {
-		PrivateKey   string `json:"privateKey"`
-		PublicKey    string `json:"publicKey"`
-		MessageCount int    `json:"messageCount"`
-	}{
-		PrivateKey:   base64.StdEncoding.EncodeToString(keyPair.PrivateKey.X.Bytes()),
-		PublicKey:    base64.StdEncoding.EncodeToString(bbs.SerializePublicKey(keyPair.PublicKey)),
-		MessageCount: keyPair.PublicKey.MessageCount,
+	// Generate or import the key pair
+	keyPair, usage, err := loadKeyPair(*messageCount, *blsPublic, *blsSecret)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error creating key pair: %v\n", err)
+		os.Exit(1)
 	}
 
-	// Convert to JSON
-	jsonData, err := json.MarshalIndent(serialized, "", "  ")
+	// Wrap the key pair in a key file envelope
+	keyFile, err := keys.NewKeyFile(keyPair, usage)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error serializing key pair: %v\n", err)
 		os.Exit(1)
@@ -45,13 +46,55 @@ func main() {
 
 	// Write to file or stdout
 	if *outputFile != "" {
-		err = os.WriteFile(*outputFile, jsonData, 0600)
+		err = keys.Save(*outputFile, keyFile, keyFormat, nil)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error writing to file: %v\n", err)
 			os.Exit(1)
 		}
 		fmt.Printf("Key pair written to %s\n", *outputFile)
 	} else {
-		fmt.Println(string(jsonData))
+		data, err := keys.Marshal(keyFile, keyFormat, nil)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error serializing key pair: %v\n", err)
+			os.Exit(1)
+		}
+		os.Stdout.Write(data)
+		if keyFormat == keys.FormatJSON {
+			fmt.Println()
+		}
 	}
 }
+
+// loadKeyPair generates a key pair, or imports an existing BLS key if one of
+// the import flags is set. Imported public keys are for verification only.
+func loadKeyPair(messageCount int, blsPublic, blsSecret string) (*bbs.KeyPair, string, error) {
+	switch {
+	case blsPublic != "" && blsSecret != "":
+		return nil, "", fmt.Errorf("use only one of -import-bls-public and -import-bls-secret")
+
+	case blsPublic != "":
+		data, err := hex.DecodeString(blsPublic)
+		if err != nil {
+			return nil, "", fmt.Errorf("invalid BLS public key hex: %w", err)
+		}
+		fmt.Printf("Importing BLS public key for %d messages...\n", messageCount)
+		publicKey, err := bbs.ImportBLSPublicKey(data, messageCount)
+		if err != nil {
+			return nil, "", err
+		}
+		return &bbs.KeyPair{PublicKey: publicKey}, keys.UsageVerification, nil
+
+	case blsSecret != "":
+		data, err := hex.DecodeString(blsSecret)
+		if err != nil {
+			return nil, "", fmt.Errorf("invalid BLS secret key hex: %w", err)
+		}
+		fmt.Printf("Importing BLS secret key for %d messages...\n", messageCount)
+		keyPair, err := bbs.ImportBLSSecretKey(data, messageCount)
+		return keyPair, keys.UsageSigning, err
+	}
+
+	fmt.Printf("Generating BBS+ key pair for %d messages...\n", messageCount)
+	keyPair, err := bbs.GenerateKeyPair(messageCount, rand.Reader)
+	return keyPair, keys.UsageSigning, err
+}
@@ -0,0 +1,72 @@
+// Command msmbench measures the multi-scalar multiplication strategies on
+// this machine and prints where the fastest one changes
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/anupsv/bbsplus-signatures/pkg/crypto/simd"
+)
+
+func main() {
+	maxSize := flag.Int("max", 1024, "Largest input size to measure (powers of two from 1)")
+	all := flag.Bool("all", false, "Measure every strategy at every size, not only the tuner candidates")
+	flag.Parse()
+
+	if *maxSize < 1 {
+		fmt.Fprintln(os.Stderr, "Error: -max must be at least 1")
+		os.Exit(1)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "size\tfastest\ttime\trunner-up")
+
+	var previous string
+	var crossovers []string
+	for n := 1; n <= *maxSize; n *= 2 {
+		list := simd.Strategies()
+		if !*all {
+			list = nil
+			for _, s := range simd.Strategies() {
+				if simd.IsCandidate(s, n) {
+					list = append(list, s)
+				}
+			}
+		}
+
+		measurements := simd.Measure(n, list)
+		best, second := measurements[0], simd.Measurement{Duration: -1}
+		for _, m := range measurements[1:] {
+			if m.Duration < best.Duration {
+				best, second = m, best
+			} else if second.Duration < 0 || m.Duration < second.Duration {
+				second = m
+			}
+		}
+
+		runnerUp := "-"
+		if second.Duration >= 0 {
+			runnerUp = fmt.Sprintf("%s (%v)", second.Strategy, second.Duration)
+		}
+		fmt.Fprintf(w, "%d\t%s\t%v\t%s\n", n, best.Strategy, best.Duration, runnerUp)
+
+		if best.Strategy != previous {
+			if previous != "" {
+				crossovers = append(crossovers, fmt.Sprintf("%s -> %s at n=%d", previous, best.Strategy, n))
+			}
+			previous = best.Strategy
+		}
+	}
+	w.Flush()
+
+	fmt.Println("\nCrossover points:")
+	if len(crossovers) == 0 {
+		fmt.Printf("  none, %s is fastest at every size\n", previous)
+	}
+	for _, c := range crossovers {
+		fmt.Println("  " + c)
+	}
+}
@@ -2,7 +2,7 @@
 
 # Compile the BBS+ WASM module
 echo "Compiling BBS+ WASM module..."
-GOOS=js GOARCH=wasm go build -o main.wasm main.go
+GOOS=js GOARCH=wasm go build -ldflags "$(../scripts/ldflags.sh)" -o main.wasm .
 if [ $? -ne 0 ]; then
     echo "Compilation failed\!"
     exit 1
@@ -0,0 +1,111 @@
+//go:build js && wasm
+
+package main
+
+import (
+	"encoding/hex"
+	"syscall/js"
+	"time"
+
+	"github.com/anupsv/bbsplus-signatures/bbs"
+	"github.com/anupsv/bbsplus-signatures/internal/secret"
+	wasmpkg "github.com/anupsv/bbsplus-signatures/pkg/wasm"
+)
+
+// Private keys passed as hex strings live in the page's JS heap, where any
+// script can read them and the garbage collector decides when they go away.
+// importKey moves a key into Go memory once and hands back an opaque handle
+// that sign accepts in place of the key. The key is zeroized when the handle
+// is destroyed, either by destroyKey or when its optional TTL runs out.
+
+// keyHandlePrefix marks a sign argument as a handle rather than a hex key
+const keyHandlePrefix = "bbskey:"
+
+// keys holds the imported keys
+var keys = secret.NewStore(keyHandlePrefix, func(keyPair *bbs.KeyPair) {
+	secret.WipeInt(keyPair.PrivateKey.X)
+})
+
+// isKeyHandle reports whether a sign argument is a key handle
+func isKeyHandle(v js.Value) bool {
+	return v.Type() == js.TypeString && keys.IsHandle(v.String())
+}
+
+// keyOptions reads the TTL from an optional options object
+func keyOptions(v js.Value) time.Duration {
+	if v.Type() != js.TypeObject {
+		return 0
+	}
+	ttl := v.Get("ttlSeconds")
+	if ttl.Type() != js.TypeNumber || ttl.Float() <= 0 {
+		return 0
+	}
+	return time.Duration(ttl.Float() * float64(time.Second))
+}
+
+// ImportKey stores a key pair in Go memory and returns a handle for sign
+func ImportKey(this js.Value, args []js.Value) interface{} {
+	// Validate input
+	if len(args) < 2 {
+		return failf(wasmpkg.CodeInvalidInput, "importKey requires privateKey and publicKey")
+	}
+	if err := checkInput(args); err != nil {
+		return errorResponse(err)
+	}
+
+	privKeyBytes, err := hex.DecodeString(args[0].String())
+	if err != nil {
+		return failf(wasmpkg.CodeInvalidKey, "Invalid private key format: %w", err)
+	}
+	defer secret.WipeBytes(privKeyBytes)
+
+	privKey, err := bbs.DeserializePrivateKey(privKeyBytes)
+	if err != nil {
+		return failf(wasmpkg.CodeInvalidKey, "Failed to deserialize private key: %w", err)
+	}
+
+	pubKeyBytes, err := hex.DecodeString(args[1].String())
+	if err != nil {
+		secret.WipeInt(privKey.X)
+		return failf(wasmpkg.CodeInvalidKey, "Invalid public key format: %w", err)
+	}
+	pubKey, err := bbs.DeserializePublicKey(pubKeyBytes)
+	if err != nil {
+		secret.WipeInt(privKey.X)
+		return failf(wasmpkg.CodeInvalidKey, "Failed to deserialize public key: %w", err)
+	}
+
+	var ttl time.Duration
+	if len(args) > 2 {
+		ttl = keyOptions(args[2])
+	}
+
+	return keyHandleResponse(&bbs.KeyPair{PrivateKey: privKey, PublicKey: pubKey}, ttl)
+}
+
+// DestroyKey zeroizes the key behind a handle
+func DestroyKey(this js.Value, args []js.Value) interface{} {
+	if len(args) < 1 || !isKeyHandle(args[0]) {
+		return failf(wasmpkg.CodeInvalidInput, "destroyKey requires a key handle")
+	}
+
+	return okResponse(map[string]interface{}{
+		"destroyed": keys.Destroy(args[0].String()),
+	})
+}
+
+// keyHandleResponse stores keyPair and returns its handle and public key
+func keyHandleResponse(keyPair *bbs.KeyPair, ttl time.Duration) interface{} {
+	handle, err := keys.Add(keyPair, ttl)
+	if err != nil {
+		secret.WipeInt(keyPair.PrivateKey.X)
+		return failf(wasmpkg.CodeInternal, "Failed to store key: %w", err)
+	}
+
+	return okResponse(map[string]interface{}{
+		"keyHandle":    handle,
+		"publicKey":    hex.EncodeToString(bbs.SerializePublicKey(keyPair.PublicKey)),
+		"fingerprint":  keyPair.PublicKey.Fingerprint(),
+		"messageCount": keyPair.PublicKey.MessageCount(),
+	})
+}
@@ -0,0 +1,130 @@
+//go:build js && wasm
+
+package main
+
+import (
+	"sync/atomic"
+	"syscall/js"
+
+	wasmpkg "github.com/anupsv/bbsplus-signatures/pkg/wasm"
+)
+
+// The bindings bound every call by the number of messages and the size of
+// its input, with defaults from pkg/wasm. A page whose credential schemas
+// need more raises them with BBS.configure, up to the upper bounds there,
+// instead of forking the build. The bbs.Limits set with setLimits still
+// apply on top.
+
+// inputDepth is how deep inputSize descends into nested arrays and objects
+const inputDepth = 8
+
+var (
+	maxMessages   atomic.Int64
+	maxInputBytes atomic.Int64
+)
+
+func init() {
+	maxMessages.Store(wasmpkg.MaxMessagesPerCredential)
+	maxInputBytes.Store(wasmpkg.MaxInputSize)
+}
+
+// Configure sets maxMessages and maxInputBytes from an object and returns
+// the values in force. Either may be left out; a value above its upper
+// bound is refused.
+func Configure(this js.Value, args []js.Value) interface{} {
+	if len(args) > 0 && args[0].Type() == js.TypeObject {
+		settings := []struct {
+			name  string
+			upper int
+			value *atomic.Int64
+		}{
+			{"maxMessages", wasmpkg.MaxMessagesUpperBound, &maxMessages},
+			{"maxInputBytes", wasmpkg.MaxInputSizeUpperBound, &maxInputBytes},
+		}
+
+		// Check both before changing either
+		values := make([]int, len(settings))
+		for i, s := range settings {
+			v := args[0].Get(s.name)
+			if v.IsUndefined() || v.IsNull() {
+				values[i] = -1
+				continue
+			}
+			if v.Type() != js.TypeNumber || v.Int() <= 0 {
+				return failf(wasmpkg.CodeInvalidInput, "%s must be a positive number", s.name)
+			}
+			if v.Int() > s.upper {
+				return failf(wasmpkg.CodeInvalidInput, "%s must be at most %d", s.name, s.upper)
+			}
+			values[i] = v.Int()
+		}
+		for i, s := range settings {
+			if values[i] > 0 {
+				s.value.Store(int64(values[i]))
+			}
+		}
+	}
+
+	return okResponse(map[string]interface{}{
+		"maxMessages":   maxMessages.Load(),
+		"maxInputBytes": maxInputBytes.Load(),
+	})
+}
+
+// checkMessages returns an error if n messages exceed maxMessages
+func checkMessages(n int) error {
+	if limit := maxMessages.Load(); int64(n) > limit {
+		return wasmpkg.Errorf(wasmpkg.CodeLimitExceeded, "Too many messages: %d, the limit is %d (see configure)", n, limit)
+	}
+	return nil
+}
+
+// checkInput returns an error if args are larger than maxInputBytes
+func checkInput(args []js.Value) error {
+	limit := maxInputBytes.Load()
+	var size int64
+	for _, arg := range args {
+		size += inputSize(arg, limit-size, inputDepth)
+		if size > limit {
+			return wasmpkg.Errorf(wasmpkg.CodeLimitExceeded, "Input too large: over %d bytes (see configure)", limit)
+		}
+	}
+	return nil
+}
+
+// inputSize returns the size of v: the length of strings and byte arrays,
+// summed over arrays and object values. It stops once the size exceeds
+// budget.
+func inputSize(v js.Value, budget int64, depth int) int64 {
+	switch v.Type() {
+	case js.TypeString:
+		// Boxing the string reads its length without copying it into Go
+		return int64(js.Global().Get("Object").Invoke(v).Get("length").Int())
+	case js.TypeObject:
+	default:
+		return 0
+	}
+
+	if n := v.Get("byteLength"); n.Type() == js.TypeNumber {
+		return int64(n.Int())
+	}
+	if depth == 0 {
+		return 0
+	}
+
+	var size int64
+	if js.Global().Get("Array").Call("isArray", v).Bool() {
+		for i := 0; i < v.Length() && size <= budget; i++ {
+			size += inputSize(v.Index(i), budget-size, depth-1)
+		}
+		return size
+	}
+
+	keys := js.Global().Get("Object").Call("keys", v)
+	for i := 0; i < keys.Length() && size <= budget; i++ {
+		key := keys.Index(i).String()
+		size += int64(len(key))
+		size += inputSize(v.Get(key), budget-size, depth-1)
+	}
+	return size
+}
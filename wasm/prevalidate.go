@@ -0,0 +1,64 @@
+//go:build js && wasm
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"syscall/js"
+
+	"github.com/anupsv/bbsplus-signatures/pkg/credential"
+	wasmpkg "github.com/anupsv/bbsplus-signatures/pkg/wasm"
+)
+
+// PrevalidatePresentation runs the verifier's checks of a presentation in
+// the wallet. It takes the credential as JSON, the names of the attributes
+// to disclose and the verifier's request as JSON, and returns whether the
+// presentation would be accepted together with every problem found, so a
+// wallet UI can show the holder what to change before presenting.
+func PrevalidatePresentation(this js.Value, args []js.Value) interface{} {
+	if len(args) < 2 || args[0].Type() != js.TypeString || args[1].Type() != js.TypeObject {
+		return failf(wasmpkg.CodeInvalidInput, "prevalidatePresentation requires a credential and the attributes to disclose")
+	}
+	if err := checkInput(args); err != nil {
+		return errorResponse(err)
+	}
+
+	var cred credential.Credential
+	if err := json.Unmarshal([]byte(args[0].String()), &cred); err != nil {
+		return failf(wasmpkg.CodeInvalidCredential, "Invalid credential: %w", err)
+	}
+
+	disclosed := make([]string, args[1].Length())
+	for i := range disclosed {
+		disclosed[i] = args[1].Index(i).String()
+	}
+
+	var request credential.PresentationRequest
+	if len(args) > 2 && args[2].Type() == js.TypeString {
+		if err := json.Unmarshal([]byte(args[2].String()), &request); err != nil {
+			return failf(wasmpkg.CodeInvalidCredential, "Invalid presentation request: %w", err)
+		}
+	}
+
+	var problems []interface{}
+	err := credential.PrevalidatePresentation(&cred, disclosed, &request)
+	var perr *credential.PrevalidationError
+	switch {
+	case errors.As(err, &perr):
+		for _, p := range perr.Problems {
+			problems = append(problems, map[string]interface{}{
+				"check":     p.Check,
+				"attribute": p.Attribute,
+				"message":   p.Message,
+			})
+		}
+	case err != nil:
+		return errorResponse(err)
+	}
+
+	return okResponse(map[string]interface{}{
+		"valid":    len(problems) == 0,
+		"problems": problems,
+	})
+}
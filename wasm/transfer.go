@@ -0,0 +1,96 @@
+//go:build js && wasm
+
+package main
+
+import (
+	"encoding/hex"
+	"syscall/js"
+
+	"github.com/anupsv/bbsplus-signatures/bbs"
+	wasmpkg "github.com/anupsv/bbsplus-signatures/pkg/wasm"
+)
+
+// Results carry keys, signatures and proofs as hex strings, which postMessage
+// copies into every worker they are posted to. serializeResult replaces them
+// with Uint8Arrays, each over its own ArrayBuffer, and lists those buffers so
+// the result can be posted with them as transferables and moved instead of
+// copied. deserializeResult turns them back into the hex strings the other
+// functions take. Everything else in a result is plain data and survives the
+// structured clone as it is.
+
+// binaryFields are the result fields holding hex-encoded bytes
+var binaryFields = []string{"privateKey", "publicKey", "signature", "proof", "checkpoint"}
+
+// binaryFieldsKey lists the fields serializeResult converted
+const binaryFieldsKey = "binaryFields"
+
+// SerializeResult converts the hex fields of a result to Uint8Arrays. It
+// returns the converted result and the ArrayBuffers to transfer with it.
+func SerializeResult(this js.Value, args []js.Value) interface{} {
+	if len(args) < 1 || args[0].Type() != js.TypeObject {
+		return failf(wasmpkg.CodeInvalidInput, "serializeResult requires a result object")
+	}
+
+	// Convert a copy so the caller's result keeps its strings
+	result := js.Global().Get("Object").Call("assign", js.Global().Get("Object").New(), args[0])
+
+	var converted, transfer []interface{}
+	for _, name := range binaryFields {
+		v := result.Get(name)
+		if v.Type() != js.TypeString {
+			continue
+		}
+		data, err := hex.DecodeString(v.String())
+		if err != nil {
+			return failf(wasmpkg.CodeInvalidInput, "Invalid %s format: %w", name, err)
+		}
+		array := js.Global().Get("Uint8Array").New(len(data))
+		js.CopyBytesToJS(array, data)
+		result.Set(name, array)
+		converted = append(converted, name)
+		transfer = append(transfer, array.Get("buffer"))
+	}
+	result.Set(binaryFieldsKey, js.ValueOf(converted))
+
+	return okResponse(map[string]interface{}{
+		"result":   result,
+		"transfer": transfer,
+	})
+}
+
+// DeserializeResult converts the Uint8Array fields of a result posted after
+// serializeResult back to hex strings. The result is returned in its own
+// envelope, as it was before serializeResult; results that were not
+// serialized are returned as they are.
+func DeserializeResult(this js.Value, args []js.Value) interface{} {
+	if len(args) < 1 || args[0].Type() != js.TypeObject {
+		return failf(wasmpkg.CodeInvalidInput, "deserializeResult requires a result object")
+	}
+
+	result := js.Global().Get("Object").Call("assign", js.Global().Get("Object").New(), args[0])
+	fields := result.Get(binaryFieldsKey)
+	if fields.Type() != js.TypeObject {
+		return result
+	}
+
+	uint8Array := js.Global().Get("Uint8Array")
+	for i := 0; i < fields.Length(); i++ {
+		name := fields.Index(i).String()
+		v := result.Get(name)
+		if !v.InstanceOf(uint8Array) {
+			return failf(wasmpkg.CodeInvalidInput, "%s must be a Uint8Array", name)
+		}
+		// Refuse oversized input before copying it
+		if name == "proof" {
+			if err := bbs.DefaultLimits.CheckProofBytes(v.Length()); err != nil {
+				return errorResponse(err)
+			}
+		}
+		data := make([]byte, v.Length())
+		js.CopyBytesToGo(data, v)
+		result.Set(name, hex.EncodeToString(data))
+	}
+	result.Delete(binaryFieldsKey)
+
+	return result
+}
@@ -11,6 +11,8 @@ import (
 	"syscall/js"
 
 	"github.com/anupsv/bbsplus-signatures/bbs"
+	"github.com/anupsv/bbsplus-signatures/internal/version"
+	wasmpkg "github.com/anupsv/bbsplus-signatures/pkg/wasm"
 )
 
 // Initialize WASM bindings
@@ -23,31 +25,74 @@ func Initialize() {
 			"verify":          js.FuncOf(Verify),
 			"createProof":     js.FuncOf(CreateProof),
 			"verifyProof":     js.FuncOf(VerifyProof),
+
+			"createProofChunked": js.FuncOf(CreateProofChunked),
+
+			"importKey":  js.FuncOf(ImportKey),
+			"destroyKey": js.FuncOf(DestroyKey),
+
+			"setLimits": js.FuncOf(SetLimits),
+			"configure": js.FuncOf(Configure),
+
+			"prevalidatePresentation": js.FuncOf(PrevalidatePresentation),
+
+			"serializeResult":   js.FuncOf(SerializeResult),
+			"deserializeResult": js.FuncOf(DeserializeResult),
+
+			"errorCodes": errorCodes(),
 		},
 	))
 }
 
-// Version returns the version information
+// errorCodes returns the error codes as an object mapping each code to
+// itself, for use as an enum: BBS.errorCodes.INVALID_KEY
+func errorCodes() map[string]interface{} {
+	codes := make(map[string]interface{}, len(wasmpkg.ErrorCodes))
+	for _, code := range wasmpkg.ErrorCodes {
+		codes[string(code)] = string(code)
+	}
+	return codes
+}
+
+// Version returns the build metadata of the module
 func Version(this js.Value, args []js.Value) interface{} {
-	return js.ValueOf(map[string]interface{}{
-		"version":   "1.0.0",
-		"buildDate": "2025-04-16",
-		"commit":    "BBS+ Library WebAssembly Build",
+	info := version.Get()
+
+	ciphersuites := make([]interface{}, len(info.Ciphersuites))
+	for i, id := range info.Ciphersuites {
+		ciphersuites[i] = id
+	}
+
+	return okResponse(map[string]interface{}{
+		"version":      info.Version,
+		"buildDate":    info.BuildDate,
+		"commit":       info.Commit,
+		"modified":     info.Modified,
+		"goVersion":    info.GoVersion,
+		"ciphersuites": ciphersuites,
 	})
 }
 
-// GenerateKeyPair generates a BBS+ key pair
+// GenerateKeyPair generates a BBS+ key pair. With options.keyHandle set the
+// private key stays in Go memory and only its handle is returned.
 func GenerateKeyPair(this js.Value, args []js.Value) interface{} {
 	// Check arguments (messageCount is optional, defaults to 5)
 	messageCount := 5
 	if len(args) > 0 && args[0].Type() == js.TypeNumber {
 		messageCount = args[0].Int()
 	}
+	if err := checkMessages(messageCount); err != nil {
+		return errorResponse(err)
+	}
 
 	// Generate key pair
 	keyPair, err := bbs.GenerateKeyPair(messageCount, rand.Reader)
 	if err != nil {
-		return errorResponse(fmt.Sprintf("Failed to generate key pair: %v", err))
+		return failf(wasmpkg.CodeInternal, "Failed to generate key pair: %w", err)
+	}
+
+	if len(args) > 1 && args[1].Type() == js.TypeObject && args[1].Get("keyHandle").Truthy() {
+		return keyHandleResponse(keyPair, keyOptions(args[1]))
 	}
 
 	// Serialize private key to bytes
@@ -59,70 +104,97 @@ func GenerateKeyPair(this js.Value, args []js.Value) interface{} {
 	pubKeyHex := hex.EncodeToString(pubKeyBytes)
 
 	// Return as JS object
-	return js.ValueOf(map[string]interface{}{
-		"success":      true,
+	return okResponse(map[string]interface{}{
 		"privateKey":   privKeyHex,
 		"publicKey":    pubKeyHex,
+		"fingerprint":  keyPair.PublicKey.Fingerprint(),
 		"messageCount": messageCount,
 	})
 }
 
-// Sign creates a BBS+ signature
+// Sign creates a BBS+ signature. The first argument is a hex private key or
+// a key handle from importKey; with a handle the public key may be null.
 func Sign(this js.Value, args []js.Value) interface{} {
 	// Validate input
 	if len(args) < 3 {
-		return errorResponse("Sign requires privateKey, publicKey, and messages")
+		return failf(wasmpkg.CodeInvalidInput, "Sign requires privateKey, publicKey, and messages")
 	}
-
-	// Parse private key from hex
-	privKeyHex := args[0].String()
-	privKeyBytes, err := hex.DecodeString(privKeyHex)
-	if err != nil {
-		return errorResponse(fmt.Sprintf("Invalid private key format: %v", err))
+	if err := checkInput(args); err != nil {
+		return errorResponse(err)
 	}
-	privKey, err := bbs.DeserializePrivateKey(privKeyBytes)
-	if err != nil {
-		return errorResponse(fmt.Sprintf("Failed to deserialize private key: %v", err))
+
+	var privKey *bbs.PrivateKey
+	var pubKey *bbs.PublicKey
+	if isKeyHandle(args[0]) {
+		keyPair, ok := keys.Get(args[0].String())
+		if !ok {
+			return failf(wasmpkg.CodeUnknownKeyHandle, "Unknown or destroyed key handle")
+		}
+		privKey = keyPair.PrivateKey
+		pubKey = keyPair.PublicKey
+	} else {
+		// Parse private key from hex
+		privKeyHex := args[0].String()
+		privKeyBytes, err := hex.DecodeString(privKeyHex)
+		if err != nil {
+			return failf(wasmpkg.CodeInvalidKey, "Invalid private key format: %w", err)
+		}
+		privKey, err = bbs.DeserializePrivateKey(privKeyBytes)
+		if err != nil {
+			return failf(wasmpkg.CodeInvalidKey, "Failed to deserialize private key: %w", err)
+		}
 	}
 
 	// Parse public key from hex
-	pubKeyHex := args[1].String()
-	pubKeyBytes, err := hex.DecodeString(pubKeyHex)
-	if err != nil {
-		return errorResponse(fmt.Sprintf("Invalid public key format: %v", err))
-	}
-	pubKey, err := bbs.DeserializePublicKey(pubKeyBytes)
-	if err != nil {
-		return errorResponse(fmt.Sprintf("Failed to deserialize public key: %v", err))
+	if pubKey == nil || args[1].Type() == js.TypeString {
+		pubKeyHex := args[1].String()
+		pubKeyBytes, err := hex.DecodeString(pubKeyHex)
+		if err != nil {
+			return failf(wasmpkg.CodeInvalidKey, "Invalid public key format: %w", err)
+		}
+		pubKey, err = bbs.DeserializePublicKey(pubKeyBytes)
+		if err != nil {
+			return failf(wasmpkg.CodeInvalidKey, "Failed to deserialize public key: %w", err)
+		}
 	}
 
 	// Parse messages
 	if args[2].Type() != js.TypeObject {
-		return errorResponse("Messages parameter must be an object with messages array")
+		return failf(wasmpkg.CodeInvalidInput, "Messages parameter must be an object with messages array")
 	}
 
 	messagesObj := args[2]
 	if !messagesObj.Get("messages").Truthy() {
-		return errorResponse("Messages parameter must contain messages array")
+		return failf(wasmpkg.CodeInvalidInput, "Messages parameter must contain messages array")
 	}
 
 	messagesJS := messagesObj.Get("messages")
 	if messagesJS.Type() != js.TypeObject || messagesJS.Length() == 0 {
-		return errorResponse("Messages must be a non-empty array")
+		return failf(wasmpkg.CodeInvalidInput, "Messages must be a non-empty array")
+	}
+	if err := bbs.DefaultLimits.CheckMessageCount(messagesJS.Length()); err != nil {
+		return errorResponse(err)
+	}
+	if err := checkMessages(messagesJS.Length()); err != nil {
+		return errorResponse(err)
 	}
 
-	// Convert string messages to field elements
-	messages := make([]*big.Int, messagesJS.Length())
-	for i := 0; i < messagesJS.Length(); i++ {
-		msgStr := messagesJS.Index(i).String()
-		msgBytes := bbs.MessageToBytes(msgStr)
-		messages[i] = bbs.MessageToFieldElement(msgBytes)
+	// Convert messages to field elements
+	messages, err := messagesFromJS(messagesJS)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	// Parse optional header
+	var header []byte
+	if len(args) > 3 {
+		header = optionalHeader(args[3])
 	}
 
 	// Create signature
-	signature, err := bbs.Sign(privKey, pubKey, messages, nil)
+	signature, err := bbs.Sign(privKey, pubKey, messages, header)
 	if err != nil {
-		return errorResponse(fmt.Sprintf("Failed to create signature: %v", err))
+		return failf(wasmpkg.CodeInvalidInput, "Failed to create signature: %w", err)
 	}
 
 	// Serialize signature to bytes
@@ -130,8 +202,7 @@ func Sign(this js.Value, args []js.Value) interface{} {
 	sigHex := hex.EncodeToString(sigBytes)
 
 	// Return as JS object
-	return js.ValueOf(map[string]interface{}{
-		"success":   true,
+	return okResponse(map[string]interface{}{
 		"signature": sigHex,
 	})
 }
@@ -140,160 +211,318 @@ func Sign(this js.Value, args []js.Value) interface{} {
 func Verify(this js.Value, args []js.Value) interface{} {
 	// Validate input
 	if len(args) < 3 {
-		return errorResponse("Verify requires publicKey, signature, and messages")
+		return failf(wasmpkg.CodeInvalidInput, "Verify requires publicKey, signature, and messages")
+	}
+	if err := checkInput(args); err != nil {
+		return errorResponse(err)
 	}
 
 	// Parse public key from hex
 	pubKeyHex := args[0].String()
 	pubKeyBytes, err := hex.DecodeString(pubKeyHex)
 	if err != nil {
-		return errorResponse(fmt.Sprintf("Invalid public key format: %v", err))
+		return failf(wasmpkg.CodeInvalidKey, "Invalid public key format: %w", err)
 	}
 	pubKey, err := bbs.DeserializePublicKey(pubKeyBytes)
 	if err != nil {
-		return errorResponse(fmt.Sprintf("Failed to deserialize public key: %v", err))
+		return failf(wasmpkg.CodeInvalidKey, "Failed to deserialize public key: %w", err)
 	}
 
 	// Parse signature from hex
 	sigHex := args[1].String()
 	sigBytes, err := hex.DecodeString(sigHex)
 	if err != nil {
-		return errorResponse(fmt.Sprintf("Invalid signature format: %v", err))
+		return failf(wasmpkg.CodeInvalidSignature, "Invalid signature format: %w", err)
 	}
 	signature, err := bbs.DeserializeSignature(sigBytes)
 	if err != nil {
-		return errorResponse(fmt.Sprintf("Failed to deserialize signature: %v", err))
+		return failf(wasmpkg.CodeInvalidSignature, "Failed to deserialize signature: %w", err)
 	}
 
 	// Parse messages
 	if args[2].Type() != js.TypeObject {
-		return errorResponse("Messages parameter must be an object with messages array")
+		return failf(wasmpkg.CodeInvalidInput, "Messages parameter must be an object with messages array")
 	}
 
 	messagesObj := args[2]
 	if !messagesObj.Get("messages").Truthy() {
-		return errorResponse("Messages parameter must contain messages array")
+		return failf(wasmpkg.CodeInvalidInput, "Messages parameter must contain messages array")
 	}
 
 	messagesJS := messagesObj.Get("messages")
 	if messagesJS.Type() != js.TypeObject || messagesJS.Length() == 0 {
-		return errorResponse("Messages must be a non-empty array")
+		return failf(wasmpkg.CodeInvalidInput, "Messages must be a non-empty array")
+	}
+	if err := bbs.DefaultLimits.CheckMessageCount(messagesJS.Length()); err != nil {
+		return errorResponse(err)
+	}
+	if err := checkMessages(messagesJS.Length()); err != nil {
+		return errorResponse(err)
 	}
 
-	// Convert string messages to field elements
-	messages := make([]*big.Int, messagesJS.Length())
-	for i := 0; i < messagesJS.Length(); i++ {
-		msgStr := messagesJS.Index(i).String()
-		msgBytes := bbs.MessageToBytes(msgStr)
-		messages[i] = bbs.MessageToFieldElement(msgBytes)
+	// Convert messages to field elements
+	messages, err := messagesFromJS(messagesJS)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	// Parse optional header
+	var header []byte
+	if len(args) > 3 {
+		header = optionalHeader(args[3])
 	}
 
 	// Verify signature
-	err = bbs.Verify(pubKey, signature, messages, nil)
+	err = bbs.Verify(pubKey, signature, messages, header)
 	if err != nil {
-		return js.ValueOf(map[string]interface{}{
-			"success": true,
-			"valid":   false,
-			"error":   err.Error(),
+		return okResponse(map[string]interface{}{
+			"valid": false,
+			"code":  string(wasmpkg.CodeOf(err)),
+			"error": err.Error(),
 		})
 	}
 
 	// Return as JS object
-	return js.ValueOf(map[string]interface{}{
-		"success": true,
-		"valid":   true,
+	return okResponse(map[string]interface{}{
+		"valid": true,
 	})
 }
 
 // CreateProof creates a BBS+ proof of knowledge
 func CreateProof(this js.Value, args []js.Value) interface{} {
 	if len(args) < 1 || args[0].Type() != js.TypeObject {
-		return errorResponse("CreateProof requires a proof request object")
+		return failf(wasmpkg.CodeInvalidInput, "CreateProof requires a proof request object")
+	}
+	if err := checkInput(args[:1]); err != nil {
+		return errorResponse(err)
 	}
 
 	proofRequest := args[0]
 
-	// Parse public key from hex
-	pubKeyHex := proofRequest.Get("publicKey").String()
-	pubKeyBytes, err := hex.DecodeString(pubKeyHex)
+	pubKey, signature, messages, disclosedIndices, err := parseProofRequest(proofRequest)
 	if err != nil {
-		return errorResponse(fmt.Sprintf("Invalid public key format: %v", err))
+		return errorResponse(err)
 	}
-	pubKey, err := bbs.DeserializePublicKey(pubKeyBytes)
+
+	// Create proof
+	proof, disclosedMsgs, err := bbs.CreateProof(
+		pubKey,
+		signature,
+		messages,
+		disclosedIndices,
+		optionalHeader(proofRequest.Get("header")),
+	)
 	if err != nil {
-		return errorResponse(fmt.Sprintf("Failed to deserialize public key: %v", err))
+		return failf(wasmpkg.CodeInvalidInput, "Failed to create proof: %w", err)
 	}
 
-	// Parse signature from hex
-	sigHex := proofRequest.Get("signature").String()
-	sigBytes, err := hex.DecodeString(sigHex)
-	if err != nil {
-		return errorResponse(fmt.Sprintf("Invalid signature format: %v", err))
+	// Serialize proof to bytes
+	proofBytes := bbs.SerializeProof(proof)
+	proofHex := hex.EncodeToString(proofBytes)
+
+	// Return as JS object
+	return okResponse(map[string]interface{}{
+		"proof":             proofHex,
+		"disclosedMessages": disclosedMessagesObject(disclosedMsgs),
+	})
+}
+
+// defaultChunkSize is the number of scalar multiplications per step of
+// CreateProofChunked
+const defaultChunkSize = 8
+
+// CreateProofChunked creates a proof in steps of chunkSize scalar
+// multiplications, yielding to the event loop between steps so large proofs
+// do not block the page. It returns a Promise for the CreateProof response.
+//
+// The optional second argument is called after every step with done and
+// total counts. If the request has a checkpointKey, the progress object also
+// carries an encrypted checkpoint, which a later request can pass as
+// checkpoint (with publicKey and checkpointKey) to resume.
+func CreateProofChunked(this js.Value, args []js.Value) interface{} {
+	var onProgress js.Value
+	if len(args) > 1 && args[1].Type() == js.TypeFunction {
+		onProgress = args[1]
+	}
+
+	var request js.Value
+	if len(args) > 0 {
+		request = args[0]
+	}
+
+	executor := js.FuncOf(func(this js.Value, promiseArgs []js.Value) interface{} {
+		resolve := promiseArgs[0]
+		go func() {
+			resolve.Invoke(runChunkedProof(request, onProgress))
+		}()
+		return nil
+	})
+	defer executor.Release()
+
+	// The Promise constructor calls the executor before returning
+	return js.Global().Get("Promise").New(executor)
+}
+
+// runChunkedProof runs a CreateProofChunked request to completion
+func runChunkedProof(request, onProgress js.Value) interface{} {
+	if request.Type() != js.TypeObject {
+		return failf(wasmpkg.CodeInvalidInput, "CreateProofChunked requires a proof request object")
 	}
-	signature, err := bbs.DeserializeSignature(sigBytes)
-	if err != nil {
-		return errorResponse(fmt.Sprintf("Failed to deserialize signature: %v", err))
+	if err := checkInput([]js.Value{request}); err != nil {
+		return errorResponse(err)
 	}
 
-	// Parse messages
-	messagesJS := proofRequest.Get("messages")
-	if messagesJS.Type() != js.TypeObject || messagesJS.Length() == 0 {
-		return errorResponse("Messages must be a non-empty array")
+	var checkpointKey []byte
+	if v := request.Get("checkpointKey"); v.Type() == js.TypeString {
+		key, err := hex.DecodeString(v.String())
+		if err != nil {
+			return failf(wasmpkg.CodeInvalidInput, "Invalid checkpoint key format: %w", err)
+		}
+		checkpointKey = key
 	}
 
-	// Convert string messages to field elements
-	messages := make([]*big.Int, messagesJS.Length())
-	for i := 0; i < messagesJS.Length(); i++ {
-		msgStr := messagesJS.Index(i).String()
-		msgBytes := bbs.MessageToBytes(msgStr)
-		messages[i] = bbs.MessageToFieldElement(msgBytes)
+	var prover *bbs.ResumableProver
+	if v := request.Get("checkpoint"); v.Type() == js.TypeString {
+		// Resume an earlier run
+		checkpoint, err := hex.DecodeString(v.String())
+		if err != nil {
+			return failf(wasmpkg.CodeInvalidProof, "Invalid checkpoint format: %w", err)
+		}
+		pubKeyBytes, err := hex.DecodeString(request.Get("publicKey").String())
+		if err != nil {
+			return failf(wasmpkg.CodeInvalidKey, "Invalid public key format: %w", err)
+		}
+		pubKey, err := bbs.DeserializePublicKey(pubKeyBytes)
+		if err != nil {
+			return failf(wasmpkg.CodeInvalidKey, "Failed to deserialize public key: %w", err)
+		}
+		prover, err = bbs.ResumeProver(pubKey, checkpoint, checkpointKey)
+		if err != nil {
+			return failf(wasmpkg.CodeInvalidInput, "Failed to resume proof: %w", err)
+		}
+	} else {
+		pubKey, signature, messages, disclosedIndices, err := parseProofRequest(request)
+		if err != nil {
+			return errorResponse(err)
+		}
+
+		prover, err = bbs.NewResumableProver(
+			pubKey,
+			signature,
+			messages,
+			disclosedIndices,
+			optionalHeader(request.Get("header")),
+		)
+		if err != nil {
+			return failf(wasmpkg.CodeInvalidInput, "Failed to create proof: %w", err)
+		}
 	}
 
-	// Parse disclosed indices
-	indicesJS := proofRequest.Get("disclosedIndices")
-	if indicesJS.Type() != js.TypeObject || indicesJS.Length() == 0 {
-		return errorResponse("disclosedIndices must be a non-empty array")
+	chunkSize := defaultChunkSize
+	if v := request.Get("chunkSize"); v.Type() == js.TypeNumber && v.Int() > 0 {
+		chunkSize = v.Int()
 	}
 
-	disclosedIndices := make([]int, indicesJS.Length())
-	for i := 0; i < indicesJS.Length(); i++ {
-		disclosedIndices[i] = indicesJS.Index(i).Int()
+	for {
+		done, err := prover.Step(chunkSize)
+		if err != nil {
+			return failf(wasmpkg.CodeInvalidInput, "Failed to create proof: %w", err)
+		}
+
+		if onProgress.Type() == js.TypeFunction {
+			completed, total := prover.Progress()
+			progress := map[string]interface{}{
+				"done":  completed,
+				"total": total,
+			}
+			if checkpointKey != nil {
+				checkpoint, err := prover.Checkpoint(checkpointKey)
+				if err != nil {
+					return failf(wasmpkg.CodeInternal, "Failed to checkpoint proof: %w", err)
+				}
+				progress["checkpoint"] = hex.EncodeToString(checkpoint)
+			}
+			onProgress.Invoke(js.ValueOf(progress))
+		}
+
+		if done {
+			break
+		}
+		yieldToEventLoop()
 	}
 
-	// Create proof
-	proof, disclosedMsgs, err := bbs.CreateProof(
-		pubKey,
-		signature,
-		messages,
-		disclosedIndices,
-		nil,
-	)
+	proof, disclosedMsgs, err := prover.Proof()
 	if err != nil {
-		return errorResponse(fmt.Sprintf("Failed to create proof: %v", err))
+		return failf(wasmpkg.CodeInvalidInput, "Failed to create proof: %w", err)
 	}
 
-	// Serialize proof to bytes
-	proofBytes := bbs.SerializeProof(proof)
-	proofHex := hex.EncodeToString(proofBytes)
+	return okResponse(map[string]interface{}{
+		"proof":             hex.EncodeToString(bbs.SerializeProof(proof)),
+		"disclosedMessages": disclosedMessagesObject(disclosedMsgs),
+	})
+}
+
+// yieldToEventLoop blocks the calling goroutine until the browser has run a
+// timer callback, giving it a chance to render and handle input
+func yieldToEventLoop() {
+	resumed := make(chan struct{})
+	var callback js.Func
+	callback = js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		callback.Release()
+		close(resumed)
+		return nil
+	})
+	js.Global().Call("setTimeout", callback, 0)
+	<-resumed
+}
 
-	// Build disclosed messages map
-	disclosedMsgsMap := make(map[string]string)
-	for i, idx := range disclosedIndices {
-		disclosedMsgsMap[fmt.Sprintf("%d", idx)] = disclosedMsgs[i].String()
+// SetLimits replaces the nonzero fields of bbs.DefaultLimits from an
+// object with maxMessageCount, maxProofBytes and maxMHatEntries, and
+// returns the limits in force
+func SetLimits(this js.Value, args []js.Value) interface{} {
+	if len(args) > 0 && args[0].Type() == js.TypeObject {
+		limits := bbs.DefaultLimits
+		for name, field := range map[string]*int{
+			"maxMessageCount": &limits.MaxMessageCount,
+			"maxProofBytes":   &limits.MaxProofBytes,
+			"maxMHatEntries":  &limits.MaxMHatEntries,
+		} {
+			v := args[0].Get(name)
+			if v.IsUndefined() || v.IsNull() {
+				continue
+			}
+			if v.Type() != js.TypeNumber || v.Int() <= 0 {
+				return failf(wasmpkg.CodeInvalidInput, "%s must be a positive number", name)
+			}
+			*field = v.Int()
+		}
+		bbs.DefaultLimits = limits
 	}
 
-	// Return as JS object
-	return js.ValueOf(map[string]interface{}{
-		"success":           true,
-		"proof":             proofHex,
-		"disclosedMessages": disclosedMsgsMap,
+	return okResponse(map[string]interface{}{
+		"maxMessageCount": bbs.DefaultLimits.MaxMessageCount,
+		"maxProofBytes":   bbs.DefaultLimits.MaxProofBytes,
+		"maxMHatEntries":  bbs.DefaultLimits.MaxMHatEntries,
 	})
 }
 
+// disclosedMessagesObject converts disclosed messages to a JS object keyed by
+// index. js.ValueOf only takes map[string]interface{}.
+func disclosedMessagesObject(disclosed map[int]*big.Int) map[string]interface{} {
+	obj := make(map[string]interface{}, len(disclosed))
+	for idx, msg := range disclosed {
+		obj[fmt.Sprintf("%d", idx)] = msg.String()
+	}
+	return obj
+}
+
 // VerifyProof verifies a BBS+ proof of knowledge
 func VerifyProof(this js.Value, args []js.Value) interface{} {
 	if len(args) < 1 || args[0].Type() != js.TypeObject {
-		return errorResponse("VerifyProof requires a verification request object")
+		return failf(wasmpkg.CodeInvalidInput, "VerifyProof requires a verification request object")
+	}
+	if err := checkInput(args[:1]); err != nil {
+		return errorResponse(err)
 	}
 
 	verifyRequest := args[0]
@@ -302,32 +531,44 @@ func VerifyProof(this js.Value, args []js.Value) interface{} {
 	pubKeyHex := verifyRequest.Get("publicKey").String()
 	pubKeyBytes, err := hex.DecodeString(pubKeyHex)
 	if err != nil {
-		return errorResponse(fmt.Sprintf("Invalid public key format: %v", err))
+		return failf(wasmpkg.CodeInvalidKey, "Invalid public key format: %w", err)
 	}
 	pubKey, err := bbs.DeserializePublicKey(pubKeyBytes)
 	if err != nil {
-		return errorResponse(fmt.Sprintf("Failed to deserialize public key: %v", err))
+		return failf(wasmpkg.CodeInvalidKey, "Failed to deserialize public key: %w", err)
 	}
 
-	// Parse proof from hex
+	// Parse proof from hex, refusing oversized input before decoding it
 	proofHex := verifyRequest.Get("proof").String()
+	if err := bbs.DefaultLimits.CheckProofBytes(len(proofHex) / 2); err != nil {
+		return errorResponse(err)
+	}
 	proofBytes, err := hex.DecodeString(proofHex)
 	if err != nil {
-		return errorResponse(fmt.Sprintf("Invalid proof format: %v", err))
+		return failf(wasmpkg.CodeInvalidProof, "Invalid proof format: %w", err)
 	}
 	proof, err := bbs.DeserializeProof(proofBytes)
 	if err != nil {
-		return errorResponse(fmt.Sprintf("Failed to deserialize proof: %v", err))
+		return failf(wasmpkg.CodeInvalidProof, "Failed to deserialize proof: %w", err)
 	}
 
-	// Parse disclosed messages
+	// Parse disclosed messages. A missing object means nothing was disclosed.
 	disclosedMsgsJS := verifyRequest.Get("disclosedMessages")
+	if disclosedMsgsJS.IsUndefined() || disclosedMsgsJS.IsNull() {
+		disclosedMsgsJS = js.Global().Get("Object").New()
+	}
 	if disclosedMsgsJS.Type() != js.TypeObject {
-		return errorResponse("disclosedMessages must be an object")
+		return failf(wasmpkg.CodeInvalidInput, "disclosedMessages must be an object")
 	}
 
 	// Get keys from disclosedMessages object
 	keys := js.Global().Get("Object").Call("keys", disclosedMsgsJS)
+	if err := bbs.DefaultLimits.CheckMessageCount(keys.Length()); err != nil {
+		return errorResponse(err)
+	}
+	if err := checkMessages(keys.Length()); err != nil {
+		return errorResponse(err)
+	}
 
 	// Convert to map of index -> big.Int
 	disclosedMsgs := make(map[int]*big.Int)
@@ -343,33 +584,136 @@ func VerifyProof(this js.Value, args []js.Value) interface{} {
 		value := new(big.Int)
 		_, ok := value.SetString(valueStr, 10)
 		if !ok {
-			return errorResponse(fmt.Sprintf("Invalid disclosed message value: %s", valueStr))
+			return failf(wasmpkg.CodeInvalidInput, "Invalid disclosed message value: %s", valueStr)
 		}
 
 		disclosedMsgs[index] = value
 	}
 
 	// Verify proof
-	err = bbs.VerifyProof(pubKey, proof, disclosedMsgs, nil)
+	err = bbs.VerifyProof(pubKey, proof, disclosedMsgs, optionalHeader(verifyRequest.Get("header")))
 	if err != nil {
-		return js.ValueOf(map[string]interface{}{
-			"success":  true,
+		return okResponse(map[string]interface{}{
 			"verified": false,
+			"code":     string(wasmpkg.CodeOf(err)),
 			"error":    err.Error(),
 		})
 	}
 
 	// Return as JS object
-	return js.ValueOf(map[string]interface{}{
-		"success":  true,
+	return okResponse(map[string]interface{}{
 		"verified": true,
 	})
 }
 
-// Helper function to create error responses
-func errorResponse(message string) interface{} {
+// parseProofRequest reads the public key, signature, messages and disclosed
+// indices of a proof request
+func parseProofRequest(proofRequest js.Value) (*bbs.PublicKey, *bbs.Signature, []*big.Int, []int, error) {
+	// Parse public key from hex
+	pubKeyHex := proofRequest.Get("publicKey").String()
+	pubKeyBytes, err := hex.DecodeString(pubKeyHex)
+	if err != nil {
+		return nil, nil, nil, nil, wasmpkg.Errorf(wasmpkg.CodeInvalidKey, "Invalid public key format: %w", err)
+	}
+	pubKey, err := bbs.DeserializePublicKey(pubKeyBytes)
+	if err != nil {
+		return nil, nil, nil, nil, wasmpkg.Errorf(wasmpkg.CodeInvalidKey, "Failed to deserialize public key: %w", err)
+	}
+
+	// Parse signature from hex
+	sigHex := proofRequest.Get("signature").String()
+	sigBytes, err := hex.DecodeString(sigHex)
+	if err != nil {
+		return nil, nil, nil, nil, wasmpkg.Errorf(wasmpkg.CodeInvalidSignature, "Invalid signature format: %w", err)
+	}
+	signature, err := bbs.DeserializeSignature(sigBytes)
+	if err != nil {
+		return nil, nil, nil, nil, wasmpkg.Errorf(wasmpkg.CodeInvalidSignature, "Failed to deserialize signature: %w", err)
+	}
+
+	// Parse messages
+	messagesJS := proofRequest.Get("messages")
+	if messagesJS.Type() != js.TypeObject || messagesJS.Length() == 0 {
+		return nil, nil, nil, nil, wasmpkg.Errorf(wasmpkg.CodeInvalidInput, "Messages must be a non-empty array")
+	}
+	if err := bbs.DefaultLimits.CheckMessageCount(messagesJS.Length()); err != nil {
+		return nil, nil, nil, nil, err
+	}
+	if err := checkMessages(messagesJS.Length()); err != nil {
+		return nil, nil, nil, nil, err
+	}
+
+	// Convert messages to field elements
+	messages, err := messagesFromJS(messagesJS)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+
+	// Parse disclosed indices. A missing or empty array discloses nothing.
+	var disclosedIndices []int
+	indicesJS := proofRequest.Get("disclosedIndices")
+	if !indicesJS.IsUndefined() && !indicesJS.IsNull() {
+		if indicesJS.Type() != js.TypeObject {
+			return nil, nil, nil, nil, wasmpkg.Errorf(wasmpkg.CodeInvalidInput, "disclosedIndices must be an array")
+		}
+		disclosedIndices = make([]int, indicesJS.Length())
+		for i := 0; i < indicesJS.Length(); i++ {
+			disclosedIndices[i] = indicesJS.Index(i).Int()
+		}
+	}
+
+	return pubKey, signature, messages, disclosedIndices, nil
+}
+
+// messagesFromJS maps a messages array to field elements. A string is
+// signed as its UTF-8 bytes and a Uint8Array as its raw bytes, so binary
+// attributes need no text encoding.
+func messagesFromJS(messagesJS js.Value) ([]*big.Int, error) {
+	uint8Array := js.Global().Get("Uint8Array")
+	messages := make([]*big.Int, messagesJS.Length())
+	for i := range messages {
+		v := messagesJS.Index(i)
+		var msgBytes []byte
+		switch {
+		case v.Type() == js.TypeString:
+			msgBytes = bbs.MessageToBytes(v.String())
+		case v.InstanceOf(uint8Array):
+			msgBytes = make([]byte, v.Length())
+			js.CopyBytesToGo(msgBytes, v)
+		default:
+			return nil, wasmpkg.Errorf(wasmpkg.CodeInvalidInput, "Message %d must be a string or a Uint8Array", i)
+		}
+		messages[i] = bbs.MessageToFieldElement(msgBytes)
+	}
+	return messages, nil
+}
+
+// optionalHeader returns the UTF-8 bytes of a header argument, or nil if it
+// is missing or empty
+func optionalHeader(v js.Value) []byte {
+	if v.Type() != js.TypeString || v.String() == "" {
+		return nil
+	}
+	return []byte(v.String())
+}
+
+// okResponse returns a successful result with fields
+func okResponse(fields map[string]interface{}) interface{} {
+	fields["success"] = true
+	return js.ValueOf(fields)
+}
+
+// errorResponse returns a failed result reporting err under its code, see
+// wasmpkg.CodeOf
+func errorResponse(err error) interface{} {
 	return js.ValueOf(map[string]interface{}{
 		"success": false,
-		"error":   message,
+		"code":    string(wasmpkg.CodeOf(err)),
+		"error":   err.Error(),
 	})
 }
+
+// failf returns a failed result with a message formatted under code
+func failf(code wasmpkg.ErrorCode, format string, args ...interface{}) interface{} {
+	return errorResponse(wasmpkg.Errorf(code, format, args...))
+}